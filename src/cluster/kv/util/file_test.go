@@ -0,0 +1,58 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package util
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPersistValueRoundTrip(t *testing.T) {
+	dir, err := ioutil.TempDir("", "kv-persist")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "nested", "value.json")
+	_, err = ReadPersistedValue(path)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, PersistValue(path, 3, &commonpb.StringProto{Value: "foo"}))
+	require.NoError(t, PersistValue(path, 4, &commonpb.StringProto{Value: "bar"}))
+
+	value, err := ReadPersistedValue(path)
+	require.NoError(t, err)
+	require.Equal(t, 4, value.Version())
+
+	var msg commonpb.StringProto
+	require.NoError(t, value.Unmarshal(&msg))
+	require.Equal(t, "bar", msg.Value)
+
+	// Only the persisted file remains after replacing it.
+	files, err := ioutil.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
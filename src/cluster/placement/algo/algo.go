@@ -21,9 +21,13 @@
 package algo
 
 import (
+	"errors"
+
 	"github.com/m3db/m3/src/cluster/placement"
 )
 
+var errRemoveLastReplica = errors.New("could not remove the last replica from the placement")
+
 // NewAlgorithm returns a placement algorithm with given options
 func NewAlgorithm(opts placement.Options) placement.Algorithm {
 	if opts == nil {
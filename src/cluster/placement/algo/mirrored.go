@@ -83,6 +83,12 @@ func (a mirroredAlgorithm) AddReplica(p placement.Placement) (placement.Placemen
 	return nil, errors.New("not supported")
 }
 
+func (a mirroredAlgorithm) RemoveReplica(p placement.Placement) (placement.Placement, error) {
+	// NB: Removing a replica from a mirrored placement requires removing a
+	// whole instance from every shard set, which is done with RemoveInstances.
+	return nil, errors.New("not supported")
+}
+
 func (a mirroredAlgorithm) RemoveInstances(
 	p placement.Placement,
 	instanceIDs []string,
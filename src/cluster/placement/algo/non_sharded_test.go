@@ -60,6 +60,19 @@ func TestNonShardedAlgo(t *testing.T) {
 	assert.Equal(t, 2, p.ReplicaFactor())
 	assert.False(t, p.IsSharded())
 
+	p, err = a.RemoveReplica(p)
+	assert.NoError(t, err)
+	assert.NoError(t, placement.Validate(p))
+	assert.Equal(t, 2, p.NumInstances())
+	assert.Equal(t, 1, p.ReplicaFactor())
+
+	_, err = a.RemoveReplica(p)
+	assert.Equal(t, errRemoveLastReplica, err)
+
+	p, err = a.AddReplica(p)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, p.ReplicaFactor())
+
 	p, err = a.AddInstances(p, []placement.Instance{i3})
 	assert.NoError(t, err)
 	assert.NoError(t, placement.Validate(p))
@@ -30,6 +30,7 @@ import (
 var (
 	errNotEnoughIsolationGroups    = errors.New("not enough isolation groups to take shards, please make sure RF is less than number of isolation groups")
 	errIncompatibleWithShardedAlgo = errors.New("could not apply sharded algo on the placement")
+	errRemoveReplicaWithLeaving    = errors.New("could not remove a replica while shards are leaving, mark the placement available first")
 )
 
 type shardedPlacementAlgorithm struct {
@@ -90,6 +91,23 @@ func (a shardedPlacementAlgorithm) AddReplica(p placement.Placement) (placement.
 	return tryCleanupShardState(ph.generatePlacement(), a.opts)
 }
 
+func (a shardedPlacementAlgorithm) RemoveReplica(p placement.Placement) (placement.Placement, error) {
+	if err := a.IsCompatibleWith(p); err != nil {
+		return nil, err
+	}
+
+	if p.ReplicaFactor() <= 1 {
+		return nil, errRemoveLastReplica
+	}
+
+	p, err := removeOneReplica(p.Clone())
+	if err != nil {
+		return nil, err
+	}
+
+	return tryCleanupShardState(p, a.opts)
+}
+
 func (a shardedPlacementAlgorithm) RemoveInstances(
 	p placement.Placement,
 	instanceIDs []string,
@@ -749,6 +749,71 @@ func newShards(shardIDs []uint32) []shard.Shard {
 	return r
 }
 
+// removeOneReplica drops one replica of every shard in the placement. Replicas
+// that are still initializing without a source, such as those added by
+// AddReplica, hold no data yet and are dropped first, otherwise the replica on
+// the most loaded instance is dropped to keep the load balanced. No shards are
+// moved, and instances left without shards are removed from the placement.
+func removeOneReplica(p placement.Placement) (placement.Placement, error) {
+	shardToInstances := make(map[uint32][]placement.Instance, p.NumShards())
+	for _, instance := range p.Instances() {
+		for _, s := range instance.Shards().All() {
+			if s.State() == shard.Leaving {
+				return nil, errRemoveReplicaWithLeaving
+			}
+			shardToInstances[s.ID()] = append(shardToInstances[s.ID()], instance)
+		}
+	}
+
+	for _, shardID := range p.Shards() {
+		var from placement.Instance
+		for _, instance := range shardToInstances[shardID] {
+			if from == nil || preferRemoveReplica(shardID, instance, from) {
+				from = instance
+			}
+		}
+		if from == nil {
+			return nil, fmt.Errorf("could not find a replica of shard %d to remove", shardID)
+		}
+		from.Shards().Remove(shardID)
+	}
+
+	instances := make([]placement.Instance, 0, p.NumInstances())
+	for _, instance := range p.Instances() {
+		if instance.Shards().NumShards() == 0 {
+			continue
+		}
+		instances = append(instances, instance)
+	}
+
+	return p.
+		SetInstances(instances).
+		SetReplicaFactor(p.ReplicaFactor() - 1), nil
+}
+
+// preferRemoveReplica returns whether the replica of the shard on the instance
+// should be removed rather than the replica on the current candidate.
+func preferRemoveReplica(shardID uint32, instance, candidate placement.Instance) bool {
+	isNew, candidateIsNew := isNewReplica(shardID, instance), isNewReplica(shardID, candidate)
+	if isNew != candidateIsNew {
+		return isNew
+	}
+
+	// Compare the load per weight of both instances without dividing.
+	load := uint64(loadOnInstance(instance)) * uint64(candidate.Weight())
+	candidateLoad := uint64(loadOnInstance(candidate)) * uint64(instance.Weight())
+	if load != candidateLoad {
+		return load > candidateLoad
+	}
+
+	return instance.ID() < candidate.ID()
+}
+
+func isNewReplica(shardID uint32, instance placement.Instance) bool {
+	s, ok := instance.Shards().Shard(shardID)
+	return ok && s.State() == shard.Initializing && s.SourceID() == ""
+}
+
 func removeInstanceFromList(instances []placement.Instance, instanceID string) []placement.Instance {
 	for i, instance := range instances {
 		if instance.ID() == instanceID {
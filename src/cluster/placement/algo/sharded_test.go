@@ -774,6 +774,106 @@ func TestAddReplica(t *testing.T) {
 	}
 }
 
+func TestRemoveReplica(t *testing.T) {
+	i1 := placement.NewEmptyInstance("i1", "r1", "", "e1", 1)
+	i2 := placement.NewEmptyInstance("i2", "r2", "", "e2", 1)
+	i3 := placement.NewEmptyInstance("i3", "r3", "", "e3", 1)
+
+	numShards := 6
+	ids := make([]uint32, numShards)
+	for i := 0; i < len(ids); i++ {
+		ids[i] = uint32(i)
+	}
+
+	a := newShardedAlgorithm(placement.NewOptions().SetShardStateMode(placement.StableShardStateOnly))
+	p, err := a.InitialPlacement([]placement.Instance{i1, i2, i3}, ids, 3)
+	require.NoError(t, err)
+	require.NoError(t, placement.Validate(p))
+
+	p, err = a.RemoveReplica(p)
+	require.NoError(t, err)
+	require.NoError(t, placement.Validate(p))
+	assert.Equal(t, 2, p.ReplicaFactor())
+	assert.Equal(t, numShards, p.NumShards())
+	assert.Equal(t, 3, p.NumInstances())
+	for _, instance := range p.Instances() {
+		// No shards are moved, so the remaining replicas stay available
+		// and the load stays balanced.
+		assert.Equal(t, 4, loadOnInstance(instance))
+		assert.Equal(t, 4, instance.Shards().NumShardsForState(shard.Available))
+	}
+
+	p, err = a.RemoveReplica(p)
+	require.NoError(t, err)
+	require.NoError(t, placement.Validate(p))
+	assert.Equal(t, 1, p.ReplicaFactor())
+	for _, instance := range p.Instances() {
+		assert.Equal(t, 2, loadOnInstance(instance))
+	}
+
+	_, err = a.RemoveReplica(p)
+	assert.Equal(t, errRemoveLastReplica, err)
+}
+
+func TestRemoveReplicaPrefersNewReplicas(t *testing.T) {
+	i1 := placement.NewEmptyInstance("i1", "r1", "", "e1", 1)
+	i1.Shards().Add(shard.NewShard(0).SetState(shard.Available))
+	i1.Shards().Add(shard.NewShard(1).SetState(shard.Available))
+
+	i2 := placement.NewEmptyInstance("i2", "r2", "", "e2", 1)
+	i2.Shards().Add(shard.NewShard(2).SetState(shard.Available))
+	i2.Shards().Add(shard.NewShard(3).SetState(shard.Available))
+
+	p := placement.NewPlacement().
+		SetInstances([]placement.Instance{i1, i2}).
+		SetShards([]uint32{0, 1, 2, 3}).
+		SetReplicaFactor(1).
+		SetIsSharded(true)
+
+	a := newShardedAlgorithm(placement.NewOptions())
+	p, err := a.AddReplica(p)
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.ReplicaFactor())
+
+	// Removing the replica before the new replica finished initializing
+	// reverts to the previous placement.
+	p, err = a.RemoveReplica(p)
+	require.NoError(t, err)
+	require.NoError(t, placement.Validate(p))
+	assert.Equal(t, 1, p.ReplicaFactor())
+	i1, _ = p.Instance("i1")
+	i2, _ = p.Instance("i2")
+	assert.Equal(t, []uint32{0, 1}, i1.Shards().AllIDs())
+	assert.Equal(t, []uint32{2, 3}, i2.Shards().AllIDs())
+	for _, instance := range p.Instances() {
+		assert.Equal(t, 0, instance.Shards().NumShardsForState(shard.Initializing))
+	}
+}
+
+func TestRemoveReplicaWithLeavingShards(t *testing.T) {
+	i1 := placement.NewEmptyInstance("i1", "r1", "", "e1", 1)
+	i1.Shards().Add(shard.NewShard(0).SetState(shard.Leaving))
+	i1.Shards().Add(shard.NewShard(1).SetState(shard.Available))
+
+	i2 := placement.NewEmptyInstance("i2", "r2", "", "e2", 1)
+	i2.Shards().Add(shard.NewShard(0).SetState(shard.Initializing).SetSourceID("i1"))
+	i2.Shards().Add(shard.NewShard(1).SetState(shard.Available))
+
+	i3 := placement.NewEmptyInstance("i3", "r3", "", "e3", 1)
+	i3.Shards().Add(shard.NewShard(0).SetState(shard.Available))
+
+	p := placement.NewPlacement().
+		SetInstances([]placement.Instance{i1, i2, i3}).
+		SetShards([]uint32{0, 1}).
+		SetReplicaFactor(2).
+		SetIsSharded(true)
+	require.NoError(t, placement.Validate(p))
+
+	a := newShardedAlgorithm(placement.NewOptions())
+	_, err := a.RemoveReplica(p)
+	assert.Equal(t, errRemoveReplicaWithLeaving, err)
+}
+
 func TestAddInstance(t *testing.T) {
 	i1 := placement.NewEmptyInstance("i1", "r1", "", "e1", 1)
 	i1.Shards().Add(shard.NewShard(0).SetState(shard.Available))
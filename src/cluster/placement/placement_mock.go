@@ -2266,6 +2266,21 @@ func (mr *MockServiceMockRecorder) AddReplica() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddReplica", reflect.TypeOf((*MockService)(nil).AddReplica))
 }
 
+// RemoveReplica mocks base method
+func (m *MockService) RemoveReplica() (Placement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RemoveReplica")
+	ret0, _ := ret[0].(Placement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RemoveReplica indicates an expected call of RemoveReplica
+func (mr *MockServiceMockRecorder) RemoveReplica() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RemoveReplica", reflect.TypeOf((*MockService)(nil).RemoveReplica))
+}
+
 // AddInstances mocks base method
 func (m *MockService) AddInstances(candidates []Instance) (Placement, []Instance, error) {
 	m.ctrl.T.Helper()
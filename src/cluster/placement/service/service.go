@@ -117,6 +117,28 @@ func (ps *placementService) AddReplica() (placement.Placement, error) {
 	return ps.CheckAndSet(tempPlacement, curPlacement.Version())
 }
 
+func (ps *placementService) RemoveReplica() (placement.Placement, error) {
+	curPlacement, err := ps.Placement()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := ps.opts.ValidateFnBeforeUpdate()(curPlacement); err != nil {
+		return nil, err
+	}
+
+	tempPlacement, err := ps.algo.RemoveReplica(curPlacement)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := placement.Validate(tempPlacement); err != nil {
+		return nil, err
+	}
+
+	return ps.CheckAndSet(tempPlacement, curPlacement.Version())
+}
+
 func (ps *placementService) AddInstances(
 	candidates []placement.Instance,
 ) (placement.Placement, []placement.Instance, error) {
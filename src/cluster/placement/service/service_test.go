@@ -281,6 +281,34 @@ func TestBadAddReplica(t *testing.T) {
 	assert.Error(t, err)
 }
 
+func TestRemoveReplica(t *testing.T) {
+	p := NewPlacementService(newMockStorage(), placement.NewOptions().SetValidZone("z1"))
+
+	_, err := p.BuildInitialPlacement([]placement.Instance{
+		placement.NewEmptyInstance("i1", "r1", "z1", "endpoint", 1),
+		placement.NewEmptyInstance("i2", "r2", "z1", "endpoint", 1),
+	}, 10, 2)
+	require.NoError(t, err)
+
+	s, err := p.RemoveReplica()
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.ReplicaFactor())
+	assert.NoError(t, placement.Validate(s))
+
+	s, err = p.Placement()
+	require.NoError(t, err)
+	assert.Equal(t, 1, s.ReplicaFactor())
+
+	// Could not remove the last replica.
+	_, err = p.RemoveReplica()
+	assert.Error(t, err)
+
+	// Could not find placement for service.
+	p = NewPlacementService(newMockStorage(), placement.NewOptions().SetValidZone("z1"))
+	_, err = p.RemoveReplica()
+	assert.Error(t, err)
+}
+
 func TestBadAddInstance(t *testing.T) {
 	ms := newMockStorage()
 	p := NewPlacementService(ms, placement.NewOptions().SetValidZone("z1"))
@@ -510,6 +510,9 @@ type Service interface {
 	// AddReplica up the replica factor by 1 in the placement.
 	AddReplica() (Placement, error)
 
+	// RemoveReplica reduces the replica factor by 1 in the placement.
+	RemoveReplica() (Placement, error)
+
 	// AddInstances adds instances from the candidate list to the placement.
 	AddInstances(candidates []Instance) (newPlacement Placement, addedInstances []Instance, err error)
 
@@ -544,6 +547,9 @@ type Algorithm interface {
 	// AddReplica up the replica factor by 1 in the placement.
 	AddReplica(p Placement) (Placement, error)
 
+	// RemoveReplica reduces the replica factor by 1 in the placement.
+	RemoveReplica(p Placement) (Placement, error)
+
 	// AddInstances adds a list of instance to the placement.
 	AddInstances(p Placement, instances []Instance) (Placement, error)
 
@@ -559,7 +559,8 @@ func filterInstances(s Service, ids []string) Service {
 	return NewService().
 		SetInstances(instances).
 		SetSharding(s.Sharding()).
-		SetReplication(s.Replication())
+		SetReplication(s.Replication()).
+		SetVersion(s.Version())
 }
 
 func filterInstancesWithWatch(s Service, hbw xwatch.Watch) Service {
@@ -692,13 +693,15 @@ func NewServiceFromPlacement(p placement.Placement, sid ServiceID) Service {
 	return NewService().
 		SetReplication(NewServiceReplication().SetReplicas(p.ReplicaFactor())).
 		SetSharding(NewServiceSharding().SetNumShards(p.NumShards()).SetIsSharded(p.IsSharded())).
-		SetInstances(serviceInstances)
+		SetInstances(serviceInstances).
+		SetVersion(p.Version())
 }
 
 type service struct {
 	instances   []ServiceInstance
 	replication ServiceReplication
 	sharding    ServiceSharding
+	version     int
 }
 
 func (s *service) Instance(instanceID string) (ServiceInstance, error) {
@@ -712,9 +715,11 @@ func (s *service) Instance(instanceID string) (ServiceInstance, error) {
 func (s *service) Instances() []ServiceInstance                 { return s.instances }
 func (s *service) Replication() ServiceReplication              { return s.replication }
 func (s *service) Sharding() ServiceSharding                    { return s.sharding }
+func (s *service) Version() int                                 { return s.version }
 func (s *service) SetInstances(insts []ServiceInstance) Service { s.instances = insts; return s }
 func (s *service) SetReplication(r ServiceReplication) Service  { s.replication = r; return s }
 func (s *service) SetSharding(ss ServiceSharding) Service       { s.sharding = ss; return s }
+func (s *service) SetVersion(v int) Service                     { s.version = v; return s }
 
 // NewServiceReplication creates a new ServiceReplication.
 func NewServiceReplication() ServiceReplication { return new(serviceReplication) }
@@ -758,6 +763,7 @@ func NewServiceInstanceFromProto(
 		SetServiceID(sid).
 		SetInstanceID(instance.Id).
 		SetEndpoint(instance.Endpoint).
+		SetIsolationGroup(instance.IsolationGroup).
 		SetShards(shards), nil
 }
 
@@ -770,23 +776,27 @@ func NewServiceInstanceFromPlacementInstance(
 		SetServiceID(sid).
 		SetInstanceID(instance.ID()).
 		SetEndpoint(instance.Endpoint()).
+		SetIsolationGroup(instance.IsolationGroup()).
 		SetShards(instance.Shards())
 }
 
 type serviceInstance struct {
-	service  ServiceID
-	id       string
-	endpoint string
-	shards   shard.Shards
-}
-
-func (i *serviceInstance) InstanceID() string                       { return i.id }
-func (i *serviceInstance) Endpoint() string                         { return i.endpoint }
-func (i *serviceInstance) Shards() shard.Shards                     { return i.shards }
-func (i *serviceInstance) ServiceID() ServiceID                     { return i.service }
-func (i *serviceInstance) SetInstanceID(id string) ServiceInstance  { i.id = id; return i }
-func (i *serviceInstance) SetEndpoint(e string) ServiceInstance     { i.endpoint = e; return i }
-func (i *serviceInstance) SetShards(s shard.Shards) ServiceInstance { i.shards = s; return i }
+	service        ServiceID
+	id             string
+	endpoint       string
+	isolationGroup string
+	shards         shard.Shards
+}
+
+func (i *serviceInstance) InstanceID() string                         { return i.id }
+func (i *serviceInstance) Endpoint() string                           { return i.endpoint }
+func (i *serviceInstance) IsolationGroup() string                     { return i.isolationGroup }
+func (i *serviceInstance) Shards() shard.Shards                       { return i.shards }
+func (i *serviceInstance) ServiceID() ServiceID                       { return i.service }
+func (i *serviceInstance) SetInstanceID(id string) ServiceInstance    { i.id = id; return i }
+func (i *serviceInstance) SetEndpoint(e string) ServiceInstance       { i.endpoint = e; return i }
+func (i *serviceInstance) SetIsolationGroup(g string) ServiceInstance { i.isolationGroup = g; return i }
+func (i *serviceInstance) SetShards(s shard.Shards) ServiceInstance   { i.shards = s; return i }
 
 func (i *serviceInstance) SetServiceID(service ServiceID) ServiceInstance {
 	i.service = service
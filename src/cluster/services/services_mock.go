@@ -727,6 +727,34 @@ func (mr *MockServiceMockRecorder) SetSharding(s interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSharding", reflect.TypeOf((*MockService)(nil).SetSharding), s)
 }
 
+// Version mocks base method
+func (m *MockService) Version() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Version indicates an expected call of Version
+func (mr *MockServiceMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockService)(nil).Version))
+}
+
+// SetVersion mocks base method
+func (m *MockService) SetVersion(v int) Service {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersion", v)
+	ret0, _ := ret[0].(Service)
+	return ret0
+}
+
+// SetVersion indicates an expected call of SetVersion
+func (mr *MockServiceMockRecorder) SetVersion(v interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersion", reflect.TypeOf((*MockService)(nil).SetVersion), v)
+}
+
 // MockServiceReplication is a mock of ServiceReplication interface
 type MockServiceReplication struct {
 	ctrl     *gomock.Controller
@@ -964,6 +992,34 @@ func (mr *MockServiceInstanceMockRecorder) SetEndpoint(e interface{}) *gomock.Ca
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEndpoint", reflect.TypeOf((*MockServiceInstance)(nil).SetEndpoint), e)
 }
 
+// IsolationGroup mocks base method
+func (m *MockServiceInstance) IsolationGroup() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsolationGroup")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// IsolationGroup indicates an expected call of IsolationGroup
+func (mr *MockServiceInstanceMockRecorder) IsolationGroup() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsolationGroup", reflect.TypeOf((*MockServiceInstance)(nil).IsolationGroup))
+}
+
+// SetIsolationGroup mocks base method
+func (m *MockServiceInstance) SetIsolationGroup(g string) ServiceInstance {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetIsolationGroup", g)
+	ret0, _ := ret[0].(ServiceInstance)
+	return ret0
+}
+
+// SetIsolationGroup indicates an expected call of SetIsolationGroup
+func (mr *MockServiceInstanceMockRecorder) SetIsolationGroup(g interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetIsolationGroup", reflect.TypeOf((*MockServiceInstance)(nil).SetIsolationGroup), g)
+}
+
 // Shards mocks base method
 func (m *MockServiceInstance) Shards() shard.Shards {
 	m.ctrl.T.Helper()
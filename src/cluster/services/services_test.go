@@ -82,6 +82,7 @@ func TestConvertBetweenProtoAndService(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "i1", i1.InstanceID())
 	assert.Equal(t, "e1", i1.Endpoint())
+	assert.Equal(t, "r1", i1.IsolationGroup())
 	assert.Equal(t, 3, i1.Shards().NumShards())
 	assert.Equal(t, sid, i1.ServiceID())
 	assert.True(t, i1.Shards().Contains(0))
@@ -92,6 +93,7 @@ func TestConvertBetweenProtoAndService(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Equal(t, "i2", i2.InstanceID())
 	assert.Equal(t, "e2", i2.Endpoint())
+	assert.Equal(t, "r2", i2.IsolationGroup())
 	assert.Equal(t, 3, i2.Shards().NumShards())
 	assert.Equal(t, sid, i2.ServiceID())
 	assert.True(t, i2.Shards().Contains(0))
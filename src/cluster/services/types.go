@@ -178,6 +178,12 @@ type Service interface {
 
 	// SetSharding sets the service sharding description or nil if none
 	SetSharding(s ServiceSharding) Service
+
+	// Version returns the version of the placement the service was built from.
+	Version() int
+
+	// SetVersion sets the version of the placement the service was built from.
+	SetVersion(v int) Service
 }
 
 // ServiceReplication describes the replication of a service.
@@ -224,6 +230,12 @@ type ServiceInstance interface {
 	// SetEndpoint sets the endpoint of the instance.
 	SetEndpoint(e string) ServiceInstance
 
+	// IsolationGroup returns the isolation group of the instance.
+	IsolationGroup() string
+
+	// SetIsolationGroup sets the isolation group of the instance.
+	SetIsolationGroup(g string) ServiceInstance
+
 	// Shards returns the shards of the instance.
 	Shards() shard.Shards
 
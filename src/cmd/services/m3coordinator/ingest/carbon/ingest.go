@@ -95,6 +95,31 @@ func NewIngester(
 	downsamplerAndWriter ingest.DownsamplerAndWriter,
 	rules CarbonIngesterRules,
 	opts Options,
+) (m3xserver.Handler, error) {
+	return newIngester(downsamplerAndWriter, rules, opts,
+		func(conn net.Conn, iOpts instrument.Options) metricScanner {
+			return lineScanner{carbon.NewScanner(conn, iOpts)}
+		})
+}
+
+// NewPickleIngester returns an ingester for carbon metrics sent using the
+// pickle protocol.
+func NewPickleIngester(
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	rules CarbonIngesterRules,
+	opts Options,
+) (m3xserver.Handler, error) {
+	return newIngester(downsamplerAndWriter, rules, opts,
+		func(conn net.Conn, iOpts instrument.Options) metricScanner {
+			return pickleScanner{carbon.NewPickleScanner(conn, iOpts)}
+		})
+}
+
+func newIngester(
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	rules CarbonIngesterRules,
+	opts Options,
+	newScanner newMetricScannerFn,
 ) (m3xserver.Handler, error) {
 	err := opts.Validate()
 	if err != nil {
@@ -132,6 +157,8 @@ func NewIngester(
 		opts:                 opts,
 		logger:               opts.InstrumentOptions.Logger(),
 		tagOpts:              tagOpts,
+		templateTagOpts:      models.NewTagOptions(),
+		newScanner:           newScanner,
 		metrics: newCarbonIngesterMetrics(
 			opts.InstrumentOptions.MetricsScope()),
 
@@ -147,6 +174,8 @@ type ingester struct {
 	logger               *zap.Logger
 	metrics              carbonIngesterMetrics
 	tagOpts              models.TagOptions
+	templateTagOpts      models.TagOptions
+	newScanner           newMetricScannerFn
 
 	rules []ruleAndRegex
 
@@ -160,7 +189,7 @@ func (i *ingester) Handle(conn net.Conn) {
 		// the same context always and rely on M3DB client timeouts.
 		ctx    = context.Background()
 		wg     = sync.WaitGroup{}
-		s      = carbon.NewScanner(conn, i.opts.InstrumentOptions)
+		s      = i.newScanner(conn, i.opts.InstrumentOptions)
 		logger = i.opts.InstrumentOptions.Logger()
 	)
 
@@ -184,8 +213,7 @@ func (i *ingester) Handle(conn net.Conn) {
 			wg.Done()
 		})
 
-		i.metrics.malformed.Inc(int64(s.MalformedCount))
-		s.MalformedCount = 0
+		i.metrics.malformed.Inc(int64(s.takeMalformedCount()))
 	}
 
 	if err := s.Err(); err != nil {
@@ -250,7 +278,7 @@ func (i *ingester) write(
 			// Break because we only want to apply one rule per metric based on which
 			// ever one matches first.
 			err := i.writeWithOptions(ctx, resources, timestamp, value,
-				downsampleAndStoragePolicies)
+				rule.template, downsampleAndStoragePolicies)
 			if err != nil {
 				return false
 			}
@@ -272,10 +300,19 @@ func (i *ingester) writeWithOptions(
 	resources *lineResources,
 	timestamp time.Time,
 	value float64,
+	template *pathTemplate,
 	opts ingest.WriteOptions,
 ) error {
+	var (
+		tags models.Tags
+		err  error
+	)
 	resources.datapoints[0] = ts.Datapoint{Timestamp: timestamp, Value: value}
-	tags, err := GenerateTagsFromNameIntoSlice(resources.name, i.tagOpts, resources.tags)
+	if template != nil {
+		tags, err = template.tags(resources.name, i.templateTagOpts)
+	} else {
+		tags, err = GenerateTagsFromNameIntoSlice(resources.name, i.tagOpts, resources.tags)
+	}
 	if err != nil {
 		i.logger.Error("err generating tags from carbon",
 			zap.String("name", string(resources.name)), zap.Error(err))
@@ -421,6 +458,13 @@ func compileRules(rules CarbonIngesterRules) ([]ruleAndRegex, error) {
 			regexp: compiled,
 		}
 
+		if rule.Template != "" {
+			compiledRule.template, err = parsePathTemplate(rule.Template)
+			if err != nil {
+				return nil, err
+			}
+		}
+
 		if rule.Aggregation.EnabledOrDefault() {
 			compiledRule.mappingRules = []downsample.AutoMappingRule{
 				downsample.AutoMappingRule{
@@ -474,4 +518,37 @@ type ruleAndRegex struct {
 	regexp          *regexp.Regexp
 	mappingRules    []downsample.AutoMappingRule
 	storagePolicies []policy.StoragePolicy
+	template        *pathTemplate
+}
+
+type newMetricScannerFn func(conn net.Conn, iOpts instrument.Options) metricScanner
+
+// metricScanner scans carbon metrics from a connection.
+type metricScanner interface {
+	Scan() bool
+	Metric() ([]byte, time.Time, float64)
+	Err() error
+	// takeMalformedCount returns the number of malformed metrics encountered
+	// since it was last called.
+	takeMalformedCount() int
+}
+
+type lineScanner struct {
+	*carbon.Scanner
+}
+
+func (s lineScanner) takeMalformedCount() int {
+	n := s.MalformedCount
+	s.MalformedCount = 0
+	return n
+}
+
+type pickleScanner struct {
+	*carbon.PickleScanner
+}
+
+func (s pickleScanner) takeMalformedCount() int {
+	n := s.MalformedCount
+	s.MalformedCount = 0
+	return n
 }
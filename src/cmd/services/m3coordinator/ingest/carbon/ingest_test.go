@@ -23,6 +23,7 @@ package ingestcarbon
 import (
 	"bytes"
 	"context"
+	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -302,6 +303,80 @@ func TestIngesterHonorsPatterns(t *testing.T) {
 	}, found)
 }
 
+func TestPickleIngesterHandleConnWithTemplate(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	mockDownsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+
+	var (
+		lock  = sync.Mutex{}
+		found = []testMetric{}
+	)
+	mockDownsamplerAndWriter.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), xtime.Second, gomock.Any(), gomock.Any()).DoAndReturn(func(
+		_ context.Context,
+		tags models.Tags,
+		dp ts.Datapoints,
+		unit xtime.Unit,
+		annotation []byte,
+		overrides ingest.WriteOptions,
+	) interface{} {
+		lock.Lock()
+		found = append(found, testMetric{
+			tags: tags.Clone(), timestamp: int(dp[0].Timestamp.Unix()), value: dp[0].Value})
+		lock.Unlock()
+		return nil
+	}).AnyTimes()
+
+	rules := CarbonIngesterRules{
+		Rules: []config.CarbonIngesterRuleConfiguration{
+			{
+				Pattern:  "^servers\\.",
+				Template: "_.env.host.measurement*",
+				Policies: []config.CarbonIngesterStoragePolicyConfiguration{
+					{
+						Resolution: 10 * time.Second,
+						Retention:  48 * time.Hour,
+					},
+				},
+			},
+		},
+	}
+
+	// Pickled [("servers.prod.host01.cpu.user", (1, 1.0)),
+	// ("servers.dev.host02.mem", (2, 2))].
+	pickled := "\x80\x02]q\x00(X\x1c\x00\x00\x00servers.prod.host01.cpu.userq\x01K\x01G?" +
+		"\xf0\x00\x00\x00\x00\x00\x00\x86q\x02\x86q\x03X\x16\x00\x00\x00servers.dev." +
+		"host02.memq\x04K\x02K\x02\x86q\x05\x86q\x06e."
+	var header [4]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(pickled)))
+	packet := append(header[:], pickled...)
+
+	byteConn := &byteConn{b: bytes.NewBuffer(packet)}
+	ingester, err := NewPickleIngester(mockDownsamplerAndWriter, rules, testOptions)
+	require.NoError(t, err)
+	ingester.Handle(byteConn)
+
+	tagOpts := models.NewTagOptions()
+	assertTestMetricsAreEqual(t, []testMetric{
+		{
+			tags: models.NewTags(3, tagOpts).AddTags([]models.Tag{
+				{Name: []byte("env"), Value: []byte("prod")},
+				{Name: []byte("host"), Value: []byte("host01")},
+			}).SetName([]byte("cpu_user")),
+			timestamp: 1,
+			value:     1,
+		},
+		{
+			tags: models.NewTags(3, tagOpts).AddTags([]models.Tag{
+				{Name: []byte("env"), Value: []byte("dev")},
+				{Name: []byte("host"), Value: []byte("host02")},
+			}).SetName([]byte("mem")),
+			timestamp: 2,
+			value:     2,
+		},
+	}, found)
+}
+
 func TestGenerateTagsFromName(t *testing.T) {
 	testCases := []struct {
 		name         string
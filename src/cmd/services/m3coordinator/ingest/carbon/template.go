@@ -0,0 +1,139 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestcarbon
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"github.com/m3db/m3/src/query/models"
+)
+
+const (
+	templateMeasurement = "measurement"
+	templateSkip        = "_"
+	templateWildcard    = "*"
+)
+
+var measurementSeparator = []byte("_")
+
+// pathTemplate maps the nodes of a carbon metric path to tags, for instance
+// the template "env.host.measurement*" maps the path
+//      prod.host01.cpu.user
+// to the tags
+//      env:prod
+//      host:host01
+//      __name__:cpu_user
+type pathTemplate struct {
+	elements []templateElement
+	// wildcard is set when the last element maps all remaining nodes.
+	wildcard bool
+}
+
+type templateElement struct {
+	tag         []byte
+	measurement bool
+	skip        bool
+}
+
+func parsePathTemplate(template string) (*pathTemplate, error) {
+	parts := strings.Split(template, string(carbonSeparatorByte))
+	t := &pathTemplate{elements: make([]templateElement, 0, len(parts))}
+	for i, part := range parts {
+		if strings.HasSuffix(part, templateWildcard) {
+			if i != len(parts)-1 {
+				return nil, fmt.Errorf(
+					"carbon template %s: wildcard only allowed on last element", template)
+			}
+			t.wildcard = true
+			part = strings.TrimSuffix(part, templateWildcard)
+		}
+
+		switch part {
+		case "":
+			return nil, fmt.Errorf("carbon template %s: has empty element", template)
+		case templateSkip:
+			t.elements = append(t.elements, templateElement{skip: true})
+		case templateMeasurement:
+			t.elements = append(t.elements, templateElement{measurement: true})
+		default:
+			t.elements = append(t.elements, templateElement{tag: []byte(part)})
+		}
+	}
+
+	return t, nil
+}
+
+// tags returns the tags for a metric path, measurement nodes are joined using
+// an underscore and nodes that map to the same tag are joined using a dot.
+func (t *pathTemplate) tags(
+	name []byte,
+	opts models.TagOptions,
+) (models.Tags, error) {
+	if len(name) == 0 {
+		return models.EmptyTags(), errCannotGenerateTagsFromEmptyName
+	}
+
+	nodes := bytes.Split(name, carbonSeparatorBytes)
+	if len(nodes) > len(t.elements) && !t.wildcard {
+		return models.EmptyTags(), fmt.Errorf(
+			"carbon metric: %s has more nodes than its template", string(name))
+	}
+
+	var (
+		measurement []byte
+		tags        = models.NewTags(len(t.elements), opts)
+	)
+	for i, node := range nodes {
+		if len(node) == 0 {
+			return models.EmptyTags(),
+				fmt.Errorf("carbon metric: %s has duplicate separator", string(name))
+		}
+
+		element := t.elements[len(t.elements)-1]
+		if i < len(t.elements) {
+			element = t.elements[i]
+		}
+
+		switch {
+		case element.skip:
+		case element.measurement:
+			if len(measurement) > 0 {
+				measurement = append(measurement, measurementSeparator...)
+			}
+			measurement = append(measurement, node...)
+		default:
+			value := append([]byte(nil), node...)
+			if existing, ok := tags.Get(element.tag); ok {
+				value = append(append(append([]byte(nil), existing...),
+					carbonSeparatorByte), node...)
+			}
+			tags = tags.AddOrUpdateTag(models.Tag{Name: element.tag, Value: value})
+		}
+	}
+
+	if len(measurement) > 0 {
+		tags = tags.SetName(measurement)
+	}
+
+	return tags, nil
+}
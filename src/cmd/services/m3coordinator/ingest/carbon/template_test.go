@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestcarbon
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/query/models"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePathTemplateErrors(t *testing.T) {
+	for _, template := range []string{
+		"",
+		"env..host",
+		"env.host*.measurement",
+	} {
+		_, err := parsePathTemplate(template)
+		assert.Error(t, err, template)
+	}
+}
+
+func TestPathTemplateTags(t *testing.T) {
+	opts := models.NewTagOptions()
+	testCases := []struct {
+		template     string
+		name         string
+		expectedTags []models.Tag
+		expectedErr  bool
+	}{
+		{
+			template: "env.host.measurement*",
+			name:     "prod.host01.cpu.user",
+			expectedTags: []models.Tag{
+				{Name: []byte("__name__"), Value: []byte("cpu_user")},
+				{Name: []byte("env"), Value: []byte("prod")},
+				{Name: []byte("host"), Value: []byte("host01")},
+			},
+		},
+		{
+			template: "_.region.region.measurement",
+			name:     "servers.us.east.requests",
+			expectedTags: []models.Tag{
+				{Name: []byte("__name__"), Value: []byte("requests")},
+				{Name: []byte("region"), Value: []byte("us.east")},
+			},
+		},
+		{
+			template: "measurement.field*",
+			name:     "disk.sda.used",
+			expectedTags: []models.Tag{
+				{Name: []byte("__name__"), Value: []byte("disk")},
+				{Name: []byte("field"), Value: []byte("sda.used")},
+			},
+		},
+		{
+			template: "env.host.measurement",
+			name:     "prod.host01",
+			expectedTags: []models.Tag{
+				{Name: []byte("env"), Value: []byte("prod")},
+				{Name: []byte("host"), Value: []byte("host01")},
+			},
+		},
+		{
+			template:    "env.measurement",
+			name:        "prod.cpu.user",
+			expectedErr: true,
+		},
+		{
+			template:    "env.measurement*",
+			name:        "prod..cpu",
+			expectedErr: true,
+		},
+		{
+			template:    "env.measurement*",
+			name:        "",
+			expectedErr: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.template+"/"+tc.name, func(t *testing.T) {
+			template, err := parsePathTemplate(tc.template)
+			require.NoError(t, err)
+
+			tags, err := template.tags([]byte(tc.name), opts)
+			if tc.expectedErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tc.expectedTags, tags.Tags)
+		})
+	}
+}
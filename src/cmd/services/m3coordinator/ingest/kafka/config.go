@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestkafka
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Configuration is the configuration for a Kafka ingester.
+type Configuration struct {
+	// Decoder is the decoder of messages, one of json, msgpack or protobuf.
+	Decoder DecoderType `yaml:"decoder" validate:"nonzero"`
+	// BatchSize is the number of writes after which a batch is written.
+	BatchSize int `yaml:"batchSize"`
+	// FlushInterval is the interval after which a batch is written if it
+	// has not reached the batch size.
+	FlushInterval time.Duration `yaml:"flushInterval"`
+	// RetryInterval is the interval to wait before retrying a failed write.
+	RetryInterval time.Duration `yaml:"retryInterval"`
+}
+
+// NewOptions returns the ingester options for the configuration.
+func (c Configuration) NewOptions(
+	iOpts instrument.Options,
+	tagOpts models.TagOptions,
+) (Options, error) {
+	decoder, err := NewDecoder(c.Decoder, tagOpts)
+	if err != nil {
+		return Options{}, err
+	}
+
+	return Options{
+		InstrumentOptions: iOpts,
+		Decoder:           decoder,
+		BatchSize:         c.BatchSize,
+		FlushInterval:     c.FlushInterval,
+		RetryInterval:     c.RetryInterval,
+	}, nil
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package ingestkafka consumes writes from Kafka topics, writing them in
+// batches and committing the consumed offsets once the writes have been
+// acknowledged by storage.
+//
+// No Kafka client library is vendored, so the Kafka client is provided by
+// implementing the Consumer interface.
+package ingestkafka
+
+// TopicPartition identifies a partition of a Kafka topic.
+type TopicPartition struct {
+	Topic     string
+	Partition int32
+}
+
+// Message is a message consumed from a Kafka partition.
+type Message struct {
+	TopicPartition
+
+	Offset int64
+	Value  []byte
+}
+
+// Consumer consumes messages from Kafka partitions.
+type Consumer interface {
+	// Messages returns the channel of consumed messages, the channel is
+	// closed when the consumer is closed.
+	Messages() <-chan Message
+
+	// CommitOffsets commits the offsets of the next messages to consume
+	// for each partition.
+	CommitOffsets(offsets map[TopicPartition]int64) error
+
+	// HighWaterMarks returns the offset of the next message to be produced
+	// to each partition consumed.
+	HighWaterMarks() map[TopicPartition]int64
+
+	// Close closes the consumer.
+	Close() error
+}
@@ -0,0 +1,179 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestkafka
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/query/ts"
+
+	msgpack "gopkg.in/vmihailenco/msgpack.v2"
+)
+
+// DecoderType is a type of message decoder.
+type DecoderType string
+
+const (
+	// JSONDecoderType decodes JSON encoded writes.
+	JSONDecoderType DecoderType = "json"
+	// MsgpackDecoderType decodes msgpack encoded writes.
+	MsgpackDecoderType DecoderType = "msgpack"
+	// ProtobufDecoderType decodes Prometheus remote write protobuf requests.
+	ProtobufDecoderType DecoderType = "protobuf"
+)
+
+var (
+	errNoTags = errors.New("kafka message: write has no tags")
+
+	validDecoderTypes = []DecoderType{
+		JSONDecoderType,
+		MsgpackDecoderType,
+		ProtobufDecoderType,
+	}
+)
+
+// Write is a single tagged write decoded from a message.
+type Write struct {
+	Tags       models.Tags
+	Datapoints ts.Datapoints
+}
+
+// Decoder decodes the writes of a message.
+type Decoder interface {
+	Decode(value []byte) ([]Write, error)
+}
+
+// NewDecoder returns a decoder of the given type.
+func NewDecoder(t DecoderType, tagOpts models.TagOptions) (Decoder, error) {
+	switch t {
+	case JSONDecoderType:
+		return &jsonDecoder{tagOpts: tagOpts}, nil
+	case MsgpackDecoderType:
+		return &msgpackDecoder{tagOpts: tagOpts}, nil
+	case ProtobufDecoderType:
+		return &protobufDecoder{tagOpts: tagOpts}, nil
+	}
+	return nil, fmt.Errorf("invalid kafka decoder type %s, valid types are: %v",
+		t, validDecoderTypes)
+}
+
+// encodedWrite is the schema of JSON and msgpack encoded writes, timestamps
+// are in milliseconds since the epoch.
+type encodedWrite struct {
+	Tags      map[string]string `json:"tags" msgpack:"tags"`
+	Timestamp int64             `json:"timestamp" msgpack:"timestamp"`
+	Value     float64           `json:"value" msgpack:"value"`
+}
+
+func (w encodedWrite) toWrite(tagOpts models.TagOptions) (Write, error) {
+	if len(w.Tags) == 0 {
+		return Write{}, errNoTags
+	}
+
+	tags := make([]models.Tag, 0, len(w.Tags))
+	for name, value := range w.Tags {
+		tags = append(tags, models.Tag{Name: []byte(name), Value: []byte(value)})
+	}
+
+	return Write{
+		Tags: models.NewTags(len(tags), tagOpts).AddTags(tags),
+		Datapoints: ts.Datapoints{{
+			Timestamp: storage.PromTimestampToTime(w.Timestamp),
+			Value:     w.Value,
+		}},
+	}, nil
+}
+
+func toWrites(encoded []encodedWrite, tagOpts models.TagOptions) ([]Write, error) {
+	writes := make([]Write, 0, len(encoded))
+	for _, w := range encoded {
+		write, err := w.toWrite(tagOpts)
+		if err != nil {
+			return nil, err
+		}
+		writes = append(writes, write)
+	}
+	return writes, nil
+}
+
+// jsonDecoder decodes a JSON encoded write or array of writes.
+type jsonDecoder struct {
+	tagOpts models.TagOptions
+}
+
+func (d *jsonDecoder) Decode(value []byte) ([]Write, error) {
+	var encoded []encodedWrite
+	if trimmed := bytes.TrimSpace(value); len(trimmed) > 0 && trimmed[0] == '[' {
+		if err := json.Unmarshal(trimmed, &encoded); err != nil {
+			return nil, err
+		}
+	} else {
+		var w encodedWrite
+		if err := json.Unmarshal(trimmed, &w); err != nil {
+			return nil, err
+		}
+		encoded = append(encoded, w)
+	}
+	return toWrites(encoded, d.tagOpts)
+}
+
+// msgpackDecoder decodes a msgpack encoded write.
+type msgpackDecoder struct {
+	tagOpts models.TagOptions
+}
+
+func (d *msgpackDecoder) Decode(value []byte) ([]Write, error) {
+	var w encodedWrite
+	if err := msgpack.Unmarshal(value, &w); err != nil {
+		return nil, err
+	}
+	return toWrites([]encodedWrite{w}, d.tagOpts)
+}
+
+// protobufDecoder decodes an uncompressed Prometheus remote write request.
+type protobufDecoder struct {
+	tagOpts models.TagOptions
+}
+
+func (d *protobufDecoder) Decode(value []byte) ([]Write, error) {
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(value); err != nil {
+		return nil, err
+	}
+
+	writes := make([]Write, 0, len(req.Timeseries))
+	for _, series := range req.Timeseries {
+		if len(series.Labels) == 0 {
+			return nil, errNoTags
+		}
+		writes = append(writes, Write{
+			Tags:       storage.PromLabelsToM3Tags(series.Labels, d.tagOpts),
+			Datapoints: storage.PromSamplesToM3Datapoints(series.Samples),
+		})
+	}
+	return writes, nil
+}
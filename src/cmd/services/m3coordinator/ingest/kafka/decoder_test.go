@@ -0,0 +1,127 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestkafka
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	msgpack "gopkg.in/vmihailenco/msgpack.v2"
+)
+
+func expectedTestWrite(ms int64, value float64, tags ...string) Write {
+	t := models.NewTags(len(tags)/2, models.NewTagOptions())
+	for i := 0; i < len(tags); i += 2 {
+		t = t.AddTag(models.Tag{Name: []byte(tags[i]), Value: []byte(tags[i+1])})
+	}
+	return Write{
+		Tags: t,
+		Datapoints: ts.Datapoints{{
+			Timestamp: time.Unix(0, ms*int64(time.Millisecond)),
+			Value:     value,
+		}},
+	}
+}
+
+func TestJSONDecoder(t *testing.T) {
+	decoder, err := NewDecoder(JSONDecoderType, models.NewTagOptions())
+	require.NoError(t, err)
+
+	writes, err := decoder.Decode([]byte(
+		`{"tags":{"__name__":"cpu","host":"a"},"timestamp":1500000000000,"value":1.5}`))
+	require.NoError(t, err)
+	assert.Equal(t, []Write{
+		expectedTestWrite(1500000000000, 1.5, "__name__", "cpu", "host", "a"),
+	}, writes)
+
+	writes, err = decoder.Decode([]byte(` [
+		{"tags":{"__name__":"cpu"},"timestamp":1000,"value":1},
+		{"tags":{"__name__":"mem"},"timestamp":2000,"value":2}
+	]`))
+	require.NoError(t, err)
+	assert.Equal(t, []Write{
+		expectedTestWrite(1000, 1, "__name__", "cpu"),
+		expectedTestWrite(2000, 2, "__name__", "mem"),
+	}, writes)
+
+	_, err = decoder.Decode([]byte(`{"timestamp":1000,"value":1}`))
+	assert.Equal(t, errNoTags, err)
+
+	_, err = decoder.Decode([]byte(`{"tags":`))
+	assert.Error(t, err)
+}
+
+func TestMsgpackDecoder(t *testing.T) {
+	decoder, err := NewDecoder(MsgpackDecoderType, models.NewTagOptions())
+	require.NoError(t, err)
+
+	value, err := msgpack.Marshal(encodedWrite{
+		Tags:      map[string]string{"__name__": "cpu", "host": "a"},
+		Timestamp: 1000,
+		Value:     3,
+	})
+	require.NoError(t, err)
+
+	writes, err := decoder.Decode(value)
+	require.NoError(t, err)
+	assert.Equal(t, []Write{
+		expectedTestWrite(1000, 3, "__name__", "cpu", "host", "a"),
+	}, writes)
+
+	_, err = decoder.Decode([]byte{0xc1})
+	assert.Error(t, err)
+}
+
+func TestProtobufDecoder(t *testing.T) {
+	decoder, err := NewDecoder(ProtobufDecoderType, models.NewTagOptions())
+	require.NoError(t, err)
+
+	req := &prompb.WriteRequest{
+		Timeseries: []prompb.TimeSeries{
+			{
+				Labels: []prompb.Label{
+					{Name: []byte("__name__"), Value: []byte("cpu")},
+					{Name: []byte("host"), Value: []byte("a")},
+				},
+				Samples: []prompb.Sample{{Timestamp: 1000, Value: 4}},
+			},
+		},
+	}
+	value, err := req.Marshal()
+	require.NoError(t, err)
+
+	writes, err := decoder.Decode(value)
+	require.NoError(t, err)
+	assert.Equal(t, []Write{
+		expectedTestWrite(1000, 4, "__name__", "cpu", "host", "a"),
+	}, writes)
+}
+
+func TestNewDecoderInvalidType(t *testing.T) {
+	_, err := NewDecoder(DecoderType("avro"), models.NewTagOptions())
+	assert.Error(t, err)
+}
@@ -0,0 +1,345 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestkafka
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultBatchSize     = 1024
+	defaultFlushInterval = time.Second
+	defaultRetryInterval = time.Second
+)
+
+var (
+	errIOptsMustBeSet   = errors.New("kafka ingester options: instrument options must be set")
+	errDecoderMustBeSet = errors.New("kafka ingester options: decoder must be set")
+	errAlreadyStarted   = errors.New("kafka ingester: already started")
+)
+
+// Options configures the ingester.
+type Options struct {
+	InstrumentOptions instrument.Options
+	Decoder           Decoder
+	// BatchSize is the number of writes after which a batch is written.
+	BatchSize int
+	// FlushInterval is the interval after which a batch is written if it
+	// has not reached the batch size.
+	FlushInterval time.Duration
+	// RetryInterval is the interval to wait before retrying a failed write.
+	RetryInterval time.Duration
+	// WriteOptions are the options used for writes.
+	WriteOptions ingest.WriteOptions
+}
+
+// Validate validates the options struct.
+func (o *Options) Validate() error {
+	if o.InstrumentOptions == nil {
+		return errIOptsMustBeSet
+	}
+
+	if o.Decoder == nil {
+		return errDecoderMustBeSet
+	}
+
+	return nil
+}
+
+type ingesterMetrics struct {
+	consumed     tally.Counter
+	malformed    tally.Counter
+	written      tally.Counter
+	writeErrors  tally.Counter
+	commits      tally.Counter
+	commitErrors tally.Counter
+}
+
+func newIngesterMetrics(scope tally.Scope) ingesterMetrics {
+	return ingesterMetrics{
+		consumed:     scope.Counter("consumed"),
+		malformed:    scope.Counter("malformed"),
+		written:      scope.Counter("written"),
+		writeErrors:  scope.Counter("write-errors"),
+		commits:      scope.Counter("commits"),
+		commitErrors: scope.Counter("commit-errors"),
+	}
+}
+
+// Ingester consumes writes from Kafka and writes them in batches, offsets
+// are only committed once the writes of all messages up to them have been
+// acknowledged so that writes are delivered at least once.
+type Ingester struct {
+	sync.Mutex
+
+	consumer             Consumer
+	downsamplerAndWriter ingest.DownsamplerAndWriter
+	opts                 Options
+	logger               *zap.Logger
+	scope                tally.Scope
+	metrics              ingesterMetrics
+
+	started bool
+	closed  bool
+	closeCh chan struct{}
+	doneCh  chan struct{}
+
+	// The fields below are only accessed by the consume loop.
+	batch     []Write
+	offsets   map[TopicPartition]int64
+	committed map[TopicPartition]int64
+	lag       map[TopicPartition]tally.Gauge
+}
+
+// NewIngester returns a new Kafka ingester.
+func NewIngester(
+	consumer Consumer,
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	opts Options,
+) (*Ingester, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = defaultFlushInterval
+	}
+	if opts.RetryInterval <= 0 {
+		opts.RetryInterval = defaultRetryInterval
+	}
+
+	scope := opts.InstrumentOptions.MetricsScope()
+	return &Ingester{
+		consumer:             consumer,
+		downsamplerAndWriter: downsamplerAndWriter,
+		opts:                 opts,
+		logger:               opts.InstrumentOptions.Logger(),
+		scope:                scope,
+		metrics:              newIngesterMetrics(scope),
+		closeCh:              make(chan struct{}),
+		doneCh:               make(chan struct{}),
+		batch:                make([]Write, 0, opts.BatchSize),
+		offsets:              make(map[TopicPartition]int64),
+		committed:            make(map[TopicPartition]int64),
+		lag:                  make(map[TopicPartition]tally.Gauge),
+	}, nil
+}
+
+// Start starts consuming in the background.
+func (i *Ingester) Start() error {
+	i.Lock()
+	defer i.Unlock()
+
+	if i.started {
+		return errAlreadyStarted
+	}
+	i.started = true
+
+	go i.consumeLoop()
+	return nil
+}
+
+// Close stops consuming, writing and committing any pending writes, and
+// closes the consumer.
+func (i *Ingester) Close() error {
+	i.Lock()
+	if i.closed {
+		i.Unlock()
+		return nil
+	}
+	i.closed = true
+	started := i.started
+	i.Unlock()
+
+	close(i.closeCh)
+	if started {
+		<-i.doneCh
+	}
+	return i.consumer.Close()
+}
+
+func (i *Ingester) consumeLoop() {
+	defer close(i.doneCh)
+
+	ticker := time.NewTicker(i.opts.FlushInterval)
+	defer ticker.Stop()
+
+	messages := i.consumer.Messages()
+	for {
+		select {
+		case msg, ok := <-messages:
+			if !ok {
+				i.flush()
+				return
+			}
+			i.add(msg)
+			if len(i.batch) >= i.opts.BatchSize {
+				i.flush()
+			}
+		case <-ticker.C:
+			i.flush()
+			i.reportLag()
+		case <-i.closeCh:
+			i.flush()
+			return
+		}
+	}
+}
+
+func (i *Ingester) add(msg Message) {
+	i.metrics.consumed.Inc(1)
+
+	// The offset of malformed messages is still committed since decoding
+	// them will never succeed.
+	i.offsets[msg.TopicPartition] = msg.Offset + 1
+
+	writes, err := i.opts.Decoder.Decode(msg.Value)
+	if err != nil {
+		i.metrics.malformed.Inc(1)
+		i.logger.Error("could not decode kafka message",
+			zap.String("topic", msg.Topic),
+			zap.Int32("partition", msg.Partition),
+			zap.Int64("offset", msg.Offset),
+			zap.Error(err))
+		return
+	}
+
+	i.batch = append(i.batch, writes...)
+}
+
+// flush writes the pending batch, retrying until it succeeds or the ingester
+// is closed, and then commits the offsets of the messages in the batch.
+func (i *Ingester) flush() {
+	if len(i.offsets) == 0 {
+		return
+	}
+
+	for len(i.batch) > 0 {
+		err := i.write()
+		if err == nil {
+			i.metrics.written.Inc(int64(len(i.batch)))
+			i.batch = i.batch[:0]
+			break
+		}
+
+		i.metrics.writeErrors.Inc(1)
+		i.logger.Error("could not write kafka batch, retrying",
+			zap.Int("numWrites", len(i.batch)), zap.Error(err))
+
+		select {
+		case <-i.closeCh:
+			// Do not commit offsets of writes that have not been acknowledged.
+			return
+		case <-time.After(i.opts.RetryInterval):
+		}
+	}
+
+	if err := i.consumer.CommitOffsets(i.offsets); err != nil {
+		// The offsets are committed again with the next batch.
+		i.metrics.commitErrors.Inc(1)
+		i.logger.Error("could not commit kafka offsets", zap.Error(err))
+		return
+	}
+
+	i.metrics.commits.Inc(1)
+	for tp, offset := range i.offsets {
+		i.committed[tp] = offset
+		delete(i.offsets, tp)
+	}
+}
+
+func (i *Ingester) write() error {
+	iter := &writeIter{writes: i.batch, idx: -1}
+	if err := i.downsamplerAndWriter.WriteBatch(context.Background(), iter,
+		i.opts.WriteOptions); err != nil {
+		return err
+	}
+	return nil
+}
+
+// reportLag reports the number of messages produced to each partition that
+// have not been committed.
+func (i *Ingester) reportLag() {
+	for tp, highWaterMark := range i.consumer.HighWaterMarks() {
+		committed, ok := i.committed[tp]
+		if !ok {
+			continue
+		}
+
+		gauge, ok := i.lag[tp]
+		if !ok {
+			gauge = i.scope.Tagged(map[string]string{
+				"topic":     tp.Topic,
+				"partition": strconv.Itoa(int(tp.Partition)),
+			}).Gauge("lag")
+			i.lag[tp] = gauge
+		}
+
+		lag := highWaterMark - committed
+		if lag < 0 {
+			lag = 0
+		}
+		gauge.Update(float64(lag))
+	}
+}
+
+type writeIter struct {
+	writes []Write
+	idx    int
+}
+
+func (i *writeIter) Next() bool {
+	i.idx++
+	return i.idx < len(i.writes)
+}
+
+func (i *writeIter) Current() (models.Tags, ts.Datapoints, xtime.Unit, []byte) {
+	if i.idx < 0 || i.idx >= len(i.writes) {
+		return models.EmptyTags(), nil, 0, nil
+	}
+	w := i.writes[i.idx]
+	return w.Tags, w.Datapoints, xtime.Millisecond, nil
+}
+
+func (i *writeIter) Reset() error {
+	i.idx = -1
+	return nil
+}
+
+func (i *writeIter) Error() error {
+	return nil
+}
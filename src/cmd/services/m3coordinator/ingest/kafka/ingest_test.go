@@ -0,0 +1,237 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestkafka
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testConsumer struct {
+	sync.Mutex
+
+	messages       chan Message
+	commits        []map[TopicPartition]int64
+	commitErr      error
+	highWaterMarks map[TopicPartition]int64
+	closed         bool
+}
+
+func newTestConsumer() *testConsumer {
+	return &testConsumer{messages: make(chan Message)}
+}
+
+func (c *testConsumer) Messages() <-chan Message { return c.messages }
+
+func (c *testConsumer) CommitOffsets(offsets map[TopicPartition]int64) error {
+	c.Lock()
+	defer c.Unlock()
+	if c.commitErr != nil {
+		return c.commitErr
+	}
+	committed := make(map[TopicPartition]int64, len(offsets))
+	for tp, offset := range offsets {
+		committed[tp] = offset
+	}
+	c.commits = append(c.commits, committed)
+	return nil
+}
+
+func (c *testConsumer) HighWaterMarks() map[TopicPartition]int64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.highWaterMarks
+}
+
+func (c *testConsumer) Close() error {
+	c.Lock()
+	defer c.Unlock()
+	c.closed = true
+	return nil
+}
+
+func (c *testConsumer) committedOffsets() []map[TopicPartition]int64 {
+	c.Lock()
+	defer c.Unlock()
+	return c.commits
+}
+
+type testBatchError struct {
+	err error
+}
+
+func (e testBatchError) Error() string    { return e.err.Error() }
+func (e testBatchError) Errors() []error  { return []error{e.err} }
+func (e testBatchError) LastError() error { return e.err }
+
+func newTestIngester(
+	t *testing.T,
+	consumer Consumer,
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	scope tally.Scope,
+) *Ingester {
+	decoder, err := NewDecoder(JSONDecoderType, models.NewTagOptions())
+	require.NoError(t, err)
+
+	ingester, err := NewIngester(consumer, downsamplerAndWriter, Options{
+		InstrumentOptions: instrument.NewOptions().SetMetricsScope(scope),
+		Decoder:           decoder,
+		BatchSize:         2,
+		FlushInterval:     time.Hour,
+		RetryInterval:     time.Millisecond,
+	})
+	require.NoError(t, err)
+	return ingester
+}
+
+func testMessage(partition int32, offset int64, value string) Message {
+	return Message{
+		TopicPartition: TopicPartition{Topic: "metrics", Partition: partition},
+		Offset:         offset,
+		Value:          []byte(value),
+	}
+}
+
+func TestIngesterCommitsAfterWrite(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		consumer             = newTestConsumer()
+		downsamplerAndWriter = ingest.NewMockDownsamplerAndWriter(ctrl)
+		numWritten           int
+		failures             = 1
+	)
+	downsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), ingest.WriteOptions{}).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			if failures > 0 {
+				failures--
+				return testBatchError{err: errors.New("unavailable")}
+			}
+			for iter.Next() {
+				numWritten++
+			}
+			return nil
+		}).Times(2)
+
+	ingester := newTestIngester(t, consumer, downsamplerAndWriter, tally.NoopScope)
+	require.NoError(t, ingester.Start())
+	require.Equal(t, errAlreadyStarted, ingester.Start())
+
+	consumer.messages <- testMessage(0, 10,
+		`{"tags":{"__name__":"cpu"},"timestamp":1000,"value":1}`)
+	consumer.messages <- testMessage(1, 20, `not json`)
+	consumer.messages <- testMessage(1, 21,
+		`{"tags":{"__name__":"mem"},"timestamp":1000,"value":2}`)
+
+	// Wait for the commit since closing stops retrying failed writes.
+	for start := time.Now(); len(consumer.committedOffsets()) == 0; {
+		require.True(t, time.Since(start) < 10*time.Second, "offsets not committed")
+		time.Sleep(time.Millisecond)
+	}
+
+	require.NoError(t, ingester.Close())
+	require.NoError(t, ingester.Close())
+
+	// The batch is written once the batch size is reached, after retrying
+	// the failed write, and the offsets are only committed afterwards.
+	assert.Equal(t, 2, numWritten)
+	assert.Equal(t, []map[TopicPartition]int64{
+		{
+			{Topic: "metrics", Partition: 0}: 11,
+			{Topic: "metrics", Partition: 1}: 22,
+		},
+	}, consumer.committedOffsets())
+	assert.True(t, consumer.closed)
+}
+
+func TestIngesterDoesNotCommitUnacknowledgedWrites(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	consumer := newTestConsumer()
+	downsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	downsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(testBatchError{err: errors.New("unavailable")}).
+		MinTimes(1)
+
+	ingester := newTestIngester(t, consumer, downsamplerAndWriter, tally.NoopScope)
+	require.NoError(t, ingester.Start())
+
+	consumer.messages <- testMessage(0, 10,
+		`{"tags":{"__name__":"cpu"},"timestamp":1000,"value":1}`)
+	consumer.messages <- testMessage(0, 11,
+		`{"tags":{"__name__":"cpu"},"timestamp":2000,"value":1}`)
+
+	require.NoError(t, ingester.Close())
+	assert.Equal(t, 0, len(consumer.committedOffsets()))
+}
+
+func TestIngesterReportsLag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		scope                = tally.NewTestScope("", nil)
+		consumer             = newTestConsumer()
+		downsamplerAndWriter = ingest.NewMockDownsamplerAndWriter(ctrl)
+		tp                   = TopicPartition{Topic: "metrics", Partition: 3}
+		uncommitted          = TopicPartition{Topic: "metrics", Partition: 4}
+	)
+	downsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil)
+
+	ingester := newTestIngester(t, consumer, downsamplerAndWriter, scope)
+	ingester.add(testMessage(3, 40,
+		`{"tags":{"__name__":"cpu"},"timestamp":1000,"value":1}`))
+	ingester.flush()
+
+	consumer.highWaterMarks = map[TopicPartition]int64{
+		tp:          50,
+		uncommitted: 10,
+	}
+	ingester.reportLag()
+
+	gauges := scope.Snapshot().Gauges()
+	require.Equal(t, 1, len(gauges))
+	gauge, ok := gauges["lag+partition=3,topic=metrics"]
+	require.True(t, ok)
+	assert.Equal(t, float64(9), gauge.Value())
+}
@@ -0,0 +1,382 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestotlp
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+	"golang.org/x/net/context"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	metricsServiceName = "opentelemetry.proto.collector.metrics.v1.MetricsService"
+
+	bucketSuffix = "_bucket"
+	sumSuffix    = "_sum"
+	countSuffix  = "_count"
+)
+
+var (
+	errIOptsMustBeSet = errors.New("otlp receiver options: instrument options must be set")
+
+	infBucketValue = []byte("+Inf")
+)
+
+// MetricsServiceServer is the server API for the OTLP metrics service.
+type MetricsServiceServer interface {
+	Export(context.Context, *ExportMetricsServiceRequest) (*ExportMetricsServiceResponse, error)
+}
+
+// RegisterMetricsServiceServer registers the OTLP metrics service with a gRPC server.
+func RegisterMetricsServiceServer(s *grpc.Server, srv MetricsServiceServer) {
+	s.RegisterService(&metricsServiceDesc, srv)
+}
+
+func metricsServiceExportHandler(
+	srv interface{},
+	ctx context.Context,
+	dec func(interface{}) error,
+	interceptor grpc.UnaryServerInterceptor,
+) (interface{}, error) {
+	in := new(ExportMetricsServiceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(MetricsServiceServer).Export(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/" + metricsServiceName + "/Export",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(MetricsServiceServer).Export(ctx, req.(*ExportMetricsServiceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+var metricsServiceDesc = grpc.ServiceDesc{
+	ServiceName: metricsServiceName,
+	HandlerType: (*MetricsServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Export",
+			Handler:    metricsServiceExportHandler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "opentelemetry/proto/collector/metrics/v1/metrics_service.proto",
+}
+
+// Options configures the receiver.
+type Options struct {
+	InstrumentOptions instrument.Options
+	// ResourceAttributes are the resource attributes added as tags.
+	ResourceAttributes []string
+	// AttributeTags maps attribute keys to tag names.
+	AttributeTags map[string]string
+	// DropAttributes are the data point attributes that are dropped.
+	DropAttributes []string
+}
+
+// Validate validates the options struct.
+func (o *Options) Validate() error {
+	if o.InstrumentOptions == nil {
+		return errIOptsMustBeSet
+	}
+
+	return nil
+}
+
+type receiverMetrics struct {
+	success     tally.Counter
+	malformed   tally.Counter
+	writeErrors tally.Counter
+	unsupported tally.Counter
+}
+
+func newReceiverMetrics(scope tally.Scope) receiverMetrics {
+	return receiverMetrics{
+		success:     scope.Counter("success"),
+		malformed:   scope.Counter("malformed"),
+		writeErrors: scope.Counter("write-errors"),
+		unsupported: scope.Counter("unsupported"),
+	}
+}
+
+type receiver struct {
+	downsamplerAndWriter ingest.DownsamplerAndWriter
+	logger               *zap.Logger
+	metrics              receiverMetrics
+	tagOpts              models.TagOptions
+
+	resourceAttributes map[string]struct{}
+	attributeTags      map[string]string
+	dropAttributes     map[string]struct{}
+}
+
+// NewReceiver returns a receiver for OTLP metrics which converts gauges, sums
+// and histograms into tagged series writes.
+func NewReceiver(
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	opts Options,
+) (MetricsServiceServer, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	r := &receiver{
+		downsamplerAndWriter: downsamplerAndWriter,
+		logger:               opts.InstrumentOptions.Logger(),
+		metrics:              newReceiverMetrics(opts.InstrumentOptions.MetricsScope()),
+		tagOpts:              models.NewTagOptions(),
+		resourceAttributes:   make(map[string]struct{}, len(opts.ResourceAttributes)),
+		attributeTags:        make(map[string]string, len(opts.AttributeTags)),
+		dropAttributes:       make(map[string]struct{}, len(opts.DropAttributes)),
+	}
+	for _, key := range opts.ResourceAttributes {
+		r.resourceAttributes[key] = struct{}{}
+	}
+	for key, tag := range opts.AttributeTags {
+		r.attributeTags[key] = sanitizeLabel(tag)
+	}
+	for _, key := range opts.DropAttributes {
+		r.dropAttributes[key] = struct{}{}
+	}
+
+	return r, nil
+}
+
+func (r *receiver) Export(
+	ctx context.Context,
+	req *ExportMetricsServiceRequest,
+) (*ExportMetricsServiceResponse, error) {
+	iter := &seriesIter{series: r.convert(req), idx: -1}
+	if len(iter.series) == 0 {
+		return &ExportMetricsServiceResponse{}, nil
+	}
+
+	batchErr := r.downsamplerAndWriter.WriteBatch(ctx, iter, ingest.WriteOptions{})
+	if batchErr == nil {
+		r.metrics.success.Inc(int64(len(iter.series)))
+		return &ExportMetricsServiceResponse{}, nil
+	}
+
+	errs := batchErr.Errors()
+	r.metrics.writeErrors.Inc(int64(len(errs)))
+
+	lastErr := batchErr.LastError()
+	r.logger.Error("otlp write error", zap.Int("numErrors", len(errs)),
+		zap.Error(lastErr))
+	if xerrors.IsInvalidParams(lastErr) {
+		return nil, grpc.Errorf(codes.InvalidArgument, "%v", lastErr)
+	}
+	return nil, grpc.Errorf(codes.Unavailable, "%v", lastErr)
+}
+
+type series struct {
+	tags       models.Tags
+	datapoints ts.Datapoints
+}
+
+func (r *receiver) convert(req *ExportMetricsServiceRequest) []series {
+	var result []series
+	for _, rm := range req.ResourceMetrics {
+		resourceTags := r.tags(rm.Resource.Attributes, true)
+		for _, metric := range rm.Metrics {
+			name := sanitizeName(metric.Name)
+			if name == "" {
+				r.metrics.malformed.Inc(1)
+				continue
+			}
+
+			switch metric.Type {
+			case MetricTypeGauge, MetricTypeSum:
+				for _, dp := range metric.NumberDataPoints {
+					tags := r.seriesTags(resourceTags, dp.Attributes, name)
+					result = append(result, newSeries(tags, dp.TimeUnixNano, dp.Value))
+				}
+			case MetricTypeHistogram:
+				for _, dp := range metric.HistogramDataPoints {
+					result = r.appendHistogram(result, resourceTags, name, dp)
+				}
+			default:
+				r.metrics.unsupported.Inc(1)
+			}
+		}
+	}
+	return result
+}
+
+// appendHistogram expands a histogram data point into cumulative bucket
+// series, a sum series and a count series.
+func (r *receiver) appendHistogram(
+	result []series,
+	resourceTags []models.Tag,
+	name string,
+	dp HistogramDataPoint,
+) []series {
+	if len(dp.BucketCounts) > 0 && len(dp.BucketCounts) != len(dp.ExplicitBounds)+1 {
+		r.metrics.malformed.Inc(1)
+		return result
+	}
+
+	var cumulative uint64
+	for i, count := range dp.BucketCounts {
+		cumulative += count
+		le := infBucketValue
+		if i < len(dp.ExplicitBounds) {
+			le = []byte(strconv.FormatFloat(dp.ExplicitBounds[i], 'g', -1, 64))
+		}
+		tags := r.seriesTags(resourceTags, dp.Attributes, name+bucketSuffix).
+			SetBucket(le)
+		result = append(result, newSeries(tags, dp.TimeUnixNano, float64(cumulative)))
+	}
+
+	sumTags := r.seriesTags(resourceTags, dp.Attributes, name+sumSuffix)
+	countTags := r.seriesTags(resourceTags, dp.Attributes, name+countSuffix)
+	return append(result,
+		newSeries(sumTags, dp.TimeUnixNano, dp.Sum),
+		newSeries(countTags, dp.TimeUnixNano, float64(dp.Count)))
+}
+
+func (r *receiver) seriesTags(
+	resourceTags []models.Tag,
+	attrs []KeyValue,
+	name string,
+) models.Tags {
+	tags := models.NewTags(len(resourceTags)+len(attrs)+1, r.tagOpts)
+	for _, tag := range resourceTags {
+		tags = tags.AddOrUpdateTag(tag)
+	}
+	// Data point attributes take precedence over resource attributes.
+	for _, tag := range r.tags(attrs, false) {
+		tags = tags.AddOrUpdateTag(tag)
+	}
+	return tags.SetName([]byte(name))
+}
+
+func (r *receiver) tags(attrs []KeyValue, resource bool) []models.Tag {
+	tags := make([]models.Tag, 0, len(attrs))
+	for _, attr := range attrs {
+		if resource {
+			if _, ok := r.resourceAttributes[attr.Key]; !ok {
+				continue
+			}
+		} else if _, ok := r.dropAttributes[attr.Key]; ok {
+			continue
+		}
+		if attr.Value == "" {
+			continue
+		}
+
+		name, ok := r.attributeTags[attr.Key]
+		if !ok {
+			name = sanitizeLabel(attr.Key)
+		}
+		if name == "" {
+			continue
+		}
+		tags = append(tags, models.Tag{Name: []byte(name), Value: []byte(attr.Value)})
+	}
+	return tags
+}
+
+func newSeries(tags models.Tags, timeUnixNano uint64, value float64) series {
+	return series{
+		tags: tags,
+		datapoints: ts.Datapoints{{
+			Timestamp: time.Unix(0, int64(timeUnixNano)),
+			Value:     value,
+		}},
+	}
+}
+
+// sanitizeName converts an OTLP metric name to a valid Prometheus metric name.
+func sanitizeName(name string) string {
+	return sanitize(name, true)
+}
+
+// sanitizeLabel converts an OTLP attribute key to a valid Prometheus label name.
+func sanitizeLabel(name string) string {
+	return sanitize(name, false)
+}
+
+func sanitize(name string, allowColon bool) string {
+	if name == "" {
+		return ""
+	}
+	b := []byte(name)
+	for i, c := range b {
+		valid := c == '_' ||
+			(c >= 'a' && c <= 'z') ||
+			(c >= 'A' && c <= 'Z') ||
+			(c == ':' && allowColon) ||
+			(c >= '0' && c <= '9' && i > 0)
+		if !valid {
+			b[i] = '_'
+		}
+	}
+	if name[0] >= '0' && name[0] <= '9' {
+		return "_" + name[0:1] + string(b[1:])
+	}
+	return string(b)
+}
+
+type seriesIter struct {
+	series []series
+	idx    int
+}
+
+func (i *seriesIter) Next() bool {
+	i.idx++
+	return i.idx < len(i.series)
+}
+
+func (i *seriesIter) Current() (models.Tags, ts.Datapoints, xtime.Unit, []byte) {
+	if i.idx < 0 || i.idx >= len(i.series) {
+		return models.EmptyTags(), nil, 0, nil
+	}
+	s := i.series[i.idx]
+	return s.tags, s.datapoints, xtime.Nanosecond, nil
+}
+
+func (i *seriesIter) Reset() error {
+	i.idx = -1
+	return nil
+}
+
+func (i *seriesIter) Error() error {
+	return nil
+}
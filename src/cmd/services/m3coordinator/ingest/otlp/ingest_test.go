@@ -0,0 +1,227 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestotlp
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/query/models"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+type testSeries struct {
+	id    string
+	time  time.Time
+	value float64
+}
+
+func newTestReceiver(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	opts Options,
+) (MetricsServiceServer, *[]testSeries, *ingest.MockDownsamplerAndWriter) {
+	var (
+		written              []testSeries
+		downsamplerAndWriter = ingest.NewMockDownsamplerAndWriter(ctrl)
+	)
+	downsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), ingest.WriteOptions{}).
+		DoAndReturn(func(
+			_ context.Context,
+			iter ingest.DownsampleAndWriteIter,
+			_ ingest.WriteOptions,
+		) ingest.BatchError {
+			for iter.Next() {
+				tags, dps, unit, annotation := iter.Current()
+				require.Equal(t, xtime.Nanosecond, unit)
+				require.Nil(t, annotation)
+				require.Equal(t, 1, len(dps))
+				written = append(written, testSeries{
+					id:    string(tags.ID()),
+					time:  dps[0].Timestamp,
+					value: dps[0].Value,
+				})
+			}
+			return nil
+		}).AnyTimes()
+
+	opts.InstrumentOptions = instrument.NewOptions()
+	receiver, err := NewReceiver(downsamplerAndWriter, opts)
+	require.NoError(t, err)
+	return receiver, &written, downsamplerAndWriter
+}
+
+func unmarshalTestRequest(t *testing.T, data []byte) *ExportMetricsServiceRequest {
+	var req ExportMetricsServiceRequest
+	require.NoError(t, req.Unmarshal(data))
+	return &req
+}
+
+func testID(tags ...string) string {
+	t := models.NewTags(len(tags)/2, models.NewTagOptions())
+	for i := 0; i < len(tags); i += 2 {
+		t = t.AddTag(models.Tag{Name: []byte(tags[i]), Value: []byte(tags[i+1])})
+	}
+	return string(t.ID())
+}
+
+func TestReceiverGaugeAndSum(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	receiver, written, _ := newTestReceiver(t, ctrl, Options{
+		ResourceAttributes: []string{"service.name"},
+		AttributeTags:      map[string]string{"host.name": "instance"},
+		DropAttributes:     []string{"pid"},
+	})
+
+	req := unmarshalTestRequest(t, testRequest(
+		[]*testEncoder{
+			testStringAttr("service.name", "api"),
+			testStringAttr("telemetry.sdk", "go"),
+		},
+		testMetric("system.cpu.time", 5, testNumberDataPoint(1000, 1.5,
+			testStringAttr("host.name", "a"),
+			testStringAttr("pid", "12"),
+			testStringAttr("cpu.state", "user"))),
+		testMetric("requests", 7, testNumberDataPoint(2000, 7)),
+	))
+
+	_, err := receiver.Export(context.Background(), req)
+	require.NoError(t, err)
+
+	assert.Equal(t, []testSeries{
+		{
+			id: testID("__name__", "system_cpu_time", "cpu_state", "user",
+				"instance", "a", "service_name", "api"),
+			time:  time.Unix(0, 1000),
+			value: 1.5,
+		},
+		{
+			id:    testID("__name__", "requests", "service_name", "api"),
+			time:  time.Unix(0, 2000),
+			value: 7,
+		},
+	}, *written)
+}
+
+func TestReceiverHistogram(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	receiver, written, _ := newTestReceiver(t, ctrl, Options{})
+
+	dp := (&testEncoder{}).
+		fixed64(3, 1000).
+		fixed64(4, 6).
+		double(5, 12.5).
+		packedFixed64s(6, []uint64{1, 2, 3}).
+		packedFixed64s(7, []uint64{0x3fe0000000000000, 0x3ff0000000000000}).
+		message(9, testStringAttr("code", "200"))
+	req := unmarshalTestRequest(t, testRequest(nil, testMetric("latency", 9, dp)))
+
+	_, err := receiver.Export(context.Background(), req)
+	require.NoError(t, err)
+
+	ts := time.Unix(0, 1000)
+	assert.Equal(t, []testSeries{
+		{id: testID("__name__", "latency_bucket", "code", "200", "le", "0.5"), time: ts, value: 1},
+		{id: testID("__name__", "latency_bucket", "code", "200", "le", "1"), time: ts, value: 3},
+		{id: testID("__name__", "latency_bucket", "code", "200", "le", "+Inf"), time: ts, value: 6},
+		{id: testID("__name__", "latency_sum", "code", "200"), time: ts, value: 12.5},
+		{id: testID("__name__", "latency_count", "code", "200"), time: ts, value: 6},
+	}, *written)
+}
+
+func TestReceiverSkipsMalformedHistogram(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	receiver, written, _ := newTestReceiver(t, ctrl, Options{})
+
+	dp := (&testEncoder{}).
+		fixed64(3, 1000).
+		packedFixed64s(6, []uint64{1, 2, 3}).
+		packedFixed64s(7, []uint64{0x3fe0000000000000})
+	req := unmarshalTestRequest(t, testRequest(nil, testMetric("latency", 9, dp)))
+
+	_, err := receiver.Export(context.Background(), req)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(*written))
+}
+
+type testBatchError struct {
+	errs []error
+}
+
+func (e testBatchError) Error() string    { return e.LastError().Error() }
+func (e testBatchError) Errors() []error  { return e.errs }
+func (e testBatchError) LastError() error { return e.errs[len(e.errs)-1] }
+
+func TestReceiverWriteError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	downsamplerAndWriter := ingest.NewMockDownsamplerAndWriter(ctrl)
+	receiver, err := NewReceiver(downsamplerAndWriter, Options{
+		InstrumentOptions: instrument.NewOptions(),
+	})
+	require.NoError(t, err)
+
+	req := unmarshalTestRequest(t, testRequest(nil,
+		testMetric("m", 5, testNumberDataPoint(1000, 1))))
+
+	downsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(testBatchError{errs: []error{errors.New("unavailable")}})
+	_, err = receiver.Export(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, codes.Unavailable, grpc.Code(err))
+
+	downsamplerAndWriter.EXPECT().
+		WriteBatch(gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(testBatchError{errs: []error{
+			xerrors.NewInvalidParamsError(errors.New("bad tags")),
+		}})
+	_, err = receiver.Export(context.Background(), req)
+	require.Error(t, err)
+	assert.Equal(t, codes.InvalidArgument, grpc.Code(err))
+}
+
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "http_server_duration", sanitizeName("http.server.duration"))
+	assert.Equal(t, "job:requests", sanitizeName("job:requests"))
+	assert.Equal(t, "job_requests", sanitizeLabel("job:requests"))
+	assert.Equal(t, "_2xx", sanitizeLabel("2xx"))
+	assert.Equal(t, "", sanitizeLabel(""))
+}
@@ -0,0 +1,644 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestotlp
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// This file decodes the subset of the OTLP metrics protocol, as defined by
+// opentelemetry/proto/collector/metrics/v1/metrics_service.proto, that is
+// required to ingest gauges, sums and histograms. Fields that are not needed
+// are skipped rather than decoded.
+
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+var errTruncated = errors.New("otlp: truncated message")
+
+// MetricType is the type of an OTLP metric.
+type MetricType int
+
+const (
+	// MetricTypeUnsupported is a metric of a type that is not ingested.
+	MetricTypeUnsupported MetricType = iota
+	// MetricTypeGauge is a gauge metric.
+	MetricTypeGauge
+	// MetricTypeSum is a sum metric.
+	MetricTypeSum
+	// MetricTypeHistogram is an explicit bucket histogram metric.
+	MetricTypeHistogram
+)
+
+// AggregationTemporality is the temporality of a sum or histogram metric.
+type AggregationTemporality int
+
+const (
+	// AggregationTemporalityUnspecified is an unspecified temporality.
+	AggregationTemporalityUnspecified AggregationTemporality = iota
+	// AggregationTemporalityDelta is the delta temporality.
+	AggregationTemporalityDelta
+	// AggregationTemporalityCumulative is the cumulative temporality.
+	AggregationTemporalityCumulative
+)
+
+// ExportMetricsServiceRequest is the request of the metrics service Export method.
+type ExportMetricsServiceRequest struct {
+	ResourceMetrics []ResourceMetrics
+}
+
+// ResourceMetrics is a collection of metrics from a resource.
+type ResourceMetrics struct {
+	Resource Resource
+	Metrics  []Metric
+}
+
+// Resource is the entity producing metrics.
+type Resource struct {
+	Attributes []KeyValue
+}
+
+// Metric is a single OTLP metric and its data points.
+type Metric struct {
+	Name                   string
+	Type                   MetricType
+	AggregationTemporality AggregationTemporality
+	IsMonotonic            bool
+	NumberDataPoints       []NumberDataPoint
+	HistogramDataPoints    []HistogramDataPoint
+}
+
+// NumberDataPoint is a data point of a gauge or sum metric.
+type NumberDataPoint struct {
+	Attributes   []KeyValue
+	TimeUnixNano uint64
+	Value        float64
+}
+
+// HistogramDataPoint is a data point of an explicit bucket histogram metric.
+type HistogramDataPoint struct {
+	Attributes     []KeyValue
+	TimeUnixNano   uint64
+	Count          uint64
+	Sum            float64
+	BucketCounts   []uint64
+	ExplicitBounds []float64
+}
+
+// KeyValue is an attribute, with the value converted to its string form.
+type KeyValue struct {
+	Key   string
+	Value string
+}
+
+// Reset resets the request.
+func (m *ExportMetricsServiceRequest) Reset() { *m = ExportMetricsServiceRequest{} }
+
+// String returns a description of the request.
+func (m *ExportMetricsServiceRequest) String() string {
+	return fmt.Sprintf("ExportMetricsServiceRequest{ResourceMetrics: %d}", len(m.ResourceMetrics))
+}
+
+// ProtoMessage marks the request as a protobuf message.
+func (*ExportMetricsServiceRequest) ProtoMessage() {}
+
+// Unmarshal decodes the request from its protobuf encoding.
+func (m *ExportMetricsServiceRequest) Unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		if field != 1 {
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := d.message(wireType)
+		if err != nil {
+			return err
+		}
+		var rm ResourceMetrics
+		if err := rm.unmarshal(b); err != nil {
+			return err
+		}
+		m.ResourceMetrics = append(m.ResourceMetrics, rm)
+	}
+	return nil
+}
+
+// ExportMetricsServiceResponse is the response of the metrics service Export method.
+type ExportMetricsServiceResponse struct{}
+
+// Reset resets the response.
+func (m *ExportMetricsServiceResponse) Reset() { *m = ExportMetricsServiceResponse{} }
+
+// String returns a description of the response.
+func (m *ExportMetricsServiceResponse) String() string { return "ExportMetricsServiceResponse{}" }
+
+// ProtoMessage marks the response as a protobuf message.
+func (*ExportMetricsServiceResponse) ProtoMessage() {}
+
+// Marshal encodes the response, which has no fields that are ever set.
+func (m *ExportMetricsServiceResponse) Marshal() ([]byte, error) { return []byte{}, nil }
+
+// Unmarshal decodes the response from its protobuf encoding.
+func (m *ExportMetricsServiceResponse) Unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		_, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		if err := d.skip(wireType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *ResourceMetrics) unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			b, err := d.message(wireType)
+			if err != nil {
+				return err
+			}
+			if err := m.Resource.unmarshal(b); err != nil {
+				return err
+			}
+		case 2, 1000:
+			// Field 1000 is the deprecated instrumentation library metrics
+			// which shares its layout with the scope metrics.
+			b, err := d.message(wireType)
+			if err != nil {
+				return err
+			}
+			if err := m.unmarshalScopeMetrics(b); err != nil {
+				return err
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *ResourceMetrics) unmarshalScopeMetrics(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		if field != 2 {
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		b, err := d.message(wireType)
+		if err != nil {
+			return err
+		}
+		var metric Metric
+		if err := metric.unmarshal(b); err != nil {
+			return err
+		}
+		m.Metrics = append(m.Metrics, metric)
+	}
+	return nil
+}
+
+func (m *Resource) unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		if field != 1 {
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+			continue
+		}
+		if m.Attributes, err = d.appendKeyValue(m.Attributes, wireType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *Metric) unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			b, err := d.message(wireType)
+			if err != nil {
+				return err
+			}
+			m.Name = string(b)
+		case 5:
+			m.Type = MetricTypeGauge
+			if err := m.unmarshalData(&d, wireType); err != nil {
+				return err
+			}
+		case 7:
+			m.Type = MetricTypeSum
+			if err := m.unmarshalData(&d, wireType); err != nil {
+				return err
+			}
+		case 9:
+			m.Type = MetricTypeHistogram
+			if err := m.unmarshalData(&d, wireType); err != nil {
+				return err
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// unmarshalData decodes the gauge, sum or histogram message of a metric,
+// which share the layout of their data points, temporality and monotonicity.
+func (m *Metric) unmarshalData(parent *decoder, wireType int) error {
+	data, err := parent.message(wireType)
+	if err != nil {
+		return err
+	}
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 1:
+			b, err := d.message(wireType)
+			if err != nil {
+				return err
+			}
+			if m.Type == MetricTypeHistogram {
+				var dp HistogramDataPoint
+				if err := dp.unmarshal(b); err != nil {
+					return err
+				}
+				m.HistogramDataPoints = append(m.HistogramDataPoints, dp)
+				continue
+			}
+			var dp NumberDataPoint
+			if err := dp.unmarshal(b); err != nil {
+				return err
+			}
+			m.NumberDataPoints = append(m.NumberDataPoints, dp)
+		case 2:
+			v, err := d.varintField(wireType)
+			if err != nil {
+				return err
+			}
+			m.AggregationTemporality = AggregationTemporality(v)
+		case 3:
+			v, err := d.varintField(wireType)
+			if err != nil {
+				return err
+			}
+			m.IsMonotonic = v != 0
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *NumberDataPoint) unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 3:
+			if m.TimeUnixNano, err = d.fixed64Field(wireType); err != nil {
+				return err
+			}
+		case 4:
+			v, err := d.fixed64Field(wireType)
+			if err != nil {
+				return err
+			}
+			m.Value = math.Float64frombits(v)
+		case 6:
+			v, err := d.fixed64Field(wireType)
+			if err != nil {
+				return err
+			}
+			m.Value = float64(int64(v))
+		case 7:
+			if m.Attributes, err = d.appendKeyValue(m.Attributes, wireType); err != nil {
+				return err
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (m *HistogramDataPoint) unmarshal(data []byte) error {
+	d := decoder{buf: data}
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return err
+		}
+		switch field {
+		case 3:
+			if m.TimeUnixNano, err = d.fixed64Field(wireType); err != nil {
+				return err
+			}
+		case 4:
+			if m.Count, err = d.fixed64Field(wireType); err != nil {
+				return err
+			}
+		case 5:
+			v, err := d.fixed64Field(wireType)
+			if err != nil {
+				return err
+			}
+			m.Sum = math.Float64frombits(v)
+		case 6:
+			if m.BucketCounts, err = d.appendFixed64s(m.BucketCounts, wireType); err != nil {
+				return err
+			}
+		case 7:
+			bounds, err := d.appendFixed64s(nil, wireType)
+			if err != nil {
+				return err
+			}
+			for _, b := range bounds {
+				m.ExplicitBounds = append(m.ExplicitBounds, math.Float64frombits(b))
+			}
+		case 9:
+			if m.Attributes, err = d.appendKeyValue(m.Attributes, wireType); err != nil {
+				return err
+			}
+		default:
+			if err := d.skip(wireType); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+type decoder struct {
+	buf []byte
+	idx int
+}
+
+func (d *decoder) done() bool {
+	return d.idx >= len(d.buf)
+}
+
+func (d *decoder) key() (int, int, error) {
+	v, err := d.varint()
+	if err != nil {
+		return 0, 0, err
+	}
+	return int(v >> 3), int(v & 0x7), nil
+}
+
+func (d *decoder) varint() (uint64, error) {
+	v, n := binary.Uvarint(d.buf[d.idx:])
+	if n <= 0 {
+		return 0, errTruncated
+	}
+	d.idx += n
+	return v, nil
+}
+
+func (d *decoder) fixed64() (uint64, error) {
+	if len(d.buf)-d.idx < 8 {
+		return 0, errTruncated
+	}
+	v := binary.LittleEndian.Uint64(d.buf[d.idx:])
+	d.idx += 8
+	return v, nil
+}
+
+func (d *decoder) bytes() ([]byte, error) {
+	l, err := d.varint()
+	if err != nil {
+		return nil, err
+	}
+	if uint64(len(d.buf)-d.idx) < l {
+		return nil, errTruncated
+	}
+	b := d.buf[d.idx : d.idx+int(l)]
+	d.idx += int(l)
+	return b, nil
+}
+
+func (d *decoder) message(wireType int) ([]byte, error) {
+	if wireType != wireBytes {
+		return nil, fmt.Errorf("otlp: wrong wire type %d for length delimited field", wireType)
+	}
+	return d.bytes()
+}
+
+func (d *decoder) varintField(wireType int) (uint64, error) {
+	if wireType != wireVarint {
+		return 0, fmt.Errorf("otlp: wrong wire type %d for varint field", wireType)
+	}
+	return d.varint()
+}
+
+func (d *decoder) fixed64Field(wireType int) (uint64, error) {
+	if wireType != wireFixed64 {
+		return 0, fmt.Errorf("otlp: wrong wire type %d for fixed64 field", wireType)
+	}
+	return d.fixed64()
+}
+
+// appendFixed64s decodes a repeated fixed64 or double field, which is either
+// packed or encoded as individual elements.
+func (d *decoder) appendFixed64s(values []uint64, wireType int) ([]uint64, error) {
+	if wireType == wireFixed64 {
+		v, err := d.fixed64()
+		if err != nil {
+			return nil, err
+		}
+		return append(values, v), nil
+	}
+	b, err := d.message(wireType)
+	if err != nil {
+		return nil, err
+	}
+	if len(b)%8 != 0 {
+		return nil, errTruncated
+	}
+	for i := 0; i < len(b); i += 8 {
+		values = append(values, binary.LittleEndian.Uint64(b[i:]))
+	}
+	return values, nil
+}
+
+// appendKeyValue decodes an attribute, skipping attributes with array or
+// key value list values which have no sensible tag representation.
+func (d *decoder) appendKeyValue(attrs []KeyValue, wireType int) ([]KeyValue, error) {
+	data, err := d.message(wireType)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		kv       KeyValue
+		hasValue bool
+		kd       = decoder{buf: data}
+	)
+	for !kd.done() {
+		field, wireType, err := kd.key()
+		if err != nil {
+			return nil, err
+		}
+		switch field {
+		case 1:
+			b, err := kd.message(wireType)
+			if err != nil {
+				return nil, err
+			}
+			kv.Key = string(b)
+		case 2:
+			b, err := kd.message(wireType)
+			if err != nil {
+				return nil, err
+			}
+			if kv.Value, hasValue, err = anyValueString(b); err != nil {
+				return nil, err
+			}
+		default:
+			if err := kd.skip(wireType); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if !hasValue {
+		return attrs, nil
+	}
+	return append(attrs, kv), nil
+}
+
+func anyValueString(data []byte) (string, bool, error) {
+	var (
+		value    string
+		hasValue bool
+		d        = decoder{buf: data}
+	)
+	for !d.done() {
+		field, wireType, err := d.key()
+		if err != nil {
+			return "", false, err
+		}
+		switch field {
+		case 1, 7:
+			b, err := d.message(wireType)
+			if err != nil {
+				return "", false, err
+			}
+			value, hasValue = string(b), true
+		case 2:
+			v, err := d.varintField(wireType)
+			if err != nil {
+				return "", false, err
+			}
+			value, hasValue = strconv.FormatBool(v != 0), true
+		case 3:
+			v, err := d.varintField(wireType)
+			if err != nil {
+				return "", false, err
+			}
+			value, hasValue = strconv.FormatInt(int64(v), 10), true
+		case 4:
+			v, err := d.fixed64Field(wireType)
+			if err != nil {
+				return "", false, err
+			}
+			value, hasValue = strconv.FormatFloat(math.Float64frombits(v), 'g', -1, 64), true
+		default:
+			if err := d.skip(wireType); err != nil {
+				return "", false, err
+			}
+		}
+	}
+	return value, hasValue, nil
+}
+
+func (d *decoder) skip(wireType int) error {
+	switch wireType {
+	case wireVarint:
+		_, err := d.varint()
+		return err
+	case wireFixed64:
+		_, err := d.fixed64()
+		return err
+	case wireBytes:
+		_, err := d.bytes()
+		return err
+	case wireFixed32:
+		if len(d.buf)-d.idx < 4 {
+			return errTruncated
+		}
+		d.idx += 4
+		return nil
+	}
+	return fmt.Errorf("otlp: unsupported wire type %d", wireType)
+}
@@ -0,0 +1,232 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingestotlp
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testEncoder encodes OTLP messages for tests.
+type testEncoder struct {
+	buf []byte
+}
+
+func (e *testEncoder) key(field, wireType int) {
+	e.varint(uint64(field<<3 | wireType))
+}
+
+func (e *testEncoder) varint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(b[:], v)
+	e.buf = append(e.buf, b[:n]...)
+}
+
+func (e *testEncoder) fixed64(field int, v uint64) *testEncoder {
+	e.key(field, wireFixed64)
+	var b [8]byte
+	binary.LittleEndian.PutUint64(b[:], v)
+	e.buf = append(e.buf, b[:]...)
+	return e
+}
+
+func (e *testEncoder) double(field int, v float64) *testEncoder {
+	return e.fixed64(field, math.Float64bits(v))
+}
+
+func (e *testEncoder) varintField(field int, v uint64) *testEncoder {
+	e.key(field, wireVarint)
+	e.varint(v)
+	return e
+}
+
+func (e *testEncoder) bytes(field int, b []byte) *testEncoder {
+	e.key(field, wireBytes)
+	e.varint(uint64(len(b)))
+	e.buf = append(e.buf, b...)
+	return e
+}
+
+func (e *testEncoder) message(field int, m *testEncoder) *testEncoder {
+	return e.bytes(field, m.buf)
+}
+
+func (e *testEncoder) packedFixed64s(field int, values []uint64) *testEncoder {
+	var b []byte
+	for _, v := range values {
+		var vb [8]byte
+		binary.LittleEndian.PutUint64(vb[:], v)
+		b = append(b, vb[:]...)
+	}
+	return e.bytes(field, b)
+}
+
+func testStringAttr(key, value string) *testEncoder {
+	anyValue := (&testEncoder{}).bytes(1, []byte(value))
+	return (&testEncoder{}).bytes(1, []byte(key)).message(2, anyValue)
+}
+
+func testNumberDataPoint(timeUnixNano uint64, value float64, attrs ...*testEncoder) *testEncoder {
+	dp := (&testEncoder{}).fixed64(3, timeUnixNano).double(4, value)
+	for _, attr := range attrs {
+		dp.message(7, attr)
+	}
+	return dp
+}
+
+func testMetric(name string, dataField int, dps ...*testEncoder) *testEncoder {
+	data := &testEncoder{}
+	for _, dp := range dps {
+		data.message(1, dp)
+	}
+	return (&testEncoder{}).bytes(1, []byte(name)).message(dataField, data)
+}
+
+func testRequest(resourceAttrs []*testEncoder, metrics ...*testEncoder) []byte {
+	resource := &testEncoder{}
+	for _, attr := range resourceAttrs {
+		resource.message(1, attr)
+	}
+	scope := &testEncoder{}
+	for _, metric := range metrics {
+		scope.message(2, metric)
+	}
+	rm := (&testEncoder{}).message(1, resource).message(2, scope)
+	return (&testEncoder{}).message(1, rm).buf
+}
+
+func TestUnmarshalGaugeAndSum(t *testing.T) {
+	sumData := (&testEncoder{}).
+		message(1, testNumberDataPoint(2000, 7)).
+		varintField(2, uint64(AggregationTemporalityCumulative)).
+		varintField(3, 1)
+	intValue := int64(-4)
+	intDataPoint := (&testEncoder{}).fixed64(3, 3000).fixed64(6, uint64(intValue))
+
+	data := testRequest(
+		[]*testEncoder{testStringAttr("service.name", "api")},
+		testMetric("cpu", 5, testNumberDataPoint(1000, 1.5, testStringAttr("host", "a"))),
+		(&testEncoder{}).bytes(1, []byte("requests")).bytes(3, []byte("1")).message(7, sumData),
+		testMetric("temp", 5, intDataPoint),
+	)
+
+	var req ExportMetricsServiceRequest
+	require.NoError(t, req.Unmarshal(data))
+	require.Equal(t, 1, len(req.ResourceMetrics))
+
+	rm := req.ResourceMetrics[0]
+	assert.Equal(t, []KeyValue{{Key: "service.name", Value: "api"}}, rm.Resource.Attributes)
+	require.Equal(t, 3, len(rm.Metrics))
+
+	assert.Equal(t, Metric{
+		Name: "cpu",
+		Type: MetricTypeGauge,
+		NumberDataPoints: []NumberDataPoint{{
+			Attributes:   []KeyValue{{Key: "host", Value: "a"}},
+			TimeUnixNano: 1000,
+			Value:        1.5,
+		}},
+	}, rm.Metrics[0])
+
+	assert.Equal(t, Metric{
+		Name:                   "requests",
+		Type:                   MetricTypeSum,
+		AggregationTemporality: AggregationTemporalityCumulative,
+		IsMonotonic:            true,
+		NumberDataPoints:       []NumberDataPoint{{TimeUnixNano: 2000, Value: 7}},
+	}, rm.Metrics[1])
+
+	assert.Equal(t, []NumberDataPoint{{TimeUnixNano: 3000, Value: -4}},
+		rm.Metrics[2].NumberDataPoints)
+}
+
+func TestUnmarshalHistogram(t *testing.T) {
+	dp := (&testEncoder{}).
+		fixed64(3, 1000).
+		fixed64(4, 6).
+		double(5, 12.5).
+		packedFixed64s(6, []uint64{1, 2, 3}).
+		packedFixed64s(7, []uint64{math.Float64bits(0.5), math.Float64bits(1)}).
+		message(9, testStringAttr("code", "200"))
+
+	var req ExportMetricsServiceRequest
+	require.NoError(t, req.Unmarshal(testRequest(nil, testMetric("latency", 9, dp))))
+	require.Equal(t, 1, len(req.ResourceMetrics))
+	require.Equal(t, 1, len(req.ResourceMetrics[0].Metrics))
+
+	metric := req.ResourceMetrics[0].Metrics[0]
+	assert.Equal(t, MetricTypeHistogram, metric.Type)
+	assert.Equal(t, []HistogramDataPoint{{
+		Attributes:     []KeyValue{{Key: "code", Value: "200"}},
+		TimeUnixNano:   1000,
+		Count:          6,
+		Sum:            12.5,
+		BucketCounts:   []uint64{1, 2, 3},
+		ExplicitBounds: []float64{0.5, 1},
+	}}, metric.HistogramDataPoints)
+}
+
+func TestUnmarshalAttributeValues(t *testing.T) {
+	boolValue := (&testEncoder{}).varintField(2, 1)
+	intValue := (&testEncoder{}).varintField(3, 42)
+	doubleValue := (&testEncoder{}).double(4, 0.25)
+	arrayValue := (&testEncoder{}).bytes(5, nil)
+
+	dp := testNumberDataPoint(1000, 1,
+		(&testEncoder{}).bytes(1, []byte("b")).message(2, boolValue),
+		(&testEncoder{}).bytes(1, []byte("i")).message(2, intValue),
+		(&testEncoder{}).bytes(1, []byte("d")).message(2, doubleValue),
+		(&testEncoder{}).bytes(1, []byte("a")).message(2, arrayValue))
+
+	var req ExportMetricsServiceRequest
+	require.NoError(t, req.Unmarshal(testRequest(nil, testMetric("m", 5, dp))))
+
+	attrs := req.ResourceMetrics[0].Metrics[0].NumberDataPoints[0].Attributes
+	assert.Equal(t, []KeyValue{
+		{Key: "b", Value: "true"},
+		{Key: "i", Value: "42"},
+		{Key: "d", Value: "0.25"},
+	}, attrs)
+}
+
+func TestUnmarshalSkipsUnknownFields(t *testing.T) {
+	metric := testMetric("m", 5, testNumberDataPoint(1000, 1)).
+		varintField(20, 5).
+		bytes(21, []byte("unknown"))
+	metric.key(22, wireFixed32)
+	metric.buf = append(metric.buf, 0, 0, 0, 0)
+
+	var req ExportMetricsServiceRequest
+	require.NoError(t, req.Unmarshal(testRequest(nil, metric)))
+	assert.Equal(t, "m", req.ResourceMetrics[0].Metrics[0].Name)
+	assert.Equal(t, 1, len(req.ResourceMetrics[0].Metrics[0].NumberDataPoints))
+}
+
+func TestUnmarshalTruncated(t *testing.T) {
+	data := testRequest(nil, testMetric("m", 5, testNumberDataPoint(1000, 1)))
+
+	var req ExportMetricsServiceRequest
+	require.Error(t, req.Unmarshal(data[:len(data)-3]))
+}
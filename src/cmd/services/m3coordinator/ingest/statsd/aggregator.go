@@ -0,0 +1,216 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingeststatsd
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// aggregatedMetric is a metric computed when the aggregation window is flushed.
+type aggregatedMetric struct {
+	name  []byte
+	value float64
+}
+
+type timerValues struct {
+	values []float64
+	// count is the number of timings scaled by their sample rate.
+	count float64
+}
+
+type gaugeValue struct {
+	value   float64
+	updated bool
+}
+
+// aggregator aggregates statsd metrics over a window, the same way statsd
+// does before flushing them to graphite.
+type aggregator struct {
+	sync.Mutex
+
+	counterPrefix string
+	timerPrefix   string
+	gaugePrefix   string
+	setPrefix     string
+	percentiles   []float64
+
+	counters map[string]float64
+	timers   map[string]*timerValues
+	gauges   map[string]*gaugeValue
+	sets     map[string]map[string]struct{}
+}
+
+func newAggregator(prefix string, percentiles []float64) *aggregator {
+	if prefix != "" {
+		prefix += "."
+	}
+	return &aggregator{
+		counterPrefix: prefix + "counters.",
+		timerPrefix:   prefix + "timers.",
+		gaugePrefix:   prefix + "gauges.",
+		setPrefix:     prefix + "sets.",
+		percentiles:   percentiles,
+		counters:      make(map[string]float64),
+		timers:        make(map[string]*timerValues),
+		gauges:        make(map[string]*gaugeValue),
+		sets:          make(map[string]map[string]struct{}),
+	}
+}
+
+func (a *aggregator) add(m metric) {
+	a.Lock()
+	defer a.Unlock()
+
+	switch m.typ {
+	case counterType:
+		a.counters[string(m.name)] += m.value / m.sampleRate
+	case timerType:
+		t, ok := a.timers[string(m.name)]
+		if !ok {
+			t = &timerValues{}
+			a.timers[string(m.name)] = t
+		}
+		t.values = append(t.values, m.value)
+		t.count += 1 / m.sampleRate
+	case gaugeType:
+		g, ok := a.gauges[string(m.name)]
+		if !ok {
+			g = &gaugeValue{}
+			a.gauges[string(m.name)] = g
+		}
+		if m.delta {
+			g.value += m.value
+		} else {
+			g.value = m.value
+		}
+		g.updated = true
+	case setType:
+		s, ok := a.sets[string(m.name)]
+		if !ok {
+			s = make(map[string]struct{})
+			a.sets[string(m.name)] = s
+		}
+		s[string(m.setValue)] = struct{}{}
+	}
+}
+
+// flush returns the metrics aggregated over the window and resets the
+// window. Gauges keep their value so that later deltas apply to it, but are
+// only returned for windows in which they were updated.
+func (a *aggregator) flush(window time.Duration) []aggregatedMetric {
+	a.Lock()
+	defer a.Unlock()
+
+	var (
+		result  []aggregatedMetric
+		seconds = window.Seconds()
+	)
+	for name, count := range a.counters {
+		prefix := a.counterPrefix + name
+		result = append(result,
+			aggregatedMetric{name: []byte(prefix + ".count"), value: count},
+			aggregatedMetric{name: []byte(prefix + ".rate"), value: count / seconds})
+	}
+	for name, t := range a.timers {
+		result = a.appendTimer(result, a.timerPrefix+name, t, seconds)
+	}
+	for name, g := range a.gauges {
+		if !g.updated {
+			continue
+		}
+		result = append(result, aggregatedMetric{name: []byte(a.gaugePrefix + name), value: g.value})
+		g.updated = false
+	}
+	for name, s := range a.sets {
+		result = append(result, aggregatedMetric{
+			name:  []byte(a.setPrefix + name + ".count"),
+			value: float64(len(s)),
+		})
+	}
+
+	a.counters = make(map[string]float64, len(a.counters))
+	a.timers = make(map[string]*timerValues, len(a.timers))
+	a.sets = make(map[string]map[string]struct{}, len(a.sets))
+	return result
+}
+
+func (a *aggregator) appendTimer(
+	result []aggregatedMetric,
+	prefix string,
+	t *timerValues,
+	seconds float64,
+) []aggregatedMetric {
+	values := t.values
+	sort.Float64s(values)
+
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+
+	n := len(values)
+	median := values[n/2]
+	if n%2 == 0 {
+		median = (values[n/2-1] + values[n/2]) / 2
+	}
+
+	add := func(suffix string, value float64) {
+		result = append(result, aggregatedMetric{name: []byte(prefix + "." + suffix), value: value})
+	}
+	add("count", t.count)
+	add("count_ps", t.count/seconds)
+	add("lower", values[0])
+	add("upper", values[n-1])
+	add("sum", sum)
+	add("mean", sum/float64(n))
+	add("median", median)
+
+	for _, p := range a.percentiles {
+		inThreshold := n
+		if n > 1 {
+			inThreshold = int(math.Round(p / 100 * float64(n)))
+		}
+		if inThreshold == 0 {
+			continue
+		}
+
+		var thresholdSum float64
+		for _, v := range values[:inThreshold] {
+			thresholdSum += v
+		}
+		suffix := percentileSuffix(p)
+		add("upper_"+suffix, values[inThreshold-1])
+		add("mean_"+suffix, thresholdSum/float64(inThreshold))
+	}
+
+	return result
+}
+
+// percentileSuffix formats a percentile for use in a metric name, e.g. 99.9
+// is formatted as 99_9.
+func percentileSuffix(p float64) string {
+	return strings.Replace(strconv.FormatFloat(p, 'f', -1, 64), ".", "_", -1)
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingeststatsd
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func mustParseLine(t *testing.T, line string) metric {
+	m, err := parseLine([]byte(line))
+	require.NoError(t, err)
+	return m
+}
+
+func flushToMap(a *aggregator, window time.Duration) map[string]float64 {
+	result := make(map[string]float64)
+	for _, m := range a.flush(window) {
+		result[string(m.name)] = m.value
+	}
+	return result
+}
+
+func TestAggregatorCountersAndSets(t *testing.T) {
+	a := newAggregator("stats", nil)
+	for _, line := range []string{
+		"requests:1|c",
+		"requests:2|c",
+		"requests:1|c|@0.1",
+		"users:alice|s",
+		"users:bob|s",
+		"users:alice|s",
+	} {
+		a.add(mustParseLine(t, line))
+	}
+
+	assert.Equal(t, map[string]float64{
+		"stats.counters.requests.count": 13,
+		"stats.counters.requests.rate":  1.3,
+		"stats.sets.users.count":        2,
+	}, flushToMap(a, 10*time.Second))
+
+	// The window is reset after flushing.
+	assert.Equal(t, 0, len(a.flush(10*time.Second)))
+}
+
+func TestAggregatorTimers(t *testing.T) {
+	a := newAggregator("", []float64{50, 99.9})
+	for _, line := range []string{
+		"latency:4|ms",
+		"latency:1|ms",
+		"latency:3|ms",
+		"latency:2|ms",
+	} {
+		a.add(mustParseLine(t, line))
+	}
+
+	assert.Equal(t, map[string]float64{
+		"timers.latency.count":      4,
+		"timers.latency.count_ps":   0.4,
+		"timers.latency.lower":      1,
+		"timers.latency.upper":      4,
+		"timers.latency.sum":        10,
+		"timers.latency.mean":       2.5,
+		"timers.latency.median":     2.5,
+		"timers.latency.upper_50":   2,
+		"timers.latency.mean_50":    1.5,
+		"timers.latency.upper_99_9": 4,
+		"timers.latency.mean_99_9":  2.5,
+	}, flushToMap(a, 10*time.Second))
+}
+
+func TestAggregatorGauges(t *testing.T) {
+	a := newAggregator("stats", nil)
+	a.add(mustParseLine(t, "queue:10|g"))
+	a.add(mustParseLine(t, "queue:+5|g"))
+	a.add(mustParseLine(t, "memory:100|g"))
+	assert.Equal(t, map[string]float64{
+		"stats.gauges.queue":  15,
+		"stats.gauges.memory": 100,
+	}, flushToMap(a, time.Second))
+
+	// Gauges are only flushed when updated but deltas apply to the last value.
+	a.add(mustParseLine(t, "queue:-3|g"))
+	flushed := a.flush(time.Second)
+	names := make([]string, 0, len(flushed))
+	for _, m := range flushed {
+		names = append(names, string(m.name))
+	}
+	sort.Strings(names)
+	assert.Equal(t, []string{"stats.gauges.queue"}, names)
+	assert.Equal(t, float64(12), flushed[0].value)
+}
@@ -0,0 +1,283 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingeststatsd
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	ingestcarbon "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/carbon"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/instrument"
+	xsync "github.com/m3db/m3/src/x/sync"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	maxPacketSize = 65535
+)
+
+var (
+	errIOptsMustBeSet          = errors.New("statsd ingester options: instrument options must be set")
+	errWorkerPoolMustBeSet     = errors.New("statsd ingester options: worker pool must be set")
+	errFlushIntervalMustBeSet  = errors.New("statsd ingester options: flush interval must be positive")
+	errServerAlreadyListening  = errors.New("statsd server: already listening")
+	errPercentileOutOfRangeFmt = "statsd ingester options: percentile %v must be in (0, 100]"
+	lineSeparator              = []byte("\n")
+	defaultWriteContext        = context.Background()
+	graphiteTagOpts            = models.NewTagOptions().SetIDSchemeType(models.TypeGraphite)
+)
+
+// Options configures the ingester.
+type Options struct {
+	InstrumentOptions instrument.Options
+	WorkerPool        xsync.PooledWorkerPool
+	// FlushInterval is the aggregation window after which aggregated
+	// metrics are written.
+	FlushInterval time.Duration
+	// Prefix is prepended to the names of all aggregated metrics.
+	Prefix string
+	// Percentiles are the percentiles computed for timers.
+	Percentiles []float64
+	// WriteOptions are the options used for writes of aggregated metrics.
+	WriteOptions ingest.WriteOptions
+}
+
+// Validate validates the options struct.
+func (o *Options) Validate() error {
+	if o.InstrumentOptions == nil {
+		return errIOptsMustBeSet
+	}
+
+	if o.WorkerPool == nil {
+		return errWorkerPoolMustBeSet
+	}
+
+	if o.FlushInterval <= 0 {
+		return errFlushIntervalMustBeSet
+	}
+
+	for _, p := range o.Percentiles {
+		if p <= 0 || p > 100 {
+			return fmt.Errorf(errPercentileOutOfRangeFmt, p)
+		}
+	}
+
+	return nil
+}
+
+type ingesterMetrics struct {
+	received  tally.Counter
+	malformed tally.Counter
+	success   tally.Counter
+	err       tally.Counter
+}
+
+func newIngesterMetrics(scope tally.Scope) ingesterMetrics {
+	return ingesterMetrics{
+		received:  scope.Counter("received"),
+		malformed: scope.Counter("malformed"),
+		success:   scope.Counter("success"),
+		err:       scope.Counter("error"),
+	}
+}
+
+// Server is a UDP server receiving statsd metrics, it aggregates them over
+// the flush interval and then writes the aggregated metrics.
+type Server struct {
+	sync.Mutex
+
+	address              string
+	downsamplerAndWriter ingest.DownsamplerAndWriter
+	opts                 Options
+	logger               *zap.Logger
+	metrics              ingesterMetrics
+	aggregator           *aggregator
+
+	conn    net.PacketConn
+	closed  bool
+	closeCh chan struct{}
+	wg      sync.WaitGroup
+}
+
+// NewServer returns a new statsd server listening on the address.
+func NewServer(
+	address string,
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+	opts Options,
+) (*Server, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	return &Server{
+		address:              address,
+		downsamplerAndWriter: downsamplerAndWriter,
+		opts:                 opts,
+		logger:               opts.InstrumentOptions.Logger(),
+		metrics:              newIngesterMetrics(opts.InstrumentOptions.MetricsScope()),
+		aggregator:           newAggregator(opts.Prefix, opts.Percentiles),
+		closeCh:              make(chan struct{}),
+	}, nil
+}
+
+// ListenAndServe starts receiving and flushing metrics in the background.
+func (s *Server) ListenAndServe() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.conn != nil {
+		return errServerAlreadyListening
+	}
+
+	conn, err := net.ListenPacket("udp", s.address)
+	if err != nil {
+		return err
+	}
+	s.conn = conn
+
+	s.wg.Add(2)
+	go s.receiveLoop()
+	go s.flushLoop()
+	return nil
+}
+
+// Close stops the server, flushing any metrics aggregated in the current window.
+func (s *Server) Close() {
+	s.Lock()
+	if s.conn == nil || s.closed {
+		s.Unlock()
+		return
+	}
+	s.closed = true
+	s.Unlock()
+
+	close(s.closeCh)
+	s.conn.Close()
+	s.wg.Wait()
+}
+
+func (s *Server) receiveLoop() {
+	defer s.wg.Done()
+
+	buf := make([]byte, maxPacketSize)
+	for {
+		n, _, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-s.closeCh:
+				return
+			default:
+			}
+			s.logger.Error("error reading statsd packet", zap.Error(err))
+			continue
+		}
+
+		s.handlePacket(buf[:n])
+	}
+}
+
+func (s *Server) handlePacket(packet []byte) {
+	for _, line := range bytes.Split(packet, lineSeparator) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 {
+			continue
+		}
+
+		m, err := parseLine(line)
+		if err != nil {
+			s.metrics.malformed.Inc(1)
+			s.logger.Debug("malformed statsd line",
+				zap.ByteString("line", line), zap.Error(err))
+			continue
+		}
+
+		s.metrics.received.Inc(1)
+		s.aggregator.add(m)
+	}
+}
+
+func (s *Server) flushLoop() {
+	defer s.wg.Done()
+
+	ticker := time.NewTicker(s.opts.FlushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.flush(time.Now())
+		case <-s.closeCh:
+			s.flush(time.Now())
+			return
+		}
+	}
+}
+
+// flush writes the metrics aggregated over the current window.
+func (s *Server) flush(now time.Time) {
+	var (
+		aggregated = s.aggregator.flush(s.opts.FlushInterval)
+		timestamp  = now.Truncate(time.Second)
+		wg         sync.WaitGroup
+	)
+	for _, m := range aggregated {
+		m := m
+		wg.Add(1)
+		s.opts.WorkerPool.Go(func() {
+			defer wg.Done()
+			s.write(m, timestamp)
+		})
+	}
+	wg.Wait()
+}
+
+func (s *Server) write(m aggregatedMetric, timestamp time.Time) {
+	tags, err := ingestcarbon.GenerateTagsFromName(m.name, graphiteTagOpts)
+	if err != nil {
+		s.metrics.malformed.Inc(1)
+		s.logger.Error("err generating tags from statsd metric",
+			zap.ByteString("name", m.name), zap.Error(err))
+		return
+	}
+
+	datapoints := ts.Datapoints{{Timestamp: timestamp, Value: m.value}}
+	err = s.downsamplerAndWriter.Write(defaultWriteContext, tags, datapoints,
+		xtime.Second, nil, s.opts.WriteOptions)
+	if err != nil {
+		s.metrics.err.Inc(1)
+		s.logger.Error("err writing statsd metric",
+			zap.ByteString("name", m.name), zap.Error(err))
+		return
+	}
+
+	s.metrics.success.Inc(1)
+}
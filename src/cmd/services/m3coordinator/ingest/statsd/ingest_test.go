@@ -0,0 +1,165 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingeststatsd
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/metrics/policy"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/ts"
+	"github.com/m3db/m3/src/x/instrument"
+	xsync "github.com/m3db/m3/src/x/sync"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestOptions(t *testing.T) Options {
+	workerPool, err := xsync.NewPooledWorkerPool(4, xsync.NewPooledWorkerPoolOptions())
+	require.NoError(t, err)
+	workerPool.Init()
+
+	return Options{
+		InstrumentOptions: instrument.NewOptions(),
+		WorkerPool:        workerPool,
+		FlushInterval:     10 * time.Second,
+		Prefix:            "stats",
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	opts := newTestOptions(t)
+	require.NoError(t, opts.Validate())
+
+	invalid := opts
+	invalid.FlushInterval = 0
+	assert.Equal(t, errFlushIntervalMustBeSet, invalid.Validate())
+
+	invalid = opts
+	invalid.Percentiles = []float64{101}
+	assert.Error(t, invalid.Validate())
+
+	invalid = opts
+	invalid.WorkerPool = nil
+	assert.Equal(t, errWorkerPoolMustBeSet, invalid.Validate())
+}
+
+func TestServerFlushWritesAggregatedMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		lock      sync.Mutex
+		written   = make(map[string]float64)
+		now       = time.Unix(1500000000, int64(500*time.Millisecond))
+		writeOpts = ingest.WriteOptions{
+			DownsampleOverride: true,
+			WriteOverride:      true,
+			WriteStoragePolicies: []policy.StoragePolicy{
+				policy.NewStoragePolicy(10*time.Second, xtime.Second, 48*time.Hour),
+			},
+		}
+		downsamplerAndWriter = ingest.NewMockDownsamplerAndWriter(ctrl)
+	)
+	downsamplerAndWriter.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), xtime.Second, gomock.Any(), writeOpts).
+		DoAndReturn(func(
+			_ context.Context,
+			tags models.Tags,
+			dps ts.Datapoints,
+			_ xtime.Unit,
+			_ []byte,
+			_ ingest.WriteOptions,
+		) error {
+			lock.Lock()
+			defer lock.Unlock()
+			if len(dps) != 1 || !dps[0].Timestamp.Equal(time.Unix(1500000000, 0)) {
+				panic("unexpected datapoints")
+			}
+			written[string(tags.ID())] = dps[0].Value
+			return nil
+		}).Times(3)
+
+	opts := newTestOptions(t)
+	opts.WriteOptions = writeOpts
+	server, err := NewServer("127.0.0.1:0", downsamplerAndWriter, opts)
+	require.NoError(t, err)
+
+	server.handlePacket([]byte("requests:1|c\nrequests:4|c\n\ngarbage\nqueue:7|g\n"))
+	server.flush(now)
+
+	assert.Equal(t, map[string]float64{
+		"stats.counters.requests.count": 5,
+		"stats.counters.requests.rate":  0.5,
+		"stats.gauges.queue":            7,
+	}, written)
+
+	// Nothing to write for an empty window.
+	server.flush(now)
+}
+
+func TestServerListenAndServe(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		lock                 sync.Mutex
+		written              []string
+		downsamplerAndWriter = ingest.NewMockDownsamplerAndWriter(ctrl)
+	)
+	downsamplerAndWriter.EXPECT().
+		Write(gomock.Any(), gomock.Any(), gomock.Any(), xtime.Second, gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			tags models.Tags,
+			_ ts.Datapoints,
+			_ xtime.Unit,
+			_ []byte,
+			_ ingest.WriteOptions,
+		) error {
+			lock.Lock()
+			written = append(written, string(tags.ID()))
+			lock.Unlock()
+			return nil
+		}).AnyTimes()
+
+	server, err := NewServer("127.0.0.1:0", downsamplerAndWriter, newTestOptions(t))
+	require.NoError(t, err)
+	require.NoError(t, server.ListenAndServe())
+	require.Equal(t, errServerAlreadyListening, server.ListenAndServe())
+
+	server.handlePacket([]byte("users:alice|s"))
+
+	// Closing flushes the current window.
+	server.Close()
+	server.Close()
+
+	sort.Strings(written)
+	assert.Equal(t, []string{"stats.sets.users.count"}, written)
+}
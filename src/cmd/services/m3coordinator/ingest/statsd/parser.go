@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingeststatsd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+type metricType int
+
+const (
+	counterType metricType = iota
+	timerType
+	gaugeType
+	setType
+)
+
+var (
+	errInvalidLine       = errors.New("statsd: invalid line")
+	errInvalidSampleRate = errors.New("statsd: invalid sample rate")
+)
+
+// metric is a single parsed statsd metric.
+type metric struct {
+	name       []byte
+	value      float64
+	setValue   []byte
+	typ        metricType
+	sampleRate float64
+	// delta is set for gauges that are adjusted by a signed value rather
+	// than set to the value.
+	delta bool
+}
+
+// parseLine parses a statsd line of the form name:value|type[|@rate][|#tags],
+// tags are accepted but ignored since the metrics are stored as graphite paths.
+func parseLine(line []byte) (metric, error) {
+	pipe := bytes.IndexByte(line, '|')
+	if pipe < 0 {
+		return metric{}, errInvalidLine
+	}
+	sep := bytes.LastIndexByte(line[:pipe], ':')
+	if sep <= 0 {
+		return metric{}, errInvalidLine
+	}
+
+	m := metric{
+		name:       sanitizeName(line[:sep]),
+		sampleRate: 1,
+	}
+	if len(m.name) == 0 {
+		return metric{}, errInvalidLine
+	}
+
+	valueBytes := line[sep+1 : pipe]
+	fields := bytes.Split(line[pipe+1:], []byte("|"))
+	switch string(fields[0]) {
+	case "c":
+		m.typ = counterType
+	case "ms", "h", "d":
+		m.typ = timerType
+	case "g":
+		m.typ = gaugeType
+		m.delta = len(valueBytes) > 0 && (valueBytes[0] == '+' || valueBytes[0] == '-')
+	case "s":
+		m.typ = setType
+		if len(valueBytes) == 0 {
+			return metric{}, errInvalidLine
+		}
+		m.setValue = valueBytes
+	default:
+		return metric{}, fmt.Errorf("statsd: unsupported metric type %s", fields[0])
+	}
+
+	if m.typ != setType {
+		value, err := strconv.ParseFloat(string(valueBytes), 64)
+		if err != nil {
+			return metric{}, err
+		}
+		m.value = value
+	}
+
+	for _, field := range fields[1:] {
+		if len(field) == 0 || field[0] != '@' {
+			continue
+		}
+		rate, err := strconv.ParseFloat(string(field[1:]), 64)
+		if err != nil || rate <= 0 || rate > 1 {
+			return metric{}, errInvalidSampleRate
+		}
+		m.sampleRate = rate
+	}
+
+	return m, nil
+}
+
+// sanitizeName sanitizes a metric name the same way statsd does before
+// sending it to graphite, replacing whitespace with underscores, slashes with
+// dashes and removing any other characters that are not alphanumeric,
+// underscores, dashes or dots.
+func sanitizeName(name []byte) []byte {
+	result := make([]byte, 0, len(name))
+	for _, c := range name {
+		switch {
+		case c == ' ' || c == '\t':
+			result = append(result, '_')
+		case c == '/':
+			result = append(result, '-')
+		case c == '_' || c == '-' || c == '.' ||
+			(c >= 'a' && c <= 'z') ||
+			(c >= 'A' && c <= 'Z') ||
+			(c >= '0' && c <= '9'):
+			result = append(result, c)
+		}
+	}
+	return result
+}
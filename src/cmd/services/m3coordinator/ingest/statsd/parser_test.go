@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ingeststatsd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseLine(t *testing.T) {
+	testCases := []struct {
+		line     string
+		expected metric
+	}{
+		{
+			line:     "requests:1|c",
+			expected: metric{name: []byte("requests"), value: 1, typ: counterType, sampleRate: 1},
+		},
+		{
+			line:     "requests:2|c|@0.5",
+			expected: metric{name: []byte("requests"), value: 2, typ: counterType, sampleRate: 0.5},
+		},
+		{
+			line:     "api.latency:12.5|ms|#env:prod,host:a",
+			expected: metric{name: []byte("api.latency"), value: 12.5, typ: timerType, sampleRate: 1},
+		},
+		{
+			line:     "api.latency:3|h",
+			expected: metric{name: []byte("api.latency"), value: 3, typ: timerType, sampleRate: 1},
+		},
+		{
+			line:     "queue size:10|g",
+			expected: metric{name: []byte("queue_size"), value: 10, typ: gaugeType, sampleRate: 1},
+		},
+		{
+			line:     "queue/size:-2|g",
+			expected: metric{name: []byte("queue-size"), value: -2, typ: gaugeType, sampleRate: 1, delta: true},
+		},
+		{
+			line:     "users:alice|s",
+			expected: metric{name: []byte("users"), setValue: []byte("alice"), typ: setType, sampleRate: 1},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.line, func(t *testing.T) {
+			m, err := parseLine([]byte(tc.line))
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, m)
+		})
+	}
+}
+
+func TestParseLineErrors(t *testing.T) {
+	for _, line := range []string{
+		"requests",
+		"requests:1",
+		":1|c",
+		"requests:1|x",
+		"requests:abc|c",
+		"requests:1|c|@0",
+		"requests:1|c|@2",
+		"users:|s",
+		"!!!:1|c",
+	} {
+		_, err := parseLine([]byte(line))
+		assert.Error(t, err, line)
+	}
+}
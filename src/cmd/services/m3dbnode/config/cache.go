@@ -20,7 +20,12 @@
 
 package config
 
-import "github.com/m3db/m3/src/dbnode/storage/series"
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+)
 
 var (
 	defaultPostingsListCacheSize   = 2 << 14 // 32,768
@@ -66,8 +71,39 @@ type SeriesCacheConfiguration struct {
 // LRUSeriesCachePolicyConfiguration contains configuration for the LRU
 // series caching policy.
 type LRUSeriesCachePolicyConfiguration struct {
-	MaxBlocks         uint `yaml:"maxBlocks" validate:"nonzero"`
-	EventsChannelSize uint `yaml:"eventsChannelSize" validate:"nonzero"`
+	MaxBlocks         uint                            `yaml:"maxBlocks" validate:"nonzero"`
+	EventsChannelSize uint                            `yaml:"eventsChannelSize" validate:"nonzero"`
+	MemoryPressure    *LRUMemoryPressureConfiguration `yaml:"memoryPressure"`
+}
+
+// LRUMemoryPressureConfiguration is the configuration for evicting blocks
+// cached by the LRU series caching policy when under memory pressure.
+type LRUMemoryPressureConfiguration struct {
+	// HeapInUseThresholdBytes is the heap in use above which cached blocks
+	// are evicted in order of least recent query access.
+	HeapInUseThresholdBytes uint64 `yaml:"heapInUseThresholdBytes" validate:"nonzero"`
+
+	// ProtectedRange is how far back from now cached blocks are never
+	// evicted under memory pressure, e.g. the range of hot dashboards.
+	ProtectedRange time.Duration `yaml:"protectedRange" validate:"min=0"`
+
+	// EvictFraction is the fraction of cached blocks evicted each time the
+	// heap in use is observed above the threshold.
+	EvictFraction *float64 `yaml:"evictFraction"`
+}
+
+// WiredListPressureOptions returns the runtime wired list pressure options.
+func (c LRUMemoryPressureConfiguration) WiredListPressureOptions(
+	defaults runtime.WiredListPressureOptions,
+) runtime.WiredListPressureOptions {
+	opts := defaults
+	opts.Enabled = true
+	opts.HeapInUseThresholdBytes = c.HeapInUseThresholdBytes
+	opts.ProtectedRange = c.ProtectedRange
+	if c.EvictFraction != nil {
+		opts.EvictFraction = *c.EvictFraction
+	}
+	return opts
 }
 
 // PostingsListCacheConfiguration is the postings list cache configuration.
@@ -32,8 +32,12 @@ import (
 	coordinatorcfg "github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/notify"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/repair"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/x/config/hostid"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/instrument"
 	xlog "github.com/m3db/m3/src/x/log"
 	"github.com/m3db/m3/src/x/opentracing"
@@ -157,6 +161,32 @@ type DBConfiguration struct {
 	// Limits contains configuration for limits that can be applied to M3DB for the purposes
 	// of applying back-pressure or protecting the db nodes.
 	Limits Limits `yaml:"limits"`
+
+	// PromRemoteWrite configures an optional listener that ingests Prometheus
+	// remote write requests and serves remote read requests directly, omit
+	// this to disable it.
+	PromRemoteWrite *PromRemoteWriteConfiguration `yaml:"promRemoteWrite"`
+
+	// Notifications configures webhooks that lifecycle events are posted to,
+	// omit this to disable notifications.
+	Notifications *notify.Configuration `yaml:"notifications"`
+
+	// WriteIdempotency configures idempotent tagged write batches, omit this
+	// to disable them.
+	WriteIdempotency *WriteIdempotencyConfiguration `yaml:"writeIdempotency"`
+
+	// Snapshot configures when snapshots are taken, omit this to snapshot
+	// every time the flush manager runs.
+	Snapshot *SnapshotPolicy `yaml:"snapshot"`
+
+	// BackgroundScheduler configures the scheduler that coordinates flushes,
+	// cleanup, repairs and index compactions, omit this to let them run
+	// independently of each other.
+	BackgroundScheduler *BackgroundSchedulerConfiguration `yaml:"backgroundScheduler"`
+
+	// FaultInjection configures faults injected into commit log writes, peer
+	// fetches and flushes for chaos testing, omit this to disable it.
+	FaultInjection *fault.Configuration `yaml:"faultInjection"`
 }
 
 // InitDefaultsAndValidate initializes all default values and validates the Configuration.
@@ -191,6 +221,31 @@ func (c *DBConfiguration) InitDefaultsAndValidate() error {
 	return nil
 }
 
+// PromRemoteWriteConfiguration is the configuration for ingesting Prometheus
+// remote write requests directly into a namespace, and serving remote read
+// requests from it, for deployments that do not run a separate coordinator.
+type PromRemoteWriteConfiguration struct {
+	// The HTTP host and port on which to listen for remote write and remote
+	// read requests.
+	ListenAddress string `yaml:"listenAddress" validate:"nonzero"`
+
+	// Namespace is the namespace that samples are written to and read from.
+	Namespace string `yaml:"namespace" validate:"nonzero"`
+}
+
+// WriteIdempotencyConfiguration is the configuration for retaining the
+// results of tagged write batches sent with an idempotency key, so that
+// batches retried by clients after an ambiguous failure are applied once.
+type WriteIdempotencyConfiguration struct {
+	// Window is the duration for which the result of a write batch is
+	// retained after it is first received.
+	Window time.Duration `yaml:"window" validate:"nonzero"`
+
+	// MaxKeys is the maximum number of idempotency keys retained at any one
+	// time, if zero the default is used.
+	MaxKeys int `yaml:"maxKeys" validate:"min=0"`
+}
+
 // IndexConfiguration contains index-specific configuration.
 type IndexConfiguration struct {
 	// MaxQueryIDsConcurrency controls the maximum number of outstanding QueryID
@@ -214,6 +269,36 @@ type IndexConfiguration struct {
 	// block boundaries by eagerly writing the series to the next block
 	// preemptively.
 	ForwardIndexThreshold float64 `yaml:"forwardIndexThreshold" validate:"min=0.0,max=1.0"`
+
+	// MaxQueryBytes is the maximum number of bytes the results of a single
+	// index query may retain before the query is aborted, protecting the node
+	// from pathological queries such as broad regexes over large blocks. A
+	// value of zero disables the budget.
+	MaxQueryBytes int64 `yaml:"maxQueryBytes" validate:"min=0"`
+
+	// QuerySpill configures spilling the results of index queries that
+	// exceed MaxQueryBytes to local temporary files rather than aborting
+	// the queries.
+	QuerySpill *IndexQuerySpillConfiguration `yaml:"querySpill"`
+}
+
+// IndexQuerySpillConfiguration is the configuration for spilling the results
+// of index queries that exceed their in-memory bytes budget to disk.
+type IndexQuerySpillConfiguration struct {
+	// Enabled enables spilling query results to disk.
+	Enabled bool `yaml:"enabled"`
+
+	// Directory is the directory spill files are created in, if empty the
+	// default directory for temporary files is used.
+	Directory string `yaml:"directory"`
+
+	// MaxQueryBytes is the maximum number of bytes a single query may spill
+	// to disk, a value of zero means no limit.
+	MaxQueryBytes int64 `yaml:"maxQueryBytes" validate:"min=0"`
+
+	// MaxBytes is the maximum number of bytes all queries may spill to
+	// disk at any one time, a value of zero means no limit.
+	MaxBytes int64 `yaml:"maxBytes" validate:"min=0"`
 }
 
 // TransformConfiguration contains configuration options that can transform
@@ -251,6 +336,46 @@ type TickConfiguration struct {
 
 	// Tick minimum interval controls the minimum tick interval for the node.
 	MinimumInterval time.Duration `yaml:"minimumInterval"`
+
+	// LoadPacing slows down the tick when the node is under load, omit this
+	// to tick at a fixed pace regardless of load.
+	LoadPacing *TickLoadPacingConfiguration `yaml:"loadPacing"`
+}
+
+// TickLoadPacingConfiguration is the configuration for pacing the tick based
+// on the load of the node, the tick sleeps and minimum interval are scaled by
+// how far the observed load exceeds the targets.
+type TickLoadPacingConfiguration struct {
+	// TargetWriteLatency is the mean write latency above which the tick is
+	// slowed down, zero ignores write latency.
+	TargetWriteLatency time.Duration `yaml:"targetWriteLatency" validate:"min=0"`
+
+	// TargetReadLatency is the mean read latency above which the tick is
+	// slowed down, zero ignores read latency.
+	TargetReadLatency time.Duration `yaml:"targetReadLatency" validate:"min=0"`
+
+	// TargetCPUUtilization is the process CPU utilization, as a fraction of
+	// the available cores, above which the tick is slowed down, zero ignores
+	// CPU utilization.
+	TargetCPUUtilization float64 `yaml:"targetCPUUtilization" validate:"min=0"`
+
+	// MaxSlowdownFactor is the maximum factor to slow down the tick by.
+	MaxSlowdownFactor *float64 `yaml:"maxSlowdownFactor"`
+}
+
+// TickLoadPacingOptions returns the runtime tick load pacing options.
+func (c TickLoadPacingConfiguration) TickLoadPacingOptions(
+	defaults runtime.TickLoadPacingOptions,
+) runtime.TickLoadPacingOptions {
+	opts := defaults
+	opts.Enabled = true
+	opts.TargetWriteLatency = c.TargetWriteLatency
+	opts.TargetReadLatency = c.TargetReadLatency
+	opts.TargetCPUUtilization = c.TargetCPUUtilization
+	if c.MaxSlowdownFactor != nil {
+		opts.MaxSlowdownFactor = *c.MaxSlowdownFactor
+	}
+	return opts
 }
 
 // BlockRetrievePolicy is the block retrieve policy.
@@ -258,6 +383,21 @@ type BlockRetrievePolicy struct {
 	// FetchConcurrency is the concurrency to fetch blocks from disk. For
 	// spinning disks it is highly recommended to set this value to 1.
 	FetchConcurrency int `yaml:"fetchConcurrency" validate:"min=0"`
+
+	// PrefetchBlocks is the number of adjacent blocks on either side of a
+	// block read from disk that are speculatively read for the same series,
+	// which improves the latency of queries over long time ranges. Zero
+	// disables prefetching.
+	PrefetchBlocks int `yaml:"prefetchBlocks" validate:"min=0"`
+
+	// PrefetchBudget is the maximum number of blocks being prefetched at any
+	// one time, if zero the default budget is used.
+	PrefetchBudget int `yaml:"prefetchBudget" validate:"min=0"`
+
+	// FetchHandOffsPerQuery is the maximum number of batches of blocks for a
+	// single query that are retrieved concurrently by other fetch loops, if
+	// zero the default is used.
+	FetchHandOffsPerQuery int `yaml:"fetchHandOffsPerQuery" validate:"min=0"`
 }
 
 // CommitLogPolicy is the commit log policy.
@@ -315,6 +455,10 @@ type RepairPolicy struct {
 	// Enabled or disabled.
 	Enabled bool `yaml:"enabled"`
 
+	// The type of repair to run, either full to repair the blocks that
+	// diverge from peers or compare_only to only record the differences.
+	Type repair.Type `yaml:"type"`
+
 	// The repair throttle.
 	Throttle time.Duration `yaml:"throttle"`
 
@@ -327,6 +471,48 @@ type RepairPolicy struct {
 	// If enabled, what percentage of metadata should perform a detailed debug
 	// shadow comparison.
 	DebugShadowComparisonsPercentage float64 `yaml:"debugShadowComparisonsPercentage"`
+
+	// The number of divergent blocks found repairing a shard above which a
+	// repair divergence notification is sent.
+	DivergenceNotifyThreshold int64 `yaml:"divergenceNotifyThreshold"`
+
+	// The number of repair outcomes retained for each block of a shard, if
+	// unset the default is used.
+	HistorySize int `yaml:"historySize"`
+}
+
+// SnapshotPolicy is the snapshot policy.
+type SnapshotPolicy struct {
+	// MinimumInterval is the minimum interval between snapshots, snapshots
+	// are taken sooner if either of the thresholds below is exceeded.
+	MinimumInterval time.Duration `yaml:"minimumInterval" validate:"min=0"`
+
+	// UnsnapshottedBytesThreshold is the estimated number of commit log bytes
+	// written since the last snapshot above which a snapshot is taken, if
+	// zero the threshold is disabled.
+	UnsnapshottedBytesThreshold int64 `yaml:"unsnapshottedBytesThreshold" validate:"min=0"`
+
+	// UnsnapshottedSeriesThreshold is the number of series created since the
+	// last snapshot above which a snapshot is taken, if zero the threshold
+	// is disabled.
+	UnsnapshottedSeriesThreshold int64 `yaml:"unsnapshottedSeriesThreshold" validate:"min=0"`
+
+	// CompactionMinVolumes is the number of snapshot volumes of a block at
+	// or above which they are compacted into a single volume during cleanup,
+	// if zero snapshot compaction is disabled.
+	CompactionMinVolumes int `yaml:"compactionMinVolumes" validate:"min=0"`
+}
+
+// BackgroundSchedulerConfiguration is the configuration for the background
+// work scheduler.
+type BackgroundSchedulerConfiguration struct {
+	// CPUBudget is the number of CPU bound units of background work that may
+	// run concurrently, if zero the default is used.
+	CPUBudget int `yaml:"cpuBudget" validate:"min=0"`
+
+	// DiskBudget is the number of disk bound units of background work that
+	// may run concurrently, if zero the default is used.
+	DiskBudget int `yaml:"diskBudget" validate:"min=0"`
 }
 
 // ReplicationPolicy is the replication policy.
@@ -25,9 +25,11 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/environment"
+	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/commitlog"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -345,6 +347,8 @@ func TestConfiguration(t *testing.T) {
     maxQueryIDsConcurrency: 0
     forwardIndexProbability: 0
     forwardIndexThreshold: 0
+    maxQueryBytes: 0
+    querySpill: null
   transforms:
     truncateBy: 0
     forceValue: null
@@ -406,6 +410,14 @@ func TestConfiguration(t *testing.T) {
     asyncWriteWorkerPoolSize: null
     asyncWriteMaxConcurrency: null
     useV2BatchAPIs: null
+    readRepair: null
+    writeIdempotencyEnabled: null
+    writeSpill: null
+    shardKeyStrategies: {}
+    fetchSeriesBlocksCompression: []
+    fetchMergeReplicaBlocks: null
+    replicaSelection: null
+    bootstrapPeerPreferences: []
   gcPercentage: 100
   writeNewSeriesLimitPerSecond: 1048576
   writeNewSeriesBackoffDuration: 2ms
@@ -433,6 +445,7 @@ func TestConfiguration(t *testing.T) {
     dataReadBufferSize: 65536
     infoReadBufferSize: 128
     seekReadBufferSize: 4096
+    seekSequentialReadSize: null
     throughputLimitMbps: 100
     throughputCheckEvery: 128
     newFileMode: null
@@ -441,6 +454,8 @@ func TestConfiguration(t *testing.T) {
     force_index_summaries_mmap_memory: true
     force_bloom_filter_mmap_memory: true
     bloomFilterFalsePositivePercent: null
+    concurrentDataWrites: null
+    migrationBlocksPerFlush: null
   commitlog:
     flushMaxBytes: 524288
     flushEvery: 1s
@@ -451,15 +466,19 @@ func TestConfiguration(t *testing.T) {
     blockSize: null
   repair:
     enabled: false
+    type: full
     throttle: 2m0s
     checkInterval: 1m0s
     debugShadowComparisonsEnabled: false
     debugShadowComparisonsPercentage: 0
+    divergenceNotifyThreshold: 0
+    historySize: 0
   replication: null
   pooling:
     blockAllocSize: 16
     thriftBytesPoolAllocSize: 2048
     type: simple
+    leakDetectionEnabled: false
     bytesPool:
       buckets:
       - size: 6291456
@@ -611,6 +630,7 @@ func TestConfiguration(t *testing.T) {
       clientOverrides:
         hostQueueFlushInterval: null
         targetHostQueueFlushSize: null
+        writeConsistencyLevel: null
       service:
         zone: embedded
         env: production
@@ -714,6 +734,17 @@ func TestConfiguration(t *testing.T) {
     maxOutstandingWriteRequests: 0
     maxOutstandingReadRequests: 0
     maxOutstandingRepairedBytes: 0
+    writeNewSeriesAdmissionLimitPerShardPerSecond: 0
+    writeNewSeriesAdmissionBurstPerShard: 0
+    backpressureRetryAfter: 0s
+    queryAdmission: null
+    shardErrorBudget: null
+  promRemoteWrite: null
+  notifications: null
+  writeIdempotency: null
+  snapshot: null
+  backgroundScheduler: null
+  faultInjection: null
 coordinator: null
 `
 
@@ -1044,3 +1075,55 @@ db:
 		result.NewOptions(), storage.DefaultTestOptions(), mapProvider, origin, adminClient)
 	require.NoError(t, err)
 }
+
+func TestTickLoadPacingConfiguration(t *testing.T) {
+	var cfg TickConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte(`
+seriesBatchSize: 512
+perSeriesSleepDuration: 100us
+minimumInterval: 10s
+loadPacing:
+  targetWriteLatency: 20ms
+  targetCPUUtilization: 0.8
+  maxSlowdownFactor: 4
+`), &cfg))
+	require.NotNil(t, cfg.LoadPacing)
+
+	defaults := runtime.NewOptions().TickLoadPacingOptions()
+	opts := cfg.LoadPacing.TickLoadPacingOptions(defaults)
+	assert.Equal(t, runtime.TickLoadPacingOptions{
+		Enabled:              true,
+		TargetWriteLatency:   20 * time.Millisecond,
+		TargetCPUUtilization: 0.8,
+		MaxSlowdownFactor:    4,
+	}, opts)
+
+	cfg.LoadPacing.MaxSlowdownFactor = nil
+	opts = cfg.LoadPacing.TickLoadPacingOptions(defaults)
+	assert.Equal(t, defaults.MaxSlowdownFactor, opts.MaxSlowdownFactor)
+}
+
+func TestLRUMemoryPressureConfiguration(t *testing.T) {
+	var cfg SeriesCacheConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte(`
+policy: lru
+lru:
+  maxBlocks: 1024
+  eventsChannelSize: 128
+  memoryPressure:
+    heapInUseThresholdBytes: 1073741824
+    protectedRange: 6h
+`), &cfg))
+	require.NotNil(t, cfg.LRU)
+	require.NotNil(t, cfg.LRU.MemoryPressure)
+
+	defaults := runtime.NewOptions().WiredListPressureOptions()
+	opts := cfg.LRU.MemoryPressure.WiredListPressureOptions(defaults)
+	assert.Equal(t, runtime.WiredListPressureOptions{
+		Enabled:                 true,
+		HeapInUseThresholdBytes: 1 << 30,
+		ProtectedRange:          6 * time.Hour,
+		EvictFraction:           defaults.EvictFraction,
+	}, opts)
+	require.NoError(t, runtime.NewOptions().SetWiredListPressureOptions(opts).Validate())
+}
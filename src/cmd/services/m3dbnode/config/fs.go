@@ -36,11 +36,13 @@ const (
 	defaultDataReadBufferSize              = 65536
 	defaultInfoReadBufferSize              = 128
 	defaultSeekReadBufferSize              = 4096
+	defaultSeekSequentialReadSize          = 1 << 20
 	defaultThroughputLimitMbps             = 100.0
 	defaultThroughputCheckEvery            = 128
 	defaultForceIndexSummariesMmapMemory   = false
 	defaultForceBloomFilterMmapMemory      = false
 	defaultBloomFilterFalsePositivePercent = 0.02
+	defaultConcurrentDataWrites            = false
 )
 
 // DefaultMmapConfiguration is the default mmap configuration.
@@ -70,6 +72,9 @@ type FilesystemConfiguration struct {
 	// Seek data read buffer size
 	SeekReadBufferSize *int `yaml:"seekReadBufferSize"`
 
+	// Seek data read ahead size for sequential scans, such as exports
+	SeekSequentialReadSize *int `yaml:"seekSequentialReadSize"`
+
 	// Disk flush throughput limit in Mb/s
 	ThroughputLimitMbps *float64 `yaml:"throughputLimitMbps"`
 
@@ -98,6 +103,16 @@ type FilesystemConfiguration struct {
 	// BloomFilterFalsePositivePercent controls the target false positive percentage
 	// for the bloom filters for the fileset files.
 	BloomFilterFalsePositivePercent *float64 `yaml:"bloomFilterFalsePositivePercent"`
+
+	// ConcurrentDataWrites controls whether fileset data files are checksummed
+	// and written out concurrently with series being merged and encoded
+	// during a flush.
+	ConcurrentDataWrites *bool `yaml:"concurrentDataWrites"`
+
+	// MigrationBlocksPerFlush is the maximum number of blocks whose filesets
+	// were written with an older format version that are rewritten to the
+	// current format during each flush, zero disables fileset migration.
+	MigrationBlocksPerFlush *int `yaml:"migrationBlocksPerFlush"`
 }
 
 // Validate validates the Filesystem configuration. We use this method to validate
@@ -127,6 +142,12 @@ func (f FilesystemConfiguration) Validate() error {
 			*f.SeekReadBufferSize)
 	}
 
+	if f.SeekSequentialReadSize != nil && *f.SeekSequentialReadSize < 1 {
+		return fmt.Errorf(
+			"fs seekSequentialReadSize is set to: %d, but must be at least 1",
+			*f.SeekSequentialReadSize)
+	}
+
 	if f.ThroughputLimitMbps != nil && *f.ThroughputLimitMbps < 1 {
 		return fmt.Errorf(
 			"fs throughputLimitMbps is set to: %f, but must be at least 1",
@@ -145,6 +166,12 @@ func (f FilesystemConfiguration) Validate() error {
 			*f.BloomFilterFalsePositivePercent)
 	}
 
+	if f.MigrationBlocksPerFlush != nil && *f.MigrationBlocksPerFlush < 0 {
+		return fmt.Errorf(
+			"fs migrationBlocksPerFlush is set to: %d, but must be at least 0",
+			*f.MigrationBlocksPerFlush)
+	}
+
 	return nil
 }
 
@@ -198,6 +225,16 @@ func (f FilesystemConfiguration) SeekReadBufferSizeOrDefault() int {
 	return defaultSeekReadBufferSize
 }
 
+// SeekSequentialReadSizeOrDefault returns the configured seek sequential read size if configured, or a
+// default value otherwise.
+func (f FilesystemConfiguration) SeekSequentialReadSizeOrDefault() int {
+	if f.SeekSequentialReadSize != nil {
+		return *f.SeekSequentialReadSize
+	}
+
+	return defaultSeekSequentialReadSize
+}
+
 // ThroughputLimitMbpsOrDefault returns the configured throughput limit mbps if configured, or a
 // default value otherwise.
 func (f FilesystemConfiguration) ThroughputLimitMbpsOrDefault() float64 {
@@ -257,6 +294,15 @@ func (f FilesystemConfiguration) BloomFilterFalsePositivePercentOrDefault() floa
 	return defaultBloomFilterFalsePositivePercent
 }
 
+// ConcurrentDataWritesOrDefault returns the configured value for whether to
+// write fileset data files concurrently if configured, or a default value otherwise.
+func (f FilesystemConfiguration) ConcurrentDataWritesOrDefault() bool {
+	if f.ConcurrentDataWrites != nil {
+		return *f.ConcurrentDataWrites
+	}
+	return defaultConcurrentDataWrites
+}
+
 // MmapConfiguration is the mmap configuration.
 type MmapConfiguration struct {
 	// HugeTLB is the huge pages configuration which will only take affect
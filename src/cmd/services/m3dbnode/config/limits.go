@@ -20,6 +20,13 @@
 
 package config
 
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/dbnode/runtime"
+)
+
 // Limits contains configuration for configurable limits that can be applied to M3DB.
 type Limits struct {
 	// MaxOutstandingWriteRequests controls the maximum number of outstanding write requests
@@ -39,4 +46,99 @@ type Limits struct {
 	// process would pause until some of the repaired bytes had been persisted to disk (and subsequently
 	// evicted from memory) at which point it would resume.
 	MaxOutstandingRepairedBytes int64 `yaml:"maxOutstandingRepairedBytes" validate:"min=0"`
+
+	// WriteNewSeriesAdmissionLimitPerShardPerSecond controls the sustained rate at which
+	// writes may create new series in each shard. Writes that would create new series in
+	// excess of this rate (and the burst below) are rejected with a cardinality throttled
+	// error before any memory is allocated for them, which protects the node from running
+	// out of memory during label explosions. Zero disables the limit.
+	WriteNewSeriesAdmissionLimitPerShardPerSecond int `yaml:"writeNewSeriesAdmissionLimitPerShardPerSecond" validate:"min=0"`
+
+	// WriteNewSeriesAdmissionBurstPerShard controls how many new series may be created in
+	// each shard in a burst above the sustained admission rate. Zero uses the admission
+	// rate as the burst.
+	WriteNewSeriesAdmissionBurstPerShard int `yaml:"writeNewSeriesAdmissionBurstPerShard" validate:"min=0"`
+
+	// BackpressureRetryAfter controls how long clients are asked to wait before retrying
+	// requests while the server is shedding load, it is returned as a response header
+	// alongside the current pressure level of the server. Zero uses the default.
+	BackpressureRetryAfter time.Duration `yaml:"backpressureRetryAfter" validate:"min=0"`
+
+	// QueryAdmission controls the rejection of large queries while the server is under
+	// garbage collection pressure, writes and small queries continue to be served.
+	QueryAdmission *QueryAdmissionLimits `yaml:"queryAdmission"`
+
+	// ShardErrorBudget controls isolating shards that persistently fail reads, such as
+	// due to corrupt filesets, so that their reads are served by the other replicas.
+	ShardErrorBudget *ShardErrorBudgetLimits `yaml:"shardErrorBudget"`
+}
+
+// ShardErrorBudgetLimits contains configuration for marking a shard as degraded once it
+// fails more reads within a window than its budget allows. A degraded shard is excluded
+// from serving reads for the isolation period and a shard degraded event is emitted.
+type ShardErrorBudgetLimits struct {
+	// MaxErrors is the number of read errors a shard may return within the window before
+	// it is marked as degraded. Zero uses the default.
+	MaxErrors int `yaml:"maxErrors" validate:"min=0"`
+
+	// Window is the period over which read errors are counted. Zero uses the default.
+	Window time.Duration `yaml:"window" validate:"min=0"`
+
+	// IsolationPeriod is how long a degraded shard is excluded from serving reads before
+	// it is given another chance. Zero uses the default.
+	IsolationPeriod time.Duration `yaml:"isolationPeriod" validate:"min=0"`
+}
+
+// ShardErrorBudgetOptions returns the runtime shard error budget options.
+func (l ShardErrorBudgetLimits) ShardErrorBudgetOptions(
+	defaults runtime.ShardErrorBudgetOptions,
+) runtime.ShardErrorBudgetOptions {
+	opts := defaults
+	opts.Enabled = true
+	if l.MaxErrors > 0 {
+		opts.MaxErrors = l.MaxErrors
+	}
+	if l.Window > 0 {
+		opts.Window = l.Window
+	}
+	if l.IsolationPeriod > 0 {
+		opts.IsolationPeriod = l.IsolationPeriod
+	}
+	return opts
+}
+
+// QueryAdmissionLimits contains configuration for rejecting large queries with an over
+// capacity error while recent garbage collection pauses or heap growth show the server
+// is under memory pressure.
+type QueryAdmissionLimits struct {
+	// GCPauseFraction is the fraction of time spent in garbage collection pauses between
+	// samples above which large queries are rejected. Zero disables the signal.
+	GCPauseFraction float64 `yaml:"gcPauseFraction" validate:"min=0,max=1"`
+
+	// HeapGrowthFactor is the factor by which the in use heap grows between samples above
+	// which large queries are rejected. Zero disables the signal.
+	HeapGrowthFactor float64 `yaml:"heapGrowthFactor" validate:"min=0"`
+
+	// SampleInterval is the minimum interval between samples of the runtime memory
+	// statistics. Zero uses the default.
+	SampleInterval time.Duration `yaml:"sampleInterval" validate:"min=0"`
+
+	// SmallQueryMaxLimit is the largest series limit for which a query is considered small
+	// and always admitted, queries without a limit are always considered large.
+	SmallQueryMaxLimit int `yaml:"smallQueryMaxLimit" validate:"min=0"`
+
+	// SmallQueryMaxRange is the longest time range for which a query is considered small.
+	// Zero does not restrict the range of small queries.
+	SmallQueryMaxRange time.Duration `yaml:"smallQueryMaxRange" validate:"min=0"`
+}
+
+// Options returns the query admission options.
+func (l QueryAdmissionLimits) Options() tchannelthrift.QueryAdmissionOptions {
+	return tchannelthrift.QueryAdmissionOptions{
+		GCPauseFraction:    l.GCPauseFraction,
+		HeapGrowthFactor:   l.HeapGrowthFactor,
+		SampleInterval:     l.SampleInterval,
+		SmallQueryMaxLimit: l.SmallQueryMaxLimit,
+		SmallQueryMaxRange: l.SmallQueryMaxRange,
+	}
 }
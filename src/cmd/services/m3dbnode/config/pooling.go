@@ -288,6 +288,10 @@ type PoolingPolicy struct {
 	// The general pool type (currently only supported: simple).
 	Type *PoolingType `yaml:"type"`
 
+	// Whether to track objects checked out of pools and report those never
+	// returned on the debug endpoint, expensive and for debugging only.
+	LeakDetectionEnabled bool `yaml:"leakDetectionEnabled"`
+
 	// The Bytes pool buckets to use.
 	BytesPool BucketPoolPolicy `yaml:"bytesPool"`
 
@@ -39,10 +39,20 @@ import (
 	xos "github.com/m3db/m3/src/x/os"
 )
 
+// Exit codes of the verify filesets mode.
+const (
+	verifyFileSetsErrExitCode     = 1
+	verifyFileSetsCorruptExitCode = 2
+)
+
 func main() {
 	var cfgOpts configflag.Options
 	cfgOpts.Register()
 
+	verifyFileSets := flag.Bool("verify-filesets", false,
+		"verify all filesets on disk, print a report and exit instead of "+
+			"starting, exits with 2 if any fileset is corrupt")
+
 	flag.Parse()
 
 	// Set globals for etcd related packages.
@@ -63,6 +73,22 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *verifyFileSets {
+		if cfg.DB == nil {
+			fmt.Fprintf(os.Stderr, "error verifying filesets: no db config\n")
+			os.Exit(verifyFileSetsErrExitCode)
+		}
+		corrupt, err := dbserver.VerifyFileSets(*cfg.DB, os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error verifying filesets: %v\n", err)
+			os.Exit(verifyFileSetsErrExitCode)
+		}
+		if corrupt {
+			os.Exit(verifyFileSetsCorruptExitCode)
+		}
+		os.Exit(0)
+	}
+
 	var (
 		numComponents     int
 		dbClientCh        chan client.Client
@@ -65,6 +65,10 @@ var (
 
 	defaultCarbonIngesterAggregationType = aggregation.Mean
 
+	defaultStatsDFlushInterval = 10 * time.Second
+	defaultStatsDPrefix        = "stats"
+	defaultStatsDPercentiles   = []float64{90}
+
 	defaultStorageQueryLimit = 10000
 )
 
@@ -124,6 +128,19 @@ type Configuration struct {
 	// Carbon is the carbon configuration.
 	Carbon *CarbonConfiguration `yaml:"carbon"`
 
+	// InfluxDB is the InfluxDB line protocol ingest configuration.
+	InfluxDB *InfluxDBConfiguration `yaml:"influxdb"`
+
+	// OTLP is the OpenTelemetry metrics ingest configuration.
+	OTLP *OTLPConfiguration `yaml:"otlp"`
+
+	// StatsD is the StatsD ingest configuration.
+	StatsD *StatsDConfiguration `yaml:"statsd"`
+
+	// Export is the bulk export API configuration, the API is disabled if
+	// not set.
+	Export *ExportConfiguration `yaml:"export"`
+
 	// Limits specifies limits on per-query resource usage.
 	Limits LimitsConfiguration `yaml:"limits"`
 
@@ -292,6 +309,127 @@ type CarbonConfiguration struct {
 	Ingester *CarbonIngesterConfiguration `yaml:"ingester"`
 }
 
+// InfluxDBFieldExpansion is how a field of an InfluxDB line protocol point is
+// expanded into a series.
+type InfluxDBFieldExpansion string
+
+const (
+	// InfluxDBFieldExpansionSuffix names the series after the measurement
+	// suffixed by the field key, e.g. "cpu_usage_idle".
+	InfluxDBFieldExpansionSuffix InfluxDBFieldExpansion = "suffix"
+	// InfluxDBFieldExpansionTag names the series after the measurement and
+	// stores the field key in a tag, e.g. "cpu{field="usage_idle"}".
+	InfluxDBFieldExpansionTag InfluxDBFieldExpansion = "tag"
+	// InfluxDBFieldExpansionMeasurement names the series after the measurement
+	// alone, discarding the field key. Only useful for fields that are the sole
+	// value of their measurement.
+	InfluxDBFieldExpansionMeasurement InfluxDBFieldExpansion = "measurement"
+	// InfluxDBFieldExpansionDrop drops the field.
+	InfluxDBFieldExpansionDrop InfluxDBFieldExpansion = "drop"
+)
+
+// InfluxDBConfiguration is the configuration for InfluxDB line protocol ingestion.
+type InfluxDBConfiguration struct {
+	// FieldRules control how fields are expanded into series, the first rule
+	// matching a field is used and fields matching no rule are expanded using
+	// the suffix expansion.
+	FieldRules []InfluxDBFieldRuleConfiguration `yaml:"fieldRules"`
+}
+
+// InfluxDBFieldRuleConfiguration is the configuration for a rule expanding
+// the fields of InfluxDB line protocol points into series.
+type InfluxDBFieldRuleConfiguration struct {
+	// Measurement is a regular expression the measurement must match, an
+	// empty expression matches all measurements.
+	Measurement string `yaml:"measurement"`
+	// Field is a regular expression the field key must match, an empty
+	// expression matches all fields.
+	Field string `yaml:"field"`
+	// Expansion is how matching fields are expanded into series.
+	Expansion InfluxDBFieldExpansion `yaml:"expansion" validate:"nonzero"`
+	// FieldTag is the name of the tag holding the field key for the tag
+	// expansion, defaults to "field".
+	FieldTag string `yaml:"fieldTag"`
+}
+
+// OTLPConfiguration is the configuration for the OpenTelemetry metrics
+// (OTLP/gRPC) ingest receiver.
+type OTLPConfiguration struct {
+	// ListenAddress is the address the OTLP gRPC receiver listens on.
+	ListenAddress string `yaml:"listenAddress" validate:"nonzero"`
+	// ResourceAttributes are the resource attributes added as tags to every
+	// series of the resource, other resource attributes are dropped.
+	ResourceAttributes []string `yaml:"resourceAttributes"`
+	// AttributeTags maps attribute keys to the name of the tag they are
+	// written as, unmapped attributes are written with their key sanitized
+	// to a valid tag name.
+	AttributeTags map[string]string `yaml:"attributeTags"`
+	// DropAttributes are the data point attributes that are not written as tags.
+	DropAttributes []string `yaml:"dropAttributes"`
+}
+
+// StatsDConfiguration is the configuration for the StatsD UDP ingest listener,
+// which aggregates metrics over the flush interval the same way statsd does
+// and writes the aggregated metrics as graphite paths.
+type StatsDConfiguration struct {
+	// ListenAddress is the UDP address to listen on.
+	ListenAddress string `yaml:"listenAddress" validate:"nonzero"`
+	// FlushInterval is the aggregation window, defaults to 10s.
+	FlushInterval time.Duration `yaml:"flushInterval"`
+	// Prefix is prepended to the names of aggregated metrics, defaults to "stats".
+	Prefix *string `yaml:"prefix"`
+	// Percentiles are the percentiles computed for timers, defaults to 90.
+	Percentiles []float64 `yaml:"percentiles"`
+	// MaxConcurrency is the max number of concurrent writes when flushing.
+	MaxConcurrency int `yaml:"maxConcurrency"`
+	// Policies are the storage policies aggregated metrics are written to, if
+	// not set aggregated metrics are written to the unaggregated namespace.
+	Policies []CarbonIngesterStoragePolicyConfiguration `yaml:"policies"`
+}
+
+// FlushIntervalOrDefault returns the flush interval or the default.
+func (c *StatsDConfiguration) FlushIntervalOrDefault() time.Duration {
+	if c.FlushInterval > 0 {
+		return c.FlushInterval
+	}
+
+	return defaultStatsDFlushInterval
+}
+
+// PrefixOrDefault returns the metric name prefix or the default.
+func (c *StatsDConfiguration) PrefixOrDefault() string {
+	if c.Prefix != nil {
+		return *c.Prefix
+	}
+
+	return defaultStatsDPrefix
+}
+
+// PercentilesOrDefault returns the timer percentiles or the default.
+func (c *StatsDConfiguration) PercentilesOrDefault() []float64 {
+	if len(c.Percentiles) > 0 {
+		return c.Percentiles
+	}
+
+	return defaultStatsDPercentiles
+}
+
+// ExportConfiguration is the configuration for the bulk export API, which
+// exports matched series to CSV or Parquet files as server side jobs.
+type ExportConfiguration struct {
+	// Directory is the local directory exports are written to.
+	Directory string `yaml:"directory" validate:"nonzero"`
+	// MaxConcurrentJobs is the max number of jobs run concurrently, defaults to 1.
+	MaxConcurrentJobs int `yaml:"maxConcurrentJobs"`
+	// MaxDatapointsPerSecond throttles each job, jobs are not throttled if zero.
+	MaxDatapointsPerSecond int `yaml:"maxDatapointsPerSecond"`
+	// FetchWindow is the time range fetched at a time, defaults to 1h.
+	FetchWindow time.Duration `yaml:"fetchWindow"`
+	// AllowUploads allows exports to be uploaded to object storage using
+	// pre-signed URLs.
+	AllowUploads bool `yaml:"allowUploads"`
+}
+
 // CarbonIngesterConfiguration is the configuration struct for carbon ingestion.
 type CarbonIngesterConfiguration struct {
 	// Deprecated: simply use the logger debug level, this has been deprecated
@@ -300,6 +438,9 @@ type CarbonIngesterConfiguration struct {
 	ListenAddress   string                            `yaml:"listenAddress"`
 	MaxConcurrency  int                               `yaml:"maxConcurrency"`
 	Rules           []CarbonIngesterRuleConfiguration `yaml:"rules"`
+	// PickleListenAddress is the address to listen on for metrics sent using
+	// the carbon pickle protocol, the pickle listener is disabled if not set.
+	PickleListenAddress string `yaml:"pickleListenAddress"`
 }
 
 // LookbackDurationOrDefault validates the LookbackDuration
@@ -374,6 +515,13 @@ type CarbonIngesterRuleConfiguration struct {
 	Continue    bool                                       `yaml:"continue"`
 	Aggregation CarbonIngesterAggregationConfiguration     `yaml:"aggregation"`
 	Policies    []CarbonIngesterStoragePolicyConfiguration `yaml:"policies"`
+	// Template maps the nodes of matching metric paths to tags instead of
+	// the default __g0__, __g1__, ... graphite tags. Each dot separated
+	// element names the tag of the node at its position, "measurement"
+	// elements are joined into the metric name, "_" elements are skipped
+	// and a "*" suffix on the last element maps all remaining nodes,
+	// e.g. "env.host.measurement*".
+	Template string `yaml:"template"`
 }
 
 // CarbonIngesterAggregationConfiguration is the configuration struct
@@ -23,6 +23,7 @@ package config
 import (
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/m3db/m3/src/query/models"
 	xconfig "github.com/m3db/m3/src/x/config"
@@ -268,3 +269,17 @@ func TestKeepNaNsDefault(t *testing.T) {
 	r = ResultOptions{}
 	assert.Equal(t, false, r.KeepNans)
 }
+
+func TestStatsDConfigurationDefaults(t *testing.T) {
+	var cfg StatsDConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte("listenAddress: 0.0.0.0:8125"), &cfg))
+	assert.Equal(t, 10*time.Second, cfg.FlushIntervalOrDefault())
+	assert.Equal(t, "stats", cfg.PrefixOrDefault())
+	assert.Equal(t, []float64{90}, cfg.PercentilesOrDefault())
+
+	config := "listenAddress: 0.0.0.0:8125\nflushInterval: 1m\nprefix: \"\"\npercentiles: [50, 99.9]"
+	require.NoError(t, yaml.Unmarshal([]byte(config), &cfg))
+	assert.Equal(t, time.Minute, cfg.FlushIntervalOrDefault())
+	assert.Equal(t, "", cfg.PrefixOrDefault())
+	assert.Equal(t, []float64{50, 99.9}, cfg.PercentilesOrDefault())
+}
@@ -0,0 +1,96 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs/clone"
+
+	"go.uber.org/zap"
+)
+
+var (
+	optSrcPathPrefix  = flag.String("src-path-prefix", "/var/lib/m3db", "Source Path prefix")
+	optSrcNamespace   = flag.String("src-namespace", "metrics", "Source Namespace")
+	optSrcSnapshots   = flag.Bool("src-snapshots", false, "Restore from snapshot filesets instead of flushed filesets")
+	optDestPathPrefix = flag.String("dest-path-prefix", "/tmp/m3db-restore", "Destination Path prefix")
+	optDestNamespace  = flag.String("dest-namespace", "metrics", "Destination Namespace")
+	optDestNumShards  = flag.Int("dest-num-shards", 0, "Destination number of shards")
+	optDestShards     = flag.String("dest-shards", "", "Comma separated destination shards to restore [all if empty]")
+)
+
+func main() {
+	flag.Parse()
+	if *optSrcPathPrefix == "" ||
+		*optDestPathPrefix == "" ||
+		*optSrcNamespace == "" ||
+		*optDestNamespace == "" ||
+		*optDestNumShards <= 0 {
+		flag.Usage()
+		os.Exit(1)
+	}
+
+	rawLogger, err := zap.NewDevelopment()
+	if err != nil {
+		log.Fatalf("unable to create logger: %+v", err)
+	}
+	logger := rawLogger.Sugar()
+
+	src := clone.RestoreSource{
+		PathPrefix:  *optSrcPathPrefix,
+		Namespace:   *optSrcNamespace,
+		FileSetType: persist.FileSetFlushType,
+	}
+	if *optSrcSnapshots {
+		src.FileSetType = persist.FileSetSnapshotType
+	}
+	dest := clone.RestoreDestination{
+		PathPrefix: *optDestPathPrefix,
+		Namespace:  *optDestNamespace,
+		NumShards:  *optDestNumShards,
+	}
+	if *optDestShards != "" {
+		for _, v := range strings.Split(*optDestShards, ",") {
+			shard, err := strconv.ParseUint(strings.TrimSpace(v), 10, 32)
+			if err != nil {
+				logger.Fatalf("invalid destination shard %s: %v", v, err)
+			}
+			dest.Shards = append(dest.Shards, uint32(shard))
+		}
+	}
+
+	logger.Infof("source: %+v", src)
+	logger.Infof("destination: %+v", dest)
+
+	restorer := clone.NewRestorer(clone.NewOptions())
+	result, err := restorer.Restore(src, dest)
+	if err != nil {
+		logger.Fatalf("unable to restore: %v", err)
+	}
+
+	logger.Infof("successfully restored data: %+v", result)
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+)
+
+var (
+	errBootstrapPeerPreferenceEmpty = errors.New(
+		"bootstrap peer preference must set a preferred isolation group or excluded hosts")
+	errBootstrapPeerPreferenceEmptyHostID = errors.New(
+		"bootstrap peer preference excluded host ID must not be empty")
+)
+
+// BootstrapPeerPreference is a preference for the peers that a set of shards
+// are bootstrapped from, so that bootstrap traffic can avoid overloaded hosts
+// or metered links between isolation groups.
+type BootstrapPeerPreference struct {
+	// Shards are the shards the preference applies to, if empty the
+	// preference applies to all shards.
+	Shards []uint32 `yaml:"shards"`
+
+	// PreferredIsolationGroup is the isolation group, typically the
+	// availability zone, of the peers that blocks are preferably streamed
+	// from. Blocks are streamed from peers in other isolation groups when
+	// no peer in the preferred isolation group has the block or streaming
+	// from them fails.
+	PreferredIsolationGroup string `yaml:"preferredIsolationGroup"`
+
+	// ExcludedHosts are the IDs of the hosts not to bootstrap from. The
+	// excluded hosts are only bootstrapped from if bootstrapping from the
+	// remaining peers fails, for instance because the bootstrap consistency
+	// level cannot be achieved without them.
+	ExcludedHosts []string `yaml:"excludedHosts"`
+}
+
+// Validate validates the bootstrap peer preference.
+func (p BootstrapPeerPreference) Validate() error {
+	if p.PreferredIsolationGroup == "" && len(p.ExcludedHosts) == 0 {
+		return errBootstrapPeerPreferenceEmpty
+	}
+	for _, id := range p.ExcludedHosts {
+		if id == "" {
+			return errBootstrapPeerPreferenceEmptyHostID
+		}
+	}
+	return nil
+}
+
+func (p BootstrapPeerPreference) appliesTo(shard uint32) bool {
+	if len(p.Shards) == 0 {
+		return true
+	}
+	for _, s := range p.Shards {
+		if s == shard {
+			return true
+		}
+	}
+	return false
+}
+
+func (p BootstrapPeerPreference) excludes(host topology.Host) bool {
+	for _, id := range p.ExcludedHosts {
+		if id == host.ID() {
+			return true
+		}
+	}
+	return false
+}
+
+func (p BootstrapPeerPreference) prefers(host topology.Host) bool {
+	return p.PreferredIsolationGroup != "" &&
+		p.PreferredIsolationGroup == host.IsolationGroup()
+}
+
+// BootstrapPeerPreferences are the preferences for the peers that shards are
+// bootstrapped from, the first preference that applies to a shard is used.
+type BootstrapPeerPreferences []BootstrapPeerPreference
+
+// Validate validates the bootstrap peer preferences.
+func (p BootstrapPeerPreferences) Validate() error {
+	for i, pref := range p {
+		if err := pref.Validate(); err != nil {
+			return fmt.Errorf("invalid bootstrap peer preference %d: %v", i, err)
+		}
+	}
+	return nil
+}
+
+// ForShard returns the preference that applies to a shard, if any.
+func (p BootstrapPeerPreferences) ForShard(shard uint32) (BootstrapPeerPreference, bool) {
+	for _, pref := range p {
+		if pref.appliesTo(shard) {
+			return pref, true
+		}
+	}
+	return BootstrapPeerPreference{}, false
+}
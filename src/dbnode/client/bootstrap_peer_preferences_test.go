@@ -0,0 +1,94 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBootstrapPeerPreferencesValidate(t *testing.T) {
+	valid := BootstrapPeerPreferences{
+		{Shards: []uint32{1, 2}, PreferredIsolationGroup: "us-east-1a"},
+		{ExcludedHosts: []string{"host3"}},
+	}
+	require.NoError(t, valid.Validate())
+
+	empty := BootstrapPeerPreferences{{Shards: []uint32{1}}}
+	require.Error(t, empty.Validate())
+
+	emptyHostID := BootstrapPeerPreferences{{ExcludedHosts: []string{""}}}
+	require.Error(t, emptyHostID.Validate())
+}
+
+func TestBootstrapPeerPreferencesForShard(t *testing.T) {
+	prefs := BootstrapPeerPreferences{
+		{Shards: []uint32{1, 2}, PreferredIsolationGroup: "us-east-1a"},
+		{ExcludedHosts: []string{"host3"}},
+	}
+
+	pref, ok := prefs.ForShard(2)
+	require.True(t, ok)
+	assert.Equal(t, "us-east-1a", pref.PreferredIsolationGroup)
+
+	pref, ok = prefs.ForShard(3)
+	require.True(t, ok)
+	assert.Equal(t, []string{"host3"}, pref.ExcludedHosts)
+
+	_, ok = BootstrapPeerPreferences(nil).ForShard(3)
+	require.False(t, ok)
+}
+
+func TestPeersWithPreference(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		hosts = []topology.Host{
+			topology.NewHostWithIsolationGroup("host1", "host1:9000", "us-east-1a"),
+			topology.NewHostWithIsolationGroup("host2", "host2:9000", "us-east-1b"),
+			topology.NewHostWithIsolationGroup("host3", "host3:9000", "us-east-1a"),
+		}
+		all []peer
+	)
+	for _, host := range hosts {
+		p := NewMockpeer(ctrl)
+		p.EXPECT().Host().Return(host).AnyTimes()
+		all = append(all, p)
+	}
+
+	pref := BootstrapPeerPreference{
+		PreferredIsolationGroup: "us-east-1a",
+		ExcludedHosts:           []string{"host3"},
+	}
+	peers := testPeers(all).withPreference(pref)
+	require.Equal(t, []peer{all[0], all[1]}, peers.peers)
+	assert.True(t, peers.isPreferred(all[0]))
+	assert.False(t, peers.isPreferred(all[1]))
+	assert.True(t, peers.isPreferred(all[2]))
+
+	assert.False(t, testPeers(all).isPreferred(all[0]))
+}
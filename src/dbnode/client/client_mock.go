@@ -32,12 +32,16 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -182,6 +186,36 @@ func (mr *MockSessionMockRecorder) WriteTagged(namespace, id, tags, t, value, un
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockSession)(nil).WriteTagged), namespace, id, tags, t, value, unit, annotation)
 }
 
+// WriteWithDispositions mocks base method
+func (m *MockSession) WriteWithDispositions(namespace, id ident.ID, t time.Time, value float64, unit time0.Unit, annotation []byte) (WriteDispositions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteWithDispositions", namespace, id, t, value, unit, annotation)
+	ret0, _ := ret[0].(WriteDispositions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteWithDispositions indicates an expected call of WriteWithDispositions
+func (mr *MockSessionMockRecorder) WriteWithDispositions(namespace, id, t, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteWithDispositions", reflect.TypeOf((*MockSession)(nil).WriteWithDispositions), namespace, id, t, value, unit, annotation)
+}
+
+// WriteTaggedWithDispositions mocks base method
+func (m *MockSession) WriteTaggedWithDispositions(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit time0.Unit, annotation []byte) (WriteDispositions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTaggedWithDispositions", namespace, id, tags, t, value, unit, annotation)
+	ret0, _ := ret[0].(WriteDispositions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteTaggedWithDispositions indicates an expected call of WriteTaggedWithDispositions
+func (mr *MockSessionMockRecorder) WriteTaggedWithDispositions(namespace, id, tags, t, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTaggedWithDispositions", reflect.TypeOf((*MockSession)(nil).WriteTaggedWithDispositions), namespace, id, tags, t, value, unit, annotation)
+}
+
 // Fetch mocks base method
 func (m *MockSession) Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error) {
 	m.ctrl.T.Helper()
@@ -275,6 +309,21 @@ func (mr *MockSessionMockRecorder) ShardID(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardID", reflect.TypeOf((*MockSession)(nil).ShardID), id)
 }
 
+// NamespaceShardID mocks base method
+func (m *MockSession) NamespaceShardID(namespace, id ident.ID) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceShardID", namespace, id)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NamespaceShardID indicates an expected call of NamespaceShardID
+func (mr *MockSessionMockRecorder) NamespaceShardID(namespace, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceShardID", reflect.TypeOf((*MockSession)(nil).NamespaceShardID), namespace, id)
+}
+
 // IteratorPools mocks base method
 func (m *MockSession) IteratorPools() (encoding.IteratorPools, error) {
 	m.ctrl.T.Helper()
@@ -770,6 +819,36 @@ func (mr *MockAdminSessionMockRecorder) WriteTagged(namespace, id, tags, t, valu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockAdminSession)(nil).WriteTagged), namespace, id, tags, t, value, unit, annotation)
 }
 
+// WriteWithDispositions mocks base method
+func (m *MockAdminSession) WriteWithDispositions(namespace, id ident.ID, t time.Time, value float64, unit time0.Unit, annotation []byte) (WriteDispositions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteWithDispositions", namespace, id, t, value, unit, annotation)
+	ret0, _ := ret[0].(WriteDispositions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteWithDispositions indicates an expected call of WriteWithDispositions
+func (mr *MockAdminSessionMockRecorder) WriteWithDispositions(namespace, id, t, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteWithDispositions", reflect.TypeOf((*MockAdminSession)(nil).WriteWithDispositions), namespace, id, t, value, unit, annotation)
+}
+
+// WriteTaggedWithDispositions mocks base method
+func (m *MockAdminSession) WriteTaggedWithDispositions(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit time0.Unit, annotation []byte) (WriteDispositions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTaggedWithDispositions", namespace, id, tags, t, value, unit, annotation)
+	ret0, _ := ret[0].(WriteDispositions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteTaggedWithDispositions indicates an expected call of WriteTaggedWithDispositions
+func (mr *MockAdminSessionMockRecorder) WriteTaggedWithDispositions(namespace, id, tags, t, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTaggedWithDispositions", reflect.TypeOf((*MockAdminSession)(nil).WriteTaggedWithDispositions), namespace, id, tags, t, value, unit, annotation)
+}
+
 // Fetch mocks base method
 func (m *MockAdminSession) Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error) {
 	m.ctrl.T.Helper()
@@ -863,6 +942,21 @@ func (mr *MockAdminSessionMockRecorder) ShardID(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardID", reflect.TypeOf((*MockAdminSession)(nil).ShardID), id)
 }
 
+// NamespaceShardID mocks base method
+func (m *MockAdminSession) NamespaceShardID(namespace, id ident.ID) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceShardID", namespace, id)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NamespaceShardID indicates an expected call of NamespaceShardID
+func (mr *MockAdminSessionMockRecorder) NamespaceShardID(namespace, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceShardID", reflect.TypeOf((*MockAdminSession)(nil).NamespaceShardID), namespace, id)
+}
+
 // IteratorPools mocks base method
 func (m *MockAdminSession) IteratorPools() (encoding.IteratorPools, error) {
 	m.ctrl.T.Helper()
@@ -950,6 +1044,36 @@ func (mr *MockAdminSessionMockRecorder) Truncate(namespace interface{}) *gomock.
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Truncate", reflect.TypeOf((*MockAdminSession)(nil).Truncate), namespace)
 }
 
+// FetchFromHost mocks base method
+func (m *MockAdminSession) FetchFromHost(namespace, id ident.ID, startInclusive, endExclusive time.Time, hostID string) (encoding.SeriesIterator, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchFromHost", namespace, id, startInclusive, endExclusive, hostID)
+	ret0, _ := ret[0].(encoding.SeriesIterator)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FetchFromHost indicates an expected call of FetchFromHost
+func (mr *MockAdminSessionMockRecorder) FetchFromHost(namespace, id, startInclusive, endExclusive, hostID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchFromHost", reflect.TypeOf((*MockAdminSession)(nil).FetchFromHost), namespace, id, startInclusive, endExclusive, hostID)
+}
+
+// ShardWatermarks mocks base method
+func (m *MockAdminSession) ShardWatermarks(namespace ident.ID) (map[uint32]ShardWatermark, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardWatermarks", namespace)
+	ret0, _ := ret[0].(map[uint32]ShardWatermark)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardWatermarks indicates an expected call of ShardWatermarks
+func (mr *MockAdminSessionMockRecorder) ShardWatermarks(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardWatermarks", reflect.TypeOf((*MockAdminSession)(nil).ShardWatermarks), namespace)
+}
+
 // FetchBootstrapBlocksFromPeers mocks base method
 func (m *MockAdminSession) FetchBootstrapBlocksFromPeers(namespace namespace.Metadata, shard uint32, start, end time.Time, opts result.Options) (result.ShardResult, error) {
 	m.ctrl.T.Helper()
@@ -1010,6 +1134,65 @@ func (mr *MockAdminSessionMockRecorder) FetchBlocksFromPeers(namespace, shard, c
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlocksFromPeers", reflect.TypeOf((*MockAdminSession)(nil).FetchBlocksFromPeers), namespace, shard, consistencyLevel, metadatas, opts)
 }
 
+// MockReplicaSelector is a mock of ReplicaSelector interface
+type MockReplicaSelector struct {
+	ctrl     *gomock.Controller
+	recorder *MockReplicaSelectorMockRecorder
+}
+
+// MockReplicaSelectorMockRecorder is the mock recorder for MockReplicaSelector
+type MockReplicaSelectorMockRecorder struct {
+	mock *MockReplicaSelector
+}
+
+// NewMockReplicaSelector creates a new mock instance
+func NewMockReplicaSelector(ctrl *gomock.Controller) *MockReplicaSelector {
+	mock := &MockReplicaSelector{ctrl: ctrl}
+	mock.recorder = &MockReplicaSelectorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockReplicaSelector) EXPECT() *MockReplicaSelectorMockRecorder {
+	return m.recorder
+}
+
+// Order mocks base method
+func (m *MockReplicaSelector) Order(replicas []Replica) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Order", replicas)
+}
+
+// Order indicates an expected call of Order
+func (mr *MockReplicaSelectorMockRecorder) Order(replicas interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Order", reflect.TypeOf((*MockReplicaSelector)(nil).Order), replicas)
+}
+
+// RequestStarted mocks base method
+func (m *MockReplicaSelector) RequestStarted(host topology.Host) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RequestStarted", host)
+}
+
+// RequestStarted indicates an expected call of RequestStarted
+func (mr *MockReplicaSelectorMockRecorder) RequestStarted(host interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestStarted", reflect.TypeOf((*MockReplicaSelector)(nil).RequestStarted), host)
+}
+
+// RequestCompleted mocks base method
+func (m *MockReplicaSelector) RequestCompleted(host topology.Host, latency time.Duration, err error) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RequestCompleted", host, latency, err)
+}
+
+// RequestCompleted indicates an expected call of RequestCompleted
+func (mr *MockReplicaSelectorMockRecorder) RequestCompleted(host, latency, err interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RequestCompleted", reflect.TypeOf((*MockReplicaSelector)(nil).RequestCompleted), host, latency, err)
+}
+
 // MockOptions is a mock of Options interface
 type MockOptions struct {
 	ctrl     *gomock.Controller
@@ -1285,6 +1468,34 @@ func (mr *MockOptionsMockRecorder) ChannelOptions() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChannelOptions", reflect.TypeOf((*MockOptions)(nil).ChannelOptions))
 }
 
+// SetPeerStats mocks base method
+func (m *MockOptions) SetPeerStats(value peerstats.Tracker) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPeerStats", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetPeerStats indicates an expected call of SetPeerStats
+func (mr *MockOptionsMockRecorder) SetPeerStats(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPeerStats", reflect.TypeOf((*MockOptions)(nil).SetPeerStats), value)
+}
+
+// PeerStats mocks base method
+func (m *MockOptions) PeerStats() peerstats.Tracker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerStats")
+	ret0, _ := ret[0].(peerstats.Tracker)
+	return ret0
+}
+
+// PeerStats indicates an expected call of PeerStats
+func (mr *MockOptionsMockRecorder) PeerStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerStats", reflect.TypeOf((*MockOptions)(nil).PeerStats))
+}
+
 // SetMaxConnectionCount mocks base method
 func (m *MockOptions) SetMaxConnectionCount(value int) Options {
 	m.ctrl.T.Helper()
@@ -2377,279 +2588,615 @@ func (mr *MockOptionsMockRecorder) UseV2BatchAPIs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseV2BatchAPIs", reflect.TypeOf((*MockOptions)(nil).UseV2BatchAPIs))
 }
 
-// MockAdminOptions is a mock of AdminOptions interface
-type MockAdminOptions struct {
-	ctrl     *gomock.Controller
-	recorder *MockAdminOptionsMockRecorder
+// SetReadRepairEnabled mocks base method
+func (m *MockOptions) SetReadRepairEnabled(value bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadRepairEnabled", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
 }
 
-// MockAdminOptionsMockRecorder is the mock recorder for MockAdminOptions
-type MockAdminOptionsMockRecorder struct {
-	mock *MockAdminOptions
+// SetReadRepairEnabled indicates an expected call of SetReadRepairEnabled
+func (mr *MockOptionsMockRecorder) SetReadRepairEnabled(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadRepairEnabled", reflect.TypeOf((*MockOptions)(nil).SetReadRepairEnabled), value)
 }
 
-// NewMockAdminOptions creates a new mock instance
-func NewMockAdminOptions(ctrl *gomock.Controller) *MockAdminOptions {
-	mock := &MockAdminOptions{ctrl: ctrl}
-	mock.recorder = &MockAdminOptionsMockRecorder{mock}
-	return mock
+// ReadRepairEnabled mocks base method
+func (m *MockOptions) ReadRepairEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRepairEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
 }
 
-// EXPECT returns an object that allows the caller to indicate expected use
-func (m *MockAdminOptions) EXPECT() *MockAdminOptionsMockRecorder {
-	return m.recorder
+// ReadRepairEnabled indicates an expected call of ReadRepairEnabled
+func (mr *MockOptionsMockRecorder) ReadRepairEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRepairEnabled", reflect.TypeOf((*MockOptions)(nil).ReadRepairEnabled))
 }
 
-// Validate mocks base method
-func (m *MockAdminOptions) Validate() error {
+// SetReadRepairQueueSize mocks base method
+func (m *MockOptions) SetReadRepairQueueSize(value int) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Validate")
-	ret0, _ := ret[0].(error)
+	ret := m.ctrl.Call(m, "SetReadRepairQueueSize", value)
+	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// Validate indicates an expected call of Validate
-func (mr *MockAdminOptionsMockRecorder) Validate() *gomock.Call {
+// SetReadRepairQueueSize indicates an expected call of SetReadRepairQueueSize
+func (mr *MockOptionsMockRecorder) SetReadRepairQueueSize(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockAdminOptions)(nil).Validate))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadRepairQueueSize", reflect.TypeOf((*MockOptions)(nil).SetReadRepairQueueSize), value)
 }
 
-// SetEncodingM3TSZ mocks base method
-func (m *MockAdminOptions) SetEncodingM3TSZ() Options {
+// ReadRepairQueueSize mocks base method
+func (m *MockOptions) ReadRepairQueueSize() int {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetEncodingM3TSZ")
-	ret0, _ := ret[0].(Options)
+	ret := m.ctrl.Call(m, "ReadRepairQueueSize")
+	ret0, _ := ret[0].(int)
 	return ret0
 }
 
-// SetEncodingM3TSZ indicates an expected call of SetEncodingM3TSZ
-func (mr *MockAdminOptionsMockRecorder) SetEncodingM3TSZ() *gomock.Call {
+// ReadRepairQueueSize indicates an expected call of ReadRepairQueueSize
+func (mr *MockOptionsMockRecorder) ReadRepairQueueSize() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncodingM3TSZ", reflect.TypeOf((*MockAdminOptions)(nil).SetEncodingM3TSZ))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRepairQueueSize", reflect.TypeOf((*MockOptions)(nil).ReadRepairQueueSize))
 }
 
-// SetEncodingProto mocks base method
-func (m *MockAdminOptions) SetEncodingProto(encodingOpts encoding.Options) Options {
+// SetFetchMergeReplicaBlocks mocks base method
+func (m *MockOptions) SetFetchMergeReplicaBlocks(value bool) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetEncodingProto", encodingOpts)
+	ret := m.ctrl.Call(m, "SetFetchMergeReplicaBlocks", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetEncodingProto indicates an expected call of SetEncodingProto
-func (mr *MockAdminOptionsMockRecorder) SetEncodingProto(encodingOpts interface{}) *gomock.Call {
+// SetFetchMergeReplicaBlocks indicates an expected call of SetFetchMergeReplicaBlocks
+func (mr *MockOptionsMockRecorder) SetFetchMergeReplicaBlocks(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncodingProto", reflect.TypeOf((*MockAdminOptions)(nil).SetEncodingProto), encodingOpts)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFetchMergeReplicaBlocks", reflect.TypeOf((*MockOptions)(nil).SetFetchMergeReplicaBlocks), value)
 }
 
-// IsSetEncodingProto mocks base method
-func (m *MockAdminOptions) IsSetEncodingProto() bool {
+// FetchMergeReplicaBlocks mocks base method
+func (m *MockOptions) FetchMergeReplicaBlocks() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "IsSetEncodingProto")
+	ret := m.ctrl.Call(m, "FetchMergeReplicaBlocks")
 	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// IsSetEncodingProto indicates an expected call of IsSetEncodingProto
-func (mr *MockAdminOptionsMockRecorder) IsSetEncodingProto() *gomock.Call {
+// FetchMergeReplicaBlocks indicates an expected call of FetchMergeReplicaBlocks
+func (mr *MockOptionsMockRecorder) FetchMergeReplicaBlocks() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSetEncodingProto", reflect.TypeOf((*MockAdminOptions)(nil).IsSetEncodingProto))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchMergeReplicaBlocks", reflect.TypeOf((*MockOptions)(nil).FetchMergeReplicaBlocks))
 }
 
-// SetRuntimeOptionsManager mocks base method
-func (m *MockAdminOptions) SetRuntimeOptionsManager(value runtime.OptionsManager) Options {
+// SetReplicaSelector mocks base method
+func (m *MockOptions) SetReplicaSelector(value ReplicaSelector) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetRuntimeOptionsManager", value)
+	ret := m.ctrl.Call(m, "SetReplicaSelector", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetRuntimeOptionsManager indicates an expected call of SetRuntimeOptionsManager
-func (mr *MockAdminOptionsMockRecorder) SetRuntimeOptionsManager(value interface{}) *gomock.Call {
+// SetReplicaSelector indicates an expected call of SetReplicaSelector
+func (mr *MockOptionsMockRecorder) SetReplicaSelector(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRuntimeOptionsManager", reflect.TypeOf((*MockAdminOptions)(nil).SetRuntimeOptionsManager), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReplicaSelector", reflect.TypeOf((*MockOptions)(nil).SetReplicaSelector), value)
 }
 
-// RuntimeOptionsManager mocks base method
-func (m *MockAdminOptions) RuntimeOptionsManager() runtime.OptionsManager {
+// ReplicaSelector mocks base method
+func (m *MockOptions) ReplicaSelector() ReplicaSelector {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "RuntimeOptionsManager")
-	ret0, _ := ret[0].(runtime.OptionsManager)
+	ret := m.ctrl.Call(m, "ReplicaSelector")
+	ret0, _ := ret[0].(ReplicaSelector)
 	return ret0
 }
 
-// RuntimeOptionsManager indicates an expected call of RuntimeOptionsManager
-func (mr *MockAdminOptionsMockRecorder) RuntimeOptionsManager() *gomock.Call {
+// ReplicaSelector indicates an expected call of ReplicaSelector
+func (mr *MockOptionsMockRecorder) ReplicaSelector() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RuntimeOptionsManager", reflect.TypeOf((*MockAdminOptions)(nil).RuntimeOptionsManager))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplicaSelector", reflect.TypeOf((*MockOptions)(nil).ReplicaSelector))
 }
 
-// SetClockOptions mocks base method
-func (m *MockAdminOptions) SetClockOptions(value clock.Options) Options {
+// SetWriteIdempotencyEnabled mocks base method
+func (m *MockOptions) SetWriteIdempotencyEnabled(value bool) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetClockOptions", value)
+	ret := m.ctrl.Call(m, "SetWriteIdempotencyEnabled", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetClockOptions indicates an expected call of SetClockOptions
-func (mr *MockAdminOptionsMockRecorder) SetClockOptions(value interface{}) *gomock.Call {
+// SetWriteIdempotencyEnabled indicates an expected call of SetWriteIdempotencyEnabled
+func (mr *MockOptionsMockRecorder) SetWriteIdempotencyEnabled(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClockOptions", reflect.TypeOf((*MockAdminOptions)(nil).SetClockOptions), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteIdempotencyEnabled", reflect.TypeOf((*MockOptions)(nil).SetWriteIdempotencyEnabled), value)
 }
 
-// ClockOptions mocks base method
-func (m *MockAdminOptions) ClockOptions() clock.Options {
+// WriteIdempotencyEnabled mocks base method
+func (m *MockOptions) WriteIdempotencyEnabled() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ClockOptions")
-	ret0, _ := ret[0].(clock.Options)
+	ret := m.ctrl.Call(m, "WriteIdempotencyEnabled")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// ClockOptions indicates an expected call of ClockOptions
-func (mr *MockAdminOptionsMockRecorder) ClockOptions() *gomock.Call {
+// WriteIdempotencyEnabled indicates an expected call of WriteIdempotencyEnabled
+func (mr *MockOptionsMockRecorder) WriteIdempotencyEnabled() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClockOptions", reflect.TypeOf((*MockAdminOptions)(nil).ClockOptions))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteIdempotencyEnabled", reflect.TypeOf((*MockOptions)(nil).WriteIdempotencyEnabled))
 }
 
-// SetInstrumentOptions mocks base method
-func (m *MockAdminOptions) SetInstrumentOptions(value instrument.Options) Options {
+// SetWriteDurability mocks base method
+func (m *MockOptions) SetWriteDurability(value ts.Durability) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetInstrumentOptions", value)
+	ret := m.ctrl.Call(m, "SetWriteDurability", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetInstrumentOptions indicates an expected call of SetInstrumentOptions
-func (mr *MockAdminOptionsMockRecorder) SetInstrumentOptions(value interface{}) *gomock.Call {
+// SetWriteDurability indicates an expected call of SetWriteDurability
+func (mr *MockOptionsMockRecorder) SetWriteDurability(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstrumentOptions", reflect.TypeOf((*MockAdminOptions)(nil).SetInstrumentOptions), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDurability", reflect.TypeOf((*MockOptions)(nil).SetWriteDurability), value)
 }
 
-// InstrumentOptions mocks base method
-func (m *MockAdminOptions) InstrumentOptions() instrument.Options {
+// WriteDurability mocks base method
+func (m *MockOptions) WriteDurability() ts.Durability {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InstrumentOptions")
-	ret0, _ := ret[0].(instrument.Options)
+	ret := m.ctrl.Call(m, "WriteDurability")
+	ret0, _ := ret[0].(ts.Durability)
 	return ret0
 }
 
-// InstrumentOptions indicates an expected call of InstrumentOptions
-func (mr *MockAdminOptionsMockRecorder) InstrumentOptions() *gomock.Call {
+// WriteDurability indicates an expected call of WriteDurability
+func (mr *MockOptionsMockRecorder) WriteDurability() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstrumentOptions", reflect.TypeOf((*MockAdminOptions)(nil).InstrumentOptions))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteDurability", reflect.TypeOf((*MockOptions)(nil).WriteDurability))
 }
 
-// SetTopologyInitializer mocks base method
-func (m *MockAdminOptions) SetTopologyInitializer(value topology.Initializer) Options {
+// SetWriteSpillEnabled mocks base method
+func (m *MockOptions) SetWriteSpillEnabled(value bool) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetTopologyInitializer", value)
+	ret := m.ctrl.Call(m, "SetWriteSpillEnabled", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetTopologyInitializer indicates an expected call of SetTopologyInitializer
-func (mr *MockAdminOptionsMockRecorder) SetTopologyInitializer(value interface{}) *gomock.Call {
+// SetWriteSpillEnabled indicates an expected call of SetWriteSpillEnabled
+func (mr *MockOptionsMockRecorder) SetWriteSpillEnabled(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTopologyInitializer", reflect.TypeOf((*MockAdminOptions)(nil).SetTopologyInitializer), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillEnabled", reflect.TypeOf((*MockOptions)(nil).SetWriteSpillEnabled), value)
 }
 
-// TopologyInitializer mocks base method
-func (m *MockAdminOptions) TopologyInitializer() topology.Initializer {
+// WriteSpillEnabled mocks base method
+func (m *MockOptions) WriteSpillEnabled() bool {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "TopologyInitializer")
-	ret0, _ := ret[0].(topology.Initializer)
+	ret := m.ctrl.Call(m, "WriteSpillEnabled")
+	ret0, _ := ret[0].(bool)
 	return ret0
 }
 
-// TopologyInitializer indicates an expected call of TopologyInitializer
-func (mr *MockAdminOptionsMockRecorder) TopologyInitializer() *gomock.Call {
+// WriteSpillEnabled indicates an expected call of WriteSpillEnabled
+func (mr *MockOptionsMockRecorder) WriteSpillEnabled() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopologyInitializer", reflect.TypeOf((*MockAdminOptions)(nil).TopologyInitializer))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillEnabled", reflect.TypeOf((*MockOptions)(nil).WriteSpillEnabled))
 }
 
-// SetReadConsistencyLevel mocks base method
-func (m *MockAdminOptions) SetReadConsistencyLevel(value topology.ReadConsistencyLevel) Options {
+// SetWriteSpillPath mocks base method
+func (m *MockOptions) SetWriteSpillPath(value string) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetReadConsistencyLevel", value)
+	ret := m.ctrl.Call(m, "SetWriteSpillPath", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetReadConsistencyLevel indicates an expected call of SetReadConsistencyLevel
-func (mr *MockAdminOptionsMockRecorder) SetReadConsistencyLevel(value interface{}) *gomock.Call {
+// SetWriteSpillPath indicates an expected call of SetWriteSpillPath
+func (mr *MockOptionsMockRecorder) SetWriteSpillPath(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).SetReadConsistencyLevel), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillPath", reflect.TypeOf((*MockOptions)(nil).SetWriteSpillPath), value)
 }
 
-// ReadConsistencyLevel mocks base method
-func (m *MockAdminOptions) ReadConsistencyLevel() topology.ReadConsistencyLevel {
+// WriteSpillPath mocks base method
+func (m *MockOptions) WriteSpillPath() string {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ReadConsistencyLevel")
-	ret0, _ := ret[0].(topology.ReadConsistencyLevel)
+	ret := m.ctrl.Call(m, "WriteSpillPath")
+	ret0, _ := ret[0].(string)
 	return ret0
 }
 
-// ReadConsistencyLevel indicates an expected call of ReadConsistencyLevel
-func (mr *MockAdminOptionsMockRecorder) ReadConsistencyLevel() *gomock.Call {
+// WriteSpillPath indicates an expected call of WriteSpillPath
+func (mr *MockOptionsMockRecorder) WriteSpillPath() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).ReadConsistencyLevel))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillPath", reflect.TypeOf((*MockOptions)(nil).WriteSpillPath))
 }
 
-// SetWriteConsistencyLevel mocks base method
-func (m *MockAdminOptions) SetWriteConsistencyLevel(value topology.ConsistencyLevel) Options {
+// SetWriteSpillMaxBytes mocks base method
+func (m *MockOptions) SetWriteSpillMaxBytes(value int64) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetWriteConsistencyLevel", value)
+	ret := m.ctrl.Call(m, "SetWriteSpillMaxBytes", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetWriteConsistencyLevel indicates an expected call of SetWriteConsistencyLevel
-func (mr *MockAdminOptionsMockRecorder) SetWriteConsistencyLevel(value interface{}) *gomock.Call {
+// SetWriteSpillMaxBytes indicates an expected call of SetWriteSpillMaxBytes
+func (mr *MockOptionsMockRecorder) SetWriteSpillMaxBytes(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteConsistencyLevel), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillMaxBytes", reflect.TypeOf((*MockOptions)(nil).SetWriteSpillMaxBytes), value)
 }
 
-// WriteConsistencyLevel mocks base method
-func (m *MockAdminOptions) WriteConsistencyLevel() topology.ConsistencyLevel {
+// WriteSpillMaxBytes mocks base method
+func (m *MockOptions) WriteSpillMaxBytes() int64 {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "WriteConsistencyLevel")
-	ret0, _ := ret[0].(topology.ConsistencyLevel)
+	ret := m.ctrl.Call(m, "WriteSpillMaxBytes")
+	ret0, _ := ret[0].(int64)
 	return ret0
 }
 
-// WriteConsistencyLevel indicates an expected call of WriteConsistencyLevel
-func (mr *MockAdminOptionsMockRecorder) WriteConsistencyLevel() *gomock.Call {
+// WriteSpillMaxBytes indicates an expected call of WriteSpillMaxBytes
+func (mr *MockOptionsMockRecorder) WriteSpillMaxBytes() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).WriteConsistencyLevel))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillMaxBytes", reflect.TypeOf((*MockOptions)(nil).WriteSpillMaxBytes))
 }
 
-// SetChannelOptions mocks base method
-func (m *MockAdminOptions) SetChannelOptions(value *tchannel_go.ChannelOptions) Options {
+// SetWriteSpillReplayInterval mocks base method
+func (m *MockOptions) SetWriteSpillReplayInterval(value time.Duration) Options {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetChannelOptions", value)
+	ret := m.ctrl.Call(m, "SetWriteSpillReplayInterval", value)
 	ret0, _ := ret[0].(Options)
 	return ret0
 }
 
-// SetChannelOptions indicates an expected call of SetChannelOptions
-func (mr *MockAdminOptionsMockRecorder) SetChannelOptions(value interface{}) *gomock.Call {
+// SetWriteSpillReplayInterval indicates an expected call of SetWriteSpillReplayInterval
+func (mr *MockOptionsMockRecorder) SetWriteSpillReplayInterval(value interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChannelOptions", reflect.TypeOf((*MockAdminOptions)(nil).SetChannelOptions), value)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillReplayInterval", reflect.TypeOf((*MockOptions)(nil).SetWriteSpillReplayInterval), value)
 }
 
-// ChannelOptions mocks base method
-func (m *MockAdminOptions) ChannelOptions() *tchannel_go.ChannelOptions {
+// WriteSpillReplayInterval mocks base method
+func (m *MockOptions) WriteSpillReplayInterval() time.Duration {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "ChannelOptions")
-	ret0, _ := ret[0].(*tchannel_go.ChannelOptions)
+	ret := m.ctrl.Call(m, "WriteSpillReplayInterval")
+	ret0, _ := ret[0].(time.Duration)
 	return ret0
 }
 
-// ChannelOptions indicates an expected call of ChannelOptions
+// WriteSpillReplayInterval indicates an expected call of WriteSpillReplayInterval
+func (mr *MockOptionsMockRecorder) WriteSpillReplayInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillReplayInterval", reflect.TypeOf((*MockOptions)(nil).WriteSpillReplayInterval))
+}
+
+// SetNamespaceShardKeyStrategies mocks base method
+func (m *MockOptions) SetNamespaceShardKeyStrategies(value map[string]string) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNamespaceShardKeyStrategies", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetNamespaceShardKeyStrategies indicates an expected call of SetNamespaceShardKeyStrategies
+func (mr *MockOptionsMockRecorder) SetNamespaceShardKeyStrategies(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNamespaceShardKeyStrategies", reflect.TypeOf((*MockOptions)(nil).SetNamespaceShardKeyStrategies), value)
+}
+
+// NamespaceShardKeyStrategies mocks base method
+func (m *MockOptions) NamespaceShardKeyStrategies() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceShardKeyStrategies")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// NamespaceShardKeyStrategies indicates an expected call of NamespaceShardKeyStrategies
+func (mr *MockOptionsMockRecorder) NamespaceShardKeyStrategies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceShardKeyStrategies", reflect.TypeOf((*MockOptions)(nil).NamespaceShardKeyStrategies))
+}
+
+// MockAdminOptions is a mock of AdminOptions interface
+type MockAdminOptions struct {
+	ctrl     *gomock.Controller
+	recorder *MockAdminOptionsMockRecorder
+}
+
+// MockAdminOptionsMockRecorder is the mock recorder for MockAdminOptions
+type MockAdminOptionsMockRecorder struct {
+	mock *MockAdminOptions
+}
+
+// NewMockAdminOptions creates a new mock instance
+func NewMockAdminOptions(ctrl *gomock.Controller) *MockAdminOptions {
+	mock := &MockAdminOptions{ctrl: ctrl}
+	mock.recorder = &MockAdminOptionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockAdminOptions) EXPECT() *MockAdminOptionsMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method
+func (m *MockAdminOptions) Validate() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate
+func (mr *MockAdminOptionsMockRecorder) Validate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockAdminOptions)(nil).Validate))
+}
+
+// SetEncodingM3TSZ mocks base method
+func (m *MockAdminOptions) SetEncodingM3TSZ() Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEncodingM3TSZ")
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetEncodingM3TSZ indicates an expected call of SetEncodingM3TSZ
+func (mr *MockAdminOptionsMockRecorder) SetEncodingM3TSZ() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncodingM3TSZ", reflect.TypeOf((*MockAdminOptions)(nil).SetEncodingM3TSZ))
+}
+
+// SetEncodingProto mocks base method
+func (m *MockAdminOptions) SetEncodingProto(encodingOpts encoding.Options) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetEncodingProto", encodingOpts)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetEncodingProto indicates an expected call of SetEncodingProto
+func (mr *MockAdminOptionsMockRecorder) SetEncodingProto(encodingOpts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetEncodingProto", reflect.TypeOf((*MockAdminOptions)(nil).SetEncodingProto), encodingOpts)
+}
+
+// IsSetEncodingProto mocks base method
+func (m *MockAdminOptions) IsSetEncodingProto() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsSetEncodingProto")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsSetEncodingProto indicates an expected call of IsSetEncodingProto
+func (mr *MockAdminOptionsMockRecorder) IsSetEncodingProto() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsSetEncodingProto", reflect.TypeOf((*MockAdminOptions)(nil).IsSetEncodingProto))
+}
+
+// SetRuntimeOptionsManager mocks base method
+func (m *MockAdminOptions) SetRuntimeOptionsManager(value runtime.OptionsManager) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRuntimeOptionsManager", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetRuntimeOptionsManager indicates an expected call of SetRuntimeOptionsManager
+func (mr *MockAdminOptionsMockRecorder) SetRuntimeOptionsManager(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRuntimeOptionsManager", reflect.TypeOf((*MockAdminOptions)(nil).SetRuntimeOptionsManager), value)
+}
+
+// RuntimeOptionsManager mocks base method
+func (m *MockAdminOptions) RuntimeOptionsManager() runtime.OptionsManager {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RuntimeOptionsManager")
+	ret0, _ := ret[0].(runtime.OptionsManager)
+	return ret0
+}
+
+// RuntimeOptionsManager indicates an expected call of RuntimeOptionsManager
+func (mr *MockAdminOptionsMockRecorder) RuntimeOptionsManager() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RuntimeOptionsManager", reflect.TypeOf((*MockAdminOptions)(nil).RuntimeOptionsManager))
+}
+
+// SetClockOptions mocks base method
+func (m *MockAdminOptions) SetClockOptions(value clock.Options) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetClockOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetClockOptions indicates an expected call of SetClockOptions
+func (mr *MockAdminOptionsMockRecorder) SetClockOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetClockOptions", reflect.TypeOf((*MockAdminOptions)(nil).SetClockOptions), value)
+}
+
+// ClockOptions mocks base method
+func (m *MockAdminOptions) ClockOptions() clock.Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClockOptions")
+	ret0, _ := ret[0].(clock.Options)
+	return ret0
+}
+
+// ClockOptions indicates an expected call of ClockOptions
+func (mr *MockAdminOptionsMockRecorder) ClockOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClockOptions", reflect.TypeOf((*MockAdminOptions)(nil).ClockOptions))
+}
+
+// SetInstrumentOptions mocks base method
+func (m *MockAdminOptions) SetInstrumentOptions(value instrument.Options) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInstrumentOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetInstrumentOptions indicates an expected call of SetInstrumentOptions
+func (mr *MockAdminOptionsMockRecorder) SetInstrumentOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstrumentOptions", reflect.TypeOf((*MockAdminOptions)(nil).SetInstrumentOptions), value)
+}
+
+// InstrumentOptions mocks base method
+func (m *MockAdminOptions) InstrumentOptions() instrument.Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstrumentOptions")
+	ret0, _ := ret[0].(instrument.Options)
+	return ret0
+}
+
+// InstrumentOptions indicates an expected call of InstrumentOptions
+func (mr *MockAdminOptionsMockRecorder) InstrumentOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstrumentOptions", reflect.TypeOf((*MockAdminOptions)(nil).InstrumentOptions))
+}
+
+// SetTopologyInitializer mocks base method
+func (m *MockAdminOptions) SetTopologyInitializer(value topology.Initializer) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTopologyInitializer", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetTopologyInitializer indicates an expected call of SetTopologyInitializer
+func (mr *MockAdminOptionsMockRecorder) SetTopologyInitializer(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTopologyInitializer", reflect.TypeOf((*MockAdminOptions)(nil).SetTopologyInitializer), value)
+}
+
+// TopologyInitializer mocks base method
+func (m *MockAdminOptions) TopologyInitializer() topology.Initializer {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TopologyInitializer")
+	ret0, _ := ret[0].(topology.Initializer)
+	return ret0
+}
+
+// TopologyInitializer indicates an expected call of TopologyInitializer
+func (mr *MockAdminOptionsMockRecorder) TopologyInitializer() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TopologyInitializer", reflect.TypeOf((*MockAdminOptions)(nil).TopologyInitializer))
+}
+
+// SetReadConsistencyLevel mocks base method
+func (m *MockAdminOptions) SetReadConsistencyLevel(value topology.ReadConsistencyLevel) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadConsistencyLevel", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetReadConsistencyLevel indicates an expected call of SetReadConsistencyLevel
+func (mr *MockAdminOptionsMockRecorder) SetReadConsistencyLevel(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).SetReadConsistencyLevel), value)
+}
+
+// ReadConsistencyLevel mocks base method
+func (m *MockAdminOptions) ReadConsistencyLevel() topology.ReadConsistencyLevel {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadConsistencyLevel")
+	ret0, _ := ret[0].(topology.ReadConsistencyLevel)
+	return ret0
+}
+
+// ReadConsistencyLevel indicates an expected call of ReadConsistencyLevel
+func (mr *MockAdminOptionsMockRecorder) ReadConsistencyLevel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).ReadConsistencyLevel))
+}
+
+// SetWriteConsistencyLevel mocks base method
+func (m *MockAdminOptions) SetWriteConsistencyLevel(value topology.ConsistencyLevel) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteConsistencyLevel", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteConsistencyLevel indicates an expected call of SetWriteConsistencyLevel
+func (mr *MockAdminOptionsMockRecorder) SetWriteConsistencyLevel(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteConsistencyLevel), value)
+}
+
+// WriteConsistencyLevel mocks base method
+func (m *MockAdminOptions) WriteConsistencyLevel() topology.ConsistencyLevel {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteConsistencyLevel")
+	ret0, _ := ret[0].(topology.ConsistencyLevel)
+	return ret0
+}
+
+// WriteConsistencyLevel indicates an expected call of WriteConsistencyLevel
+func (mr *MockAdminOptionsMockRecorder) WriteConsistencyLevel() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteConsistencyLevel", reflect.TypeOf((*MockAdminOptions)(nil).WriteConsistencyLevel))
+}
+
+// SetChannelOptions mocks base method
+func (m *MockAdminOptions) SetChannelOptions(value *tchannel_go.ChannelOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetChannelOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetChannelOptions indicates an expected call of SetChannelOptions
+func (mr *MockAdminOptionsMockRecorder) SetChannelOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetChannelOptions", reflect.TypeOf((*MockAdminOptions)(nil).SetChannelOptions), value)
+}
+
+// ChannelOptions mocks base method
+func (m *MockAdminOptions) ChannelOptions() *tchannel_go.ChannelOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChannelOptions")
+	ret0, _ := ret[0].(*tchannel_go.ChannelOptions)
+	return ret0
+}
+
+// ChannelOptions indicates an expected call of ChannelOptions
 func (mr *MockAdminOptionsMockRecorder) ChannelOptions() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChannelOptions", reflect.TypeOf((*MockAdminOptions)(nil).ChannelOptions))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChannelOptions", reflect.TypeOf((*MockAdminOptions)(nil).ChannelOptions))
+}
+
+// SetPeerStats mocks base method
+func (m *MockAdminOptions) SetPeerStats(value peerstats.Tracker) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPeerStats", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetPeerStats indicates an expected call of SetPeerStats
+func (mr *MockAdminOptionsMockRecorder) SetPeerStats(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPeerStats", reflect.TypeOf((*MockAdminOptions)(nil).SetPeerStats), value)
+}
+
+// PeerStats mocks base method
+func (m *MockAdminOptions) PeerStats() peerstats.Tracker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PeerStats")
+	ret0, _ := ret[0].(peerstats.Tracker)
+	return ret0
+}
+
+// PeerStats indicates an expected call of PeerStats
+func (mr *MockAdminOptionsMockRecorder) PeerStats() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PeerStats", reflect.TypeOf((*MockAdminOptions)(nil).PeerStats))
 }
 
 // SetMaxConnectionCount mocks base method
@@ -3744,6 +4291,314 @@ func (mr *MockAdminOptionsMockRecorder) UseV2BatchAPIs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UseV2BatchAPIs", reflect.TypeOf((*MockAdminOptions)(nil).UseV2BatchAPIs))
 }
 
+// SetReadRepairEnabled mocks base method
+func (m *MockAdminOptions) SetReadRepairEnabled(value bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadRepairEnabled", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetReadRepairEnabled indicates an expected call of SetReadRepairEnabled
+func (mr *MockAdminOptionsMockRecorder) SetReadRepairEnabled(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadRepairEnabled", reflect.TypeOf((*MockAdminOptions)(nil).SetReadRepairEnabled), value)
+}
+
+// ReadRepairEnabled mocks base method
+func (m *MockAdminOptions) ReadRepairEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRepairEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ReadRepairEnabled indicates an expected call of ReadRepairEnabled
+func (mr *MockAdminOptionsMockRecorder) ReadRepairEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRepairEnabled", reflect.TypeOf((*MockAdminOptions)(nil).ReadRepairEnabled))
+}
+
+// SetReadRepairQueueSize mocks base method
+func (m *MockAdminOptions) SetReadRepairQueueSize(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReadRepairQueueSize", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetReadRepairQueueSize indicates an expected call of SetReadRepairQueueSize
+func (mr *MockAdminOptionsMockRecorder) SetReadRepairQueueSize(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReadRepairQueueSize", reflect.TypeOf((*MockAdminOptions)(nil).SetReadRepairQueueSize), value)
+}
+
+// ReadRepairQueueSize mocks base method
+func (m *MockAdminOptions) ReadRepairQueueSize() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReadRepairQueueSize")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// ReadRepairQueueSize indicates an expected call of ReadRepairQueueSize
+func (mr *MockAdminOptionsMockRecorder) ReadRepairQueueSize() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReadRepairQueueSize", reflect.TypeOf((*MockAdminOptions)(nil).ReadRepairQueueSize))
+}
+
+// SetFetchMergeReplicaBlocks mocks base method
+func (m *MockAdminOptions) SetFetchMergeReplicaBlocks(value bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFetchMergeReplicaBlocks", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFetchMergeReplicaBlocks indicates an expected call of SetFetchMergeReplicaBlocks
+func (mr *MockAdminOptionsMockRecorder) SetFetchMergeReplicaBlocks(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFetchMergeReplicaBlocks", reflect.TypeOf((*MockAdminOptions)(nil).SetFetchMergeReplicaBlocks), value)
+}
+
+// FetchMergeReplicaBlocks mocks base method
+func (m *MockAdminOptions) FetchMergeReplicaBlocks() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchMergeReplicaBlocks")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// FetchMergeReplicaBlocks indicates an expected call of FetchMergeReplicaBlocks
+func (mr *MockAdminOptionsMockRecorder) FetchMergeReplicaBlocks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchMergeReplicaBlocks", reflect.TypeOf((*MockAdminOptions)(nil).FetchMergeReplicaBlocks))
+}
+
+// SetReplicaSelector mocks base method
+func (m *MockAdminOptions) SetReplicaSelector(value ReplicaSelector) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReplicaSelector", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetReplicaSelector indicates an expected call of SetReplicaSelector
+func (mr *MockAdminOptionsMockRecorder) SetReplicaSelector(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReplicaSelector", reflect.TypeOf((*MockAdminOptions)(nil).SetReplicaSelector), value)
+}
+
+// ReplicaSelector mocks base method
+func (m *MockAdminOptions) ReplicaSelector() ReplicaSelector {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplicaSelector")
+	ret0, _ := ret[0].(ReplicaSelector)
+	return ret0
+}
+
+// ReplicaSelector indicates an expected call of ReplicaSelector
+func (mr *MockAdminOptionsMockRecorder) ReplicaSelector() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplicaSelector", reflect.TypeOf((*MockAdminOptions)(nil).ReplicaSelector))
+}
+
+// SetWriteIdempotencyEnabled mocks base method
+func (m *MockAdminOptions) SetWriteIdempotencyEnabled(value bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteIdempotencyEnabled", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteIdempotencyEnabled indicates an expected call of SetWriteIdempotencyEnabled
+func (mr *MockAdminOptionsMockRecorder) SetWriteIdempotencyEnabled(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteIdempotencyEnabled", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteIdempotencyEnabled), value)
+}
+
+// WriteIdempotencyEnabled mocks base method
+func (m *MockAdminOptions) WriteIdempotencyEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteIdempotencyEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// WriteIdempotencyEnabled indicates an expected call of WriteIdempotencyEnabled
+func (mr *MockAdminOptionsMockRecorder) WriteIdempotencyEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteIdempotencyEnabled", reflect.TypeOf((*MockAdminOptions)(nil).WriteIdempotencyEnabled))
+}
+
+// SetWriteDurability mocks base method
+func (m *MockAdminOptions) SetWriteDurability(value ts.Durability) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteDurability", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteDurability indicates an expected call of SetWriteDurability
+func (mr *MockAdminOptionsMockRecorder) SetWriteDurability(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDurability", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteDurability), value)
+}
+
+// WriteDurability mocks base method
+func (m *MockAdminOptions) WriteDurability() ts.Durability {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteDurability")
+	ret0, _ := ret[0].(ts.Durability)
+	return ret0
+}
+
+// WriteDurability indicates an expected call of WriteDurability
+func (mr *MockAdminOptionsMockRecorder) WriteDurability() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteDurability", reflect.TypeOf((*MockAdminOptions)(nil).WriteDurability))
+}
+
+// SetWriteSpillEnabled mocks base method
+func (m *MockAdminOptions) SetWriteSpillEnabled(value bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteSpillEnabled", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteSpillEnabled indicates an expected call of SetWriteSpillEnabled
+func (mr *MockAdminOptionsMockRecorder) SetWriteSpillEnabled(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillEnabled", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteSpillEnabled), value)
+}
+
+// WriteSpillEnabled mocks base method
+func (m *MockAdminOptions) WriteSpillEnabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSpillEnabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// WriteSpillEnabled indicates an expected call of WriteSpillEnabled
+func (mr *MockAdminOptionsMockRecorder) WriteSpillEnabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillEnabled", reflect.TypeOf((*MockAdminOptions)(nil).WriteSpillEnabled))
+}
+
+// SetWriteSpillPath mocks base method
+func (m *MockAdminOptions) SetWriteSpillPath(value string) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteSpillPath", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteSpillPath indicates an expected call of SetWriteSpillPath
+func (mr *MockAdminOptionsMockRecorder) SetWriteSpillPath(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillPath", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteSpillPath), value)
+}
+
+// WriteSpillPath mocks base method
+func (m *MockAdminOptions) WriteSpillPath() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSpillPath")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// WriteSpillPath indicates an expected call of WriteSpillPath
+func (mr *MockAdminOptionsMockRecorder) WriteSpillPath() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillPath", reflect.TypeOf((*MockAdminOptions)(nil).WriteSpillPath))
+}
+
+// SetWriteSpillMaxBytes mocks base method
+func (m *MockAdminOptions) SetWriteSpillMaxBytes(value int64) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteSpillMaxBytes", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteSpillMaxBytes indicates an expected call of SetWriteSpillMaxBytes
+func (mr *MockAdminOptionsMockRecorder) SetWriteSpillMaxBytes(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillMaxBytes", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteSpillMaxBytes), value)
+}
+
+// WriteSpillMaxBytes mocks base method
+func (m *MockAdminOptions) WriteSpillMaxBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSpillMaxBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// WriteSpillMaxBytes indicates an expected call of WriteSpillMaxBytes
+func (mr *MockAdminOptionsMockRecorder) WriteSpillMaxBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillMaxBytes", reflect.TypeOf((*MockAdminOptions)(nil).WriteSpillMaxBytes))
+}
+
+// SetWriteSpillReplayInterval mocks base method
+func (m *MockAdminOptions) SetWriteSpillReplayInterval(value time.Duration) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteSpillReplayInterval", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteSpillReplayInterval indicates an expected call of SetWriteSpillReplayInterval
+func (mr *MockAdminOptionsMockRecorder) SetWriteSpillReplayInterval(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteSpillReplayInterval", reflect.TypeOf((*MockAdminOptions)(nil).SetWriteSpillReplayInterval), value)
+}
+
+// WriteSpillReplayInterval mocks base method
+func (m *MockAdminOptions) WriteSpillReplayInterval() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteSpillReplayInterval")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// WriteSpillReplayInterval indicates an expected call of WriteSpillReplayInterval
+func (mr *MockAdminOptionsMockRecorder) WriteSpillReplayInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteSpillReplayInterval", reflect.TypeOf((*MockAdminOptions)(nil).WriteSpillReplayInterval))
+}
+
+// SetNamespaceShardKeyStrategies mocks base method
+func (m *MockAdminOptions) SetNamespaceShardKeyStrategies(value map[string]string) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNamespaceShardKeyStrategies", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetNamespaceShardKeyStrategies indicates an expected call of SetNamespaceShardKeyStrategies
+func (mr *MockAdminOptionsMockRecorder) SetNamespaceShardKeyStrategies(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNamespaceShardKeyStrategies", reflect.TypeOf((*MockAdminOptions)(nil).SetNamespaceShardKeyStrategies), value)
+}
+
+// NamespaceShardKeyStrategies mocks base method
+func (m *MockAdminOptions) NamespaceShardKeyStrategies() map[string]string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceShardKeyStrategies")
+	ret0, _ := ret[0].(map[string]string)
+	return ret0
+}
+
+// NamespaceShardKeyStrategies indicates an expected call of NamespaceShardKeyStrategies
+func (mr *MockAdminOptionsMockRecorder) NamespaceShardKeyStrategies() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceShardKeyStrategies", reflect.TypeOf((*MockAdminOptions)(nil).NamespaceShardKeyStrategies))
+}
+
 // SetOrigin mocks base method
 func (m *MockAdminOptions) SetOrigin(value topology.Host) AdminOptions {
 	m.ctrl.T.Helper()
@@ -3968,6 +4823,90 @@ func (mr *MockAdminOptionsMockRecorder) StreamBlocksRetrier() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamBlocksRetrier", reflect.TypeOf((*MockAdminOptions)(nil).StreamBlocksRetrier))
 }
 
+// SetFetchSeriesBlocksCompression mocks base method
+func (m *MockAdminOptions) SetFetchSeriesBlocksCompression(value []compress.Type) AdminOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFetchSeriesBlocksCompression", value)
+	ret0, _ := ret[0].(AdminOptions)
+	return ret0
+}
+
+// SetFetchSeriesBlocksCompression indicates an expected call of SetFetchSeriesBlocksCompression
+func (mr *MockAdminOptionsMockRecorder) SetFetchSeriesBlocksCompression(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFetchSeriesBlocksCompression", reflect.TypeOf((*MockAdminOptions)(nil).SetFetchSeriesBlocksCompression), value)
+}
+
+// FetchSeriesBlocksCompression mocks base method
+func (m *MockAdminOptions) FetchSeriesBlocksCompression() []compress.Type {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FetchSeriesBlocksCompression")
+	ret0, _ := ret[0].([]compress.Type)
+	return ret0
+}
+
+// FetchSeriesBlocksCompression indicates an expected call of FetchSeriesBlocksCompression
+func (mr *MockAdminOptionsMockRecorder) FetchSeriesBlocksCompression() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchSeriesBlocksCompression", reflect.TypeOf((*MockAdminOptions)(nil).FetchSeriesBlocksCompression))
+}
+
+// SetFaultInjector mocks base method
+func (m *MockAdminOptions) SetFaultInjector(value fault.Injector) AdminOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFaultInjector", value)
+	ret0, _ := ret[0].(AdminOptions)
+	return ret0
+}
+
+// SetFaultInjector indicates an expected call of SetFaultInjector
+func (mr *MockAdminOptionsMockRecorder) SetFaultInjector(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFaultInjector", reflect.TypeOf((*MockAdminOptions)(nil).SetFaultInjector), value)
+}
+
+// FaultInjector mocks base method
+func (m *MockAdminOptions) FaultInjector() fault.Injector {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FaultInjector")
+	ret0, _ := ret[0].(fault.Injector)
+	return ret0
+}
+
+// FaultInjector indicates an expected call of FaultInjector
+func (mr *MockAdminOptionsMockRecorder) FaultInjector() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FaultInjector", reflect.TypeOf((*MockAdminOptions)(nil).FaultInjector))
+}
+
+// SetBootstrapPeerPreferences mocks base method
+func (m *MockAdminOptions) SetBootstrapPeerPreferences(value BootstrapPeerPreferences) AdminOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBootstrapPeerPreferences", value)
+	ret0, _ := ret[0].(AdminOptions)
+	return ret0
+}
+
+// SetBootstrapPeerPreferences indicates an expected call of SetBootstrapPeerPreferences
+func (mr *MockAdminOptionsMockRecorder) SetBootstrapPeerPreferences(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBootstrapPeerPreferences", reflect.TypeOf((*MockAdminOptions)(nil).SetBootstrapPeerPreferences), value)
+}
+
+// BootstrapPeerPreferences mocks base method
+func (m *MockAdminOptions) BootstrapPeerPreferences() BootstrapPeerPreferences {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BootstrapPeerPreferences")
+	ret0, _ := ret[0].(BootstrapPeerPreferences)
+	return ret0
+}
+
+// BootstrapPeerPreferences indicates an expected call of BootstrapPeerPreferences
+func (mr *MockAdminOptionsMockRecorder) BootstrapPeerPreferences() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootstrapPeerPreferences", reflect.TypeOf((*MockAdminOptions)(nil).BootstrapPeerPreferences))
+}
+
 // MockclientSession is a mock of clientSession interface
 type MockclientSession struct {
 	ctrl     *gomock.Controller
@@ -4019,6 +4958,36 @@ func (mr *MockclientSessionMockRecorder) WriteTagged(namespace, id, tags, t, val
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockclientSession)(nil).WriteTagged), namespace, id, tags, t, value, unit, annotation)
 }
 
+// WriteWithDispositions mocks base method
+func (m *MockclientSession) WriteWithDispositions(namespace, id ident.ID, t time.Time, value float64, unit time0.Unit, annotation []byte) (WriteDispositions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteWithDispositions", namespace, id, t, value, unit, annotation)
+	ret0, _ := ret[0].(WriteDispositions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteWithDispositions indicates an expected call of WriteWithDispositions
+func (mr *MockclientSessionMockRecorder) WriteWithDispositions(namespace, id, t, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteWithDispositions", reflect.TypeOf((*MockclientSession)(nil).WriteWithDispositions), namespace, id, t, value, unit, annotation)
+}
+
+// WriteTaggedWithDispositions mocks base method
+func (m *MockclientSession) WriteTaggedWithDispositions(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit time0.Unit, annotation []byte) (WriteDispositions, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTaggedWithDispositions", namespace, id, tags, t, value, unit, annotation)
+	ret0, _ := ret[0].(WriteDispositions)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WriteTaggedWithDispositions indicates an expected call of WriteTaggedWithDispositions
+func (mr *MockclientSessionMockRecorder) WriteTaggedWithDispositions(namespace, id, tags, t, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTaggedWithDispositions", reflect.TypeOf((*MockclientSession)(nil).WriteTaggedWithDispositions), namespace, id, tags, t, value, unit, annotation)
+}
+
 // Fetch mocks base method
 func (m *MockclientSession) Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error) {
 	m.ctrl.T.Helper()
@@ -4112,6 +5081,21 @@ func (mr *MockclientSessionMockRecorder) ShardID(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardID", reflect.TypeOf((*MockclientSession)(nil).ShardID), id)
 }
 
+// NamespaceShardID mocks base method
+func (m *MockclientSession) NamespaceShardID(namespace, id ident.ID) (uint32, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NamespaceShardID", namespace, id)
+	ret0, _ := ret[0].(uint32)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// NamespaceShardID indicates an expected call of NamespaceShardID
+func (mr *MockclientSessionMockRecorder) NamespaceShardID(namespace, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceShardID", reflect.TypeOf((*MockclientSession)(nil).NamespaceShardID), namespace, id)
+}
+
 // IteratorPools mocks base method
 func (m *MockclientSession) IteratorPools() (encoding.IteratorPools, error) {
 	m.ctrl.T.Helper()
@@ -31,7 +31,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/environment"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/ts"
 	xtchannel "github.com/m3db/m3/src/dbnode/x/tchannel"
+	"github.com/m3db/m3/src/x/compress"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
@@ -100,6 +102,72 @@ type Configuration struct {
 	// UseV2BatchAPIs determines whether the V2 batch APIs are used. Note that the M3DB nodes must
 	// have support for the V2 APIs in order for this feature to be used.
 	UseV2BatchAPIs *bool `yaml:"useV2BatchAPIs"`
+
+	// ReadRepair is the read repair configuration.
+	ReadRepair *ReadRepairConfiguration `yaml:"readRepair"`
+
+	// WriteIdempotencyEnabled determines whether tagged write batches are sent with an
+	// idempotency key. Note that the M3DB nodes must have idempotent writes enabled for
+	// retried batches to not be applied twice.
+	WriteIdempotencyEnabled *bool `yaml:"writeIdempotencyEnabled"`
+
+	// WriteDurability is the durability class write batches request the M3DB
+	// nodes acknowledge their writes with.
+	WriteDurability *ts.Durability `yaml:"writeDurability"`
+
+	// WriteSpill is the configuration for spilling writes to disk while the
+	// nodes owning their shard are unavailable.
+	WriteSpill *WriteSpillConfiguration `yaml:"writeSpill"`
+
+	// ShardKeyStrategies is the shard key strategy of each namespace that
+	// does not hash whole series IDs to assign series to shards, by
+	// namespace ID.
+	ShardKeyStrategies map[string]string `yaml:"shardKeyStrategies"`
+
+	// FetchSeriesBlocksCompression is the compression types accepted for
+	// series blocks streamed from peers, in order of preference.
+	FetchSeriesBlocksCompression []compress.Type `yaml:"fetchSeriesBlocksCompression"`
+
+	// FetchMergeReplicaBlocks determines whether fetches at a read consistency
+	// level that requires more than one replica merge the blocks returned by
+	// every replica that responded rather than only the first responses.
+	FetchMergeReplicaBlocks *bool `yaml:"fetchMergeReplicaBlocks"`
+
+	// ReplicaSelection is the strategy for selecting the replicas that
+	// fetches are sent to.
+	ReplicaSelection *ReplicaSelectionStrategy `yaml:"replicaSelection"`
+
+	// BootstrapPeerPreferences are the preferences for the peers that shards
+	// are bootstrapped from, the first preference that applies to a shard is
+	// used.
+	BootstrapPeerPreferences BootstrapPeerPreferences `yaml:"bootstrapPeerPreferences"`
+}
+
+// ReadRepairConfiguration is the configuration for repairing divergent
+// replicas observed on the fetch path.
+type ReadRepairConfiguration struct {
+	// Enabled specifies whether read repair is enabled.
+	Enabled bool `yaml:"enabled"`
+
+	// QueueSize is the maximum number of pending read repairs.
+	QueueSize *int `yaml:"queueSize"`
+}
+
+// WriteSpillConfiguration is the configuration for spilling writes to disk
+// per shard while the nodes owning the shard are unavailable and replaying
+// them once the nodes recover.
+type WriteSpillConfiguration struct {
+	// Enabled specifies whether write spill is enabled.
+	Enabled bool `yaml:"enabled"`
+
+	// Path is the directory writes are spilled to.
+	Path string `yaml:"path"`
+
+	// MaxBytes is the maximum size of the writes spilled to disk.
+	MaxBytes *int64 `yaml:"maxBytes"`
+
+	// ReplayInterval is the interval at which spilled writes are replayed.
+	ReplayInterval *time.Duration `yaml:"replayInterval"`
 }
 
 // ProtoConfiguration is the configuration for running with ProtoDataMode enabled.
@@ -187,6 +255,10 @@ func (c *Configuration) Validate() error {
 		return fmt.Errorf("error validating M3DB client proto configuration: %v", err)
 	}
 
+	if err := c.BootstrapPeerPreferences.Validate(); err != nil {
+		return fmt.Errorf("error validating M3DB client bootstrap peer preferences: %v", err)
+	}
+
 	return nil
 }
 
@@ -304,6 +376,56 @@ func (c Configuration) NewAdminClient(
 		v = v.SetUseV2BatchAPIs(*c.UseV2BatchAPIs)
 	}
 
+	if c.ReadRepair != nil {
+		v = v.SetReadRepairEnabled(c.ReadRepair.Enabled)
+		if c.ReadRepair.QueueSize != nil {
+			v = v.SetReadRepairQueueSize(*c.ReadRepair.QueueSize)
+		}
+	}
+
+	if c.WriteIdempotencyEnabled != nil {
+		v = v.SetWriteIdempotencyEnabled(*c.WriteIdempotencyEnabled)
+	}
+
+	if c.WriteDurability != nil {
+		v = v.SetWriteDurability(*c.WriteDurability)
+	}
+
+	if c.WriteSpill != nil {
+		v = v.SetWriteSpillEnabled(c.WriteSpill.Enabled).
+			SetWriteSpillPath(c.WriteSpill.Path)
+		if c.WriteSpill.MaxBytes != nil {
+			v = v.SetWriteSpillMaxBytes(*c.WriteSpill.MaxBytes)
+		}
+		if c.WriteSpill.ReplayInterval != nil {
+			v = v.SetWriteSpillReplayInterval(*c.WriteSpill.ReplayInterval)
+		}
+	}
+
+	if len(c.ShardKeyStrategies) > 0 {
+		v = v.SetNamespaceShardKeyStrategies(c.ShardKeyStrategies)
+	}
+
+	if len(c.FetchSeriesBlocksCompression) > 0 {
+		v = v.(AdminOptions).SetFetchSeriesBlocksCompression(c.FetchSeriesBlocksCompression)
+	}
+
+	if len(c.BootstrapPeerPreferences) > 0 {
+		v = v.(AdminOptions).SetBootstrapPeerPreferences(c.BootstrapPeerPreferences)
+	}
+
+	if c.FetchMergeReplicaBlocks != nil {
+		v = v.SetFetchMergeReplicaBlocks(*c.FetchMergeReplicaBlocks)
+	}
+
+	if c.ReplicaSelection != nil {
+		selector, err := NewReplicaSelector(*c.ReplicaSelection)
+		if err != nil {
+			return nil, err
+		}
+		v = v.SetReplicaSelector(selector)
+	}
+
 	if buildAsyncPool {
 		var size int
 		if c.AsyncWriteWorkerPoolSize == nil {
@@ -30,6 +30,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	nchannel "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/node/channel"
 	"github.com/m3db/m3/src/dbnode/topology"
 	xclose "github.com/m3db/m3/src/x/close"
@@ -297,12 +298,28 @@ func (p *connPool) healthCheckEvery(interval time.Duration, stutter time.Duratio
 }
 
 func newConn(channelName string, address string, opts Options) (xclose.SimpleCloser, rpc.TChanNode, error) {
-	channel, err := tchannel.NewChannel(channelName, opts.ChannelOptions())
+	var (
+		channelOpts = opts.ChannelOptions()
+		peerStats   = opts.PeerStats()
+	)
+	if peerStats != nil {
+		// Copy the channel options to avoid mutating the shared options.
+		var tracked tchannel.ChannelOptions
+		if channelOpts != nil {
+			tracked = *channelOpts
+		}
+		tracked.Dialer = peerstats.NewDialFn(tracked.Dialer, peerStats)
+		channelOpts = &tracked
+	}
+	channel, err := tchannel.NewChannel(channelName, channelOpts)
 	if err != nil {
 		return nil, nil, err
 	}
 	endpoint := &thrift.ClientOptions{HostPort: address}
 	thriftClient := thrift.NewClient(channel, nchannel.ChannelName, endpoint)
+	if peerStats != nil {
+		thriftClient = peerstats.NewClient(thriftClient, address, peerStats)
+	}
 	client := rpc.NewTChanNodeClient(thriftClient)
 	return channel, client, nil
 }
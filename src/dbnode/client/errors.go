@@ -21,12 +21,17 @@
 package client
 
 import (
+	"context"
 	"fmt"
 	"sync"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/thrift"
 )
 
 // IsInternalServerError determines if the error is an internal server error.
@@ -54,6 +59,105 @@ func IsBadRequestError(err error) bool {
 	return false
 }
 
+// IsResourceExhaustedError determines if the error is the result of a
+// resource being exhausted, either server side (i.e. the server is
+// overloaded or a limit was exceeded) or client side.
+func IsResourceExhaustedError(err error) bool {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsResourceExhaustedErrorFlag(e) {
+			return true
+		}
+		if e := xerrors.GetInnerResourceExhaustedError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// IsUnavailableError determines if the error is the result of a server
+// being unavailable to serve the request, i.e. it is still bootstrapping.
+func IsUnavailableError(err error) bool {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsUnavailableErrorFlag(e) {
+			return true
+		}
+		if e := xerrors.GetInnerUnavailableError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// IsStaleTopologyError determines if the error is the result of a write
+// being routed with a topology older than the one known to the server,
+// i.e. the shard has since moved to another host.
+func IsStaleTopologyError(err error) bool {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsStaleTopologyErrorFlag(e) {
+			return true
+		}
+		if e := xerrors.GetInnerStaleTopologyError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// IsOverCapacityError determines if the error is the result of a server
+// rejecting a request while it is over capacity, i.e. a large query was
+// rejected while the server is under memory pressure. Over capacity errors
+// are also resource exhausted errors.
+func IsOverCapacityError(err error) bool {
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsOverCapacityErrorFlag(e) {
+			return true
+		}
+		if e := xerrors.GetInnerOverCapacityError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// IsRetryableError determines if the error has been explicitly marked as
+// retryable, either by the server or the client. Note that errors that are
+// not marked retryable may still succeed if retried, bad request errors
+// however will never succeed if retried.
+func IsRetryableError(err error) bool {
+	if IsBadRequestError(err) {
+		return false
+	}
+	for err != nil {
+		if e, ok := err.(*rpc.Error); ok && tterrors.IsRetryableErrorFlag(e) {
+			return true
+		}
+		if e := xerrors.GetInnerRetryableError(err); e != nil {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// WriteErrorType returns why a write was rejected when the write failed as an
+// element of a write batch, i.e. whether it failed to be written to storage,
+// failed to be indexed or was rejected by a quota, so that callers can decide
+// which failed writes to retry. Returns false if the error does not describe
+// a write batch element or the server did not classify the failure.
+func WriteErrorType(err error) (rpc.WriteBatchRawErrorType, bool) {
+	for err != nil {
+		if e, ok := err.(writeBatchElementError); ok {
+			return e.errType, true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return rpc.WriteBatchRawErrorType_STORAGE, false
+}
+
 // IsConsistencyResultError determines if the error is a consistency result error.
 func IsConsistencyResultError(err error) bool {
 	_, ok := err.(consistencyResultErr)
@@ -94,6 +198,43 @@ func NumError(err error) int {
 	return 0
 }
 
+func isTimeoutError(err error) bool {
+	return err == context.DeadlineExceeded ||
+		tchannel.GetSystemErrorCode(err) == tchannel.ErrCodeTimeout
+}
+
+// newBackpressureError attaches the retry after hint a host returned with a
+// failed request, if any, to the error so that retries of the request wait
+// at least as long as the host asked clients to back off for.
+func newBackpressureError(ctx thrift.Context, err error) error {
+	bp, ok := tchannelthrift.BackpressureFromHeaders(ctx.ResponseHeaders())
+	if !ok || bp.RetryAfter <= 0 {
+		return err
+	}
+	return xerrors.NewRetryAfterError(err, bp.RetryAfter)
+}
+
+type writeBatchElementError struct {
+	errType rpc.WriteBatchRawErrorType
+	err     *rpc.Error
+}
+
+func newWriteBatchElementError(batchErr *rpc.WriteBatchRawError) error {
+	if !batchErr.IsSetErrType() {
+		// Servers that do not classify write batch errors only return the error.
+		return batchErr.Err
+	}
+	return writeBatchElementError{errType: batchErr.GetErrType(), err: batchErr.Err}
+}
+
+func (e writeBatchElementError) Error() string {
+	return e.err.Error()
+}
+
+func (e writeBatchElementError) InnerError() error {
+	return e.err
+}
+
 type hostNotAvailableError struct {
 	err error
 }
@@ -52,3 +52,87 @@ func TestConsistencyResultError(t *testing.T) {
 	assert.Equal(t, 1, NumSuccess(err))
 	assert.Equal(t, 2, NumError(err))
 }
+
+func TestErrorFlags(t *testing.T) {
+	resourceExhaustedErr := xerrors.NewRenamedError(&rpc.Error{
+		Type:  rpc.ErrorType_INTERNAL_ERROR,
+		Flags: int64(rpc.ErrorFlags_RESOURCE_EXHAUSTED | rpc.ErrorFlags_RETRYABLE),
+	}, fmt.Errorf("renamed error"))
+	assert.True(t, IsResourceExhaustedError(resourceExhaustedErr))
+	assert.False(t, IsUnavailableError(resourceExhaustedErr))
+	assert.True(t, IsRetryableError(resourceExhaustedErr))
+
+	unavailableErr := &rpc.Error{
+		Type:  rpc.ErrorType_INTERNAL_ERROR,
+		Flags: int64(rpc.ErrorFlags_UNAVAILABLE | rpc.ErrorFlags_RETRYABLE),
+	}
+	assert.False(t, IsResourceExhaustedError(unavailableErr))
+	assert.True(t, IsUnavailableError(unavailableErr))
+	assert.False(t, IsStaleTopologyError(unavailableErr))
+	assert.True(t, IsRetryableError(unavailableErr))
+
+	staleTopologyErr := &rpc.Error{
+		Type:  rpc.ErrorType_INTERNAL_ERROR,
+		Flags: int64(rpc.ErrorFlags_STALE_TOPOLOGY | rpc.ErrorFlags_RETRYABLE),
+	}
+	assert.False(t, IsUnavailableError(staleTopologyErr))
+	assert.True(t, IsStaleTopologyError(staleTopologyErr))
+	assert.True(t, IsRetryableError(staleTopologyErr))
+
+	overCapacityErr := &rpc.Error{
+		Type: rpc.ErrorType_INTERNAL_ERROR,
+		Flags: int64(rpc.ErrorFlags_OVER_CAPACITY |
+			rpc.ErrorFlags_RESOURCE_EXHAUSTED | rpc.ErrorFlags_RETRYABLE),
+	}
+	assert.True(t, IsOverCapacityError(overCapacityErr))
+	assert.True(t, IsResourceExhaustedError(overCapacityErr))
+	assert.False(t, IsOverCapacityError(resourceExhaustedErr))
+	assert.True(t, IsRetryableError(overCapacityErr))
+
+	internalErr := &rpc.Error{Type: rpc.ErrorType_INTERNAL_ERROR}
+	assert.False(t, IsResourceExhaustedError(internalErr))
+	assert.False(t, IsUnavailableError(internalErr))
+	assert.False(t, IsRetryableError(internalErr))
+
+	badReqErr := &rpc.Error{
+		Type:  rpc.ErrorType_BAD_REQUEST,
+		Flags: int64(rpc.ErrorFlags_RETRYABLE),
+	}
+	assert.False(t, IsRetryableError(badReqErr))
+
+	assert.True(t, IsResourceExhaustedError(
+		xerrors.NewResourceExhaustedError(fmt.Errorf("client error"))))
+}
+
+func TestWriteErrorType(t *testing.T) {
+	rpcErr := &rpc.Error{
+		Type:    rpc.ErrorType_INTERNAL_ERROR,
+		Message: "index queue full",
+		Flags:   int64(rpc.ErrorFlags_RESOURCE_EXHAUSTED | rpc.ErrorFlags_RETRYABLE),
+	}
+	err := newWriteBatchElementError(&rpc.WriteBatchRawError{
+		Index:   3,
+		Err:     rpcErr,
+		ErrType: rpc.WriteBatchRawErrorTypePtr(rpc.WriteBatchRawErrorType_INDEX),
+	})
+	assert.Equal(t, "index queue full", err.Error())
+
+	errType, ok := WriteErrorType(err)
+	assert.True(t, ok)
+	assert.Equal(t, rpc.WriteBatchRawErrorType_INDEX, errType)
+	assert.True(t, IsResourceExhaustedError(err))
+	assert.True(t, IsRetryableError(err))
+
+	// Classification is preserved through the consistency result error.
+	consistencyErr := newConsistencyResultError(
+		topology.ConsistencyLevelMajority, 3, 3, []error{err})
+	errType, ok = WriteErrorType(consistencyErr)
+	assert.True(t, ok)
+	assert.Equal(t, rpc.WriteBatchRawErrorType_INDEX, errType)
+
+	// Unclassified errors are returned as is.
+	err = newWriteBatchElementError(&rpc.WriteBatchRawError{Index: 3, Err: rpcErr})
+	assert.Equal(t, rpcErr, err)
+	_, ok = WriteErrorType(err)
+	assert.False(t, ok)
+}
@@ -56,7 +56,7 @@ func (f *fetchAttempt) reset() {
 
 func (f *fetchAttempt) perform() error {
 	result, err := f.session.fetchIDsAttempt(f.args.namespace,
-		f.args.ids, f.args.start, f.args.end)
+		f.args.ids, f.args.start, f.args.end, true)
 	f.result = result
 
 	if IsBadRequestError(err) {
@@ -0,0 +1,203 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+// FetchRepairHint describes a block of a fetched series that some of the
+// replicas that responded to the fetch returned less data for than the
+// replica the block was read from.
+type FetchRepairHint struct {
+	// ID is the ID of the series.
+	ID ident.ID
+	// BlockStart is the start of the block.
+	BlockStart time.Time
+	// BlockSize is the size of the block.
+	BlockSize time.Duration
+	// DeficientReplicas are the IDs of the hosts that were missing the
+	// block or returned less data for it.
+	DeficientReplicas []string
+}
+
+// FetchRepairHints returns the repair hints attached to series iterators
+// returned by a fetch that merged replica blocks, or nil if there are none.
+func FetchRepairHints(iters encoding.SeriesIterators) []FetchRepairHint {
+	withHints, ok := iters.(*seriesIteratorsWithRepairHints)
+	if !ok {
+		return nil
+	}
+	return withHints.hints
+}
+
+type seriesIteratorsWithRepairHints struct {
+	encoding.MutableSeriesIterators
+
+	hints []FetchRepairHint
+}
+
+// replicaBlockDeficiency is a block that some replicas returned less data
+// for than the most complete replica, replicas are referenced by the order
+// they responded in.
+type replicaBlockDeficiency struct {
+	start             int64
+	size              int64
+	deficientReplicas []int
+}
+
+type replicaBlockCandidate struct {
+	segments *rpc.Segments
+	size     int64
+	bytes    []int
+}
+
+// mergeReplicaBlocks merges the blocks returned by each replica for a series
+// into a single set of segments by choosing the replica that returned the
+// most data for each block, ties are resolved in favor of the replica that
+// responded first. It also returns the blocks for which some of the
+// replicas returned less data, sorted by block start.
+func mergeReplicaBlocks(
+	replicas [][]*rpc.Segments,
+) ([]*rpc.Segments, []replicaBlockDeficiency) {
+	byStart := make(map[int64]*replicaBlockCandidate)
+	for replica, segments := range replicas {
+		for _, seg := range segments {
+			start, size, ok := replicaSegmentsBlock(seg)
+			if !ok {
+				continue
+			}
+			candidate, ok := byStart[start]
+			if !ok {
+				candidate = &replicaBlockCandidate{
+					size:  size,
+					bytes: make([]int, len(replicas)),
+				}
+				byStart[start] = candidate
+			}
+			n := replicaSegmentsBytes(seg)
+			candidate.bytes[replica] = n
+			if candidate.segments == nil || n > replicaSegmentsBytes(candidate.segments) {
+				candidate.segments = seg
+			}
+		}
+	}
+
+	var (
+		starts       = make([]int64, 0, len(byStart))
+		merged       = make([]*rpc.Segments, 0, len(byStart))
+		deficiencies []replicaBlockDeficiency
+	)
+	for start := range byStart {
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool {
+		return starts[i] < starts[j]
+	})
+	for _, start := range starts {
+		candidate := byStart[start]
+		merged = append(merged, candidate.segments)
+		if len(replicas) < 2 {
+			continue
+		}
+
+		var (
+			best      = replicaSegmentsBytes(candidate.segments)
+			deficient []int
+		)
+		for replica, n := range candidate.bytes {
+			if n < best {
+				deficient = append(deficient, replica)
+			}
+		}
+		if len(deficient) == 0 {
+			continue
+		}
+		deficiencies = append(deficiencies, replicaBlockDeficiency{
+			start:             start,
+			size:              candidate.size,
+			deficientReplicas: deficient,
+		})
+	}
+	return merged, deficiencies
+}
+
+func replicaSegmentsBlock(seg *rpc.Segments) (int64, int64, bool) {
+	if seg == nil {
+		return 0, 0, false
+	}
+	if merged := seg.Merged; merged != nil {
+		if merged.StartTime == nil || merged.BlockSize == nil {
+			return 0, 0, false
+		}
+		return *merged.StartTime, *merged.BlockSize, true
+	}
+	// All unmerged segments of a block share the same start.
+	for _, unmerged := range seg.Unmerged {
+		if unmerged.StartTime == nil || unmerged.BlockSize == nil {
+			continue
+		}
+		return *unmerged.StartTime, *unmerged.BlockSize, true
+	}
+	return 0, 0, false
+}
+
+func replicaSegmentsBytes(seg *rpc.Segments) int {
+	if merged := seg.Merged; merged != nil {
+		return len(merged.Head) + len(merged.Tail)
+	}
+	n := 0
+	for _, unmerged := range seg.Unmerged {
+		n += len(unmerged.Head) + len(unmerged.Tail)
+	}
+	return n
+}
+
+func newFetchRepairHints(
+	id ident.ID,
+	hostIDs []string,
+	deficiencies []replicaBlockDeficiency,
+) []FetchRepairHint {
+	// NB: The ID is owned by the fetch so take a copy that lives for as
+	// long as the returned iterators.
+	var (
+		idCopy = ident.BytesID(append([]byte(nil), id.Bytes()...))
+		hints  = make([]FetchRepairHint, 0, len(deficiencies))
+	)
+	for _, deficiency := range deficiencies {
+		deficient := make([]string, 0, len(deficiency.deficientReplicas))
+		for _, replica := range deficiency.deficientReplicas {
+			deficient = append(deficient, hostIDs[replica])
+		}
+		hints = append(hints, FetchRepairHint{
+			ID:                idCopy,
+			BlockStart:        time.Unix(0, deficiency.start),
+			BlockSize:         time.Duration(deficiency.size),
+			DeficientReplicas: deficient,
+		})
+	}
+	return hints
+}
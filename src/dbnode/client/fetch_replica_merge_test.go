@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMergeReplicaBlocksSingleReplica(t *testing.T) {
+	size := int64(time.Hour)
+	segments := []*rpc.Segments{
+		{Merged: testReadRepairSegment(size, size, []byte("a"), nil)},
+		{Merged: testReadRepairSegment(0, size, []byte("b"), nil)},
+	}
+
+	merged, deficiencies := mergeReplicaBlocks([][]*rpc.Segments{segments})
+	require.Equal(t, 2, len(merged))
+	// Blocks are ordered by start.
+	assert.Equal(t, segments[1], merged[0])
+	assert.Equal(t, segments[0], merged[1])
+	assert.Nil(t, deficiencies)
+}
+
+func TestMergeReplicaBlocksChoosesMostCompleteReplica(t *testing.T) {
+	size := int64(time.Hour)
+	var (
+		first = []*rpc.Segments{
+			{Merged: testReadRepairSegment(0, size, []byte("ab"), []byte("c"))},
+			{Merged: testReadRepairSegment(size, size, []byte("a"), nil)},
+		}
+		second = []*rpc.Segments{
+			{Merged: testReadRepairSegment(0, size, []byte("abc"), nil)},
+			{Unmerged: []*rpc.Segment{
+				testReadRepairSegment(size, size, []byte("a"), nil),
+				testReadRepairSegment(size, size, []byte("b"), nil),
+			}},
+		}
+		third = []*rpc.Segments{
+			{Merged: testReadRepairSegment(2*size, size, []byte("a"), nil)},
+			{Merged: &rpc.Segment{Head: []byte("abcd")}},
+		}
+	)
+
+	merged, deficiencies := mergeReplicaBlocks([][]*rpc.Segments{first, second, third})
+	require.Equal(t, 3, len(merged))
+	// Ties are resolved in favor of the replica that responded first.
+	assert.Equal(t, first[0], merged[0])
+	assert.Equal(t, second[1], merged[1])
+	assert.Equal(t, third[0], merged[2])
+
+	assert.Equal(t, []replicaBlockDeficiency{
+		{start: 0, size: size, deficientReplicas: []int{2}},
+		{start: size, size: size, deficientReplicas: []int{0, 2}},
+		{start: 2 * size, size: size, deficientReplicas: []int{0, 1}},
+	}, deficiencies)
+}
+
+func TestNewFetchRepairHints(t *testing.T) {
+	size := int64(time.Hour)
+	id := ident.StringID("foo")
+	hints := newFetchRepairHints(id, []string{"a", "b", "c"},
+		[]replicaBlockDeficiency{
+			{start: size, size: size, deficientReplicas: []int{0, 2}},
+		})
+	id.Finalize()
+
+	require.Equal(t, 1, len(hints))
+	assert.Equal(t, "foo", hints[0].ID.String())
+	assert.True(t, time.Unix(0, size).Equal(hints[0].BlockStart))
+	assert.Equal(t, time.Hour, hints[0].BlockSize)
+	assert.Equal(t, []string{"a", "c"}, hints[0].DeficientReplicas)
+}
+
+func TestFetchRepairHints(t *testing.T) {
+	iters := encoding.NewSeriesIterators(nil, nil)
+	assert.Nil(t, FetchRepairHints(iters))
+
+	hints := []FetchRepairHint{{BlockSize: time.Hour}}
+	assert.Equal(t, hints, FetchRepairHints(&seriesIteratorsWithRepairHints{
+		MutableSeriesIterators: iters,
+		hints:                  hints,
+	}))
+}
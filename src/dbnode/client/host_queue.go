@@ -22,14 +22,19 @@ package client
 
 import (
 	"bytes"
+	"crypto/rand"
+	"encoding/binary"
 	"fmt"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
 	xsync "github.com/m3db/m3/src/x/sync"
@@ -69,6 +74,10 @@ type queue struct {
 	fetchOpBatchSize                             tally.Histogram
 	status                                       status
 	serverSupportsV2APIs                         bool
+	idempotencyKeyPrefix                         uint64
+	idempotencyKeySeq                            uint64
+	fencingTokenFn                               func() int64
+	durability                                   *rpc.WriteDurability
 }
 
 func newHostQueue(
@@ -124,6 +133,18 @@ func newHostQueue(
 	opArrayPool := newOpArrayPool(opArrayPoolOpts, opArrayPoolCapacity)
 	opArrayPool.Init()
 
+	durability, err := writeDurability(opts.WriteDurability())
+	if err != nil {
+		return nil, err
+	}
+
+	// Idempotency keys are a random per queue prefix followed by a sequence
+	// number so that they are unique across clients without coordination.
+	var prefix [8]byte
+	if _, err := rand.Read(prefix[:]); err != nil {
+		return nil, err
+	}
+
 	return &queue{
 		opts:                                   opts,
 		nowFn:                                  opts.ClockOptions().NowFn(),
@@ -147,6 +168,9 @@ func newHostQueue(
 		fetchOpBatchSize:                             scopeWithoutHostID.Histogram("fetch-op-batch-size", fetchOpBatchSizeBuckets),
 		drainIn:                                      make(chan []op, opsArraysLen),
 		serverSupportsV2APIs:                         opts.UseV2BatchAPIs(),
+		idempotencyKeyPrefix:                         binary.BigEndian.Uint64(prefix[:]),
+		fencingTokenFn:                               hostQueueOpts.fencingTokenFn,
+		durability:                                   durability,
 	}, nil
 }
 
@@ -251,13 +275,13 @@ func (q *queue) drain() {
 		for i := 0; i < opsLen; i++ {
 			switch v := ops[i].(type) {
 			case *writeOperation:
-				if q.serverSupportsV2APIs {
+				if q.serverSupportsV2APIs && !v.includeDispositions {
 					currV2WriteReq, currV2WriteOps = q.drainWriteOpV2(v, currV2WriteReq, currV2WriteOps, ops[i])
 				} else {
 					currWriteOpsByNamespace = q.drainWriteOpV1(v, currWriteOpsByNamespace, ops[i])
 				}
 			case *writeTaggedOperation:
-				if q.serverSupportsV2APIs {
+				if q.serverSupportsV2APIs && !v.includeDispositions {
 					currV2WriteTaggedReq, currV2WriteTaggedOps = q.drainTaggedWriteOpV2(v, currV2WriteTaggedReq, currV2WriteTaggedOps, ops[i])
 				} else {
 					currTaggedWriteOpsByNamespace = q.drainTaggedWriteOpV1(v, currTaggedWriteOpsByNamespace, ops[i])
@@ -511,6 +535,79 @@ func (q *queue) drainFetchBatchRawV2Op(
 	return currV2FetchBatchRawReq, currV2FetchBatchRawOps
 }
 
+func (q *queue) nextIdempotencyKey() []byte {
+	key := make([]byte, 16)
+	binary.BigEndian.PutUint64(key[:8], q.idempotencyKeyPrefix)
+	binary.BigEndian.PutUint64(key[8:], atomic.AddUint64(&q.idempotencyKeySeq, 1))
+	return key
+}
+
+// fencingToken returns the version of the topology writes are routed with,
+// or nil if the topology is not versioned.
+func (q *queue) fencingToken() *int64 {
+	if q.fencingTokenFn == nil {
+		return nil
+	}
+	token := q.fencingTokenFn()
+	if token <= 0 {
+		return nil
+	}
+	return &token
+}
+
+// writeDurability returns the durability write batches request, or nil if
+// writes are acknowledged with the durability of the namespace.
+func writeDurability(value ts.Durability) (*rpc.WriteDurability, error) {
+	if value == ts.DurabilityDefault {
+		return nil, nil
+	}
+	durability, err := convert.ToRPCDurability(value)
+	if err != nil {
+		return nil, err
+	}
+	return &durability, nil
+}
+
+// includesDispositions returns whether any of the writes of a batch report
+// how they were applied.
+func includesDispositions(ops []op) bool {
+	for i := range ops {
+		switch v := ops[i].(type) {
+		case *writeOperation:
+			if v.includeDispositions {
+				return true
+			}
+		case *writeTaggedOperation:
+			if v.includeDispositions {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// callAllWriteCompletionFns completes all the writes of a batch that
+// succeeded, with the disposition of each write if the node returned them.
+func (q *queue) callAllWriteCompletionFns(ops []op, result *rpc.WriteBatchRawResult_) {
+	if result == nil || result.Dispositions == nil {
+		callAllCompletionFns(ops, q.host, nil)
+		return
+	}
+	for i := range ops {
+		ops[i].CompletionFn()(q.writeResult(result.Dispositions, i), nil)
+	}
+}
+
+// writeResult returns the result to complete the write at an index of a
+// batch with, which carries the disposition of the write if the node
+// returned them.
+func (q *queue) writeResult(dispositions []rpc.WriteDisposition, idx int) interface{} {
+	if idx >= len(dispositions) {
+		return q.host
+	}
+	return hostWriteResult{host: q.host, disposition: dispositions[idx]}
+}
+
 func (q *queue) asyncTaggedWrite(
 	namespace ident.ID,
 	ops []op,
@@ -543,11 +640,24 @@ func (q *queue) asyncTaggedWrite(
 			return
 		}
 
+		if q.opts.WriteIdempotencyEnabled() {
+			req.IdempotencyKey = q.nextIdempotencyKey()
+		}
+		req.FencingToken = q.fencingToken()
+		req.Durability = q.durability
+		req.IncludeDispositions = includesDispositions(ops)
+
 		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
-		err = client.WriteTaggedBatchRaw(ctx, req)
+		result, err := client.WriteTaggedBatchRaw(ctx, req)
+		if req.IsSetIdempotencyKey() && isTimeoutError(err) {
+			// The batch may or may not have been applied, retry it once with
+			// the same idempotency key so that it is applied at most once.
+			ctx, _ = thrift.NewContext(q.opts.WriteRequestTimeout())
+			result, err = client.WriteTaggedBatchRaw(ctx, req)
+		}
 		if err == nil {
 			// All succeeded
-			callAllCompletionFns(ops, q.host, nil)
+			q.callAllWriteCompletionFns(ops, result)
 			cleanup()
 			return
 		}
@@ -557,14 +667,15 @@ func (q *queue) asyncTaggedWrite(
 			hasErr := make(map[int]struct{})
 			for _, batchErr := range batchErrs.Errors {
 				op := ops[batchErr.Index]
-				op.CompletionFn()(q.host, batchErr.Err)
+				op.CompletionFn()(q.writeResult(batchErrs.Dispositions, int(batchErr.Index)),
+					newBackpressureError(ctx, newWriteBatchElementError(batchErr)))
 				hasErr[int(batchErr.Index)] = struct{}{}
 			}
 			// Callback all writes with no errors
 			for i := range ops {
 				if _, ok := hasErr[i]; !ok {
 					// No error
-					ops[i].CompletionFn()(q.host, nil)
+					ops[i].CompletionFn()(q.writeResult(batchErrs.Dispositions, i), nil)
 				}
 			}
 			cleanup()
@@ -572,7 +683,7 @@ func (q *queue) asyncTaggedWrite(
 		}
 
 		// Entire batch failed
-		callAllCompletionFns(ops, q.host, err)
+		callAllCompletionFns(ops, q.host, newBackpressureError(ctx, err))
 		cleanup()
 	})
 }
@@ -603,8 +714,20 @@ func (q *queue) asyncTaggedWriteV2(
 			return
 		}
 
+		if q.opts.WriteIdempotencyEnabled() {
+			req.IdempotencyKey = q.nextIdempotencyKey()
+		}
+		req.FencingToken = q.fencingToken()
+		req.Durability = q.durability
+
 		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
 		err = client.WriteTaggedBatchRawV2(ctx, req)
+		if req.IsSetIdempotencyKey() && isTimeoutError(err) {
+			// The batch may or may not have been applied, retry it once with
+			// the same idempotency key so that it is applied at most once.
+			ctx, _ = thrift.NewContext(q.opts.WriteRequestTimeout())
+			err = client.WriteTaggedBatchRawV2(ctx, req)
+		}
 		if err == nil {
 			// All succeeded
 			callAllCompletionFns(ops, q.host, nil)
@@ -617,7 +740,7 @@ func (q *queue) asyncTaggedWriteV2(
 			hasErr := make(map[int]struct{})
 			for _, batchErr := range batchErrs.Errors {
 				op := ops[batchErr.Index]
-				op.CompletionFn()(q.host, batchErr.Err)
+				op.CompletionFn()(q.host, newBackpressureError(ctx, newWriteBatchElementError(batchErr)))
 				hasErr[int(batchErr.Index)] = struct{}{}
 			}
 			// Callback all writes with no errors
@@ -632,7 +755,7 @@ func (q *queue) asyncTaggedWriteV2(
 		}
 
 		// Entire batch failed
-		callAllCompletionFns(ops, q.host, err)
+		callAllCompletionFns(ops, q.host, newBackpressureError(ctx, err))
 		cleanup()
 	})
 }
@@ -668,11 +791,15 @@ func (q *queue) asyncWrite(
 			return
 		}
 
+		req.FencingToken = q.fencingToken()
+		req.Durability = q.durability
+		req.IncludeDispositions = includesDispositions(ops)
+
 		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
-		err = client.WriteBatchRaw(ctx, req)
+		result, err := client.WriteBatchRaw(ctx, req)
 		if err == nil {
 			// All succeeded
-			callAllCompletionFns(ops, q.host, nil)
+			q.callAllWriteCompletionFns(ops, result)
 			cleanup()
 			return
 		}
@@ -682,14 +809,15 @@ func (q *queue) asyncWrite(
 			hasErr := make(map[int]struct{})
 			for _, batchErr := range batchErrs.Errors {
 				op := ops[batchErr.Index]
-				op.CompletionFn()(q.host, batchErr.Err)
+				op.CompletionFn()(q.writeResult(batchErrs.Dispositions, int(batchErr.Index)),
+					newBackpressureError(ctx, newWriteBatchElementError(batchErr)))
 				hasErr[int(batchErr.Index)] = struct{}{}
 			}
 			// Callback all writes with no errors
 			for i := range ops {
 				if _, ok := hasErr[i]; !ok {
 					// No error
-					ops[i].CompletionFn()(q.host, nil)
+					ops[i].CompletionFn()(q.writeResult(batchErrs.Dispositions, i), nil)
 				}
 			}
 			cleanup()
@@ -697,7 +825,7 @@ func (q *queue) asyncWrite(
 		}
 
 		// Entire batch failed
-		callAllCompletionFns(ops, q.host, err)
+		callAllCompletionFns(ops, q.host, newBackpressureError(ctx, err))
 		cleanup()
 	})
 }
@@ -727,6 +855,9 @@ func (q *queue) asyncWriteV2(
 			return
 		}
 
+		req.FencingToken = q.fencingToken()
+		req.Durability = q.durability
+
 		ctx, _ := thrift.NewContext(q.opts.WriteRequestTimeout())
 		err = client.WriteBatchRawV2(ctx, req)
 		if err == nil {
@@ -741,7 +872,7 @@ func (q *queue) asyncWriteV2(
 			hasErr := make(map[int]struct{})
 			for _, batchErr := range batchErrs.Errors {
 				op := ops[batchErr.Index]
-				op.CompletionFn()(q.host, batchErr.Err)
+				op.CompletionFn()(q.host, newBackpressureError(ctx, newWriteBatchElementError(batchErr)))
 				hasErr[int(batchErr.Index)] = struct{}{}
 			}
 			// Callback all writes with no errors.
@@ -756,7 +887,7 @@ func (q *queue) asyncWriteV2(
 		}
 
 		// Entire batch failed.
-		callAllCompletionFns(ops, q.host, err)
+		callAllCompletionFns(ops, q.host, newBackpressureError(ctx, err))
 		cleanup()
 	})
 }
@@ -783,7 +914,7 @@ func (q *queue) asyncFetch(op *fetchBatchOp) {
 		ctx, _ := thrift.NewContext(q.opts.FetchRequestTimeout())
 		result, err := client.FetchBatchRaw(ctx, &op.request)
 		if err != nil {
-			op.completeAll(nil, err)
+			op.completeAll(nil, newBackpressureError(ctx, err))
 			cleanup()
 			return
 		}
@@ -836,7 +967,7 @@ func (q *queue) asyncFetchV2(
 		ctx, _ := thrift.NewContext(q.opts.FetchRequestTimeout())
 		result, err := client.FetchBatchRawV2(ctx, currV2FetchBatchRawReq)
 		if err != nil {
-			callAllCompletionFns(ops, nil, err)
+			callAllCompletionFns(ops, nil, newBackpressureError(ctx, err))
 			cleanup()
 			return
 		}
@@ -883,7 +1014,7 @@ func (q *queue) asyncFetchTagged(op *fetchTaggedOp) {
 		ctx, _ := thrift.NewContext(q.opts.FetchRequestTimeout())
 		result, err := client.FetchTagged(ctx, &op.request)
 		if err != nil {
-			op.CompletionFn()(fetchTaggedResultAccumulatorOpts{host: q.host}, err)
+			op.CompletionFn()(fetchTaggedResultAccumulatorOpts{host: q.host}, newBackpressureError(ctx, err))
 			cleanup()
 			return
 		}
@@ -916,7 +1047,7 @@ func (q *queue) asyncAggregate(op *aggregateOp) {
 		ctx, _ := thrift.NewContext(q.opts.FetchRequestTimeout())
 		result, err := client.AggregateRaw(ctx, &op.request)
 		if err != nil {
-			op.CompletionFn()(aggregateResultAccumulatorOpts{host: q.host}, err)
+			op.CompletionFn()(aggregateResultAccumulatorOpts{host: q.host}, newBackpressureError(ctx, err))
 			cleanup()
 			return
 		}
@@ -27,10 +27,14 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/dbnode/ts"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/uber/tchannel-go/thrift"
 )
 
@@ -112,7 +116,7 @@ func TestHostQueueWriteBatches(t *testing.T) {
 						assert.Equal(t, req.Elements[i].Datapoint, write.request.Datapoint)
 					}
 				}
-				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil)
+				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil)
 			}
 
 			mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
@@ -142,6 +146,210 @@ func TestHostQueueWriteBatches(t *testing.T) {
 	}
 }
 
+func TestHostQueueWriteBatchesFencingToken(t *testing.T) {
+	for _, opts := range []Options{
+		newHostQueueTestOptions().SetUseV2BatchAPIs(false),
+		newHostQueueTestOptions().SetUseV2BatchAPIs(true),
+	} {
+		t.Run(fmt.Sprintf("useV2: %v", opts.UseV2BatchAPIs()), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConnPool := NewMockconnectionPool(ctrl)
+			queue := newTestHostQueue(opts)
+			queue.connPool = mockConnPool
+			queue.fencingTokenFn = func() int64 { return 5 }
+
+			mockConnPool.EXPECT().Open()
+			queue.Open()
+
+			var wg sync.WaitGroup
+			callback := func(r interface{}, err error) {
+				assert.NoError(t, err)
+				wg.Done()
+			}
+
+			writes := []*writeOperation{
+				testWriteOp("testNs", "foo", 1.0, 1000, rpc.TimeType_UNIX_SECONDS, callback),
+				testWriteOp("testNs", "bar", 2.0, 2000, rpc.TimeType_UNIX_SECONDS, callback),
+				testWriteOp("testNs", "baz", 3.0, 3000, rpc.TimeType_UNIX_SECONDS, callback),
+				testWriteOp("testNs", "qux", 4.0, 4000, rpc.TimeType_UNIX_SECONDS, callback),
+			}
+			wg.Add(len(writes))
+
+			// The topology version is sent with the batch as the fencing token.
+			mockClient := rpc.NewMockTChanNode(ctrl)
+			if opts.UseV2BatchAPIs() {
+				writeBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawV2Request) {
+					assert.True(t, req.IsSetFencingToken())
+					assert.Equal(t, int64(5), req.GetFencingToken())
+				}
+				mockClient.EXPECT().WriteBatchRawV2(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil)
+			} else {
+				writeBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawRequest) {
+					assert.True(t, req.IsSetFencingToken())
+					assert.Equal(t, int64(5), req.GetFencingToken())
+				}
+				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil)
+			}
+			mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
+
+			for _, write := range writes {
+				assert.NoError(t, queue.Enqueue(write))
+			}
+			wg.Wait()
+
+			var closeWg sync.WaitGroup
+			closeWg.Add(1)
+			mockConnPool.EXPECT().Close().Do(func() {
+				closeWg.Done()
+			})
+			queue.Close()
+			closeWg.Wait()
+		})
+	}
+}
+
+func TestHostQueueWriteBatchesDurability(t *testing.T) {
+	for _, opts := range []Options{
+		newHostQueueTestOptions().SetUseV2BatchAPIs(false),
+		newHostQueueTestOptions().SetUseV2BatchAPIs(true),
+	} {
+		opts = opts.SetWriteDurability(ts.DurabilityFsync)
+		t.Run(fmt.Sprintf("useV2: %v", opts.UseV2BatchAPIs()), func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockConnPool := NewMockconnectionPool(ctrl)
+			queue := newTestHostQueue(opts)
+			queue.connPool = mockConnPool
+
+			mockConnPool.EXPECT().Open()
+			queue.Open()
+
+			var wg sync.WaitGroup
+			callback := func(r interface{}, err error) {
+				assert.NoError(t, err)
+				wg.Done()
+			}
+
+			writes := []*writeOperation{
+				testWriteOp("testNs", "foo", 1.0, 1000, rpc.TimeType_UNIX_SECONDS, callback),
+				testWriteOp("testNs", "bar", 2.0, 2000, rpc.TimeType_UNIX_SECONDS, callback),
+				testWriteOp("testNs", "baz", 3.0, 3000, rpc.TimeType_UNIX_SECONDS, callback),
+				testWriteOp("testNs", "qux", 4.0, 4000, rpc.TimeType_UNIX_SECONDS, callback),
+			}
+			wg.Add(len(writes))
+
+			// The durability is requested with the batch.
+			mockClient := rpc.NewMockTChanNode(ctrl)
+			if opts.UseV2BatchAPIs() {
+				writeBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawV2Request) {
+					assert.True(t, req.IsSetDurability())
+					assert.Equal(t, rpc.WriteDurability_FSYNC, req.GetDurability())
+				}
+				mockClient.EXPECT().WriteBatchRawV2(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil)
+			} else {
+				writeBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawRequest) {
+					assert.True(t, req.IsSetDurability())
+					assert.Equal(t, rpc.WriteDurability_FSYNC, req.GetDurability())
+				}
+				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil)
+			}
+			mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
+
+			for _, write := range writes {
+				assert.NoError(t, queue.Enqueue(write))
+			}
+			wg.Wait()
+
+			var closeWg sync.WaitGroup
+			closeWg.Add(1)
+			mockConnPool.EXPECT().Close().Do(func() {
+				closeWg.Done()
+			})
+			queue.Close()
+			closeWg.Wait()
+		})
+	}
+}
+
+func TestHostQueueWriteBatchesDispositions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// Writes that request dispositions use the V1 APIs even when V2 is enabled.
+	opts := newHostQueueTestOptions().SetUseV2BatchAPIs(true)
+	mockConnPool := NewMockconnectionPool(ctrl)
+	queue := newTestHostQueue(opts)
+	queue.connPool = mockConnPool
+
+	mockConnPool.EXPECT().Open()
+	queue.Open()
+
+	var (
+		results []hostQueueResult
+		lock    sync.Mutex
+		wg      sync.WaitGroup
+	)
+	callback := func(r interface{}, err error) {
+		lock.Lock()
+		results = append(results, hostQueueResult{r, err})
+		lock.Unlock()
+		wg.Done()
+	}
+
+	writes := []*writeOperation{
+		testWriteOp("testNs", "foo", 1.0, 1000, rpc.TimeType_UNIX_SECONDS, callback),
+		testWriteOp("testNs", "bar", 2.0, 2000, rpc.TimeType_UNIX_SECONDS, callback),
+		testWriteOp("testNs", "baz", 3.0, 3000, rpc.TimeType_UNIX_SECONDS, callback),
+		testWriteOp("testNs", "qux", 4.0, 4000, rpc.TimeType_UNIX_SECONDS, callback),
+	}
+	for _, write := range writes {
+		write.includeDispositions = true
+	}
+	wg.Add(len(writes))
+
+	mockClient := rpc.NewMockTChanNode(ctrl)
+	writeBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawRequest) {
+		assert.True(t, req.IncludeDispositions)
+	}
+	dispositions := []rpc.WriteDisposition{
+		rpc.WriteDisposition_ACCEPTED,
+		rpc.WriteDisposition_CLAMPED,
+		rpc.WriteDisposition_DUPLICATE,
+		rpc.WriteDisposition_ACCEPTED,
+	}
+	mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).
+		Return(&rpc.WriteBatchRawResult_{Dispositions: dispositions}, nil)
+	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
+
+	for _, write := range writes {
+		assert.NoError(t, queue.Enqueue(write))
+	}
+	wg.Wait()
+
+	// Each write is completed with its own disposition.
+	require.Equal(t, len(writes), len(results))
+	var observed []rpc.WriteDisposition
+	for _, result := range results {
+		require.NoError(t, result.err)
+		r, ok := result.result.(hostWriteResult)
+		require.True(t, ok)
+		assert.Equal(t, queue.host, r.host)
+		observed = append(observed, r.disposition)
+	}
+	assert.ElementsMatch(t, dispositions, observed)
+
+	var closeWg sync.WaitGroup
+	closeWg.Add(1)
+	mockConnPool.EXPECT().Close().Do(func() {
+		closeWg.Done()
+	})
+	queue.Close()
+	closeWg.Wait()
+}
+
 func TestHostQueueWriteBatchesDifferentNamespaces(t *testing.T) {
 	for _, opts := range []Options{
 		newHostQueueTestOptions().SetUseV2BatchAPIs(false),
@@ -220,7 +428,7 @@ func TestHostQueueWriteBatchesDifferentNamespaces(t *testing.T) {
 				}
 
 				// Assert the writes will be handled in two batches
-				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil).Times(2)
+				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil).Times(2)
 				mockConnPool.EXPECT().NextClient().Return(mockClient, nil).Times(2)
 			}
 
@@ -355,7 +563,7 @@ func TestHostQueueWriteBatchesPartialBatchErrs(t *testing.T) {
 						assert.Equal(t, req.Elements[i].Datapoint, write.request.Datapoint)
 					}
 				}
-				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(batchErrs)
+				mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, batchErrs)
 			}
 			mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
@@ -417,7 +625,69 @@ func TestHostQueueWriteBatchesEntireBatchErr(t *testing.T) {
 			assert.Equal(t, req.Elements[i].Datapoint, write.request.Datapoint)
 		}
 	}
-	mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(writeErr)
+	mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, writeErr)
+	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
+
+	// Perform writes
+	for _, write := range writes {
+		assert.NoError(t, queue.Enqueue(write))
+	}
+
+	// Wait for flush
+	wg.Wait()
+
+	// Close
+	var closeWg sync.WaitGroup
+	closeWg.Add(1)
+	mockConnPool.EXPECT().Close().Do(func() {
+		closeWg.Done()
+	})
+	queue.Close()
+	closeWg.Wait()
+}
+
+func TestHostQueueWriteBatchesEntireBatchErrRetryAfter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConnPool := NewMockconnectionPool(ctrl)
+
+	opts := newHostQueueTestOptions()
+	opts = opts.SetHostQueueOpsFlushSize(2)
+	queue := newTestHostQueue(opts)
+	queue.connPool = mockConnPool
+
+	// Open
+	mockConnPool.EXPECT().Open()
+	queue.Open()
+	assert.Equal(t, statusOpen, queue.status)
+
+	// Prepare writes
+	var wg sync.WaitGroup
+	writeErr := fmt.Errorf("an error")
+	callback := func(r interface{}, err error) {
+		assert.Error(t, err)
+		assert.Equal(t, writeErr, xerrors.InnerError(err))
+		retryAfter, ok := xerrors.GetRetryAfter(err)
+		assert.True(t, ok)
+		assert.Equal(t, 2*time.Second, retryAfter)
+		wg.Done()
+	}
+	writes := []*writeOperation{
+		testWriteOp("testNs", "foo", 1.0, 1000, rpc.TimeType_UNIX_SECONDS, callback),
+		testWriteOp("testNs", "bar", 2.0, 2000, rpc.TimeType_UNIX_SECONDS, callback),
+	}
+	wg.Add(len(writes))
+
+	// Prepare mocks for flush, responding with backpressure headers
+	mockClient := rpc.NewMockTChanNode(ctrl)
+	writeBatch := func(ctx thrift.Context, req *rpc.WriteBatchRawRequest) {
+		ctx.SetResponseHeaders(map[string]string{
+			tchannelthrift.PressureLevelHeader: tchannelthrift.PressureLevelShedding.String(),
+			tchannelthrift.RetryAfterHeader:    "2000",
+		})
+	}
+	mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, writeErr)
 	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
 	// Perform writes
@@ -486,7 +756,7 @@ func TestHostQueueDrainOnClose(t *testing.T) {
 			assert.Equal(t, req.Elements[i].Datapoint, write.request.Datapoint)
 		}
 	}
-	mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil)
+	mockClient.EXPECT().WriteBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil)
 
 	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
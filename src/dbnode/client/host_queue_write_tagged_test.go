@@ -32,6 +32,7 @@ import (
 
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
+	"github.com/uber/tchannel-go"
 	"github.com/uber/tchannel-go/thrift"
 )
 
@@ -127,7 +128,7 @@ func TestHostQueueWriteTaggedBatches(t *testing.T) {
 						assert.Equal(t, req.Elements[i].EncodedTags, write.request.EncodedTags)
 					}
 				}
-				mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil)
+				mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil)
 			}
 			mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
@@ -242,7 +243,7 @@ func TestHostQueueWriteTaggedBatchesDifferentNamespaces(t *testing.T) {
 					}
 				}
 				// Assert the writes will be handled in two batches.
-				mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil).Times(2)
+				mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil).Times(2)
 				mockConnPool.EXPECT().NextClient().Return(mockClient, nil).Times(2)
 			}
 			for _, write := range writes {
@@ -367,7 +368,7 @@ func TestHostQueueWriteTaggedBatchesPartialBatchErrs(t *testing.T) {
 			Message: writeErr,
 		}},
 	}}
-	mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(batchErrs)
+	mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, batchErrs)
 	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
 	// Perform writes
@@ -427,7 +428,7 @@ func TestHostQueueWriteTaggedBatchesEntireBatchErr(t *testing.T) {
 			assert.Equal(t, req.Elements[i].EncodedTags, write.request.EncodedTags)
 		}
 	}
-	mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(writeErr)
+	mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, writeErr)
 	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
 	// Perform writes
@@ -448,6 +449,73 @@ func TestHostQueueWriteTaggedBatchesEntireBatchErr(t *testing.T) {
 	closeWg.Wait()
 }
 
+func TestHostQueueWriteTaggedBatchesIdempotentRetryOnTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockConnPool := NewMockconnectionPool(ctrl)
+
+	opts := newHostQueueTestOptions().
+		SetHostQueueOpsFlushSize(2).
+		SetWriteIdempotencyEnabled(true)
+	queue := newTestHostQueue(opts)
+	queue.connPool = mockConnPool
+
+	// Open
+	mockConnPool.EXPECT().Open()
+	queue.Open()
+	assert.Equal(t, statusOpen, queue.status)
+
+	// Prepare writes
+	var wg sync.WaitGroup
+	callback := func(r interface{}, err error) {
+		assert.NoError(t, err)
+		wg.Done()
+	}
+	writes := []*writeTaggedOperation{
+		testWriteTaggedOp("testNs", "foo", map[string]string{"abc": "def"}, 1.0, 1000, rpc.TimeType_UNIX_SECONDS, callback),
+		testWriteTaggedOp("testNs", "bar", map[string]string{"ghi": "klm"}, 2.0, 2000, rpc.TimeType_UNIX_SECONDS, callback),
+	}
+	wg.Add(len(writes))
+
+	// Prepare mocks for flush, the first attempt times out and the retry
+	// must be sent with the same idempotency key.
+	var keys [][]byte
+	mockClient := rpc.NewMockTChanNode(ctrl)
+	writeBatch := func(ctx thrift.Context, req *rpc.WriteTaggedBatchRawRequest) {
+		assert.Equal(t, len(writes), len(req.Elements))
+		keys = append(keys, append([]byte(nil), req.IdempotencyKey...))
+	}
+	gomock.InOrder(
+		mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).
+			Do(writeBatch).Return(nil, tchannel.ErrTimeout),
+		mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).
+			Do(writeBatch).Return(nil, nil),
+	)
+	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
+
+	// Perform writes
+	for _, write := range writes {
+		assert.NoError(t, queue.Enqueue(write))
+	}
+
+	// Wait for flush
+	wg.Wait()
+
+	assert.Equal(t, 2, len(keys))
+	assert.Equal(t, 16, len(keys[0]))
+	assert.Equal(t, keys[0], keys[1])
+
+	// Close
+	var closeWg sync.WaitGroup
+	closeWg.Add(1)
+	mockConnPool.EXPECT().Close().Do(func() {
+		closeWg.Done()
+	})
+	queue.Close()
+	closeWg.Wait()
+}
+
 func TestHostQueueDrainOnCloseTaggedWrite(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -497,7 +565,7 @@ func TestHostQueueDrainOnCloseTaggedWrite(t *testing.T) {
 			assert.Equal(t, req.Elements[i].EncodedTags, write.request.EncodedTags)
 		}
 	}
-	mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil)
+	mockClient.EXPECT().WriteTaggedBatchRaw(gomock.Any(), gomock.Any()).Do(writeBatch).Return(nil, nil)
 
 	mockConnPool.EXPECT().NextClient().Return(mockClient, nil)
 
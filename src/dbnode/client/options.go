@@ -22,6 +22,7 @@ package client
 
 import (
 	"errors"
+	"fmt"
 	"io"
 	"math"
 	"runtime"
@@ -33,9 +34,14 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding/proto"
 	"github.com/m3db/m3/src/dbnode/environment"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -160,6 +166,38 @@ const (
 	// defaultUseV2BatchAPIs is the default setting for whether the v2 version of the batch APIs should
 	// be used.
 	defaultUseV2BatchAPIs = false
+
+	// defaultReadRepairEnabled is the default setting for whether divergent
+	// replicas observed on the fetch path are enqueued for repair.
+	defaultReadRepairEnabled = false
+
+	// defaultReadRepairQueueSize is the default size of the read repair queue.
+	defaultReadRepairQueueSize = 4096
+
+	// defaultFetchMergeReplicaBlocks is the default setting for whether
+	// fetches merge the blocks returned by replicas.
+	defaultFetchMergeReplicaBlocks = false
+
+	// defaultWriteIdempotencyEnabled is the default setting for whether
+	// tagged write batches are sent with an idempotency key.
+	defaultWriteIdempotencyEnabled = false
+
+	// defaultWriteDurability is the default durability class write batches
+	// request, deferring to the durability class of the namespace.
+	defaultWriteDurability = ts.DurabilityDefault
+
+	// defaultWriteSpillEnabled is the default setting for whether writes
+	// that fail because the nodes owning their shard are unavailable are
+	// spilled to disk and replayed once the nodes recover.
+	defaultWriteSpillEnabled = false
+
+	// defaultWriteSpillMaxBytes is the default maximum size of the writes
+	// spilled to disk.
+	defaultWriteSpillMaxBytes = 256 * 1024 * 1024
+
+	// defaultWriteSpillReplayInterval is the default interval at which
+	// writes spilled to disk are replayed.
+	defaultWriteSpillReplayInterval = 10 * time.Second
 )
 
 var (
@@ -201,6 +239,10 @@ var (
 
 	errNoTopologyInitializerSet    = errors.New("no topology initializer set")
 	errNoReaderIteratorAllocateSet = errors.New("no reader iterator allocator set, encoding not set")
+	errReadRepairQueueSizeInvalid  = errors.New("read repair queue size must be positive when read repair is enabled")
+	errWriteSpillPathNotSet        = errors.New("write spill path must be set when write spill is enabled")
+	errWriteSpillMaxBytesInvalid   = errors.New("write spill max bytes must be positive when write spill is enabled")
+	errWriteSpillIntervalInvalid   = errors.New("write spill replay interval must be positive when write spill is enabled")
 )
 
 type options struct {
@@ -212,6 +254,7 @@ type options struct {
 	writeConsistencyLevel                   topology.ConsistencyLevel
 	bootstrapConsistencyLevel               topology.ReadConsistencyLevel
 	channelOptions                          *tchannel.ChannelOptions
+	peerStats                               peerstats.Tracker
 	maxConnectionCount                      int
 	minConnectionCount                      int
 	hostConnectTimeout                      time.Duration
@@ -253,12 +296,26 @@ type options struct {
 	fetchSeriesBlocksMetadataBatchTimeout   time.Duration
 	fetchSeriesBlocksBatchTimeout           time.Duration
 	fetchSeriesBlocksBatchConcurrency       int
+	fetchSeriesBlocksCompression            []compress.Type
+	faultInjector                           fault.Injector
+	bootstrapPeerPreferences                BootstrapPeerPreferences
 	schemaRegistry                          namespace.SchemaRegistry
 	isProtoEnabled                          bool
 	asyncTopologyInitializers               []topology.Initializer
 	asyncWriteWorkerPool                    xsync.PooledWorkerPool
 	asyncWriteMaxConcurrency                int
 	useV2BatchAPIs                          bool
+	readRepairEnabled                       bool
+	readRepairQueueSize                     int
+	fetchMergeReplicaBlocks                 bool
+	replicaSelector                         ReplicaSelector
+	writeIdempotencyEnabled                 bool
+	writeDurability                         ts.Durability
+	writeSpillEnabled                       bool
+	writeSpillPath                          string
+	writeSpillMaxBytes                      int64
+	writeSpillReplayInterval                time.Duration
+	namespaceShardKeyStrategies             map[string]string
 }
 
 // NewOptions creates a new set of client options with defaults
@@ -283,6 +340,9 @@ func NewOptionsForAsyncClusters(opts Options, topoInits []topology.Initializer,
 		if overrides[i].TargetHostQueueFlushSize != nil {
 			options = options.SetHostQueueOpsFlushSize(*overrides[i].TargetHostQueueFlushSize)
 		}
+		if overrides[i].WriteConsistencyLevel != nil {
+			options = options.SetWriteConsistencyLevel(*overrides[i].WriteConsistencyLevel)
+		}
 		result = append(result, options)
 	}
 	return result
@@ -312,6 +372,7 @@ func newOptions() *options {
 	opts := &options{
 		clockOpts:                               clock.NewOptions(),
 		instrumentOpts:                          instrument.NewOptions(),
+		peerStats:                               peerstats.NewTracker(instrument.NewOptions()),
 		writeConsistencyLevel:                   defaultWriteConsistencyLevel,
 		readConsistencyLevel:                    defaultReadConsistencyLevel,
 		bootstrapConsistencyLevel:               defaultBootstrapConsistencyLevel,
@@ -354,10 +415,19 @@ func newOptions() *options {
 		fetchSeriesBlocksMetadataBatchTimeout:   defaultFetchSeriesBlocksMetadataBatchTimeout,
 		fetchSeriesBlocksBatchTimeout:           defaultFetchSeriesBlocksBatchTimeout,
 		fetchSeriesBlocksBatchConcurrency:       defaultFetchSeriesBlocksBatchConcurrency,
+		faultInjector:                           fault.NewNopInjector(),
 		schemaRegistry:                          namespace.NewSchemaRegistry(false, nil),
 		asyncTopologyInitializers:               []topology.Initializer{},
 		asyncWriteMaxConcurrency:                defaultAsyncWriteMaxConcurrency,
 		useV2BatchAPIs:                          defaultUseV2BatchAPIs,
+		readRepairEnabled:                       defaultReadRepairEnabled,
+		readRepairQueueSize:                     defaultReadRepairQueueSize,
+		fetchMergeReplicaBlocks:                 defaultFetchMergeReplicaBlocks,
+		writeIdempotencyEnabled:                 defaultWriteIdempotencyEnabled,
+		writeDurability:                         defaultWriteDurability,
+		writeSpillEnabled:                       defaultWriteSpillEnabled,
+		writeSpillMaxBytes:                      defaultWriteSpillMaxBytes,
+		writeSpillReplayInterval:                defaultWriteSpillReplayInterval,
 	}
 	return opts.SetEncodingM3TSZ().(*options)
 }
@@ -384,6 +454,28 @@ func validate(opts *options) error {
 	); err != nil {
 		return err
 	}
+	if opts.readRepairEnabled && opts.readRepairQueueSize <= 0 {
+		return errReadRepairQueueSizeInvalid
+	}
+	if opts.writeSpillEnabled {
+		if opts.writeSpillPath == "" {
+			return errWriteSpillPathNotSet
+		}
+		if opts.writeSpillMaxBytes <= 0 {
+			return errWriteSpillMaxBytesInvalid
+		}
+		if opts.writeSpillReplayInterval <= 0 {
+			return errWriteSpillIntervalInvalid
+		}
+	}
+	for ns, strategy := range opts.namespaceShardKeyStrategies {
+		if _, err := sharding.LookupShardKeyStrategy(strategy); err != nil {
+			return fmt.Errorf("invalid shard key strategy for namespace %s: %v", ns, err)
+		}
+	}
+	if err := opts.bootstrapPeerPreferences.Validate(); err != nil {
+		return err
+	}
 	return topology.ValidateConnectConsistencyLevel(
 		opts.clusterConnectConsistencyLevel,
 	)
@@ -495,6 +587,16 @@ func (o *options) ChannelOptions() *tchannel.ChannelOptions {
 	return o.channelOptions
 }
 
+func (o *options) SetPeerStats(value peerstats.Tracker) Options {
+	opts := *o
+	opts.peerStats = value
+	return &opts
+}
+
+func (o *options) PeerStats() peerstats.Tracker {
+	return o.peerStats
+}
+
 func (o *options) SetMaxConnectionCount(value int) Options {
 	opts := *o
 	opts.maxConnectionCount = value
@@ -915,6 +1017,36 @@ func (o *options) FetchSeriesBlocksBatchConcurrency() int {
 	return o.fetchSeriesBlocksBatchConcurrency
 }
 
+func (o *options) SetFetchSeriesBlocksCompression(value []compress.Type) AdminOptions {
+	opts := *o
+	opts.fetchSeriesBlocksCompression = value
+	return &opts
+}
+
+func (o *options) FetchSeriesBlocksCompression() []compress.Type {
+	return o.fetchSeriesBlocksCompression
+}
+
+func (o *options) SetFaultInjector(value fault.Injector) AdminOptions {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
+
+func (o *options) SetBootstrapPeerPreferences(value BootstrapPeerPreferences) AdminOptions {
+	opts := *o
+	opts.bootstrapPeerPreferences = value
+	return &opts
+}
+
+func (o *options) BootstrapPeerPreferences() BootstrapPeerPreferences {
+	return o.bootstrapPeerPreferences
+}
+
 func (o *options) SetAsyncTopologyInitializers(value []topology.Initializer) Options {
 	opts := *o
 	opts.asyncTopologyInitializers = value
@@ -954,3 +1086,113 @@ func (o *options) SetUseV2BatchAPIs(value bool) Options {
 func (o *options) UseV2BatchAPIs() bool {
 	return o.useV2BatchAPIs
 }
+
+func (o *options) SetReadRepairEnabled(value bool) Options {
+	opts := *o
+	opts.readRepairEnabled = value
+	return &opts
+}
+
+func (o *options) ReadRepairEnabled() bool {
+	return o.readRepairEnabled
+}
+
+func (o *options) SetReadRepairQueueSize(value int) Options {
+	opts := *o
+	opts.readRepairQueueSize = value
+	return &opts
+}
+
+func (o *options) ReadRepairQueueSize() int {
+	return o.readRepairQueueSize
+}
+
+func (o *options) SetFetchMergeReplicaBlocks(value bool) Options {
+	opts := *o
+	opts.fetchMergeReplicaBlocks = value
+	return &opts
+}
+
+func (o *options) FetchMergeReplicaBlocks() bool {
+	return o.fetchMergeReplicaBlocks
+}
+
+func (o *options) SetReplicaSelector(value ReplicaSelector) Options {
+	opts := *o
+	opts.replicaSelector = value
+	return &opts
+}
+
+func (o *options) ReplicaSelector() ReplicaSelector {
+	return o.replicaSelector
+}
+
+func (o *options) SetWriteIdempotencyEnabled(value bool) Options {
+	opts := *o
+	opts.writeIdempotencyEnabled = value
+	return &opts
+}
+
+func (o *options) WriteIdempotencyEnabled() bool {
+	return o.writeIdempotencyEnabled
+}
+
+func (o *options) SetWriteDurability(value ts.Durability) Options {
+	opts := *o
+	opts.writeDurability = value
+	return &opts
+}
+
+func (o *options) WriteDurability() ts.Durability {
+	return o.writeDurability
+}
+
+func (o *options) SetWriteSpillEnabled(value bool) Options {
+	opts := *o
+	opts.writeSpillEnabled = value
+	return &opts
+}
+
+func (o *options) WriteSpillEnabled() bool {
+	return o.writeSpillEnabled
+}
+
+func (o *options) SetWriteSpillPath(value string) Options {
+	opts := *o
+	opts.writeSpillPath = value
+	return &opts
+}
+
+func (o *options) WriteSpillPath() string {
+	return o.writeSpillPath
+}
+
+func (o *options) SetWriteSpillMaxBytes(value int64) Options {
+	opts := *o
+	opts.writeSpillMaxBytes = value
+	return &opts
+}
+
+func (o *options) WriteSpillMaxBytes() int64 {
+	return o.writeSpillMaxBytes
+}
+
+func (o *options) SetWriteSpillReplayInterval(value time.Duration) Options {
+	opts := *o
+	opts.writeSpillReplayInterval = value
+	return &opts
+}
+
+func (o *options) WriteSpillReplayInterval() time.Duration {
+	return o.writeSpillReplayInterval
+}
+
+func (o *options) SetNamespaceShardKeyStrategies(value map[string]string) Options {
+	opts := *o
+	opts.namespaceShardKeyStrategies = value
+	return &opts
+}
+
+func (o *options) NamespaceShardKeyStrategies() map[string]string {
+	return o.namespaceShardKeyStrategies
+}
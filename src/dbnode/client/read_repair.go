@@ -0,0 +1,339 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package client
+
+import (
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+var errReadRepairerAlreadyOpen = errors.New("read repairer already open")
+
+// readRepairBlock is the summary of a single block returned by a replica
+// for a fetch, used to detect divergence between replicas.
+type readRepairBlock struct {
+	start    int64
+	size     int64
+	checksum uint32
+	// merged is false when the replica returned the block as a set of
+	// unmerged segments, in which case its checksum is not comparable
+	// against other replicas.
+	merged bool
+}
+
+func newReadRepairBlocks(segments []*rpc.Segments) []readRepairBlock {
+	blocks := make([]readRepairBlock, 0, len(segments))
+	for _, seg := range segments {
+		if seg == nil {
+			continue
+		}
+		if merged := seg.Merged; merged != nil {
+			if merged.StartTime == nil || merged.BlockSize == nil {
+				continue
+			}
+			d := digest.NewDigest()
+			d = d.Update(merged.Head)
+			d = d.Update(merged.Tail)
+			blocks = append(blocks, readRepairBlock{
+				start:    *merged.StartTime,
+				size:     *merged.BlockSize,
+				checksum: d.Sum32(),
+				merged:   true,
+			})
+			continue
+		}
+		for _, unmerged := range seg.Unmerged {
+			if unmerged.StartTime == nil || unmerged.BlockSize == nil {
+				continue
+			}
+			blocks = append(blocks, readRepairBlock{
+				start: *unmerged.StartTime,
+				size:  *unmerged.BlockSize,
+			})
+			// All unmerged segments of a block share the same start.
+			break
+		}
+	}
+	return blocks
+}
+
+type readRepairDivergence struct {
+	start    int64
+	size     int64
+	missing  bool
+	mismatch bool
+}
+
+// readRepairDivergentBlocks returns the blocks that are either missing on
+// some of the replicas or have mismatched checksums between the replicas,
+// sorted by block start.
+func readRepairDivergentBlocks(replicas [][]readRepairBlock) []readRepairDivergence {
+	if len(replicas) < 2 {
+		return nil
+	}
+
+	type observed struct {
+		block    readRepairBlock
+		count    int
+		mismatch bool
+	}
+	byStart := make(map[int64]*observed)
+	for _, blocks := range replicas {
+		for _, block := range blocks {
+			existing, ok := byStart[block.start]
+			if !ok {
+				byStart[block.start] = &observed{block: block, count: 1}
+				continue
+			}
+			existing.count++
+			if !existing.block.merged {
+				// Prefer comparing against a merged block if one is observed.
+				existing.block = block
+				continue
+			}
+			if block.merged && block.checksum != existing.block.checksum {
+				existing.mismatch = true
+			}
+		}
+	}
+
+	var result []readRepairDivergence
+	for start, o := range byStart {
+		missing := o.count < len(replicas)
+		if !missing && !o.mismatch {
+			continue
+		}
+		result = append(result, readRepairDivergence{
+			start:    start,
+			size:     o.block.size,
+			missing:  missing,
+			mismatch: o.mismatch,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].start < result[j].start
+	})
+	return result
+}
+
+// readRepairRequest is a request to repair a single block of a series.
+type readRepairRequest struct {
+	namespace  ident.ID
+	id         ident.ID
+	blockStart time.Time
+	blockEnd   time.Time
+}
+
+// readRepairFn repairs the requested block of a series and returns the
+// number of datapoints rewritten to the replicas.
+type readRepairFn func(req readRepairRequest) (int, error)
+
+type readRepairMetrics struct {
+	divergentSeries     tally.Counter
+	missingBlocks       tally.Counter
+	mismatchedBlocks    tally.Counter
+	enqueued            tally.Counter
+	dropped             tally.Counter
+	repairSuccess       tally.Counter
+	repairErrors        tally.Counter
+	datapointsRewritten tally.Counter
+}
+
+func newReadRepairMetrics(scope tally.Scope) readRepairMetrics {
+	return readRepairMetrics{
+		divergentSeries:     scope.Counter("divergent-series"),
+		missingBlocks:       scope.Counter("missing-blocks"),
+		mismatchedBlocks:    scope.Counter("mismatched-blocks"),
+		enqueued:            scope.Counter("enqueued"),
+		dropped:             scope.Counter("dropped"),
+		repairSuccess:       scope.Counter("repair-success"),
+		repairErrors:        scope.Counter("repair-errors"),
+		datapointsRewritten: scope.Counter("datapoints-rewritten"),
+	}
+}
+
+// readRepairer enqueues series blocks observed to diverge between replicas
+// on the fetch path and repairs them in the background so that hot data
+// converges faster than with the periodic repair process alone.
+type readRepairer struct {
+	sync.Mutex
+
+	repairFn readRepairFn
+	queue    chan readRepairRequest
+	doneCh   chan struct{}
+	wg       sync.WaitGroup
+	open     bool
+	logger   *zap.Logger
+	metrics  readRepairMetrics
+}
+
+func newReadRepairer(
+	queueSize int,
+	repairFn readRepairFn,
+	scope tally.Scope,
+	logger *zap.Logger,
+) *readRepairer {
+	return &readRepairer{
+		repairFn: repairFn,
+		queue:    make(chan readRepairRequest, queueSize),
+		doneCh:   make(chan struct{}),
+		logger:   logger,
+		metrics:  newReadRepairMetrics(scope),
+	}
+}
+
+// Open starts the background repair worker.
+func (r *readRepairer) Open() error {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.open {
+		return errReadRepairerAlreadyOpen
+	}
+	r.open = true
+	r.wg.Add(1)
+	go r.repairLoop()
+	return nil
+}
+
+// Observe compares the blocks returned by the replicas that responded to a
+// fetch of a series and enqueues any divergent blocks for repair.
+func (r *readRepairer) Observe(
+	namespace, id ident.ID,
+	replicas [][]readRepairBlock,
+) {
+	divergent := readRepairDivergentBlocks(replicas)
+	if len(divergent) == 0 {
+		return
+	}
+
+	r.metrics.divergentSeries.Inc(1)
+
+	// NB: The namespace and ID are owned by the fetch that observed the
+	// divergence so take copies that live for as long as the request.
+	var (
+		namespaceCopy = ident.BytesID(append([]byte(nil), namespace.Bytes()...))
+		idCopy        = ident.BytesID(append([]byte(nil), id.Bytes()...))
+	)
+	for _, block := range divergent {
+		if block.missing {
+			r.metrics.missingBlocks.Inc(1)
+		} else {
+			r.metrics.mismatchedBlocks.Inc(1)
+		}
+
+		blockStart := time.Unix(0, block.start)
+		req := readRepairRequest{
+			namespace:  namespaceCopy,
+			id:         idCopy,
+			blockStart: blockStart,
+			blockEnd:   blockStart.Add(time.Duration(block.size)),
+		}
+		select {
+		case r.queue <- req:
+			r.metrics.enqueued.Inc(1)
+		default:
+			r.metrics.dropped.Inc(1)
+		}
+	}
+}
+
+func (r *readRepairer) repairLoop() {
+	defer r.wg.Done()
+	for {
+		select {
+		case <-r.doneCh:
+			return
+		case req := <-r.queue:
+			n, err := r.repairFn(req)
+			r.metrics.datapointsRewritten.Inc(int64(n))
+			if err != nil {
+				r.metrics.repairErrors.Inc(1)
+				r.logger.Debug("read repair of series block failed",
+					zap.Stringer("namespace", req.namespace),
+					zap.Stringer("id", req.id),
+					zap.Time("blockStart", req.blockStart),
+					zap.Error(err))
+				continue
+			}
+			r.metrics.repairSuccess.Inc(1)
+		}
+	}
+}
+
+// Close stops the background repair worker, pending repairs are dropped.
+func (r *readRepairer) Close() {
+	r.Lock()
+	defer r.Unlock()
+
+	if !r.open {
+		return
+	}
+	r.open = false
+	close(r.doneCh)
+	r.wg.Wait()
+}
+
+// readRepair rewrites the datapoints of a series block read at the session
+// read consistency level to the replicas so that replicas missing
+// datapoints converge with the ones that have them.
+func (s *session) readRepair(req readRepairRequest) (int, error) {
+	iters, err := s.fetchIDsAttempt(req.namespace, ident.NewIDsIterator(req.id),
+		req.blockStart, req.blockEnd, false)
+	if err != nil {
+		return 0, err
+	}
+	defer iters.Close()
+
+	var (
+		rewritten int
+		multiErr  xerrors.MultiError
+	)
+	for _, iter := range iters.Iters() {
+		for iter.Next() {
+			dp, unit, annotation := iter.Current()
+			if annotation != nil {
+				// NB: The iterator reuses the annotation buffer between
+				// datapoints so take a copy for the write.
+				annotation = append([]byte(nil), annotation...)
+			}
+			if err := s.Write(req.namespace, req.id, dp.Timestamp, dp.Value,
+				unit, annotation); err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			rewritten++
+		}
+		if err := iter.Err(); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return rewritten, multiErr.FinalError()
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package client
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+func testReadRepairSegment(start, size int64, head, tail []byte) *rpc.Segment {
+	return &rpc.Segment{
+		Head:      head,
+		Tail:      tail,
+		StartTime: &start,
+		BlockSize: &size,
+	}
+}
+
+func TestNewReadRepairBlocks(t *testing.T) {
+	size := int64(2 * time.Hour)
+	segments := []*rpc.Segments{
+		{Merged: testReadRepairSegment(0, size, []byte("a"), []byte("b"))},
+		{Merged: testReadRepairSegment(size, size, []byte("ab"), nil)},
+		{Unmerged: []*rpc.Segment{
+			testReadRepairSegment(2*size, size, []byte("a"), nil),
+			testReadRepairSegment(2*size, size, []byte("b"), nil),
+		}},
+		{Merged: &rpc.Segment{Head: []byte("a")}},
+	}
+
+	blocks := newReadRepairBlocks(segments)
+	require.Equal(t, 3, len(blocks))
+
+	assert.Equal(t, int64(0), blocks[0].start)
+	assert.True(t, blocks[0].merged)
+	// Checksum is computed over the head and tail so the split does not matter.
+	assert.Equal(t, blocks[0].checksum, blocks[1].checksum)
+	assert.Equal(t, size, blocks[1].start)
+	assert.Equal(t, 2*size, blocks[2].start)
+	assert.False(t, blocks[2].merged)
+}
+
+func TestReadRepairDivergentBlocks(t *testing.T) {
+	size := int64(time.Hour)
+	block := func(start int64, checksum uint32, merged bool) readRepairBlock {
+		return readRepairBlock{start: start, size: size, checksum: checksum, merged: merged}
+	}
+
+	// Single replica can never diverge.
+	assert.Nil(t, readRepairDivergentBlocks([][]readRepairBlock{
+		{block(0, 1, true)},
+	}))
+
+	// Identical replicas do not diverge.
+	assert.Nil(t, readRepairDivergentBlocks([][]readRepairBlock{
+		{block(0, 1, true), block(size, 2, true)},
+		{block(0, 1, true), block(size, 2, true)},
+	}))
+
+	// Unmerged blocks are not compared by checksum.
+	assert.Nil(t, readRepairDivergentBlocks([][]readRepairBlock{
+		{block(0, 1, true)},
+		{block(0, 3, false)},
+	}))
+
+	divergent := readRepairDivergentBlocks([][]readRepairBlock{
+		{block(0, 1, true), block(size, 2, true), block(2*size, 3, true)},
+		{block(0, 1, true), block(size, 5, true)},
+		{block(0, 1, true), block(size, 2, true), block(2*size, 3, true)},
+	})
+	assert.Equal(t, []readRepairDivergence{
+		{start: size, size: size, mismatch: true},
+		{start: 2 * size, size: size, missing: true},
+	}, divergent)
+}
+
+func TestReadRepairerObserveEnqueuesAndRepairs(t *testing.T) {
+	var (
+		scope = tally.NewTestScope("", nil)
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		reqs  []readRepairRequest
+		size  = int64(time.Hour)
+	)
+	wg.Add(2)
+	repairer := newReadRepairer(8, func(req readRepairRequest) (int, error) {
+		mu.Lock()
+		reqs = append(reqs, req)
+		mu.Unlock()
+		wg.Done()
+		return 3, nil
+	}, scope, zap.NewNop())
+	require.NoError(t, repairer.Open())
+	require.Equal(t, errReadRepairerAlreadyOpen, repairer.Open())
+
+	ns := ident.StringID("testNs")
+	id := ident.StringID("foo")
+	repairer.Observe(ns, id, [][]readRepairBlock{
+		{{start: 0, size: size, checksum: 1, merged: true}},
+		{
+			{start: 0, size: size, checksum: 2, merged: true},
+			{start: size, size: size, checksum: 1, merged: true},
+		},
+	})
+	// The IDs are copied so finalizing them must not affect the requests.
+	ns.Finalize()
+	id.Finalize()
+
+	wg.Wait()
+	repairer.Close()
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, 2, len(reqs))
+	for i, req := range reqs {
+		start := time.Unix(0, int64(i)*size)
+		assert.Equal(t, "testNs", req.namespace.String())
+		assert.Equal(t, "foo", req.id.String())
+		assert.True(t, start.Equal(req.blockStart))
+		assert.True(t, start.Add(time.Hour).Equal(req.blockEnd))
+	}
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(1), counters["divergent-series+"].Value())
+	assert.Equal(t, int64(1), counters["missing-blocks+"].Value())
+	assert.Equal(t, int64(1), counters["mismatched-blocks+"].Value())
+	assert.Equal(t, int64(2), counters["enqueued+"].Value())
+	assert.Equal(t, int64(2), counters["repair-success+"].Value())
+	assert.Equal(t, int64(6), counters["datapoints-rewritten+"].Value())
+}
+
+func TestReadRepairerObserveDropsWhenQueueFull(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	repairer := newReadRepairer(1, func(req readRepairRequest) (int, error) {
+		return 0, nil
+	}, scope, zap.NewNop())
+
+	// Do not open the repairer so that nothing drains the queue.
+	size := int64(time.Hour)
+	repairer.Observe(ident.StringID("testNs"), ident.StringID("foo"), [][]readRepairBlock{
+		{},
+		{
+			{start: 0, size: size, merged: true},
+			{start: size, size: size, merged: true},
+		},
+	})
+
+	counters := scope.Snapshot().Counters()
+	assert.Equal(t, int64(2), counters["missing-blocks+"].Value())
+	assert.Equal(t, int64(1), counters["enqueued+"].Value())
+	assert.Equal(t, int64(1), counters["dropped+"].Value())
+}
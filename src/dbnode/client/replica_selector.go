@@ -0,0 +1,319 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+)
+
+// ReplicaSelectionStrategy is a strategy for selecting the replicas of a
+// shard that reads are sent to.
+type ReplicaSelectionStrategy string
+
+const (
+	// AllReplicaSelectionStrategy reads from every replica in topology order.
+	AllReplicaSelectionStrategy ReplicaSelectionStrategy = "all"
+	// RoundRobinReplicaSelectionStrategy rotates the replicas that are read
+	// from between reads.
+	RoundRobinReplicaSelectionStrategy ReplicaSelectionStrategy = "roundRobin"
+	// LeastOutstandingReplicaSelectionStrategy reads from the replicas with
+	// the fewest outstanding reads.
+	LeastOutstandingReplicaSelectionStrategy ReplicaSelectionStrategy = "leastOutstanding"
+	// LatencyWeightedReplicaSelectionStrategy reads from replicas at random
+	// weighted by the inverse of the exponentially weighted moving average of
+	// their read latencies.
+	LatencyWeightedReplicaSelectionStrategy ReplicaSelectionStrategy = "latencyWeighted"
+
+	// latencyWeightedDecay is the weight of a new latency sample in the
+	// moving average of the read latencies of a replica.
+	latencyWeightedDecay = 0.3
+	// latencyWeightedErrorPenalty is the latency recorded for a read that
+	// returns an error, so that failing replicas are read from less often.
+	latencyWeightedErrorPenalty = 10 * time.Second
+	// latencyWeightedMinLatency is the lower bound of the moving average used
+	// to weight a replica, this avoids replicas without samples or with very
+	// low latencies from receiving every read.
+	latencyWeightedMinLatency = time.Millisecond
+)
+
+var validReplicaSelectionStrategies = []ReplicaSelectionStrategy{
+	AllReplicaSelectionStrategy,
+	RoundRobinReplicaSelectionStrategy,
+	LeastOutstandingReplicaSelectionStrategy,
+	LatencyWeightedReplicaSelectionStrategy,
+}
+
+// ParseReplicaSelectionStrategy parses a replica selection strategy.
+func ParseReplicaSelectionStrategy(str string) (ReplicaSelectionStrategy, error) {
+	for _, s := range validReplicaSelectionStrategies {
+		if str == string(s) {
+			return s, nil
+		}
+	}
+	return AllReplicaSelectionStrategy, fmt.Errorf(
+		"invalid replica selection strategy '%s', valid strategies are: %v",
+		str, validReplicaSelectionStrategies)
+}
+
+// UnmarshalYAML unmarshals a replica selection strategy.
+func (s *ReplicaSelectionStrategy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseReplicaSelectionStrategy(str)
+	if err != nil {
+		return err
+	}
+	*s = parsed
+	return nil
+}
+
+// NewReplicaSelector returns a new replica selector for a strategy, the all
+// replicas strategy returns a nil selector as every replica is read from.
+func NewReplicaSelector(strategy ReplicaSelectionStrategy) (ReplicaSelector, error) {
+	switch strategy {
+	case AllReplicaSelectionStrategy:
+		return nil, nil
+	case RoundRobinReplicaSelectionStrategy:
+		return NewRoundRobinReplicaSelector(), nil
+	case LeastOutstandingReplicaSelectionStrategy:
+		return NewLeastOutstandingReplicaSelector(), nil
+	case LatencyWeightedReplicaSelectionStrategy:
+		return NewLatencyWeightedReplicaSelector(), nil
+	}
+	return nil, fmt.Errorf("unknown replica selection strategy: %s", strategy)
+}
+
+// Replica is a replica of a shard that a read can be sent to.
+type Replica struct {
+	Host topology.Host
+
+	hostIdx int
+}
+
+type roundRobinReplicaSelector struct {
+	next uint64
+}
+
+// NewRoundRobinReplicaSelector returns a replica selector that rotates the
+// replicas that are read from between reads.
+func NewRoundRobinReplicaSelector() ReplicaSelector {
+	return &roundRobinReplicaSelector{}
+}
+
+func (s *roundRobinReplicaSelector) Order(replicas []Replica) {
+	if len(replicas) < 2 {
+		return
+	}
+	offset := int(atomic.AddUint64(&s.next, 1) % uint64(len(replicas)))
+	reverseReplicas(replicas[:offset])
+	reverseReplicas(replicas[offset:])
+	reverseReplicas(replicas)
+}
+
+func (s *roundRobinReplicaSelector) RequestStarted(host topology.Host) {}
+
+func (s *roundRobinReplicaSelector) RequestCompleted(
+	host topology.Host,
+	latency time.Duration,
+	err error,
+) {
+}
+
+func reverseReplicas(replicas []Replica) {
+	for i, j := 0, len(replicas)-1; i < j; i, j = i+1, j-1 {
+		replicas[i], replicas[j] = replicas[j], replicas[i]
+	}
+}
+
+// replicaStats are the read statistics of a replica.
+type replicaStats struct {
+	outstanding int64
+	// latencyBits is the float64 bits of the moving average of the read
+	// latencies in nanoseconds.
+	latencyBits uint64
+}
+
+func (s *replicaStats) latency() float64 {
+	return math.Float64frombits(atomic.LoadUint64(&s.latencyBits))
+}
+
+func (s *replicaStats) recordLatency(latency time.Duration) {
+	for {
+		prevBits := atomic.LoadUint64(&s.latencyBits)
+		prev := math.Float64frombits(prevBits)
+		next := float64(latency)
+		if prev > 0 {
+			next = latencyWeightedDecay*next + (1-latencyWeightedDecay)*prev
+		}
+		if atomic.CompareAndSwapUint64(&s.latencyBits, prevBits, math.Float64bits(next)) {
+			return
+		}
+	}
+}
+
+// replicaStatsMap tracks the read statistics of replicas by host ID.
+type replicaStatsMap struct {
+	sync.RWMutex
+	stats map[string]*replicaStats
+}
+
+func newReplicaStatsMap() *replicaStatsMap {
+	return &replicaStatsMap{stats: make(map[string]*replicaStats)}
+}
+
+func (m *replicaStatsMap) get(host topology.Host) *replicaStats {
+	id := host.ID()
+	m.RLock()
+	stats, ok := m.stats[id]
+	m.RUnlock()
+	if ok {
+		return stats
+	}
+
+	m.Lock()
+	stats, ok = m.stats[id]
+	if !ok {
+		stats = &replicaStats{}
+		m.stats[id] = stats
+	}
+	m.Unlock()
+	return stats
+}
+
+type leastOutstandingReplicaSelector struct {
+	stats *replicaStatsMap
+}
+
+// NewLeastOutstandingReplicaSelector returns a replica selector that reads
+// from the replicas with the fewest outstanding reads.
+func NewLeastOutstandingReplicaSelector() ReplicaSelector {
+	return &leastOutstandingReplicaSelector{stats: newReplicaStatsMap()}
+}
+
+func (s *leastOutstandingReplicaSelector) Order(replicas []Replica) {
+	if len(replicas) < 2 {
+		return
+	}
+	outstanding := make([]int64, len(replicas))
+	for i, r := range replicas {
+		outstanding[i] = atomic.LoadInt64(&s.stats.get(r.Host).outstanding)
+	}
+	sort.Stable(replicasByScore{replicas: replicas, scores: outstanding})
+}
+
+func (s *leastOutstandingReplicaSelector) RequestStarted(host topology.Host) {
+	atomic.AddInt64(&s.stats.get(host).outstanding, 1)
+}
+
+func (s *leastOutstandingReplicaSelector) RequestCompleted(
+	host topology.Host,
+	latency time.Duration,
+	err error,
+) {
+	atomic.AddInt64(&s.stats.get(host).outstanding, -1)
+}
+
+type replicasByScore struct {
+	replicas []Replica
+	scores   []int64
+}
+
+func (r replicasByScore) Len() int           { return len(r.replicas) }
+func (r replicasByScore) Less(i, j int) bool { return r.scores[i] < r.scores[j] }
+func (r replicasByScore) Swap(i, j int) {
+	r.replicas[i], r.replicas[j] = r.replicas[j], r.replicas[i]
+	r.scores[i], r.scores[j] = r.scores[j], r.scores[i]
+}
+
+type latencyWeightedReplicaSelector struct {
+	stats *replicaStatsMap
+	// randFn returns a pseudo-random number in [0.0,1.0).
+	randFn func() float64
+}
+
+// NewLatencyWeightedReplicaSelector returns a replica selector that reads
+// from replicas at random weighted by the inverse of the exponentially
+// weighted moving average of their read latencies, reads that return an
+// error are recorded with a latency penalty.
+func NewLatencyWeightedReplicaSelector() ReplicaSelector {
+	return &latencyWeightedReplicaSelector{
+		stats:  newReplicaStatsMap(),
+		randFn: rand.Float64,
+	}
+}
+
+func (s *latencyWeightedReplicaSelector) Order(replicas []Replica) {
+	if len(replicas) < 2 {
+		return
+	}
+	var (
+		weights = make([]float64, len(replicas))
+		total   float64
+	)
+	for i, r := range replicas {
+		latency := math.Max(s.stats.get(r.Host).latency(),
+			float64(latencyWeightedMinLatency))
+		weights[i] = 1 / latency
+		total += weights[i]
+	}
+
+	// Pick each position in turn from the remaining replicas in proportion
+	// to their weights.
+	for i := 0; i < len(replicas)-1; i++ {
+		var (
+			target = s.randFn() * total
+			picked = len(replicas) - 1
+		)
+		for j := i; j < len(replicas); j++ {
+			target -= weights[j]
+			if target < 0 {
+				picked = j
+				break
+			}
+		}
+		total -= weights[picked]
+		replicas[i], replicas[picked] = replicas[picked], replicas[i]
+		weights[i], weights[picked] = weights[picked], weights[i]
+	}
+}
+
+func (s *latencyWeightedReplicaSelector) RequestStarted(host topology.Host) {}
+
+func (s *latencyWeightedReplicaSelector) RequestCompleted(
+	host topology.Host,
+	latency time.Duration,
+	err error,
+) {
+	if err != nil && latency < latencyWeightedErrorPenalty {
+		latency = latencyWeightedErrorPenalty
+	}
+	s.stats.get(host).recordLatency(latency)
+}
@@ -0,0 +1,148 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func newTestReplicas(n int) []Replica {
+	replicas := make([]Replica, 0, n)
+	for i := 0; i < n; i++ {
+		id := string(rune('a' + i))
+		replicas = append(replicas, Replica{
+			Host:    topology.NewHost(id, id+":9000"),
+			hostIdx: i,
+		})
+	}
+	return replicas
+}
+
+func replicaHostIDs(replicas []Replica) []string {
+	ids := make([]string, 0, len(replicas))
+	for _, r := range replicas {
+		ids = append(ids, r.Host.ID())
+	}
+	return ids
+}
+
+func TestParseReplicaSelectionStrategy(t *testing.T) {
+	for _, s := range validReplicaSelectionStrategies {
+		parsed, err := ParseReplicaSelectionStrategy(string(s))
+		require.NoError(t, err)
+		require.Equal(t, s, parsed)
+	}
+
+	_, err := ParseReplicaSelectionStrategy("random")
+	require.Error(t, err)
+
+	var strategy ReplicaSelectionStrategy
+	require.NoError(t, yaml.Unmarshal([]byte("leastOutstanding"), &strategy))
+	require.Equal(t, LeastOutstandingReplicaSelectionStrategy, strategy)
+	require.Error(t, yaml.Unmarshal([]byte("random"), &strategy))
+}
+
+func TestNewReplicaSelector(t *testing.T) {
+	selector, err := NewReplicaSelector(AllReplicaSelectionStrategy)
+	require.NoError(t, err)
+	require.Nil(t, selector)
+
+	for _, s := range []ReplicaSelectionStrategy{
+		RoundRobinReplicaSelectionStrategy,
+		LeastOutstandingReplicaSelectionStrategy,
+		LatencyWeightedReplicaSelectionStrategy,
+	} {
+		selector, err := NewReplicaSelector(s)
+		require.NoError(t, err)
+		require.NotNil(t, selector)
+	}
+}
+
+func TestRoundRobinReplicaSelector(t *testing.T) {
+	selector := NewRoundRobinReplicaSelector()
+
+	var firsts []string
+	for i := 0; i < 4; i++ {
+		replicas := newTestReplicas(3)
+		selector.Order(replicas)
+		firsts = append(firsts, replicas[0].Host.ID())
+	}
+	require.Equal(t, []string{"b", "c", "a", "b"}, firsts)
+
+	replicas := newTestReplicas(3)
+	selector.Order(replicas)
+	require.Equal(t, []string{"c", "a", "b"}, replicaHostIDs(replicas))
+}
+
+func TestLeastOutstandingReplicaSelector(t *testing.T) {
+	selector := NewLeastOutstandingReplicaSelector()
+
+	replicas := newTestReplicas(3)
+	selector.RequestStarted(replicas[0].Host)
+	selector.RequestStarted(replicas[0].Host)
+	selector.RequestStarted(replicas[1].Host)
+
+	selector.Order(replicas)
+	require.Equal(t, []string{"c", "b", "a"}, replicaHostIDs(replicas))
+
+	host := replicas[0].Host
+	for i := 0; i < 4; i++ {
+		selector.RequestStarted(host)
+	}
+	selector.RequestCompleted(host, time.Millisecond, nil)
+
+	selector.Order(replicas)
+	require.Equal(t, []string{"b", "a", "c"}, replicaHostIDs(replicas))
+}
+
+func TestLatencyWeightedReplicaSelector(t *testing.T) {
+	selector := NewLatencyWeightedReplicaSelector().(*latencyWeightedReplicaSelector)
+
+	replicas := newTestReplicas(3)
+	selector.RequestCompleted(replicas[0].Host, 100*time.Millisecond, nil)
+	selector.RequestCompleted(replicas[1].Host, 10*time.Millisecond, nil)
+	selector.RequestCompleted(replicas[2].Host, time.Millisecond, errors.New("an error"))
+
+	// Always picking the start of the remaining weights picks the first
+	// remaining replica.
+	selector.randFn = func() float64 { return 0 }
+	selector.Order(replicas)
+	require.Equal(t, []string{"a", "b", "c"}, replicaHostIDs(replicas))
+
+	// Picking the middle of the weights picks the replica with the lowest
+	// latency as it has most of the weight.
+	selector.randFn = func() float64 { return 0.5 }
+	selector.Order(replicas)
+	require.Equal(t, "b", replicas[0].Host.ID())
+
+	// The latency moving average decays towards new samples.
+	stats := selector.stats.get(replicas[0].Host)
+	before := stats.latency()
+	selector.RequestCompleted(replicas[0].Host, 200*time.Millisecond, nil)
+	require.True(t, stats.latency() > before)
+}
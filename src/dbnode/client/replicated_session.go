@@ -22,8 +22,10 @@ package client
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/storage/block"
@@ -50,6 +52,8 @@ type replicatedSession struct {
 	scope                tally.Scope
 	log                  *zap.Logger
 	metrics              replicatedSessionMetrics
+	asyncMetrics         []*replicatedSessionAsyncMetrics
+	nowFn                clock.NowFn
 	outCh                chan error
 }
 
@@ -67,6 +71,37 @@ func newReplicatedSessionMetrics(scope tally.Scope) replicatedSessionMetrics {
 	}
 }
 
+// replicatedSessionAsyncMetrics are the metrics for replicating writes to a
+// single async cluster, the lag is the time from a write being received to
+// it being applied to the async cluster.
+type replicatedSessionAsyncMetrics struct {
+	numPending int64
+
+	pending tally.Gauge
+	lag     tally.Timer
+	errors  tally.Counter
+}
+
+func newReplicatedSessionAsyncMetrics(
+	scope tally.Scope,
+	cluster string,
+) *replicatedSessionAsyncMetrics {
+	scope = scope.Tagged(map[string]string{"cluster": cluster})
+	return &replicatedSessionAsyncMetrics{
+		pending: scope.Gauge("replicate.pending"),
+		lag:     scope.Timer("replicate.lag"),
+		errors:  scope.Counter("replicate.cluster-error"),
+	}
+}
+
+func (m *replicatedSessionAsyncMetrics) incPending() {
+	m.pending.Update(float64(atomic.AddInt64(&m.numPending, 1)))
+}
+
+func (m *replicatedSessionAsyncMetrics) decPending() {
+	m.pending.Update(float64(atomic.AddInt64(&m.numPending, -1)))
+}
+
 // Ensure replicatedSession implements the clientSession interface.
 var _ clientSession = (*replicatedSession)(nil)
 
@@ -90,6 +125,7 @@ func newReplicatedSession(opts Options, asyncOpts []Options, options ...replicat
 		scope:                scope,
 		log:                  opts.InstrumentOptions().Logger(),
 		metrics:              newReplicatedSessionMetrics(scope),
+		nowFn:                opts.ClockOptions().NowFn(),
 	}
 
 	// Apply options
@@ -123,9 +159,13 @@ func (s *replicatedSession) setSession(opts Options) error {
 }
 
 func (s *replicatedSession) setAsyncSessions(opts []Options) error {
-	sessions := make([]clientSession, 0, len(opts))
+	var (
+		sessions = make([]clientSession, 0, len(opts))
+		metrics  = make([]*replicatedSessionAsyncMetrics, 0, len(opts))
+	)
 	for i, oo := range opts {
-		subscope := oo.InstrumentOptions().MetricsScope().SubScope(fmt.Sprintf("async-%d", i))
+		cluster := fmt.Sprintf("async-%d", i)
+		subscope := oo.InstrumentOptions().MetricsScope().SubScope(cluster)
 		oo = oo.SetInstrumentOptions(oo.InstrumentOptions().SetMetricsScope(subscope))
 
 		session, err := s.newSessionFn(oo)
@@ -133,8 +173,10 @@ func (s *replicatedSession) setAsyncSessions(opts []Options) error {
 			return err
 		}
 		sessions = append(sessions, session)
+		metrics = append(metrics, newReplicatedSessionAsyncMetrics(s.scope, cluster))
 	}
 	s.asyncSessions = sessions
+	s.asyncMetrics = metrics
 	return nil
 }
 
@@ -147,25 +189,47 @@ type replicatedParams struct {
 	annotation []byte
 	tags       ident.TagIterator
 	useTags    bool
+
+	// NB: Only the dispositions of the synchronous write are returned.
+	useDispositions bool
 }
 
 // NB(srobb): it would be a nicer to accept a lambda which is the fn to
 // be performed on all sessions, however this causes an extra allocation.
-func (s replicatedSession) replicate(params replicatedParams) error {
-	for _, asyncSession := range s.asyncSessions {
-		asyncSession := asyncSession // capture var
+func (s replicatedSession) replicate(params replicatedParams) (WriteDispositions, error) {
+	var receivedAt time.Time
+	if len(s.asyncSessions) > 0 {
+		receivedAt = s.nowFn()
+	}
+	for i, asyncSession := range s.asyncSessions {
+		var (
+			asyncSession = asyncSession // capture var
+			asyncMetrics = s.asyncMetrics[i]
+			tags         ident.TagIterator
+		)
 		select {
 		case s.replicationSemaphore <- struct{}{}:
+			if params.useTags {
+				// Each write consumes the tags so every async write needs its
+				// own copy independent of the synchronous write.
+				tags = params.tags.Duplicate()
+			}
+			asyncMetrics.incPending()
 			s.workerPool.Go(func() {
 				var err error
 				if params.useTags {
-					err = asyncSession.WriteTagged(params.namespace, params.id, params.tags, params.t, params.value, params.unit, params.annotation)
+					err = asyncSession.WriteTagged(params.namespace, params.id, tags, params.t, params.value, params.unit, params.annotation)
+					tags.Close()
 				} else {
 					err = asyncSession.Write(params.namespace, params.id, params.t, params.value, params.unit, params.annotation)
 				}
+				asyncMetrics.decPending()
 				if err != nil {
 					s.metrics.replicateError.Inc(1)
+					asyncMetrics.errors.Inc(1)
 					s.log.Error("could not replicate write", zap.Error(err))
+				} else {
+					asyncMetrics.lag.Record(s.nowFn().Sub(receivedAt))
 				}
 				if s.outCh != nil {
 					s.outCh <- err
@@ -178,15 +242,20 @@ func (s replicatedSession) replicate(params replicatedParams) error {
 		}
 	}
 
-	if params.useTags {
-		return s.session.WriteTagged(params.namespace, params.id, params.tags, params.t, params.value, params.unit, params.annotation)
+	switch {
+	case params.useTags && params.useDispositions:
+		return s.session.WriteTaggedWithDispositions(params.namespace, params.id, params.tags, params.t, params.value, params.unit, params.annotation)
+	case params.useTags:
+		return nil, s.session.WriteTagged(params.namespace, params.id, params.tags, params.t, params.value, params.unit, params.annotation)
+	case params.useDispositions:
+		return s.session.WriteWithDispositions(params.namespace, params.id, params.t, params.value, params.unit, params.annotation)
 	}
-	return s.session.Write(params.namespace, params.id, params.t, params.value, params.unit, params.annotation)
+	return nil, s.session.Write(params.namespace, params.id, params.t, params.value, params.unit, params.annotation)
 }
 
 // Write value to the database for an ID.
 func (s replicatedSession) Write(namespace, id ident.ID, t time.Time, value float64, unit xtime.Unit, annotation []byte) error {
-	return s.replicate(replicatedParams{
+	_, err := s.replicate(replicatedParams{
 		namespace:  namespace,
 		id:         id,
 		t:          t,
@@ -194,11 +263,12 @@ func (s replicatedSession) Write(namespace, id ident.ID, t time.Time, value floa
 		unit:       unit,
 		annotation: annotation,
 	})
+	return err
 }
 
 // WriteTagged value to the database for an ID and given tags.
 func (s replicatedSession) WriteTagged(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) error {
-	return s.replicate(replicatedParams{
+	_, err := s.replicate(replicatedParams{
 		namespace:  namespace,
 		id:         id,
 		t:          t,
@@ -208,6 +278,38 @@ func (s replicatedSession) WriteTagged(namespace, id ident.ID, tags ident.TagIte
 		tags:       tags,
 		useTags:    true,
 	})
+	return err
+}
+
+// WriteWithDispositions writes a value to the database for an ID and
+// returns how the write was applied by each host that reported it.
+func (s replicatedSession) WriteWithDispositions(namespace, id ident.ID, t time.Time, value float64, unit xtime.Unit, annotation []byte) (WriteDispositions, error) {
+	return s.replicate(replicatedParams{
+		namespace:       namespace,
+		id:              id,
+		t:               t,
+		value:           value,
+		unit:            unit,
+		annotation:      annotation,
+		useDispositions: true,
+	})
+}
+
+// WriteTaggedWithDispositions writes a value to the database for an ID and
+// given tags and returns how the write was applied by each host that
+// reported it.
+func (s replicatedSession) WriteTaggedWithDispositions(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) (WriteDispositions, error) {
+	return s.replicate(replicatedParams{
+		namespace:       namespace,
+		id:              id,
+		t:               t,
+		value:           value,
+		unit:            unit,
+		annotation:      annotation,
+		tags:            tags,
+		useTags:         true,
+		useDispositions: true,
+	})
 }
 
 // Fetch values from the database for an ID.
@@ -244,6 +346,12 @@ func (s replicatedSession) ShardID(id ident.ID) (uint32, error) {
 	return s.session.ShardID(id)
 }
 
+// NamespaceShardID returns the shard for an ID of a namespace, routing the
+// ID with the shard key strategy of the namespace.
+func (s replicatedSession) NamespaceShardID(namespace, id ident.ID) (uint32, error) {
+	return s.session.NamespaceShardID(namespace, id)
+}
+
 // IteratorPools exposes the internal iterator pools used by the session to clients.
 func (s replicatedSession) IteratorPools() (encoding.IteratorPools, error) {
 	return s.session.IteratorPools()
@@ -283,6 +391,23 @@ func (s replicatedSession) Truncate(namespace ident.ID) (int64, error) {
 	return s.session.Truncate(namespace)
 }
 
+// FetchFromHost will fetch the values for a series from a single host
+// only, bypassing consistency checks and merging across replicas.
+func (s replicatedSession) FetchFromHost(
+	namespace ident.ID,
+	id ident.ID,
+	startInclusive, endExclusive time.Time,
+	hostID string,
+) (encoding.SeriesIterator, error) {
+	return s.session.FetchFromHost(namespace, id, startInclusive, endExclusive, hostID)
+}
+
+// ShardWatermarks returns the flush and repair watermarks of each shard
+// of a namespace.
+func (s replicatedSession) ShardWatermarks(namespace ident.ID) (map[uint32]ShardWatermark, error) {
+	return s.session.ShardWatermarks(namespace)
+}
+
 // FetchBootstrapBlocksFromPeers will fetch the most fulfilled block
 // for each series using the runtime configurable bootstrap level consistency.
 func (s replicatedSession) FetchBootstrapBlocksFromPeers(
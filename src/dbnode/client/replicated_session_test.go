@@ -22,6 +22,7 @@ package client
 
 import (
 	"errors"
+	"fmt"
 	"testing"
 	"time"
 
@@ -33,6 +34,7 @@ import (
 	xsync "github.com/m3db/m3/src/x/sync"
 	xtime "github.com/m3db/m3/src/x/time"
 	"github.com/stretchr/testify/suite"
+	"github.com/uber-go/tally"
 )
 
 type replicatedSessionTestSuite struct {
@@ -199,6 +201,71 @@ func (s *replicatedSessionTestSuite) TestReplicate() {
 	}
 }
 
+func (s *replicatedSessionTestSuite) TestReplicateTaggedRecordsLag() {
+	asyncCount := 2
+	namespace := ident.StringID("foo")
+	id := ident.StringID("bar")
+	tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("baz", "qux")))
+	now := time.Now()
+	value := float64(123)
+	unit := xtime.Nanosecond
+	annotation := []byte{}
+
+	var newSessionFunc = func(opts Options) (clientSession, error) {
+		s := NewMockclientSession(s.mockCtrl)
+		s.EXPECT().
+			WriteTagged(namespace, id, gomock.Any(), now, value, unit, annotation).
+			Return(nil)
+		return s, nil
+	}
+
+	scope := tally.NewTestScope("", nil)
+	opts := optionsWithAsyncSessions(true, asyncCount)
+	opts = opts.SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(scope))
+	s.initReplicatedSession(opts, newSessionFunc)
+	s.replicatedSession.outCh = make(chan error)
+
+	err := s.replicatedSession.WriteTagged(namespace, id, tags, now, value, unit, annotation)
+	s.NoError(err)
+
+	t := time.NewTimer(1 * time.Second) // Allow async expectations to occur before ending test
+	for i := 0; i < asyncCount; i++ {
+		select {
+		case err := <-s.replicatedSession.outCh:
+			s.NoError(err)
+		case <-t.C:
+			s.FailNow("timed out waiting for async writes")
+		}
+	}
+
+	snapshot := scope.Snapshot()
+	for i := 0; i < asyncCount; i++ {
+		key := fmt.Sprintf("replicate.lag+cluster=async-%d", i)
+		timer, ok := snapshot.Timers()[key]
+		s.True(ok, key)
+		s.Len(timer.Values(), 1)
+
+		key = fmt.Sprintf("replicate.pending+cluster=async-%d", i)
+		gauge, ok := snapshot.Gauges()[key]
+		s.True(ok, key)
+		s.Equal(float64(0), gauge.Value())
+	}
+}
+
+func (s *replicatedSessionTestSuite) TestAsyncClusterWriteConsistencyOverride() {
+	opts := optionsWithAsyncSessions(true, 2)
+	level := topology.ConsistencyLevelOne
+	overrides := []environment.ClientOverrides{
+		{WriteConsistencyLevel: &level},
+		{},
+	}
+
+	asyncOpts := NewOptionsForAsyncClusters(opts, opts.AsyncTopologyInitializers(), overrides)
+	s.Len(asyncOpts, 2)
+	s.Equal(topology.ConsistencyLevelOne, asyncOpts[0].WriteConsistencyLevel())
+	s.Equal(opts.WriteConsistencyLevel(), asyncOpts[1].WriteConsistencyLevel())
+}
+
 func (s *replicatedSessionTestSuite) TestOpenReplicatedSession() {
 	var newSessionFunc = func(opts Options) (clientSession, error) {
 		s := NewMockclientSession(s.mockCtrl)
@@ -39,6 +39,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
@@ -49,8 +50,10 @@ import (
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/x/checked"
 	xclose "github.com/m3db/m3/src/x/close"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -147,6 +150,8 @@ type session struct {
 	streamBlocksRetrier              xretry.Retrier
 	pools                            sessionPools
 	fetchBatchSize                   int
+	fetchMergeReplicaBlocks          bool
+	replicaSelector                  ReplicaSelector
 	newPeerBlocksQueueFn             newPeerBlocksQueueFn
 	reattemptStreamBlocksFromPeersFn reattemptStreamBlocksFromPeersFn
 	pickBestPeerFn                   pickBestPeerFn
@@ -156,7 +161,14 @@ type session struct {
 	streamBlocksBatchSize            int
 	streamBlocksMetadataBatchTimeout time.Duration
 	streamBlocksBatchTimeout         time.Duration
+	streamBlocksCompression          []string
+	faultInjector                    fault.Injector
+	bootstrapPeerPreferences         BootstrapPeerPreferences
+	readRepairer                     *readRepairer
+	writeSpill                       *writeSpillQueue
+	shardKeyFns                      map[string]sharding.ShardKeyFn
 	metrics                          sessionMetrics
+	topologyVersion                  int64
 }
 
 type shardMetricsKey struct {
@@ -211,6 +223,7 @@ type streamFromPeersMetrics struct {
 	fetchBlockRetriesReqError                         tally.Counter
 	fetchBlockRetriesRespError                        tally.Counter
 	fetchBlockRetriesConsistencyLevelNotAchievedError tally.Counter
+	fetchBlocksPreferredPeersFallback                 tally.Counter
 	blocksEnqueueChannel                              tally.Gauge
 }
 
@@ -225,6 +238,7 @@ type hostQueueOpts struct {
 	writeTaggedBatchRawV2RequestElementArrayPool writeTaggedBatchRawV2RequestElementArrayPool
 	fetchBatchRawV2RequestPool                   fetchBatchRawV2RequestPool
 	fetchBatchRawV2RequestElementArrayPool       fetchBatchRawV2RequestElementArrayPool
+	fencingTokenFn                               func() int64
 	opts                                         Options
 }
 
@@ -248,15 +262,18 @@ func newSession(opts Options) (clientSession, error) {
 			queuesByHostID: make(map[string]hostQueue),
 			topo:           topo,
 		},
-		opts:                 opts,
-		scope:                scope,
-		nowFn:                opts.ClockOptions().NowFn(),
-		log:                  opts.InstrumentOptions().Logger(),
-		newHostQueueFn:       newHostQueue,
-		fetchBatchSize:       opts.FetchBatchSize(),
-		newPeerBlocksQueueFn: newPeerBlocksQueue,
-		writeRetrier:         opts.WriteRetrier(),
-		fetchRetrier:         opts.FetchRetrier(),
+		opts:                    opts,
+		scope:                   scope,
+		nowFn:                   opts.ClockOptions().NowFn(),
+		log:                     opts.InstrumentOptions().Logger(),
+		newHostQueueFn:          newHostQueue,
+		fetchBatchSize:          opts.FetchBatchSize(),
+		fetchMergeReplicaBlocks: opts.FetchMergeReplicaBlocks(),
+		replicaSelector:         opts.ReplicaSelector(),
+		newPeerBlocksQueueFn:    newPeerBlocksQueue,
+		writeRetrier:            opts.WriteRetrier(),
+		fetchRetrier:            opts.FetchRetrier(),
+		faultInjector:           fault.NewNopInjector(),
 		pools: sessionPools{
 			context: opts.ContextPool(),
 			id:      opts.IdentifierPool(),
@@ -265,6 +282,25 @@ func newSession(opts Options) (clientSession, error) {
 	}
 	s.reattemptStreamBlocksFromPeersFn = s.streamBlocksReattemptFromPeers
 	s.pickBestPeerFn = s.streamBlocksPickBestPeer
+	if opts.ReadRepairEnabled() {
+		s.readRepairer = newReadRepairer(opts.ReadRepairQueueSize(),
+			s.readRepair, scope.SubScope("read-repair"), s.log)
+	}
+	if opts.WriteSpillEnabled() {
+		s.writeSpill = newWriteSpillQueue(opts.WriteSpillPath(),
+			opts.WriteSpillMaxBytes(), opts.WriteSpillReplayInterval(),
+			s.replaySpilledWrite, scope.SubScope("write-spill"), s.log)
+	}
+	for ns, strategy := range opts.NamespaceShardKeyStrategies() {
+		fn, err := sharding.LookupShardKeyStrategy(strategy)
+		if err != nil {
+			return nil, err
+		}
+		if s.shardKeyFns == nil {
+			s.shardKeyFns = make(map[string]sharding.ShardKeyFn)
+		}
+		s.shardKeyFns[ns] = fn
+	}
 	writeAttemptPoolOpts := pool.NewObjectPoolOptions().
 		SetSize(opts.WriteOpPoolSize()).
 		SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(
@@ -330,6 +366,12 @@ func newSession(opts Options) (clientSession, error) {
 		s.streamBlocksMetadataBatchTimeout = opts.FetchSeriesBlocksMetadataBatchTimeout()
 		s.streamBlocksBatchTimeout = opts.FetchSeriesBlocksBatchTimeout()
 		s.streamBlocksRetrier = opts.StreamBlocksRetrier()
+		for _, compressionType := range opts.FetchSeriesBlocksCompression() {
+			s.streamBlocksCompression = append(s.streamBlocksCompression,
+				string(compressionType))
+		}
+		s.faultInjector = opts.FaultInjector()
+		s.bootstrapPeerPreferences = opts.BootstrapPeerPreferences()
 	}
 
 	if runtimeOptsMgr := opts.RuntimeOptionsManager(); runtimeOptsMgr != nil {
@@ -358,6 +400,10 @@ func (s *session) ShardID(id ident.ID) (uint32, error) {
 	return value, nil
 }
 
+func (s *session) NamespaceShardID(namespace, id ident.ID) (uint32, error) {
+	return s.ShardID(s.shardKey(namespace, id))
+}
+
 // newPeerMetadataStreamingProgressMetrics returns a struct with an embedded
 // list of fields that can be used to emit metrics about the current state of
 // the peer metadata streaming process
@@ -408,7 +454,8 @@ func (s *session) newPeerMetadataStreamingProgressMetrics(
 		fetchBlockRetriesConsistencyLevelNotAchievedError: scope.Tagged(map[string]string{
 			"reason": "consistency-level-not-achieved-error",
 		}).Counter("fetch-block-retries"),
-		blocksEnqueueChannel: scope.Gauge("fetch-blocks-enqueue-channel-length"),
+		fetchBlocksPreferredPeersFallback: scope.Counter("fetch-blocks-preferred-peers-fallback"),
+		blocksEnqueueChannel:              scope.Gauge("fetch-blocks-enqueue-channel-length"),
 	}
 	s.metrics.streamFromPeersMetrics[mKey] = m
 	s.metrics.Unlock()
@@ -559,6 +606,17 @@ func (s *session) Open() error {
 	s.state.status = statusOpen
 	s.state.Unlock()
 
+	if s.readRepairer != nil {
+		if err := s.readRepairer.Open(); err != nil {
+			return err
+		}
+	}
+	if s.writeSpill != nil {
+		if err := s.writeSpill.Open(); err != nil {
+			return err
+		}
+	}
+
 	go func() {
 		for range watch.C() {
 			s.log.Info("received update for topology")
@@ -626,7 +684,10 @@ func (s *session) hostQueues(
 	queues := make([]hostQueue, 0, len(hosts))
 	newQueues := make([]hostQueue, 0, len(hosts))
 	for _, host := range hosts {
-		if existingQueue, ok := existingByHostID[host.ID()]; ok {
+		existingQueue, ok := existingByHostID[host.ID()]
+		// NB: A host whose address changed, such as when re-resolved by
+		// a DNS topology, needs a new queue to connect to the new address.
+		if ok && existingQueue.Host().Address() == host.Address() {
 			queues = append(queues, existingQueue)
 			continue
 		}
@@ -736,6 +797,7 @@ func (s *session) setTopologyWithLock(topoMap topology.Map, queues []hostQueue,
 	s.state.queuesByHostID = newQueuesByHostID
 
 	s.state.topoMap = topoMap
+	atomic.StoreInt64(&s.topologyVersion, int64(topoMap.Version()))
 
 	s.state.replicas = replicas
 	s.state.majority = majority
@@ -922,6 +984,7 @@ func (s *session) newHostQueue(host topology.Host, topoMap topology.Map) (hostQu
 		writeTaggedBatchRawV2RequestElementArrayPool: writeTaggedBatchRawV2RequestElementArrayPool,
 		fetchBatchRawV2RequestPool:                   fetchBatchRawV2RequestPool,
 		fetchBatchRawV2RequestElementArrayPool:       fetchBatchRawV2RequestElementArrayPool,
+		fencingTokenFn:                               s.fencingToken,
 		opts:                                         s.opts,
 	})
 	if err != nil {
@@ -931,6 +994,13 @@ func (s *session) newHostQueue(host topology.Host, topoMap topology.Map) (hostQu
 	return hostQueue, nil
 }
 
+// fencingToken returns the version of the topology the session is routing
+// writes with, sent with write batches so that nodes can reject writes
+// routed with a topology older than their own.
+func (s *session) fencingToken() int64 {
+	return atomic.LoadInt64(&s.topologyVersion)
+}
+
 func (s *session) Write(
 	nsID, id ident.ID,
 	t time.Time,
@@ -938,15 +1008,8 @@ func (s *session) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
-	w := s.pools.writeAttempt.Get()
-	w.args.attemptType = untaggedWriteAttemptType
-	w.args.namespace, w.args.id = nsID, id
-	w.args.tags = ident.EmptyTagIterator
-	w.args.t, w.args.value, w.args.unit, w.args.annotation =
-		t, value, unit, annotation
-	err := s.writeRetrier.Attempt(w.attemptFn)
-	s.pools.writeAttempt.Put(w)
-	return err
+	return s.write(untaggedWriteAttemptType, nsID, id, ident.EmptyTagIterator,
+		t, value, unit, annotation, nil)
 }
 
 func (s *session) WriteTagged(
@@ -956,13 +1019,60 @@ func (s *session) WriteTagged(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+) error {
+	return s.write(taggedWriteAttemptType, nsID, id, tags,
+		t, value, unit, annotation, nil)
+}
+
+func (s *session) WriteWithDispositions(
+	nsID, id ident.ID,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) (WriteDispositions, error) {
+	dispositions := make(WriteDispositions)
+	err := s.write(untaggedWriteAttemptType, nsID, id, ident.EmptyTagIterator,
+		t, value, unit, annotation, dispositions)
+	return dispositions, err
+}
+
+func (s *session) WriteTaggedWithDispositions(
+	nsID, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) (WriteDispositions, error) {
+	dispositions := make(WriteDispositions)
+	err := s.write(taggedWriteAttemptType, nsID, id, tags,
+		t, value, unit, annotation, dispositions)
+	return dispositions, err
+}
+
+// write attempts a write with retries, dispositions is only non-nil for
+// writes that report how they were applied by each host.
+func (s *session) write(
+	wType writeAttemptType,
+	nsID, id ident.ID,
+	tags ident.TagIterator,
+	t time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+	dispositions WriteDispositions,
 ) error {
 	w := s.pools.writeAttempt.Get()
-	w.args.attemptType = taggedWriteAttemptType
+	w.args.attemptType = wType
 	w.args.namespace, w.args.id, w.args.tags = nsID, id, tags
 	w.args.t, w.args.value, w.args.unit, w.args.annotation =
 		t, value, unit, annotation
+	w.args.dispositions = dispositions
 	err := s.writeRetrier.Attempt(w.attemptFn)
+	if err != nil && s.writeSpill != nil && isWriteSpillableError(err) {
+		err = s.spillWrite(w.args, err)
+	}
 	s.pools.writeAttempt.Put(w)
 	return err
 }
@@ -975,6 +1085,7 @@ func (s *session) writeAttempt(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+	dispositions WriteDispositions,
 ) error {
 	startWriteAttempt := s.nowFn()
 
@@ -995,7 +1106,8 @@ func (s *session) writeAttempt(
 	}
 
 	state, majority, enqueued, err := s.writeAttemptWithRLock(
-		wType, nsID, id, inputTags, timestamp, value, timeType, annotation)
+		wType, nsID, id, inputTags, timestamp, value, timeType, annotation,
+		dispositions != nil)
 	s.state.RUnlock()
 
 	if err != nil {
@@ -1008,6 +1120,9 @@ func (s *session) writeAttempt(
 
 	err = s.writeConsistencyResult(state.consistencyLevel, majority, enqueued,
 		enqueued-state.pending, int32(len(state.errors)), state.errors)
+	for hostID, disposition := range state.dispositions {
+		dispositions[hostID] = disposition
+	}
 
 	s.recordWriteMetrics(err, int32(len(state.errors)), startWriteAttempt)
 
@@ -1030,6 +1145,7 @@ func (s *session) writeAttemptWithRLock(
 	value float64,
 	timeType rpc.TimeType,
 	annotation []byte,
+	includeDispositions bool,
 ) (*writeState, int32, int32, error) {
 	var (
 		majority = int32(s.state.majority)
@@ -1052,12 +1168,14 @@ func (s *session) writeAttemptWithRLock(
 		}
 	}
 
+	routeID := s.shardKey(nsID, tsID)
+
 	var op writeOp
 	switch wType {
 	case untaggedWriteAttemptType:
 		wop := s.pools.writeOperation.Get()
 		wop.namespace = nsID
-		wop.shardID = s.state.topoMap.ShardSet().Lookup(tsID)
+		wop.shardID = s.state.topoMap.ShardSet().Lookup(routeID)
 		wop.request.ID = tsID.Bytes()
 		wop.request.Datapoint.Value = value
 		wop.request.Datapoint.Timestamp = timestamp
@@ -1065,11 +1183,12 @@ func (s *session) writeAttemptWithRLock(
 		wop.request.Datapoint.Annotation = annotation
 		wop.requestV2.ID = wop.request.ID
 		wop.requestV2.Datapoint = wop.request.Datapoint
+		wop.includeDispositions = includeDispositions
 		op = wop
 	case taggedWriteAttemptType:
 		wop := s.pools.writeTaggedOperation.Get()
 		wop.namespace = nsID
-		wop.shardID = s.state.topoMap.ShardSet().Lookup(tsID)
+		wop.shardID = s.state.topoMap.ShardSet().Lookup(routeID)
 		wop.request.ID = tsID.Bytes()
 		encodedTagBytes, ok := tagEncoder.Data()
 		if !ok {
@@ -1083,6 +1202,7 @@ func (s *session) writeAttemptWithRLock(
 		wop.requestV2.ID = wop.request.ID
 		wop.requestV2.EncodedTags = wop.request.EncodedTags
 		wop.requestV2.Datapoint = wop.request.Datapoint
+		wop.includeDispositions = includeDispositions
 		op = wop
 	default:
 		// should never happen
@@ -1092,6 +1212,9 @@ func (s *session) writeAttemptWithRLock(
 	state := s.pools.writeState.Get()
 	state.consistencyLevel = s.state.writeLevel
 	state.topoMap = s.state.topoMap
+	if includeDispositions {
+		state.dispositions = make(WriteDispositions)
+	}
 	state.incRef()
 
 	// todo@bl: Can we combine the writeOpPool and the writeStatePool?
@@ -1099,7 +1222,7 @@ func (s *session) writeAttemptWithRLock(
 	state.nsID, state.tsID, state.tagEncoder = nsID, tsID, tagEncoder
 	op.SetCompletionFn(state.completionFn)
 
-	if err := s.state.topoMap.RouteForEach(tsID, func(idx int, host topology.Host) {
+	if err := s.state.topoMap.RouteForEach(routeID, func(idx int, host topology.Host) {
 		// Count pending write requests before we enqueue the completion fns,
 		// which rely on the count when executing
 		state.pending++
@@ -1448,6 +1571,7 @@ func (s *session) fetchIDsAttempt(
 	inputNamespace ident.ID,
 	inputIDs ident.Iterator,
 	startInclusive, endExclusive time.Time,
+	readRepair bool,
 ) (encoding.SeriesIterators, error) {
 	nsCtx, err := s.nsCtxFor(inputNamespace)
 	if err != nil {
@@ -1468,6 +1592,13 @@ func (s *session) fetchIDsAttempt(
 		fetchBatchOpsByHostIdx [][]*fetchBatchOp
 		success                = false
 		startFetchAttempt      = s.nowFn()
+		detectDivergence       = readRepair && s.readRepairer != nil
+		mergeReplicas          bool
+		selectReplicas         bool
+		numSelectedReplicas    int
+		replicas               []Replica
+		repairHintsLock        sync.Mutex
+		repairHints            []FetchRepairHint
 	)
 
 	// NB(prateek): need to make a copy of inputNamespace and inputIDs to control
@@ -1517,6 +1648,24 @@ func (s *session) fetchIDsAttempt(
 	majority = int32(s.state.majority)
 	numReplicas = int32(s.state.replicas)
 
+	// NB: Only merge replica blocks for the fetches issued by callers, the
+	// fetches issued by read repair only need the first sufficient responses.
+	mergeReplicas = readRepair && s.fetchMergeReplicaBlocks &&
+		topology.NumDesiredForReadConsistency(consistencyLevel,
+			int(numReplicas), int(majority)) > 1
+
+	// NB: Detecting divergence and merging replica blocks compare the blocks
+	// of every replica so fetches are always sent to every replica for them.
+	selectReplicas = s.replicaSelector != nil && !detectDivergence && !mergeReplicas
+	if selectReplicas {
+		numSelectedReplicas = topology.NumDesiredForReadConsistency(consistencyLevel,
+			int(numReplicas), int(majority))
+		if numSelectedReplicas < 1 {
+			numSelectedReplicas = 1
+		}
+		replicas = make([]Replica, 0, numReplicas)
+	}
+
 	// NB(prateek): namespaceAccessors tracks the number of pending accessors for nsID.
 	// It is set to incremented by `replica` for each requested ID during fetch enqueuing,
 	// and once by initial request, and is decremented for each replica retrieved, inside
@@ -1539,6 +1688,9 @@ func (s *session) fetchIDsAttempt(
 			idAccessors      int32 = 1
 			resultsLock      sync.RWMutex
 			results          []encoding.MultiReaderIterator
+			replicaBlocks    [][]readRepairBlock
+			replicaSegments  [][]*rpc.Segments
+			replicaHostIDs   []string
 			enqueued         int32
 			pending          int32
 			success          int32
@@ -1571,15 +1723,41 @@ func (s *session) fetchIDsAttempt(
 				resultErrs++
 				resultErrLock.Unlock()
 			} else {
-				resultsLock.RLock()
-				numItersToInclude := int(success)
-				numDesired := topology.NumDesiredForReadConsistency(consistencyLevel, int(numReplicas), int(majority))
-				if numDesired < numItersToInclude {
-					// Avoid decoding more data than is required to satisfy the consistency guarantees.
-					numItersToInclude = numDesired
+				var itersToInclude []encoding.MultiReaderIterator
+				if mergeReplicas {
+					// Merge the blocks of every replica that has responded into
+					// a single replica so that each block is read from the most
+					// complete replica.
+					resultsLock.Lock()
+					merged, deficiencies := mergeReplicaBlocks(replicaSegments)
+					hostIDs := replicaHostIDs
+					if success > 0 {
+						slicesIter := s.pools.readerSliceOfSlicesIterator.Get()
+						slicesIter.Reset(merged)
+						multiIter := s.pools.multiReaderIterator.Get()
+						multiIter.ResetSliceOfSlices(slicesIter, nsCtx.Schema)
+						results[0] = multiIter
+						itersToInclude = results[:1]
+					}
+					resultsLock.Unlock()
+
+					if len(deficiencies) > 0 {
+						hints := newFetchRepairHints(tsID, hostIDs, deficiencies)
+						repairHintsLock.Lock()
+						repairHints = append(repairHints, hints...)
+						repairHintsLock.Unlock()
+					}
+				} else {
+					resultsLock.RLock()
+					numItersToInclude := int(success)
+					numDesired := topology.NumDesiredForReadConsistency(consistencyLevel, int(numReplicas), int(majority))
+					if numDesired < numItersToInclude {
+						// Avoid decoding more data than is required to satisfy the consistency guarantees.
+						numItersToInclude = numDesired
+					}
+					itersToInclude = results[:numItersToInclude]
+					resultsLock.RUnlock()
 				}
-				itersToInclude := results[:numItersToInclude]
-				resultsLock.RUnlock()
 
 				iter := s.pools.seriesIterator.Get()
 				// NB(prateek): we need to allocate a copy of ident.ID to allow the seriesIterator
@@ -1608,7 +1786,7 @@ func (s *session) fetchIDsAttempt(
 			}
 			wg.Done()
 		}
-		completionFn := func(result interface{}, err error) {
+		replicaCompletionFn := func(hostID string, result interface{}, err error) {
 			var snapshotSuccess int32
 			if err != nil {
 				atomic.AddInt32(&errs, 1)
@@ -1621,15 +1799,33 @@ func (s *session) fetchIDsAttempt(
 				errors = append(errors, err)
 				resultErrLock.Unlock()
 			} else {
-				slicesIter := s.pools.readerSliceOfSlicesIterator.Get()
-				slicesIter.Reset(result.([]*rpc.Segments))
-				multiIter := s.pools.multiReaderIterator.Get()
-				multiIter.ResetSliceOfSlices(slicesIter, nsCtx.Schema)
+				segments := result.([]*rpc.Segments)
+				var blocks []readRepairBlock
+				if detectDivergence {
+					blocks = newReadRepairBlocks(segments)
+				}
+				var multiIter encoding.MultiReaderIterator
+				if !mergeReplicas {
+					// NB: When merging replicas the iterator is created from the
+					// merged segments once the read consistency is satisfied.
+					slicesIter := s.pools.readerSliceOfSlicesIterator.Get()
+					slicesIter.Reset(segments)
+					multiIter = s.pools.multiReaderIterator.Get()
+					multiIter.ResetSliceOfSlices(slicesIter, nsCtx.Schema)
+				}
 				// Results is pre-allocated after creating fetch ops for this ID below
 				resultsLock.Lock()
-				results[success] = multiIter
+				if mergeReplicas {
+					replicaSegments = append(replicaSegments, segments)
+					replicaHostIDs = append(replicaHostIDs, hostID)
+				} else {
+					results[success] = multiIter
+				}
 				success++
 				snapshotSuccess = success
+				if detectDivergence {
+					replicaBlocks = append(replicaBlocks, blocks)
+				}
 				resultsLock.Unlock()
 			}
 			// NB(xichen): decrementing pending and checking remaining against zero must
@@ -1642,6 +1838,16 @@ func (s *session) fetchIDsAttempt(
 				allCompletionFn()
 			}
 
+			if detectDivergence && remaining == 0 {
+				// Only compare replicas once every replica has responded so
+				// that a replica that has yet to respond is not considered
+				// to be missing blocks.
+				resultsLock.RLock()
+				observed := replicaBlocks
+				resultsLock.RUnlock()
+				s.readRepairer.Observe(namespace, tsID, observed)
+			}
+
 			if atomic.AddInt32(&resultsAccessors, -1) == 0 {
 				s.pools.multiReaderIteratorArray.Put(results)
 			}
@@ -1652,8 +1858,12 @@ func (s *session) fetchIDsAttempt(
 				namespace.Finalize()
 			}
 		}
+		completionFn := func(result interface{}, err error) {
+			replicaCompletionFn("", result, err)
+		}
 
-		if err := s.state.topoMap.RouteForEach(tsID, func(hostIdx int, host topology.Host) {
+		routeID := s.shardKey(namespace, tsID)
+		enqueueFn := func(hostIdx int, host topology.Host) {
 			// Inc safely as this for each is sequential
 			enqueued++
 			pending++
@@ -1683,9 +1893,31 @@ func (s *session) fetchIDsAttempt(
 			}
 
 			// Append IDWithNamespace to this request
-			f.append(namespace.Bytes(), tsID.Bytes(), completionFn)
-		}); err != nil {
-			routeErr = err
+			if mergeReplicas {
+				// Repair hints reference the replicas by host ID.
+				hostID := host.ID()
+				f.append(namespace.Bytes(), tsID.Bytes(), func(result interface{}, err error) {
+					replicaCompletionFn(hostID, result, err)
+				})
+			} else if selectReplicas {
+				s.replicaSelector.RequestStarted(host)
+				f.append(namespace.Bytes(), tsID.Bytes(), func(result interface{}, err error) {
+					s.replicaSelector.RequestCompleted(host,
+						s.nowFn().Sub(startFetchAttempt), err)
+					completionFn(result, err)
+				})
+			} else {
+				f.append(namespace.Bytes(), tsID.Bytes(), completionFn)
+			}
+		}
+
+		if selectReplicas {
+			replicas, routeErr = s.selectReplicasWithRLock(routeID, replicas[:0],
+				numSelectedReplicas, enqueueFn)
+		} else {
+			routeErr = s.state.topoMap.RouteForEach(routeID, enqueueFn)
+		}
+		if routeErr != nil {
 			break
 		}
 
@@ -1730,6 +1962,12 @@ func (s *session) fetchIDsAttempt(
 		return nil, retErr
 	}
 	success = true
+	if len(repairHints) > 0 {
+		return &seriesIteratorsWithRepairHints{
+			MutableSeriesIterators: iters,
+			hints:                  repairHints,
+		}, nil
+	}
 	return iters, nil
 }
 
@@ -1746,6 +1984,29 @@ func (s *session) writeConsistencyResult(
 	return nil
 }
 
+// selectReplicasWithRLock orders the replicas of the shard an ID routes to
+// with the replica selector and calls the function for the number of replicas
+// selected, returning the replicas slice so that it can be reused.
+func (s *session) selectReplicasWithRLock(
+	routeID ident.ID,
+	replicas []Replica,
+	numSelected int,
+	fn topology.RouteForEachFn,
+) ([]Replica, error) {
+	err := s.state.topoMap.RouteForEach(routeID, func(hostIdx int, host topology.Host) {
+		replicas = append(replicas, Replica{Host: host, hostIdx: hostIdx})
+	})
+	if err != nil {
+		return replicas, err
+	}
+
+	s.replicaSelector.Order(replicas)
+	for i := 0; i < len(replicas) && i < numSelected; i++ {
+		fn(replicas[i].hostIdx, replicas[i].Host)
+	}
+	return replicas, nil
+}
+
 func (s *session) readConsistencyResult(
 	level topology.ReadConsistencyLevel,
 	majority, enqueued, responded, resultErrs int32,
@@ -1787,6 +2048,14 @@ func (s *session) Close() error {
 	topoWatch.Close()
 	topo.Close()
 
+	if s.readRepairer != nil {
+		s.readRepairer.Close()
+	}
+
+	if s.writeSpill != nil {
+		s.writeSpill.Close()
+	}
+
 	if closer := s.runtimeOptsListenerCloser; closer != nil {
 		closer.Close()
 	}
@@ -1867,6 +2136,143 @@ func (s *session) Truncate(namespace ident.ID) (int64, error) {
 	return truncated, resultErr.FinalError()
 }
 
+func (s *session) FetchFromHost(
+	namespace ident.ID,
+	id ident.ID,
+	startInclusive, endExclusive time.Time,
+	hostID string,
+) (encoding.SeriesIterator, error) {
+	nsCtx, err := s.nsCtxFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rangeStart, tsErr := convert.ToValue(startInclusive, rpc.TimeType_UNIX_NANOSECONDS)
+	if tsErr != nil {
+		return nil, tsErr
+	}
+	rangeEnd, tsErr := convert.ToValue(endExclusive, rpc.TimeType_UNIX_NANOSECONDS)
+	if tsErr != nil {
+		return nil, tsErr
+	}
+
+	var (
+		result   *rpc.FetchBatchRawResult_
+		fetchErr error
+	)
+	req := rpc.NewFetchBatchRawRequest()
+	req.NameSpace = namespace.Bytes()
+	req.RangeStart = rangeStart
+	req.RangeEnd = rangeEnd
+	req.RangeTimeType = rpc.TimeType_UNIX_NANOSECONDS
+	req.Ids = [][]byte{id.Bytes()}
+
+	// NB: The request is issued directly against the borrowed connection
+	// rather than enqueued for every replica so that the result reflects
+	// exactly what the host returns, without any consistency merging.
+	if err := s.BorrowConnection(hostID, func(client rpc.TChanNode) {
+		tctx, _ := thrift.NewContext(s.opts.FetchRequestTimeout())
+		result, fetchErr = client.FetchBatchRaw(tctx, req)
+	}); err != nil {
+		return nil, err
+	}
+	if fetchErr != nil {
+		return nil, fetchErr
+	}
+	if len(result.Elements) != 1 {
+		return nil, errQueueFetchNoResponse(hostID)
+	}
+	if elemErr := result.Elements[0].Err; elemErr != nil {
+		return nil, elemErr
+	}
+
+	slicesIter := s.pools.readerSliceOfSlicesIterator.Get()
+	slicesIter.Reset(result.Elements[0].Segments)
+	multiIter := s.pools.multiReaderIterator.Get()
+	multiIter.ResetSliceOfSlices(slicesIter, nsCtx.Schema)
+
+	iter := s.pools.seriesIterator.Get()
+	iter.Reset(encoding.SeriesIteratorOptions{
+		ID:             s.pools.id.Clone(id),
+		Namespace:      s.pools.id.Clone(namespace),
+		StartInclusive: startInclusive,
+		EndExclusive:   endExclusive,
+		Replicas:       []encoding.MultiReaderIterator{multiIter},
+	})
+	return iter, nil
+}
+
+func (s *session) ShardWatermarks(namespace ident.ID) (map[uint32]ShardWatermark, error) {
+	topoMap, err := s.TopologyMap()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		req        = rpc.NewShardWatermarksRequest()
+		watermarks = make(map[uint32]ShardWatermark)
+		multiErr   xerrors.MultiError
+	)
+	req.NameSpace = namespace.Bytes()
+	for _, host := range topoMap.Hosts() {
+		var (
+			result *rpc.ShardWatermarksResult_
+			rpcErr error
+		)
+		if err := s.BorrowConnection(host.ID(), func(client rpc.TChanNode) {
+			tctx, _ := thrift.NewContext(s.opts.FetchRequestTimeout())
+			result, rpcErr = client.ShardWatermarks(tctx, req)
+		}); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if rpcErr != nil {
+			multiErr = multiErr.Add(rpcErr)
+			continue
+		}
+
+		// Replicas are merged by taking the earliest of each watermark so
+		// the result only moves forward once every replica has caught up.
+		for _, w := range result.Watermarks {
+			replica := ShardWatermark{
+				FlushedTo:  watermarkTime(w.FlushedTo),
+				RepairedTo: watermarkTime(w.RepairedTo),
+				DurableTo:  watermarkTime(w.DurableTo),
+			}
+			shard := uint32(w.Shard)
+			curr, ok := watermarks[shard]
+			if !ok {
+				watermarks[shard] = replica
+				continue
+			}
+			watermarks[shard] = ShardWatermark{
+				FlushedTo:  minTime(curr.FlushedTo, replica.FlushedTo),
+				RepairedTo: minTime(curr.RepairedTo, replica.RepairedTo),
+				DurableTo:  minTime(curr.DurableTo, replica.DurableTo),
+			}
+		}
+	}
+
+	if err := multiErr.FinalError(); err != nil {
+		return nil, err
+	}
+	return watermarks, nil
+}
+
+func watermarkTime(unixNanos int64) time.Time {
+	if unixNanos == 0 {
+		return time.Time{}
+	}
+	return time.Unix(0, unixNanos)
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
 // NB(r): Excluding maligned struct check here as we can
 // live with a few extra bytes since this struct is only
 // ever passed by stack, its much more readable not optimized
@@ -1877,6 +2283,29 @@ type peers struct {
 	majorityReplicas int
 	selfExcluded     bool
 	selfHostShardSet topology.HostShardSet
+	// preferredIsolationGroup is the isolation group of the peers that
+	// blocks are preferably streamed from, if any.
+	preferredIsolationGroup string
+}
+
+// withPreference returns the peers that are not excluded by the preference
+// and that prefer streaming blocks from the preferred isolation group.
+func (p peers) withPreference(pref BootstrapPeerPreference) peers {
+	result := p
+	result.peers = make([]peer, 0, len(p.peers))
+	for _, peer := range p.peers {
+		if pref.excludes(peer.Host()) {
+			continue
+		}
+		result.peers = append(result.peers, peer)
+	}
+	result.preferredIsolationGroup = pref.PreferredIsolationGroup
+	return result
+}
+
+func (p peers) isPreferred(peer peer) bool {
+	return p.preferredIsolationGroup != "" &&
+		p.preferredIsolationGroup == peer.Host().IsolationGroup()
 }
 
 func (p peers) selfExcludedAndSelfHasShardAvailable() bool {
@@ -1987,6 +2416,55 @@ func (s *session) FetchBootstrapBlocksFromPeers(
 	if err != nil {
 		return nil, err
 	}
+
+	// Determine which peers own the specified shard
+	peers, err := s.peersForShard(shard)
+	if err != nil {
+		return nil, err
+	}
+
+	pref, ok := s.bootstrapPeerPreferences.ForShard(shard)
+	if !ok {
+		return s.fetchBootstrapBlocksFromPeers(nsMetadata, nsCtx, shard,
+			peers, start, end, opts)
+	}
+
+	preferred := peers.withPreference(pref)
+	if len(preferred.peers) == 0 || len(preferred.peers) == len(peers.peers) {
+		// Either no peers are excluded or every peer is excluded, in both
+		// cases bootstrap from every peer that is not the origin.
+		preferred.peers = peers.peers
+		return s.fetchBootstrapBlocksFromPeers(nsMetadata, nsCtx, shard,
+			preferred, start, end, opts)
+	}
+
+	shardResult, err := s.fetchBootstrapBlocksFromPeers(nsMetadata, nsCtx, shard,
+		preferred, start, end, opts)
+	if err == nil {
+		return shardResult, nil
+	}
+
+	// Fall back to bootstrapping from every peer, including the excluded
+	// ones, so that the shard can still be bootstrapped.
+	s.newPeerMetadataStreamingProgressMetrics(shard, resultTypeBootstrap).
+		fetchBlocksPreferredPeersFallback.Inc(1)
+	s.log.Warn("failed to bootstrap shard from preferred peers, falling back to all peers",
+		zap.Uint32("shard", shard),
+		zap.Strings("excludedHosts", pref.ExcludedHosts),
+		zap.Error(err))
+	preferred.peers = peers.peers
+	return s.fetchBootstrapBlocksFromPeers(nsMetadata, nsCtx, shard,
+		preferred, start, end, opts)
+}
+
+func (s *session) fetchBootstrapBlocksFromPeers(
+	nsMetadata namespace.Metadata,
+	nsCtx namespace.Context,
+	shard uint32,
+	peers peers,
+	start, end time.Time,
+	opts result.Options,
+) (result.ShardResult, error) {
 	var (
 		result = newBulkBlocksResult(nsCtx, s.opts, opts,
 			s.pools.tagDecoder, s.pools.id)
@@ -1996,12 +2474,6 @@ func (s *session) FetchBootstrapBlocksFromPeers(
 		level = newSessionBootstrapRuntimeReadConsistencyLevel(s)
 	)
 
-	// Determine which peers own the specified shard
-	peers, err := s.peersForShard(shard)
-	if err != nil {
-		return nil, err
-	}
-
 	// Emit a gauge indicating whether we're done or not
 	go func() {
 		for {
@@ -2311,6 +2783,10 @@ func (s *session) streamBlocksMetadataFromPeer(
 		req.IncludeLastRead = &optionIncludeLastRead
 
 		progress.metadataFetchBatchCall.Inc(1)
+		if err := s.faultInjector.Inject(fault.PeerFetch); err != nil {
+			progress.metadataFetchBatchError.Inc(1)
+			return err
+		}
 		result, err := client.FetchBlocksMetadataRawV2(tctx, req)
 		if err != nil {
 			progress.metadataFetchBatchError.Inc(1)
@@ -2464,6 +2940,7 @@ func (s *session) streamBlocksFromPeers(
 				s.streamBlocksBatchFromPeer(nsMetadata, shard, peer, batch, opts,
 					result, enqueueCh, s.streamBlocksRetrier, progress)
 			})
+		queue.preferred = peers.isPreferred(peer)
 		peerQueues = append(peerQueues, queue)
 	}
 
@@ -2905,6 +3382,7 @@ func (s *session) streamBlocksBatchFromPeer(
 	)
 	req.NameSpace = namespaceMetadata.ID().Bytes()
 	req.Shard = int32(shard)
+	req.AcceptCompression = s.streamBlocksCompression
 	req.Elements = make([]*rpc.FetchBlocksRawRequestElement, 0, len(batch))
 	for i := range batch {
 		blockStart := batch[i].block.start
@@ -2926,6 +3404,9 @@ func (s *session) streamBlocksBatchFromPeer(
 	if err := retrier.Attempt(func() error {
 		var attemptErr error
 		borrowErr := peer.BorrowConnection(func(client rpc.TChanNode) {
+			if attemptErr = s.faultInjector.Inject(fault.PeerFetch); attemptErr != nil {
+				return
+			}
 			tctx, _ := thrift.NewContext(s.streamBlocksBatchTimeout)
 			result, attemptErr = client.FetchBlocksRaw(tctx, req)
 		})
@@ -2943,6 +3424,20 @@ func (s *session) streamBlocksBatchFromPeer(
 		return
 	}
 
+	// Peers that do not support compression leave the result uncompressed.
+	compressor, err := s.streamBlocksCompressor(result)
+	if err != nil {
+		blocksErr := fmt.Errorf(
+			"stream blocks bad compression: error=%s, peer=%s",
+			err.Error(), peer.Host().String(),
+		)
+		s.reattemptStreamBlocksFromPeersFn(batch, enqueueCh, blocksErr,
+			respErrReason, nextRetryReattemptType, m)
+		m.fetchBlockError.Inc(int64(reqBlocksLen))
+		s.log.Debug(blocksErr.Error())
+		return
+	}
+
 	// Parse and act on result
 	tooManyIDsLogged := false
 	for i := range result.Elements {
@@ -3014,8 +3509,14 @@ func (s *session) streamBlocksBatchFromPeer(
 				continue
 			}
 
-			// Verify and if verify succeeds add the block from the peer
-			err := s.verifyFetchedBlock(block)
+			// Decompress and verify, if verify succeeds add the block from the peer
+			var err error
+			if compressor != nil && block.Err == nil {
+				err = convert.DecompressSegments(block.Segments, compressor)
+			}
+			if err == nil {
+				err = s.verifyFetchedBlock(block)
+			}
 			if err == nil {
 				err = blocksResult.addBlockFromPeer(id, batch[i].encodedTags,
 					peer.Host(), block)
@@ -3043,6 +3544,22 @@ func (s *session) streamBlocksBatchFromPeer(
 	}
 }
 
+func (s *session) streamBlocksCompressor(
+	result *rpc.FetchBlocksRawResult_,
+) (compress.Compressor, error) {
+	if !result.IsSetCompression() {
+		return nil, nil
+	}
+	compressionType, err := compress.ParseType(result.GetCompression())
+	if err != nil {
+		return nil, err
+	}
+	if compressionType == compress.None {
+		return nil, nil
+	}
+	return compress.NewCompressor(compressionType)
+}
+
 func (s *session) verifyFetchedBlock(block *rpc.Block) error {
 	if block.Err != nil {
 		return fmt.Errorf("block error from peer: %s %s", block.Err.Type.String(), block.Err.Message)
@@ -3097,6 +3614,16 @@ func (s *session) nsCtxFor(ns ident.ID) (namespace.Context, error) {
 	return nsCtx, nil
 }
 
+// shardKey returns the ID used to route a series of a namespace to the hosts
+// owning its shard, as configured by the shard key strategy of the namespace.
+func (s *session) shardKey(ns, id ident.ID) ident.ID {
+	fn, ok := s.shardKeyFns[string(ns.Bytes())]
+	if !ok {
+		return id
+	}
+	return fn(id)
+}
+
 type reason int
 
 const (
@@ -3689,6 +4216,7 @@ type peerBlocksQueue struct {
 	sync.RWMutex
 	closed       bool
 	peer         peer
+	preferred    bool
 	queue        []receivedBlockMetadata
 	doneFns      []func()
 	assigned     uint64
@@ -3864,6 +4392,13 @@ func (arr receivedBlockMetadataQueuesByAttemptsAscOutstandingAsc) Less(i, j int)
 		return attemptsI < attemptsJ
 	}
 
+	// Prefer peers in the preferred isolation group, peers that have already
+	// been attempted more are ranked lower regardless so that fetches fall
+	// back to peers in other isolation groups.
+	if arr[i].queue.preferred != arr[j].queue.preferred {
+		return arr[i].queue.preferred
+	}
+
 	outstandingI :=
 		atomic.LoadUint64(&arr[i].queue.assigned) -
 			atomic.LoadUint64(&arr[i].queue.completed)
@@ -44,6 +44,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
@@ -874,6 +875,60 @@ func TestSelectPeersFromPerPeerBlockMetadatasAllPeersSucceed(t *testing.T) {
 	assert.Equal(t, []peer{peerA}, selected[0].block.reattempt.attempted)
 }
 
+func TestSelectPeersFromPerPeerBlockMetadatasPrefersPreferredPeers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestAdminOptions()
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	var (
+		metrics          = session.newPeerMetadataStreamingProgressMetrics(0, resultTypeRaw)
+		peerA            = NewMockpeer(ctrl)
+		peerB            = NewMockpeer(ctrl)
+		peers            = preparedMockPeers(peerA, peerB)
+		enqueueCh        = NewMockenqueueChannel(ctrl)
+		peerBlocksQueues = mockPeerBlocksQueues(peers, opts)
+	)
+	defer peerBlocksQueues.closeAll()
+
+	// Prefer the second peer, e.g. since it is in the same isolation group.
+	peerBlocksQueues[1].preferred = true
+
+	var (
+		start    = timeZero
+		checksum = uint32(1)
+		perPeer  = []receivedBlockMetadata{
+			{
+				peer: peerA,
+				id:   fooID,
+				block: blockMetadata{
+					start: start, size: 2, checksum: &checksum,
+				},
+			},
+			{
+				peer: peerB,
+				id:   fooID,
+				block: blockMetadata{
+					start: start, size: 2, checksum: &checksum,
+				},
+			},
+		}
+		pooled = selectPeersFromPerPeerBlockMetadatasPooledResources{}
+	)
+
+	selected, _ := session.selectPeersFromPerPeerBlockMetadatas(
+		perPeer, peerBlocksQueues, enqueueCh,
+		newStaticRuntimeReadConsistencyLevel(opts.BootstrapConsistencyLevel()),
+		testPeers(peers), pooled, metrics)
+
+	require.Equal(t, 1, len(selected))
+	assert.Equal(t, 1, selected[0].block.reattempt.attempt)
+	assert.Equal(t, []peer{peerB}, selected[0].block.reattempt.attempted)
+}
+
 func TestSelectPeersFromPerPeerBlockMetadatasSelectAllOnDifferingChecksums(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1891,6 +1946,31 @@ func TestBlocksResultAddBlockFromPeerErrorOnNoSegmentsData(t *testing.T) {
 	assert.Equal(t, errSessionBadBlockResultFromPeer, err)
 }
 
+func TestStreamBlocksCompressor(t *testing.T) {
+	s := &session{}
+
+	result := rpc.NewFetchBlocksRawResult_()
+	compressor, err := s.streamBlocksCompressor(result)
+	require.NoError(t, err)
+	assert.Nil(t, compressor)
+
+	compression := string(compress.None)
+	result.Compression = &compression
+	compressor, err = s.streamBlocksCompressor(result)
+	require.NoError(t, err)
+	assert.Nil(t, compressor)
+
+	compression = string(compress.LZ4)
+	compressor, err = s.streamBlocksCompressor(result)
+	require.NoError(t, err)
+	require.NotNil(t, compressor)
+	assert.Equal(t, compress.LZ4, compressor.Type())
+
+	compression = "unknown"
+	_, err = s.streamBlocksCompressor(result)
+	require.Error(t, err)
+}
+
 func TestEnqueueChannelEnqueueDelayed(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -121,6 +121,62 @@ func TestSessionFetchNotOpenError(t *testing.T) {
 	assert.Equal(t, errSessionStatusNotOpen, err)
 }
 
+func TestSessionFetchFromHost(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestAdminOptions()
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	mockHostQueues, mockClients := mockHostQueuesAndClientsForFetchBootstrapBlocks(ctrl, opts)
+	session.newHostQueueFn = mockHostQueues.newHostQueueFn()
+	require.NoError(t, session.Open())
+
+	start := time.Now().Truncate(time.Hour)
+	end := start.Add(2 * time.Hour)
+	fetches := testFetches([]testFetch{
+		{"foo", []testValue{
+			{1.0, start.Add(1 * time.Second), xtime.Second, nil},
+			{2.0, start.Add(2 * time.Second), xtime.Second, nil},
+		}},
+	})
+
+	encoder := m3tsz.NewEncoder(start, nil, true, nil)
+	for _, value := range fetches[0].values {
+		dp := ts.Datapoint{Timestamp: value.t, Value: value.value}
+		require.NoError(t, encoder.Encode(dp, value.unit, nil))
+	}
+	seg := encoder.Discard()
+
+	// Only the requested host should be queried.
+	mockClients[1].EXPECT().
+		FetchBatchRaw(gomock.Any(), gomock.Any()).
+		Do(func(_ interface{}, req *rpc.FetchBatchRawRequest) {
+			assert.Equal(t, testNamespaceName, string(req.NameSpace))
+			assert.Equal(t, [][]byte{[]byte("foo")}, req.Ids)
+		}).
+		Return(&rpc.FetchBatchRawResult_{
+			Elements: []*rpc.FetchRawResult_{
+				{Segments: []*rpc.Segments{{
+					Merged: &rpc.Segment{Head: bytesIfNotNil(seg.Head), Tail: bytesIfNotNil(seg.Tail)},
+				}}},
+			},
+		}, nil)
+
+	iter, err := session.FetchFromHost(ident.StringID(testNamespaceName),
+		ident.StringID("foo"), start, end, testHostName(1))
+	require.NoError(t, err)
+	assertFetchResults(t, start, end, fetches, seriesIterators(iter), nil)
+
+	_, err = session.FetchFromHost(ident.StringID(testNamespaceName),
+		ident.StringID("foo"), start, end, "unknown")
+	require.Equal(t, errSessionHasNoHostQueueForHost, err)
+
+	require.NoError(t, session.Close())
+}
+
 func TestSessionFetchIDs(t *testing.T) {
 	opts := newSessionTestOptions()
 	testSessionFetchIDs(t, testOptions{nsID: ident.StringID(testNamespaceName), opts: opts})
@@ -25,9 +25,11 @@ import (
 	"sync"
 	"sync/atomic"
 	"testing"
+	"time"
 
 	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
@@ -176,6 +178,141 @@ func TestSessionShardID(t *testing.T) {
 	assert.NoError(t, s.Close())
 }
 
+func TestSessionShardKey(t *testing.T) {
+	opts := newSessionTestOptions().SetNamespaceShardKeyStrategies(map[string]string{
+		"tenants": sharding.TenantPrefixShardKeyStrategy,
+	})
+	s, err := newSession(opts)
+	require.NoError(t, err)
+
+	session := s.(*session)
+	id := ident.StringID("acme:cpu.user")
+	assert.Equal(t, "acme", session.shardKey(ident.StringID("tenants"), id).String())
+	assert.Equal(t, id.String(), session.shardKey(ident.StringID("other"), id).String())
+
+	_, err = newSession(opts.SetNamespaceShardKeyStrategies(map[string]string{
+		"tenants": "unknown",
+	}))
+	assert.Error(t, err)
+}
+
+// newSessionTestShardKeyOptions returns session test options whose shard set
+// assigns the acme tenant prefix to shard 1 and every other ID to shard 0,
+// with the tenant prefix shard key strategy for the tenants namespace.
+func newSessionTestShardKeyOptions() Options {
+	var ids []uint32
+	for i := uint32(0); i < uint32(sessionTestShards); i++ {
+		ids = append(ids, i)
+	}
+
+	shards := sharding.NewShards(ids, shard.Available)
+	shardSet, _ := sharding.NewShardSet(shards, func(id ident.ID) uint32 {
+		if id.String() == "acme" {
+			return 1
+		}
+		return 0
+	})
+	return newSessionTestOptions().
+		SetTopologyInitializer(topology.NewStaticInitializer(
+			topology.NewStaticOptions().
+				SetReplicas(sessionTestReplicas).
+				SetShardSet(shardSet).
+				SetHostShardSets(sessionTestHostAndShards(shardSet)))).
+		SetNamespaceShardKeyStrategies(map[string]string{
+			"tenants": sharding.TenantPrefixShardKeyStrategy,
+		})
+}
+
+func TestSessionNamespaceShardID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s, err := newSession(newSessionTestShardKeyOptions())
+	require.NoError(t, err)
+
+	id := ident.StringID("acme:cpu.user")
+	_, err = s.NamespaceShardID(ident.StringID("tenants"), id)
+	assert.Equal(t, errSessionStatusNotOpen, err)
+
+	mockHostQueues(ctrl, s.(*session), sessionTestReplicas, nil)
+	require.NoError(t, s.Open())
+
+	shard, err := s.NamespaceShardID(ident.StringID("tenants"), id)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), shard)
+
+	// Namespaces without a shard key strategy hash the whole ID.
+	shard, err = s.NamespaceShardID(ident.StringID("other"), id)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), shard)
+
+	shard, err = s.ShardID(id)
+	require.NoError(t, err)
+	assert.Equal(t, uint32(0), shard)
+
+	assert.NoError(t, s.Close())
+}
+
+func TestSessionShardWatermarks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestAdminOptions()
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	mockHostQueues, mockClients := mockHostQueuesAndClientsForFetchBootstrapBlocks(ctrl, opts)
+	session.newHostQueueFn = mockHostQueues.newHostQueueFn()
+	require.NoError(t, session.Open())
+
+	var (
+		flushedTo  = time.Now().Truncate(time.Hour)
+		repairedTo = flushedTo.Add(-time.Hour)
+	)
+	for i, client := range mockClients {
+		watermark := &rpc.ShardWatermark{
+			Shard:      0,
+			FlushedTo:  flushedTo.UnixNano(),
+			RepairedTo: repairedTo.UnixNano(),
+			DurableTo:  repairedTo.UnixNano(),
+		}
+		if i == 1 {
+			// A lagging replica holds back the watermarks of the shard.
+			watermark.FlushedTo = repairedTo.UnixNano()
+			watermark.RepairedTo = 0
+			watermark.DurableTo = 0
+		}
+		client.EXPECT().
+			ShardWatermarks(gomock.Any(), &rpc.ShardWatermarksRequest{
+				NameSpace: []byte(testNamespaceName),
+			}).
+			Return(&rpc.ShardWatermarksResult_{
+				Watermarks: []*rpc.ShardWatermark{
+					watermark,
+					{
+						Shard:      1,
+						FlushedTo:  flushedTo.UnixNano(),
+						RepairedTo: repairedTo.UnixNano(),
+						DurableTo:  repairedTo.UnixNano(),
+					},
+				},
+			}, nil)
+	}
+
+	watermarks, err := session.ShardWatermarks(ident.StringID(testNamespaceName))
+	require.NoError(t, err)
+	require.Equal(t, 2, len(watermarks))
+	assert.True(t, repairedTo.Equal(watermarks[0].FlushedTo))
+	assert.True(t, watermarks[0].RepairedTo.IsZero())
+	assert.True(t, watermarks[0].DurableTo.IsZero())
+	assert.True(t, flushedTo.Equal(watermarks[1].FlushedTo))
+	assert.True(t, repairedTo.Equal(watermarks[1].RepairedTo))
+	assert.True(t, repairedTo.Equal(watermarks[1].DurableTo))
+
+	require.NoError(t, session.Close())
+}
+
 func TestSessionClusterConnectConsistencyLevelAll(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -319,6 +456,58 @@ func testSessionClusterConnectConsistencyLevel(
 	}
 }
 
+func TestSessionHostQueuesRecreatedOnAddressChange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSessionTestOptions().
+		SetClusterConnectConsistencyLevel(topology.ConnectConsistencyLevelNone)
+	s, err := newSession(opts)
+	require.NoError(t, err)
+	session := s.(*session)
+
+	session.newHostQueueFn = func(
+		host topology.Host,
+		opts hostQueueOpts,
+	) (hostQueue, error) {
+		hostQueue := NewMockhostQueue(ctrl)
+		hostQueue.EXPECT().Open()
+		hostQueue.EXPECT().Host().Return(host).AnyTimes()
+		return hostQueue, nil
+	}
+
+	shardSet := sessionTestShardSet()
+	hostShardSets := sessionTestHostAndShards(shardSet)
+	topoMap := topology.NewStaticMap(topology.NewStaticOptions().
+		SetReplicas(sessionTestReplicas).
+		SetShardSet(shardSet).
+		SetHostShardSets(hostShardSets))
+	existing, _, _, err := session.hostQueues(topoMap, nil)
+	require.NoError(t, err)
+	require.Len(t, existing, sessionTestReplicas)
+
+	// Move the first host to a new address.
+	moved := hostShardSets[0].Host()
+	hostShardSets[0] = topology.NewHostShardSet(
+		topology.NewHost(moved.ID(), "127.0.0.1:9000"), shardSet)
+	topoMap = topology.NewStaticMap(topology.NewStaticOptions().
+		SetReplicas(sessionTestReplicas).
+		SetShardSet(shardSet).
+		SetHostShardSets(hostShardSets))
+	queues, _, _, err := session.hostQueues(topoMap, existing)
+	require.NoError(t, err)
+	require.Len(t, queues, sessionTestReplicas)
+
+	for i, queue := range queues {
+		if queue.Host().ID() == moved.ID() {
+			assert.True(t, queue != existing[i])
+			assert.Equal(t, "127.0.0.1:9000", queue.Host().Address())
+			continue
+		}
+		assert.True(t, queue == existing[i])
+	}
+}
+
 func mockHostQueues(
 	ctrl *gomock.Controller,
 	s *session,
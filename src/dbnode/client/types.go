@@ -27,12 +27,16 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -59,6 +63,11 @@ type Client interface {
 	DefaultSessionActive() bool
 }
 
+// WriteDispositions are how a write was applied keyed by the ID of each host
+// that reported it, hosts running versions that do not report dispositions
+// are omitted.
+type WriteDispositions map[string]rpc.WriteDisposition
+
 // Session can write and read to a cluster.
 type Session interface {
 	// Write value to the database for an ID.
@@ -67,6 +76,15 @@ type Session interface {
 	// WriteTagged value to the database for an ID and given tags.
 	WriteTagged(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) error
 
+	// WriteWithDispositions writes a value to the database for an ID and
+	// returns how the write was applied by each host that reported it.
+	WriteWithDispositions(namespace, id ident.ID, t time.Time, value float64, unit xtime.Unit, annotation []byte) (WriteDispositions, error)
+
+	// WriteTaggedWithDispositions writes a value to the database for an ID
+	// and given tags and returns how the write was applied by each host that
+	// reported it.
+	WriteTaggedWithDispositions(namespace, id ident.ID, tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit, annotation []byte) (WriteDispositions, error)
+
 	// Fetch values from the database for an ID.
 	Fetch(namespace, id ident.ID, startInclusive, endExclusive time.Time) (encoding.SeriesIterator, error)
 
@@ -87,6 +105,11 @@ type Session interface {
 	// for given IDs begin failing.
 	ShardID(id ident.ID) (uint32, error)
 
+	// NamespaceShardID returns the shard for an ID of a namespace, routing
+	// the ID with the shard key strategy of the namespace as writes and
+	// fetches for the namespace are routed.
+	NamespaceShardID(namespace, id ident.ID) (uint32, error)
+
 	// IteratorPools exposes the internal iterator pools used by the session to clients.
 	IteratorPools() (encoding.IteratorPools, error)
 
@@ -168,6 +191,14 @@ type PeerBlocksIter interface {
 	Err() error
 }
 
+// ShardWatermark describes up to which time the data of a shard has been
+// flushed and repaired, zero times indicate the watermark has not advanced.
+type ShardWatermark struct {
+	FlushedTo  time.Time
+	RepairedTo time.Time
+	DurableTo  time.Time
+}
+
 // AdminSession can perform administrative and node-to-node operations.
 type AdminSession interface {
 	Session
@@ -187,6 +218,21 @@ type AdminSession interface {
 	// Truncate will truncate the namespace for a given shard.
 	Truncate(namespace ident.ID) (int64, error)
 
+	// FetchFromHost will fetch the values for a series from a single host
+	// only, bypassing consistency checks and merging across replicas, this
+	// is useful for comparing what each replica holds for a series.
+	FetchFromHost(
+		namespace ident.ID,
+		id ident.ID,
+		startInclusive, endExclusive time.Time,
+		hostID string,
+	) (encoding.SeriesIterator, error)
+
+	// ShardWatermarks returns the flush and repair watermarks of each shard
+	// of a namespace, taking the earliest watermark reported by any replica
+	// so that data before the durable watermark is durable on all replicas.
+	ShardWatermarks(namespace ident.ID) (map[uint32]ShardWatermark, error)
+
 	// FetchBootstrapBlocksFromPeers will fetch the most fulfilled block
 	// for each series using the runtime configurable bootstrap level consistency.
 	FetchBootstrapBlocksFromPeers(
@@ -226,6 +272,19 @@ type AdminSession interface {
 	) (PeerBlocksIter, error)
 }
 
+// ReplicaSelector selects the replicas of a shard that reads are sent to.
+type ReplicaSelector interface {
+	// Order orders the replicas of a shard in place by preference, reads are
+	// sent to as many of the first replicas as the read consistency requires.
+	Order(replicas []Replica)
+
+	// RequestStarted is called when a read is sent to a replica.
+	RequestStarted(host topology.Host)
+
+	// RequestCompleted is called when a read sent to a replica completes.
+	RequestCompleted(host topology.Host, latency time.Duration, err error)
+}
+
 // Options is a set of client options.
 type Options interface {
 	// Validate validates the options.
@@ -282,6 +341,12 @@ type Options interface {
 	// ChannelOptions returns the channelOptions.
 	ChannelOptions() *tchannel.ChannelOptions
 
+	// SetPeerStats sets the tracker of network statistics per peer.
+	SetPeerStats(value peerstats.Tracker) Options
+
+	// PeerStats returns the tracker of network statistics per peer.
+	PeerStats() peerstats.Tracker
+
 	// SetMaxConnectionCount sets the maxConnectionCount.
 	SetMaxConnectionCount(value int) Options
 
@@ -533,6 +598,104 @@ type Options interface {
 
 	// UseV2BatchAPIs returns whether the V2 batch APIs should be used.
 	UseV2BatchAPIs() bool
+
+	// SetReadRepairEnabled sets whether fetches that observe divergent
+	// replicas enqueue the divergent series blocks for repair.
+	SetReadRepairEnabled(value bool) Options
+
+	// ReadRepairEnabled returns whether fetches that observe divergent
+	// replicas enqueue the divergent series blocks for repair.
+	ReadRepairEnabled() bool
+
+	// SetReadRepairQueueSize sets the maximum number of pending read repairs,
+	// repairs observed while the queue is full are dropped.
+	SetReadRepairQueueSize(value int) Options
+
+	// ReadRepairQueueSize returns the maximum number of pending read repairs.
+	ReadRepairQueueSize() int
+
+	// SetFetchMergeReplicaBlocks sets whether fetches at a read consistency
+	// level that requires more than one replica merge the blocks returned by
+	// every replica that responded, choosing the most complete replica for
+	// each block, and attach repair hints for the deficient replicas.
+	SetFetchMergeReplicaBlocks(value bool) Options
+
+	// FetchMergeReplicaBlocks returns whether fetches at a read consistency
+	// level that requires more than one replica merge the blocks returned by
+	// every replica that responded.
+	FetchMergeReplicaBlocks() bool
+
+	// SetReplicaSelector sets the replica selector that orders the replicas
+	// fetches are sent to, fetches are only sent to as many replicas as the
+	// read consistency level requires. If nil fetches are sent to every
+	// replica.
+	SetReplicaSelector(value ReplicaSelector) Options
+
+	// ReplicaSelector returns the replica selector that orders the replicas
+	// fetches are sent to.
+	ReplicaSelector() ReplicaSelector
+
+	// SetWriteIdempotencyEnabled sets whether tagged write batches are sent
+	// with an idempotency key so that a batch that times out can be safely
+	// retried, the M3DB nodes must have idempotent writes enabled for the
+	// retried batch to not be applied twice.
+	SetWriteIdempotencyEnabled(value bool) Options
+
+	// WriteIdempotencyEnabled returns whether tagged write batches are sent
+	// with an idempotency key so that a batch that times out can be safely
+	// retried.
+	WriteIdempotencyEnabled() bool
+
+	// SetWriteDurability sets the durability class write batches request the
+	// M3DB nodes acknowledge their writes with, nodes acknowledge writes with
+	// the stronger of the requested class and the class of the namespace.
+	SetWriteDurability(value ts.Durability) Options
+
+	// WriteDurability returns the durability class write batches request the
+	// M3DB nodes acknowledge their writes with.
+	WriteDurability() ts.Durability
+
+	// SetWriteSpillEnabled sets whether writes that fail because the nodes
+	// owning their shard are unavailable are spilled to disk per shard and
+	// replayed in the background once the nodes recover.
+	SetWriteSpillEnabled(value bool) Options
+
+	// WriteSpillEnabled returns whether writes that fail because the nodes
+	// owning their shard are unavailable are spilled to disk per shard and
+	// replayed in the background once the nodes recover.
+	WriteSpillEnabled() bool
+
+	// SetWriteSpillPath sets the directory writes are spilled to.
+	SetWriteSpillPath(value string) Options
+
+	// WriteSpillPath returns the directory writes are spilled to.
+	WriteSpillPath() string
+
+	// SetWriteSpillMaxBytes sets the maximum size of the writes spilled to
+	// disk, writes that would exceed it return the original write error.
+	SetWriteSpillMaxBytes(value int64) Options
+
+	// WriteSpillMaxBytes returns the maximum size of the writes spilled to disk.
+	WriteSpillMaxBytes() int64
+
+	// SetWriteSpillReplayInterval sets the interval at which writes spilled
+	// to disk are replayed.
+	SetWriteSpillReplayInterval(value time.Duration) Options
+
+	// WriteSpillReplayInterval returns the interval at which writes spilled
+	// to disk are replayed.
+	WriteSpillReplayInterval() time.Duration
+
+	// SetNamespaceShardKeyStrategies sets the shard key strategy of each
+	// namespace that does not hash whole series IDs to assign series to
+	// shards, by namespace ID, these must match the shard key strategies of
+	// the namespaces on the M3DB nodes.
+	SetNamespaceShardKeyStrategies(value map[string]string) Options
+
+	// NamespaceShardKeyStrategies returns the shard key strategy of each
+	// namespace that does not hash whole series IDs to assign series to
+	// shards, by namespace ID.
+	NamespaceShardKeyStrategies() map[string]string
 }
 
 // AdminOptions is a set of administration client options.
@@ -586,6 +749,29 @@ type AdminOptions interface {
 
 	// StreamBlocksRetrier returns the retrier for streaming blocks.
 	StreamBlocksRetrier() xretry.Retrier
+
+	// SetFetchSeriesBlocksCompression sets the compression types accepted for
+	// streamed series blocks, in order of preference. Peers that do not support
+	// any of the types stream the blocks uncompressed.
+	SetFetchSeriesBlocksCompression(value []compress.Type) AdminOptions
+
+	// FetchSeriesBlocksCompression returns the compression types accepted for
+	// streamed series blocks, in order of preference.
+	FetchSeriesBlocksCompression() []compress.Type
+
+	// SetFaultInjector sets the fault injector used when fetching from peers.
+	SetFaultInjector(value fault.Injector) AdminOptions
+
+	// FaultInjector returns the fault injector used when fetching from peers.
+	FaultInjector() fault.Injector
+
+	// SetBootstrapPeerPreferences sets the preferences for the peers that
+	// shards are bootstrapped from.
+	SetBootstrapPeerPreferences(value BootstrapPeerPreferences) AdminOptions
+
+	// BootstrapPeerPreferences returns the preferences for the peers that
+	// shards are bootstrapped from.
+	BootstrapPeerPreferences() BootstrapPeerPreferences
 }
 
 // The rest of these types are internal types that mocks are generated for
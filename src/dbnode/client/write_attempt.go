@@ -58,6 +58,10 @@ type writeAttemptArgs struct {
 	annotation  []byte
 	unit        xtime.Unit
 	attemptType writeAttemptType
+
+	// dispositions is only set for writes that report how they were
+	// applied by each host.
+	dispositions WriteDispositions
 }
 
 func (w *writeAttempt) reset() {
@@ -67,7 +71,7 @@ func (w *writeAttempt) reset() {
 func (w *writeAttempt) perform() error {
 	err := w.session.writeAttempt(w.args.attemptType,
 		w.args.namespace, w.args.id, w.args.tags, w.args.t,
-		w.args.value, w.args.unit, w.args.annotation)
+		w.args.value, w.args.unit, w.args.annotation, w.args.dispositions)
 
 	if IsBadRequestError(err) {
 		// Do not retry bad request errors
@@ -41,6 +41,10 @@ type writeOperation struct {
 	datapoint    rpc.Datapoint
 	completionFn completionFn
 	pool         *writeOperationPool
+
+	// includeDispositions is set for writes that report how they were
+	// applied, these are always written with the V1 batch APIs.
+	includeDispositions bool
 }
 
 func (w *writeOperation) reset() {
@@ -0,0 +1,623 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	writeSpillFilePrefix = "shard-"
+	writeSpillFileSuffix = ".spill"
+
+	// writeSpillRecordHeaderLen is the length of the header preceding each
+	// spilled write, the length of the record followed by its checksum.
+	writeSpillRecordHeaderLen = 8
+)
+
+var (
+	errWriteSpillQueueAlreadyOpen = errors.New("write spill queue already open")
+	errWriteSpillQueueFull        = errors.New("write spill queue is full")
+	errWriteSpillRecordCorrupt    = errors.New("write spill record is corrupt")
+)
+
+// writeSpillRecord is a single write spilled to disk while the nodes that
+// own its shard are unavailable.
+type writeSpillRecord struct {
+	tagged     bool
+	namespace  []byte
+	id         []byte
+	tags       []writeSpillTag
+	timestamp  time.Time
+	value      float64
+	unit       xtime.Unit
+	annotation []byte
+}
+
+type writeSpillTag struct {
+	name  []byte
+	value []byte
+}
+
+func (r writeSpillRecord) encode() []byte {
+	var (
+		buf     bytes.Buffer
+		scratch [binary.MaxVarintLen64]byte
+	)
+	writeUvarint := func(v uint64) {
+		n := binary.PutUvarint(scratch[:], v)
+		buf.Write(scratch[:n])
+	}
+	writeBytes := func(b []byte) {
+		writeUvarint(uint64(len(b)))
+		buf.Write(b)
+	}
+
+	buf.Write(make([]byte, writeSpillRecordHeaderLen))
+	if r.tagged {
+		buf.WriteByte(1)
+	} else {
+		buf.WriteByte(0)
+	}
+	writeBytes(r.namespace)
+	writeBytes(r.id)
+	writeUvarint(uint64(len(r.tags)))
+	for _, tag := range r.tags {
+		writeBytes(tag.name)
+		writeBytes(tag.value)
+	}
+	n := binary.PutVarint(scratch[:], r.timestamp.UnixNano())
+	buf.Write(scratch[:n])
+	binary.LittleEndian.PutUint64(scratch[:8], math.Float64bits(r.value))
+	buf.Write(scratch[:8])
+	buf.WriteByte(byte(r.unit))
+	writeBytes(r.annotation)
+
+	data := buf.Bytes()
+	payload := data[writeSpillRecordHeaderLen:]
+	binary.LittleEndian.PutUint32(data[0:4], uint32(len(payload)))
+	binary.LittleEndian.PutUint32(data[4:8], crc32.ChecksumIEEE(payload))
+	return data
+}
+
+// decodeWriteSpillRecord decodes the record at the start of data and returns
+// it along with the number of bytes it occupied.
+func decodeWriteSpillRecord(data []byte) (writeSpillRecord, int, error) {
+	if len(data) < writeSpillRecordHeaderLen {
+		return writeSpillRecord{}, 0, errWriteSpillRecordCorrupt
+	}
+	var (
+		size     = int(binary.LittleEndian.Uint32(data[0:4]))
+		checksum = binary.LittleEndian.Uint32(data[4:8])
+		total    = writeSpillRecordHeaderLen + size
+	)
+	if size <= 0 || len(data) < total {
+		return writeSpillRecord{}, 0, errWriteSpillRecordCorrupt
+	}
+	payload := data[writeSpillRecordHeaderLen:total]
+	if crc32.ChecksumIEEE(payload) != checksum {
+		return writeSpillRecord{}, 0, errWriteSpillRecordCorrupt
+	}
+
+	d := writeSpillDecoder{data: payload}
+	r := writeSpillRecord{
+		tagged:    d.byte() == 1,
+		namespace: d.bytes(),
+		id:        d.bytes(),
+	}
+	if numTags := d.uvarint(); d.err == nil && numTags > 0 {
+		if numTags > uint64(len(payload)) {
+			return writeSpillRecord{}, 0, errWriteSpillRecordCorrupt
+		}
+		r.tags = make([]writeSpillTag, 0, numTags)
+		for i := uint64(0); i < numTags && d.err == nil; i++ {
+			r.tags = append(r.tags, writeSpillTag{name: d.bytes(), value: d.bytes()})
+		}
+	}
+	r.timestamp = time.Unix(0, d.varint())
+	r.value = math.Float64frombits(d.uint64())
+	r.unit = xtime.Unit(d.byte())
+	r.annotation = d.bytes()
+	if d.err != nil || len(d.data) != 0 {
+		return writeSpillRecord{}, 0, errWriteSpillRecordCorrupt
+	}
+	return r, total, nil
+}
+
+type writeSpillDecoder struct {
+	data []byte
+	err  error
+}
+
+func (d *writeSpillDecoder) byte() byte {
+	if d.err != nil || len(d.data) < 1 {
+		d.err = errWriteSpillRecordCorrupt
+		return 0
+	}
+	v := d.data[0]
+	d.data = d.data[1:]
+	return v
+}
+
+func (d *writeSpillDecoder) uint64() uint64 {
+	if d.err != nil || len(d.data) < 8 {
+		d.err = errWriteSpillRecordCorrupt
+		return 0
+	}
+	v := binary.LittleEndian.Uint64(d.data)
+	d.data = d.data[8:]
+	return v
+}
+
+func (d *writeSpillDecoder) uvarint() uint64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Uvarint(d.data)
+	if n <= 0 {
+		d.err = errWriteSpillRecordCorrupt
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *writeSpillDecoder) varint() int64 {
+	if d.err != nil {
+		return 0
+	}
+	v, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.err = errWriteSpillRecordCorrupt
+		return 0
+	}
+	d.data = d.data[n:]
+	return v
+}
+
+func (d *writeSpillDecoder) bytes() []byte {
+	size := d.uvarint()
+	if d.err != nil {
+		return nil
+	}
+	if size > uint64(len(d.data)) {
+		d.err = errWriteSpillRecordCorrupt
+		return nil
+	}
+	if size == 0 {
+		return nil
+	}
+	v := d.data[:size:size]
+	d.data = d.data[size:]
+	return v
+}
+
+// writeSpillReplayFn replays a spilled write against the cluster.
+type writeSpillReplayFn func(r writeSpillRecord) error
+
+type writeSpillMetrics struct {
+	spilled       tally.Counter
+	full          tally.Counter
+	spillErrors   tally.Counter
+	replayed      tally.Counter
+	replayErrors  tally.Counter
+	replayDropped tally.Counter
+	corrupt       tally.Counter
+	bytes         tally.Gauge
+}
+
+func newWriteSpillMetrics(scope tally.Scope) writeSpillMetrics {
+	return writeSpillMetrics{
+		spilled:       scope.Counter("spilled"),
+		full:          scope.Counter("full"),
+		spillErrors:   scope.Counter("spill-errors"),
+		replayed:      scope.Counter("replayed"),
+		replayErrors:  scope.Counter("replay-errors"),
+		replayDropped: scope.Counter("replay-dropped"),
+		corrupt:       scope.Counter("corrupt"),
+		bytes:         scope.Gauge("bytes"),
+	}
+}
+
+// writeSpillQueue buffers writes per shard in files on local disk while the
+// nodes that own the shard are unavailable and replays them in order in the
+// background once the nodes recover, so that brief node outages do not
+// surface as write errors to edge writers.
+type writeSpillQueue struct {
+	sync.Mutex
+
+	path           string
+	maxBytes       int64
+	replayInterval time.Duration
+	replayFn       writeSpillReplayFn
+	shards         map[uint32]int64
+	size           int64
+	doneCh         chan struct{}
+	wg             sync.WaitGroup
+	open           bool
+	logger         *zap.Logger
+	metrics        writeSpillMetrics
+}
+
+func newWriteSpillQueue(
+	path string,
+	maxBytes int64,
+	replayInterval time.Duration,
+	replayFn writeSpillReplayFn,
+	scope tally.Scope,
+	logger *zap.Logger,
+) *writeSpillQueue {
+	return &writeSpillQueue{
+		path:           path,
+		maxBytes:       maxBytes,
+		replayInterval: replayInterval,
+		replayFn:       replayFn,
+		shards:         make(map[uint32]int64),
+		doneCh:         make(chan struct{}),
+		logger:         logger,
+		metrics:        newWriteSpillMetrics(scope),
+	}
+}
+
+// Open loads any writes spilled by a previous process and starts the
+// background replay worker.
+func (q *writeSpillQueue) Open() error {
+	q.Lock()
+	defer q.Unlock()
+
+	if q.open {
+		return errWriteSpillQueueAlreadyOpen
+	}
+	if err := os.MkdirAll(q.path, 0755); err != nil {
+		return err
+	}
+	files, err := ioutil.ReadDir(q.path)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		shard, ok := writeSpillFileShard(f.Name())
+		if !ok || f.IsDir() {
+			continue
+		}
+		q.shards[shard] = f.Size()
+		q.size += f.Size()
+	}
+	q.metrics.bytes.Update(float64(q.size))
+
+	q.open = true
+	q.wg.Add(1)
+	go q.replayLoop()
+	return nil
+}
+
+// Spill appends a write to the spill file of its shard, it returns an error
+// if the queue would exceed its size cap.
+func (q *writeSpillQueue) Spill(shard uint32, r writeSpillRecord) error {
+	data := r.encode()
+
+	q.Lock()
+	defer q.Unlock()
+
+	if q.size+int64(len(data)) > q.maxBytes {
+		q.metrics.full.Inc(1)
+		return errWriteSpillQueueFull
+	}
+	if err := q.appendWithLock(shard, data); err != nil {
+		q.metrics.spillErrors.Inc(1)
+		return err
+	}
+	q.shards[shard] += int64(len(data))
+	q.size += int64(len(data))
+	q.metrics.bytes.Update(float64(q.size))
+	q.metrics.spilled.Inc(1)
+	return nil
+}
+
+// Size returns the number of bytes of writes currently spilled to disk.
+func (q *writeSpillQueue) Size() int64 {
+	q.Lock()
+	defer q.Unlock()
+	return q.size
+}
+
+func (q *writeSpillQueue) appendWithLock(shard uint32, data []byte) error {
+	fd, err := os.OpenFile(q.shardFilePath(shard),
+		os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fd.Write(data); err != nil {
+		fd.Close()
+		return err
+	}
+	return fd.Close()
+}
+
+func (q *writeSpillQueue) replayLoop() {
+	defer q.wg.Done()
+
+	ticker := time.NewTicker(q.replayInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-q.doneCh:
+			return
+		case <-ticker.C:
+			q.Replay()
+		}
+	}
+}
+
+// Replay replays the spilled writes of every shard in the order they were
+// spilled, stopping at the first write of a shard that fails to replay.
+func (q *writeSpillQueue) Replay() {
+	q.Lock()
+	shards := make([]uint32, 0, len(q.shards))
+	for shard := range q.shards {
+		shards = append(shards, shard)
+	}
+	q.Unlock()
+
+	sort.Slice(shards, func(i, j int) bool {
+		return shards[i] < shards[j]
+	})
+	for _, shard := range shards {
+		if q.closing() {
+			return
+		}
+		if err := q.replayShard(shard); err != nil {
+			q.logger.Warn("could not replay spilled writes",
+				zap.Uint32("shard", shard), zap.Error(err))
+		}
+	}
+}
+
+func (q *writeSpillQueue) replayShard(shard uint32) error {
+	// NB: Read the spilled writes without holding the lock so that writes
+	// can continue to be spilled while the shard is replayed, writes
+	// spilled during the replay are appended after the bytes read here.
+	q.Lock()
+	data, err := ioutil.ReadFile(q.shardFilePath(shard))
+	q.Unlock()
+	if err != nil {
+		return err
+	}
+
+	var (
+		replayed  int
+		replayErr error
+	)
+	for replayed < len(data) {
+		r, n, err := decodeWriteSpillRecord(data[replayed:])
+		if err != nil {
+			// NB: Nothing after a corrupt record can be trusted, for
+			// instance a partial record left behind by a crash, so
+			// drop the remainder of what was read.
+			q.metrics.corrupt.Inc(1)
+			replayed = len(data)
+			replayErr = fmt.Errorf("dropped corrupt spilled writes: %v", err)
+			break
+		}
+		if err := q.replayFn(r); err != nil {
+			if isWriteSpillableError(err) || q.closing() {
+				// Nodes are still unavailable or the session is closing,
+				// retry on the next replay to preserve the order of
+				// writes to the shard.
+				q.metrics.replayErrors.Inc(1)
+				replayErr = err
+				break
+			}
+			q.metrics.replayDropped.Inc(1)
+			q.logger.Error("dropping spilled write that cannot be replayed",
+				zap.Uint32("shard", shard),
+				zap.ByteString("id", r.id),
+				zap.Error(err))
+		} else {
+			q.metrics.replayed.Inc(1)
+		}
+		replayed += n
+	}
+	if replayed == 0 {
+		return replayErr
+	}
+
+	q.Lock()
+	defer q.Unlock()
+
+	if err := q.truncateWithLock(shard, replayed); err != nil {
+		return err
+	}
+	return replayErr
+}
+
+// truncateWithLock removes the first n bytes of the spill file of a shard.
+func (q *writeSpillQueue) truncateWithLock(shard uint32, n int) error {
+	filePath := q.shardFilePath(shard)
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	remaining := data[n:]
+	if len(remaining) == 0 {
+		if err := os.Remove(filePath); err != nil {
+			return err
+		}
+		delete(q.shards, shard)
+	} else {
+		tmpPath := filePath + ".tmp"
+		if err := ioutil.WriteFile(tmpPath, remaining, 0644); err != nil {
+			return err
+		}
+		if err := os.Rename(tmpPath, filePath); err != nil {
+			return err
+		}
+		q.shards[shard] = int64(len(remaining))
+	}
+	q.size -= int64(n)
+	q.metrics.bytes.Update(float64(q.size))
+	return nil
+}
+
+func (q *writeSpillQueue) closing() bool {
+	select {
+	case <-q.doneCh:
+		return true
+	default:
+		return false
+	}
+}
+
+func (q *writeSpillQueue) shardFilePath(shard uint32) string {
+	return filepath.Join(q.path, fmt.Sprintf("%s%d%s",
+		writeSpillFilePrefix, shard, writeSpillFileSuffix))
+}
+
+func writeSpillFileShard(name string) (uint32, bool) {
+	if !strings.HasPrefix(name, writeSpillFilePrefix) ||
+		!strings.HasSuffix(name, writeSpillFileSuffix) {
+		return 0, false
+	}
+	name = strings.TrimPrefix(name, writeSpillFilePrefix)
+	name = strings.TrimSuffix(name, writeSpillFileSuffix)
+	shard, err := strconv.ParseUint(name, 10, 32)
+	if err != nil {
+		return 0, false
+	}
+	return uint32(shard), true
+}
+
+// Close stops the background replay worker, spilled writes remain on disk
+// and are replayed by the next queue opened on the same path.
+func (q *writeSpillQueue) Close() {
+	q.Lock()
+	if !q.open {
+		q.Unlock()
+		return
+	}
+	q.open = false
+	close(q.doneCh)
+	q.Unlock()
+
+	q.wg.Wait()
+}
+
+// isWriteSpillableError returns whether a write failed because the nodes
+// that own its shard were unavailable, as opposed to the write itself
+// being invalid.
+func isWriteSpillableError(err error) bool {
+	if err == nil || IsBadRequestError(err) {
+		return false
+	}
+	return IsConsistencyResultError(err) ||
+		IsUnavailableError(err) ||
+		isHostNotAvailableError(err)
+}
+
+// spillWrite spills a write that failed because the nodes owning its shard
+// were unavailable, returning the original write error if it could not be
+// spilled.
+func (s *session) spillWrite(args writeAttemptArgs, writeErr error) error {
+	s.state.RLock()
+	if s.state.status != statusOpen {
+		s.state.RUnlock()
+		return writeErr
+	}
+	shard := s.state.topoMap.ShardSet().Lookup(s.shardKey(args.namespace, args.id))
+	s.state.RUnlock()
+
+	// NB: The write arguments are owned by the caller so take copies that
+	// live for as long as the write is spilled.
+	r := writeSpillRecord{
+		tagged:     args.attemptType == taggedWriteAttemptType,
+		namespace:  append([]byte(nil), args.namespace.Bytes()...),
+		id:         append([]byte(nil), args.id.Bytes()...),
+		timestamp:  args.t,
+		value:      args.value,
+		unit:       args.unit,
+		annotation: append([]byte(nil), args.annotation...),
+	}
+	if r.tagged {
+		tags := args.tags.Duplicate()
+		for tags.Next() {
+			tag := tags.Current()
+			r.tags = append(r.tags, writeSpillTag{
+				name:  append([]byte(nil), tag.Name.Bytes()...),
+				value: append([]byte(nil), tag.Value.Bytes()...),
+			})
+		}
+		err := tags.Err()
+		tags.Close()
+		if err != nil {
+			return writeErr
+		}
+	}
+
+	if err := s.writeSpill.Spill(shard, r); err != nil {
+		s.log.Debug("could not spill write",
+			zap.Uint32("shard", shard),
+			zap.Stringer("id", args.id),
+			zap.Error(err))
+		return writeErr
+	}
+	return nil
+}
+
+// replaySpilledWrite attempts a spilled write once, the spill queue retries
+// writes that fail on its next replay.
+func (s *session) replaySpilledWrite(r writeSpillRecord) error {
+	var (
+		wType = untaggedWriteAttemptType
+		tags  = ident.EmptyTagIterator
+	)
+	if r.tagged {
+		wType = taggedWriteAttemptType
+		tagsSlice := make([]ident.Tag, 0, len(r.tags))
+		for _, tag := range r.tags {
+			tagsSlice = append(tagsSlice, ident.Tag{
+				Name:  ident.BytesID(tag.name),
+				Value: ident.BytesID(tag.value),
+			})
+		}
+		tags = ident.NewTagsIterator(ident.NewTags(tagsSlice...))
+	}
+	return s.writeAttempt(wType, ident.BytesID(r.namespace),
+		ident.BytesID(r.id), tags, r.timestamp, r.value, r.unit, r.annotation, nil)
+}
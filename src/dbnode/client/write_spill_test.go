@@ -0,0 +1,277 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package client
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+type testWriteSpillReplayer struct {
+	sync.Mutex
+	err      error
+	replayed []writeSpillRecord
+}
+
+func (r *testWriteSpillReplayer) replay(record writeSpillRecord) error {
+	r.Lock()
+	defer r.Unlock()
+	if r.err != nil {
+		return r.err
+	}
+	r.replayed = append(r.replayed, record)
+	return nil
+}
+
+func (r *testWriteSpillReplayer) setErr(err error) {
+	r.Lock()
+	r.err = err
+	r.Unlock()
+}
+
+func newTestWriteSpillQueue(
+	t *testing.T,
+	maxBytes int64,
+	replayFn writeSpillReplayFn,
+) (*writeSpillQueue, string) {
+	dir, err := ioutil.TempDir("", "write-spill")
+	require.NoError(t, err)
+	q := newWriteSpillQueue(dir, maxBytes, time.Hour, replayFn,
+		tally.NoopScope, zap.NewNop())
+	return q, dir
+}
+
+func testWriteSpillRecord(id string, value float64) writeSpillRecord {
+	return writeSpillRecord{
+		tagged:    true,
+		namespace: []byte("testNs"),
+		id:        []byte(id),
+		tags: []writeSpillTag{
+			{name: []byte("host"), value: []byte("a")},
+			{name: []byte("dc"), value: []byte("b")},
+		},
+		timestamp:  time.Unix(0, 1000),
+		value:      value,
+		unit:       xtime.Second,
+		annotation: []byte("annotation"),
+	}
+}
+
+func TestWriteSpillRecordEncodeDecode(t *testing.T) {
+	records := []writeSpillRecord{
+		testWriteSpillRecord("foo", 42.5),
+		{
+			namespace: []byte("testNs"),
+			id:        []byte("bar"),
+			timestamp: time.Unix(10, 0),
+			value:     -1,
+			unit:      xtime.Millisecond,
+		},
+	}
+	for _, expected := range records {
+		data := expected.encode()
+		actual, n, err := decodeWriteSpillRecord(data)
+		require.NoError(t, err)
+		assert.Equal(t, len(data), n)
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestWriteSpillRecordDecodeCorrupt(t *testing.T) {
+	data := testWriteSpillRecord("foo", 1).encode()
+
+	_, _, err := decodeWriteSpillRecord(data[:len(data)-1])
+	assert.Equal(t, errWriteSpillRecordCorrupt, err)
+
+	data[len(data)-1]++
+	_, _, err = decodeWriteSpillRecord(data)
+	assert.Equal(t, errWriteSpillRecordCorrupt, err)
+}
+
+func TestWriteSpillQueueReplayInOrder(t *testing.T) {
+	replayer := &testWriteSpillReplayer{}
+	q, dir := newTestWriteSpillQueue(t, 1<<20, replayer.replay)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, q.Open())
+	defer q.Close()
+
+	require.NoError(t, q.Spill(1, testWriteSpillRecord("a", 1)))
+	require.NoError(t, q.Spill(1, testWriteSpillRecord("b", 2)))
+	require.NoError(t, q.Spill(2, testWriteSpillRecord("c", 3)))
+	assert.True(t, q.Size() > 0)
+
+	// Nodes still unavailable, nothing is replayed.
+	replayer.setErr(newConsistencyResultError(
+		topology.ConsistencyLevelMajority, 3, 3, []error{errors.New("timeout")}))
+	q.Replay()
+	assert.Equal(t, 0, len(replayer.replayed))
+
+	replayer.setErr(nil)
+	q.Replay()
+	require.Equal(t, 3, len(replayer.replayed))
+	for i, id := range []string{"a", "b", "c"} {
+		assert.Equal(t, id, string(replayer.replayed[i].id))
+	}
+	assert.Equal(t, int64(0), q.Size())
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, 0, len(files))
+}
+
+func TestWriteSpillQueueDropsInvalidWrites(t *testing.T) {
+	var replayed []string
+	replayFn := func(r writeSpillRecord) error {
+		if string(r.id) == "invalid" {
+			return errors.New("invalid write")
+		}
+		replayed = append(replayed, string(r.id))
+		return nil
+	}
+	q, dir := newTestWriteSpillQueue(t, 1<<20, replayFn)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, q.Open())
+	defer q.Close()
+
+	require.NoError(t, q.Spill(0, testWriteSpillRecord("invalid", 1)))
+	require.NoError(t, q.Spill(0, testWriteSpillRecord("valid", 2)))
+
+	q.Replay()
+	assert.Equal(t, []string{"valid"}, replayed)
+	assert.Equal(t, int64(0), q.Size())
+}
+
+func TestWriteSpillQueueFull(t *testing.T) {
+	record := testWriteSpillRecord("foo", 1)
+	size := int64(len(record.encode()))
+
+	q, dir := newTestWriteSpillQueue(t, 2*size, (&testWriteSpillReplayer{}).replay)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, q.Open())
+	defer q.Close()
+
+	require.NoError(t, q.Spill(0, record))
+	require.NoError(t, q.Spill(1, record))
+	assert.Equal(t, errWriteSpillQueueFull, q.Spill(2, record))
+	assert.Equal(t, 2*size, q.Size())
+}
+
+func TestWriteSpillQueueReopen(t *testing.T) {
+	replayer := &testWriteSpillReplayer{}
+	q, dir := newTestWriteSpillQueue(t, 1<<20, replayer.replay)
+	defer os.RemoveAll(dir)
+
+	require.NoError(t, q.Open())
+	require.NoError(t, q.Spill(3, testWriteSpillRecord("a", 1)))
+	size := q.Size()
+	q.Close()
+
+	q = newWriteSpillQueue(dir, 1<<20, time.Hour, replayer.replay,
+		tally.NoopScope, zap.NewNop())
+	require.NoError(t, q.Open())
+	defer q.Close()
+	assert.Equal(t, size, q.Size())
+
+	q.Replay()
+	require.Equal(t, 1, len(replayer.replayed))
+	assert.Equal(t, testWriteSpillRecord("a", 1), replayer.replayed[0])
+}
+
+func TestWriteSpillQueueDropsCorruptWrites(t *testing.T) {
+	replayer := &testWriteSpillReplayer{}
+	q, dir := newTestWriteSpillQueue(t, 1<<20, replayer.replay)
+	defer os.RemoveAll(dir)
+
+	data := testWriteSpillRecord("a", 1).encode()
+	data = append(data, testWriteSpillRecord("b", 2).encode()[:4]...)
+	require.NoError(t, ioutil.WriteFile(
+		filepath.Join(dir, "shard-5.spill"), data, 0644))
+
+	require.NoError(t, q.Open())
+	defer q.Close()
+
+	q.Replay()
+	require.Equal(t, 1, len(replayer.replayed))
+	assert.Equal(t, "a", string(replayer.replayed[0].id))
+	assert.Equal(t, int64(0), q.Size())
+}
+
+func TestSessionSpillWriteRoutesWithShardKey(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s, err := newSession(newSessionTestShardKeyOptions())
+	require.NoError(t, err)
+
+	// The session opens and closes the queue.
+	q, dir := newTestWriteSpillQueue(t, 1<<20, (&testWriteSpillReplayer{}).replay)
+	defer os.RemoveAll(dir)
+	session := s.(*session)
+	session.writeSpill = q
+
+	mockHostQueues(ctrl, session, sessionTestReplicas, nil)
+	require.NoError(t, s.Open())
+
+	var (
+		writeErr = errors.New("unavailable")
+		args     = writeAttemptArgs{
+			namespace:   ident.StringID("tenants"),
+			id:          ident.StringID("acme:cpu.user"),
+			t:           time.Unix(0, 1000),
+			value:       1,
+			unit:        xtime.Second,
+			attemptType: untaggedWriteAttemptType,
+		}
+	)
+	require.NoError(t, session.spillWrite(args, writeErr))
+
+	// Namespaces without a shard key strategy spill to the shard of the
+	// whole ID.
+	args.namespace = ident.StringID("other")
+	require.NoError(t, session.spillWrite(args, writeErr))
+
+	q.Lock()
+	shards := q.shards
+	q.Unlock()
+	require.Len(t, shards, 2)
+	assert.True(t, shards[0] > 0)
+	assert.True(t, shards[1] > 0)
+
+	assert.NoError(t, s.Close())
+}
@@ -25,6 +25,7 @@ import (
 	"sync"
 
 	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/x/serialize"
 	xerrors "github.com/m3db/m3/src/x/errors"
@@ -43,6 +44,13 @@ type writeOp interface {
 	Close()
 }
 
+// hostWriteResult is the result a write is completed with when the host
+// returned the disposition of the write.
+type hostWriteResult struct {
+	host        topology.Host
+	disposition rpc.WriteDisposition
+}
+
 type writeState struct {
 	sync.Cond
 	sync.Mutex
@@ -57,6 +65,7 @@ type writeState struct {
 	majority, pending int32
 	success           int32
 	errors            []error
+	dispositions      WriteDispositions
 
 	queues         []hostQueue
 	tagEncoderPool serialize.TagEncoderPool
@@ -93,6 +102,7 @@ func (w *writeState) close() {
 		w.errors[i] = nil
 	}
 	w.errors = w.errors[:0]
+	w.dispositions = nil
 
 	for i := range w.queues {
 		w.queues[i] = nil
@@ -106,11 +116,24 @@ func (w *writeState) close() {
 }
 
 func (w *writeState) completionFn(result interface{}, err error) {
-	hostID := result.(topology.Host).ID()
-	// NB(bl) panic on invalid result, it indicates a bug in the code
+	var (
+		host           topology.Host
+		disposition    rpc.WriteDisposition
+		hasDisposition bool
+	)
+	if r, ok := result.(hostWriteResult); ok {
+		host, disposition, hasDisposition = r.host, r.disposition, true
+	} else {
+		// NB(bl) panic on invalid result, it indicates a bug in the code
+		host = result.(topology.Host)
+	}
+	hostID := host.ID()
 
 	w.Lock()
 	w.pending--
+	if hasDisposition && w.dispositions != nil {
+		w.dispositions[hostID] = disposition
+	}
 
 	var wErr error
 
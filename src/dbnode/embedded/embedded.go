@@ -0,0 +1,401 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package embedded
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/commitlog"
+	bfs "github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/fs"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper/uninitialized"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	// DefaultNamespace is the name of the namespace created when no
+	// namespaces are specified.
+	DefaultNamespace = "default"
+
+	defaultRetentionPeriod = 48 * time.Hour
+	defaultBlockSize       = 2 * time.Hour
+
+	// The embedded database owns a single shard, all series hash to it.
+	embeddedShardID = 0
+)
+
+var embeddedBootstrappers = []string{
+	bfs.FileSystemBootstrapperName,
+	commitlog.CommitLogBootstrapperName,
+	uninitialized.UninitializedTopologyBootstrapperName,
+}
+
+type db struct {
+	db          storage.Database
+	contextPool context.Pool
+	writeUnit   xtime.Unit
+}
+
+// New creates, opens and bootstraps a single-node database. Data written is
+// persisted to the file path prefix and bootstrapped from the filesets and
+// commit logs found there when the database is next created.
+func New(opts Options) (DB, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	namespaces := opts.Namespaces()
+	if len(namespaces) == 0 {
+		md, err := newDefaultNamespace()
+		if err != nil {
+			return nil, err
+		}
+		namespaces = []namespace.Metadata{md}
+	}
+
+	shardSet, err := sharding.NewShardSet(
+		sharding.NewShards([]uint32{embeddedShardID}, shard.Available),
+		sharding.DefaultHashFn(1))
+	if err != nil {
+		return nil, err
+	}
+
+	origin := topology.NewHost(opts.HostID(), "")
+	topoMap := topology.NewStaticMap(topology.NewStaticOptions().
+		SetShardSet(shardSet).
+		SetReplicas(1).
+		SetHostShardSets([]topology.HostShardSet{
+			topology.NewHostShardSet(origin, shardSet),
+		}))
+
+	storageOpts, err := newStorageOptions(opts, namespaces, topoMap, origin)
+	if err != nil {
+		return nil, err
+	}
+
+	database, err := storage.NewDatabase(shardSet, storageOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	// Blocks are leased by the block retriever, the lease verifier needs
+	// the database to verify leases against.
+	leaseVerifier := storage.NewLeaseVerifier(database)
+	if err := storageOpts.BlockLeaseManager().SetLeaseVerifier(leaseVerifier); err != nil {
+		return nil, err
+	}
+
+	if err := database.Open(); err != nil {
+		return nil, fmt.Errorf("could not open database: %v", err)
+	}
+	if err := database.Bootstrap(); err != nil {
+		database.Close()
+		return nil, fmt.Errorf("could not bootstrap database: %v", err)
+	}
+
+	return &db{
+		db:          database,
+		contextPool: storageOpts.ContextPool(),
+		writeUnit:   opts.WriteUnit(),
+	}, nil
+}
+
+func newDefaultNamespace() (namespace.Metadata, error) {
+	return namespace.NewMetadata(ident.StringID(DefaultNamespace),
+		namespace.NewOptions().
+			SetRetentionOptions(retention.NewOptions().
+				SetRetentionPeriod(defaultRetentionPeriod).
+				SetBlockSize(defaultBlockSize)).
+			SetIndexOptions(namespace.NewIndexOptions().
+				SetEnabled(true).
+				SetBlockSize(defaultBlockSize)))
+}
+
+func newStorageOptions(
+	opts Options,
+	namespaces []namespace.Metadata,
+	topoMap topology.Map,
+	origin topology.Host,
+) (storage.Options, error) {
+	iopts := opts.InstrumentOptions()
+	storageOpts := opts.StorageOptions().
+		SetInstrumentOptions(iopts).
+		SetNamespaceInitializer(namespace.NewStaticInitializer(namespaces)).
+		SetRepairEnabled(false)
+
+	blockLeaseManager := block.NewLeaseManager(nil)
+	storageOpts = storageOpts.SetBlockLeaseManager(blockLeaseManager)
+
+	fsOpts := fs.NewOptions().
+		SetClockOptions(storageOpts.ClockOptions()).
+		SetInstrumentOptions(iopts).
+		SetRuntimeOptionsManager(storageOpts.RuntimeOptionsManager()).
+		SetFilePathPrefix(opts.FilePathPrefix())
+	storageOpts = storageOpts.SetCommitLogOptions(storageOpts.CommitLogOptions().
+		SetInstrumentOptions(iopts).
+		SetFilesystemOptions(fsOpts))
+
+	switch storageOpts.SeriesCachePolicy() {
+	case series.CacheAll:
+		// No block retriever needed, all series are kept in memory.
+	default:
+		retrieverOpts := fs.NewBlockRetrieverOptions().
+			SetBytesPool(storageOpts.BytesPool()).
+			SetRetrieveRequestPool(storageOpts.RetrieveRequestPool()).
+			SetIdentifierPool(storageOpts.IdentifierPool()).
+			SetBlockLeaseManager(blockLeaseManager)
+		blockRetrieverMgr := block.NewDatabaseBlockRetrieverManager(
+			func(md namespace.Metadata) (block.DatabaseBlockRetriever, error) {
+				retriever, err := fs.NewBlockRetriever(retrieverOpts, fsOpts)
+				if err != nil {
+					return nil, err
+				}
+				if err := retriever.Open(md); err != nil {
+					return nil, err
+				}
+				return retriever, nil
+			})
+		storageOpts = storageOpts.SetDatabaseBlockRetrieverManager(blockRetrieverMgr)
+	}
+
+	if storageOpts.SeriesCachePolicy() == series.CacheLRU &&
+		storageOpts.DatabaseBlockOptions().WiredList() == nil {
+		wiredList := block.NewWiredList(block.WiredListOptions{
+			RuntimeOptionsManager: storageOpts.RuntimeOptionsManager(),
+			InstrumentOptions:     iopts,
+			ClockOptions:          storageOpts.ClockOptions(),
+		})
+		blockOpts := storageOpts.DatabaseBlockOptions().SetWiredList(wiredList)
+		// The default block pool allocates blocks with the options it was
+		// created with, recreate it so blocks are aware of the wired list.
+		blockPool := block.NewDatabaseBlockPool(nil)
+		blockPool.Init(func() block.DatabaseBlock {
+			return block.NewDatabaseBlock(time.Time{}, 0, ts.Segment{}, blockOpts, namespace.Context{})
+		})
+		blockOpts = blockOpts.SetDatabaseBlockPool(blockPool)
+		storageOpts = storageOpts.SetDatabaseBlockOptions(blockOpts)
+	}
+
+	pm, err := fs.NewPersistManager(fsOpts)
+	if err != nil {
+		return nil, err
+	}
+	storageOpts = storageOpts.SetPersistManager(pm)
+
+	rsOpts := result.NewOptions().
+		SetInstrumentOptions(iopts).
+		SetDatabaseBlockOptions(storageOpts.DatabaseBlockOptions()).
+		SetSeriesCachePolicy(storageOpts.SeriesCachePolicy()).
+		SetIndexDocumentsBuilderAllocator(
+			index.NewBootstrapResultDocumentsBuilderAllocator(storageOpts.IndexOptions()))
+
+	// Only the filesystem and commit log are consulted, the shard is always
+	// available so the uninitialized bootstrapper never fulfills any ranges
+	// and is only present as the final bootstrapper.
+	bsCfg := config.BootstrapConfiguration{Bootstrappers: embeddedBootstrappers}
+	bs, err := bsCfg.New(config.NewBootstrapConfigurationValidator(),
+		rsOpts, storageOpts, staticMapProvider{topoMap: topoMap}, origin, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return storageOpts.SetBootstrapProcessProvider(bs), nil
+}
+
+type staticMapProvider struct {
+	topoMap topology.Map
+}
+
+func (p staticMapProvider) TopologyMap() (topology.Map, error) {
+	return p.topoMap, nil
+}
+
+func (d *db) Write(
+	namespace string,
+	id string,
+	tags map[string]string,
+	timestamp time.Time,
+	value float64,
+) error {
+	ctx := d.contextPool.Get()
+	defer ctx.BlockingClose()
+
+	nsID := ident.StringID(namespace)
+	seriesID := ident.StringID(id)
+	if len(tags) == 0 {
+		return d.db.Write(ctx, nsID, seriesID, timestamp, value, d.writeUnit, nil)
+	}
+
+	tagsIter := ident.NewTagsIterator(tagsFromMap(tags))
+	return d.db.WriteTagged(ctx, nsID, seriesID, tagsIter, timestamp, value,
+		d.writeUnit, nil)
+}
+
+func (d *db) Read(
+	namespace string,
+	id string,
+	start, end time.Time,
+) ([]ts.Datapoint, error) {
+	ctx := d.contextPool.Get()
+	defer ctx.BlockingClose()
+
+	return d.read(ctx, ident.StringID(namespace), ident.StringID(id), start, end)
+}
+
+func (d *db) Query(
+	namespace string,
+	query index.Query,
+	start, end time.Time,
+) ([]Series, error) {
+	ctx := d.contextPool.Get()
+	defer ctx.BlockingClose()
+
+	nsID := ident.StringID(namespace)
+	queryResult, err := d.db.QueryIDs(ctx, nsID, query, index.QueryOptions{
+		StartInclusive: start,
+		EndExclusive:   end,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := index.ResultsEntries(queryResult.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	matched := make([]Series, 0, len(entries))
+	for _, entry := range entries {
+		datapoints, err := d.read(ctx, nsID, entry.Key(), start, end)
+		if err != nil {
+			return nil, err
+		}
+
+		tags, err := tagsToMap(entry.Value().Duplicate())
+		if err != nil {
+			return nil, err
+		}
+
+		matched = append(matched, Series{
+			ID:         entry.Key().String(),
+			Tags:       tags,
+			Datapoints: datapoints,
+		})
+	}
+
+	// Results are backed by a map, sort to return series in a stable order.
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID < matched[j].ID
+	})
+
+	return matched, nil
+}
+
+func (d *db) read(
+	ctx context.Context,
+	nsID ident.ID,
+	id ident.ID,
+	start, end time.Time,
+) ([]ts.Datapoint, error) {
+	encoded, err := d.db.ReadEncoded(ctx, nsID, id, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve all futures (block reads can be backed by async implementations) and filter out any empty segments.
+	filtered, err := xio.FilterEmptyBlockReadersSliceOfSlicesInPlace(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	multiIt := d.db.Options().MultiReaderIteratorPool().Get()
+	nsCtx := namespace.NewContextFor(nsID, d.db.Options().SchemaRegistry())
+	multiIt.ResetSliceOfSlices(
+		xio.NewReaderSliceOfSlicesFromBlockReadersIterator(filtered), nsCtx.Schema)
+	defer multiIt.Close()
+
+	var datapoints []ts.Datapoint
+	for multiIt.Next() {
+		dp, _, _ := multiIt.Current()
+		datapoints = append(datapoints, dp)
+	}
+
+	if err := multiIt.Err(); err != nil {
+		return nil, err
+	}
+
+	return datapoints, nil
+}
+
+func (d *db) Database() storage.Database {
+	return d.db
+}
+
+func (d *db) Close() error {
+	return d.db.Close()
+}
+
+func tagsFromMap(m map[string]string) ident.Tags {
+	names := make([]string, 0, len(m))
+	for name := range m {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	tags := make([]ident.Tag, 0, len(names))
+	for _, name := range names {
+		tags = append(tags, ident.StringTag(name, m[name]))
+	}
+	return ident.NewTags(tags...)
+}
+
+func tagsToMap(tags ident.TagIterator) (map[string]string, error) {
+	defer tags.Close()
+
+	m := make(map[string]string, tags.Remaining())
+	for tags.Next() {
+		tag := tags.Current()
+		m[tag.Name.String()] = tag.Value.String()
+	}
+
+	if err := tags.Err(); err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package embedded
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/m3ninx/idx"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDB(t *testing.T, dir string) DB {
+	db, err := New(NewOptions().SetFilePathPrefix(dir))
+	require.NoError(t, err)
+	return db
+}
+
+func TestEmbeddedWriteReadQuery(t *testing.T) {
+	dir, err := ioutil.TempDir("", "embedded")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		now   = time.Now().Truncate(time.Second)
+		start = now.Add(-time.Minute)
+		end   = now.Add(time.Minute)
+		tags  = map[string]string{"city": "nyc", "host": "a"}
+	)
+
+	db := newTestDB(t, dir)
+	require.NoError(t, db.Write(DefaultNamespace, "foo", tags, now.Add(-2*time.Second), 1))
+	require.NoError(t, db.Write(DefaultNamespace, "foo", tags, now.Add(-time.Second), 2))
+	require.NoError(t, db.Write(DefaultNamespace, "bar", nil, now.Add(-time.Second), 3))
+
+	expected := []ts.Datapoint{
+		{Timestamp: now.Add(-2 * time.Second), Value: 1},
+		{Timestamp: now.Add(-time.Second), Value: 2},
+	}
+	datapoints, err := db.Read(DefaultNamespace, "foo", start, end)
+	require.NoError(t, err)
+	requireDatapointsEqual(t, expected, datapoints)
+
+	query := index.Query{Query: idx.NewTermQuery([]byte("city"), []byte("nyc"))}
+	series, err := db.Query(DefaultNamespace, query, start, end)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(series))
+	require.Equal(t, "foo", series[0].ID)
+	require.Equal(t, tags, series[0].Tags)
+	requireDatapointsEqual(t, expected, series[0].Datapoints)
+
+	// Untagged series are not indexed.
+	query = index.Query{Query: idx.NewAllQuery()}
+	series, err = db.Query(DefaultNamespace, query, start, end)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(series))
+
+	require.NoError(t, db.Close())
+
+	// Reopening bootstraps the writes from the commit log.
+	db = newTestDB(t, dir)
+	defer db.Close()
+
+	datapoints, err = db.Read(DefaultNamespace, "foo", start, end)
+	require.NoError(t, err)
+	requireDatapointsEqual(t, expected, datapoints)
+
+	datapoints, err = db.Read(DefaultNamespace, "bar", start, end)
+	require.NoError(t, err)
+	requireDatapointsEqual(t, []ts.Datapoint{
+		{Timestamp: now.Add(-time.Second), Value: 3},
+	}, datapoints)
+}
+
+func TestEmbeddedWriteUnknownNamespace(t *testing.T) {
+	dir, err := ioutil.TempDir("", "embedded")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db := newTestDB(t, dir)
+	defer db.Close()
+
+	require.Error(t, db.Write("unknown", "foo", nil, time.Now(), 1))
+}
+
+func TestOptionsValidate(t *testing.T) {
+	require.Equal(t, errNoFilePathPrefix, NewOptions().Validate())
+	require.NoError(t, NewOptions().SetFilePathPrefix("/tmp").Validate())
+	require.Equal(t, errNoHostID,
+		NewOptions().SetFilePathPrefix("/tmp").SetHostID("").Validate())
+}
+
+func requireDatapointsEqual(t *testing.T, expected, actual []ts.Datapoint) {
+	require.Equal(t, len(expected), len(actual))
+	for i := range expected {
+		require.True(t, expected[i].Equal(actual[i]),
+			"expected %v, actual %v", expected[i], actual[i])
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package embedded
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	defaultHostID    = "embedded"
+	defaultWriteUnit = xtime.Millisecond
+)
+
+var (
+	errNoFilePathPrefix    = errors.New("no file path prefix set")
+	errNoHostID            = errors.New("no host ID set")
+	errInvalidWriteUnit    = errors.New("invalid write unit")
+	errNoStorageOptions    = errors.New("no storage options set")
+	errNoInstrumentOptions = errors.New("no instrument options set")
+)
+
+type options struct {
+	filePathPrefix string
+	namespaces     []namespace.Metadata
+	hostID         string
+	writeUnit      xtime.Unit
+	instrumentOpts instrument.Options
+	storageOpts    storage.Options
+}
+
+// NewOptions creates new embedded database options.
+func NewOptions() Options {
+	return &options{
+		hostID:         defaultHostID,
+		writeUnit:      defaultWriteUnit,
+		instrumentOpts: instrument.NewOptions(),
+		storageOpts:    storage.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.filePathPrefix == "" {
+		return errNoFilePathPrefix
+	}
+	if o.hostID == "" {
+		return errNoHostID
+	}
+	if !o.writeUnit.IsValid() {
+		return errInvalidWriteUnit
+	}
+	if o.instrumentOpts == nil {
+		return errNoInstrumentOptions
+	}
+	if o.storageOpts == nil {
+		return errNoStorageOptions
+	}
+	return nil
+}
+
+func (o *options) SetFilePathPrefix(value string) Options {
+	opts := *o
+	opts.filePathPrefix = value
+	return &opts
+}
+
+func (o *options) FilePathPrefix() string {
+	return o.filePathPrefix
+}
+
+func (o *options) SetNamespaces(value []namespace.Metadata) Options {
+	opts := *o
+	opts.namespaces = value
+	return &opts
+}
+
+func (o *options) Namespaces() []namespace.Metadata {
+	return o.namespaces
+}
+
+func (o *options) SetHostID(value string) Options {
+	opts := *o
+	opts.hostID = value
+	return &opts
+}
+
+func (o *options) HostID() string {
+	return o.hostID
+}
+
+func (o *options) SetWriteUnit(value xtime.Unit) Options {
+	opts := *o
+	opts.writeUnit = value
+	return &opts
+}
+
+func (o *options) WriteUnit() xtime.Unit {
+	return o.writeUnit
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetStorageOptions(value storage.Options) Options {
+	opts := *o
+	opts.storageOpts = value
+	return &opts
+}
+
+func (o *options) StorageOptions() storage.Options {
+	return o.storageOpts
+}
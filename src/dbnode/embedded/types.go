@@ -0,0 +1,122 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package embedded runs a single-node database in-process with a static
+// single-shard placement and local filesystem persistence, for use in tests
+// and edge deployments where running a cluster is not warranted.
+package embedded
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/instrument"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// DB is a single-node database running in-process.
+type DB interface {
+	// Write writes a datapoint for a series, the series is indexed with the
+	// tags if any are specified and the namespace has indexing enabled.
+	Write(
+		namespace string,
+		id string,
+		tags map[string]string,
+		timestamp time.Time,
+		value float64,
+	) error
+
+	// Read returns the datapoints of a series within [start, end).
+	Read(
+		namespace string,
+		id string,
+		start, end time.Time,
+	) ([]ts.Datapoint, error)
+
+	// Query returns the series matching the query along with their
+	// datapoints within [start, end).
+	Query(
+		namespace string,
+		query index.Query,
+		start, end time.Time,
+	) ([]Series, error)
+
+	// Database returns the underlying database for operations not
+	// covered by the embedded API.
+	Database() storage.Database
+
+	// Close flushes the commit log and closes the database.
+	Close() error
+}
+
+// Series is a series returned from a query.
+type Series struct {
+	ID         string
+	Tags       map[string]string
+	Datapoints []ts.Datapoint
+}
+
+// Options are the options for an embedded database.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetFilePathPrefix sets the directory data and commit logs are stored in.
+	SetFilePathPrefix(value string) Options
+
+	// FilePathPrefix returns the directory data and commit logs are stored in.
+	FilePathPrefix() string
+
+	// SetNamespaces sets the namespaces of the database, a single indexed
+	// namespace named "default" is created if none are set.
+	SetNamespaces(value []namespace.Metadata) Options
+
+	// Namespaces returns the namespaces of the database.
+	Namespaces() []namespace.Metadata
+
+	// SetHostID sets the host ID of the node.
+	SetHostID(value string) Options
+
+	// HostID returns the host ID of the node.
+	HostID() string
+
+	// SetWriteUnit sets the time unit datapoints are written with.
+	SetWriteUnit(value xtime.Unit) Options
+
+	// WriteUnit returns the time unit datapoints are written with.
+	WriteUnit() xtime.Unit
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetStorageOptions sets the base storage options, the embedded
+	// database overrides the namespace, persistence, repair and
+	// bootstrap related options.
+	SetStorageOptions(value storage.Options) Options
+
+	// StorageOptions returns the base storage options.
+	StorageOptions() storage.Options
+}
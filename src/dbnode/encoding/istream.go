@@ -23,7 +23,6 @@ package encoding
 import (
 	"bufio"
 	"io"
-	"math"
 )
 
 // istream encapsulates a readable stream.
@@ -81,6 +80,16 @@ func (is *istream) ReadByte() (byte, error) {
 		return 0, is.err
 	}
 	remaining := is.remaining
+	if remaining == 0 {
+		// Aligned on a byte boundary, read straight from the underlying reader
+		// rather than loading the byte into the buffer and consuming it again.
+		b, err := is.r.ReadByte()
+		if err != nil {
+			is.err = err
+			return 0, err
+		}
+		return b, nil
+	}
 	res := is.consumeBuffer(remaining)
 	if remaining == 8 {
 		return res, nil
@@ -98,35 +107,35 @@ func (is *istream) ReadBits(numBits int) (uint64, error) {
 		return 0, is.err
 	}
 
-	var res uint64
+	// Fast path for reads that can be satisfied entirely by the current byte,
+	// which is the common case for control bits and small deltas.
+	if numBits <= is.remaining {
+		return uint64(is.consumeBuffer(numBits)), nil
+	}
+
+	// Drain whatever is left of the current byte so that the remainder of the
+	// read is aligned on a byte boundary and whole bytes can be read without
+	// any shifting of the buffered byte.
+	numBits -= is.remaining
+	res := uint64(is.consumeBuffer(is.remaining))
 	for numBits >= 8 {
-		byteRead, err := is.ReadByte()
+		b, err := is.r.ReadByte()
 		if err != nil {
+			is.err = err
 			return 0, err
 		}
-		res = (res << 8) | uint64(byteRead)
+		res = (res << 8) | uint64(b)
 		numBits -= 8
 	}
 
-	for numBits > 0 {
-		// This is equivalent to calling is.ReadBit() in a loop but some manual inlining
-		// has been performed to optimize this loop as its heavily used in the hot path.
-		if is.remaining == 0 {
-			if err := is.readByteFromStream(); err != nil {
-				return 0, err
-			}
-		}
+	if numBits == 0 {
+		return res, nil
+	}
 
-		numToRead := numBits
-		if is.remaining < numToRead {
-			numToRead = is.remaining
-		}
-		bits := is.current >> uint(8-numToRead)
-		is.current <<= uint(numToRead)
-		is.remaining -= numToRead
-		res = (res << uint64(numToRead)) | uint64(bits)
-		numBits -= numToRead
+	if err := is.readByteFromStream(); err != nil {
+		return 0, err
 	}
+	res = (res << uint(numBits)) | uint64(is.consumeBuffer(numBits))
 	return res, nil
 }
 
@@ -142,7 +151,7 @@ func (is *istream) PeekBits(numBits int) (uint64, error) {
 	// now check the bytes buffered and read more if necessary.
 	numBitsRead := is.remaining
 	res := uint64(readBitsInByte(is.current, is.remaining))
-	numBytesToRead := int(math.Ceil(float64(numBits-numBitsRead) / 8))
+	numBytesToRead := (numBits - numBitsRead + 7) / 8
 	bytesRead, err := is.r.Peek(numBytesToRead)
 	if err != nil {
 		return 0, err
@@ -123,3 +123,68 @@ func TestResetIStream(t *testing.T) {
 	require.Equal(t, byte(0), is.current)
 	require.Equal(t, 0, is.remaining)
 }
+
+func TestReadBitsUnaligned(t *testing.T) {
+	byteStream := make([]byte, 64)
+	for i := range byteStream {
+		byteStream[i] = byte(i*37 + 11)
+	}
+
+	// Read every width from 1 to 64 bits at every bit offset within the first
+	// byte and compare against reading the same bits one at a time.
+	for offset := 0; offset < 8; offset++ {
+		for numBits := 1; numBits <= 64; numBits++ {
+			expectedStream := NewIStream(bytes.NewReader(byteStream), 16)
+			actualStream := NewIStream(bytes.NewReader(byteStream), 16)
+
+			_, err := expectedStream.ReadBits(offset)
+			require.NoError(t, err)
+			_, err = actualStream.ReadBits(offset)
+			require.NoError(t, err)
+
+			var expected uint64
+			for i := 0; i < numBits; i++ {
+				bit, err := expectedStream.ReadBit()
+				require.NoError(t, err)
+				expected = (expected << 1) | uint64(bit)
+			}
+
+			actual, err := actualStream.ReadBits(numBits)
+			require.NoError(t, err)
+			require.Equal(t, expected, actual, "offset=%d numBits=%d", offset, numBits)
+			require.Equal(t, expectedStream.RemainingBitsInCurrentByte(),
+				actualStream.RemainingBitsInCurrentByte())
+		}
+	}
+}
+
+func BenchmarkReadBits(b *testing.B) {
+	byteStream := make([]byte, 4096)
+	for i := range byteStream {
+		byteStream[i] = byte(i)
+	}
+
+	var (
+		reader  = bytes.NewReader(byteStream)
+		is      = NewIStream(reader, 4096)
+		widths  = []int{1, 2, 7, 12, 17, 32, 64}
+		numBits = 0
+	)
+	for _, w := range widths {
+		numBits += w
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		reader.Reset(byteStream)
+		is.Reset(reader)
+		for read := 0; read+numBits <= len(byteStream)*8; read += numBits {
+			for _, w := range widths {
+				if _, err := is.ReadBits(w); err != nil {
+					b.Fatal(err)
+				}
+			}
+		}
+	}
+}
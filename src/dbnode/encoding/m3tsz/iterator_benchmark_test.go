@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package m3tsz
+
+import (
+	"bytes"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const benchmarkNumPoints = 720
+
+func BenchmarkReaderIteratorCounters(b *testing.B) {
+	benchmarkReaderIterator(b, generateCounterDatapoints(benchmarkNumPoints, time.Second), true)
+}
+
+func BenchmarkReaderIteratorTimers(b *testing.B) {
+	benchmarkReaderIterator(b, generateTimerDatapoints(benchmarkNumPoints, time.Second), true)
+}
+
+func BenchmarkReaderIteratorPreciseFloats(b *testing.B) {
+	benchmarkReaderIterator(b, generatePreciseFloatDatapoints(benchmarkNumPoints, time.Second), false)
+}
+
+func BenchmarkReaderIteratorMixed(b *testing.B) {
+	benchmarkReaderIterator(b, generateMixedDatapoints(benchmarkNumPoints, time.Second), true)
+}
+
+func benchmarkReaderIterator(b *testing.B, dps []ts.Datapoint, intOpt bool) {
+	raw := encodeBenchmarkDatapoints(b, dps, intOpt)
+	it := NewReaderIterator(bytes.NewReader(raw), intOpt, encoding.NewOptions())
+	reader := bytes.NewReader(nil)
+
+	b.ReportAllocs()
+	b.SetBytes(int64(len(raw)))
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		reader.Reset(raw)
+		it.Reset(reader, nil)
+		for it.Next() {
+			_, _, _ = it.Current()
+		}
+		if err := it.Err(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func encodeBenchmarkDatapoints(b *testing.B, dps []ts.Datapoint, intOpt bool) []byte {
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	encoder := NewEncoder(testStartTime, nil, intOpt, nil)
+	for _, dp := range dps {
+		require.NoError(b, encoder.Encode(dp, xtime.Second, nil))
+	}
+
+	stream, ok := encoder.Stream(ctx)
+	require.True(b, ok)
+
+	raw, err := ioutil.ReadAll(stream)
+	require.NoError(b, err)
+	return raw
+}
@@ -244,7 +244,7 @@ func (it *TimestampIterator) readDeltaOfDelta(
 	for i := 0; i < len(buckets); i++ {
 		nextCB, err := stream.ReadBits(1)
 		if err != nil {
-			return 0, nil
+			return 0, err
 		}
 
 		cb = (cb << 1) | nextCB
@@ -257,7 +257,7 @@ func (it *TimestampIterator) readDeltaOfDelta(
 			dod := encoding.SignExtend(dodBits, buckets[i].NumValueBits())
 			timeUnit, err := it.TimeUnit.Value()
 			if err != nil {
-				return 0, nil
+				return 0, err
 			}
 
 			return xtime.FromNormalizedDuration(dod, timeUnit), nil
@@ -273,7 +273,7 @@ func (it *TimestampIterator) readDeltaOfDelta(
 	dod := encoding.SignExtend(dodBits, numValueBits)
 	timeUnit, err := it.TimeUnit.Value()
 	if err != nil {
-		return 0, nil
+		return 0, err
 	}
 
 	return xtime.FromNormalizedDuration(dod, timeUnit), nil
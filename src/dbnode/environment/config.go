@@ -24,6 +24,8 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
+	"strings"
 	"time"
 
 	clusterclient "github.com/m3db/m3/src/cluster/client"
@@ -39,6 +41,10 @@ import (
 	"github.com/m3db/m3/src/x/instrument"
 )
 
+const (
+	defaultLocalCacheInitTimeout = 30 * time.Second
+)
+
 var (
 	errInvalidConfig    = errors.New("must supply either service or static config")
 	errInvalidSyncCount = errors.New("must supply exactly one synchronous cluster")
@@ -93,12 +99,42 @@ type DynamicCluster struct {
 	Async           bool                      `yaml:"async"`
 	ClientOverrides ClientOverrides           `yaml:"clientOverrides"`
 	Service         *etcdclient.Configuration `yaml:"service"`
+	LocalCache      *LocalCacheConfiguration  `yaml:"localCache"`
+}
+
+// LocalCacheConfiguration is the configuration for persisting the last
+// received placement and namespace registry to local disk so that a node
+// can start with them while etcd is unavailable.
+type LocalCacheConfiguration struct {
+	// Dir is the directory the placement and namespace registry are
+	// persisted to.
+	Dir string `yaml:"dir" validate:"nonzero"`
+
+	// InitTimeout is how long to wait for etcd on start up before using
+	// the persisted placement and namespace registry.
+	InitTimeout *time.Duration `yaml:"initTimeout"`
+}
+
+// InitTimeoutOrDefault returns the configured init timeout or the default.
+func (c LocalCacheConfiguration) InitTimeoutOrDefault() time.Duration {
+	if c.InitTimeout != nil {
+		return *c.InitTimeout
+	}
+	return defaultLocalCacheInitTimeout
+}
+
+// Path returns the path of the file to persist a value for a service to.
+func (c LocalCacheConfiguration) Path(service *etcdclient.Configuration, name string) string {
+	fileName := strings.Join([]string{service.Env, service.Service, service.Zone, name}, "_")
+	fileName = strings.Replace(fileName, string(os.PathSeparator), "_", -1)
+	return filepath.Join(c.Dir, fileName+".json")
 }
 
 // ClientOverrides represents M3DB client overrides for a given cluster.
 type ClientOverrides struct {
-	HostQueueFlushInterval   *time.Duration `yaml:"hostQueueFlushInterval"`
-	TargetHostQueueFlushSize *int           `yaml:"targetHostQueueFlushSize"`
+	HostQueueFlushInterval   *time.Duration             `yaml:"hostQueueFlushInterval"`
+	TargetHostQueueFlushSize *int                       `yaml:"targetHostQueueFlushSize"`
+	WriteConsistencyLevel    *topology.ConsistencyLevel `yaml:"writeConsistencyLevel"`
 }
 
 // Validate validates the DynamicConfiguration.
@@ -203,11 +239,12 @@ type ConfigurationParameters struct {
 // UnmarshalYAML normalizes the config into a list of services.
 func (c *Configuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	var cfg struct {
-		Services  DynamicConfiguration      `yaml:"services"`
-		Service   *etcdclient.Configuration `yaml:"service"`
-		Static    *StaticCluster            `yaml:"static"`
-		Statics   StaticConfiguration       `yaml:"statics"`
-		SeedNodes *SeedNodesConfig          `yaml:"seedNodes"`
+		Services   DynamicConfiguration      `yaml:"services"`
+		Service    *etcdclient.Configuration `yaml:"service"`
+		LocalCache *LocalCacheConfiguration  `yaml:"localCache"`
+		Static     *StaticCluster            `yaml:"static"`
+		Statics    StaticConfiguration       `yaml:"statics"`
+		SeedNodes  *SeedNodesConfig          `yaml:"seedNodes"`
 	}
 
 	if err := unmarshal(&cfg); err != nil {
@@ -222,7 +259,7 @@ func (c *Configuration) UnmarshalYAML(unmarshal func(interface{}) error) error {
 	c.Services = cfg.Services
 	if cfg.Service != nil {
 		c.Services = DynamicConfiguration{
-			&DynamicCluster{Service: cfg.Service},
+			&DynamicCluster{Service: cfg.Service, LocalCache: cfg.LocalCache},
 		}
 	}
 
@@ -295,7 +332,13 @@ func (c Configuration) configureDynamic(cfgParams ConfigurationParameters) (Conf
 		dynamicOpts := namespace.NewDynamicOptions().
 			SetInstrumentOptions(cfgParams.InstrumentOpts).
 			SetConfigServiceClient(configSvcClient).
-			SetNamespaceRegistryKey(kvconfig.NamespacesKey)
+			SetNamespaceRegistryKey(kvconfig.NamespacesKey).
+			SetHostID(cfgParams.HostID)
+		if localCache := cluster.LocalCache; localCache != nil {
+			dynamicOpts = dynamicOpts.
+				SetInitTimeout(localCache.InitTimeoutOrDefault()).
+				SetLocalCachePath(localCache.Path(cluster.Service, "namespace_registry"))
+		}
 		nsInit := namespace.NewDynamicInitializer(dynamicOpts)
 
 		serviceID := services.NewServiceID().
@@ -309,6 +352,11 @@ func (c Configuration) configureDynamic(cfgParams ConfigurationParameters) (Conf
 			SetQueryOptions(services.NewQueryOptions().SetIncludeUnhealthy(true)).
 			SetInstrumentOptions(cfgParams.InstrumentOpts).
 			SetHashGen(sharding.NewHashGenWithSeed(cfgParams.HashingSeed))
+		if localCache := cluster.LocalCache; localCache != nil {
+			topoOpts = topoOpts.
+				SetInitTimeout(localCache.InitTimeoutOrDefault()).
+				SetLocalCachePath(localCache.Path(cluster.Service, "placement"))
+		}
 		topoInit := topology.NewDynamicInitializer(topoOpts)
 
 		kv, err := configSvcClient.KV()
@@ -364,8 +412,16 @@ func (c Configuration) configureStatic(cfgParams ConfigurationParameters) (Confi
 		numHosts := len(cluster.TopologyConfig.Hosts)
 		numReplicas := cluster.TopologyConfig.Replicas
 
-		switch numReplicas {
-		case 0:
+		switch {
+		case hasShardAssignments(cluster.TopologyConfig.Hosts):
+			// With explicit shard assignments hosts own a subset of the
+			// shards, the replicas of each shard are checked when the
+			// topology options are validated.
+			if numReplicas == 0 {
+				numReplicas = 1
+			}
+			staticOptions = staticOptions.SetReplicas(numReplicas)
+		case numReplicas == 0:
 			if numHosts != 1 {
 				err := fmt.Errorf("number of hosts (%d) must be 1 if replicas is not set", numHosts)
 				return emptyConfig, err
@@ -380,6 +436,15 @@ func (c Configuration) configureStatic(cfgParams ConfigurationParameters) (Confi
 		}
 
 		topoInit := topology.NewStaticInitializer(staticOptions)
+		if dnsCfg := cluster.TopologyConfig.DNS; dnsCfg != nil {
+			dnsOptions := topology.NewDNSOptions().
+				SetStaticOptions(staticOptions).
+				SetInstrumentOptions(cfgParams.InstrumentOpts)
+			if dnsCfg.RefreshInterval > 0 {
+				dnsOptions = dnsOptions.SetRefreshInterval(dnsCfg.RefreshInterval)
+			}
+			topoInit = topology.NewDNSInitializer(dnsOptions)
+		}
 		result := ConfigureResult{
 			NamespaceInitializer: nsInitStatic,
 			TopologyInitializer:  topoInit,
@@ -412,10 +477,33 @@ func newStaticShardSet(numShards int, hosts []topology.HostShardConfig) (shardin
 	}
 
 	for _, i := range hosts {
-		host := topology.NewHost(i.HostID, i.ListenAddress)
-		hostShardSet := topology.NewHostShardSet(host, shardSet)
+		host := topology.NewHostWithIsolationGroup(i.HostID, i.ListenAddress, i.IsolationGroup)
+		hostShards := shardSet
+		if len(i.Shards) > 0 {
+			for _, id := range i.Shards {
+				if id >= uint32(numShards) {
+					return nil, nil, fmt.Errorf("host %s assigned shard %d, must be less than %d",
+						i.HostID, id, numShards)
+				}
+			}
+			shards := sharding.NewShards(i.Shards, shard.Available)
+			hostShards, err = sharding.NewShardSet(shards, shardSet.HashFn())
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		hostShardSet := topology.NewHostShardSet(host, hostShards)
 		hostShardSets = append(hostShardSets, hostShardSet)
 	}
 
 	return shardSet, hostShardSets, nil
 }
+
+func hasShardAssignments(hosts []topology.HostShardConfig) bool {
+	for _, host := range hosts {
+		if len(host.Shards) > 0 {
+			return true
+		}
+	}
+	return false
+}
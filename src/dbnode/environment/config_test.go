@@ -32,6 +32,7 @@ import (
 	"github.com/m3db/m3/src/x/instrument"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v2"
 )
 
@@ -76,6 +77,79 @@ func TestConfigureStatic(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestConfigureStaticShardAssignmentsWithDNS(t *testing.T) {
+	config := Configuration{
+		Statics: StaticConfiguration{
+			&StaticCluster{
+				Namespaces: []namespace.MetadataConfiguration{
+					namespace.MetadataConfiguration{
+						ID: "metrics",
+						Retention: retention.Configuration{
+							RetentionPeriod: 24 * time.Hour,
+							BlockSize:       time.Hour,
+						},
+					},
+				},
+				TopologyConfig: &topology.StaticConfiguration{
+					Shards:   4,
+					Replicas: 2,
+					Hosts: []topology.HostShardConfig{
+						topology.HostShardConfig{
+							HostID:        "a",
+							ListenAddress: "127.0.0.1:9000",
+							Shards:        []uint32{0, 1, 2, 3},
+						},
+						topology.HostShardConfig{
+							HostID:        "b",
+							ListenAddress: "127.0.0.2:9000",
+							Shards:        []uint32{0, 1},
+						},
+						topology.HostShardConfig{
+							HostID:        "c",
+							ListenAddress: "127.0.0.3:9000",
+							Shards:        []uint32{2, 3},
+						},
+					},
+					DNS: &topology.DNSConfiguration{
+						RefreshInterval: time.Minute,
+					},
+				},
+			},
+		},
+	}
+
+	configRes, err := config.Configure(ConfigurationParameters{
+		InstrumentOpts: instrument.NewOptions(),
+	})
+	require.NoError(t, err)
+	require.Len(t, configRes, 1)
+
+	topo, err := configRes[0].TopologyInitializer.Init()
+	require.NoError(t, err)
+	defer topo.Close()
+
+	m := topo.Get()
+	assert.Equal(t, 3, m.HostsLen())
+	assert.Equal(t, 2, m.Replicas())
+	b, ok := m.LookupHostShardSet("b")
+	require.True(t, ok)
+	assert.Equal(t, []uint32{0, 1}, b.ShardSet().AllIDs())
+
+	// Shards outside of the shard count are rejected.
+	config.Statics[0].TopologyConfig.Hosts[2].Shards = []uint32{2, 4}
+	_, err = config.Configure(ConfigurationParameters{})
+	require.Error(t, err)
+
+	// Shards without enough replicas are rejected on init.
+	config.Statics[0].TopologyConfig.Hosts[2].Shards = []uint32{2}
+	configRes, err = config.Configure(ConfigurationParameters{
+		InstrumentOpts: instrument.NewOptions(),
+	})
+	require.NoError(t, err)
+	_, err = configRes[0].TopologyInitializer.Init()
+	require.Error(t, err)
+}
+
 func TestConfigureDynamic(t *testing.T) {
 	config := Configuration{
 		Services: DynamicConfiguration{
@@ -122,6 +196,32 @@ service:
 	assert.Len(t, cfg.Services, 1)
 }
 
+func TestUnmarshalDynamicSingleLocalCache(t *testing.T) {
+	in := `
+service:
+  zone: dca8
+  env: test
+  service: m3db
+localCache:
+  dir: /var/lib/m3db/cache
+  initTimeout: 10s
+`
+
+	var cfg Configuration
+	err := yaml.Unmarshal([]byte(in), &cfg)
+	require.NoError(t, err)
+	require.Len(t, cfg.Services, 1)
+
+	cluster := cfg.Services[0]
+	require.NotNil(t, cluster.LocalCache)
+	assert.Equal(t, 10*time.Second, cluster.LocalCache.InitTimeoutOrDefault())
+	assert.Equal(t, "/var/lib/m3db/cache/test_m3db_dca8_placement.json",
+		cluster.LocalCache.Path(cluster.Service, "placement"))
+
+	assert.Equal(t, defaultLocalCacheInitTimeout,
+		LocalCacheConfiguration{}.InitTimeoutOrDefault())
+}
+
 func TestUnmarshalDynamicList(t *testing.T) {
 	in := `
 services:
@@ -32,6 +32,18 @@
 		RetentionOptions
 		IndexOptions
 		NamespaceOptions
+		RetentionTier
+		ArchivalOptions
+		CompactionOptions
+		FutureWriteOptions
+		ExpiryDownsampleOptions
+		RelabelRule
+		RelabelOptions
+		MirrorMatcher
+		MirrorOptions
+		ReshardOptions
+		QueryLimitsOptions
+		ValidationOptions
 		Registry
 		SchemaOptions
 		SchemaHistory
@@ -43,6 +55,8 @@ import proto "github.com/gogo/protobuf/proto"
 import fmt "fmt"
 import math "math"
 
+import binary "encoding/binary"
+
 import io "io"
 
 // Reference imports to suppress errors if they are not otherwise used.
@@ -56,6 +70,81 @@ var _ = math.Inf
 // proto package needs to be updated.
 const _ = proto.GoGoProtoPackageIsVersion2 // please upgrade the proto package
 
+type FutureWriteAction int32
+
+const (
+	FutureWriteAction_REJECT FutureWriteAction = 0
+	FutureWriteAction_CLAMP  FutureWriteAction = 1
+)
+
+var FutureWriteAction_name = map[int32]string{
+	0: "REJECT",
+	1: "CLAMP",
+}
+var FutureWriteAction_value = map[string]int32{
+	"REJECT": 0,
+	"CLAMP":  1,
+}
+
+func (x FutureWriteAction) String() string {
+	return proto.EnumName(FutureWriteAction_name, int32(x))
+}
+func (FutureWriteAction) EnumDescriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{0} }
+
+type RelabelAction int32
+
+const (
+	RelabelAction_DROP   RelabelAction = 0
+	RelabelAction_RENAME RelabelAction = 1
+	RelabelAction_HASH   RelabelAction = 2
+	RelabelAction_ADD    RelabelAction = 3
+)
+
+var RelabelAction_name = map[int32]string{
+	0: "DROP",
+	1: "RENAME",
+	2: "HASH",
+	3: "ADD",
+}
+var RelabelAction_value = map[string]int32{
+	"DROP":   0,
+	"RENAME": 1,
+	"HASH":   2,
+	"ADD":    3,
+}
+
+func (x RelabelAction) String() string {
+	return proto.EnumName(RelabelAction_name, int32(x))
+}
+func (RelabelAction) EnumDescriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{1} }
+
+type WriteDurability int32
+
+const (
+	WriteDurability_DEFAULT           WriteDurability = 0
+	WriteDurability_MEMORY            WriteDurability = 1
+	WriteDurability_COMMIT_LOG_BUFFER WriteDurability = 2
+	WriteDurability_FSYNC             WriteDurability = 3
+)
+
+var WriteDurability_name = map[int32]string{
+	0: "DEFAULT",
+	1: "MEMORY",
+	2: "COMMIT_LOG_BUFFER",
+	3: "FSYNC",
+}
+var WriteDurability_value = map[string]int32{
+	"DEFAULT":           0,
+	"MEMORY":            1,
+	"COMMIT_LOG_BUFFER": 2,
+	"FSYNC":             3,
+}
+
+func (x WriteDurability) String() string {
+	return proto.EnumName(WriteDurability_name, int32(x))
+}
+func (WriteDurability) EnumDescriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{2} }
+
 type RetentionOptions struct {
 	RetentionPeriodNanos                     int64 `protobuf:"varint,1,opt,name=retentionPeriodNanos,proto3" json:"retentionPeriodNanos,omitempty"`
 	BlockSizeNanos                           int64 `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
@@ -145,16 +234,29 @@ func (m *IndexOptions) GetBlockSizeNanos() int64 {
 }
 
 type NamespaceOptions struct {
-	BootstrapEnabled  bool              `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
-	FlushEnabled      bool              `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
-	WritesToCommitLog bool              `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
-	CleanupEnabled    bool              `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
-	RepairEnabled     bool              `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
-	RetentionOptions  *RetentionOptions `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
-	SnapshotEnabled   bool              `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
-	IndexOptions      *IndexOptions     `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
-	SchemaOptions     *SchemaOptions    `protobuf:"bytes,9,opt,name=schemaOptions" json:"schemaOptions,omitempty"`
-	ColdWritesEnabled bool              `protobuf:"varint,10,opt,name=coldWritesEnabled,proto3" json:"coldWritesEnabled,omitempty"`
+	BootstrapEnabled        bool                     `protobuf:"varint,1,opt,name=bootstrapEnabled,proto3" json:"bootstrapEnabled,omitempty"`
+	FlushEnabled            bool                     `protobuf:"varint,2,opt,name=flushEnabled,proto3" json:"flushEnabled,omitempty"`
+	WritesToCommitLog       bool                     `protobuf:"varint,3,opt,name=writesToCommitLog,proto3" json:"writesToCommitLog,omitempty"`
+	CleanupEnabled          bool                     `protobuf:"varint,4,opt,name=cleanupEnabled,proto3" json:"cleanupEnabled,omitempty"`
+	RepairEnabled           bool                     `protobuf:"varint,5,opt,name=repairEnabled,proto3" json:"repairEnabled,omitempty"`
+	RetentionOptions        *RetentionOptions        `protobuf:"bytes,6,opt,name=retentionOptions" json:"retentionOptions,omitempty"`
+	SnapshotEnabled         bool                     `protobuf:"varint,7,opt,name=snapshotEnabled,proto3" json:"snapshotEnabled,omitempty"`
+	IndexOptions            *IndexOptions            `protobuf:"bytes,8,opt,name=indexOptions" json:"indexOptions,omitempty"`
+	SchemaOptions           *SchemaOptions           `protobuf:"bytes,9,opt,name=schemaOptions" json:"schemaOptions,omitempty"`
+	ColdWritesEnabled       bool                     `protobuf:"varint,10,opt,name=coldWritesEnabled,proto3" json:"coldWritesEnabled,omitempty"`
+	RetentionTiers          []*RetentionTier         `protobuf:"bytes,11,rep,name=retentionTiers" json:"retentionTiers,omitempty"`
+	ArchivalOptions         *ArchivalOptions         `protobuf:"bytes,12,opt,name=archivalOptions" json:"archivalOptions,omitempty"`
+	FutureWriteOptions      *FutureWriteOptions      `protobuf:"bytes,13,opt,name=futureWriteOptions" json:"futureWriteOptions,omitempty"`
+	ExpiryDownsampleOptions *ExpiryDownsampleOptions `protobuf:"bytes,14,opt,name=expiryDownsampleOptions" json:"expiryDownsampleOptions,omitempty"`
+	InMemory                bool                     `protobuf:"varint,15,opt,name=inMemory,proto3" json:"inMemory,omitempty"`
+	ShardKeyStrategy        string                   `protobuf:"bytes,16,opt,name=shardKeyStrategy,proto3" json:"shardKeyStrategy,omitempty"`
+	RelabelOptions          *RelabelOptions          `protobuf:"bytes,17,opt,name=relabelOptions" json:"relabelOptions,omitempty"`
+	MirrorOptions           *MirrorOptions           `protobuf:"bytes,18,opt,name=mirrorOptions" json:"mirrorOptions,omitempty"`
+	ReshardOptions          *ReshardOptions          `protobuf:"bytes,19,opt,name=reshardOptions" json:"reshardOptions,omitempty"`
+	QueryLimitsOptions      *QueryLimitsOptions      `protobuf:"bytes,20,opt,name=queryLimitsOptions" json:"queryLimitsOptions,omitempty"`
+	WriteDurability         WriteDurability          `protobuf:"varint,21,opt,name=writeDurability,proto3,enum=namespace.WriteDurability" json:"writeDurability,omitempty"`
+	ValidationOptions       *ValidationOptions       `protobuf:"bytes,22,opt,name=validationOptions" json:"validationOptions,omitempty"`
+	CompactionOptions       *CompactionOptions       `protobuf:"bytes,23,opt,name=compactionOptions" json:"compactionOptions,omitempty"`
 }
 
 func (m *NamespaceOptions) Reset()                    { *m = NamespaceOptions{} }
@@ -232,403 +334,3254 @@ func (m *NamespaceOptions) GetColdWritesEnabled() bool {
 	return false
 }
 
-type Registry struct {
-	Namespaces map[string]*NamespaceOptions `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
+func (m *NamespaceOptions) GetRetentionTiers() []*RetentionTier {
+	if m != nil {
+		return m.RetentionTiers
+	}
+	return nil
 }
 
-func (m *Registry) Reset()                    { *m = Registry{} }
-func (m *Registry) String() string            { return proto.CompactTextString(m) }
-func (*Registry) ProtoMessage()               {}
-func (*Registry) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{3} }
-
-func (m *Registry) GetNamespaces() map[string]*NamespaceOptions {
+func (m *NamespaceOptions) GetArchivalOptions() *ArchivalOptions {
 	if m != nil {
-		return m.Namespaces
+		return m.ArchivalOptions
 	}
 	return nil
 }
 
-func init() {
-	proto.RegisterType((*RetentionOptions)(nil), "namespace.RetentionOptions")
-	proto.RegisterType((*IndexOptions)(nil), "namespace.IndexOptions")
-	proto.RegisterType((*NamespaceOptions)(nil), "namespace.NamespaceOptions")
-	proto.RegisterType((*Registry)(nil), "namespace.Registry")
+func (m *NamespaceOptions) GetFutureWriteOptions() *FutureWriteOptions {
+	if m != nil {
+		return m.FutureWriteOptions
+	}
+	return nil
 }
-func (m *RetentionOptions) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+
+func (m *NamespaceOptions) GetExpiryDownsampleOptions() *ExpiryDownsampleOptions {
+	if m != nil {
+		return m.ExpiryDownsampleOptions
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *RetentionOptions) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.RetentionPeriodNanos != 0 {
-		dAtA[i] = 0x8
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionPeriodNanos))
+func (m *NamespaceOptions) GetInMemory() bool {
+	if m != nil {
+		return m.InMemory
 	}
-	if m.BlockSizeNanos != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
+	return false
+}
+
+func (m *NamespaceOptions) GetShardKeyStrategy() string {
+	if m != nil {
+		return m.ShardKeyStrategy
 	}
-	if m.BufferFutureNanos != 0 {
-		dAtA[i] = 0x18
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.BufferFutureNanos))
+	return ""
+}
+
+func (m *NamespaceOptions) GetRelabelOptions() *RelabelOptions {
+	if m != nil {
+		return m.RelabelOptions
 	}
-	if m.BufferPastNanos != 0 {
-		dAtA[i] = 0x20
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.BufferPastNanos))
+	return nil
+}
+
+func (m *NamespaceOptions) GetMirrorOptions() *MirrorOptions {
+	if m != nil {
+		return m.MirrorOptions
 	}
-	if m.BlockDataExpiry {
-		dAtA[i] = 0x28
-		i++
-		if m.BlockDataExpiry {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return nil
+}
+
+func (m *NamespaceOptions) GetReshardOptions() *ReshardOptions {
+	if m != nil {
+		return m.ReshardOptions
 	}
-	if m.BlockDataExpiryAfterNotAccessPeriodNanos != 0 {
-		dAtA[i] = 0x30
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockDataExpiryAfterNotAccessPeriodNanos))
+	return nil
+}
+
+func (m *NamespaceOptions) GetQueryLimitsOptions() *QueryLimitsOptions {
+	if m != nil {
+		return m.QueryLimitsOptions
 	}
-	if m.FutureRetentionPeriodNanos != 0 {
-		dAtA[i] = 0x38
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.FutureRetentionPeriodNanos))
+	return nil
+}
+
+func (m *NamespaceOptions) GetWriteDurability() WriteDurability {
+	if m != nil {
+		return m.WriteDurability
 	}
-	return i, nil
+	return WriteDurability_DEFAULT
 }
 
-func (m *IndexOptions) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *NamespaceOptions) GetValidationOptions() *ValidationOptions {
+	if m != nil {
+		return m.ValidationOptions
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *IndexOptions) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.Enabled {
-		dAtA[i] = 0x8
-		i++
-		if m.Enabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+func (m *NamespaceOptions) GetCompactionOptions() *CompactionOptions {
+	if m != nil {
+		return m.CompactionOptions
 	}
-	if m.BlockSizeNanos != 0 {
-		dAtA[i] = 0x10
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
+	return nil
+}
+
+type RetentionTier struct {
+	ResolutionNanos int64 `protobuf:"varint,1,opt,name=resolutionNanos,proto3" json:"resolutionNanos,omitempty"`
+	RetentionNanos  int64 `protobuf:"varint,2,opt,name=retentionNanos,proto3" json:"retentionNanos,omitempty"`
+}
+
+func (m *RetentionTier) Reset()                    { *m = RetentionTier{} }
+func (m *RetentionTier) String() string            { return proto.CompactTextString(m) }
+func (*RetentionTier) ProtoMessage()               {}
+func (*RetentionTier) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{3} }
+
+func (m *RetentionTier) GetResolutionNanos() int64 {
+	if m != nil {
+		return m.ResolutionNanos
 	}
-	return i, nil
+	return 0
 }
 
-func (m *NamespaceOptions) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *RetentionTier) GetRetentionNanos() int64 {
+	if m != nil {
+		return m.RetentionNanos
 	}
-	return dAtA[:n], nil
+	return 0
 }
 
-func (m *NamespaceOptions) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if m.BootstrapEnabled {
-		dAtA[i] = 0x8
-		i++
-		if m.BootstrapEnabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+type ArchivalOptions struct {
+	Enabled              bool  `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	ImmutableAfterNanos  int64 `protobuf:"varint,2,opt,name=immutableAfterNanos,proto3" json:"immutableAfterNanos,omitempty"`
+	VerifyChecksumOnRead bool  `protobuf:"varint,3,opt,name=verifyChecksumOnRead,proto3" json:"verifyChecksumOnRead,omitempty"`
+}
+
+func (m *ArchivalOptions) Reset()                    { *m = ArchivalOptions{} }
+func (m *ArchivalOptions) String() string            { return proto.CompactTextString(m) }
+func (*ArchivalOptions) ProtoMessage()               {}
+func (*ArchivalOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{4} }
+
+func (m *ArchivalOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
 	}
-	if m.FlushEnabled {
-		dAtA[i] = 0x10
-		i++
-		if m.FlushEnabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return false
+}
+
+func (m *ArchivalOptions) GetImmutableAfterNanos() int64 {
+	if m != nil {
+		return m.ImmutableAfterNanos
 	}
-	if m.WritesToCommitLog {
-		dAtA[i] = 0x18
-		i++
-		if m.WritesToCommitLog {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return 0
+}
+
+func (m *ArchivalOptions) GetVerifyChecksumOnRead() bool {
+	if m != nil {
+		return m.VerifyChecksumOnRead
 	}
-	if m.CleanupEnabled {
-		dAtA[i] = 0x20
-		i++
-		if m.CleanupEnabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return false
+}
+
+type CompactionOptions struct {
+	Enabled        bool  `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	BlockSizeNanos int64 `protobuf:"varint,2,opt,name=blockSizeNanos,proto3" json:"blockSizeNanos,omitempty"`
+	AfterNanos     int64 `protobuf:"varint,3,opt,name=afterNanos,proto3" json:"afterNanos,omitempty"`
+}
+
+func (m *CompactionOptions) Reset()                    { *m = CompactionOptions{} }
+func (m *CompactionOptions) String() string            { return proto.CompactTextString(m) }
+func (*CompactionOptions) ProtoMessage()               {}
+func (*CompactionOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{5} }
+
+func (m *CompactionOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
 	}
-	if m.RepairEnabled {
-		dAtA[i] = 0x28
-		i++
-		if m.RepairEnabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return false
+}
+
+func (m *CompactionOptions) GetBlockSizeNanos() int64 {
+	if m != nil {
+		return m.BlockSizeNanos
 	}
-	if m.RetentionOptions != nil {
-		dAtA[i] = 0x32
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionOptions.Size()))
-		n1, err := m.RetentionOptions.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n1
+	return 0
+}
+
+func (m *CompactionOptions) GetAfterNanos() int64 {
+	if m != nil {
+		return m.AfterNanos
 	}
-	if m.SnapshotEnabled {
-		dAtA[i] = 0x38
-		i++
-		if m.SnapshotEnabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return 0
+}
+
+type FutureWriteOptions struct {
+	Enabled        bool              `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	ToleranceNanos int64             `protobuf:"varint,2,opt,name=toleranceNanos,proto3" json:"toleranceNanos,omitempty"`
+	Action         FutureWriteAction `protobuf:"varint,3,opt,name=action,proto3,enum=namespace.FutureWriteAction" json:"action,omitempty"`
+}
+
+func (m *FutureWriteOptions) Reset()                    { *m = FutureWriteOptions{} }
+func (m *FutureWriteOptions) String() string            { return proto.CompactTextString(m) }
+func (*FutureWriteOptions) ProtoMessage()               {}
+func (*FutureWriteOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{6} }
+
+func (m *FutureWriteOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
 	}
-	if m.IndexOptions != nil {
-		dAtA[i] = 0x42
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.IndexOptions.Size()))
-		n2, err := m.IndexOptions.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n2
+	return false
+}
+
+func (m *FutureWriteOptions) GetToleranceNanos() int64 {
+	if m != nil {
+		return m.ToleranceNanos
 	}
-	if m.SchemaOptions != nil {
-		dAtA[i] = 0x4a
-		i++
-		i = encodeVarintNamespace(dAtA, i, uint64(m.SchemaOptions.Size()))
-		n3, err := m.SchemaOptions.MarshalTo(dAtA[i:])
-		if err != nil {
-			return 0, err
-		}
-		i += n3
+	return 0
+}
+
+func (m *FutureWriteOptions) GetAction() FutureWriteAction {
+	if m != nil {
+		return m.Action
 	}
-	if m.ColdWritesEnabled {
-		dAtA[i] = 0x50
-		i++
-		if m.ColdWritesEnabled {
-			dAtA[i] = 1
-		} else {
-			dAtA[i] = 0
-		}
-		i++
+	return FutureWriteAction_REJECT
+}
+
+type ExpiryDownsampleOptions struct {
+	Enabled         bool   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	TargetNamespace string `protobuf:"bytes,2,opt,name=targetNamespace,proto3" json:"targetNamespace,omitempty"`
+	ResolutionNanos int64  `protobuf:"varint,3,opt,name=resolutionNanos,proto3" json:"resolutionNanos,omitempty"`
+}
+
+func (m *ExpiryDownsampleOptions) Reset()         { *m = ExpiryDownsampleOptions{} }
+func (m *ExpiryDownsampleOptions) String() string { return proto.CompactTextString(m) }
+func (*ExpiryDownsampleOptions) ProtoMessage()    {}
+func (*ExpiryDownsampleOptions) Descriptor() ([]byte, []int) {
+	return fileDescriptorNamespace, []int{7}
+}
+
+func (m *ExpiryDownsampleOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
 	}
-	return i, nil
+	return false
 }
 
-func (m *Registry) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalTo(dAtA)
-	if err != nil {
-		return nil, err
+func (m *ExpiryDownsampleOptions) GetTargetNamespace() string {
+	if m != nil {
+		return m.TargetNamespace
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *Registry) MarshalTo(dAtA []byte) (int, error) {
-	var i int
-	_ = i
-	var l int
-	_ = l
-	if len(m.Namespaces) > 0 {
-		for k, _ := range m.Namespaces {
-			dAtA[i] = 0xa
-			i++
-			v := m.Namespaces[k]
-			msgSize := 0
-			if v != nil {
-				msgSize = v.Size()
-				msgSize += 1 + sovNamespace(uint64(msgSize))
-			}
-			mapSize := 1 + len(k) + sovNamespace(uint64(len(k))) + msgSize
-			i = encodeVarintNamespace(dAtA, i, uint64(mapSize))
-			dAtA[i] = 0xa
-			i++
-			i = encodeVarintNamespace(dAtA, i, uint64(len(k)))
-			i += copy(dAtA[i:], k)
-			if v != nil {
-				dAtA[i] = 0x12
-				i++
-				i = encodeVarintNamespace(dAtA, i, uint64(v.Size()))
-				n4, err := v.MarshalTo(dAtA[i:])
-				if err != nil {
-					return 0, err
-				}
-				i += n4
-			}
-		}
+func (m *ExpiryDownsampleOptions) GetResolutionNanos() int64 {
+	if m != nil {
+		return m.ResolutionNanos
 	}
-	return i, nil
+	return 0
 }
 
-func encodeVarintNamespace(dAtA []byte, offset int, v uint64) int {
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+type RelabelRule struct {
+	Action      RelabelAction `protobuf:"varint,1,opt,name=action,proto3,enum=namespace.RelabelAction" json:"action,omitempty"`
+	Name        string        `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Target      string        `protobuf:"bytes,3,opt,name=target,proto3" json:"target,omitempty"`
+	Value       string        `protobuf:"bytes,4,opt,name=value,proto3" json:"value,omitempty"`
+	HashBuckets uint32        `protobuf:"varint,5,opt,name=hashBuckets,proto3" json:"hashBuckets,omitempty"`
+}
+
+func (m *RelabelRule) Reset()                    { *m = RelabelRule{} }
+func (m *RelabelRule) String() string            { return proto.CompactTextString(m) }
+func (*RelabelRule) ProtoMessage()               {}
+func (*RelabelRule) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{8} }
+
+func (m *RelabelRule) GetAction() RelabelAction {
+	if m != nil {
+		return m.Action
 	}
-	dAtA[offset] = uint8(v)
-	return offset + 1
+	return RelabelAction_DROP
 }
-func (m *RetentionOptions) Size() (n int) {
-	var l int
-	_ = l
-	if m.RetentionPeriodNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.RetentionPeriodNanos))
+
+func (m *RelabelRule) GetName() string {
+	if m != nil {
+		return m.Name
 	}
-	if m.BlockSizeNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
+	return ""
+}
+
+func (m *RelabelRule) GetTarget() string {
+	if m != nil {
+		return m.Target
 	}
-	if m.BufferFutureNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.BufferFutureNanos))
+	return ""
+}
+
+func (m *RelabelRule) GetValue() string {
+	if m != nil {
+		return m.Value
 	}
-	if m.BufferPastNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.BufferPastNanos))
+	return ""
+}
+
+func (m *RelabelRule) GetHashBuckets() uint32 {
+	if m != nil {
+		return m.HashBuckets
 	}
-	if m.BlockDataExpiry {
-		n += 2
+	return 0
+}
+
+type RelabelOptions struct {
+	Rules []*RelabelRule `protobuf:"bytes,1,rep,name=rules" json:"rules,omitempty"`
+}
+
+func (m *RelabelOptions) Reset()                    { *m = RelabelOptions{} }
+func (m *RelabelOptions) String() string            { return proto.CompactTextString(m) }
+func (*RelabelOptions) ProtoMessage()               {}
+func (*RelabelOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{9} }
+
+func (m *RelabelOptions) GetRules() []*RelabelRule {
+	if m != nil {
+		return m.Rules
 	}
-	if m.BlockDataExpiryAfterNotAccessPeriodNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.BlockDataExpiryAfterNotAccessPeriodNanos))
+	return nil
+}
+
+type MirrorMatcher struct {
+	Name  string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Value string `protobuf:"bytes,2,opt,name=value,proto3" json:"value,omitempty"`
+}
+
+func (m *MirrorMatcher) Reset()                    { *m = MirrorMatcher{} }
+func (m *MirrorMatcher) String() string            { return proto.CompactTextString(m) }
+func (*MirrorMatcher) ProtoMessage()               {}
+func (*MirrorMatcher) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{10} }
+
+func (m *MirrorMatcher) GetName() string {
+	if m != nil {
+		return m.Name
 	}
-	if m.FutureRetentionPeriodNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.FutureRetentionPeriodNanos))
+	return ""
+}
+
+func (m *MirrorMatcher) GetValue() string {
+	if m != nil {
+		return m.Value
 	}
-	return n
+	return ""
 }
 
-func (m *IndexOptions) Size() (n int) {
-	var l int
-	_ = l
-	if m.Enabled {
-		n += 2
+type MirrorOptions struct {
+	Enabled         bool             `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	TargetNamespace string           `protobuf:"bytes,2,opt,name=targetNamespace,proto3" json:"targetNamespace,omitempty"`
+	Percentage      float64          `protobuf:"fixed64,3,opt,name=percentage,proto3" json:"percentage,omitempty"`
+	Matchers        []*MirrorMatcher `protobuf:"bytes,4,rep,name=matchers" json:"matchers,omitempty"`
+}
+
+func (m *MirrorOptions) Reset()                    { *m = MirrorOptions{} }
+func (m *MirrorOptions) String() string            { return proto.CompactTextString(m) }
+func (*MirrorOptions) ProtoMessage()               {}
+func (*MirrorOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{11} }
+
+func (m *MirrorOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
 	}
-	if m.BlockSizeNanos != 0 {
-		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
+	return false
+}
+
+func (m *MirrorOptions) GetTargetNamespace() string {
+	if m != nil {
+		return m.TargetNamespace
 	}
-	return n
+	return ""
 }
 
-func (m *NamespaceOptions) Size() (n int) {
-	var l int
-	_ = l
-	if m.BootstrapEnabled {
-		n += 2
+func (m *MirrorOptions) GetPercentage() float64 {
+	if m != nil {
+		return m.Percentage
 	}
-	if m.FlushEnabled {
-		n += 2
+	return 0
+}
+
+func (m *MirrorOptions) GetMatchers() []*MirrorMatcher {
+	if m != nil {
+		return m.Matchers
 	}
-	if m.WritesToCommitLog {
-		n += 2
+	return nil
+}
+
+type ReshardOptions struct {
+	Enabled       bool   `protobuf:"varint,1,opt,name=enabled,proto3" json:"enabled,omitempty"`
+	FromNumShards uint32 `protobuf:"varint,2,opt,name=fromNumShards,proto3" json:"fromNumShards,omitempty"`
+	ToNumShards   uint32 `protobuf:"varint,3,opt,name=toNumShards,proto3" json:"toNumShards,omitempty"`
+}
+
+func (m *ReshardOptions) Reset()                    { *m = ReshardOptions{} }
+func (m *ReshardOptions) String() string            { return proto.CompactTextString(m) }
+func (*ReshardOptions) ProtoMessage()               {}
+func (*ReshardOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{12} }
+
+func (m *ReshardOptions) GetEnabled() bool {
+	if m != nil {
+		return m.Enabled
 	}
-	if m.CleanupEnabled {
-		n += 2
+	return false
+}
+
+func (m *ReshardOptions) GetFromNumShards() uint32 {
+	if m != nil {
+		return m.FromNumShards
 	}
-	if m.RepairEnabled {
-		n += 2
+	return 0
+}
+
+func (m *ReshardOptions) GetToNumShards() uint32 {
+	if m != nil {
+		return m.ToNumShards
 	}
-	if m.RetentionOptions != nil {
-		l = m.RetentionOptions.Size()
-		n += 1 + l + sovNamespace(uint64(l))
+	return 0
+}
+
+type QueryLimitsOptions struct {
+	MaxConcurrentQueries   int64 `protobuf:"varint,1,opt,name=maxConcurrentQueries,proto3" json:"maxConcurrentQueries,omitempty"`
+	MaxInFlightResultBytes int64 `protobuf:"varint,2,opt,name=maxInFlightResultBytes,proto3" json:"maxInFlightResultBytes,omitempty"`
+}
+
+func (m *QueryLimitsOptions) Reset()                    { *m = QueryLimitsOptions{} }
+func (m *QueryLimitsOptions) String() string            { return proto.CompactTextString(m) }
+func (*QueryLimitsOptions) ProtoMessage()               {}
+func (*QueryLimitsOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{13} }
+
+func (m *QueryLimitsOptions) GetMaxConcurrentQueries() int64 {
+	if m != nil {
+		return m.MaxConcurrentQueries
 	}
-	if m.SnapshotEnabled {
-		n += 2
+	return 0
+}
+
+func (m *QueryLimitsOptions) GetMaxInFlightResultBytes() int64 {
+	if m != nil {
+		return m.MaxInFlightResultBytes
 	}
-	if m.IndexOptions != nil {
-		l = m.IndexOptions.Size()
-		n += 1 + l + sovNamespace(uint64(l))
+	return 0
+}
+
+type ValidationOptions struct {
+	RejectNaN         bool    `protobuf:"varint,1,opt,name=rejectNaN,proto3" json:"rejectNaN,omitempty"`
+	RejectInf         bool    `protobuf:"varint,2,opt,name=rejectInf,proto3" json:"rejectInf,omitempty"`
+	BoundsEnabled     bool    `protobuf:"varint,3,opt,name=boundsEnabled,proto3" json:"boundsEnabled,omitempty"`
+	MinValue          float64 `protobuf:"fixed64,4,opt,name=minValue,proto3" json:"minValue,omitempty"`
+	MaxValue          float64 `protobuf:"fixed64,5,opt,name=maxValue,proto3" json:"maxValue,omitempty"`
+	MaxAnnotationSize int64   `protobuf:"varint,6,opt,name=maxAnnotationSize,proto3" json:"maxAnnotationSize,omitempty"`
+}
+
+func (m *ValidationOptions) Reset()                    { *m = ValidationOptions{} }
+func (m *ValidationOptions) String() string            { return proto.CompactTextString(m) }
+func (*ValidationOptions) ProtoMessage()               {}
+func (*ValidationOptions) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{14} }
+
+func (m *ValidationOptions) GetRejectNaN() bool {
+	if m != nil {
+		return m.RejectNaN
 	}
-	if m.SchemaOptions != nil {
-		l = m.SchemaOptions.Size()
-		n += 1 + l + sovNamespace(uint64(l))
+	return false
+}
+
+func (m *ValidationOptions) GetRejectInf() bool {
+	if m != nil {
+		return m.RejectInf
 	}
-	if m.ColdWritesEnabled {
-		n += 2
+	return false
+}
+
+func (m *ValidationOptions) GetBoundsEnabled() bool {
+	if m != nil {
+		return m.BoundsEnabled
 	}
-	return n
+	return false
 }
 
-func (m *Registry) Size() (n int) {
-	var l int
-	_ = l
-	if len(m.Namespaces) > 0 {
-		for k, v := range m.Namespaces {
-			_ = k
+func (m *ValidationOptions) GetMinValue() float64 {
+	if m != nil {
+		return m.MinValue
+	}
+	return 0
+}
+
+func (m *ValidationOptions) GetMaxValue() float64 {
+	if m != nil {
+		return m.MaxValue
+	}
+	return 0
+}
+
+func (m *ValidationOptions) GetMaxAnnotationSize() int64 {
+	if m != nil {
+		return m.MaxAnnotationSize
+	}
+	return 0
+}
+
+type Registry struct {
+	Namespaces map[string]*NamespaceOptions `protobuf:"bytes,1,rep,name=namespaces" json:"namespaces,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value"`
+}
+
+func (m *Registry) Reset()                    { *m = Registry{} }
+func (m *Registry) String() string            { return proto.CompactTextString(m) }
+func (*Registry) ProtoMessage()               {}
+func (*Registry) Descriptor() ([]byte, []int) { return fileDescriptorNamespace, []int{15} }
+
+func (m *Registry) GetNamespaces() map[string]*NamespaceOptions {
+	if m != nil {
+		return m.Namespaces
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterType((*RetentionOptions)(nil), "namespace.RetentionOptions")
+	proto.RegisterType((*IndexOptions)(nil), "namespace.IndexOptions")
+	proto.RegisterType((*NamespaceOptions)(nil), "namespace.NamespaceOptions")
+	proto.RegisterType((*RetentionTier)(nil), "namespace.RetentionTier")
+	proto.RegisterType((*ArchivalOptions)(nil), "namespace.ArchivalOptions")
+	proto.RegisterType((*CompactionOptions)(nil), "namespace.CompactionOptions")
+	proto.RegisterType((*FutureWriteOptions)(nil), "namespace.FutureWriteOptions")
+	proto.RegisterType((*ExpiryDownsampleOptions)(nil), "namespace.ExpiryDownsampleOptions")
+	proto.RegisterType((*RelabelRule)(nil), "namespace.RelabelRule")
+	proto.RegisterType((*RelabelOptions)(nil), "namespace.RelabelOptions")
+	proto.RegisterType((*MirrorMatcher)(nil), "namespace.MirrorMatcher")
+	proto.RegisterType((*MirrorOptions)(nil), "namespace.MirrorOptions")
+	proto.RegisterType((*ReshardOptions)(nil), "namespace.ReshardOptions")
+	proto.RegisterType((*QueryLimitsOptions)(nil), "namespace.QueryLimitsOptions")
+	proto.RegisterType((*ValidationOptions)(nil), "namespace.ValidationOptions")
+	proto.RegisterType((*Registry)(nil), "namespace.Registry")
+	proto.RegisterEnum("namespace.FutureWriteAction", FutureWriteAction_name, FutureWriteAction_value)
+	proto.RegisterEnum("namespace.RelabelAction", RelabelAction_name, RelabelAction_value)
+	proto.RegisterEnum("namespace.WriteDurability", WriteDurability_name, WriteDurability_value)
+}
+func (m *RetentionOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RetentionOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.RetentionPeriodNanos != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionPeriodNanos))
+	}
+	if m.BlockSizeNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
+	}
+	if m.BufferFutureNanos != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BufferFutureNanos))
+	}
+	if m.BufferPastNanos != 0 {
+		dAtA[i] = 0x20
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BufferPastNanos))
+	}
+	if m.BlockDataExpiry {
+		dAtA[i] = 0x28
+		i++
+		if m.BlockDataExpiry {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.BlockDataExpiryAfterNotAccessPeriodNanos != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockDataExpiryAfterNotAccessPeriodNanos))
+	}
+	if m.FutureRetentionPeriodNanos != 0 {
+		dAtA[i] = 0x38
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.FutureRetentionPeriodNanos))
+	}
+	return i, nil
+}
+
+func (m *IndexOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IndexOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.BlockSizeNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
+	}
+	return i, nil
+}
+
+func (m *NamespaceOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NamespaceOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.BootstrapEnabled {
+		dAtA[i] = 0x8
+		i++
+		if m.BootstrapEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.FlushEnabled {
+		dAtA[i] = 0x10
+		i++
+		if m.FlushEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.WritesToCommitLog {
+		dAtA[i] = 0x18
+		i++
+		if m.WritesToCommitLog {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.CleanupEnabled {
+		dAtA[i] = 0x20
+		i++
+		if m.CleanupEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.RepairEnabled {
+		dAtA[i] = 0x28
+		i++
+		if m.RepairEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.RetentionOptions != nil {
+		dAtA[i] = 0x32
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionOptions.Size()))
+		n1, err := m.RetentionOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n1
+	}
+	if m.SnapshotEnabled {
+		dAtA[i] = 0x38
+		i++
+		if m.SnapshotEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.IndexOptions != nil {
+		dAtA[i] = 0x42
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.IndexOptions.Size()))
+		n2, err := m.IndexOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n2
+	}
+	if m.SchemaOptions != nil {
+		dAtA[i] = 0x4a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.SchemaOptions.Size()))
+		n3, err := m.SchemaOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n3
+	}
+	if m.ColdWritesEnabled {
+		dAtA[i] = 0x50
+		i++
+		if m.ColdWritesEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.RetentionTiers) > 0 {
+		for _, msg := range m.RetentionTiers {
+			dAtA[i] = 0x5a
+			i++
+			i = encodeVarintNamespace(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	if m.ArchivalOptions != nil {
+		dAtA[i] = 0x62
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ArchivalOptions.Size()))
+		n4, err := m.ArchivalOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n4
+	}
+	if m.FutureWriteOptions != nil {
+		dAtA[i] = 0x6a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.FutureWriteOptions.Size()))
+		n5, err := m.FutureWriteOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n5
+	}
+	if m.ExpiryDownsampleOptions != nil {
+		dAtA[i] = 0x72
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ExpiryDownsampleOptions.Size()))
+		n6, err := m.ExpiryDownsampleOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n6
+	}
+	if m.InMemory {
+		dAtA[i] = 0x78
+		i++
+		if m.InMemory {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.ShardKeyStrategy) > 0 {
+		dAtA[i] = 0x82
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.ShardKeyStrategy)))
+		i += copy(dAtA[i:], m.ShardKeyStrategy)
+	}
+	if m.RelabelOptions != nil {
+		dAtA[i] = 0x8a
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.RelabelOptions.Size()))
+		n7, err := m.RelabelOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n7
+	}
+	if m.MirrorOptions != nil {
+		dAtA[i] = 0x92
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.MirrorOptions.Size()))
+		n8, err := m.MirrorOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n8
+	}
+	if m.ReshardOptions != nil {
+		dAtA[i] = 0x9a
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ReshardOptions.Size()))
+		n9, err := m.ReshardOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n9
+	}
+	if m.QueryLimitsOptions != nil {
+		dAtA[i] = 0xa2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.QueryLimitsOptions.Size()))
+		n10, err := m.QueryLimitsOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n10
+	}
+	if m.WriteDurability != 0 {
+		dAtA[i] = 0xa8
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.WriteDurability))
+	}
+	if m.ValidationOptions != nil {
+		dAtA[i] = 0xb2
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ValidationOptions.Size()))
+		n11, err := m.ValidationOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n11
+	}
+	if m.CompactionOptions != nil {
+		dAtA[i] = 0xba
+		i++
+		dAtA[i] = 0x1
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.CompactionOptions.Size()))
+		n12, err := m.CompactionOptions.MarshalTo(dAtA[i:])
+		if err != nil {
+			return 0, err
+		}
+		i += n12
+	}
+	return i, nil
+}
+
+func (m *RetentionTier) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RetentionTier) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.ResolutionNanos != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ResolutionNanos))
+	}
+	if m.RetentionNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.RetentionNanos))
+	}
+	return i, nil
+}
+
+func (m *ArchivalOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ArchivalOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.ImmutableAfterNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ImmutableAfterNanos))
+	}
+	if m.VerifyChecksumOnRead {
+		dAtA[i] = 0x18
+		i++
+		if m.VerifyChecksumOnRead {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	return i, nil
+}
+
+func (m *CompactionOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CompactionOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.BlockSizeNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.BlockSizeNanos))
+	}
+	if m.AfterNanos != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.AfterNanos))
+	}
+	return i, nil
+}
+
+func (m *FutureWriteOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *FutureWriteOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.ToleranceNanos != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ToleranceNanos))
+	}
+	if m.Action != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.Action))
+	}
+	return i, nil
+}
+
+func (m *ExpiryDownsampleOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ExpiryDownsampleOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.TargetNamespace) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.TargetNamespace)))
+		i += copy(dAtA[i:], m.TargetNamespace)
+	}
+	if m.ResolutionNanos != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ResolutionNanos))
+	}
+	return i, nil
+}
+
+func (m *RelabelRule) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RelabelRule) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Action != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.Action))
+	}
+	if len(m.Name) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	if len(m.Target) > 0 {
+		dAtA[i] = 0x1a
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Target)))
+		i += copy(dAtA[i:], m.Target)
+	}
+	if len(m.Value) > 0 {
+		dAtA[i] = 0x22
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Value)))
+		i += copy(dAtA[i:], m.Value)
+	}
+	if m.HashBuckets != 0 {
+		dAtA[i] = 0x28
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.HashBuckets))
+	}
+	return i, nil
+}
+
+func (m *RelabelOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *RelabelOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Rules) > 0 {
+		for _, msg := range m.Rules {
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintNamespace(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *MirrorMatcher) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MirrorMatcher) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Name) > 0 {
+		dAtA[i] = 0xa
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Name)))
+		i += copy(dAtA[i:], m.Name)
+	}
+	if len(m.Value) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.Value)))
+		i += copy(dAtA[i:], m.Value)
+	}
+	return i, nil
+}
+
+func (m *MirrorOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MirrorOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if len(m.TargetNamespace) > 0 {
+		dAtA[i] = 0x12
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(len(m.TargetNamespace)))
+		i += copy(dAtA[i:], m.TargetNamespace)
+	}
+	if m.Percentage != 0 {
+		dAtA[i] = 0x19
+		i++
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.Percentage))))
+		i += 8
+	}
+	if len(m.Matchers) > 0 {
+		for _, msg := range m.Matchers {
+			dAtA[i] = 0x22
+			i++
+			i = encodeVarintNamespace(dAtA, i, uint64(msg.Size()))
+			n, err := msg.MarshalTo(dAtA[i:])
+			if err != nil {
+				return 0, err
+			}
+			i += n
+		}
+	}
+	return i, nil
+}
+
+func (m *ReshardOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ReshardOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.Enabled {
+		dAtA[i] = 0x8
+		i++
+		if m.Enabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.FromNumShards != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.FromNumShards))
+	}
+	if m.ToNumShards != 0 {
+		dAtA[i] = 0x18
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.ToNumShards))
+	}
+	return i, nil
+}
+
+func (m *QueryLimitsOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *QueryLimitsOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.MaxConcurrentQueries != 0 {
+		dAtA[i] = 0x8
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.MaxConcurrentQueries))
+	}
+	if m.MaxInFlightResultBytes != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.MaxInFlightResultBytes))
+	}
+	return i, nil
+}
+
+func (m *ValidationOptions) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ValidationOptions) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if m.RejectNaN {
+		dAtA[i] = 0x8
+		i++
+		if m.RejectNaN {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.RejectInf {
+		dAtA[i] = 0x10
+		i++
+		if m.RejectInf {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.BoundsEnabled {
+		dAtA[i] = 0x18
+		i++
+		if m.BoundsEnabled {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i++
+	}
+	if m.MinValue != 0 {
+		dAtA[i] = 0x21
+		i++
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.MinValue))))
+		i += 8
+	}
+	if m.MaxValue != 0 {
+		dAtA[i] = 0x29
+		i++
+		binary.LittleEndian.PutUint64(dAtA[i:], uint64(math.Float64bits(float64(m.MaxValue))))
+		i += 8
+	}
+	if m.MaxAnnotationSize != 0 {
+		dAtA[i] = 0x30
+		i++
+		i = encodeVarintNamespace(dAtA, i, uint64(m.MaxAnnotationSize))
+	}
+	return i, nil
+}
+
+func (m *Registry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalTo(dAtA)
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Registry) MarshalTo(dAtA []byte) (int, error) {
+	var i int
+	_ = i
+	var l int
+	_ = l
+	if len(m.Namespaces) > 0 {
+		for k, _ := range m.Namespaces {
+			dAtA[i] = 0xa
+			i++
+			v := m.Namespaces[k]
+			msgSize := 0
+			if v != nil {
+				msgSize = v.Size()
+				msgSize += 1 + sovNamespace(uint64(msgSize))
+			}
+			mapSize := 1 + len(k) + sovNamespace(uint64(len(k))) + msgSize
+			i = encodeVarintNamespace(dAtA, i, uint64(mapSize))
+			dAtA[i] = 0xa
+			i++
+			i = encodeVarintNamespace(dAtA, i, uint64(len(k)))
+			i += copy(dAtA[i:], k)
+			if v != nil {
+				dAtA[i] = 0x12
+				i++
+				i = encodeVarintNamespace(dAtA, i, uint64(v.Size()))
+				n13, err := v.MarshalTo(dAtA[i:])
+				if err != nil {
+					return 0, err
+				}
+				i += n13
+			}
+		}
+	}
+	return i, nil
+}
+
+func encodeVarintNamespace(dAtA []byte, offset int, v uint64) int {
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return offset + 1
+}
+func (m *RetentionOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.RetentionPeriodNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.RetentionPeriodNanos))
+	}
+	if m.BlockSizeNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
+	}
+	if m.BufferFutureNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.BufferFutureNanos))
+	}
+	if m.BufferPastNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.BufferPastNanos))
+	}
+	if m.BlockDataExpiry {
+		n += 2
+	}
+	if m.BlockDataExpiryAfterNotAccessPeriodNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.BlockDataExpiryAfterNotAccessPeriodNanos))
+	}
+	if m.FutureRetentionPeriodNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.FutureRetentionPeriodNanos))
+	}
+	return n
+}
+
+func (m *IndexOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.BlockSizeNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
+	}
+	return n
+}
+
+func (m *NamespaceOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.BootstrapEnabled {
+		n += 2
+	}
+	if m.FlushEnabled {
+		n += 2
+	}
+	if m.WritesToCommitLog {
+		n += 2
+	}
+	if m.CleanupEnabled {
+		n += 2
+	}
+	if m.RepairEnabled {
+		n += 2
+	}
+	if m.RetentionOptions != nil {
+		l = m.RetentionOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.SnapshotEnabled {
+		n += 2
+	}
+	if m.IndexOptions != nil {
+		l = m.IndexOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.SchemaOptions != nil {
+		l = m.SchemaOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.ColdWritesEnabled {
+		n += 2
+	}
+	if len(m.RetentionTiers) > 0 {
+		for _, e := range m.RetentionTiers {
+			l = e.Size()
+			n += 1 + l + sovNamespace(uint64(l))
+		}
+	}
+	if m.ArchivalOptions != nil {
+		l = m.ArchivalOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.FutureWriteOptions != nil {
+		l = m.FutureWriteOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.ExpiryDownsampleOptions != nil {
+		l = m.ExpiryDownsampleOptions.Size()
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.InMemory {
+		n += 2
+	}
+	l = len(m.ShardKeyStrategy)
+	if l > 0 {
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	if m.RelabelOptions != nil {
+		l = m.RelabelOptions.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	if m.MirrorOptions != nil {
+		l = m.MirrorOptions.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	if m.ReshardOptions != nil {
+		l = m.ReshardOptions.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	if m.QueryLimitsOptions != nil {
+		l = m.QueryLimitsOptions.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	if m.WriteDurability != 0 {
+		n += 2 + sovNamespace(uint64(m.WriteDurability))
+	}
+	if m.ValidationOptions != nil {
+		l = m.ValidationOptions.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	if m.CompactionOptions != nil {
+		l = m.CompactionOptions.Size()
+		n += 2 + l + sovNamespace(uint64(l))
+	}
+	return n
+}
+
+func (m *RetentionTier) Size() (n int) {
+	var l int
+	_ = l
+	if m.ResolutionNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.ResolutionNanos))
+	}
+	if m.RetentionNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.RetentionNanos))
+	}
+	return n
+}
+
+func (m *ArchivalOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.ImmutableAfterNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.ImmutableAfterNanos))
+	}
+	if m.VerifyChecksumOnRead {
+		n += 2
+	}
+	return n
+}
+
+func (m *CompactionOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.BlockSizeNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.BlockSizeNanos))
+	}
+	if m.AfterNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.AfterNanos))
+	}
+	return n
+}
+
+func (m *FutureWriteOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.ToleranceNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.ToleranceNanos))
+	}
+	if m.Action != 0 {
+		n += 1 + sovNamespace(uint64(m.Action))
+	}
+	return n
+}
+
+func (m *ExpiryDownsampleOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	l = len(m.TargetNamespace)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.ResolutionNanos != 0 {
+		n += 1 + sovNamespace(uint64(m.ResolutionNanos))
+	}
+	return n
+}
+
+func (m *RelabelRule) Size() (n int) {
+	var l int
+	_ = l
+	if m.Action != 0 {
+		n += 1 + sovNamespace(uint64(m.Action))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	l = len(m.Target)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.HashBuckets != 0 {
+		n += 1 + sovNamespace(uint64(m.HashBuckets))
+	}
+	return n
+}
+
+func (m *RelabelOptions) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Rules) > 0 {
+		for _, e := range m.Rules {
+			l = e.Size()
+			n += 1 + l + sovNamespace(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *MirrorMatcher) Size() (n int) {
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	return n
+}
+
+func (m *MirrorOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	l = len(m.TargetNamespace)
+	if l > 0 {
+		n += 1 + l + sovNamespace(uint64(l))
+	}
+	if m.Percentage != 0 {
+		n += 9
+	}
+	if len(m.Matchers) > 0 {
+		for _, e := range m.Matchers {
+			l = e.Size()
+			n += 1 + l + sovNamespace(uint64(l))
+		}
+	}
+	return n
+}
+
+func (m *ReshardOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.Enabled {
+		n += 2
+	}
+	if m.FromNumShards != 0 {
+		n += 1 + sovNamespace(uint64(m.FromNumShards))
+	}
+	if m.ToNumShards != 0 {
+		n += 1 + sovNamespace(uint64(m.ToNumShards))
+	}
+	return n
+}
+
+func (m *QueryLimitsOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.MaxConcurrentQueries != 0 {
+		n += 1 + sovNamespace(uint64(m.MaxConcurrentQueries))
+	}
+	if m.MaxInFlightResultBytes != 0 {
+		n += 1 + sovNamespace(uint64(m.MaxInFlightResultBytes))
+	}
+	return n
+}
+
+func (m *ValidationOptions) Size() (n int) {
+	var l int
+	_ = l
+	if m.RejectNaN {
+		n += 2
+	}
+	if m.RejectInf {
+		n += 2
+	}
+	if m.BoundsEnabled {
+		n += 2
+	}
+	if m.MinValue != 0 {
+		n += 9
+	}
+	if m.MaxValue != 0 {
+		n += 9
+	}
+	if m.MaxAnnotationSize != 0 {
+		n += 1 + sovNamespace(uint64(m.MaxAnnotationSize))
+	}
+	return n
+}
+
+func (m *Registry) Size() (n int) {
+	var l int
+	_ = l
+	if len(m.Namespaces) > 0 {
+		for k, v := range m.Namespaces {
+			_ = k
 			_ = v
 			l = 0
 			if v != nil {
 				l = v.Size()
 				l += 1 + sovNamespace(uint64(l))
 			}
-			mapEntrySize := 1 + len(k) + sovNamespace(uint64(len(k))) + l
-			n += mapEntrySize + 1 + sovNamespace(uint64(mapEntrySize))
+			mapEntrySize := 1 + len(k) + sovNamespace(uint64(len(k))) + l
+			n += mapEntrySize + 1 + sovNamespace(uint64(mapEntrySize))
+		}
+	}
+	return n
+}
+
+func sovNamespace(x uint64) (n int) {
+	for {
+		n++
+		x >>= 7
+		if x == 0 {
+			break
+		}
+	}
+	return n
+}
+func sozNamespace(x uint64) (n int) {
+	return sovNamespace(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RetentionOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RetentionOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionPeriodNanos", wireType)
+			}
+			m.RetentionPeriodNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RetentionPeriodNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockSizeNanos", wireType)
+			}
+			m.BlockSizeNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlockSizeNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BufferFutureNanos", wireType)
+			}
+			m.BufferFutureNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BufferFutureNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BufferPastNanos", wireType)
+			}
+			m.BufferPastNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BufferPastNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockDataExpiry", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.BlockDataExpiry = bool(v != 0)
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockDataExpiryAfterNotAccessPeriodNanos", wireType)
+			}
+			m.BlockDataExpiryAfterNotAccessPeriodNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlockDataExpiryAfterNotAccessPeriodNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FutureRetentionPeriodNanos", wireType)
+			}
+			m.FutureRetentionPeriodNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.FutureRetentionPeriodNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IndexOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IndexOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IndexOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Enabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockSizeNanos", wireType)
+			}
+			m.BlockSizeNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlockSizeNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NamespaceOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NamespaceOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BootstrapEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.BootstrapEnabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FlushEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.FlushEnabled = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WritesToCommitLog", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.WritesToCommitLog = bool(v != 0)
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CleanupEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.CleanupEnabled = bool(v != 0)
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RepairEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.RepairEnabled = bool(v != 0)
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RetentionOptions == nil {
+				m.RetentionOptions = &RetentionOptions{}
+			}
+			if err := m.RetentionOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.SnapshotEnabled = bool(v != 0)
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field IndexOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.IndexOptions == nil {
+				m.IndexOptions = &IndexOptions{}
+			}
+			if err := m.IndexOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SchemaOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.SchemaOptions == nil {
+				m.SchemaOptions = &SchemaOptions{}
+			}
+			if err := m.SchemaOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ColdWritesEnabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ColdWritesEnabled = bool(v != 0)
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionTiers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.RetentionTiers = append(m.RetentionTiers, &RetentionTier{})
+			if err := m.RetentionTiers[len(m.RetentionTiers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ArchivalOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ArchivalOptions == nil {
+				m.ArchivalOptions = &ArchivalOptions{}
+			}
+			if err := m.ArchivalOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field FutureWriteOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.FutureWriteOptions == nil {
+				m.FutureWriteOptions = &FutureWriteOptions{}
+			}
+			if err := m.FutureWriteOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExpiryDownsampleOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ExpiryDownsampleOptions == nil {
+				m.ExpiryDownsampleOptions = &ExpiryDownsampleOptions{}
+			}
+			if err := m.ExpiryDownsampleOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field InMemory", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.InMemory = bool(v != 0)
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardKeyStrategy", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ShardKeyStrategy = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RelabelOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.RelabelOptions == nil {
+				m.RelabelOptions = &RelabelOptions{}
+			}
+			if err := m.RelabelOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MirrorOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.MirrorOptions == nil {
+				m.MirrorOptions = &MirrorOptions{}
+			}
+			if err := m.MirrorOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 19:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ReshardOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ReshardOptions == nil {
+				m.ReshardOptions = &ReshardOptions{}
+			}
+			if err := m.ReshardOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 20:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field QueryLimitsOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.QueryLimitsOptions == nil {
+				m.QueryLimitsOptions = &QueryLimitsOptions{}
+			}
+			if err := m.QueryLimitsOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 21:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field WriteDurability", wireType)
+			}
+			m.WriteDurability = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.WriteDurability |= (WriteDurability(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 22:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ValidationOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.ValidationOptions == nil {
+				m.ValidationOptions = &ValidationOptions{}
+			}
+			if err := m.ValidationOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 23:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CompactionOptions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.CompactionOptions == nil {
+				m.CompactionOptions = &CompactionOptions{}
+			}
+			if err := m.CompactionOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RetentionTier) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RetentionTier: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RetentionTier: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResolutionNanos", wireType)
+			}
+			m.ResolutionNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResolutionNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RetentionNanos", wireType)
+			}
+			m.RetentionNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.RetentionNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ArchivalOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ArchivalOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ArchivalOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Enabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ImmutableAfterNanos", wireType)
+			}
+			m.ImmutableAfterNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ImmutableAfterNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field VerifyChecksumOnRead", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.VerifyChecksumOnRead = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CompactionOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CompactionOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CompactionOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Enabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BlockSizeNanos", wireType)
+			}
+			m.BlockSizeNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.BlockSizeNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field AfterNanos", wireType)
+			}
+			m.AfterNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.AfterNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *FutureWriteOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: FutureWriteOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: FutureWriteOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Enabled = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ToleranceNanos", wireType)
+			}
+			m.ToleranceNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ToleranceNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Action", wireType)
+			}
+			m.Action = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Action |= (FutureWriteAction(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ExpiryDownsampleOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ExpiryDownsampleOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ExpiryDownsampleOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= (int(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Enabled = bool(v != 0)
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TargetNamespace", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TargetNamespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResolutionNanos", wireType)
+			}
+			m.ResolutionNanos = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ResolutionNanos |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
 		}
 	}
-	return n
-}
 
-func sovNamespace(x uint64) (n int) {
-	for {
-		n++
-		x >>= 7
-		if x == 0 {
-			break
-		}
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-func sozNamespace(x uint64) (n int) {
-	return sovNamespace(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
+func (m *RelabelRule) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -651,17 +3604,17 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: RetentionOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: RelabelRule: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: RetentionOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: RelabelRule: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RetentionPeriodNanos", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Action", wireType)
 			}
-			m.RetentionPeriodNanos = 0
+			m.Action = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -671,16 +3624,16 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.RetentionPeriodNanos |= (int64(b) & 0x7F) << shift
+				m.Action |= (RelabelAction(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BlockSizeNanos", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			m.BlockSizeNanos = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -690,16 +3643,26 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.BlockSizeNanos |= (int64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BufferFutureNanos", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Target", wireType)
 			}
-			m.BufferFutureNanos = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -709,16 +3672,26 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.BufferFutureNanos |= (int64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Target = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BufferPastNanos", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
 			}
-			m.BufferPastNanos = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -728,16 +3701,26 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.BufferPastNanos |= (int64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 5:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BlockDataExpiry", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field HashBuckets", wireType)
 			}
-			var v int
+			m.HashBuckets = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -747,17 +3730,66 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.HashBuckets |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.BlockDataExpiry = bool(v != 0)
-		case 6:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BlockDataExpiryAfterNotAccessPeriodNanos", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			m.BlockDataExpiryAfterNotAccessPeriodNanos = 0
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *RelabelOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: RelabelOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: RelabelOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Rules", wireType)
+			}
+			var msglen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -767,16 +3799,107 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.BlockDataExpiryAfterNotAccessPeriodNanos |= (int64(b) & 0x7F) << shift
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 7:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FutureRetentionPeriodNanos", wireType)
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Rules = append(m.Rules, &RelabelRule{})
+			if err := m.Rules[len(m.Rules)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MirrorMatcher) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MirrorMatcher: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MirrorMatcher: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
 			}
-			m.FutureRetentionPeriodNanos = 0
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -786,11 +3909,21 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.FutureRetentionPeriodNanos |= (int64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -812,7 +3945,7 @@ func (m *RetentionOptions) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *IndexOptions) Unmarshal(dAtA []byte) error {
+func (m *MirrorOptions) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -835,10 +3968,10 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: IndexOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: MirrorOptions: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: IndexOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: MirrorOptions: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
@@ -862,10 +3995,10 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 			}
 			m.Enabled = bool(v != 0)
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BlockSizeNanos", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field TargetNamespace", wireType)
 			}
-			m.BlockSizeNanos = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -875,11 +4008,63 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.BlockSizeNanos |= (int64(b) & 0x7F) << shift
+				stringLen |= (uint64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.TargetNamespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Percentage", wireType)
+			}
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
+			}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.Percentage = float64(math.Float64frombits(v))
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Matchers", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowNamespace
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			if msglen < 0 {
+				return ErrInvalidLengthNamespace
+			}
+			postIndex := iNdEx + msglen
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Matchers = append(m.Matchers, &MirrorMatcher{})
+			if err := m.Matchers[len(m.Matchers)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -901,7 +4086,7 @@ func (m *IndexOptions) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
+func (m *ReshardOptions) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -924,15 +4109,15 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: NamespaceOptions: wiretype end group for non-group")
+			return fmt.Errorf("proto: ReshardOptions: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: NamespaceOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ReshardOptions: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field BootstrapEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Enabled", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -949,12 +4134,12 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.BootstrapEnabled = bool(v != 0)
+			m.Enabled = bool(v != 0)
 		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field FlushEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field FromNumShards", wireType)
 			}
-			var v int
+			m.FromNumShards = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -964,17 +4149,16 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.FromNumShards |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.FlushEnabled = bool(v != 0)
 		case 3:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field WritesToCommitLog", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field ToNumShards", wireType)
 			}
-			var v int
+			m.ToNumShards = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -984,37 +4168,66 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.ToNumShards |= (uint32(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.WritesToCommitLog = bool(v != 0)
-		case 4:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CleanupEnabled", wireType)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
 			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowNamespace
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= (int(b) & 0x7F) << shift
-				if b < 0x80 {
-					break
-				}
+			if skippy < 0 {
+				return ErrInvalidLengthNamespace
 			}
-			m.CleanupEnabled = bool(v != 0)
-		case 5:
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *QueryLimitsOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: QueryLimitsOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: QueryLimitsOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RepairEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxConcurrentQueries", wireType)
 			}
-			var v int
+			m.MaxConcurrentQueries = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -1024,17 +4237,16 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.MaxConcurrentQueries |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.RepairEnabled = bool(v != 0)
-		case 6:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field RetentionOptions", wireType)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxInFlightResultBytes", wireType)
 			}
-			var msglen int
+			m.MaxInFlightResultBytes = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -1044,28 +4256,64 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				m.MaxInFlightResultBytes |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+		default:
+			iNdEx = preIndex
+			skippy, err := skipNamespace(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if skippy < 0 {
 				return ErrInvalidLengthNamespace
 			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
+			if (iNdEx + skippy) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.RetentionOptions == nil {
-				m.RetentionOptions = &RetentionOptions{}
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ValidationOptions) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowNamespace
 			}
-			if err := m.RetentionOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
 			}
-			iNdEx = postIndex
-		case 7:
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= (uint64(b) & 0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ValidationOptions: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ValidationOptions: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SnapshotEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field RejectNaN", wireType)
 			}
 			var v int
 			for shift := uint(0); ; shift += 7 {
@@ -1082,12 +4330,12 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
-			m.SnapshotEnabled = bool(v != 0)
-		case 8:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field IndexOptions", wireType)
+			m.RejectNaN = bool(v != 0)
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field RejectInf", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -1097,30 +4345,17 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				v |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthNamespace
-			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
-				return io.ErrUnexpectedEOF
-			}
-			if m.IndexOptions == nil {
-				m.IndexOptions = &IndexOptions{}
-			}
-			if err := m.IndexOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			iNdEx = postIndex
-		case 9:
-			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field SchemaOptions", wireType)
+			m.RejectInf = bool(v != 0)
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field BoundsEnabled", wireType)
 			}
-			var msglen int
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -1130,30 +4365,39 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= (int(b) & 0x7F) << shift
+				v |= (int(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
-				return ErrInvalidLengthNamespace
+			m.BoundsEnabled = bool(v != 0)
+		case 4:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MinValue", wireType)
 			}
-			postIndex := iNdEx + msglen
-			if postIndex > l {
+			var v uint64
+			if (iNdEx + 8) > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.SchemaOptions == nil {
-				m.SchemaOptions = &SchemaOptions{}
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.MinValue = float64(math.Float64frombits(v))
+		case 5:
+			if wireType != 1 {
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxValue", wireType)
 			}
-			if err := m.SchemaOptions.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			var v uint64
+			if (iNdEx + 8) > l {
+				return io.ErrUnexpectedEOF
 			}
-			iNdEx = postIndex
-		case 10:
+			v = uint64(binary.LittleEndian.Uint64(dAtA[iNdEx:]))
+			iNdEx += 8
+			m.MaxValue = float64(math.Float64frombits(v))
+		case 6:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ColdWritesEnabled", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field MaxAnnotationSize", wireType)
 			}
-			var v int
+			m.MaxAnnotationSize = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowNamespace
@@ -1163,12 +4407,11 @@ func (m *NamespaceOptions) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				v |= (int(b) & 0x7F) << shift
+				m.MaxAnnotationSize |= (int64(b) & 0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			m.ColdWritesEnabled = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipNamespace(dAtA[iNdEx:])
@@ -1473,41 +4716,95 @@ func init() {
 }
 
 var fileDescriptorNamespace = []byte{
-	// 575 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x9c, 0x94, 0x51, 0x6b, 0xd3, 0x50,
-	0x14, 0xc7, 0x4d, 0xb3, 0xad, 0xed, 0x59, 0xe7, 0xe2, 0x45, 0xb0, 0x54, 0x28, 0xa3, 0x8a, 0x14,
-	0x91, 0x06, 0xdb, 0x17, 0x51, 0x18, 0xcc, 0xad, 0x0e, 0x41, 0x6a, 0xb9, 0x13, 0x84, 0xbd, 0xdd,
-	0x24, 0xa7, 0x6d, 0x58, 0x92, 0x1b, 0xee, 0xbd, 0xd1, 0xd5, 0xcf, 0xe0, 0x83, 0xdf, 0xc3, 0x2f,
-	0xe2, 0xa3, 0x1f, 0x41, 0xea, 0xd7, 0xf0, 0x41, 0x72, 0x63, 0xba, 0x24, 0x1d, 0x32, 0xf6, 0x52,
-	0x6e, 0xff, 0xe7, 0x77, 0xce, 0xb9, 0x3d, 0xff, 0x73, 0x0b, 0xa7, 0x73, 0x5f, 0x2d, 0x12, 0x67,
-	0xe0, 0xf2, 0xd0, 0x0e, 0x47, 0x9e, 0x63, 0x87, 0x23, 0x5b, 0x0a, 0xd7, 0xf6, 0x9c, 0x88, 0x7b,
-	0x68, 0xcf, 0x31, 0x42, 0xc1, 0x14, 0x7a, 0x76, 0x2c, 0xb8, 0xe2, 0x76, 0xc4, 0x42, 0x94, 0x31,
-	0x73, 0xf1, 0xea, 0x34, 0xd0, 0x11, 0xd2, 0x5c, 0x0b, 0x9d, 0x93, 0xdb, 0xd6, 0x94, 0xee, 0x02,
-	0x43, 0x96, 0x15, 0xec, 0x7d, 0x35, 0xc1, 0xa2, 0xa8, 0x30, 0x52, 0x3e, 0x8f, 0xde, 0xc7, 0xe9,
-	0xa7, 0x24, 0x43, 0xb8, 0x2f, 0x72, 0x6d, 0x8a, 0xc2, 0xe7, 0xde, 0x84, 0x45, 0x5c, 0xb6, 0x8d,
-	0x03, 0xa3, 0x6f, 0xd2, 0x6b, 0x63, 0xe4, 0x09, 0xdc, 0x75, 0x02, 0xee, 0x5e, 0x9c, 0xf9, 0x5f,
-	0x30, 0xa3, 0x6b, 0x9a, 0xae, 0xa8, 0xe4, 0x19, 0xdc, 0x73, 0x92, 0xd9, 0x0c, 0xc5, 0x9b, 0x44,
-	0x25, 0xe2, 0x1f, 0x6a, 0x6a, 0x74, 0x33, 0x40, 0xfa, 0xb0, 0x9f, 0x89, 0x53, 0x26, 0x55, 0xc6,
-	0x6e, 0x69, 0xb6, 0x2a, 0x6b, 0x32, 0xed, 0x74, 0xc2, 0x14, 0x1b, 0x5f, 0xc6, 0xbe, 0x58, 0xb6,
-	0xb7, 0x0f, 0x8c, 0x7e, 0x83, 0x56, 0x65, 0x72, 0x0e, 0xfd, 0x8a, 0x74, 0x34, 0x53, 0x28, 0x26,
-	0x5c, 0x1d, 0xb9, 0x2e, 0x4a, 0x59, 0xfc, 0xc5, 0x3b, 0xba, 0xd9, 0x8d, 0x79, 0x72, 0x08, 0x9d,
-	0x99, 0xbe, 0x3e, 0xbd, 0x6e, 0x7e, 0x75, 0x5d, 0xed, 0x3f, 0x44, 0x6f, 0x0a, 0xad, 0xb7, 0x91,
-	0x87, 0x97, 0xb9, 0x13, 0x6d, 0xa8, 0x63, 0xc4, 0x9c, 0x00, 0x3d, 0x3d, 0xfc, 0x06, 0xcd, 0xbf,
-	0xde, 0x74, 0xde, 0xbd, 0x3f, 0x26, 0x58, 0x93, 0xdc, 0xfb, 0xbc, 0xec, 0x53, 0xb0, 0x1c, 0xce,
-	0x95, 0x54, 0x82, 0xc5, 0xe3, 0x52, 0xfd, 0x0d, 0x9d, 0xf4, 0xa0, 0x35, 0x0b, 0x12, 0xb9, 0xc8,
-	0xb9, 0x9a, 0xe6, 0x4a, 0x5a, 0x6a, 0xea, 0x67, 0xe1, 0x2b, 0x94, 0x1f, 0xf8, 0x31, 0x0f, 0x43,
-	0x5f, 0xbd, 0xe3, 0x73, 0x6d, 0x6a, 0x83, 0x6e, 0x06, 0xd2, 0xab, 0xbb, 0x01, 0xb2, 0x28, 0x59,
-	0xf7, 0xde, 0xd2, 0x68, 0x45, 0x25, 0x8f, 0x61, 0x4f, 0x60, 0xcc, 0x7c, 0x91, 0x63, 0x99, 0xa1,
-	0x65, 0x91, 0x9c, 0x82, 0x25, 0x2a, 0x0b, 0xac, 0x6d, 0xdb, 0x1d, 0x3e, 0x1c, 0x5c, 0x3d, 0x9f,
-	0xea, 0x8e, 0xd3, 0x8d, 0xa4, 0x74, 0x83, 0x64, 0xc4, 0x62, 0xb9, 0xe0, 0x2a, 0x6f, 0x58, 0xcf,
-	0x36, 0xa8, 0x22, 0x93, 0x57, 0xd0, 0xf2, 0x0b, 0x2e, 0xb5, 0x1b, 0xba, 0xdd, 0x83, 0x42, 0xbb,
-	0xa2, 0x89, 0xb4, 0x04, 0x93, 0x43, 0xd8, 0xcb, 0x5e, 0x60, 0x9e, 0xdd, 0xd4, 0xd9, 0xed, 0x42,
-	0xf6, 0x59, 0x31, 0x4e, 0xcb, 0x78, 0x3a, 0x6b, 0x97, 0x07, 0xde, 0x47, 0x3d, 0xd6, 0xfc, 0xa2,
-	0x90, 0xcd, 0x7a, 0x23, 0xd0, 0xfb, 0x6e, 0x40, 0x83, 0xe2, 0xdc, 0x97, 0x4a, 0x2c, 0xc9, 0x31,
-	0xc0, 0xba, 0x49, 0xfa, 0x9a, 0xcd, 0xfe, 0xee, 0xf0, 0x51, 0x69, 0x48, 0x19, 0x38, 0x58, 0x2f,
-	0x8c, 0x1c, 0x47, 0x4a, 0x2c, 0x69, 0x21, 0xad, 0x73, 0x0e, 0xfb, 0x95, 0x30, 0xb1, 0xc0, 0xbc,
-	0xc0, 0xa5, 0xde, 0xa0, 0x26, 0x4d, 0x8f, 0xe4, 0x39, 0x6c, 0x7f, 0x62, 0x41, 0x82, 0x7a, 0x5b,
-	0xca, 0x4e, 0x54, 0x97, 0x91, 0x66, 0xe4, 0xcb, 0xda, 0x0b, 0xe3, 0xb5, 0xf5, 0x63, 0xd5, 0x35,
-	0x7e, 0xae, 0xba, 0xc6, 0xaf, 0x55, 0xd7, 0xf8, 0xf6, 0xbb, 0x7b, 0xc7, 0xd9, 0xd1, 0x7f, 0x53,
-	0xa3, 0xbf, 0x01, 0x00, 0x00, 0xff, 0xff, 0x60, 0xfa, 0xeb, 0x0c, 0x42, 0x05, 0x00, 0x00,
+	// 1433 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xac, 0x57, 0x4b, 0x6f, 0x1b, 0xb7,
+	0x16, 0xce, 0x58, 0x7e, 0x48, 0xc7, 0x96, 0x3d, 0x66, 0x12, 0x5b, 0xd7, 0x37, 0xd7, 0x30, 0xe6,
+	0x06, 0x85, 0x60, 0x04, 0x56, 0x9a, 0x04, 0x45, 0xda, 0x02, 0x41, 0x65, 0x3d, 0x12, 0xa7, 0x96,
+	0xec, 0xd2, 0x4e, 0x82, 0x04, 0x05, 0x02, 0x6a, 0x44, 0x49, 0x53, 0xcf, 0x43, 0xe5, 0x70, 0x1c,
+	0xab, 0xab, 0xee, 0xba, 0xc9, 0xa2, 0xfd, 0x0d, 0x5d, 0x15, 0xfd, 0x23, 0x5d, 0x76, 0xdf, 0x4d,
+	0x91, 0x6e, 0xfb, 0x23, 0x0a, 0x72, 0x1e, 0xe2, 0xcc, 0xc8, 0x46, 0xd0, 0x76, 0x23, 0x88, 0xdf,
+	0xf9, 0xce, 0xe1, 0xe1, 0x79, 0x91, 0x03, 0x8f, 0x87, 0x16, 0x1f, 0x05, 0xbd, 0x3d, 0xd3, 0x73,
+	0x6a, 0xce, 0xfd, 0x7e, 0xaf, 0xe6, 0xdc, 0xaf, 0xf9, 0xcc, 0xac, 0xf5, 0x7b, 0xae, 0xd7, 0xa7,
+	0xb5, 0x21, 0x75, 0x29, 0x23, 0x9c, 0xf6, 0x6b, 0x63, 0xe6, 0x71, 0xaf, 0xe6, 0x12, 0x87, 0xfa,
+	0x63, 0x62, 0xd2, 0xe9, 0xbf, 0x3d, 0x29, 0x41, 0xa5, 0x04, 0xd8, 0x6a, 0xfe, 0x5d, 0x9b, 0xbe,
+	0x39, 0xa2, 0x0e, 0x09, 0x0d, 0x1a, 0x6f, 0x0b, 0xa0, 0x63, 0xca, 0xa9, 0xcb, 0x2d, 0xcf, 0x3d,
+	0x1a, 0x8b, 0x5f, 0x1f, 0xdd, 0x83, 0x1b, 0x2c, 0xc6, 0x8e, 0x29, 0xb3, 0xbc, 0x7e, 0x97, 0xb8,
+	0x9e, 0x5f, 0xd1, 0x76, 0xb4, 0x6a, 0x01, 0xcf, 0x94, 0xa1, 0x0f, 0x60, 0xb5, 0x67, 0x7b, 0xe6,
+	0xd9, 0x89, 0xf5, 0x0d, 0x0d, 0xd9, 0x73, 0x92, 0x9d, 0x41, 0xd1, 0x1d, 0x58, 0xef, 0x05, 0x83,
+	0x01, 0x65, 0xed, 0x80, 0x07, 0x2c, 0xa2, 0x16, 0x24, 0x35, 0x2f, 0x40, 0x55, 0x58, 0x0b, 0xc1,
+	0x63, 0xe2, 0xf3, 0x90, 0x3b, 0x2f, 0xb9, 0x59, 0x58, 0x32, 0xc5, 0x4e, 0x4d, 0xc2, 0x49, 0xeb,
+	0x62, 0x6c, 0xb1, 0x49, 0x65, 0x61, 0x47, 0xab, 0x16, 0x71, 0x16, 0x46, 0xaf, 0xa0, 0x9a, 0x81,
+	0xea, 0x03, 0x4e, 0x59, 0xd7, 0xe3, 0x75, 0xd3, 0xa4, 0xbe, 0xaf, 0x9e, 0x78, 0x51, 0x6e, 0xf6,
+	0xde, 0x7c, 0xf4, 0x08, 0xb6, 0x06, 0xd2, 0x7d, 0x3c, 0x2b, 0x7e, 0x4b, 0xd2, 0xda, 0x15, 0x0c,
+	0xe3, 0x18, 0x56, 0x0e, 0xdc, 0x3e, 0xbd, 0x88, 0x33, 0x51, 0x81, 0x25, 0xea, 0x92, 0x9e, 0x4d,
+	0xfb, 0x32, 0xf8, 0x45, 0x1c, 0x2f, 0xdf, 0x37, 0xde, 0xc6, 0x9f, 0x00, 0x7a, 0x37, 0xce, 0x7d,
+	0x6c, 0x76, 0x17, 0xf4, 0x9e, 0xe7, 0x71, 0x9f, 0x33, 0x32, 0x6e, 0xa5, 0xec, 0xe7, 0x70, 0x64,
+	0xc0, 0xca, 0xc0, 0x0e, 0xfc, 0x51, 0xcc, 0x9b, 0x93, 0xbc, 0x14, 0x26, 0x92, 0xfa, 0x86, 0x59,
+	0x9c, 0xfa, 0xa7, 0x5e, 0xc3, 0x73, 0x1c, 0x8b, 0x1f, 0x7a, 0x43, 0x99, 0xd4, 0x22, 0xce, 0x0b,
+	0x84, 0xeb, 0xa6, 0x4d, 0x89, 0x1b, 0x24, 0x7b, 0xcf, 0x4b, 0x6a, 0x06, 0x45, 0xb7, 0xa1, 0xcc,
+	0xe8, 0x98, 0x58, 0x2c, 0xa6, 0x85, 0x09, 0x4d, 0x83, 0xe8, 0x31, 0xe8, 0x2c, 0x53, 0xc0, 0x32,
+	0x6d, 0xcb, 0xf7, 0xfe, 0xbb, 0x37, 0x6d, 0x9f, 0x6c, 0x8d, 0xe3, 0x9c, 0x92, 0xa8, 0x20, 0xdf,
+	0x25, 0x63, 0x7f, 0xe4, 0xf1, 0x78, 0xc3, 0xa5, 0xb0, 0x82, 0x32, 0x30, 0xfa, 0x14, 0x56, 0x2c,
+	0x25, 0x4b, 0x95, 0xa2, 0xdc, 0x6e, 0x53, 0xd9, 0x4e, 0x4d, 0x22, 0x4e, 0x91, 0xd1, 0x23, 0x28,
+	0x87, 0x1d, 0x18, 0x6b, 0x97, 0xa4, 0x76, 0x45, 0xd1, 0x3e, 0x51, 0xe5, 0x38, 0x4d, 0x17, 0xb1,
+	0x36, 0x3d, 0xbb, 0xff, 0x42, 0x86, 0x35, 0x76, 0x14, 0xc2, 0x58, 0xe7, 0x04, 0xe8, 0x33, 0x58,
+	0x4d, 0x0e, 0x7a, 0x6a, 0x51, 0xe6, 0x57, 0x96, 0x77, 0x0a, 0x99, 0xed, 0xb0, 0x4a, 0xc0, 0x19,
+	0x3e, 0x6a, 0xc2, 0x1a, 0x61, 0xe6, 0xc8, 0x3a, 0x27, 0x76, 0xec, 0xf1, 0x8a, 0xf4, 0x78, 0x4b,
+	0x31, 0x51, 0x4f, 0x33, 0x70, 0x56, 0x05, 0x75, 0x00, 0x85, 0x65, 0x2f, 0xdd, 0x8b, 0x0d, 0x95,
+	0xa5, 0xa1, 0xff, 0x29, 0x86, 0xda, 0x39, 0x12, 0x9e, 0xa1, 0x88, 0xbe, 0x84, 0x4d, 0x2a, 0x5b,
+	0xb1, 0xe9, 0xbd, 0x71, 0x7d, 0xe2, 0x8c, 0xed, 0xc4, 0xe6, 0xaa, 0xb4, 0x69, 0x28, 0x36, 0x5b,
+	0xb3, 0x99, 0xf8, 0x32, 0x13, 0x68, 0x0b, 0x8a, 0x96, 0xdb, 0xa1, 0x8e, 0xc7, 0x26, 0x95, 0x35,
+	0x19, 0xd9, 0x64, 0x2d, 0x5a, 0xc7, 0x1f, 0x11, 0xd6, 0xff, 0x9c, 0x4e, 0x4e, 0xb8, 0x18, 0xb0,
+	0xc3, 0x49, 0x45, 0xdf, 0xd1, 0xaa, 0x25, 0x9c, 0xc3, 0x51, 0x5d, 0x04, 0xdf, 0x26, 0x3d, 0x9a,
+	0x44, 0x6e, 0x5d, 0x3a, 0xf7, 0x9f, 0x54, 0xf0, 0x55, 0x02, 0xce, 0x28, 0x88, 0x6a, 0x71, 0x2c,
+	0xc6, 0x3c, 0x16, 0x5b, 0x40, 0xb9, 0x6a, 0xe9, 0xa8, 0x72, 0x9c, 0xa6, 0x87, 0x2e, 0x48, 0xc7,
+	0x62, 0x03, 0xd7, 0x67, 0xb8, 0xa0, 0x12, 0x70, 0x46, 0x41, 0xa4, 0xee, 0xeb, 0x80, 0xb2, 0xc9,
+	0xa1, 0xe5, 0x58, 0xdc, 0x8f, 0xcd, 0xdc, 0xc8, 0xa5, 0xee, 0x8b, 0x1c, 0x09, 0xcf, 0x50, 0x14,
+	0xf5, 0x24, 0x47, 0x42, 0x33, 0x60, 0xa4, 0x67, 0xd9, 0x16, 0x9f, 0x54, 0x6e, 0xee, 0x68, 0xd5,
+	0xd5, 0x54, 0x3d, 0xbd, 0x48, 0x33, 0x70, 0x56, 0x05, 0x3d, 0x85, 0xf5, 0x73, 0x62, 0x5b, 0x7d,
+	0xa2, 0xb6, 0xfd, 0x86, 0xf4, 0xe9, 0x96, 0x62, 0xe7, 0x79, 0x96, 0x83, 0xf3, 0x6a, 0xc2, 0x96,
+	0xe9, 0x39, 0x63, 0x62, 0xaa, 0xb6, 0x36, 0x73, 0xb6, 0x1a, 0x59, 0x0e, 0xce, 0xab, 0x19, 0x04,
+	0xca, 0xa9, 0x76, 0x12, 0x53, 0x85, 0x51, 0xdf, 0xb3, 0x03, 0x81, 0xa8, 0xd7, 0x68, 0x16, 0x16,
+	0x63, 0x31, 0x69, 0xbd, 0xd4, 0x44, 0x4f, 0xa3, 0xc6, 0x0f, 0x1a, 0xac, 0x65, 0xfa, 0xed, 0x8a,
+	0x7b, 0xe2, 0x2e, 0x5c, 0xb7, 0x1c, 0x27, 0xe0, 0x62, 0x15, 0xde, 0x5b, 0x8a, 0xe9, 0x59, 0x22,
+	0x71, 0xfb, 0x9f, 0x53, 0x66, 0x0d, 0x26, 0x8d, 0x11, 0x35, 0xcf, 0xfc, 0xc0, 0x39, 0x72, 0x31,
+	0x25, 0xfd, 0x68, 0x9e, 0xcf, 0x94, 0x19, 0x01, 0xac, 0xe7, 0xc2, 0xf3, 0xcf, 0x2f, 0x2f, 0xb4,
+	0x0d, 0x40, 0xa6, 0x3e, 0x87, 0xaf, 0x04, 0x05, 0x31, 0xde, 0x6a, 0x80, 0xf2, 0x13, 0xe3, 0xea,
+	0x8d, 0xb9, 0x67, 0x53, 0x46, 0x5c, 0x33, 0xbd, 0x71, 0x1a, 0x45, 0x0f, 0x60, 0x31, 0x3c, 0x8b,
+	0xdc, 0x74, 0x35, 0x55, 0x07, 0xca, 0x86, 0x75, 0xc9, 0xc1, 0x11, 0xd7, 0xf8, 0x4e, 0x83, 0xcd,
+	0x4b, 0x86, 0xcd, 0x15, 0x3e, 0x55, 0x61, 0x8d, 0x13, 0x36, 0xa4, 0x3c, 0xb9, 0xa6, 0xa5, 0x53,
+	0x25, 0x9c, 0x85, 0x67, 0xd5, 0x52, 0x61, 0x66, 0x2d, 0x19, 0x3f, 0x6a, 0xb0, 0x1c, 0x4d, 0x16,
+	0x1c, 0xd8, 0x14, 0xdd, 0x4d, 0xce, 0xa3, 0xc9, 0xf3, 0x54, 0xf2, 0x13, 0x28, 0x7d, 0x16, 0x84,
+	0x60, 0x5e, 0x50, 0x22, 0x57, 0xe4, 0x7f, 0xb4, 0x01, 0x8b, 0xa1, 0x4b, 0x72, 0xdb, 0x12, 0x8e,
+	0x56, 0xe8, 0x06, 0x2c, 0x9c, 0x13, 0x3b, 0xa0, 0xf2, 0x1e, 0x2f, 0xe1, 0x70, 0x81, 0x76, 0x60,
+	0x79, 0x44, 0xfc, 0xd1, 0x7e, 0x60, 0x9e, 0x51, 0xee, 0xcb, 0xcb, 0xbb, 0x8c, 0x55, 0xc8, 0x78,
+	0x04, 0xab, 0xe9, 0xf1, 0x87, 0xee, 0xc0, 0x02, 0x0b, 0x6c, 0x2a, 0x7a, 0x44, 0xdc, 0x52, 0x1b,
+	0x79, 0x37, 0xc5, 0x71, 0x70, 0x48, 0x32, 0x3e, 0x86, 0x72, 0x38, 0xfc, 0x3a, 0x84, 0x9b, 0x23,
+	0xca, 0x12, 0xa7, 0x35, 0xc5, 0xe9, 0xc4, 0xb9, 0x39, 0xc5, 0x39, 0xe3, 0x27, 0x2d, 0xd6, 0xfd,
+	0x37, 0x13, 0xb4, 0x0d, 0x30, 0xa6, 0xcc, 0xa4, 0x2e, 0x27, 0x43, 0x2a, 0x83, 0xa4, 0x61, 0x05,
+	0x41, 0x0f, 0xa0, 0xe8, 0x84, 0xae, 0x8a, 0x77, 0x6c, 0x61, 0xe6, 0x20, 0x8f, 0xce, 0x82, 0x13,
+	0xa6, 0xc1, 0x44, 0x98, 0x52, 0x23, 0xf9, 0x72, 0x5f, 0x6f, 0x43, 0x79, 0xc0, 0x3c, 0xa7, 0x1b,
+	0x38, 0x27, 0x42, 0x21, 0xac, 0xef, 0x32, 0x4e, 0x83, 0x22, 0x35, 0xdc, 0x9b, 0x72, 0x0a, 0x61,
+	0x6a, 0x14, 0xc8, 0xf8, 0x56, 0x03, 0x94, 0x1f, 0xe8, 0x62, 0x36, 0x38, 0xe4, 0xa2, 0xe1, 0xb9,
+	0x66, 0xc0, 0x18, 0x75, 0xb9, 0xa0, 0x58, 0x34, 0xf9, 0x32, 0x98, 0x25, 0x43, 0x1f, 0xc1, 0x86,
+	0x43, 0x2e, 0x0e, 0xdc, 0xb6, 0x6d, 0x0d, 0x47, 0x1c, 0x53, 0x3f, 0xb0, 0xf9, 0xfe, 0x84, 0xd3,
+	0xb8, 0xf7, 0x2e, 0x91, 0x1a, 0xbf, 0x69, 0xb0, 0x9e, 0x9b, 0xdf, 0xe8, 0x16, 0x94, 0x18, 0xfd,
+	0x8a, 0x9a, 0xbc, 0x4b, 0xba, 0xd1, 0xe1, 0xa7, 0xc0, 0x54, 0x7a, 0xe0, 0x0e, 0xa2, 0x97, 0xea,
+	0x14, 0x10, 0xc1, 0xe9, 0x79, 0x81, 0xdb, 0x4f, 0x9e, 0x4d, 0xe1, 0x48, 0x4b, 0x83, 0xe2, 0xf6,
+	0x77, 0x2c, 0xf7, 0x79, 0x52, 0xd0, 0x1a, 0x4e, 0xd6, 0x52, 0x46, 0x2e, 0x42, 0xd9, 0x42, 0x24,
+	0x8b, 0xd6, 0xe2, 0x61, 0xe6, 0x90, 0x8b, 0xba, 0xeb, 0x7a, 0x5c, 0x7a, 0x2c, 0xc6, 0x58, 0xf4,
+	0x01, 0x91, 0x17, 0x18, 0x3f, 0x6b, 0x50, 0xc4, 0x74, 0x68, 0xf9, 0x9c, 0x4d, 0x50, 0x03, 0x20,
+	0x29, 0x83, 0xb8, 0xf6, 0xff, 0x9f, 0xaa, 0xfd, 0x90, 0xb8, 0x97, 0x94, 0x9a, 0xdf, 0x72, 0x39,
+	0x9b, 0x60, 0x45, 0x6d, 0xeb, 0x15, 0xac, 0x65, 0xc4, 0x48, 0x87, 0xc2, 0x19, 0x9d, 0x44, 0xed,
+	0x20, 0xfe, 0xa2, 0x0f, 0xd5, 0x6e, 0x48, 0x3f, 0x91, 0xb3, 0x5f, 0x09, 0x51, 0xab, 0x7c, 0x32,
+	0xf7, 0x50, 0xdb, 0xdd, 0x85, 0xf5, 0xdc, 0xd8, 0x43, 0x00, 0x8b, 0xb8, 0xf5, 0xb4, 0xd5, 0x38,
+	0xd5, 0xaf, 0xa1, 0x12, 0x2c, 0x34, 0x0e, 0xeb, 0x9d, 0x63, 0x5d, 0xdb, 0x7d, 0x08, 0xe5, 0xa8,
+	0x57, 0x23, 0x5e, 0x11, 0xe6, 0x9b, 0xf8, 0xe8, 0x58, 0xbf, 0x16, 0x6a, 0x74, 0xeb, 0x9d, 0x96,
+	0xae, 0x09, 0xf4, 0x49, 0xfd, 0xe4, 0x89, 0x3e, 0x87, 0x96, 0xa0, 0x50, 0x6f, 0x36, 0xf5, 0xc2,
+	0xee, 0x21, 0xac, 0x65, 0x2e, 0x7e, 0xb4, 0x0c, 0x4b, 0xcd, 0x56, 0xbb, 0xfe, 0xec, 0xf0, 0x34,
+	0x54, 0xef, 0xb4, 0x3a, 0x47, 0xf8, 0xa5, 0xae, 0xa1, 0x9b, 0xb0, 0xde, 0x38, 0xea, 0x74, 0x0e,
+	0x4e, 0x5f, 0x1f, 0x1e, 0x3d, 0x7e, 0xbd, 0xff, 0xac, 0xdd, 0x6e, 0x61, 0x7d, 0x4e, 0xf8, 0xd1,
+	0x3e, 0x79, 0xd9, 0x6d, 0xe8, 0x85, 0x7d, 0xfd, 0x97, 0x77, 0xdb, 0xda, 0xaf, 0xef, 0xb6, 0xb5,
+	0xdf, 0xdf, 0x6d, 0x6b, 0xdf, 0xff, 0xb1, 0x7d, 0xad, 0xb7, 0x28, 0xbf, 0x79, 0xef, 0xff, 0x35,
+	0x00, 0x57, 0xcd, 0x12, 0x97, 0x8f, 0x0f, 0x00, 0x00,
 }
@@ -75,6 +75,7 @@ func (m *PageToken) GetFlushedSeriesPhase() *PageToken_FlushedSeriesPhase {
 
 type PageToken_ActiveSeriesPhase struct {
 	IndexCursor int64 `protobuf:"varint,1,opt,name=indexCursor,proto3" json:"indexCursor,omitempty"`
+	ShardEpoch  int64 `protobuf:"varint,2,opt,name=shardEpoch,proto3" json:"shardEpoch,omitempty"`
 }
 
 func (m *PageToken_ActiveSeriesPhase) Reset()         { *m = PageToken_ActiveSeriesPhase{} }
@@ -91,6 +92,13 @@ func (m *PageToken_ActiveSeriesPhase) GetIndexCursor() int64 {
 	return 0
 }
 
+func (m *PageToken_ActiveSeriesPhase) GetShardEpoch() int64 {
+	if m != nil {
+		return m.ShardEpoch
+	}
+	return 0
+}
+
 type PageToken_FlushedSeriesPhase struct {
 	CurrBlockStartUnixNanos int64 `protobuf:"varint,1,opt,name=currBlockStartUnixNanos,proto3" json:"currBlockStartUnixNanos,omitempty"`
 	CurrBlockEntryIdx       int64 `protobuf:"varint,2,opt,name=currBlockEntryIdx,proto3" json:"currBlockEntryIdx,omitempty"`
@@ -188,6 +196,11 @@ func (m *PageToken_ActiveSeriesPhase) MarshalTo(dAtA []byte) (int, error) {
 		i++
 		i = encodeVarintPagetoken(dAtA, i, uint64(m.IndexCursor))
 	}
+	if m.ShardEpoch != 0 {
+		dAtA[i] = 0x10
+		i++
+		i = encodeVarintPagetoken(dAtA, i, uint64(m.ShardEpoch))
+	}
 	return i, nil
 }
 
@@ -253,6 +266,9 @@ func (m *PageToken_ActiveSeriesPhase) Size() (n int) {
 	if m.IndexCursor != 0 {
 		n += 1 + sovPagetoken(uint64(m.IndexCursor))
 	}
+	if m.ShardEpoch != 0 {
+		n += 1 + sovPagetoken(uint64(m.ShardEpoch))
+	}
 	return n
 }
 
@@ -448,6 +464,25 @@ func (m *PageToken_ActiveSeriesPhase) Unmarshal(dAtA []byte) error {
 					break
 				}
 			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ShardEpoch", wireType)
+			}
+			m.ShardEpoch = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowPagetoken
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ShardEpoch |= (int64(b) & 0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
 		default:
 			iNdEx = preIndex
 			skippy, err := skipPagetoken(dAtA[iNdEx:])
@@ -686,25 +721,24 @@ func init() {
 }
 
 var fileDescriptorPagetoken = []byte{
-	// 307 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x74, 0x91, 0xc1, 0x4a, 0xc3, 0x30,
-	0x1c, 0xc6, 0x8d, 0x85, 0xc1, 0xb2, 0x8b, 0x8b, 0xa2, 0x63, 0x87, 0x32, 0x3c, 0xa8, 0x07, 0x69,
-	0xc0, 0x21, 0x78, 0x75, 0xa2, 0xe2, 0x45, 0xc6, 0xa6, 0x82, 0xa7, 0x91, 0x36, 0xff, 0xb5, 0x65,
-	0x6d, 0x52, 0x92, 0x74, 0xd4, 0x77, 0xf0, 0xe0, 0xc1, 0x87, 0xf2, 0xe8, 0x23, 0x48, 0x7d, 0x11,
-	0x59, 0x1c, 0xdd, 0xb4, 0x7a, 0xcb, 0xff, 0xfb, 0xbe, 0xff, 0x2f, 0x5f, 0x08, 0xbe, 0x0e, 0x63,
-	0x13, 0xe5, 0xbe, 0x17, 0xc8, 0x94, 0xa6, 0x7d, 0xee, 0xd3, 0xb4, 0x4f, 0xb5, 0x0a, 0x28, 0xf7,
-	0x85, 0xe4, 0x40, 0x43, 0x10, 0xa0, 0x98, 0x01, 0x4e, 0x33, 0x25, 0x8d, 0xa4, 0x19, 0x0b, 0xc1,
-	0xc8, 0x19, 0x88, 0xd5, 0xc9, 0xb3, 0x0e, 0x69, 0x56, 0xc2, 0xfe, 0xb3, 0x83, 0x9b, 0x43, 0x16,
-	0xc2, 0xdd, 0x62, 0x22, 0x0f, 0x78, 0x9b, 0x05, 0x26, 0x9e, 0xc3, 0x44, 0x83, 0x8a, 0x41, 0x4f,
-	0xb2, 0x88, 0x69, 0xe8, 0xa0, 0x1e, 0x3a, 0x6a, 0x9d, 0x1c, 0x78, 0x2b, 0x4e, 0xb5, 0xe2, 0x9d,
-	0xdb, 0xfc, 0xd8, 0xc6, 0x87, 0x8b, 0xf4, 0xa8, 0xcd, 0x7e, 0x4b, 0xe4, 0x11, 0xef, 0x4c, 0x93,
-	0x5c, 0x47, 0xc0, 0x7f, 0x82, 0x37, 0x2d, 0xf8, 0xf0, 0x4f, 0xf0, 0xd5, 0xf7, 0xc2, 0x3a, 0x99,
-	0x4c, 0x6b, 0x5a, 0xf7, 0x14, 0xb7, 0x6b, 0x15, 0x48, 0x0f, 0xb7, 0x62, 0xc1, 0xa1, 0xb8, 0xc8,
-	0x95, 0x96, 0xca, 0xf6, 0x77, 0x46, 0xeb, 0x52, 0xf7, 0x15, 0x61, 0x52, 0xbf, 0x81, 0x9c, 0xe1,
-	0xbd, 0x20, 0x57, 0x6a, 0x90, 0xc8, 0x60, 0x36, 0x36, 0x4c, 0x99, 0x7b, 0x11, 0x17, 0xb7, 0x4c,
-	0x48, 0xbd, 0x84, 0xfc, 0x67, 0x93, 0x63, 0xdc, 0xae, 0xac, 0x4b, 0x61, 0xd4, 0xd3, 0x0d, 0x2f,
-	0xec, 0xfb, 0x9c, 0x51, 0xdd, 0x20, 0xbb, 0xb8, 0x31, 0x97, 0x49, 0x9e, 0x42, 0xc7, 0xb1, 0x91,
-	0xe5, 0x34, 0xd8, 0x7a, 0x2b, 0x5d, 0xf4, 0x5e, 0xba, 0xe8, 0xa3, 0x74, 0xd1, 0xcb, 0xa7, 0xbb,
-	0xe1, 0x37, 0xec, 0x97, 0xf5, 0xbf, 0x02, 0x00, 0x00, 0xff, 0xff, 0x6b, 0x8d, 0x80, 0xf2, 0xfd,
-	0x01, 0x00, 0x00,
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x75, 0x51, 0xc1, 0x4a, 0xc3, 0x40,
+	0x10, 0x35, 0x06, 0x0a, 0xdd, 0x5e, 0xcc, 0x2a, 0x5a, 0x7a, 0x08, 0xc5, 0x83, 0x7a, 0x90, 0x04,
+	0xec, 0xc5, 0xab, 0x95, 0x2a, 0x5e, 0xa4, 0xa4, 0x56, 0xf0, 0x54, 0x36, 0xd9, 0x69, 0x12, 0x9a,
+	0xee, 0x86, 0xdd, 0x4d, 0x89, 0x7f, 0xe1, 0xc1, 0xcf, 0xf0, 0x43, 0x3c, 0xfa, 0x09, 0xa2, 0x3f,
+	0x62, 0xba, 0x86, 0x34, 0x1a, 0x3d, 0xcc, 0xb0, 0xf3, 0xde, 0x9b, 0x37, 0x0f, 0x16, 0x5d, 0x87,
+	0xb1, 0x8a, 0x32, 0xdf, 0x09, 0xf8, 0xd2, 0x5d, 0x0e, 0xa8, 0x5f, 0x34, 0x57, 0x8a, 0xc0, 0xa5,
+	0x3e, 0xe3, 0x14, 0xdc, 0x10, 0x18, 0x08, 0xa2, 0x80, 0xba, 0xa9, 0xe0, 0x8a, 0xbb, 0x29, 0x09,
+	0x41, 0xf1, 0x05, 0xb0, 0xcd, 0xcb, 0xd1, 0x0c, 0x6e, 0x57, 0xc0, 0xe1, 0x8b, 0x89, 0xda, 0xe3,
+	0x62, 0xba, 0x5b, 0x4f, 0xf8, 0x1e, 0xed, 0x92, 0x40, 0xc5, 0x2b, 0x98, 0x49, 0x10, 0x31, 0xc8,
+	0x59, 0x1a, 0x11, 0x09, 0x5d, 0xa3, 0x6f, 0x9c, 0x74, 0xce, 0x8e, 0x9c, 0x8d, 0x4f, 0xb5, 0xe2,
+	0x5c, 0x68, 0xfd, 0x44, 0xcb, 0xc7, 0x6b, 0xb5, 0x67, 0x91, 0xdf, 0x10, 0x7e, 0x40, 0x7b, 0xf3,
+	0x24, 0x93, 0x11, 0xd0, 0x9f, 0xc6, 0xdb, 0xda, 0xf8, 0xf8, 0x4f, 0xe3, 0xab, 0xef, 0x85, 0xba,
+	0x33, 0x9e, 0x37, 0xb0, 0xde, 0x14, 0x59, 0x8d, 0x08, 0xb8, 0x8f, 0x3a, 0x31, 0xa3, 0x90, 0x5f,
+	0x66, 0x42, 0x72, 0xa1, 0xf3, 0x9b, 0x5e, 0x1d, 0xc2, 0x36, 0x42, 0x32, 0x22, 0x82, 0x8e, 0x52,
+	0x1e, 0x44, 0x3a, 0x87, 0xe9, 0xd5, 0x90, 0xde, 0xb3, 0x81, 0x70, 0x33, 0x01, 0x3e, 0x47, 0x07,
+	0x41, 0x26, 0xc4, 0x30, 0xe1, 0xc1, 0x62, 0xa2, 0x88, 0x50, 0x53, 0x16, 0xe7, 0xb7, 0x84, 0x71,
+	0x59, 0x1e, 0xf9, 0x8f, 0xc6, 0xa7, 0xc8, 0xaa, 0xa8, 0x11, 0x53, 0xe2, 0xf1, 0x86, 0xe6, 0xe5,
+	0xdd, 0x26, 0x81, 0xf7, 0x51, 0x6b, 0xc5, 0x93, 0x6c, 0x09, 0x5d, 0x53, 0x4b, 0xca, 0x69, 0xb8,
+	0xf3, 0xfa, 0x61, 0x1b, 0x6f, 0x45, 0xbd, 0x17, 0xf5, 0xf4, 0x69, 0x6f, 0xf9, 0x2d, 0xfd, 0xa5,
+	0x83, 0x2f, 0x56, 0x42, 0xae, 0x8f, 0x1d, 0x02, 0x00, 0x00,
 }
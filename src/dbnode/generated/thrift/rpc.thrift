@@ -32,13 +32,67 @@ enum ErrorType {
 	BAD_REQUEST
 }
 
+enum ErrorFlags {
+	NONE = 0x00,
+	RESOURCE_EXHAUSTED = 0x01,
+	UNAVAILABLE = 0x02,
+	RETRYABLE = 0x04,
+	STALE_TOPOLOGY = 0x08,
+	OVER_CAPACITY = 0x10
+}
+
+enum BlockSource {
+	UNKNOWN,
+	MEMORY,
+	DISK,
+	PEER
+}
+
+enum WriteBatchRawErrorType {
+	STORAGE,
+	INDEX,
+	QUOTA,
+	BAD_REQUEST
+}
+
+enum WriteDurability {
+	DEFAULT,
+	MEMORY,
+	COMMIT_LOG_BUFFER,
+	FSYNC
+}
+
+enum GapPolicy {
+	OMIT,
+	MARK
+}
+
+enum GapReason {
+	NO_DATA,
+	EXPIRED
+}
+
+enum WriteDisposition {
+	UNKNOWN,
+	ACCEPTED,
+	CLAMPED,
+	DUPLICATE_OVERWRITTEN,
+	REJECTED_TOO_OLD,
+	DROPPED,
+	DUPLICATE,
+	REJECTED_TOO_FUTURE,
+	REJECTED_COLD_WRITES_DISABLED
+}
+
 exception Error {
 	1: required ErrorType type = ErrorType.INTERNAL_ERROR
 	2: required string message
+	3: optional i64 flags = 0
 }
 
 exception WriteBatchRawErrors {
 	1: required list<WriteBatchRawError> errors
+	2: optional list<WriteDisposition> dispositions
 }
 
 service Node {
@@ -46,6 +100,7 @@ service Node {
 	QueryResult query(1: QueryRequest req) throws (1: Error err)
 	AggregateQueryRawResult aggregateRaw(1: AggregateQueryRawRequest req) throws (1: Error err)
 	AggregateQueryResult aggregate(1: AggregateQueryRequest req) throws (1: Error err)
+	AggregateQueryResult aggregateMulti(1: AggregateMultiQueryRequest req) throws (1: Error err)
 	FetchResult fetch(1: FetchRequest req) throws (1: Error err)
 	FetchTaggedResult fetchTagged(1: FetchTaggedRequest req) throws (1: Error err)
 	void write(1: WriteRequest req) throws (1: Error err)
@@ -57,9 +112,9 @@ service Node {
 	FetchBlocksRawResult fetchBlocksRaw(1: FetchBlocksRawRequest req) throws (1: Error err)
 
 	FetchBlocksMetadataRawV2Result fetchBlocksMetadataRawV2(1: FetchBlocksMetadataRawV2Request req) throws (1: Error err)
-	void writeBatchRaw(1: WriteBatchRawRequest req) throws (1: WriteBatchRawErrors err)
+	WriteBatchRawResult writeBatchRaw(1: WriteBatchRawRequest req) throws (1: WriteBatchRawErrors err)
 	void writeBatchRawV2(1: WriteBatchRawV2Request req) throws (1: WriteBatchRawErrors err)
-	void writeTaggedBatchRaw(1: WriteTaggedBatchRawRequest req) throws (1: WriteBatchRawErrors err)
+	WriteBatchRawResult writeTaggedBatchRaw(1: WriteTaggedBatchRawRequest req) throws (1: WriteBatchRawErrors err)
 	void writeTaggedBatchRawV2(1: WriteTaggedBatchRawV2Request req) throws (1: WriteBatchRawErrors err)
 	void repair() throws (1: Error err)
 	TruncateResult truncate(1: TruncateRequest req) throws (1: Error err)
@@ -78,6 +133,7 @@ service Node {
 	NodeWriteNewSeriesBackoffDurationResult setWriteNewSeriesBackoffDuration(1: NodeSetWriteNewSeriesBackoffDurationRequest req) throws (1: Error err)
 	NodeWriteNewSeriesLimitPerShardPerSecondResult getWriteNewSeriesLimitPerShardPerSecond() throws (1: Error err)
 	NodeWriteNewSeriesLimitPerShardPerSecondResult setWriteNewSeriesLimitPerShardPerSecond(1: NodeSetWriteNewSeriesLimitPerShardPerSecondRequest req) throws (1: Error err)
+	ShardWatermarksResult shardWatermarks(1: ShardWatermarksRequest req) throws (1: Error err)
 }
 
 struct FetchRequest {
@@ -87,10 +143,12 @@ struct FetchRequest {
 	4: required string id
 	5: optional TimeType rangeType = TimeType.UNIX_SECONDS
 	6: optional TimeType resultTimeType = TimeType.UNIX_SECONDS
+	7: optional GapPolicy gapPolicy
 }
 
 struct FetchResult {
 	1: required list<Datapoint> datapoints
+	2: optional bool frozen = false
 }
 
 struct Datapoint {
@@ -98,6 +156,8 @@ struct Datapoint {
 	2: required double value
 	3: optional binary annotation
 	4: optional TimeType timestampTimeType = TimeType.UNIX_SECONDS
+	5: optional i64 gapDuration
+	6: optional GapReason gapReason
 }
 
 struct WriteRequest {
@@ -119,6 +179,7 @@ struct FetchBatchRawRequest {
 	3: required binary nameSpace
 	4: required list<binary> ids
 	5: optional TimeType rangeTimeType = TimeType.UNIX_SECONDS
+	6: optional bool fetchBlockMetadata = false
 }
 
 
@@ -154,6 +215,9 @@ struct Segment {
 	2: required binary tail
 	3: optional i64 startTime
 	4: optional i64 blockSize
+	5: optional i64 checksum
+	6: optional i64 lastWriteTime
+	7: optional BlockSource source
 }
 
 struct FetchTaggedRequest {
@@ -164,11 +228,17 @@ struct FetchTaggedRequest {
 	5: required bool fetchData
 	6: optional i64 limit
 	7: optional TimeType rangeTimeType = TimeType.UNIX_SECONDS
+	8: optional bool sortedOrder = false
+	9: optional binary pageToken
+	10: optional bool sequentialScan = false
+	11: optional bool fetchBlockMetadata = false
 }
 
 struct FetchTaggedResult {
 	1: required list<FetchTaggedIDResult> elements
 	2: required bool exhaustive
+	3: optional binary nextPageToken
+	4: optional bool frozen = false
 }
 
 struct FetchTaggedIDResult {
@@ -183,6 +253,7 @@ struct FetchBlocksRawRequest {
 	1: required binary nameSpace
 	2: required i32 shard
 	3: required list<FetchBlocksRawRequestElement> elements
+	4: optional list<string> acceptCompression
 }
 
 struct FetchBlocksRawRequestElement {
@@ -192,6 +263,7 @@ struct FetchBlocksRawRequestElement {
 
 struct FetchBlocksRawResult {
 	1: required list<Blocks> elements
+	2: optional string compression
 }
 
 struct Blocks {
@@ -242,11 +314,16 @@ struct BlockMetadataV2 {
 struct WriteBatchRawRequest {
 	1: required binary nameSpace
 	2: required list<WriteBatchRawRequestElement> elements
+	3: optional i64 fencingToken
+	4: optional WriteDurability durability
+	5: optional bool includeDispositions = false
 }
 
 struct WriteBatchRawV2Request {
 	1: required list<binary> nameSpaces
 	2: required list<WriteBatchRawV2RequestElement> elements
+	3: optional i64 fencingToken
+	4: optional WriteDurability durability
 }
 
 struct WriteBatchRawRequestElement {
@@ -263,11 +340,18 @@ struct WriteBatchRawV2RequestElement {
 struct WriteTaggedBatchRawRequest {
 	1: required binary nameSpace
 	2: required list<WriteTaggedBatchRawRequestElement> elements
+	3: optional binary idempotencyKey
+	4: optional i64 fencingToken
+	5: optional WriteDurability durability
+	6: optional bool includeDispositions = false
 }
 
 struct WriteTaggedBatchRawV2Request {
 	1: required list<binary> nameSpaces
 	2: required list<WriteTaggedBatchRawV2RequestElement> elements
+	3: optional binary idempotencyKey
+	4: optional i64 fencingToken
+	5: optional WriteDurability durability
 }
 
 struct WriteTaggedBatchRawRequestElement {
@@ -286,6 +370,13 @@ struct WriteTaggedBatchRawV2RequestElement {
 struct WriteBatchRawError {
 	1: required i64 index
 	2: required Error err
+	3: optional WriteBatchRawErrorType errType
+}
+
+// NB: dispositions are only set when the request asked for them and are
+// indexed by the position of the write in the request elements.
+struct WriteBatchRawResult {
+	1: optional list<WriteDisposition> dispositions
 }
 
 struct TruncateRequest {
@@ -296,6 +387,22 @@ struct TruncateResult {
 	1: required i64 numSeries
 }
 
+struct ShardWatermarksRequest {
+	1: required binary nameSpace
+}
+
+struct ShardWatermarksResult {
+	1: required list<ShardWatermark> watermarks
+}
+
+// NB: watermarks are unix nanoseconds, zero if not yet reached.
+struct ShardWatermark {
+	1: required i32 shard
+	2: required i64 flushedTo
+	3: required i64 repairedTo
+	4: required i64 durableTo
+}
+
 struct NodeHealthResult {
 	1: required bool ok
 	2: required string status
@@ -421,6 +528,19 @@ struct AggregateQueryResultTagValueElement {
 	1: required string tagValue
 }
 
+// AggregateMultiQueryRequest is identical to AggregateQueryRequest save for running
+// against a list of namespaces, with results merged and deduplicated across them.
+struct AggregateMultiQueryRequest {
+	1: optional Query query
+	2: required i64 rangeStart
+	3: required i64 rangeEnd
+	4: required list<string> nameSpaces
+	5: optional i64 limit
+	6: optional list<string> tagNameFilter
+	7: optional AggregateQueryType aggregateQueryType = AggregateQueryType.AGGREGATE_BY_TAG_NAME_VALUE
+	8: optional TimeType rangeType = TimeType.UNIX_SECONDS
+}
+
 // Query wrapper types for simple non-optimized query use
 struct QueryRequest {
 	1: required Query query
@@ -162,41 +162,61 @@ func (p *ErrorType) Value() (driver.Value, error) {
 	return int64(*p), nil
 }
 
-type AggregateQueryType int64
+type ErrorFlags int64
 
 const (
-	AggregateQueryType_AGGREGATE_BY_TAG_NAME       AggregateQueryType = 0
-	AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE AggregateQueryType = 1
+	ErrorFlags_NONE               ErrorFlags = 0
+	ErrorFlags_RESOURCE_EXHAUSTED ErrorFlags = 1
+	ErrorFlags_UNAVAILABLE        ErrorFlags = 2
+	ErrorFlags_RETRYABLE          ErrorFlags = 4
+	ErrorFlags_STALE_TOPOLOGY     ErrorFlags = 8
+	ErrorFlags_OVER_CAPACITY      ErrorFlags = 16
 )
 
-func (p AggregateQueryType) String() string {
+func (p ErrorFlags) String() string {
 	switch p {
-	case AggregateQueryType_AGGREGATE_BY_TAG_NAME:
-		return "AGGREGATE_BY_TAG_NAME"
-	case AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE:
-		return "AGGREGATE_BY_TAG_NAME_VALUE"
+	case ErrorFlags_NONE:
+		return "NONE"
+	case ErrorFlags_RESOURCE_EXHAUSTED:
+		return "RESOURCE_EXHAUSTED"
+	case ErrorFlags_UNAVAILABLE:
+		return "UNAVAILABLE"
+	case ErrorFlags_RETRYABLE:
+		return "RETRYABLE"
+	case ErrorFlags_STALE_TOPOLOGY:
+		return "STALE_TOPOLOGY"
+	case ErrorFlags_OVER_CAPACITY:
+		return "OVER_CAPACITY"
 	}
 	return "<UNSET>"
 }
 
-func AggregateQueryTypeFromString(s string) (AggregateQueryType, error) {
+func ErrorFlagsFromString(s string) (ErrorFlags, error) {
 	switch s {
-	case "AGGREGATE_BY_TAG_NAME":
-		return AggregateQueryType_AGGREGATE_BY_TAG_NAME, nil
-	case "AGGREGATE_BY_TAG_NAME_VALUE":
-		return AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE, nil
+	case "NONE":
+		return ErrorFlags_NONE, nil
+	case "RESOURCE_EXHAUSTED":
+		return ErrorFlags_RESOURCE_EXHAUSTED, nil
+	case "UNAVAILABLE":
+		return ErrorFlags_UNAVAILABLE, nil
+	case "RETRYABLE":
+		return ErrorFlags_RETRYABLE, nil
+	case "STALE_TOPOLOGY":
+		return ErrorFlags_STALE_TOPOLOGY, nil
+	case "OVER_CAPACITY":
+		return ErrorFlags_OVER_CAPACITY, nil
 	}
-	return AggregateQueryType(0), fmt.Errorf("not a valid AggregateQueryType string")
+	return ErrorFlags(0), fmt.Errorf("not a valid ErrorFlags string")
 }
 
-func AggregateQueryTypePtr(v AggregateQueryType) *AggregateQueryType { return &v }
+func ErrorFlagsPtr(v ErrorFlags) *ErrorFlags { return &v }
 
-func (p AggregateQueryType) MarshalText() ([]byte, error) {
+func (p ErrorFlags) MarshalText() ([]byte, error) {
 	return []byte(p.String()), nil
 }
 
-func (p *AggregateQueryType) UnmarshalText(text []byte) error {
-	q, err := AggregateQueryTypeFromString(string(text))
+func (p *ErrorFlags) UnmarshalText(text []byte) error {
+	q, err := ErrorFlagsFromString(string(text))
 	if err != nil {
 		return err
 	}
@@ -204,358 +224,533 @@ func (p *AggregateQueryType) UnmarshalText(text []byte) error {
 	return nil
 }
 
-func (p *AggregateQueryType) Scan(value interface{}) error {
+func (p *ErrorFlags) Scan(value interface{}) error {
 	v, ok := value.(int64)
 	if !ok {
 		return errors.New("Scan value is not int64")
 	}
-	*p = AggregateQueryType(v)
+	*p = ErrorFlags(v)
 	return nil
 }
 
-func (p *AggregateQueryType) Value() (driver.Value, error) {
+func (p *ErrorFlags) Value() (driver.Value, error) {
 	if p == nil {
 		return nil, nil
 	}
 	return int64(*p), nil
 }
 
-// Attributes:
-//  - Type
-//  - Message
-type Error struct {
-	Type    ErrorType `thrift:"type,1,required" db:"type" json:"type"`
-	Message string    `thrift:"message,2,required" db:"message" json:"message"`
-}
+type BlockSource int64
 
-func NewError() *Error {
-	return &Error{
-		Type: 0,
+const (
+	BlockSource_UNKNOWN BlockSource = 0
+	BlockSource_MEMORY  BlockSource = 1
+	BlockSource_DISK    BlockSource = 2
+	BlockSource_PEER    BlockSource = 3
+)
+
+func (p BlockSource) String() string {
+	switch p {
+	case BlockSource_UNKNOWN:
+		return "UNKNOWN"
+	case BlockSource_MEMORY:
+		return "MEMORY"
+	case BlockSource_DISK:
+		return "DISK"
+	case BlockSource_PEER:
+		return "PEER"
 	}
+	return "<UNSET>"
 }
 
-func (p *Error) GetType() ErrorType {
-	return p.Type
+func BlockSourceFromString(s string) (BlockSource, error) {
+	switch s {
+	case "UNKNOWN":
+		return BlockSource_UNKNOWN, nil
+	case "MEMORY":
+		return BlockSource_MEMORY, nil
+	case "DISK":
+		return BlockSource_DISK, nil
+	case "PEER":
+		return BlockSource_PEER, nil
+	}
+	return BlockSource(0), fmt.Errorf("not a valid BlockSource string")
 }
 
-func (p *Error) GetMessage() string {
-	return p.Message
+func BlockSourcePtr(v BlockSource) *BlockSource { return &v }
+
+func (p BlockSource) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
 }
-func (p *Error) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
 
-	var issetType bool = false
-	var issetMessage bool = false
+func (p *BlockSource) UnmarshalText(text []byte) error {
+	q, err := BlockSourceFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
 
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetType = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-			issetMessage = true
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
-			}
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
+func (p *BlockSource) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
 	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	*p = BlockSource(v)
+	return nil
+}
+
+func (p *BlockSource) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
 	}
-	if !issetType {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Type is not set"))
+	return int64(*p), nil
+}
+
+type WriteBatchRawErrorType int64
+
+const (
+	WriteBatchRawErrorType_STORAGE     WriteBatchRawErrorType = 0
+	WriteBatchRawErrorType_INDEX       WriteBatchRawErrorType = 1
+	WriteBatchRawErrorType_QUOTA       WriteBatchRawErrorType = 2
+	WriteBatchRawErrorType_BAD_REQUEST WriteBatchRawErrorType = 3
+)
+
+func (p WriteBatchRawErrorType) String() string {
+	switch p {
+	case WriteBatchRawErrorType_STORAGE:
+		return "STORAGE"
+	case WriteBatchRawErrorType_INDEX:
+		return "INDEX"
+	case WriteBatchRawErrorType_QUOTA:
+		return "QUOTA"
+	case WriteBatchRawErrorType_BAD_REQUEST:
+		return "BAD_REQUEST"
 	}
-	if !issetMessage {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Message is not set"))
+	return "<UNSET>"
+}
+
+func WriteBatchRawErrorTypeFromString(s string) (WriteBatchRawErrorType, error) {
+	switch s {
+	case "STORAGE":
+		return WriteBatchRawErrorType_STORAGE, nil
+	case "INDEX":
+		return WriteBatchRawErrorType_INDEX, nil
+	case "QUOTA":
+		return WriteBatchRawErrorType_QUOTA, nil
+	case "BAD_REQUEST":
+		return WriteBatchRawErrorType_BAD_REQUEST, nil
 	}
-	return nil
+	return WriteBatchRawErrorType(0), fmt.Errorf("not a valid WriteBatchRawErrorType string")
 }
 
-func (p *Error) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		temp := ErrorType(v)
-		p.Type = temp
+func WriteBatchRawErrorTypePtr(v WriteBatchRawErrorType) *WriteBatchRawErrorType { return &v }
+
+func (p WriteBatchRawErrorType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *WriteBatchRawErrorType) UnmarshalText(text []byte) error {
+	q, err := WriteBatchRawErrorTypeFromString(string(text))
+	if err != nil {
+		return err
 	}
+	*p = q
 	return nil
 }
 
-func (p *Error) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.Message = v
+func (p *WriteBatchRawErrorType) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
 	}
+	*p = WriteBatchRawErrorType(v)
 	return nil
 }
 
-func (p *Error) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Error"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+func (p *WriteBatchRawErrorType) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
 	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
+	return int64(*p), nil
+}
+
+type WriteDurability int64
+
+const (
+	WriteDurability_DEFAULT           WriteDurability = 0
+	WriteDurability_MEMORY            WriteDurability = 1
+	WriteDurability_COMMIT_LOG_BUFFER WriteDurability = 2
+	WriteDurability_FSYNC             WriteDurability = 3
+)
+
+func (p WriteDurability) String() string {
+	switch p {
+	case WriteDurability_DEFAULT:
+		return "DEFAULT"
+	case WriteDurability_MEMORY:
+		return "MEMORY"
+	case WriteDurability_COMMIT_LOG_BUFFER:
+		return "COMMIT_LOG_BUFFER"
+	case WriteDurability_FSYNC:
+		return "FSYNC"
 	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
+	return "<UNSET>"
+}
+
+func WriteDurabilityFromString(s string) (WriteDurability, error) {
+	switch s {
+	case "DEFAULT":
+		return WriteDurability_DEFAULT, nil
+	case "MEMORY":
+		return WriteDurability_MEMORY, nil
+	case "COMMIT_LOG_BUFFER":
+		return WriteDurability_COMMIT_LOG_BUFFER, nil
+	case "FSYNC":
+		return WriteDurability_FSYNC, nil
 	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
+	return WriteDurability(0), fmt.Errorf("not a valid WriteDurability string")
+}
+
+func WriteDurabilityPtr(v WriteDurability) *WriteDurability { return &v }
+
+func (p WriteDurability) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *WriteDurability) UnmarshalText(text []byte) error {
+	q, err := WriteDurabilityFromString(string(text))
+	if err != nil {
+		return err
 	}
+	*p = q
 	return nil
 }
 
-func (p *Error) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("type", thrift.I32, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:type: ", p), err)
+func (p *WriteDurability) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
 	}
-	if err := oprot.WriteI32(int32(p.Type)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.type (1) field write error: ", p), err)
+	*p = WriteDurability(v)
+	return nil
+}
+
+func (p *WriteDurability) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:type: ", p), err)
+	return int64(*p), nil
+}
+
+type GapPolicy int64
+
+const (
+	GapPolicy_OMIT GapPolicy = 0
+	GapPolicy_MARK GapPolicy = 1
+)
+
+func (p GapPolicy) String() string {
+	switch p {
+	case GapPolicy_OMIT:
+		return "OMIT"
+	case GapPolicy_MARK:
+		return "MARK"
 	}
-	return err
+	return "<UNSET>"
 }
 
-func (p *Error) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("message", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:message: ", p), err)
+func GapPolicyFromString(s string) (GapPolicy, error) {
+	switch s {
+	case "OMIT":
+		return GapPolicy_OMIT, nil
+	case "MARK":
+		return GapPolicy_MARK, nil
 	}
-	if err := oprot.WriteString(string(p.Message)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.message (2) field write error: ", p), err)
+	return GapPolicy(0), fmt.Errorf("not a valid GapPolicy string")
+}
+
+func GapPolicyPtr(v GapPolicy) *GapPolicy { return &v }
+
+func (p GapPolicy) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *GapPolicy) UnmarshalText(text []byte) error {
+	q, err := GapPolicyFromString(string(text))
+	if err != nil {
+		return err
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:message: ", p), err)
+	*p = q
+	return nil
+}
+
+func (p *GapPolicy) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
 	}
-	return err
+	*p = GapPolicy(v)
+	return nil
 }
 
-func (p *Error) String() string {
+func (p *GapPolicy) Value() (driver.Value, error) {
 	if p == nil {
-		return "<nil>"
+		return nil, nil
 	}
-	return fmt.Sprintf("Error(%+v)", *p)
+	return int64(*p), nil
 }
 
-func (p *Error) Error() string {
-	return p.String()
-}
+type GapReason int64
 
-// Attributes:
-//  - Errors
-type WriteBatchRawErrors struct {
-	Errors []*WriteBatchRawError `thrift:"errors,1,required" db:"errors" json:"errors"`
+const (
+	GapReason_NO_DATA GapReason = 0
+	GapReason_EXPIRED GapReason = 1
+)
+
+func (p GapReason) String() string {
+	switch p {
+	case GapReason_NO_DATA:
+		return "NO_DATA"
+	case GapReason_EXPIRED:
+		return "EXPIRED"
+	}
+	return "<UNSET>"
 }
 
-func NewWriteBatchRawErrors() *WriteBatchRawErrors {
-	return &WriteBatchRawErrors{}
+func GapReasonFromString(s string) (GapReason, error) {
+	switch s {
+	case "NO_DATA":
+		return GapReason_NO_DATA, nil
+	case "EXPIRED":
+		return GapReason_EXPIRED, nil
+	}
+	return GapReason(0), fmt.Errorf("not a valid GapReason string")
 }
 
-func (p *WriteBatchRawErrors) GetErrors() []*WriteBatchRawError {
-	return p.Errors
+func GapReasonPtr(v GapReason) *GapReason { return &v }
+
+func (p GapReason) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
 }
-func (p *WriteBatchRawErrors) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
 
-	var issetErrors bool = false
+func (p *GapReason) UnmarshalText(text []byte) error {
+	q, err := GapReasonFromString(string(text))
+	if err != nil {
+		return err
+	}
+	*p = q
+	return nil
+}
 
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetErrors = true
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
-			}
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
-	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
-	}
-	if !issetErrors {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Errors is not set"))
+func (p *GapReason) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
 	}
+	*p = GapReason(v)
 	return nil
 }
 
-func (p *WriteBatchRawErrors) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*WriteBatchRawError, 0, size)
-	p.Errors = tSlice
-	for i := 0; i < size; i++ {
-		_elem0 := &WriteBatchRawError{}
-		if err := _elem0.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem0), err)
-		}
-		p.Errors = append(p.Errors, _elem0)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *GapReason) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
 	}
-	return nil
+	return int64(*p), nil
 }
 
-func (p *WriteBatchRawErrors) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteBatchRawErrors"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
-		}
-	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
+type WriteDisposition int64
+
+const (
+	WriteDisposition_UNKNOWN                       WriteDisposition = 0
+	WriteDisposition_ACCEPTED                      WriteDisposition = 1
+	WriteDisposition_CLAMPED                       WriteDisposition = 2
+	WriteDisposition_DUPLICATE_OVERWRITTEN         WriteDisposition = 3
+	WriteDisposition_REJECTED_TOO_OLD              WriteDisposition = 4
+	WriteDisposition_DROPPED                       WriteDisposition = 5
+	WriteDisposition_DUPLICATE                     WriteDisposition = 6
+	WriteDisposition_REJECTED_TOO_FUTURE           WriteDisposition = 7
+	WriteDisposition_REJECTED_COLD_WRITES_DISABLED WriteDisposition = 8
+)
+
+func (p WriteDisposition) String() string {
+	switch p {
+	case WriteDisposition_UNKNOWN:
+		return "UNKNOWN"
+	case WriteDisposition_ACCEPTED:
+		return "ACCEPTED"
+	case WriteDisposition_CLAMPED:
+		return "CLAMPED"
+	case WriteDisposition_DUPLICATE_OVERWRITTEN:
+		return "DUPLICATE_OVERWRITTEN"
+	case WriteDisposition_REJECTED_TOO_OLD:
+		return "REJECTED_TOO_OLD"
+	case WriteDisposition_DROPPED:
+		return "DROPPED"
+	case WriteDisposition_DUPLICATE:
+		return "DUPLICATE"
+	case WriteDisposition_REJECTED_TOO_FUTURE:
+		return "REJECTED_TOO_FUTURE"
+	case WriteDisposition_REJECTED_COLD_WRITES_DISABLED:
+		return "REJECTED_COLD_WRITES_DISABLED"
 	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
+	return "<UNSET>"
+}
+
+func WriteDispositionFromString(s string) (WriteDisposition, error) {
+	switch s {
+	case "UNKNOWN":
+		return WriteDisposition_UNKNOWN, nil
+	case "ACCEPTED":
+		return WriteDisposition_ACCEPTED, nil
+	case "CLAMPED":
+		return WriteDisposition_CLAMPED, nil
+	case "DUPLICATE_OVERWRITTEN":
+		return WriteDisposition_DUPLICATE_OVERWRITTEN, nil
+	case "REJECTED_TOO_OLD":
+		return WriteDisposition_REJECTED_TOO_OLD, nil
+	case "DROPPED":
+		return WriteDisposition_DROPPED, nil
+	case "DUPLICATE":
+		return WriteDisposition_DUPLICATE, nil
+	case "REJECTED_TOO_FUTURE":
+		return WriteDisposition_REJECTED_TOO_FUTURE, nil
+	case "REJECTED_COLD_WRITES_DISABLED":
+		return WriteDisposition_REJECTED_COLD_WRITES_DISABLED, nil
+	}
+	return WriteDisposition(0), fmt.Errorf("not a valid WriteDisposition string")
+}
+
+func WriteDispositionPtr(v WriteDisposition) *WriteDisposition { return &v }
+
+func (p WriteDisposition) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *WriteDisposition) UnmarshalText(text []byte) error {
+	q, err := WriteDispositionFromString(string(text))
+	if err != nil {
+		return err
 	}
+	*p = q
 	return nil
 }
 
-func (p *WriteBatchRawErrors) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("errors", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:errors: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Errors)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Errors {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
-	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:errors: ", p), err)
+func (p *WriteDisposition) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
 	}
-	return err
+	*p = WriteDisposition(v)
+	return nil
 }
 
-func (p *WriteBatchRawErrors) String() string {
+func (p *WriteDisposition) Value() (driver.Value, error) {
 	if p == nil {
-		return "<nil>"
+		return nil, nil
 	}
-	return fmt.Sprintf("WriteBatchRawErrors(%+v)", *p)
+	return int64(*p), nil
 }
 
-func (p *WriteBatchRawErrors) Error() string {
-	return p.String()
+type AggregateQueryType int64
+
+const (
+	AggregateQueryType_AGGREGATE_BY_TAG_NAME       AggregateQueryType = 0
+	AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE AggregateQueryType = 1
+)
+
+func (p AggregateQueryType) String() string {
+	switch p {
+	case AggregateQueryType_AGGREGATE_BY_TAG_NAME:
+		return "AGGREGATE_BY_TAG_NAME"
+	case AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE:
+		return "AGGREGATE_BY_TAG_NAME_VALUE"
+	}
+	return "<UNSET>"
 }
 
-// Attributes:
-//  - RangeStart
-//  - RangeEnd
-//  - NameSpace
-//  - ID
-//  - RangeType
-//  - ResultTimeType
-type FetchRequest struct {
-	RangeStart     int64    `thrift:"rangeStart,1,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd       int64    `thrift:"rangeEnd,2,required" db:"rangeEnd" json:"rangeEnd"`
-	NameSpace      string   `thrift:"nameSpace,3,required" db:"nameSpace" json:"nameSpace"`
-	ID             string   `thrift:"id,4,required" db:"id" json:"id"`
-	RangeType      TimeType `thrift:"rangeType,5" db:"rangeType" json:"rangeType,omitempty"`
-	ResultTimeType TimeType `thrift:"resultTimeType,6" db:"resultTimeType" json:"resultTimeType,omitempty"`
+func AggregateQueryTypeFromString(s string) (AggregateQueryType, error) {
+	switch s {
+	case "AGGREGATE_BY_TAG_NAME":
+		return AggregateQueryType_AGGREGATE_BY_TAG_NAME, nil
+	case "AGGREGATE_BY_TAG_NAME_VALUE":
+		return AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE, nil
+	}
+	return AggregateQueryType(0), fmt.Errorf("not a valid AggregateQueryType string")
 }
 
-func NewFetchRequest() *FetchRequest {
-	return &FetchRequest{
-		RangeType: 0,
+func AggregateQueryTypePtr(v AggregateQueryType) *AggregateQueryType { return &v }
 
-		ResultTimeType: 0,
+func (p AggregateQueryType) MarshalText() ([]byte, error) {
+	return []byte(p.String()), nil
+}
+
+func (p *AggregateQueryType) UnmarshalText(text []byte) error {
+	q, err := AggregateQueryTypeFromString(string(text))
+	if err != nil {
+		return err
 	}
+	*p = q
+	return nil
 }
 
-func (p *FetchRequest) GetRangeStart() int64 {
-	return p.RangeStart
+func (p *AggregateQueryType) Scan(value interface{}) error {
+	v, ok := value.(int64)
+	if !ok {
+		return errors.New("Scan value is not int64")
+	}
+	*p = AggregateQueryType(v)
+	return nil
 }
 
-func (p *FetchRequest) GetRangeEnd() int64 {
-	return p.RangeEnd
+func (p *AggregateQueryType) Value() (driver.Value, error) {
+	if p == nil {
+		return nil, nil
+	}
+	return int64(*p), nil
 }
 
-func (p *FetchRequest) GetNameSpace() string {
-	return p.NameSpace
+// Attributes:
+//  - Type
+//  - Message
+//  - Flags
+type Error struct {
+	Type    ErrorType `thrift:"type,1,required" db:"type" json:"type"`
+	Message string    `thrift:"message,2,required" db:"message" json:"message"`
+	Flags   int64     `thrift:"flags,3" db:"flags" json:"flags,omitempty"`
 }
 
-func (p *FetchRequest) GetID() string {
-	return p.ID
+func NewError() *Error {
+	return &Error{
+		Type: 0,
+	}
 }
 
-var FetchRequest_RangeType_DEFAULT TimeType = 0
+func (p *Error) GetType() ErrorType {
+	return p.Type
+}
 
-func (p *FetchRequest) GetRangeType() TimeType {
-	return p.RangeType
+func (p *Error) GetMessage() string {
+	return p.Message
 }
 
-var FetchRequest_ResultTimeType_DEFAULT TimeType = 0
+var Error_Flags_DEFAULT int64 = 0
 
-func (p *FetchRequest) GetResultTimeType() TimeType {
-	return p.ResultTimeType
-}
-func (p *FetchRequest) IsSetRangeType() bool {
-	return p.RangeType != FetchRequest_RangeType_DEFAULT
+func (p *Error) GetFlags() int64 {
+	return p.Flags
 }
-
-func (p *FetchRequest) IsSetResultTimeType() bool {
-	return p.ResultTimeType != FetchRequest_ResultTimeType_DEFAULT
+func (p *Error) IsSetFlags() bool {
+	return p.Flags != Error_Flags_DEFAULT
 }
 
-func (p *FetchRequest) Read(iprot thrift.TProtocol) error {
+func (p *Error) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetRangeStart bool = false
-	var issetRangeEnd bool = false
-	var issetNameSpace bool = false
-	var issetID bool = false
+	var issetType bool = false
+	var issetMessage bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -570,30 +765,16 @@ func (p *FetchRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetRangeStart = true
+			issetType = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetRangeEnd = true
+			issetMessage = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
-			issetID = true
-		case 5:
-			if err := p.ReadField5(iprot); err != nil {
-				return err
-			}
-		case 6:
-			if err := p.ReadField6(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -606,79 +787,45 @@ func (p *FetchRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetRangeStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
-	}
-	if !issetRangeEnd {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
-	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	if !issetType {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Type is not set"))
 	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
+	if !issetMessage {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Message is not set"))
 	}
 	return nil
 }
 
-func (p *FetchRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *Error) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.RangeStart = v
+		temp := ErrorType(v)
+		p.Type = temp
 	}
 	return nil
 }
 
-func (p *FetchRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *Error) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.RangeEnd = v
+		p.Message = v
 	}
 	return nil
 }
 
-func (p *FetchRequest) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
+func (p *Error) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.NameSpace = v
-	}
-	return nil
-}
-
-func (p *FetchRequest) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.ID = v
-	}
-	return nil
-}
-
-func (p *FetchRequest) ReadField5(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
-	} else {
-		temp := TimeType(v)
-		p.RangeType = temp
-	}
-	return nil
-}
-
-func (p *FetchRequest) ReadField6(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 6: ", err)
-	} else {
-		temp := TimeType(v)
-		p.ResultTimeType = temp
+		p.Flags = v
 	}
 	return nil
 }
 
-func (p *FetchRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchRequest"); err != nil {
+func (p *Error) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Error"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -691,15 +838,6 @@ func (p *FetchRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField3(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField6(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -710,114 +848,89 @@ func (p *FetchRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:rangeStart: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:rangeStart: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeEnd: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (2) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeEnd: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nameSpace: ", p), err)
+func (p *Error) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("type", thrift.I32, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:type: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (3) field write error: ", p), err)
+	if err := oprot.WriteI32(int32(p.Type)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.type (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:type: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchRequest) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:id: ", p), err)
+func (p *Error) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("message", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:message: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.ID)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (4) field write error: ", p), err)
+	if err := oprot.WriteString(string(p.Message)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.message (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:id: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchRequest) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetRangeType() {
-		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:rangeType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.rangeType (5) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:rangeType: ", p), err)
-		}
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:message: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchRequest) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetResultTimeType() {
-		if err := oprot.WriteFieldBegin("resultTimeType", thrift.I32, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:resultTimeType: ", p), err)
+func (p *Error) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFlags() {
+		if err := oprot.WriteFieldBegin("flags", thrift.I64, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:flags: ", p), err)
 		}
-		if err := oprot.WriteI32(int32(p.ResultTimeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.resultTimeType (6) field write error: ", p), err)
+		if err := oprot.WriteI64(int64(p.Flags)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.flags (3) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:resultTimeType: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:flags: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *FetchRequest) String() string {
+func (p *Error) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchRequest(%+v)", *p)
+	return fmt.Sprintf("Error(%+v)", *p)
+}
+
+func (p *Error) Error() string {
+	return p.String()
 }
 
 // Attributes:
-//  - Datapoints
-type FetchResult_ struct {
-	Datapoints []*Datapoint `thrift:"datapoints,1,required" db:"datapoints" json:"datapoints"`
+//  - Errors
+//  - Dispositions
+type WriteBatchRawErrors struct {
+	Errors       []*WriteBatchRawError `thrift:"errors,1,required" db:"errors" json:"errors"`
+	Dispositions []WriteDisposition    `thrift:"dispositions,2" db:"dispositions" json:"dispositions,omitempty"`
 }
 
-func NewFetchResult_() *FetchResult_ {
-	return &FetchResult_{}
+func NewWriteBatchRawErrors() *WriteBatchRawErrors {
+	return &WriteBatchRawErrors{}
 }
 
-func (p *FetchResult_) GetDatapoints() []*Datapoint {
-	return p.Datapoints
+func (p *WriteBatchRawErrors) GetErrors() []*WriteBatchRawError {
+	return p.Errors
 }
-func (p *FetchResult_) Read(iprot thrift.TProtocol) error {
+
+var WriteBatchRawErrors_Dispositions_DEFAULT []WriteDisposition
+
+func (p *WriteBatchRawErrors) GetDispositions() []WriteDisposition {
+	return p.Dispositions
+}
+func (p *WriteBatchRawErrors) IsSetDispositions() bool {
+	return p.Dispositions != nil
+}
+
+func (p *WriteBatchRawErrors) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetDatapoints bool = false
+	var issetErrors bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -832,7 +945,11 @@ func (p *FetchResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetDatapoints = true
+			issetErrors = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -845,27 +962,48 @@ func (p *FetchResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetDatapoints {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoints is not set"))
+	if !issetErrors {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Errors is not set"))
 	}
 	return nil
 }
 
-func (p *FetchResult_) ReadField1(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawErrors) ReadField1(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*Datapoint, 0, size)
-	p.Datapoints = tSlice
+	tSlice := make([]*WriteBatchRawError, 0, size)
+	p.Errors = tSlice
 	for i := 0; i < size; i++ {
-		_elem1 := &Datapoint{
-			TimestampTimeType: 0,
+		_elem0 := &WriteBatchRawError{}
+		if err := _elem0.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem0), err)
 		}
-		if err := _elem1.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem1), err)
+		p.Errors = append(p.Errors, _elem0)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *WriteBatchRawErrors) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]WriteDisposition, 0, size)
+	p.Dispositions = tSlice
+	for i := 0; i < size; i++ {
+		var _elem233 WriteDisposition
+		if v, err := iprot.ReadI32(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			temp := WriteDisposition(v)
+			_elem233 = temp
 		}
-		p.Datapoints = append(p.Datapoints, _elem1)
+		p.Dispositions = append(p.Dispositions, _elem233)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -873,14 +1011,17 @@ func (p *FetchResult_) ReadField1(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchResult"); err != nil {
+func (p *WriteBatchRawErrors) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawErrors"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -891,14 +1032,14 @@ func (p *FetchResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoints", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:datapoints: ", p), err)
+func (p *WriteBatchRawErrors) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("errors", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:errors: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Datapoints)); err != nil {
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Errors)); err != nil {
 		return thrift.PrependError("error writing list begin: ", err)
 	}
-	for _, v := range p.Datapoints {
+	for _, v := range p.Errors {
 		if err := v.Write(oprot); err != nil {
 			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
 		}
@@ -907,70 +1048,128 @@ func (p *FetchResult_) writeField1(oprot thrift.TProtocol) (err error) {
 		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:datapoints: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:errors: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchResult_) String() string {
+func (p *WriteBatchRawErrors) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDispositions() {
+		if err := oprot.WriteFieldBegin("dispositions", thrift.LIST, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:dispositions: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.I32, len(p.Dispositions)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.Dispositions {
+			if err := oprot.WriteI32(int32(v)); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:dispositions: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *WriteBatchRawErrors) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchResult_(%+v)", *p)
+	return fmt.Sprintf("WriteBatchRawErrors(%+v)", *p)
+}
+
+func (p *WriteBatchRawErrors) Error() string {
+	return p.String()
 }
 
 // Attributes:
-//  - Timestamp
-//  - Value
-//  - Annotation
-//  - TimestampTimeType
-type Datapoint struct {
-	Timestamp         int64    `thrift:"timestamp,1,required" db:"timestamp" json:"timestamp"`
-	Value             float64  `thrift:"value,2,required" db:"value" json:"value"`
-	Annotation        []byte   `thrift:"annotation,3" db:"annotation" json:"annotation,omitempty"`
-	TimestampTimeType TimeType `thrift:"timestampTimeType,4" db:"timestampTimeType" json:"timestampTimeType,omitempty"`
+//  - RangeStart
+//  - RangeEnd
+//  - NameSpace
+//  - ID
+//  - RangeType
+//  - ResultTimeType
+//  - GapPolicy
+type FetchRequest struct {
+	RangeStart     int64      `thrift:"rangeStart,1,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd       int64      `thrift:"rangeEnd,2,required" db:"rangeEnd" json:"rangeEnd"`
+	NameSpace      string     `thrift:"nameSpace,3,required" db:"nameSpace" json:"nameSpace"`
+	ID             string     `thrift:"id,4,required" db:"id" json:"id"`
+	RangeType      TimeType   `thrift:"rangeType,5" db:"rangeType" json:"rangeType,omitempty"`
+	ResultTimeType TimeType   `thrift:"resultTimeType,6" db:"resultTimeType" json:"resultTimeType,omitempty"`
+	GapPolicy      *GapPolicy `thrift:"gapPolicy,7" db:"gapPolicy" json:"gapPolicy,omitempty"`
 }
 
-func NewDatapoint() *Datapoint {
-	return &Datapoint{
-		TimestampTimeType: 0,
+func NewFetchRequest() *FetchRequest {
+	return &FetchRequest{
+		RangeType: 0,
+
+		ResultTimeType: 0,
 	}
 }
 
-func (p *Datapoint) GetTimestamp() int64 {
-	return p.Timestamp
+func (p *FetchRequest) GetRangeStart() int64 {
+	return p.RangeStart
 }
 
-func (p *Datapoint) GetValue() float64 {
-	return p.Value
+func (p *FetchRequest) GetRangeEnd() int64 {
+	return p.RangeEnd
 }
 
-var Datapoint_Annotation_DEFAULT []byte
+func (p *FetchRequest) GetNameSpace() string {
+	return p.NameSpace
+}
 
-func (p *Datapoint) GetAnnotation() []byte {
-	return p.Annotation
+func (p *FetchRequest) GetID() string {
+	return p.ID
 }
 
-var Datapoint_TimestampTimeType_DEFAULT TimeType = 0
+var FetchRequest_RangeType_DEFAULT TimeType = 0
 
-func (p *Datapoint) GetTimestampTimeType() TimeType {
-	return p.TimestampTimeType
+func (p *FetchRequest) GetRangeType() TimeType {
+	return p.RangeType
 }
-func (p *Datapoint) IsSetAnnotation() bool {
-	return p.Annotation != nil
+
+var FetchRequest_ResultTimeType_DEFAULT TimeType = 0
+
+func (p *FetchRequest) GetResultTimeType() TimeType {
+	return p.ResultTimeType
 }
 
-func (p *Datapoint) IsSetTimestampTimeType() bool {
-	return p.TimestampTimeType != Datapoint_TimestampTimeType_DEFAULT
+var FetchRequest_GapPolicy_DEFAULT GapPolicy
+
+func (p *FetchRequest) GetGapPolicy() GapPolicy {
+	if !p.IsSetGapPolicy() {
+		return FetchRequest_GapPolicy_DEFAULT
+	}
+	return *p.GapPolicy
+}
+func (p *FetchRequest) IsSetRangeType() bool {
+	return p.RangeType != FetchRequest_RangeType_DEFAULT
 }
 
-func (p *Datapoint) Read(iprot thrift.TProtocol) error {
+func (p *FetchRequest) IsSetResultTimeType() bool {
+	return p.ResultTimeType != FetchRequest_ResultTimeType_DEFAULT
+}
+
+func (p *FetchRequest) IsSetGapPolicy() bool {
+	return p.GapPolicy != nil
+}
+
+func (p *FetchRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetTimestamp bool = false
-	var issetValue bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetNameSpace bool = false
+	var issetID bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -985,20 +1184,34 @@ func (p *Datapoint) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetTimestamp = true
+			issetRangeStart = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetValue = true
+			issetRangeEnd = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
+			issetNameSpace = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
+			issetID = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -1011,54 +1224,89 @@ func (p *Datapoint) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetTimestamp {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Timestamp is not set"))
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
 	}
-	if !issetValue {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Value is not set"))
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
 	return nil
 }
 
-func (p *Datapoint) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchRequest) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Timestamp = v
+		p.RangeStart = v
 	}
 	return nil
 }
 
-func (p *Datapoint) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadDouble(); err != nil {
+func (p *FetchRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.Value = v
+		p.RangeEnd = v
 	}
 	return nil
 }
 
-func (p *Datapoint) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
+func (p *FetchRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.Annotation = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *Datapoint) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
+func (p *FetchRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.ID = v
+	}
+	return nil
+}
+
+func (p *FetchRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
 	} else {
 		temp := TimeType(v)
-		p.TimestampTimeType = temp
+		p.RangeType = temp
 	}
 	return nil
 }
 
-func (p *Datapoint) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Datapoint"); err != nil {
+func (p *FetchRequest) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		temp := TimeType(v)
+		p.ResultTimeType = temp
+	}
+	return nil
+}
+
+func (p *FetchRequest) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := GapPolicy(v)
+		p.GapPolicy = &temp
+	}
+	return nil
+}
+
+func (p *FetchRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -1074,6 +1322,15 @@ func (p *Datapoint) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField4(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -1084,111 +1341,141 @@ func (p *Datapoint) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Datapoint) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("timestamp", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:timestamp: ", p), err)
+func (p *FetchRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:rangeStart: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.Timestamp)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.timestamp (1) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:timestamp: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:rangeStart: ", p), err)
 	}
 	return err
 }
 
-func (p *Datapoint) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("value", thrift.DOUBLE, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:value: ", p), err)
+func (p *FetchRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeEnd: ", p), err)
 	}
-	if err := oprot.WriteDouble(float64(p.Value)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.value (2) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:value: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeEnd: ", p), err)
 	}
 	return err
 }
 
-func (p *Datapoint) writeField3(oprot thrift.TProtocol) (err error) {
-	if p.IsSetAnnotation() {
-		if err := oprot.WriteFieldBegin("annotation", thrift.STRING, 3); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:annotation: ", p), err)
+func (p *FetchRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nameSpace: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nameSpace: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:id: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.ID)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:id: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRangeType() {
+		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:rangeType: ", p), err)
 		}
-		if err := oprot.WriteBinary(p.Annotation); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.annotation (3) field write error: ", p), err)
+		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.rangeType (5) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:annotation: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:rangeType: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *Datapoint) writeField4(oprot thrift.TProtocol) (err error) {
-	if p.IsSetTimestampTimeType() {
-		if err := oprot.WriteFieldBegin("timestampTimeType", thrift.I32, 4); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:timestampTimeType: ", p), err)
+func (p *FetchRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetResultTimeType() {
+		if err := oprot.WriteFieldBegin("resultTimeType", thrift.I32, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:resultTimeType: ", p), err)
 		}
-		if err := oprot.WriteI32(int32(p.TimestampTimeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.timestampTimeType (4) field write error: ", p), err)
+		if err := oprot.WriteI32(int32(p.ResultTimeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.resultTimeType (6) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:timestampTimeType: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:resultTimeType: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *Datapoint) String() string {
+func (p *FetchRequest) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetGapPolicy() {
+		if err := oprot.WriteFieldBegin("gapPolicy", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:gapPolicy: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.GapPolicy)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.gapPolicy (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:gapPolicy: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Datapoint(%+v)", *p)
+	return fmt.Sprintf("FetchRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
-//  - ID
-//  - Datapoint
-type WriteRequest struct {
-	NameSpace string     `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	ID        string     `thrift:"id,2,required" db:"id" json:"id"`
-	Datapoint *Datapoint `thrift:"datapoint,3,required" db:"datapoint" json:"datapoint"`
-}
-
-func NewWriteRequest() *WriteRequest {
-	return &WriteRequest{}
+//  - Datapoints
+//  - Frozen
+type FetchResult_ struct {
+	Datapoints []*Datapoint `thrift:"datapoints,1,required" db:"datapoints" json:"datapoints"`
+	Frozen     bool         `thrift:"frozen,2" db:"frozen" json:"frozen,omitempty"`
 }
 
-func (p *WriteRequest) GetNameSpace() string {
-	return p.NameSpace
+func NewFetchResult_() *FetchResult_ {
+	return &FetchResult_{}
 }
 
-func (p *WriteRequest) GetID() string {
-	return p.ID
+func (p *FetchResult_) GetDatapoints() []*Datapoint {
+	return p.Datapoints
 }
 
-var WriteRequest_Datapoint_DEFAULT *Datapoint
+var FetchResult__Frozen_DEFAULT bool = false
 
-func (p *WriteRequest) GetDatapoint() *Datapoint {
-	if !p.IsSetDatapoint() {
-		return WriteRequest_Datapoint_DEFAULT
-	}
-	return p.Datapoint
+func (p *FetchResult_) GetFrozen() bool {
+	return p.Frozen
 }
-func (p *WriteRequest) IsSetDatapoint() bool {
-	return p.Datapoint != nil
+func (p *FetchResult_) IsSetFrozen() bool {
+	return p.Frozen != FetchResult__Frozen_DEFAULT
 }
 
-func (p *WriteRequest) Read(iprot thrift.TProtocol) error {
+func (p *FetchResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
-	var issetID bool = false
-	var issetDatapoint bool = false
+	var issetDatapoints bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -1203,17 +1490,11 @@ func (p *WriteRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetDatapoints = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetID = true
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
-			issetDatapoint = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -1226,48 +1507,45 @@ func (p *WriteRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
-	}
-	if !issetDatapoint {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
+	if !issetDatapoints {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoints is not set"))
 	}
 	return nil
 }
 
-func (p *WriteRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.NameSpace = v
+func (p *FetchResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*Datapoint, 0, size)
+	p.Datapoints = tSlice
+	for i := 0; i < size; i++ {
+		_elem1 := &Datapoint{
+			TimestampTimeType: 0,
+		}
+		if err := _elem1.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem1), err)
+		}
+		p.Datapoints = append(p.Datapoints, _elem1)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *WriteRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
+func (p *FetchResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.ID = v
-	}
-	return nil
-}
-
-func (p *WriteRequest) ReadField3(iprot thrift.TProtocol) error {
-	p.Datapoint = &Datapoint{
-		TimestampTimeType: 0,
-	}
-	if err := p.Datapoint.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+		p.Frozen = v
 	}
 	return nil
 }
 
-func (p *WriteRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteRequest"); err != nil {
+func (p *FetchResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -1277,9 +1555,6 @@ func (p *WriteRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -1290,101 +1565,131 @@ func (p *WriteRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
-	}
-	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+func (p *FetchResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoints", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:datapoints: ", p), err)
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Datapoints)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
 	}
-	return err
-}
-
-func (p *WriteRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:id: ", p), err)
+	for _, v := range p.Datapoints {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
 	}
-	if err := oprot.WriteString(string(p.ID)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (2) field write error: ", p), err)
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:datapoints: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:datapoint: ", p), err)
-	}
-	if err := p.Datapoint.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:datapoint: ", p), err)
+func (p *FetchResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFrozen() {
+		if err := oprot.WriteFieldBegin("frozen", thrift.BOOL, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:frozen: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.Frozen)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.frozen (2) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:frozen: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *WriteRequest) String() string {
+func (p *FetchResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteRequest(%+v)", *p)
+	return fmt.Sprintf("FetchResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
-//  - ID
-//  - Tags
-//  - Datapoint
-type WriteTaggedRequest struct {
-	NameSpace string     `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	ID        string     `thrift:"id,2,required" db:"id" json:"id"`
-	Tags      []*Tag     `thrift:"tags,3,required" db:"tags" json:"tags"`
-	Datapoint *Datapoint `thrift:"datapoint,4,required" db:"datapoint" json:"datapoint"`
+//  - Timestamp
+//  - Value
+//  - Annotation
+//  - TimestampTimeType
+//  - GapDuration
+//  - GapReason
+type Datapoint struct {
+	Timestamp         int64      `thrift:"timestamp,1,required" db:"timestamp" json:"timestamp"`
+	Value             float64    `thrift:"value,2,required" db:"value" json:"value"`
+	Annotation        []byte     `thrift:"annotation,3" db:"annotation" json:"annotation,omitempty"`
+	TimestampTimeType TimeType   `thrift:"timestampTimeType,4" db:"timestampTimeType" json:"timestampTimeType,omitempty"`
+	GapDuration       *int64     `thrift:"gapDuration,5" db:"gapDuration" json:"gapDuration,omitempty"`
+	GapReason         *GapReason `thrift:"gapReason,6" db:"gapReason" json:"gapReason,omitempty"`
 }
 
-func NewWriteTaggedRequest() *WriteTaggedRequest {
-	return &WriteTaggedRequest{}
+func NewDatapoint() *Datapoint {
+	return &Datapoint{
+		TimestampTimeType: 0,
+	}
 }
 
-func (p *WriteTaggedRequest) GetNameSpace() string {
-	return p.NameSpace
+func (p *Datapoint) GetTimestamp() int64 {
+	return p.Timestamp
 }
 
-func (p *WriteTaggedRequest) GetID() string {
-	return p.ID
+func (p *Datapoint) GetValue() float64 {
+	return p.Value
 }
 
-func (p *WriteTaggedRequest) GetTags() []*Tag {
-	return p.Tags
+var Datapoint_Annotation_DEFAULT []byte
+
+func (p *Datapoint) GetAnnotation() []byte {
+	return p.Annotation
 }
 
-var WriteTaggedRequest_Datapoint_DEFAULT *Datapoint
+var Datapoint_TimestampTimeType_DEFAULT TimeType = 0
 
-func (p *WriteTaggedRequest) GetDatapoint() *Datapoint {
-	if !p.IsSetDatapoint() {
-		return WriteTaggedRequest_Datapoint_DEFAULT
+func (p *Datapoint) GetTimestampTimeType() TimeType {
+	return p.TimestampTimeType
+}
+
+var Datapoint_GapDuration_DEFAULT int64
+
+func (p *Datapoint) GetGapDuration() int64 {
+	if !p.IsSetGapDuration() {
+		return Datapoint_GapDuration_DEFAULT
 	}
-	return p.Datapoint
+	return *p.GapDuration
 }
-func (p *WriteTaggedRequest) IsSetDatapoint() bool {
-	return p.Datapoint != nil
+
+var Datapoint_GapReason_DEFAULT GapReason
+
+func (p *Datapoint) GetGapReason() GapReason {
+	if !p.IsSetGapReason() {
+		return Datapoint_GapReason_DEFAULT
+	}
+	return *p.GapReason
+}
+func (p *Datapoint) IsSetAnnotation() bool {
+	return p.Annotation != nil
 }
 
-func (p *WriteTaggedRequest) Read(iprot thrift.TProtocol) error {
+func (p *Datapoint) IsSetTimestampTimeType() bool {
+	return p.TimestampTimeType != Datapoint_TimestampTimeType_DEFAULT
+}
+
+func (p *Datapoint) IsSetGapDuration() bool {
+	return p.GapDuration != nil
+}
+
+func (p *Datapoint) IsSetGapReason() bool {
+	return p.GapReason != nil
+}
+
+func (p *Datapoint) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
-	var issetID bool = false
-	var issetTags bool = false
-	var issetDatapoint bool = false
+	var issetTimestamp bool = false
+	var issetValue bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -1399,22 +1704,28 @@ func (p *WriteTaggedRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetTimestamp = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetID = true
+			issetValue = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetTags = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
-			issetDatapoint = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -1427,71 +1738,73 @@ func (p *WriteTaggedRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
-	}
-	if !issetTags {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Tags is not set"))
+	if !issetTimestamp {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Timestamp is not set"))
 	}
-	if !issetDatapoint {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
+	if !issetValue {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Value is not set"))
 	}
 	return nil
 }
 
-func (p *WriteTaggedRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
+func (p *Datapoint) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.NameSpace = v
+		p.Timestamp = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
+func (p *Datapoint) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadDouble(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.ID = v
+		p.Value = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedRequest) ReadField3(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*Tag, 0, size)
-	p.Tags = tSlice
-	for i := 0; i < size; i++ {
-		_elem2 := &Tag{}
-		if err := _elem2.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem2), err)
-		}
-		p.Tags = append(p.Tags, _elem2)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *Datapoint) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.Annotation = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedRequest) ReadField4(iprot thrift.TProtocol) error {
-	p.Datapoint = &Datapoint{
-		TimestampTimeType: 0,
-	}
-	if err := p.Datapoint.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+func (p *Datapoint) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		temp := TimeType(v)
+		p.TimestampTimeType = temp
 	}
 	return nil
 }
 
-func (p *WriteTaggedRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteTaggedRequest"); err != nil {
+func (p *Datapoint) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.GapDuration = &v
+	}
+	return nil
+}
+
+func (p *Datapoint) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		temp := GapReason(v)
+		p.GapReason = &temp
+	}
+	return nil
+}
+
+func (p *Datapoint) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Datapoint"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -1507,6 +1820,12 @@ func (p *WriteTaggedRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField4(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -1517,127 +1836,141 @@ func (p *WriteTaggedRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteTaggedRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+func (p *Datapoint) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("timestamp", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:timestamp: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.Timestamp)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.timestamp (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:timestamp: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:id: ", p), err)
+func (p *Datapoint) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("value", thrift.DOUBLE, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:value: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.ID)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (2) field write error: ", p), err)
+	if err := oprot.WriteDouble(float64(p.Value)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.value (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:value: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("tags", thrift.LIST, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:tags: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Tags)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Tags {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+func (p *Datapoint) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetAnnotation() {
+		if err := oprot.WriteFieldBegin("annotation", thrift.STRING, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:annotation: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.Annotation); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.annotation (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:annotation: ", p), err)
 		}
-	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:tags: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedRequest) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:datapoint: ", p), err)
+func (p *Datapoint) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTimestampTimeType() {
+		if err := oprot.WriteFieldBegin("timestampTimeType", thrift.I32, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:timestampTimeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.TimestampTimeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.timestampTimeType (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:timestampTimeType: ", p), err)
+		}
 	}
-	if err := p.Datapoint.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
+	return err
+}
+
+func (p *Datapoint) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetGapDuration() {
+		if err := oprot.WriteFieldBegin("gapDuration", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:gapDuration: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.GapDuration)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.gapDuration (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:gapDuration: ", p), err)
+		}
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:datapoint: ", p), err)
+	return err
+}
+
+func (p *Datapoint) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetGapReason() {
+		if err := oprot.WriteFieldBegin("gapReason", thrift.I32, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:gapReason: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.GapReason)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.gapReason (6) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:gapReason: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *WriteTaggedRequest) String() string {
+func (p *Datapoint) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteTaggedRequest(%+v)", *p)
+	return fmt.Sprintf("Datapoint(%+v)", *p)
 }
 
 // Attributes:
-//  - RangeStart
-//  - RangeEnd
 //  - NameSpace
-//  - Ids
-//  - RangeTimeType
-type FetchBatchRawRequest struct {
-	RangeStart    int64    `thrift:"rangeStart,1,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd      int64    `thrift:"rangeEnd,2,required" db:"rangeEnd" json:"rangeEnd"`
-	NameSpace     []byte   `thrift:"nameSpace,3,required" db:"nameSpace" json:"nameSpace"`
-	Ids           [][]byte `thrift:"ids,4,required" db:"ids" json:"ids"`
-	RangeTimeType TimeType `thrift:"rangeTimeType,5" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
-}
-
-func NewFetchBatchRawRequest() *FetchBatchRawRequest {
-	return &FetchBatchRawRequest{
-		RangeTimeType: 0,
-	}
-}
-
-func (p *FetchBatchRawRequest) GetRangeStart() int64 {
-	return p.RangeStart
+//  - ID
+//  - Datapoint
+type WriteRequest struct {
+	NameSpace string     `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	ID        string     `thrift:"id,2,required" db:"id" json:"id"`
+	Datapoint *Datapoint `thrift:"datapoint,3,required" db:"datapoint" json:"datapoint"`
 }
 
-func (p *FetchBatchRawRequest) GetRangeEnd() int64 {
-	return p.RangeEnd
+func NewWriteRequest() *WriteRequest {
+	return &WriteRequest{}
 }
 
-func (p *FetchBatchRawRequest) GetNameSpace() []byte {
+func (p *WriteRequest) GetNameSpace() string {
 	return p.NameSpace
 }
 
-func (p *FetchBatchRawRequest) GetIds() [][]byte {
-	return p.Ids
+func (p *WriteRequest) GetID() string {
+	return p.ID
 }
 
-var FetchBatchRawRequest_RangeTimeType_DEFAULT TimeType = 0
+var WriteRequest_Datapoint_DEFAULT *Datapoint
 
-func (p *FetchBatchRawRequest) GetRangeTimeType() TimeType {
-	return p.RangeTimeType
+func (p *WriteRequest) GetDatapoint() *Datapoint {
+	if !p.IsSetDatapoint() {
+		return WriteRequest_Datapoint_DEFAULT
+	}
+	return p.Datapoint
 }
-func (p *FetchBatchRawRequest) IsSetRangeTimeType() bool {
-	return p.RangeTimeType != FetchBatchRawRequest_RangeTimeType_DEFAULT
+func (p *WriteRequest) IsSetDatapoint() bool {
+	return p.Datapoint != nil
 }
 
-func (p *FetchBatchRawRequest) Read(iprot thrift.TProtocol) error {
+func (p *WriteRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetRangeStart bool = false
-	var issetRangeEnd bool = false
 	var issetNameSpace bool = false
-	var issetIds bool = false
+	var issetID bool = false
+	var issetDatapoint bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -1652,26 +1985,17 @@ func (p *FetchBatchRawRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetRangeStart = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetRangeEnd = true
+			issetID = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
-			issetIds = true
-		case 5:
-			if err := p.ReadField5(iprot); err != nil {
-				return err
-			}
+			issetDatapoint = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -1684,82 +2008,48 @@ func (p *FetchBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetRangeStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
-	}
-	if !issetRangeEnd {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
-	}
 	if !issetNameSpace {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
-	if !issetIds {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Ids is not set"))
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
+	}
+	if !issetDatapoint {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBatchRawRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *WriteRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.RangeStart = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *FetchBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *WriteRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.RangeEnd = v
-	}
-	return nil
-}
-
-func (p *FetchBatchRawRequest) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.NameSpace = v
+		p.ID = v
 	}
 	return nil
 }
 
-func (p *FetchBatchRawRequest) ReadField4(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([][]byte, 0, size)
-	p.Ids = tSlice
-	for i := 0; i < size; i++ {
-		var _elem3 []byte
-		if v, err := iprot.ReadBinary(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem3 = v
-		}
-		p.Ids = append(p.Ids, _elem3)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *WriteRequest) ReadField3(iprot thrift.TProtocol) error {
+	p.Datapoint = &Datapoint{
+		TimestampTimeType: 0,
 	}
-	return nil
-}
-
-func (p *FetchBatchRawRequest) ReadField5(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
-	} else {
-		temp := TimeType(v)
-		p.RangeTimeType = temp
+	if err := p.Datapoint.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
 	}
 	return nil
 }
 
-func (p *FetchBatchRawRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBatchRawRequest"); err != nil {
+func (p *WriteRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -1772,12 +2062,6 @@ func (p *FetchBatchRawRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField3(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -1788,114 +2072,101 @@ func (p *FetchBatchRawRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBatchRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:rangeStart: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:rangeStart: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeEnd: ", p), err)
+func (p *WriteRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (2) field write error: ", p), err)
+	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeEnd: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nameSpace: ", p), err)
+func (p *WriteRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:id: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (3) field write error: ", p), err)
+	if err := oprot.WriteString(string(p.ID)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:id: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("ids", thrift.LIST, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:ids: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRING, len(p.Ids)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Ids {
-		if err := oprot.WriteBinary(v); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
-		}
+func (p *WriteRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:datapoint: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	if err := p.Datapoint.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:ids: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchBatchRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetRangeTimeType() {
-		if err := oprot.WriteFieldBegin("rangeTimeType", thrift.I32, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:rangeTimeType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.RangeTimeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.rangeTimeType (5) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:rangeTimeType: ", p), err)
-		}
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:datapoint: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawRequest) String() string {
+func (p *WriteRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBatchRawRequest(%+v)", *p)
+	return fmt.Sprintf("WriteRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpaces
-//  - Elements
-type FetchBatchRawV2Request struct {
-	NameSpaces [][]byte                         `thrift:"nameSpaces,1,required" db:"nameSpaces" json:"nameSpaces"`
-	Elements   []*FetchBatchRawV2RequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+//  - NameSpace
+//  - ID
+//  - Tags
+//  - Datapoint
+type WriteTaggedRequest struct {
+	NameSpace string     `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	ID        string     `thrift:"id,2,required" db:"id" json:"id"`
+	Tags      []*Tag     `thrift:"tags,3,required" db:"tags" json:"tags"`
+	Datapoint *Datapoint `thrift:"datapoint,4,required" db:"datapoint" json:"datapoint"`
 }
 
-func NewFetchBatchRawV2Request() *FetchBatchRawV2Request {
-	return &FetchBatchRawV2Request{}
+func NewWriteTaggedRequest() *WriteTaggedRequest {
+	return &WriteTaggedRequest{}
 }
 
-func (p *FetchBatchRawV2Request) GetNameSpaces() [][]byte {
-	return p.NameSpaces
+func (p *WriteTaggedRequest) GetNameSpace() string {
+	return p.NameSpace
 }
 
-func (p *FetchBatchRawV2Request) GetElements() []*FetchBatchRawV2RequestElement {
-	return p.Elements
+func (p *WriteTaggedRequest) GetID() string {
+	return p.ID
 }
-func (p *FetchBatchRawV2Request) Read(iprot thrift.TProtocol) error {
+
+func (p *WriteTaggedRequest) GetTags() []*Tag {
+	return p.Tags
+}
+
+var WriteTaggedRequest_Datapoint_DEFAULT *Datapoint
+
+func (p *WriteTaggedRequest) GetDatapoint() *Datapoint {
+	if !p.IsSetDatapoint() {
+		return WriteTaggedRequest_Datapoint_DEFAULT
+	}
+	return p.Datapoint
+}
+func (p *WriteTaggedRequest) IsSetDatapoint() bool {
+	return p.Datapoint != nil
+}
+
+func (p *WriteTaggedRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpaces bool = false
-	var issetElements bool = false
+	var issetNameSpace bool = false
+	var issetID bool = false
+	var issetTags bool = false
+	var issetDatapoint bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -1910,12 +2181,22 @@ func (p *FetchBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpaces = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetElements = true
+			issetID = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetTags = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetDatapoint = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -1928,52 +2209,52 @@ func (p *FetchBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpaces {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
-	if !issetElements {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
+	}
+	if !issetTags {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Tags is not set"))
+	}
+	if !issetDatapoint {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2Request) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([][]byte, 0, size)
-	p.NameSpaces = tSlice
-	for i := 0; i < size; i++ {
-		var _elem4 []byte
-		if v, err := iprot.ReadBinary(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem4 = v
-		}
-		p.NameSpaces = append(p.NameSpaces, _elem4)
+func (p *WriteTaggedRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.NameSpace = v
 	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	return nil
+}
+
+func (p *WriteTaggedRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.ID = v
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
+func (p *WriteTaggedRequest) ReadField3(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*FetchBatchRawV2RequestElement, 0, size)
-	p.Elements = tSlice
+	tSlice := make([]*Tag, 0, size)
+	p.Tags = tSlice
 	for i := 0; i < size; i++ {
-		_elem5 := &FetchBatchRawV2RequestElement{
-			RangeTimeType: 0,
-		}
-		if err := _elem5.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem5), err)
+		_elem2 := &Tag{}
+		if err := _elem2.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem2), err)
 		}
-		p.Elements = append(p.Elements, _elem5)
+		p.Tags = append(p.Tags, _elem2)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -1981,8 +2262,18 @@ func (p *FetchBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBatchRawV2Request) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBatchRawV2Request"); err != nil {
+func (p *WriteTaggedRequest) ReadField4(iprot thrift.TProtocol) error {
+	p.Datapoint = &Datapoint{
+		TimestampTimeType: 0,
+	}
+	if err := p.Datapoint.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+	}
+	return nil
+}
+
+func (p *WriteTaggedRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteTaggedRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -1992,6 +2283,12 @@ func (p *FetchBatchRawV2Request) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2002,35 +2299,40 @@ func (p *FetchBatchRawV2Request) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBatchRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpaces: ", p), err)
+func (p *WriteTaggedRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
+	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
-	for _, v := range p.NameSpaces {
-		if err := oprot.WriteBinary(v); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
-		}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	return err
+}
+
+func (p *WriteTaggedRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:id: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.ID)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpaces: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:id: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
+func (p *WriteTaggedRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("tags", thrift.LIST, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:tags: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Tags)); err != nil {
 		return thrift.PrependError("error writing list begin: ", err)
 	}
-	for _, v := range p.Elements {
+	for _, v := range p.Tags {
 		if err := v.Write(oprot); err != nil {
 			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
 		}
@@ -2039,72 +2341,97 @@ func (p *FetchBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error)
 		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:tags: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawV2Request) String() string {
+func (p *WriteTaggedRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:datapoint: ", p), err)
+	}
+	if err := p.Datapoint.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:datapoint: ", p), err)
+	}
+	return err
+}
+
+func (p *WriteTaggedRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBatchRawV2Request(%+v)", *p)
+	return fmt.Sprintf("WriteTaggedRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
 //  - RangeStart
 //  - RangeEnd
-//  - ID
+//  - NameSpace
+//  - Ids
 //  - RangeTimeType
-type FetchBatchRawV2RequestElement struct {
-	NameSpace     int64    `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	RangeStart    int64    `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd      int64    `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
-	ID            []byte   `thrift:"id,4,required" db:"id" json:"id"`
-	RangeTimeType TimeType `thrift:"rangeTimeType,5" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
+//  - FetchBlockMetadata
+type FetchBatchRawRequest struct {
+	RangeStart         int64    `thrift:"rangeStart,1,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd           int64    `thrift:"rangeEnd,2,required" db:"rangeEnd" json:"rangeEnd"`
+	NameSpace          []byte   `thrift:"nameSpace,3,required" db:"nameSpace" json:"nameSpace"`
+	Ids                [][]byte `thrift:"ids,4,required" db:"ids" json:"ids"`
+	RangeTimeType      TimeType `thrift:"rangeTimeType,5" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
+	FetchBlockMetadata bool     `thrift:"fetchBlockMetadata,6" db:"fetchBlockMetadata" json:"fetchBlockMetadata,omitempty"`
 }
 
-func NewFetchBatchRawV2RequestElement() *FetchBatchRawV2RequestElement {
-	return &FetchBatchRawV2RequestElement{
+func NewFetchBatchRawRequest() *FetchBatchRawRequest {
+	return &FetchBatchRawRequest{
 		RangeTimeType: 0,
 	}
 }
 
-func (p *FetchBatchRawV2RequestElement) GetNameSpace() int64 {
-	return p.NameSpace
-}
-
-func (p *FetchBatchRawV2RequestElement) GetRangeStart() int64 {
+func (p *FetchBatchRawRequest) GetRangeStart() int64 {
 	return p.RangeStart
 }
 
-func (p *FetchBatchRawV2RequestElement) GetRangeEnd() int64 {
+func (p *FetchBatchRawRequest) GetRangeEnd() int64 {
 	return p.RangeEnd
 }
 
-func (p *FetchBatchRawV2RequestElement) GetID() []byte {
-	return p.ID
+func (p *FetchBatchRawRequest) GetNameSpace() []byte {
+	return p.NameSpace
 }
 
-var FetchBatchRawV2RequestElement_RangeTimeType_DEFAULT TimeType = 0
+func (p *FetchBatchRawRequest) GetIds() [][]byte {
+	return p.Ids
+}
 
-func (p *FetchBatchRawV2RequestElement) GetRangeTimeType() TimeType {
+var FetchBatchRawRequest_RangeTimeType_DEFAULT TimeType = 0
+
+func (p *FetchBatchRawRequest) GetRangeTimeType() TimeType {
 	return p.RangeTimeType
 }
-func (p *FetchBatchRawV2RequestElement) IsSetRangeTimeType() bool {
-	return p.RangeTimeType != FetchBatchRawV2RequestElement_RangeTimeType_DEFAULT
+
+var FetchBatchRawRequest_FetchBlockMetadata_DEFAULT bool = false
+
+func (p *FetchBatchRawRequest) GetFetchBlockMetadata() bool {
+	return p.FetchBlockMetadata
+}
+func (p *FetchBatchRawRequest) IsSetRangeTimeType() bool {
+	return p.RangeTimeType != FetchBatchRawRequest_RangeTimeType_DEFAULT
 }
 
-func (p *FetchBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawRequest) IsSetFetchBlockMetadata() bool {
+	return p.FetchBlockMetadata != FetchBatchRawRequest_FetchBlockMetadata_DEFAULT
+}
+
+func (p *FetchBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
 	var issetRangeStart bool = false
 	var issetRangeEnd bool = false
-	var issetID bool = false
+	var issetNameSpace bool = false
+	var issetIds bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -2119,26 +2446,30 @@ func (p *FetchBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetRangeStart = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetRangeStart = true
+			issetRangeEnd = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetRangeEnd = true
+			issetNameSpace = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
-			issetID = true
+			issetIds = true
 		case 5:
 			if err := p.ReadField5(iprot); err != nil {
 				return err
 			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -2151,58 +2482,71 @@ func (p *FetchBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
 	if !issetRangeStart {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
 	}
 	if !issetRangeEnd {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
 	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	if !issetIds {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Ids is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawRequest) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.NameSpace = v
+		p.RangeStart = v
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) ReadField2(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.RangeStart = v
+		p.RangeEnd = v
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *FetchBatchRawRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.RangeEnd = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.ID = v
+func (p *FetchBatchRawRequest) ReadField4(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([][]byte, 0, size)
+	p.Ids = tSlice
+	for i := 0; i < size; i++ {
+		var _elem3 []byte
+		if v, err := iprot.ReadBinary(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem3 = v
+		}
+		p.Ids = append(p.Ids, _elem3)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) ReadField5(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawRequest) ReadField5(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI32(); err != nil {
 		return thrift.PrependError("error reading field 5: ", err)
 	} else {
@@ -2212,8 +2556,17 @@ func (p *FetchBatchRawV2RequestElement) ReadField5(iprot thrift.TProtocol) error
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBatchRawV2RequestElement"); err != nil {
+func (p *FetchBatchRawRequest) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		p.FetchBlockMetadata = v
+	}
+	return nil
+}
+
+func (p *FetchBatchRawRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBatchRawRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -2232,6 +2585,9 @@ func (p *FetchBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField5(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2242,59 +2598,67 @@ func (p *FetchBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBatchRawV2RequestElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+func (p *FetchBatchRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:rangeStart: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:rangeStart: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawV2RequestElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
+func (p *FetchBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeEnd: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeEnd: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawV2RequestElement) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
+func (p *FetchBatchRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (3) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawV2RequestElement) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:id: ", p), err)
+func (p *FetchBatchRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("ids", thrift.LIST, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:ids: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (4) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRING, len(p.Ids)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Ids {
+		if err := oprot.WriteBinary(v); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:ids: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawV2RequestElement) writeField5(oprot thrift.TProtocol) (err error) {
+func (p *FetchBatchRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
 	if p.IsSetRangeTimeType() {
 		if err := oprot.WriteFieldBegin("rangeTimeType", thrift.I32, 5); err != nil {
 			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:rangeTimeType: ", p), err)
@@ -2309,31 +2673,53 @@ func (p *FetchBatchRawV2RequestElement) writeField5(oprot thrift.TProtocol) (err
 	return err
 }
 
-func (p *FetchBatchRawV2RequestElement) String() string {
+func (p *FetchBatchRawRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFetchBlockMetadata() {
+		if err := oprot.WriteFieldBegin("fetchBlockMetadata", thrift.BOOL, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:fetchBlockMetadata: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.FetchBlockMetadata)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.fetchBlockMetadata (6) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:fetchBlockMetadata: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchBatchRawRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBatchRawV2RequestElement(%+v)", *p)
+	return fmt.Sprintf("FetchBatchRawRequest(%+v)", *p)
 }
 
 // Attributes:
+//  - NameSpaces
 //  - Elements
-type FetchBatchRawResult_ struct {
-	Elements []*FetchRawResult_ `thrift:"elements,1,required" db:"elements" json:"elements"`
+type FetchBatchRawV2Request struct {
+	NameSpaces [][]byte                         `thrift:"nameSpaces,1,required" db:"nameSpaces" json:"nameSpaces"`
+	Elements   []*FetchBatchRawV2RequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
 }
 
-func NewFetchBatchRawResult_() *FetchBatchRawResult_ {
-	return &FetchBatchRawResult_{}
+func NewFetchBatchRawV2Request() *FetchBatchRawV2Request {
+	return &FetchBatchRawV2Request{}
 }
 
-func (p *FetchBatchRawResult_) GetElements() []*FetchRawResult_ {
+func (p *FetchBatchRawV2Request) GetNameSpaces() [][]byte {
+	return p.NameSpaces
+}
+
+func (p *FetchBatchRawV2Request) GetElements() []*FetchBatchRawV2RequestElement {
 	return p.Elements
 }
-func (p *FetchBatchRawResult_) Read(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
+	var issetNameSpaces bool = false
 	var issetElements bool = false
 
 	for {
@@ -2349,6 +2735,11 @@ func (p *FetchBatchRawResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
+			issetNameSpaces = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
 			issetElements = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
@@ -2362,25 +2753,30 @@ func (p *FetchBatchRawResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
+	if !issetNameSpaces {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
+	}
 	if !issetElements {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBatchRawResult_) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawV2Request) ReadField1(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*FetchRawResult_, 0, size)
-	p.Elements = tSlice
+	tSlice := make([][]byte, 0, size)
+	p.NameSpaces = tSlice
 	for i := 0; i < size; i++ {
-		_elem6 := &FetchRawResult_{}
-		if err := _elem6.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem6), err)
+		var _elem4 []byte
+		if v, err := iprot.ReadBinary(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem4 = v
 		}
-		p.Elements = append(p.Elements, _elem6)
+		p.NameSpaces = append(p.NameSpaces, _elem4)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -2388,14 +2784,39 @@ func (p *FetchBatchRawResult_) ReadField1(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBatchRawResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBatchRawResult"); err != nil {
+func (p *FetchBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*FetchBatchRawV2RequestElement, 0, size)
+	p.Elements = tSlice
+	for i := 0; i < size; i++ {
+		_elem5 := &FetchBatchRawV2RequestElement{
+			RangeTimeType: 0,
+		}
+		if err := _elem5.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem5), err)
+		}
+		p.Elements = append(p.Elements, _elem5)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *FetchBatchRawV2Request) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBatchRawV2Request"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2406,9 +2827,30 @@ func (p *FetchBatchRawResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBatchRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
+func (p *FetchBatchRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpaces: ", p), err)
+	}
+	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.NameSpaces {
+		if err := oprot.WriteBinary(v); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpaces: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
 	}
 	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
 		return thrift.PrependError("error writing list begin: ", err)
@@ -2422,52 +2864,72 @@ func (p *FetchBatchRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
 		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBatchRawResult_) String() string {
+func (p *FetchBatchRawV2Request) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBatchRawResult_(%+v)", *p)
+	return fmt.Sprintf("FetchBatchRawV2Request(%+v)", *p)
 }
 
 // Attributes:
-//  - Segments
-//  - Err
-type FetchRawResult_ struct {
-	Segments []*Segments `thrift:"segments,1,required" db:"segments" json:"segments"`
-	Err      *Error      `thrift:"err,2" db:"err" json:"err,omitempty"`
+//  - NameSpace
+//  - RangeStart
+//  - RangeEnd
+//  - ID
+//  - RangeTimeType
+type FetchBatchRawV2RequestElement struct {
+	NameSpace     int64    `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	RangeStart    int64    `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd      int64    `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
+	ID            []byte   `thrift:"id,4,required" db:"id" json:"id"`
+	RangeTimeType TimeType `thrift:"rangeTimeType,5" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
 }
 
-func NewFetchRawResult_() *FetchRawResult_ {
-	return &FetchRawResult_{}
+func NewFetchBatchRawV2RequestElement() *FetchBatchRawV2RequestElement {
+	return &FetchBatchRawV2RequestElement{
+		RangeTimeType: 0,
+	}
 }
 
-func (p *FetchRawResult_) GetSegments() []*Segments {
-	return p.Segments
+func (p *FetchBatchRawV2RequestElement) GetNameSpace() int64 {
+	return p.NameSpace
 }
 
-var FetchRawResult__Err_DEFAULT *Error
+func (p *FetchBatchRawV2RequestElement) GetRangeStart() int64 {
+	return p.RangeStart
+}
 
-func (p *FetchRawResult_) GetErr() *Error {
-	if !p.IsSetErr() {
-		return FetchRawResult__Err_DEFAULT
-	}
-	return p.Err
+func (p *FetchBatchRawV2RequestElement) GetRangeEnd() int64 {
+	return p.RangeEnd
 }
-func (p *FetchRawResult_) IsSetErr() bool {
-	return p.Err != nil
+
+func (p *FetchBatchRawV2RequestElement) GetID() []byte {
+	return p.ID
 }
 
-func (p *FetchRawResult_) Read(iprot thrift.TProtocol) error {
+var FetchBatchRawV2RequestElement_RangeTimeType_DEFAULT TimeType = 0
+
+func (p *FetchBatchRawV2RequestElement) GetRangeTimeType() TimeType {
+	return p.RangeTimeType
+}
+func (p *FetchBatchRawV2RequestElement) IsSetRangeTimeType() bool {
+	return p.RangeTimeType != FetchBatchRawV2RequestElement_RangeTimeType_DEFAULT
+}
+
+func (p *FetchBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetSegments bool = false
+	var issetNameSpace bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetID bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -2482,11 +2944,26 @@ func (p *FetchRawResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetSegments = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
+			issetRangeStart = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetRangeEnd = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetID = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -2499,44 +2976,69 @@ func (p *FetchRawResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetSegments {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Segments is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
 	return nil
 }
 
-func (p *FetchRawResult_) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
+func (p *FetchBatchRawV2RequestElement) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.NameSpace = v
 	}
-	tSlice := make([]*Segments, 0, size)
-	p.Segments = tSlice
-	for i := 0; i < size; i++ {
-		_elem7 := &Segments{}
-		if err := _elem7.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem7), err)
-		}
-		p.Segments = append(p.Segments, _elem7)
+	return nil
+}
+
+func (p *FetchBatchRawV2RequestElement) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.RangeStart = v
 	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	return nil
+}
+
+func (p *FetchBatchRawV2RequestElement) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RangeEnd = v
 	}
 	return nil
 }
 
-func (p *FetchRawResult_) ReadField2(iprot thrift.TProtocol) error {
-	p.Err = &Error{
-		Type: 0,
+func (p *FetchBatchRawV2RequestElement) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.ID = v
 	}
-	if err := p.Err.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	return nil
+}
+
+func (p *FetchBatchRawV2RequestElement) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		temp := TimeType(v)
+		p.RangeTimeType = temp
 	}
 	return nil
 }
 
-func (p *FetchRawResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchRawResult"); err != nil {
+func (p *FetchBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBatchRawV2RequestElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -2546,6 +3048,15 @@ func (p *FetchRawResult_) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2556,88 +3067,100 @@ func (p *FetchRawResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("segments", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:segments: ", p), err)
+func (p *FetchBatchRawV2RequestElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Segments)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
+	if err := oprot.WriteI64(int64(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
-	for _, v := range p.Segments {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	return err
+}
+
+func (p *FetchBatchRawV2RequestElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:segments: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchRawResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetErr() {
-		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:err: ", p), err)
-		}
-		if err := p.Err.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:err: ", p), err)
-		}
+func (p *FetchBatchRawV2RequestElement) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchRawResult_) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *FetchBatchRawV2RequestElement) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:id: ", p), err)
 	}
-	return fmt.Sprintf("FetchRawResult_(%+v)", *p)
-}
-
-// Attributes:
-//  - Merged
-//  - Unmerged
-type Segments struct {
-	Merged   *Segment   `thrift:"merged,1" db:"merged" json:"merged,omitempty"`
-	Unmerged []*Segment `thrift:"unmerged,2" db:"unmerged" json:"unmerged,omitempty"`
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:id: ", p), err)
+	}
+	return err
 }
 
-func NewSegments() *Segments {
-	return &Segments{}
+func (p *FetchBatchRawV2RequestElement) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRangeTimeType() {
+		if err := oprot.WriteFieldBegin("rangeTimeType", thrift.I32, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:rangeTimeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.RangeTimeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.rangeTimeType (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:rangeTimeType: ", p), err)
+		}
+	}
+	return err
 }
 
-var Segments_Merged_DEFAULT *Segment
-
-func (p *Segments) GetMerged() *Segment {
-	if !p.IsSetMerged() {
-		return Segments_Merged_DEFAULT
+func (p *FetchBatchRawV2RequestElement) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	return p.Merged
+	return fmt.Sprintf("FetchBatchRawV2RequestElement(%+v)", *p)
 }
 
-var Segments_Unmerged_DEFAULT []*Segment
-
-func (p *Segments) GetUnmerged() []*Segment {
-	return p.Unmerged
-}
-func (p *Segments) IsSetMerged() bool {
-	return p.Merged != nil
+// Attributes:
+//  - Elements
+type FetchBatchRawResult_ struct {
+	Elements []*FetchRawResult_ `thrift:"elements,1,required" db:"elements" json:"elements"`
 }
 
-func (p *Segments) IsSetUnmerged() bool {
-	return p.Unmerged != nil
+func NewFetchBatchRawResult_() *FetchBatchRawResult_ {
+	return &FetchBatchRawResult_{}
 }
 
-func (p *Segments) Read(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawResult_) GetElements() []*FetchRawResult_ {
+	return p.Elements
+}
+func (p *FetchBatchRawResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
+	var issetElements bool = false
+
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
 		if err != nil {
@@ -2651,10 +3174,7 @@ func (p *Segments) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
+			issetElements = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -2667,30 +3187,25 @@ func (p *Segments) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	return nil
-}
-
-func (p *Segments) ReadField1(iprot thrift.TProtocol) error {
-	p.Merged = &Segment{}
-	if err := p.Merged.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Merged), err)
+	if !issetElements {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
 	return nil
 }
 
-func (p *Segments) ReadField2(iprot thrift.TProtocol) error {
+func (p *FetchBatchRawResult_) ReadField1(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*Segment, 0, size)
-	p.Unmerged = tSlice
+	tSlice := make([]*FetchRawResult_, 0, size)
+	p.Elements = tSlice
 	for i := 0; i < size; i++ {
-		_elem8 := &Segment{}
-		if err := _elem8.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem8), err)
+		_elem6 := &FetchRawResult_{}
+		if err := _elem6.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem6), err)
 		}
-		p.Unmerged = append(p.Unmerged, _elem8)
+		p.Elements = append(p.Elements, _elem6)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -2698,17 +3213,14 @@ func (p *Segments) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Segments) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Segments"); err != nil {
+func (p *FetchBatchRawResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBatchRawResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2719,107 +3231,68 @@ func (p *Segments) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Segments) writeField1(oprot thrift.TProtocol) (err error) {
-	if p.IsSetMerged() {
-		if err := oprot.WriteFieldBegin("merged", thrift.STRUCT, 1); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:merged: ", p), err)
-		}
-		if err := p.Merged.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Merged), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:merged: ", p), err)
-		}
+func (p *FetchBatchRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
 	}
-	return err
-}
-
-func (p *Segments) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetUnmerged() {
-		if err := oprot.WriteFieldBegin("unmerged", thrift.LIST, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:unmerged: ", p), err)
-		}
-		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Unmerged)); err != nil {
-			return thrift.PrependError("error writing list begin: ", err)
-		}
-		for _, v := range p.Unmerged {
-			if err := v.Write(oprot); err != nil {
-				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-			}
-		}
-		if err := oprot.WriteListEnd(); err != nil {
-			return thrift.PrependError("error writing list end: ", err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:unmerged: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
 		}
 	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
+	}
 	return err
 }
 
-func (p *Segments) String() string {
+func (p *FetchBatchRawResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Segments(%+v)", *p)
+	return fmt.Sprintf("FetchBatchRawResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - Head
-//  - Tail
-//  - StartTime
-//  - BlockSize
-type Segment struct {
-	Head      []byte `thrift:"head,1,required" db:"head" json:"head"`
-	Tail      []byte `thrift:"tail,2,required" db:"tail" json:"tail"`
-	StartTime *int64 `thrift:"startTime,3" db:"startTime" json:"startTime,omitempty"`
-	BlockSize *int64 `thrift:"blockSize,4" db:"blockSize" json:"blockSize,omitempty"`
-}
-
-func NewSegment() *Segment {
-	return &Segment{}
-}
-
-func (p *Segment) GetHead() []byte {
-	return p.Head
+//  - Segments
+//  - Err
+type FetchRawResult_ struct {
+	Segments []*Segments `thrift:"segments,1,required" db:"segments" json:"segments"`
+	Err      *Error      `thrift:"err,2" db:"err" json:"err,omitempty"`
 }
 
-func (p *Segment) GetTail() []byte {
-	return p.Tail
+func NewFetchRawResult_() *FetchRawResult_ {
+	return &FetchRawResult_{}
 }
 
-var Segment_StartTime_DEFAULT int64
-
-func (p *Segment) GetStartTime() int64 {
-	if !p.IsSetStartTime() {
-		return Segment_StartTime_DEFAULT
-	}
-	return *p.StartTime
+func (p *FetchRawResult_) GetSegments() []*Segments {
+	return p.Segments
 }
 
-var Segment_BlockSize_DEFAULT int64
+var FetchRawResult__Err_DEFAULT *Error
 
-func (p *Segment) GetBlockSize() int64 {
-	if !p.IsSetBlockSize() {
-		return Segment_BlockSize_DEFAULT
+func (p *FetchRawResult_) GetErr() *Error {
+	if !p.IsSetErr() {
+		return FetchRawResult__Err_DEFAULT
 	}
-	return *p.BlockSize
-}
-func (p *Segment) IsSetStartTime() bool {
-	return p.StartTime != nil
+	return p.Err
 }
-
-func (p *Segment) IsSetBlockSize() bool {
-	return p.BlockSize != nil
+func (p *FetchRawResult_) IsSetErr() bool {
+	return p.Err != nil
 }
 
-func (p *Segment) Read(iprot thrift.TProtocol) error {
+func (p *FetchRawResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetHead bool = false
-	var issetTail bool = false
+	var issetSegments bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -2834,20 +3307,11 @@ func (p *Segment) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetHead = true
+			issetSegments = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetTail = true
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -2860,53 +3324,44 @@ func (p *Segment) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetHead {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Head is not set"))
-	}
-	if !issetTail {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Tail is not set"))
-	}
-	return nil
-}
-
-func (p *Segment) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.Head = v
+	if !issetSegments {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Segments is not set"))
 	}
 	return nil
 }
 
-func (p *Segment) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.Tail = v
+func (p *FetchRawResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	return nil
-}
-
-func (p *Segment) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.StartTime = &v
+	tSlice := make([]*Segments, 0, size)
+	p.Segments = tSlice
+	for i := 0; i < size; i++ {
+		_elem7 := &Segments{}
+		if err := _elem7.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem7), err)
+		}
+		p.Segments = append(p.Segments, _elem7)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *Segment) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.BlockSize = &v
+func (p *FetchRawResult_) ReadField2(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
 	}
 	return nil
 }
 
-func (p *Segment) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Segment"); err != nil {
+func (p *FetchRawResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchRawResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -2916,12 +3371,6 @@ func (p *Segment) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -2932,146 +3381,88 @@ func (p *Segment) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Segment) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("head", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:head: ", p), err)
-	}
-	if err := oprot.WriteBinary(p.Head); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.head (1) field write error: ", p), err)
+func (p *FetchRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("segments", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:segments: ", p), err)
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:head: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Segments)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
 	}
-	return err
-}
-
-func (p *Segment) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("tail", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:tail: ", p), err)
+	for _, v := range p.Segments {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
 	}
-	if err := oprot.WriteBinary(p.Tail); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.tail (2) field write error: ", p), err)
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:tail: ", p), err)
-	}
-	return err
-}
-
-func (p *Segment) writeField3(oprot thrift.TProtocol) (err error) {
-	if p.IsSetStartTime() {
-		if err := oprot.WriteFieldBegin("startTime", thrift.I64, 3); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:startTime: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.StartTime)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.startTime (3) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:startTime: ", p), err)
-		}
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:segments: ", p), err)
 	}
 	return err
 }
 
-func (p *Segment) writeField4(oprot thrift.TProtocol) (err error) {
-	if p.IsSetBlockSize() {
-		if err := oprot.WriteFieldBegin("blockSize", thrift.I64, 4); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:blockSize: ", p), err)
+func (p *FetchRawResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:err: ", p), err)
 		}
-		if err := oprot.WriteI64(int64(*p.BlockSize)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.blockSize (4) field write error: ", p), err)
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:blockSize: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:err: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *Segment) String() string {
+func (p *FetchRawResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Segment(%+v)", *p)
+	return fmt.Sprintf("FetchRawResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
-//  - Query
-//  - RangeStart
-//  - RangeEnd
-//  - FetchData
-//  - Limit
-//  - RangeTimeType
-type FetchTaggedRequest struct {
-	NameSpace     []byte   `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	Query         []byte   `thrift:"query,2,required" db:"query" json:"query"`
-	RangeStart    int64    `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd      int64    `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
-	FetchData     bool     `thrift:"fetchData,5,required" db:"fetchData" json:"fetchData"`
-	Limit         *int64   `thrift:"limit,6" db:"limit" json:"limit,omitempty"`
-	RangeTimeType TimeType `thrift:"rangeTimeType,7" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
-}
-
-func NewFetchTaggedRequest() *FetchTaggedRequest {
-	return &FetchTaggedRequest{
-		RangeTimeType: 0,
-	}
-}
-
-func (p *FetchTaggedRequest) GetNameSpace() []byte {
-	return p.NameSpace
-}
-
-func (p *FetchTaggedRequest) GetQuery() []byte {
-	return p.Query
-}
-
-func (p *FetchTaggedRequest) GetRangeStart() int64 {
-	return p.RangeStart
-}
-
-func (p *FetchTaggedRequest) GetRangeEnd() int64 {
-	return p.RangeEnd
+//  - Merged
+//  - Unmerged
+type Segments struct {
+	Merged   *Segment   `thrift:"merged,1" db:"merged" json:"merged,omitempty"`
+	Unmerged []*Segment `thrift:"unmerged,2" db:"unmerged" json:"unmerged,omitempty"`
 }
 
-func (p *FetchTaggedRequest) GetFetchData() bool {
-	return p.FetchData
+func NewSegments() *Segments {
+	return &Segments{}
 }
 
-var FetchTaggedRequest_Limit_DEFAULT int64
+var Segments_Merged_DEFAULT *Segment
 
-func (p *FetchTaggedRequest) GetLimit() int64 {
-	if !p.IsSetLimit() {
-		return FetchTaggedRequest_Limit_DEFAULT
+func (p *Segments) GetMerged() *Segment {
+	if !p.IsSetMerged() {
+		return Segments_Merged_DEFAULT
 	}
-	return *p.Limit
+	return p.Merged
 }
 
-var FetchTaggedRequest_RangeTimeType_DEFAULT TimeType = 0
+var Segments_Unmerged_DEFAULT []*Segment
 
-func (p *FetchTaggedRequest) GetRangeTimeType() TimeType {
-	return p.RangeTimeType
+func (p *Segments) GetUnmerged() []*Segment {
+	return p.Unmerged
 }
-func (p *FetchTaggedRequest) IsSetLimit() bool {
-	return p.Limit != nil
+func (p *Segments) IsSetMerged() bool {
+	return p.Merged != nil
 }
 
-func (p *FetchTaggedRequest) IsSetRangeTimeType() bool {
-	return p.RangeTimeType != FetchTaggedRequest_RangeTimeType_DEFAULT
+func (p *Segments) IsSetUnmerged() bool {
+	return p.Unmerged != nil
 }
 
-func (p *FetchTaggedRequest) Read(iprot thrift.TProtocol) error {
+func (p *Segments) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
-	var issetQuery bool = false
-	var issetRangeStart bool = false
-	var issetRangeEnd bool = false
-	var issetFetchData bool = false
-
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
 		if err != nil {
@@ -3085,35 +3476,10 @@ func (p *FetchTaggedRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetQuery = true
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
-			issetRangeStart = true
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
-			issetRangeEnd = true
-		case 5:
-			if err := p.ReadField5(iprot); err != nil {
-				return err
-			}
-			issetFetchData = true
-		case 6:
-			if err := p.ReadField6(iprot); err != nil {
-				return err
-			}
-		case 7:
-			if err := p.ReadField7(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -3126,90 +3492,39 @@ func (p *FetchTaggedRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
-	if !issetQuery {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Query is not set"))
-	}
-	if !issetRangeStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
-	}
-	if !issetRangeEnd {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
-	}
-	if !issetFetchData {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field FetchData is not set"))
-	}
 	return nil
 }
 
-func (p *FetchTaggedRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.NameSpace = v
+func (p *Segments) ReadField1(iprot thrift.TProtocol) error {
+	p.Merged = &Segment{}
+	if err := p.Merged.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Merged), err)
 	}
 	return nil
 }
 
-func (p *FetchTaggedRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.Query = v
+func (p *Segments) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	return nil
-}
-
-func (p *FetchTaggedRequest) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.RangeStart = v
+	tSlice := make([]*Segment, 0, size)
+	p.Unmerged = tSlice
+	for i := 0; i < size; i++ {
+		_elem8 := &Segment{}
+		if err := _elem8.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem8), err)
+		}
+		p.Unmerged = append(p.Unmerged, _elem8)
 	}
-	return nil
-}
-
-func (p *FetchTaggedRequest) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.RangeEnd = v
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *FetchTaggedRequest) ReadField5(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
-	} else {
-		p.FetchData = v
-	}
-	return nil
-}
-
-func (p *FetchTaggedRequest) ReadField6(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 6: ", err)
-	} else {
-		p.Limit = &v
-	}
-	return nil
-}
-
-func (p *FetchTaggedRequest) ReadField7(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 7: ", err)
-	} else {
-		temp := TimeType(v)
-		p.RangeTimeType = temp
-	}
-	return nil
-}
-
-func (p *FetchTaggedRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchTaggedRequest"); err != nil {
+func (p *Segments) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Segments"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -3219,21 +3534,6 @@ func (p *FetchTaggedRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField6(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField7(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -3244,134 +3544,152 @@ func (p *FetchTaggedRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchTaggedRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
-	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+func (p *Segments) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetMerged() {
+		if err := oprot.WriteFieldBegin("merged", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:merged: ", p), err)
+		}
+		if err := p.Merged.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Merged), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:merged: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *FetchTaggedRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("query", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:query: ", p), err)
-	}
-	if err := oprot.WriteBinary(p.Query); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.query (2) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:query: ", p), err)
+func (p *Segments) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetUnmerged() {
+		if err := oprot.WriteFieldBegin("unmerged", thrift.LIST, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:unmerged: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Unmerged)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.Unmerged {
+			if err := v.Write(oprot); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:unmerged: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *FetchTaggedRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeStart: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (3) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeStart: ", p), err)
+func (p *Segments) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	return err
+	return fmt.Sprintf("Segments(%+v)", *p)
 }
 
-func (p *FetchTaggedRequest) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:rangeEnd: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (4) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:rangeEnd: ", p), err)
-	}
-	return err
+// Attributes:
+//  - Head
+//  - Tail
+//  - StartTime
+//  - BlockSize
+//  - Checksum
+//  - LastWriteTime
+//  - Source
+type Segment struct {
+	Head          []byte       `thrift:"head,1,required" db:"head" json:"head"`
+	Tail          []byte       `thrift:"tail,2,required" db:"tail" json:"tail"`
+	StartTime     *int64       `thrift:"startTime,3" db:"startTime" json:"startTime,omitempty"`
+	BlockSize     *int64       `thrift:"blockSize,4" db:"blockSize" json:"blockSize,omitempty"`
+	Checksum      *int64       `thrift:"checksum,5" db:"checksum" json:"checksum,omitempty"`
+	LastWriteTime *int64       `thrift:"lastWriteTime,6" db:"lastWriteTime" json:"lastWriteTime,omitempty"`
+	Source        *BlockSource `thrift:"source,7" db:"source" json:"source,omitempty"`
 }
 
-func (p *FetchTaggedRequest) writeField5(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("fetchData", thrift.BOOL, 5); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:fetchData: ", p), err)
-	}
-	if err := oprot.WriteBool(bool(p.FetchData)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.fetchData (5) field write error: ", p), err)
+func NewSegment() *Segment {
+	return &Segment{}
+}
+
+func (p *Segment) GetHead() []byte {
+	return p.Head
+}
+
+func (p *Segment) GetTail() []byte {
+	return p.Tail
+}
+
+var Segment_StartTime_DEFAULT int64
+
+func (p *Segment) GetStartTime() int64 {
+	if !p.IsSetStartTime() {
+		return Segment_StartTime_DEFAULT
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 5:fetchData: ", p), err)
+	return *p.StartTime
+}
+
+var Segment_BlockSize_DEFAULT int64
+
+func (p *Segment) GetBlockSize() int64 {
+	if !p.IsSetBlockSize() {
+		return Segment_BlockSize_DEFAULT
 	}
-	return err
+	return *p.BlockSize
 }
 
-func (p *FetchTaggedRequest) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLimit() {
-		if err := oprot.WriteFieldBegin("limit", thrift.I64, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:limit: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.limit (6) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:limit: ", p), err)
-		}
+var Segment_Checksum_DEFAULT int64
+
+func (p *Segment) GetChecksum() int64 {
+	if !p.IsSetChecksum() {
+		return Segment_Checksum_DEFAULT
 	}
-	return err
+	return *p.Checksum
 }
 
-func (p *FetchTaggedRequest) writeField7(oprot thrift.TProtocol) (err error) {
-	if p.IsSetRangeTimeType() {
-		if err := oprot.WriteFieldBegin("rangeTimeType", thrift.I32, 7); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:rangeTimeType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.RangeTimeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.rangeTimeType (7) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:rangeTimeType: ", p), err)
-		}
+var Segment_LastWriteTime_DEFAULT int64
+
+func (p *Segment) GetLastWriteTime() int64 {
+	if !p.IsSetLastWriteTime() {
+		return Segment_LastWriteTime_DEFAULT
 	}
-	return err
+	return *p.LastWriteTime
 }
 
-func (p *FetchTaggedRequest) String() string {
-	if p == nil {
-		return "<nil>"
+var Segment_Source_DEFAULT BlockSource
+
+func (p *Segment) GetSource() BlockSource {
+	if !p.IsSetSource() {
+		return Segment_Source_DEFAULT
 	}
-	return fmt.Sprintf("FetchTaggedRequest(%+v)", *p)
+	return *p.Source
+}
+func (p *Segment) IsSetStartTime() bool {
+	return p.StartTime != nil
 }
 
-// Attributes:
-//  - Elements
-//  - Exhaustive
-type FetchTaggedResult_ struct {
-	Elements   []*FetchTaggedIDResult_ `thrift:"elements,1,required" db:"elements" json:"elements"`
-	Exhaustive bool                    `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
+func (p *Segment) IsSetBlockSize() bool {
+	return p.BlockSize != nil
 }
 
-func NewFetchTaggedResult_() *FetchTaggedResult_ {
-	return &FetchTaggedResult_{}
+func (p *Segment) IsSetChecksum() bool {
+	return p.Checksum != nil
 }
 
-func (p *FetchTaggedResult_) GetElements() []*FetchTaggedIDResult_ {
-	return p.Elements
+func (p *Segment) IsSetLastWriteTime() bool {
+	return p.LastWriteTime != nil
 }
 
-func (p *FetchTaggedResult_) GetExhaustive() bool {
-	return p.Exhaustive
+func (p *Segment) IsSetSource() bool {
+	return p.Source != nil
 }
-func (p *FetchTaggedResult_) Read(iprot thrift.TProtocol) error {
+
+func (p *Segment) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetElements bool = false
-	var issetExhaustive bool = false
+	var issetHead bool = false
+	var issetTail bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -3386,12 +3704,32 @@ func (p *FetchTaggedResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetElements = true
+			issetHead = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetExhaustive = true
+			issetTail = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -3404,46 +3742,81 @@ func (p *FetchTaggedResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetElements {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	if !issetHead {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Head is not set"))
 	}
-	if !issetExhaustive {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Exhaustive is not set"))
+	if !issetTail {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Tail is not set"))
 	}
 	return nil
 }
 
-func (p *FetchTaggedResult_) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*FetchTaggedIDResult_, 0, size)
-	p.Elements = tSlice
-	for i := 0; i < size; i++ {
-		_elem9 := &FetchTaggedIDResult_{}
-		if err := _elem9.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem9), err)
-		}
-		p.Elements = append(p.Elements, _elem9)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *Segment) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Head = v
 	}
 	return nil
 }
 
-func (p *FetchTaggedResult_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
+func (p *Segment) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.Exhaustive = v
+		p.Tail = v
 	}
 	return nil
 }
 
-func (p *FetchTaggedResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchTaggedResult"); err != nil {
+func (p *Segment) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.StartTime = &v
+	}
+	return nil
+}
+
+func (p *Segment) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.BlockSize = &v
+	}
+	return nil
+}
+
+func (p *Segment) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Checksum = &v
+	}
+	return nil
+}
+
+func (p *Segment) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		p.LastWriteTime = &v
+	}
+	return nil
+}
+
+func (p *Segment) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := BlockSource(v)
+		p.Source = &temp
+	}
+	return nil
+}
+
+func (p *Segment) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Segment"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -3453,6 +3826,21 @@ func (p *FetchTaggedResult_) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -3463,140 +3851,297 @@ func (p *FetchTaggedResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchTaggedResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
+func (p *Segment) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("head", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:head: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
+	if err := oprot.WriteBinary(p.Head); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.head (1) field write error: ", p), err)
 	}
-	for _, v := range p.Elements {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:head: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	return err
+}
+
+func (p *Segment) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("tail", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:tail: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.Tail); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.tail (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:tail: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchTaggedResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("exhaustive", thrift.BOOL, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:exhaustive: ", p), err)
+func (p *Segment) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetStartTime() {
+		if err := oprot.WriteFieldBegin("startTime", thrift.I64, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:startTime: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.StartTime)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.startTime (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:startTime: ", p), err)
+		}
 	}
-	if err := oprot.WriteBool(bool(p.Exhaustive)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.exhaustive (2) field write error: ", p), err)
+	return err
+}
+
+func (p *Segment) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetBlockSize() {
+		if err := oprot.WriteFieldBegin("blockSize", thrift.I64, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:blockSize: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.BlockSize)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.blockSize (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:blockSize: ", p), err)
+		}
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:exhaustive: ", p), err)
+	return err
+}
+
+func (p *Segment) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetChecksum() {
+		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:checksum: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.checksum (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:checksum: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *FetchTaggedResult_) String() string {
+func (p *Segment) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLastWriteTime() {
+		if err := oprot.WriteFieldBegin("lastWriteTime", thrift.I64, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:lastWriteTime: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.LastWriteTime)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.lastWriteTime (6) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:lastWriteTime: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *Segment) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSource() {
+		if err := oprot.WriteFieldBegin("source", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:source: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.Source)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.source (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:source: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *Segment) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchTaggedResult_(%+v)", *p)
+	return fmt.Sprintf("Segment(%+v)", *p)
 }
 
 // Attributes:
-//  - ID
 //  - NameSpace
-//  - EncodedTags
-//  - Segments
-//  - Err
-type FetchTaggedIDResult_ struct {
-	ID          []byte      `thrift:"id,1,required" db:"id" json:"id"`
-	NameSpace   []byte      `thrift:"nameSpace,2,required" db:"nameSpace" json:"nameSpace"`
-	EncodedTags []byte      `thrift:"encodedTags,3,required" db:"encodedTags" json:"encodedTags"`
-	Segments    []*Segments `thrift:"segments,4" db:"segments" json:"segments,omitempty"`
-	Err         *Error      `thrift:"err,5" db:"err" json:"err,omitempty"`
+//  - Query
+//  - RangeStart
+//  - RangeEnd
+//  - FetchData
+//  - Limit
+//  - RangeTimeType
+//  - SortedOrder
+//  - PageToken
+//  - SequentialScan
+//  - FetchBlockMetadata
+type FetchTaggedRequest struct {
+	NameSpace          []byte   `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Query              []byte   `thrift:"query,2,required" db:"query" json:"query"`
+	RangeStart         int64    `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd           int64    `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
+	FetchData          bool     `thrift:"fetchData,5,required" db:"fetchData" json:"fetchData"`
+	Limit              *int64   `thrift:"limit,6" db:"limit" json:"limit,omitempty"`
+	RangeTimeType      TimeType `thrift:"rangeTimeType,7" db:"rangeTimeType" json:"rangeTimeType,omitempty"`
+	SortedOrder        bool     `thrift:"sortedOrder,8" db:"sortedOrder" json:"sortedOrder,omitempty"`
+	PageToken          []byte   `thrift:"pageToken,9" db:"pageToken" json:"pageToken,omitempty"`
+	SequentialScan     bool     `thrift:"sequentialScan,10" db:"sequentialScan" json:"sequentialScan,omitempty"`
+	FetchBlockMetadata bool     `thrift:"fetchBlockMetadata,11" db:"fetchBlockMetadata" json:"fetchBlockMetadata,omitempty"`
 }
 
-func NewFetchTaggedIDResult_() *FetchTaggedIDResult_ {
-	return &FetchTaggedIDResult_{}
+func NewFetchTaggedRequest() *FetchTaggedRequest {
+	return &FetchTaggedRequest{
+		RangeTimeType: 0,
+	}
 }
 
-func (p *FetchTaggedIDResult_) GetID() []byte {
-	return p.ID
+func (p *FetchTaggedRequest) GetNameSpace() []byte {
+	return p.NameSpace
 }
 
-func (p *FetchTaggedIDResult_) GetNameSpace() []byte {
-	return p.NameSpace
+func (p *FetchTaggedRequest) GetQuery() []byte {
+	return p.Query
 }
 
-func (p *FetchTaggedIDResult_) GetEncodedTags() []byte {
-	return p.EncodedTags
+func (p *FetchTaggedRequest) GetRangeStart() int64 {
+	return p.RangeStart
 }
 
-var FetchTaggedIDResult__Segments_DEFAULT []*Segments
+func (p *FetchTaggedRequest) GetRangeEnd() int64 {
+	return p.RangeEnd
+}
 
-func (p *FetchTaggedIDResult_) GetSegments() []*Segments {
-	return p.Segments
+func (p *FetchTaggedRequest) GetFetchData() bool {
+	return p.FetchData
 }
 
-var FetchTaggedIDResult__Err_DEFAULT *Error
+var FetchTaggedRequest_Limit_DEFAULT int64
 
-func (p *FetchTaggedIDResult_) GetErr() *Error {
-	if !p.IsSetErr() {
-		return FetchTaggedIDResult__Err_DEFAULT
+func (p *FetchTaggedRequest) GetLimit() int64 {
+	if !p.IsSetLimit() {
+		return FetchTaggedRequest_Limit_DEFAULT
 	}
-	return p.Err
+	return *p.Limit
 }
-func (p *FetchTaggedIDResult_) IsSetSegments() bool {
-	return p.Segments != nil
+
+var FetchTaggedRequest_RangeTimeType_DEFAULT TimeType = 0
+
+func (p *FetchTaggedRequest) GetRangeTimeType() TimeType {
+	return p.RangeTimeType
 }
 
-func (p *FetchTaggedIDResult_) IsSetErr() bool {
-	return p.Err != nil
+var FetchTaggedRequest_SortedOrder_DEFAULT bool = false
+
+func (p *FetchTaggedRequest) GetSortedOrder() bool {
+	return p.SortedOrder
 }
 
-func (p *FetchTaggedIDResult_) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
+var FetchTaggedRequest_PageToken_DEFAULT []byte
 
-	var issetID bool = false
-	var issetNameSpace bool = false
-	var issetEncodedTags bool = false
+func (p *FetchTaggedRequest) GetPageToken() []byte {
+	return p.PageToken
+}
 
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		switch fieldId {
+var FetchTaggedRequest_SequentialScan_DEFAULT bool = false
+
+func (p *FetchTaggedRequest) GetSequentialScan() bool {
+	return p.SequentialScan
+}
+
+var FetchTaggedRequest_FetchBlockMetadata_DEFAULT bool = false
+
+func (p *FetchTaggedRequest) GetFetchBlockMetadata() bool {
+	return p.FetchBlockMetadata
+}
+func (p *FetchTaggedRequest) IsSetLimit() bool {
+	return p.Limit != nil
+}
+
+func (p *FetchTaggedRequest) IsSetRangeTimeType() bool {
+	return p.RangeTimeType != FetchTaggedRequest_RangeTimeType_DEFAULT
+}
+
+func (p *FetchTaggedRequest) IsSetSortedOrder() bool {
+	return p.SortedOrder != FetchTaggedRequest_SortedOrder_DEFAULT
+}
+
+func (p *FetchTaggedRequest) IsSetPageToken() bool {
+	return p.PageToken != nil
+}
+
+func (p *FetchTaggedRequest) IsSetSequentialScan() bool {
+	return p.SequentialScan != FetchTaggedRequest_SequentialScan_DEFAULT
+}
+
+func (p *FetchTaggedRequest) IsSetFetchBlockMetadata() bool {
+	return p.FetchBlockMetadata != FetchTaggedRequest_FetchBlockMetadata_DEFAULT
+}
+
+func (p *FetchTaggedRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetNameSpace bool = false
+	var issetQuery bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetFetchData bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
 		case 1:
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetID = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetQuery = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetEncodedTags = true
+			issetRangeStart = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
+			issetRangeEnd = true
 		case 5:
 			if err := p.ReadField5(iprot); err != nil {
 				return err
 			}
+			issetFetchData = true
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
+		case 8:
+			if err := p.ReadField8(iprot); err != nil {
+				return err
+			}
+		case 9:
+			if err := p.ReadField9(iprot); err != nil {
+				return err
+			}
+		case 10:
+			if err := p.ReadField10(iprot); err != nil {
+				return err
+			}
+		case 11:
+			if err := p.ReadField11(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -3609,77 +4154,126 @@ func (p *FetchTaggedIDResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
-	}
 	if !issetNameSpace {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
-	if !issetEncodedTags {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field EncodedTags is not set"))
+	if !issetQuery {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Query is not set"))
+	}
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetFetchData {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field FetchData is not set"))
 	}
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchTaggedRequest) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.ID = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) ReadField2(iprot thrift.TProtocol) error {
+func (p *FetchTaggedRequest) ReadField2(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.NameSpace = v
+		p.Query = v
 	}
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
+func (p *FetchTaggedRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.EncodedTags = v
+		p.RangeStart = v
 	}
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) ReadField4(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
+func (p *FetchTaggedRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.RangeEnd = v
 	}
-	tSlice := make([]*Segments, 0, size)
-	p.Segments = tSlice
-	for i := 0; i < size; i++ {
-		_elem10 := &Segments{}
-		if err := _elem10.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem10), err)
-		}
-		p.Segments = append(p.Segments, _elem10)
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.FetchData = v
 	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		p.Limit = &v
 	}
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) ReadField5(iprot thrift.TProtocol) error {
-	p.Err = &Error{
-		Type: 0,
+func (p *FetchTaggedRequest) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := TimeType(v)
+		p.RangeTimeType = temp
 	}
-	if err := p.Err.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		p.SortedOrder = v
 	}
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchTaggedIDResult"); err != nil {
+func (p *FetchTaggedRequest) ReadField9(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 9: ", err)
+	} else {
+		p.PageToken = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField10(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 10: ", err)
+	} else {
+		p.SequentialScan = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedRequest) ReadField11(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 11: ", err)
+	} else {
+		p.FetchBlockMetadata = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchTaggedRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -3698,6 +4292,24 @@ func (p *FetchTaggedIDResult_) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField5(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField9(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField10(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField11(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -3708,123 +4320,217 @@ func (p *FetchTaggedIDResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchTaggedIDResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
+func (p *FetchTaggedRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchTaggedIDResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:nameSpace: ", p), err)
+func (p *FetchTaggedRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("query", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:query: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (2) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.Query); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.query (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:query: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchTaggedIDResult_) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:encodedTags: ", p), err)
+func (p *FetchTaggedRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeStart: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.EncodedTags); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.encodedTags (3) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (3) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:encodedTags: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeStart: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchTaggedIDResult_) writeField4(oprot thrift.TProtocol) (err error) {
-	if p.IsSetSegments() {
-		if err := oprot.WriteFieldBegin("segments", thrift.LIST, 4); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:segments: ", p), err)
-		}
-		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Segments)); err != nil {
-			return thrift.PrependError("error writing list begin: ", err)
-		}
-		for _, v := range p.Segments {
-			if err := v.Write(oprot); err != nil {
-				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-			}
-		}
-		if err := oprot.WriteListEnd(); err != nil {
-			return thrift.PrependError("error writing list end: ", err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:segments: ", p), err)
-		}
+func (p *FetchTaggedRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:rangeEnd: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchTaggedIDResult_) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetErr() {
-		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:err: ", p), err)
+func (p *FetchTaggedRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("fetchData", thrift.BOOL, 5); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:fetchData: ", p), err)
+	}
+	if err := oprot.WriteBool(bool(p.FetchData)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.fetchData (5) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 5:fetchData: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimit() {
+		if err := oprot.WriteFieldBegin("limit", thrift.I64, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:limit: ", p), err)
 		}
-		if err := p.Err.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limit (6) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:err: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:limit: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *FetchTaggedIDResult_) String() string {
+func (p *FetchTaggedRequest) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRangeTimeType() {
+		if err := oprot.WriteFieldBegin("rangeTimeType", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:rangeTimeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.RangeTimeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.rangeTimeType (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:rangeTimeType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSortedOrder() {
+		if err := oprot.WriteFieldBegin("sortedOrder", thrift.BOOL, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:sortedOrder: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.SortedOrder)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.sortedOrder (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:sortedOrder: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField9(oprot thrift.TProtocol) (err error) {
+	if p.IsSetPageToken() {
+		if err := oprot.WriteFieldBegin("pageToken", thrift.STRING, 9); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 9:pageToken: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.PageToken); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.pageToken (9) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 9:pageToken: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField10(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSequentialScan() {
+		if err := oprot.WriteFieldBegin("sequentialScan", thrift.BOOL, 10); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 10:sequentialScan: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.SequentialScan)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.sequentialScan (10) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 10:sequentialScan: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) writeField11(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFetchBlockMetadata() {
+		if err := oprot.WriteFieldBegin("fetchBlockMetadata", thrift.BOOL, 11); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 11:fetchBlockMetadata: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.FetchBlockMetadata)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.fetchBlockMetadata (11) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 11:fetchBlockMetadata: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchTaggedIDResult_(%+v)", *p)
+	return fmt.Sprintf("FetchTaggedRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
-//  - Shard
 //  - Elements
-type FetchBlocksRawRequest struct {
-	NameSpace []byte                          `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	Shard     int32                           `thrift:"shard,2,required" db:"shard" json:"shard"`
-	Elements  []*FetchBlocksRawRequestElement `thrift:"elements,3,required" db:"elements" json:"elements"`
+//  - Exhaustive
+//  - NextPageToken
+//  - Frozen
+type FetchTaggedResult_ struct {
+	Elements      []*FetchTaggedIDResult_ `thrift:"elements,1,required" db:"elements" json:"elements"`
+	Exhaustive    bool                    `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
+	NextPageToken []byte                  `thrift:"nextPageToken,3" db:"nextPageToken" json:"nextPageToken,omitempty"`
+	Frozen        bool                    `thrift:"frozen,4" db:"frozen" json:"frozen,omitempty"`
 }
 
-func NewFetchBlocksRawRequest() *FetchBlocksRawRequest {
-	return &FetchBlocksRawRequest{}
+func NewFetchTaggedResult_() *FetchTaggedResult_ {
+	return &FetchTaggedResult_{}
 }
 
-func (p *FetchBlocksRawRequest) GetNameSpace() []byte {
-	return p.NameSpace
+func (p *FetchTaggedResult_) GetElements() []*FetchTaggedIDResult_ {
+	return p.Elements
 }
 
-func (p *FetchBlocksRawRequest) GetShard() int32 {
-	return p.Shard
+func (p *FetchTaggedResult_) GetExhaustive() bool {
+	return p.Exhaustive
 }
 
-func (p *FetchBlocksRawRequest) GetElements() []*FetchBlocksRawRequestElement {
-	return p.Elements
+var FetchTaggedResult__NextPageToken_DEFAULT []byte
+
+func (p *FetchTaggedResult_) GetNextPageToken() []byte {
+	return p.NextPageToken
 }
-func (p *FetchBlocksRawRequest) Read(iprot thrift.TProtocol) error {
+func (p *FetchTaggedResult_) IsSetNextPageToken() bool {
+	return p.NextPageToken != nil
+}
+
+var FetchTaggedResult__Frozen_DEFAULT bool = false
+
+func (p *FetchTaggedResult_) GetFrozen() bool {
+	return p.Frozen
+}
+func (p *FetchTaggedResult_) IsSetFrozen() bool {
+	return p.Frozen != FetchTaggedResult__Frozen_DEFAULT
+}
+
+func (p *FetchTaggedResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
-	var issetShard bool = false
 	var issetElements bool = false
+	var issetExhaustive bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -3839,17 +4545,20 @@ func (p *FetchBlocksRawRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetElements = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetShard = true
+			issetExhaustive = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetElements = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -3862,49 +4571,28 @@ func (p *FetchBlocksRawRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
-	if !issetShard {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Shard is not set"))
-	}
 	if !issetElements {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
-	return nil
-}
-
-func (p *FetchBlocksRawRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.NameSpace = v
-	}
-	return nil
-}
-
-func (p *FetchBlocksRawRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.Shard = v
+	if !issetExhaustive {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Exhaustive is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBlocksRawRequest) ReadField3(iprot thrift.TProtocol) error {
+func (p *FetchTaggedResult_) ReadField1(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*FetchBlocksRawRequestElement, 0, size)
+	tSlice := make([]*FetchTaggedIDResult_, 0, size)
 	p.Elements = tSlice
 	for i := 0; i < size; i++ {
-		_elem11 := &FetchBlocksRawRequestElement{}
-		if err := _elem11.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem11), err)
+		_elem9 := &FetchTaggedIDResult_{}
+		if err := _elem9.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem9), err)
 		}
-		p.Elements = append(p.Elements, _elem11)
+		p.Elements = append(p.Elements, _elem9)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -3912,8 +4600,35 @@ func (p *FetchBlocksRawRequest) ReadField3(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksRawRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBlocksRawRequest"); err != nil {
+func (p *FetchTaggedResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Exhaustive = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedResult_) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.NextPageToken = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedResult_) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.Frozen = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchTaggedResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -3926,6 +4641,9 @@ func (p *FetchBlocksRawRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField3(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -3936,86 +4654,137 @@ func (p *FetchBlocksRawRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+func (p *FetchTaggedResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("shard", thrift.I32, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:shard: ", p), err)
+func (p *FetchTaggedResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("exhaustive", thrift.BOOL, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:exhaustive: ", p), err)
 	}
-	if err := oprot.WriteI32(int32(p.Shard)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.shard (2) field write error: ", p), err)
+	if err := oprot.WriteBool(bool(p.Exhaustive)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.exhaustive (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:shard: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:exhaustive: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:elements: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Elements {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+func (p *FetchTaggedResult_) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetNextPageToken() {
+		if err := oprot.WriteFieldBegin("nextPageToken", thrift.STRING, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nextPageToken: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.NextPageToken); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.nextPageToken (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nextPageToken: ", p), err)
 		}
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:elements: ", p), err)
+	return err
+}
+
+func (p *FetchTaggedResult_) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFrozen() {
+		if err := oprot.WriteFieldBegin("frozen", thrift.BOOL, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:frozen: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.Frozen)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.frozen (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:frozen: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *FetchBlocksRawRequest) String() string {
+func (p *FetchTaggedResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBlocksRawRequest(%+v)", *p)
+	return fmt.Sprintf("FetchTaggedResult_(%+v)", *p)
 }
 
 // Attributes:
 //  - ID
-//  - Starts
-type FetchBlocksRawRequestElement struct {
-	ID     []byte  `thrift:"id,1,required" db:"id" json:"id"`
-	Starts []int64 `thrift:"starts,2,required" db:"starts" json:"starts"`
+//  - NameSpace
+//  - EncodedTags
+//  - Segments
+//  - Err
+type FetchTaggedIDResult_ struct {
+	ID          []byte      `thrift:"id,1,required" db:"id" json:"id"`
+	NameSpace   []byte      `thrift:"nameSpace,2,required" db:"nameSpace" json:"nameSpace"`
+	EncodedTags []byte      `thrift:"encodedTags,3,required" db:"encodedTags" json:"encodedTags"`
+	Segments    []*Segments `thrift:"segments,4" db:"segments" json:"segments,omitempty"`
+	Err         *Error      `thrift:"err,5" db:"err" json:"err,omitempty"`
 }
 
-func NewFetchBlocksRawRequestElement() *FetchBlocksRawRequestElement {
-	return &FetchBlocksRawRequestElement{}
+func NewFetchTaggedIDResult_() *FetchTaggedIDResult_ {
+	return &FetchTaggedIDResult_{}
 }
 
-func (p *FetchBlocksRawRequestElement) GetID() []byte {
+func (p *FetchTaggedIDResult_) GetID() []byte {
 	return p.ID
 }
 
-func (p *FetchBlocksRawRequestElement) GetStarts() []int64 {
-	return p.Starts
+func (p *FetchTaggedIDResult_) GetNameSpace() []byte {
+	return p.NameSpace
 }
-func (p *FetchBlocksRawRequestElement) Read(iprot thrift.TProtocol) error {
+
+func (p *FetchTaggedIDResult_) GetEncodedTags() []byte {
+	return p.EncodedTags
+}
+
+var FetchTaggedIDResult__Segments_DEFAULT []*Segments
+
+func (p *FetchTaggedIDResult_) GetSegments() []*Segments {
+	return p.Segments
+}
+
+var FetchTaggedIDResult__Err_DEFAULT *Error
+
+func (p *FetchTaggedIDResult_) GetErr() *Error {
+	if !p.IsSetErr() {
+		return FetchTaggedIDResult__Err_DEFAULT
+	}
+	return p.Err
+}
+func (p *FetchTaggedIDResult_) IsSetSegments() bool {
+	return p.Segments != nil
+}
+
+func (p *FetchTaggedIDResult_) IsSetErr() bool {
+	return p.Err != nil
+}
+
+func (p *FetchTaggedIDResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
 	var issetID bool = false
-	var issetStarts bool = false
+	var issetNameSpace bool = false
+	var issetEncodedTags bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -4035,7 +4804,20 @@ func (p *FetchBlocksRawRequestElement) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetStarts = true
+			issetNameSpace = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetEncodedTags = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -4051,13 +4833,16 @@ func (p *FetchBlocksRawRequestElement) Read(iprot thrift.TProtocol) error {
 	if !issetID {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetStarts {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Starts is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	if !issetEncodedTags {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field EncodedTags is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBlocksRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchTaggedIDResult_) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
@@ -4066,21 +4851,37 @@ func (p *FetchBlocksRawRequestElement) ReadField1(iprot thrift.TProtocol) error
 	return nil
 }
 
-func (p *FetchBlocksRawRequestElement) ReadField2(iprot thrift.TProtocol) error {
+func (p *FetchTaggedIDResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.NameSpace = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedIDResult_) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.EncodedTags = v
+	}
+	return nil
+}
+
+func (p *FetchTaggedIDResult_) ReadField4(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]int64, 0, size)
-	p.Starts = tSlice
+	tSlice := make([]*Segments, 0, size)
+	p.Segments = tSlice
 	for i := 0; i < size; i++ {
-		var _elem12 int64
-		if v, err := iprot.ReadI64(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem12 = v
+		_elem10 := &Segments{}
+		if err := _elem10.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem10), err)
 		}
-		p.Starts = append(p.Starts, _elem12)
+		p.Segments = append(p.Segments, _elem10)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -4088,8 +4889,18 @@ func (p *FetchBlocksRawRequestElement) ReadField2(iprot thrift.TProtocol) error
 	return nil
 }
 
-func (p *FetchBlocksRawRequestElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBlocksRawRequestElement"); err != nil {
+func (p *FetchTaggedIDResult_) ReadField5(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	}
+	return nil
+}
+
+func (p *FetchTaggedIDResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchTaggedIDResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -4099,6 +4910,15 @@ func (p *FetchBlocksRawRequestElement) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -4109,7 +4929,7 @@ func (p *FetchBlocksRawRequestElement) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksRawRequestElement) writeField1(oprot thrift.TProtocol) (err error) {
+func (p *FetchTaggedIDResult_) writeField1(oprot thrift.TProtocol) (err error) {
 	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
@@ -4122,52 +4942,121 @@ func (p *FetchBlocksRawRequestElement) writeField1(oprot thrift.TProtocol) (err
 	return err
 }
 
-func (p *FetchBlocksRawRequestElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("starts", thrift.LIST, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:starts: ", p), err)
+func (p *FetchTaggedIDResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.I64, len(p.Starts)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (2) field write error: ", p), err)
 	}
-	for _, v := range p.Starts {
-		if err := oprot.WriteI64(int64(v)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
-		}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	return err
+}
+
+func (p *FetchTaggedIDResult_) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:encodedTags: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.EncodedTags); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.encodedTags (3) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:starts: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:encodedTags: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksRawRequestElement) String() string {
+func (p *FetchTaggedIDResult_) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSegments() {
+		if err := oprot.WriteFieldBegin("segments", thrift.LIST, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:segments: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Segments)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.Segments {
+			if err := v.Write(oprot); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:segments: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedIDResult_) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:err: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchTaggedIDResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBlocksRawRequestElement(%+v)", *p)
+	return fmt.Sprintf("FetchTaggedIDResult_(%+v)", *p)
 }
 
 // Attributes:
+//  - NameSpace
+//  - Shard
 //  - Elements
-type FetchBlocksRawResult_ struct {
-	Elements []*Blocks `thrift:"elements,1,required" db:"elements" json:"elements"`
+//  - AcceptCompression
+type FetchBlocksRawRequest struct {
+	NameSpace         []byte                          `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Shard             int32                           `thrift:"shard,2,required" db:"shard" json:"shard"`
+	Elements          []*FetchBlocksRawRequestElement `thrift:"elements,3,required" db:"elements" json:"elements"`
+	AcceptCompression []string                        `thrift:"acceptCompression,4" db:"acceptCompression" json:"acceptCompression,omitempty"`
 }
 
-func NewFetchBlocksRawResult_() *FetchBlocksRawResult_ {
-	return &FetchBlocksRawResult_{}
+func NewFetchBlocksRawRequest() *FetchBlocksRawRequest {
+	return &FetchBlocksRawRequest{}
 }
 
-func (p *FetchBlocksRawResult_) GetElements() []*Blocks {
+func (p *FetchBlocksRawRequest) GetNameSpace() []byte {
+	return p.NameSpace
+}
+
+func (p *FetchBlocksRawRequest) GetShard() int32 {
+	return p.Shard
+}
+
+func (p *FetchBlocksRawRequest) GetElements() []*FetchBlocksRawRequestElement {
 	return p.Elements
 }
-func (p *FetchBlocksRawResult_) Read(iprot thrift.TProtocol) error {
+
+var FetchBlocksRawRequest_AcceptCompression_DEFAULT []string
+
+func (p *FetchBlocksRawRequest) GetAcceptCompression() []string {
+	return p.AcceptCompression
+}
+func (p *FetchBlocksRawRequest) IsSetAcceptCompression() bool {
+	return p.AcceptCompression != nil
+}
+
+func (p *FetchBlocksRawRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
+	var issetNameSpace bool = false
+	var issetShard bool = false
 	var issetElements bool = false
 
 	for {
@@ -4183,7 +5072,21 @@ func (p *FetchBlocksRawResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
+			issetNameSpace = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetShard = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
 			issetElements = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -4196,25 +5099,49 @@ func (p *FetchBlocksRawResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	if !issetShard {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Shard is not set"))
+	}
 	if !issetElements {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBlocksRawResult_) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchBlocksRawRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.NameSpace = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksRawRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Shard = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksRawRequest) ReadField3(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*Blocks, 0, size)
+	tSlice := make([]*FetchBlocksRawRequestElement, 0, size)
 	p.Elements = tSlice
 	for i := 0; i < size; i++ {
-		_elem13 := &Blocks{}
-		if err := _elem13.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem13), err)
+		_elem11 := &FetchBlocksRawRequestElement{}
+		if err := _elem11.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem11), err)
 		}
-		p.Elements = append(p.Elements, _elem13)
+		p.Elements = append(p.Elements, _elem11)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -4222,14 +5149,45 @@ func (p *FetchBlocksRawResult_) ReadField1(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksRawResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBlocksRawResult"); err != nil {
+func (p *FetchBlocksRawRequest) ReadField4(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]string, 0, size)
+	p.AcceptCompression = tSlice
+	for i := 0; i < size; i++ {
+		var _elem33 string
+		if v, err := iprot.ReadString(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem33 = v
+		}
+		p.AcceptCompression = append(p.AcceptCompression, _elem33)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *FetchBlocksRawRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksRawRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -4240,9 +5198,35 @@ func (p *FetchBlocksRawResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
+func (p *FetchBlocksRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("shard", thrift.I32, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:shard: ", p), err)
+	}
+	if err := oprot.WriteI32(int32(p.Shard)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.shard (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:shard: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:elements: ", p), err)
 	}
 	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
 		return thrift.PrependError("error writing list begin: ", err)
@@ -4256,44 +5240,67 @@ func (p *FetchBlocksRawResult_) writeField1(oprot thrift.TProtocol) (err error)
 		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksRawResult_) String() string {
+func (p *FetchBlocksRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetAcceptCompression() {
+		if err := oprot.WriteFieldBegin("acceptCompression", thrift.LIST, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:acceptCompression: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.STRING, len(p.AcceptCompression)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.AcceptCompression {
+			if err := oprot.WriteString(string(v)); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:acceptCompression: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchBlocksRawRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBlocksRawResult_(%+v)", *p)
+	return fmt.Sprintf("FetchBlocksRawRequest(%+v)", *p)
 }
 
 // Attributes:
 //  - ID
-//  - Blocks
-type Blocks struct {
-	ID     []byte   `thrift:"id,1,required" db:"id" json:"id"`
-	Blocks []*Block `thrift:"blocks,2,required" db:"blocks" json:"blocks"`
+//  - Starts
+type FetchBlocksRawRequestElement struct {
+	ID     []byte  `thrift:"id,1,required" db:"id" json:"id"`
+	Starts []int64 `thrift:"starts,2,required" db:"starts" json:"starts"`
 }
 
-func NewBlocks() *Blocks {
-	return &Blocks{}
+func NewFetchBlocksRawRequestElement() *FetchBlocksRawRequestElement {
+	return &FetchBlocksRawRequestElement{}
 }
 
-func (p *Blocks) GetID() []byte {
+func (p *FetchBlocksRawRequestElement) GetID() []byte {
 	return p.ID
 }
 
-func (p *Blocks) GetBlocks() []*Block {
-	return p.Blocks
+func (p *FetchBlocksRawRequestElement) GetStarts() []int64 {
+	return p.Starts
 }
-func (p *Blocks) Read(iprot thrift.TProtocol) error {
+func (p *FetchBlocksRawRequestElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
 	var issetID bool = false
-	var issetBlocks bool = false
+	var issetStarts bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -4313,7 +5320,7 @@ func (p *Blocks) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetBlocks = true
+			issetStarts = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -4329,13 +5336,13 @@ func (p *Blocks) Read(iprot thrift.TProtocol) error {
 	if !issetID {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetBlocks {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Blocks is not set"))
+	if !issetStarts {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Starts is not set"))
 	}
 	return nil
 }
 
-func (p *Blocks) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchBlocksRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
@@ -4344,19 +5351,21 @@ func (p *Blocks) ReadField1(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Blocks) ReadField2(iprot thrift.TProtocol) error {
+func (p *FetchBlocksRawRequestElement) ReadField2(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*Block, 0, size)
-	p.Blocks = tSlice
+	tSlice := make([]int64, 0, size)
+	p.Starts = tSlice
 	for i := 0; i < size; i++ {
-		_elem14 := &Block{}
-		if err := _elem14.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem14), err)
+		var _elem12 int64
+		if v, err := iprot.ReadI64(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem12 = v
 		}
-		p.Blocks = append(p.Blocks, _elem14)
+		p.Starts = append(p.Starts, _elem12)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -4364,8 +5373,8 @@ func (p *Blocks) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Blocks) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Blocks"); err != nil {
+func (p *FetchBlocksRawRequestElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksRawRequestElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -4385,7 +5394,7 @@ func (p *Blocks) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Blocks) writeField1(oprot thrift.TProtocol) (err error) {
+func (p *FetchBlocksRawRequestElement) writeField1(oprot thrift.TProtocol) (err error) {
 	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
@@ -4398,98 +5407,68 @@ func (p *Blocks) writeField1(oprot thrift.TProtocol) (err error) {
 	return err
 }
 
-func (p *Blocks) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("blocks", thrift.LIST, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:blocks: ", p), err)
+func (p *FetchBlocksRawRequestElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("starts", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:starts: ", p), err)
 	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Blocks)); err != nil {
+	if err := oprot.WriteListBegin(thrift.I64, len(p.Starts)); err != nil {
 		return thrift.PrependError("error writing list begin: ", err)
 	}
-	for _, v := range p.Blocks {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+	for _, v := range p.Starts {
+		if err := oprot.WriteI64(int64(v)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
 		}
 	}
 	if err := oprot.WriteListEnd(); err != nil {
 		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:blocks: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:starts: ", p), err)
 	}
 	return err
 }
 
-func (p *Blocks) String() string {
+func (p *FetchBlocksRawRequestElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Blocks(%+v)", *p)
+	return fmt.Sprintf("FetchBlocksRawRequestElement(%+v)", *p)
 }
 
 // Attributes:
-//  - Start
-//  - Segments
-//  - Err
-//  - Checksum
-type Block struct {
-	Start    int64     `thrift:"start,1,required" db:"start" json:"start"`
-	Segments *Segments `thrift:"segments,2" db:"segments" json:"segments,omitempty"`
-	Err      *Error    `thrift:"err,3" db:"err" json:"err,omitempty"`
-	Checksum *int64    `thrift:"checksum,4" db:"checksum" json:"checksum,omitempty"`
-}
-
-func NewBlock() *Block {
-	return &Block{}
-}
-
-func (p *Block) GetStart() int64 {
-	return p.Start
+//  - Elements
+//  - Compression
+type FetchBlocksRawResult_ struct {
+	Elements    []*Blocks `thrift:"elements,1,required" db:"elements" json:"elements"`
+	Compression *string   `thrift:"compression,2" db:"compression" json:"compression,omitempty"`
 }
 
-var Block_Segments_DEFAULT *Segments
-
-func (p *Block) GetSegments() *Segments {
-	if !p.IsSetSegments() {
-		return Block_Segments_DEFAULT
-	}
-	return p.Segments
+func NewFetchBlocksRawResult_() *FetchBlocksRawResult_ {
+	return &FetchBlocksRawResult_{}
 }
 
-var Block_Err_DEFAULT *Error
-
-func (p *Block) GetErr() *Error {
-	if !p.IsSetErr() {
-		return Block_Err_DEFAULT
-	}
-	return p.Err
+func (p *FetchBlocksRawResult_) GetElements() []*Blocks {
+	return p.Elements
 }
 
-var Block_Checksum_DEFAULT int64
+var FetchBlocksRawResult__Compression_DEFAULT string
 
-func (p *Block) GetChecksum() int64 {
-	if !p.IsSetChecksum() {
-		return Block_Checksum_DEFAULT
+func (p *FetchBlocksRawResult_) GetCompression() string {
+	if !p.IsSetCompression() {
+		return FetchBlocksRawResult__Compression_DEFAULT
 	}
-	return *p.Checksum
-}
-func (p *Block) IsSetSegments() bool {
-	return p.Segments != nil
-}
-
-func (p *Block) IsSetErr() bool {
-	return p.Err != nil
+	return *p.Compression
 }
-
-func (p *Block) IsSetChecksum() bool {
-	return p.Checksum != nil
+func (p *FetchBlocksRawResult_) IsSetCompression() bool {
+	return p.Compression != nil
 }
 
-func (p *Block) Read(iprot thrift.TProtocol) error {
+func (p *FetchBlocksRawResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetStart bool = false
+	var issetElements bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -4504,19 +5483,11 @@ func (p *Block) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetStart = true
+			issetElements = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -4529,50 +5500,43 @@ func (p *Block) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Start is not set"))
+	if !issetElements {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
 	return nil
 }
 
-func (p *Block) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.Start = v
-	}
-	return nil
-}
-
-func (p *Block) ReadField2(iprot thrift.TProtocol) error {
-	p.Segments = &Segments{}
-	if err := p.Segments.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Segments), err)
+func (p *FetchBlocksRawResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	return nil
-}
-
-func (p *Block) ReadField3(iprot thrift.TProtocol) error {
-	p.Err = &Error{
-		Type: 0,
+	tSlice := make([]*Blocks, 0, size)
+	p.Elements = tSlice
+	for i := 0; i < size; i++ {
+		_elem13 := &Blocks{}
+		if err := _elem13.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem13), err)
+		}
+		p.Elements = append(p.Elements, _elem13)
 	}
-	if err := p.Err.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *Block) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
+func (p *FetchBlocksRawResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.Checksum = &v
+		p.Compression = &v
 	}
 	return nil
 }
 
-func (p *Block) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Block"); err != nil {
+func (p *FetchBlocksRawResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksRawResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -4582,12 +5546,6 @@ func (p *Block) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -4598,97 +5556,75 @@ func (p *Block) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Block) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("start", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:start: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.Start)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.start (1) field write error: ", p), err)
+func (p *FetchBlocksRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:start: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
 	}
-	return err
-}
-
-func (p *Block) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetSegments() {
-		if err := oprot.WriteFieldBegin("segments", thrift.STRUCT, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:segments: ", p), err)
-		}
-		if err := p.Segments.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Segments), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:segments: ", p), err)
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
 		}
 	}
-	return err
-}
-
-func (p *Block) writeField3(oprot thrift.TProtocol) (err error) {
-	if p.IsSetErr() {
-		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 3); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:err: ", p), err)
-		}
-		if err := p.Err.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:err: ", p), err)
-		}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *Block) writeField4(oprot thrift.TProtocol) (err error) {
-	if p.IsSetChecksum() {
-		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 4); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:checksum: ", p), err)
+func (p *FetchBlocksRawResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetCompression() {
+		if err := oprot.WriteFieldBegin("compression", thrift.STRING, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:compression: ", p), err)
 		}
-		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.checksum (4) field write error: ", p), err)
+		if err := oprot.WriteString(string(*p.Compression)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.compression (2) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:checksum: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:compression: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *Block) String() string {
+func (p *FetchBlocksRawResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Block(%+v)", *p)
+	return fmt.Sprintf("FetchBlocksRawResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - Name
-//  - Value
-type Tag struct {
-	Name  string `thrift:"name,1,required" db:"name" json:"name"`
-	Value string `thrift:"value,2,required" db:"value" json:"value"`
+//  - ID
+//  - Blocks
+type Blocks struct {
+	ID     []byte   `thrift:"id,1,required" db:"id" json:"id"`
+	Blocks []*Block `thrift:"blocks,2,required" db:"blocks" json:"blocks"`
 }
 
-func NewTag() *Tag {
-	return &Tag{}
+func NewBlocks() *Blocks {
+	return &Blocks{}
 }
 
-func (p *Tag) GetName() string {
-	return p.Name
+func (p *Blocks) GetID() []byte {
+	return p.ID
 }
 
-func (p *Tag) GetValue() string {
-	return p.Value
+func (p *Blocks) GetBlocks() []*Block {
+	return p.Blocks
 }
-func (p *Tag) Read(iprot thrift.TProtocol) error {
+func (p *Blocks) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetName bool = false
-	var issetValue bool = false
+	var issetID bool = false
+	var issetBlocks bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -4703,12 +5639,12 @@ func (p *Tag) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetName = true
+			issetID = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetValue = true
+			issetBlocks = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -4721,35 +5657,46 @@ func (p *Tag) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetName {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Name is not set"))
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetValue {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Value is not set"))
+	if !issetBlocks {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Blocks is not set"))
 	}
 	return nil
 }
 
-func (p *Tag) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
+func (p *Blocks) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Name = v
+		p.ID = v
 	}
 	return nil
 }
 
-func (p *Tag) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.Value = v
+func (p *Blocks) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*Block, 0, size)
+	p.Blocks = tSlice
+	for i := 0; i < size; i++ {
+		_elem14 := &Block{}
+		if err := _elem14.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem14), err)
+		}
+		p.Blocks = append(p.Blocks, _elem14)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *Tag) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("Tag"); err != nil {
+func (p *Blocks) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Blocks"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -4769,143 +5716,111 @@ func (p *Tag) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *Tag) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("name", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:name: ", p), err)
+func (p *Blocks) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.Name)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.name (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:name: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
 	}
 	return err
 }
 
-func (p *Tag) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("value", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:value: ", p), err)
+func (p *Blocks) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("blocks", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:blocks: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.Value)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.value (2) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Blocks)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Blocks {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:value: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:blocks: ", p), err)
 	}
 	return err
 }
 
-func (p *Tag) String() string {
+func (p *Blocks) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("Tag(%+v)", *p)
+	return fmt.Sprintf("Blocks(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
-//  - Shard
-//  - RangeStart
-//  - RangeEnd
-//  - Limit
-//  - PageToken
-//  - IncludeSizes
-//  - IncludeChecksums
-//  - IncludeLastRead
-type FetchBlocksMetadataRawV2Request struct {
-	NameSpace        []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	Shard            int32  `thrift:"shard,2,required" db:"shard" json:"shard"`
-	RangeStart       int64  `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd         int64  `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
-	Limit            int64  `thrift:"limit,5,required" db:"limit" json:"limit"`
-	PageToken        []byte `thrift:"pageToken,6" db:"pageToken" json:"pageToken,omitempty"`
-	IncludeSizes     *bool  `thrift:"includeSizes,7" db:"includeSizes" json:"includeSizes,omitempty"`
-	IncludeChecksums *bool  `thrift:"includeChecksums,8" db:"includeChecksums" json:"includeChecksums,omitempty"`
-	IncludeLastRead  *bool  `thrift:"includeLastRead,9" db:"includeLastRead" json:"includeLastRead,omitempty"`
+//  - Start
+//  - Segments
+//  - Err
+//  - Checksum
+type Block struct {
+	Start    int64     `thrift:"start,1,required" db:"start" json:"start"`
+	Segments *Segments `thrift:"segments,2" db:"segments" json:"segments,omitempty"`
+	Err      *Error    `thrift:"err,3" db:"err" json:"err,omitempty"`
+	Checksum *int64    `thrift:"checksum,4" db:"checksum" json:"checksum,omitempty"`
 }
 
-func NewFetchBlocksMetadataRawV2Request() *FetchBlocksMetadataRawV2Request {
-	return &FetchBlocksMetadataRawV2Request{}
+func NewBlock() *Block {
+	return &Block{}
 }
 
-func (p *FetchBlocksMetadataRawV2Request) GetNameSpace() []byte {
-	return p.NameSpace
+func (p *Block) GetStart() int64 {
+	return p.Start
 }
 
-func (p *FetchBlocksMetadataRawV2Request) GetShard() int32 {
-	return p.Shard
-}
+var Block_Segments_DEFAULT *Segments
 
-func (p *FetchBlocksMetadataRawV2Request) GetRangeStart() int64 {
-	return p.RangeStart
-}
-
-func (p *FetchBlocksMetadataRawV2Request) GetRangeEnd() int64 {
-	return p.RangeEnd
-}
-
-func (p *FetchBlocksMetadataRawV2Request) GetLimit() int64 {
-	return p.Limit
-}
-
-var FetchBlocksMetadataRawV2Request_PageToken_DEFAULT []byte
-
-func (p *FetchBlocksMetadataRawV2Request) GetPageToken() []byte {
-	return p.PageToken
-}
-
-var FetchBlocksMetadataRawV2Request_IncludeSizes_DEFAULT bool
-
-func (p *FetchBlocksMetadataRawV2Request) GetIncludeSizes() bool {
-	if !p.IsSetIncludeSizes() {
-		return FetchBlocksMetadataRawV2Request_IncludeSizes_DEFAULT
+func (p *Block) GetSegments() *Segments {
+	if !p.IsSetSegments() {
+		return Block_Segments_DEFAULT
 	}
-	return *p.IncludeSizes
+	return p.Segments
 }
 
-var FetchBlocksMetadataRawV2Request_IncludeChecksums_DEFAULT bool
+var Block_Err_DEFAULT *Error
 
-func (p *FetchBlocksMetadataRawV2Request) GetIncludeChecksums() bool {
-	if !p.IsSetIncludeChecksums() {
-		return FetchBlocksMetadataRawV2Request_IncludeChecksums_DEFAULT
+func (p *Block) GetErr() *Error {
+	if !p.IsSetErr() {
+		return Block_Err_DEFAULT
 	}
-	return *p.IncludeChecksums
+	return p.Err
 }
 
-var FetchBlocksMetadataRawV2Request_IncludeLastRead_DEFAULT bool
+var Block_Checksum_DEFAULT int64
 
-func (p *FetchBlocksMetadataRawV2Request) GetIncludeLastRead() bool {
-	if !p.IsSetIncludeLastRead() {
-		return FetchBlocksMetadataRawV2Request_IncludeLastRead_DEFAULT
+func (p *Block) GetChecksum() int64 {
+	if !p.IsSetChecksum() {
+		return Block_Checksum_DEFAULT
 	}
-	return *p.IncludeLastRead
-}
-func (p *FetchBlocksMetadataRawV2Request) IsSetPageToken() bool {
-	return p.PageToken != nil
+	return *p.Checksum
 }
-
-func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeSizes() bool {
-	return p.IncludeSizes != nil
+func (p *Block) IsSetSegments() bool {
+	return p.Segments != nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeChecksums() bool {
-	return p.IncludeChecksums != nil
+func (p *Block) IsSetErr() bool {
+	return p.Err != nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeLastRead() bool {
-	return p.IncludeLastRead != nil
+func (p *Block) IsSetChecksum() bool {
+	return p.Checksum != nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) Read(iprot thrift.TProtocol) error {
+func (p *Block) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
-	var issetShard bool = false
-	var issetRangeStart bool = false
-	var issetRangeEnd bool = false
-	var issetLimit bool = false
+	var issetStart bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -4920,43 +5835,19 @@ func (p *FetchBlocksMetadataRawV2Request) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetStart = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetShard = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetRangeStart = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
-			issetRangeEnd = true
-		case 5:
-			if err := p.ReadField5(iprot); err != nil {
-				return err
-			}
-			issetLimit = true
-		case 6:
-			if err := p.ReadField6(iprot); err != nil {
-				return err
-			}
-		case 7:
-			if err := p.ReadField7(iprot); err != nil {
-				return err
-			}
-		case 8:
-			if err := p.ReadField8(iprot); err != nil {
-				return err
-			}
-		case 9:
-			if err := p.ReadField9(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -4969,107 +5860,50 @@ func (p *FetchBlocksMetadataRawV2Request) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
-	if !issetShard {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Shard is not set"))
-	}
-	if !issetRangeStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
-	}
-	if !issetRangeEnd {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
-	}
-	if !issetLimit {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Limit is not set"))
+	if !issetStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Start is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
+func (p *Block) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.NameSpace = v
+		p.Start = v
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.Shard = v
+func (p *Block) ReadField2(iprot thrift.TProtocol) error {
+	p.Segments = &Segments{}
+	if err := p.Segments.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Segments), err)
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.RangeStart = v
+func (p *Block) ReadField3(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
 	}
-	return nil
-}
-
-func (p *FetchBlocksMetadataRawV2Request) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.RangeEnd = v
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) ReadField5(iprot thrift.TProtocol) error {
+func (p *Block) ReadField4(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
-	} else {
-		p.Limit = v
-	}
-	return nil
-}
-
-func (p *FetchBlocksMetadataRawV2Request) ReadField6(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 6: ", err)
-	} else {
-		p.PageToken = v
-	}
-	return nil
-}
-
-func (p *FetchBlocksMetadataRawV2Request) ReadField7(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 7: ", err)
-	} else {
-		p.IncludeSizes = &v
-	}
-	return nil
-}
-
-func (p *FetchBlocksMetadataRawV2Request) ReadField8(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 8: ", err)
-	} else {
-		p.IncludeChecksums = &v
-	}
-	return nil
-}
-
-func (p *FetchBlocksMetadataRawV2Request) ReadField9(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 9: ", err)
+		return thrift.PrependError("error reading field 4: ", err)
 	} else {
-		p.IncludeLastRead = &v
+		p.Checksum = &v
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBlocksMetadataRawV2Request"); err != nil {
+func (p *Block) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Block"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -5085,21 +5919,6 @@ func (p *FetchBlocksMetadataRawV2Request) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField4(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField6(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField7(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField8(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField9(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -5110,169 +5929,97 @@ func (p *FetchBlocksMetadataRawV2Request) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+func (p *Block) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("start", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:start: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.Start)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.start (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:start: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("shard", thrift.I32, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:shard: ", p), err)
-	}
-	if err := oprot.WriteI32(int32(p.Shard)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.shard (2) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:shard: ", p), err)
+func (p *Block) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSegments() {
+		if err := oprot.WriteFieldBegin("segments", thrift.STRUCT, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:segments: ", p), err)
+		}
+		if err := p.Segments.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Segments), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:segments: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Request) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeStart: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (3) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeStart: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchBlocksMetadataRawV2Request) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:rangeEnd: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (4) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:rangeEnd: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchBlocksMetadataRawV2Request) writeField5(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.Limit)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
-	}
-	return err
-}
-
-func (p *FetchBlocksMetadataRawV2Request) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetPageToken() {
-		if err := oprot.WriteFieldBegin("pageToken", thrift.STRING, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:pageToken: ", p), err)
-		}
-		if err := oprot.WriteBinary(p.PageToken); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.pageToken (6) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:pageToken: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *FetchBlocksMetadataRawV2Request) writeField7(oprot thrift.TProtocol) (err error) {
-	if p.IsSetIncludeSizes() {
-		if err := oprot.WriteFieldBegin("includeSizes", thrift.BOOL, 7); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:includeSizes: ", p), err)
-		}
-		if err := oprot.WriteBool(bool(*p.IncludeSizes)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.includeSizes (7) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:includeSizes: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *FetchBlocksMetadataRawV2Request) writeField8(oprot thrift.TProtocol) (err error) {
-	if p.IsSetIncludeChecksums() {
-		if err := oprot.WriteFieldBegin("includeChecksums", thrift.BOOL, 8); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:includeChecksums: ", p), err)
-		}
-		if err := oprot.WriteBool(bool(*p.IncludeChecksums)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.includeChecksums (8) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:includeChecksums: ", p), err)
-		}
+func (p *Block) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:err: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Request) writeField9(oprot thrift.TProtocol) (err error) {
-	if p.IsSetIncludeLastRead() {
-		if err := oprot.WriteFieldBegin("includeLastRead", thrift.BOOL, 9); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 9:includeLastRead: ", p), err)
+func (p *Block) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetChecksum() {
+		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:checksum: ", p), err)
 		}
-		if err := oprot.WriteBool(bool(*p.IncludeLastRead)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.includeLastRead (9) field write error: ", p), err)
+		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.checksum (4) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 9:includeLastRead: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:checksum: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Request) String() string {
+func (p *Block) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBlocksMetadataRawV2Request(%+v)", *p)
+	return fmt.Sprintf("Block(%+v)", *p)
 }
 
 // Attributes:
-//  - Elements
-//  - NextPageToken
-type FetchBlocksMetadataRawV2Result_ struct {
-	Elements      []*BlockMetadataV2 `thrift:"elements,1,required" db:"elements" json:"elements"`
-	NextPageToken []byte             `thrift:"nextPageToken,2" db:"nextPageToken" json:"nextPageToken,omitempty"`
+//  - Name
+//  - Value
+type Tag struct {
+	Name  string `thrift:"name,1,required" db:"name" json:"name"`
+	Value string `thrift:"value,2,required" db:"value" json:"value"`
 }
 
-func NewFetchBlocksMetadataRawV2Result_() *FetchBlocksMetadataRawV2Result_ {
-	return &FetchBlocksMetadataRawV2Result_{}
+func NewTag() *Tag {
+	return &Tag{}
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) GetElements() []*BlockMetadataV2 {
-	return p.Elements
+func (p *Tag) GetName() string {
+	return p.Name
 }
 
-var FetchBlocksMetadataRawV2Result__NextPageToken_DEFAULT []byte
-
-func (p *FetchBlocksMetadataRawV2Result_) GetNextPageToken() []byte {
-	return p.NextPageToken
-}
-func (p *FetchBlocksMetadataRawV2Result_) IsSetNextPageToken() bool {
-	return p.NextPageToken != nil
+func (p *Tag) GetValue() string {
+	return p.Value
 }
-
-func (p *FetchBlocksMetadataRawV2Result_) Read(iprot thrift.TProtocol) error {
+func (p *Tag) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetElements bool = false
+	var issetName bool = false
+	var issetValue bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -5287,11 +6034,12 @@ func (p *FetchBlocksMetadataRawV2Result_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetElements = true
+			issetName = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
+			issetValue = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -5304,45 +6052,35 @@ func (p *FetchBlocksMetadataRawV2Result_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetElements {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	if !issetName {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Name is not set"))
+	}
+	if !issetValue {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Value is not set"))
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*BlockMetadataV2, 0, size)
-	p.Elements = tSlice
-	for i := 0; i < size; i++ {
-		_elem15 := &BlockMetadataV2{
-			LastReadTimeType: 0,
-		}
-		if err := _elem15.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem15), err)
-		}
-		p.Elements = append(p.Elements, _elem15)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *Tag) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Name = v
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
+func (p *Tag) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.NextPageToken = v
+		p.Value = v
 	}
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("FetchBlocksMetadataRawV2Result"); err != nil {
+func (p *Tag) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("Tag"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -5362,161 +6100,143 @@ func (p *FetchBlocksMetadataRawV2Result_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Elements {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
+func (p *Tag) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("name", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:name: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	if err := oprot.WriteString(string(p.Name)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.name (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:name: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetNextPageToken() {
-		if err := oprot.WriteFieldBegin("nextPageToken", thrift.STRING, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:nextPageToken: ", p), err)
-		}
-		if err := oprot.WriteBinary(p.NextPageToken); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.nextPageToken (2) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:nextPageToken: ", p), err)
-		}
+func (p *Tag) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("value", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:value: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.Value)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.value (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:value: ", p), err)
 	}
 	return err
 }
 
-func (p *FetchBlocksMetadataRawV2Result_) String() string {
+func (p *Tag) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("FetchBlocksMetadataRawV2Result_(%+v)", *p)
+	return fmt.Sprintf("Tag(%+v)", *p)
 }
 
 // Attributes:
-//  - ID
-//  - Start
-//  - Err
-//  - Size
-//  - Checksum
-//  - LastRead
-//  - LastReadTimeType
-//  - EncodedTags
-type BlockMetadataV2 struct {
-	ID               []byte   `thrift:"id,1,required" db:"id" json:"id"`
-	Start            int64    `thrift:"start,2,required" db:"start" json:"start"`
-	Err              *Error   `thrift:"err,3" db:"err" json:"err,omitempty"`
-	Size             *int64   `thrift:"size,4" db:"size" json:"size,omitempty"`
-	Checksum         *int64   `thrift:"checksum,5" db:"checksum" json:"checksum,omitempty"`
-	LastRead         *int64   `thrift:"lastRead,6" db:"lastRead" json:"lastRead,omitempty"`
-	LastReadTimeType TimeType `thrift:"lastReadTimeType,7" db:"lastReadTimeType" json:"lastReadTimeType,omitempty"`
-	EncodedTags      []byte   `thrift:"encodedTags,8" db:"encodedTags" json:"encodedTags,omitempty"`
+//  - NameSpace
+//  - Shard
+//  - RangeStart
+//  - RangeEnd
+//  - Limit
+//  - PageToken
+//  - IncludeSizes
+//  - IncludeChecksums
+//  - IncludeLastRead
+type FetchBlocksMetadataRawV2Request struct {
+	NameSpace        []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Shard            int32  `thrift:"shard,2,required" db:"shard" json:"shard"`
+	RangeStart       int64  `thrift:"rangeStart,3,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd         int64  `thrift:"rangeEnd,4,required" db:"rangeEnd" json:"rangeEnd"`
+	Limit            int64  `thrift:"limit,5,required" db:"limit" json:"limit"`
+	PageToken        []byte `thrift:"pageToken,6" db:"pageToken" json:"pageToken,omitempty"`
+	IncludeSizes     *bool  `thrift:"includeSizes,7" db:"includeSizes" json:"includeSizes,omitempty"`
+	IncludeChecksums *bool  `thrift:"includeChecksums,8" db:"includeChecksums" json:"includeChecksums,omitempty"`
+	IncludeLastRead  *bool  `thrift:"includeLastRead,9" db:"includeLastRead" json:"includeLastRead,omitempty"`
 }
 
-func NewBlockMetadataV2() *BlockMetadataV2 {
-	return &BlockMetadataV2{
-		LastReadTimeType: 0,
-	}
+func NewFetchBlocksMetadataRawV2Request() *FetchBlocksMetadataRawV2Request {
+	return &FetchBlocksMetadataRawV2Request{}
 }
 
-func (p *BlockMetadataV2) GetID() []byte {
-	return p.ID
+func (p *FetchBlocksMetadataRawV2Request) GetNameSpace() []byte {
+	return p.NameSpace
 }
 
-func (p *BlockMetadataV2) GetStart() int64 {
-	return p.Start
+func (p *FetchBlocksMetadataRawV2Request) GetShard() int32 {
+	return p.Shard
 }
 
-var BlockMetadataV2_Err_DEFAULT *Error
-
-func (p *BlockMetadataV2) GetErr() *Error {
-	if !p.IsSetErr() {
-		return BlockMetadataV2_Err_DEFAULT
-	}
-	return p.Err
+func (p *FetchBlocksMetadataRawV2Request) GetRangeStart() int64 {
+	return p.RangeStart
 }
 
-var BlockMetadataV2_Size_DEFAULT int64
+func (p *FetchBlocksMetadataRawV2Request) GetRangeEnd() int64 {
+	return p.RangeEnd
+}
 
-func (p *BlockMetadataV2) GetSize() int64 {
-	if !p.IsSetSize() {
-		return BlockMetadataV2_Size_DEFAULT
-	}
-	return *p.Size
+func (p *FetchBlocksMetadataRawV2Request) GetLimit() int64 {
+	return p.Limit
 }
 
-var BlockMetadataV2_Checksum_DEFAULT int64
+var FetchBlocksMetadataRawV2Request_PageToken_DEFAULT []byte
 
-func (p *BlockMetadataV2) GetChecksum() int64 {
-	if !p.IsSetChecksum() {
-		return BlockMetadataV2_Checksum_DEFAULT
-	}
-	return *p.Checksum
+func (p *FetchBlocksMetadataRawV2Request) GetPageToken() []byte {
+	return p.PageToken
 }
 
-var BlockMetadataV2_LastRead_DEFAULT int64
+var FetchBlocksMetadataRawV2Request_IncludeSizes_DEFAULT bool
 
-func (p *BlockMetadataV2) GetLastRead() int64 {
-	if !p.IsSetLastRead() {
-		return BlockMetadataV2_LastRead_DEFAULT
+func (p *FetchBlocksMetadataRawV2Request) GetIncludeSizes() bool {
+	if !p.IsSetIncludeSizes() {
+		return FetchBlocksMetadataRawV2Request_IncludeSizes_DEFAULT
 	}
-	return *p.LastRead
+	return *p.IncludeSizes
 }
 
-var BlockMetadataV2_LastReadTimeType_DEFAULT TimeType = 0
+var FetchBlocksMetadataRawV2Request_IncludeChecksums_DEFAULT bool
 
-func (p *BlockMetadataV2) GetLastReadTimeType() TimeType {
-	return p.LastReadTimeType
+func (p *FetchBlocksMetadataRawV2Request) GetIncludeChecksums() bool {
+	if !p.IsSetIncludeChecksums() {
+		return FetchBlocksMetadataRawV2Request_IncludeChecksums_DEFAULT
+	}
+	return *p.IncludeChecksums
 }
 
-var BlockMetadataV2_EncodedTags_DEFAULT []byte
-
-func (p *BlockMetadataV2) GetEncodedTags() []byte {
-	return p.EncodedTags
-}
-func (p *BlockMetadataV2) IsSetErr() bool {
-	return p.Err != nil
-}
+var FetchBlocksMetadataRawV2Request_IncludeLastRead_DEFAULT bool
 
-func (p *BlockMetadataV2) IsSetSize() bool {
-	return p.Size != nil
+func (p *FetchBlocksMetadataRawV2Request) GetIncludeLastRead() bool {
+	if !p.IsSetIncludeLastRead() {
+		return FetchBlocksMetadataRawV2Request_IncludeLastRead_DEFAULT
+	}
+	return *p.IncludeLastRead
 }
-
-func (p *BlockMetadataV2) IsSetChecksum() bool {
-	return p.Checksum != nil
+func (p *FetchBlocksMetadataRawV2Request) IsSetPageToken() bool {
+	return p.PageToken != nil
 }
 
-func (p *BlockMetadataV2) IsSetLastRead() bool {
-	return p.LastRead != nil
+func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeSizes() bool {
+	return p.IncludeSizes != nil
 }
 
-func (p *BlockMetadataV2) IsSetLastReadTimeType() bool {
-	return p.LastReadTimeType != BlockMetadataV2_LastReadTimeType_DEFAULT
+func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeChecksums() bool {
+	return p.IncludeChecksums != nil
 }
 
-func (p *BlockMetadataV2) IsSetEncodedTags() bool {
-	return p.EncodedTags != nil
+func (p *FetchBlocksMetadataRawV2Request) IsSetIncludeLastRead() bool {
+	return p.IncludeLastRead != nil
 }
 
-func (p *BlockMetadataV2) Read(iprot thrift.TProtocol) error {
+func (p *FetchBlocksMetadataRawV2Request) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetID bool = false
-	var issetStart bool = false
+	var issetNameSpace bool = false
+	var issetShard bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetLimit bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -5531,24 +6251,27 @@ func (p *BlockMetadataV2) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetID = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetStart = true
+			issetShard = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
+			issetRangeStart = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
+			issetRangeEnd = true
 		case 5:
 			if err := p.ReadField5(iprot); err != nil {
 				return err
 			}
+			issetLimit = true
 		case 6:
 			if err := p.ReadField6(iprot); err != nil {
 				return err
@@ -5561,6 +6284,10 @@ func (p *BlockMetadataV2) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField8(iprot); err != nil {
 				return err
 			}
+		case 9:
+			if err := p.ReadField9(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -5573,91 +6300,107 @@ func (p *BlockMetadataV2) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
-	if !issetStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Start is not set"))
+	if !issetShard {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Shard is not set"))
+	}
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetLimit {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Limit is not set"))
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField1(iprot thrift.TProtocol) error {
+func (p *FetchBlocksMetadataRawV2Request) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.ID = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *FetchBlocksMetadataRawV2Request) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.Start = v
+		p.Shard = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField3(iprot thrift.TProtocol) error {
-	p.Err = &Error{
-		Type: 0,
-	}
-	if err := p.Err.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+func (p *FetchBlocksMetadataRawV2Request) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RangeStart = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField4(iprot thrift.TProtocol) error {
+func (p *FetchBlocksMetadataRawV2Request) ReadField4(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 4: ", err)
 	} else {
-		p.Size = &v
+		p.RangeEnd = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField5(iprot thrift.TProtocol) error {
+func (p *FetchBlocksMetadataRawV2Request) ReadField5(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 5: ", err)
 	} else {
-		p.Checksum = &v
+		p.Limit = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField6(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *FetchBlocksMetadataRawV2Request) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 6: ", err)
 	} else {
-		p.LastRead = &v
+		p.PageToken = v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField7(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
+func (p *FetchBlocksMetadataRawV2Request) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
 		return thrift.PrependError("error reading field 7: ", err)
 	} else {
-		temp := TimeType(v)
-		p.LastReadTimeType = temp
+		p.IncludeSizes = &v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) ReadField8(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
+func (p *FetchBlocksMetadataRawV2Request) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
 		return thrift.PrependError("error reading field 8: ", err)
 	} else {
-		p.EncodedTags = v
+		p.IncludeChecksums = &v
 	}
 	return nil
 }
 
-func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("BlockMetadataV2"); err != nil {
+func (p *FetchBlocksMetadataRawV2Request) ReadField9(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 9: ", err)
+	} else {
+		p.IncludeLastRead = &v
+	}
+	return nil
+}
+
+func (p *FetchBlocksMetadataRawV2Request) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksMetadataRawV2Request"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -5685,6 +6428,9 @@ func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField8(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField9(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -5695,154 +6441,168 @@ func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *BlockMetadataV2) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
+func (p *FetchBlocksMetadataRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("start", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:start: ", p), err)
+func (p *FetchBlocksMetadataRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("shard", thrift.I32, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:shard: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.Start)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.start (2) field write error: ", p), err)
+	if err := oprot.WriteI32(int32(p.Shard)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.shard (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:start: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:shard: ", p), err)
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField3(oprot thrift.TProtocol) (err error) {
-	if p.IsSetErr() {
-		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 3); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:err: ", p), err)
-		}
-		if err := p.Err.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:err: ", p), err)
-		}
+func (p *FetchBlocksMetadataRawV2Request) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeStart: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeStart: ", p), err)
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField4(oprot thrift.TProtocol) (err error) {
-	if p.IsSetSize() {
-		if err := oprot.WriteFieldBegin("size", thrift.I64, 4); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:size: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.Size)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.size (4) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:size: ", p), err)
-		}
+func (p *FetchBlocksMetadataRawV2Request) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:rangeEnd: ", p), err)
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetChecksum() {
-		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:checksum: ", p), err)
+func (p *FetchBlocksMetadataRawV2Request) writeField5(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.Limit)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
+	}
+	return err
+}
+
+func (p *FetchBlocksMetadataRawV2Request) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetPageToken() {
+		if err := oprot.WriteFieldBegin("pageToken", thrift.STRING, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:pageToken: ", p), err)
 		}
-		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.checksum (5) field write error: ", p), err)
+		if err := oprot.WriteBinary(p.PageToken); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.pageToken (6) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:checksum: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:pageToken: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLastRead() {
-		if err := oprot.WriteFieldBegin("lastRead", thrift.I64, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:lastRead: ", p), err)
+func (p *FetchBlocksMetadataRawV2Request) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIncludeSizes() {
+		if err := oprot.WriteFieldBegin("includeSizes", thrift.BOOL, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:includeSizes: ", p), err)
 		}
-		if err := oprot.WriteI64(int64(*p.LastRead)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.lastRead (6) field write error: ", p), err)
+		if err := oprot.WriteBool(bool(*p.IncludeSizes)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.includeSizes (7) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:lastRead: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:includeSizes: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField7(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLastReadTimeType() {
-		if err := oprot.WriteFieldBegin("lastReadTimeType", thrift.I32, 7); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:lastReadTimeType: ", p), err)
+func (p *FetchBlocksMetadataRawV2Request) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIncludeChecksums() {
+		if err := oprot.WriteFieldBegin("includeChecksums", thrift.BOOL, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:includeChecksums: ", p), err)
 		}
-		if err := oprot.WriteI32(int32(p.LastReadTimeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.lastReadTimeType (7) field write error: ", p), err)
+		if err := oprot.WriteBool(bool(*p.IncludeChecksums)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.includeChecksums (8) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:lastReadTimeType: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:includeChecksums: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) writeField8(oprot thrift.TProtocol) (err error) {
-	if p.IsSetEncodedTags() {
-		if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 8); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:encodedTags: ", p), err)
+func (p *FetchBlocksMetadataRawV2Request) writeField9(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIncludeLastRead() {
+		if err := oprot.WriteFieldBegin("includeLastRead", thrift.BOOL, 9); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 9:includeLastRead: ", p), err)
 		}
-		if err := oprot.WriteBinary(p.EncodedTags); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.encodedTags (8) field write error: ", p), err)
+		if err := oprot.WriteBool(bool(*p.IncludeLastRead)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.includeLastRead (9) field write error: ", p), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:encodedTags: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 9:includeLastRead: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *BlockMetadataV2) String() string {
+func (p *FetchBlocksMetadataRawV2Request) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("BlockMetadataV2(%+v)", *p)
+	return fmt.Sprintf("FetchBlocksMetadataRawV2Request(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
 //  - Elements
-type WriteBatchRawRequest struct {
-	NameSpace []byte                         `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	Elements  []*WriteBatchRawRequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+//  - NextPageToken
+type FetchBlocksMetadataRawV2Result_ struct {
+	Elements      []*BlockMetadataV2 `thrift:"elements,1,required" db:"elements" json:"elements"`
+	NextPageToken []byte             `thrift:"nextPageToken,2" db:"nextPageToken" json:"nextPageToken,omitempty"`
 }
 
-func NewWriteBatchRawRequest() *WriteBatchRawRequest {
-	return &WriteBatchRawRequest{}
+func NewFetchBlocksMetadataRawV2Result_() *FetchBlocksMetadataRawV2Result_ {
+	return &FetchBlocksMetadataRawV2Result_{}
 }
 
-func (p *WriteBatchRawRequest) GetNameSpace() []byte {
-	return p.NameSpace
+func (p *FetchBlocksMetadataRawV2Result_) GetElements() []*BlockMetadataV2 {
+	return p.Elements
 }
 
-func (p *WriteBatchRawRequest) GetElements() []*WriteBatchRawRequestElement {
-	return p.Elements
+var FetchBlocksMetadataRawV2Result__NextPageToken_DEFAULT []byte
+
+func (p *FetchBlocksMetadataRawV2Result_) GetNextPageToken() []byte {
+	return p.NextPageToken
 }
-func (p *WriteBatchRawRequest) Read(iprot thrift.TProtocol) error {
+func (p *FetchBlocksMetadataRawV2Result_) IsSetNextPageToken() bool {
+	return p.NextPageToken != nil
+}
+
+func (p *FetchBlocksMetadataRawV2Result_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
 	var issetElements bool = false
 
 	for {
@@ -5858,12 +6618,11 @@ func (p *WriteBatchRawRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetElements = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetElements = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -5876,37 +6635,27 @@ func (p *WriteBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
 	if !issetElements {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
 	return nil
 }
 
-func (p *WriteBatchRawRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.NameSpace = v
-	}
-	return nil
-}
-
-func (p *WriteBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
+func (p *FetchBlocksMetadataRawV2Result_) ReadField1(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*WriteBatchRawRequestElement, 0, size)
+	tSlice := make([]*BlockMetadataV2, 0, size)
 	p.Elements = tSlice
 	for i := 0; i < size; i++ {
-		_elem16 := &WriteBatchRawRequestElement{}
-		if err := _elem16.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem16), err)
+		_elem15 := &BlockMetadataV2{
+			LastReadTimeType: 0,
 		}
-		p.Elements = append(p.Elements, _elem16)
+		if err := _elem15.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem15), err)
+		}
+		p.Elements = append(p.Elements, _elem15)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -5914,8 +6663,17 @@ func (p *WriteBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteBatchRawRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteBatchRawRequest"); err != nil {
+func (p *FetchBlocksMetadataRawV2Result_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.NextPageToken = v
+	}
+	return nil
+}
+
+func (p *FetchBlocksMetadataRawV2Result_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("FetchBlocksMetadataRawV2Result"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -5935,22 +6693,9 @@ func (p *WriteBatchRawRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteBatchRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
-	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
-	}
-	return err
-}
-
-func (p *WriteBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
+func (p *FetchBlocksMetadataRawV2Result_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:elements: ", p), err)
 	}
 	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
 		return thrift.PrependError("error writing list begin: ", err)
@@ -5964,232 +6709,145 @@ func (p *WriteBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
 		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteBatchRawRequest) String() string {
+func (p *FetchBlocksMetadataRawV2Result_) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetNextPageToken() {
+		if err := oprot.WriteFieldBegin("nextPageToken", thrift.STRING, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:nextPageToken: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.NextPageToken); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.nextPageToken (2) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:nextPageToken: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *FetchBlocksMetadataRawV2Result_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteBatchRawRequest(%+v)", *p)
+	return fmt.Sprintf("FetchBlocksMetadataRawV2Result_(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpaces
-//  - Elements
-type WriteBatchRawV2Request struct {
-	NameSpaces [][]byte                         `thrift:"nameSpaces,1,required" db:"nameSpaces" json:"nameSpaces"`
-	Elements   []*WriteBatchRawV2RequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+//  - ID
+//  - Start
+//  - Err
+//  - Size
+//  - Checksum
+//  - LastRead
+//  - LastReadTimeType
+//  - EncodedTags
+type BlockMetadataV2 struct {
+	ID               []byte   `thrift:"id,1,required" db:"id" json:"id"`
+	Start            int64    `thrift:"start,2,required" db:"start" json:"start"`
+	Err              *Error   `thrift:"err,3" db:"err" json:"err,omitempty"`
+	Size             *int64   `thrift:"size,4" db:"size" json:"size,omitempty"`
+	Checksum         *int64   `thrift:"checksum,5" db:"checksum" json:"checksum,omitempty"`
+	LastRead         *int64   `thrift:"lastRead,6" db:"lastRead" json:"lastRead,omitempty"`
+	LastReadTimeType TimeType `thrift:"lastReadTimeType,7" db:"lastReadTimeType" json:"lastReadTimeType,omitempty"`
+	EncodedTags      []byte   `thrift:"encodedTags,8" db:"encodedTags" json:"encodedTags,omitempty"`
 }
 
-func NewWriteBatchRawV2Request() *WriteBatchRawV2Request {
-	return &WriteBatchRawV2Request{}
+func NewBlockMetadataV2() *BlockMetadataV2 {
+	return &BlockMetadataV2{
+		LastReadTimeType: 0,
+	}
 }
 
-func (p *WriteBatchRawV2Request) GetNameSpaces() [][]byte {
-	return p.NameSpaces
+func (p *BlockMetadataV2) GetID() []byte {
+	return p.ID
 }
 
-func (p *WriteBatchRawV2Request) GetElements() []*WriteBatchRawV2RequestElement {
-	return p.Elements
+func (p *BlockMetadataV2) GetStart() int64 {
+	return p.Start
 }
-func (p *WriteBatchRawV2Request) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
 
-	var issetNameSpaces bool = false
-	var issetElements bool = false
+var BlockMetadataV2_Err_DEFAULT *Error
 
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetNameSpaces = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-			issetElements = true
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
-			}
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
-	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+func (p *BlockMetadataV2) GetErr() *Error {
+	if !p.IsSetErr() {
+		return BlockMetadataV2_Err_DEFAULT
 	}
-	if !issetNameSpaces {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
-	}
-	if !issetElements {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
-	}
-	return nil
+	return p.Err
 }
 
-func (p *WriteBatchRawV2Request) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([][]byte, 0, size)
-	p.NameSpaces = tSlice
-	for i := 0; i < size; i++ {
-		var _elem17 []byte
-		if v, err := iprot.ReadBinary(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem17 = v
-		}
-		p.NameSpaces = append(p.NameSpaces, _elem17)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
-	}
-	return nil
-}
+var BlockMetadataV2_Size_DEFAULT int64
 
-func (p *WriteBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*WriteBatchRawV2RequestElement, 0, size)
-	p.Elements = tSlice
-	for i := 0; i < size; i++ {
-		_elem18 := &WriteBatchRawV2RequestElement{}
-		if err := _elem18.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem18), err)
-		}
-		p.Elements = append(p.Elements, _elem18)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *BlockMetadataV2) GetSize() int64 {
+	if !p.IsSetSize() {
+		return BlockMetadataV2_Size_DEFAULT
 	}
-	return nil
+	return *p.Size
 }
 
-func (p *WriteBatchRawV2Request) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteBatchRawV2Request"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
-	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
-	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
-	}
-	return nil
-}
+var BlockMetadataV2_Checksum_DEFAULT int64
 
-func (p *WriteBatchRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpaces: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.NameSpaces {
-		if err := oprot.WriteBinary(v); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
-		}
-	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpaces: ", p), err)
+func (p *BlockMetadataV2) GetChecksum() int64 {
+	if !p.IsSetChecksum() {
+		return BlockMetadataV2_Checksum_DEFAULT
 	}
-	return err
+	return *p.Checksum
 }
 
-func (p *WriteBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Elements {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
-	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
+var BlockMetadataV2_LastRead_DEFAULT int64
+
+func (p *BlockMetadataV2) GetLastRead() int64 {
+	if !p.IsSetLastRead() {
+		return BlockMetadataV2_LastRead_DEFAULT
 	}
-	return err
+	return *p.LastRead
 }
 
-func (p *WriteBatchRawV2Request) String() string {
-	if p == nil {
-		return "<nil>"
-	}
-	return fmt.Sprintf("WriteBatchRawV2Request(%+v)", *p)
+var BlockMetadataV2_LastReadTimeType_DEFAULT TimeType = 0
+
+func (p *BlockMetadataV2) GetLastReadTimeType() TimeType {
+	return p.LastReadTimeType
 }
 
-// Attributes:
-//  - ID
-//  - Datapoint
-type WriteBatchRawRequestElement struct {
-	ID        []byte     `thrift:"id,1,required" db:"id" json:"id"`
-	Datapoint *Datapoint `thrift:"datapoint,2,required" db:"datapoint" json:"datapoint"`
+var BlockMetadataV2_EncodedTags_DEFAULT []byte
+
+func (p *BlockMetadataV2) GetEncodedTags() []byte {
+	return p.EncodedTags
+}
+func (p *BlockMetadataV2) IsSetErr() bool {
+	return p.Err != nil
 }
 
-func NewWriteBatchRawRequestElement() *WriteBatchRawRequestElement {
-	return &WriteBatchRawRequestElement{}
+func (p *BlockMetadataV2) IsSetSize() bool {
+	return p.Size != nil
 }
 
-func (p *WriteBatchRawRequestElement) GetID() []byte {
-	return p.ID
+func (p *BlockMetadataV2) IsSetChecksum() bool {
+	return p.Checksum != nil
 }
 
-var WriteBatchRawRequestElement_Datapoint_DEFAULT *Datapoint
+func (p *BlockMetadataV2) IsSetLastRead() bool {
+	return p.LastRead != nil
+}
 
-func (p *WriteBatchRawRequestElement) GetDatapoint() *Datapoint {
-	if !p.IsSetDatapoint() {
-		return WriteBatchRawRequestElement_Datapoint_DEFAULT
-	}
-	return p.Datapoint
+func (p *BlockMetadataV2) IsSetLastReadTimeType() bool {
+	return p.LastReadTimeType != BlockMetadataV2_LastReadTimeType_DEFAULT
 }
-func (p *WriteBatchRawRequestElement) IsSetDatapoint() bool {
-	return p.Datapoint != nil
+
+func (p *BlockMetadataV2) IsSetEncodedTags() bool {
+	return p.EncodedTags != nil
 }
 
-func (p *WriteBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
+func (p *BlockMetadataV2) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
 	var issetID bool = false
-	var issetDatapoint bool = false
+	var issetStart bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -6209,7 +6867,31 @@ func (p *WriteBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetDatapoint = true
+			issetStart = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
+		case 8:
+			if err := p.ReadField8(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -6225,13 +6907,13 @@ func (p *WriteBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
 	if !issetID {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetDatapoint {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
+	if !issetStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Start is not set"))
 	}
 	return nil
 }
 
-func (p *WriteBatchRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
+func (p *BlockMetadataV2) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
@@ -6240,112 +6922,301 @@ func (p *WriteBatchRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteBatchRawRequestElement) ReadField2(iprot thrift.TProtocol) error {
-	p.Datapoint = &Datapoint{
-		TimestampTimeType: 0,
-	}
-	if err := p.Datapoint.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+func (p *BlockMetadataV2) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Start = v
 	}
 	return nil
 }
 
-func (p *WriteBatchRawRequestElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteBatchRawRequestElement"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
-	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
+func (p *BlockMetadataV2) ReadField3(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
 	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
 	}
 	return nil
 }
 
-func (p *WriteBatchRawRequestElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
-	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
+func (p *BlockMetadataV2) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.Size = &v
 	}
-	return err
+	return nil
 }
 
-func (p *WriteBatchRawRequestElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:datapoint: ", p), err)
+func (p *BlockMetadataV2) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Checksum = &v
 	}
-	if err := p.Datapoint.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
+	} else {
+		p.LastRead = &v
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := TimeType(v)
+		p.LastReadTimeType = temp
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		p.EncodedTags = v
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("BlockMetadataV2"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *BlockMetadataV2) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:datapoint: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteBatchRawRequestElement) String() string {
+func (p *BlockMetadataV2) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("start", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:start: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.Start)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.start (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:start: ", p), err)
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:err: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSize() {
+		if err := oprot.WriteFieldBegin("size", thrift.I64, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:size: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Size)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.size (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:size: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetChecksum() {
+		if err := oprot.WriteFieldBegin("checksum", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:checksum: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Checksum)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.checksum (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:checksum: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLastRead() {
+		if err := oprot.WriteFieldBegin("lastRead", thrift.I64, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:lastRead: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.LastRead)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.lastRead (6) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:lastRead: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLastReadTimeType() {
+		if err := oprot.WriteFieldBegin("lastReadTimeType", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:lastReadTimeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.LastReadTimeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.lastReadTimeType (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:lastReadTimeType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetEncodedTags() {
+		if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:encodedTags: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.EncodedTags); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.encodedTags (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:encodedTags: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *BlockMetadataV2) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteBatchRawRequestElement(%+v)", *p)
+	return fmt.Sprintf("BlockMetadataV2(%+v)", *p)
 }
 
 // Attributes:
-//  - ID
-//  - Datapoint
 //  - NameSpace
-type WriteBatchRawV2RequestElement struct {
-	ID        []byte     `thrift:"id,1,required" db:"id" json:"id"`
-	Datapoint *Datapoint `thrift:"datapoint,2,required" db:"datapoint" json:"datapoint"`
-	NameSpace int64      `thrift:"nameSpace,3,required" db:"nameSpace" json:"nameSpace"`
+//  - Elements
+//  - FencingToken
+//  - Durability
+//  - IncludeDispositions
+type WriteBatchRawRequest struct {
+	NameSpace           []byte                         `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Elements            []*WriteBatchRawRequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+	FencingToken        *int64                         `thrift:"fencingToken,3" db:"fencingToken" json:"fencingToken,omitempty"`
+	Durability          *WriteDurability               `thrift:"durability,4" db:"durability" json:"durability,omitempty"`
+	IncludeDispositions bool                           `thrift:"includeDispositions,5" db:"includeDispositions" json:"includeDispositions,omitempty"`
 }
 
-func NewWriteBatchRawV2RequestElement() *WriteBatchRawV2RequestElement {
-	return &WriteBatchRawV2RequestElement{}
+func NewWriteBatchRawRequest() *WriteBatchRawRequest {
+	return &WriteBatchRawRequest{}
 }
 
-func (p *WriteBatchRawV2RequestElement) GetID() []byte {
-	return p.ID
+func (p *WriteBatchRawRequest) GetNameSpace() []byte {
+	return p.NameSpace
 }
 
-var WriteBatchRawV2RequestElement_Datapoint_DEFAULT *Datapoint
+func (p *WriteBatchRawRequest) GetElements() []*WriteBatchRawRequestElement {
+	return p.Elements
+}
 
-func (p *WriteBatchRawV2RequestElement) GetDatapoint() *Datapoint {
-	if !p.IsSetDatapoint() {
-		return WriteBatchRawV2RequestElement_Datapoint_DEFAULT
+var WriteBatchRawRequest_FencingToken_DEFAULT int64
+
+func (p *WriteBatchRawRequest) GetFencingToken() int64 {
+	if !p.IsSetFencingToken() {
+		return WriteBatchRawRequest_FencingToken_DEFAULT
 	}
-	return p.Datapoint
+	return *p.FencingToken
 }
 
-func (p *WriteBatchRawV2RequestElement) GetNameSpace() int64 {
-	return p.NameSpace
+var WriteBatchRawRequest_Durability_DEFAULT WriteDurability
+
+func (p *WriteBatchRawRequest) GetDurability() WriteDurability {
+	if !p.IsSetDurability() {
+		return WriteBatchRawRequest_Durability_DEFAULT
+	}
+	return *p.Durability
 }
-func (p *WriteBatchRawV2RequestElement) IsSetDatapoint() bool {
-	return p.Datapoint != nil
+
+var WriteBatchRawRequest_IncludeDispositions_DEFAULT bool = false
+
+func (p *WriteBatchRawRequest) GetIncludeDispositions() bool {
+	return p.IncludeDispositions
+}
+func (p *WriteBatchRawRequest) IsSetFencingToken() bool {
+	return p.FencingToken != nil
 }
 
-func (p *WriteBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawRequest) IsSetDurability() bool {
+	return p.Durability != nil
+}
+
+func (p *WriteBatchRawRequest) IsSetIncludeDispositions() bool {
+	return p.IncludeDispositions != WriteBatchRawRequest_IncludeDispositions_DEFAULT
+}
+
+func (p *WriteBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetID bool = false
-	var issetDatapoint bool = false
 	var issetNameSpace bool = false
+	var issetElements bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -6360,17 +7231,24 @@ func (p *WriteBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetID = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetDatapoint = true
+			issetElements = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -6383,48 +7261,74 @@ func (p *WriteBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
-	}
-	if !issetDatapoint {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
-	}
 	if !issetNameSpace {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
+	if !issetElements {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	}
 	return nil
 }
 
-func (p *WriteBatchRawV2RequestElement) ReadField1(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawRequest) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.ID = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *WriteBatchRawV2RequestElement) ReadField2(iprot thrift.TProtocol) error {
-	p.Datapoint = &Datapoint{
-		TimestampTimeType: 0,
+func (p *WriteBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	if err := p.Datapoint.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+	tSlice := make([]*WriteBatchRawRequestElement, 0, size)
+	p.Elements = tSlice
+	for i := 0; i < size; i++ {
+		_elem16 := &WriteBatchRawRequestElement{}
+		if err := _elem16.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem16), err)
+		}
+		p.Elements = append(p.Elements, _elem16)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *WriteBatchRawV2RequestElement) ReadField3(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawRequest) ReadField3(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.NameSpace = v
+		p.FencingToken = &v
 	}
 	return nil
 }
 
-func (p *WriteBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteBatchRawV2RequestElement"); err != nil {
+func (p *WriteBatchRawRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		temp := WriteDurability(v)
+		p.Durability = &temp
+	}
+	return nil
+}
+
+func (p *WriteBatchRawRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.IncludeDispositions = v
+	}
+	return nil
+}
+
+func (p *WriteBatchRawRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -6437,6 +7341,12 @@ func (p *WriteBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField3(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -6447,77 +7357,147 @@ func (p *WriteBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteBatchRawV2RequestElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
+func (p *WriteBatchRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteBatchRawV2RequestElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:datapoint: ", p), err)
+func (p *WriteBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
 	}
-	if err := p.Datapoint.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:datapoint: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteBatchRawV2RequestElement) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nameSpace: ", p), err)
+func (p *WriteBatchRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFencingToken() {
+		if err := oprot.WriteFieldBegin("fencingToken", thrift.I64, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:fencingToken: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.FencingToken)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.fencingToken (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:fencingToken: ", p), err)
+		}
 	}
-	if err := oprot.WriteI64(int64(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (3) field write error: ", p), err)
+	return err
+}
+
+func (p *WriteBatchRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDurability() {
+		if err := oprot.WriteFieldBegin("durability", thrift.I32, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:durability: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.Durability)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.durability (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:durability: ", p), err)
+		}
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nameSpace: ", p), err)
+	return err
+}
+
+func (p *WriteBatchRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIncludeDispositions() {
+		if err := oprot.WriteFieldBegin("includeDispositions", thrift.BOOL, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:includeDispositions: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.IncludeDispositions)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.includeDispositions (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:includeDispositions: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *WriteBatchRawV2RequestElement) String() string {
+func (p *WriteBatchRawRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteBatchRawV2RequestElement(%+v)", *p)
+	return fmt.Sprintf("WriteBatchRawRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
+//  - NameSpaces
 //  - Elements
-type WriteTaggedBatchRawRequest struct {
-	NameSpace []byte                               `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
-	Elements  []*WriteTaggedBatchRawRequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+//  - FencingToken
+//  - Durability
+type WriteBatchRawV2Request struct {
+	NameSpaces   [][]byte                         `thrift:"nameSpaces,1,required" db:"nameSpaces" json:"nameSpaces"`
+	Elements     []*WriteBatchRawV2RequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+	FencingToken *int64                           `thrift:"fencingToken,3" db:"fencingToken" json:"fencingToken,omitempty"`
+	Durability   *WriteDurability                 `thrift:"durability,4" db:"durability" json:"durability,omitempty"`
 }
 
-func NewWriteTaggedBatchRawRequest() *WriteTaggedBatchRawRequest {
-	return &WriteTaggedBatchRawRequest{}
+func NewWriteBatchRawV2Request() *WriteBatchRawV2Request {
+	return &WriteBatchRawV2Request{}
 }
 
-func (p *WriteTaggedBatchRawRequest) GetNameSpace() []byte {
-	return p.NameSpace
+func (p *WriteBatchRawV2Request) GetNameSpaces() [][]byte {
+	return p.NameSpaces
 }
 
-func (p *WriteTaggedBatchRawRequest) GetElements() []*WriteTaggedBatchRawRequestElement {
+func (p *WriteBatchRawV2Request) GetElements() []*WriteBatchRawV2RequestElement {
 	return p.Elements
 }
-func (p *WriteTaggedBatchRawRequest) Read(iprot thrift.TProtocol) error {
+
+var WriteBatchRawV2Request_FencingToken_DEFAULT int64
+
+func (p *WriteBatchRawV2Request) GetFencingToken() int64 {
+	if !p.IsSetFencingToken() {
+		return WriteBatchRawV2Request_FencingToken_DEFAULT
+	}
+	return *p.FencingToken
+}
+
+var WriteBatchRawV2Request_Durability_DEFAULT WriteDurability
+
+func (p *WriteBatchRawV2Request) GetDurability() WriteDurability {
+	if !p.IsSetDurability() {
+		return WriteBatchRawV2Request_Durability_DEFAULT
+	}
+	return *p.Durability
+}
+func (p *WriteBatchRawV2Request) IsSetFencingToken() bool {
+	return p.FencingToken != nil
+}
+
+func (p *WriteBatchRawV2Request) IsSetDurability() bool {
+	return p.Durability != nil
+}
+
+func (p *WriteBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
+	var issetNameSpaces bool = false
 	var issetElements bool = false
 
 	for {
@@ -6533,12 +7513,20 @@ func (p *WriteTaggedBatchRawRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetNameSpaces = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
 			issetElements = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -6551,8 +7539,8 @@ func (p *WriteTaggedBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	if !issetNameSpaces {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
 	}
 	if !issetElements {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
@@ -6560,28 +7548,41 @@ func (p *WriteTaggedBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.NameSpace = v
+func (p *WriteBatchRawV2Request) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([][]byte, 0, size)
+	p.NameSpaces = tSlice
+	for i := 0; i < size; i++ {
+		var _elem17 []byte
+		if v, err := iprot.ReadBinary(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem17 = v
+		}
+		p.NameSpaces = append(p.NameSpaces, _elem17)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*WriteTaggedBatchRawRequestElement, 0, size)
+	tSlice := make([]*WriteBatchRawV2RequestElement, 0, size)
 	p.Elements = tSlice
 	for i := 0; i < size; i++ {
-		_elem19 := &WriteTaggedBatchRawRequestElement{}
-		if err := _elem19.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem19), err)
+		_elem18 := &WriteBatchRawV2RequestElement{}
+		if err := _elem18.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem18), err)
 		}
-		p.Elements = append(p.Elements, _elem19)
+		p.Elements = append(p.Elements, _elem18)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -6589,8 +7590,27 @@ func (p *WriteTaggedBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteTaggedBatchRawRequest"); err != nil {
+func (p *WriteBatchRawV2Request) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.FencingToken = &v
+	}
+	return nil
+}
+
+func (p *WriteBatchRawV2Request) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		temp := WriteDurability(v)
+		p.Durability = &temp
+	}
+	return nil
+}
+
+func (p *WriteBatchRawV2Request) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawV2Request"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -6600,6 +7620,12 @@ func (p *WriteTaggedBatchRawRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -6610,20 +7636,28 @@ func (p *WriteTaggedBatchRawRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+func (p *WriteBatchRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpaces: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.NameSpaces {
+		if err := oprot.WriteBinary(v); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpaces: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+func (p *WriteBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
 	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
 	}
@@ -6644,39 +7678,78 @@ func (p *WriteTaggedBatchRawRequest) writeField2(oprot thrift.TProtocol) (err er
 	return err
 }
 
-func (p *WriteTaggedBatchRawRequest) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *WriteBatchRawV2Request) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFencingToken() {
+		if err := oprot.WriteFieldBegin("fencingToken", thrift.I64, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:fencingToken: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.FencingToken)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.fencingToken (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:fencingToken: ", p), err)
+		}
 	}
-	return fmt.Sprintf("WriteTaggedBatchRawRequest(%+v)", *p)
+	return err
 }
 
-// Attributes:
-//  - NameSpaces
-//  - Elements
-type WriteTaggedBatchRawV2Request struct {
-	NameSpaces [][]byte                               `thrift:"nameSpaces,1,required" db:"nameSpaces" json:"nameSpaces"`
-	Elements   []*WriteTaggedBatchRawV2RequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+func (p *WriteBatchRawV2Request) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDurability() {
+		if err := oprot.WriteFieldBegin("durability", thrift.I32, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:durability: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.Durability)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.durability (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:durability: ", p), err)
+		}
+	}
+	return err
 }
 
-func NewWriteTaggedBatchRawV2Request() *WriteTaggedBatchRawV2Request {
-	return &WriteTaggedBatchRawV2Request{}
+func (p *WriteBatchRawV2Request) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("WriteBatchRawV2Request(%+v)", *p)
 }
 
-func (p *WriteTaggedBatchRawV2Request) GetNameSpaces() [][]byte {
-	return p.NameSpaces
+// Attributes:
+//  - ID
+//  - Datapoint
+type WriteBatchRawRequestElement struct {
+	ID        []byte     `thrift:"id,1,required" db:"id" json:"id"`
+	Datapoint *Datapoint `thrift:"datapoint,2,required" db:"datapoint" json:"datapoint"`
 }
 
-func (p *WriteTaggedBatchRawV2Request) GetElements() []*WriteTaggedBatchRawV2RequestElement {
-	return p.Elements
+func NewWriteBatchRawRequestElement() *WriteBatchRawRequestElement {
+	return &WriteBatchRawRequestElement{}
 }
-func (p *WriteTaggedBatchRawV2Request) Read(iprot thrift.TProtocol) error {
+
+func (p *WriteBatchRawRequestElement) GetID() []byte {
+	return p.ID
+}
+
+var WriteBatchRawRequestElement_Datapoint_DEFAULT *Datapoint
+
+func (p *WriteBatchRawRequestElement) GetDatapoint() *Datapoint {
+	if !p.IsSetDatapoint() {
+		return WriteBatchRawRequestElement_Datapoint_DEFAULT
+	}
+	return p.Datapoint
+}
+func (p *WriteBatchRawRequestElement) IsSetDatapoint() bool {
+	return p.Datapoint != nil
+}
+
+func (p *WriteBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpaces bool = false
-	var issetElements bool = false
+	var issetID bool = false
+	var issetDatapoint bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -6691,12 +7764,12 @@ func (p *WriteTaggedBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpaces = true
+			issetID = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetElements = true
+			issetDatapoint = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -6709,59 +7782,36 @@ func (p *WriteTaggedBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpaces {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetElements {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	if !issetDatapoint {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2Request) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([][]byte, 0, size)
-	p.NameSpaces = tSlice
-	for i := 0; i < size; i++ {
-		var _elem20 []byte
-		if v, err := iprot.ReadBinary(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem20 = v
-		}
-		p.NameSpaces = append(p.NameSpaces, _elem20)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+func (p *WriteBatchRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.ID = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*WriteTaggedBatchRawV2RequestElement, 0, size)
-	p.Elements = tSlice
-	for i := 0; i < size; i++ {
-		_elem21 := &WriteTaggedBatchRawV2RequestElement{}
-		if err := _elem21.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem21), err)
-		}
-		p.Elements = append(p.Elements, _elem21)
+func (p *WriteBatchRawRequestElement) ReadField2(iprot thrift.TProtocol) error {
+	p.Datapoint = &Datapoint{
+		TimestampTimeType: 0,
 	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	if err := p.Datapoint.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2Request) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteTaggedBatchRawV2Request"); err != nil {
+func (p *WriteBatchRawRequestElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawRequestElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -6781,97 +7831,81 @@ func (p *WriteTaggedBatchRawV2Request) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpaces: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.NameSpaces {
-		if err := oprot.WriteBinary(v); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
-		}
+func (p *WriteBatchRawRequestElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpaces: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Elements {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
+func (p *WriteBatchRawRequestElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:datapoint: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	if err := p.Datapoint.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:datapoint: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawV2Request) String() string {
+func (p *WriteBatchRawRequestElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteTaggedBatchRawV2Request(%+v)", *p)
+	return fmt.Sprintf("WriteBatchRawRequestElement(%+v)", *p)
 }
 
 // Attributes:
 //  - ID
-//  - EncodedTags
 //  - Datapoint
-type WriteTaggedBatchRawRequestElement struct {
-	ID          []byte     `thrift:"id,1,required" db:"id" json:"id"`
-	EncodedTags []byte     `thrift:"encodedTags,2,required" db:"encodedTags" json:"encodedTags"`
-	Datapoint   *Datapoint `thrift:"datapoint,3,required" db:"datapoint" json:"datapoint"`
+//  - NameSpace
+type WriteBatchRawV2RequestElement struct {
+	ID        []byte     `thrift:"id,1,required" db:"id" json:"id"`
+	Datapoint *Datapoint `thrift:"datapoint,2,required" db:"datapoint" json:"datapoint"`
+	NameSpace int64      `thrift:"nameSpace,3,required" db:"nameSpace" json:"nameSpace"`
 }
 
-func NewWriteTaggedBatchRawRequestElement() *WriteTaggedBatchRawRequestElement {
-	return &WriteTaggedBatchRawRequestElement{}
+func NewWriteBatchRawV2RequestElement() *WriteBatchRawV2RequestElement {
+	return &WriteBatchRawV2RequestElement{}
 }
 
-func (p *WriteTaggedBatchRawRequestElement) GetID() []byte {
+func (p *WriteBatchRawV2RequestElement) GetID() []byte {
 	return p.ID
 }
 
-func (p *WriteTaggedBatchRawRequestElement) GetEncodedTags() []byte {
-	return p.EncodedTags
-}
-
-var WriteTaggedBatchRawRequestElement_Datapoint_DEFAULT *Datapoint
+var WriteBatchRawV2RequestElement_Datapoint_DEFAULT *Datapoint
 
-func (p *WriteTaggedBatchRawRequestElement) GetDatapoint() *Datapoint {
+func (p *WriteBatchRawV2RequestElement) GetDatapoint() *Datapoint {
 	if !p.IsSetDatapoint() {
-		return WriteTaggedBatchRawRequestElement_Datapoint_DEFAULT
+		return WriteBatchRawV2RequestElement_Datapoint_DEFAULT
 	}
 	return p.Datapoint
 }
-func (p *WriteTaggedBatchRawRequestElement) IsSetDatapoint() bool {
+
+func (p *WriteBatchRawV2RequestElement) GetNameSpace() int64 {
+	return p.NameSpace
+}
+func (p *WriteBatchRawV2RequestElement) IsSetDatapoint() bool {
 	return p.Datapoint != nil
 }
 
-func (p *WriteTaggedBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
 	var issetID bool = false
-	var issetEncodedTags bool = false
 	var issetDatapoint bool = false
+	var issetNameSpace bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -6891,12 +7925,12 @@ func (p *WriteTaggedBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetEncodedTags = true
+			issetDatapoint = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetDatapoint = true
+			issetNameSpace = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -6912,16 +7946,16 @@ func (p *WriteTaggedBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
 	if !issetID {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetEncodedTags {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field EncodedTags is not set"))
-	}
 	if !issetDatapoint {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
 	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawV2RequestElement) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
@@ -6930,16 +7964,7 @@ func (p *WriteTaggedBatchRawRequestElement) ReadField1(iprot thrift.TProtocol) e
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequestElement) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.EncodedTags = v
-	}
-	return nil
-}
-
-func (p *WriteTaggedBatchRawRequestElement) ReadField3(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawV2RequestElement) ReadField2(iprot thrift.TProtocol) error {
 	p.Datapoint = &Datapoint{
 		TimestampTimeType: 0,
 	}
@@ -6949,8 +7974,17 @@ func (p *WriteTaggedBatchRawRequestElement) ReadField3(iprot thrift.TProtocol) e
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequestElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteTaggedBatchRawRequestElement"); err != nil {
+func (p *WriteBatchRawV2RequestElement) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.NameSpace = v
+	}
+	return nil
+}
+
+func (p *WriteBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawV2RequestElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -6973,7 +8007,7 @@ func (p *WriteTaggedBatchRawRequestElement) Write(oprot thrift.TProtocol) error
 	return nil
 }
 
-func (p *WriteTaggedBatchRawRequestElement) writeField1(oprot thrift.TProtocol) (err error) {
+func (p *WriteBatchRawV2RequestElement) writeField1(oprot thrift.TProtocol) (err error) {
 	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
@@ -6986,88 +8020,119 @@ func (p *WriteTaggedBatchRawRequestElement) writeField1(oprot thrift.TProtocol)
 	return err
 }
 
-func (p *WriteTaggedBatchRawRequestElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:encodedTags: ", p), err)
+func (p *WriteBatchRawV2RequestElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:datapoint: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.EncodedTags); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.encodedTags (2) field write error: ", p), err)
+	if err := p.Datapoint.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:encodedTags: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:datapoint: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawRequestElement) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:datapoint: ", p), err)
+func (p *WriteBatchRawV2RequestElement) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:nameSpace: ", p), err)
 	}
-	if err := p.Datapoint.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
+	if err := oprot.WriteI64(int64(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (3) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:datapoint: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawRequestElement) String() string {
+func (p *WriteBatchRawV2RequestElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteTaggedBatchRawRequestElement(%+v)", *p)
+	return fmt.Sprintf("WriteBatchRawV2RequestElement(%+v)", *p)
 }
 
 // Attributes:
-//  - ID
-//  - EncodedTags
-//  - Datapoint
 //  - NameSpace
-type WriteTaggedBatchRawV2RequestElement struct {
-	ID          []byte     `thrift:"id,1,required" db:"id" json:"id"`
-	EncodedTags []byte     `thrift:"encodedTags,2,required" db:"encodedTags" json:"encodedTags"`
-	Datapoint   *Datapoint `thrift:"datapoint,3,required" db:"datapoint" json:"datapoint"`
-	NameSpace   int64      `thrift:"nameSpace,4,required" db:"nameSpace" json:"nameSpace"`
+//  - Elements
+//  - IdempotencyKey
+//  - FencingToken
+//  - Durability
+//  - IncludeDispositions
+type WriteTaggedBatchRawRequest struct {
+	NameSpace           []byte                               `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+	Elements            []*WriteTaggedBatchRawRequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+	IdempotencyKey      []byte                               `thrift:"idempotencyKey,3" db:"idempotencyKey" json:"idempotencyKey,omitempty"`
+	FencingToken        *int64                               `thrift:"fencingToken,4" db:"fencingToken" json:"fencingToken,omitempty"`
+	Durability          *WriteDurability                     `thrift:"durability,5" db:"durability" json:"durability,omitempty"`
+	IncludeDispositions bool                                 `thrift:"includeDispositions,6" db:"includeDispositions" json:"includeDispositions,omitempty"`
 }
 
-func NewWriteTaggedBatchRawV2RequestElement() *WriteTaggedBatchRawV2RequestElement {
-	return &WriteTaggedBatchRawV2RequestElement{}
+func NewWriteTaggedBatchRawRequest() *WriteTaggedBatchRawRequest {
+	return &WriteTaggedBatchRawRequest{}
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) GetID() []byte {
-	return p.ID
+func (p *WriteTaggedBatchRawRequest) GetNameSpace() []byte {
+	return p.NameSpace
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) GetEncodedTags() []byte {
-	return p.EncodedTags
+func (p *WriteTaggedBatchRawRequest) GetElements() []*WriteTaggedBatchRawRequestElement {
+	return p.Elements
 }
 
-var WriteTaggedBatchRawV2RequestElement_Datapoint_DEFAULT *Datapoint
+var WriteTaggedBatchRawRequest_IdempotencyKey_DEFAULT []byte
 
-func (p *WriteTaggedBatchRawV2RequestElement) GetDatapoint() *Datapoint {
-	if !p.IsSetDatapoint() {
-		return WriteTaggedBatchRawV2RequestElement_Datapoint_DEFAULT
+func (p *WriteTaggedBatchRawRequest) GetIdempotencyKey() []byte {
+	return p.IdempotencyKey
+}
+
+var WriteTaggedBatchRawRequest_FencingToken_DEFAULT int64
+
+func (p *WriteTaggedBatchRawRequest) GetFencingToken() int64 {
+	if !p.IsSetFencingToken() {
+		return WriteTaggedBatchRawRequest_FencingToken_DEFAULT
 	}
-	return p.Datapoint
+	return *p.FencingToken
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) GetNameSpace() int64 {
-	return p.NameSpace
+var WriteTaggedBatchRawRequest_Durability_DEFAULT WriteDurability
+
+func (p *WriteTaggedBatchRawRequest) GetDurability() WriteDurability {
+	if !p.IsSetDurability() {
+		return WriteTaggedBatchRawRequest_Durability_DEFAULT
+	}
+	return *p.Durability
 }
-func (p *WriteTaggedBatchRawV2RequestElement) IsSetDatapoint() bool {
-	return p.Datapoint != nil
+
+var WriteTaggedBatchRawRequest_IncludeDispositions_DEFAULT bool = false
+
+func (p *WriteTaggedBatchRawRequest) GetIncludeDispositions() bool {
+	return p.IncludeDispositions
+}
+func (p *WriteTaggedBatchRawRequest) IsSetIdempotencyKey() bool {
+	return p.IdempotencyKey != nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
+func (p *WriteTaggedBatchRawRequest) IsSetFencingToken() bool {
+	return p.FencingToken != nil
+}
+
+func (p *WriteTaggedBatchRawRequest) IsSetDurability() bool {
+	return p.Durability != nil
+}
+
+func (p *WriteTaggedBatchRawRequest) IsSetIncludeDispositions() bool {
+	return p.IncludeDispositions != WriteTaggedBatchRawRequest_IncludeDispositions_DEFAULT
+}
+
+func (p *WriteTaggedBatchRawRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetID bool = false
-	var issetEncodedTags bool = false
-	var issetDatapoint bool = false
 	var issetNameSpace bool = false
+	var issetElements bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -7082,22 +8147,28 @@ func (p *WriteTaggedBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetID = true
+			issetNameSpace = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetEncodedTags = true
+			issetElements = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetDatapoint = true
 		case 4:
 			if err := p.ReadField4(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -7110,60 +8181,83 @@ func (p *WriteTaggedBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetID {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
-	}
-	if !issetEncodedTags {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field EncodedTags is not set"))
-	}
-	if !issetDatapoint {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
-	}
 	if !issetNameSpace {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
+	if !issetElements {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
+	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) ReadField1(iprot thrift.TProtocol) error {
+func (p *WriteTaggedBatchRawRequest) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.ID = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) ReadField2(iprot thrift.TProtocol) error {
+func (p *WriteTaggedBatchRawRequest) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*WriteTaggedBatchRawRequestElement, 0, size)
+	p.Elements = tSlice
+	for i := 0; i < size; i++ {
+		_elem19 := &WriteTaggedBatchRawRequestElement{}
+		if err := _elem19.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem19), err)
+		}
+		p.Elements = append(p.Elements, _elem19)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *WriteTaggedBatchRawRequest) ReadField3(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
+		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.EncodedTags = v
+		p.IdempotencyKey = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) ReadField3(iprot thrift.TProtocol) error {
-	p.Datapoint = &Datapoint{
-		TimestampTimeType: 0,
+func (p *WriteTaggedBatchRawRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.FencingToken = &v
 	}
-	if err := p.Datapoint.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+	return nil
+}
+
+func (p *WriteTaggedBatchRawRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		temp := WriteDurability(v)
+		p.Durability = &temp
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
+func (p *WriteTaggedBatchRawRequest) ReadField6(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 6: ", err)
 	} else {
-		p.NameSpace = v
+		p.IncludeDispositions = v
 	}
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteTaggedBatchRawV2RequestElement"); err != nil {
+func (p *WriteTaggedBatchRawRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteTaggedBatchRawRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -7179,6 +8273,12 @@ func (p *WriteTaggedBatchRawV2RequestElement) Write(oprot thrift.TProtocol) erro
 		if err := p.writeField4(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -7189,100 +8289,175 @@ func (p *WriteTaggedBatchRawV2RequestElement) Write(oprot thrift.TProtocol) erro
 	return nil
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
+func (p *WriteTaggedBatchRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.ID); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:encodedTags: ", p), err)
+func (p *WriteTaggedBatchRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.EncodedTags); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.encodedTags (2) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:encodedTags: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:datapoint: ", p), err)
-	}
-	if err := p.Datapoint.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:datapoint: ", p), err)
+func (p *WriteTaggedBatchRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIdempotencyKey() {
+		if err := oprot.WriteFieldBegin("idempotencyKey", thrift.STRING, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:idempotencyKey: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.IdempotencyKey); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.idempotencyKey (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:idempotencyKey: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.I64, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpace: ", p), err)
+func (p *WriteTaggedBatchRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFencingToken() {
+		if err := oprot.WriteFieldBegin("fencingToken", thrift.I64, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:fencingToken: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.FencingToken)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.fencingToken (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:fencingToken: ", p), err)
+		}
 	}
-	if err := oprot.WriteI64(int64(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (4) field write error: ", p), err)
+	return err
+}
+
+func (p *WriteTaggedBatchRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDurability() {
+		if err := oprot.WriteFieldBegin("durability", thrift.I32, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:durability: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.Durability)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.durability (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:durability: ", p), err)
+		}
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpace: ", p), err)
+	return err
+}
+
+func (p *WriteTaggedBatchRawRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIncludeDispositions() {
+		if err := oprot.WriteFieldBegin("includeDispositions", thrift.BOOL, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:includeDispositions: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(p.IncludeDispositions)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.includeDispositions (6) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:includeDispositions: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *WriteTaggedBatchRawV2RequestElement) String() string {
+func (p *WriteTaggedBatchRawRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteTaggedBatchRawV2RequestElement(%+v)", *p)
+	return fmt.Sprintf("WriteTaggedBatchRawRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - Index
-//  - Err
-type WriteBatchRawError struct {
-	Index int64  `thrift:"index,1,required" db:"index" json:"index"`
-	Err   *Error `thrift:"err,2,required" db:"err" json:"err"`
+//  - NameSpaces
+//  - Elements
+//  - IdempotencyKey
+//  - FencingToken
+//  - Durability
+type WriteTaggedBatchRawV2Request struct {
+	NameSpaces     [][]byte                               `thrift:"nameSpaces,1,required" db:"nameSpaces" json:"nameSpaces"`
+	Elements       []*WriteTaggedBatchRawV2RequestElement `thrift:"elements,2,required" db:"elements" json:"elements"`
+	IdempotencyKey []byte                                 `thrift:"idempotencyKey,3" db:"idempotencyKey" json:"idempotencyKey,omitempty"`
+	FencingToken   *int64                                 `thrift:"fencingToken,4" db:"fencingToken" json:"fencingToken,omitempty"`
+	Durability     *WriteDurability                       `thrift:"durability,5" db:"durability" json:"durability,omitempty"`
 }
 
-func NewWriteBatchRawError() *WriteBatchRawError {
-	return &WriteBatchRawError{}
+func NewWriteTaggedBatchRawV2Request() *WriteTaggedBatchRawV2Request {
+	return &WriteTaggedBatchRawV2Request{}
 }
 
-func (p *WriteBatchRawError) GetIndex() int64 {
-	return p.Index
+func (p *WriteTaggedBatchRawV2Request) GetNameSpaces() [][]byte {
+	return p.NameSpaces
 }
 
-var WriteBatchRawError_Err_DEFAULT *Error
+func (p *WriteTaggedBatchRawV2Request) GetElements() []*WriteTaggedBatchRawV2RequestElement {
+	return p.Elements
+}
 
-func (p *WriteBatchRawError) GetErr() *Error {
-	if !p.IsSetErr() {
-		return WriteBatchRawError_Err_DEFAULT
+var WriteTaggedBatchRawV2Request_IdempotencyKey_DEFAULT []byte
+
+func (p *WriteTaggedBatchRawV2Request) GetIdempotencyKey() []byte {
+	return p.IdempotencyKey
+}
+
+var WriteTaggedBatchRawV2Request_FencingToken_DEFAULT int64
+
+func (p *WriteTaggedBatchRawV2Request) GetFencingToken() int64 {
+	if !p.IsSetFencingToken() {
+		return WriteTaggedBatchRawV2Request_FencingToken_DEFAULT
 	}
-	return p.Err
+	return *p.FencingToken
 }
-func (p *WriteBatchRawError) IsSetErr() bool {
-	return p.Err != nil
+
+var WriteTaggedBatchRawV2Request_Durability_DEFAULT WriteDurability
+
+func (p *WriteTaggedBatchRawV2Request) GetDurability() WriteDurability {
+	if !p.IsSetDurability() {
+		return WriteTaggedBatchRawV2Request_Durability_DEFAULT
+	}
+	return *p.Durability
+}
+func (p *WriteTaggedBatchRawV2Request) IsSetIdempotencyKey() bool {
+	return p.IdempotencyKey != nil
 }
 
-func (p *WriteBatchRawError) Read(iprot thrift.TProtocol) error {
+func (p *WriteTaggedBatchRawV2Request) IsSetFencingToken() bool {
+	return p.FencingToken != nil
+}
+
+func (p *WriteTaggedBatchRawV2Request) IsSetDurability() bool {
+	return p.Durability != nil
+}
+
+func (p *WriteTaggedBatchRawV2Request) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetIndex bool = false
-	var issetErr bool = false
+	var issetNameSpaces bool = false
+	var issetElements bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -7297,12 +8472,24 @@ func (p *WriteBatchRawError) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetIndex = true
+			issetNameSpaces = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetErr = true
+			issetElements = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -7315,36 +8502,87 @@ func (p *WriteBatchRawError) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetIndex {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Index is not set"))
+	if !issetNameSpaces {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
 	}
-	if !issetErr {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Err is not set"))
+	if !issetElements {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Elements is not set"))
 	}
 	return nil
 }
 
-func (p *WriteBatchRawError) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
+func (p *WriteTaggedBatchRawV2Request) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([][]byte, 0, size)
+	p.NameSpaces = tSlice
+	for i := 0; i < size; i++ {
+		var _elem20 []byte
+		if v, err := iprot.ReadBinary(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem20 = v
+		}
+		p.NameSpaces = append(p.NameSpaces, _elem20)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *WriteTaggedBatchRawV2Request) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*WriteTaggedBatchRawV2RequestElement, 0, size)
+	p.Elements = tSlice
+	for i := 0; i < size; i++ {
+		_elem21 := &WriteTaggedBatchRawV2RequestElement{}
+		if err := _elem21.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem21), err)
+		}
+		p.Elements = append(p.Elements, _elem21)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *WriteTaggedBatchRawV2Request) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.Index = v
+		p.IdempotencyKey = v
 	}
 	return nil
 }
 
-func (p *WriteBatchRawError) ReadField2(iprot thrift.TProtocol) error {
-	p.Err = &Error{
-		Type: 0,
+func (p *WriteTaggedBatchRawV2Request) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.FencingToken = &v
 	}
-	if err := p.Err.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	return nil
+}
+
+func (p *WriteTaggedBatchRawV2Request) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		temp := WriteDurability(v)
+		p.Durability = &temp
 	}
 	return nil
 }
 
-func (p *WriteBatchRawError) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("WriteBatchRawError"); err != nil {
+func (p *WriteTaggedBatchRawV2Request) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteTaggedBatchRawV2Request"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -7354,6 +8592,15 @@ func (p *WriteBatchRawError) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -7364,58 +8611,142 @@ func (p *WriteBatchRawError) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *WriteBatchRawError) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("index", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:index: ", p), err)
+func (p *WriteTaggedBatchRawV2Request) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpaces: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.Index)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.index (1) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.NameSpaces {
+		if err := oprot.WriteBinary(v); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:index: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpaces: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteBatchRawError) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:err: ", p), err)
+func (p *WriteTaggedBatchRawV2Request) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("elements", thrift.LIST, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:elements: ", p), err)
 	}
-	if err := p.Err.Write(oprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Elements)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Elements {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:err: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:elements: ", p), err)
 	}
 	return err
 }
 
-func (p *WriteBatchRawError) String() string {
+func (p *WriteTaggedBatchRawV2Request) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetIdempotencyKey() {
+		if err := oprot.WriteFieldBegin("idempotencyKey", thrift.STRING, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:idempotencyKey: ", p), err)
+		}
+		if err := oprot.WriteBinary(p.IdempotencyKey); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.idempotencyKey (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:idempotencyKey: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *WriteTaggedBatchRawV2Request) writeField4(oprot thrift.TProtocol) (err error) {
+	if p.IsSetFencingToken() {
+		if err := oprot.WriteFieldBegin("fencingToken", thrift.I64, 4); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:fencingToken: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.FencingToken)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.fencingToken (4) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 4:fencingToken: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *WriteTaggedBatchRawV2Request) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDurability() {
+		if err := oprot.WriteFieldBegin("durability", thrift.I32, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:durability: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.Durability)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.durability (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:durability: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *WriteTaggedBatchRawV2Request) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("WriteBatchRawError(%+v)", *p)
+	return fmt.Sprintf("WriteTaggedBatchRawV2Request(%+v)", *p)
 }
 
 // Attributes:
-//  - NameSpace
-type TruncateRequest struct {
-	NameSpace []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
+//  - ID
+//  - EncodedTags
+//  - Datapoint
+type WriteTaggedBatchRawRequestElement struct {
+	ID          []byte     `thrift:"id,1,required" db:"id" json:"id"`
+	EncodedTags []byte     `thrift:"encodedTags,2,required" db:"encodedTags" json:"encodedTags"`
+	Datapoint   *Datapoint `thrift:"datapoint,3,required" db:"datapoint" json:"datapoint"`
 }
 
-func NewTruncateRequest() *TruncateRequest {
-	return &TruncateRequest{}
+func NewWriteTaggedBatchRawRequestElement() *WriteTaggedBatchRawRequestElement {
+	return &WriteTaggedBatchRawRequestElement{}
 }
 
-func (p *TruncateRequest) GetNameSpace() []byte {
-	return p.NameSpace
+func (p *WriteTaggedBatchRawRequestElement) GetID() []byte {
+	return p.ID
 }
-func (p *TruncateRequest) Read(iprot thrift.TProtocol) error {
+
+func (p *WriteTaggedBatchRawRequestElement) GetEncodedTags() []byte {
+	return p.EncodedTags
+}
+
+var WriteTaggedBatchRawRequestElement_Datapoint_DEFAULT *Datapoint
+
+func (p *WriteTaggedBatchRawRequestElement) GetDatapoint() *Datapoint {
+	if !p.IsSetDatapoint() {
+		return WriteTaggedBatchRawRequestElement_Datapoint_DEFAULT
+	}
+	return p.Datapoint
+}
+func (p *WriteTaggedBatchRawRequestElement) IsSetDatapoint() bool {
+	return p.Datapoint != nil
+}
+
+func (p *WriteTaggedBatchRawRequestElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetNameSpace bool = false
+	var issetID bool = false
+	var issetEncodedTags bool = false
+	var issetDatapoint bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -7430,7 +8761,17 @@ func (p *TruncateRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetNameSpace = true
+			issetID = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetEncodedTags = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetDatapoint = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -7443,29 +8784,60 @@ func (p *TruncateRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
+	}
+	if !issetEncodedTags {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field EncodedTags is not set"))
+	}
+	if !issetDatapoint {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
 	}
 	return nil
 }
 
-func (p *TruncateRequest) ReadField1(iprot thrift.TProtocol) error {
+func (p *WriteTaggedBatchRawRequestElement) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.NameSpace = v
+		p.ID = v
 	}
 	return nil
 }
 
-func (p *TruncateRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("TruncateRequest"); err != nil {
+func (p *WriteTaggedBatchRawRequestElement) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.EncodedTags = v
+	}
+	return nil
+}
+
+func (p *WriteTaggedBatchRawRequestElement) ReadField3(iprot thrift.TProtocol) error {
+	p.Datapoint = &Datapoint{
+		TimestampTimeType: 0,
+	}
+	if err := p.Datapoint.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+	}
+	return nil
+}
+
+func (p *WriteTaggedBatchRawRequestElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteTaggedBatchRawRequestElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -7476,158 +8848,101 @@ func (p *TruncateRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *TruncateRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
+func (p *WriteTaggedBatchRawRequestElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
 	}
 	return err
 }
 
-func (p *TruncateRequest) String() string {
-	if p == nil {
-		return "<nil>"
-	}
-	return fmt.Sprintf("TruncateRequest(%+v)", *p)
-}
-
-// Attributes:
-//  - NumSeries
-type TruncateResult_ struct {
-	NumSeries int64 `thrift:"numSeries,1,required" db:"numSeries" json:"numSeries"`
-}
-
-func NewTruncateResult_() *TruncateResult_ {
-	return &TruncateResult_{}
-}
-
-func (p *TruncateResult_) GetNumSeries() int64 {
-	return p.NumSeries
-}
-func (p *TruncateResult_) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
-
-	var issetNumSeries bool = false
-
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetNumSeries = true
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
-			}
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
-	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
-	}
-	if !issetNumSeries {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NumSeries is not set"))
-	}
-	return nil
-}
-
-func (p *TruncateResult_) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.NumSeries = v
-	}
-	return nil
-}
-
-func (p *TruncateResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("TruncateResult"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
-		}
+func (p *WriteTaggedBatchRawRequestElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:encodedTags: ", p), err)
 	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
+	if err := oprot.WriteBinary(p.EncodedTags); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.encodedTags (2) field write error: ", p), err)
 	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:encodedTags: ", p), err)
 	}
-	return nil
+	return err
 }
 
-func (p *TruncateResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("numSeries", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:numSeries: ", p), err)
+func (p *WriteTaggedBatchRawRequestElement) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:datapoint: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.NumSeries)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.numSeries (1) field write error: ", p), err)
+	if err := p.Datapoint.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:numSeries: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:datapoint: ", p), err)
 	}
 	return err
 }
 
-func (p *TruncateResult_) String() string {
+func (p *WriteTaggedBatchRawRequestElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("TruncateResult_(%+v)", *p)
+	return fmt.Sprintf("WriteTaggedBatchRawRequestElement(%+v)", *p)
 }
 
 // Attributes:
-//  - Ok
-//  - Status
-//  - Bootstrapped
-type NodeHealthResult_ struct {
-	Ok           bool   `thrift:"ok,1,required" db:"ok" json:"ok"`
-	Status       string `thrift:"status,2,required" db:"status" json:"status"`
-	Bootstrapped bool   `thrift:"bootstrapped,3,required" db:"bootstrapped" json:"bootstrapped"`
+//  - ID
+//  - EncodedTags
+//  - Datapoint
+//  - NameSpace
+type WriteTaggedBatchRawV2RequestElement struct {
+	ID          []byte     `thrift:"id,1,required" db:"id" json:"id"`
+	EncodedTags []byte     `thrift:"encodedTags,2,required" db:"encodedTags" json:"encodedTags"`
+	Datapoint   *Datapoint `thrift:"datapoint,3,required" db:"datapoint" json:"datapoint"`
+	NameSpace   int64      `thrift:"nameSpace,4,required" db:"nameSpace" json:"nameSpace"`
 }
 
-func NewNodeHealthResult_() *NodeHealthResult_ {
-	return &NodeHealthResult_{}
+func NewWriteTaggedBatchRawV2RequestElement() *WriteTaggedBatchRawV2RequestElement {
+	return &WriteTaggedBatchRawV2RequestElement{}
 }
 
-func (p *NodeHealthResult_) GetOk() bool {
-	return p.Ok
+func (p *WriteTaggedBatchRawV2RequestElement) GetID() []byte {
+	return p.ID
 }
 
-func (p *NodeHealthResult_) GetStatus() string {
-	return p.Status
+func (p *WriteTaggedBatchRawV2RequestElement) GetEncodedTags() []byte {
+	return p.EncodedTags
 }
 
-func (p *NodeHealthResult_) GetBootstrapped() bool {
-	return p.Bootstrapped
+var WriteTaggedBatchRawV2RequestElement_Datapoint_DEFAULT *Datapoint
+
+func (p *WriteTaggedBatchRawV2RequestElement) GetDatapoint() *Datapoint {
+	if !p.IsSetDatapoint() {
+		return WriteTaggedBatchRawV2RequestElement_Datapoint_DEFAULT
+	}
+	return p.Datapoint
 }
-func (p *NodeHealthResult_) Read(iprot thrift.TProtocol) error {
+
+func (p *WriteTaggedBatchRawV2RequestElement) GetNameSpace() int64 {
+	return p.NameSpace
+}
+func (p *WriteTaggedBatchRawV2RequestElement) IsSetDatapoint() bool {
+	return p.Datapoint != nil
+}
+
+func (p *WriteTaggedBatchRawV2RequestElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetOk bool = false
-	var issetStatus bool = false
-	var issetBootstrapped bool = false
+	var issetID bool = false
+	var issetEncodedTags bool = false
+	var issetDatapoint bool = false
+	var issetNameSpace bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -7642,17 +8957,22 @@ func (p *NodeHealthResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetOk = true
+			issetID = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetStatus = true
+			issetEncodedTags = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetBootstrapped = true
+			issetDatapoint = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetNameSpace = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -7665,47 +8985,60 @@ func (p *NodeHealthResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetOk {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Ok is not set"))
+	if !issetID {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field ID is not set"))
 	}
-	if !issetStatus {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Status is not set"))
+	if !issetEncodedTags {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field EncodedTags is not set"))
 	}
-	if !issetBootstrapped {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Bootstrapped is not set"))
+	if !issetDatapoint {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Datapoint is not set"))
+	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
 	return nil
 }
 
-func (p *NodeHealthResult_) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
+func (p *WriteTaggedBatchRawV2RequestElement) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Ok = v
+		p.ID = v
 	}
 	return nil
 }
 
-func (p *NodeHealthResult_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
+func (p *WriteTaggedBatchRawV2RequestElement) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.Status = v
+		p.EncodedTags = v
 	}
 	return nil
 }
 
-func (p *NodeHealthResult_) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
+func (p *WriteTaggedBatchRawV2RequestElement) ReadField3(iprot thrift.TProtocol) error {
+	p.Datapoint = &Datapoint{
+		TimestampTimeType: 0,
+	}
+	if err := p.Datapoint.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Datapoint), err)
+	}
+	return nil
+}
+
+func (p *WriteTaggedBatchRawV2RequestElement) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
 	} else {
-		p.Bootstrapped = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *NodeHealthResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeHealthResult"); err != nil {
+func (p *WriteTaggedBatchRawV2RequestElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteTaggedBatchRawV2RequestElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -7718,6 +9051,9 @@ func (p *NodeHealthResult_) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField3(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -7728,195 +9064,115 @@ func (p *NodeHealthResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *NodeHealthResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("ok", thrift.BOOL, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:ok: ", p), err)
+func (p *WriteTaggedBatchRawV2RequestElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("id", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:id: ", p), err)
 	}
-	if err := oprot.WriteBool(bool(p.Ok)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.ok (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.ID); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.id (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:ok: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:id: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeHealthResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("status", thrift.STRING, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:status: ", p), err)
+func (p *WriteTaggedBatchRawV2RequestElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("encodedTags", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:encodedTags: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.Status)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.status (2) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.EncodedTags); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.encodedTags (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:status: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:encodedTags: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeHealthResult_) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("bootstrapped", thrift.BOOL, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:bootstrapped: ", p), err)
+func (p *WriteTaggedBatchRawV2RequestElement) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("datapoint", thrift.STRUCT, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:datapoint: ", p), err)
 	}
-	if err := oprot.WriteBool(bool(p.Bootstrapped)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.bootstrapped (3) field write error: ", p), err)
+	if err := p.Datapoint.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Datapoint), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:bootstrapped: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:datapoint: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeHealthResult_) String() string {
-	if p == nil {
-		return "<nil>"
-	}
-	return fmt.Sprintf("NodeHealthResult_(%+v)", *p)
-}
-
-type NodeBootstrappedResult_ struct {
-}
-
-func NewNodeBootstrappedResult_() *NodeBootstrappedResult_ {
-	return &NodeBootstrappedResult_{}
-}
-
-func (p *NodeBootstrappedResult_) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
-
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		if err := iprot.Skip(fieldTypeId); err != nil {
-			return err
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
-	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
-	}
-	return nil
-}
-
-func (p *NodeBootstrappedResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeBootstrappedResult"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
+func (p *WriteTaggedBatchRawV2RequestElement) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.I64, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
+	if err := oprot.WriteI64(int64(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (4) field write error: ", p), err)
 	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpace: ", p), err)
 	}
-	return nil
+	return err
 }
 
-func (p *NodeBootstrappedResult_) String() string {
+func (p *WriteTaggedBatchRawV2RequestElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeBootstrappedResult_(%+v)", *p)
+	return fmt.Sprintf("WriteTaggedBatchRawV2RequestElement(%+v)", *p)
 }
 
-type NodeBootstrappedInPlacementOrNoPlacementResult_ struct {
+// Attributes:
+//  - Index
+//  - Err
+//  - ErrType
+type WriteBatchRawError struct {
+	Index   int64                   `thrift:"index,1,required" db:"index" json:"index"`
+	Err     *Error                  `thrift:"err,2,required" db:"err" json:"err"`
+	ErrType *WriteBatchRawErrorType `thrift:"errType,3" db:"errType" json:"errType,omitempty"`
 }
 
-func NewNodeBootstrappedInPlacementOrNoPlacementResult_() *NodeBootstrappedInPlacementOrNoPlacementResult_ {
-	return &NodeBootstrappedInPlacementOrNoPlacementResult_{}
+func NewWriteBatchRawError() *WriteBatchRawError {
+	return &WriteBatchRawError{}
 }
 
-func (p *NodeBootstrappedInPlacementOrNoPlacementResult_) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
-
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		if err := iprot.Skip(fieldTypeId); err != nil {
-			return err
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
-	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
-	}
-	return nil
+func (p *WriteBatchRawError) GetIndex() int64 {
+	return p.Index
 }
 
-func (p *NodeBootstrappedInPlacementOrNoPlacementResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeBootstrappedInPlacementOrNoPlacementResult"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
-	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
-	}
-	return nil
-}
+var WriteBatchRawError_Err_DEFAULT *Error
 
-func (p *NodeBootstrappedInPlacementOrNoPlacementResult_) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *WriteBatchRawError) GetErr() *Error {
+	if !p.IsSetErr() {
+		return WriteBatchRawError_Err_DEFAULT
 	}
-	return fmt.Sprintf("NodeBootstrappedInPlacementOrNoPlacementResult_(%+v)", *p)
+	return p.Err
 }
 
-// Attributes:
-//  - LimitEnabled
-//  - LimitMbps
-//  - LimitCheckEvery
-type NodePersistRateLimitResult_ struct {
-	LimitEnabled    bool    `thrift:"limitEnabled,1,required" db:"limitEnabled" json:"limitEnabled"`
-	LimitMbps       float64 `thrift:"limitMbps,2,required" db:"limitMbps" json:"limitMbps"`
-	LimitCheckEvery int64   `thrift:"limitCheckEvery,3,required" db:"limitCheckEvery" json:"limitCheckEvery"`
-}
+var WriteBatchRawError_ErrType_DEFAULT WriteBatchRawErrorType
 
-func NewNodePersistRateLimitResult_() *NodePersistRateLimitResult_ {
-	return &NodePersistRateLimitResult_{}
+func (p *WriteBatchRawError) GetErrType() WriteBatchRawErrorType {
+	if !p.IsSetErrType() {
+		return WriteBatchRawError_ErrType_DEFAULT
+	}
+	return *p.ErrType
 }
-
-func (p *NodePersistRateLimitResult_) GetLimitEnabled() bool {
-	return p.LimitEnabled
+func (p *WriteBatchRawError) IsSetErr() bool {
+	return p.Err != nil
 }
 
-func (p *NodePersistRateLimitResult_) GetLimitMbps() float64 {
-	return p.LimitMbps
+func (p *WriteBatchRawError) IsSetErrType() bool {
+	return p.ErrType != nil
 }
 
-func (p *NodePersistRateLimitResult_) GetLimitCheckEvery() int64 {
-	return p.LimitCheckEvery
-}
-func (p *NodePersistRateLimitResult_) Read(iprot thrift.TProtocol) error {
+func (p *WriteBatchRawError) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetLimitEnabled bool = false
-	var issetLimitMbps bool = false
-	var issetLimitCheckEvery bool = false
+	var issetIndex bool = false
+	var issetErr bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -7931,17 +9187,16 @@ func (p *NodePersistRateLimitResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetLimitEnabled = true
+			issetIndex = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetLimitMbps = true
+			issetErr = true
 		case 3:
 			if err := p.ReadField3(iprot); err != nil {
 				return err
 			}
-			issetLimitCheckEvery = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -7954,47 +9209,46 @@ func (p *NodePersistRateLimitResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetLimitEnabled {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field LimitEnabled is not set"))
-	}
-	if !issetLimitMbps {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field LimitMbps is not set"))
+	if !issetIndex {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Index is not set"))
 	}
-	if !issetLimitCheckEvery {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field LimitCheckEvery is not set"))
+	if !issetErr {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Err is not set"))
 	}
 	return nil
 }
 
-func (p *NodePersistRateLimitResult_) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
+func (p *WriteBatchRawError) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.LimitEnabled = v
+		p.Index = v
 	}
 	return nil
 }
 
-func (p *NodePersistRateLimitResult_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadDouble(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.LimitMbps = v
+func (p *WriteBatchRawError) ReadField2(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
 	}
 	return nil
 }
 
-func (p *NodePersistRateLimitResult_) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *WriteBatchRawError) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
 		return thrift.PrependError("error reading field 3: ", err)
 	} else {
-		p.LimitCheckEvery = v
+		temp := WriteBatchRawErrorType(v)
+		p.ErrType = &temp
 	}
 	return nil
 }
 
-func (p *NodePersistRateLimitResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodePersistRateLimitResult"); err != nil {
+func (p *WriteBatchRawError) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawError"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -8017,107 +9271,76 @@ func (p *NodePersistRateLimitResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *NodePersistRateLimitResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("limitEnabled", thrift.BOOL, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:limitEnabled: ", p), err)
+func (p *WriteBatchRawError) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("index", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:index: ", p), err)
 	}
-	if err := oprot.WriteBool(bool(p.LimitEnabled)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.limitEnabled (1) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.Index)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.index (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:limitEnabled: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:index: ", p), err)
 	}
 	return err
 }
 
-func (p *NodePersistRateLimitResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("limitMbps", thrift.DOUBLE, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:limitMbps: ", p), err)
+func (p *WriteBatchRawError) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:err: ", p), err)
 	}
-	if err := oprot.WriteDouble(float64(p.LimitMbps)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.limitMbps (2) field write error: ", p), err)
+	if err := p.Err.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:limitMbps: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:err: ", p), err)
 	}
 	return err
 }
 
-func (p *NodePersistRateLimitResult_) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("limitCheckEvery", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:limitCheckEvery: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.LimitCheckEvery)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.limitCheckEvery (3) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:limitCheckEvery: ", p), err)
+func (p *WriteBatchRawError) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErrType() {
+		if err := oprot.WriteFieldBegin("errType", thrift.I32, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:errType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(*p.ErrType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.errType (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:errType: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *NodePersistRateLimitResult_) String() string {
+func (p *WriteBatchRawError) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodePersistRateLimitResult_(%+v)", *p)
+	return fmt.Sprintf("WriteBatchRawError(%+v)", *p)
 }
 
 // Attributes:
-//  - LimitEnabled
-//  - LimitMbps
-//  - LimitCheckEvery
-type NodeSetPersistRateLimitRequest struct {
-	LimitEnabled    *bool    `thrift:"limitEnabled,1" db:"limitEnabled" json:"limitEnabled,omitempty"`
-	LimitMbps       *float64 `thrift:"limitMbps,2" db:"limitMbps" json:"limitMbps,omitempty"`
-	LimitCheckEvery *int64   `thrift:"limitCheckEvery,3" db:"limitCheckEvery" json:"limitCheckEvery,omitempty"`
+//  - Dispositions
+type WriteBatchRawResult_ struct {
+	Dispositions []WriteDisposition `thrift:"dispositions,1" db:"dispositions" json:"dispositions,omitempty"`
 }
 
-func NewNodeSetPersistRateLimitRequest() *NodeSetPersistRateLimitRequest {
-	return &NodeSetPersistRateLimitRequest{}
+func NewWriteBatchRawResult_() *WriteBatchRawResult_ {
+	return &WriteBatchRawResult_{}
 }
 
-var NodeSetPersistRateLimitRequest_LimitEnabled_DEFAULT bool
+var WriteBatchRawResult__Dispositions_DEFAULT []WriteDisposition
 
-func (p *NodeSetPersistRateLimitRequest) GetLimitEnabled() bool {
-	if !p.IsSetLimitEnabled() {
-		return NodeSetPersistRateLimitRequest_LimitEnabled_DEFAULT
-	}
-	return *p.LimitEnabled
+func (p *WriteBatchRawResult_) GetDispositions() []WriteDisposition {
+	return p.Dispositions
+}
+func (p *WriteBatchRawResult_) IsSetDispositions() bool {
+	return p.Dispositions != nil
 }
 
-var NodeSetPersistRateLimitRequest_LimitMbps_DEFAULT float64
-
-func (p *NodeSetPersistRateLimitRequest) GetLimitMbps() float64 {
-	if !p.IsSetLimitMbps() {
-		return NodeSetPersistRateLimitRequest_LimitMbps_DEFAULT
-	}
-	return *p.LimitMbps
-}
-
-var NodeSetPersistRateLimitRequest_LimitCheckEvery_DEFAULT int64
-
-func (p *NodeSetPersistRateLimitRequest) GetLimitCheckEvery() int64 {
-	if !p.IsSetLimitCheckEvery() {
-		return NodeSetPersistRateLimitRequest_LimitCheckEvery_DEFAULT
-	}
-	return *p.LimitCheckEvery
-}
-func (p *NodeSetPersistRateLimitRequest) IsSetLimitEnabled() bool {
-	return p.LimitEnabled != nil
-}
-
-func (p *NodeSetPersistRateLimitRequest) IsSetLimitMbps() bool {
-	return p.LimitMbps != nil
-}
-
-func (p *NodeSetPersistRateLimitRequest) IsSetLimitCheckEvery() bool {
-	return p.LimitCheckEvery != nil
-}
-
-func (p *NodeSetPersistRateLimitRequest) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+func (p *WriteBatchRawResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
 	for {
@@ -8133,14 +9356,6 @@ func (p *NodeSetPersistRateLimitRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -8156,47 +9371,37 @@ func (p *NodeSetPersistRateLimitRequest) Read(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *NodeSetPersistRateLimitRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.LimitEnabled = &v
+func (p *WriteBatchRawResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	return nil
-}
-
-func (p *NodeSetPersistRateLimitRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadDouble(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.LimitMbps = &v
+	tSlice := make([]WriteDisposition, 0, size)
+	p.Dispositions = tSlice
+	for i := 0; i < size; i++ {
+		var _elem234 WriteDisposition
+		if v, err := iprot.ReadI32(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			temp := WriteDisposition(v)
+			_elem234 = temp
+		}
+		p.Dispositions = append(p.Dispositions, _elem234)
 	}
-	return nil
-}
-
-func (p *NodeSetPersistRateLimitRequest) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.LimitCheckEvery = &v
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *NodeSetPersistRateLimitRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeSetPersistRateLimitRequest"); err != nil {
+func (p *WriteBatchRawResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("WriteBatchRawResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -8207,77 +9412,55 @@ func (p *NodeSetPersistRateLimitRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *NodeSetPersistRateLimitRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLimitEnabled() {
-		if err := oprot.WriteFieldBegin("limitEnabled", thrift.BOOL, 1); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:limitEnabled: ", p), err)
-		}
-		if err := oprot.WriteBool(bool(*p.LimitEnabled)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.limitEnabled (1) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:limitEnabled: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *NodeSetPersistRateLimitRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLimitMbps() {
-		if err := oprot.WriteFieldBegin("limitMbps", thrift.DOUBLE, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:limitMbps: ", p), err)
-		}
-		if err := oprot.WriteDouble(float64(*p.LimitMbps)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.limitMbps (2) field write error: ", p), err)
+func (p *WriteBatchRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDispositions() {
+		if err := oprot.WriteFieldBegin("dispositions", thrift.LIST, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:dispositions: ", p), err)
 		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:limitMbps: ", p), err)
+		if err := oprot.WriteListBegin(thrift.I32, len(p.Dispositions)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
 		}
-	}
-	return err
-}
-
-func (p *NodeSetPersistRateLimitRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLimitCheckEvery() {
-		if err := oprot.WriteFieldBegin("limitCheckEvery", thrift.I64, 3); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:limitCheckEvery: ", p), err)
+		for _, v := range p.Dispositions {
+			if err := oprot.WriteI32(int32(v)); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+			}
 		}
-		if err := oprot.WriteI64(int64(*p.LimitCheckEvery)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.limitCheckEvery (3) field write error: ", p), err)
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:limitCheckEvery: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:dispositions: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *NodeSetPersistRateLimitRequest) String() string {
+func (p *WriteBatchRawResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeSetPersistRateLimitRequest(%+v)", *p)
+	return fmt.Sprintf("WriteBatchRawResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - WriteNewSeriesAsync
-type NodeWriteNewSeriesAsyncResult_ struct {
-	WriteNewSeriesAsync bool `thrift:"writeNewSeriesAsync,1,required" db:"writeNewSeriesAsync" json:"writeNewSeriesAsync"`
+//  - NameSpace
+type TruncateRequest struct {
+	NameSpace []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
 }
 
-func NewNodeWriteNewSeriesAsyncResult_() *NodeWriteNewSeriesAsyncResult_ {
-	return &NodeWriteNewSeriesAsyncResult_{}
+func NewTruncateRequest() *TruncateRequest {
+	return &TruncateRequest{}
 }
 
-func (p *NodeWriteNewSeriesAsyncResult_) GetWriteNewSeriesAsync() bool {
-	return p.WriteNewSeriesAsync
+func (p *TruncateRequest) GetNameSpace() []byte {
+	return p.NameSpace
 }
-func (p *NodeWriteNewSeriesAsyncResult_) Read(iprot thrift.TProtocol) error {
+func (p *TruncateRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetWriteNewSeriesAsync bool = false
+	var issetNameSpace bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -8292,7 +9475,7 @@ func (p *NodeWriteNewSeriesAsyncResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetWriteNewSeriesAsync = true
+			issetNameSpace = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -8305,23 +9488,23 @@ func (p *NodeWriteNewSeriesAsyncResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetWriteNewSeriesAsync {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesAsync is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
 	return nil
 }
 
-func (p *NodeWriteNewSeriesAsyncResult_) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
+func (p *TruncateRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.WriteNewSeriesAsync = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *NodeWriteNewSeriesAsyncResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeWriteNewSeriesAsyncResult"); err != nil {
+func (p *TruncateRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("TruncateRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -8338,45 +9521,45 @@ func (p *NodeWriteNewSeriesAsyncResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *NodeWriteNewSeriesAsyncResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("writeNewSeriesAsync", thrift.BOOL, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesAsync: ", p), err)
+func (p *TruncateRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteBool(bool(p.WriteNewSeriesAsync)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesAsync (1) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesAsync: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeWriteNewSeriesAsyncResult_) String() string {
+func (p *TruncateRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeWriteNewSeriesAsyncResult_(%+v)", *p)
+	return fmt.Sprintf("TruncateRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - WriteNewSeriesAsync
-type NodeSetWriteNewSeriesAsyncRequest struct {
-	WriteNewSeriesAsync bool `thrift:"writeNewSeriesAsync,1,required" db:"writeNewSeriesAsync" json:"writeNewSeriesAsync"`
+//  - NumSeries
+type TruncateResult_ struct {
+	NumSeries int64 `thrift:"numSeries,1,required" db:"numSeries" json:"numSeries"`
 }
 
-func NewNodeSetWriteNewSeriesAsyncRequest() *NodeSetWriteNewSeriesAsyncRequest {
-	return &NodeSetWriteNewSeriesAsyncRequest{}
+func NewTruncateResult_() *TruncateResult_ {
+	return &TruncateResult_{}
 }
 
-func (p *NodeSetWriteNewSeriesAsyncRequest) GetWriteNewSeriesAsync() bool {
-	return p.WriteNewSeriesAsync
+func (p *TruncateResult_) GetNumSeries() int64 {
+	return p.NumSeries
 }
-func (p *NodeSetWriteNewSeriesAsyncRequest) Read(iprot thrift.TProtocol) error {
+func (p *TruncateResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetWriteNewSeriesAsync bool = false
+	var issetNumSeries bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -8391,7 +9574,7 @@ func (p *NodeSetWriteNewSeriesAsyncRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetWriteNewSeriesAsync = true
+			issetNumSeries = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -8404,23 +9587,23 @@ func (p *NodeSetWriteNewSeriesAsyncRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetWriteNewSeriesAsync {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesAsync is not set"))
+	if !issetNumSeries {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NumSeries is not set"))
 	}
 	return nil
 }
 
-func (p *NodeSetWriteNewSeriesAsyncRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
+func (p *TruncateResult_) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.WriteNewSeriesAsync = v
+		p.NumSeries = v
 	}
 	return nil
 }
 
-func (p *NodeSetWriteNewSeriesAsyncRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeSetWriteNewSeriesAsyncRequest"); err != nil {
+func (p *TruncateResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("TruncateResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -8437,52 +9620,45 @@ func (p *NodeSetWriteNewSeriesAsyncRequest) Write(oprot thrift.TProtocol) error
 	return nil
 }
 
-func (p *NodeSetWriteNewSeriesAsyncRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("writeNewSeriesAsync", thrift.BOOL, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesAsync: ", p), err)
+func (p *TruncateResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("numSeries", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:numSeries: ", p), err)
 	}
-	if err := oprot.WriteBool(bool(p.WriteNewSeriesAsync)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesAsync (1) field write error: ", p), err)
+	if err := oprot.WriteI64(int64(p.NumSeries)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.numSeries (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesAsync: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:numSeries: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeSetWriteNewSeriesAsyncRequest) String() string {
+func (p *TruncateResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeSetWriteNewSeriesAsyncRequest(%+v)", *p)
+	return fmt.Sprintf("TruncateResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - WriteNewSeriesBackoffDuration
-//  - DurationType
-type NodeWriteNewSeriesBackoffDurationResult_ struct {
-	WriteNewSeriesBackoffDuration int64    `thrift:"writeNewSeriesBackoffDuration,1,required" db:"writeNewSeriesBackoffDuration" json:"writeNewSeriesBackoffDuration"`
-	DurationType                  TimeType `thrift:"durationType,2,required" db:"durationType" json:"durationType"`
-}
-
-func NewNodeWriteNewSeriesBackoffDurationResult_() *NodeWriteNewSeriesBackoffDurationResult_ {
-	return &NodeWriteNewSeriesBackoffDurationResult_{}
+//  - NameSpace
+type ShardWatermarksRequest struct {
+	NameSpace []byte `thrift:"nameSpace,1,required" db:"nameSpace" json:"nameSpace"`
 }
 
-func (p *NodeWriteNewSeriesBackoffDurationResult_) GetWriteNewSeriesBackoffDuration() int64 {
-	return p.WriteNewSeriesBackoffDuration
+func NewShardWatermarksRequest() *ShardWatermarksRequest {
+	return &ShardWatermarksRequest{}
 }
 
-func (p *NodeWriteNewSeriesBackoffDurationResult_) GetDurationType() TimeType {
-	return p.DurationType
+func (p *ShardWatermarksRequest) GetNameSpace() []byte {
+	return p.NameSpace
 }
-func (p *NodeWriteNewSeriesBackoffDurationResult_) Read(iprot thrift.TProtocol) error {
+func (p *ShardWatermarksRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetWriteNewSeriesBackoffDuration bool = false
-	var issetDurationType bool = false
+	var issetNameSpace bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -8497,12 +9673,7 @@ func (p *NodeWriteNewSeriesBackoffDurationResult_) Read(iprot thrift.TProtocol)
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetWriteNewSeriesBackoffDuration = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-			issetDurationType = true
+			issetNameSpace = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -8515,45 +9686,29 @@ func (p *NodeWriteNewSeriesBackoffDurationResult_) Read(iprot thrift.TProtocol)
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetWriteNewSeriesBackoffDuration {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesBackoffDuration is not set"))
-	}
-	if !issetDurationType {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field DurationType is not set"))
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
 	return nil
 }
 
-func (p *NodeWriteNewSeriesBackoffDurationResult_) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *ShardWatermarksRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.WriteNewSeriesBackoffDuration = v
+		p.NameSpace = v
 	}
 	return nil
 }
 
-func (p *NodeWriteNewSeriesBackoffDurationResult_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		temp := TimeType(v)
-		p.DurationType = temp
-	}
-	return nil
-}
-
-func (p *NodeWriteNewSeriesBackoffDurationResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeWriteNewSeriesBackoffDurationResult"); err != nil {
+func (p *ShardWatermarksRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("ShardWatermarksRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -8564,72 +9719,45 @@ func (p *NodeWriteNewSeriesBackoffDurationResult_) Write(oprot thrift.TProtocol)
 	return nil
 }
 
-func (p *NodeWriteNewSeriesBackoffDurationResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("writeNewSeriesBackoffDuration", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesBackoffDuration: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.WriteNewSeriesBackoffDuration)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesBackoffDuration (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesBackoffDuration: ", p), err)
-	}
-	return err
-}
-
-func (p *NodeWriteNewSeriesBackoffDurationResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("durationType", thrift.I32, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:durationType: ", p), err)
+func (p *ShardWatermarksRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:nameSpace: ", p), err)
 	}
-	if err := oprot.WriteI32(int32(p.DurationType)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.durationType (2) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:durationType: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:nameSpace: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeWriteNewSeriesBackoffDurationResult_) String() string {
+func (p *ShardWatermarksRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeWriteNewSeriesBackoffDurationResult_(%+v)", *p)
+	return fmt.Sprintf("ShardWatermarksRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - WriteNewSeriesBackoffDuration
-//  - DurationType
-type NodeSetWriteNewSeriesBackoffDurationRequest struct {
-	WriteNewSeriesBackoffDuration int64    `thrift:"writeNewSeriesBackoffDuration,1,required" db:"writeNewSeriesBackoffDuration" json:"writeNewSeriesBackoffDuration"`
-	DurationType                  TimeType `thrift:"durationType,2" db:"durationType" json:"durationType,omitempty"`
-}
-
-func NewNodeSetWriteNewSeriesBackoffDurationRequest() *NodeSetWriteNewSeriesBackoffDurationRequest {
-	return &NodeSetWriteNewSeriesBackoffDurationRequest{
-		DurationType: 2,
-	}
+//  - Watermarks
+type ShardWatermarksResult_ struct {
+	Watermarks []*ShardWatermark `thrift:"watermarks,1,required" db:"watermarks" json:"watermarks"`
 }
 
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) GetWriteNewSeriesBackoffDuration() int64 {
-	return p.WriteNewSeriesBackoffDuration
+func NewShardWatermarksResult_() *ShardWatermarksResult_ {
+	return &ShardWatermarksResult_{}
 }
 
-var NodeSetWriteNewSeriesBackoffDurationRequest_DurationType_DEFAULT TimeType = 2
-
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) GetDurationType() TimeType {
-	return p.DurationType
-}
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) IsSetDurationType() bool {
-	return p.DurationType != NodeSetWriteNewSeriesBackoffDurationRequest_DurationType_DEFAULT
+func (p *ShardWatermarksResult_) GetWatermarks() []*ShardWatermark {
+	return p.Watermarks
 }
-
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Read(iprot thrift.TProtocol) error {
+func (p *ShardWatermarksResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetWriteNewSeriesBackoffDuration bool = false
+	var issetWatermarks bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -8644,11 +9772,7 @@ func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Read(iprot thrift.TProtoco
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetWriteNewSeriesBackoffDuration = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
+			issetWatermarks = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -8661,42 +9785,40 @@ func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Read(iprot thrift.TProtoco
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetWriteNewSeriesBackoffDuration {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesBackoffDuration is not set"))
+	if !issetWatermarks {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Watermarks is not set"))
 	}
 	return nil
 }
 
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.WriteNewSeriesBackoffDuration = v
+func (p *ShardWatermarksResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
 	}
-	return nil
-}
-
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		temp := TimeType(v)
-		p.DurationType = temp
+	tSlice := make([]*ShardWatermark, 0, size)
+	p.Watermarks = tSlice
+	for i := 0; i < size; i++ {
+		_elem230 := &ShardWatermark{}
+		if err := _elem230.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem230), err)
+		}
+		p.Watermarks = append(p.Watermarks, _elem230)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
 	}
 	return nil
 }
 
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeSetWriteNewSeriesBackoffDurationRequest"); err != nil {
+func (p *ShardWatermarksResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("ShardWatermarksResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -8707,60 +9829,71 @@ func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Write(oprot thrift.TProtoc
 	return nil
 }
 
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("writeNewSeriesBackoffDuration", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesBackoffDuration: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.WriteNewSeriesBackoffDuration)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesBackoffDuration (1) field write error: ", p), err)
+func (p *ShardWatermarksResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("watermarks", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:watermarks: ", p), err)
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesBackoffDuration: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Watermarks)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
 	}
-	return err
-}
-
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetDurationType() {
-		if err := oprot.WriteFieldBegin("durationType", thrift.I32, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:durationType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.DurationType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.durationType (2) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:durationType: ", p), err)
+	for _, v := range p.Watermarks {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
 		}
 	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:watermarks: ", p), err)
+	}
 	return err
 }
 
-func (p *NodeSetWriteNewSeriesBackoffDurationRequest) String() string {
+func (p *ShardWatermarksResult_) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeSetWriteNewSeriesBackoffDurationRequest(%+v)", *p)
+	return fmt.Sprintf("ShardWatermarksResult_(%+v)", *p)
 }
 
 // Attributes:
-//  - WriteNewSeriesLimitPerShardPerSecond
-type NodeWriteNewSeriesLimitPerShardPerSecondResult_ struct {
-	WriteNewSeriesLimitPerShardPerSecond int64 `thrift:"writeNewSeriesLimitPerShardPerSecond,1,required" db:"writeNewSeriesLimitPerShardPerSecond" json:"writeNewSeriesLimitPerShardPerSecond"`
+//  - Shard
+//  - FlushedTo
+//  - RepairedTo
+//  - DurableTo
+type ShardWatermark struct {
+	Shard      int32 `thrift:"shard,1,required" db:"shard" json:"shard"`
+	FlushedTo  int64 `thrift:"flushedTo,2,required" db:"flushedTo" json:"flushedTo"`
+	RepairedTo int64 `thrift:"repairedTo,3,required" db:"repairedTo" json:"repairedTo"`
+	DurableTo  int64 `thrift:"durableTo,4,required" db:"durableTo" json:"durableTo"`
 }
 
-func NewNodeWriteNewSeriesLimitPerShardPerSecondResult_() *NodeWriteNewSeriesLimitPerShardPerSecondResult_ {
-	return &NodeWriteNewSeriesLimitPerShardPerSecondResult_{}
+func NewShardWatermark() *ShardWatermark {
+	return &ShardWatermark{}
 }
 
-func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) GetWriteNewSeriesLimitPerShardPerSecond() int64 {
-	return p.WriteNewSeriesLimitPerShardPerSecond
+func (p *ShardWatermark) GetShard() int32 {
+	return p.Shard
 }
-func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Read(iprot thrift.TProtocol) error {
+func (p *ShardWatermark) GetFlushedTo() int64 {
+	return p.FlushedTo
+}
+func (p *ShardWatermark) GetRepairedTo() int64 {
+	return p.RepairedTo
+}
+func (p *ShardWatermark) GetDurableTo() int64 {
+	return p.DurableTo
+}
+func (p *ShardWatermark) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetWriteNewSeriesLimitPerShardPerSecond bool = false
+	var issetShard bool = false
+	var issetFlushedTo bool = false
+	var issetRepairedTo bool = false
+	var issetDurableTo bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -8775,7 +9908,22 @@ func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Read(iprot thrift.TPro
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetWriteNewSeriesLimitPerShardPerSecond = true
+			issetShard = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetFlushedTo = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetRepairedTo = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetDurableTo = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -8788,29 +9936,74 @@ func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Read(iprot thrift.TPro
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetWriteNewSeriesLimitPerShardPerSecond {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesLimitPerShardPerSecond is not set"))
+	if !issetShard {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Shard is not set"))
+	}
+	if !issetFlushedTo {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field FlushedTo is not set"))
+	}
+	if !issetRepairedTo {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RepairedTo is not set"))
+	}
+	if !issetDurableTo {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field DurableTo is not set"))
 	}
 	return nil
 }
 
-func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
+func (p *ShardWatermark) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.WriteNewSeriesLimitPerShardPerSecond = v
+		p.Shard = v
 	}
 	return nil
 }
 
-func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeWriteNewSeriesLimitPerShardPerSecondResult"); err != nil {
+func (p *ShardWatermark) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.FlushedTo = v
+	}
+	return nil
+}
+
+func (p *ShardWatermark) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RepairedTo = v
+	}
+	return nil
+}
+
+func (p *ShardWatermark) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.DurableTo = v
+	}
+	return nil
+}
+
+func (p *ShardWatermark) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("ShardWatermark"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -8821,151 +10014,98 @@ func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Write(oprot thrift.TPr
 	return nil
 }
 
-func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("writeNewSeriesLimitPerShardPerSecond", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
+func (p *ShardWatermark) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("shard", thrift.I32, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:shard: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.WriteNewSeriesLimitPerShardPerSecond)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesLimitPerShardPerSecond (1) field write error: ", p), err)
+	if err := oprot.WriteI32(int32(p.Shard)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.shard (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:shard: ", p), err)
 	}
 	return err
 }
 
-func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) String() string {
+func (p *ShardWatermark) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("flushedTo", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:flushedTo: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.FlushedTo)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.flushedTo (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:flushedTo: ", p), err)
+	}
+	return err
+}
+
+func (p *ShardWatermark) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("repairedTo", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:repairedTo: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RepairedTo)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.repairedTo (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:repairedTo: ", p), err)
+	}
+	return err
+}
+
+func (p *ShardWatermark) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("durableTo", thrift.I64, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:durableTo: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.DurableTo)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.durableTo (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:durableTo: ", p), err)
+	}
+	return err
+}
+
+func (p *ShardWatermark) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("NodeWriteNewSeriesLimitPerShardPerSecondResult_(%+v)", *p)
+	return fmt.Sprintf("ShardWatermark(%+v)", *p)
 }
 
 // Attributes:
-//  - WriteNewSeriesLimitPerShardPerSecond
-type NodeSetWriteNewSeriesLimitPerShardPerSecondRequest struct {
-	WriteNewSeriesLimitPerShardPerSecond int64 `thrift:"writeNewSeriesLimitPerShardPerSecond,1,required" db:"writeNewSeriesLimitPerShardPerSecond" json:"writeNewSeriesLimitPerShardPerSecond"`
+//  - Ok
+//  - Status
+//  - Bootstrapped
+type NodeHealthResult_ struct {
+	Ok           bool   `thrift:"ok,1,required" db:"ok" json:"ok"`
+	Status       string `thrift:"status,2,required" db:"status" json:"status"`
+	Bootstrapped bool   `thrift:"bootstrapped,3,required" db:"bootstrapped" json:"bootstrapped"`
 }
 
-func NewNodeSetWriteNewSeriesLimitPerShardPerSecondRequest() *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest {
-	return &NodeSetWriteNewSeriesLimitPerShardPerSecondRequest{}
+func NewNodeHealthResult_() *NodeHealthResult_ {
+	return &NodeHealthResult_{}
 }
 
-func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) GetWriteNewSeriesLimitPerShardPerSecond() int64 {
-	return p.WriteNewSeriesLimitPerShardPerSecond
+func (p *NodeHealthResult_) GetOk() bool {
+	return p.Ok
 }
-func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
-	}
-
-	var issetWriteNewSeriesLimitPerShardPerSecond bool = false
-
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
-		}
-		if fieldTypeId == thrift.STOP {
-			break
-		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetWriteNewSeriesLimitPerShardPerSecond = true
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
-			}
-		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
-		}
-	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
-	}
-	if !issetWriteNewSeriesLimitPerShardPerSecond {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesLimitPerShardPerSecond is not set"))
-	}
-	return nil
-}
-
-func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.WriteNewSeriesLimitPerShardPerSecond = v
-	}
-	return nil
-}
-
-func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("NodeSetWriteNewSeriesLimitPerShardPerSecondRequest"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
-		}
-	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
-	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
-	}
-	return nil
-}
-
-func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("writeNewSeriesLimitPerShardPerSecond", thrift.I64, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.WriteNewSeriesLimitPerShardPerSecond)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesLimitPerShardPerSecond (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
-	}
-	return err
-}
-
-func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) String() string {
-	if p == nil {
-		return "<nil>"
-	}
-	return fmt.Sprintf("NodeSetWriteNewSeriesLimitPerShardPerSecondRequest(%+v)", *p)
-}
-
-// Attributes:
-//  - Ok
-//  - Status
-type HealthResult_ struct {
-	Ok     bool   `thrift:"ok,1,required" db:"ok" json:"ok"`
-	Status string `thrift:"status,2,required" db:"status" json:"status"`
-}
-
-func NewHealthResult_() *HealthResult_ {
-	return &HealthResult_{}
-}
-
-func (p *HealthResult_) GetOk() bool {
-	return p.Ok
-}
-
-func (p *HealthResult_) GetStatus() string {
-	return p.Status
-}
-func (p *HealthResult_) Read(iprot thrift.TProtocol) error {
+
+func (p *NodeHealthResult_) GetStatus() string {
+	return p.Status
+}
+
+func (p *NodeHealthResult_) GetBootstrapped() bool {
+	return p.Bootstrapped
+}
+func (p *NodeHealthResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
 	var issetOk bool = false
 	var issetStatus bool = false
+	var issetBootstrapped bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -8986,6 +10126,11 @@ func (p *HealthResult_) Read(iprot thrift.TProtocol) error {
 				return err
 			}
 			issetStatus = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetBootstrapped = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -9004,10 +10149,13 @@ func (p *HealthResult_) Read(iprot thrift.TProtocol) error {
 	if !issetStatus {
 		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Status is not set"))
 	}
+	if !issetBootstrapped {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Bootstrapped is not set"))
+	}
 	return nil
 }
 
-func (p *HealthResult_) ReadField1(iprot thrift.TProtocol) error {
+func (p *NodeHealthResult_) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadBool(); err != nil {
 		return thrift.PrependError("error reading field 1: ", err)
 	} else {
@@ -9016,7 +10164,7 @@ func (p *HealthResult_) ReadField1(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *HealthResult_) ReadField2(iprot thrift.TProtocol) error {
+func (p *NodeHealthResult_) ReadField2(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadString(); err != nil {
 		return thrift.PrependError("error reading field 2: ", err)
 	} else {
@@ -9025,8 +10173,17 @@ func (p *HealthResult_) ReadField2(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *HealthResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("HealthResult"); err != nil {
+func (p *NodeHealthResult_) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.Bootstrapped = v
+	}
+	return nil
+}
+
+func (p *NodeHealthResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeHealthResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -9036,6 +10193,9 @@ func (p *HealthResult_) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -9046,7 +10206,7 @@ func (p *HealthResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *HealthResult_) writeField1(oprot thrift.TProtocol) (err error) {
+func (p *NodeHealthResult_) writeField1(oprot thrift.TProtocol) (err error) {
 	if err := oprot.WriteFieldBegin("ok", thrift.BOOL, 1); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:ok: ", p), err)
 	}
@@ -9059,7 +10219,7 @@ func (p *HealthResult_) writeField1(oprot thrift.TProtocol) (err error) {
 	return err
 }
 
-func (p *HealthResult_) writeField2(oprot thrift.TProtocol) (err error) {
+func (p *NodeHealthResult_) writeField2(oprot thrift.TProtocol) (err error) {
 	if err := oprot.WriteFieldBegin("status", thrift.STRING, 2); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:status: ", p), err)
 	}
@@ -9072,109 +10232,38 @@ func (p *HealthResult_) writeField2(oprot thrift.TProtocol) (err error) {
 	return err
 }
 
-func (p *HealthResult_) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *NodeHealthResult_) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("bootstrapped", thrift.BOOL, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:bootstrapped: ", p), err)
 	}
-	return fmt.Sprintf("HealthResult_(%+v)", *p)
-}
-
-// Attributes:
-//  - Query
-//  - RangeStart
-//  - RangeEnd
-//  - NameSpace
-//  - Limit
-//  - TagNameFilter
-//  - AggregateQueryType
-//  - RangeType
-type AggregateQueryRawRequest struct {
-	Query              []byte             `thrift:"query,1,required" db:"query" json:"query"`
-	RangeStart         int64              `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd           int64              `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
-	NameSpace          []byte             `thrift:"nameSpace,4,required" db:"nameSpace" json:"nameSpace"`
-	Limit              *int64             `thrift:"limit,5" db:"limit" json:"limit,omitempty"`
-	TagNameFilter      [][]byte           `thrift:"tagNameFilter,6" db:"tagNameFilter" json:"tagNameFilter,omitempty"`
-	AggregateQueryType AggregateQueryType `thrift:"aggregateQueryType,7" db:"aggregateQueryType" json:"aggregateQueryType,omitempty"`
-	RangeType          TimeType           `thrift:"rangeType,8" db:"rangeType" json:"rangeType,omitempty"`
-}
-
-func NewAggregateQueryRawRequest() *AggregateQueryRawRequest {
-	return &AggregateQueryRawRequest{
-		AggregateQueryType: 1,
-
-		RangeType: 0,
+	if err := oprot.WriteBool(bool(p.Bootstrapped)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.bootstrapped (3) field write error: ", p), err)
 	}
-}
-
-func (p *AggregateQueryRawRequest) GetQuery() []byte {
-	return p.Query
-}
-
-func (p *AggregateQueryRawRequest) GetRangeStart() int64 {
-	return p.RangeStart
-}
-
-func (p *AggregateQueryRawRequest) GetRangeEnd() int64 {
-	return p.RangeEnd
-}
-
-func (p *AggregateQueryRawRequest) GetNameSpace() []byte {
-	return p.NameSpace
-}
-
-var AggregateQueryRawRequest_Limit_DEFAULT int64
-
-func (p *AggregateQueryRawRequest) GetLimit() int64 {
-	if !p.IsSetLimit() {
-		return AggregateQueryRawRequest_Limit_DEFAULT
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:bootstrapped: ", p), err)
 	}
-	return *p.Limit
-}
-
-var AggregateQueryRawRequest_TagNameFilter_DEFAULT [][]byte
-
-func (p *AggregateQueryRawRequest) GetTagNameFilter() [][]byte {
-	return p.TagNameFilter
-}
-
-var AggregateQueryRawRequest_AggregateQueryType_DEFAULT AggregateQueryType = 1
-
-func (p *AggregateQueryRawRequest) GetAggregateQueryType() AggregateQueryType {
-	return p.AggregateQueryType
-}
-
-var AggregateQueryRawRequest_RangeType_DEFAULT TimeType = 0
-
-func (p *AggregateQueryRawRequest) GetRangeType() TimeType {
-	return p.RangeType
-}
-func (p *AggregateQueryRawRequest) IsSetLimit() bool {
-	return p.Limit != nil
+	return err
 }
 
-func (p *AggregateQueryRawRequest) IsSetTagNameFilter() bool {
-	return p.TagNameFilter != nil
+func (p *NodeHealthResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeHealthResult_(%+v)", *p)
 }
 
-func (p *AggregateQueryRawRequest) IsSetAggregateQueryType() bool {
-	return p.AggregateQueryType != AggregateQueryRawRequest_AggregateQueryType_DEFAULT
+type NodeBootstrappedResult_ struct {
 }
 
-func (p *AggregateQueryRawRequest) IsSetRangeType() bool {
-	return p.RangeType != AggregateQueryRawRequest_RangeType_DEFAULT
+func NewNodeBootstrappedResult_() *NodeBootstrappedResult_ {
+	return &NodeBootstrappedResult_{}
 }
 
-func (p *AggregateQueryRawRequest) Read(iprot thrift.TProtocol) error {
+func (p *NodeBootstrappedResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetQuery bool = false
-	var issetRangeStart bool = false
-	var issetRangeEnd bool = false
-	var issetNameSpace bool = false
-
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
 		if err != nil {
@@ -9183,47 +10272,8 @@ func (p *AggregateQueryRawRequest) Read(iprot thrift.TProtocol) error {
 		if fieldTypeId == thrift.STOP {
 			break
 		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetQuery = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-			issetRangeStart = true
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
-			issetRangeEnd = true
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
-			issetNameSpace = true
-		case 5:
-			if err := p.ReadField5(iprot); err != nil {
-				return err
-			}
-		case 6:
-			if err := p.ReadField6(iprot); err != nil {
-				return err
-			}
-		case 7:
-			if err := p.ReadField7(iprot); err != nil {
-				return err
-			}
-		case 8:
-			if err := p.ReadField8(iprot); err != nil {
-				return err
-			}
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
-			}
+		if err := iprot.Skip(fieldTypeId); err != nil {
+			return err
 		}
 		if err := iprot.ReadFieldEnd(); err != nil {
 			return err
@@ -9232,110 +10282,197 @@ func (p *AggregateQueryRawRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetQuery {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Query is not set"))
+	return nil
+}
+
+func (p *NodeBootstrappedResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeBootstrappedResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
-	if !issetRangeStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	if p != nil {
 	}
-	if !issetRangeEnd {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
 	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.Query = v
+func (p *NodeBootstrappedResult_) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	return nil
+	return fmt.Sprintf("NodeBootstrappedResult_(%+v)", *p)
 }
 
-func (p *AggregateQueryRawRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.RangeStart = v
-	}
-	return nil
+type NodeBootstrappedInPlacementOrNoPlacementResult_ struct {
 }
 
-func (p *AggregateQueryRawRequest) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.RangeEnd = v
+func NewNodeBootstrappedInPlacementOrNoPlacementResult_() *NodeBootstrappedInPlacementOrNoPlacementResult_ {
+	return &NodeBootstrappedInPlacementOrNoPlacementResult_{}
+}
+
+func (p *NodeBootstrappedInPlacementOrNoPlacementResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		if err := iprot.Skip(fieldTypeId); err != nil {
+			return err
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) ReadField4(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.NameSpace = v
+func (p *NodeBootstrappedInPlacementOrNoPlacementResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeBootstrappedInPlacementOrNoPlacementResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) ReadField5(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
-	} else {
-		p.Limit = &v
+func (p *NodeBootstrappedInPlacementOrNoPlacementResult_) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	return nil
+	return fmt.Sprintf("NodeBootstrappedInPlacementOrNoPlacementResult_(%+v)", *p)
 }
 
-func (p *AggregateQueryRawRequest) ReadField6(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
+// Attributes:
+//  - LimitEnabled
+//  - LimitMbps
+//  - LimitCheckEvery
+type NodePersistRateLimitResult_ struct {
+	LimitEnabled    bool    `thrift:"limitEnabled,1,required" db:"limitEnabled" json:"limitEnabled"`
+	LimitMbps       float64 `thrift:"limitMbps,2,required" db:"limitMbps" json:"limitMbps"`
+	LimitCheckEvery int64   `thrift:"limitCheckEvery,3,required" db:"limitCheckEvery" json:"limitCheckEvery"`
+}
+
+func NewNodePersistRateLimitResult_() *NodePersistRateLimitResult_ {
+	return &NodePersistRateLimitResult_{}
+}
+
+func (p *NodePersistRateLimitResult_) GetLimitEnabled() bool {
+	return p.LimitEnabled
+}
+
+func (p *NodePersistRateLimitResult_) GetLimitMbps() float64 {
+	return p.LimitMbps
+}
+
+func (p *NodePersistRateLimitResult_) GetLimitCheckEvery() int64 {
+	return p.LimitCheckEvery
+}
+func (p *NodePersistRateLimitResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
-	tSlice := make([][]byte, 0, size)
-	p.TagNameFilter = tSlice
-	for i := 0; i < size; i++ {
-		var _elem22 []byte
-		if v, err := iprot.ReadBinary(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem22 = v
+
+	var issetLimitEnabled bool = false
+	var issetLimitMbps bool = false
+	var issetLimitCheckEvery bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetLimitEnabled = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetLimitMbps = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetLimitCheckEvery = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
 		}
-		p.TagNameFilter = append(p.TagNameFilter, _elem22)
 	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetLimitEnabled {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field LimitEnabled is not set"))
+	}
+	if !issetLimitMbps {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field LimitMbps is not set"))
+	}
+	if !issetLimitCheckEvery {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field LimitCheckEvery is not set"))
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) ReadField7(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 7: ", err)
+func (p *NodePersistRateLimitResult_) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		temp := AggregateQueryType(v)
-		p.AggregateQueryType = temp
+		p.LimitEnabled = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) ReadField8(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 8: ", err)
+func (p *NodePersistRateLimitResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadDouble(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		temp := TimeType(v)
-		p.RangeType = temp
+		p.LimitMbps = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryRawRequest"); err != nil {
+func (p *NodePersistRateLimitResult_) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.LimitCheckEvery = v
+	}
+	return nil
+}
+
+func (p *NodePersistRateLimitResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodePersistRateLimitResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -9348,19 +10485,1604 @@ func (p *AggregateQueryRawRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField3(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField6(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField7(oprot); err != nil {
-			return err
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodePersistRateLimitResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("limitEnabled", thrift.BOOL, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:limitEnabled: ", p), err)
+	}
+	if err := oprot.WriteBool(bool(p.LimitEnabled)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.limitEnabled (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:limitEnabled: ", p), err)
+	}
+	return err
+}
+
+func (p *NodePersistRateLimitResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("limitMbps", thrift.DOUBLE, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:limitMbps: ", p), err)
+	}
+	if err := oprot.WriteDouble(float64(p.LimitMbps)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.limitMbps (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:limitMbps: ", p), err)
+	}
+	return err
+}
+
+func (p *NodePersistRateLimitResult_) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("limitCheckEvery", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:limitCheckEvery: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.LimitCheckEvery)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.limitCheckEvery (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:limitCheckEvery: ", p), err)
+	}
+	return err
+}
+
+func (p *NodePersistRateLimitResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodePersistRateLimitResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - LimitEnabled
+//  - LimitMbps
+//  - LimitCheckEvery
+type NodeSetPersistRateLimitRequest struct {
+	LimitEnabled    *bool    `thrift:"limitEnabled,1" db:"limitEnabled" json:"limitEnabled,omitempty"`
+	LimitMbps       *float64 `thrift:"limitMbps,2" db:"limitMbps" json:"limitMbps,omitempty"`
+	LimitCheckEvery *int64   `thrift:"limitCheckEvery,3" db:"limitCheckEvery" json:"limitCheckEvery,omitempty"`
+}
+
+func NewNodeSetPersistRateLimitRequest() *NodeSetPersistRateLimitRequest {
+	return &NodeSetPersistRateLimitRequest{}
+}
+
+var NodeSetPersistRateLimitRequest_LimitEnabled_DEFAULT bool
+
+func (p *NodeSetPersistRateLimitRequest) GetLimitEnabled() bool {
+	if !p.IsSetLimitEnabled() {
+		return NodeSetPersistRateLimitRequest_LimitEnabled_DEFAULT
+	}
+	return *p.LimitEnabled
+}
+
+var NodeSetPersistRateLimitRequest_LimitMbps_DEFAULT float64
+
+func (p *NodeSetPersistRateLimitRequest) GetLimitMbps() float64 {
+	if !p.IsSetLimitMbps() {
+		return NodeSetPersistRateLimitRequest_LimitMbps_DEFAULT
+	}
+	return *p.LimitMbps
+}
+
+var NodeSetPersistRateLimitRequest_LimitCheckEvery_DEFAULT int64
+
+func (p *NodeSetPersistRateLimitRequest) GetLimitCheckEvery() int64 {
+	if !p.IsSetLimitCheckEvery() {
+		return NodeSetPersistRateLimitRequest_LimitCheckEvery_DEFAULT
+	}
+	return *p.LimitCheckEvery
+}
+func (p *NodeSetPersistRateLimitRequest) IsSetLimitEnabled() bool {
+	return p.LimitEnabled != nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) IsSetLimitMbps() bool {
+	return p.LimitMbps != nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) IsSetLimitCheckEvery() bool {
+	return p.LimitCheckEvery != nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.LimitEnabled = &v
+	}
+	return nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadDouble(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.LimitMbps = &v
+	}
+	return nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.LimitCheckEvery = &v
+	}
+	return nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeSetPersistRateLimitRequest"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeSetPersistRateLimitRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimitEnabled() {
+		if err := oprot.WriteFieldBegin("limitEnabled", thrift.BOOL, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:limitEnabled: ", p), err)
+		}
+		if err := oprot.WriteBool(bool(*p.LimitEnabled)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limitEnabled (1) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:limitEnabled: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeSetPersistRateLimitRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimitMbps() {
+		if err := oprot.WriteFieldBegin("limitMbps", thrift.DOUBLE, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:limitMbps: ", p), err)
+		}
+		if err := oprot.WriteDouble(float64(*p.LimitMbps)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limitMbps (2) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:limitMbps: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeSetPersistRateLimitRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimitCheckEvery() {
+		if err := oprot.WriteFieldBegin("limitCheckEvery", thrift.I64, 3); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:limitCheckEvery: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.LimitCheckEvery)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limitCheckEvery (3) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 3:limitCheckEvery: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeSetPersistRateLimitRequest) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeSetPersistRateLimitRequest(%+v)", *p)
+}
+
+// Attributes:
+//  - WriteNewSeriesAsync
+type NodeWriteNewSeriesAsyncResult_ struct {
+	WriteNewSeriesAsync bool `thrift:"writeNewSeriesAsync,1,required" db:"writeNewSeriesAsync" json:"writeNewSeriesAsync"`
+}
+
+func NewNodeWriteNewSeriesAsyncResult_() *NodeWriteNewSeriesAsyncResult_ {
+	return &NodeWriteNewSeriesAsyncResult_{}
+}
+
+func (p *NodeWriteNewSeriesAsyncResult_) GetWriteNewSeriesAsync() bool {
+	return p.WriteNewSeriesAsync
+}
+func (p *NodeWriteNewSeriesAsyncResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetWriteNewSeriesAsync bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetWriteNewSeriesAsync = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetWriteNewSeriesAsync {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesAsync is not set"))
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesAsyncResult_) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.WriteNewSeriesAsync = v
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesAsyncResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeWriteNewSeriesAsyncResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesAsyncResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("writeNewSeriesAsync", thrift.BOOL, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesAsync: ", p), err)
+	}
+	if err := oprot.WriteBool(bool(p.WriteNewSeriesAsync)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesAsync (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesAsync: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeWriteNewSeriesAsyncResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeWriteNewSeriesAsyncResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - WriteNewSeriesAsync
+type NodeSetWriteNewSeriesAsyncRequest struct {
+	WriteNewSeriesAsync bool `thrift:"writeNewSeriesAsync,1,required" db:"writeNewSeriesAsync" json:"writeNewSeriesAsync"`
+}
+
+func NewNodeSetWriteNewSeriesAsyncRequest() *NodeSetWriteNewSeriesAsyncRequest {
+	return &NodeSetWriteNewSeriesAsyncRequest{}
+}
+
+func (p *NodeSetWriteNewSeriesAsyncRequest) GetWriteNewSeriesAsync() bool {
+	return p.WriteNewSeriesAsync
+}
+func (p *NodeSetWriteNewSeriesAsyncRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetWriteNewSeriesAsync bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetWriteNewSeriesAsync = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetWriteNewSeriesAsync {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesAsync is not set"))
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesAsyncRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.WriteNewSeriesAsync = v
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesAsyncRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeSetWriteNewSeriesAsyncRequest"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesAsyncRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("writeNewSeriesAsync", thrift.BOOL, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesAsync: ", p), err)
+	}
+	if err := oprot.WriteBool(bool(p.WriteNewSeriesAsync)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesAsync (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesAsync: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeSetWriteNewSeriesAsyncRequest) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeSetWriteNewSeriesAsyncRequest(%+v)", *p)
+}
+
+// Attributes:
+//  - WriteNewSeriesBackoffDuration
+//  - DurationType
+type NodeWriteNewSeriesBackoffDurationResult_ struct {
+	WriteNewSeriesBackoffDuration int64    `thrift:"writeNewSeriesBackoffDuration,1,required" db:"writeNewSeriesBackoffDuration" json:"writeNewSeriesBackoffDuration"`
+	DurationType                  TimeType `thrift:"durationType,2,required" db:"durationType" json:"durationType"`
+}
+
+func NewNodeWriteNewSeriesBackoffDurationResult_() *NodeWriteNewSeriesBackoffDurationResult_ {
+	return &NodeWriteNewSeriesBackoffDurationResult_{}
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) GetWriteNewSeriesBackoffDuration() int64 {
+	return p.WriteNewSeriesBackoffDuration
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) GetDurationType() TimeType {
+	return p.DurationType
+}
+func (p *NodeWriteNewSeriesBackoffDurationResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetWriteNewSeriesBackoffDuration bool = false
+	var issetDurationType bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetWriteNewSeriesBackoffDuration = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetDurationType = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetWriteNewSeriesBackoffDuration {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesBackoffDuration is not set"))
+	}
+	if !issetDurationType {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field DurationType is not set"))
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.WriteNewSeriesBackoffDuration = v
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		temp := TimeType(v)
+		p.DurationType = temp
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeWriteNewSeriesBackoffDurationResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("writeNewSeriesBackoffDuration", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesBackoffDuration: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.WriteNewSeriesBackoffDuration)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesBackoffDuration (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesBackoffDuration: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("durationType", thrift.I32, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:durationType: ", p), err)
+	}
+	if err := oprot.WriteI32(int32(p.DurationType)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.durationType (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:durationType: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeWriteNewSeriesBackoffDurationResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeWriteNewSeriesBackoffDurationResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - WriteNewSeriesBackoffDuration
+//  - DurationType
+type NodeSetWriteNewSeriesBackoffDurationRequest struct {
+	WriteNewSeriesBackoffDuration int64    `thrift:"writeNewSeriesBackoffDuration,1,required" db:"writeNewSeriesBackoffDuration" json:"writeNewSeriesBackoffDuration"`
+	DurationType                  TimeType `thrift:"durationType,2" db:"durationType" json:"durationType,omitempty"`
+}
+
+func NewNodeSetWriteNewSeriesBackoffDurationRequest() *NodeSetWriteNewSeriesBackoffDurationRequest {
+	return &NodeSetWriteNewSeriesBackoffDurationRequest{
+		DurationType: 2,
+	}
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) GetWriteNewSeriesBackoffDuration() int64 {
+	return p.WriteNewSeriesBackoffDuration
+}
+
+var NodeSetWriteNewSeriesBackoffDurationRequest_DurationType_DEFAULT TimeType = 2
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) GetDurationType() TimeType {
+	return p.DurationType
+}
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) IsSetDurationType() bool {
+	return p.DurationType != NodeSetWriteNewSeriesBackoffDurationRequest_DurationType_DEFAULT
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetWriteNewSeriesBackoffDuration bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetWriteNewSeriesBackoffDuration = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetWriteNewSeriesBackoffDuration {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesBackoffDuration is not set"))
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.WriteNewSeriesBackoffDuration = v
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		temp := TimeType(v)
+		p.DurationType = temp
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeSetWriteNewSeriesBackoffDurationRequest"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("writeNewSeriesBackoffDuration", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesBackoffDuration: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.WriteNewSeriesBackoffDuration)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesBackoffDuration (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesBackoffDuration: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetDurationType() {
+		if err := oprot.WriteFieldBegin("durationType", thrift.I32, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:durationType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.DurationType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.durationType (2) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:durationType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeSetWriteNewSeriesBackoffDurationRequest) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeSetWriteNewSeriesBackoffDurationRequest(%+v)", *p)
+}
+
+// Attributes:
+//  - WriteNewSeriesLimitPerShardPerSecond
+type NodeWriteNewSeriesLimitPerShardPerSecondResult_ struct {
+	WriteNewSeriesLimitPerShardPerSecond int64 `thrift:"writeNewSeriesLimitPerShardPerSecond,1,required" db:"writeNewSeriesLimitPerShardPerSecond" json:"writeNewSeriesLimitPerShardPerSecond"`
+}
+
+func NewNodeWriteNewSeriesLimitPerShardPerSecondResult_() *NodeWriteNewSeriesLimitPerShardPerSecondResult_ {
+	return &NodeWriteNewSeriesLimitPerShardPerSecondResult_{}
+}
+
+func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) GetWriteNewSeriesLimitPerShardPerSecond() int64 {
+	return p.WriteNewSeriesLimitPerShardPerSecond
+}
+func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetWriteNewSeriesLimitPerShardPerSecond bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetWriteNewSeriesLimitPerShardPerSecond = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetWriteNewSeriesLimitPerShardPerSecond {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesLimitPerShardPerSecond is not set"))
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.WriteNewSeriesLimitPerShardPerSecond = v
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeWriteNewSeriesLimitPerShardPerSecondResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("writeNewSeriesLimitPerShardPerSecond", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.WriteNewSeriesLimitPerShardPerSecond)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesLimitPerShardPerSecond (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeWriteNewSeriesLimitPerShardPerSecondResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeWriteNewSeriesLimitPerShardPerSecondResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - WriteNewSeriesLimitPerShardPerSecond
+type NodeSetWriteNewSeriesLimitPerShardPerSecondRequest struct {
+	WriteNewSeriesLimitPerShardPerSecond int64 `thrift:"writeNewSeriesLimitPerShardPerSecond,1,required" db:"writeNewSeriesLimitPerShardPerSecond" json:"writeNewSeriesLimitPerShardPerSecond"`
+}
+
+func NewNodeSetWriteNewSeriesLimitPerShardPerSecondRequest() *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest {
+	return &NodeSetWriteNewSeriesLimitPerShardPerSecondRequest{}
+}
+
+func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) GetWriteNewSeriesLimitPerShardPerSecond() int64 {
+	return p.WriteNewSeriesLimitPerShardPerSecond
+}
+func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetWriteNewSeriesLimitPerShardPerSecond bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetWriteNewSeriesLimitPerShardPerSecond = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetWriteNewSeriesLimitPerShardPerSecond {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field WriteNewSeriesLimitPerShardPerSecond is not set"))
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.WriteNewSeriesLimitPerShardPerSecond = v
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("NodeSetWriteNewSeriesLimitPerShardPerSecondRequest"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("writeNewSeriesLimitPerShardPerSecond", thrift.I64, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.WriteNewSeriesLimitPerShardPerSecond)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.writeNewSeriesLimitPerShardPerSecond (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:writeNewSeriesLimitPerShardPerSecond: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeSetWriteNewSeriesLimitPerShardPerSecondRequest(%+v)", *p)
+}
+
+// Attributes:
+//  - Ok
+//  - Status
+type HealthResult_ struct {
+	Ok     bool   `thrift:"ok,1,required" db:"ok" json:"ok"`
+	Status string `thrift:"status,2,required" db:"status" json:"status"`
+}
+
+func NewHealthResult_() *HealthResult_ {
+	return &HealthResult_{}
+}
+
+func (p *HealthResult_) GetOk() bool {
+	return p.Ok
+}
+
+func (p *HealthResult_) GetStatus() string {
+	return p.Status
+}
+func (p *HealthResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetOk bool = false
+	var issetStatus bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetOk = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetStatus = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetOk {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Ok is not set"))
+	}
+	if !issetStatus {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Status is not set"))
+	}
+	return nil
+}
+
+func (p *HealthResult_) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Ok = v
+	}
+	return nil
+}
+
+func (p *HealthResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Status = v
+	}
+	return nil
+}
+
+func (p *HealthResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("HealthResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *HealthResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("ok", thrift.BOOL, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:ok: ", p), err)
+	}
+	if err := oprot.WriteBool(bool(p.Ok)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.ok (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:ok: ", p), err)
+	}
+	return err
+}
+
+func (p *HealthResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("status", thrift.STRING, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:status: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.Status)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.status (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:status: ", p), err)
+	}
+	return err
+}
+
+func (p *HealthResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("HealthResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - Query
+//  - RangeStart
+//  - RangeEnd
+//  - NameSpace
+//  - Limit
+//  - TagNameFilter
+//  - AggregateQueryType
+//  - RangeType
+type AggregateQueryRawRequest struct {
+	Query              []byte             `thrift:"query,1,required" db:"query" json:"query"`
+	RangeStart         int64              `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd           int64              `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
+	NameSpace          []byte             `thrift:"nameSpace,4,required" db:"nameSpace" json:"nameSpace"`
+	Limit              *int64             `thrift:"limit,5" db:"limit" json:"limit,omitempty"`
+	TagNameFilter      [][]byte           `thrift:"tagNameFilter,6" db:"tagNameFilter" json:"tagNameFilter,omitempty"`
+	AggregateQueryType AggregateQueryType `thrift:"aggregateQueryType,7" db:"aggregateQueryType" json:"aggregateQueryType,omitempty"`
+	RangeType          TimeType           `thrift:"rangeType,8" db:"rangeType" json:"rangeType,omitempty"`
+}
+
+func NewAggregateQueryRawRequest() *AggregateQueryRawRequest {
+	return &AggregateQueryRawRequest{
+		AggregateQueryType: 1,
+
+		RangeType: 0,
+	}
+}
+
+func (p *AggregateQueryRawRequest) GetQuery() []byte {
+	return p.Query
+}
+
+func (p *AggregateQueryRawRequest) GetRangeStart() int64 {
+	return p.RangeStart
+}
+
+func (p *AggregateQueryRawRequest) GetRangeEnd() int64 {
+	return p.RangeEnd
+}
+
+func (p *AggregateQueryRawRequest) GetNameSpace() []byte {
+	return p.NameSpace
+}
+
+var AggregateQueryRawRequest_Limit_DEFAULT int64
+
+func (p *AggregateQueryRawRequest) GetLimit() int64 {
+	if !p.IsSetLimit() {
+		return AggregateQueryRawRequest_Limit_DEFAULT
+	}
+	return *p.Limit
+}
+
+var AggregateQueryRawRequest_TagNameFilter_DEFAULT [][]byte
+
+func (p *AggregateQueryRawRequest) GetTagNameFilter() [][]byte {
+	return p.TagNameFilter
+}
+
+var AggregateQueryRawRequest_AggregateQueryType_DEFAULT AggregateQueryType = 1
+
+func (p *AggregateQueryRawRequest) GetAggregateQueryType() AggregateQueryType {
+	return p.AggregateQueryType
+}
+
+var AggregateQueryRawRequest_RangeType_DEFAULT TimeType = 0
+
+func (p *AggregateQueryRawRequest) GetRangeType() TimeType {
+	return p.RangeType
+}
+func (p *AggregateQueryRawRequest) IsSetLimit() bool {
+	return p.Limit != nil
+}
+
+func (p *AggregateQueryRawRequest) IsSetTagNameFilter() bool {
+	return p.TagNameFilter != nil
+}
+
+func (p *AggregateQueryRawRequest) IsSetAggregateQueryType() bool {
+	return p.AggregateQueryType != AggregateQueryRawRequest_AggregateQueryType_DEFAULT
+}
+
+func (p *AggregateQueryRawRequest) IsSetRangeType() bool {
+	return p.RangeType != AggregateQueryRawRequest_RangeType_DEFAULT
+}
+
+func (p *AggregateQueryRawRequest) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetQuery bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetNameSpace bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetQuery = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetRangeStart = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetRangeEnd = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetNameSpace = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
+		case 8:
+			if err := p.ReadField8(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetQuery {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Query is not set"))
+	}
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.Query = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.RangeStart = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RangeEnd = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.NameSpace = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Limit = &v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField6(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([][]byte, 0, size)
+	p.TagNameFilter = tSlice
+	for i := 0; i < size; i++ {
+		var _elem22 []byte
+		if v, err := iprot.ReadBinary(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem22 = v
+		}
+		p.TagNameFilter = append(p.TagNameFilter, _elem22)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := AggregateQueryType(v)
+		p.AggregateQueryType = temp
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		temp := TimeType(v)
+		p.RangeType = temp
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryRawRequest"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("query", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:query: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.Query); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.query (1) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:query: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpace: ", p), err)
+	}
+	if err := oprot.WriteBinary(p.NameSpace); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpace: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimit() {
+		if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTagNameFilter() {
+		if err := oprot.WriteFieldBegin("tagNameFilter", thrift.LIST, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:tagNameFilter: ", p), err)
+		}
+		if err := oprot.WriteListBegin(thrift.STRING, len(p.TagNameFilter)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.TagNameFilter {
+			if err := oprot.WriteBinary(v); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:tagNameFilter: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetAggregateQueryType() {
+		if err := oprot.WriteFieldBegin("aggregateQueryType", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:aggregateQueryType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.AggregateQueryType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.aggregateQueryType (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:aggregateQueryType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRangeType() {
+		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:rangeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.rangeType (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:rangeType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *AggregateQueryRawRequest) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("AggregateQueryRawRequest(%+v)", *p)
+}
+
+// Attributes:
+//  - Results
+//  - Exhaustive
+type AggregateQueryRawResult_ struct {
+	Results    []*AggregateQueryRawResultTagNameElement `thrift:"results,1,required" db:"results" json:"results"`
+	Exhaustive bool                                     `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
+}
+
+func NewAggregateQueryRawResult_() *AggregateQueryRawResult_ {
+	return &AggregateQueryRawResult_{}
+}
+
+func (p *AggregateQueryRawResult_) GetResults() []*AggregateQueryRawResultTagNameElement {
+	return p.Results
+}
+
+func (p *AggregateQueryRawResult_) GetExhaustive() bool {
+	return p.Exhaustive
+}
+func (p *AggregateQueryRawResult_) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetResults bool = false
+	var issetExhaustive bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
 		}
-		if err := p.writeField8(oprot); err != nil {
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetResults = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetExhaustive = true
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetResults {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Results is not set"))
+	}
+	if !issetExhaustive {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Exhaustive is not set"))
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*AggregateQueryRawResultTagNameElement, 0, size)
+	p.Results = tSlice
+	for i := 0; i < size; i++ {
+		_elem23 := &AggregateQueryRawResultTagNameElement{}
+		if err := _elem23.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem23), err)
+		}
+		p.Results = append(p.Results, _elem23)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.Exhaustive = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryRawResult"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
 	}
@@ -9373,159 +12095,227 @@ func (p *AggregateQueryRawRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *AggregateQueryRawRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("query", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:query: ", p), err)
+func (p *AggregateQueryRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("results", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:results: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.Query); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.query (1) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Results)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Results {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:query: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:results: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryRawRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
+func (p *AggregateQueryRawResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("exhaustive", thrift.BOOL, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:exhaustive: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
+	if err := oprot.WriteBool(bool(p.Exhaustive)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.exhaustive (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:exhaustive: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryRawRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
+func (p *AggregateQueryRawResult_) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("AggregateQueryRawResult_(%+v)", *p)
+}
+
+// Attributes:
+//  - TagName
+//  - TagValues
+type AggregateQueryRawResultTagNameElement struct {
+	TagName   []byte                                    `thrift:"tagName,1,required" db:"tagName" json:"tagName"`
+	TagValues []*AggregateQueryRawResultTagValueElement `thrift:"tagValues,2" db:"tagValues" json:"tagValues,omitempty"`
+}
+
+func NewAggregateQueryRawResultTagNameElement() *AggregateQueryRawResultTagNameElement {
+	return &AggregateQueryRawResultTagNameElement{}
+}
+
+func (p *AggregateQueryRawResultTagNameElement) GetTagName() []byte {
+	return p.TagName
+}
+
+var AggregateQueryRawResultTagNameElement_TagValues_DEFAULT []*AggregateQueryRawResultTagValueElement
+
+func (p *AggregateQueryRawResultTagNameElement) GetTagValues() []*AggregateQueryRawResultTagValueElement {
+	return p.TagValues
+}
+func (p *AggregateQueryRawResultTagNameElement) IsSetTagValues() bool {
+	return p.TagValues != nil
+}
+
+func (p *AggregateQueryRawResultTagNameElement) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	var issetTagName bool = false
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+			issetTagName = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetTagName {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagName is not set"))
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawResultTagNameElement) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
+	} else {
+		p.TagName = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawResultTagNameElement) ReadField2(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*AggregateQueryRawResultTagValueElement, 0, size)
+	p.TagValues = tSlice
+	for i := 0; i < size; i++ {
+		_elem24 := &AggregateQueryRawResultTagValueElement{}
+		if err := _elem24.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem24), err)
+		}
+		p.TagValues = append(p.TagValues, _elem24)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *AggregateQueryRawResultTagNameElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryRawResultTagNameElement"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
 	}
-	return err
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
 }
 
-func (p *AggregateQueryRawRequest) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpace: ", p), err)
+func (p *AggregateQueryRawResultTagNameElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("tagName", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagName: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.NameSpace); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (4) field write error: ", p), err)
+	if err := oprot.WriteBinary(p.TagName); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.tagName (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpace: ", p), err)
-	}
-	return err
-}
-
-func (p *AggregateQueryRawRequest) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLimit() {
-		if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
-		}
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagName: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryRawRequest) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetTagNameFilter() {
-		if err := oprot.WriteFieldBegin("tagNameFilter", thrift.LIST, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:tagNameFilter: ", p), err)
+func (p *AggregateQueryRawResultTagNameElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTagValues() {
+		if err := oprot.WriteFieldBegin("tagValues", thrift.LIST, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:tagValues: ", p), err)
 		}
-		if err := oprot.WriteListBegin(thrift.STRING, len(p.TagNameFilter)); err != nil {
+		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.TagValues)); err != nil {
 			return thrift.PrependError("error writing list begin: ", err)
 		}
-		for _, v := range p.TagNameFilter {
-			if err := oprot.WriteBinary(v); err != nil {
-				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+		for _, v := range p.TagValues {
+			if err := v.Write(oprot); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
 			}
 		}
 		if err := oprot.WriteListEnd(); err != nil {
 			return thrift.PrependError("error writing list end: ", err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:tagNameFilter: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *AggregateQueryRawRequest) writeField7(oprot thrift.TProtocol) (err error) {
-	if p.IsSetAggregateQueryType() {
-		if err := oprot.WriteFieldBegin("aggregateQueryType", thrift.I32, 7); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:aggregateQueryType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.AggregateQueryType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.aggregateQueryType (7) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:aggregateQueryType: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *AggregateQueryRawRequest) writeField8(oprot thrift.TProtocol) (err error) {
-	if p.IsSetRangeType() {
-		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 8); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:rangeType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.rangeType (8) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:rangeType: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:tagValues: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *AggregateQueryRawRequest) String() string {
+func (p *AggregateQueryRawResultTagNameElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("AggregateQueryRawRequest(%+v)", *p)
+	return fmt.Sprintf("AggregateQueryRawResultTagNameElement(%+v)", *p)
 }
 
 // Attributes:
-//  - Results
-//  - Exhaustive
-type AggregateQueryRawResult_ struct {
-	Results    []*AggregateQueryRawResultTagNameElement `thrift:"results,1,required" db:"results" json:"results"`
-	Exhaustive bool                                     `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
-}
-
-func NewAggregateQueryRawResult_() *AggregateQueryRawResult_ {
-	return &AggregateQueryRawResult_{}
+//  - TagValue
+type AggregateQueryRawResultTagValueElement struct {
+	TagValue []byte `thrift:"tagValue,1,required" db:"tagValue" json:"tagValue"`
 }
 
-func (p *AggregateQueryRawResult_) GetResults() []*AggregateQueryRawResultTagNameElement {
-	return p.Results
+func NewAggregateQueryRawResultTagValueElement() *AggregateQueryRawResultTagValueElement {
+	return &AggregateQueryRawResultTagValueElement{}
 }
 
-func (p *AggregateQueryRawResult_) GetExhaustive() bool {
-	return p.Exhaustive
+func (p *AggregateQueryRawResultTagValueElement) GetTagValue() []byte {
+	return p.TagValue
 }
-func (p *AggregateQueryRawResult_) Read(iprot thrift.TProtocol) error {
+func (p *AggregateQueryRawResultTagValueElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetResults bool = false
-	var issetExhaustive bool = false
+	var issetTagValue bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -9540,12 +12330,7 @@ func (p *AggregateQueryRawResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetResults = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-			issetExhaustive = true
+			issetTagValue = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -9558,55 +12343,29 @@ func (p *AggregateQueryRawResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetResults {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Results is not set"))
-	}
-	if !issetExhaustive {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Exhaustive is not set"))
-	}
-	return nil
-}
-
-func (p *AggregateQueryRawResult_) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*AggregateQueryRawResultTagNameElement, 0, size)
-	p.Results = tSlice
-	for i := 0; i < size; i++ {
-		_elem23 := &AggregateQueryRawResultTagNameElement{}
-		if err := _elem23.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem23), err)
-		}
-		p.Results = append(p.Results, _elem23)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	if !issetTagValue {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagValue is not set"))
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawResult_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
+func (p *AggregateQueryRawResultTagValueElement) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBinary(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Exhaustive = v
+		p.TagValue = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryRawResult"); err != nil {
+func (p *AggregateQueryRawResultTagValueElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryRawResultTagValueElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -9617,78 +12376,129 @@ func (p *AggregateQueryRawResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *AggregateQueryRawResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("results", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:results: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Results)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Results {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
+func (p *AggregateQueryRawResultTagValueElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("tagValue", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagValue: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	if err := oprot.WriteBinary(p.TagValue); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.tagValue (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:results: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagValue: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryRawResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("exhaustive", thrift.BOOL, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:exhaustive: ", p), err)
+func (p *AggregateQueryRawResultTagValueElement) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	if err := oprot.WriteBool(bool(p.Exhaustive)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.exhaustive (2) field write error: ", p), err)
+	return fmt.Sprintf("AggregateQueryRawResultTagValueElement(%+v)", *p)
+}
+
+// Attributes:
+//  - Query
+//  - RangeStart
+//  - RangeEnd
+//  - NameSpace
+//  - Limit
+//  - TagNameFilter
+//  - AggregateQueryType
+//  - RangeType
+type AggregateQueryRequest struct {
+	Query              *Query             `thrift:"query,1" db:"query" json:"query,omitempty"`
+	RangeStart         int64              `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd           int64              `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
+	NameSpace          string             `thrift:"nameSpace,4,required" db:"nameSpace" json:"nameSpace"`
+	Limit              *int64             `thrift:"limit,5" db:"limit" json:"limit,omitempty"`
+	TagNameFilter      []string           `thrift:"tagNameFilter,6" db:"tagNameFilter" json:"tagNameFilter,omitempty"`
+	AggregateQueryType AggregateQueryType `thrift:"aggregateQueryType,7" db:"aggregateQueryType" json:"aggregateQueryType,omitempty"`
+	RangeType          TimeType           `thrift:"rangeType,8" db:"rangeType" json:"rangeType,omitempty"`
+}
+
+func NewAggregateQueryRequest() *AggregateQueryRequest {
+	return &AggregateQueryRequest{
+		AggregateQueryType: 1,
+
+		RangeType: 0,
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:exhaustive: ", p), err)
+}
+
+var AggregateQueryRequest_Query_DEFAULT *Query
+
+func (p *AggregateQueryRequest) GetQuery() *Query {
+	if !p.IsSetQuery() {
+		return AggregateQueryRequest_Query_DEFAULT
+	}
+	return p.Query
+}
+
+func (p *AggregateQueryRequest) GetRangeStart() int64 {
+	return p.RangeStart
+}
+
+func (p *AggregateQueryRequest) GetRangeEnd() int64 {
+	return p.RangeEnd
+}
+
+func (p *AggregateQueryRequest) GetNameSpace() string {
+	return p.NameSpace
+}
+
+var AggregateQueryRequest_Limit_DEFAULT int64
+
+func (p *AggregateQueryRequest) GetLimit() int64 {
+	if !p.IsSetLimit() {
+		return AggregateQueryRequest_Limit_DEFAULT
 	}
-	return err
+	return *p.Limit
 }
 
-func (p *AggregateQueryRawResult_) String() string {
-	if p == nil {
-		return "<nil>"
-	}
-	return fmt.Sprintf("AggregateQueryRawResult_(%+v)", *p)
+var AggregateQueryRequest_TagNameFilter_DEFAULT []string
+
+func (p *AggregateQueryRequest) GetTagNameFilter() []string {
+	return p.TagNameFilter
 }
 
-// Attributes:
-//  - TagName
-//  - TagValues
-type AggregateQueryRawResultTagNameElement struct {
-	TagName   []byte                                    `thrift:"tagName,1,required" db:"tagName" json:"tagName"`
-	TagValues []*AggregateQueryRawResultTagValueElement `thrift:"tagValues,2" db:"tagValues" json:"tagValues,omitempty"`
+var AggregateQueryRequest_AggregateQueryType_DEFAULT AggregateQueryType = 1
+
+func (p *AggregateQueryRequest) GetAggregateQueryType() AggregateQueryType {
+	return p.AggregateQueryType
 }
 
-func NewAggregateQueryRawResultTagNameElement() *AggregateQueryRawResultTagNameElement {
-	return &AggregateQueryRawResultTagNameElement{}
+var AggregateQueryRequest_RangeType_DEFAULT TimeType = 0
+
+func (p *AggregateQueryRequest) GetRangeType() TimeType {
+	return p.RangeType
+}
+func (p *AggregateQueryRequest) IsSetQuery() bool {
+	return p.Query != nil
 }
 
-func (p *AggregateQueryRawResultTagNameElement) GetTagName() []byte {
-	return p.TagName
+func (p *AggregateQueryRequest) IsSetLimit() bool {
+	return p.Limit != nil
 }
 
-var AggregateQueryRawResultTagNameElement_TagValues_DEFAULT []*AggregateQueryRawResultTagValueElement
+func (p *AggregateQueryRequest) IsSetTagNameFilter() bool {
+	return p.TagNameFilter != nil
+}
 
-func (p *AggregateQueryRawResultTagNameElement) GetTagValues() []*AggregateQueryRawResultTagValueElement {
-	return p.TagValues
+func (p *AggregateQueryRequest) IsSetAggregateQueryType() bool {
+	return p.AggregateQueryType != AggregateQueryRequest_AggregateQueryType_DEFAULT
 }
-func (p *AggregateQueryRawResultTagNameElement) IsSetTagValues() bool {
-	return p.TagValues != nil
+
+func (p *AggregateQueryRequest) IsSetRangeType() bool {
+	return p.RangeType != AggregateQueryRequest_RangeType_DEFAULT
 }
 
-func (p *AggregateQueryRawResultTagNameElement) Read(iprot thrift.TProtocol) error {
+func (p *AggregateQueryRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetTagName bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetNameSpace bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -9703,11 +12513,37 @@ func (p *AggregateQueryRawResultTagNameElement) Read(iprot thrift.TProtocol) err
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetTagName = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
+			issetRangeStart = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetRangeEnd = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetNameSpace = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
+		case 8:
+			if err := p.ReadField8(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -9720,34 +12556,77 @@ func (p *AggregateQueryRawResultTagNameElement) Read(iprot thrift.TProtocol) err
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetTagName {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagName is not set"))
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetNameSpace {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagNameElement) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
+func (p *AggregateQueryRequest) ReadField1(iprot thrift.TProtocol) error {
+	p.Query = &Query{}
+	if err := p.Query.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Query), err)
+	}
+	return nil
+}
+
+func (p *AggregateQueryRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.TagName = v
+		p.RangeStart = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagNameElement) ReadField2(iprot thrift.TProtocol) error {
+func (p *AggregateQueryRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RangeEnd = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRequest) ReadField4(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 4: ", err)
+	} else {
+		p.NameSpace = v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
+	} else {
+		p.Limit = &v
+	}
+	return nil
+}
+
+func (p *AggregateQueryRequest) ReadField6(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*AggregateQueryRawResultTagValueElement, 0, size)
-	p.TagValues = tSlice
+	tSlice := make([]string, 0, size)
+	p.TagNameFilter = tSlice
 	for i := 0; i < size; i++ {
-		_elem24 := &AggregateQueryRawResultTagValueElement{}
-		if err := _elem24.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem24), err)
+		var _elem25 string
+		if v, err := iprot.ReadString(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem25 = v
 		}
-		p.TagValues = append(p.TagValues, _elem24)
+		p.TagNameFilter = append(p.TagNameFilter, _elem25)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -9755,8 +12634,28 @@ func (p *AggregateQueryRawResultTagNameElement) ReadField2(iprot thrift.TProtoco
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagNameElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryRawResultTagNameElement"); err != nil {
+func (p *AggregateQueryRequest) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := AggregateQueryType(v)
+		p.AggregateQueryType = temp
+	}
+	return nil
+}
+
+func (p *AggregateQueryRequest) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		temp := TimeType(v)
+		p.RangeType = temp
+	}
+	return nil
+}
+
+func (p *AggregateQueryRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -9766,6 +12665,24 @@ func (p *AggregateQueryRawResultTagNameElement) Write(oprot thrift.TProtocol) er
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -9776,68 +12693,161 @@ func (p *AggregateQueryRawResultTagNameElement) Write(oprot thrift.TProtocol) er
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagNameElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("tagName", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagName: ", p), err)
+func (p *AggregateQueryRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetQuery() {
+		if err := oprot.WriteFieldBegin("query", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:query: ", p), err)
+		}
+		if err := p.Query.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Query), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:query: ", p), err)
+		}
 	}
-	if err := oprot.WriteBinary(p.TagName); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.tagName (1) field write error: ", p), err)
+	return err
+}
+
+func (p *AggregateQueryRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagName: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpace: ", p), err)
+	}
+	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (4) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpace: ", p), err)
+	}
+	return err
+}
+
+func (p *AggregateQueryRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimit() {
+		if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *AggregateQueryRawResultTagNameElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetTagValues() {
-		if err := oprot.WriteFieldBegin("tagValues", thrift.LIST, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:tagValues: ", p), err)
+func (p *AggregateQueryRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTagNameFilter() {
+		if err := oprot.WriteFieldBegin("tagNameFilter", thrift.LIST, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:tagNameFilter: ", p), err)
 		}
-		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.TagValues)); err != nil {
+		if err := oprot.WriteListBegin(thrift.STRING, len(p.TagNameFilter)); err != nil {
 			return thrift.PrependError("error writing list begin: ", err)
 		}
-		for _, v := range p.TagValues {
-			if err := v.Write(oprot); err != nil {
-				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		for _, v := range p.TagNameFilter {
+			if err := oprot.WriteString(string(v)); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
 			}
 		}
 		if err := oprot.WriteListEnd(); err != nil {
 			return thrift.PrependError("error writing list end: ", err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:tagValues: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:tagNameFilter: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *AggregateQueryRawResultTagNameElement) String() string {
+func (p *AggregateQueryRequest) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetAggregateQueryType() {
+		if err := oprot.WriteFieldBegin("aggregateQueryType", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:aggregateQueryType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.AggregateQueryType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.aggregateQueryType (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:aggregateQueryType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *AggregateQueryRequest) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRangeType() {
+		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:rangeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.rangeType (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:rangeType: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *AggregateQueryRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("AggregateQueryRawResultTagNameElement(%+v)", *p)
+	return fmt.Sprintf("AggregateQueryRequest(%+v)", *p)
 }
 
 // Attributes:
-//  - TagValue
-type AggregateQueryRawResultTagValueElement struct {
-	TagValue []byte `thrift:"tagValue,1,required" db:"tagValue" json:"tagValue"`
+//  - Results
+//  - Exhaustive
+type AggregateQueryResult_ struct {
+	Results    []*AggregateQueryResultTagNameElement `thrift:"results,1,required" db:"results" json:"results"`
+	Exhaustive bool                                  `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
 }
 
-func NewAggregateQueryRawResultTagValueElement() *AggregateQueryRawResultTagValueElement {
-	return &AggregateQueryRawResultTagValueElement{}
+func NewAggregateQueryResult_() *AggregateQueryResult_ {
+	return &AggregateQueryResult_{}
 }
 
-func (p *AggregateQueryRawResultTagValueElement) GetTagValue() []byte {
-	return p.TagValue
+func (p *AggregateQueryResult_) GetResults() []*AggregateQueryResultTagNameElement {
+	return p.Results
 }
-func (p *AggregateQueryRawResultTagValueElement) Read(iprot thrift.TProtocol) error {
+
+func (p *AggregateQueryResult_) GetExhaustive() bool {
+	return p.Exhaustive
+}
+func (p *AggregateQueryResult_) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetTagValue bool = false
+	var issetResults bool = false
+	var issetExhaustive bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -9852,7 +12862,12 @@ func (p *AggregateQueryRawResultTagValueElement) Read(iprot thrift.TProtocol) er
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetTagValue = true
+			issetResults = true
+		case 2:
+			if err := p.ReadField2(iprot); err != nil {
+				return err
+			}
+			issetExhaustive = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -9865,29 +12880,55 @@ func (p *AggregateQueryRawResultTagValueElement) Read(iprot thrift.TProtocol) er
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetTagValue {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagValue is not set"))
+	if !issetResults {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Results is not set"))
+	}
+	if !issetExhaustive {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Exhaustive is not set"))
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagValueElement) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBinary(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
+func (p *AggregateQueryResult_) ReadField1(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]*AggregateQueryResultTagNameElement, 0, size)
+	p.Results = tSlice
+	for i := 0; i < size; i++ {
+		_elem26 := &AggregateQueryResultTagNameElement{}
+		if err := _elem26.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem26), err)
+		}
+		p.Results = append(p.Results, _elem26)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *AggregateQueryResult_) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadBool(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
 	} else {
-		p.TagValue = v
+		p.Exhaustive = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagValueElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryRawResultTagValueElement"); err != nil {
+func (p *AggregateQueryResult_) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryResult"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField2(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -9898,129 +12939,78 @@ func (p *AggregateQueryRawResultTagValueElement) Write(oprot thrift.TProtocol) e
 	return nil
 }
 
-func (p *AggregateQueryRawResultTagValueElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("tagValue", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagValue: ", p), err)
+func (p *AggregateQueryResult_) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("results", thrift.LIST, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:results: ", p), err)
 	}
-	if err := oprot.WriteBinary(p.TagValue); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.tagValue (1) field write error: ", p), err)
+	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Results)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.Results {
+		if err := v.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagValue: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:results: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryRawResultTagValueElement) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *AggregateQueryResult_) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("exhaustive", thrift.BOOL, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:exhaustive: ", p), err)
 	}
-	return fmt.Sprintf("AggregateQueryRawResultTagValueElement(%+v)", *p)
-}
-
-// Attributes:
-//  - Query
-//  - RangeStart
-//  - RangeEnd
-//  - NameSpace
-//  - Limit
-//  - TagNameFilter
-//  - AggregateQueryType
-//  - RangeType
-type AggregateQueryRequest struct {
-	Query              *Query             `thrift:"query,1" db:"query" json:"query,omitempty"`
-	RangeStart         int64              `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
-	RangeEnd           int64              `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
-	NameSpace          string             `thrift:"nameSpace,4,required" db:"nameSpace" json:"nameSpace"`
-	Limit              *int64             `thrift:"limit,5" db:"limit" json:"limit,omitempty"`
-	TagNameFilter      []string           `thrift:"tagNameFilter,6" db:"tagNameFilter" json:"tagNameFilter,omitempty"`
-	AggregateQueryType AggregateQueryType `thrift:"aggregateQueryType,7" db:"aggregateQueryType" json:"aggregateQueryType,omitempty"`
-	RangeType          TimeType           `thrift:"rangeType,8" db:"rangeType" json:"rangeType,omitempty"`
-}
-
-func NewAggregateQueryRequest() *AggregateQueryRequest {
-	return &AggregateQueryRequest{
-		AggregateQueryType: 1,
-
-		RangeType: 0,
+	if err := oprot.WriteBool(bool(p.Exhaustive)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.exhaustive (2) field write error: ", p), err)
 	}
-}
-
-var AggregateQueryRequest_Query_DEFAULT *Query
-
-func (p *AggregateQueryRequest) GetQuery() *Query {
-	if !p.IsSetQuery() {
-		return AggregateQueryRequest_Query_DEFAULT
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:exhaustive: ", p), err)
 	}
-	return p.Query
-}
-
-func (p *AggregateQueryRequest) GetRangeStart() int64 {
-	return p.RangeStart
-}
-
-func (p *AggregateQueryRequest) GetRangeEnd() int64 {
-	return p.RangeEnd
-}
-
-func (p *AggregateQueryRequest) GetNameSpace() string {
-	return p.NameSpace
+	return err
 }
 
-var AggregateQueryRequest_Limit_DEFAULT int64
-
-func (p *AggregateQueryRequest) GetLimit() int64 {
-	if !p.IsSetLimit() {
-		return AggregateQueryRequest_Limit_DEFAULT
+func (p *AggregateQueryResult_) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	return *p.Limit
-}
-
-var AggregateQueryRequest_TagNameFilter_DEFAULT []string
-
-func (p *AggregateQueryRequest) GetTagNameFilter() []string {
-	return p.TagNameFilter
-}
-
-var AggregateQueryRequest_AggregateQueryType_DEFAULT AggregateQueryType = 1
-
-func (p *AggregateQueryRequest) GetAggregateQueryType() AggregateQueryType {
-	return p.AggregateQueryType
+	return fmt.Sprintf("AggregateQueryResult_(%+v)", *p)
 }
 
-var AggregateQueryRequest_RangeType_DEFAULT TimeType = 0
-
-func (p *AggregateQueryRequest) GetRangeType() TimeType {
-	return p.RangeType
-}
-func (p *AggregateQueryRequest) IsSetQuery() bool {
-	return p.Query != nil
+// Attributes:
+//  - TagName
+//  - TagValues
+type AggregateQueryResultTagNameElement struct {
+	TagName   string                                 `thrift:"tagName,1,required" db:"tagName" json:"tagName"`
+	TagValues []*AggregateQueryResultTagValueElement `thrift:"tagValues,2" db:"tagValues" json:"tagValues,omitempty"`
 }
 
-func (p *AggregateQueryRequest) IsSetLimit() bool {
-	return p.Limit != nil
+func NewAggregateQueryResultTagNameElement() *AggregateQueryResultTagNameElement {
+	return &AggregateQueryResultTagNameElement{}
 }
 
-func (p *AggregateQueryRequest) IsSetTagNameFilter() bool {
-	return p.TagNameFilter != nil
+func (p *AggregateQueryResultTagNameElement) GetTagName() string {
+	return p.TagName
 }
 
-func (p *AggregateQueryRequest) IsSetAggregateQueryType() bool {
-	return p.AggregateQueryType != AggregateQueryRequest_AggregateQueryType_DEFAULT
-}
+var AggregateQueryResultTagNameElement_TagValues_DEFAULT []*AggregateQueryResultTagValueElement
 
-func (p *AggregateQueryRequest) IsSetRangeType() bool {
-	return p.RangeType != AggregateQueryRequest_RangeType_DEFAULT
+func (p *AggregateQueryResultTagNameElement) GetTagValues() []*AggregateQueryResultTagValueElement {
+	return p.TagValues
+}
+func (p *AggregateQueryResultTagNameElement) IsSetTagValues() bool {
+	return p.TagValues != nil
 }
 
-func (p *AggregateQueryRequest) Read(iprot thrift.TProtocol) error {
+func (p *AggregateQueryResultTagNameElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetRangeStart bool = false
-	var issetRangeEnd bool = false
-	var issetNameSpace bool = false
+	var issetTagName bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -10035,37 +13025,11 @@ func (p *AggregateQueryRequest) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
+			issetTagName = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
-			issetRangeStart = true
-		case 3:
-			if err := p.ReadField3(iprot); err != nil {
-				return err
-			}
-			issetRangeEnd = true
-		case 4:
-			if err := p.ReadField4(iprot); err != nil {
-				return err
-			}
-			issetNameSpace = true
-		case 5:
-			if err := p.ReadField5(iprot); err != nil {
-				return err
-			}
-		case 6:
-			if err := p.ReadField6(iprot); err != nil {
-				return err
-			}
-		case 7:
-			if err := p.ReadField7(iprot); err != nil {
-				return err
-			}
-		case 8:
-			if err := p.ReadField8(iprot); err != nil {
-				return err
-			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -10078,77 +13042,34 @@ func (p *AggregateQueryRequest) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetRangeStart {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
-	}
-	if !issetRangeEnd {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
-	}
-	if !issetNameSpace {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpace is not set"))
-	}
-	return nil
-}
-
-func (p *AggregateQueryRequest) ReadField1(iprot thrift.TProtocol) error {
-	p.Query = &Query{}
-	if err := p.Query.Read(iprot); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Query), err)
-	}
-	return nil
-}
-
-func (p *AggregateQueryRequest) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
-	} else {
-		p.RangeStart = v
-	}
-	return nil
-}
-
-func (p *AggregateQueryRequest) ReadField3(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 3: ", err)
-	} else {
-		p.RangeEnd = v
+	if !issetTagName {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagName is not set"))
 	}
 	return nil
 }
 
-func (p *AggregateQueryRequest) ReadField4(iprot thrift.TProtocol) error {
+func (p *AggregateQueryResultTagNameElement) ReadField1(iprot thrift.TProtocol) error {
 	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 4: ", err)
-	} else {
-		p.NameSpace = v
-	}
-	return nil
-}
-
-func (p *AggregateQueryRequest) ReadField5(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI64(); err != nil {
-		return thrift.PrependError("error reading field 5: ", err)
+		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Limit = &v
+		p.TagName = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryRequest) ReadField6(iprot thrift.TProtocol) error {
+func (p *AggregateQueryResultTagNameElement) ReadField2(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]string, 0, size)
-	p.TagNameFilter = tSlice
+	tSlice := make([]*AggregateQueryResultTagValueElement, 0, size)
+	p.TagValues = tSlice
 	for i := 0; i < size; i++ {
-		var _elem25 string
-		if v, err := iprot.ReadString(); err != nil {
-			return thrift.PrependError("error reading field 0: ", err)
-		} else {
-			_elem25 = v
+		_elem27 := &AggregateQueryResultTagValueElement{}
+		if err := _elem27.Read(iprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem27), err)
 		}
-		p.TagNameFilter = append(p.TagNameFilter, _elem25)
+		p.TagValues = append(p.TagValues, _elem27)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -10156,28 +13077,8 @@ func (p *AggregateQueryRequest) ReadField6(iprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *AggregateQueryRequest) ReadField7(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 7: ", err)
-	} else {
-		temp := AggregateQueryType(v)
-		p.AggregateQueryType = temp
-	}
-	return nil
-}
-
-func (p *AggregateQueryRequest) ReadField8(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadI32(); err != nil {
-		return thrift.PrependError("error reading field 8: ", err)
-	} else {
-		temp := TimeType(v)
-		p.RangeType = temp
-	}
-	return nil
-}
-
-func (p *AggregateQueryRequest) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryRequest"); err != nil {
+func (p *AggregateQueryResultTagNameElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryResultTagNameElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -10187,24 +13088,6 @@ func (p *AggregateQueryRequest) Write(oprot thrift.TProtocol) error {
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField3(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField4(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField5(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField6(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField7(oprot); err != nil {
-			return err
-		}
-		if err := p.writeField8(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -10215,161 +13098,68 @@ func (p *AggregateQueryRequest) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *AggregateQueryRequest) writeField1(oprot thrift.TProtocol) (err error) {
-	if p.IsSetQuery() {
-		if err := oprot.WriteFieldBegin("query", thrift.STRUCT, 1); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:query: ", p), err)
-		}
-		if err := p.Query.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Query), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:query: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *AggregateQueryRequest) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
-	}
-	return err
-}
-
-func (p *AggregateQueryRequest) writeField3(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
-	}
-	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
-	}
-	return err
-}
-
-func (p *AggregateQueryRequest) writeField4(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("nameSpace", thrift.STRING, 4); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpace: ", p), err)
+func (p *AggregateQueryResultTagNameElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("tagName", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagName: ", p), err)
 	}
-	if err := oprot.WriteString(string(p.NameSpace)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.nameSpace (4) field write error: ", p), err)
+	if err := oprot.WriteString(string(p.TagName)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.tagName (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpace: ", p), err)
-	}
-	return err
-}
-
-func (p *AggregateQueryRequest) writeField5(oprot thrift.TProtocol) (err error) {
-	if p.IsSetLimit() {
-		if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
-		}
-		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *AggregateQueryRequest) writeField6(oprot thrift.TProtocol) (err error) {
-	if p.IsSetTagNameFilter() {
-		if err := oprot.WriteFieldBegin("tagNameFilter", thrift.LIST, 6); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:tagNameFilter: ", p), err)
-		}
-		if err := oprot.WriteListBegin(thrift.STRING, len(p.TagNameFilter)); err != nil {
-			return thrift.PrependError("error writing list begin: ", err)
-		}
-		for _, v := range p.TagNameFilter {
-			if err := oprot.WriteString(string(v)); err != nil {
-				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
-			}
-		}
-		if err := oprot.WriteListEnd(); err != nil {
-			return thrift.PrependError("error writing list end: ", err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:tagNameFilter: ", p), err)
-		}
-	}
-	return err
-}
-
-func (p *AggregateQueryRequest) writeField7(oprot thrift.TProtocol) (err error) {
-	if p.IsSetAggregateQueryType() {
-		if err := oprot.WriteFieldBegin("aggregateQueryType", thrift.I32, 7); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:aggregateQueryType: ", p), err)
-		}
-		if err := oprot.WriteI32(int32(p.AggregateQueryType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.aggregateQueryType (7) field write error: ", p), err)
-		}
-		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:aggregateQueryType: ", p), err)
-		}
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagName: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryRequest) writeField8(oprot thrift.TProtocol) (err error) {
-	if p.IsSetRangeType() {
-		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 8); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:rangeType: ", p), err)
+func (p *AggregateQueryResultTagNameElement) writeField2(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTagValues() {
+		if err := oprot.WriteFieldBegin("tagValues", thrift.LIST, 2); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:tagValues: ", p), err)
 		}
-		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T.rangeType (8) field write error: ", p), err)
+		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.TagValues)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
+		}
+		for _, v := range p.TagValues {
+			if err := v.Write(oprot); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
+			}
+		}
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:rangeType: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:tagValues: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *AggregateQueryRequest) String() string {
+func (p *AggregateQueryResultTagNameElement) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("AggregateQueryRequest(%+v)", *p)
+	return fmt.Sprintf("AggregateQueryResultTagNameElement(%+v)", *p)
 }
 
 // Attributes:
-//  - Results
-//  - Exhaustive
-type AggregateQueryResult_ struct {
-	Results    []*AggregateQueryResultTagNameElement `thrift:"results,1,required" db:"results" json:"results"`
-	Exhaustive bool                                  `thrift:"exhaustive,2,required" db:"exhaustive" json:"exhaustive"`
-}
-
-func NewAggregateQueryResult_() *AggregateQueryResult_ {
-	return &AggregateQueryResult_{}
+//  - TagValue
+type AggregateQueryResultTagValueElement struct {
+	TagValue string `thrift:"tagValue,1,required" db:"tagValue" json:"tagValue"`
 }
 
-func (p *AggregateQueryResult_) GetResults() []*AggregateQueryResultTagNameElement {
-	return p.Results
+func NewAggregateQueryResultTagValueElement() *AggregateQueryResultTagValueElement {
+	return &AggregateQueryResultTagValueElement{}
 }
 
-func (p *AggregateQueryResult_) GetExhaustive() bool {
-	return p.Exhaustive
+func (p *AggregateQueryResultTagValueElement) GetTagValue() string {
+	return p.TagValue
 }
-func (p *AggregateQueryResult_) Read(iprot thrift.TProtocol) error {
+func (p *AggregateQueryResultTagValueElement) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetResults bool = false
-	var issetExhaustive bool = false
+	var issetTagValue bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -10384,12 +13174,7 @@ func (p *AggregateQueryResult_) Read(iprot thrift.TProtocol) error {
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetResults = true
-		case 2:
-			if err := p.ReadField2(iprot); err != nil {
-				return err
-			}
-			issetExhaustive = true
+			issetTagValue = true
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -10402,55 +13187,29 @@ func (p *AggregateQueryResult_) Read(iprot thrift.TProtocol) error {
 	if err := iprot.ReadStructEnd(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
 	}
-	if !issetResults {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Results is not set"))
-	}
-	if !issetExhaustive {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field Exhaustive is not set"))
-	}
-	return nil
-}
-
-func (p *AggregateQueryResult_) ReadField1(iprot thrift.TProtocol) error {
-	_, size, err := iprot.ReadListBegin()
-	if err != nil {
-		return thrift.PrependError("error reading list begin: ", err)
-	}
-	tSlice := make([]*AggregateQueryResultTagNameElement, 0, size)
-	p.Results = tSlice
-	for i := 0; i < size; i++ {
-		_elem26 := &AggregateQueryResultTagNameElement{}
-		if err := _elem26.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem26), err)
-		}
-		p.Results = append(p.Results, _elem26)
-	}
-	if err := iprot.ReadListEnd(); err != nil {
-		return thrift.PrependError("error reading list end: ", err)
+	if !issetTagValue {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagValue is not set"))
 	}
 	return nil
 }
 
-func (p *AggregateQueryResult_) ReadField2(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadBool(); err != nil {
-		return thrift.PrependError("error reading field 2: ", err)
+func (p *AggregateQueryResultTagValueElement) ReadField1(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadString(); err != nil {
+		return thrift.PrependError("error reading field 1: ", err)
 	} else {
-		p.Exhaustive = v
+		p.TagValue = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryResult_) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryResult"); err != nil {
+func (p *AggregateQueryResultTagValueElement) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateQueryResultTagValueElement"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
-		if err := p.writeField2(oprot); err != nil {
-			return err
-		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -10461,78 +13220,129 @@ func (p *AggregateQueryResult_) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
-func (p *AggregateQueryResult_) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("results", thrift.LIST, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:results: ", p), err)
-	}
-	if err := oprot.WriteListBegin(thrift.STRUCT, len(p.Results)); err != nil {
-		return thrift.PrependError("error writing list begin: ", err)
-	}
-	for _, v := range p.Results {
-		if err := v.Write(oprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-		}
+func (p *AggregateQueryResultTagValueElement) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("tagValue", thrift.STRING, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagValue: ", p), err)
 	}
-	if err := oprot.WriteListEnd(); err != nil {
-		return thrift.PrependError("error writing list end: ", err)
+	if err := oprot.WriteString(string(p.TagValue)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.tagValue (1) field write error: ", p), err)
 	}
 	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:results: ", p), err)
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagValue: ", p), err)
 	}
 	return err
 }
 
-func (p *AggregateQueryResult_) writeField2(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("exhaustive", thrift.BOOL, 2); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:exhaustive: ", p), err)
+func (p *AggregateQueryResultTagValueElement) String() string {
+	if p == nil {
+		return "<nil>"
 	}
-	if err := oprot.WriteBool(bool(p.Exhaustive)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.exhaustive (2) field write error: ", p), err)
+	return fmt.Sprintf("AggregateQueryResultTagValueElement(%+v)", *p)
+}
+
+// Attributes:
+//  - Query
+//  - RangeStart
+//  - RangeEnd
+//  - NameSpaces
+//  - Limit
+//  - TagNameFilter
+//  - AggregateQueryType
+//  - RangeType
+type AggregateMultiQueryRequest struct {
+	Query              *Query             `thrift:"query,1" db:"query" json:"query,omitempty"`
+	RangeStart         int64              `thrift:"rangeStart,2,required" db:"rangeStart" json:"rangeStart"`
+	RangeEnd           int64              `thrift:"rangeEnd,3,required" db:"rangeEnd" json:"rangeEnd"`
+	NameSpaces         []string           `thrift:"nameSpaces,4,required" db:"nameSpaces" json:"nameSpaces"`
+	Limit              *int64             `thrift:"limit,5" db:"limit" json:"limit,omitempty"`
+	TagNameFilter      []string           `thrift:"tagNameFilter,6" db:"tagNameFilter" json:"tagNameFilter,omitempty"`
+	AggregateQueryType AggregateQueryType `thrift:"aggregateQueryType,7" db:"aggregateQueryType" json:"aggregateQueryType,omitempty"`
+	RangeType          TimeType           `thrift:"rangeType,8" db:"rangeType" json:"rangeType,omitempty"`
+}
+
+func NewAggregateMultiQueryRequest() *AggregateMultiQueryRequest {
+	return &AggregateMultiQueryRequest{
+		AggregateQueryType: 1,
+
+		RangeType: 0,
 	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:exhaustive: ", p), err)
+}
+
+var AggregateMultiQueryRequest_Query_DEFAULT *Query
+
+func (p *AggregateMultiQueryRequest) GetQuery() *Query {
+	if !p.IsSetQuery() {
+		return AggregateMultiQueryRequest_Query_DEFAULT
 	}
-	return err
+	return p.Query
 }
 
-func (p *AggregateQueryResult_) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *AggregateMultiQueryRequest) GetRangeStart() int64 {
+	return p.RangeStart
+}
+
+func (p *AggregateMultiQueryRequest) GetRangeEnd() int64 {
+	return p.RangeEnd
+}
+
+func (p *AggregateMultiQueryRequest) GetNameSpaces() []string {
+	return p.NameSpaces
+}
+
+var AggregateMultiQueryRequest_Limit_DEFAULT int64
+
+func (p *AggregateMultiQueryRequest) GetLimit() int64 {
+	if !p.IsSetLimit() {
+		return AggregateMultiQueryRequest_Limit_DEFAULT
 	}
-	return fmt.Sprintf("AggregateQueryResult_(%+v)", *p)
+	return *p.Limit
 }
 
-// Attributes:
-//  - TagName
-//  - TagValues
-type AggregateQueryResultTagNameElement struct {
-	TagName   string                                 `thrift:"tagName,1,required" db:"tagName" json:"tagName"`
-	TagValues []*AggregateQueryResultTagValueElement `thrift:"tagValues,2" db:"tagValues" json:"tagValues,omitempty"`
+var AggregateMultiQueryRequest_TagNameFilter_DEFAULT []string
+
+func (p *AggregateMultiQueryRequest) GetTagNameFilter() []string {
+	return p.TagNameFilter
 }
 
-func NewAggregateQueryResultTagNameElement() *AggregateQueryResultTagNameElement {
-	return &AggregateQueryResultTagNameElement{}
+var AggregateMultiQueryRequest_AggregateQueryType_DEFAULT AggregateQueryType = 1
+
+func (p *AggregateMultiQueryRequest) GetAggregateQueryType() AggregateQueryType {
+	return p.AggregateQueryType
 }
 
-func (p *AggregateQueryResultTagNameElement) GetTagName() string {
-	return p.TagName
+var AggregateMultiQueryRequest_RangeType_DEFAULT TimeType = 0
+
+func (p *AggregateMultiQueryRequest) GetRangeType() TimeType {
+	return p.RangeType
+}
+func (p *AggregateMultiQueryRequest) IsSetQuery() bool {
+	return p.Query != nil
 }
 
-var AggregateQueryResultTagNameElement_TagValues_DEFAULT []*AggregateQueryResultTagValueElement
+func (p *AggregateMultiQueryRequest) IsSetLimit() bool {
+	return p.Limit != nil
+}
 
-func (p *AggregateQueryResultTagNameElement) GetTagValues() []*AggregateQueryResultTagValueElement {
-	return p.TagValues
+func (p *AggregateMultiQueryRequest) IsSetTagNameFilter() bool {
+	return p.TagNameFilter != nil
 }
-func (p *AggregateQueryResultTagNameElement) IsSetTagValues() bool {
-	return p.TagValues != nil
+
+func (p *AggregateMultiQueryRequest) IsSetAggregateQueryType() bool {
+	return p.AggregateQueryType != AggregateMultiQueryRequest_AggregateQueryType_DEFAULT
 }
 
-func (p *AggregateQueryResultTagNameElement) Read(iprot thrift.TProtocol) error {
+func (p *AggregateMultiQueryRequest) IsSetRangeType() bool {
+	return p.RangeType != AggregateMultiQueryRequest_RangeType_DEFAULT
+}
+
+func (p *AggregateMultiQueryRequest) Read(iprot thrift.TProtocol) error {
 	if _, err := iprot.ReadStructBegin(); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
 	}
 
-	var issetTagName bool = false
+	var issetRangeStart bool = false
+	var issetRangeEnd bool = false
+	var issetNameSpaces bool = false
 
 	for {
 		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
@@ -10547,11 +13357,37 @@ func (p *AggregateQueryResultTagNameElement) Read(iprot thrift.TProtocol) error
 			if err := p.ReadField1(iprot); err != nil {
 				return err
 			}
-			issetTagName = true
 		case 2:
 			if err := p.ReadField2(iprot); err != nil {
 				return err
 			}
+			issetRangeStart = true
+		case 3:
+			if err := p.ReadField3(iprot); err != nil {
+				return err
+			}
+			issetRangeEnd = true
+		case 4:
+			if err := p.ReadField4(iprot); err != nil {
+				return err
+			}
+			issetNameSpaces = true
+		case 5:
+			if err := p.ReadField5(iprot); err != nil {
+				return err
+			}
+		case 6:
+			if err := p.ReadField6(iprot); err != nil {
+				return err
+			}
+		case 7:
+			if err := p.ReadField7(iprot); err != nil {
+				return err
+			}
+		case 8:
+			if err := p.ReadField8(iprot); err != nil {
+				return err
+			}
 		default:
 			if err := iprot.Skip(fieldTypeId); err != nil {
 				return err
@@ -10561,37 +13397,93 @@ func (p *AggregateQueryResultTagNameElement) Read(iprot thrift.TProtocol) error
 			return err
 		}
 	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	if !issetRangeStart {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeStart is not set"))
+	}
+	if !issetRangeEnd {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field RangeEnd is not set"))
+	}
+	if !issetNameSpaces {
+		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field NameSpaces is not set"))
+	}
+	return nil
+}
+
+func (p *AggregateMultiQueryRequest) ReadField1(iprot thrift.TProtocol) error {
+	p.Query = &Query{}
+	if err := p.Query.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Query), err)
+	}
+	return nil
+}
+
+func (p *AggregateMultiQueryRequest) ReadField2(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 2: ", err)
+	} else {
+		p.RangeStart = v
 	}
-	if !issetTagName {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagName is not set"))
+	return nil
+}
+
+func (p *AggregateMultiQueryRequest) ReadField3(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 3: ", err)
+	} else {
+		p.RangeEnd = v
 	}
 	return nil
 }
 
-func (p *AggregateQueryResultTagNameElement) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
+func (p *AggregateMultiQueryRequest) ReadField4(iprot thrift.TProtocol) error {
+	_, size, err := iprot.ReadListBegin()
+	if err != nil {
+		return thrift.PrependError("error reading list begin: ", err)
+	}
+	tSlice := make([]string, 0, size)
+	p.NameSpaces = tSlice
+	for i := 0; i < size; i++ {
+		var _elem226 string
+		if v, err := iprot.ReadString(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem226 = v
+		}
+		p.NameSpaces = append(p.NameSpaces, _elem226)
+	}
+	if err := iprot.ReadListEnd(); err != nil {
+		return thrift.PrependError("error reading list end: ", err)
+	}
+	return nil
+}
+
+func (p *AggregateMultiQueryRequest) ReadField5(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI64(); err != nil {
+		return thrift.PrependError("error reading field 5: ", err)
 	} else {
-		p.TagName = v
+		p.Limit = &v
 	}
 	return nil
 }
 
-func (p *AggregateQueryResultTagNameElement) ReadField2(iprot thrift.TProtocol) error {
+func (p *AggregateMultiQueryRequest) ReadField6(iprot thrift.TProtocol) error {
 	_, size, err := iprot.ReadListBegin()
 	if err != nil {
 		return thrift.PrependError("error reading list begin: ", err)
 	}
-	tSlice := make([]*AggregateQueryResultTagValueElement, 0, size)
-	p.TagValues = tSlice
+	tSlice := make([]string, 0, size)
+	p.TagNameFilter = tSlice
 	for i := 0; i < size; i++ {
-		_elem27 := &AggregateQueryResultTagValueElement{}
-		if err := _elem27.Read(iprot); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", _elem27), err)
+		var _elem227 string
+		if v, err := iprot.ReadString(); err != nil {
+			return thrift.PrependError("error reading field 0: ", err)
+		} else {
+			_elem227 = v
 		}
-		p.TagValues = append(p.TagValues, _elem27)
+		p.TagNameFilter = append(p.TagNameFilter, _elem227)
 	}
 	if err := iprot.ReadListEnd(); err != nil {
 		return thrift.PrependError("error reading list end: ", err)
@@ -10599,8 +13491,28 @@ func (p *AggregateQueryResultTagNameElement) ReadField2(iprot thrift.TProtocol)
 	return nil
 }
 
-func (p *AggregateQueryResultTagNameElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryResultTagNameElement"); err != nil {
+func (p *AggregateMultiQueryRequest) ReadField7(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 7: ", err)
+	} else {
+		temp := AggregateQueryType(v)
+		p.AggregateQueryType = temp
+	}
+	return nil
+}
+
+func (p *AggregateMultiQueryRequest) ReadField8(iprot thrift.TProtocol) error {
+	if v, err := iprot.ReadI32(); err != nil {
+		return thrift.PrependError("error reading field 8: ", err)
+	} else {
+		temp := TimeType(v)
+		p.RangeType = temp
+	}
+	return nil
+}
+
+func (p *AggregateMultiQueryRequest) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("AggregateMultiQueryRequest"); err != nil {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
@@ -10610,6 +13522,24 @@ func (p *AggregateQueryResultTagNameElement) Write(oprot thrift.TProtocol) error
 		if err := p.writeField2(oprot); err != nil {
 			return err
 		}
+		if err := p.writeField3(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField4(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField5(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField6(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField7(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField8(oprot); err != nil {
+			return err
+		}
 	}
 	if err := oprot.WriteFieldStop(); err != nil {
 		return thrift.PrependError("write field stop error: ", err)
@@ -10620,146 +13550,141 @@ func (p *AggregateQueryResultTagNameElement) Write(oprot thrift.TProtocol) error
 	return nil
 }
 
-func (p *AggregateQueryResultTagNameElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("tagName", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagName: ", p), err)
-	}
-	if err := oprot.WriteString(string(p.TagName)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.tagName (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagName: ", p), err)
-	}
-	return err
-}
-
-func (p *AggregateQueryResultTagNameElement) writeField2(oprot thrift.TProtocol) (err error) {
-	if p.IsSetTagValues() {
-		if err := oprot.WriteFieldBegin("tagValues", thrift.LIST, 2); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:tagValues: ", p), err)
-		}
-		if err := oprot.WriteListBegin(thrift.STRUCT, len(p.TagValues)); err != nil {
-			return thrift.PrependError("error writing list begin: ", err)
-		}
-		for _, v := range p.TagValues {
-			if err := v.Write(oprot); err != nil {
-				return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", v), err)
-			}
+func (p *AggregateMultiQueryRequest) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetQuery() {
+		if err := oprot.WriteFieldBegin("query", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:query: ", p), err)
 		}
-		if err := oprot.WriteListEnd(); err != nil {
-			return thrift.PrependError("error writing list end: ", err)
+		if err := p.Query.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Query), err)
 		}
 		if err := oprot.WriteFieldEnd(); err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T write field end error 2:tagValues: ", p), err)
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:query: ", p), err)
 		}
 	}
 	return err
 }
 
-func (p *AggregateQueryResultTagNameElement) String() string {
-	if p == nil {
-		return "<nil>"
+func (p *AggregateMultiQueryRequest) writeField2(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeStart", thrift.I64, 2); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 2:rangeStart: ", p), err)
 	}
-	return fmt.Sprintf("AggregateQueryResultTagNameElement(%+v)", *p)
+	if err := oprot.WriteI64(int64(p.RangeStart)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeStart (2) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 2:rangeStart: ", p), err)
+	}
+	return err
 }
 
-// Attributes:
-//  - TagValue
-type AggregateQueryResultTagValueElement struct {
-	TagValue string `thrift:"tagValue,1,required" db:"tagValue" json:"tagValue"`
+func (p *AggregateMultiQueryRequest) writeField3(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("rangeEnd", thrift.I64, 3); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 3:rangeEnd: ", p), err)
+	}
+	if err := oprot.WriteI64(int64(p.RangeEnd)); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T.rangeEnd (3) field write error: ", p), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 3:rangeEnd: ", p), err)
+	}
+	return err
 }
 
-func NewAggregateQueryResultTagValueElement() *AggregateQueryResultTagValueElement {
-	return &AggregateQueryResultTagValueElement{}
+func (p *AggregateMultiQueryRequest) writeField4(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("nameSpaces", thrift.LIST, 4); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 4:nameSpaces: ", p), err)
+	}
+	if err := oprot.WriteListBegin(thrift.STRING, len(p.NameSpaces)); err != nil {
+		return thrift.PrependError("error writing list begin: ", err)
+	}
+	for _, v := range p.NameSpaces {
+		if err := oprot.WriteString(string(v)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
+		}
+	}
+	if err := oprot.WriteListEnd(); err != nil {
+		return thrift.PrependError("error writing list end: ", err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 4:nameSpaces: ", p), err)
+	}
+	return err
 }
 
-func (p *AggregateQueryResultTagValueElement) GetTagValue() string {
-	return p.TagValue
-}
-func (p *AggregateQueryResultTagValueElement) Read(iprot thrift.TProtocol) error {
-	if _, err := iprot.ReadStructBegin(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+func (p *AggregateMultiQueryRequest) writeField5(oprot thrift.TProtocol) (err error) {
+	if p.IsSetLimit() {
+		if err := oprot.WriteFieldBegin("limit", thrift.I64, 5); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 5:limit: ", p), err)
+		}
+		if err := oprot.WriteI64(int64(*p.Limit)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.limit (5) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 5:limit: ", p), err)
+		}
 	}
+	return err
+}
 
-	var issetTagValue bool = false
-
-	for {
-		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
-		if err != nil {
-			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+func (p *AggregateMultiQueryRequest) writeField6(oprot thrift.TProtocol) (err error) {
+	if p.IsSetTagNameFilter() {
+		if err := oprot.WriteFieldBegin("tagNameFilter", thrift.LIST, 6); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 6:tagNameFilter: ", p), err)
 		}
-		if fieldTypeId == thrift.STOP {
-			break
+		if err := oprot.WriteListBegin(thrift.STRING, len(p.TagNameFilter)); err != nil {
+			return thrift.PrependError("error writing list begin: ", err)
 		}
-		switch fieldId {
-		case 1:
-			if err := p.ReadField1(iprot); err != nil {
-				return err
-			}
-			issetTagValue = true
-		default:
-			if err := iprot.Skip(fieldTypeId); err != nil {
-				return err
+		for _, v := range p.TagNameFilter {
+			if err := oprot.WriteString(string(v)); err != nil {
+				return thrift.PrependError(fmt.Sprintf("%T. (0) field write error: ", p), err)
 			}
 		}
-		if err := iprot.ReadFieldEnd(); err != nil {
-			return err
+		if err := oprot.WriteListEnd(); err != nil {
+			return thrift.PrependError("error writing list end: ", err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 6:tagNameFilter: ", p), err)
 		}
 	}
-	if err := iprot.ReadStructEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
-	}
-	if !issetTagValue {
-		return thrift.NewTProtocolExceptionWithType(thrift.INVALID_DATA, fmt.Errorf("Required field TagValue is not set"))
-	}
-	return nil
-}
-
-func (p *AggregateQueryResultTagValueElement) ReadField1(iprot thrift.TProtocol) error {
-	if v, err := iprot.ReadString(); err != nil {
-		return thrift.PrependError("error reading field 1: ", err)
-	} else {
-		p.TagValue = v
-	}
-	return nil
+	return err
 }
 
-func (p *AggregateQueryResultTagValueElement) Write(oprot thrift.TProtocol) error {
-	if err := oprot.WriteStructBegin("AggregateQueryResultTagValueElement"); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
-	}
-	if p != nil {
-		if err := p.writeField1(oprot); err != nil {
-			return err
+func (p *AggregateMultiQueryRequest) writeField7(oprot thrift.TProtocol) (err error) {
+	if p.IsSetAggregateQueryType() {
+		if err := oprot.WriteFieldBegin("aggregateQueryType", thrift.I32, 7); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 7:aggregateQueryType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.AggregateQueryType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.aggregateQueryType (7) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 7:aggregateQueryType: ", p), err)
 		}
 	}
-	if err := oprot.WriteFieldStop(); err != nil {
-		return thrift.PrependError("write field stop error: ", err)
-	}
-	if err := oprot.WriteStructEnd(); err != nil {
-		return thrift.PrependError("write struct stop error: ", err)
-	}
-	return nil
+	return err
 }
 
-func (p *AggregateQueryResultTagValueElement) writeField1(oprot thrift.TProtocol) (err error) {
-	if err := oprot.WriteFieldBegin("tagValue", thrift.STRING, 1); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:tagValue: ", p), err)
-	}
-	if err := oprot.WriteString(string(p.TagValue)); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T.tagValue (1) field write error: ", p), err)
-	}
-	if err := oprot.WriteFieldEnd(); err != nil {
-		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:tagValue: ", p), err)
+func (p *AggregateMultiQueryRequest) writeField8(oprot thrift.TProtocol) (err error) {
+	if p.IsSetRangeType() {
+		if err := oprot.WriteFieldBegin("rangeType", thrift.I32, 8); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 8:rangeType: ", p), err)
+		}
+		if err := oprot.WriteI32(int32(p.RangeType)); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T.rangeType (8) field write error: ", p), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 8:rangeType: ", p), err)
+		}
 	}
 	return err
 }
 
-func (p *AggregateQueryResultTagValueElement) String() string {
+func (p *AggregateMultiQueryRequest) String() string {
 	if p == nil {
 		return "<nil>"
 	}
-	return fmt.Sprintf("AggregateQueryResultTagValueElement(%+v)", *p)
+	return fmt.Sprintf("AggregateMultiQueryRequest(%+v)", *p)
 }
 
 // Attributes:
@@ -12710,6 +15635,9 @@ type Node interface {
 	Aggregate(req *AggregateQueryRequest) (r *AggregateQueryResult_, err error)
 	// Parameters:
 	//  - Req
+	AggregateMulti(req *AggregateMultiQueryRequest) (r *AggregateQueryResult_, err error)
+	// Parameters:
+	//  - Req
 	Fetch(req *FetchRequest) (r *FetchResult_, err error)
 	// Parameters:
 	//  - Req
@@ -12734,13 +15662,13 @@ type Node interface {
 	FetchBlocksMetadataRawV2(req *FetchBlocksMetadataRawV2Request) (r *FetchBlocksMetadataRawV2Result_, err error)
 	// Parameters:
 	//  - Req
-	WriteBatchRaw(req *WriteBatchRawRequest) (err error)
+	WriteBatchRaw(req *WriteBatchRawRequest) (r *WriteBatchRawResult_, err error)
 	// Parameters:
 	//  - Req
 	WriteBatchRawV2(req *WriteBatchRawV2Request) (err error)
 	// Parameters:
 	//  - Req
-	WriteTaggedBatchRaw(req *WriteTaggedBatchRawRequest) (err error)
+	WriteTaggedBatchRaw(req *WriteTaggedBatchRawRequest) (r *WriteBatchRawResult_, err error)
 	// Parameters:
 	//  - Req
 	WriteTaggedBatchRawV2(req *WriteTaggedBatchRawV2Request) (err error)
@@ -12767,6 +15695,9 @@ type Node interface {
 	// Parameters:
 	//  - Req
 	SetWriteNewSeriesLimitPerShardPerSecond(req *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) (r *NodeWriteNewSeriesLimitPerShardPerSecondResult_, err error)
+	// Parameters:
+	//  - Req
+	ShardWatermarks(req *ShardWatermarksRequest) (r *ShardWatermarksResult_, err error)
 }
 
 type NodeClient struct {
@@ -13038,6 +15969,87 @@ func (p *NodeClient) recvAggregate() (value *AggregateQueryResult_, err error) {
 	return
 }
 
+// Parameters:
+//  - Req
+func (p *NodeClient) AggregateMulti(req *AggregateMultiQueryRequest) (r *AggregateQueryResult_, err error) {
+	if err = p.sendAggregateMulti(req); err != nil {
+		return
+	}
+	return p.recvAggregateMulti()
+}
+
+func (p *NodeClient) sendAggregateMulti(req *AggregateMultiQueryRequest) (err error) {
+	oprot := p.OutputProtocol
+	if oprot == nil {
+		oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.OutputProtocol = oprot
+	}
+	p.SeqId++
+	if err = oprot.WriteMessageBegin("aggregateMulti", thrift.CALL, p.SeqId); err != nil {
+		return
+	}
+	args := NodeAggregateMultiArgs{
+		Req: req,
+	}
+	if err = args.Write(oprot); err != nil {
+		return
+	}
+	if err = oprot.WriteMessageEnd(); err != nil {
+		return
+	}
+	return oprot.Flush()
+}
+
+func (p *NodeClient) recvAggregateMulti() (value *AggregateQueryResult_, err error) {
+	iprot := p.InputProtocol
+	if iprot == nil {
+		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.InputProtocol = iprot
+	}
+	method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return
+	}
+	if method != "aggregateMulti" {
+		err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "aggregateMulti failed: wrong method name")
+		return
+	}
+	if p.SeqId != seqId {
+		err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "aggregateMulti failed: out of sequence response")
+		return
+	}
+	if mTypeId == thrift.EXCEPTION {
+		error228 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error229 error
+		error229, err = error228.Read(iprot)
+		if err != nil {
+			return
+		}
+		if err = iprot.ReadMessageEnd(); err != nil {
+			return
+		}
+		err = error229
+		return
+	}
+	if mTypeId != thrift.REPLY {
+		err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "aggregateMulti failed: invalid message type")
+		return
+	}
+	result := NodeAggregateMultiResult{}
+	if err = result.Read(iprot); err != nil {
+		return
+	}
+	if err = iprot.ReadMessageEnd(); err != nil {
+		return
+	}
+	if result.Err != nil {
+		err = result.Err
+		return
+	}
+	value = result.GetSuccess()
+	return
+}
+
 // Parameters:
 //  - Req
 func (p *NodeClient) Fetch(req *FetchRequest) (r *FetchResult_, err error) {
@@ -13686,7 +16698,7 @@ func (p *NodeClient) recvFetchBlocksMetadataRawV2() (value *FetchBlocksMetadataR
 
 // Parameters:
 //  - Req
-func (p *NodeClient) WriteBatchRaw(req *WriteBatchRawRequest) (err error) {
+func (p *NodeClient) WriteBatchRaw(req *WriteBatchRawRequest) (r *WriteBatchRawResult_, err error) {
 	if err = p.sendWriteBatchRaw(req); err != nil {
 		return
 	}
@@ -13715,7 +16727,7 @@ func (p *NodeClient) sendWriteBatchRaw(req *WriteBatchRawRequest) (err error) {
 	return oprot.Flush()
 }
 
-func (p *NodeClient) recvWriteBatchRaw() (err error) {
+func (p *NodeClient) recvWriteBatchRaw() (value *WriteBatchRawResult_, err error) {
 	iprot := p.InputProtocol
 	if iprot == nil {
 		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
@@ -13761,6 +16773,7 @@ func (p *NodeClient) recvWriteBatchRaw() (err error) {
 		err = result.Err
 		return
 	}
+	value = result.GetSuccess()
 	return
 }
 
@@ -13846,7 +16859,7 @@ func (p *NodeClient) recvWriteBatchRawV2() (err error) {
 
 // Parameters:
 //  - Req
-func (p *NodeClient) WriteTaggedBatchRaw(req *WriteTaggedBatchRawRequest) (err error) {
+func (p *NodeClient) WriteTaggedBatchRaw(req *WriteTaggedBatchRawRequest) (r *WriteBatchRawResult_, err error) {
 	if err = p.sendWriteTaggedBatchRaw(req); err != nil {
 		return
 	}
@@ -13875,7 +16888,7 @@ func (p *NodeClient) sendWriteTaggedBatchRaw(req *WriteTaggedBatchRawRequest) (e
 	return oprot.Flush()
 }
 
-func (p *NodeClient) recvWriteTaggedBatchRaw() (err error) {
+func (p *NodeClient) recvWriteTaggedBatchRaw() (value *WriteBatchRawResult_, err error) {
 	iprot := p.InputProtocol
 	if iprot == nil {
 		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
@@ -13921,6 +16934,7 @@ func (p *NodeClient) recvWriteTaggedBatchRaw() (err error) {
 		err = result.Err
 		return
 	}
+	value = result.GetSuccess()
 	return
 }
 
@@ -15024,6 +18038,87 @@ func (p *NodeClient) recvSetWriteNewSeriesLimitPerShardPerSecond() (value *NodeW
 	return
 }
 
+// Parameters:
+//  - Req
+func (p *NodeClient) ShardWatermarks(req *ShardWatermarksRequest) (r *ShardWatermarksResult_, err error) {
+	if err = p.sendShardWatermarks(req); err != nil {
+		return
+	}
+	return p.recvShardWatermarks()
+}
+
+func (p *NodeClient) sendShardWatermarks(req *ShardWatermarksRequest) (err error) {
+	oprot := p.OutputProtocol
+	if oprot == nil {
+		oprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.OutputProtocol = oprot
+	}
+	p.SeqId++
+	if err = oprot.WriteMessageBegin("shardWatermarks", thrift.CALL, p.SeqId); err != nil {
+		return
+	}
+	args := NodeShardWatermarksArgs{
+		Req: req,
+	}
+	if err = args.Write(oprot); err != nil {
+		return
+	}
+	if err = oprot.WriteMessageEnd(); err != nil {
+		return
+	}
+	return oprot.Flush()
+}
+
+func (p *NodeClient) recvShardWatermarks() (value *ShardWatermarksResult_, err error) {
+	iprot := p.InputProtocol
+	if iprot == nil {
+		iprot = p.ProtocolFactory.GetProtocol(p.Transport)
+		p.InputProtocol = iprot
+	}
+	method, mTypeId, seqId, err := iprot.ReadMessageBegin()
+	if err != nil {
+		return
+	}
+	if method != "shardWatermarks" {
+		err = thrift.NewTApplicationException(thrift.WRONG_METHOD_NAME, "shardWatermarks failed: wrong method name")
+		return
+	}
+	if p.SeqId != seqId {
+		err = thrift.NewTApplicationException(thrift.BAD_SEQUENCE_ID, "shardWatermarks failed: out of sequence response")
+		return
+	}
+	if mTypeId == thrift.EXCEPTION {
+		error231 := thrift.NewTApplicationException(thrift.UNKNOWN_APPLICATION_EXCEPTION, "Unknown Exception")
+		var error232 error
+		error232, err = error231.Read(iprot)
+		if err != nil {
+			return
+		}
+		if err = iprot.ReadMessageEnd(); err != nil {
+			return
+		}
+		err = error232
+		return
+	}
+	if mTypeId != thrift.REPLY {
+		err = thrift.NewTApplicationException(thrift.INVALID_MESSAGE_TYPE_EXCEPTION, "shardWatermarks failed: invalid message type")
+		return
+	}
+	result := NodeShardWatermarksResult{}
+	if err = result.Read(iprot); err != nil {
+		return
+	}
+	if err = iprot.ReadMessageEnd(); err != nil {
+		return
+	}
+	if result.Err != nil {
+		err = result.Err
+		return
+	}
+	value = result.GetSuccess()
+	return
+}
+
 type NodeProcessor struct {
 	processorMap map[string]thrift.TProcessorFunction
 	handler      Node
@@ -15048,6 +18143,7 @@ func NewNodeProcessor(handler Node) *NodeProcessor {
 	self89.processorMap["query"] = &nodeProcessorQuery{handler: handler}
 	self89.processorMap["aggregateRaw"] = &nodeProcessorAggregateRaw{handler: handler}
 	self89.processorMap["aggregate"] = &nodeProcessorAggregate{handler: handler}
+	self89.processorMap["aggregateMulti"] = &nodeProcessorAggregateMulti{handler: handler}
 	self89.processorMap["fetch"] = &nodeProcessorFetch{handler: handler}
 	self89.processorMap["fetchTagged"] = &nodeProcessorFetchTagged{handler: handler}
 	self89.processorMap["write"] = &nodeProcessorWrite{handler: handler}
@@ -15073,6 +18169,7 @@ func NewNodeProcessor(handler Node) *NodeProcessor {
 	self89.processorMap["setWriteNewSeriesBackoffDuration"] = &nodeProcessorSetWriteNewSeriesBackoffDuration{handler: handler}
 	self89.processorMap["getWriteNewSeriesLimitPerShardPerSecond"] = &nodeProcessorGetWriteNewSeriesLimitPerShardPerSecond{handler: handler}
 	self89.processorMap["setWriteNewSeriesLimitPerShardPerSecond"] = &nodeProcessorSetWriteNewSeriesLimitPerShardPerSecond{handler: handler}
+	self89.processorMap["shardWatermarks"] = &nodeProcessorShardWatermarks{handler: handler}
 	return self89
 }
 
@@ -15254,6 +18351,59 @@ func (p *nodeProcessorAggregate) Process(seqId int32, iprot, oprot thrift.TProto
 	return true, err
 }
 
+type nodeProcessorAggregateMulti struct {
+	handler Node
+}
+
+func (p *nodeProcessorAggregateMulti) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	args := NodeAggregateMultiArgs{}
+	if err = args.Read(iprot); err != nil {
+		iprot.ReadMessageEnd()
+		x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+		oprot.WriteMessageBegin("aggregateMulti", thrift.EXCEPTION, seqId)
+		x.Write(oprot)
+		oprot.WriteMessageEnd()
+		oprot.Flush()
+		return false, err
+	}
+
+	iprot.ReadMessageEnd()
+	result := NodeAggregateMultiResult{}
+	var retval *AggregateQueryResult_
+	var err2 error
+	if retval, err2 = p.handler.AggregateMulti(args.Req); err2 != nil {
+		switch v := err2.(type) {
+		case *Error:
+			result.Err = v
+		default:
+			x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing aggregateMulti: "+err2.Error())
+			oprot.WriteMessageBegin("aggregateMulti", thrift.EXCEPTION, seqId)
+			x.Write(oprot)
+			oprot.WriteMessageEnd()
+			oprot.Flush()
+			return true, err2
+		}
+	} else {
+		result.Success = retval
+	}
+	if err2 = oprot.WriteMessageBegin("aggregateMulti", thrift.REPLY, seqId); err2 != nil {
+		err = err2
+	}
+	if err2 = result.Write(oprot); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.Flush(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err != nil {
+		return
+	}
+	return true, err
+}
+
 type nodeProcessorFetch struct {
 	handler Node
 }
@@ -15690,8 +18840,9 @@ func (p *nodeProcessorWriteBatchRaw) Process(seqId int32, iprot, oprot thrift.TP
 
 	iprot.ReadMessageEnd()
 	result := NodeWriteBatchRawResult{}
+	var retval *WriteBatchRawResult_
 	var err2 error
-	if err2 = p.handler.WriteBatchRaw(args.Req); err2 != nil {
+	if retval, err2 = p.handler.WriteBatchRaw(args.Req); err2 != nil {
 		switch v := err2.(type) {
 		case *WriteBatchRawErrors:
 			result.Err = v
@@ -15703,6 +18854,8 @@ func (p *nodeProcessorWriteBatchRaw) Process(seqId int32, iprot, oprot thrift.TP
 			oprot.Flush()
 			return true, err2
 		}
+	} else {
+		result.Success = retval
 	}
 	if err2 = oprot.WriteMessageBegin("writeBatchRaw", thrift.REPLY, seqId); err2 != nil {
 		err = err2
@@ -15790,8 +18943,9 @@ func (p *nodeProcessorWriteTaggedBatchRaw) Process(seqId int32, iprot, oprot thr
 
 	iprot.ReadMessageEnd()
 	result := NodeWriteTaggedBatchRawResult{}
+	var retval *WriteBatchRawResult_
 	var err2 error
-	if err2 = p.handler.WriteTaggedBatchRaw(args.Req); err2 != nil {
+	if retval, err2 = p.handler.WriteTaggedBatchRaw(args.Req); err2 != nil {
 		switch v := err2.(type) {
 		case *WriteBatchRawErrors:
 			result.Err = v
@@ -15803,6 +18957,8 @@ func (p *nodeProcessorWriteTaggedBatchRaw) Process(seqId int32, iprot, oprot thr
 			oprot.Flush()
 			return true, err2
 		}
+	} else {
+		result.Success = retval
 	}
 	if err2 = oprot.WriteMessageBegin("writeTaggedBatchRaw", thrift.REPLY, seqId); err2 != nil {
 		err = err2
@@ -16540,7 +19696,60 @@ func (p *nodeProcessorSetWriteNewSeriesLimitPerShardPerSecond) Process(seqId int
 	} else {
 		result.Success = retval
 	}
-	if err2 = oprot.WriteMessageBegin("setWriteNewSeriesLimitPerShardPerSecond", thrift.REPLY, seqId); err2 != nil {
+	if err2 = oprot.WriteMessageBegin("setWriteNewSeriesLimitPerShardPerSecond", thrift.REPLY, seqId); err2 != nil {
+		err = err2
+	}
+	if err2 = result.Write(oprot); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.WriteMessageEnd(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err2 = oprot.Flush(); err == nil && err2 != nil {
+		err = err2
+	}
+	if err != nil {
+		return
+	}
+	return true, err
+}
+
+type nodeProcessorShardWatermarks struct {
+	handler Node
+}
+
+func (p *nodeProcessorShardWatermarks) Process(seqId int32, iprot, oprot thrift.TProtocol) (success bool, err thrift.TException) {
+	args := NodeShardWatermarksArgs{}
+	if err = args.Read(iprot); err != nil {
+		iprot.ReadMessageEnd()
+		x := thrift.NewTApplicationException(thrift.PROTOCOL_ERROR, err.Error())
+		oprot.WriteMessageBegin("shardWatermarks", thrift.EXCEPTION, seqId)
+		x.Write(oprot)
+		oprot.WriteMessageEnd()
+		oprot.Flush()
+		return false, err
+	}
+
+	iprot.ReadMessageEnd()
+	result := NodeShardWatermarksResult{}
+	var retval *ShardWatermarksResult_
+	var err2 error
+	if retval, err2 = p.handler.ShardWatermarks(args.Req); err2 != nil {
+		switch v := err2.(type) {
+		case *Error:
+			result.Err = v
+		default:
+			x := thrift.NewTApplicationException(thrift.INTERNAL_ERROR, "Internal error processing shardWatermarks: "+err2.Error())
+			oprot.WriteMessageBegin("shardWatermarks", thrift.EXCEPTION, seqId)
+			x.Write(oprot)
+			oprot.WriteMessageEnd()
+			oprot.Flush()
+			return true, err2
+		}
+	} else {
+		result.Success = retval
+	}
+	if err2 = oprot.WriteMessageBegin("shardWatermarks", thrift.REPLY, seqId); err2 != nil {
 		err = err2
 	}
 	if err2 = result.Write(oprot); err == nil && err2 != nil {
@@ -17325,6 +20534,261 @@ func (p *NodeAggregateResult) String() string {
 	return fmt.Sprintf("NodeAggregateResult(%+v)", *p)
 }
 
+// Attributes:
+//  - Req
+type NodeAggregateMultiArgs struct {
+	Req *AggregateMultiQueryRequest `thrift:"req,1" db:"req" json:"req"`
+}
+
+func NewNodeAggregateMultiArgs() *NodeAggregateMultiArgs {
+	return &NodeAggregateMultiArgs{}
+}
+
+var NodeAggregateMultiArgs_Req_DEFAULT *AggregateMultiQueryRequest
+
+func (p *NodeAggregateMultiArgs) GetReq() *AggregateMultiQueryRequest {
+	if !p.IsSetReq() {
+		return NodeAggregateMultiArgs_Req_DEFAULT
+	}
+	return p.Req
+}
+func (p *NodeAggregateMultiArgs) IsSetReq() bool {
+	return p.Req != nil
+}
+
+func (p *NodeAggregateMultiArgs) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiArgs) ReadField1(iprot thrift.TProtocol) error {
+	p.Req = &AggregateMultiQueryRequest{
+		AggregateQueryType: 1,
+
+		RangeType: 0,
+	}
+	if err := p.Req.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Req), err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiArgs) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("aggregate_args"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiArgs) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("req", thrift.STRUCT, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:req: ", p), err)
+	}
+	if err := p.Req.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Req), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:req: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeAggregateMultiArgs) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeAggregateMultiArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - Err
+type NodeAggregateMultiResult struct {
+	Success *AggregateQueryResult_ `thrift:"success,0" db:"success" json:"success,omitempty"`
+	Err     *Error                 `thrift:"err,1" db:"err" json:"err,omitempty"`
+}
+
+func NewNodeAggregateMultiResult() *NodeAggregateMultiResult {
+	return &NodeAggregateMultiResult{}
+}
+
+var NodeAggregateMultiResult_Success_DEFAULT *AggregateQueryResult_
+
+func (p *NodeAggregateMultiResult) GetSuccess() *AggregateQueryResult_ {
+	if !p.IsSetSuccess() {
+		return NodeAggregateMultiResult_Success_DEFAULT
+	}
+	return p.Success
+}
+
+var NodeAggregateMultiResult_Err_DEFAULT *Error
+
+func (p *NodeAggregateMultiResult) GetErr() *Error {
+	if !p.IsSetErr() {
+		return NodeAggregateMultiResult_Err_DEFAULT
+	}
+	return p.Err
+}
+func (p *NodeAggregateMultiResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+
+func (p *NodeAggregateMultiResult) IsSetErr() bool {
+	return p.Err != nil
+}
+
+func (p *NodeAggregateMultiResult) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 0:
+			if err := p.ReadField0(iprot); err != nil {
+				return err
+			}
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiResult) ReadField0(iprot thrift.TProtocol) error {
+	p.Success = &AggregateQueryResult_{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiResult) ReadField1(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiResult) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("aggregate_result"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField0(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeAggregateMultiResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeAggregateMultiResult) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeAggregateMultiResult) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeAggregateMultiResult(%+v)", *p)
+}
+
 // Attributes:
 //  - Req
 type NodeFetchArgs struct {
@@ -19353,15 +22817,26 @@ func (p *NodeWriteBatchRawArgs) String() string {
 }
 
 // Attributes:
+//  - Success
 //  - Err
 type NodeWriteBatchRawResult struct {
-	Err *WriteBatchRawErrors `thrift:"err,1" db:"err" json:"err,omitempty"`
+	Success *WriteBatchRawResult_ `thrift:"success,0" db:"success" json:"success,omitempty"`
+	Err     *WriteBatchRawErrors  `thrift:"err,1" db:"err" json:"err,omitempty"`
 }
 
 func NewNodeWriteBatchRawResult() *NodeWriteBatchRawResult {
 	return &NodeWriteBatchRawResult{}
 }
 
+var NodeWriteBatchRawResult_Success_DEFAULT *WriteBatchRawResult_
+
+func (p *NodeWriteBatchRawResult) GetSuccess() *WriteBatchRawResult_ {
+	if !p.IsSetSuccess() {
+		return NodeWriteBatchRawResult_Success_DEFAULT
+	}
+	return p.Success
+}
+
 var NodeWriteBatchRawResult_Err_DEFAULT *WriteBatchRawErrors
 
 func (p *NodeWriteBatchRawResult) GetErr() *WriteBatchRawErrors {
@@ -19370,6 +22845,10 @@ func (p *NodeWriteBatchRawResult) GetErr() *WriteBatchRawErrors {
 	}
 	return p.Err
 }
+func (p *NodeWriteBatchRawResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+
 func (p *NodeWriteBatchRawResult) IsSetErr() bool {
 	return p.Err != nil
 }
@@ -19388,6 +22867,10 @@ func (p *NodeWriteBatchRawResult) Read(iprot thrift.TProtocol) error {
 			break
 		}
 		switch fieldId {
+		case 0:
+			if err := p.ReadField0(iprot); err != nil {
+				return err
+			}
 		case 1:
 			if err := p.ReadField1(iprot); err != nil {
 				return err
@@ -19407,6 +22890,14 @@ func (p *NodeWriteBatchRawResult) Read(iprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *NodeWriteBatchRawResult) ReadField0(iprot thrift.TProtocol) error {
+	p.Success = &WriteBatchRawResult_{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
 func (p *NodeWriteBatchRawResult) ReadField1(iprot thrift.TProtocol) error {
 	p.Err = &WriteBatchRawErrors{}
 	if err := p.Err.Read(iprot); err != nil {
@@ -19420,6 +22911,9 @@ func (p *NodeWriteBatchRawResult) Write(oprot thrift.TProtocol) error {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
+		if err := p.writeField0(oprot); err != nil {
+			return err
+		}
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
@@ -19430,7 +22924,22 @@ func (p *NodeWriteBatchRawResult) Write(oprot thrift.TProtocol) error {
 	if err := oprot.WriteStructEnd(); err != nil {
 		return thrift.PrependError("write struct stop error: ", err)
 	}
-	return nil
+	return nil
+}
+
+func (p *NodeWriteBatchRawResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
 }
 
 func (p *NodeWriteBatchRawResult) writeField1(oprot thrift.TProtocol) (err error) {
@@ -19761,15 +23270,26 @@ func (p *NodeWriteTaggedBatchRawArgs) String() string {
 }
 
 // Attributes:
+//  - Success
 //  - Err
 type NodeWriteTaggedBatchRawResult struct {
-	Err *WriteBatchRawErrors `thrift:"err,1" db:"err" json:"err,omitempty"`
+	Success *WriteBatchRawResult_ `thrift:"success,0" db:"success" json:"success,omitempty"`
+	Err     *WriteBatchRawErrors  `thrift:"err,1" db:"err" json:"err,omitempty"`
 }
 
 func NewNodeWriteTaggedBatchRawResult() *NodeWriteTaggedBatchRawResult {
 	return &NodeWriteTaggedBatchRawResult{}
 }
 
+var NodeWriteTaggedBatchRawResult_Success_DEFAULT *WriteBatchRawResult_
+
+func (p *NodeWriteTaggedBatchRawResult) GetSuccess() *WriteBatchRawResult_ {
+	if !p.IsSetSuccess() {
+		return NodeWriteTaggedBatchRawResult_Success_DEFAULT
+	}
+	return p.Success
+}
+
 var NodeWriteTaggedBatchRawResult_Err_DEFAULT *WriteBatchRawErrors
 
 func (p *NodeWriteTaggedBatchRawResult) GetErr() *WriteBatchRawErrors {
@@ -19778,6 +23298,10 @@ func (p *NodeWriteTaggedBatchRawResult) GetErr() *WriteBatchRawErrors {
 	}
 	return p.Err
 }
+func (p *NodeWriteTaggedBatchRawResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+
 func (p *NodeWriteTaggedBatchRawResult) IsSetErr() bool {
 	return p.Err != nil
 }
@@ -19796,6 +23320,10 @@ func (p *NodeWriteTaggedBatchRawResult) Read(iprot thrift.TProtocol) error {
 			break
 		}
 		switch fieldId {
+		case 0:
+			if err := p.ReadField0(iprot); err != nil {
+				return err
+			}
 		case 1:
 			if err := p.ReadField1(iprot); err != nil {
 				return err
@@ -19815,6 +23343,14 @@ func (p *NodeWriteTaggedBatchRawResult) Read(iprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *NodeWriteTaggedBatchRawResult) ReadField0(iprot thrift.TProtocol) error {
+	p.Success = &WriteBatchRawResult_{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
 func (p *NodeWriteTaggedBatchRawResult) ReadField1(iprot thrift.TProtocol) error {
 	p.Err = &WriteBatchRawErrors{}
 	if err := p.Err.Read(iprot); err != nil {
@@ -19828,6 +23364,9 @@ func (p *NodeWriteTaggedBatchRawResult) Write(oprot thrift.TProtocol) error {
 		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
 	}
 	if p != nil {
+		if err := p.writeField0(oprot); err != nil {
+			return err
+		}
 		if err := p.writeField1(oprot); err != nil {
 			return err
 		}
@@ -19841,6 +23380,21 @@ func (p *NodeWriteTaggedBatchRawResult) Write(oprot thrift.TProtocol) error {
 	return nil
 }
 
+func (p *NodeWriteTaggedBatchRawResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
+}
+
 func (p *NodeWriteTaggedBatchRawResult) writeField1(oprot thrift.TProtocol) (err error) {
 	if p.IsSetErr() {
 		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
@@ -22919,6 +26473,257 @@ func (p *NodeSetWriteNewSeriesLimitPerShardPerSecondResult) String() string {
 	return fmt.Sprintf("NodeSetWriteNewSeriesLimitPerShardPerSecondResult(%+v)", *p)
 }
 
+// Attributes:
+//  - Req
+type NodeShardWatermarksArgs struct {
+	Req *ShardWatermarksRequest `thrift:"req,1" db:"req" json:"req"`
+}
+
+func NewNodeShardWatermarksArgs() *NodeShardWatermarksArgs {
+	return &NodeShardWatermarksArgs{}
+}
+
+var NodeShardWatermarksArgs_Req_DEFAULT *ShardWatermarksRequest
+
+func (p *NodeShardWatermarksArgs) GetReq() *ShardWatermarksRequest {
+	if !p.IsSetReq() {
+		return NodeShardWatermarksArgs_Req_DEFAULT
+	}
+	return p.Req
+}
+func (p *NodeShardWatermarksArgs) IsSetReq() bool {
+	return p.Req != nil
+}
+
+func (p *NodeShardWatermarksArgs) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksArgs) ReadField1(iprot thrift.TProtocol) error {
+	p.Req = &ShardWatermarksRequest{}
+	if err := p.Req.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Req), err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksArgs) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("shardWatermarks_args"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksArgs) writeField1(oprot thrift.TProtocol) (err error) {
+	if err := oprot.WriteFieldBegin("req", thrift.STRUCT, 1); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:req: ", p), err)
+	}
+	if err := p.Req.Write(oprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Req), err)
+	}
+	if err := oprot.WriteFieldEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write field end error 1:req: ", p), err)
+	}
+	return err
+}
+
+func (p *NodeShardWatermarksArgs) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeShardWatermarksArgs(%+v)", *p)
+}
+
+// Attributes:
+//  - Success
+//  - Err
+type NodeShardWatermarksResult struct {
+	Success *ShardWatermarksResult_ `thrift:"success,0" db:"success" json:"success,omitempty"`
+	Err     *Error                  `thrift:"err,1" db:"err" json:"err,omitempty"`
+}
+
+func NewNodeShardWatermarksResult() *NodeShardWatermarksResult {
+	return &NodeShardWatermarksResult{}
+}
+
+var NodeShardWatermarksResult_Success_DEFAULT *ShardWatermarksResult_
+
+func (p *NodeShardWatermarksResult) GetSuccess() *ShardWatermarksResult_ {
+	if !p.IsSetSuccess() {
+		return NodeShardWatermarksResult_Success_DEFAULT
+	}
+	return p.Success
+}
+
+var NodeShardWatermarksResult_Err_DEFAULT *Error
+
+func (p *NodeShardWatermarksResult) GetErr() *Error {
+	if !p.IsSetErr() {
+		return NodeShardWatermarksResult_Err_DEFAULT
+	}
+	return p.Err
+}
+func (p *NodeShardWatermarksResult) IsSetSuccess() bool {
+	return p.Success != nil
+}
+
+func (p *NodeShardWatermarksResult) IsSetErr() bool {
+	return p.Err != nil
+}
+
+func (p *NodeShardWatermarksResult) Read(iprot thrift.TProtocol) error {
+	if _, err := iprot.ReadStructBegin(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read error: ", p), err)
+	}
+
+	for {
+		_, fieldTypeId, fieldId, err := iprot.ReadFieldBegin()
+		if err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T field %d read error: ", p, fieldId), err)
+		}
+		if fieldTypeId == thrift.STOP {
+			break
+		}
+		switch fieldId {
+		case 0:
+			if err := p.ReadField0(iprot); err != nil {
+				return err
+			}
+		case 1:
+			if err := p.ReadField1(iprot); err != nil {
+				return err
+			}
+		default:
+			if err := iprot.Skip(fieldTypeId); err != nil {
+				return err
+			}
+		}
+		if err := iprot.ReadFieldEnd(); err != nil {
+			return err
+		}
+	}
+	if err := iprot.ReadStructEnd(); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T read struct end error: ", p), err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksResult) ReadField0(iprot thrift.TProtocol) error {
+	p.Success = &ShardWatermarksResult_{}
+	if err := p.Success.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Success), err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksResult) ReadField1(iprot thrift.TProtocol) error {
+	p.Err = &Error{
+		Type: 0,
+	}
+	if err := p.Err.Read(iprot); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T error reading struct: ", p.Err), err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksResult) Write(oprot thrift.TProtocol) error {
+	if err := oprot.WriteStructBegin("shardWatermarks_result"); err != nil {
+		return thrift.PrependError(fmt.Sprintf("%T write struct begin error: ", p), err)
+	}
+	if p != nil {
+		if err := p.writeField0(oprot); err != nil {
+			return err
+		}
+		if err := p.writeField1(oprot); err != nil {
+			return err
+		}
+	}
+	if err := oprot.WriteFieldStop(); err != nil {
+		return thrift.PrependError("write field stop error: ", err)
+	}
+	if err := oprot.WriteStructEnd(); err != nil {
+		return thrift.PrependError("write struct stop error: ", err)
+	}
+	return nil
+}
+
+func (p *NodeShardWatermarksResult) writeField0(oprot thrift.TProtocol) (err error) {
+	if p.IsSetSuccess() {
+		if err := oprot.WriteFieldBegin("success", thrift.STRUCT, 0); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 0:success: ", p), err)
+		}
+		if err := p.Success.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Success), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 0:success: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeShardWatermarksResult) writeField1(oprot thrift.TProtocol) (err error) {
+	if p.IsSetErr() {
+		if err := oprot.WriteFieldBegin("err", thrift.STRUCT, 1); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field begin error 1:err: ", p), err)
+		}
+		if err := p.Err.Write(oprot); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T error writing struct: ", p.Err), err)
+		}
+		if err := oprot.WriteFieldEnd(); err != nil {
+			return thrift.PrependError(fmt.Sprintf("%T write field end error 1:err: ", p), err)
+		}
+	}
+	return err
+}
+
+func (p *NodeShardWatermarksResult) String() string {
+	if p == nil {
+		return "<nil>"
+	}
+	return fmt.Sprintf("NodeShardWatermarksResult(%+v)", *p)
+}
+
 type Cluster interface {
 	Health() (r *HealthResult_, err error)
 	// Parameters:
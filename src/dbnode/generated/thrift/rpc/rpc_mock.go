@@ -195,6 +195,21 @@ func (mr *MockTChanNodeMockRecorder) Aggregate(ctx, req interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Aggregate", reflect.TypeOf((*MockTChanNode)(nil).Aggregate), ctx, req)
 }
 
+// AggregateMulti mocks base method
+func (m *MockTChanNode) AggregateMulti(ctx thrift.Context, req *AggregateMultiQueryRequest) (*AggregateQueryResult_, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AggregateMulti", ctx, req)
+	ret0, _ := ret[0].(*AggregateQueryResult_)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AggregateMulti indicates an expected call of AggregateMulti
+func (mr *MockTChanNodeMockRecorder) AggregateMulti(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AggregateMulti", reflect.TypeOf((*MockTChanNode)(nil).AggregateMulti), ctx, req)
+}
+
 // AggregateRaw mocks base method
 func (m *MockTChanNode) AggregateRaw(ctx thrift.Context, req *AggregateQueryRawRequest) (*AggregateQueryRawResult_, error) {
 	m.ctrl.T.Helper()
@@ -494,6 +509,21 @@ func (mr *MockTChanNodeMockRecorder) SetWriteNewSeriesLimitPerShardPerSecond(ctx
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteNewSeriesLimitPerShardPerSecond", reflect.TypeOf((*MockTChanNode)(nil).SetWriteNewSeriesLimitPerShardPerSecond), ctx, req)
 }
 
+// ShardWatermarks mocks base method
+func (m *MockTChanNode) ShardWatermarks(ctx thrift.Context, req *ShardWatermarksRequest) (*ShardWatermarksResult_, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardWatermarks", ctx, req)
+	ret0, _ := ret[0].(*ShardWatermarksResult_)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardWatermarks indicates an expected call of ShardWatermarks
+func (mr *MockTChanNodeMockRecorder) ShardWatermarks(ctx, req interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardWatermarks", reflect.TypeOf((*MockTChanNode)(nil).ShardWatermarks), ctx, req)
+}
+
 // Truncate mocks base method
 func (m *MockTChanNode) Truncate(ctx thrift.Context, req *TruncateRequest) (*TruncateResult_, error) {
 	m.ctrl.T.Helper()
@@ -524,11 +554,12 @@ func (mr *MockTChanNodeMockRecorder) Write(ctx, req interface{}) *gomock.Call {
 }
 
 // WriteBatchRaw mocks base method
-func (m *MockTChanNode) WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) error {
+func (m *MockTChanNode) WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) (*WriteBatchRawResult_, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "WriteBatchRaw", ctx, req)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*WriteBatchRawResult_)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // WriteBatchRaw indicates an expected call of WriteBatchRaw
@@ -566,11 +597,12 @@ func (mr *MockTChanNodeMockRecorder) WriteTagged(ctx, req interface{}) *gomock.C
 }
 
 // WriteTaggedBatchRaw mocks base method
-func (m *MockTChanNode) WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTaggedBatchRawRequest) error {
+func (m *MockTChanNode) WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTaggedBatchRawRequest) (*WriteBatchRawResult_, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "WriteTaggedBatchRaw", ctx, req)
-	ret0, _ := ret[0].(error)
-	return ret0
+	ret0, _ := ret[0].(*WriteBatchRawResult_)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // WriteTaggedBatchRaw indicates an expected call of WriteTaggedBatchRaw
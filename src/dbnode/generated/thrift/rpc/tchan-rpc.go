@@ -46,6 +46,7 @@ type TChanCluster interface {
 // TChanNode is the interface that defines the server handler and client interface.
 type TChanNode interface {
 	Aggregate(ctx thrift.Context, req *AggregateQueryRequest) (*AggregateQueryResult_, error)
+	AggregateMulti(ctx thrift.Context, req *AggregateMultiQueryRequest) (*AggregateQueryResult_, error)
 	AggregateRaw(ctx thrift.Context, req *AggregateQueryRawRequest) (*AggregateQueryRawResult_, error)
 	Bootstrapped(ctx thrift.Context) (*NodeBootstrappedResult_, error)
 	BootstrappedInPlacementOrNoPlacement(ctx thrift.Context) (*NodeBootstrappedInPlacementOrNoPlacementResult_, error)
@@ -66,12 +67,13 @@ type TChanNode interface {
 	SetWriteNewSeriesAsync(ctx thrift.Context, req *NodeSetWriteNewSeriesAsyncRequest) (*NodeWriteNewSeriesAsyncResult_, error)
 	SetWriteNewSeriesBackoffDuration(ctx thrift.Context, req *NodeSetWriteNewSeriesBackoffDurationRequest) (*NodeWriteNewSeriesBackoffDurationResult_, error)
 	SetWriteNewSeriesLimitPerShardPerSecond(ctx thrift.Context, req *NodeSetWriteNewSeriesLimitPerShardPerSecondRequest) (*NodeWriteNewSeriesLimitPerShardPerSecondResult_, error)
+	ShardWatermarks(ctx thrift.Context, req *ShardWatermarksRequest) (*ShardWatermarksResult_, error)
 	Truncate(ctx thrift.Context, req *TruncateRequest) (*TruncateResult_, error)
 	Write(ctx thrift.Context, req *WriteRequest) error
-	WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) error
+	WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) (*WriteBatchRawResult_, error)
 	WriteBatchRawV2(ctx thrift.Context, req *WriteBatchRawV2Request) error
 	WriteTagged(ctx thrift.Context, req *WriteTaggedRequest) error
-	WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTaggedBatchRawRequest) error
+	WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTaggedBatchRawRequest) (*WriteBatchRawResult_, error)
 	WriteTaggedBatchRawV2(ctx thrift.Context, req *WriteTaggedBatchRawV2Request) error
 }
 
@@ -497,6 +499,24 @@ func (c *tchanNodeClient) Aggregate(ctx thrift.Context, req *AggregateQueryReque
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) AggregateMulti(ctx thrift.Context, req *AggregateMultiQueryRequest) (*AggregateQueryResult_, error) {
+	var resp NodeAggregateMultiResult
+	args := NodeAggregateMultiArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "aggregateMulti", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for aggregateMulti")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) AggregateRaw(ctx thrift.Context, req *AggregateQueryRawRequest) (*AggregateQueryRawResult_, error) {
 	var resp NodeAggregateRawResult
 	args := NodeAggregateRawArgs{
@@ -841,6 +861,24 @@ func (c *tchanNodeClient) SetWriteNewSeriesLimitPerShardPerSecond(ctx thrift.Con
 	return resp.GetSuccess(), err
 }
 
+func (c *tchanNodeClient) ShardWatermarks(ctx thrift.Context, req *ShardWatermarksRequest) (*ShardWatermarksResult_, error) {
+	var resp NodeShardWatermarksResult
+	args := NodeShardWatermarksArgs{
+		Req: req,
+	}
+	success, err := c.client.Call(ctx, c.thriftService, "shardWatermarks", &args, &resp)
+	if err == nil && !success {
+		switch {
+		case resp.Err != nil:
+			err = resp.Err
+		default:
+			err = fmt.Errorf("received no result or unknown exception for shardWatermarks")
+		}
+	}
+
+	return resp.GetSuccess(), err
+}
+
 func (c *tchanNodeClient) Truncate(ctx thrift.Context, req *TruncateRequest) (*TruncateResult_, error) {
 	var resp NodeTruncateResult
 	args := NodeTruncateArgs{
@@ -877,7 +915,7 @@ func (c *tchanNodeClient) Write(ctx thrift.Context, req *WriteRequest) error {
 	return err
 }
 
-func (c *tchanNodeClient) WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) error {
+func (c *tchanNodeClient) WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRequest) (*WriteBatchRawResult_, error) {
 	var resp NodeWriteBatchRawResult
 	args := NodeWriteBatchRawArgs{
 		Req: req,
@@ -892,7 +930,7 @@ func (c *tchanNodeClient) WriteBatchRaw(ctx thrift.Context, req *WriteBatchRawRe
 		}
 	}
 
-	return err
+	return resp.GetSuccess(), err
 }
 
 func (c *tchanNodeClient) WriteBatchRawV2(ctx thrift.Context, req *WriteBatchRawV2Request) error {
@@ -931,7 +969,7 @@ func (c *tchanNodeClient) WriteTagged(ctx thrift.Context, req *WriteTaggedReques
 	return err
 }
 
-func (c *tchanNodeClient) WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTaggedBatchRawRequest) error {
+func (c *tchanNodeClient) WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTaggedBatchRawRequest) (*WriteBatchRawResult_, error) {
 	var resp NodeWriteTaggedBatchRawResult
 	args := NodeWriteTaggedBatchRawArgs{
 		Req: req,
@@ -946,7 +984,7 @@ func (c *tchanNodeClient) WriteTaggedBatchRaw(ctx thrift.Context, req *WriteTagg
 		}
 	}
 
-	return err
+	return resp.GetSuccess(), err
 }
 
 func (c *tchanNodeClient) WriteTaggedBatchRawV2(ctx thrift.Context, req *WriteTaggedBatchRawV2Request) error {
@@ -986,6 +1024,7 @@ func (s *tchanNodeServer) Service() string {
 func (s *tchanNodeServer) Methods() []string {
 	return []string{
 		"aggregate",
+		"aggregateMulti",
 		"aggregateRaw",
 		"bootstrapped",
 		"bootstrappedInPlacementOrNoPlacement",
@@ -1006,6 +1045,7 @@ func (s *tchanNodeServer) Methods() []string {
 		"setWriteNewSeriesAsync",
 		"setWriteNewSeriesBackoffDuration",
 		"setWriteNewSeriesLimitPerShardPerSecond",
+		"shardWatermarks",
 		"truncate",
 		"write",
 		"writeBatchRaw",
@@ -1020,6 +1060,8 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 	switch methodName {
 	case "aggregate":
 		return s.handleAggregate(ctx, protocol)
+	case "aggregateMulti":
+		return s.handleAggregateMulti(ctx, protocol)
 	case "aggregateRaw":
 		return s.handleAggregateRaw(ctx, protocol)
 	case "bootstrapped":
@@ -1060,6 +1102,8 @@ func (s *tchanNodeServer) Handle(ctx thrift.Context, methodName string, protocol
 		return s.handleSetWriteNewSeriesBackoffDuration(ctx, protocol)
 	case "setWriteNewSeriesLimitPerShardPerSecond":
 		return s.handleSetWriteNewSeriesLimitPerShardPerSecond(ctx, protocol)
+	case "shardWatermarks":
+		return s.handleShardWatermarks(ctx, protocol)
 	case "truncate":
 		return s.handleTruncate(ctx, protocol)
 	case "write":
@@ -1108,6 +1152,34 @@ func (s *tchanNodeServer) handleAggregate(ctx thrift.Context, protocol athrift.T
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleAggregateMulti(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeAggregateMultiArgs
+	var res NodeAggregateMultiResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.AggregateMulti(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleAggregateRaw(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeAggregateRawArgs
 	var res NodeAggregateRawResult
@@ -1667,6 +1739,34 @@ func (s *tchanNodeServer) handleSetWriteNewSeriesLimitPerShardPerSecond(ctx thri
 	return err == nil, &res, nil
 }
 
+func (s *tchanNodeServer) handleShardWatermarks(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
+	var req NodeShardWatermarksArgs
+	var res NodeShardWatermarksResult
+
+	if err := req.Read(protocol); err != nil {
+		return false, nil, err
+	}
+
+	r, err :=
+		s.handler.ShardWatermarks(ctx, req.Req)
+
+	if err != nil {
+		switch v := err.(type) {
+		case *Error:
+			if v == nil {
+				return false, nil, fmt.Errorf("Handler for err returned non-nil error type *Error but nil value")
+			}
+			res.Err = v
+		default:
+			return false, nil, err
+		}
+	} else {
+		res.Success = r
+	}
+
+	return err == nil, &res, nil
+}
+
 func (s *tchanNodeServer) handleTruncate(ctx thrift.Context, protocol athrift.TProtocol) (bool, athrift.TStruct, error) {
 	var req NodeTruncateArgs
 	var res NodeTruncateResult
@@ -1730,7 +1830,7 @@ func (s *tchanNodeServer) handleWriteBatchRaw(ctx thrift.Context, protocol athri
 		return false, nil, err
 	}
 
-	err :=
+	r, err :=
 		s.handler.WriteBatchRaw(ctx, req.Req)
 
 	if err != nil {
@@ -1744,6 +1844,7 @@ func (s *tchanNodeServer) handleWriteBatchRaw(ctx thrift.Context, protocol athri
 			return false, nil, err
 		}
 	} else {
+		res.Success = r
 	}
 
 	return err == nil, &res, nil
@@ -1811,7 +1912,7 @@ func (s *tchanNodeServer) handleWriteTaggedBatchRaw(ctx thrift.Context, protocol
 		return false, nil, err
 	}
 
-	err :=
+	r, err :=
 		s.handler.WriteTaggedBatchRaw(ctx, req.Req)
 
 	if err != nil {
@@ -1825,6 +1926,7 @@ func (s *tchanNodeServer) handleWriteTaggedBatchRaw(ctx thrift.Context, protocol
 			return false, nil, err
 		}
 	} else {
+		res.Success = r
 	}
 
 	return err == nil, &res, nil
@@ -269,6 +269,7 @@ type m3ClusterService struct {
 	instances   []services.ServiceInstance
 	replication services.ServiceReplication
 	sharding    services.ServiceSharding
+	version     int
 }
 
 func (s *m3ClusterService) Instance(
@@ -328,3 +329,16 @@ func (s *m3ClusterService) SetSharding(
 	s.sharding = ss
 	return s
 }
+
+func (s *m3ClusterService) Version() int {
+	s.RLock()
+	defer s.RUnlock()
+	return s.version
+}
+
+func (s *m3ClusterService) SetVersion(v int) services.Service {
+	s.Lock()
+	defer s.Unlock()
+	s.version = v
+	return s
+}
@@ -0,0 +1,380 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package loadgen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	// maxLatencySamples is the size of the reservoir of latencies kept per
+	// operation type to compute percentiles from.
+	maxLatencySamples = 8192
+
+	nameTag       = "__name__"
+	indexTag      = "index"
+	generationTag = "generation"
+)
+
+var (
+	errGeneratorAlreadyRun = errors.New("load generator has already been run")
+)
+
+type generator struct {
+	sync.Mutex
+
+	session     client.Session
+	opts        Options
+	log         *zap.Logger
+	nowFn       func() time.Time
+	generations []int64
+	churned     int64
+	writes      *opRecorder
+	fetches     *opRecorder
+	fetchTagged *opRecorder
+	ran         bool
+	stopOnce    sync.Once
+	stopCh      chan struct{}
+}
+
+// NewGenerator creates a new load generator that issues writes and queries
+// using the given session.
+func NewGenerator(session client.Session, opts Options) (Generator, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	scope := opts.InstrumentOptions().MetricsScope().SubScope("loadgen")
+	return &generator{
+		session:     session,
+		opts:        opts,
+		log:         opts.InstrumentOptions().Logger(),
+		nowFn:       opts.ClockOptions().NowFn(),
+		generations: make([]int64, opts.Cardinality()),
+		writes:      newOpRecorder(scope.SubScope("write")),
+		fetches:     newOpRecorder(scope.SubScope("fetch")),
+		fetchTagged: newOpRecorder(scope.SubScope("fetch-tagged")),
+		stopCh:      make(chan struct{}),
+	}, nil
+}
+
+func (g *generator) Run() (Report, error) {
+	g.Lock()
+	if g.ran {
+		g.Unlock()
+		return Report{}, errGeneratorAlreadyRun
+	}
+	g.ran = true
+	g.Unlock()
+
+	var (
+		start       = g.nowFn()
+		concurrency = g.opts.Concurrency()
+		wg          sync.WaitGroup
+	)
+	g.log.Info("starting load generation",
+		zap.Stringer("namespace", g.opts.Namespace()),
+		zap.Int("cardinality", g.opts.Cardinality()),
+		zap.Int("writeRate", g.opts.WriteRate()),
+		zap.Int("queryRate", g.opts.QueryRate()),
+		zap.Duration("duration", g.opts.Duration()))
+
+	for i := 0; i < concurrency; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.writeLoop(i)
+		}()
+		if g.opts.QueryRate() > 0 {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				g.queryLoop(i)
+			}()
+		}
+	}
+	if g.opts.ChurnInterval() > 0 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			g.churnLoop()
+		}()
+	}
+
+	if duration := g.opts.Duration(); duration > 0 {
+		timer := time.NewTimer(duration)
+		select {
+		case <-timer.C:
+			g.Stop()
+		case <-g.stopCh:
+			timer.Stop()
+		}
+	}
+	wg.Wait()
+
+	elapsed := g.nowFn().Sub(start)
+	report := Report{
+		Duration:    elapsed,
+		Writes:      g.writes.report(elapsed),
+		Fetches:     g.fetches.report(elapsed),
+		FetchTagged: g.fetchTagged.report(elapsed),
+		Churned:     atomic.LoadInt64(&g.churned),
+	}
+	g.log.Info("load generation complete",
+		zap.Duration("duration", elapsed),
+		zap.Int64("writes", report.Writes.Count),
+		zap.Int64("writeErrors", report.Writes.Errors),
+		zap.Int64("fetches", report.Fetches.Count+report.FetchTagged.Count),
+		zap.Int64("fetchErrors", report.Fetches.Errors+report.FetchTagged.Errors))
+
+	return report, nil
+}
+
+func (g *generator) Stop() {
+	g.stopOnce.Do(func() {
+		close(g.stopCh)
+	})
+}
+
+func (g *generator) writeLoop(worker int) {
+	var (
+		concurrency = g.opts.Concurrency()
+		cardinality = g.opts.Cardinality()
+		namespace   = g.opts.Namespace()
+		rng         = rand.New(rand.NewSource(time.Now().UnixNano() + int64(worker)))
+		ticker      = time.NewTicker(workerInterval(g.opts.WriteRate(), concurrency))
+		next        = worker % cardinality
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		// Workers write to the series in a round robin fashion so that all
+		// series are written to at roughly the same rate.
+		seriesIdx := next
+		next = (next + concurrency) % cardinality
+
+		id, tags := g.series(seriesIdx)
+		start := g.nowFn()
+		err := g.session.WriteTagged(namespace, id, ident.NewTagsIterator(tags),
+			start, rng.Float64(), xtime.Second, nil)
+		g.writes.record(g.nowFn().Sub(start), err)
+	}
+}
+
+func (g *generator) queryLoop(worker int) {
+	var (
+		namespace = g.opts.Namespace()
+		mix       = g.opts.QueryMix()
+		total     = mix.FetchWeight + mix.FetchTaggedWeight
+		rng       = rand.New(rand.NewSource(time.Now().UnixNano() - int64(worker)))
+		ticker    = time.NewTicker(workerInterval(g.opts.QueryRate(), g.opts.Concurrency()))
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		var (
+			seriesIdx = rng.Intn(g.opts.Cardinality())
+			end       = g.nowFn()
+			start     = end.Add(-g.opts.QueryRange())
+		)
+		if rng.Float64()*total < mix.FetchWeight {
+			id, _ := g.series(seriesIdx)
+			iter, err := g.session.Fetch(namespace, id, start, end)
+			if err == nil {
+				iter.Close()
+			}
+			g.fetches.record(g.nowFn().Sub(end), err)
+			continue
+		}
+
+		query := index.Query{
+			Query: idx.NewTermQuery([]byte(indexTag), []byte(strconv.Itoa(seriesIdx))),
+		}
+		iters, _, err := g.session.FetchTagged(namespace, query, index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+		})
+		if err == nil {
+			iters.Close()
+		}
+		g.fetchTagged.record(g.nowFn().Sub(end), err)
+	}
+}
+
+func (g *generator) churnLoop() {
+	var (
+		cardinality = g.opts.Cardinality()
+		numChurn    = int(float64(cardinality) * g.opts.ChurnPercent())
+		rng         = rand.New(rand.NewSource(time.Now().UnixNano()))
+		ticker      = time.NewTicker(g.opts.ChurnInterval())
+	)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-g.stopCh:
+			return
+		case <-ticker.C:
+		}
+
+		// Replace series with new series by bumping their generation, the
+		// replaced series are no longer written to.
+		for _, i := range rng.Perm(cardinality)[:numChurn] {
+			atomic.AddInt64(&g.generations[i], 1)
+		}
+		atomic.AddInt64(&g.churned, int64(numChurn))
+	}
+}
+
+func (g *generator) series(i int) (ident.ID, ident.Tags) {
+	var (
+		name       = g.opts.MetricName()
+		seriesIdx  = strconv.Itoa(i)
+		generation = strconv.FormatInt(atomic.LoadInt64(&g.generations[i]), 10)
+		id         = fmt.Sprintf("%s{%s=%s,%s=%s}", name, indexTag, seriesIdx,
+			generationTag, generation)
+	)
+	tags := ident.NewTags(
+		ident.StringTag(nameTag, name),
+		ident.StringTag(indexTag, seriesIdx),
+		ident.StringTag(generationTag, generation),
+	)
+	return ident.StringID(id), tags
+}
+
+// workerInterval returns the interval between operations of a single worker
+// such that all workers combined issue operations at the given rate.
+func workerInterval(rate int, concurrency int) time.Duration {
+	interval := time.Duration(concurrency) * time.Second / time.Duration(rate)
+	if interval <= 0 {
+		interval = time.Nanosecond
+	}
+	return interval
+}
+
+type opRecorder struct {
+	sync.Mutex
+
+	count   int64
+	errors  int64
+	seen    int64
+	max     time.Duration
+	samples []time.Duration
+	rng     *rand.Rand
+
+	success tally.Counter
+	errs    tally.Counter
+	latency tally.Timer
+}
+
+func newOpRecorder(scope tally.Scope) *opRecorder {
+	return &opRecorder{
+		rng:     rand.New(rand.NewSource(time.Now().UnixNano())),
+		success: scope.Counter("success"),
+		errs:    scope.Counter("errors"),
+		latency: scope.Timer("latency"),
+	}
+}
+
+func (r *opRecorder) record(latency time.Duration, err error) {
+	r.latency.Record(latency)
+	if err != nil {
+		r.errs.Inc(1)
+	} else {
+		r.success.Inc(1)
+	}
+
+	r.Lock()
+	defer r.Unlock()
+
+	r.count++
+	if err != nil {
+		r.errors++
+	}
+	if latency > r.max {
+		r.max = latency
+	}
+
+	// Reservoir sample the latencies to bound memory on long soak runs.
+	r.seen++
+	if len(r.samples) < maxLatencySamples {
+		r.samples = append(r.samples, latency)
+	} else if j := r.rng.Int63n(r.seen); j < maxLatencySamples {
+		r.samples[j] = latency
+	}
+}
+
+func (r *opRecorder) report(elapsed time.Duration) OpReport {
+	r.Lock()
+	defer r.Unlock()
+
+	report := OpReport{
+		Count:      r.count,
+		Errors:     r.errors,
+		LatencyMax: r.max,
+	}
+	if elapsed > 0 {
+		report.Rate = float64(r.count) / elapsed.Seconds()
+	}
+	if len(r.samples) == 0 {
+		return report
+	}
+
+	sorted := append([]time.Duration(nil), r.samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	report.LatencyP50 = percentile(sorted, 0.5)
+	report.LatencyP90 = percentile(sorted, 0.9)
+	report.LatencyP99 = percentile(sorted, 0.99)
+	return report
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	i := int(float64(len(sorted)-1) * p)
+	return sorted[i]
+}
@@ -0,0 +1,132 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package loadgen
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testOptions() Options {
+	return NewOptions().
+		SetNamespace(ident.StringID("testns")).
+		SetCardinality(10).
+		SetWriteRate(1000).
+		SetConcurrency(2)
+}
+
+func TestOptionsValidate(t *testing.T) {
+	require.Equal(t, errNoNamespace, NewOptions().Validate())
+	require.NoError(t, testOptions().Validate())
+	require.Equal(t, errInvalidCardinality, testOptions().SetCardinality(0).Validate())
+	require.Equal(t, errInvalidChurnPercent, testOptions().SetChurnPercent(1.5).Validate())
+	require.Equal(t, errInvalidWriteRate, testOptions().SetWriteRate(0).Validate())
+	require.Equal(t, errInvalidQueryMix, testOptions().SetQueryMix(QueryMix{}).Validate())
+}
+
+func TestGeneratorRun(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	session.EXPECT().
+		WriteTagged(ident.NewIDMatcher("testns"), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		MinTimes(1)
+	session.EXPECT().
+		Fetch(ident.NewIDMatcher("testns"), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(_, _ ident.ID, _, _ time.Time) (encoding.SeriesIterator, error) {
+			iter := encoding.NewMockSeriesIterator(ctrl)
+			iter.EXPECT().Close()
+			return iter, nil
+		}).
+		AnyTimes()
+	session.EXPECT().
+		FetchTagged(ident.NewIDMatcher("testns"), gomock.Any(), gomock.Any()).
+		Return(nil, false, errors.New("fetch tagged error")).
+		AnyTimes()
+
+	generator, err := NewGenerator(session, testOptions().
+		SetQueryRate(500).
+		SetChurnInterval(10*time.Millisecond).
+		SetChurnPercent(0.5).
+		SetDuration(200*time.Millisecond))
+	require.NoError(t, err)
+
+	report, err := generator.Run()
+	require.NoError(t, err)
+
+	assert.True(t, report.Duration >= 200*time.Millisecond)
+	assert.True(t, report.Writes.Count > 0)
+	assert.Equal(t, int64(0), report.Writes.Errors)
+	assert.True(t, report.Writes.Rate > 0)
+	assert.True(t, report.Writes.LatencyMax >= report.Writes.LatencyP50)
+	assert.Equal(t, report.FetchTagged.Count, report.FetchTagged.Errors)
+	assert.True(t, report.Fetches.Count+report.FetchTagged.Count > 0)
+	assert.True(t, report.Churned > 0)
+
+	_, err = generator.Run()
+	require.Equal(t, errGeneratorAlreadyRun, err)
+}
+
+func TestGeneratorStop(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockSession(ctrl)
+	session.EXPECT().
+		WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+			gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(nil).
+		AnyTimes()
+
+	generator, err := NewGenerator(session, testOptions())
+	require.NoError(t, err)
+
+	time.AfterFunc(50*time.Millisecond, generator.Stop)
+	report, err := generator.Run()
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), report.Fetches.Count)
+	assert.Equal(t, int64(0), report.FetchTagged.Count)
+}
+
+func TestGeneratorSeriesChurn(t *testing.T) {
+	g, err := NewGenerator(nil, testOptions())
+	require.NoError(t, err)
+
+	gen := g.(*generator)
+	id, tags := gen.series(3)
+	assert.Equal(t, "loadgen{index=3,generation=0}", id.String())
+	assert.Equal(t, 3, len(tags.Values()))
+
+	gen.generations[3]++
+	id, _ = gen.series(3)
+	assert.Equal(t, "loadgen{index=3,generation=1}", id.String())
+}
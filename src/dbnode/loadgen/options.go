@@ -0,0 +1,253 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package loadgen
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultMetricName   = "loadgen"
+	defaultCardinality  = 1000
+	defaultWriteRate    = 1000
+	defaultQueryRange   = 10 * time.Minute
+	defaultConcurrency  = 8
+	defaultChurnPercent = 0.1
+)
+
+var (
+	defaultQueryMix = QueryMix{FetchWeight: 1, FetchTaggedWeight: 1}
+
+	errNoNamespace          = errors.New("no namespace in load generator options")
+	errNoMetricName         = errors.New("no metric name in load generator options")
+	errInvalidCardinality   = errors.New("load generator cardinality must be positive")
+	errInvalidChurnInterval = errors.New("load generator churn interval must not be negative")
+	errInvalidChurnPercent  = errors.New("load generator churn percent must be between 0 and 1")
+	errInvalidWriteRate     = errors.New("load generator write rate must be positive")
+	errInvalidQueryRate     = errors.New("load generator query rate must not be negative")
+	errInvalidQueryMix      = errors.New("load generator query mix weights must not be negative and must not all be zero")
+	errInvalidQueryRange    = errors.New("load generator query range must be positive")
+	errInvalidConcurrency   = errors.New("load generator concurrency must be positive")
+	errInvalidDuration      = errors.New("load generator duration must not be negative")
+)
+
+type options struct {
+	instrumentOpts instrument.Options
+	clockOpts      clock.Options
+	namespace      ident.ID
+	metricName     string
+	cardinality    int
+	churnInterval  time.Duration
+	churnPercent   float64
+	writeRate      int
+	queryRate      int
+	queryMix       QueryMix
+	queryRange     time.Duration
+	concurrency    int
+	duration       time.Duration
+}
+
+// NewOptions creates new load generator options.
+func NewOptions() Options {
+	return &options{
+		instrumentOpts: instrument.NewOptions(),
+		clockOpts:      clock.NewOptions(),
+		metricName:     defaultMetricName,
+		cardinality:    defaultCardinality,
+		churnPercent:   defaultChurnPercent,
+		writeRate:      defaultWriteRate,
+		queryMix:       defaultQueryMix,
+		queryRange:     defaultQueryRange,
+		concurrency:    defaultConcurrency,
+	}
+}
+
+func (o *options) Validate() error {
+	if o.namespace == nil {
+		return errNoNamespace
+	}
+	if o.metricName == "" {
+		return errNoMetricName
+	}
+	if o.cardinality <= 0 {
+		return errInvalidCardinality
+	}
+	if o.churnInterval < 0 {
+		return errInvalidChurnInterval
+	}
+	if o.churnPercent < 0 || o.churnPercent > 1 {
+		return errInvalidChurnPercent
+	}
+	if o.writeRate <= 0 {
+		return errInvalidWriteRate
+	}
+	if o.queryRate < 0 {
+		return errInvalidQueryRate
+	}
+	if o.queryMix.FetchWeight < 0 || o.queryMix.FetchTaggedWeight < 0 ||
+		o.queryMix.FetchWeight+o.queryMix.FetchTaggedWeight <= 0 {
+		return errInvalidQueryMix
+	}
+	if o.queryRange <= 0 {
+		return errInvalidQueryRange
+	}
+	if o.concurrency <= 0 {
+		return errInvalidConcurrency
+	}
+	if o.duration < 0 {
+		return errInvalidDuration
+	}
+	return nil
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetNamespace(value ident.ID) Options {
+	opts := *o
+	opts.namespace = value
+	return &opts
+}
+
+func (o *options) Namespace() ident.ID {
+	return o.namespace
+}
+
+func (o *options) SetMetricName(value string) Options {
+	opts := *o
+	opts.metricName = value
+	return &opts
+}
+
+func (o *options) MetricName() string {
+	return o.metricName
+}
+
+func (o *options) SetCardinality(value int) Options {
+	opts := *o
+	opts.cardinality = value
+	return &opts
+}
+
+func (o *options) Cardinality() int {
+	return o.cardinality
+}
+
+func (o *options) SetChurnInterval(value time.Duration) Options {
+	opts := *o
+	opts.churnInterval = value
+	return &opts
+}
+
+func (o *options) ChurnInterval() time.Duration {
+	return o.churnInterval
+}
+
+func (o *options) SetChurnPercent(value float64) Options {
+	opts := *o
+	opts.churnPercent = value
+	return &opts
+}
+
+func (o *options) ChurnPercent() float64 {
+	return o.churnPercent
+}
+
+func (o *options) SetWriteRate(value int) Options {
+	opts := *o
+	opts.writeRate = value
+	return &opts
+}
+
+func (o *options) WriteRate() int {
+	return o.writeRate
+}
+
+func (o *options) SetQueryRate(value int) Options {
+	opts := *o
+	opts.queryRate = value
+	return &opts
+}
+
+func (o *options) QueryRate() int {
+	return o.queryRate
+}
+
+func (o *options) SetQueryMix(value QueryMix) Options {
+	opts := *o
+	opts.queryMix = value
+	return &opts
+}
+
+func (o *options) QueryMix() QueryMix {
+	return o.queryMix
+}
+
+func (o *options) SetQueryRange(value time.Duration) Options {
+	opts := *o
+	opts.queryRange = value
+	return &opts
+}
+
+func (o *options) QueryRange() time.Duration {
+	return o.queryRange
+}
+
+func (o *options) SetConcurrency(value int) Options {
+	opts := *o
+	opts.concurrency = value
+	return &opts
+}
+
+func (o *options) Concurrency() int {
+	return o.concurrency
+}
+
+func (o *options) SetDuration(value time.Duration) Options {
+	opts := *o
+	opts.duration = value
+	return &opts
+}
+
+func (o *options) Duration() time.Duration {
+	return o.duration
+}
@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package loadgen provides load and soak generation against an M3DB cluster
+// using a client session.
+package loadgen
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Generator generates write and query load against a namespace.
+type Generator interface {
+	// Run generates load until the configured duration elapses or Stop is
+	// called, whichever happens first, and returns a report of the run.
+	Run() (Report, error)
+
+	// Stop stops a run in progress.
+	Stop()
+}
+
+// QueryMix describes the relative weights of the query types issued.
+type QueryMix struct {
+	// FetchWeight is the weight of fetches of a single series by ID.
+	FetchWeight float64
+
+	// FetchTaggedWeight is the weight of fetches of series by tag query.
+	FetchTaggedWeight float64
+}
+
+// Report captures the results of a load generation run.
+type Report struct {
+	// Duration is how long the run lasted.
+	Duration time.Duration
+
+	// Writes is the report for writes.
+	Writes OpReport
+
+	// Fetches is the report for fetches by series ID.
+	Fetches OpReport
+
+	// FetchTagged is the report for fetches by tag query.
+	FetchTagged OpReport
+
+	// Churned is the number of series replaced by churn.
+	Churned int64
+}
+
+// OpReport captures the results of a type of operation.
+type OpReport struct {
+	// Count is the number of operations issued.
+	Count int64
+
+	// Errors is the number of operations that returned an error.
+	Errors int64
+
+	// Rate is the number of operations issued per second.
+	Rate float64
+
+	// LatencyP50 is the 50th percentile latency.
+	LatencyP50 time.Duration
+
+	// LatencyP90 is the 90th percentile latency.
+	LatencyP90 time.Duration
+
+	// LatencyP99 is the 99th percentile latency.
+	LatencyP99 time.Duration
+
+	// LatencyMax is the maximum latency.
+	LatencyMax time.Duration
+}
+
+// Options are the load generator options.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetNamespace sets the namespace load is generated against.
+	SetNamespace(value ident.ID) Options
+
+	// Namespace returns the namespace load is generated against.
+	Namespace() ident.ID
+
+	// SetMetricName sets the metric name of the generated series.
+	SetMetricName(value string) Options
+
+	// MetricName returns the metric name of the generated series.
+	MetricName() string
+
+	// SetCardinality sets the number of series written to concurrently.
+	SetCardinality(value int) Options
+
+	// Cardinality returns the number of series written to concurrently.
+	Cardinality() int
+
+	// SetChurnInterval sets how often series are replaced by new series,
+	// churn is disabled if zero.
+	SetChurnInterval(value time.Duration) Options
+
+	// ChurnInterval returns how often series are replaced by new series.
+	ChurnInterval() time.Duration
+
+	// SetChurnPercent sets the fraction of series replaced each churn interval.
+	SetChurnPercent(value float64) Options
+
+	// ChurnPercent returns the fraction of series replaced each churn interval.
+	ChurnPercent() float64
+
+	// SetWriteRate sets the number of writes issued per second.
+	SetWriteRate(value int) Options
+
+	// WriteRate returns the number of writes issued per second.
+	WriteRate() int
+
+	// SetQueryRate sets the number of queries issued per second, queries
+	// are disabled if zero.
+	SetQueryRate(value int) Options
+
+	// QueryRate returns the number of queries issued per second.
+	QueryRate() int
+
+	// SetQueryMix sets the relative weights of the query types issued.
+	SetQueryMix(value QueryMix) Options
+
+	// QueryMix returns the relative weights of the query types issued.
+	QueryMix() QueryMix
+
+	// SetQueryRange sets the time range queried, ending at the current time.
+	SetQueryRange(value time.Duration) Options
+
+	// QueryRange returns the time range queried, ending at the current time.
+	QueryRange() time.Duration
+
+	// SetConcurrency sets the number of workers issuing each of writes and queries.
+	SetConcurrency(value int) Options
+
+	// Concurrency returns the number of workers issuing each of writes and queries.
+	Concurrency() int
+
+	// SetDuration sets how long to run for, runs until stopped if zero.
+	SetDuration(value time.Duration) Options
+
+	// Duration returns how long to run for.
+	Duration() time.Duration
+}
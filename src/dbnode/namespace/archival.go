@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/retention"
+)
+
+var (
+	errArchivalImmutableAfterTooSmall = errors.New(
+		"archival immutable after must be at least block size plus buffer past")
+	errArchivalImmutableAfterTooLarge = errors.New(
+		"archival immutable after must be less than the namespace retention period")
+)
+
+// ArchivalOptions controls whether aged blocks of a namespace are archived,
+// i.e. become immutable and are only ever read after a certain age. Once a
+// block is immutable it is no longer written to, rewritten by cold flushes
+// or rollups, or repaired.
+type ArchivalOptions struct {
+	// Enabled is whether aged blocks are archived.
+	Enabled bool
+	// ImmutableAfter is the age after which a block becomes immutable.
+	ImmutableAfter time.Duration
+	// VerifyChecksumOnRead is whether to verify the checksum recorded for
+	// immutable blocks every time they are read from memory. Reads from disk
+	// always verify the checksum recorded in the fileset index.
+	VerifyChecksumOnRead bool
+}
+
+// IsBlockImmutable returns whether the block starting at the given time is
+// immutable at the given time.
+func (o ArchivalOptions) IsBlockImmutable(
+	blockStart time.Time,
+	blockSize time.Duration,
+	now time.Time,
+) bool {
+	if !o.Enabled {
+		return false
+	}
+	return !blockStart.Add(blockSize).After(now.Add(-o.ImmutableAfter))
+}
+
+// ImmutableBefore returns the time before which all blocks are immutable,
+// returning false if archival is disabled.
+func (o ArchivalOptions) ImmutableBefore(
+	blockSize time.Duration,
+	now time.Time,
+) (time.Time, bool) {
+	if !o.Enabled {
+		return time.Time{}, false
+	}
+	return now.Add(-o.ImmutableAfter).Add(-blockSize).Truncate(blockSize).Add(blockSize), true
+}
+
+func validateArchivalOptions(o ArchivalOptions, ropts retention.Options) error {
+	if !o.Enabled {
+		return nil
+	}
+	// Blocks must not become immutable before they are warm flushed.
+	if o.ImmutableAfter < ropts.BlockSize()+ropts.BufferPast() {
+		return errArchivalImmutableAfterTooSmall
+	}
+	if o.ImmutableAfter >= ropts.RetentionPeriod() {
+		return errArchivalImmutableAfterTooLarge
+	}
+	return nil
+}
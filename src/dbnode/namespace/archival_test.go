@@ -0,0 +1,105 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/retention"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestArchivalOptionsIsBlockImmutable(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		now       = time.Now().Truncate(blockSize).Add(time.Minute)
+		opts      = ArchivalOptions{Enabled: true, ImmutableAfter: 24 * time.Hour}
+	)
+
+	immutableBefore, ok := opts.ImmutableBefore(blockSize, now)
+	require.True(t, ok)
+	for blockStart := now.Truncate(blockSize).Add(-48 * time.Hour); blockStart.Before(now); blockStart = blockStart.Add(blockSize) {
+		expected := blockStart.Before(immutableBefore)
+		require.Equal(t, expected, opts.IsBlockImmutable(blockStart, blockSize, now),
+			"block start %v", blockStart)
+	}
+
+	// The block that ends exactly at the immutable boundary is immutable.
+	boundary := now.Truncate(blockSize).Add(-24*time.Hour - blockSize)
+	require.False(t, opts.IsBlockImmutable(boundary.Add(blockSize), blockSize, now))
+	require.True(t, opts.IsBlockImmutable(boundary.Add(-blockSize), blockSize, now))
+
+	opts.Enabled = false
+	require.False(t, opts.IsBlockImmutable(boundary.Add(-blockSize), blockSize, now))
+	_, ok = opts.ImmutableBefore(blockSize, now)
+	require.False(t, ok)
+}
+
+func TestArchivalOptionsValidate(t *testing.T) {
+	ropts := retention.NewOptions().
+		SetBlockSize(2 * time.Hour).
+		SetBufferPast(10 * time.Minute).
+		SetRetentionPeriod(30 * 24 * time.Hour)
+	opts := NewOptions().SetRetentionOptions(ropts)
+
+	require.NoError(t, opts.SetArchivalOptions(ArchivalOptions{
+		ImmutableAfter: time.Minute,
+	}).Validate())
+	require.NoError(t, opts.SetArchivalOptions(ArchivalOptions{
+		Enabled:        true,
+		ImmutableAfter: 7 * 24 * time.Hour,
+	}).Validate())
+	require.Equal(t, errArchivalImmutableAfterTooSmall, opts.SetArchivalOptions(ArchivalOptions{
+		Enabled:        true,
+		ImmutableAfter: 2 * time.Hour,
+	}).Validate())
+	require.Equal(t, errArchivalImmutableAfterTooLarge, opts.SetArchivalOptions(ArchivalOptions{
+		Enabled:        true,
+		ImmutableAfter: 30 * 24 * time.Hour,
+	}).Validate())
+}
+
+func TestMetadataConfigArchival(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 720h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+archival:
+  immutableAfter: 168h
+  verifyChecksumOnRead: true
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, ArchivalOptions{
+		Enabled:              true,
+		ImmutableAfter:       168 * time.Hour,
+		VerifyChecksumOnRead: true,
+	}, md.Options().ArchivalOptions())
+}
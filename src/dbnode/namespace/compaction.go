@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	errCompactionBlockSizeNotMultiple = errors.New(
+		"compaction block size must be larger than and a multiple of the block size and index block size")
+	errCompactionBlockSizeTooLarge = errors.New(
+		"compaction block size must not be larger than the namespace retention period")
+	errCompactionRequiresArchival = errors.New(
+		"compaction requires archival to be enabled")
+	errCompactionAfterTooSmall = errors.New(
+		"compaction after must be at least the archival immutable after")
+	errCompactionAfterTooLarge = errors.New(
+		"compaction after must be less than the namespace retention period")
+)
+
+// CompactionOptions controls whether aged blocks of a namespace are compacted
+// into filesets of a larger block size. The filesets of all the blocks that
+// make up a compacted block are rewritten into a single fileset that holds
+// the data of each series for all of these blocks under a single index entry,
+// reducing the number of files and the memory used by the index summaries and
+// bloom filters of the seekers of namespaces with a long retention.
+//
+// Only blocks that have been archived are compacted, since archived blocks
+// are immutable and are never rewritten by cold flushes, rollups or repairs.
+// The namespace block size remains the unit that blocks are read, bootstrapped
+// and streamed to peers at.
+type CompactionOptions struct {
+	// Enabled is whether aged blocks are compacted.
+	Enabled bool
+	// BlockSize is the block size blocks are compacted into.
+	BlockSize time.Duration
+	// After is the age after which a compacted block is compacted.
+	After time.Duration
+}
+
+// CompactedBlockStart returns the start of the compacted block the block
+// starting at the given time belongs to.
+func (o CompactionOptions) CompactedBlockStart(blockStart time.Time) time.Time {
+	return blockStart.Truncate(o.BlockSize)
+}
+
+// IsBlockCompactable returns whether the compacted block starting at the
+// given time can be compacted at the given time.
+func (o CompactionOptions) IsBlockCompactable(
+	compactedBlockStart time.Time,
+	now time.Time,
+) bool {
+	if !o.Enabled {
+		return false
+	}
+	return !compactedBlockStart.Add(o.BlockSize).After(now.Add(-o.After))
+}
+
+// EarliestToRetain returns the earliest time filesets must be retained from
+// for the given earliest time to retain data from, filesets of compacted
+// blocks are only expired once all of the blocks they hold have expired.
+func (o CompactionOptions) EarliestToRetain(earliestToRetain time.Time) time.Time {
+	if !o.Enabled {
+		return earliestToRetain
+	}
+	return o.CompactedBlockStart(earliestToRetain)
+}
+
+func validateCompactionOptions(o Options) error {
+	co := o.CompactionOptions()
+	if !co.Enabled {
+		return nil
+	}
+	var (
+		ropts     = o.RetentionOptions()
+		blockSize = ropts.BlockSize()
+	)
+	if co.BlockSize <= blockSize || co.BlockSize%blockSize != 0 {
+		return errCompactionBlockSizeNotMultiple
+	}
+	if iopts := o.IndexOptions(); iopts.Enabled() && iopts.BlockSize() > 0 &&
+		co.BlockSize%iopts.BlockSize() != 0 {
+		return errCompactionBlockSizeNotMultiple
+	}
+	if co.BlockSize > ropts.RetentionPeriod() {
+		return errCompactionBlockSizeTooLarge
+	}
+	archivalOpts := o.ArchivalOptions()
+	if !archivalOpts.Enabled {
+		return errCompactionRequiresArchival
+	}
+	// All blocks of a compacted block must be immutable once it is compacted.
+	if co.After < archivalOpts.ImmutableAfter {
+		return errCompactionAfterTooSmall
+	}
+	if co.After >= ropts.RetentionPeriod() {
+		return errCompactionAfterTooLarge
+	}
+	return nil
+}
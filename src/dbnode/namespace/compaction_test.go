@@ -0,0 +1,190 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/retention"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCompactionOptionsIsBlockCompactable(t *testing.T) {
+	var (
+		now  = time.Now().Truncate(24 * time.Hour).Add(time.Minute)
+		opts = CompactionOptions{
+			Enabled:   true,
+			BlockSize: 24 * time.Hour,
+			After:     7 * 24 * time.Hour,
+		}
+	)
+
+	// The compacted block that ends exactly at the compaction boundary can be
+	// compacted, the one after it cannot.
+	boundary := now.Truncate(24 * time.Hour).Add(-7*24*time.Hour - 24*time.Hour)
+	require.True(t, opts.IsBlockCompactable(boundary, now))
+	require.True(t, opts.IsBlockCompactable(boundary.Add(-24*time.Hour), now))
+	require.False(t, opts.IsBlockCompactable(boundary.Add(24*time.Hour), now))
+
+	require.Equal(t, boundary, opts.CompactedBlockStart(boundary.Add(22*time.Hour)))
+
+	opts.Enabled = false
+	require.False(t, opts.IsBlockCompactable(boundary.Add(-24*time.Hour), now))
+}
+
+func TestCompactionOptionsEarliestToRetain(t *testing.T) {
+	var (
+		day      = time.Now().Truncate(24 * time.Hour)
+		earliest = day.Add(6 * time.Hour)
+		opts     = CompactionOptions{
+			Enabled:   true,
+			BlockSize: 24 * time.Hour,
+			After:     7 * 24 * time.Hour,
+		}
+	)
+
+	// Filesets of the compacted block that is only partially expired are
+	// retained until all of its blocks have expired.
+	require.Equal(t, day, opts.EarliestToRetain(earliest))
+
+	opts.Enabled = false
+	require.Equal(t, earliest, opts.EarliestToRetain(earliest))
+}
+
+func TestCompactionOptionsValidate(t *testing.T) {
+	ropts := retention.NewOptions().
+		SetBlockSize(2 * time.Hour).
+		SetBufferPast(10 * time.Minute).
+		SetRetentionPeriod(30 * 24 * time.Hour)
+	opts := NewOptions().
+		SetRetentionOptions(ropts).
+		SetIndexOptions(NewIndexOptions().SetEnabled(true).SetBlockSize(4 * time.Hour)).
+		SetArchivalOptions(ArchivalOptions{
+			Enabled:        true,
+			ImmutableAfter: 7 * 24 * time.Hour,
+		})
+
+	require.NoError(t, opts.SetCompactionOptions(CompactionOptions{
+		BlockSize: time.Minute,
+	}).Validate())
+	require.NoError(t, opts.SetCompactionOptions(CompactionOptions{
+		Enabled:   true,
+		BlockSize: 24 * time.Hour,
+		After:     14 * 24 * time.Hour,
+	}).Validate())
+
+	tests := []struct {
+		name     string
+		opts     Options
+		expected error
+	}{
+		{
+			name: "block size not larger than block size",
+			opts: opts.SetCompactionOptions(CompactionOptions{
+				Enabled:   true,
+				BlockSize: 2 * time.Hour,
+				After:     14 * 24 * time.Hour,
+			}),
+			expected: errCompactionBlockSizeNotMultiple,
+		},
+		{
+			name: "block size not a multiple of index block size",
+			opts: opts.SetCompactionOptions(CompactionOptions{
+				Enabled:   true,
+				BlockSize: 6 * time.Hour,
+				After:     14 * 24 * time.Hour,
+			}),
+			expected: errCompactionBlockSizeNotMultiple,
+		},
+		{
+			name: "block size larger than retention",
+			opts: opts.SetCompactionOptions(CompactionOptions{
+				Enabled:   true,
+				BlockSize: 32 * 24 * time.Hour,
+				After:     14 * 24 * time.Hour,
+			}),
+			expected: errCompactionBlockSizeTooLarge,
+		},
+		{
+			name: "archival disabled",
+			opts: opts.SetArchivalOptions(ArchivalOptions{}).
+				SetCompactionOptions(CompactionOptions{
+					Enabled:   true,
+					BlockSize: 24 * time.Hour,
+					After:     14 * 24 * time.Hour,
+				}),
+			expected: errCompactionRequiresArchival,
+		},
+		{
+			name: "compacted before immutable",
+			opts: opts.SetCompactionOptions(CompactionOptions{
+				Enabled:   true,
+				BlockSize: 24 * time.Hour,
+				After:     24 * time.Hour,
+			}),
+			expected: errCompactionAfterTooSmall,
+		},
+		{
+			name: "compacted after retention",
+			opts: opts.SetCompactionOptions(CompactionOptions{
+				Enabled:   true,
+				BlockSize: 24 * time.Hour,
+				After:     30 * 24 * time.Hour,
+			}),
+			expected: errCompactionAfterTooLarge,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			require.Equal(t, test.expected, test.opts.Validate())
+		})
+	}
+}
+
+func TestMetadataConfigCompaction(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 720h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+archival:
+  immutableAfter: 168h
+compaction:
+  blockSize: 24h
+  after: 336h
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, CompactionOptions{
+		Enabled:   true,
+		BlockSize: 24 * time.Hour,
+		After:     336 * time.Hour,
+	}, md.Options().CompactionOptions())
+}
@@ -22,9 +22,11 @@ package namespace
 
 import (
 	"fmt"
+	"math"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/ident"
 )
 
@@ -48,22 +50,39 @@ func (m *MapConfiguration) Map() (Map, error) {
 
 // MetadataConfiguration is the configuration for a single namespace
 type MetadataConfiguration struct {
-	ID                string                  `yaml:"id" validate:"nonzero"`
-	BootstrapEnabled  *bool                   `yaml:"bootstrapEnabled"`
-	FlushEnabled      *bool                   `yaml:"flushEnabled"`
-	WritesToCommitLog *bool                   `yaml:"writesToCommitLog"`
-	CleanupEnabled    *bool                   `yaml:"cleanupEnabled"`
-	RepairEnabled     *bool                   `yaml:"repairEnabled"`
-	ColdWritesEnabled *bool                   `yaml:"coldWritesEnabled"`
-	Retention         retention.Configuration `yaml:"retention" validate:"nonzero"`
-	Index             IndexConfiguration      `yaml:"index"`
+	ID                string                         `yaml:"id" validate:"nonzero"`
+	BootstrapEnabled  *bool                          `yaml:"bootstrapEnabled"`
+	FlushEnabled      *bool                          `yaml:"flushEnabled"`
+	WritesToCommitLog *bool                          `yaml:"writesToCommitLog"`
+	CleanupEnabled    *bool                          `yaml:"cleanupEnabled"`
+	RepairEnabled     *bool                          `yaml:"repairEnabled"`
+	ColdWritesEnabled *bool                          `yaml:"coldWritesEnabled"`
+	Retention         retention.Configuration        `yaml:"retention" validate:"nonzero"`
+	RetentionTiers    []RetentionTierConfiguration   `yaml:"retentionTiers"`
+	Archival          *ArchivalConfiguration         `yaml:"archival"`
+	Compaction        *CompactionConfiguration       `yaml:"compaction"`
+	FutureWrites      *FutureWriteConfiguration      `yaml:"futureWrites"`
+	ExpiryDownsample  *ExpiryDownsampleConfiguration `yaml:"expiryDownsample"`
+	Relabel           []RelabelRuleConfiguration     `yaml:"relabel"`
+	Mirror            *MirrorConfiguration           `yaml:"mirror"`
+	Reshard           *ReshardConfiguration          `yaml:"reshard"`
+	QueryLimits       *QueryLimitsConfiguration      `yaml:"queryLimits"`
+	WriteDurability   *ts.Durability                 `yaml:"writeDurability"`
+	Validation        *ValidationConfiguration       `yaml:"validation"`
+	InMemory          bool                           `yaml:"inMemory"`
+	ShardKeyStrategy  string                         `yaml:"shardKeyStrategy"`
+	Index             IndexConfiguration             `yaml:"index"`
 }
 
 // Metadata returns a Metadata corresponding to the receiver struct
 func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 	iopts := mc.Index.Options()
 	ropts := mc.Retention.Options()
-	opts := NewOptions().
+	opts := NewOptions()
+	if mc.InMemory {
+		opts = NewInMemoryOptions()
+	}
+	opts = opts.
 		SetRetentionOptions(ropts).
 		SetIndexOptions(iopts)
 	if v := mc.BootstrapEnabled; v != nil {
@@ -84,6 +103,50 @@ func (mc *MetadataConfiguration) Metadata() (Metadata, error) {
 	if v := mc.ColdWritesEnabled; v != nil {
 		opts = opts.SetColdWritesEnabled(*v)
 	}
+	if len(mc.RetentionTiers) > 0 {
+		tiers := make([]RetentionTier, 0, len(mc.RetentionTiers))
+		for _, tier := range mc.RetentionTiers {
+			tiers = append(tiers, tier.RetentionTier())
+		}
+		opts = opts.SetRetentionTiers(tiers)
+	}
+	if v := mc.Archival; v != nil {
+		opts = opts.SetArchivalOptions(v.ArchivalOptions())
+	}
+	if v := mc.Compaction; v != nil {
+		opts = opts.SetCompactionOptions(v.CompactionOptions())
+	}
+	if v := mc.FutureWrites; v != nil {
+		opts = opts.SetFutureWriteOptions(v.FutureWriteOptions())
+	}
+	if v := mc.ExpiryDownsample; v != nil {
+		opts = opts.SetExpiryDownsampleOptions(v.ExpiryDownsampleOptions())
+	}
+	if len(mc.Relabel) > 0 {
+		rules := make([]RelabelRule, 0, len(mc.Relabel))
+		for _, rule := range mc.Relabel {
+			rules = append(rules, rule.RelabelRule())
+		}
+		opts = opts.SetRelabelOptions(RelabelOptions{Rules: rules})
+	}
+	if v := mc.Mirror; v != nil {
+		opts = opts.SetMirrorOptions(v.MirrorOptions())
+	}
+	if v := mc.Reshard; v != nil {
+		opts = opts.SetReshardOptions(v.ReshardOptions())
+	}
+	if v := mc.QueryLimits; v != nil {
+		opts = opts.SetQueryLimitsOptions(v.QueryLimitsOptions())
+	}
+	if v := mc.WriteDurability; v != nil {
+		opts = opts.SetWriteDurability(*v)
+	}
+	if v := mc.Validation; v != nil {
+		opts = opts.SetValidationOptions(v.ValidationOptions())
+	}
+	if v := mc.ShardKeyStrategy; v != "" {
+		opts = opts.SetShardKeyStrategy(v)
+	}
 	return NewMetadata(ident.StringID(mc.ID), opts)
 }
 
@@ -99,3 +162,195 @@ func (ic *IndexConfiguration) Options() IndexOptions {
 		SetEnabled(ic.Enabled).
 		SetBlockSize(ic.BlockSize)
 }
+
+// RetentionTierConfiguration is the configuration for a single resolution/retention
+// tier of a namespace.
+type RetentionTierConfiguration struct {
+	Resolution time.Duration `yaml:"resolution"`
+	Retention  time.Duration `yaml:"retention" validate:"nonzero"`
+}
+
+// RetentionTier returns the RetentionTier corresponding to the receiver struct.
+func (rc *RetentionTierConfiguration) RetentionTier() RetentionTier {
+	return RetentionTier{
+		Resolution: rc.Resolution,
+		Retention:  rc.Retention,
+	}
+}
+
+// ArchivalConfiguration is the configuration for archiving aged blocks of a
+// namespace.
+type ArchivalConfiguration struct {
+	ImmutableAfter       time.Duration `yaml:"immutableAfter" validate:"nonzero"`
+	VerifyChecksumOnRead bool          `yaml:"verifyChecksumOnRead"`
+}
+
+// ArchivalOptions returns the ArchivalOptions corresponding to the receiver struct.
+func (ac *ArchivalConfiguration) ArchivalOptions() ArchivalOptions {
+	return ArchivalOptions{
+		Enabled:              true,
+		ImmutableAfter:       ac.ImmutableAfter,
+		VerifyChecksumOnRead: ac.VerifyChecksumOnRead,
+	}
+}
+
+// CompactionConfiguration is the configuration for compacting aged blocks of
+// a namespace into filesets of a larger block size.
+type CompactionConfiguration struct {
+	BlockSize time.Duration `yaml:"blockSize" validate:"nonzero"`
+	After     time.Duration `yaml:"after" validate:"nonzero"`
+}
+
+// CompactionOptions returns the CompactionOptions corresponding to the receiver struct.
+func (cc *CompactionConfiguration) CompactionOptions() CompactionOptions {
+	return CompactionOptions{
+		Enabled:   true,
+		BlockSize: cc.BlockSize,
+		After:     cc.After,
+	}
+}
+
+// FutureWriteConfiguration is the configuration for how far in the future
+// writes to a namespace may be timestamped.
+type FutureWriteConfiguration struct {
+	Tolerance time.Duration     `yaml:"tolerance" validate:"nonzero"`
+	Action    FutureWriteAction `yaml:"action"`
+}
+
+// FutureWriteOptions returns the FutureWriteOptions corresponding to the receiver struct.
+func (fc *FutureWriteConfiguration) FutureWriteOptions() FutureWriteOptions {
+	return FutureWriteOptions{
+		Enabled:   true,
+		Tolerance: fc.Tolerance,
+		Action:    fc.Action,
+	}
+}
+
+// ExpiryDownsampleConfiguration is the configuration for downsampling blocks
+// of a namespace into a longer retention namespace before they expire.
+type ExpiryDownsampleConfiguration struct {
+	Namespace  string        `yaml:"namespace" validate:"nonzero"`
+	Resolution time.Duration `yaml:"resolution" validate:"nonzero"`
+}
+
+// ExpiryDownsampleOptions returns the ExpiryDownsampleOptions corresponding to the receiver struct.
+func (ec *ExpiryDownsampleConfiguration) ExpiryDownsampleOptions() ExpiryDownsampleOptions {
+	return ExpiryDownsampleOptions{
+		Enabled:         true,
+		TargetNamespace: ec.Namespace,
+		Resolution:      ec.Resolution,
+	}
+}
+
+// RelabelRuleConfiguration is the configuration for a single rule applied to
+// the tags of series written to a namespace before they are indexed.
+type RelabelRuleConfiguration struct {
+	Action      RelabelAction `yaml:"action"`
+	Name        string        `yaml:"name" validate:"nonzero"`
+	Target      string        `yaml:"target"`
+	Value       string        `yaml:"value"`
+	HashBuckets uint32        `yaml:"hashBuckets"`
+}
+
+// RelabelRule returns the RelabelRule corresponding to the receiver struct.
+func (rc *RelabelRuleConfiguration) RelabelRule() RelabelRule {
+	return RelabelRule{
+		Action:      rc.Action,
+		Name:        rc.Name,
+		Target:      rc.Target,
+		Value:       rc.Value,
+		HashBuckets: rc.HashBuckets,
+	}
+}
+
+// MirrorConfiguration is the configuration for mirroring writes to a
+// namespace into a shadow namespace.
+type MirrorConfiguration struct {
+	Namespace  string                       `yaml:"namespace" validate:"nonzero"`
+	Percentage float64                      `yaml:"percentage" validate:"nonzero"`
+	Matchers   []MirrorMatcherConfiguration `yaml:"matchers"`
+}
+
+// MirrorMatcherConfiguration is the configuration for a single matcher
+// series must match to be mirrored.
+type MirrorMatcherConfiguration struct {
+	Name  string `yaml:"name" validate:"nonzero"`
+	Value string `yaml:"value"`
+}
+
+// MirrorOptions returns the MirrorOptions corresponding to the receiver struct.
+func (mc *MirrorConfiguration) MirrorOptions() MirrorOptions {
+	opts := MirrorOptions{
+		Enabled:         true,
+		TargetNamespace: mc.Namespace,
+		Percentage:      mc.Percentage,
+	}
+	for _, matcher := range mc.Matchers {
+		opts.Matchers = append(opts.Matchers, MirrorMatcher{
+			Name:  matcher.Name,
+			Value: matcher.Value,
+		})
+	}
+	return opts
+}
+
+// ReshardConfiguration is the configuration for splitting the shard space of
+// a namespace into a larger shard space.
+type ReshardConfiguration struct {
+	FromNumShards uint32 `yaml:"fromNumShards" validate:"nonzero"`
+	ToNumShards   uint32 `yaml:"toNumShards" validate:"nonzero"`
+}
+
+// ReshardOptions returns the ReshardOptions corresponding to the receiver struct.
+func (rc *ReshardConfiguration) ReshardOptions() ReshardOptions {
+	return ReshardOptions{
+		Enabled:       true,
+		FromNumShards: rc.FromNumShards,
+		ToNumShards:   rc.ToNumShards,
+	}
+}
+
+// QueryLimitsConfiguration is the configuration for limiting the index
+// queries against a namespace.
+type QueryLimitsConfiguration struct {
+	MaxConcurrentQueries   int   `yaml:"maxConcurrentQueries" validate:"min=0"`
+	MaxInFlightResultBytes int64 `yaml:"maxInFlightResultBytes" validate:"min=0"`
+}
+
+// QueryLimitsOptions returns the QueryLimitsOptions corresponding to the receiver struct.
+func (qc *QueryLimitsConfiguration) QueryLimitsOptions() QueryLimitsOptions {
+	return QueryLimitsOptions{
+		MaxConcurrentQueries:   qc.MaxConcurrentQueries,
+		MaxInFlightResultBytes: qc.MaxInFlightResultBytes,
+	}
+}
+
+// ValidationConfiguration is the configuration for the rules values written
+// to a namespace are validated against.
+type ValidationConfiguration struct {
+	RejectNaN         bool     `yaml:"rejectNaN"`
+	RejectInf         bool     `yaml:"rejectInf"`
+	MinValue          *float64 `yaml:"minValue"`
+	MaxValue          *float64 `yaml:"maxValue"`
+	MaxAnnotationSize int      `yaml:"maxAnnotationSize" validate:"min=0"`
+}
+
+// ValidationOptions returns the ValidationOptions corresponding to the receiver struct.
+func (vc *ValidationConfiguration) ValidationOptions() ValidationOptions {
+	opts := ValidationOptions{
+		RejectNaN:         vc.RejectNaN,
+		RejectInf:         vc.RejectInf,
+		MinValue:          math.Inf(-1),
+		MaxValue:          math.Inf(1),
+		MaxAnnotationSize: vc.MaxAnnotationSize,
+	}
+	if v := vc.MinValue; v != nil {
+		opts.BoundsEnabled = true
+		opts.MinValue = *v
+	}
+	if v := vc.MaxValue; v != nil {
+		opts.BoundsEnabled = true
+		opts.MaxValue = *v
+	}
+	return opts
+}
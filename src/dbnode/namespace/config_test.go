@@ -232,3 +232,28 @@ metadatas:
 	require.True(t, testRetentionOpts.Equal(opts.RetentionOptions()))
 
 }
+
+func TestMetadataConfigRetentionTiers(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 720h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+retentionTiers:
+  - retention: 48h
+  - resolution: 5m
+    retention: 720h
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, []RetentionTier{
+		{Retention: 48 * time.Hour},
+		{Resolution: 5 * time.Minute, Retention: 720 * time.Hour},
+	}, md.Options().RetentionTiers())
+}
@@ -26,6 +26,7 @@ import (
 
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 )
@@ -113,11 +114,165 @@ func ToMetadata(
 		SetSchemaHistory(sr).
 		SetRetentionOptions(ropts).
 		SetIndexOptions(iopts).
-		SetColdWritesEnabled(opts.ColdWritesEnabled)
+		SetColdWritesEnabled(opts.ColdWritesEnabled).
+		SetRetentionTiers(ToRetentionTiers(opts.RetentionTiers)).
+		SetArchivalOptions(ToArchivalOptions(opts.ArchivalOptions)).
+		SetCompactionOptions(ToCompactionOptions(opts.CompactionOptions)).
+		SetFutureWriteOptions(ToFutureWriteOptions(opts.FutureWriteOptions)).
+		SetExpiryDownsampleOptions(ToExpiryDownsampleOptions(opts.ExpiryDownsampleOptions)).
+		SetInMemory(opts.InMemory).
+		SetShardKeyStrategy(opts.ShardKeyStrategy).
+		SetRelabelOptions(ToRelabelOptions(opts.RelabelOptions)).
+		SetMirrorOptions(ToMirrorOptions(opts.MirrorOptions)).
+		SetReshardOptions(ToReshardOptions(opts.ReshardOptions)).
+		SetQueryLimitsOptions(ToQueryLimitsOptions(opts.QueryLimitsOptions)).
+		SetWriteDurability(ts.Durability(opts.WriteDurability)).
+		SetValidationOptions(ToValidationOptions(opts.ValidationOptions))
 
 	return NewMetadata(ident.StringID(id), mopts)
 }
 
+// ToRetentionTiers converts []*nsproto.RetentionTier to []RetentionTier
+func ToRetentionTiers(tiers []*nsproto.RetentionTier) []RetentionTier {
+	if len(tiers) == 0 {
+		return nil
+	}
+	result := make([]RetentionTier, 0, len(tiers))
+	for _, tier := range tiers {
+		result = append(result, RetentionTier{
+			Resolution: fromNanos(tier.ResolutionNanos),
+			Retention:  fromNanos(tier.RetentionNanos),
+		})
+	}
+	return result
+}
+
+// ToArchivalOptions converts nsproto.ArchivalOptions to ArchivalOptions
+func ToArchivalOptions(ao *nsproto.ArchivalOptions) ArchivalOptions {
+	if ao == nil {
+		return ArchivalOptions{}
+	}
+	return ArchivalOptions{
+		Enabled:              ao.Enabled,
+		ImmutableAfter:       fromNanos(ao.ImmutableAfterNanos),
+		VerifyChecksumOnRead: ao.VerifyChecksumOnRead,
+	}
+}
+
+// ToCompactionOptions converts nsproto.CompactionOptions to CompactionOptions
+func ToCompactionOptions(co *nsproto.CompactionOptions) CompactionOptions {
+	if co == nil {
+		return CompactionOptions{}
+	}
+	return CompactionOptions{
+		Enabled:   co.Enabled,
+		BlockSize: fromNanos(co.BlockSizeNanos),
+		After:     fromNanos(co.AfterNanos),
+	}
+}
+
+// ToFutureWriteOptions converts nsproto.FutureWriteOptions to FutureWriteOptions
+func ToFutureWriteOptions(fo *nsproto.FutureWriteOptions) FutureWriteOptions {
+	if fo == nil {
+		return FutureWriteOptions{}
+	}
+	return FutureWriteOptions{
+		Enabled:   fo.Enabled,
+		Tolerance: fromNanos(fo.ToleranceNanos),
+		Action:    FutureWriteAction(fo.Action),
+	}
+}
+
+// ToExpiryDownsampleOptions converts nsproto.ExpiryDownsampleOptions to
+// ExpiryDownsampleOptions
+func ToExpiryDownsampleOptions(eo *nsproto.ExpiryDownsampleOptions) ExpiryDownsampleOptions {
+	if eo == nil {
+		return ExpiryDownsampleOptions{}
+	}
+	return ExpiryDownsampleOptions{
+		Enabled:         eo.Enabled,
+		TargetNamespace: eo.TargetNamespace,
+		Resolution:      fromNanos(eo.ResolutionNanos),
+	}
+}
+
+// ToRelabelOptions converts nsproto.RelabelOptions to RelabelOptions
+func ToRelabelOptions(ro *nsproto.RelabelOptions) RelabelOptions {
+	if ro == nil || len(ro.Rules) == 0 {
+		return RelabelOptions{}
+	}
+	rules := make([]RelabelRule, 0, len(ro.Rules))
+	for _, rule := range ro.Rules {
+		rules = append(rules, RelabelRule{
+			Action:      RelabelAction(rule.Action),
+			Name:        rule.Name,
+			Target:      rule.Target,
+			Value:       rule.Value,
+			HashBuckets: rule.HashBuckets,
+		})
+	}
+	return RelabelOptions{Rules: rules}
+}
+
+// ToMirrorOptions converts nsproto.MirrorOptions to MirrorOptions
+func ToMirrorOptions(mo *nsproto.MirrorOptions) MirrorOptions {
+	if mo == nil {
+		return MirrorOptions{}
+	}
+	var matchers []MirrorMatcher
+	for _, matcher := range mo.Matchers {
+		matchers = append(matchers, MirrorMatcher{
+			Name:  matcher.Name,
+			Value: matcher.Value,
+		})
+	}
+	return MirrorOptions{
+		Enabled:         mo.Enabled,
+		TargetNamespace: mo.TargetNamespace,
+		Percentage:      mo.Percentage,
+		Matchers:        matchers,
+	}
+}
+
+// ToReshardOptions converts nsproto.ReshardOptions to ReshardOptions
+func ToReshardOptions(ro *nsproto.ReshardOptions) ReshardOptions {
+	if ro == nil {
+		return ReshardOptions{}
+	}
+	return ReshardOptions{
+		Enabled:       ro.Enabled,
+		FromNumShards: ro.FromNumShards,
+		ToNumShards:   ro.ToNumShards,
+	}
+}
+
+// ToQueryLimitsOptions converts nsproto.QueryLimitsOptions to
+// QueryLimitsOptions
+func ToQueryLimitsOptions(qo *nsproto.QueryLimitsOptions) QueryLimitsOptions {
+	if qo == nil {
+		return QueryLimitsOptions{}
+	}
+	return QueryLimitsOptions{
+		MaxConcurrentQueries:   int(qo.MaxConcurrentQueries),
+		MaxInFlightResultBytes: qo.MaxInFlightResultBytes,
+	}
+}
+
+// ToValidationOptions converts nsproto.ValidationOptions to ValidationOptions
+func ToValidationOptions(vo *nsproto.ValidationOptions) ValidationOptions {
+	if vo == nil {
+		return ValidationOptions{}
+	}
+	return ValidationOptions{
+		RejectNaN:         vo.RejectNaN,
+		RejectInf:         vo.RejectInf,
+		BoundsEnabled:     vo.BoundsEnabled,
+		MinValue:          vo.MinValue,
+		MaxValue:          vo.MaxValue,
+		MaxAnnotationSize: int(vo.MaxAnnotationSize),
+	}
+}
+
 // ToProto converts Map to nsproto.Registry
 func ToProto(m Map) *nsproto.Registry {
 	reg := nsproto.Registry{
@@ -170,6 +325,121 @@ func OptionsToProto(opts Options) *nsproto.NamespaceOptions {
 			Enabled:        iopts.Enabled(),
 			BlockSizeNanos: iopts.BlockSize().Nanoseconds(),
 		},
-		ColdWritesEnabled: opts.ColdWritesEnabled(),
+		ColdWritesEnabled:       opts.ColdWritesEnabled(),
+		RetentionTiers:          retentionTiersToProto(opts.RetentionTiers()),
+		ArchivalOptions:         archivalOptionsToProto(opts.ArchivalOptions()),
+		CompactionOptions:       compactionOptionsToProto(opts.CompactionOptions()),
+		FutureWriteOptions:      futureWriteOptionsToProto(opts.FutureWriteOptions()),
+		ExpiryDownsampleOptions: expiryDownsampleOptionsToProto(opts.ExpiryDownsampleOptions()),
+		InMemory:                opts.InMemory(),
+		ShardKeyStrategy:        opts.ShardKeyStrategy(),
+		RelabelOptions:          relabelOptionsToProto(opts.RelabelOptions()),
+		MirrorOptions:           mirrorOptionsToProto(opts.MirrorOptions()),
+		ReshardOptions:          reshardOptionsToProto(opts.ReshardOptions()),
+		QueryLimitsOptions:      queryLimitsOptionsToProto(opts.QueryLimitsOptions()),
+		WriteDurability:         nsproto.WriteDurability(opts.WriteDurability()),
+		ValidationOptions:       validationOptionsToProto(opts.ValidationOptions()),
+	}
+}
+
+func retentionTiersToProto(tiers []RetentionTier) []*nsproto.RetentionTier {
+	if len(tiers) == 0 {
+		return nil
+	}
+	result := make([]*nsproto.RetentionTier, 0, len(tiers))
+	for _, tier := range tiers {
+		result = append(result, &nsproto.RetentionTier{
+			ResolutionNanos: tier.Resolution.Nanoseconds(),
+			RetentionNanos:  tier.Retention.Nanoseconds(),
+		})
+	}
+	return result
+}
+
+func archivalOptionsToProto(opts ArchivalOptions) *nsproto.ArchivalOptions {
+	return &nsproto.ArchivalOptions{
+		Enabled:              opts.Enabled,
+		ImmutableAfterNanos:  opts.ImmutableAfter.Nanoseconds(),
+		VerifyChecksumOnRead: opts.VerifyChecksumOnRead,
+	}
+}
+
+func compactionOptionsToProto(opts CompactionOptions) *nsproto.CompactionOptions {
+	return &nsproto.CompactionOptions{
+		Enabled:        opts.Enabled,
+		BlockSizeNanos: opts.BlockSize.Nanoseconds(),
+		AfterNanos:     opts.After.Nanoseconds(),
+	}
+}
+
+func futureWriteOptionsToProto(opts FutureWriteOptions) *nsproto.FutureWriteOptions {
+	return &nsproto.FutureWriteOptions{
+		Enabled:        opts.Enabled,
+		ToleranceNanos: opts.Tolerance.Nanoseconds(),
+		Action:         nsproto.FutureWriteAction(opts.Action),
+	}
+}
+
+func expiryDownsampleOptionsToProto(opts ExpiryDownsampleOptions) *nsproto.ExpiryDownsampleOptions {
+	return &nsproto.ExpiryDownsampleOptions{
+		Enabled:         opts.Enabled,
+		TargetNamespace: opts.TargetNamespace,
+		ResolutionNanos: opts.Resolution.Nanoseconds(),
+	}
+}
+
+func relabelOptionsToProto(opts RelabelOptions) *nsproto.RelabelOptions {
+	rules := make([]*nsproto.RelabelRule, 0, len(opts.Rules))
+	for _, rule := range opts.Rules {
+		rules = append(rules, &nsproto.RelabelRule{
+			Action:      nsproto.RelabelAction(rule.Action),
+			Name:        rule.Name,
+			Target:      rule.Target,
+			Value:       rule.Value,
+			HashBuckets: rule.HashBuckets,
+		})
+	}
+	return &nsproto.RelabelOptions{Rules: rules}
+}
+
+func mirrorOptionsToProto(opts MirrorOptions) *nsproto.MirrorOptions {
+	matchers := make([]*nsproto.MirrorMatcher, 0, len(opts.Matchers))
+	for _, matcher := range opts.Matchers {
+		matchers = append(matchers, &nsproto.MirrorMatcher{
+			Name:  matcher.Name,
+			Value: matcher.Value,
+		})
+	}
+	return &nsproto.MirrorOptions{
+		Enabled:         opts.Enabled,
+		TargetNamespace: opts.TargetNamespace,
+		Percentage:      opts.Percentage,
+		Matchers:        matchers,
+	}
+}
+
+func reshardOptionsToProto(opts ReshardOptions) *nsproto.ReshardOptions {
+	return &nsproto.ReshardOptions{
+		Enabled:       opts.Enabled,
+		FromNumShards: opts.FromNumShards,
+		ToNumShards:   opts.ToNumShards,
+	}
+}
+
+func queryLimitsOptionsToProto(opts QueryLimitsOptions) *nsproto.QueryLimitsOptions {
+	return &nsproto.QueryLimitsOptions{
+		MaxConcurrentQueries:   int64(opts.MaxConcurrentQueries),
+		MaxInFlightResultBytes: opts.MaxInFlightResultBytes,
+	}
+}
+
+func validationOptionsToProto(opts ValidationOptions) *nsproto.ValidationOptions {
+	return &nsproto.ValidationOptions{
+		RejectNaN:         opts.RejectNaN,
+		RejectInf:         opts.RejectInf,
+		BoundsEnabled:     opts.BoundsEnabled,
+		MinValue:          opts.MinValue,
+		MaxValue:          opts.MaxValue,
+		MaxAnnotationSize: int64(opts.MaxAnnotationSize),
 	}
 }
@@ -27,6 +27,8 @@ import (
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/ident"
 
 	"github.com/stretchr/testify/require"
@@ -271,6 +273,197 @@ func TestFromProtoSnapshotEnabled(t *testing.T) {
 	require.Equal(t, !namespace.NewOptions().SnapshotEnabled(), md.Options().SnapshotEnabled())
 }
 
+func TestOptionsToProtoRoundTrip(t *testing.T) {
+	base := namespace.NewOptions()
+	tests := []struct {
+		name string
+		opts namespace.Options
+	}{
+		{
+			name: "defaults",
+			opts: base,
+		},
+		{
+			name: "retention tiers",
+			opts: base.SetRetentionTiers([]namespace.RetentionTier{
+				{Resolution: 0, Retention: 24 * time.Hour},
+				{Resolution: time.Minute, Retention: 48 * time.Hour},
+			}),
+		},
+		{
+			name: "archival",
+			opts: base.SetArchivalOptions(namespace.ArchivalOptions{
+				Enabled:              true,
+				ImmutableAfter:       24 * time.Hour,
+				VerifyChecksumOnRead: true,
+			}),
+		},
+		{
+			name: "compaction",
+			opts: base.SetArchivalOptions(namespace.ArchivalOptions{
+				Enabled:        true,
+				ImmutableAfter: 24 * time.Hour,
+			}).SetCompactionOptions(namespace.CompactionOptions{
+				Enabled:   true,
+				BlockSize: 12 * time.Hour,
+				After:     36 * time.Hour,
+			}),
+		},
+		{
+			name: "future writes",
+			opts: base.SetFutureWriteOptions(namespace.FutureWriteOptions{
+				Enabled:   true,
+				Tolerance: time.Hour,
+				Action:    namespace.FutureWriteClamp,
+			}),
+		},
+		{
+			name: "expiry downsample",
+			opts: base.SetExpiryDownsampleOptions(namespace.ExpiryDownsampleOptions{
+				Enabled:         true,
+				TargetNamespace: "long",
+				Resolution:      time.Minute,
+			}),
+		},
+		{
+			name: "in-memory",
+			opts: namespace.NewInMemoryOptions(),
+		},
+		{
+			name: "shard key strategy",
+			opts: base.SetShardKeyStrategy(sharding.TenantPrefixShardKeyStrategy),
+		},
+		{
+			name: "relabel",
+			opts: base.SetRelabelOptions(namespace.RelabelOptions{
+				Rules: []namespace.RelabelRule{
+					{Action: namespace.RelabelDrop, Name: "pod"},
+					{Action: namespace.RelabelRename, Name: "host", Target: "instance"},
+					{Action: namespace.RelabelHash, Name: "user", HashBuckets: 16},
+					{Action: namespace.RelabelAdd, Name: "env", Value: "prod"},
+				},
+			}),
+		},
+		{
+			name: "mirror",
+			opts: base.SetMirrorOptions(namespace.MirrorOptions{
+				Enabled:         true,
+				TargetNamespace: "shadow",
+				Percentage:      12.5,
+				Matchers: []namespace.MirrorMatcher{
+					{Name: "service", Value: "api"},
+					{Name: "env"},
+				},
+			}),
+		},
+		{
+			name: "reshard",
+			opts: base.SetReshardOptions(namespace.ReshardOptions{
+				Enabled:       true,
+				FromNumShards: 4,
+				ToNumShards:   16,
+			}),
+		},
+		{
+			name: "query limits",
+			opts: base.SetQueryLimitsOptions(namespace.QueryLimitsOptions{
+				MaxConcurrentQueries:   8,
+				MaxInFlightResultBytes: 1 << 20,
+			}),
+		},
+		{
+			name: "write durability",
+			opts: base.SetWriteDurability(ts.DurabilityFsync),
+		},
+		{
+			name: "validation",
+			opts: base.SetValidationOptions(namespace.ValidationOptions{
+				RejectNaN:         true,
+				RejectInf:         true,
+				BoundsEnabled:     true,
+				MinValue:          -1,
+				MaxValue:          1,
+				MaxAnnotationSize: 64,
+			}),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			md, err := namespace.NewMetadata(ident.StringID("ns1"), test.opts)
+			require.NoError(t, err)
+			nsMap, err := namespace.NewMap([]namespace.Metadata{md})
+			require.NoError(t, err)
+
+			// Round trip through the wire format so that the options are
+			// preserved when stored in KV.
+			data, err := namespace.ToProto(nsMap).Marshal()
+			require.NoError(t, err)
+			var reg nsproto.Registry
+			require.NoError(t, reg.Unmarshal(data))
+
+			nsMap, err = namespace.FromProto(reg)
+			require.NoError(t, err)
+			observed, err := nsMap.Get(ident.StringID("ns1"))
+			require.NoError(t, err)
+			require.True(t, test.opts.Equal(observed.Options()))
+		})
+	}
+}
+
+func TestToMetadataNamespaceOptions(t *testing.T) {
+	opts := validNamespaceOpts[0]
+	opts.RetentionTiers = []*nsproto.RetentionTier{
+		{ResolutionNanos: 0, RetentionNanos: toNanos(600)},
+		{ResolutionNanos: toNanos(1), RetentionNanos: toNanos(1200)},
+	}
+	opts.ArchivalOptions = &nsproto.ArchivalOptions{
+		Enabled:             true,
+		ImmutableAfterNanos: toNanos(600),
+	}
+	opts.FutureWriteOptions = &nsproto.FutureWriteOptions{
+		Enabled:        true,
+		ToleranceNanos: toNanos(60),
+		Action:         nsproto.FutureWriteAction_CLAMP,
+	}
+	opts.RelabelOptions = &nsproto.RelabelOptions{
+		Rules: []*nsproto.RelabelRule{
+			{Action: nsproto.RelabelAction_RENAME, Name: "host", Target: "instance"},
+		},
+	}
+	opts.WriteDurability = nsproto.WriteDurability_COMMIT_LOG_BUFFER
+
+	md, err := namespace.ToMetadata("abc", &opts)
+	require.NoError(t, err)
+	assertEqualMetadata(t, "abc", opts, md)
+
+	observed := md.Options()
+	require.Equal(t, []namespace.RetentionTier{
+		{Resolution: 0, Retention: 10 * time.Hour},
+		{Resolution: time.Minute, Retention: 20 * time.Hour},
+	}, observed.RetentionTiers())
+	require.Equal(t, namespace.ArchivalOptions{
+		Enabled:        true,
+		ImmutableAfter: 10 * time.Hour,
+	}, observed.ArchivalOptions())
+	require.Equal(t, namespace.FutureWriteOptions{
+		Enabled:   true,
+		Tolerance: time.Hour,
+		Action:    namespace.FutureWriteClamp,
+	}, observed.FutureWriteOptions())
+	require.Equal(t, namespace.RelabelOptions{
+		Rules: []namespace.RelabelRule{
+			{Action: namespace.RelabelRename, Name: "host", Target: "instance"},
+		},
+	}, observed.RelabelOptions())
+	require.Equal(t, ts.DurabilityCommitLogBuffer, observed.WriteDurability())
+
+	// Options that fail validation are rejected.
+	opts.FutureWriteOptions.ToleranceNanos = 0
+	_, err = namespace.ToMetadata("abc", &opts)
+	require.Error(t, err)
+}
+
 func assertEqualMetadata(t *testing.T, name string, expected nsproto.NamespaceOptions, observed namespace.Metadata) {
 	require.Equal(t, name, observed.ID().String())
 	opts := observed.Options()
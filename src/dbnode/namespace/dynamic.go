@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/cluster/kv"
+	kvutil "github.com/m3db/m3/src/cluster/kv/util"
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
 	xwatch "github.com/m3db/m3/src/x/watch"
 
@@ -78,20 +79,26 @@ type dynamicRegistry struct {
 	watchable    xwatch.Watchable
 	kvWatch      kv.ValueWatch
 	currentValue kv.Value
+	cachedValue  bool
 	currentMap   Map
+	staged       *stagedRegistryWatch
+	stagedMap    Map
+	publishedMap Map
 	closed       bool
 }
 
 type dynamicRegistryMetrics struct {
-	numInvalidUpdates tally.Counter
-	currentVersion    tally.Gauge
+	numInvalidUpdates       tally.Counter
+	numInvalidStagedUpdates tally.Counter
+	currentVersion          tally.Gauge
 }
 
 func newDynamicRegistryMetrics(opts DynamicOptions) dynamicRegistryMetrics {
 	scope := opts.InstrumentOptions().MetricsScope().SubScope("namespace-registry")
 	return dynamicRegistryMetrics{
-		numInvalidUpdates: scope.Counter("invalid-update"),
-		currentVersion:    scope.Gauge("current-version"),
+		numInvalidUpdates:       scope.Counter("invalid-update"),
+		numInvalidStagedUpdates: scope.Counter("invalid-staged-update"),
+		currentVersion:          scope.Gauge("current-version"),
 	}
 }
 
@@ -109,33 +116,96 @@ func newDynamicRegistry(opts DynamicOptions) (Registry, error) {
 	logger := opts.InstrumentOptions().Logger()
 	logger.Info("waiting for dynamic namespace registry initialization, " +
 		"if this takes a long time, make sure that a namespace is configured")
-	<-watch.C()
-	logger.Info("initial namespace value received")
+	initValue, cached := waitForInitValue(watch, opts, logger)
+	logger.Info("initial namespace value received", zap.Bool("localCache", cached))
 
-	initValue := watch.Get()
 	m, err := getMapFromUpdate(initValue)
 	if err != nil {
 		logger.Error("dynamic namespace registry received invalid initial value", zap.Error(err))
 		return nil, err
 	}
 
-	watchable := xwatch.NewWatchable()
-	watchable.Update(m)
+	var staged *stagedRegistryWatch
+	if hostID := opts.HostID(); hostID != "" {
+		staged, err = newStagedRegistryWatch(kvStore, opts.NamespaceRegistryKey(), hostID)
+		if err != nil {
+			watch.Close()
+			return nil, err
+		}
+	}
 
 	dt := &dynamicRegistry{
 		opts:         opts,
 		logger:       logger,
 		metrics:      newDynamicRegistryMetrics(opts),
-		watchable:    watchable,
+		watchable:    xwatch.NewWatchable(),
 		kvWatch:      watch,
 		currentValue: initValue,
+		cachedValue:  cached,
 		currentMap:   m,
+		staged:       staged,
 	}
+	if !cached {
+		dt.persist(initValue)
+	}
+	dt.updateStaged()
 	go dt.run()
 	go dt.reportMetrics()
 	return dt, nil
 }
 
+// waitForInitValue waits for the initial registry from the config service,
+// if a local cache is configured and the config service does not return the
+// registry within the init timeout the registry persisted to the local cache
+// is returned instead and reconciled with the config service once available.
+func waitForInitValue(
+	watch kv.ValueWatch,
+	opts DynamicOptions,
+	logger *zap.Logger,
+) (kv.Value, bool) {
+	path := opts.LocalCachePath()
+	if path == "" {
+		<-watch.C()
+		return watch.Get(), false
+	}
+
+	select {
+	case <-watch.C():
+		return watch.Get(), false
+	case <-time.After(opts.InitTimeout()):
+	}
+
+	value, err := kvutil.ReadPersistedValue(path)
+	if err != nil {
+		logger.Warn("could not read namespace registry local cache, "+
+			"waiting for config service", zap.String("path", path), zap.Error(err))
+		<-watch.C()
+		return watch.Get(), false
+	}
+
+	logger.Warn("namespace registry not received from config service within timeout, "+
+		"using local cache", zap.String("path", path), zap.Int("version", value.Version()))
+	return value, true
+}
+
+// persist persists the registry to the local cache if one is configured.
+func (r *dynamicRegistry) persist(val kv.Value) {
+	path := r.opts.LocalCachePath()
+	if path == "" {
+		return
+	}
+
+	var protoRegistry nsproto.Registry
+	err := val.Unmarshal(&protoRegistry)
+	if err == nil {
+		err = kvutil.PersistValue(path, val.Version(), &protoRegistry)
+	}
+	if err != nil {
+		r.logger.Warn("could not persist namespace registry to local cache",
+			zap.String("path", path), zap.Error(err))
+	}
+}
+
 func (r *dynamicRegistry) isClosed() bool {
 	r.RLock()
 	closed := r.closed
@@ -169,46 +239,152 @@ func (r *dynamicRegistry) reportMetrics() {
 }
 
 func (r *dynamicRegistry) run() {
+	var stagedRegistryC, stagedHostsC <-chan struct{}
+	if r.staged != nil {
+		stagedRegistryC = r.staged.registry.C()
+		stagedHostsC = r.staged.hosts.C()
+	}
+
 	for !r.isClosed() {
-		if _, ok := <-r.kvWatch.C(); !ok {
+		var ok bool
+		select {
+		case _, ok = <-r.kvWatch.C():
+			if ok {
+				r.update()
+			}
+		case _, ok = <-stagedRegistryC:
+			if ok {
+				r.updateStaged()
+			}
+		case _, ok = <-stagedHostsC:
+			if ok {
+				r.updateStaged()
+			}
+		}
+		if !ok {
 			r.Close()
 			break
 		}
+	}
+}
 
-		val := r.kvWatch.Get()
-		if val == nil {
-			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warn("dynamic namespace registry received nil, skipping")
-			continue
-		}
+func (r *dynamicRegistry) update() {
+	val := r.kvWatch.Get()
+	if val == nil {
+		r.metrics.numInvalidUpdates.Inc(1)
+		r.logger.Warn("dynamic namespace registry received nil, skipping")
+		return
+	}
 
-		if !val.IsNewer(r.currentValue) {
-			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warn("dynamic namespace registry received older version, skipping",
-				zap.Int("version", val.Version()))
-			continue
-		}
+	r.RLock()
+	cached := r.cachedValue
+	r.RUnlock()
 
-		m, err := getMapFromUpdate(val)
-		if err != nil {
-			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warn("dynamic namespace registry received invalid update, skipping",
-				zap.Error(err))
-			continue
+	// NB: The registry read from the local cache is replaced by the first
+	// registry from the config service since the config service is the
+	// source of truth, even if it has an older version.
+	if !cached && !val.IsNewer(r.value()) {
+		r.metrics.numInvalidUpdates.Inc(1)
+		r.logger.Warn("dynamic namespace registry received older version, skipping",
+			zap.Int("version", val.Version()))
+		return
+	}
+
+	m, err := getMapFromUpdate(val)
+	if err != nil {
+		r.metrics.numInvalidUpdates.Inc(1)
+		r.logger.Warn("dynamic namespace registry received invalid update, skipping",
+			zap.Error(err))
+		return
+	}
+
+	if m.Equal(r.maps()) {
+		if cached {
+			r.logger.Info("dynamic namespace registry reconciled local cache with config service")
+			r.Lock()
+			r.currentValue = val
+			r.cachedValue = false
+			r.Unlock()
+			r.persist(val)
+			return
 		}
+		r.metrics.numInvalidUpdates.Inc(1)
+		r.logger.Warn("dynamic namespace registry received identical update, skipping")
+		return
+	}
 
-		if m.Equal(r.maps()) {
-			r.metrics.numInvalidUpdates.Inc(1)
-			r.logger.Warn("dynamic namespace registry received identical update, skipping")
-			continue
+	r.logger.Info("dynamic namespace registry updated to version", zap.Int("version", val.Version()))
+	r.Lock()
+	r.currentValue = val
+	r.cachedValue = false
+	r.currentMap = m
+	r.Unlock()
+	r.persist(val)
+
+	// The staged registry needs validating against the new registry.
+	r.updateStaged()
+}
+
+// updateStaged applies the staged registry if it has been rolled out to
+// this host and is a valid update of the current registry, reporting back
+// any error so the rollout can be rolled back.
+func (r *dynamicRegistry) updateStaged() {
+	var (
+		staged Map
+		err    error
+	)
+	if r.staged != nil {
+		staged, err = r.staged.stagedMap()
+		if err == nil && staged != nil {
+			err = ValidateMapUpdate(r.maps(), staged)
 		}
+	}
+	if err != nil {
+		r.metrics.numInvalidStagedUpdates.Inc(1)
+		r.logger.Warn("dynamic namespace registry received invalid staged update, skipping",
+			zap.Error(err))
+		r.reportStagedError(err)
+		staged = nil
+	}
 
-		r.logger.Info("dynamic namespace registry updated to version", zap.Int("version", val.Version()))
-		r.Lock()
-		r.currentValue = val
-		r.currentMap = m
-		r.watchable.Update(m)
-		r.Unlock()
+	r.Lock()
+	defer r.Unlock()
+
+	r.stagedMap = staged
+	m := r.currentMap
+	if staged != nil {
+		m = staged
+	}
+	if r.publishedMap != nil && m.Equal(r.publishedMap) {
+		return
+	}
+	if staged != nil {
+		r.logger.Info("dynamic namespace registry applying staged update")
+	}
+	r.publishedMap = m
+	r.watchable.Update(m)
+}
+
+// reportUpdateError reports an error applying a registry published to the
+// watchers, which is only of interest if the staged registry was published.
+func (r *dynamicRegistry) reportUpdateError(m Map, err error) {
+	r.RLock()
+	staged := r.stagedMap
+	r.RUnlock()
+
+	if staged == nil || !staged.Equal(m) {
+		return
+	}
+	r.reportStagedError(err)
+}
+
+func (r *dynamicRegistry) reportStagedError(err error) {
+	if r.staged == nil {
+		return
+	}
+	if reportErr := r.staged.reportError(err); reportErr != nil {
+		r.logger.Error("could not report staged namespace registry error",
+			zap.Error(reportErr))
 	}
 }
 
@@ -217,7 +393,7 @@ func (r *dynamicRegistry) Watch() (Watch, error) {
 	if err != nil {
 		return nil, err
 	}
-	return NewWatch(w), err
+	return dynamicWatch{Watch: NewWatch(w), registry: r}, err
 }
 
 func (r *dynamicRegistry) Close() error {
@@ -231,10 +407,24 @@ func (r *dynamicRegistry) Close() error {
 	r.closed = true
 
 	r.kvWatch.Close()
+	if r.staged != nil {
+		r.staged.Close()
+	}
 	r.watchable.Close()
 	return nil
 }
 
+// dynamicWatch is a watch that reports errors applying updates back to the
+// dynamic registry.
+type dynamicWatch struct {
+	Watch
+	registry *dynamicRegistry
+}
+
+func (w dynamicWatch) reportUpdateError(m Map, err error) {
+	w.registry.reportUpdateError(m, err)
+}
+
 func getMapFromUpdate(val kv.Value) (Map, error) {
 	if val == nil {
 		return nil, errInvalidRegistry
@@ -44,6 +44,8 @@ type dynamicOpts struct {
 	csClient      client.Client
 	nsRegistryKey string
 	initTimeout   time.Duration
+	hostID        string
+	cachePath     string
 }
 
 // NewDynamicOptions creates a new DynamicOptions
@@ -97,3 +99,33 @@ func (o *dynamicOpts) SetNamespaceRegistryKey(k string) DynamicOptions {
 func (o *dynamicOpts) NamespaceRegistryKey() string {
 	return o.nsRegistryKey
 }
+
+func (o *dynamicOpts) SetHostID(value string) DynamicOptions {
+	opts := *o
+	opts.hostID = value
+	return &opts
+}
+
+func (o *dynamicOpts) HostID() string {
+	return o.hostID
+}
+
+func (o *dynamicOpts) SetInitTimeout(value time.Duration) DynamicOptions {
+	opts := *o
+	opts.initTimeout = value
+	return &opts
+}
+
+func (o *dynamicOpts) InitTimeout() time.Duration {
+	return o.initTimeout
+}
+
+func (o *dynamicOpts) SetLocalCachePath(value string) DynamicOptions {
+	opts := *o
+	opts.cachePath = value
+	return &opts
+}
+
+func (o *dynamicOpts) LocalCachePath() string {
+	return o.cachePath
+}
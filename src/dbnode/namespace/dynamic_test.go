@@ -21,13 +21,21 @@
 package namespace
 
 import (
+	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
 	"github.com/m3db/m3/src/cluster/client"
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
 	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	kvutil "github.com/m3db/m3/src/cluster/kv/util"
 	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -325,6 +333,67 @@ func TestInitializerUpdateSuccess(t *testing.T) {
 	require.NoError(t, reg.Close())
 }
 
+func TestInitializerLocalCache(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "namespace-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "registry.json")
+
+	// The registry received from the config service is persisted.
+	initValue := singleTestValue()
+	w := newTestWatchable(t, initValue)
+	defer w.Close()
+
+	opts := newTestOpts(t, ctrl, w).SetLocalCachePath(cachePath)
+	reg, err := NewDynamicInitializer(opts).Init()
+	require.NoError(t, err)
+	require.NoError(t, reg.Close())
+
+	cached, err := kvutil.ReadPersistedValue(cachePath)
+	require.NoError(t, err)
+	require.Equal(t, initValue.Version(), cached.Version())
+
+	// Without a registry from the config service the persisted registry is used.
+	emptyWatchable := newTestWatchable(t, nil)
+	defer emptyWatchable.Close()
+
+	opts = newTestOpts(t, ctrl, emptyWatchable).
+		SetLocalCachePath(cachePath).
+		SetInitTimeout(10 * time.Millisecond)
+	reg, err = NewDynamicInitializer(opts).Init()
+	require.NoError(t, err)
+
+	rmap, err := reg.Watch()
+	require.NoError(t, err)
+	require.Len(t, rmap.Get().Metadatas(), 1)
+
+	// Once available the config service registry replaces the cached
+	// registry, even with an older version.
+	require.NoError(t, emptyWatchable.Update(&testValue{
+		version: 0,
+		Registry: nsproto.Registry{
+			Namespaces: map[string]*nsproto.NamespaceOptions{
+				"testns1": initValue.Namespaces["testns1"],
+				"testns2": initValue.Namespaces["testns1"],
+			},
+		},
+	}))
+	for len(rmap.Get().Metadatas()) != 2 {
+		time.Sleep(10 * time.Millisecond)
+	}
+	require.Equal(t, int64(0), numInvalidUpdates(opts))
+	require.NoError(t, reg.Close())
+
+	cached, err = kvutil.ReadPersistedValue(cachePath)
+	require.NoError(t, err)
+	require.Equal(t, 0, cached.Version())
+}
+
 func singleTestValue() *testValue {
 	return &testValue{
 		version: 1,
@@ -384,3 +453,143 @@ func newTestWatchable(t *testing.T, initValue *testValue) kv.ValueWatchable {
 func toNanosInt64(t time.Duration) int64 {
 	return xtime.ToNormalizedDuration(t, time.Nanosecond)
 }
+
+func TestInitializerStagedRollout(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mem.NewStore()
+	initValue := singleTestValue()
+	_, err := store.Set(defaultNsRegistryKey, &initValue.Registry)
+	require.NoError(t, err)
+
+	mockCSClient := client.NewMockClient(ctrl)
+	mockCSClient.EXPECT().KV().Return(store, nil)
+
+	opts := NewDynamicOptions().
+		SetInstrumentOptions(instrument.NewOptions().
+			SetMetricsScope(tally.NewTestScope("", nil))).
+		SetConfigServiceClient(mockCSClient).
+		SetHostID("host1")
+	reg, err := NewDynamicInitializer(opts).Init()
+	require.NoError(t, err)
+
+	rw, err := reg.Watch()
+	require.NoError(t, err)
+	committed := rw.Get()
+
+	var (
+		nsOpts     = *initValue.Namespaces["testns1"]
+		retention  = *nsOpts.RetentionOptions
+		stagedOpts = nsOpts
+	)
+	retention.RetentionPeriodNanos = toNanosInt64(96 * time.Hour)
+	stagedOpts.RetentionOptions = &retention
+	_, err = store.Set(StagedRegistryKey(defaultNsRegistryKey), &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{"testns1": &stagedOpts},
+	})
+	require.NoError(t, err)
+
+	retentionPeriod := func() time.Duration {
+		md, err := rw.Get().Get(ident.StringID("testns1"))
+		require.NoError(t, err)
+		return md.Options().RetentionOptions().RetentionPeriod()
+	}
+	waitFor := func(fn func() bool) {
+		start := time.Now()
+		for !fn() {
+			require.True(t, time.Since(start) < 10*time.Second)
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Only applied once rolled out to the host.
+	_, err = store.Set(StagedRegistryHostsKey(defaultNsRegistryKey),
+		&commonpb.StringArrayProto{Values: []string{"host2"}})
+	require.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	require.Equal(t, 48*time.Hour, retentionPeriod())
+
+	_, err = store.Set(StagedRegistryHostsKey(defaultNsRegistryKey),
+		&commonpb.StringArrayProto{Values: []string{"host1", "host2"}})
+	require.NoError(t, err)
+	waitFor(func() bool { return retentionPeriod() == 96*time.Hour })
+
+	// Errors applying the staged registry are reported.
+	reporter, ok := rw.(updateErrorReporter)
+	require.True(t, ok)
+	reporter.reportUpdateError(committed, errors.New("not staged"))
+	_, err = store.Get(StagedRegistryErrorKey(defaultNsRegistryKey, "host1"))
+	require.Equal(t, kv.ErrNotFound, err)
+
+	reporter.reportUpdateError(rw.Get(), errors.New("failed to apply"))
+	value, err := store.Get(StagedRegistryErrorKey(defaultNsRegistryKey, "host1"))
+	require.NoError(t, err)
+	var reported commonpb.StringProto
+	require.NoError(t, value.Unmarshal(&reported))
+	require.Equal(t, "failed to apply", reported.Value)
+
+	// Rolling back reverts to the committed registry.
+	_, err = store.Delete(StagedRegistryHostsKey(defaultNsRegistryKey))
+	require.NoError(t, err)
+	waitFor(func() bool { return retentionPeriod() == 48*time.Hour })
+
+	require.NoError(t, rw.Close())
+	require.NoError(t, reg.Close())
+}
+
+func TestInitializerStagedRolloutInvalidUpdate(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Second)()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	store := mem.NewStore()
+	initValue := singleTestValue()
+	_, err := store.Set(defaultNsRegistryKey, &initValue.Registry)
+	require.NoError(t, err)
+
+	var (
+		nsOpts     = *initValue.Namespaces["testns1"]
+		retention  = *nsOpts.RetentionOptions
+		stagedOpts = nsOpts
+	)
+	retention.BlockSizeNanos = toNanosInt64(4 * time.Hour)
+	stagedOpts.RetentionOptions = &retention
+	_, err = store.Set(StagedRegistryKey(defaultNsRegistryKey), &nsproto.Registry{
+		Namespaces: map[string]*nsproto.NamespaceOptions{"testns1": &stagedOpts},
+	})
+	require.NoError(t, err)
+	_, err = store.Set(StagedRegistryHostsKey(defaultNsRegistryKey),
+		&commonpb.StringArrayProto{Values: []string{"host1"}})
+	require.NoError(t, err)
+
+	mockCSClient := client.NewMockClient(ctrl)
+	mockCSClient.EXPECT().KV().Return(store, nil)
+
+	scope := tally.NewTestScope("", nil)
+	opts := NewDynamicOptions().
+		SetInstrumentOptions(instrument.NewOptions().SetMetricsScope(scope)).
+		SetConfigServiceClient(mockCSClient).
+		SetHostID("host1")
+	reg, err := NewDynamicInitializer(opts).Init()
+	require.NoError(t, err)
+
+	// The invalid staged registry is not applied and the error reported.
+	rw, err := reg.Watch()
+	require.NoError(t, err)
+	md, err := rw.Get().Get(ident.StringID("testns1"))
+	require.NoError(t, err)
+	require.Equal(t, 2*time.Hour, md.Options().RetentionOptions().BlockSize())
+
+	_, err = store.Get(StagedRegistryErrorKey(defaultNsRegistryKey, "host1"))
+	require.NoError(t, err)
+	counter, ok := scope.Snapshot().Counters()["namespace-registry.invalid-staged-update+"]
+	require.True(t, ok)
+	require.Equal(t, int64(1), counter.Value())
+
+	require.NoError(t, rw.Close())
+	require.NoError(t, reg.Close())
+}
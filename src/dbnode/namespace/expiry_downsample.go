@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	errExpiryDownsampleNamespaceEmpty = errors.New(
+		"expiry downsample target namespace must be set")
+	errExpiryDownsampleResolutionPositive = errors.New(
+		"expiry downsample resolution must be positive")
+)
+
+// ExpiryDownsampleOptions controls whether the data of a namespace is rolled
+// up into a longer retention namespace just before it ages out of retention.
+// The target namespace must accept writes for the time range of the expiring
+// blocks, typically by having cold writes enabled.
+type ExpiryDownsampleOptions struct {
+	// Enabled is whether expiring blocks are downsampled.
+	Enabled bool
+	// TargetNamespace is the namespace the downsampled series are written to.
+	TargetNamespace string
+	// Resolution is the resolution of the downsampled series, the last
+	// datapoint of every resolution window of a series is kept.
+	Resolution time.Duration
+}
+
+func validateExpiryDownsampleOptions(o ExpiryDownsampleOptions) error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.TargetNamespace == "" {
+		return errExpiryDownsampleNamespaceEmpty
+	}
+	if o.Resolution <= 0 {
+		return errExpiryDownsampleResolutionPositive
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+var (
+	errFutureWriteActionUnspecified = errors.New("future write action unspecified")
+	errFutureWriteTolerancePositive = errors.New("future write tolerance must be positive")
+)
+
+// FutureWriteAction is the action taken for a write that is timestamped
+// further in the future than the future write tolerance of a namespace.
+type FutureWriteAction uint
+
+const (
+	// FutureWriteReject rejects writes beyond the future write tolerance.
+	FutureWriteReject FutureWriteAction = iota
+	// FutureWriteClamp clamps the timestamp of writes beyond the future
+	// write tolerance to the tolerance.
+	FutureWriteClamp
+)
+
+// ValidFutureWriteActions returns the valid future write actions.
+func ValidFutureWriteActions() []FutureWriteAction {
+	return []FutureWriteAction{FutureWriteReject, FutureWriteClamp}
+}
+
+func (a FutureWriteAction) String() string {
+	switch a {
+	case FutureWriteReject:
+		return "reject"
+	case FutureWriteClamp:
+		return "clamp"
+	}
+	return "unknown"
+}
+
+// ParseFutureWriteAction parses a FutureWriteAction from a string.
+func ParseFutureWriteAction(str string) (FutureWriteAction, error) {
+	var r FutureWriteAction
+	if str == "" {
+		return r, errFutureWriteActionUnspecified
+	}
+	for _, valid := range ValidFutureWriteActions() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid FutureWriteAction '%s' valid types are: %v",
+		str, ValidFutureWriteActions())
+}
+
+// UnmarshalYAML unmarshals a FutureWriteAction into a valid type from string.
+func (a *FutureWriteAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseFutureWriteAction(str)
+	if err != nil {
+		return err
+	}
+	*a = r
+	return nil
+}
+
+// FutureWriteOptions controls how far in the future writes to a namespace
+// may be timestamped, protecting the namespace from clock-skewed clients
+// allocating blocks far in the future.
+type FutureWriteOptions struct {
+	// Enabled is whether the future write tolerance is enforced.
+	Enabled bool
+	// Tolerance is how far ahead of now a write may be timestamped.
+	Tolerance time.Duration
+	// Action is the action taken for writes beyond the tolerance.
+	Action FutureWriteAction
+}
+
+// FutureLimit returns the latest time a write may be timestamped at the
+// given time, returning false if the tolerance is not enforced.
+func (o FutureWriteOptions) FutureLimit(now time.Time) (time.Time, bool) {
+	if !o.Enabled {
+		return time.Time{}, false
+	}
+	return now.Add(o.Tolerance), true
+}
+
+// Clamp returns the timestamp a write timestamped at the given time is
+// applied at, returning true if the timestamp is beyond the tolerance and the
+// clamp action is configured.
+func (o FutureWriteOptions) Clamp(timestamp, now time.Time) (time.Time, bool) {
+	limit, ok := o.FutureLimit(now)
+	if !ok || o.Action != FutureWriteClamp || !timestamp.After(limit) {
+		return timestamp, false
+	}
+	return limit, true
+}
+
+func validateFutureWriteOptions(o FutureWriteOptions) error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.Tolerance <= 0 {
+		return errFutureWriteTolerancePositive
+	}
+	for _, valid := range ValidFutureWriteActions() {
+		if o.Action == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid FutureWriteAction '%d' valid types are: %v",
+		uint(o.Action), ValidFutureWriteActions())
+}
@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestFutureWriteOptionsFutureLimit(t *testing.T) {
+	now := time.Now()
+	opts := FutureWriteOptions{Enabled: true, Tolerance: time.Hour}
+
+	limit, ok := opts.FutureLimit(now)
+	require.True(t, ok)
+	require.True(t, now.Add(time.Hour).Equal(limit))
+
+	opts.Enabled = false
+	_, ok = opts.FutureLimit(now)
+	require.False(t, ok)
+}
+
+func TestFutureWriteOptionsClamp(t *testing.T) {
+	now := time.Now()
+	opts := FutureWriteOptions{
+		Enabled:   true,
+		Tolerance: time.Hour,
+		Action:    FutureWriteClamp,
+	}
+
+	ts, clamped := opts.Clamp(now.Add(30*time.Minute), now)
+	require.False(t, clamped)
+	require.True(t, now.Add(30*time.Minute).Equal(ts))
+
+	ts, clamped = opts.Clamp(now.Add(2*time.Hour), now)
+	require.True(t, clamped)
+	require.True(t, now.Add(time.Hour).Equal(ts))
+
+	opts.Action = FutureWriteReject
+	ts, clamped = opts.Clamp(now.Add(2*time.Hour), now)
+	require.False(t, clamped)
+	require.True(t, now.Add(2*time.Hour).Equal(ts))
+
+	opts.Action = FutureWriteClamp
+	opts.Enabled = false
+	_, clamped = opts.Clamp(now.Add(2*time.Hour), now)
+	require.False(t, clamped)
+}
+
+func TestFutureWriteOptionsValidate(t *testing.T) {
+	opts := NewOptions()
+
+	require.NoError(t, opts.SetFutureWriteOptions(FutureWriteOptions{}).Validate())
+	require.NoError(t, opts.SetFutureWriteOptions(FutureWriteOptions{
+		Enabled:   true,
+		Tolerance: time.Hour,
+		Action:    FutureWriteClamp,
+	}).Validate())
+	require.Equal(t, errFutureWriteTolerancePositive, opts.SetFutureWriteOptions(FutureWriteOptions{
+		Enabled: true,
+	}).Validate())
+	require.Error(t, opts.SetFutureWriteOptions(FutureWriteOptions{
+		Enabled:   true,
+		Tolerance: time.Hour,
+		Action:    FutureWriteAction(100),
+	}).Validate())
+}
+
+func TestParseFutureWriteAction(t *testing.T) {
+	for _, valid := range ValidFutureWriteActions() {
+		action, err := ParseFutureWriteAction(valid.String())
+		require.NoError(t, err)
+		require.Equal(t, valid, action)
+	}
+
+	_, err := ParseFutureWriteAction("")
+	require.Equal(t, errFutureWriteActionUnspecified, err)
+	_, err = ParseFutureWriteAction("drop")
+	require.Error(t, err)
+}
+
+func TestMetadataConfigFutureWrites(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 24h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+futureWrites:
+  tolerance: 1h
+  action: clamp
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, FutureWriteOptions{
+		Enabled:   true,
+		Tolerance: time.Hour,
+		Action:    FutureWriteClamp,
+	}, md.Options().FutureWriteOptions())
+}
@@ -0,0 +1,63 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"errors"
+)
+
+var (
+	errInMemoryPersistenceEnabled = errors.New(
+		"in-memory namespaces must disable bootstrapping, flushing, snapshotting, " +
+			"commit log writes, cleanup and repair")
+	errInMemoryFilesetFeaturesEnabled = errors.New(
+		"in-memory namespaces do not support retention tiers, archival, compaction or expiry downsampling")
+)
+
+// NewInMemoryOptions returns options for an in-memory namespace, with all
+// persistence related features disabled.
+func NewInMemoryOptions() Options {
+	return NewOptions().
+		SetInMemory(true).
+		SetBootstrapEnabled(false).
+		SetFlushEnabled(false).
+		SetSnapshotEnabled(false).
+		SetWritesToCommitLog(false).
+		SetCleanupEnabled(false).
+		SetRepairEnabled(false)
+}
+
+// validateInMemory validates that an in-memory namespace does not enable
+// any feature that relies on the commit log or filesets.
+func validateInMemory(o Options) error {
+	if !o.InMemory() {
+		return nil
+	}
+	if o.BootstrapEnabled() || o.FlushEnabled() || o.SnapshotEnabled() ||
+		o.WritesToCommitLog() || o.CleanupEnabled() || o.RepairEnabled() {
+		return errInMemoryPersistenceEnabled
+	}
+	if len(o.RetentionTiers()) > 0 || o.ArchivalOptions().Enabled ||
+		o.CompactionOptions().Enabled || o.ExpiryDownsampleOptions().Enabled {
+		return errInMemoryFilesetFeaturesEnabled
+	}
+	return nil
+}
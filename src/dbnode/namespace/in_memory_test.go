@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestInMemoryOptionsValidate(t *testing.T) {
+	opts := NewInMemoryOptions()
+	require.True(t, opts.InMemory())
+	require.NoError(t, opts.Validate())
+
+	require.Equal(t, errInMemoryPersistenceEnabled,
+		opts.SetFlushEnabled(true).Validate())
+	require.Equal(t, errInMemoryPersistenceEnabled,
+		opts.SetWritesToCommitLog(true).Validate())
+	require.Equal(t, errInMemoryPersistenceEnabled,
+		opts.SetBootstrapEnabled(true).Validate())
+	require.Equal(t, errInMemoryFilesetFeaturesEnabled,
+		opts.SetExpiryDownsampleOptions(ExpiryDownsampleOptions{
+			Enabled:         true,
+			TargetNamespace: "other",
+			Resolution:      opts.RetentionOptions().BlockSize(),
+		}).Validate())
+
+	// Persistent namespaces are unaffected.
+	require.NoError(t, NewOptions().Validate())
+	require.False(t, NewOptions().InMemory())
+	require.False(t, NewOptions().Equal(opts))
+}
+
+func TestInMemoryConfiguration(t *testing.T) {
+	str := `
+id: requests
+inMemory: true
+retention:
+  retentionPeriod: 1h
+  blockSize: 10m
+  bufferFuture: 1m
+  bufferPast: 1m
+`
+	var cfg MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+
+	md, err := cfg.Metadata()
+	require.NoError(t, err)
+
+	opts := md.Options()
+	require.True(t, opts.InMemory())
+	require.False(t, opts.BootstrapEnabled())
+	require.False(t, opts.FlushEnabled())
+	require.False(t, opts.SnapshotEnabled())
+	require.False(t, opts.WritesToCommitLog())
+	require.False(t, opts.CleanupEnabled())
+	require.False(t, opts.RepairEnabled())
+
+	// Explicitly re-enabling persistence is rejected.
+	cfg.FlushEnabled = new(bool)
+	*cfg.FlushEnabled = true
+	_, err = cfg.Metadata()
+	require.Error(t, err)
+}
@@ -155,3 +155,97 @@ func (mr *MockNamespaceMetadataAdminServiceMockRecorder) ResetSchema(name interf
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ResetSchema", reflect.TypeOf((*MockNamespaceMetadataAdminService)(nil).ResetSchema), name)
 }
+
+// MockNamespaceRolloutService is a mock of NamespaceRolloutService interface
+type MockNamespaceRolloutService struct {
+	ctrl     *gomock.Controller
+	recorder *MockNamespaceRolloutServiceMockRecorder
+}
+
+// MockNamespaceRolloutServiceMockRecorder is the mock recorder for MockNamespaceRolloutService
+type MockNamespaceRolloutServiceMockRecorder struct {
+	mock *MockNamespaceRolloutService
+}
+
+// NewMockNamespaceRolloutService creates a new mock instance
+func NewMockNamespaceRolloutService(ctrl *gomock.Controller) *MockNamespaceRolloutService {
+	mock := &MockNamespaceRolloutService{ctrl: ctrl}
+	mock.recorder = &MockNamespaceRolloutServiceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockNamespaceRolloutService) EXPECT() *MockNamespaceRolloutServiceMockRecorder {
+	return m.recorder
+}
+
+// Stage mocks base method
+func (m *MockNamespaceRolloutService) Stage(name string, options *namespace.NamespaceOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Stage", name, options)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Stage indicates an expected call of Stage
+func (mr *MockNamespaceRolloutServiceMockRecorder) Stage(name, options interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Stage", reflect.TypeOf((*MockNamespaceRolloutService)(nil).Stage), name, options)
+}
+
+// RollOut mocks base method
+func (m *MockNamespaceRolloutService) RollOut(hostIDs []string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RollOut", hostIDs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RollOut indicates an expected call of RollOut
+func (mr *MockNamespaceRolloutServiceMockRecorder) RollOut(hostIDs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RollOut", reflect.TypeOf((*MockNamespaceRolloutService)(nil).RollOut), hostIDs)
+}
+
+// Commit mocks base method
+func (m *MockNamespaceRolloutService) Commit() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Commit")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Commit indicates an expected call of Commit
+func (mr *MockNamespaceRolloutServiceMockRecorder) Commit() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Commit", reflect.TypeOf((*MockNamespaceRolloutService)(nil).Commit))
+}
+
+// Rollback mocks base method
+func (m *MockNamespaceRolloutService) Rollback() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Rollback")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Rollback indicates an expected call of Rollback
+func (mr *MockNamespaceRolloutServiceMockRecorder) Rollback() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Rollback", reflect.TypeOf((*MockNamespaceRolloutService)(nil).Rollback))
+}
+
+// Status mocks base method
+func (m *MockNamespaceRolloutService) Status() (RolloutStatus, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Status")
+	ret0, _ := ret[0].(RolloutStatus)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Status indicates an expected call of Status
+func (mr *MockNamespaceRolloutServiceMockRecorder) Status() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Status", reflect.TypeOf((*MockNamespaceRolloutService)(nil).Status))
+}
@@ -127,7 +127,7 @@ func (as *adminService) Add(name string, options *nsproto.NamespaceOptions) erro
 }
 
 func (as *adminService) Set(name string, options *nsproto.NamespaceOptions) error {
-	_, err := namespace.ToMetadata(name, options)
+	nsMeta, err := namespace.ToMetadata(name, options)
 	if err != nil {
 		return xerrors.Wrapf(err, "invalid options for namespace: %v", name)
 	}
@@ -135,9 +135,17 @@ func (as *adminService) Set(name string, options *nsproto.NamespaceOptions) erro
 	if err != nil {
 		return xerrors.Wrapf(err, "failed to load namespace registry at %s", as.key)
 	}
-	if _, ok := currentRegistry.GetNamespaces()[name]; !ok {
+	currentOptions, ok := currentRegistry.GetNamespaces()[name]
+	if !ok {
 		return ErrNamespaceNotFound
 	}
+	currentMeta, err := namespace.ToMetadata(name, currentOptions)
+	if err != nil {
+		return xerrors.Wrapf(err, "invalid current options for namespace: %v", name)
+	}
+	if err := namespace.ValidateUpdate(currentMeta, nsMeta); err != nil {
+		return xerrors.Wrapf(err, "invalid update for namespace: %v", name)
+	}
 
 	currentRegistry.Namespaces[name] = options
 
@@ -0,0 +1,259 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package kvadmin
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	nsproto "github.com/m3db/m3/src/dbnode/generated/proto/namespace"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+var (
+	ErrNoStagedChange     = errors.New("no namespace change is staged")
+	ErrStagedChangeExists = errors.New("a namespace change is already staged")
+	ErrRegistryChanged    = errors.New("namespace registry changed since the change was staged")
+)
+
+type rolloutService struct {
+	admin *adminService
+}
+
+// NewRolloutService returns a service to stage namespace changes and roll
+// them out to the hosts watching the namespace registry at the given key.
+func NewRolloutService(store kv.Store, key string) NamespaceRolloutService {
+	if len(key) == 0 {
+		key = M3DBNodeNamespacesKey
+	}
+	return &rolloutService{
+		admin: &adminService{store: store, key: key},
+	}
+}
+
+func (rs *rolloutService) Stage(name string, options *nsproto.NamespaceOptions) error {
+	nsMeta, err := namespace.ToMetadata(name, options)
+	if err != nil {
+		return xerrors.Wrapf(err, "invalid options for namespace: %v", name)
+	}
+
+	store, key := rs.admin.store, rs.admin.key
+	if _, err := store.Get(namespace.StagedRegistryKey(key)); err == nil {
+		return ErrStagedChangeExists
+	} else if err != kv.ErrNotFound {
+		return xerrors.Wrapf(err, "failed to load staged namespace registry for %s", key)
+	}
+
+	currentRegistry, currentVersion, err := rs.admin.currentRegistry()
+	if err != nil {
+		return xerrors.Wrapf(err, "failed to load namespace registry at %s", key)
+	}
+	if currentOptions, ok := currentRegistry.GetNamespaces()[name]; ok {
+		currentMeta, err := namespace.ToMetadata(name, currentOptions)
+		if err != nil {
+			return xerrors.Wrapf(err, "invalid current options for namespace: %v", name)
+		}
+		if err := namespace.ValidateUpdate(currentMeta, nsMeta); err != nil {
+			return xerrors.Wrapf(err, "invalid update for namespace: %v", name)
+		}
+	}
+
+	staged := &nsproto.Registry{
+		Namespaces: make(map[string]*nsproto.NamespaceOptions,
+			len(currentRegistry.GetNamespaces())+1),
+	}
+	for id, opts := range currentRegistry.GetNamespaces() {
+		staged.Namespaces[id] = opts
+	}
+	staged.Namespaces[name] = options
+
+	if _, err := store.SetIfNotExists(namespace.StagedRegistryKey(key), staged); err != nil {
+		if err == kv.ErrAlreadyExists {
+			return ErrStagedChangeExists
+		}
+		return xerrors.Wrapf(err, "failed to stage change for namespace %v", name)
+	}
+	_, err = store.Set(namespace.StagedRegistryVersionKey(key),
+		&commonpb.Int64Proto{Value: int64(currentVersion)})
+	if err != nil {
+		return xerrors.Wrapf(err, "failed to stage change for namespace %v", name)
+	}
+	return nil
+}
+
+func (rs *rolloutService) RollOut(hostIDs []string) error {
+	status, err := rs.Status()
+	if err != nil {
+		return err
+	}
+	if err := rs.rollbackOnErrors(status); err != nil {
+		return err
+	}
+
+	hosts := make(map[string]struct{}, len(status.HostIDs)+len(hostIDs))
+	for _, hostID := range status.HostIDs {
+		hosts[hostID] = struct{}{}
+	}
+	for _, hostID := range hostIDs {
+		hosts[hostID] = struct{}{}
+	}
+	values := make([]string, 0, len(hosts))
+	for hostID := range hosts {
+		values = append(values, hostID)
+	}
+	sort.Strings(values)
+
+	key := namespace.StagedRegistryHostsKey(rs.admin.key)
+	if _, err := rs.admin.store.Set(key, &commonpb.StringArrayProto{Values: values}); err != nil {
+		return xerrors.Wrapf(err, "failed to roll out staged namespace change to %v", hostIDs)
+	}
+	return nil
+}
+
+func (rs *rolloutService) Commit() error {
+	status, err := rs.Status()
+	if err != nil {
+		return err
+	}
+	if err := rs.rollbackOnErrors(status); err != nil {
+		return err
+	}
+
+	store, key := rs.admin.store, rs.admin.key
+	value, err := store.Get(namespace.StagedRegistryVersionKey(key))
+	if err != nil {
+		return xerrors.Wrapf(err, "failed to load staged namespace registry version for %s", key)
+	}
+	var version commonpb.Int64Proto
+	if err := value.Unmarshal(&version); err != nil {
+		return fmt.Errorf("unable to parse value, err: %v", err)
+	}
+
+	if _, err := store.CheckAndSet(key, int(version.Value), status.Staged); err != nil {
+		if err == kv.ErrVersionMismatch {
+			return ErrRegistryChanged
+		}
+		return xerrors.Wrapf(err, "failed to commit staged namespace change to %s", key)
+	}
+	return rs.clear(status.HostIDs)
+}
+
+func (rs *rolloutService) Rollback() error {
+	status, err := rs.Status()
+	if err != nil {
+		return err
+	}
+	return rs.clear(status.HostIDs)
+}
+
+func (rs *rolloutService) Status() (RolloutStatus, error) {
+	store, key := rs.admin.store, rs.admin.key
+	value, err := store.Get(namespace.StagedRegistryKey(key))
+	if err == kv.ErrNotFound {
+		return RolloutStatus{}, ErrNoStagedChange
+	}
+	if err != nil {
+		return RolloutStatus{}, xerrors.Wrapf(err, "failed to load staged namespace registry for %s", key)
+	}
+
+	var staged nsproto.Registry
+	if err := value.Unmarshal(&staged); err != nil {
+		return RolloutStatus{}, fmt.Errorf("unable to parse value, err: %v", err)
+	}
+
+	status := RolloutStatus{
+		Staged: &staged,
+		Errors: make(map[string]string),
+	}
+
+	value, err = store.Get(namespace.StagedRegistryHostsKey(key))
+	if err != nil && err != kv.ErrNotFound {
+		return RolloutStatus{}, xerrors.Wrapf(err, "failed to load staged namespace hosts for %s", key)
+	}
+	if err == nil {
+		var hosts commonpb.StringArrayProto
+		if err := value.Unmarshal(&hosts); err != nil {
+			return RolloutStatus{}, fmt.Errorf("unable to parse value, err: %v", err)
+		}
+		status.HostIDs = hosts.Values
+	}
+
+	for _, hostID := range status.HostIDs {
+		value, err := store.Get(namespace.StagedRegistryErrorKey(key, hostID))
+		if err == kv.ErrNotFound {
+			continue
+		}
+		if err != nil {
+			return RolloutStatus{}, xerrors.Wrapf(err, "failed to load staged namespace error for host %s", hostID)
+		}
+		var hostErr commonpb.StringProto
+		if err := value.Unmarshal(&hostErr); err != nil {
+			return RolloutStatus{}, fmt.Errorf("unable to parse value, err: %v", err)
+		}
+		status.Errors[hostID] = hostErr.Value
+	}
+
+	return status, nil
+}
+
+func (rs *rolloutService) rollbackOnErrors(status RolloutStatus) error {
+	if len(status.Errors) == 0 {
+		return nil
+	}
+
+	hostErrs := make([]string, 0, len(status.Errors))
+	for hostID, hostErr := range status.Errors {
+		hostErrs = append(hostErrs, fmt.Sprintf("%s: %s", hostID, hostErr))
+	}
+	sort.Strings(hostErrs)
+
+	if err := rs.clear(status.HostIDs); err != nil {
+		return xerrors.Wrap(err, "failed to roll back staged namespace change")
+	}
+	return fmt.Errorf("rolled back staged namespace change, hosts reported errors: %s",
+		strings.Join(hostErrs, ", "))
+}
+
+// clear removes the staged change, removing the hosts first so that they
+// revert to the namespace registry straight away.
+func (rs *rolloutService) clear(hostIDs []string) error {
+	key := rs.admin.key
+	keys := []string{
+		namespace.StagedRegistryHostsKey(key),
+		namespace.StagedRegistryKey(key),
+		namespace.StagedRegistryVersionKey(key),
+	}
+	for _, hostID := range hostIDs {
+		keys = append(keys, namespace.StagedRegistryErrorKey(key, hostID))
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, k := range keys {
+		if _, err := rs.admin.store.Delete(k); err != nil && err != kv.ErrNotFound {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return multiErr.FinalError()
+}
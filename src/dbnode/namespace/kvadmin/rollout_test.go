@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package kvadmin
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+	"github.com/m3db/m3/src/cluster/kv/mem"
+	"github.com/m3db/m3/src/dbnode/namespace"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRolloutService(t *testing.T) (NamespaceRolloutService, NamespaceMetadataAdminService, kv.Store) {
+	store := mem.NewStore()
+	key := "nsRegKey"
+	as := NewAdminService(store, key, nil)
+	require.NoError(t, as.Add("ns1", namespace.OptionsToProto(namespace.NewOptions())))
+	return NewRolloutService(store, key), as, store
+}
+
+func TestRolloutServiceCommit(t *testing.T) {
+	rs, as, store := newTestRolloutService(t)
+
+	_, err := rs.Status()
+	require.Equal(t, ErrNoStagedChange, err)
+
+	opts := namespace.NewOptions()
+	updated := opts.SetRetentionOptions(opts.RetentionOptions().SetRetentionPeriod(96 * time.Hour))
+	require.NoError(t, rs.Stage("ns1", namespace.OptionsToProto(updated)))
+	require.Equal(t, ErrStagedChangeExists, rs.Stage("ns1", namespace.OptionsToProto(updated)))
+
+	require.NoError(t, rs.RollOut([]string{"host1"}))
+	require.NoError(t, rs.RollOut([]string{"host2", "host3"}))
+
+	status, err := rs.Status()
+	require.NoError(t, err)
+	require.Equal(t, []string{"host1", "host2", "host3"}, status.HostIDs)
+	require.Empty(t, status.Errors)
+	require.Len(t, status.Staged.Namespaces, 1)
+
+	// Not applied until committed.
+	nsOpts, err := as.Get("ns1")
+	require.NoError(t, err)
+	require.Equal(t, int64(48*time.Hour), nsOpts.RetentionOptions.RetentionPeriodNanos)
+
+	require.NoError(t, rs.Commit())
+
+	nsOpts, err = as.Get("ns1")
+	require.NoError(t, err)
+	require.Equal(t, int64(96*time.Hour), nsOpts.RetentionOptions.RetentionPeriodNanos)
+
+	_, err = rs.Status()
+	require.Equal(t, ErrNoStagedChange, err)
+	_, err = store.Get(namespace.StagedRegistryHostsKey("nsRegKey"))
+	require.Equal(t, kv.ErrNotFound, err)
+}
+
+func TestRolloutServiceRollbackOnHostError(t *testing.T) {
+	rs, as, store := newTestRolloutService(t)
+
+	opts := namespace.NewOptions()
+	updated := opts.SetRetentionOptions(opts.RetentionOptions().SetRetentionPeriod(96 * time.Hour))
+	require.NoError(t, rs.Stage("ns1", namespace.OptionsToProto(updated)))
+	require.NoError(t, rs.RollOut([]string{"host1"}))
+
+	_, err := store.Set(namespace.StagedRegistryErrorKey("nsRegKey", "host1"),
+		&commonpb.StringProto{Value: "failed to apply"})
+	require.NoError(t, err)
+
+	status, err := rs.Status()
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"host1": "failed to apply"}, status.Errors)
+
+	err = rs.RollOut([]string{"host2"})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "host1: failed to apply")
+
+	_, err = rs.Status()
+	require.Equal(t, ErrNoStagedChange, err)
+	_, err = store.Get(namespace.StagedRegistryErrorKey("nsRegKey", "host1"))
+	require.Equal(t, kv.ErrNotFound, err)
+
+	nsOpts, err := as.Get("ns1")
+	require.NoError(t, err)
+	require.Equal(t, int64(48*time.Hour), nsOpts.RetentionOptions.RetentionPeriodNanos)
+}
+
+func TestRolloutServiceStageInvalidUpdate(t *testing.T) {
+	rs, as, _ := newTestRolloutService(t)
+
+	opts := namespace.NewOptions()
+	updated := opts.SetRetentionOptions(opts.RetentionOptions().SetBlockSize(time.Hour))
+	require.Error(t, rs.Stage("ns1", namespace.OptionsToProto(updated)))
+	require.Error(t, as.Set("ns1", namespace.OptionsToProto(updated)))
+
+	_, err := rs.Status()
+	require.Equal(t, ErrNoStagedChange, err)
+}
+
+func TestRolloutServiceCommitRegistryChanged(t *testing.T) {
+	rs, as, _ := newTestRolloutService(t)
+
+	opts := namespace.NewOptions()
+	require.NoError(t, rs.Stage("ns1", namespace.OptionsToProto(opts.SetRepairEnabled(true))))
+	require.NoError(t, as.Add("ns2", namespace.OptionsToProto(opts)))
+
+	require.Equal(t, ErrRegistryChanged, rs.Commit())
+	require.NoError(t, rs.Rollback())
+	require.Equal(t, ErrNoStagedChange, rs.Rollback())
+}
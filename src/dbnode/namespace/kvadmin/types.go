@@ -49,3 +49,39 @@ type NamespaceMetadataAdminService interface {
 	// ResetSchema reset schema for the specified namespace.
 	ResetSchema(name string) error
 }
+
+// NamespaceRolloutService stages namespace option changes and rolls them out
+// to a subset of hosts at a time, rolling them back if any host reports an
+// error applying the change.
+type NamespaceRolloutService interface {
+	// Stage validates and stages new options for the specified namespace,
+	// no host applies the staged options until they are rolled out to it.
+	Stage(name string, options *nsproto.NamespaceOptions) error
+
+	// RollOut rolls the staged change out to the given hosts in addition to
+	// the hosts it was already rolled out to. If any of the hosts the change
+	// was already rolled out to reported an error the change is rolled back.
+	RollOut(hostIDs []string) error
+
+	// Commit writes the staged change to the namespace registry once all
+	// hosts it was rolled out to applied it, otherwise the change is rolled
+	// back.
+	Commit() error
+
+	// Rollback discards the staged change, hosts it was rolled out to revert
+	// to the namespace registry.
+	Rollback() error
+
+	// Status returns the status of the staged change.
+	Status() (RolloutStatus, error)
+}
+
+// RolloutStatus is the status of a staged namespace change.
+type RolloutStatus struct {
+	// Staged is the staged namespace registry.
+	Staged *nsproto.Registry
+	// HostIDs are the hosts the staged change has been rolled out to.
+	HostIDs []string
+	// Errors are the errors applying the staged change keyed by host.
+	Errors map[string]string
+}
@@ -0,0 +1,149 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"bytes"
+	"errors"
+	"math"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/spaolacci/murmur3"
+)
+
+// mirrorPercentageBuckets is the number of buckets series IDs are hashed
+// into to decide whether a series is mirrored, allowing percentages with up
+// to two decimal places.
+const mirrorPercentageBuckets = 10000
+
+var (
+	errMirrorNamespaceEmpty    = errors.New("mirror target namespace must be set")
+	errMirrorPercentageInvalid = errors.New("mirror percentage must be greater than 0 and at most 100")
+	errMirrorMatcherNameEmpty  = errors.New("mirror matcher tag name must be set")
+)
+
+// MirrorMatcher matches the series whose tags include a tag with the given
+// name and value, or with the given name and any value if the value is empty.
+type MirrorMatcher struct {
+	// Name is the name of the tag.
+	Name string
+	// Value is the value of the tag, empty matches any value.
+	Value string
+}
+
+// MirrorOptions controls whether writes to a namespace are mirrored into a
+// shadow namespace, allowing new namespace settings such as block sizes to
+// be tried out with live traffic without changing any producers. Series are
+// sampled by a hash of their ID so that every datapoint of a mirrored
+// series is mirrored.
+type MirrorOptions struct {
+	// Enabled is whether writes are mirrored.
+	Enabled bool
+	// TargetNamespace is the shadow namespace writes are mirrored into.
+	TargetNamespace string
+	// Percentage is the percentage of series that are mirrored.
+	Percentage float64
+	// Matchers are the matchers series must all match to be mirrored, series
+	// written without tags are only mirrored if there are no matchers.
+	Matchers []MirrorMatcher
+}
+
+// Equal returns whether the mirror options are equal to the given ones.
+func (o MirrorOptions) Equal(other MirrorOptions) bool {
+	if o.Enabled != other.Enabled ||
+		o.TargetNamespace != other.TargetNamespace ||
+		o.Percentage != other.Percentage ||
+		len(o.Matchers) != len(other.Matchers) {
+		return false
+	}
+	for i := range o.Matchers {
+		if o.Matchers[i] != other.Matchers[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Sampled returns whether the series with the given ID is within the
+// percentage of series that are mirrored.
+func (o MirrorOptions) Sampled(id ident.ID) bool {
+	if o.Percentage >= 100 {
+		return true
+	}
+	bucket := murmur3.Sum32(id.Bytes()) % mirrorPercentageBuckets
+	return float64(bucket) < o.Percentage*mirrorPercentageBuckets/100
+}
+
+// Matches returns whether the tags match all of the matchers, the tags are
+// iterated over a duplicate so the given iterator is left as is. Nil tags
+// only match if there are no matchers.
+func (o MirrorOptions) Matches(tags ident.TagIterator) (bool, error) {
+	if len(o.Matchers) == 0 {
+		return true, nil
+	}
+	if tags == nil {
+		return false, nil
+	}
+
+	matched := make([]bool, len(o.Matchers))
+	iter := tags.Duplicate()
+	defer iter.Close()
+	for iter.Next() {
+		tag := iter.Current()
+		for i, matcher := range o.Matchers {
+			if !bytes.Equal(tag.Name.Bytes(), []byte(matcher.Name)) {
+				continue
+			}
+			if matcher.Value == "" || bytes.Equal(tag.Value.Bytes(), []byte(matcher.Value)) {
+				matched[i] = true
+			}
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return false, err
+	}
+
+	for _, m := range matched {
+		if !m {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func validateMirrorOptions(o MirrorOptions) error {
+	if !o.Enabled {
+		return nil
+	}
+	if o.TargetNamespace == "" {
+		return errMirrorNamespaceEmpty
+	}
+	if math.IsNaN(o.Percentage) || o.Percentage <= 0 || o.Percentage > 100 {
+		return errMirrorPercentageInvalid
+	}
+	for _, matcher := range o.Matchers {
+		if matcher.Name == "" {
+			return errMirrorMatcherNameEmpty
+		}
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestMirrorOptionsMatches(t *testing.T) {
+	opts := MirrorOptions{
+		Enabled:         true,
+		TargetNamespace: "shadow",
+		Percentage:      100,
+		Matchers: []MirrorMatcher{
+			{Name: "service", Value: "api"},
+			{Name: "env"},
+		},
+	}
+
+	tags := ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("env", "prod"),
+		ident.StringTag("service", "api"),
+	))
+	matches, err := opts.Matches(tags)
+	require.NoError(t, err)
+	require.True(t, matches)
+	// The given iterator is left as is.
+	require.Equal(t, 2, tags.Remaining())
+
+	matches, err = opts.Matches(ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("env", "prod"),
+		ident.StringTag("service", "web"),
+	)))
+	require.NoError(t, err)
+	require.False(t, matches)
+
+	matches, err = opts.Matches(ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag("service", "api"),
+	)))
+	require.NoError(t, err)
+	require.False(t, matches)
+
+	matches, err = opts.Matches(nil)
+	require.NoError(t, err)
+	require.False(t, matches)
+
+	matches, err = MirrorOptions{}.Matches(nil)
+	require.NoError(t, err)
+	require.True(t, matches)
+}
+
+func TestMirrorOptionsSampled(t *testing.T) {
+	opts := MirrorOptions{Enabled: true, TargetNamespace: "shadow", Percentage: 25}
+
+	sampled := 0
+	for i := 0; i < 10000; i++ {
+		id := ident.StringID(fmt.Sprintf("series-%d", i))
+		if opts.Sampled(id) {
+			sampled++
+			// Series are sampled consistently.
+			require.True(t, opts.Sampled(id))
+		}
+	}
+	require.InDelta(t, 2500, sampled, 250)
+
+	opts.Percentage = 100
+	require.True(t, opts.Sampled(ident.StringID("series")))
+}
+
+func TestMirrorOptionsValidate(t *testing.T) {
+	opts := NewOptions()
+	require.NoError(t, opts.SetMirrorOptions(MirrorOptions{
+		Enabled:         true,
+		TargetNamespace: "shadow",
+		Percentage:      10,
+	}).Validate())
+	require.Equal(t, errMirrorNamespaceEmpty, opts.SetMirrorOptions(MirrorOptions{
+		Enabled:    true,
+		Percentage: 10,
+	}).Validate())
+	require.Equal(t, errMirrorPercentageInvalid, opts.SetMirrorOptions(MirrorOptions{
+		Enabled:         true,
+		TargetNamespace: "shadow",
+		Percentage:      101,
+	}).Validate())
+	require.Equal(t, errMirrorMatcherNameEmpty, opts.SetMirrorOptions(MirrorOptions{
+		Enabled:         true,
+		TargetNamespace: "shadow",
+		Percentage:      10,
+		Matchers:        []MirrorMatcher{{Value: "api"}},
+	}).Validate())
+}
+
+func TestMirrorConfiguration(t *testing.T) {
+	str := `
+namespace: shadow
+percentage: 12.5
+matchers:
+  - name: service
+    value: api
+`
+	var cfg MirrorConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+
+	opts := cfg.MirrorOptions()
+	require.True(t, opts.Equal(MirrorOptions{
+		Enabled:         true,
+		TargetNamespace: "shadow",
+		Percentage:      12.5,
+		Matchers:        []MirrorMatcher{{Name: "service", Value: "api"}},
+	}))
+}
@@ -30,6 +30,7 @@ import (
 
 	"github.com/m3db/m3/src/cluster/client"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/close"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
@@ -368,6 +369,370 @@ func (mr *MockOptionsMockRecorder) SchemaHistory() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaHistory", reflect.TypeOf((*MockOptions)(nil).SchemaHistory))
 }
 
+// SetRetentionTiers mocks base method
+func (m *MockOptions) SetRetentionTiers(value []RetentionTier) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRetentionTiers", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetRetentionTiers indicates an expected call of SetRetentionTiers
+func (mr *MockOptionsMockRecorder) SetRetentionTiers(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRetentionTiers", reflect.TypeOf((*MockOptions)(nil).SetRetentionTiers), value)
+}
+
+// RetentionTiers mocks base method
+func (m *MockOptions) RetentionTiers() []RetentionTier {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RetentionTiers")
+	ret0, _ := ret[0].([]RetentionTier)
+	return ret0
+}
+
+// RetentionTiers indicates an expected call of RetentionTiers
+func (mr *MockOptionsMockRecorder) RetentionTiers() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RetentionTiers", reflect.TypeOf((*MockOptions)(nil).RetentionTiers))
+}
+
+// SetArchivalOptions mocks base method
+func (m *MockOptions) SetArchivalOptions(value ArchivalOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetArchivalOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetArchivalOptions indicates an expected call of SetArchivalOptions
+func (mr *MockOptionsMockRecorder) SetArchivalOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetArchivalOptions", reflect.TypeOf((*MockOptions)(nil).SetArchivalOptions), value)
+}
+
+// ArchivalOptions mocks base method
+func (m *MockOptions) ArchivalOptions() ArchivalOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ArchivalOptions")
+	ret0, _ := ret[0].(ArchivalOptions)
+	return ret0
+}
+
+// ArchivalOptions indicates an expected call of ArchivalOptions
+func (mr *MockOptionsMockRecorder) ArchivalOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ArchivalOptions", reflect.TypeOf((*MockOptions)(nil).ArchivalOptions))
+}
+
+// SetCompactionOptions mocks base method
+func (m *MockOptions) SetCompactionOptions(value CompactionOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetCompactionOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetCompactionOptions indicates an expected call of SetCompactionOptions
+func (mr *MockOptionsMockRecorder) SetCompactionOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCompactionOptions", reflect.TypeOf((*MockOptions)(nil).SetCompactionOptions), value)
+}
+
+// CompactionOptions mocks base method
+func (m *MockOptions) CompactionOptions() CompactionOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompactionOptions")
+	ret0, _ := ret[0].(CompactionOptions)
+	return ret0
+}
+
+// CompactionOptions indicates an expected call of CompactionOptions
+func (mr *MockOptionsMockRecorder) CompactionOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompactionOptions", reflect.TypeOf((*MockOptions)(nil).CompactionOptions))
+}
+
+// SetFutureWriteOptions mocks base method
+func (m *MockOptions) SetFutureWriteOptions(value FutureWriteOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFutureWriteOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFutureWriteOptions indicates an expected call of SetFutureWriteOptions
+func (mr *MockOptionsMockRecorder) SetFutureWriteOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFutureWriteOptions", reflect.TypeOf((*MockOptions)(nil).SetFutureWriteOptions), value)
+}
+
+// FutureWriteOptions mocks base method
+func (m *MockOptions) FutureWriteOptions() FutureWriteOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FutureWriteOptions")
+	ret0, _ := ret[0].(FutureWriteOptions)
+	return ret0
+}
+
+// FutureWriteOptions indicates an expected call of FutureWriteOptions
+func (mr *MockOptionsMockRecorder) FutureWriteOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FutureWriteOptions", reflect.TypeOf((*MockOptions)(nil).FutureWriteOptions))
+}
+
+// SetExpiryDownsampleOptions mocks base method
+func (m *MockOptions) SetExpiryDownsampleOptions(value ExpiryDownsampleOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetExpiryDownsampleOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetExpiryDownsampleOptions indicates an expected call of SetExpiryDownsampleOptions
+func (mr *MockOptionsMockRecorder) SetExpiryDownsampleOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetExpiryDownsampleOptions", reflect.TypeOf((*MockOptions)(nil).SetExpiryDownsampleOptions), value)
+}
+
+// ExpiryDownsampleOptions mocks base method
+func (m *MockOptions) ExpiryDownsampleOptions() ExpiryDownsampleOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ExpiryDownsampleOptions")
+	ret0, _ := ret[0].(ExpiryDownsampleOptions)
+	return ret0
+}
+
+// ExpiryDownsampleOptions indicates an expected call of ExpiryDownsampleOptions
+func (mr *MockOptionsMockRecorder) ExpiryDownsampleOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ExpiryDownsampleOptions", reflect.TypeOf((*MockOptions)(nil).ExpiryDownsampleOptions))
+}
+
+// SetInMemory mocks base method
+func (m *MockOptions) SetInMemory(value bool) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInMemory", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetInMemory indicates an expected call of SetInMemory
+func (mr *MockOptionsMockRecorder) SetInMemory(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInMemory", reflect.TypeOf((*MockOptions)(nil).SetInMemory), value)
+}
+
+// InMemory mocks base method
+func (m *MockOptions) InMemory() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InMemory")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// InMemory indicates an expected call of InMemory
+func (mr *MockOptionsMockRecorder) InMemory() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InMemory", reflect.TypeOf((*MockOptions)(nil).InMemory))
+}
+
+// SetShardKeyStrategy mocks base method
+func (m *MockOptions) SetShardKeyStrategy(value string) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShardKeyStrategy", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetShardKeyStrategy indicates an expected call of SetShardKeyStrategy
+func (mr *MockOptionsMockRecorder) SetShardKeyStrategy(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShardKeyStrategy", reflect.TypeOf((*MockOptions)(nil).SetShardKeyStrategy), value)
+}
+
+// ShardKeyStrategy mocks base method
+func (m *MockOptions) ShardKeyStrategy() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardKeyStrategy")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// ShardKeyStrategy indicates an expected call of ShardKeyStrategy
+func (mr *MockOptionsMockRecorder) ShardKeyStrategy() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardKeyStrategy", reflect.TypeOf((*MockOptions)(nil).ShardKeyStrategy))
+}
+
+// SetRelabelOptions mocks base method
+func (m *MockOptions) SetRelabelOptions(value RelabelOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRelabelOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetRelabelOptions indicates an expected call of SetRelabelOptions
+func (mr *MockOptionsMockRecorder) SetRelabelOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRelabelOptions", reflect.TypeOf((*MockOptions)(nil).SetRelabelOptions), value)
+}
+
+// RelabelOptions mocks base method
+func (m *MockOptions) RelabelOptions() RelabelOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RelabelOptions")
+	ret0, _ := ret[0].(RelabelOptions)
+	return ret0
+}
+
+// RelabelOptions indicates an expected call of RelabelOptions
+func (mr *MockOptionsMockRecorder) RelabelOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RelabelOptions", reflect.TypeOf((*MockOptions)(nil).RelabelOptions))
+}
+
+// SetMirrorOptions mocks base method
+func (m *MockOptions) SetMirrorOptions(value MirrorOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMirrorOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetMirrorOptions indicates an expected call of SetMirrorOptions
+func (mr *MockOptionsMockRecorder) SetMirrorOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMirrorOptions", reflect.TypeOf((*MockOptions)(nil).SetMirrorOptions), value)
+}
+
+// MirrorOptions mocks base method
+func (m *MockOptions) MirrorOptions() MirrorOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MirrorOptions")
+	ret0, _ := ret[0].(MirrorOptions)
+	return ret0
+}
+
+// MirrorOptions indicates an expected call of MirrorOptions
+func (mr *MockOptionsMockRecorder) MirrorOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MirrorOptions", reflect.TypeOf((*MockOptions)(nil).MirrorOptions))
+}
+
+// SetReshardOptions mocks base method
+func (m *MockOptions) SetReshardOptions(value ReshardOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetReshardOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetReshardOptions indicates an expected call of SetReshardOptions
+func (mr *MockOptionsMockRecorder) SetReshardOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetReshardOptions", reflect.TypeOf((*MockOptions)(nil).SetReshardOptions), value)
+}
+
+// ReshardOptions mocks base method
+func (m *MockOptions) ReshardOptions() ReshardOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReshardOptions")
+	ret0, _ := ret[0].(ReshardOptions)
+	return ret0
+}
+
+// ReshardOptions indicates an expected call of ReshardOptions
+func (mr *MockOptionsMockRecorder) ReshardOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReshardOptions", reflect.TypeOf((*MockOptions)(nil).ReshardOptions))
+}
+
+// SetQueryLimitsOptions mocks base method
+func (m *MockOptions) SetQueryLimitsOptions(value QueryLimitsOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQueryLimitsOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetQueryLimitsOptions indicates an expected call of SetQueryLimitsOptions
+func (mr *MockOptionsMockRecorder) SetQueryLimitsOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQueryLimitsOptions", reflect.TypeOf((*MockOptions)(nil).SetQueryLimitsOptions), value)
+}
+
+// QueryLimitsOptions mocks base method
+func (m *MockOptions) QueryLimitsOptions() QueryLimitsOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryLimitsOptions")
+	ret0, _ := ret[0].(QueryLimitsOptions)
+	return ret0
+}
+
+// QueryLimitsOptions indicates an expected call of QueryLimitsOptions
+func (mr *MockOptionsMockRecorder) QueryLimitsOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryLimitsOptions", reflect.TypeOf((*MockOptions)(nil).QueryLimitsOptions))
+}
+
+// SetWriteDurability mocks base method
+func (m *MockOptions) SetWriteDurability(value ts.Durability) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteDurability", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteDurability indicates an expected call of SetWriteDurability
+func (mr *MockOptionsMockRecorder) SetWriteDurability(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteDurability", reflect.TypeOf((*MockOptions)(nil).SetWriteDurability), value)
+}
+
+// WriteDurability mocks base method
+func (m *MockOptions) WriteDurability() ts.Durability {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteDurability")
+	ret0, _ := ret[0].(ts.Durability)
+	return ret0
+}
+
+// WriteDurability indicates an expected call of WriteDurability
+func (mr *MockOptionsMockRecorder) WriteDurability() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteDurability", reflect.TypeOf((*MockOptions)(nil).WriteDurability))
+}
+
+// SetValidationOptions mocks base method
+func (m *MockOptions) SetValidationOptions(value ValidationOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetValidationOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetValidationOptions indicates an expected call of SetValidationOptions
+func (mr *MockOptionsMockRecorder) SetValidationOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetValidationOptions", reflect.TypeOf((*MockOptions)(nil).SetValidationOptions), value)
+}
+
+// ValidationOptions mocks base method
+func (m *MockOptions) ValidationOptions() ValidationOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidationOptions")
+	ret0, _ := ret[0].(ValidationOptions)
+	return ret0
+}
+
+// ValidationOptions indicates an expected call of ValidationOptions
+func (mr *MockOptionsMockRecorder) ValidationOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidationOptions", reflect.TypeOf((*MockOptions)(nil).ValidationOptions))
+}
+
 // MockIndexOptions is a mock of IndexOptions interface
 type MockIndexOptions struct {
 	ctrl     *gomock.Controller
@@ -1185,6 +1550,90 @@ func (mr *MockDynamicOptionsMockRecorder) NamespaceRegistryKey() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NamespaceRegistryKey", reflect.TypeOf((*MockDynamicOptions)(nil).NamespaceRegistryKey))
 }
 
+// SetHostID mocks base method
+func (m *MockDynamicOptions) SetHostID(value string) DynamicOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetHostID", value)
+	ret0, _ := ret[0].(DynamicOptions)
+	return ret0
+}
+
+// SetHostID indicates an expected call of SetHostID
+func (mr *MockDynamicOptionsMockRecorder) SetHostID(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetHostID", reflect.TypeOf((*MockDynamicOptions)(nil).SetHostID), value)
+}
+
+// HostID mocks base method
+func (m *MockDynamicOptions) HostID() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HostID")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// HostID indicates an expected call of HostID
+func (mr *MockDynamicOptionsMockRecorder) HostID() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostID", reflect.TypeOf((*MockDynamicOptions)(nil).HostID))
+}
+
+// SetInitTimeout mocks base method
+func (m *MockDynamicOptions) SetInitTimeout(value time.Duration) DynamicOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInitTimeout", value)
+	ret0, _ := ret[0].(DynamicOptions)
+	return ret0
+}
+
+// SetInitTimeout indicates an expected call of SetInitTimeout
+func (mr *MockDynamicOptionsMockRecorder) SetInitTimeout(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInitTimeout", reflect.TypeOf((*MockDynamicOptions)(nil).SetInitTimeout), value)
+}
+
+// InitTimeout mocks base method
+func (m *MockDynamicOptions) InitTimeout() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitTimeout")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// InitTimeout indicates an expected call of InitTimeout
+func (mr *MockDynamicOptionsMockRecorder) InitTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitTimeout", reflect.TypeOf((*MockDynamicOptions)(nil).InitTimeout))
+}
+
+// SetLocalCachePath mocks base method
+func (m *MockDynamicOptions) SetLocalCachePath(value string) DynamicOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocalCachePath", value)
+	ret0, _ := ret[0].(DynamicOptions)
+	return ret0
+}
+
+// SetLocalCachePath indicates an expected call of SetLocalCachePath
+func (mr *MockDynamicOptionsMockRecorder) SetLocalCachePath(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalCachePath", reflect.TypeOf((*MockDynamicOptions)(nil).SetLocalCachePath), value)
+}
+
+// LocalCachePath mocks base method
+func (m *MockDynamicOptions) LocalCachePath() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LocalCachePath")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LocalCachePath indicates an expected call of LocalCachePath
+func (mr *MockDynamicOptionsMockRecorder) LocalCachePath() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalCachePath", reflect.TypeOf((*MockDynamicOptions)(nil).LocalCachePath))
+}
+
 // MockNamespaceWatch is a mock of NamespaceWatch interface
 type MockNamespaceWatch struct {
 	ctrl     *gomock.Controller
@@ -36,6 +36,12 @@ var (
 	errNotWatching     = errors.New("database is not watching for namespace updates")
 )
 
+// updateErrorReporter is implemented by watches that need to know about
+// errors applying the maps they publish.
+type updateErrorReporter interface {
+	reportUpdateError(m Map, err error)
+}
+
 type dbNamespaceWatch struct {
 	sync.Mutex
 
@@ -145,6 +151,9 @@ func (w *dbNamespaceWatch) startWatch() {
 			if err := w.update(newMap); err != nil {
 				w.log.Error("failed to update owned namespaces",
 					zap.Error(err))
+				if reporter, ok := w.watch.(updateErrorReporter); ok {
+					reporter.reportUpdateError(newMap, err)
+				}
 			}
 		}
 	}
@@ -24,6 +24,8 @@ import (
 	"errors"
 
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/ts"
 )
 
 const (
@@ -66,6 +68,19 @@ type options struct {
 	retentionOpts     retention.Options
 	indexOpts         IndexOptions
 	schemaHis         SchemaHistory
+	retentionTiers    []RetentionTier
+	archivalOpts      ArchivalOptions
+	compactionOpts    CompactionOptions
+	futureWriteOpts   FutureWriteOptions
+	expiryDsOpts      ExpiryDownsampleOptions
+	relabelOpts       RelabelOptions
+	mirrorOpts        MirrorOptions
+	reshardOpts       ReshardOptions
+	queryLimitsOpts   QueryLimitsOptions
+	writeDurability   ts.Durability
+	validationOpts    ValidationOptions
+	inMemory          bool
+	shardKeyStrategy  string
 }
 
 // NewSchemaHistory returns an empty schema history.
@@ -93,6 +108,45 @@ func (o *options) Validate() error {
 	if err := o.retentionOpts.Validate(); err != nil {
 		return err
 	}
+	if err := validateRetentionTiers(o.retentionTiers, o.retentionOpts); err != nil {
+		return err
+	}
+	if err := validateArchivalOptions(o.archivalOpts, o.retentionOpts); err != nil {
+		return err
+	}
+	if err := validateCompactionOptions(o); err != nil {
+		return err
+	}
+	if err := validateFutureWriteOptions(o.futureWriteOpts); err != nil {
+		return err
+	}
+	if err := validateExpiryDownsampleOptions(o.expiryDsOpts); err != nil {
+		return err
+	}
+	if err := validateRelabelOptions(o.relabelOpts); err != nil {
+		return err
+	}
+	if err := validateMirrorOptions(o.mirrorOpts); err != nil {
+		return err
+	}
+	if err := validateReshardOptions(o.reshardOpts); err != nil {
+		return err
+	}
+	if err := validateQueryLimitsOptions(o.queryLimitsOpts); err != nil {
+		return err
+	}
+	if err := validateWriteDurability(o); err != nil {
+		return err
+	}
+	if err := validateValidationOptions(o.validationOpts); err != nil {
+		return err
+	}
+	if err := validateInMemory(o); err != nil {
+		return err
+	}
+	if _, err := sharding.LookupShardKeyStrategy(o.shardKeyStrategy); err != nil {
+		return err
+	}
 	if !o.indexOpts.Enabled() {
 		return nil
 	}
@@ -124,7 +178,20 @@ func (o *options) Equal(value Options) bool {
 		o.coldWritesEnabled == value.ColdWritesEnabled() &&
 		o.retentionOpts.Equal(value.RetentionOptions()) &&
 		o.indexOpts.Equal(value.IndexOptions()) &&
-		o.schemaHis.Equal(value.SchemaHistory())
+		o.schemaHis.Equal(value.SchemaHistory()) &&
+		retentionTiersEqual(o.retentionTiers, value.RetentionTiers()) &&
+		o.archivalOpts == value.ArchivalOptions() &&
+		o.compactionOpts == value.CompactionOptions() &&
+		o.futureWriteOpts == value.FutureWriteOptions() &&
+		o.expiryDsOpts == value.ExpiryDownsampleOptions() &&
+		o.relabelOpts.Equal(value.RelabelOptions()) &&
+		o.mirrorOpts.Equal(value.MirrorOptions()) &&
+		o.reshardOpts == value.ReshardOptions() &&
+		o.queryLimitsOpts == value.QueryLimitsOptions() &&
+		o.writeDurability == value.WriteDurability() &&
+		o.validationOpts == value.ValidationOptions() &&
+		o.inMemory == value.InMemory() &&
+		o.shardKeyStrategy == value.ShardKeyStrategy()
 }
 
 func (o *options) SetBootstrapEnabled(value bool) Options {
@@ -226,3 +293,133 @@ func (o *options) SetSchemaHistory(value SchemaHistory) Options {
 func (o *options) SchemaHistory() SchemaHistory {
 	return o.schemaHis
 }
+
+func (o *options) SetRetentionTiers(value []RetentionTier) Options {
+	opts := *o
+	opts.retentionTiers = value
+	return &opts
+}
+
+func (o *options) RetentionTiers() []RetentionTier {
+	return o.retentionTiers
+}
+
+func (o *options) SetArchivalOptions(value ArchivalOptions) Options {
+	opts := *o
+	opts.archivalOpts = value
+	return &opts
+}
+
+func (o *options) ArchivalOptions() ArchivalOptions {
+	return o.archivalOpts
+}
+
+func (o *options) SetCompactionOptions(value CompactionOptions) Options {
+	opts := *o
+	opts.compactionOpts = value
+	return &opts
+}
+
+func (o *options) CompactionOptions() CompactionOptions {
+	return o.compactionOpts
+}
+
+func (o *options) SetFutureWriteOptions(value FutureWriteOptions) Options {
+	opts := *o
+	opts.futureWriteOpts = value
+	return &opts
+}
+
+func (o *options) FutureWriteOptions() FutureWriteOptions {
+	return o.futureWriteOpts
+}
+
+func (o *options) SetExpiryDownsampleOptions(value ExpiryDownsampleOptions) Options {
+	opts := *o
+	opts.expiryDsOpts = value
+	return &opts
+}
+
+func (o *options) ExpiryDownsampleOptions() ExpiryDownsampleOptions {
+	return o.expiryDsOpts
+}
+
+func (o *options) SetInMemory(value bool) Options {
+	opts := *o
+	opts.inMemory = value
+	return &opts
+}
+
+func (o *options) InMemory() bool {
+	return o.inMemory
+}
+
+func (o *options) SetShardKeyStrategy(value string) Options {
+	opts := *o
+	opts.shardKeyStrategy = value
+	return &opts
+}
+
+func (o *options) ShardKeyStrategy() string {
+	return o.shardKeyStrategy
+}
+
+func (o *options) SetRelabelOptions(value RelabelOptions) Options {
+	opts := *o
+	opts.relabelOpts = value
+	return &opts
+}
+
+func (o *options) RelabelOptions() RelabelOptions {
+	return o.relabelOpts
+}
+
+func (o *options) SetMirrorOptions(value MirrorOptions) Options {
+	opts := *o
+	opts.mirrorOpts = value
+	return &opts
+}
+
+func (o *options) MirrorOptions() MirrorOptions {
+	return o.mirrorOpts
+}
+
+func (o *options) SetReshardOptions(value ReshardOptions) Options {
+	opts := *o
+	opts.reshardOpts = value
+	return &opts
+}
+
+func (o *options) ReshardOptions() ReshardOptions {
+	return o.reshardOpts
+}
+
+func (o *options) SetQueryLimitsOptions(value QueryLimitsOptions) Options {
+	opts := *o
+	opts.queryLimitsOpts = value
+	return &opts
+}
+
+func (o *options) QueryLimitsOptions() QueryLimitsOptions {
+	return o.queryLimitsOpts
+}
+
+func (o *options) SetWriteDurability(value ts.Durability) Options {
+	opts := *o
+	opts.writeDurability = value
+	return &opts
+}
+
+func (o *options) WriteDurability() ts.Durability {
+	return o.writeDurability
+}
+
+func (o *options) SetValidationOptions(value ValidationOptions) Options {
+	opts := *o
+	opts.validationOpts = value
+	return &opts
+}
+
+func (o *options) ValidationOptions() ValidationOptions {
+	return o.validationOpts
+}
@@ -0,0 +1,59 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"errors"
+)
+
+var (
+	errQueryLimitsMaxConcurrentQueriesNegative   = errors.New("query limits max concurrent queries must not be negative")
+	errQueryLimitsMaxInFlightResultBytesNegative = errors.New("query limits max in-flight result bytes must not be negative")
+)
+
+// QueryLimitsOptions limits the index queries against a namespace so that
+// heavy queries against one namespace cannot exhaust the resources used to
+// serve queries against other namespaces on the same node. Queries beyond
+// the limits are rejected as resource exhausted.
+type QueryLimitsOptions struct {
+	// MaxConcurrentQueries is the maximum number of index queries that may
+	// run concurrently, zero disables the limit.
+	MaxConcurrentQueries int
+	// MaxInFlightResultBytes is the maximum number of bytes the results of
+	// all index queries may retain until they are finalized, zero disables
+	// the limit.
+	MaxInFlightResultBytes int64
+}
+
+// Enabled returns whether any limit is set.
+func (o QueryLimitsOptions) Enabled() bool {
+	return o.MaxConcurrentQueries > 0 || o.MaxInFlightResultBytes > 0
+}
+
+func validateQueryLimitsOptions(o QueryLimitsOptions) error {
+	if o.MaxConcurrentQueries < 0 {
+		return errQueryLimitsMaxConcurrentQueriesNegative
+	}
+	if o.MaxInFlightResultBytes < 0 {
+		return errQueryLimitsMaxInFlightResultBytesNegative
+	}
+	return nil
+}
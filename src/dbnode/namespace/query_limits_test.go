@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestQueryLimitsOptionsValidate(t *testing.T) {
+	opts := NewOptions()
+
+	require.NoError(t, opts.SetQueryLimitsOptions(QueryLimitsOptions{}).Validate())
+	require.NoError(t, opts.SetQueryLimitsOptions(QueryLimitsOptions{
+		MaxConcurrentQueries:   8,
+		MaxInFlightResultBytes: 1 << 20,
+	}).Validate())
+	require.Equal(t, errQueryLimitsMaxConcurrentQueriesNegative,
+		opts.SetQueryLimitsOptions(QueryLimitsOptions{
+			MaxConcurrentQueries: -1,
+		}).Validate())
+	require.Equal(t, errQueryLimitsMaxInFlightResultBytesNegative,
+		opts.SetQueryLimitsOptions(QueryLimitsOptions{
+			MaxInFlightResultBytes: -1,
+		}).Validate())
+}
+
+func TestQueryLimitsOptionsEnabled(t *testing.T) {
+	require.False(t, QueryLimitsOptions{}.Enabled())
+	require.True(t, QueryLimitsOptions{MaxConcurrentQueries: 1}.Enabled())
+	require.True(t, QueryLimitsOptions{MaxInFlightResultBytes: 1}.Enabled())
+}
+
+func TestQueryLimitsOptionsEqual(t *testing.T) {
+	var (
+		limitsOpts = QueryLimitsOptions{MaxConcurrentQueries: 8}
+		opts       = NewOptions().SetQueryLimitsOptions(limitsOpts)
+	)
+	require.True(t, opts.Equal(NewOptions().SetQueryLimitsOptions(limitsOpts)))
+	require.False(t, opts.Equal(NewOptions()))
+}
+
+func TestMetadataConfigQueryLimits(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 24h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+queryLimits:
+  maxConcurrentQueries: 4
+  maxInFlightResultBytes: 1048576
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, QueryLimitsOptions{
+		MaxConcurrentQueries:   4,
+		MaxInFlightResultBytes: 1048576,
+	}, md.Options().QueryLimitsOptions())
+}
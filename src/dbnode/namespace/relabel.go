@@ -0,0 +1,263 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/spaolacci/murmur3"
+)
+
+var (
+	errRelabelRuleNameEmpty   = errors.New("relabel rule tag name must be set")
+	errRelabelRuleTargetEmpty = errors.New("relabel rename rule target tag name must be set")
+	errRelabelRuleValueEmpty  = errors.New("relabel add rule tag value must be set")
+)
+
+// RelabelAction is the action a relabel rule applies to the tags of a series.
+type RelabelAction uint
+
+const (
+	// RelabelDrop drops the tag.
+	RelabelDrop RelabelAction = iota
+	// RelabelRename renames the tag, replacing any existing tag with the
+	// target name.
+	RelabelRename
+	// RelabelHash replaces the value of the tag with a hash of the value.
+	RelabelHash
+	// RelabelAdd adds a tag with a static value, replacing the value of the
+	// tag if it already exists.
+	RelabelAdd
+)
+
+// ValidRelabelActions returns the valid relabel actions.
+func ValidRelabelActions() []RelabelAction {
+	return []RelabelAction{RelabelDrop, RelabelRename, RelabelHash, RelabelAdd}
+}
+
+func (a RelabelAction) String() string {
+	switch a {
+	case RelabelDrop:
+		return "drop"
+	case RelabelRename:
+		return "rename"
+	case RelabelHash:
+		return "hash"
+	case RelabelAdd:
+		return "add"
+	}
+	return "unknown"
+}
+
+// ParseRelabelAction parses a RelabelAction from a string.
+func ParseRelabelAction(str string) (RelabelAction, error) {
+	var r RelabelAction
+	for _, valid := range ValidRelabelActions() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid RelabelAction '%s' valid types are: %v",
+		str, ValidRelabelActions())
+}
+
+// UnmarshalYAML unmarshals a RelabelAction into a valid type from string.
+func (a *RelabelAction) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseRelabelAction(str)
+	if err != nil {
+		return err
+	}
+	*a = r
+	return nil
+}
+
+// RelabelRule is a single rule applied to the tags of a series written to a
+// namespace before the series is indexed.
+type RelabelRule struct {
+	// Action is the action applied to the tag.
+	Action RelabelAction
+	// Name is the name of the tag the rule applies to.
+	Name string
+	// Target is the new name of the tag for rename rules.
+	Target string
+	// Value is the static value of the tag for add rules.
+	Value string
+	// HashBuckets is the number of buckets the values are hashed into for
+	// hash rules, zero keeps the full hash.
+	HashBuckets uint32
+}
+
+// RelabelOptions controls the rules applied in order to the tags of series
+// written to a namespace before they are indexed, allowing operators to
+// control the cardinality of the index without changing every producer.
+// The rules do not change the IDs of the series since the ID determines
+// the shard a series belongs to and clients route writes and fetches by it,
+// series whose tags relabel to the same tags remain distinct series that are
+// indexed with the same tags.
+type RelabelOptions struct {
+	// Rules are the relabel rules, applied in order.
+	Rules []RelabelRule
+}
+
+// Enabled returns whether any relabel rules are set.
+func (o RelabelOptions) Enabled() bool {
+	return len(o.Rules) > 0
+}
+
+// Equal returns whether the relabel options are equal to the given ones.
+func (o RelabelOptions) Equal(other RelabelOptions) bool {
+	if len(o.Rules) != len(other.Rules) {
+		return false
+	}
+	for i := range o.Rules {
+		if o.Rules[i] != other.Rules[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// Relabel applies the relabel rules to the tags, returning an iterator over
+// the relabeled tags sorted by name. The relabeled tags may reference the
+// bytes of the given tags so they must not be used once it is closed.
+func (o RelabelOptions) Relabel(tags ident.TagIterator) (ident.TagIterator, error) {
+	relabeled, err := o.relabel(tags)
+	if err != nil {
+		return nil, err
+	}
+	return ident.NewTagsIterator(relabeled), nil
+}
+
+func (o RelabelOptions) relabel(tags ident.TagIterator) (ident.Tags, error) {
+	iter := tags.Duplicate()
+	defer iter.Close()
+
+	relabeled := make([]ident.Tag, 0, iter.Remaining()+len(o.Rules))
+	for iter.Next() {
+		tag := iter.Current()
+		relabeled = append(relabeled, ident.Tag{
+			Name:  ident.BytesID(tag.Name.Bytes()),
+			Value: ident.BytesID(tag.Value.Bytes()),
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return ident.Tags{}, err
+	}
+
+	for _, rule := range o.Rules {
+		relabeled = rule.apply(relabeled)
+	}
+
+	sort.Slice(relabeled, func(i, j int) bool {
+		return bytes.Compare(relabeled[i].Name.Bytes(), relabeled[j].Name.Bytes()) < 0
+	})
+	return ident.NewTags(relabeled...), nil
+}
+
+func (r RelabelRule) apply(tags []ident.Tag) []ident.Tag {
+	switch r.Action {
+	case RelabelDrop:
+		return dropRelabelTag(tags, r.Name)
+	case RelabelRename:
+		idx := findRelabelTag(tags, r.Name)
+		if idx < 0 {
+			return tags
+		}
+		value := tags[idx].Value
+		tags = dropRelabelTag(tags, r.Name)
+		tags = dropRelabelTag(tags, r.Target)
+		return append(tags, ident.Tag{
+			Name:  ident.StringID(r.Target),
+			Value: value,
+		})
+	case RelabelHash:
+		idx := findRelabelTag(tags, r.Name)
+		if idx < 0 {
+			return tags
+		}
+		hash := murmur3.Sum32(tags[idx].Value.Bytes())
+		if r.HashBuckets > 0 {
+			hash %= r.HashBuckets
+		}
+		tags[idx].Value = ident.StringID(strconv.FormatUint(uint64(hash), 10))
+		return tags
+	case RelabelAdd:
+		tags = dropRelabelTag(tags, r.Name)
+		return append(tags, ident.Tag{
+			Name:  ident.StringID(r.Name),
+			Value: ident.StringID(r.Value),
+		})
+	}
+	return tags
+}
+
+func findRelabelTag(tags []ident.Tag, name string) int {
+	for i, tag := range tags {
+		if string(tag.Name.Bytes()) == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func dropRelabelTag(tags []ident.Tag, name string) []ident.Tag {
+	filtered := tags[:0]
+	for _, tag := range tags {
+		if string(tag.Name.Bytes()) != name {
+			filtered = append(filtered, tag)
+		}
+	}
+	return filtered
+}
+
+func validateRelabelOptions(o RelabelOptions) error {
+	for _, rule := range o.Rules {
+		if rule.Name == "" {
+			return errRelabelRuleNameEmpty
+		}
+		switch rule.Action {
+		case RelabelDrop, RelabelHash:
+		case RelabelRename:
+			if rule.Target == "" {
+				return errRelabelRuleTargetEmpty
+			}
+		case RelabelAdd:
+			if rule.Value == "" {
+				return errRelabelRuleValueEmpty
+			}
+		default:
+			return fmt.Errorf("invalid RelabelAction '%d' valid types are: %v",
+				uint(rule.Action), ValidRelabelActions())
+		}
+	}
+	return nil
+}
@@ -0,0 +1,156 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/spaolacci/murmur3"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func testRelabelTags(t *testing.T, opts RelabelOptions, tags ...string) map[string]string {
+	iter, err := opts.Relabel(ident.NewTagsIterator(ident.NewTags(
+		ident.StringTag(tags[0], tags[1]),
+		ident.StringTag(tags[2], tags[3]),
+		ident.StringTag(tags[4], tags[5]),
+	)))
+	require.NoError(t, err)
+
+	var (
+		result = make(map[string]string)
+		last   string
+	)
+	for iter.Next() {
+		tag := iter.Current()
+		// Relabeled tags are sorted by name.
+		require.True(t, last < tag.Name.String())
+		last = tag.Name.String()
+		result[tag.Name.String()] = tag.Value.String()
+	}
+	require.NoError(t, iter.Err())
+	return result
+}
+
+func TestRelabelOptionsRelabel(t *testing.T) {
+	opts := RelabelOptions{Rules: []RelabelRule{
+		{Action: RelabelDrop, Name: "pod"},
+		{Action: RelabelRename, Name: "dc", Target: "region"},
+		{Action: RelabelHash, Name: "user", HashBuckets: 16},
+		{Action: RelabelAdd, Name: "env", Value: "prod"},
+	}}
+	require.True(t, opts.Enabled())
+
+	expectedUser := strconv.FormatUint(uint64(murmur3.Sum32([]byte("alice"))%16), 10)
+	require.Equal(t, map[string]string{
+		"env":    "prod",
+		"region": "us-east",
+		"user":   expectedUser,
+	}, testRelabelTags(t, opts, "dc", "us-east", "pod", "pod-123", "user", "alice"))
+
+	// Tags not matched by the rules are left as is, add and rename rules
+	// replace existing tags.
+	require.Equal(t, map[string]string{
+		"env":    "prod",
+		"foo":    "bar",
+		"region": "us-west",
+	}, testRelabelTags(t, opts, "dc", "us-west", "env", "dev", "foo", "bar"))
+}
+
+func TestRelabelOptionsEqual(t *testing.T) {
+	rules := []RelabelRule{{Action: RelabelDrop, Name: "pod"}}
+	require.True(t, RelabelOptions{}.Equal(RelabelOptions{}))
+	require.True(t, RelabelOptions{Rules: rules}.Equal(RelabelOptions{
+		Rules: []RelabelRule{{Action: RelabelDrop, Name: "pod"}},
+	}))
+	require.False(t, RelabelOptions{Rules: rules}.Equal(RelabelOptions{}))
+	require.False(t, RelabelOptions{Rules: rules}.Equal(RelabelOptions{
+		Rules: []RelabelRule{{Action: RelabelHash, Name: "pod"}},
+	}))
+
+	opts := NewOptions()
+	require.False(t, opts.Equal(opts.SetRelabelOptions(RelabelOptions{Rules: rules})))
+}
+
+func TestRelabelOptionsValidate(t *testing.T) {
+	opts := NewOptions()
+
+	require.NoError(t, opts.SetRelabelOptions(RelabelOptions{}).Validate())
+	require.NoError(t, opts.SetRelabelOptions(RelabelOptions{Rules: []RelabelRule{
+		{Action: RelabelDrop, Name: "pod"},
+		{Action: RelabelHash, Name: "user"},
+	}}).Validate())
+	require.Equal(t, errRelabelRuleNameEmpty, opts.SetRelabelOptions(RelabelOptions{
+		Rules: []RelabelRule{{Action: RelabelDrop}},
+	}).Validate())
+	require.Equal(t, errRelabelRuleTargetEmpty, opts.SetRelabelOptions(RelabelOptions{
+		Rules: []RelabelRule{{Action: RelabelRename, Name: "dc"}},
+	}).Validate())
+	require.Equal(t, errRelabelRuleValueEmpty, opts.SetRelabelOptions(RelabelOptions{
+		Rules: []RelabelRule{{Action: RelabelAdd, Name: "env"}},
+	}).Validate())
+	require.Error(t, opts.SetRelabelOptions(RelabelOptions{
+		Rules: []RelabelRule{{Action: RelabelAction(100), Name: "env"}},
+	}).Validate())
+}
+
+func TestParseRelabelAction(t *testing.T) {
+	for _, valid := range ValidRelabelActions() {
+		action, err := ParseRelabelAction(valid.String())
+		require.NoError(t, err)
+		require.Equal(t, valid, action)
+	}
+
+	_, err := ParseRelabelAction("clamp")
+	require.Error(t, err)
+}
+
+func TestMetadataConfigRelabel(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 24h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+relabel:
+  - action: rename
+    name: dc
+    target: region
+  - action: hash
+    name: user
+    hashBuckets: 16
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, RelabelOptions{Rules: []RelabelRule{
+		{Action: RelabelRename, Name: "dc", Target: "region"},
+		{Action: RelabelHash, Name: "user", HashBuckets: 16},
+	}}, md.Options().RelabelOptions())
+}
@@ -0,0 +1,53 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"github.com/m3db/m3/src/dbnode/sharding"
+)
+
+// ReshardOptions controls the splitting of the shard space of a namespace
+// into a larger shard space. Filesets flushed for the shards of the shard
+// space split from are lazily rewritten into filesets for the shards they
+// are split into as those shards are bootstrapped.
+type ReshardOptions struct {
+	// Enabled is whether the namespace shard space has been split.
+	Enabled bool
+	// FromNumShards is the number of shards split from.
+	FromNumShards uint32
+	// ToNumShards is the number of shards split into, it must be a multiple
+	// of the number of shards split from and match the number of shards of
+	// the placement.
+	ToNumShards uint32
+}
+
+// ShardSplit returns the shard split described by the options.
+func (o ReshardOptions) ShardSplit() (sharding.ShardSplit, error) {
+	return sharding.NewShardSplit(o.FromNumShards, o.ToNumShards)
+}
+
+func validateReshardOptions(o ReshardOptions) error {
+	if !o.Enabled {
+		return nil
+	}
+	_, err := o.ShardSplit()
+	return err
+}
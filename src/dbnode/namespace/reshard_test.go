@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestReshardOptionsValidate(t *testing.T) {
+	opts := NewOptions()
+
+	require.NoError(t, opts.SetReshardOptions(ReshardOptions{}).Validate())
+	require.NoError(t, opts.SetReshardOptions(ReshardOptions{
+		Enabled:       true,
+		FromNumShards: 256,
+		ToNumShards:   1024,
+	}).Validate())
+	require.Error(t, opts.SetReshardOptions(ReshardOptions{
+		Enabled:       true,
+		FromNumShards: 256,
+		ToNumShards:   1000,
+	}).Validate())
+	require.Error(t, opts.SetReshardOptions(ReshardOptions{
+		Enabled:       true,
+		FromNumShards: 256,
+		ToNumShards:   128,
+	}).Validate())
+}
+
+func TestReshardOptionsEqual(t *testing.T) {
+	var (
+		reshardOpts = ReshardOptions{
+			Enabled:       true,
+			FromNumShards: 256,
+			ToNumShards:   1024,
+		}
+		opts = NewOptions().SetReshardOptions(reshardOpts)
+	)
+	require.True(t, opts.Equal(NewOptions().SetReshardOptions(reshardOpts)))
+	require.False(t, opts.Equal(NewOptions()))
+}
+
+func TestMetadataConfigReshard(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 24h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+reshard:
+  fromNumShards: 256
+  toNumShards: 1024
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, ReshardOptions{
+		Enabled:       true,
+		FromNumShards: 256,
+		ToNumShards:   1024,
+	}, md.Options().ReshardOptions())
+
+	split, err := md.Options().ReshardOptions().ShardSplit()
+	require.NoError(t, err)
+	require.Equal(t, uint32(255), split.Parent(1023))
+}
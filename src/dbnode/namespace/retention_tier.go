@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"errors"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/retention"
+)
+
+var (
+	errRetentionTierRetentionPositive     = errors.New("retention tier retention must be positive")
+	errRetentionTierResolutionNegative    = errors.New("retention tier resolution must not be negative")
+	errRetentionTierResolutionBlockSize   = errors.New("retention tier resolution must evenly divide the block size")
+	errRetentionTiersNotOrdered           = errors.New("retention tiers must be ordered by increasing resolution and retention")
+	errRetentionTiersRetentionPeriodMatch = errors.New("last retention tier retention must equal the namespace retention period")
+)
+
+// RetentionTier is a resolution at which a namespace retains data for a
+// period of time. A tier with a zero resolution retains datapoints at the
+// resolution they were written at.
+//
+// Blocks older than a tier's retention are rolled up to the resolution of
+// the next tier when they are flushed. Since every block is only ever stored
+// at the resolution of the tier it falls into, reads for a time range are
+// served from the appropriate tier without any extra selection logic.
+type RetentionTier struct {
+	// Resolution is the resolution datapoints are rolled up to.
+	Resolution time.Duration
+	// Retention is how long data is kept at this resolution.
+	Retention time.Duration
+}
+
+// RetentionTierForBlock returns the tier a block falls into at the given
+// time, that is the finest resolution tier whose retention still covers any
+// part of the block. Returns false if there are no tiers or the block has
+// fallen out of the retention of all tiers.
+func RetentionTierForBlock(
+	tiers []RetentionTier,
+	blockStart time.Time,
+	blockSize time.Duration,
+	now time.Time,
+) (RetentionTier, bool) {
+	blockEnd := blockStart.Add(blockSize)
+	for _, tier := range tiers {
+		if blockEnd.After(now.Add(-tier.Retention)) {
+			return tier, true
+		}
+	}
+	return RetentionTier{}, false
+}
+
+func validateRetentionTiers(tiers []RetentionTier, ropts retention.Options) error {
+	if len(tiers) == 0 {
+		return nil
+	}
+	blockSize := ropts.BlockSize()
+	for i, tier := range tiers {
+		if tier.Retention <= 0 {
+			return errRetentionTierRetentionPositive
+		}
+		if tier.Resolution < 0 {
+			return errRetentionTierResolutionNegative
+		}
+		if tier.Resolution > 0 && blockSize%tier.Resolution != 0 {
+			return errRetentionTierResolutionBlockSize
+		}
+		if i == 0 {
+			continue
+		}
+		prev := tiers[i-1]
+		if tier.Resolution <= prev.Resolution || tier.Retention <= prev.Retention {
+			return errRetentionTiersNotOrdered
+		}
+	}
+	if tiers[len(tiers)-1].Retention != ropts.RetentionPeriod() {
+		return errRetentionTiersRetentionPeriodMatch
+	}
+	return nil
+}
+
+func retentionTiersEqual(a, b []RetentionTier) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
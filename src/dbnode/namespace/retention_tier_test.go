@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/retention"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testRetentionTierOptions() retention.Options {
+	return retention.NewOptions().
+		SetBlockSize(2 * time.Hour).
+		SetRetentionPeriod(30 * 24 * time.Hour)
+}
+
+func TestRetentionTierForBlock(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		now       = time.Now().Truncate(blockSize)
+		tiers     = []RetentionTier{
+			{Retention: 48 * time.Hour},
+			{Resolution: 5 * time.Minute, Retention: 30 * 24 * time.Hour},
+		}
+	)
+
+	tier, ok := RetentionTierForBlock(tiers, now.Add(-blockSize), blockSize, now)
+	require.True(t, ok)
+	require.Equal(t, tiers[0], tier)
+
+	// A block that still partially overlaps the raw tier stays raw.
+	tier, ok = RetentionTierForBlock(tiers, now.Add(-48*time.Hour-time.Hour), blockSize, now)
+	require.True(t, ok)
+	require.Equal(t, tiers[0], tier)
+
+	tier, ok = RetentionTierForBlock(tiers, now.Add(-48*time.Hour-blockSize), blockSize, now)
+	require.True(t, ok)
+	require.Equal(t, tiers[1], tier)
+
+	_, ok = RetentionTierForBlock(tiers, now.Add(-31*24*time.Hour), blockSize, now)
+	require.False(t, ok)
+
+	_, ok = RetentionTierForBlock(nil, now, blockSize, now)
+	require.False(t, ok)
+}
+
+func TestRetentionTiersValidate(t *testing.T) {
+	ropts := testRetentionTierOptions()
+	opts := NewOptions().SetRetentionOptions(ropts)
+
+	require.NoError(t, opts.Validate())
+	require.NoError(t, opts.SetRetentionTiers([]RetentionTier{
+		{Retention: 48 * time.Hour},
+		{Resolution: 5 * time.Minute, Retention: 7 * 24 * time.Hour},
+		{Resolution: time.Hour, Retention: 30 * 24 * time.Hour},
+	}).Validate())
+
+	tests := []struct {
+		tiers    []RetentionTier
+		expected error
+	}{
+		{
+			tiers:    []RetentionTier{{Retention: 0}},
+			expected: errRetentionTierRetentionPositive,
+		},
+		{
+			tiers:    []RetentionTier{{Resolution: -time.Minute, Retention: 30 * 24 * time.Hour}},
+			expected: errRetentionTierResolutionNegative,
+		},
+		{
+			tiers:    []RetentionTier{{Resolution: 7 * time.Minute, Retention: 30 * 24 * time.Hour}},
+			expected: errRetentionTierResolutionBlockSize,
+		},
+		{
+			tiers: []RetentionTier{
+				{Resolution: 5 * time.Minute, Retention: 48 * time.Hour},
+				{Retention: 30 * 24 * time.Hour},
+			},
+			expected: errRetentionTiersNotOrdered,
+		},
+		{
+			tiers: []RetentionTier{
+				{Retention: 30 * 24 * time.Hour},
+				{Resolution: 5 * time.Minute, Retention: 48 * time.Hour},
+			},
+			expected: errRetentionTiersNotOrdered,
+		},
+		{
+			tiers: []RetentionTier{
+				{Retention: 48 * time.Hour},
+				{Resolution: 5 * time.Minute, Retention: 7 * 24 * time.Hour},
+			},
+			expected: errRetentionTiersRetentionPeriodMatch,
+		},
+	}
+	for _, test := range tests {
+		require.Equal(t, test.expected, opts.SetRetentionTiers(test.tiers).Validate())
+	}
+}
+
+func TestOptionsEqualsRetentionTiers(t *testing.T) {
+	o1 := NewOptions()
+	o2 := o1.SetRetentionTiers([]RetentionTier{
+		{Retention: 48 * time.Hour},
+		{Resolution: 5 * time.Minute, Retention: 30 * 24 * time.Hour},
+	})
+	require.True(t, o2.Equal(o2))
+	require.False(t, o1.Equal(o2))
+	require.False(t, o2.Equal(o1))
+}
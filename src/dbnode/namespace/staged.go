@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/cluster/generated/proto/commonpb"
+	"github.com/m3db/m3/src/cluster/kv"
+)
+
+// A namespace registry change can be staged for rollout rather than written
+// to the registry key directly. The staged registry is only applied by the
+// hosts listed in the staged hosts key, which is grown a node or zone at a
+// time, and hosts that fail to validate or apply the staged registry report
+// the error under their staged error key so the rollout can be rolled back.
+const (
+	stagedRegistryKeySuffix        = ".staged"
+	stagedRegistryHostsKeySuffix   = ".staged.hosts"
+	stagedRegistryVersionKeySuffix = ".staged.version"
+	stagedRegistryErrorKeySuffix   = ".staged.errors."
+)
+
+var errStagedHostsInvalid = errors.New("could not parse staged rollout hosts from config service")
+
+// StagedRegistryKey returns the kv key of the namespace registry staged for
+// rollout in place of the registry stored at the registry key.
+func StagedRegistryKey(registryKey string) string {
+	return registryKey + stagedRegistryKeySuffix
+}
+
+// StagedRegistryHostsKey returns the kv key of the hosts the staged
+// namespace registry has been rolled out to.
+func StagedRegistryHostsKey(registryKey string) string {
+	return registryKey + stagedRegistryHostsKeySuffix
+}
+
+// StagedRegistryVersionKey returns the kv key of the version of the registry
+// the staged namespace registry was staged against.
+func StagedRegistryVersionKey(registryKey string) string {
+	return registryKey + stagedRegistryVersionKeySuffix
+}
+
+// StagedRegistryErrorKey returns the kv key a host reports errors applying
+// the staged namespace registry under.
+func StagedRegistryErrorKey(registryKey, hostID string) string {
+	return registryKey + stagedRegistryErrorKeySuffix + hostID
+}
+
+// stagedRegistryWatch watches the staged namespace registry on behalf of a
+// single host.
+type stagedRegistryWatch struct {
+	store       kv.Store
+	registryKey string
+	hostID      string
+	registry    kv.ValueWatch
+	hosts       kv.ValueWatch
+}
+
+func newStagedRegistryWatch(
+	store kv.Store,
+	registryKey string,
+	hostID string,
+) (*stagedRegistryWatch, error) {
+	registry, err := store.Watch(StagedRegistryKey(registryKey))
+	if err != nil {
+		return nil, err
+	}
+
+	hosts, err := store.Watch(StagedRegistryHostsKey(registryKey))
+	if err != nil {
+		registry.Close()
+		return nil, err
+	}
+
+	return &stagedRegistryWatch{
+		store:       store,
+		registryKey: registryKey,
+		hostID:      hostID,
+		registry:    registry,
+		hosts:       hosts,
+	}, nil
+}
+
+// stagedMap returns the staged namespace registry if it has been rolled out
+// to the host, nil otherwise.
+func (w *stagedRegistryWatch) stagedMap() (Map, error) {
+	hostsValue := w.hosts.Get()
+	if hostsValue == nil {
+		return nil, nil
+	}
+
+	var hosts commonpb.StringArrayProto
+	if err := hostsValue.Unmarshal(&hosts); err != nil {
+		return nil, errStagedHostsInvalid
+	}
+
+	rolledOut := false
+	for _, hostID := range hosts.Values {
+		if hostID == w.hostID {
+			rolledOut = true
+			break
+		}
+	}
+	if !rolledOut {
+		return nil, nil
+	}
+
+	registryValue := w.registry.Get()
+	if registryValue == nil {
+		return nil, nil
+	}
+
+	return getMapFromUpdate(registryValue)
+}
+
+// reportError reports an error applying the staged namespace registry.
+func (w *stagedRegistryWatch) reportError(err error) error {
+	_, setErr := w.store.Set(StagedRegistryErrorKey(w.registryKey, w.hostID),
+		&commonpb.StringProto{Value: err.Error()})
+	return setErr
+}
+
+func (w *stagedRegistryWatch) Close() {
+	w.registry.Close()
+	w.hosts.Close()
+}
@@ -25,6 +25,7 @@ import (
 
 	"github.com/m3db/m3/src/cluster/client"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	xclose "github.com/m3db/m3/src/x/close"
@@ -97,6 +98,106 @@ type Options interface {
 
 	// SchemaHistory returns the schema registry for this namespace.
 	SchemaHistory() SchemaHistory
+
+	// SetRetentionTiers sets the resolution/retention tiers for this namespace.
+	SetRetentionTiers(value []RetentionTier) Options
+
+	// RetentionTiers returns the resolution/retention tiers for this namespace.
+	RetentionTiers() []RetentionTier
+
+	// SetArchivalOptions sets the archival options for this namespace.
+	SetArchivalOptions(value ArchivalOptions) Options
+
+	// ArchivalOptions returns the archival options for this namespace.
+	ArchivalOptions() ArchivalOptions
+
+	// SetCompactionOptions sets the options for compacting aged blocks of
+	// this namespace into filesets of a larger block size.
+	SetCompactionOptions(value CompactionOptions) Options
+
+	// CompactionOptions returns the options for compacting aged blocks of
+	// this namespace into filesets of a larger block size.
+	CompactionOptions() CompactionOptions
+
+	// SetFutureWriteOptions sets the future write options for this namespace.
+	SetFutureWriteOptions(value FutureWriteOptions) Options
+
+	// FutureWriteOptions returns the future write options for this namespace.
+	FutureWriteOptions() FutureWriteOptions
+
+	// SetExpiryDownsampleOptions sets the options for downsampling blocks of
+	// this namespace into another namespace before they expire.
+	SetExpiryDownsampleOptions(value ExpiryDownsampleOptions) Options
+
+	// ExpiryDownsampleOptions returns the options for downsampling blocks of
+	// this namespace into another namespace before they expire.
+	ExpiryDownsampleOptions() ExpiryDownsampleOptions
+
+	// SetInMemory sets whether the namespace is purely in-memory, data is
+	// never written to the commit log or filesets and is evicted once it
+	// falls out of retention.
+	SetInMemory(value bool) Options
+
+	// InMemory returns whether the namespace is purely in-memory.
+	InMemory() bool
+
+	// SetShardKeyStrategy sets the name of the registered strategy used to
+	// derive the key hashed to assign series of this namespace to shards,
+	// empty selects hashing the whole series ID.
+	SetShardKeyStrategy(value string) Options
+
+	// ShardKeyStrategy returns the name of the registered strategy used to
+	// derive the key hashed to assign series of this namespace to shards.
+	ShardKeyStrategy() string
+
+	// SetRelabelOptions sets the rules applied to the tags of series written
+	// to this namespace before they are indexed.
+	SetRelabelOptions(value RelabelOptions) Options
+
+	// RelabelOptions returns the rules applied to the tags of series written
+	// to this namespace before they are indexed.
+	RelabelOptions() RelabelOptions
+
+	// SetMirrorOptions sets the options for mirroring writes to this
+	// namespace into a shadow namespace.
+	SetMirrorOptions(value MirrorOptions) Options
+
+	// MirrorOptions returns the options for mirroring writes to this
+	// namespace into a shadow namespace.
+	MirrorOptions() MirrorOptions
+
+	// SetReshardOptions sets the options describing the split of the shard
+	// space of this namespace.
+	SetReshardOptions(value ReshardOptions) Options
+
+	// ReshardOptions returns the options describing the split of the shard
+	// space of this namespace.
+	ReshardOptions() ReshardOptions
+
+	// SetQueryLimitsOptions sets the limits of the index queries against
+	// this namespace.
+	SetQueryLimitsOptions(value QueryLimitsOptions) Options
+
+	// QueryLimitsOptions returns the limits of the index queries against
+	// this namespace.
+	QueryLimitsOptions() QueryLimitsOptions
+
+	// SetWriteDurability sets the durability class writes to this namespace
+	// are acknowledged with, writes requesting a stronger class are
+	// acknowledged with the stronger class.
+	SetWriteDurability(value ts.Durability) Options
+
+	// WriteDurability returns the durability class writes to this namespace
+	// are acknowledged with.
+	WriteDurability() ts.Durability
+
+	// SetValidationOptions sets the rules values written to this namespace
+	// are validated against.
+	SetValidationOptions(value ValidationOptions) Options
+
+	// ValidationOptions returns the rules values written to this namespace
+	// are validated against.
+	ValidationOptions() ValidationOptions
 }
 
 // IndexOptions controls the indexing options for a namespace.
@@ -255,6 +356,30 @@ type DynamicOptions interface {
 	// NamespaceRegistryKey returns the kv-store key used for the
 	// NamespaceRegistry
 	NamespaceRegistryKey() string
+
+	// SetHostID sets the ID of the host the registry is used by, when set
+	// the host takes part in staged rollouts of namespace registry changes
+	SetHostID(value string) DynamicOptions
+
+	// HostID returns the ID of the host the registry is used by
+	HostID() string
+
+	// SetInitTimeout sets how long to wait for the initial registry from
+	// the config service before falling back to the local cache
+	SetInitTimeout(value time.Duration) DynamicOptions
+
+	// InitTimeout returns how long to wait for the initial registry from
+	// the config service before falling back to the local cache
+	InitTimeout() time.Duration
+
+	// SetLocalCachePath sets the path of the file the last received registry
+	// is persisted to, which is used if the config service is unavailable
+	// on start up
+	SetLocalCachePath(value string) DynamicOptions
+
+	// LocalCachePath returns the path of the file the last received registry
+	// is persisted to
+	LocalCachePath() string
 }
 
 // NamespaceWatch watches for namespace updates.
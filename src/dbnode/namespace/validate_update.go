@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/dbnode/sharding"
+)
+
+// ValidateUpdate validates that the options of an existing namespace can be
+// changed to the updated options without invalidating data already written,
+// i.e. the updated options must be valid on their own (which covers retention
+// against block size and buffers), block sizes and the shard key strategy
+// must not change and indexing can not be toggled.
+func ValidateUpdate(existing, updated Metadata) error {
+	if !existing.ID().Equal(updated.ID()) {
+		return fmt.Errorf("can not update namespace %s with options of namespace %s",
+			existing.ID().String(), updated.ID().String())
+	}
+
+	id := existing.ID().String()
+	existingOpts, updatedOpts := existing.Options(), updated.Options()
+	if err := updatedOpts.Validate(); err != nil {
+		return fmt.Errorf("invalid options for namespace %s: %v", id, err)
+	}
+
+	var (
+		existingRetention = existingOpts.RetentionOptions()
+		updatedRetention  = updatedOpts.RetentionOptions()
+	)
+	if existingRetention.BlockSize() != updatedRetention.BlockSize() {
+		return fmt.Errorf("can not change block size of namespace %s from %s to %s",
+			id, existingRetention.BlockSize().String(),
+			updatedRetention.BlockSize().String())
+	}
+
+	var (
+		existingIndex = existingOpts.IndexOptions()
+		updatedIndex  = updatedOpts.IndexOptions()
+	)
+	if existingIndex.Enabled() != updatedIndex.Enabled() {
+		return fmt.Errorf("can not change indexing of namespace %s from enabled=%v to enabled=%v",
+			id, existingIndex.Enabled(), updatedIndex.Enabled())
+	}
+	if existingIndex.Enabled() && existingIndex.BlockSize() != updatedIndex.BlockSize() {
+		return fmt.Errorf("can not change index block size of namespace %s from %s to %s",
+			id, existingIndex.BlockSize().String(), updatedIndex.BlockSize().String())
+	}
+
+	// Series already written are owned by the shards assigned to them by the
+	// existing strategy, changing it would make them unreachable.
+	var (
+		existingStrategy = shardKeyStrategyName(existingOpts.ShardKeyStrategy())
+		updatedStrategy  = shardKeyStrategyName(updatedOpts.ShardKeyStrategy())
+	)
+	if existingStrategy != updatedStrategy {
+		return fmt.Errorf("can not change shard key strategy of namespace %s from %s to %s",
+			id, existingStrategy, updatedStrategy)
+	}
+
+	return nil
+}
+
+// ValidateMapUpdate validates every namespace present in both the existing
+// and the updated map with ValidateUpdate, namespaces may be freely added
+// or removed.
+func ValidateMapUpdate(existing, updated Map) error {
+	for _, updatedMd := range updated.Metadatas() {
+		existingMd, err := existing.Get(updatedMd.ID())
+		if err != nil {
+			// New namespace.
+			continue
+		}
+		if err := ValidateUpdate(existingMd, updatedMd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func shardKeyStrategyName(strategy string) string {
+	if strategy == "" {
+		return sharding.IDShardKeyStrategy
+	}
+	return strategy
+}
@@ -0,0 +1,152 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package namespace
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newValidateUpdateTestMetadata(t *testing.T, id string, opts Options) Metadata {
+	md, err := NewMetadata(ident.StringID(id), opts)
+	require.NoError(t, err)
+	return md
+}
+
+func TestValidateUpdate(t *testing.T) {
+	base := NewOptions().SetIndexOptions(NewIndexOptions().SetEnabled(true))
+	existing := newValidateUpdateTestMetadata(t, "ns", base)
+
+	tests := []struct {
+		name    string
+		id      string
+		opts    Options
+		wantErr bool
+	}{
+		{
+			name: "unchanged",
+			id:   "ns",
+			opts: base,
+		},
+		{
+			name: "retention changed",
+			id:   "ns",
+			opts: base.SetRetentionOptions(base.RetentionOptions().
+				SetRetentionPeriod(2 * base.RetentionOptions().RetentionPeriod())),
+		},
+		{
+			name:    "different namespace",
+			id:      "other",
+			opts:    base,
+			wantErr: true,
+		},
+		{
+			name: "block size changed",
+			id:   "ns",
+			opts: base.SetRetentionOptions(base.RetentionOptions().
+				SetBlockSize(base.RetentionOptions().BlockSize() / 2)),
+			wantErr: true,
+		},
+		{
+			name: "retention shorter than block size",
+			id:   "ns",
+			opts: base.SetRetentionOptions(base.RetentionOptions().
+				SetRetentionPeriod(time.Minute)),
+			wantErr: true,
+		},
+		{
+			name:    "indexing disabled",
+			id:      "ns",
+			opts:    base.SetIndexOptions(base.IndexOptions().SetEnabled(false)),
+			wantErr: true,
+		},
+		{
+			name: "index block size changed",
+			id:   "ns",
+			opts: base.SetIndexOptions(base.IndexOptions().
+				SetBlockSize(2 * base.IndexOptions().BlockSize())),
+			wantErr: true,
+		},
+		{
+			name: "default shard key strategy made explicit",
+			id:   "ns",
+			opts: base.SetShardKeyStrategy(sharding.IDShardKeyStrategy),
+		},
+		{
+			name:    "shard key strategy changed",
+			id:      "ns",
+			opts:    base.SetShardKeyStrategy(sharding.TenantPrefixShardKeyStrategy),
+			wantErr: true,
+		},
+		{
+			name:    "unknown shard key strategy",
+			id:      "ns",
+			opts:    base.SetShardKeyStrategy("unknown"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, err := NewMetadata(ident.StringID(tt.id), tt.opts)
+			if err != nil {
+				// Options invalid on their own are rejected up front.
+				require.True(t, tt.wantErr)
+				return
+			}
+			err = ValidateUpdate(existing, updated)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateMapUpdate(t *testing.T) {
+	opts := NewOptions()
+	existing, err := NewMap([]Metadata{
+		newValidateUpdateTestMetadata(t, "ns1", opts),
+		newValidateUpdateTestMetadata(t, "ns2", opts),
+	})
+	require.NoError(t, err)
+
+	// Namespaces can be added and removed.
+	updated, err := NewMap([]Metadata{
+		newValidateUpdateTestMetadata(t, "ns1", opts),
+		newValidateUpdateTestMetadata(t, "ns3", opts.SetRetentionOptions(
+			opts.RetentionOptions().SetBlockSize(time.Hour))),
+	})
+	require.NoError(t, err)
+	require.NoError(t, ValidateMapUpdate(existing, updated))
+
+	updated, err = NewMap([]Metadata{
+		newValidateUpdateTestMetadata(t, "ns1", opts.SetRetentionOptions(
+			opts.RetentionOptions().SetBlockSize(time.Hour))),
+	})
+	require.NoError(t, err)
+	require.Error(t, ValidateMapUpdate(existing, updated))
+}
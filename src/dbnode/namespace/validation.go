@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"errors"
+	"math"
+)
+
+var (
+	errValidationBoundsInvalid            = errors.New("validation min value must be less than or equal to max value")
+	errValidationMaxAnnotationSizeInvalid = errors.New("validation max annotation size must not be negative")
+)
+
+// ValidationRule is a rule that values written to a namespace are
+// validated against.
+type ValidationRule uint
+
+const (
+	// ValidationRuleNone is returned when a write satisfies all rules.
+	ValidationRuleNone ValidationRule = iota
+	// ValidationRuleNaN rejects NaN values.
+	ValidationRuleNaN
+	// ValidationRuleInf rejects positive and negative infinity values.
+	ValidationRuleInf
+	// ValidationRuleMinValue rejects values less than the min value.
+	ValidationRuleMinValue
+	// ValidationRuleMaxValue rejects values greater than the max value.
+	ValidationRuleMaxValue
+	// ValidationRuleMaxAnnotationSize rejects annotations larger than the
+	// max annotation size.
+	ValidationRuleMaxAnnotationSize
+)
+
+// ValidValidationRules returns the rules writes may violate.
+func ValidValidationRules() []ValidationRule {
+	return []ValidationRule{
+		ValidationRuleNaN,
+		ValidationRuleInf,
+		ValidationRuleMinValue,
+		ValidationRuleMaxValue,
+		ValidationRuleMaxAnnotationSize,
+	}
+}
+
+func (r ValidationRule) String() string {
+	switch r {
+	case ValidationRuleNone:
+		return "none"
+	case ValidationRuleNaN:
+		return "nan"
+	case ValidationRuleInf:
+		return "inf"
+	case ValidationRuleMinValue:
+		return "min-value"
+	case ValidationRuleMaxValue:
+		return "max-value"
+	case ValidationRuleMaxAnnotationSize:
+		return "max-annotation-size"
+	}
+	return "unknown"
+}
+
+// ValidationOptions are the rules values written to a namespace are
+// validated against at ingest, writes that violate a rule are rejected
+// before they reach the commit log or the series buffers.
+type ValidationOptions struct {
+	// RejectNaN rejects NaN values.
+	RejectNaN bool
+	// RejectInf rejects positive and negative infinity values.
+	RejectInf bool
+	// BoundsEnabled is whether values must be within MinValue and MaxValue
+	// inclusive.
+	BoundsEnabled bool
+	// MinValue is the min value that may be written.
+	MinValue float64
+	// MaxValue is the max value that may be written.
+	MaxValue float64
+	// MaxAnnotationSize is the max size in bytes of annotations, zero means
+	// annotations are not limited.
+	MaxAnnotationSize int
+}
+
+// Enabled returns whether any validation rules are set.
+func (o ValidationOptions) Enabled() bool {
+	return o.RejectNaN || o.RejectInf || o.BoundsEnabled || o.MaxAnnotationSize > 0
+}
+
+// Validate returns the first rule the write violates, or ValidationRuleNone
+// if the write satisfies all rules.
+func (o ValidationOptions) Validate(value float64, annotation []byte) ValidationRule {
+	if math.IsNaN(value) {
+		if o.RejectNaN {
+			return ValidationRuleNaN
+		}
+	} else if math.IsInf(value, 0) && o.RejectInf {
+		return ValidationRuleInf
+	}
+	if o.BoundsEnabled {
+		// NB: NaN compares false with both bounds so is only
+		// subject to the NaN rule.
+		if value < o.MinValue {
+			return ValidationRuleMinValue
+		}
+		if value > o.MaxValue {
+			return ValidationRuleMaxValue
+		}
+	}
+	if o.MaxAnnotationSize > 0 && len(annotation) > o.MaxAnnotationSize {
+		return ValidationRuleMaxAnnotationSize
+	}
+	return ValidationRuleNone
+}
+
+func validateValidationOptions(o ValidationOptions) error {
+	if o.MaxAnnotationSize < 0 {
+		return errValidationMaxAnnotationSizeInvalid
+	}
+	if !o.BoundsEnabled {
+		return nil
+	}
+	if math.IsNaN(o.MinValue) || math.IsNaN(o.MaxValue) || o.MinValue > o.MaxValue {
+		return errValidationBoundsInvalid
+	}
+	return nil
+}
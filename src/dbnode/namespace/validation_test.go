@@ -0,0 +1,107 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestValidationOptionsValidate(t *testing.T) {
+	opts := ValidationOptions{
+		RejectNaN:         true,
+		RejectInf:         true,
+		BoundsEnabled:     true,
+		MinValue:          -10,
+		MaxValue:          10,
+		MaxAnnotationSize: 4,
+	}
+	require.True(t, opts.Enabled())
+
+	require.Equal(t, ValidationRuleNone, opts.Validate(1, []byte("abcd")))
+	require.Equal(t, ValidationRuleNone, opts.Validate(-10, nil))
+	require.Equal(t, ValidationRuleNone, opts.Validate(10, nil))
+	require.Equal(t, ValidationRuleNaN, opts.Validate(math.NaN(), nil))
+	require.Equal(t, ValidationRuleInf, opts.Validate(math.Inf(1), nil))
+	require.Equal(t, ValidationRuleInf, opts.Validate(math.Inf(-1), nil))
+	require.Equal(t, ValidationRuleMinValue, opts.Validate(-11, nil))
+	require.Equal(t, ValidationRuleMaxValue, opts.Validate(11, nil))
+	require.Equal(t, ValidationRuleMaxAnnotationSize, opts.Validate(1, []byte("abcde")))
+}
+
+func TestValidationOptionsValidateNonFiniteAllowed(t *testing.T) {
+	opts := ValidationOptions{
+		BoundsEnabled: true,
+		MinValue:      math.Inf(-1),
+		MaxValue:      100,
+	}
+	require.Equal(t, ValidationRuleNone, opts.Validate(math.NaN(), nil))
+	require.Equal(t, ValidationRuleNone, opts.Validate(math.Inf(-1), nil))
+	require.Equal(t, ValidationRuleMaxValue, opts.Validate(math.Inf(1), nil))
+
+	require.False(t, ValidationOptions{}.Enabled())
+	require.Equal(t, ValidationRuleNone, ValidationOptions{}.Validate(math.NaN(), nil))
+}
+
+func TestValidationOptionsOptionsValidate(t *testing.T) {
+	opts := NewOptions()
+
+	require.NoError(t, opts.SetValidationOptions(ValidationOptions{}).Validate())
+	require.NoError(t, opts.SetValidationOptions(ValidationOptions{
+		BoundsEnabled: true,
+		MinValue:      1,
+		MaxValue:      1,
+	}).Validate())
+	require.Equal(t, errValidationBoundsInvalid, opts.SetValidationOptions(ValidationOptions{
+		BoundsEnabled: true,
+		MinValue:      2,
+		MaxValue:      1,
+	}).Validate())
+	require.Equal(t, errValidationBoundsInvalid, opts.SetValidationOptions(ValidationOptions{
+		BoundsEnabled: true,
+		MinValue:      math.NaN(),
+		MaxValue:      1,
+	}).Validate())
+	require.Equal(t, errValidationMaxAnnotationSizeInvalid, opts.SetValidationOptions(ValidationOptions{
+		MaxAnnotationSize: -1,
+	}).Validate())
+}
+
+func TestValidationConfiguration(t *testing.T) {
+	str := `
+rejectNaN: true
+maxValue: 100
+maxAnnotationSize: 256
+`
+	var cfg ValidationConfiguration
+	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+
+	opts := cfg.ValidationOptions()
+	require.True(t, opts.RejectNaN)
+	require.False(t, opts.RejectInf)
+	require.True(t, opts.BoundsEnabled)
+	require.True(t, math.IsInf(opts.MinValue, -1))
+	require.Equal(t, float64(100), opts.MaxValue)
+	require.Equal(t, 256, opts.MaxAnnotationSize)
+}
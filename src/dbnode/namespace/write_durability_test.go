@@ -0,0 +1,70 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package namespace
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/ts"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestWriteDurabilityValidate(t *testing.T) {
+	opts := NewOptions()
+	require.Equal(t, ts.DurabilityDefault, opts.WriteDurability())
+
+	for _, durability := range ts.ValidDurabilities() {
+		require.NoError(t, opts.SetWriteDurability(durability).Validate())
+	}
+	require.Error(t, opts.SetWriteDurability(ts.Durability(100)).Validate())
+
+	opts = opts.SetWritesToCommitLog(false)
+	require.NoError(t, opts.SetWriteDurability(ts.DurabilityMemory).Validate())
+	require.Equal(t, errWriteDurabilityRequiresCommitLog,
+		opts.SetWriteDurability(ts.DurabilityFsync).Validate())
+}
+
+func TestWriteDurabilityEqual(t *testing.T) {
+	opts := NewOptions()
+	require.True(t, opts.Equal(opts.SetWriteDurability(ts.DurabilityDefault)))
+	require.False(t, opts.Equal(opts.SetWriteDurability(ts.DurabilityFsync)))
+}
+
+func TestMetadataConfigWriteDurability(t *testing.T) {
+	yamlBytes := []byte(`
+id: "metrics"
+retention:
+  retentionPeriod: 24h
+  blockSize: 2h
+  bufferFuture: 10m
+  bufferPast: 10m
+writeDurability: ack-on-commitlog-buffer
+`)
+
+	var conf MetadataConfiguration
+	require.NoError(t, yaml.Unmarshal(yamlBytes, &conf))
+
+	md, err := conf.Metadata()
+	require.NoError(t, err)
+	require.Equal(t, ts.DurabilityCommitLogBuffer, md.Options().WriteDurability())
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerstats
+
+import (
+	"context"
+	"net"
+	"sync"
+)
+
+// DialFn dials a connection to an address.
+type DialFn func(ctx context.Context, network, address string) (net.Conn, error)
+
+type conn struct {
+	net.Conn
+
+	peer      string
+	tracker   Tracker
+	closeOnce sync.Once
+}
+
+func newConn(c net.Conn, peer string, tracker Tracker) net.Conn {
+	tracker.ConnectionOpened(peer)
+	return &conn{Conn: c, peer: peer, tracker: tracker}
+}
+
+func (c *conn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.tracker.RecordBytesIn(c.peer, n)
+	return n, err
+}
+
+func (c *conn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.tracker.RecordBytesOut(c.peer, n)
+	return n, err
+}
+
+func (c *conn) Close() error {
+	c.closeOnce.Do(func() {
+		c.tracker.ConnectionClosed(c.peer)
+	})
+	return c.Conn.Close()
+}
+
+type listener struct {
+	net.Listener
+
+	tracker Tracker
+}
+
+// NewListener returns a listener that tracks the bytes read from and written
+// to the connections it accepts, by the host of the remote address of each
+// connection since the ports of inbound connections are ephemeral.
+func NewListener(l net.Listener, tracker Tracker) net.Listener {
+	return &listener{Listener: l, tracker: tracker}
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return newConn(c, RemoteHost(c.RemoteAddr().String()), l.tracker), nil
+}
+
+// NewDialFn returns a dial function that tracks the bytes read from and
+// written to the connections it dials by the address dialed, using the
+// given dial function to dial connections or a default dialer if nil.
+func NewDialFn(dialFn DialFn, tracker Tracker) DialFn {
+	if dialFn == nil {
+		var dialer net.Dialer
+		dialFn = dialer.DialContext
+	}
+	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		c, err := dialFn(ctx, network, address)
+		if err != nil {
+			return nil, err
+		}
+		return newConn(c, address, tracker), nil
+	}
+}
+
+// RemoteHost returns the host of a remote address, or the address itself if
+// it does not have a port.
+func RemoteHost(address string) string {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return address
+	}
+	return host
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package peerstats tracks network statistics per remote peer.
+package peerstats
+
+import (
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+// Stats are the network statistics of a single remote peer.
+type Stats struct {
+	Peer        string `json:"peer"`
+	Connections int64  `json:"connections"`
+	BytesIn     int64  `json:"bytesIn"`
+	BytesOut    int64  `json:"bytesOut"`
+	RPCs        int64  `json:"rpcs"`
+	RPCErrors   int64  `json:"rpcErrors"`
+}
+
+// Tracker tracks network statistics per remote peer.
+type Tracker interface {
+	// ConnectionOpened records a connection being opened to or from a peer.
+	ConnectionOpened(peer string)
+
+	// ConnectionClosed records a connection to or from a peer being closed.
+	ConnectionClosed(peer string)
+
+	// RecordBytesIn records bytes read from a peer.
+	RecordBytesIn(peer string, n int)
+
+	// RecordBytesOut records bytes written to a peer.
+	RecordBytesOut(peer string, n int)
+
+	// RecordRPC records an RPC to or from a peer and whether it failed.
+	RecordRPC(peer string, failed bool)
+
+	// Stats returns the statistics of every peer seen, sorted by peer.
+	Stats() []Stats
+}
+
+type peerMetrics struct {
+	connections tally.Gauge
+	bytesIn     tally.Counter
+	bytesOut    tally.Counter
+	rpcs        tally.Counter
+	rpcErrors   tally.Counter
+}
+
+type peer struct {
+	connections int64
+	bytesIn     int64
+	bytesOut    int64
+	rpcs        int64
+	rpcErrors   int64
+
+	metrics peerMetrics
+}
+
+type tracker struct {
+	sync.RWMutex
+
+	scope tally.Scope
+	peers map[string]*peer
+}
+
+// NewTracker returns a new peer statistics tracker that emits metrics
+// tagged by peer.
+func NewTracker(iopts instrument.Options) Tracker {
+	return &tracker{
+		scope: iopts.MetricsScope(),
+		peers: make(map[string]*peer),
+	}
+}
+
+func (t *tracker) peer(name string) *peer {
+	t.RLock()
+	p, ok := t.peers[name]
+	t.RUnlock()
+	if ok {
+		return p
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	p, ok = t.peers[name]
+	if ok {
+		return p
+	}
+
+	scope := t.scope.Tagged(map[string]string{"peer": name})
+	p = &peer{
+		metrics: peerMetrics{
+			connections: scope.Gauge("peer.connections"),
+			bytesIn:     scope.Counter("peer.bytes-in"),
+			bytesOut:    scope.Counter("peer.bytes-out"),
+			rpcs:        scope.Counter("peer.rpcs"),
+			rpcErrors:   scope.Counter("peer.rpc-errors"),
+		},
+	}
+	t.peers[name] = p
+	return p
+}
+
+func (t *tracker) ConnectionOpened(name string) {
+	p := t.peer(name)
+	p.metrics.connections.Update(float64(atomic.AddInt64(&p.connections, 1)))
+}
+
+func (t *tracker) ConnectionClosed(name string) {
+	p := t.peer(name)
+	p.metrics.connections.Update(float64(atomic.AddInt64(&p.connections, -1)))
+}
+
+func (t *tracker) RecordBytesIn(name string, n int) {
+	if n <= 0 {
+		return
+	}
+	p := t.peer(name)
+	atomic.AddInt64(&p.bytesIn, int64(n))
+	p.metrics.bytesIn.Inc(int64(n))
+}
+
+func (t *tracker) RecordBytesOut(name string, n int) {
+	if n <= 0 {
+		return
+	}
+	p := t.peer(name)
+	atomic.AddInt64(&p.bytesOut, int64(n))
+	p.metrics.bytesOut.Inc(int64(n))
+}
+
+func (t *tracker) RecordRPC(name string, failed bool) {
+	p := t.peer(name)
+	atomic.AddInt64(&p.rpcs, 1)
+	p.metrics.rpcs.Inc(1)
+	if failed {
+		atomic.AddInt64(&p.rpcErrors, 1)
+		p.metrics.rpcErrors.Inc(1)
+	}
+}
+
+func (t *tracker) Stats() []Stats {
+	t.RLock()
+	stats := make([]Stats, 0, len(t.peers))
+	for name, p := range t.peers {
+		stats = append(stats, Stats{
+			Peer:        name,
+			Connections: atomic.LoadInt64(&p.connections),
+			BytesIn:     atomic.LoadInt64(&p.bytesIn),
+			BytesOut:    atomic.LoadInt64(&p.bytesOut),
+			RPCs:        atomic.LoadInt64(&p.rpcs),
+			RPCErrors:   atomic.LoadInt64(&p.rpcErrors),
+		})
+	}
+	t.RUnlock()
+
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Peer < stats[j].Peer
+	})
+	return stats
+}
@@ -0,0 +1,98 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerstats
+
+import (
+	"context"
+	"io/ioutil"
+	"net"
+	"testing"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestTrackerStats(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	tracker := NewTracker(instrument.NewOptions().SetMetricsScope(scope))
+
+	tracker.ConnectionOpened("b")
+	tracker.RecordBytesIn("b", 10)
+	tracker.RecordBytesOut("b", 20)
+	tracker.RecordBytesOut("b", 0)
+	tracker.RecordRPC("b", false)
+	tracker.RecordRPC("b", true)
+	tracker.ConnectionOpened("a")
+	tracker.ConnectionClosed("a")
+
+	require.Equal(t, []Stats{
+		{Peer: "a"},
+		{
+			Peer:        "b",
+			Connections: 1,
+			BytesIn:     10,
+			BytesOut:    20,
+			RPCs:        2,
+			RPCErrors:   1,
+		},
+	}, tracker.Stats())
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(2), counters["peer.rpcs+peer=b"].Value())
+	require.Equal(t, int64(1), counters["peer.rpc-errors+peer=b"].Value())
+}
+
+func TestListenerAndDialFnTrackBytes(t *testing.T) {
+	var (
+		serverTracker = NewTracker(instrument.NewOptions())
+		clientTracker = NewTracker(instrument.NewOptions())
+	)
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	l = NewListener(l, serverTracker)
+	defer l.Close()
+
+	doneCh := make(chan error)
+	go func() {
+		c, err := l.Accept()
+		if err != nil {
+			doneCh <- err
+			return
+		}
+		_, err = ioutil.ReadAll(c)
+		c.Close()
+		doneCh <- err
+	}()
+
+	address := l.Addr().String()
+	c, err := NewDialFn(nil, clientTracker)(context.Background(), "tcp", address)
+	require.NoError(t, err)
+	_, err = c.Write([]byte("hello"))
+	require.NoError(t, err)
+	require.NoError(t, c.Close())
+	require.NoError(t, <-doneCh)
+
+	require.Equal(t, []Stats{{Peer: address, BytesOut: 5}}, clientTracker.Stats())
+	require.Equal(t, []Stats{{Peer: "127.0.0.1", BytesIn: 5}}, serverTracker.Stats())
+}
@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package peerstats
+
+import (
+	apachethrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/tchannel-go"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+type server struct {
+	thrift.TChanServer
+
+	tracker Tracker
+}
+
+// NewServer returns a thrift server that records the RPCs it handles by the
+// host of the calling peer.
+func NewServer(s thrift.TChanServer, tracker Tracker) thrift.TChanServer {
+	return &server{TChanServer: s, tracker: tracker}
+}
+
+func (s *server) Handle(
+	ctx thrift.Context,
+	methodName string,
+	protocol apachethrift.TProtocol,
+) (bool, apachethrift.TStruct, error) {
+	success, resp, err := s.TChanServer.Handle(ctx, methodName, protocol)
+	if call := tchannel.CurrentCall(ctx); call != nil {
+		peer := RemoteHost(call.RemotePeer().HostPort)
+		s.tracker.RecordRPC(peer, !success || err != nil)
+	}
+	return success, resp, err
+}
+
+type client struct {
+	thrift.TChanClient
+
+	peer    string
+	tracker Tracker
+}
+
+// NewClient returns a thrift client that records the RPCs it makes to the
+// given peer.
+func NewClient(c thrift.TChanClient, peer string, tracker Tracker) thrift.TChanClient {
+	return &client{TChanClient: c, peer: peer, tracker: tracker}
+}
+
+func (c *client) Call(
+	ctx thrift.Context,
+	serviceName string,
+	methodName string,
+	req apachethrift.TStruct,
+	resp apachethrift.TStruct,
+) (bool, error) {
+	success, err := c.TChanClient.Call(ctx, serviceName, methodName, req, resp)
+	c.tracker.RecordRPC(c.peer, !success || err != nil)
+	return success, err
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// BlockSizeAnalysisURL is the URL of the block size analysis handler.
+	BlockSizeAnalysisURL = "/api/v1/namespace/block-size-analysis"
+
+	blockSizeAnalysisNamespaceParam = "namespace"
+)
+
+type blockSizeAnalysisResponse struct {
+	Namespace string `json:"namespace"`
+	// Matches is whether the configured block size and index block size
+	// match the recommended ones, false if there are no recommendations.
+	Matches  bool                      `json:"matches"`
+	Analysis storage.BlockSizeAnalysis `json:"analysis"`
+}
+
+// NewBlockSizeAnalysisHandler returns a handler that serves the block size
+// and index block size recommended for each namespace, or only for the
+// namespace query parameter if set, based on the datapoint density and index
+// query ranges observed, so operators can validate their configuration
+// against real traffic.
+func NewBlockSizeAnalysisHandler(
+	db storage.Database,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter := r.URL.Query().Get(blockSizeAnalysisNamespaceParam)
+		namespaces := db.Namespaces()
+		sort.Sort(storage.NamespacesByID(namespaces))
+
+		results := make([]blockSizeAnalysisResponse, 0, len(namespaces))
+		for _, ns := range namespaces {
+			id := ns.ID().String()
+			if filter != "" && filter != id {
+				continue
+			}
+			analysis := ns.BlockSizeAnalysis()
+			results = append(results, blockSizeAnalysisResponse{
+				Namespace: id,
+				Matches: analysis.RecommendedBlockSize != 0 &&
+					analysis.RecommendedBlockSize == analysis.BlockSize &&
+					analysis.RecommendedIndexBlockSize == analysis.IndexBlockSize,
+				Analysis: analysis,
+			})
+		}
+		if filter != "" && len(results) == 0 {
+			http.Error(w, "namespace not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			logger.Error("unable to encode block size analysis", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestBlockSizeAnalysisHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		db       = storage.NewMockDatabase(ctrl)
+		analyses = map[string]storage.BlockSizeAnalysis{
+			"matches": {
+				BlockSize:                 2 * time.Hour,
+				IndexBlockSize:            4 * time.Hour,
+				RecommendedBlockSize:      2 * time.Hour,
+				RecommendedIndexBlockSize: 4 * time.Hour,
+			},
+			"mismatches": {
+				BlockSize:                 2 * time.Hour,
+				IndexBlockSize:            4 * time.Hour,
+				RecommendedBlockSize:      6 * time.Hour,
+				RecommendedIndexBlockSize: 12 * time.Hour,
+			},
+			// Nothing has been observed yet so there is no recommendation.
+			"unobserved": {
+				BlockSize:      2 * time.Hour,
+				IndexBlockSize: 4 * time.Hour,
+			},
+		}
+		namespaces []storage.Namespace
+	)
+	for id, analysis := range analyses {
+		ns := storage.NewMockNamespace(ctrl)
+		ns.EXPECT().ID().Return(ident.StringID(id)).AnyTimes()
+		ns.EXPECT().BlockSizeAnalysis().Return(analysis).AnyTimes()
+		namespaces = append(namespaces, ns)
+	}
+	db.EXPECT().Namespaces().DoAndReturn(func() []storage.Namespace {
+		return append([]storage.Namespace(nil), namespaces...)
+	}).AnyTimes()
+
+	handler := NewBlockSizeAnalysisHandler(db, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, BlockSizeAnalysisURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp []blockSizeAnalysisResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, []blockSizeAnalysisResponse{
+		{Namespace: "matches", Matches: true, Analysis: analyses["matches"]},
+		{Namespace: "mismatches", Matches: false, Analysis: analyses["mismatches"]},
+		{Namespace: "unobserved", Matches: false, Analysis: analyses["unobserved"]},
+	}, resp)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet,
+		BlockSizeAnalysisURL+"?namespace=mismatches", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = nil
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, 1, len(resp))
+	require.Equal(t, "mismatches", resp[0].Namespace)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet,
+		BlockSizeAnalysisURL+"?namespace=unknown", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, BlockSizeAnalysisURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,185 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ImportURL is the URL of the import handler.
+	ImportURL = "/api/v1/import"
+
+	importNamespaceParam = "namespace"
+)
+
+// importSeries is a series in the body of an import request, the body is a
+// stream of these JSON objects, typically one per line.
+type importSeries struct {
+	ID         string            `json:"id"`
+	Tags       map[string]string `json:"tags"`
+	Datapoints []importDatapoint `json:"datapoints"`
+}
+
+// importDatapoint is a datapoint of an imported series, the timestamp is in
+// Unix milliseconds.
+type importDatapoint struct {
+	Timestamp int64   `json:"timestamp"`
+	Value     float64 `json:"value"`
+}
+
+type importResponse struct {
+	NumSeries     int64 `json:"numSeries"`
+	NumDatapoints int64 `json:"numDatapoints"`
+	NumBlocks     int64 `json:"numBlocks"`
+}
+
+// NewImportHandler returns a handler that streams historical datapoints in
+// the request body directly into the flushed filesets of a namespace, the
+// series are decoded one at a time as the import consumes them so arbitrarily
+// large bodies can be used.
+func NewImportHandler(
+	db storage.Database,
+	contextPool context.Pool,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "request must be POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		namespace := r.URL.Query().Get(importNamespaceParam)
+		if namespace == "" {
+			http.Error(w, fmt.Sprintf("missing %s param", importNamespaceParam),
+				http.StatusBadRequest)
+			return
+		}
+
+		ctx := contextPool.Get()
+		defer ctx.Close()
+
+		iter := newImportSeriesIterator(r.Body)
+		result, err := db.Import(ctx, ident.StringID(namespace), iter)
+		if err != nil {
+			logger.Error("import error",
+				zap.String("namespace", namespace),
+				zap.Int64("numSeries", result.NumSeries),
+				zap.Error(err))
+			status := http.StatusInternalServerError
+			if xerrors.IsInvalidParams(err) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(importResponse{
+			NumSeries:     result.NumSeries,
+			NumDatapoints: result.NumDatapoints,
+			NumBlocks:     result.NumBlocks,
+		}); err != nil {
+			logger.Error("unable to encode import response", zap.Error(err))
+		}
+	}
+}
+
+// importSeriesIterator implements storage.ImportIterator over a stream of
+// JSON encoded series.
+type importSeriesIterator struct {
+	decoder *json.Decoder
+	series  importSeries
+	id      ident.ID
+	tags    ident.Tags
+	idx     int
+	err     error
+}
+
+func newImportSeriesIterator(r io.Reader) *importSeriesIterator {
+	return &importSeriesIterator{decoder: json.NewDecoder(r)}
+}
+
+func (it *importSeriesIterator) Next() bool {
+	if it.err != nil {
+		return false
+	}
+
+	it.idx++
+	for it.idx >= len(it.series.Datapoints) {
+		var series importSeries
+		if err := it.decoder.Decode(&series); err != nil {
+			if err != io.EOF {
+				it.err = xerrors.NewInvalidParamsError(
+					fmt.Errorf("unable to decode import series: %v", err))
+			}
+			return false
+		}
+		if series.ID == "" {
+			it.err = xerrors.NewInvalidParamsError(
+				errors.New("import series is missing an id"))
+			return false
+		}
+
+		names := make([]string, 0, len(series.Tags))
+		for name := range series.Tags {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		tags := ident.NewTags()
+		for _, name := range names {
+			tags.Append(ident.StringTag(name, series.Tags[name]))
+		}
+
+		it.series = series
+		it.id = ident.StringID(series.ID)
+		it.tags = tags
+		it.idx = 0
+	}
+	return true
+}
+
+func (it *importSeriesIterator) Current() (ident.ID, ident.TagIterator, ts.Datapoint,
+	xtime.Unit, ts.Annotation) {
+	dp := it.series.Datapoints[it.idx]
+	return it.id, ident.NewTagsIterator(it.tags), ts.Datapoint{
+		Timestamp: time.Unix(0, dp.Timestamp*int64(time.Millisecond)),
+		Value:     dp.Value,
+	}, xtime.Millisecond, nil
+}
+
+func (it *importSeriesIterator) Err() error {
+	return it.err
+}
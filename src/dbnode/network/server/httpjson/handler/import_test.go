@@ -0,0 +1,188 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type testImportDatapoint struct {
+	id    string
+	tags  map[string]string
+	at    time.Time
+	value float64
+}
+
+func readTestImportDatapoints(iter storage.ImportIterator) []testImportDatapoint {
+	var datapoints []testImportDatapoint
+	for iter.Next() {
+		id, tagIter, dp, _, _ := iter.Current()
+		tags := make(map[string]string)
+		for tagIter.Next() {
+			tag := tagIter.Current()
+			tags[tag.Name.String()] = tag.Value.String()
+		}
+		datapoints = append(datapoints, testImportDatapoint{
+			id:    id.String(),
+			tags:  tags,
+			at:    dp.Timestamp,
+			value: dp.Value,
+		})
+	}
+	return datapoints
+}
+
+func TestImportHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	body := `{"id":"a","tags":{"host":"a","dc":"east"},"datapoints":[{"timestamp":1000,"value":1},{"timestamp":2000,"value":2}]}
+{"id":"b","datapoints":[]}
+{"id":"c","tags":{},"datapoints":[{"timestamp":3000,"value":3}]}
+`
+
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Import(gomock.Any(), ident.NewIDMatcher("metrics"), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			iter storage.ImportIterator,
+		) (storage.ImportResult, error) {
+			// Series without datapoints are skipped.
+			require.Equal(t, []testImportDatapoint{
+				{id: "a", tags: map[string]string{"dc": "east", "host": "a"}, at: time.Unix(1, 0), value: 1},
+				{id: "a", tags: map[string]string{"dc": "east", "host": "a"}, at: time.Unix(2, 0), value: 2},
+				{id: "c", tags: map[string]string{}, at: time.Unix(3, 0), value: 3},
+			}, readTestImportDatapoints(iter))
+			require.NoError(t, iter.Err())
+			return storage.ImportResult{NumSeries: 2, NumDatapoints: 3, NumBlocks: 1}, nil
+		})
+
+	w := httptest.NewRecorder()
+	NewImportHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())(w,
+		httptest.NewRequest(http.MethodPost, ImportURL+"?namespace=metrics",
+			strings.NewReader(body)))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp importResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, importResponse{NumSeries: 2, NumDatapoints: 3, NumBlocks: 1}, resp)
+}
+
+func TestImportHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	importErr := errors.New("import failed")
+	tests := []struct {
+		name      string
+		method    string
+		url       string
+		importErr error
+		status    int
+	}{
+		{
+			name:   "not post",
+			method: http.MethodGet,
+			url:    ImportURL + "?namespace=metrics",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "missing namespace",
+			url:    ImportURL,
+			status: http.StatusBadRequest,
+		},
+		{
+			name:      "import error",
+			url:       ImportURL + "?namespace=metrics",
+			importErr: importErr,
+			status:    http.StatusInternalServerError,
+		},
+		{
+			name:      "invalid params import error",
+			url:       ImportURL + "?namespace=metrics",
+			importErr: xerrors.NewInvalidParamsError(importErr),
+			status:    http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := storage.NewMockDatabase(ctrl)
+			if test.importErr != nil {
+				db.EXPECT().Import(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(storage.ImportResult{}, test.importErr)
+			}
+
+			method := test.method
+			if method == "" {
+				method = http.MethodPost
+			}
+
+			w := httptest.NewRecorder()
+			NewImportHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())(w,
+				httptest.NewRequest(method, test.url, strings.NewReader("")))
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestImportSeriesIteratorErrors(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		numDatapoints int
+	}{
+		{
+			name:          "invalid json",
+			body:          `{"id":"a","datapoints":[{"timestamp":1000,"value":1}]}{"id":`,
+			numDatapoints: 1,
+		},
+		{
+			name: "missing id",
+			body: `{"datapoints":[{"timestamp":1000,"value":1}]}`,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			iter := newImportSeriesIterator(strings.NewReader(test.body))
+			require.Equal(t, test.numDatapoints, len(readTestImportDatapoints(iter)))
+			require.Error(t, iter.Err())
+			require.True(t, xerrors.IsInvalidParams(iter.Err()))
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// NamespaceFreezeURL is the URL of the namespace freeze handler.
+	NamespaceFreezeURL = "/api/v1/namespace/freeze"
+	// NamespaceUnfreezeURL is the URL of the namespace unfreeze handler.
+	NamespaceUnfreezeURL = "/api/v1/namespace/unfreeze"
+
+	namespaceFreezeParam      = "namespace"
+	namespaceFreezeStartParam = "start"
+	namespaceFreezeEndParam   = "end"
+	namespaceFreezeTimeLayout = time.RFC3339Nano
+)
+
+type namespaceFreezeRange struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+type namespaceFreezeResponse struct {
+	Namespace string                 `json:"namespace"`
+	Frozen    []namespaceFreezeRange `json:"frozen"`
+}
+
+// NewNamespaceFreezeHandler returns a handler that serves the frozen time
+// ranges of each namespace, or only of the namespace query parameter if set,
+// on GET and freezes the start to end time range of the namespace query
+// parameter on POST so that its data is preserved as is while being
+// investigated.
+func NewNamespaceFreezeHandler(
+	db storage.Database,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			writeNamespaceFreezes(w, db, r.URL.Query().Get(namespaceFreezeParam), logger)
+		case http.MethodPost:
+			updateNamespaceFreeze(w, r, db, storage.Namespace.Freeze, logger)
+		default:
+			http.Error(w, "request must be GET or POST", http.StatusMethodNotAllowed)
+		}
+	}
+}
+
+// NewNamespaceUnfreezeHandler returns a handler that unfreezes the start to
+// end time range of the namespace query parameter, any frozen data outside of
+// the range remains frozen.
+func NewNamespaceUnfreezeHandler(
+	db storage.Database,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "request must be POST", http.StatusMethodNotAllowed)
+			return
+		}
+		updateNamespaceFreeze(w, r, db, storage.Namespace.Unfreeze, logger)
+	}
+}
+
+func updateNamespaceFreeze(
+	w http.ResponseWriter,
+	r *http.Request,
+	db storage.Database,
+	updateFn func(ns storage.Namespace, start, end time.Time) error,
+	logger *zap.Logger,
+) {
+	query := r.URL.Query()
+	id := query.Get(namespaceFreezeParam)
+	if id == "" {
+		http.Error(w, fmt.Sprintf("missing %s param", namespaceFreezeParam),
+			http.StatusBadRequest)
+		return
+	}
+	ns, ok := db.Namespace(ident.StringID(id))
+	if !ok {
+		http.Error(w, "namespace not found", http.StatusNotFound)
+		return
+	}
+
+	var times [2]time.Time
+	for i, param := range []string{namespaceFreezeStartParam, namespaceFreezeEndParam} {
+		t, err := time.Parse(namespaceFreezeTimeLayout, query.Get(param))
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid %s param: %v", param, err),
+				http.StatusBadRequest)
+			return
+		}
+		times[i] = t
+	}
+
+	if err := updateFn(ns, times[0], times[1]); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	writeNamespaceFreezes(w, db, id, logger)
+}
+
+func writeNamespaceFreezes(
+	w http.ResponseWriter,
+	db storage.Database,
+	filter string,
+	logger *zap.Logger,
+) {
+	namespaces := db.Namespaces()
+	sort.Sort(storage.NamespacesByID(namespaces))
+
+	results := make([]namespaceFreezeResponse, 0, len(namespaces))
+	for _, ns := range namespaces {
+		id := ns.ID().String()
+		if filter != "" && filter != id {
+			continue
+		}
+		ranges := ns.FrozenRanges()
+		frozen := make([]namespaceFreezeRange, 0, len(ranges))
+		for _, r := range ranges {
+			frozen = append(frozen, namespaceFreezeRange{Start: r.Start, End: r.End})
+		}
+		results = append(results, namespaceFreezeResponse{
+			Namespace: id,
+			Frozen:    frozen,
+		})
+	}
+	if filter != "" && len(results) == 0 {
+		http.Error(w, "namespace not found", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(results); err != nil {
+		logger.Error("unable to encode namespace freezes", zap.Error(err))
+	}
+}
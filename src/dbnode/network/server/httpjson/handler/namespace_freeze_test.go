@@ -0,0 +1,210 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newTestNamespaceFreezeURL(path, namespace string, start, end time.Time) string {
+	params := url.Values{}
+	params.Set(namespaceFreezeParam, namespace)
+	params.Set(namespaceFreezeStartParam, start.Format(namespaceFreezeTimeLayout))
+	params.Set(namespaceFreezeEndParam, end.Format(namespaceFreezeTimeLayout))
+	return path + "?" + params.Encode()
+}
+
+func decodeTestNamespaceFreezes(
+	t *testing.T,
+	w *httptest.ResponseRecorder,
+) []namespaceFreezeResponse {
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	var resp []namespaceFreezeResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	return resp
+}
+
+func TestNamespaceFreezeHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		start  = time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+		end    = start.Add(6 * time.Hour)
+		ranges []xtime.Range
+		db     = storage.NewMockDatabase(ctrl)
+		nsA    = storage.NewMockNamespace(ctrl)
+		nsB    = storage.NewMockNamespace(ctrl)
+	)
+	nsA.EXPECT().ID().Return(ident.StringID("a")).AnyTimes()
+	nsA.EXPECT().FrozenRanges().DoAndReturn(func() []xtime.Range {
+		return ranges
+	}).AnyTimes()
+	nsB.EXPECT().ID().Return(ident.StringID("b")).AnyTimes()
+	nsB.EXPECT().FrozenRanges().Return(nil).AnyTimes()
+	db.EXPECT().Namespaces().Return([]storage.Namespace{nsB, nsA}).AnyTimes()
+	db.EXPECT().Namespace(ident.NewIDMatcher("a")).Return(nsA, true).AnyTimes()
+
+	gomock.InOrder(
+		nsA.EXPECT().Freeze(start, end).DoAndReturn(func(start, end time.Time) error {
+			ranges = []xtime.Range{{Start: start, End: end}}
+			return nil
+		}),
+		nsA.EXPECT().Unfreeze(start, end).DoAndReturn(func(start, end time.Time) error {
+			ranges = nil
+			return nil
+		}),
+	)
+
+	var (
+		freeze   = NewNamespaceFreezeHandler(db, zap.NewNop())
+		unfreeze = NewNamespaceUnfreezeHandler(db, zap.NewNop())
+	)
+
+	w := httptest.NewRecorder()
+	freeze(w, httptest.NewRequest(http.MethodPost,
+		newTestNamespaceFreezeURL(NamespaceFreezeURL, "a", start, end), nil))
+	resp := decodeTestNamespaceFreezes(t, w)
+	require.Equal(t, 1, len(resp))
+	require.Equal(t, "a", resp[0].Namespace)
+	require.Equal(t, 1, len(resp[0].Frozen))
+	require.True(t, start.Equal(resp[0].Frozen[0].Start))
+	require.True(t, end.Equal(resp[0].Frozen[0].End))
+
+	// Namespaces are listed in order of their IDs.
+	w = httptest.NewRecorder()
+	freeze(w, httptest.NewRequest(http.MethodGet, NamespaceFreezeURL, nil))
+	resp = decodeTestNamespaceFreezes(t, w)
+	require.Equal(t, 2, len(resp))
+	require.Equal(t, "a", resp[0].Namespace)
+	require.Equal(t, 1, len(resp[0].Frozen))
+	require.Equal(t, "b", resp[1].Namespace)
+	require.Equal(t, 0, len(resp[1].Frozen))
+
+	w = httptest.NewRecorder()
+	unfreeze(w, httptest.NewRequest(http.MethodPost,
+		newTestNamespaceFreezeURL(NamespaceUnfreezeURL, "a", start, end), nil))
+	resp = decodeTestNamespaceFreezes(t, w)
+	require.Equal(t, 1, len(resp))
+	require.Equal(t, 0, len(resp[0].Frozen))
+}
+
+func TestNamespaceFreezeHandlersErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		start = time.Date(2020, 9, 1, 0, 0, 0, 0, time.UTC)
+		end   = start.Add(6 * time.Hour)
+		db    = storage.NewMockDatabase(ctrl)
+		ns    = storage.NewMockNamespace(ctrl)
+	)
+	ns.EXPECT().ID().Return(ident.StringID("a")).AnyTimes()
+	db.EXPECT().Namespaces().Return([]storage.Namespace{ns}).AnyTimes()
+	db.EXPECT().Namespace(ident.NewIDMatcher("a")).Return(ns, true).AnyTimes()
+	db.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
+	ns.EXPECT().Freeze(end, start).Return(errors.New("start must be before end"))
+
+	var (
+		freeze   = NewNamespaceFreezeHandler(db, zap.NewNop())
+		unfreeze = NewNamespaceUnfreezeHandler(db, zap.NewNop())
+	)
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		method  string
+		url     string
+		status  int
+	}{
+		{
+			name:    "freeze not get or post",
+			handler: freeze,
+			method:  http.MethodDelete,
+			url:     NamespaceFreezeURL,
+			status:  http.StatusMethodNotAllowed,
+		},
+		{
+			name:    "unfreeze not post",
+			handler: unfreeze,
+			method:  http.MethodGet,
+			url:     NamespaceUnfreezeURL,
+			status:  http.StatusMethodNotAllowed,
+		},
+		{
+			name:    "list unknown namespace",
+			handler: freeze,
+			method:  http.MethodGet,
+			url:     NamespaceFreezeURL + "?namespace=unknown",
+			status:  http.StatusNotFound,
+		},
+		{
+			name:    "missing namespace",
+			handler: freeze,
+			method:  http.MethodPost,
+			url:     NamespaceFreezeURL,
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "unknown namespace",
+			handler: unfreeze,
+			method:  http.MethodPost,
+			url:     newTestNamespaceFreezeURL(NamespaceUnfreezeURL, "unknown", start, end),
+			status:  http.StatusNotFound,
+		},
+		{
+			name:    "invalid start",
+			handler: freeze,
+			method:  http.MethodPost,
+			url:     NamespaceFreezeURL + "?namespace=a&start=yesterday",
+			status:  http.StatusBadRequest,
+		},
+		{
+			name:    "freeze error",
+			handler: freeze,
+			method:  http.MethodPost,
+			url:     newTestNamespaceFreezeURL(NamespaceFreezeURL, "a", end, start),
+			status:  http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			test.handler(w, httptest.NewRequest(test.method, test.url, nil))
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,66 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
+
+	"go.uber.org/zap"
+)
+
+// PeerStatsURL is the URL of the peer stats handler.
+const PeerStatsURL = "/debug/peers"
+
+type peerStatsResponse struct {
+	// Inbound are the statistics of the connections and RPCs from clients
+	// and other nodes, by the host of the peer.
+	Inbound []peerstats.Stats `json:"inbound"`
+	// Outbound are the statistics of the connections and RPCs to other
+	// nodes, by the address of the peer.
+	Outbound []peerstats.Stats `json:"outbound"`
+}
+
+// NewPeerStatsHandler returns a handler that serves the bytes transferred,
+// RPCs and RPC errors of each remote peer so a slow or noisy peer can be
+// identified quickly.
+func NewPeerStatsHandler(
+	inbound peerstats.Tracker,
+	outbound peerstats.Tracker,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(peerStatsResponse{
+			Inbound:  inbound.Stats(),
+			Outbound: outbound.Stats(),
+		}); err != nil {
+			logger.Error("unable to encode peer stats", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPeerStatsHandler(t *testing.T) {
+	var (
+		inbound  = peerstats.NewTracker(instrument.NewOptions())
+		outbound = peerstats.NewTracker(instrument.NewOptions())
+	)
+	inbound.ConnectionOpened("client-a")
+	inbound.RecordBytesIn("client-a", 100)
+	inbound.RecordBytesOut("client-a", 10)
+	inbound.RecordRPC("client-a", false)
+	inbound.RecordRPC("client-a", true)
+	outbound.ConnectionOpened("10.0.0.2:9000")
+	outbound.RecordBytesOut("10.0.0.2:9000", 50)
+	outbound.RecordRPC("10.0.0.2:9000", false)
+
+	handler := NewPeerStatsHandler(inbound, outbound, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, PeerStatsURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp peerStatsResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, peerStatsResponse{
+		Inbound: []peerstats.Stats{{
+			Peer:        "client-a",
+			Connections: 1,
+			BytesIn:     100,
+			BytesOut:    10,
+			RPCs:        2,
+			RPCErrors:   1,
+		}},
+		Outbound: []peerstats.Stats{{
+			Peer:        "10.0.0.2:9000",
+			Connections: 1,
+			BytesOut:    50,
+			RPCs:        1,
+		}},
+	}, resp)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, PeerStatsURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
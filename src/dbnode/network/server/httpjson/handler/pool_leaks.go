@@ -0,0 +1,67 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/x/pool"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PoolLeaksURL is the URL of the pool leaks handler.
+	PoolLeaksURL = "/debug/pool/leaks"
+
+	poolLeaksOlderThanParam = "olderThan"
+)
+
+// NewPoolLeaksHandler returns a handler that serves the objects checked out
+// of object pools that have not been returned, grouped by the stack trace
+// that acquired them. Only objects checked out for at least the olderThan
+// query parameter are reported, defaults to reporting all of them.
+func NewPoolLeaksHandler(logger *zap.Logger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		var olderThan time.Duration
+		if str := r.URL.Query().Get(poolLeaksOlderThanParam); str != "" {
+			var err error
+			olderThan, err = time.ParseDuration(str)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s: %v",
+					poolLeaksOlderThanParam, err), http.StatusBadRequest)
+				return
+			}
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(pool.DumpLeaks(olderThan)); err != nil {
+			logger.Error("unable to encode pool leaks", zap.Error(err))
+		}
+	}
+}
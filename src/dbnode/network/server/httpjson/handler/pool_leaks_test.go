@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/x/pool"
+
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type poolLeaksTestObject struct {
+	value []byte
+}
+
+func TestPoolLeaksHandler(t *testing.T) {
+	pool.EnableLeakDetection()
+	defer pool.DisableLeakDetection()
+
+	objPool := pool.NewObjectPool(pool.NewObjectPoolOptions().SetSize(1))
+	objPool.Init(func() interface{} {
+		return &poolLeaksTestObject{value: make([]byte, 64)}
+	})
+	obj := objPool.Get()
+	defer objPool.Put(obj)
+
+	handler := NewPoolLeaksHandler(zap.NewNop())
+	get := func(url string) []pool.Leak {
+		w := httptest.NewRecorder()
+		handler(w, httptest.NewRequest(http.MethodGet, url, nil))
+		require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+		var resp []pool.Leak
+		require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+
+		// Only consider the objects checked out by this test.
+		var leaks []pool.Leak
+		for _, leak := range resp {
+			if leak.Type == "*handler.poolLeaksTestObject" {
+				leaks = append(leaks, leak)
+			}
+		}
+		return leaks
+	}
+
+	leaks := get(PoolLeaksURL)
+	require.Equal(t, 1, len(leaks))
+	require.Equal(t, 1, leaks[0].Outstanding)
+	require.True(t, strings.Contains(leaks[0].Stack, "TestPoolLeaksHandler"))
+
+	// Nothing is old enough to be reported.
+	require.Equal(t, 0, len(get(PoolLeaksURL+"?olderThan=1h")))
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, PoolLeaksURL+"?olderThan=foo", nil))
+	require.Equal(t, http.StatusBadRequest, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, PoolLeaksURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
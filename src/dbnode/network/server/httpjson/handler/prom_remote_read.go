@@ -0,0 +1,532 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package handler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	querystorage "github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/snappy"
+	"go.uber.org/zap"
+)
+
+const (
+	// PromRemoteReadURL is the URL of the Prometheus remote read handler.
+	PromRemoteReadURL = "/api/v1/prom/remote/read"
+
+	promStreamedContentType = "application/x-streamed-protobuf; proto=prometheus.ChunkedReadResponse"
+
+	// promMaxBytesInFrame is the soft limit for the size of a single frame of
+	// a streamed response, series with more chunks are split across frames.
+	promMaxBytesInFrame = 1024 * 1024
+)
+
+// promReadResponseType is the ReadRequest_ResponseType enum of the remote
+// read protocol, the generated prompb types predate streamed responses.
+type promReadResponseType uint64
+
+const (
+	promReadResponseTypeSamples           promReadResponseType = 0
+	promReadResponseTypeStreamedXORChunks promReadResponseType = 1
+)
+
+const (
+	promWireVarint  = 0
+	promWireFixed64 = 1
+	promWireBytes   = 2
+	promWireFixed32 = 5
+
+	promReadRequestAcceptedResponseTypesField = 2
+
+	promChunkedReadResponseSeriesField     = 1
+	promChunkedReadResponseQueryIndexField = 2
+	promChunkedSeriesLabelsField           = 1
+	promChunkedSeriesChunksField           = 2
+	promChunkMinTimeField                  = 1
+	promChunkMaxTimeField                  = 2
+	promChunkTypeField                     = 3
+	promChunkDataField                     = 4
+
+	promChunkEncodingXOR = 1
+)
+
+var (
+	errPromMalformedReadRequest = errors.New("malformed remote read request")
+
+	promCastagnoliTable = crc32.MakeTable(crc32.Castagnoli)
+)
+
+type promReadSeries struct {
+	labels  []prompb.Label
+	samples []prompb.Sample
+}
+
+// NewPromRemoteReadHandler returns a handler that serves Prometheus remote
+// read requests by resolving the query matchers against the index and reading
+// the matching series directly from the database. Both sampled responses and
+// streamed XOR chunk responses are supported, the first response type
+// accepted by the client that is supported is used.
+func NewPromRemoteReadHandler(
+	db storage.Database,
+	nsID ident.ID,
+	contextPool context.Pool,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "request must be POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		result, parseErr := prometheus.ParsePromCompressedRequest(r)
+		if parseErr != nil {
+			http.Error(w, parseErr.Error(), parseErr.Code())
+			return
+		}
+
+		var req prompb.ReadRequest
+		if err := req.Unmarshal(result.UncompressedBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		responseType, err := promNegotiateReadResponseType(result.UncompressedBody)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		ctx := contextPool.Get()
+		defer ctx.Close()
+
+		if responseType == promReadResponseTypeStreamedXORChunks {
+			promRemoteReadStreamed(ctx, w, db, nsID, req.Queries, logger)
+			return
+		}
+
+		resp := &prompb.ReadResponse{
+			Results: make([]*prompb.QueryResult, 0, len(req.Queries)),
+		}
+		for _, query := range req.Queries {
+			series, err := promReadQuery(ctx, db, nsID, query)
+			if err != nil {
+				logger.Error("prom remote read error", zap.Error(err))
+				http.Error(w, err.Error(), promReadErrorStatus(err))
+				return
+			}
+
+			timeseries := make([]*prompb.TimeSeries, 0, len(series))
+			for _, s := range series {
+				timeseries = append(timeseries, &prompb.TimeSeries{
+					Labels:  s.labels,
+					Samples: s.samples,
+				})
+			}
+			resp.Results = append(resp.Results,
+				&prompb.QueryResult{Timeseries: timeseries})
+		}
+
+		data, err := resp.Marshal()
+		if err != nil {
+			logger.Error("unable to marshal prom remote read response",
+				zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-protobuf")
+		w.Header().Set("Content-Encoding", "snappy")
+		if _, err := w.Write(snappy.Encode(nil, data)); err != nil {
+			logger.Error("unable to write prom remote read response",
+				zap.Error(err))
+		}
+	}
+}
+
+// promRemoteReadStreamed writes each series of each query as one or more
+// length prefixed and checksummed ChunkedReadResponse frames. All queries are
+// run before the first frame is written so that a failed query is reported
+// with a status code rather than as a truncated response, which the client
+// could not tell apart from a complete one.
+func promRemoteReadStreamed(
+	ctx context.Context,
+	w http.ResponseWriter,
+	db storage.Database,
+	nsID ident.ID,
+	queries []*prompb.Query,
+	logger *zap.Logger,
+) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	results := make([][]promReadSeries, 0, len(queries))
+	for _, query := range queries {
+		series, err := promReadQuery(ctx, db, nsID, query)
+		if err != nil {
+			logger.Error("prom remote read error", zap.Error(err))
+			http.Error(w, err.Error(), promReadErrorStatus(err))
+			return
+		}
+		results = append(results, series)
+	}
+
+	w.Header().Set("Content-Type", promStreamedContentType)
+	if err := promWriteStreamedResults(w, flusher, results); err != nil {
+		logger.Error("unable to write prom remote read frame", zap.Error(err))
+		// Abort the response so the connection is closed and the client
+		// sees a broken stream instead of a complete response.
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// promWriteStreamedResults writes the series of the results of each query as
+// ChunkedReadResponse frames, splitting the chunks of a series across frames
+// once a frame exceeds promMaxBytesInFrame.
+func promWriteStreamedResults(
+	w http.ResponseWriter,
+	flusher http.Flusher,
+	results [][]promReadSeries,
+) error {
+	for i, series := range results {
+		for _, s := range series {
+			var (
+				chunks = promXORChunks(s.samples)
+				size   = 0
+				start  = 0
+			)
+			for j, chunk := range chunks {
+				size += len(chunk.data)
+				if size < promMaxBytesInFrame && j != len(chunks)-1 {
+					continue
+				}
+
+				frame, err := promEncodeChunkedReadResponse(s.labels,
+					chunks[start:j+1], int64(i))
+				if err != nil {
+					return err
+				}
+				if err := promWriteFrame(w, frame); err != nil {
+					return err
+				}
+				flusher.Flush()
+				size, start = 0, j+1
+			}
+		}
+	}
+
+	return nil
+}
+
+func promReadQuery(
+	ctx context.Context,
+	db storage.Database,
+	nsID ident.ID,
+	query *prompb.Query,
+) ([]promReadSeries, error) {
+	fetchQuery, err := querystorage.PromReadQueryToM3(query)
+	if err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+
+	fetchOpts := querystorage.NewFetchOptions()
+	indexQuery, err := querystorage.FetchQueryToM3Query(fetchQuery, fetchOpts)
+	if err != nil {
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+
+	queryResult, err := db.QueryIDs(ctx, nsID, indexQuery,
+		querystorage.FetchOptionsToM3Options(fetchOpts, fetchQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := index.ResultsEntries(queryResult.Results)
+	if err != nil {
+		return nil, err
+	}
+
+	series := make([]promReadSeries, 0, len(entries))
+	for _, entry := range entries {
+		samples, err := promReadSamples(ctx, db, nsID, entry.Key(),
+			fetchQuery.Start, fetchQuery.End)
+		if err != nil {
+			return nil, err
+		}
+		if len(samples) == 0 {
+			continue
+		}
+
+		labels, err := promTagsToLabels(entry.Value().Duplicate())
+		if err != nil {
+			return nil, err
+		}
+
+		series = append(series, promReadSeries{
+			labels:  labels,
+			samples: samples,
+		})
+	}
+
+	// Streamed responses are merged by the client assuming series are sorted
+	// by their label sets, sort sampled responses too for consistency.
+	sort.Slice(series, func(i, j int) bool {
+		return promCompareLabels(series[i].labels, series[j].labels) < 0
+	})
+
+	return series, nil
+}
+
+// promReadSamples reads the samples of a series within the inclusive range
+// [start, end] of a Prometheus query.
+func promReadSamples(
+	ctx context.Context,
+	db storage.Database,
+	nsID ident.ID,
+	id ident.ID,
+	start, end time.Time,
+) ([]prompb.Sample, error) {
+	// NB: The end of a Prometheus query is inclusive, since samples have
+	// millisecond precision read up to the millisecond following it.
+	datapoints, err := readDatapoints(ctx, db, nsID, id, start,
+		end.Add(time.Millisecond))
+	if err != nil {
+		return nil, err
+	}
+
+	samples := make([]prompb.Sample, 0, len(datapoints))
+	for _, dp := range datapoints {
+		samples = append(samples, prompb.Sample{
+			Timestamp: querystorage.TimeToPromTimestamp(dp.Timestamp),
+			Value:     dp.Value,
+		})
+	}
+
+	return samples, nil
+}
+
+// promTagsToLabels copies the tags into labels sorted by name, series written
+// through remote write use the Prometheus label names as tag names as is.
+func promTagsToLabels(tags ident.TagIterator) ([]prompb.Label, error) {
+	defer tags.Close()
+
+	labels := make([]prompb.Label, 0, tags.Remaining())
+	for tags.Next() {
+		tag := tags.Current()
+		labels = append(labels, prompb.Label{
+			Name:  append([]byte(nil), tag.Name.Bytes()...),
+			Value: append([]byte(nil), tag.Value.Bytes()...),
+		})
+	}
+
+	if err := tags.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(labels, func(i, j int) bool {
+		return bytes.Compare(labels[i].Name, labels[j].Name) < 0
+	})
+
+	return labels, nil
+}
+
+func promCompareLabels(a, b []prompb.Label) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if c := bytes.Compare(a[i].Name, b[i].Name); c != 0 {
+			return c
+		}
+		if c := bytes.Compare(a[i].Value, b[i].Value); c != 0 {
+			return c
+		}
+	}
+
+	return len(a) - len(b)
+}
+
+func promReadErrorStatus(err error) int {
+	if xerrors.IsInvalidParams(err) {
+		return http.StatusBadRequest
+	}
+
+	return http.StatusInternalServerError
+}
+
+// promNegotiateReadResponseType returns the first response type in the
+// accepted_response_types field of the raw ReadRequest that is supported,
+// defaulting to sampled responses when none are listed.
+func promNegotiateReadResponseType(
+	data []byte,
+) (promReadResponseType, error) {
+	for len(data) > 0 {
+		key, n := binary.Uvarint(data)
+		if n <= 0 {
+			return 0, errPromMalformedReadRequest
+		}
+		data = data[n:]
+
+		field, wireType := key>>3, key&0x7
+		switch wireType {
+		case promWireVarint:
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				return 0, errPromMalformedReadRequest
+			}
+			data = data[n:]
+
+			if field == promReadRequestAcceptedResponseTypesField {
+				if t, ok := promSupportedReadResponseType(v); ok {
+					return t, nil
+				}
+			}
+		case promWireBytes:
+			l, n := binary.Uvarint(data)
+			if n <= 0 || uint64(len(data)-n) < l {
+				return 0, errPromMalformedReadRequest
+			}
+			value := data[n : n+int(l)]
+			data = data[n+int(l):]
+
+			if field != promReadRequestAcceptedResponseTypesField {
+				continue
+			}
+
+			// Packed repeated enum.
+			for len(value) > 0 {
+				v, n := binary.Uvarint(value)
+				if n <= 0 {
+					return 0, errPromMalformedReadRequest
+				}
+				value = value[n:]
+
+				if t, ok := promSupportedReadResponseType(v); ok {
+					return t, nil
+				}
+			}
+		case promWireFixed64:
+			if len(data) < 8 {
+				return 0, errPromMalformedReadRequest
+			}
+			data = data[8:]
+		case promWireFixed32:
+			if len(data) < 4 {
+				return 0, errPromMalformedReadRequest
+			}
+			data = data[4:]
+		default:
+			return 0, errPromMalformedReadRequest
+		}
+	}
+
+	return promReadResponseTypeSamples, nil
+}
+
+func promSupportedReadResponseType(v uint64) (promReadResponseType, bool) {
+	switch t := promReadResponseType(v); t {
+	case promReadResponseTypeSamples, promReadResponseTypeStreamedXORChunks:
+		return t, true
+	default:
+		return 0, false
+	}
+}
+
+// promEncodeChunkedReadResponse encodes a ChunkedReadResponse holding a
+// single ChunkedSeries, the generated prompb types predate chunked responses.
+func promEncodeChunkedReadResponse(
+	labels []prompb.Label,
+	chunks []promXORChunk,
+	queryIndex int64,
+) ([]byte, error) {
+	var series []byte
+	for i := range labels {
+		label, err := labels[i].Marshal()
+		if err != nil {
+			return nil, err
+		}
+		series = promAppendBytes(series, promChunkedSeriesLabelsField, label)
+	}
+
+	for _, chunk := range chunks {
+		var c []byte
+		c = promAppendKey(c, promChunkMinTimeField, promWireVarint)
+		c = promAppendUvarint(c, uint64(chunk.minTimeMs))
+		c = promAppendKey(c, promChunkMaxTimeField, promWireVarint)
+		c = promAppendUvarint(c, uint64(chunk.maxTimeMs))
+		c = promAppendKey(c, promChunkTypeField, promWireVarint)
+		c = promAppendUvarint(c, promChunkEncodingXOR)
+		c = promAppendBytes(c, promChunkDataField, chunk.data)
+		series = promAppendBytes(series, promChunkedSeriesChunksField, c)
+	}
+
+	var resp []byte
+	resp = promAppendBytes(resp, promChunkedReadResponseSeriesField, series)
+	if queryIndex != 0 {
+		resp = promAppendKey(resp, promChunkedReadResponseQueryIndexField,
+			promWireVarint)
+		resp = promAppendUvarint(resp, uint64(queryIndex))
+	}
+
+	return resp, nil
+}
+
+func promAppendUvarint(b []byte, v uint64) []byte {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	return append(b, buf[:n]...)
+}
+
+func promAppendKey(b []byte, field, wireType uint64) []byte {
+	return promAppendUvarint(b, field<<3|wireType)
+}
+
+func promAppendBytes(b []byte, field uint64, value []byte) []byte {
+	b = promAppendKey(b, field, promWireBytes)
+	b = promAppendUvarint(b, uint64(len(value)))
+	return append(b, value...)
+}
+
+// promWriteFrame writes a frame of a streamed response, the message is
+// prefixed by its uvarint length and big endian CRC32 Castagnoli checksum.
+func promWriteFrame(w http.ResponseWriter, msg []byte) error {
+	var header [binary.MaxVarintLen64 + 4]byte
+	n := binary.PutUvarint(header[:], uint64(len(msg)))
+	binary.BigEndian.PutUint32(header[n:], crc32.Checksum(msg, promCastagnoliTable))
+	if _, err := w.Write(header[:n+4]); err != nil {
+		return err
+	}
+
+	_, err := w.Write(msg)
+	return err
+}
@@ -0,0 +1,309 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	querystorage "github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	upstreamprompb "github.com/prometheus/prometheus/prompb"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPromNegotiateReadResponseType(t *testing.T) {
+	tests := []struct {
+		name     string
+		accepted []upstreamprompb.ReadRequest_ResponseType
+		expected promReadResponseType
+	}{
+		{
+			name:     "none accepted",
+			expected: promReadResponseTypeSamples,
+		},
+		{
+			name: "samples first",
+			accepted: []upstreamprompb.ReadRequest_ResponseType{
+				upstreamprompb.ReadRequest_SAMPLES,
+				upstreamprompb.ReadRequest_STREAMED_XOR_CHUNKS,
+			},
+			expected: promReadResponseTypeSamples,
+		},
+		{
+			name: "unsupported types skipped",
+			accepted: []upstreamprompb.ReadRequest_ResponseType{
+				upstreamprompb.ReadRequest_ResponseType(7),
+				upstreamprompb.ReadRequest_STREAMED_XOR_CHUNKS,
+			},
+			expected: promReadResponseTypeStreamedXORChunks,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := &upstreamprompb.ReadRequest{
+				Queries: []*upstreamprompb.Query{{
+					StartTimestampMs: 1000,
+					EndTimestampMs:   2000,
+					Matchers: []*upstreamprompb.LabelMatcher{{
+						Type:  upstreamprompb.LabelMatcher_EQ,
+						Name:  "__name__",
+						Value: "foo",
+					}},
+				}},
+				AcceptedResponseTypes: test.accepted,
+			}
+			data, err := req.Marshal()
+			require.NoError(t, err)
+
+			actual, err := promNegotiateReadResponseType(data)
+			require.NoError(t, err)
+			require.Equal(t, test.expected, actual)
+
+			// The request is still a valid request without the field.
+			var decoded prompb.ReadRequest
+			require.NoError(t, decoded.Unmarshal(data))
+			require.Len(t, decoded.Queries, 1)
+		})
+	}
+}
+
+func TestPromNegotiateReadResponseTypeUnpacked(t *testing.T) {
+	var data []byte
+	data = promAppendKey(data, promReadRequestAcceptedResponseTypesField, promWireVarint)
+	data = promAppendUvarint(data, uint64(upstreamprompb.ReadRequest_STREAMED_XOR_CHUNKS))
+
+	actual, err := promNegotiateReadResponseType(data)
+	require.NoError(t, err)
+	require.Equal(t, promReadResponseTypeStreamedXORChunks, actual)
+
+	_, err = promNegotiateReadResponseType(data[:1])
+	require.Equal(t, errPromMalformedReadRequest, err)
+}
+
+func TestPromEncodeChunkedReadResponseRoundTrip(t *testing.T) {
+	var (
+		labels = []prompb.Label{
+			{Name: []byte("__name__"), Value: []byte("foo")},
+			{Name: []byte("city"), Value: []byte("nyc")},
+		}
+		chunks = promXORChunks(newTestPromSamples(250))
+	)
+	require.Len(t, chunks, 3)
+
+	frame, err := promEncodeChunkedReadResponse(labels, chunks, 2)
+	require.NoError(t, err)
+
+	var resp upstreamprompb.ChunkedReadResponse
+	require.NoError(t, resp.Unmarshal(frame))
+	require.Equal(t, int64(2), resp.QueryIndex)
+	require.Len(t, resp.ChunkedSeries, 1)
+
+	series := resp.ChunkedSeries[0]
+	require.Equal(t, []upstreamprompb.Label{
+		{Name: "__name__", Value: "foo"},
+		{Name: "city", Value: "nyc"},
+	}, series.Labels)
+	require.Len(t, series.Chunks, len(chunks))
+	for i, chunk := range series.Chunks {
+		require.Equal(t, upstreamprompb.Chunk_XOR, chunk.Type)
+		require.Equal(t, chunks[i].minTimeMs, chunk.MinTimeMs)
+		require.Equal(t, chunks[i].maxTimeMs, chunk.MaxTimeMs)
+		require.Equal(t, chunks[i].data, chunk.Data)
+	}
+}
+
+func TestPromWriteStreamedResultsRoundTrip(t *testing.T) {
+	results := [][]promReadSeries{
+		{
+			{
+				labels:  []prompb.Label{{Name: []byte("__name__"), Value: []byte("foo")}},
+				samples: newTestPromSamples(130),
+			},
+			{
+				labels:  []prompb.Label{{Name: []byte("__name__"), Value: []byte("qux")}},
+				samples: newTestPromSamples(3),
+			},
+		},
+		nil,
+		{
+			{
+				labels:  []prompb.Label{{Name: []byte("__name__"), Value: []byte("bar")}},
+				samples: newTestPromSamples(1),
+			},
+		},
+	}
+
+	w := httptest.NewRecorder()
+	require.NoError(t, promWriteStreamedResults(w, w, results))
+
+	var (
+		body   = w.Body.Bytes()
+		actual = make([][]promReadSeries, len(results))
+	)
+	for len(body) > 0 {
+		size, n := binary.Uvarint(body)
+		require.True(t, n > 0)
+		body = body[n:]
+		require.True(t, len(body) >= 4+int(size))
+		checksum := binary.BigEndian.Uint32(body)
+		msg := body[4 : 4+size]
+		body = body[4+size:]
+		require.Equal(t, crc32.Checksum(msg, promCastagnoliTable), checksum)
+
+		var resp upstreamprompb.ChunkedReadResponse
+		require.NoError(t, resp.Unmarshal(msg))
+		for _, series := range resp.ChunkedSeries {
+			decoded := promReadSeries{}
+			for _, label := range series.Labels {
+				decoded.labels = append(decoded.labels, prompb.Label{
+					Name:  []byte(label.Name),
+					Value: []byte(label.Value),
+				})
+			}
+			for _, chunk := range series.Chunks {
+				decoded.samples = append(decoded.samples,
+					decodeTestPromXORChunk(t, chunk.Data)...)
+			}
+			actual[resp.QueryIndex] = append(actual[resp.QueryIndex], decoded)
+		}
+	}
+	require.Equal(t, results, actual)
+}
+
+func TestPromRemoteReadStreamedQueryErrorBeforeFrames(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		nsID  = ident.StringID("metrics")
+		db    = storage.NewMockDatabase(ctrl)
+		query = &prompb.Query{
+			StartTimestampMs: 1000,
+			EndTimestampMs:   2000,
+			Matchers: []*prompb.LabelMatcher{{
+				Type:  prompb.LabelMatcher_EQ,
+				Name:  []byte("__name__"),
+				Value: []byte("foo"),
+			}},
+		}
+	)
+	gomock.InOrder(
+		db.EXPECT().QueryIDs(gomock.Any(), nsID, gomock.Any(), gomock.Any()).
+			Return(index.QueryResult{
+				Results: index.NewQueryResults(nsID, index.QueryResultsOptions{},
+					index.NewOptions()),
+			}, nil),
+		db.EXPECT().QueryIDs(gomock.Any(), nsID, gomock.Any(), gomock.Any()).
+			Return(index.QueryResult{}, errors.New("query failed")),
+	)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// A failed later query is reported with a status code since no frame has
+	// been written yet.
+	w := httptest.NewRecorder()
+	promRemoteReadStreamed(ctx, w, db, nsID, []*prompb.Query{query, query},
+		zap.NewNop())
+	require.Equal(t, http.StatusInternalServerError, w.Code)
+	require.NotEqual(t, promStreamedContentType, w.Header().Get("Content-Type"))
+	require.True(t, bytes.Contains(w.Body.Bytes(), []byte("query failed")))
+}
+
+func TestPromReadQueryClipsSamplesToRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// The query range starts and ends in the middle of the block of the
+	// series, the end of the range is inclusive.
+	var (
+		nsID  = ident.StringID("metrics")
+		start = testQueryStart
+		end   = start.Add(time.Minute)
+		// Samples are read up to the millisecond following the end.
+		readEnd = end.Add(time.Millisecond)
+		series  = []testQuerySeries{
+			{
+				id:   "foo",
+				tags: ident.NewTags(ident.StringTag("__name__", "foo")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{-30 * time.Second, 0, 30 * time.Second,
+						60 * time.Second, 90 * time.Second},
+					[]float64{1, 2, 3, 4, 5}),
+			},
+		}
+		db    = newTestQueryDatabase(t, ctrl, ctx, nsID, start, readEnd, series)
+		query = &prompb.Query{
+			StartTimestampMs: querystorage.TimeToPromTimestamp(start),
+			EndTimestampMs:   querystorage.TimeToPromTimestamp(end),
+			Matchers: []*prompb.LabelMatcher{{
+				Type:  prompb.LabelMatcher_EQ,
+				Name:  []byte("__name__"),
+				Value: []byte("foo"),
+			}},
+		}
+	)
+
+	results, err := promReadQuery(ctx, db, nsID, query)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(results))
+	require.Equal(t, []prompb.Sample{
+		{Timestamp: querystorage.TimeToPromTimestamp(start), Value: 2},
+		{Timestamp: querystorage.TimeToPromTimestamp(start.Add(30 * time.Second)), Value: 3},
+		{Timestamp: querystorage.TimeToPromTimestamp(end), Value: 4},
+	}, results[0].samples)
+
+	// Streamed chunks are built from the clipped samples.
+	chunks := promXORChunks(results[0].samples)
+	require.Equal(t, 1, len(chunks))
+	require.Equal(t, query.StartTimestampMs, chunks[0].minTimeMs)
+	require.Equal(t, query.EndTimestampMs, chunks[0].maxTimeMs)
+}
+
+func newTestPromSamples(n int) []prompb.Sample {
+	samples := make([]prompb.Sample, 0, n)
+	for i := 0; i < n; i++ {
+		samples = append(samples, prompb.Sample{
+			// Irregular intervals exercise each delta of delta encoding.
+			Timestamp: int64(1000 + i*15000 + (i%7)*(i%3)*997),
+			Value:     float64(i%11) * 1.5,
+		})
+	}
+	return samples
+}
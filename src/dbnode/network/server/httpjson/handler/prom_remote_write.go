@@ -0,0 +1,255 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	querystorage "github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/serialize"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// PromRemoteWriteURL is the URL of the Prometheus remote write handler.
+	PromRemoteWriteURL = "/api/v1/prom/remote/write"
+
+	promRemoteWriteDispositionsParam = "dispositions"
+)
+
+// promRemoteWriteResponse is returned for remote write requests that ask for
+// the disposition of every sample, the samples are listed in request order.
+type promRemoteWriteResponse struct {
+	Samples []promRemoteWriteSampleResult `json:"samples"`
+}
+
+type promRemoteWriteSampleResult struct {
+	Disposition series.WriteDisposition `json:"disposition"`
+	Error       string                  `json:"error,omitempty"`
+}
+
+// promRemoteWriteDispositions records the outcome of every sample of a batch
+// write, it implements storage.IndexedWriteDispositionHandler.
+type promRemoteWriteDispositions struct {
+	samples  []promRemoteWriteSampleResult
+	multiErr xerrors.MultiError
+}
+
+func (d *promRemoteWriteDispositions) HandleError(index int, err error) {
+	d.samples[index].Error = err.Error()
+	d.multiErr = d.multiErr.Add(err)
+}
+
+func (d *promRemoteWriteDispositions) HandleWriteDisposition(
+	index int,
+	disposition series.WriteDisposition,
+) {
+	d.samples[index].Disposition = disposition
+}
+
+// NewPromRemoteWriteHandler returns a handler that ingests Prometheus remote
+// write requests by writing every sample directly to the database. Series IDs
+// are generated the same way the coordinator generates them so that a
+// coordinator can be introduced in front of the node later on without
+// changing the series written. Requests with the dispositions param set are
+// written as a single batch and are answered with how each sample was
+// applied, e.g. whether it was clamped or overwrote a datapoint with the same
+// timestamp.
+func NewPromRemoteWriteHandler(
+	db storage.Database,
+	namespace ident.ID,
+	contextPool context.Pool,
+	tagEncoderPool serialize.TagEncoderPool,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	tagOpts := models.NewTagOptions()
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "request must be POST", http.StatusMethodNotAllowed)
+			return
+		}
+
+		result, parseErr := prometheus.ParsePromCompressedRequest(r)
+		if parseErr != nil {
+			http.Error(w, parseErr.Error(), parseErr.Code())
+			return
+		}
+
+		var req prompb.WriteRequest
+		if err := req.Unmarshal(result.UncompressedBody); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var withDispositions bool
+		if str := r.URL.Query().Get(promRemoteWriteDispositionsParam); str != "" {
+			v, err := strconv.ParseBool(str)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s param: %v",
+					promRemoteWriteDispositionsParam, err), http.StatusBadRequest)
+				return
+			}
+			withDispositions = v
+		}
+
+		ctx := contextPool.Get()
+		defer ctx.Close()
+
+		if withDispositions {
+			writePromWithDispositions(w, ctx, db, namespace, req,
+				tagOpts, tagEncoderPool, logger)
+			return
+		}
+
+		multiErr := xerrors.NewMultiError()
+		for _, series := range req.Timeseries {
+			tags := querystorage.PromLabelsToM3Tags(series.Labels, tagOpts)
+			id := ident.BytesID(tags.ID())
+			for _, sample := range series.Samples {
+				err := db.WriteTagged(ctx, namespace, id,
+					querystorage.TagsToIdentTagIterator(tags),
+					querystorage.PromTimestampToTime(sample.Timestamp),
+					sample.Value, xtime.Millisecond, nil)
+				if err != nil {
+					multiErr = multiErr.Add(err)
+				}
+			}
+		}
+
+		if err := multiErr.FinalError(); err != nil {
+			logger.Error("prom remote write error",
+				zap.Int("numErrors", multiErr.NumErrors()), zap.Error(err))
+			status := http.StatusInternalServerError
+			if xerrors.IsInvalidParams(multiErr.LastError()) {
+				status = http.StatusBadRequest
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func writePromWithDispositions(
+	w http.ResponseWriter,
+	ctx context.Context,
+	db storage.Database,
+	namespace ident.ID,
+	req prompb.WriteRequest,
+	tagOpts models.TagOptions,
+	tagEncoderPool serialize.TagEncoderPool,
+	logger *zap.Logger,
+) {
+	numSamples := 0
+	for _, series := range req.Timeseries {
+		numSamples += len(series.Samples)
+	}
+
+	batchWriter, err := db.BatchWriter(namespace, numSamples)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if xerrors.IsInvalidParams(err) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	handler := &promRemoteWriteDispositions{
+		samples:  make([]promRemoteWriteSampleResult, numSamples),
+		multiErr: xerrors.NewMultiError(),
+	}
+
+	idx := 0
+	for _, series := range req.Timeseries {
+		tags := querystorage.PromLabelsToM3Tags(series.Labels, tagOpts)
+		id := ident.BytesID(tags.ID())
+
+		// The encoded tags are copied out of the pooled encoder since the
+		// commit log may still reference them after the request completes.
+		enc := tagEncoderPool.Get()
+		err := enc.Encode(querystorage.TagsToIdentTagIterator(tags))
+		var encodedTags []byte
+		if err == nil {
+			data, ok := enc.Data()
+			if !ok {
+				err = fmt.Errorf("unable to encode tags: unable to unwrap bytes")
+			} else {
+				encodedTags = append([]byte(nil), data.Bytes()...)
+			}
+		}
+		enc.Finalize()
+
+		for _, sample := range series.Samples {
+			if err != nil {
+				handler.HandleError(idx, xerrors.NewInvalidParamsError(err))
+				idx++
+				continue
+			}
+			batchWriter.AddTagged(idx, id,
+				querystorage.TagsToIdentTagIterator(tags), encodedTags,
+				querystorage.PromTimestampToTime(sample.Timestamp),
+				sample.Value, xtime.Millisecond, nil)
+			idx++
+		}
+	}
+
+	if err := db.WriteTaggedBatch(ctx, namespace, batchWriter, handler); err != nil {
+		status := http.StatusInternalServerError
+		if xerrors.IsInvalidParams(err) {
+			status = http.StatusBadRequest
+		}
+		http.Error(w, err.Error(), status)
+		return
+	}
+
+	status := http.StatusOK
+	if err := handler.multiErr.FinalError(); err != nil {
+		logger.Error("prom remote write error",
+			zap.Int("numErrors", handler.multiErr.NumErrors()), zap.Error(err))
+		status = http.StatusInternalServerError
+		if xerrors.IsInvalidParams(handler.multiErr.LastError()) {
+			status = http.StatusBadRequest
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(promRemoteWriteResponse{
+		Samples: handler.samples,
+	}); err != nil {
+		logger.Error("unable to encode prom remote write response", zap.Error(err))
+	}
+}
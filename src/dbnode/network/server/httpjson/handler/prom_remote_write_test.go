@@ -0,0 +1,266 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	querystorage "github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/pool"
+	"github.com/m3db/m3/src/x/serialize"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/golang/snappy"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testPromRemoteWriteNamespace = ident.StringID("metrics")
+
+func newTestPromRemoteWriteHandler(db storage.Database) http.HandlerFunc {
+	tagEncoderPool := serialize.NewTagEncoderPool(serialize.NewTagEncoderOptions(),
+		pool.NewObjectPoolOptions().SetSize(1))
+	tagEncoderPool.Init()
+	return NewPromRemoteWriteHandler(db, testPromRemoteWriteNamespace,
+		context.NewPool(context.NewOptions()), tagEncoderPool, zap.NewNop())
+}
+
+func newTestPromRemoteWriteRequest(
+	t *testing.T,
+	url string,
+	timeseries []prompb.TimeSeries,
+) *http.Request {
+	req := prompb.WriteRequest{Timeseries: timeseries}
+	data, err := req.Marshal()
+	require.NoError(t, err)
+	return httptest.NewRequest(http.MethodPost, url,
+		bytes.NewReader(snappy.Encode(nil, data)))
+}
+
+func newTestPromRemoteWriteTimeSeries() []prompb.TimeSeries {
+	return []prompb.TimeSeries{
+		{
+			Labels: []prompb.Label{
+				{Name: []byte("__name__"), Value: []byte("up")},
+				{Name: []byte("job"), Value: []byte("a")},
+			},
+			Samples: []prompb.Sample{
+				{Timestamp: 1600000000000, Value: 1},
+				{Timestamp: 1600000010000, Value: 2},
+			},
+		},
+		{
+			Labels: []prompb.Label{
+				{Name: []byte("__name__"), Value: []byte("up")},
+				{Name: []byte("job"), Value: []byte("b")},
+			},
+			Samples: []prompb.Sample{
+				{Timestamp: 1600000000000, Value: 3},
+			},
+		},
+	}
+}
+
+// testPromRemoteWriteID returns the ID the coordinator generates for the
+// labels of a series.
+func testPromRemoteWriteID(labels []prompb.Label) string {
+	tags := querystorage.PromLabelsToM3Tags(labels, models.NewTagOptions())
+	return string(tags.ID())
+}
+
+func TestPromRemoteWriteHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeseries := newTestPromRemoteWriteTimeSeries()
+	db := storage.NewMockDatabase(ctrl)
+	for _, s := range timeseries {
+		for _, sample := range s.Samples {
+			db.EXPECT().WriteTagged(gomock.Any(),
+				ident.NewIDMatcher(testPromRemoteWriteNamespace.String()),
+				ident.NewIDMatcher(testPromRemoteWriteID(s.Labels)), gomock.Any(),
+				querystorage.PromTimestampToTime(sample.Timestamp), sample.Value,
+				xtime.Millisecond, nil).
+				Return(nil)
+		}
+	}
+
+	w := httptest.NewRecorder()
+	newTestPromRemoteWriteHandler(db)(w,
+		newTestPromRemoteWriteRequest(t, PromRemoteWriteURL, timeseries))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+}
+
+func TestPromRemoteWriteHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeseries := newTestPromRemoteWriteTimeSeries()[1:]
+	writeErr := errors.New("write failed")
+
+	tests := []struct {
+		name     string
+		method   string
+		url      string
+		writeErr error
+		status   int
+	}{
+		{
+			name:   "not post",
+			method: http.MethodGet,
+			url:    PromRemoteWriteURL,
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "invalid dispositions param",
+			url:    PromRemoteWriteURL + "?dispositions=maybe",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:     "write error",
+			url:      PromRemoteWriteURL,
+			writeErr: writeErr,
+			status:   http.StatusInternalServerError,
+		},
+		{
+			name:     "invalid params write error",
+			url:      PromRemoteWriteURL,
+			writeErr: xerrors.NewInvalidParamsError(writeErr),
+			status:   http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			db := storage.NewMockDatabase(ctrl)
+			if test.writeErr != nil {
+				db.EXPECT().WriteTagged(gomock.Any(), gomock.Any(), gomock.Any(),
+					gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(test.writeErr)
+			}
+
+			req := newTestPromRemoteWriteRequest(t, test.url, timeseries)
+			if test.method != "" {
+				req.Method = test.method
+			}
+
+			w := httptest.NewRecorder()
+			newTestPromRemoteWriteHandler(db)(w, req)
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+
+	t.Run("invalid body", func(t *testing.T) {
+		db := storage.NewMockDatabase(ctrl)
+		w := httptest.NewRecorder()
+		newTestPromRemoteWriteHandler(db)(w, httptest.NewRequest(http.MethodPost,
+			PromRemoteWriteURL, bytes.NewReader([]byte("not snappy"))))
+		require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+	})
+}
+
+func TestPromRemoteWriteHandlerDispositions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		timeseries = newTestPromRemoteWriteTimeSeries()
+		writeErr   = errors.New("write failed")
+		db         = storage.NewMockDatabase(ctrl)
+	)
+	db.EXPECT().BatchWriter(ident.NewIDMatcher(testPromRemoteWriteNamespace.String()), 3).
+		Return(ts.NewWriteBatch(3, testPromRemoteWriteNamespace, nil), nil)
+	db.EXPECT().WriteTaggedBatch(gomock.Any(),
+		ident.NewIDMatcher(testPromRemoteWriteNamespace.String()), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			writes ts.BatchWriter,
+			errHandler storage.IndexedErrorHandler,
+		) error {
+			batch := writes.(ts.WriteBatch).Iter()
+			require.Equal(t, 3, len(batch))
+
+			var i int
+			for _, s := range timeseries {
+				for _, sample := range s.Samples {
+					write := batch[i].Write
+					require.Equal(t, testPromRemoteWriteID(s.Labels), write.Series.ID.String())
+					require.True(t, querystorage.PromTimestampToTime(sample.Timestamp).
+						Equal(write.Datapoint.Timestamp))
+					require.Equal(t, sample.Value, write.Datapoint.Value)
+					require.NotEmpty(t, batch[i].EncodedTags)
+					i++
+				}
+			}
+
+			dispositions := errHandler.(storage.IndexedWriteDispositionHandler)
+			dispositions.HandleWriteDisposition(0, series.WriteAccepted)
+			dispositions.HandleWriteDisposition(1, series.WriteDuplicateOverwritten)
+			errHandler.HandleError(2, writeErr)
+			return nil
+		})
+
+	w := httptest.NewRecorder()
+	newTestPromRemoteWriteHandler(db)(w, newTestPromRemoteWriteRequest(t,
+		PromRemoteWriteURL+"?"+promRemoteWriteDispositionsParam+"=true", timeseries))
+	require.Equal(t, http.StatusInternalServerError, w.Code, w.Body.String())
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp promRemoteWriteResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, promRemoteWriteResponse{
+		Samples: []promRemoteWriteSampleResult{
+			{Disposition: series.WriteAccepted},
+			{Disposition: series.WriteDuplicateOverwritten},
+			{Disposition: series.WriteDispositionUnknown, Error: writeErr.Error()},
+		},
+	}, resp)
+}
+
+func TestPromRemoteWriteHandlerDispositionsBatchWriterError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().BatchWriter(gomock.Any(), gomock.Any()).
+		Return(nil, xerrors.NewInvalidParamsError(errors.New("namespace not found")))
+
+	w := httptest.NewRecorder()
+	newTestPromRemoteWriteHandler(db)(w, newTestPromRemoteWriteRequest(t,
+		PromRemoteWriteURL+"?"+promRemoteWriteDispositionsParam+"=true",
+		newTestPromRemoteWriteTimeSeries()))
+	require.Equal(t, http.StatusBadRequest, w.Code, w.Body.String())
+}
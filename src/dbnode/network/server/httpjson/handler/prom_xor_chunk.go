@@ -0,0 +1,182 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package handler
+
+import (
+	"encoding/binary"
+	"math"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+)
+
+const (
+	// promMaxSamplesPerChunk mirrors the number of samples Prometheus itself
+	// cuts XOR chunks at, readers size their buffers around this value.
+	promMaxSamplesPerChunk = 120
+
+	promXORChunkHeaderBytes = 2
+	promXORNoLeading        = 0xff
+)
+
+// promXORChunk is a single Prometheus XOR encoded chunk.
+type promXORChunk struct {
+	minTimeMs int64
+	maxTimeMs int64
+	data      []byte
+}
+
+// promXORChunkEncoder encodes samples using the Prometheus TSDB XOR chunk
+// encoding, which is a millisecond variant of the Gorilla encoding that M3TSZ
+// is also based on. The first two bytes of the chunk hold the sample count.
+type promXORChunkEncoder struct {
+	os       encoding.OStream
+	num      uint16
+	t        int64
+	tDelta   uint64
+	v        float64
+	leading  uint8
+	trailing uint8
+	varint   [binary.MaxVarintLen64]byte
+}
+
+func newPromXORChunkEncoder() *promXORChunkEncoder {
+	enc := &promXORChunkEncoder{
+		os:      encoding.NewOStream(nil, true, nil),
+		leading: promXORNoLeading,
+	}
+	enc.os.WriteBits(0, 8*promXORChunkHeaderBytes)
+	return enc
+}
+
+func (e *promXORChunkEncoder) numSamples() int {
+	return int(e.num)
+}
+
+func (e *promXORChunkEncoder) encode(t int64, v float64) {
+	var tDelta uint64
+	switch e.num {
+	case 0:
+		n := binary.PutVarint(e.varint[:], t)
+		e.os.WriteBytes(e.varint[:n])
+		e.os.WriteBits(math.Float64bits(v), 64)
+	case 1:
+		tDelta = uint64(t - e.t)
+		n := binary.PutUvarint(e.varint[:], tDelta)
+		e.os.WriteBytes(e.varint[:n])
+		e.encodeValue(v)
+	default:
+		tDelta = uint64(t - e.t)
+		dod := int64(tDelta - e.tDelta)
+		switch {
+		case dod == 0:
+			e.os.WriteBit(encoding.Bit(0))
+		case promBitRange(dod, 14):
+			e.os.WriteBits(0x02, 2)
+			e.os.WriteBits(uint64(dod), 14)
+		case promBitRange(dod, 17):
+			e.os.WriteBits(0x06, 3)
+			e.os.WriteBits(uint64(dod), 17)
+		case promBitRange(dod, 20):
+			e.os.WriteBits(0x0e, 4)
+			e.os.WriteBits(uint64(dod), 20)
+		default:
+			e.os.WriteBits(0x0f, 4)
+			e.os.WriteBits(uint64(dod), 64)
+		}
+		e.encodeValue(v)
+	}
+
+	e.t = t
+	e.v = v
+	e.tDelta = tDelta
+	e.num++
+}
+
+func (e *promXORChunkEncoder) encodeValue(v float64) {
+	delta := math.Float64bits(v) ^ math.Float64bits(e.v)
+	if delta == 0 {
+		e.os.WriteBit(encoding.Bit(0))
+		return
+	}
+	e.os.WriteBit(encoding.Bit(1))
+
+	numLeading, numTrailing := encoding.LeadingAndTrailingZeros(delta)
+	leading, trailing := uint8(numLeading), uint8(numTrailing)
+	// The leading zero count is written using 5 bits so clamp it.
+	if leading >= 32 {
+		leading = 31
+	}
+
+	if e.leading != promXORNoLeading &&
+		leading >= e.leading && trailing >= e.trailing {
+		// Meaningful bits fall within the previous window, reuse it.
+		e.os.WriteBit(encoding.Bit(0))
+		e.os.WriteBits(delta>>e.trailing, 64-int(e.leading)-int(e.trailing))
+		return
+	}
+
+	e.leading, e.trailing = leading, trailing
+	e.os.WriteBit(encoding.Bit(1))
+	e.os.WriteBits(uint64(leading), 5)
+	// A significant bit count of 64 overflows to 0 in 6 bits, readers treat
+	// 0 as 64 since there is always at least one significant bit.
+	sigBits := 64 - leading - trailing
+	e.os.WriteBits(uint64(sigBits), 6)
+	e.os.WriteBits(delta>>trailing, int(sigBits))
+}
+
+func (e *promXORChunkEncoder) bytes() []byte {
+	data, _ := e.os.Rawbytes()
+	binary.BigEndian.PutUint16(data, e.num)
+	return data
+}
+
+// promBitRange returns whether x can be represented using nbits.
+func promBitRange(x int64, nbits uint8) bool {
+	return -((1<<(nbits-1))-1) <= x && x <= 1<<(nbits-1)
+}
+
+// promXORChunks encodes samples, which must be sorted by timestamp, into
+// consecutive XOR chunks of at most promMaxSamplesPerChunk samples each.
+func promXORChunks(samples []prompb.Sample) []promXORChunk {
+	chunks := make([]promXORChunk, 0,
+		(len(samples)+promMaxSamplesPerChunk-1)/promMaxSamplesPerChunk)
+	for len(samples) > 0 {
+		n := len(samples)
+		if n > promMaxSamplesPerChunk {
+			n = promMaxSamplesPerChunk
+		}
+
+		enc := newPromXORChunkEncoder()
+		for _, sample := range samples[:n] {
+			enc.encode(sample.Timestamp, sample.Value)
+		}
+
+		chunks = append(chunks, promXORChunk{
+			minTimeMs: samples[0].Timestamp,
+			maxTimeMs: samples[n-1].Timestamp,
+			data:      enc.bytes(),
+		})
+		samples = samples[n:]
+	}
+
+	return chunks
+}
@@ -0,0 +1,83 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"math"
+	"testing"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+
+	"github.com/prometheus/prometheus/tsdb/chunkenc"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPromXORChunksRoundTrip(t *testing.T) {
+	samples := []prompb.Sample{
+		{Timestamp: 1000, Value: 1},
+		{Timestamp: 2000, Value: 1},
+		{Timestamp: 3000, Value: -2.5},
+		{Timestamp: 3001, Value: math.MaxFloat64},
+		{Timestamp: 3001 + 1<<15, Value: math.SmallestNonzeroFloat64},
+		{Timestamp: 3001 + 1<<20, Value: 0},
+		{Timestamp: 3001 + 1<<40, Value: math.Inf(1)},
+		{Timestamp: 3002 + 1<<40, Value: 42},
+	}
+	samples = append(samples, newTestPromSamples(2*promMaxSamplesPerChunk)...)
+	for i := len(samples) - 2*promMaxSamplesPerChunk; i < len(samples); i++ {
+		samples[i].Timestamp += 3003 + 1<<40
+	}
+
+	chunks := promXORChunks(samples)
+	require.Len(t, chunks, 3)
+
+	var decoded []prompb.Sample
+	for _, chunk := range chunks {
+		chunkSamples := decodeTestPromXORChunk(t, chunk.data)
+		require.True(t, len(chunkSamples) <= promMaxSamplesPerChunk)
+		require.Equal(t, chunkSamples[0].Timestamp, chunk.minTimeMs)
+		require.Equal(t, chunkSamples[len(chunkSamples)-1].Timestamp, chunk.maxTimeMs)
+		decoded = append(decoded, chunkSamples...)
+	}
+	require.Equal(t, samples, decoded)
+}
+
+func TestPromXORChunksEmpty(t *testing.T) {
+	require.Empty(t, promXORChunks(nil))
+}
+
+// decodeTestPromXORChunk decodes a chunk with the Prometheus TSDB decoder.
+func decodeTestPromXORChunk(t *testing.T, data []byte) []prompb.Sample {
+	chunk, err := chunkenc.FromData(chunkenc.EncXOR, data)
+	require.NoError(t, err)
+
+	var (
+		samples []prompb.Sample
+		iter    = chunk.Iterator(nil)
+	)
+	for iter.Next() {
+		ts, v := iter.At()
+		samples = append(samples, prompb.Sample{Timestamp: ts, Value: v})
+	}
+	require.NoError(t, iter.Err())
+	require.Equal(t, chunk.NumSamples(), len(samples))
+	return samples
+}
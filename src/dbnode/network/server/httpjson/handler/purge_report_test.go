@@ -0,0 +1,77 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestPurgeReportHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		reporter = storage.NewMockPurgeReporter(ctrl)
+		start    = time.Unix(1000, 0).UTC()
+		report   = storage.PurgeReport{
+			StartTime: start,
+			EndTime:   start.Add(time.Second),
+			DataFileSets: []storage.PurgedFile{
+				{Path: "/var/lib/m3db/data/foo/0/fileset-0-0-data.db", Bytes: 100},
+			},
+			CommitLogs: []storage.PurgedFile{
+				{Path: "/var/lib/m3db/commitlogs/commitlog-0-0.db", Bytes: 50},
+			},
+			BytesReclaimed: 150,
+		}
+	)
+	handler := NewPurgeReportHandler(reporter, zap.NewNop())
+
+	// No cleanup has completed yet.
+	reporter.EXPECT().LastReport().Return(storage.PurgeReport{}, false)
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, PurgeReportURL, nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	reporter.EXPECT().LastReport().Return(report, true)
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, PurgeReportURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+	var resp storage.PurgeReport
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, report, resp)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, PurgeReportURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
@@ -0,0 +1,503 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// QueryURL is the URL of the query handler.
+	QueryURL = "/api/v1/query"
+
+	queryNamespaceParam   = "namespace"
+	queryMatchParam       = "match"
+	queryStartParam       = "start"
+	queryEndParam         = "end"
+	queryAggregationParam = "aggregation"
+	queryStepParam        = "step"
+	queryGroupByParam     = "groupBy"
+	queryLimitParam       = "limit"
+
+	defaultQueryRange = time.Hour
+	defaultQueryLimit = 1000
+)
+
+// queryAggregation aggregates the datapoints of a series within each step.
+type queryAggregation string
+
+const (
+	queryAggregationSum   queryAggregation = "sum"
+	queryAggregationAvg   queryAggregation = "avg"
+	queryAggregationMin   queryAggregation = "min"
+	queryAggregationMax   queryAggregation = "max"
+	queryAggregationCount queryAggregation = "count"
+	queryAggregationLast  queryAggregation = "last"
+)
+
+// queryValue is a datapoint value that encodes NaN and infinite values,
+// which JSON can not represent, as null.
+type queryValue float64
+
+func (v queryValue) MarshalJSON() ([]byte, error) {
+	f := float64(v)
+	if math.IsNaN(f) || math.IsInf(f, 0) {
+		return []byte("null"), nil
+	}
+	return json.Marshal(f)
+}
+
+type queryDatapoint struct {
+	Timestamp time.Time  `json:"timestamp"`
+	Value     queryValue `json:"value"`
+}
+
+type querySeries struct {
+	ID         string            `json:"id"`
+	Tags       map[string]string `json:"tags"`
+	Datapoints []queryDatapoint  `json:"datapoints"`
+}
+
+type queryResponse struct {
+	Namespace   string           `json:"namespace"`
+	Start       time.Time        `json:"start"`
+	End         time.Time        `json:"end"`
+	Aggregation queryAggregation `json:"aggregation,omitempty"`
+	Step        string           `json:"step,omitempty"`
+	GroupBy     string           `json:"groupBy,omitempty"`
+	Exhaustive  bool             `json:"exhaustive"`
+	Series      []querySeries    `json:"series"`
+}
+
+type queryRequest struct {
+	namespace   ident.ID
+	query       index.Query
+	start       time.Time
+	end         time.Time
+	aggregation queryAggregation
+	step        time.Duration
+	groupBy     string
+	limit       int
+}
+
+// NewQueryHandler returns a handler that serves the datapoints of the series
+// matching a set of tag matchers within a time range, optionally aggregated
+// per series within each step, so data can be inspected without running a
+// coordinator. Matchers are of the form name=value, name!=value, name=~regexp
+// and name!~regexp and are all required to match. Series can be grouped by
+// the value of a tag, returning one series per group that aggregates the
+// datapoints of all series of the group within each step, to collapse high
+// cardinality series before they leave the node.
+func NewQueryHandler(
+	db storage.Database,
+	contextPool context.Pool,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		nowFn := db.Options().ClockOptions().NowFn()
+		req, err := parseQueryRequest(r, nowFn())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, ok := db.Namespace(req.namespace); !ok {
+			http.Error(w, "namespace not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := contextPool.Get()
+		defer ctx.Close()
+
+		resp, err := runQuery(ctx, db, req)
+		if err != nil {
+			logger.Error("query error",
+				zap.String("namespace", req.namespace.String()), zap.Error(err))
+			http.Error(w, err.Error(), promReadErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			logger.Error("unable to encode query response", zap.Error(err))
+		}
+	}
+}
+
+func parseQueryRequest(r *http.Request, now time.Time) (queryRequest, error) {
+	values := r.URL.Query()
+
+	namespace := values.Get(queryNamespaceParam)
+	if namespace == "" {
+		return queryRequest{}, fmt.Errorf("missing %s param", queryNamespaceParam)
+	}
+
+	matchers := values[queryMatchParam]
+	if len(matchers) == 0 {
+		return queryRequest{}, fmt.Errorf("missing %s param", queryMatchParam)
+	}
+	queries := make([]idx.Query, 0, len(matchers))
+	for _, matcher := range matchers {
+		q, err := parseQueryMatcher(matcher)
+		if err != nil {
+			return queryRequest{}, err
+		}
+		queries = append(queries, q)
+	}
+
+	req := queryRequest{
+		namespace: ident.StringID(namespace),
+		query:     index.Query{Query: idx.NewConjunctionQuery(queries...)},
+		start:     now.Add(-defaultQueryRange),
+		end:       now,
+		limit:     defaultQueryLimit,
+	}
+
+	var err error
+	if v := values.Get(queryStartParam); v != "" {
+		if req.start, err = parseQueryTime(v); err != nil {
+			return queryRequest{}, fmt.Errorf("invalid %s param: %v", queryStartParam, err)
+		}
+	}
+	if v := values.Get(queryEndParam); v != "" {
+		if req.end, err = parseQueryTime(v); err != nil {
+			return queryRequest{}, fmt.Errorf("invalid %s param: %v", queryEndParam, err)
+		}
+	}
+	if !req.start.Before(req.end) {
+		return queryRequest{}, fmt.Errorf("%s must be before %s", queryStartParam, queryEndParam)
+	}
+
+	if v := values.Get(queryAggregationParam); v != "" {
+		req.aggregation = queryAggregation(v)
+		switch req.aggregation {
+		case queryAggregationSum, queryAggregationAvg, queryAggregationMin,
+			queryAggregationMax, queryAggregationCount, queryAggregationLast:
+		default:
+			return queryRequest{}, fmt.Errorf("unknown %s: %s", queryAggregationParam, v)
+		}
+	}
+	if v := values.Get(queryStepParam); v != "" {
+		if req.aggregation == "" {
+			return queryRequest{}, fmt.Errorf("%s param requires %s param",
+				queryStepParam, queryAggregationParam)
+		}
+		if req.step, err = time.ParseDuration(v); err != nil || req.step <= 0 {
+			return queryRequest{}, fmt.Errorf("invalid %s param: %s", queryStepParam, v)
+		}
+	}
+	if v := values.Get(queryGroupByParam); v != "" {
+		if req.aggregation == "" {
+			return queryRequest{}, fmt.Errorf("%s param requires %s param",
+				queryGroupByParam, queryAggregationParam)
+		}
+		req.groupBy = v
+	}
+	if v := values.Get(queryLimitParam); v != "" {
+		if req.limit, err = strconv.Atoi(v); err != nil || req.limit <= 0 {
+			return queryRequest{}, fmt.Errorf("invalid %s param: %s", queryLimitParam, v)
+		}
+	}
+
+	return req, nil
+}
+
+// parseQueryMatcher parses a matcher of the form name=value, name!=value,
+// name=~regexp or name!~regexp into an index query.
+func parseQueryMatcher(matcher string) (idx.Query, error) {
+	i := strings.IndexAny(matcher, "=!")
+	if i <= 0 {
+		return nil, fmt.Errorf("invalid matcher: %s", matcher)
+	}
+
+	var (
+		name   = []byte(matcher[:i])
+		op     = matcher[i:]
+		negate bool
+		regexp bool
+	)
+	switch {
+	case strings.HasPrefix(op, "!="):
+		op, negate = op[2:], true
+	case strings.HasPrefix(op, "!~"):
+		op, negate, regexp = op[2:], true, true
+	case strings.HasPrefix(op, "=~"):
+		op, regexp = op[2:], true
+	case strings.HasPrefix(op, "="):
+		op = op[1:]
+	default:
+		return nil, fmt.Errorf("invalid matcher: %s", matcher)
+	}
+
+	q := idx.NewTermQuery(name, []byte(op))
+	if regexp {
+		var err error
+		q, err = idx.NewRegexpQuery(name, []byte(op))
+		if err != nil {
+			return nil, fmt.Errorf("invalid matcher regexp: %s: %v", matcher, err)
+		}
+	}
+	if negate {
+		q = idx.NewNegationQuery(q)
+	}
+	return q, nil
+}
+
+// parseQueryTime parses a time as either unix seconds or RFC3339.
+func parseQueryTime(v string) (time.Time, error) {
+	if secs, err := strconv.ParseInt(v, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+func runQuery(
+	ctx context.Context,
+	db storage.Database,
+	req queryRequest,
+) (queryResponse, error) {
+	result, err := db.QueryIDs(ctx, req.namespace, req.query, index.QueryOptions{
+		StartInclusive: req.start,
+		EndExclusive:   req.end,
+		Limit:          req.limit,
+	})
+	if err != nil {
+		return queryResponse{}, err
+	}
+
+	entries, err := index.ResultsEntries(result.Results)
+	if err != nil {
+		return queryResponse{}, err
+	}
+
+	resp := queryResponse{
+		Namespace:   req.namespace.String(),
+		Start:       req.start,
+		End:         req.end,
+		Aggregation: req.aggregation,
+		Exhaustive:  result.Exhaustive,
+		Series:      make([]querySeries, 0, len(entries)),
+	}
+	if req.step > 0 {
+		resp.Step = req.step.String()
+	}
+	resp.GroupBy = req.groupBy
+
+	// Raw datapoints of every series in a group, by the value of the group
+	// by tag.
+	groups := make(map[string][]ts.Datapoint)
+	for _, entry := range entries {
+		datapoints, err := readDatapoints(ctx, db, req.namespace, entry.Key(),
+			req.start, req.end)
+		if err != nil {
+			return queryResponse{}, err
+		}
+		if len(datapoints) == 0 {
+			continue
+		}
+
+		tags, err := queryTags(entry.Value().Duplicate())
+		if err != nil {
+			return queryResponse{}, err
+		}
+
+		if req.groupBy != "" {
+			// Series without the tag are grouped together.
+			group := tags[req.groupBy]
+			groups[group] = append(groups[group], datapoints...)
+			continue
+		}
+
+		resp.Series = append(resp.Series, querySeries{
+			ID:         entry.Key().String(),
+			Tags:       tags,
+			Datapoints: aggregateDatapoints(datapoints, req),
+		})
+	}
+
+	for group, datapoints := range groups {
+		// Aggregate the datapoints of all series of the group together, as
+		// if they were the datapoints of a single series.
+		sort.SliceStable(datapoints, func(i, j int) bool {
+			return datapoints[i].Timestamp.Before(datapoints[j].Timestamp)
+		})
+		resp.Series = append(resp.Series, querySeries{
+			ID:         req.groupBy + "=" + group,
+			Tags:       map[string]string{req.groupBy: group},
+			Datapoints: aggregateDatapoints(datapoints, req),
+		})
+	}
+
+	sort.Slice(resp.Series, func(i, j int) bool {
+		return resp.Series[i].ID < resp.Series[j].ID
+	})
+
+	return resp, nil
+}
+
+func queryTags(tags ident.TagIterator) (map[string]string, error) {
+	defer tags.Close()
+
+	result := make(map[string]string, tags.Remaining())
+	for tags.Next() {
+		tag := tags.Current()
+		result[tag.Name.String()] = tag.Value.String()
+	}
+
+	if err := tags.Err(); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// aggregateDatapoints aggregates the datapoints within each step starting at
+// the query start, or within the whole query range if no step is set, each
+// aggregated datapoint is timestamped with the start of its step.
+func aggregateDatapoints(
+	datapoints []ts.Datapoint,
+	req queryRequest,
+) []queryDatapoint {
+	if req.aggregation == "" {
+		result := make([]queryDatapoint, 0, len(datapoints))
+		for _, dp := range datapoints {
+			result = append(result, queryDatapoint{
+				Timestamp: dp.Timestamp,
+				Value:     queryValue(dp.Value),
+			})
+		}
+		return result
+	}
+
+	step := req.step
+	if step <= 0 {
+		step = req.end.Sub(req.start)
+	}
+
+	var result []queryDatapoint
+	for i := 0; i < len(datapoints); {
+		var (
+			stepIdx   = datapoints[i].Timestamp.Sub(req.start) / step
+			stepStart = req.start.Add(stepIdx * step)
+			stepEnd   = stepStart.Add(step)
+			j         = i
+		)
+		for j < len(datapoints) && datapoints[j].Timestamp.Before(stepEnd) {
+			j++
+		}
+		result = append(result, queryDatapoint{
+			Timestamp: stepStart,
+			Value:     queryValue(aggregate(req.aggregation, datapoints[i:j])),
+		})
+		i = j
+	}
+	return result
+}
+
+func aggregate(aggregation queryAggregation, datapoints []ts.Datapoint) float64 {
+	switch aggregation {
+	case queryAggregationCount:
+		return float64(len(datapoints))
+	case queryAggregationLast:
+		return datapoints[len(datapoints)-1].Value
+	}
+
+	result := datapoints[0].Value
+	for _, dp := range datapoints[1:] {
+		switch aggregation {
+		case queryAggregationSum, queryAggregationAvg:
+			result += dp.Value
+		case queryAggregationMin:
+			result = math.Min(result, dp.Value)
+		case queryAggregationMax:
+			result = math.Max(result, dp.Value)
+		}
+	}
+	if aggregation == queryAggregationAvg {
+		result /= float64(len(datapoints))
+	}
+	return result
+}
+
+// readDatapoints reads the datapoints of a series within [start, end).
+func readDatapoints(
+	ctx context.Context,
+	db storage.Database,
+	nsID ident.ID,
+	id ident.ID,
+	start, end time.Time,
+) ([]ts.Datapoint, error) {
+	encoded, err := db.ReadEncoded(ctx, nsID, id, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	// Resolve all futures (block reads can be backed by async implementations) and filter out any empty segments.
+	filtered, err := xio.FilterEmptyBlockReadersSliceOfSlicesInPlace(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	multiIt := db.Options().MultiReaderIteratorPool().Get()
+	nsCtx := namespace.NewContextFor(nsID, db.Options().SchemaRegistry())
+	multiIt.ResetSliceOfSlices(
+		xio.NewReaderSliceOfSlicesFromBlockReadersIterator(filtered), nsCtx.Schema)
+	defer multiIt.Close()
+
+	// NB: Reads return whole blocks, so drop any datapoints of the blocks
+	// that fall outside of [start, end).
+	var datapoints []ts.Datapoint
+	for multiIt.Next() {
+		dp, _, _ := multiIt.Current()
+		if dp.Timestamp.Before(start) || !dp.Timestamp.Before(end) {
+			continue
+		}
+		datapoints = append(datapoints, dp)
+	}
+
+	if err := multiIt.Err(); err != nil {
+		return nil, err
+	}
+
+	return datapoints, nil
+}
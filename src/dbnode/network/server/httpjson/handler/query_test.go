@@ -0,0 +1,623 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+var testQueryStart = time.Unix(1600000000, 0)
+
+type testQuerySeries struct {
+	id         string
+	tags       ident.Tags
+	datapoints []ts.Datapoint
+}
+
+// newTestQueryDatabase returns a database whose index matches the given
+// series and that reads all of their datapoints for the given time range.
+func newTestQueryDatabase(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	ctx context.Context,
+	nsID ident.ID,
+	start, end time.Time,
+	series []testQuerySeries,
+) *storage.MockDatabase {
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Options().Return(storage.NewOptions()).AnyTimes()
+
+	results := index.NewQueryResults(nsID, index.QueryResultsOptions{},
+		index.NewOptions())
+	for _, s := range series {
+		results.Map().Set(ident.StringID(s.id), ident.NewTagsIterator(s.tags))
+
+		// Encode the datapoints as a single block that may start before and
+		// end after the read range, as reads return whole blocks.
+		blockStart := start
+		if len(s.datapoints) > 0 && s.datapoints[0].Timestamp.Before(start) {
+			blockStart = s.datapoints[0].Timestamp
+		}
+		enc := m3tsz.NewEncoder(blockStart, nil, m3tsz.DefaultIntOptimizationEnabled,
+			encoding.NewOptions())
+		for _, dp := range s.datapoints {
+			require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+		}
+		db.EXPECT().
+			ReadEncoded(gomock.Any(), ident.NewIDMatcher(nsID.String()),
+				ident.NewIDMatcher(s.id), start, end).
+			DoAndReturn(func(_ context.Context, _, _ ident.ID, _, _ time.Time) ([][]xio.BlockReader, error) {
+				// Each read consumes its stream so return a new one every time.
+				stream, _ := enc.Stream(ctx)
+				return [][]xio.BlockReader{{
+					xio.BlockReader{SegmentReader: stream},
+				}}, nil
+			}).
+			AnyTimes()
+	}
+	db.EXPECT().QueryIDs(gomock.Any(), ident.NewIDMatcher(nsID.String()),
+		gomock.Any(), gomock.Any()).
+		Return(index.QueryResult{Results: results, Exhaustive: true}, nil).
+		AnyTimes()
+
+	return db
+}
+
+func newTestQueryDatapoints(offsets []time.Duration, values []float64) []ts.Datapoint {
+	datapoints := make([]ts.Datapoint, 0, len(offsets))
+	for i, offset := range offsets {
+		datapoints = append(datapoints, ts.Datapoint{
+			Timestamp: testQueryStart.Add(offset),
+			Value:     values[i],
+		})
+	}
+	return datapoints
+}
+
+func TestRunQueryGroupByAggregatesDatapointsOfGroup(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		nsID   = ident.StringID("metrics")
+		start  = testQueryStart
+		end    = start.Add(3 * time.Minute)
+		series = []testQuerySeries{
+			{
+				id:   "a",
+				tags: ident.NewTags(ident.StringTag("dc", "east"), ident.StringTag("host", "a")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{0, 30 * time.Second, 60 * time.Second, 90 * time.Second},
+					[]float64{1, 3, 5, 7}),
+			},
+			{
+				id:   "b",
+				tags: ident.NewTags(ident.StringTag("dc", "east"), ident.StringTag("host", "b")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{10 * time.Second, 20 * time.Second, 40 * time.Second, 70 * time.Second},
+					[]float64{10, 20, 30, 40}),
+			},
+			{
+				id:   "c",
+				tags: ident.NewTags(ident.StringTag("dc", "west"), ident.StringTag("host", "c")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{0, 50 * time.Second},
+					[]float64{100, 200}),
+			},
+		}
+		db = newTestQueryDatabase(t, ctrl, ctx, nsID, start, end, series)
+	)
+
+	tests := []struct {
+		aggregation queryAggregation
+		east        []queryValue
+		west        queryValue
+	}{
+		{
+			// Series a sums to 4 and 12, series b to 60 and 40.
+			aggregation: queryAggregationSum,
+			east:        []queryValue{64, 52},
+			west:        300,
+		},
+		{
+			// The datapoints of both series are averaged together, rather
+			// than averaging the averages of each series.
+			aggregation: queryAggregationAvg,
+			east:        []queryValue{64.0 / 5, 52.0 / 3},
+			west:        150,
+		},
+		{
+			// The last datapoints of the group are 30 of series b and 7 of
+			// series a.
+			aggregation: queryAggregationLast,
+			east:        []queryValue{30, 7},
+			west:        200,
+		},
+		{
+			aggregation: queryAggregationMin,
+			east:        []queryValue{1, 5},
+			west:        100,
+		},
+		{
+			aggregation: queryAggregationMax,
+			east:        []queryValue{30, 40},
+			west:        200,
+		},
+		{
+			aggregation: queryAggregationCount,
+			east:        []queryValue{5, 3},
+			west:        2,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(string(test.aggregation), func(t *testing.T) {
+			resp, err := runQuery(ctx, db, queryRequest{
+				namespace:   nsID,
+				query:       index.Query{Query: idx.NewAllQuery()},
+				start:       start,
+				end:         end,
+				aggregation: test.aggregation,
+				step:        time.Minute,
+				groupBy:     "dc",
+				limit:       defaultQueryLimit,
+			})
+			require.NoError(t, err)
+			require.Equal(t, "dc", resp.GroupBy)
+			require.Equal(t, []querySeries{
+				{
+					ID:   "dc=east",
+					Tags: map[string]string{"dc": "east"},
+					Datapoints: []queryDatapoint{
+						{Timestamp: start, Value: test.east[0]},
+						{Timestamp: start.Add(time.Minute), Value: test.east[1]},
+					},
+				},
+				{
+					ID:   "dc=west",
+					Tags: map[string]string{"dc": "west"},
+					Datapoints: []queryDatapoint{
+						{Timestamp: start, Value: test.west},
+					},
+				},
+			}, resp.Series)
+		})
+	}
+}
+
+func TestRunQueryDropsDatapointsOutsideRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// The block of the series starts before and ends after the query range.
+	var (
+		nsID   = ident.StringID("metrics")
+		start  = testQueryStart
+		end    = start.Add(time.Minute)
+		series = []testQuerySeries{
+			{
+				id:   "a",
+				tags: ident.NewTags(ident.StringTag("host", "a")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{-30 * time.Second, 0, 30 * time.Second,
+						60 * time.Second, 90 * time.Second},
+					[]float64{1, 2, 3, 4, 5}),
+			},
+		}
+		db = newTestQueryDatabase(t, ctrl, ctx, nsID, start, end, series)
+	)
+
+	tests := []struct {
+		name        string
+		aggregation queryAggregation
+		step        time.Duration
+		datapoints  []queryDatapoint
+	}{
+		{
+			name: "raw",
+			datapoints: []queryDatapoint{
+				{Timestamp: start, Value: 2},
+				{Timestamp: start.Add(30 * time.Second), Value: 3},
+			},
+		},
+		{
+			name:        "sum without step",
+			aggregation: queryAggregationSum,
+			datapoints: []queryDatapoint{
+				{Timestamp: start, Value: 5},
+			},
+		},
+		{
+			name:        "sum with step",
+			aggregation: queryAggregationSum,
+			step:        30 * time.Second,
+			datapoints: []queryDatapoint{
+				{Timestamp: start, Value: 2},
+				{Timestamp: start.Add(30 * time.Second), Value: 3},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp, err := runQuery(ctx, db, queryRequest{
+				namespace:   nsID,
+				query:       index.Query{Query: idx.NewAllQuery()},
+				start:       start,
+				end:         end,
+				aggregation: test.aggregation,
+				step:        test.step,
+				limit:       defaultQueryLimit,
+			})
+			require.NoError(t, err)
+			require.Equal(t, 1, len(resp.Series))
+			require.Equal(t, test.datapoints, resp.Series[0].Datapoints)
+		})
+	}
+}
+
+func newTestQueryURL(params url.Values) string {
+	return QueryURL + "?" + params.Encode()
+}
+
+func TestQueryHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		nsID   = ident.StringID("metrics")
+		start  = testQueryStart
+		end    = start.Add(2 * time.Minute)
+		series = []testQuerySeries{
+			{
+				id:   "a",
+				tags: ident.NewTags(ident.StringTag("host", "a")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{0, 30 * time.Second, 60 * time.Second},
+					[]float64{1, 2, 3}),
+			},
+			{
+				id:   "b",
+				tags: ident.NewTags(ident.StringTag("host", "b")),
+				datapoints: newTestQueryDatapoints(
+					[]time.Duration{90 * time.Second},
+					[]float64{4}),
+			},
+		}
+		db = newTestQueryDatabase(t, ctrl, ctx, nsID, start, end, series)
+	)
+	db.EXPECT().Namespace(ident.NewIDMatcher(nsID.String())).
+		Return(storage.NewMockNamespace(ctrl), true).AnyTimes()
+
+	handler := NewQueryHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())
+
+	tests := []struct {
+		name     string
+		params   url.Values
+		expected []querySeries
+	}{
+		{
+			name: "raw",
+			params: url.Values{
+				queryMatchParam: []string{"host=~a|b"},
+			},
+			expected: []querySeries{
+				{
+					ID:   "a",
+					Tags: map[string]string{"host": "a"},
+					Datapoints: []queryDatapoint{
+						{Timestamp: start, Value: 1},
+						{Timestamp: start.Add(30 * time.Second), Value: 2},
+						{Timestamp: start.Add(60 * time.Second), Value: 3},
+					},
+				},
+				{
+					ID:   "b",
+					Tags: map[string]string{"host": "b"},
+					Datapoints: []queryDatapoint{
+						{Timestamp: start.Add(90 * time.Second), Value: 4},
+					},
+				},
+			},
+		},
+		{
+			name: "aggregated",
+			params: url.Values{
+				queryMatchParam:       []string{"host=~a|b"},
+				queryAggregationParam: []string{string(queryAggregationSum)},
+				queryStepParam:        []string{"1m"},
+			},
+			expected: []querySeries{
+				{
+					ID:   "a",
+					Tags: map[string]string{"host": "a"},
+					Datapoints: []queryDatapoint{
+						{Timestamp: start, Value: 3},
+						{Timestamp: start.Add(time.Minute), Value: 3},
+					},
+				},
+				{
+					ID:   "b",
+					Tags: map[string]string{"host": "b"},
+					Datapoints: []queryDatapoint{
+						{Timestamp: start.Add(time.Minute), Value: 4},
+					},
+				},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := test.params
+			params.Set(queryNamespaceParam, nsID.String())
+			params.Set(queryStartParam, fmt.Sprintf("%d", start.Unix()))
+			params.Set(queryEndParam, fmt.Sprintf("%d", end.Unix()))
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet, newTestQueryURL(params), nil))
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+			require.Equal(t, "application/json", w.Header().Get("Content-Type"))
+
+			resp := queryResponse{
+				Namespace:   nsID.String(),
+				Start:       start,
+				End:         end,
+				Aggregation: queryAggregation(params.Get(queryAggregationParam)),
+				Step:        params.Get(queryStepParam),
+				Exhaustive:  true,
+				Series:      test.expected,
+			}
+			if resp.Step != "" {
+				resp.Step = time.Minute.String()
+			}
+			expected, err := json.Marshal(resp)
+			require.NoError(t, err)
+			require.JSONEq(t, string(expected), w.Body.String())
+		})
+	}
+}
+
+func TestQueryHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		nsID     = ident.StringID("metrics")
+		queryErr = errors.New("query failed")
+		db       = storage.NewMockDatabase(ctrl)
+	)
+	db.EXPECT().Options().Return(storage.NewOptions()).AnyTimes()
+	db.EXPECT().Namespace(ident.NewIDMatcher(nsID.String())).
+		Return(storage.NewMockNamespace(ctrl), true).AnyTimes()
+	db.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
+	gomock.InOrder(
+		db.EXPECT().QueryIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(index.QueryResult{}, queryErr),
+		db.EXPECT().QueryIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(index.QueryResult{}, xerrors.NewInvalidParamsError(queryErr)),
+	)
+
+	handler := NewQueryHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		status int
+	}{
+		{
+			name:   "not get",
+			method: http.MethodPost,
+			params: "namespace=metrics&match=host%3Da",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "missing namespace",
+			params: "match=host%3Da",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing matcher",
+			params: "namespace=metrics",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid matcher",
+			params: "namespace=metrics&match=host",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid regexp",
+			params: "namespace=metrics&match=host%3D~%28",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "start after end",
+			params: "namespace=metrics&match=host%3Da&start=200&end=100",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid start",
+			params: "namespace=metrics&match=host%3Da&start=yesterday",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid end",
+			params: "namespace=metrics&match=host%3Da&end=now",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown aggregation",
+			params: "namespace=metrics&match=host%3Da&aggregation=median",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "step without aggregation",
+			params: "namespace=metrics&match=host%3Da&step=1m",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid step",
+			params: "namespace=metrics&match=host%3Da&aggregation=sum&step=-1m",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "group by without aggregation",
+			params: "namespace=metrics&match=host%3Da&groupBy=dc",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid limit",
+			params: "namespace=metrics&match=host%3Da&limit=0",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown namespace",
+			params: "namespace=unknown&match=host%3Da",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "query error",
+			params: "namespace=metrics&match=host%3Da",
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "invalid params query error",
+			params: "namespace=metrics&match=host%3Da",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(method, QueryURL+"?"+test.params, nil))
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+}
+
+func TestParseQueryMatcher(t *testing.T) {
+	mustRegexp := func(name, pattern string) idx.Query {
+		q, err := idx.NewRegexpQuery([]byte(name), []byte(pattern))
+		require.NoError(t, err)
+		return q
+	}
+
+	tests := []struct {
+		matcher  string
+		expected idx.Query
+	}{
+		{
+			matcher:  "host=a",
+			expected: idx.NewTermQuery([]byte("host"), []byte("a")),
+		},
+		{
+			matcher:  "host=",
+			expected: idx.NewTermQuery([]byte("host"), []byte("")),
+		},
+		{
+			matcher:  "host!=a",
+			expected: idx.NewNegationQuery(idx.NewTermQuery([]byte("host"), []byte("a"))),
+		},
+		{
+			matcher:  "host=~a.*",
+			expected: mustRegexp("host", "a.*"),
+		},
+		{
+			matcher:  "host!~a.*",
+			expected: idx.NewNegationQuery(mustRegexp("host", "a.*")),
+		},
+		{
+			// Only the first operator splits the matcher.
+			matcher:  "host=a=b",
+			expected: idx.NewTermQuery([]byte("host"), []byte("a=b")),
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.matcher, func(t *testing.T) {
+			q, err := parseQueryMatcher(test.matcher)
+			require.NoError(t, err)
+			require.Equal(t, test.expected.String(), q.String())
+		})
+	}
+
+	for _, matcher := range []string{"host", "=a", "!=a", "host!a", "host=~("} {
+		t.Run(matcher, func(t *testing.T) {
+			_, err := parseQueryMatcher(matcher)
+			require.Error(t, err)
+		})
+	}
+}
+
+func TestParseQueryTime(t *testing.T) {
+	expected := time.Unix(1600000000, 0)
+
+	parsed, err := parseQueryTime("1600000000")
+	require.NoError(t, err)
+	require.True(t, expected.Equal(parsed))
+
+	parsed, err = parseQueryTime(expected.UTC().Format(time.RFC3339))
+	require.NoError(t, err)
+	require.True(t, expected.Equal(parsed))
+
+	_, err = parseQueryTime("yesterday")
+	require.Error(t, err)
+}
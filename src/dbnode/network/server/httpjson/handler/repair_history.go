@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// RepairHistoryURL is the URL of the repair history handler.
+	RepairHistoryURL = "/api/v1/namespace/repair-history"
+
+	repairHistoryNamespaceParam  = "namespace"
+	repairHistoryShardParam      = "shard"
+	repairHistoryBlockStartParam = "blockStart"
+)
+
+type repairOutcome struct {
+	Time               time.Time            `json:"time"`
+	Duration           string               `json:"duration"`
+	Action             storage.RepairAction `json:"action"`
+	NumSeries          int64                `json:"numSeries"`
+	NumBlocks          int64                `json:"numBlocks"`
+	SizeDiffSeries     int64                `json:"sizeDiffSeries"`
+	SizeDiffBlocks     int64                `json:"sizeDiffBlocks"`
+	ChecksumDiffSeries int64                `json:"checksumDiffSeries"`
+	ChecksumDiffBlocks int64                `json:"checksumDiffBlocks"`
+	Peers              []string             `json:"peers,omitempty"`
+	Error              string               `json:"error,omitempty"`
+}
+
+type blockRepairHistory struct {
+	Shard      uint32    `json:"shard"`
+	BlockStart time.Time `json:"blockStart"`
+	// NumDiverged is the number of the retained repairs that found the
+	// block had diverged from its peers, a count close to the number of
+	// outcomes indicates divergence is recurring for the block.
+	NumDiverged int             `json:"numDiverged"`
+	Outcomes    []repairOutcome `json:"outcomes"`
+}
+
+type repairHistoryResponse struct {
+	Namespace string               `json:"namespace"`
+	Blocks    []blockRepairHistory `json:"blocks"`
+}
+
+// NewRepairHistoryHandler returns a handler that serves the recent repair
+// outcomes of each block of each shard of a namespace, optionally only for
+// the shard and block start query parameters, so operators can see whether
+// divergence between replicas keeps recurring for specific blocks.
+func NewRepairHistoryHandler(
+	db storage.Database,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		values := r.URL.Query()
+		namespace := values.Get(repairHistoryNamespaceParam)
+		if namespace == "" {
+			http.Error(w, fmt.Sprintf("missing %s param", repairHistoryNamespaceParam),
+				http.StatusBadRequest)
+			return
+		}
+
+		var (
+			shard            uint64
+			filterShard      = values.Get(repairHistoryShardParam) != ""
+			blockStart       time.Time
+			filterBlockStart = values.Get(repairHistoryBlockStartParam) != ""
+			err              error
+		)
+		if filterShard {
+			shard, err = strconv.ParseUint(values.Get(repairHistoryShardParam), 10, 32)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s param: %v", repairHistoryShardParam, err),
+					http.StatusBadRequest)
+				return
+			}
+		}
+		if filterBlockStart {
+			blockStart, err = parseQueryTime(values.Get(repairHistoryBlockStartParam))
+			if err != nil {
+				http.Error(w, fmt.Sprintf("invalid %s param: %v", repairHistoryBlockStartParam, err),
+					http.StatusBadRequest)
+				return
+			}
+		}
+
+		nsID := ident.StringID(namespace)
+		if _, ok := db.Namespace(nsID); !ok {
+			http.Error(w, "namespace not found", http.StatusNotFound)
+			return
+		}
+
+		history, err := db.RepairHistory(nsID)
+		if err != nil {
+			logger.Error("unable to get repair history",
+				zap.String("namespace", namespace), zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		resp := repairHistoryResponse{
+			Namespace: namespace,
+			Blocks:    make([]blockRepairHistory, 0, len(history)),
+		}
+		for _, block := range history {
+			if filterShard && block.Shard != uint32(shard) {
+				continue
+			}
+			if filterBlockStart && !block.BlockStart.Equal(blockStart) {
+				continue
+			}
+
+			result := blockRepairHistory{
+				Shard:      block.Shard,
+				BlockStart: block.BlockStart,
+				Outcomes:   make([]repairOutcome, 0, len(block.Outcomes)),
+			}
+			for _, outcome := range block.Outcomes {
+				if outcome.SizeDiffBlocks > 0 || outcome.ChecksumDiffBlocks > 0 {
+					result.NumDiverged++
+				}
+				result.Outcomes = append(result.Outcomes, repairOutcome{
+					Time:               outcome.Time,
+					Duration:           outcome.Duration.String(),
+					Action:             outcome.Action,
+					NumSeries:          outcome.NumSeries,
+					NumBlocks:          outcome.NumBlocks,
+					SizeDiffSeries:     outcome.SizeDiffSeries,
+					SizeDiffBlocks:     outcome.SizeDiffBlocks,
+					ChecksumDiffSeries: outcome.ChecksumDiffSeries,
+					ChecksumDiffBlocks: outcome.ChecksumDiffBlocks,
+					Peers:              outcome.Peers,
+					Error:              outcome.Error,
+				})
+			}
+			resp.Blocks = append(resp.Blocks, result)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			logger.Error("unable to encode repair history", zap.Error(err))
+		}
+	}
+}
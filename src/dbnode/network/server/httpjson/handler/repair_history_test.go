@@ -0,0 +1,214 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestRepairHistoryHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		nsID       = ident.StringID("metrics")
+		blockStart = time.Unix(1600000000, 0)
+		repairedAt = blockStart.Add(4 * time.Hour)
+		db         = storage.NewMockDatabase(ctrl)
+		history    = []storage.BlockRepairHistory{
+			{
+				Shard:      1,
+				BlockStart: blockStart,
+				Outcomes: []storage.RepairOutcome{
+					{
+						Time:           repairedAt,
+						Duration:       time.Second,
+						Action:         storage.RepairActionLoaded,
+						NumSeries:      10,
+						NumBlocks:      10,
+						SizeDiffSeries: 2,
+						SizeDiffBlocks: 2,
+						Peers:          []string{"peer-a"},
+					},
+					{
+						Time:      repairedAt.Add(time.Hour),
+						Duration:  time.Second,
+						Action:    storage.RepairActionNone,
+						NumSeries: 10,
+						NumBlocks: 10,
+					},
+				},
+			},
+			{
+				Shard:      1,
+				BlockStart: blockStart.Add(2 * time.Hour),
+			},
+			{
+				Shard:      2,
+				BlockStart: blockStart,
+			},
+		}
+	)
+	db.EXPECT().Namespace(ident.NewIDMatcher(nsID.String())).
+		Return(storage.NewMockNamespace(ctrl), true).AnyTimes()
+	db.EXPECT().RepairHistory(ident.NewIDMatcher(nsID.String())).
+		Return(history, nil).AnyTimes()
+
+	handler := NewRepairHistoryHandler(db, zap.NewNop())
+
+	tests := []struct {
+		name     string
+		params   string
+		expected []int
+	}{
+		{
+			name:     "all",
+			params:   "namespace=metrics",
+			expected: []int{0, 1, 2},
+		},
+		{
+			name:     "shard",
+			params:   "namespace=metrics&shard=1",
+			expected: []int{0, 1},
+		},
+		{
+			name:     "block start",
+			params:   fmt.Sprintf("namespace=metrics&blockStart=%d", blockStart.Unix()),
+			expected: []int{0, 2},
+		},
+		{
+			name:     "shard and block start",
+			params:   fmt.Sprintf("namespace=metrics&shard=2&blockStart=%d", blockStart.Unix()),
+			expected: []int{2},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(http.MethodGet,
+				RepairHistoryURL+"?"+test.params, nil))
+			require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+			var resp repairHistoryResponse
+			require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+			require.Equal(t, nsID.String(), resp.Namespace)
+			require.Equal(t, len(test.expected), len(resp.Blocks))
+			for i, idx := range test.expected {
+				require.Equal(t, history[idx].Shard, resp.Blocks[i].Shard)
+				require.True(t, history[idx].BlockStart.Equal(resp.Blocks[i].BlockStart))
+				require.Equal(t, len(history[idx].Outcomes), len(resp.Blocks[i].Outcomes))
+			}
+		})
+	}
+
+	// Only the outcomes that found divergent blocks count as diverged.
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet,
+		RepairHistoryURL+"?namespace=metrics&shard=1", nil))
+	var resp repairHistoryResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	block := resp.Blocks[0]
+	require.Equal(t, 1, block.NumDiverged)
+	require.Equal(t, storage.RepairActionLoaded, block.Outcomes[0].Action)
+	require.Equal(t, "1s", block.Outcomes[0].Duration)
+	require.Equal(t, int64(2), block.Outcomes[0].SizeDiffBlocks)
+	require.Equal(t, []string{"peer-a"}, block.Outcomes[0].Peers)
+	require.True(t, repairedAt.Equal(block.Outcomes[0].Time))
+	require.Equal(t, storage.RepairActionNone, block.Outcomes[1].Action)
+}
+
+func TestRepairHistoryHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Namespace(ident.NewIDMatcher("metrics")).
+		Return(storage.NewMockNamespace(ctrl), true).AnyTimes()
+	db.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
+	db.EXPECT().RepairHistory(gomock.Any()).
+		Return(nil, errors.New("repair history unavailable"))
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		status int
+	}{
+		{
+			name:   "not get",
+			method: http.MethodPost,
+			params: "namespace=metrics",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "missing namespace",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid shard",
+			params: "namespace=metrics&shard=-1",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid block start",
+			params: "namespace=metrics&blockStart=yesterday",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown namespace",
+			params: "namespace=unknown",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "repair history error",
+			params: "namespace=metrics",
+			status: http.StatusInternalServerError,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			NewRepairHistoryHandler(db, zap.NewNop())(w,
+				httptest.NewRequest(method, RepairHistoryURL+"?"+test.params, nil))
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,231 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// StaleSeriesURL is the URL of the stale series handler.
+	StaleSeriesURL = "/api/v1/series/stale"
+
+	staleSeriesStaleAfterParam = "staleAfter"
+	staleSeriesLookbackParam   = "lookback"
+
+	defaultStaleSeriesStaleAfter = 5 * time.Minute
+	defaultStaleSeriesLookback   = 24 * time.Hour
+)
+
+type staleSeries struct {
+	ID        string            `json:"id"`
+	Tags      map[string]string `json:"tags"`
+	LastWrite *time.Time        `json:"lastWrite,omitempty"`
+}
+
+type staleSeriesResponse struct {
+	Namespace  string        `json:"namespace"`
+	StaleAfter string        `json:"staleAfter"`
+	Lookback   string        `json:"lookback"`
+	Exhaustive bool          `json:"exhaustive"`
+	Series     []staleSeries `json:"series"`
+}
+
+type staleSeriesRequest struct {
+	namespace  ident.ID
+	query      index.Query
+	staleAfter time.Duration
+	lookback   time.Duration
+	limit      int
+}
+
+// NewStaleSeriesHandler returns a handler that serves the series matching a
+// set of tag matchers that were indexed within the lookback but have not been
+// written to within the stale after duration, so that sources that stopped
+// reporting can be found without reading any datapoints. The last write time
+// is tracked in memory, series that are no longer held in memory or that have
+// not been written to since they were loaded are returned without a last
+// write time.
+func NewStaleSeriesHandler(
+	db storage.Database,
+	contextPool context.Pool,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		req, err := parseStaleSeriesRequest(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		ns, ok := db.Namespace(req.namespace)
+		if !ok {
+			http.Error(w, "namespace not found", http.StatusNotFound)
+			return
+		}
+
+		ctx := contextPool.Get()
+		defer ctx.Close()
+
+		now := db.Options().ClockOptions().NowFn()()
+		resp, err := runStaleSeriesQuery(ctx, db, ns, req, now)
+		if err != nil {
+			logger.Error("stale series query error",
+				zap.String("namespace", req.namespace.String()), zap.Error(err))
+			http.Error(w, err.Error(), promReadErrorStatus(err))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(resp); err != nil {
+			logger.Error("unable to encode stale series response", zap.Error(err))
+		}
+	}
+}
+
+func parseStaleSeriesRequest(r *http.Request) (staleSeriesRequest, error) {
+	values := r.URL.Query()
+
+	namespace := values.Get(queryNamespaceParam)
+	if namespace == "" {
+		return staleSeriesRequest{}, fmt.Errorf("missing %s param", queryNamespaceParam)
+	}
+
+	matchers := values[queryMatchParam]
+	if len(matchers) == 0 {
+		return staleSeriesRequest{}, fmt.Errorf("missing %s param", queryMatchParam)
+	}
+	queries := make([]idx.Query, 0, len(matchers))
+	for _, matcher := range matchers {
+		q, err := parseQueryMatcher(matcher)
+		if err != nil {
+			return staleSeriesRequest{}, err
+		}
+		queries = append(queries, q)
+	}
+
+	req := staleSeriesRequest{
+		namespace:  ident.StringID(namespace),
+		query:      index.Query{Query: idx.NewConjunctionQuery(queries...)},
+		staleAfter: defaultStaleSeriesStaleAfter,
+		lookback:   defaultStaleSeriesLookback,
+		limit:      defaultQueryLimit,
+	}
+
+	var err error
+	if v := values.Get(staleSeriesStaleAfterParam); v != "" {
+		if req.staleAfter, err = time.ParseDuration(v); err != nil || req.staleAfter <= 0 {
+			return staleSeriesRequest{}, fmt.Errorf("invalid %s param: %s",
+				staleSeriesStaleAfterParam, v)
+		}
+	}
+	if v := values.Get(staleSeriesLookbackParam); v != "" {
+		if req.lookback, err = time.ParseDuration(v); err != nil || req.lookback <= 0 {
+			return staleSeriesRequest{}, fmt.Errorf("invalid %s param: %s",
+				staleSeriesLookbackParam, v)
+		}
+	}
+	if v := values.Get(queryLimitParam); v != "" {
+		if req.limit, err = strconv.Atoi(v); err != nil || req.limit <= 0 {
+			return staleSeriesRequest{}, fmt.Errorf("invalid %s param: %s", queryLimitParam, v)
+		}
+	}
+
+	return req, nil
+}
+
+func runStaleSeriesQuery(
+	ctx context.Context,
+	db storage.Database,
+	ns storage.Namespace,
+	req staleSeriesRequest,
+	now time.Time,
+) (staleSeriesResponse, error) {
+	result, err := db.QueryIDs(ctx, req.namespace, req.query, index.QueryOptions{
+		StartInclusive: now.Add(-req.lookback),
+		EndExclusive:   now,
+		Limit:          req.limit,
+	})
+	if err != nil {
+		return staleSeriesResponse{}, err
+	}
+
+	entries, err := index.ResultsEntries(result.Results)
+	if err != nil {
+		return staleSeriesResponse{}, err
+	}
+
+	resp := staleSeriesResponse{
+		Namespace:  req.namespace.String(),
+		StaleAfter: req.staleAfter.String(),
+		Lookback:   req.lookback.String(),
+		Exhaustive: result.Exhaustive,
+		Series:     make([]staleSeries, 0),
+	}
+
+	staleBefore := now.Add(-req.staleAfter)
+	for _, entry := range entries {
+		lastWrite, _, err := ns.SeriesLastWrite(entry.Key())
+		if err != nil {
+			return staleSeriesResponse{}, err
+		}
+		if !lastWrite.IsZero() && !lastWrite.Before(staleBefore) {
+			continue
+		}
+
+		tags, err := queryTags(entry.Value().Duplicate())
+		if err != nil {
+			return staleSeriesResponse{}, err
+		}
+
+		series := staleSeries{
+			ID:   entry.Key().String(),
+			Tags: tags,
+		}
+		if !lastWrite.IsZero() {
+			series.LastWrite = &lastWrite
+		}
+		resp.Series = append(resp.Series, series)
+	}
+
+	sort.Slice(resp.Series, func(i, j int) bool {
+		return resp.Series[i].ID < resp.Series[j].ID
+	})
+
+	return resp, nil
+}
@@ -0,0 +1,168 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestStaleSeriesHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		now  = time.Unix(1600000000, 0)
+		nsID = ident.StringID("metrics")
+		db   = storage.NewMockDatabase(ctrl)
+		ns   = storage.NewMockNamespace(ctrl)
+	)
+	db.EXPECT().Options().Return(storage.NewOptions().SetClockOptions(
+		clock.NewOptions().SetNowFn(func() time.Time { return now }))).AnyTimes()
+	db.EXPECT().Namespace(ident.NewIDMatcher(nsID.String())).Return(ns, true).AnyTimes()
+
+	results := index.NewQueryResults(nsID, index.QueryResultsOptions{},
+		index.NewOptions())
+	lastWrites := map[string]time.Time{
+		// Written to within the stale after duration.
+		"a": now.Add(-time.Minute),
+		"b": now.Add(-10 * time.Minute),
+		// Not written to since it was loaded.
+		"c": {},
+	}
+	for id := range lastWrites {
+		results.Map().Set(ident.StringID(id), ident.NewTagsIterator(
+			ident.NewTags(ident.StringTag("host", id))))
+	}
+	db.EXPECT().QueryIDs(gomock.Any(), ident.NewIDMatcher(nsID.String()), gomock.Any(),
+		index.QueryOptions{
+			StartInclusive: now.Add(-time.Hour),
+			EndExclusive:   now,
+			Limit:          10,
+		}).
+		Return(index.QueryResult{Results: results, Exhaustive: true}, nil)
+	ns.EXPECT().SeriesLastWrite(gomock.Any()).DoAndReturn(
+		func(id ident.ID) (time.Time, bool, error) {
+			lastWrite := lastWrites[id.String()]
+			return lastWrite, !lastWrite.IsZero(), nil
+		}).Times(len(lastWrites))
+
+	w := httptest.NewRecorder()
+	NewStaleSeriesHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())(w,
+		httptest.NewRequest(http.MethodGet, StaleSeriesURL+
+			"?namespace=metrics&match=host%3D~.%2A&staleAfter=5m&lookback=1h&limit=10", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp staleSeriesResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, "metrics", resp.Namespace)
+	require.Equal(t, "5m0s", resp.StaleAfter)
+	require.Equal(t, "1h0m0s", resp.Lookback)
+	require.True(t, resp.Exhaustive)
+	require.Equal(t, 2, len(resp.Series))
+
+	require.Equal(t, "b", resp.Series[0].ID)
+	require.Equal(t, map[string]string{"host": "b"}, resp.Series[0].Tags)
+	require.NotNil(t, resp.Series[0].LastWrite)
+	require.True(t, lastWrites["b"].Equal(*resp.Series[0].LastWrite))
+
+	require.Equal(t, "c", resp.Series[1].ID)
+	require.Nil(t, resp.Series[1].LastWrite)
+}
+
+func TestStaleSeriesHandlerErrors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	db := storage.NewMockDatabase(ctrl)
+	db.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		status int
+	}{
+		{
+			name:   "not get",
+			method: http.MethodPost,
+			params: "namespace=metrics&match=host%3Da",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "missing namespace",
+			params: "match=host%3Da",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing matcher",
+			params: "namespace=metrics",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid stale after",
+			params: "namespace=metrics&match=host%3Da&staleAfter=0s",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid lookback",
+			params: "namespace=metrics&match=host%3Da&lookback=day",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "invalid limit",
+			params: "namespace=metrics&match=host%3Da&limit=-1",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "unknown namespace",
+			params: "namespace=metrics&match=host%3Da",
+			status: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			NewStaleSeriesHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())(w,
+				httptest.NewRequest(method, StaleSeriesURL+"?"+test.params, nil))
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+}
@@ -0,0 +1,136 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// SeriesMetadataURL is the URL of the series metadata handler.
+	SeriesMetadataURL = "/api/v1/series/metadata"
+
+	seriesMetadataNamespaceParam = "namespace"
+	seriesMetadataIDParam        = "id"
+)
+
+type seriesMetadataResponse struct {
+	Namespace string                 `json:"namespace"`
+	ID        string                 `json:"id"`
+	Metadata  convert.SeriesMetadata `json:"metadata"`
+}
+
+// NewSeriesMetadataHandler returns a handler that serves the metadata
+// attached to a series at its first write, looking up the series in the index
+// over the retention of its namespace.
+func NewSeriesMetadataHandler(
+	db storage.Database,
+	contextPool context.Pool,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		namespace := r.URL.Query().Get(seriesMetadataNamespaceParam)
+		if namespace == "" {
+			http.Error(w, fmt.Sprintf("missing %s param", seriesMetadataNamespaceParam),
+				http.StatusBadRequest)
+			return
+		}
+		id := r.URL.Query().Get(seriesMetadataIDParam)
+		if id == "" {
+			http.Error(w, fmt.Sprintf("missing %s param", seriesMetadataIDParam),
+				http.StatusBadRequest)
+			return
+		}
+
+		nsID := ident.StringID(namespace)
+		ns, ok := db.Namespace(nsID)
+		if !ok {
+			http.Error(w, "namespace not found", http.StatusNotFound)
+			return
+		}
+
+		var (
+			ropts = ns.Options().RetentionOptions()
+			now   = db.Options().ClockOptions().NowFn()()
+			query = index.Query{
+				Query: idx.NewTermQuery(doc.IDReservedFieldName, []byte(id)),
+			}
+			opts = index.QueryOptions{
+				StartInclusive: now.Add(-ropts.RetentionPeriod()),
+				EndExclusive:   now.Add(ropts.BufferFuture()),
+				Limit:          1,
+				SeriesMetadata: true,
+			}
+		)
+
+		ctx := contextPool.Get()
+		defer ctx.Close()
+
+		result, err := db.QueryIDs(ctx, nsID, query, opts)
+		if err != nil {
+			logger.Error("series metadata query error",
+				zap.String("namespace", namespace),
+				zap.String("id", id),
+				zap.Error(err))
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		tags, ok := result.Results.Map().Get(ident.StringID(id))
+		if !ok {
+			http.Error(w, "series not found", http.StatusNotFound)
+			return
+		}
+		iter := tags.Duplicate()
+		metadata, err := convert.SeriesMetadataFromTags(iter)
+		iter.Close()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(seriesMetadataResponse{
+			Namespace: namespace,
+			ID:        id,
+			Metadata:  metadata,
+		}); err != nil {
+			logger.Error("unable to encode series metadata", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestSeriesMetadataHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		now      = time.Unix(1600000000, 0)
+		nsID     = ident.StringID("metrics")
+		nsOpts   = namespace.NewOptions()
+		ropts    = nsOpts.RetentionOptions()
+		db       = storage.NewMockDatabase(ctrl)
+		ns       = storage.NewMockNamespace(ctrl)
+		metadata = convert.SeriesMetadata{
+			Unit:        "seconds",
+			Description: "request latency",
+			Type:        "histogram",
+		}
+	)
+	db.EXPECT().Options().Return(storage.NewOptions().SetClockOptions(
+		clock.NewOptions().SetNowFn(func() time.Time { return now }))).AnyTimes()
+	db.EXPECT().Namespace(ident.NewIDMatcher(nsID.String())).Return(ns, true).AnyTimes()
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+
+	results := index.NewQueryResults(nsID, index.QueryResultsOptions{},
+		index.NewOptions())
+	tags := ident.NewTags(append([]ident.Tag{ident.StringTag("host", "a")},
+		metadata.Tags()...)...)
+	results.Map().Set(ident.StringID("a"), ident.NewTagsIterator(tags))
+
+	gomock.InOrder(
+		db.EXPECT().QueryIDs(gomock.Any(), ident.NewIDMatcher(nsID.String()),
+			index.Query{Query: idx.NewTermQuery(doc.IDReservedFieldName, []byte("a"))},
+			index.QueryOptions{
+				StartInclusive: now.Add(-ropts.RetentionPeriod()),
+				EndExclusive:   now.Add(ropts.BufferFuture()),
+				Limit:          1,
+				SeriesMetadata: true,
+			}).
+			Return(index.QueryResult{Results: results, Exhaustive: true}, nil),
+		db.EXPECT().QueryIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(index.QueryResult{Results: index.NewQueryResults(nsID,
+				index.QueryResultsOptions{}, index.NewOptions())}, nil),
+		db.EXPECT().QueryIDs(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(index.QueryResult{}, errors.New("query failed")),
+	)
+
+	handler := NewSeriesMetadataHandler(db, context.NewPool(context.NewOptions()), zap.NewNop())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet,
+		SeriesMetadataURL+"?namespace=metrics&id=a", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp seriesMetadataResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, seriesMetadataResponse{
+		Namespace: nsID.String(),
+		ID:        "a",
+		Metadata:  metadata,
+	}, resp)
+
+	tests := []struct {
+		name   string
+		method string
+		params string
+		status int
+	}{
+		{
+			name:   "series not found",
+			params: "namespace=metrics&id=b",
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "query error",
+			params: "namespace=metrics&id=a",
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "not get",
+			method: http.MethodPost,
+			params: "namespace=metrics&id=a",
+			status: http.StatusMethodNotAllowed,
+		},
+		{
+			name:   "missing namespace",
+			params: "id=a",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "missing id",
+			params: "namespace=metrics",
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			method := test.method
+			if method == "" {
+				method = http.MethodGet
+			}
+
+			w := httptest.NewRecorder()
+			handler(w, httptest.NewRequest(method, SeriesMetadataURL+"?"+test.params, nil))
+			require.Equal(t, test.status, w.Code, w.Body.String())
+		})
+	}
+
+	db.EXPECT().Namespace(gomock.Any()).Return(nil, false)
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet,
+		SeriesMetadataURL+"?namespace=unknown&id=a", nil))
+	require.Equal(t, http.StatusNotFound, w.Code, w.Body.String())
+}
@@ -0,0 +1,111 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+
+	"github.com/m3db/m3/src/dbnode/storage"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// WriteLatenessURL is the URL of the write lateness handler.
+	WriteLatenessURL = "/api/v1/namespace/write-lateness"
+
+	writeLatenessNamespaceParam = "namespace"
+)
+
+// writeLatenessQuantiles are the quantiles of write lateness reported
+// alongside the distribution of each namespace.
+var writeLatenessQuantiles = []struct {
+	name  string
+	value float64
+}{
+	{name: "p50", value: 0.5},
+	{name: "p90", value: 0.9},
+	{name: "p99", value: 0.99},
+	{name: "p999", value: 0.999},
+}
+
+type writeLatenessResponse struct {
+	Namespace  string                `json:"namespace"`
+	BufferPast string                `json:"bufferPast"`
+	Quantiles  map[string]string     `json:"quantiles"`
+	Lateness   storage.WriteLateness `json:"lateness"`
+}
+
+// NewWriteLatenessHandler returns a handler that serves the distribution of
+// how late datapoints arrived relative to now for each namespace, or only for
+// the namespace query parameter if set, along with the buffer past of the
+// namespace so operators can tell whether late writes are at risk of being
+// rejected.
+func NewWriteLatenessHandler(
+	db storage.Database,
+	logger *zap.Logger,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			http.Error(w, "request must be GET", http.StatusMethodNotAllowed)
+			return
+		}
+
+		filter := r.URL.Query().Get(writeLatenessNamespaceParam)
+		namespaces := db.Namespaces()
+		sort.Sort(storage.NamespacesByID(namespaces))
+
+		results := make([]writeLatenessResponse, 0, len(namespaces))
+		for _, ns := range namespaces {
+			id := ns.ID().String()
+			if filter != "" && filter != id {
+				continue
+			}
+			lateness := ns.WriteLateness()
+			quantiles := make(map[string]string, len(writeLatenessQuantiles))
+			for _, q := range writeLatenessQuantiles {
+				value, ok := lateness.Quantile(q.value)
+				if !ok {
+					quantiles[q.name] = ">" + value.String()
+					continue
+				}
+				quantiles[q.name] = "<=" + value.String()
+			}
+			results = append(results, writeLatenessResponse{
+				Namespace:  id,
+				BufferPast: ns.Options().RetentionOptions().BufferPast().String(),
+				Quantiles:  quantiles,
+				Lateness:   lateness,
+			})
+		}
+		if filter != "" && len(results) == 0 {
+			http.Error(w, "namespace not found", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(results); err != nil {
+			logger.Error("unable to encode write lateness", zap.Error(err))
+		}
+	}
+}
@@ -0,0 +1,104 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestWriteLatenessHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		db       = storage.NewMockDatabase(ctrl)
+		nsA      = storage.NewMockNamespace(ctrl)
+		nsB      = storage.NewMockNamespace(ctrl)
+		lateness = storage.WriteLateness{
+			Buckets: []storage.WriteLatenessBucket{
+				{UpperBound: time.Second, Count: 8},
+				{UpperBound: time.Minute, Count: 1},
+				{Count: 1},
+			},
+			Count: 10,
+		}
+		nsOpts = namespace.NewOptions().SetRetentionOptions(
+			retention.NewOptions().SetBufferPast(10 * time.Minute))
+	)
+	nsA.EXPECT().ID().Return(ident.StringID("a")).AnyTimes()
+	nsA.EXPECT().Options().Return(nsOpts).AnyTimes()
+	nsA.EXPECT().WriteLateness().Return(lateness).AnyTimes()
+	nsB.EXPECT().ID().Return(ident.StringID("b")).AnyTimes()
+	nsB.EXPECT().Options().Return(nsOpts).AnyTimes()
+	nsB.EXPECT().WriteLateness().Return(storage.WriteLateness{}).AnyTimes()
+	db.EXPECT().Namespaces().Return([]storage.Namespace{nsB, nsA}).AnyTimes()
+
+	handler := NewWriteLatenessHandler(db, zap.NewNop())
+
+	w := httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, WriteLatenessURL, nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+
+	var resp []writeLatenessResponse
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, 2, len(resp))
+	require.Equal(t, "a", resp[0].Namespace)
+	require.Equal(t, "b", resp[1].Namespace)
+
+	a := resp[0]
+	require.Equal(t, "10m0s", a.BufferPast)
+	require.Equal(t, lateness, a.Lateness)
+	require.Equal(t, "<=1s", a.Quantiles["p50"])
+	require.Equal(t, "<=1m0s", a.Quantiles["p90"])
+	// The quantiles that fall in the overflow bucket are only bounded below.
+	require.True(t, strings.HasPrefix(a.Quantiles["p99"], ">"))
+	require.True(t, strings.HasPrefix(a.Quantiles["p999"], ">"))
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, WriteLatenessURL+"?namespace=b", nil))
+	require.Equal(t, http.StatusOK, w.Code, w.Body.String())
+	resp = nil
+	require.NoError(t, json.NewDecoder(w.Body).Decode(&resp))
+	require.Equal(t, 1, len(resp))
+	require.Equal(t, "b", resp[0].Namespace)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodGet, WriteLatenessURL+"?namespace=c", nil))
+	require.Equal(t, http.StatusNotFound, w.Code)
+
+	w = httptest.NewRecorder()
+	handler(w, httptest.NewRequest(http.MethodPost, WriteLatenessURL, nil))
+	require.Equal(t, http.StatusMethodNotAllowed, w.Code)
+}
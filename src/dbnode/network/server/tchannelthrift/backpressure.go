@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package tchannelthrift
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/uber/tchannel-go/thrift"
+)
+
+const (
+	// PressureLevelHeader is the response header carrying the pressure
+	// level of the node that served the request.
+	PressureLevelHeader = "m3-pressure-level"
+
+	// RetryAfterHeader is the response header carrying how long, in
+	// milliseconds, clients should wait before retrying a request.
+	RetryAfterHeader = "m3-retry-after-ms"
+)
+
+// PressureLevel describes how close a node is to shedding load.
+type PressureLevel int
+
+const (
+	// PressureLevelNone indicates the node is not under pressure.
+	PressureLevelNone PressureLevel = iota
+	// PressureLevelElevated indicates the node is nearing its limits.
+	PressureLevelElevated
+	// PressureLevelShedding indicates the node is rejecting requests.
+	PressureLevelShedding
+)
+
+func (l PressureLevel) String() string {
+	switch l {
+	case PressureLevelElevated:
+		return "elevated"
+	case PressureLevelShedding:
+		return "shedding"
+	}
+	return "none"
+}
+
+func parsePressureLevel(str string) (PressureLevel, bool) {
+	for _, l := range []PressureLevel{
+		PressureLevelNone,
+		PressureLevelElevated,
+		PressureLevelShedding,
+	} {
+		if str == l.String() {
+			return l, true
+		}
+	}
+	return PressureLevelNone, false
+}
+
+// Backpressure is a hint returned by a node to its clients of the
+// pressure it is under and how long to wait before retrying requests.
+type Backpressure struct {
+	Level      PressureLevel
+	RetryAfter time.Duration
+}
+
+// SetBackpressureHeaders sets the response headers of a request to
+// describe the backpressure, no headers are set when under no pressure.
+func SetBackpressureHeaders(ctx thrift.Context, bp Backpressure) {
+	if bp.Level == PressureLevelNone {
+		return
+	}
+	headers := map[string]string{
+		PressureLevelHeader: bp.Level.String(),
+	}
+	if bp.RetryAfter > 0 {
+		headers[RetryAfterHeader] = strconv.FormatInt(
+			int64(bp.RetryAfter/time.Millisecond), 10)
+	}
+	ctx.SetResponseHeaders(headers)
+}
+
+// BackpressureFromHeaders returns the backpressure described by the
+// response headers of a request, false if the headers describe none.
+func BackpressureFromHeaders(headers map[string]string) (Backpressure, bool) {
+	level, ok := parsePressureLevel(headers[PressureLevelHeader])
+	if !ok || level == PressureLevelNone {
+		return Backpressure{}, false
+	}
+	bp := Backpressure{Level: level}
+	if ms, err := strconv.ParseInt(headers[RetryAfterHeader], 10, 64); err == nil && ms > 0 {
+		bp.RetryAfter = time.Duration(ms) * time.Millisecond
+	}
+	return bp, true
+}
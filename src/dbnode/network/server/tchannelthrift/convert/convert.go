@@ -29,26 +29,38 @@ import (
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/m3ninx/generated/proto/querypb"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/compress"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 )
 
 var (
-	errUnknownTimeType  = errors.New("unknown time type")
-	errUnknownUnit      = errors.New("unknown unit")
-	errNilTaggedRequest = errors.New("nil write tagged request")
+	errUnknownTimeType    = errors.New("unknown time type")
+	errUnknownUnit        = errors.New("unknown unit")
+	errUnknownDurability  = errors.New("unknown durability")
+	errUnknownDisposition = errors.New("unknown write disposition")
+	errNilTaggedRequest   = errors.New("nil write tagged request")
+
+	errInvalidFetchTaggedPageToken = errors.New("invalid fetch tagged page token")
+	errNoAggregateNamespaces       = errors.New("no namespaces specified for aggregate")
 
 	timeZero time.Time
 )
 
 const (
 	fetchTaggedTimeType = rpc.TimeType_UNIX_NANOSECONDS
+
+	// fetchTaggedPageTokenVersion prefixes fetch tagged page tokens so that
+	// their format can change without breaking clients holding old tokens.
+	fetchTaggedPageTokenVersion byte = 1
 )
 
 // ToTime converts a value to a time
@@ -112,6 +124,86 @@ func ToTimeType(unit xtime.Unit) (rpc.TimeType, error) {
 	return 0, errUnknownUnit
 }
 
+// ToDurability converts an RPC write durability to a durability
+func ToDurability(durability rpc.WriteDurability) (ts.Durability, error) {
+	switch durability {
+	case rpc.WriteDurability_DEFAULT:
+		return ts.DurabilityDefault, nil
+	case rpc.WriteDurability_MEMORY:
+		return ts.DurabilityMemory, nil
+	case rpc.WriteDurability_COMMIT_LOG_BUFFER:
+		return ts.DurabilityCommitLogBuffer, nil
+	case rpc.WriteDurability_FSYNC:
+		return ts.DurabilityFsync, nil
+	}
+	return 0, errUnknownDurability
+}
+
+// ToRPCDurability converts a durability to an RPC write durability
+func ToRPCDurability(durability ts.Durability) (rpc.WriteDurability, error) {
+	switch durability {
+	case ts.DurabilityDefault:
+		return rpc.WriteDurability_DEFAULT, nil
+	case ts.DurabilityMemory:
+		return rpc.WriteDurability_MEMORY, nil
+	case ts.DurabilityCommitLogBuffer:
+		return rpc.WriteDurability_COMMIT_LOG_BUFFER, nil
+	case ts.DurabilityFsync:
+		return rpc.WriteDurability_FSYNC, nil
+	}
+	return 0, errUnknownDurability
+}
+
+// ToWriteDisposition converts an RPC write disposition to a write disposition
+func ToWriteDisposition(disposition rpc.WriteDisposition) (series.WriteDisposition, error) {
+	switch disposition {
+	case rpc.WriteDisposition_UNKNOWN:
+		return series.WriteDispositionUnknown, nil
+	case rpc.WriteDisposition_ACCEPTED:
+		return series.WriteAccepted, nil
+	case rpc.WriteDisposition_CLAMPED:
+		return series.WriteClamped, nil
+	case rpc.WriteDisposition_DUPLICATE_OVERWRITTEN:
+		return series.WriteDuplicateOverwritten, nil
+	case rpc.WriteDisposition_REJECTED_TOO_OLD:
+		return series.WriteRejectedTooOld, nil
+	case rpc.WriteDisposition_DROPPED:
+		return series.WriteDropped, nil
+	case rpc.WriteDisposition_DUPLICATE:
+		return series.WriteDuplicate, nil
+	case rpc.WriteDisposition_REJECTED_TOO_FUTURE:
+		return series.WriteRejectedTooFuture, nil
+	case rpc.WriteDisposition_REJECTED_COLD_WRITES_DISABLED:
+		return series.WriteRejectedColdWritesDisabled, nil
+	}
+	return 0, errUnknownDisposition
+}
+
+// ToRPCWriteDisposition converts a write disposition to an RPC write disposition
+func ToRPCWriteDisposition(disposition series.WriteDisposition) (rpc.WriteDisposition, error) {
+	switch disposition {
+	case series.WriteDispositionUnknown:
+		return rpc.WriteDisposition_UNKNOWN, nil
+	case series.WriteAccepted:
+		return rpc.WriteDisposition_ACCEPTED, nil
+	case series.WriteClamped:
+		return rpc.WriteDisposition_CLAMPED, nil
+	case series.WriteDuplicateOverwritten:
+		return rpc.WriteDisposition_DUPLICATE_OVERWRITTEN, nil
+	case series.WriteRejectedTooOld:
+		return rpc.WriteDisposition_REJECTED_TOO_OLD, nil
+	case series.WriteDropped:
+		return rpc.WriteDisposition_DROPPED, nil
+	case series.WriteDuplicate:
+		return rpc.WriteDisposition_DUPLICATE, nil
+	case series.WriteRejectedTooFuture:
+		return rpc.WriteDisposition_REJECTED_TOO_FUTURE, nil
+	case series.WriteRejectedColdWritesDisabled:
+		return rpc.WriteDisposition_REJECTED_COLD_WRITES_DISABLED, nil
+	}
+	return 0, errUnknownDisposition
+}
+
 // ToSegmentsResult is the result of a convert to segments call,
 // if the segments were merged then checksum is ptr to the checksum
 // otherwise it is nil.
@@ -120,8 +212,24 @@ type ToSegmentsResult struct {
 	Checksum *int64
 }
 
+// ToSegmentsOptions is a set of options for converting blocks to segments.
+type ToSegmentsOptions struct {
+	// BlockMetadata when set fills in the checksum, last write time and
+	// source of each returned segment.
+	BlockMetadata bool
+}
+
 // ToSegments converts a list of blocks to segments.
 func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
+	return ToSegmentsWithOptions(blocks, ToSegmentsOptions{})
+}
+
+// ToSegmentsWithOptions converts a list of blocks to segments using the
+// given options.
+func ToSegmentsWithOptions(
+	blocks []xio.BlockReader,
+	opts ToSegmentsOptions,
+) (ToSegmentsResult, error) {
 	if len(blocks) == 0 {
 		return ToSegmentsResult{}, nil
 	}
@@ -145,6 +253,9 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 			BlockSize: &blockSize,
 		}
 		checksum := int64(digest.SegmentChecksum(seg))
+		if opts.BlockMetadata {
+			setSegmentMetadata(s.Merged, blocks[0], checksum)
+		}
 		return ToSegmentsResult{
 			Segments: s,
 			Checksum: &checksum,
@@ -161,12 +272,17 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 		}
 		startTime := xtime.ToNormalizedTime(block.Start, time.Nanosecond)
 		blockSize := xtime.ToNormalizedDuration(block.BlockSize, time.Nanosecond)
-		s.Unmerged = append(s.Unmerged, &rpc.Segment{
+		unmerged := &rpc.Segment{
 			Head:      bytesRef(seg.Head),
 			Tail:      bytesRef(seg.Tail),
 			StartTime: &startTime,
 			BlockSize: &blockSize,
-		})
+		}
+		if opts.BlockMetadata {
+			checksum := int64(digest.SegmentChecksum(seg))
+			setSegmentMetadata(unmerged, block, checksum)
+		}
+		s.Unmerged = append(s.Unmerged, unmerged)
 	}
 	if len(s.Unmerged) == 0 {
 		return ToSegmentsResult{}, nil
@@ -175,6 +291,87 @@ func ToSegments(blocks []xio.BlockReader) (ToSegmentsResult, error) {
 	return ToSegmentsResult{Segments: s}, nil
 }
 
+func setSegmentMetadata(
+	segment *rpc.Segment,
+	block xio.BlockReader,
+	checksum int64,
+) {
+	segment.Checksum = &checksum
+	if !block.LastWrite.IsZero() {
+		lastWrite := xtime.ToNormalizedTime(block.LastWrite, time.Nanosecond)
+		segment.LastWriteTime = &lastWrite
+	}
+	source := ToRPCBlockSource(block.Source)
+	segment.Source = &source
+}
+
+// ToRPCBlockSource converts a block source to the RPC block source.
+func ToRPCBlockSource(source xio.BlockSource) rpc.BlockSource {
+	switch source {
+	case xio.BlockSourceMemory:
+		return rpc.BlockSource_MEMORY
+	case xio.BlockSourceDisk:
+		return rpc.BlockSource_DISK
+	case xio.BlockSourcePeer:
+		return rpc.BlockSource_PEER
+	}
+	return rpc.BlockSource_UNKNOWN
+}
+
+// CompressSegments compresses the head and tail of each segment in place,
+// the checksums of segments are always of the uncompressed data.
+func CompressSegments(segments *rpc.Segments, compressor compress.Compressor) error {
+	return transformSegments(segments, compressor.Compress)
+}
+
+// DecompressSegments decompresses the head and tail of each segment in place.
+func DecompressSegments(segments *rpc.Segments, compressor compress.Compressor) error {
+	return transformSegments(segments, compressor.Decompress)
+}
+
+func transformSegments(
+	segments *rpc.Segments,
+	fn func(dst, src []byte) ([]byte, error),
+) error {
+	if segments == nil {
+		return nil
+	}
+	if segments.Merged != nil {
+		if err := transformSegment(segments.Merged, fn); err != nil {
+			return err
+		}
+	}
+	for _, seg := range segments.Unmerged {
+		if err := transformSegment(seg, fn); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func transformSegment(
+	seg *rpc.Segment,
+	fn func(dst, src []byte) ([]byte, error),
+) error {
+	// NB: Empty heads and tails are left as is so they stay empty on
+	// either side of the transform.
+	if len(seg.Head) > 0 {
+		head, err := fn(nil, seg.Head)
+		if err != nil {
+			return err
+		}
+		seg.Head = head
+	}
+	if len(seg.Tail) > 0 {
+		tail, err := fn(nil, seg.Tail)
+		if err != nil {
+			return err
+		}
+		seg.Tail = tail
+	}
+	return nil
+}
+
 func bytesRef(data checked.Bytes) []byte {
 	if data != nil {
 		return data.Bytes()
@@ -239,6 +436,23 @@ func FromRPCFetchTaggedRequest(
 	return ns, index.Query{Query: q}, opts, req.FetchData, nil
 }
 
+// ToRPCFetchTaggedPageToken returns the opaque page token to resume a sorted
+// fetch tagged after the series with the given ID.
+func ToRPCFetchTaggedPageToken(lastID []byte) []byte {
+	token := make([]byte, 0, 1+len(lastID))
+	token = append(token, fetchTaggedPageTokenVersion)
+	return append(token, lastID...)
+}
+
+// FromRPCFetchTaggedPageToken returns the ID of the series a sorted fetch
+// tagged page token resumes after.
+func FromRPCFetchTaggedPageToken(token []byte) ([]byte, error) {
+	if len(token) < 2 || token[0] != fetchTaggedPageTokenVersion {
+		return nil, errInvalidFetchTaggedPageToken
+	}
+	return token[1:], nil
+}
+
 // ToRPCFetchTaggedRequest converts the Go `client/` types into rpc request type for FetchTaggedRequest.
 func ToRPCFetchTaggedRequest(
 	ns ident.ID,
@@ -321,6 +535,42 @@ func FromRPCAggregateQueryRequest(
 	return ns, index.Query{Query: query}, opts, nil
 }
 
+// FromRPCAggregateMultiQueryRequest converts the rpc request type for AggregateMultiQueryRequest
+// into corresponding Go API types, returning each distinct namespace to query once.
+func FromRPCAggregateMultiQueryRequest(
+	req *rpc.AggregateMultiQueryRequest,
+) ([]ident.ID, index.Query, index.AggregationOptions, error) {
+	if len(req.NameSpaces) == 0 {
+		return nil, index.Query{}, index.AggregationOptions{}, errNoAggregateNamespaces
+	}
+
+	_, query, opts, err := FromRPCAggregateQueryRequest(&rpc.AggregateQueryRequest{
+		Query:              req.Query,
+		RangeStart:         req.RangeStart,
+		RangeEnd:           req.RangeEnd,
+		Limit:              req.Limit,
+		TagNameFilter:      req.TagNameFilter,
+		AggregateQueryType: req.AggregateQueryType,
+		RangeType:          req.RangeType,
+	})
+	if err != nil {
+		return nil, index.Query{}, index.AggregationOptions{}, err
+	}
+
+	var (
+		namespaces = make([]ident.ID, 0, len(req.NameSpaces))
+		seen       = make(map[string]struct{}, len(req.NameSpaces))
+	)
+	for _, ns := range req.NameSpaces {
+		if _, ok := seen[ns]; ok {
+			continue
+		}
+		seen[ns] = struct{}{}
+		namespaces = append(namespaces, ident.StringID(ns))
+	}
+	return namespaces, query, opts, nil
+}
+
 // FromRPCAggregateQueryRawRequest converts the rpc request type for AggregateRawQueryRequest into corresponding Go API types.
 func FromRPCAggregateQueryRawRequest(
 	req *rpc.AggregateQueryRawRequest,
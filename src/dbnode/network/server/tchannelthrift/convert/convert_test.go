@@ -25,11 +25,17 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
 
@@ -165,6 +171,18 @@ func TestConvertFetchTaggedRequest(t *testing.T) {
 	}
 }
 
+func TestConvertFetchTaggedPageToken(t *testing.T) {
+	token := convert.ToRPCFetchTaggedPageToken([]byte("foo"))
+	id, err := convert.FromRPCFetchTaggedPageToken(token)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("foo"), id)
+
+	for _, invalid := range [][]byte{nil, {}, {1}, []byte("foo")} {
+		_, err := convert.FromRPCFetchTaggedPageToken(invalid)
+		assert.Error(t, err)
+	}
+}
+
 func TestConvertAggregateRawQueryRequest(t *testing.T) {
 	ns := ident.StringID("abc")
 	opts := index.AggregationOptions{
@@ -240,6 +258,40 @@ func TestConvertAggregateRawQueryRequest(t *testing.T) {
 	}
 }
 
+func TestConvertAggregateMultiQueryRequest(t *testing.T) {
+	start := time.Now().Add(-900 * time.Hour)
+	end := time.Now()
+	var limit int64 = 10
+	req := &rpc.AggregateMultiQueryRequest{
+		Query:      &rpc.Query{All: &rpc.AllQuery{}},
+		RangeStart: mustToRpcTime(t, start),
+		RangeEnd:   mustToRpcTime(t, end),
+		NameSpaces: []string{"short", "long", "short"},
+		Limit:      &limit,
+		TagNameFilter: []string{
+			"some",
+			"string",
+		},
+		AggregateQueryType: rpc.AggregateQueryType_AGGREGATE_BY_TAG_NAME,
+	}
+
+	namespaces, query, opts, err := convert.FromRPCAggregateMultiQueryRequest(req)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(namespaces))
+	require.Equal(t, "short", namespaces[0].String())
+	require.Equal(t, "long", namespaces[1].String())
+	require.True(t, index.NewQueryMatcher(index.Query{Query: idx.NewAllQuery()}).Matches(query))
+	require.True(t, start.Equal(opts.StartInclusive))
+	require.True(t, end.Equal(opts.EndExclusive))
+	require.Equal(t, 10, opts.Limit)
+	require.Equal(t, index.AggregateTagNames, opts.Type)
+	require.Equal(t, index.AggregateFieldFilter{[]byte("some"), []byte("string")}, opts.FieldFilter)
+
+	req.NameSpaces = nil
+	_, _, _, err = convert.FromRPCAggregateMultiQueryRequest(req)
+	require.Error(t, err)
+}
+
 type testPools struct {
 	id      ident.Pool
 	wrapper xpool.CheckedBytesWrapperPool
@@ -261,3 +313,137 @@ var _ convert.FetchTaggedConversionPools = &testPools{}
 
 func (t *testPools) ID() ident.Pool                                     { return t.id }
 func (t *testPools) CheckedBytesWrapper() xpool.CheckedBytesWrapperPool { return t.wrapper }
+
+func TestConvertCompressSegmentsRoundTrip(t *testing.T) {
+	for _, compressionType := range []compress.Type{compress.LZ4, compress.Zstd} {
+		t.Run(string(compressionType), func(t *testing.T) {
+			compressor, err := compress.NewCompressor(compressionType)
+			require.NoError(t, err)
+
+			newSegments := func() *rpc.Segments {
+				return &rpc.Segments{
+					Merged: &rpc.Segment{
+						Head: []byte("merged head merged head merged head"),
+						Tail: []byte("merged tail"),
+					},
+					Unmerged: []*rpc.Segment{
+						{Head: []byte("unmerged head"), Tail: nil},
+						{Head: []byte{}, Tail: []byte("unmerged tail")},
+					},
+				}
+			}
+
+			segments := newSegments()
+			require.NoError(t, convert.CompressSegments(segments, compressor))
+			assert.NotEqual(t, newSegments().Merged.Head, segments.Merged.Head)
+			assert.Nil(t, segments.Unmerged[0].Tail)
+			assert.Equal(t, []byte{}, segments.Unmerged[1].Head)
+
+			require.NoError(t, convert.DecompressSegments(segments, compressor))
+			assert.Equal(t, newSegments(), segments)
+		})
+	}
+}
+
+func TestConvertDecompressSegmentsCorrupt(t *testing.T) {
+	compressor, err := compress.NewCompressor(compress.LZ4)
+	require.NoError(t, err)
+
+	segments := &rpc.Segments{
+		Merged: &rpc.Segment{Head: []byte("not compressed")},
+	}
+	require.Error(t, convert.DecompressSegments(segments, compressor))
+	require.NoError(t, convert.DecompressSegments(nil, compressor))
+}
+
+func TestConvertDurabilityRoundTrip(t *testing.T) {
+	for _, durability := range ts.ValidDurabilities() {
+		rpcDurability, err := convert.ToRPCDurability(durability)
+		require.NoError(t, err)
+
+		result, err := convert.ToDurability(rpcDurability)
+		require.NoError(t, err)
+		require.Equal(t, durability, result)
+	}
+
+	_, err := convert.ToDurability(rpc.WriteDurability(100))
+	require.Error(t, err)
+	_, err = convert.ToRPCDurability(ts.Durability(100))
+	require.Error(t, err)
+}
+
+func TestConvertWriteDispositionRoundTrip(t *testing.T) {
+	for _, disposition := range series.ValidWriteDispositions() {
+		rpcDisposition, err := convert.ToRPCWriteDisposition(disposition)
+		require.NoError(t, err)
+
+		result, err := convert.ToWriteDisposition(rpcDisposition)
+		require.NoError(t, err)
+		require.Equal(t, disposition, result)
+	}
+
+	_, err := convert.ToWriteDisposition(rpc.WriteDisposition(100))
+	require.Error(t, err)
+	_, err = convert.ToRPCWriteDisposition(series.WriteDisposition(100))
+	require.Error(t, err)
+}
+
+func TestConvertToSegmentsBlockMetadata(t *testing.T) {
+	var (
+		start     = time.Now().Truncate(time.Hour)
+		blockSize = time.Hour
+		lastWrite = start.Add(time.Minute)
+	)
+	newBlock := func(
+		head string,
+		source xio.BlockSource,
+		lastWrite time.Time,
+	) (xio.BlockReader, ts.Segment) {
+		segment := ts.NewSegment(checked.NewBytes([]byte(head), nil),
+			checked.NewBytes([]byte("tail"), nil), ts.FinalizeNone)
+		return xio.BlockReader{
+			SegmentReader: xio.NewSegmentReader(segment),
+			Start:         start,
+			BlockSize:     blockSize,
+			Source:        source,
+			LastWrite:     lastWrite,
+		}, segment
+	}
+
+	memory, memorySegment := newBlock("memory", xio.BlockSourceMemory, lastWrite)
+	disk, diskSegment := newBlock("disk", xio.BlockSourceDisk, time.Time{})
+
+	result, err := convert.ToSegments([]xio.BlockReader{memory, disk})
+	require.NoError(t, err)
+	require.Len(t, result.Segments.Unmerged, 2)
+	for _, segment := range result.Segments.Unmerged {
+		assert.False(t, segment.IsSetChecksum())
+		assert.False(t, segment.IsSetLastWriteTime())
+		assert.False(t, segment.IsSetSource())
+	}
+
+	memory, _ = newBlock("memory", xio.BlockSourceMemory, lastWrite)
+	disk, _ = newBlock("disk", xio.BlockSourceDisk, time.Time{})
+	result, err = convert.ToSegmentsWithOptions([]xio.BlockReader{memory, disk},
+		convert.ToSegmentsOptions{BlockMetadata: true})
+	require.NoError(t, err)
+	require.Len(t, result.Segments.Unmerged, 2)
+
+	first := result.Segments.Unmerged[0]
+	assert.Equal(t, int64(digest.SegmentChecksum(memorySegment)), first.GetChecksum())
+	assert.Equal(t, lastWrite.UnixNano(), first.GetLastWriteTime())
+	assert.Equal(t, rpc.BlockSource_MEMORY, first.GetSource())
+
+	second := result.Segments.Unmerged[1]
+	assert.Equal(t, int64(digest.SegmentChecksum(diskSegment)), second.GetChecksum())
+	assert.False(t, second.IsSetLastWriteTime())
+	assert.Equal(t, rpc.BlockSource_DISK, second.GetSource())
+
+	disk, _ = newBlock("disk", xio.BlockSourceDisk, time.Time{})
+	result, err = convert.ToSegmentsWithOptions([]xio.BlockReader{disk},
+		convert.ToSegmentsOptions{BlockMetadata: true})
+	require.NoError(t, err)
+	require.NotNil(t, result.Segments.Merged)
+	assert.Equal(t, *result.Checksum, result.Segments.Merged.GetChecksum())
+	assert.Equal(t, rpc.BlockSource_DISK, result.Segments.Merged.GetSource())
+}
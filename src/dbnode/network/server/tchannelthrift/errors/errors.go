@@ -24,15 +24,59 @@ import (
 	"fmt"
 
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	xerrors "github.com/m3db/m3/src/x/errors"
 )
 
 func newError(errType rpc.ErrorType, err error) *rpc.Error {
 	rpcErr := rpc.NewError()
 	rpcErr.Type = errType
 	rpcErr.Message = fmt.Sprintf("%v", err)
+	rpcErr.Flags = int64(errorFlags(errType, err))
 	return rpcErr
 }
 
+func errorFlags(errType rpc.ErrorType, err error) rpc.ErrorFlags {
+	if errType == rpc.ErrorType_BAD_REQUEST {
+		// Bad requests will never succeed if retried.
+		return rpc.ErrorFlags_NONE
+	}
+	switch {
+	case xerrors.IsOverCapacityError(err):
+		return rpc.ErrorFlags_OVER_CAPACITY | rpc.ErrorFlags_RESOURCE_EXHAUSTED |
+			rpc.ErrorFlags_RETRYABLE
+	case xerrors.IsResourceExhaustedError(err):
+		return rpc.ErrorFlags_RESOURCE_EXHAUSTED | rpc.ErrorFlags_RETRYABLE
+	case xerrors.IsUnavailableError(err):
+		return rpc.ErrorFlags_UNAVAILABLE | rpc.ErrorFlags_RETRYABLE
+	case xerrors.IsStaleTopologyError(err):
+		return rpc.ErrorFlags_STALE_TOPOLOGY | rpc.ErrorFlags_RETRYABLE
+	case xerrors.IsNonRetryableError(err):
+		return rpc.ErrorFlags_NONE
+	case xerrors.IsRetryableError(err):
+		return rpc.ErrorFlags_RETRYABLE
+	}
+	return rpc.ErrorFlags_NONE
+}
+
+// writeBatchRawErrorType classifies why an element of a write batch failed
+// so that callers can decide which of the failed elements to retry.
+func writeBatchRawErrorType(err error) rpc.WriteBatchRawErrorType {
+	switch {
+	case xerrors.IsInvalidParams(err):
+		return rpc.WriteBatchRawErrorType_BAD_REQUEST
+	case dberrors.IsIndexError(err):
+		return rpc.WriteBatchRawErrorType_INDEX
+	case xerrors.IsResourceExhaustedError(err):
+		return rpc.WriteBatchRawErrorType_QUOTA
+	}
+	return rpc.WriteBatchRawErrorType_STORAGE
+}
+
+func hasErrorFlag(err *rpc.Error, flag rpc.ErrorFlags) bool {
+	return err != nil && rpc.ErrorFlags(err.Flags)&flag != 0
+}
+
 // IsInternalError returns whether the error is an internal error
 func IsInternalError(err *rpc.Error) bool {
 	return err != nil && err.Type == rpc.ErrorType_INTERNAL_ERROR
@@ -43,6 +87,36 @@ func IsBadRequestError(err *rpc.Error) bool {
 	return err != nil && err.Type == rpc.ErrorType_BAD_REQUEST
 }
 
+// IsResourceExhaustedErrorFlag returns whether the error is flagged as
+// the result of a resource being exhausted, i.e. the server is overloaded
+// or a limit has been exceeded
+func IsResourceExhaustedErrorFlag(err *rpc.Error) bool {
+	return hasErrorFlag(err, rpc.ErrorFlags_RESOURCE_EXHAUSTED)
+}
+
+// IsUnavailableErrorFlag returns whether the error is flagged as the result
+// of the server being unavailable to serve the request
+func IsUnavailableErrorFlag(err *rpc.Error) bool {
+	return hasErrorFlag(err, rpc.ErrorFlags_UNAVAILABLE)
+}
+
+// IsStaleTopologyErrorFlag returns whether the error is flagged as the
+// result of the request being routed with a stale topology
+func IsStaleTopologyErrorFlag(err *rpc.Error) bool {
+	return hasErrorFlag(err, rpc.ErrorFlags_STALE_TOPOLOGY)
+}
+
+// IsOverCapacityErrorFlag returns whether the error is flagged as the
+// result of the server rejecting work while it is over capacity
+func IsOverCapacityErrorFlag(err *rpc.Error) bool {
+	return hasErrorFlag(err, rpc.ErrorFlags_OVER_CAPACITY)
+}
+
+// IsRetryableErrorFlag returns whether the error is flagged as retryable
+func IsRetryableErrorFlag(err *rpc.Error) bool {
+	return hasErrorFlag(err, rpc.ErrorFlags_RETRYABLE)
+}
+
 // NewInternalError creates a new internal error
 func NewInternalError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_INTERNAL_ERROR, err)
@@ -53,11 +127,40 @@ func NewBadRequestError(err error) *rpc.Error {
 	return newError(rpc.ErrorType_BAD_REQUEST, err)
 }
 
+// NewResourceExhaustedError creates a new retryable internal error flagged
+// as the result of a resource being exhausted
+func NewResourceExhaustedError(err error) *rpc.Error {
+	return newError(rpc.ErrorType_INTERNAL_ERROR,
+		xerrors.NewResourceExhaustedError(err))
+}
+
+// NewUnavailableError creates a new retryable internal error flagged
+// as the result of the server being unavailable
+func NewUnavailableError(err error) *rpc.Error {
+	return newError(rpc.ErrorType_INTERNAL_ERROR,
+		xerrors.NewUnavailableError(err))
+}
+
+// NewStaleTopologyError creates a new retryable internal error flagged
+// as the result of the request being routed with a stale topology
+func NewStaleTopologyError(err error) *rpc.Error {
+	return newError(rpc.ErrorType_INTERNAL_ERROR,
+		xerrors.NewStaleTopologyError(err))
+}
+
+// NewOverCapacityError creates a new retryable internal error flagged
+// as the result of the server rejecting work while it is over capacity
+func NewOverCapacityError(err error) *rpc.Error {
+	return newError(rpc.ErrorType_INTERNAL_ERROR,
+		xerrors.NewOverCapacityError(err))
+}
+
 // NewWriteBatchRawError creates a new write batch error
 func NewWriteBatchRawError(index int, err error) *rpc.WriteBatchRawError {
 	batchErr := rpc.NewWriteBatchRawError()
 	batchErr.Index = int64(index)
 	batchErr.Err = NewInternalError(err)
+	batchErr.ErrType = rpc.WriteBatchRawErrorTypePtr(writeBatchRawErrorType(err))
 	return batchErr
 }
 
@@ -66,5 +169,6 @@ func NewBadRequestWriteBatchRawError(index int, err error) *rpc.WriteBatchRawErr
 	batchErr := rpc.NewWriteBatchRawError()
 	batchErr.Index = int64(index)
 	batchErr.Err = NewBadRequestError(err)
+	batchErr.ErrType = rpc.WriteBatchRawErrorTypePtr(rpc.WriteBatchRawErrorType_BAD_REQUEST)
 	return batchErr
 }
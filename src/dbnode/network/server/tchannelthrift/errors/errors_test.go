@@ -0,0 +1,152 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package errors
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorFlags(t *testing.T) {
+	inner := errors.New("an error")
+	tests := []struct {
+		name              string
+		err               *rpc.Error
+		badRequest        bool
+		resourceExhausted bool
+		unavailable       bool
+		staleTopology     bool
+		overCapacity      bool
+		retryable         bool
+	}{
+		{
+			name: "internal",
+			err:  NewInternalError(inner),
+		},
+		{
+			name:       "bad request",
+			err:        NewBadRequestError(xerrors.NewRetryableError(inner)),
+			badRequest: true,
+		},
+		{
+			name:      "retryable",
+			err:       NewInternalError(xerrors.NewRetryableError(inner)),
+			retryable: true,
+		},
+		{
+			name: "non-retryable",
+			err: NewInternalError(xerrors.NewNonRetryableError(
+				xerrors.NewRetryableError(inner))),
+		},
+		{
+			name:              "resource exhausted",
+			err:               NewResourceExhaustedError(inner),
+			resourceExhausted: true,
+			retryable:         true,
+		},
+		{
+			name:              "wrapped resource exhausted",
+			err:               NewInternalError(xerrors.NewResourceExhaustedError(inner)),
+			resourceExhausted: true,
+			retryable:         true,
+		},
+		{
+			name:        "unavailable",
+			err:         NewUnavailableError(inner),
+			unavailable: true,
+			retryable:   true,
+		},
+		{
+			name:          "stale topology",
+			err:           NewStaleTopologyError(inner),
+			staleTopology: true,
+			retryable:     true,
+		},
+		{
+			name:              "over capacity",
+			err:               NewOverCapacityError(inner),
+			resourceExhausted: true,
+			overCapacity:      true,
+			retryable:         true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, inner.Error(), tt.err.Message)
+			assert.Equal(t, tt.badRequest, IsBadRequestError(tt.err))
+			assert.Equal(t, !tt.badRequest, IsInternalError(tt.err))
+			assert.Equal(t, tt.resourceExhausted, IsResourceExhaustedErrorFlag(tt.err))
+			assert.Equal(t, tt.unavailable, IsUnavailableErrorFlag(tt.err))
+			assert.Equal(t, tt.staleTopology, IsStaleTopologyErrorFlag(tt.err))
+			assert.Equal(t, tt.overCapacity, IsOverCapacityErrorFlag(tt.err))
+			assert.Equal(t, tt.retryable, IsRetryableErrorFlag(tt.err))
+		})
+	}
+}
+
+func TestWriteBatchRawErrorType(t *testing.T) {
+	inner := errors.New("an error")
+	tests := []struct {
+		name     string
+		err      *rpc.WriteBatchRawError
+		expected rpc.WriteBatchRawErrorType
+	}{
+		{
+			name:     "storage",
+			err:      NewWriteBatchRawError(1, inner),
+			expected: rpc.WriteBatchRawErrorType_STORAGE,
+		},
+		{
+			name:     "index",
+			err:      NewWriteBatchRawError(1, dberrors.NewIndexError(xerrors.NewResourceExhaustedError(inner))),
+			expected: rpc.WriteBatchRawErrorType_INDEX,
+		},
+		{
+			name:     "quota",
+			err:      NewWriteBatchRawError(1, dberrors.NewCardinalityThrottledError(0)),
+			expected: rpc.WriteBatchRawErrorType_QUOTA,
+		},
+		{
+			name:     "invalid params",
+			err:      NewWriteBatchRawError(1, xerrors.NewInvalidParamsError(inner)),
+			expected: rpc.WriteBatchRawErrorType_BAD_REQUEST,
+		},
+		{
+			name:     "bad request",
+			err:      NewBadRequestWriteBatchRawError(1, inner),
+			expected: rpc.WriteBatchRawErrorType_BAD_REQUEST,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, int64(1), tt.err.Index)
+			assert.True(t, tt.err.IsSetErrType())
+			assert.Equal(t, tt.expected, tt.err.GetErrType())
+		})
+	}
+}
@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+
+	apachethrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+// backpressureServer sets the backpressure of the node as response headers
+// of every request so that clients can back off before requests are shed.
+type backpressureServer struct {
+	thrift.TChanServer
+
+	service Service
+}
+
+func newBackpressureServer(server thrift.TChanServer, service Service) thrift.TChanServer {
+	return &backpressureServer{TChanServer: server, service: service}
+}
+
+func (s *backpressureServer) Handle(
+	ctx thrift.Context,
+	methodName string,
+	protocol apachethrift.TProtocol,
+) (bool, apachethrift.TStruct, error) {
+	success, resp, err := s.TChanServer.Handle(ctx, methodName, protocol)
+	tchannelthrift.SetBackpressureHeaders(ctx, s.service.Backpressure())
+	return success, resp, err
+}
@@ -0,0 +1,120 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/dbnode/storage"
+
+	apachethrift "github.com/apache/thrift/lib/go/thrift"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+	"github.com/uber/tchannel-go/thrift"
+)
+
+type testTChanServer struct {
+	thrift.TChanServer
+
+	handled []string
+}
+
+func (s *testTChanServer) Handle(
+	ctx thrift.Context,
+	methodName string,
+	protocol apachethrift.TProtocol,
+) (bool, apachethrift.TStruct, error) {
+	s.handled = append(s.handled, methodName)
+	return true, nil, nil
+}
+
+func TestServiceBackpressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	opts := testTChannelThriftOptions.
+		SetMaxOutstandingWriteRequests(10).
+		SetBackpressureRetryAfter(2 * time.Second)
+	service := NewService(mockDB, opts).(*service)
+
+	mockDB.EXPECT().IsOverloaded().Return(false).Times(3)
+	require.Equal(t, tchannelthrift.Backpressure{}, service.Backpressure())
+
+	service.state.numOutstandingWriteRPCs = 8
+	require.Equal(t, tchannelthrift.Backpressure{
+		Level: tchannelthrift.PressureLevelElevated,
+	}, service.Backpressure())
+
+	service.state.numOutstandingWriteRPCs = 10
+	require.Equal(t, tchannelthrift.Backpressure{
+		Level:      tchannelthrift.PressureLevelShedding,
+		RetryAfter: 2 * time.Second,
+	}, service.Backpressure())
+
+	service.state.numOutstandingWriteRPCs = 0
+	mockDB.EXPECT().IsOverloaded().Return(true)
+	require.Equal(t, tchannelthrift.Backpressure{
+		Level:      tchannelthrift.PressureLevelShedding,
+		RetryAfter: 2 * time.Second,
+	}, service.Backpressure())
+}
+
+func TestBackpressureServerSetsResponseHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	opts := testTChannelThriftOptions.SetBackpressureRetryAfter(1500 * time.Millisecond)
+	service := NewService(mockDB, opts).(*service)
+	inner := &testTChanServer{}
+	server := newBackpressureServer(inner, service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	mockDB.EXPECT().IsOverloaded().Return(false)
+	success, _, err := server.Handle(tctx, "write", nil)
+	require.NoError(t, err)
+	require.True(t, success)
+	require.Equal(t, []string{"write"}, inner.handled)
+	require.Empty(t, tctx.ResponseHeaders())
+
+	tctx, _ = tchannelthrift.NewContext(time.Minute)
+	mockDB.EXPECT().IsOverloaded().Return(true)
+	_, _, err = server.Handle(tctx, "write", nil)
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{
+		tchannelthrift.PressureLevelHeader: "shedding",
+		tchannelthrift.RetryAfterHeader:    "1500",
+	}, tctx.ResponseHeaders())
+
+	bp, ok := tchannelthrift.BackpressureFromHeaders(tctx.ResponseHeaders())
+	require.True(t, ok)
+	require.Equal(t, tchannelthrift.Backpressure{
+		Level:      tchannelthrift.PressureLevelShedding,
+		RetryAfter: 1500 * time.Millisecond,
+	}, bp)
+}
@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	"github.com/m3db/m3/src/dbnode/retention"
+)
+
+// datapointGap is a run of blocks of a fetch range that hold no datapoints.
+type datapointGap struct {
+	start  time.Time
+	end    time.Time
+	reason rpc.GapReason
+}
+
+func (g datapointGap) marker(timeType rpc.TimeType) (*rpc.Datapoint, error) {
+	unit, err := convert.ToDuration(timeType)
+	if err != nil {
+		return nil, err
+	}
+	timestamp, err := convert.ToValue(g.start, timeType)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		duration = int64(g.end.Sub(g.start) / unit)
+		reason   = g.reason
+	)
+	marker := rpc.NewDatapoint()
+	marker.Timestamp = timestamp
+	marker.GapDuration = &duration
+	marker.GapReason = &reason
+	return marker, nil
+}
+
+// markDatapointGaps inserts a gap marker into the datapoints, which must be
+// sorted by timestamp, for each run of blocks in the range [start, end) that
+// holds no datapoints. A marker is a zero valued datapoint timestamped at the
+// start of the gap with the duration of the gap in units of the time type,
+// its reason tells gaps where no data was written apart from gaps where the
+// data has expired from the retention of the namespace.
+func markDatapointGaps(
+	datapoints []*rpc.Datapoint,
+	start, end time.Time,
+	ropts retention.Options,
+	now time.Time,
+	timeType rpc.TimeType,
+) ([]*rpc.Datapoint, error) {
+	var (
+		blockSize      = ropts.BlockSize()
+		retentionStart = retention.FlushTimeStart(ropts, now)
+		result         = make([]*rpc.Datapoint, 0, len(datapoints))
+		gap            datapointGap
+		inGap          bool
+		idx            int
+	)
+	appendGap := func() error {
+		if !inGap {
+			return nil
+		}
+		inGap = false
+		marker, err := gap.marker(timeType)
+		if err != nil {
+			return err
+		}
+		result = append(result, marker)
+		return nil
+	}
+	for blockStart := start.Truncate(blockSize); blockStart.Before(end); blockStart = blockStart.Add(blockSize) {
+		blockEnd := blockStart.Add(blockSize)
+		n := 0
+		for ; idx+n < len(datapoints); n++ {
+			t, err := convert.ToTime(datapoints[idx+n].Timestamp, timeType)
+			if err != nil {
+				return nil, err
+			}
+			if !t.Before(blockEnd) {
+				break
+			}
+		}
+		if n > 0 {
+			if err := appendGap(); err != nil {
+				return nil, err
+			}
+			result = append(result, datapoints[idx:idx+n]...)
+			idx += n
+			continue
+		}
+
+		reason := rpc.GapReason_NO_DATA
+		if blockStart.Before(retentionStart) {
+			reason = rpc.GapReason_EXPIRED
+		}
+		gapStart, gapEnd := blockStart, blockEnd
+		if gapStart.Before(start) {
+			gapStart = start
+		}
+		if gapEnd.After(end) {
+			gapEnd = end
+		}
+		if inGap && gap.reason == reason {
+			// Coalesce consecutive empty blocks into a single marker.
+			gap.end = gapEnd
+			continue
+		}
+		if err := appendGap(); err != nil {
+			return nil, err
+		}
+		gap = datapointGap{start: gapStart, end: gapEnd, reason: reason}
+		inGap = true
+	}
+	if err := appendGap(); err != nil {
+		return nil, err
+	}
+	return append(result, datapoints[idx:]...), nil
+}
@@ -0,0 +1,87 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/retention"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMarkDatapointGaps(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		ropts     = retention.NewOptions().
+				SetBlockSize(blockSize).
+				SetRetentionPeriod(8 * time.Hour)
+		now   = time.Unix(0, 0).Add(100 * blockSize)
+		start = now.Add(-6 * blockSize)
+		end   = now
+	)
+
+	datapoints := []*rpc.Datapoint{
+		{Timestamp: now.Add(-2*blockSize + time.Minute).Unix(), Value: 1},
+		{Timestamp: now.Add(-2*blockSize + 2*time.Minute).Unix(), Value: 2},
+	}
+	result, err := markDatapointGaps(datapoints, start, end, ropts, now,
+		rpc.TimeType_UNIX_SECONDS)
+	require.NoError(t, err)
+	require.Equal(t, 5, len(result))
+
+	requireGap := func(dp *rpc.Datapoint, start time.Time, duration time.Duration, reason rpc.GapReason) {
+		require.Equal(t, start.Unix(), dp.Timestamp)
+		require.True(t, dp.IsSetGapDuration())
+		require.Equal(t, int64(duration/time.Second), dp.GetGapDuration())
+		require.Equal(t, reason, dp.GetGapReason())
+	}
+
+	// Blocks before the retention period have expired.
+	requireGap(result[0], start, 2*blockSize, rpc.GapReason_EXPIRED)
+	// Blocks within the retention period had no data written.
+	requireGap(result[1], now.Add(-4*blockSize), 2*blockSize, rpc.GapReason_NO_DATA)
+	require.Equal(t, datapoints[0], result[2])
+	require.Equal(t, datapoints[1], result[3])
+	requireGap(result[4], now.Add(-blockSize), blockSize, rpc.GapReason_NO_DATA)
+}
+
+func TestMarkDatapointGapsPartialBlocks(t *testing.T) {
+	var (
+		blockSize = 2 * time.Hour
+		ropts     = retention.NewOptions().
+				SetBlockSize(blockSize).
+				SetRetentionPeriod(48 * time.Hour)
+		now   = time.Unix(0, 0).Add(100 * blockSize)
+		start = now.Add(-blockSize - time.Hour)
+		end   = now.Add(-30 * time.Minute)
+	)
+
+	result, err := markDatapointGaps(nil, start, end, ropts, now,
+		rpc.TimeType_UNIX_MILLISECONDS)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(result))
+	require.Equal(t, start.UnixNano()/int64(time.Millisecond), result[0].Timestamp)
+	require.Equal(t, int64(end.Sub(start)/time.Millisecond), result[0].GetGapDuration())
+	require.Equal(t, rpc.GapReason_NO_DATA, result[0].GetGapReason())
+}
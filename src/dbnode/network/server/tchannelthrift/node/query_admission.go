@@ -0,0 +1,207 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/uber-go/tally"
+)
+
+const defaultQueryAdmissionSampleInterval = time.Second
+
+// errQueryOverCapacity is raised when a large query is rejected while the
+// node is under garbage collection pressure.
+var errQueryOverCapacity = xerrors.NewOverCapacityError(
+	errors.New("query rejected, node is over capacity due to memory pressure"))
+
+// queryAdmission rejects large queries while recent samples of the runtime
+// memory statistics show the node is under garbage collection pressure, so
+// that a node in a garbage collection death spiral keeps serving writes and
+// small queries rather than falling over. A nil queryAdmission admits every
+// query.
+//
+// Samples are taken on a background loop rather than when queries arrive
+// since reading the runtime memory statistics briefly stops the world, and
+// queries only read the result of the latest sample.
+type queryAdmission struct {
+	opts         tchannelthrift.QueryAdmissionOptions
+	nowFn        clock.NowFn
+	readMemStats func(*runtime.MemStats)
+
+	// underPressure is accessed atomically by queries, the remaining sample
+	// state is only accessed by the sample loop.
+	underPressure int32
+
+	memStats         runtime.MemStats
+	lastSampleAt     time.Time
+	lastPauseTotalNs uint64
+	lastHeapInuse    uint64
+
+	closeOnce sync.Once
+	closedCh  chan struct{}
+
+	metrics queryAdmissionMetrics
+}
+
+type queryAdmissionMetrics struct {
+	rejected        tally.Counter
+	underPressure   tally.Gauge
+	gcPauseFraction tally.Gauge
+	heapGrowth      tally.Gauge
+}
+
+func newQueryAdmissionMetrics(scope tally.Scope) queryAdmissionMetrics {
+	scope = scope.SubScope("query-admission")
+	return queryAdmissionMetrics{
+		rejected:        scope.Counter("rejected"),
+		underPressure:   scope.Gauge("under-pressure"),
+		gcPauseFraction: scope.Gauge("gc-pause-fraction"),
+		heapGrowth:      scope.Gauge("heap-growth"),
+	}
+}
+
+func newQueryAdmission(
+	opts tchannelthrift.QueryAdmissionOptions,
+	nowFn clock.NowFn,
+	scope tally.Scope,
+) *queryAdmission {
+	if !opts.Enabled() {
+		return nil
+	}
+	if opts.SampleInterval <= 0 {
+		opts.SampleInterval = defaultQueryAdmissionSampleInterval
+	}
+	return &queryAdmission{
+		opts:         opts,
+		nowFn:        nowFn,
+		readMemStats: runtime.ReadMemStats,
+		closedCh:     make(chan struct{}),
+		metrics:      newQueryAdmissionMetrics(scope),
+	}
+}
+
+// start takes an initial sample and starts the sample loop, which runs until
+// close is called.
+func (a *queryAdmission) start() {
+	if a == nil {
+		return
+	}
+	a.sample()
+	go a.sampleLoop()
+}
+
+func (a *queryAdmission) close() {
+	if a == nil {
+		return
+	}
+	a.closeOnce.Do(func() {
+		close(a.closedCh)
+	})
+}
+
+func (a *queryAdmission) sampleLoop() {
+	t := time.NewTicker(a.opts.SampleInterval)
+	for {
+		select {
+		case <-t.C:
+			a.sample()
+		case <-a.closedCh:
+			t.Stop()
+			return
+		}
+	}
+}
+
+// admit returns an over capacity error if the query is large and the node
+// was under garbage collection pressure as of the latest sample.
+func (a *queryAdmission) admit(opts index.QueryOptions) error {
+	if a == nil || a.isSmall(opts) {
+		return nil
+	}
+	if atomic.LoadInt32(&a.underPressure) == 0 {
+		return nil
+	}
+	a.metrics.rejected.Inc(1)
+	return errQueryOverCapacity
+}
+
+func (a *queryAdmission) isSmall(opts index.QueryOptions) bool {
+	if opts.Limit <= 0 || opts.Limit > a.opts.SmallQueryMaxLimit {
+		return false
+	}
+	maxRange := a.opts.SmallQueryMaxRange
+	return maxRange <= 0 || opts.EndExclusive.Sub(opts.StartInclusive) <= maxRange
+}
+
+// sample reads the runtime memory statistics and records whether the node is
+// under pressure compared to the previous sample. It must only be called by
+// one goroutine at a time.
+func (a *queryAdmission) sample() {
+	now := a.nowFn()
+	a.readMemStats(&a.memStats)
+	var (
+		pauseTotalNs  = a.memStats.PauseTotalNs
+		heapInuse     = a.memStats.HeapInuse
+		underPressure = false
+	)
+	if !a.lastSampleAt.IsZero() {
+		var (
+			elapsed         = now.Sub(a.lastSampleAt)
+			gcPauseFraction float64
+			heapGrowth      float64
+		)
+		if elapsed > 0 {
+			gcPauseFraction = float64(pauseTotalNs-a.lastPauseTotalNs) / float64(elapsed)
+		}
+		if a.lastHeapInuse > 0 {
+			heapGrowth = float64(heapInuse) / float64(a.lastHeapInuse)
+		}
+		if threshold := a.opts.GCPauseFraction; threshold > 0 && gcPauseFraction >= threshold {
+			underPressure = true
+		}
+		if threshold := a.opts.HeapGrowthFactor; threshold > 0 && heapGrowth >= threshold {
+			underPressure = true
+		}
+		a.metrics.gcPauseFraction.Update(gcPauseFraction)
+		a.metrics.heapGrowth.Update(heapGrowth)
+	}
+
+	a.lastSampleAt = now
+	a.lastPauseTotalNs = pauseTotalNs
+	a.lastHeapInuse = heapInuse
+	if underPressure {
+		atomic.StoreInt32(&a.underPressure, 1)
+		a.metrics.underPressure.Update(1)
+	} else {
+		atomic.StoreInt32(&a.underPressure, 0)
+		a.metrics.underPressure.Update(0)
+	}
+}
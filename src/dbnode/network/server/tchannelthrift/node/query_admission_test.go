@@ -0,0 +1,161 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
+	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	xclock "github.com/m3db/m3/src/x/clock"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestQueryAdmission(
+	opts tchannelthrift.QueryAdmissionOptions,
+	now *time.Time,
+	stats *runtime.MemStats,
+) *queryAdmission {
+	a := newQueryAdmission(opts, func() time.Time { return *now },
+		tally.NoopScope)
+	a.readMemStats = func(m *runtime.MemStats) { *m = *stats }
+	return a
+}
+
+func TestQueryAdmissionDisabled(t *testing.T) {
+	a := newQueryAdmission(tchannelthrift.QueryAdmissionOptions{},
+		time.Now, tally.NoopScope)
+	require.Nil(t, a)
+	require.NoError(t, a.admit(index.QueryOptions{}))
+}
+
+func TestQueryAdmissionRejectsLargeQueriesUnderGCPressure(t *testing.T) {
+	var (
+		now   = time.Now()
+		stats = &runtime.MemStats{HeapInuse: 1000}
+		a     = newTestQueryAdmission(tchannelthrift.QueryAdmissionOptions{
+			GCPauseFraction:    0.5,
+			SampleInterval:     time.Second,
+			SmallQueryMaxLimit: 10,
+			SmallQueryMaxRange: time.Hour,
+		}, &now, stats)
+		large = index.QueryOptions{
+			StartInclusive: now.Add(-time.Hour),
+			EndExclusive:   now,
+		}
+		small = index.QueryOptions{
+			StartInclusive: now.Add(-time.Hour),
+			EndExclusive:   now,
+			Limit:          10,
+		}
+	)
+
+	// The first sample has nothing to compare against.
+	a.sample()
+	require.NoError(t, a.admit(large))
+
+	// Spend most of the interval paused for garbage collection.
+	now = now.Add(time.Second)
+	stats.PauseTotalNs += uint64(600 * time.Millisecond)
+	a.sample()
+	err := a.admit(large)
+	require.Error(t, err)
+	rpcErr := convert.ToRPCError(err)
+	require.True(t, tterrors.IsOverCapacityErrorFlag(rpcErr))
+	require.True(t, tterrors.IsResourceExhaustedErrorFlag(rpcErr))
+
+	// Small queries are still admitted.
+	require.NoError(t, a.admit(small))
+
+	// A query over a long range is not small regardless of its limit.
+	small.StartInclusive = now.Add(-2 * time.Hour)
+	require.Error(t, a.admit(small))
+
+	// Pressure is retained until the next sample.
+	stats.PauseTotalNs += uint64(10 * time.Millisecond)
+	require.Error(t, a.admit(large))
+
+	// Recovers once pauses subside.
+	now = now.Add(time.Second)
+	a.sample()
+	require.NoError(t, a.admit(large))
+}
+
+func TestQueryAdmissionRejectsLargeQueriesUnderHeapGrowth(t *testing.T) {
+	var (
+		now   = time.Now()
+		stats = &runtime.MemStats{HeapInuse: 1000}
+		a     = newTestQueryAdmission(tchannelthrift.QueryAdmissionOptions{
+			HeapGrowthFactor: 1.5,
+		}, &now, stats)
+		large = index.QueryOptions{Limit: 100}
+	)
+
+	a.sample()
+	require.NoError(t, a.admit(large))
+
+	now = now.Add(defaultQueryAdmissionSampleInterval)
+	stats.HeapInuse = 1200
+	a.sample()
+	require.NoError(t, a.admit(large))
+
+	now = now.Add(defaultQueryAdmissionSampleInterval)
+	stats.HeapInuse = 2000
+	a.sample()
+	require.Error(t, a.admit(large))
+}
+
+func TestQueryAdmissionSamplesInBackground(t *testing.T) {
+	var (
+		lock      sync.Mutex
+		heapInuse uint64 = 1000
+	)
+	a := newQueryAdmission(tchannelthrift.QueryAdmissionOptions{
+		HeapGrowthFactor: 1.5,
+		SampleInterval:   time.Millisecond,
+	}, time.Now, tally.NoopScope)
+	a.readMemStats = func(m *runtime.MemStats) {
+		lock.Lock()
+		m.HeapInuse = heapInuse
+		lock.Unlock()
+	}
+	a.start()
+	defer a.close()
+
+	large := index.QueryOptions{Limit: 100}
+	require.NoError(t, a.admit(large))
+
+	// The heap doubling is picked up without queries taking samples.
+	lock.Lock()
+	heapInuse = 2000
+	lock.Unlock()
+	rejected := xclock.WaitUntil(func() bool {
+		return a.admit(large) != nil
+	}, 5*time.Second)
+	require.True(t, rejected)
+}
@@ -0,0 +1,92 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/uber-go/tally"
+)
+
+// readInterceptorChain runs the read interceptors configured for the service
+// against every series read before it is serialized.
+type readInterceptorChain struct {
+	nowFn        clock.NowFn
+	interceptors []readInterceptor
+}
+
+type readInterceptor struct {
+	tchannelthrift.ReadInterceptor
+
+	latency  tally.Timer
+	filtered tally.Counter
+	errors   tally.Counter
+}
+
+func newReadInterceptorChain(
+	interceptors []tchannelthrift.ReadInterceptor,
+	nowFn clock.NowFn,
+	scope tally.Scope,
+) readInterceptorChain {
+	chain := readInterceptorChain{
+		nowFn:        nowFn,
+		interceptors: make([]readInterceptor, 0, len(interceptors)),
+	}
+	for _, interceptor := range interceptors {
+		interceptorScope := scope.Tagged(map[string]string{
+			"interceptor": interceptor.Name(),
+		})
+		chain.interceptors = append(chain.interceptors, readInterceptor{
+			ReadInterceptor: interceptor,
+			latency:         interceptorScope.Timer("read-interceptor.latency"),
+			filtered:        interceptorScope.Counter("read-interceptor.filtered"),
+			errors:          interceptorScope.Counter("read-interceptor.errors"),
+		})
+	}
+	return chain
+}
+
+// intercept runs the interceptors in order against a series, returning the
+// tags to serialize for the series and whether it should be included in the
+// results, stopping at the first interceptor that filters it out.
+func (c readInterceptorChain) intercept(
+	nsID ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+) (ident.TagIterator, bool, error) {
+	for _, interceptor := range c.interceptors {
+		start := c.nowFn()
+		result, include, err := interceptor.InterceptRead(nsID, id, tags)
+		interceptor.latency.Record(c.nowFn().Sub(start))
+		if err != nil {
+			interceptor.errors.Inc(1)
+			return nil, false, err
+		}
+		if !include {
+			interceptor.filtered.Inc(1)
+			return nil, false, nil
+		}
+		tags = result
+	}
+	return tags, true, nil
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+type testReadInterceptor struct {
+	name string
+	fn   func(id ident.ID, tags ident.TagIterator) (ident.TagIterator, bool, error)
+}
+
+func (i testReadInterceptor) Name() string {
+	return i.name
+}
+
+func (i testReadInterceptor) InterceptRead(
+	_ ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+) (ident.TagIterator, bool, error) {
+	return i.fn(id, tags)
+}
+
+func TestReadInterceptorChain(t *testing.T) {
+	var (
+		scope       = tally.NewTestScope("", nil)
+		nsID        = ident.StringID("metrics")
+		errRejected = errors.New("rejected")
+	)
+	redact := testReadInterceptor{
+		name: "redact",
+		fn: func(_ ident.ID, tags ident.TagIterator) (ident.TagIterator, bool, error) {
+			return ident.NewTagsIterator(ident.NewTags(
+				ident.StringTag("tenant", "redacted"))), true, nil
+		},
+	}
+	tenancy := testReadInterceptor{
+		name: "tenancy",
+		fn: func(id ident.ID, tags ident.TagIterator) (ident.TagIterator, bool, error) {
+			switch id.String() {
+			case "filtered":
+				return nil, false, nil
+			case "error":
+				return nil, false, errRejected
+			}
+			return tags, true, nil
+		},
+	}
+	chain := newReadInterceptorChain([]tchannelthrift.ReadInterceptor{redact, tenancy},
+		time.Now, scope)
+
+	tags, include, err := chain.intercept(nsID, ident.StringID("foo"),
+		ident.NewTagsIterator(ident.NewTags(ident.StringTag("tenant", "a"))))
+	require.NoError(t, err)
+	require.True(t, include)
+	require.True(t, tags.Next())
+	require.Equal(t, "redacted", tags.Current().Value.String())
+
+	_, include, err = chain.intercept(nsID, ident.StringID("filtered"), nil)
+	require.NoError(t, err)
+	require.False(t, include)
+
+	_, include, err = chain.intercept(nsID, ident.StringID("error"), nil)
+	require.Equal(t, errRejected, err)
+	require.False(t, include)
+
+	snapshot := scope.Snapshot()
+	require.Equal(t, int64(1),
+		snapshot.Counters()["read-interceptor.filtered+interceptor=tenancy"].Value())
+	require.Equal(t, int64(1),
+		snapshot.Counters()["read-interceptor.errors+interceptor=tenancy"].Value())
+	require.Equal(t, 3,
+		len(snapshot.Timers()["read-interceptor.latency+interceptor=redact"].Values()))
+	require.Equal(t, 3,
+		len(snapshot.Timers()["read-interceptor.latency+interceptor=tenancy"].Values()))
+}
+
+func TestReadInterceptorChainEmpty(t *testing.T) {
+	chain := newReadInterceptorChain(nil, time.Now, tally.NoopScope)
+
+	tags := ident.NewTagsIterator(ident.NewTags(ident.StringTag("foo", "bar")))
+	result, include, err := chain.intercept(ident.StringID("metrics"),
+		ident.StringID("foo"), tags)
+	require.NoError(t, err)
+	require.True(t, include)
+	require.Equal(t, tags, result)
+}
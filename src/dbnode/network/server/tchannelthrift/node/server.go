@@ -21,7 +21,10 @@
 package node
 
 import (
+	"net"
+
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	ns "github.com/m3db/m3/src/dbnode/network/server"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/node/channel"
@@ -63,9 +66,21 @@ func (s *server) ListenAndServe() (ns.Close, error) {
 		return nil, err
 	}
 
-	tchannelthrift.RegisterServer(channel, rpc.NewTChanNodeServer(s.service), s.contextPool)
+	listener, err := net.Listen("tcp", s.address)
+	if err != nil {
+		channel.Close()
+		return nil, err
+	}
 
-	channel.ListenAndServe(s.address)
+	peerStats := s.service.PeerStats()
+	server := newBackpressureServer(rpc.NewTChanNodeServer(s.service), s.service)
+	server = peerstats.NewServer(server, peerStats)
+	tchannelthrift.RegisterServer(channel, server, s.contextPool)
+
+	if err := channel.Serve(peerstats.NewListener(listener, peerStats)); err != nil {
+		channel.Close()
+		return nil, err
+	}
 
 	return channel.Close, nil
 }
@@ -21,6 +21,7 @@
 package node
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"sort"
@@ -30,17 +31,21 @@ import (
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/convert"
 	tterrors "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/errors"
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/compress"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
@@ -63,6 +68,10 @@ var (
 	// NB(r): pool sizes are vars to help reduce stress on tests.
 	segmentArrayPoolSize        = 65536
 	writeBatchPooledReqPoolSize = 1024
+
+	// fetchBlocksRawCompressionTypes are the compression types a peer can
+	// negotiate for block segments streamed with FetchBlocksRaw.
+	fetchBlocksRawCompressionTypes = []compress.Type{compress.LZ4, compress.Zstd}
 )
 
 const (
@@ -70,11 +79,15 @@ const (
 	maxSegmentArrayPooledLength = 32
 	// Any pooled error slices that grow beyond this capcity will be thrown away.
 	writeBatchPooledReqPoolMaxErrorsSliceSize = 4096
+	// The fraction of the outstanding RPC limits above which the node
+	// signals elevated pressure to clients.
+	elevatedPressureLimitFraction = 0.8
 )
 
 var (
 	// errServerIsOverloaded raised when trying to process a request when the server is overloaded
-	errServerIsOverloaded = errors.New("server is overloaded")
+	errServerIsOverloaded = xerrors.NewResourceExhaustedError(
+		errors.New("server is overloaded"))
 
 	// errIllegalTagValues raised when the tags specified are in-correct
 	errIllegalTagValues = errors.New("illegal tag values specified")
@@ -83,11 +96,13 @@ var (
 	errRequiresDatapoint = errors.New("requires datapoint")
 
 	// errNodeIsNotBootstrapped
-	errNodeIsNotBootstrapped = errors.New("node is not bootstrapped")
+	errNodeIsNotBootstrapped = xerrors.NewUnavailableError(
+		errors.New("node is not bootstrapped"))
 
 	// errDatabaseIsNotInitializedYet is raised when an RPC attempt is made before the database
 	// has been set.
-	errDatabaseIsNotInitializedYet = errors.New("database is not yet initialized")
+	errDatabaseIsNotInitializedYet = xerrors.NewUnavailableError(
+		errors.New("database is not yet initialized"))
 
 	// errDatabaseHasAlreadyBeenSet is raised when SetDatabase() is called more than one time.
 	errDatabaseHasAlreadyBeenSet = errors.New("database has already been set")
@@ -103,12 +118,14 @@ type serviceMetrics struct {
 	fetch                   instrument.MethodMetrics
 	fetchTagged             instrument.MethodMetrics
 	aggregate               instrument.MethodMetrics
+	aggregateMulti          instrument.MethodMetrics
 	write                   instrument.MethodMetrics
 	writeTagged             instrument.MethodMetrics
 	fetchBlocks             instrument.MethodMetrics
 	fetchBlocksMetadata     instrument.MethodMetrics
 	repair                  instrument.MethodMetrics
 	truncate                instrument.MethodMetrics
+	shardWatermarks         instrument.MethodMetrics
 	fetchBatchRawRPCS       tally.Counter
 	fetchBatchRaw           instrument.BatchMethodMetrics
 	writeBatchRawRPCs       tally.Counter
@@ -116,6 +133,8 @@ type serviceMetrics struct {
 	writeTaggedBatchRawRPCs tally.Counter
 	writeTaggedBatchRaw     instrument.BatchMethodMetrics
 	overloadRejected        tally.Counter
+	writeIdempotentRetries  tally.Counter
+	writeStaleTopology      tally.Counter
 }
 
 func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
@@ -123,12 +142,14 @@ func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
 		fetch:                   instrument.NewMethodMetrics(scope, "fetch", samplingRate),
 		fetchTagged:             instrument.NewMethodMetrics(scope, "fetchTagged", samplingRate),
 		aggregate:               instrument.NewMethodMetrics(scope, "aggregate", samplingRate),
+		aggregateMulti:          instrument.NewMethodMetrics(scope, "aggregateMulti", samplingRate),
 		write:                   instrument.NewMethodMetrics(scope, "write", samplingRate),
 		writeTagged:             instrument.NewMethodMetrics(scope, "writeTagged", samplingRate),
 		fetchBlocks:             instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
 		fetchBlocksMetadata:     instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
 		repair:                  instrument.NewMethodMetrics(scope, "repair", samplingRate),
 		truncate:                instrument.NewMethodMetrics(scope, "truncate", samplingRate),
+		shardWatermarks:         instrument.NewMethodMetrics(scope, "shardWatermarks", samplingRate),
 		fetchBatchRawRPCS:       scope.Counter("fetchBatchRaw-rpcs"),
 		fetchBatchRaw:           instrument.NewBatchMethodMetrics(scope, "fetchBatchRaw", samplingRate),
 		writeBatchRawRPCs:       scope.Counter("writeBatchRaw-rpcs"),
@@ -136,6 +157,8 @@ func newServiceMetrics(scope tally.Scope, samplingRate float64) serviceMetrics {
 		writeTaggedBatchRawRPCs: scope.Counter("writeTaggedBatchRaw-rpcs"),
 		writeTaggedBatchRaw:     instrument.NewBatchMethodMetrics(scope, "writeTaggedBatchRaw", samplingRate),
 		overloadRejected:        scope.Counter("overload-rejected"),
+		writeIdempotentRetries:  scope.Counter("write-idempotent-retries"),
+		writeStaleTopology:      scope.Counter("write-stale-topology"),
 	}
 }
 
@@ -145,10 +168,13 @@ type service struct {
 
 	logger *zap.Logger
 
-	opts    tchannelthrift.Options
-	nowFn   clock.NowFn
-	pools   pools
-	metrics serviceMetrics
+	opts             tchannelthrift.Options
+	nowFn            clock.NowFn
+	pools            pools
+	metrics          serviceMetrics
+	writeIdempotency *writeIdempotencyWindow
+	readInterceptors readInterceptorChain
+	queryAdmission   *queryAdmission
 }
 
 type serviceState struct {
@@ -223,6 +249,32 @@ func (s *serviceState) DecNumOutstandingReadRPCs() {
 	s.Unlock()
 }
 
+// PressureLevel returns the pressure level of the node given how many of
+// the allowed outstanding write and read RPCs are in flight.
+func (s *serviceState) PressureLevel() tchannelthrift.PressureLevel {
+	s.RLock()
+	defer s.RUnlock()
+
+	level := tchannelthrift.PressureLevelNone
+	for _, limit := range []struct {
+		num, max int
+	}{
+		{num: s.numOutstandingWriteRPCs, max: s.maxOutstandingWriteRPCs},
+		{num: s.numOutstandingReadRPCs, max: s.maxOutstandingReadRPCs},
+	} {
+		if limit.max == 0 {
+			continue
+		}
+		if limit.num >= limit.max {
+			return tchannelthrift.PressureLevelShedding
+		}
+		if float64(limit.num) >= elevatedPressureLimitFraction*float64(limit.max) {
+			level = tchannelthrift.PressureLevelElevated
+		}
+	}
+	return level
+}
+
 type pools struct {
 	id                      ident.Pool
 	tagEncoder              serialize.TagEncoderPool
@@ -246,6 +298,12 @@ type Service interface {
 
 	// Only safe to be called one time once the service has started.
 	SetDatabase(db storage.Database) error
+
+	// Backpressure returns the current backpressure of the node.
+	Backpressure() tchannelthrift.Backpressure
+
+	// PeerStats returns the tracker of network statistics per calling peer.
+	PeerStats() peerstats.Tracker
 }
 
 // NewService creates a new node TChannel Thrift service
@@ -286,6 +344,18 @@ func NewService(db storage.Database, opts tchannelthrift.Options) Service {
 	writeBatchPooledReqPool := newWriteBatchPooledReqPool(writeBatchPoolSize, iopts)
 	writeBatchPooledReqPool.Init(opts.TagDecoderPool())
 
+	var writeIdempotency *writeIdempotencyWindow
+	if window := opts.WriteIdempotencyWindow(); window > 0 {
+		writeIdempotency = newWriteIdempotencyWindow(
+			opts.ClockOptions().NowFn(), window, opts.WriteIdempotencyMaxKeys())
+	}
+
+	// NB: the service is never closed so the query admission sample loop
+	// runs for the lifetime of the process.
+	queryAdmission := newQueryAdmission(opts.QueryAdmissionOptions(),
+		opts.ClockOptions().NowFn(), scope)
+	queryAdmission.start()
+
 	return &service{
 		state: serviceState{
 			db: db,
@@ -311,6 +381,10 @@ func NewService(db storage.Database, opts tchannelthrift.Options) Service {
 			blockMetadataV2:         opts.BlockMetadataV2Pool(),
 			blockMetadataV2Slice:    opts.BlockMetadataV2SlicePool(),
 		},
+		writeIdempotency: writeIdempotency,
+		readInterceptors: newReadInterceptorChain(opts.ReadInterceptors(),
+			opts.ClockOptions().NowFn(), scope),
+		queryAdmission: queryAdmission,
 	}
 }
 
@@ -452,21 +526,35 @@ func (s *service) query(ctx context.Context, db storage.Database, req *rpc.Query
 	if l := req.Limit; l != nil {
 		opts.Limit = int(*l)
 	}
+	if err := s.queryAdmission.admit(opts); err != nil {
+		return nil, convert.ToRPCError(err)
+	}
 	queryResult, err := db.QueryIDs(ctx, nsID, index.Query{Query: q}, opts)
 	if err != nil {
 		return nil, convert.ToRPCError(err)
 	}
 
+	entries, err := index.ResultsEntries(queryResult.Results)
+	if err != nil {
+		return nil, convert.ToRPCError(err)
+	}
+
 	result := &rpc.QueryResult_{
-		Results:    make([]*rpc.QueryResultElement, 0, queryResult.Results.Map().Len()),
+		Results:    make([]*rpc.QueryResultElement, 0, len(entries)),
 		Exhaustive: queryResult.Exhaustive,
 	}
 	fetchData := true
 	if req.NoData != nil && *req.NoData {
 		fetchData = false
 	}
-	for _, entry := range queryResult.Results.Map().Iter() {
-		tags := entry.Value()
+	for _, entry := range entries {
+		tags, include, err := s.readInterceptors.intercept(nsID, entry.Key(), entry.Value())
+		if err != nil {
+			return nil, convert.ToRPCError(err)
+		}
+		if !include {
+			continue
+		}
 		elem := &rpc.QueryResultElement{
 			ID:   entry.Key().String(),
 			Tags: make([]*rpc.Tag, 0, tags.Remaining()),
@@ -520,6 +608,16 @@ func (s *service) Fetch(tctx thrift.Context, req *rpc.FetchRequest) (*rpc.FetchR
 	tsID := s.pools.id.GetStringID(ctx, req.ID)
 	nsID := s.pools.id.GetStringID(ctx, req.NameSpace)
 
+	_, include, err := s.readInterceptors.intercept(nsID, tsID, nil)
+	if err != nil {
+		s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+	if !include {
+		s.metrics.fetch.ReportSuccess(s.nowFn().Sub(callStart))
+		return &rpc.FetchResult_{Datapoints: []*rpc.Datapoint{}}, nil
+	}
+
 	// Make datapoints an initialized empty array for JSON serialization as empty array than null
 	datapoints, err := s.readDatapoints(ctx, db, nsID, tsID, start, end,
 		req.ResultTimeType)
@@ -528,8 +626,43 @@ func (s *service) Fetch(tctx thrift.Context, req *rpc.FetchRequest) (*rpc.FetchR
 		return nil, convert.ToRPCError(err)
 	}
 
+	switch req.GetGapPolicy() {
+	case rpc.GapPolicy_OMIT:
+	case rpc.GapPolicy_MARK:
+		if ns, ok := db.Namespace(nsID); ok {
+			datapoints, err = markDatapointGaps(datapoints, start, end,
+				ns.Options().RetentionOptions(), s.nowFn(), req.ResultTimeType)
+			if err != nil {
+				s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
+				return nil, convert.ToRPCError(xerrors.NewInvalidParamsError(err))
+			}
+		}
+	default:
+		s.metrics.fetch.ReportError(s.nowFn().Sub(callStart))
+		return nil, tterrors.NewBadRequestError(
+			fmt.Errorf("unknown gap policy: %v", req.GetGapPolicy()))
+	}
+
 	s.metrics.fetch.ReportSuccess(s.nowFn().Sub(callStart))
-	return &rpc.FetchResult_{Datapoints: datapoints}, nil
+	return &rpc.FetchResult_{
+		Datapoints: datapoints,
+		Frozen:     isNamespaceRangeFrozen(db, nsID, start, end),
+	}, nil
+}
+
+// isNamespaceRangeFrozen returns whether a read range overlaps a range of
+// the namespace that is frozen, so that callers can tell the data returned
+// is being held for investigation rather than expiring naturally.
+func isNamespaceRangeFrozen(
+	db storage.Database,
+	nsID ident.ID,
+	start, end time.Time,
+) bool {
+	ns, ok := db.Namespace(nsID)
+	if !ok {
+		return false
+	}
+	return ns.IsFrozen(start, end)
 }
 
 func (s *service) readDatapoints(
@@ -617,6 +750,33 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
 		return nil, tterrors.NewBadRequestError(err)
 	}
+	if err := s.queryAdmission.admit(opts); err != nil {
+		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	var (
+		sorted  = req.GetSortedOrder() || req.IsSetPageToken()
+		afterID []byte
+		limit   = opts.Limit
+	)
+	if req.IsSetPageToken() {
+		afterID, err = convert.FromRPCFetchTaggedPageToken(req.PageToken)
+		if err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return nil, tterrors.NewBadRequestError(err)
+		}
+	}
+	if sorted {
+		// The limit is applied after sorting, a page has to hold the
+		// lowest IDs matched rather than any IDs matched.
+		opts.Limit = 0
+	}
+	if fetchData && req.GetSequentialScan() {
+		// Full series are being scanned, such as for an export, so hint
+		// to the block retriever that it should read the data files ahead.
+		xio.WithSequentialReads(ctx)
+	}
 
 	queryResult, err := db.QueryIDs(ctx, ns, query, opts)
 	if err != nil {
@@ -625,27 +785,44 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 	}
 
 	results := queryResult.Results
+	entries, err := index.ResultsEntries(results)
+	if err != nil {
+		s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	var nextPageToken []byte
+	if sorted {
+		entries, nextPageToken = sortedFetchTaggedPage(entries, afterID, limit)
+	}
+
 	response := &rpc.FetchTaggedResult_{
-		Exhaustive: queryResult.Exhaustive,
-		Elements:   make([]*rpc.FetchTaggedIDResult_, 0, results.Size()),
+		Exhaustive:    queryResult.Exhaustive && nextPageToken == nil,
+		Elements:      make([]*rpc.FetchTaggedIDResult_, 0, len(entries)),
+		NextPageToken: nextPageToken,
 	}
 	nsID := results.Namespace()
 	nsIDBytes := nsID.Bytes()
+	response.Frozen = isNamespaceRangeFrozen(db, nsID,
+		opts.StartInclusive, opts.EndExclusive)
 
 	// NB(r): Step 1 if reading data then read using an asynchronous block reader,
 	// but don't serialize yet so that all block reader requests can
 	// be issued at once before waiting for their results.
 	var encodedDataResults [][][]xio.BlockReader
 	if fetchData {
-		encodedDataResults = make([][][]xio.BlockReader, results.Size())
+		encodedDataResults = make([][][]xio.BlockReader, len(entries))
 	}
-	if err := s.fetchReadEncoded(ctx, db, response, results, nsID, nsIDBytes, callStart, opts, fetchData, encodedDataResults); err != nil {
+	if err := s.fetchReadEncoded(ctx, db, response, entries, nsID, nsIDBytes, callStart, opts, fetchData, encodedDataResults); err != nil {
 		return nil, err
 	}
 
 	// Step 2: If fetching data read the results of the asynchronuous block readers.
 	if fetchData {
-		s.fetchReadResults(ctx, response, nsID, encodedDataResults)
+		segmentsOpts := convert.ToSegmentsOptions{
+			BlockMetadata: req.GetFetchBlockMetadata(),
+		}
+		s.fetchReadResults(ctx, response, nsID, encodedDataResults, segmentsOpts)
 	}
 
 	s.metrics.fetchTagged.ReportSuccess(s.nowFn().Sub(callStart))
@@ -655,7 +832,7 @@ func (s *service) fetchTagged(ctx context.Context, db storage.Database, req *rpc
 func (s *service) fetchReadEncoded(ctx context.Context,
 	db storage.Database,
 	response *rpc.FetchTaggedResult_,
-	results index.QueryResults,
+	entries []index.ResultsMapEntry,
 	nsID ident.ID,
 	nsIDBytes []byte,
 	callStart time.Time,
@@ -672,13 +849,16 @@ func (s *service) fetchReadEncoded(ctx context.Context,
 	}
 	defer sp.Finish()
 
-	i := 0
-	for _, entry := range results.Map().Iter() {
-		idx := i
-		i++
-
+	for _, entry := range entries {
 		tsID := entry.Key()
-		tags := entry.Value()
+		tags, include, err := s.readInterceptors.intercept(nsID, tsID, entry.Value())
+		if err != nil {
+			s.metrics.fetchTagged.ReportError(s.nowFn().Sub(callStart))
+			return convert.ToRPCError(err)
+		}
+		if !include {
+			continue
+		}
 		enc := s.pools.tagEncoder.Get()
 		ctx.RegisterFinalizer(enc)
 		encodedTags, err := s.encodeTags(enc, tags)
@@ -702,16 +882,47 @@ func (s *service) fetchReadEncoded(ctx context.Context,
 		if err != nil {
 			elem.Err = convert.ToRPCError(err)
 		} else {
-			encodedDataResults[idx] = encoded
+			encodedDataResults[len(response.Elements)-1] = encoded
 		}
 	}
 	return nil
 }
 
+// sortedFetchTaggedPage sorts the entries by ID and returns the page of at
+// most limit entries with IDs after the given ID, along with the token for
+// the next page if there are entries beyond the page.
+func sortedFetchTaggedPage(
+	entries []index.ResultsMapEntry,
+	afterID []byte,
+	limit int,
+) ([]index.ResultsMapEntry, []byte) {
+	if afterID != nil {
+		filtered := entries[:0]
+		for _, entry := range entries {
+			if bytes.Compare(entry.Key().Bytes(), afterID) > 0 {
+				filtered = append(filtered, entry)
+			}
+		}
+		entries = filtered
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		return bytes.Compare(entries[i].Key().Bytes(), entries[j].Key().Bytes()) < 0
+	})
+
+	if limit <= 0 || len(entries) <= limit {
+		return entries, nil
+	}
+
+	entries = entries[:limit]
+	return entries, convert.ToRPCFetchTaggedPageToken(entries[limit-1].Key().Bytes())
+}
+
 func (s *service) fetchReadResults(ctx context.Context,
 	response *rpc.FetchTaggedResult_,
 	nsID ident.ID,
 	encodedDataResults [][][]xio.BlockReader,
+	segmentsOpts convert.ToSegmentsOptions,
 ) {
 	ctx, sp, sampled := ctx.StartSampledTraceSpan(tracepoint.FetchReadResults)
 	if sampled {
@@ -727,7 +938,7 @@ func (s *service) fetchReadResults(ctx context.Context,
 			continue
 		}
 
-		segments, rpcErr := s.readEncodedResult(ctx, nsID, encodedDataResults[idx])
+		segments, rpcErr := s.readEncodedResult(ctx, nsID, encodedDataResults[idx], segmentsOpts)
 		if rpcErr != nil {
 			elem.Err = rpcErr
 			continue
@@ -752,6 +963,10 @@ func (s *service) Aggregate(tctx thrift.Context, req *rpc.AggregateQueryRequest)
 		s.metrics.aggregate.ReportError(s.nowFn().Sub(callStart))
 		return nil, tterrors.NewBadRequestError(err)
 	}
+	if err := s.queryAdmission.admit(opts.QueryOptions); err != nil {
+		s.metrics.aggregate.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
 
 	queryResult, err := db.AggregateQuery(ctx, ns, query, opts)
 	if err != nil {
@@ -781,6 +996,86 @@ func (s *service) Aggregate(tctx thrift.Context, req *rpc.AggregateQueryRequest)
 	return response, nil
 }
 
+func (s *service) AggregateMulti(tctx thrift.Context, req *rpc.AggregateMultiQueryRequest) (*rpc.AggregateQueryResult_, error) {
+	db, err := s.startReadRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+	defer s.readRPCCompleted()
+
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+
+	namespaces, query, opts, err := convert.FromRPCAggregateMultiQueryRequest(req)
+	if err != nil {
+		s.metrics.aggregateMulti.ReportError(s.nowFn().Sub(callStart))
+		return nil, tterrors.NewBadRequestError(err)
+	}
+	if err := s.queryAdmission.admit(opts.QueryOptions); err != nil {
+		s.metrics.aggregateMulti.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	// Merge the results of each namespace so that tag names and values
+	// present in more than one namespace are only returned once.
+	var (
+		exhaustive = true
+		merged     = make(map[string]map[string]struct{})
+	)
+	for _, ns := range namespaces {
+		queryResult, err := db.AggregateQuery(ctx, ns, query, opts)
+		if err != nil {
+			s.metrics.aggregateMulti.ReportError(s.nowFn().Sub(callStart))
+			return nil, convert.ToRPCError(err)
+		}
+
+		exhaustive = exhaustive && queryResult.Exhaustive
+		for _, entry := range queryResult.Results.Map().Iter() {
+			tagName := entry.Key().String()
+			values, ok := merged[tagName]
+			if !ok {
+				values = make(map[string]struct{})
+				merged[tagName] = values
+			}
+			for _, entry := range entry.Value().Map().Iter() {
+				values[entry.Key().String()] = struct{}{}
+			}
+		}
+	}
+
+	tagNames := make([]string, 0, len(merged))
+	for tagName := range merged {
+		tagNames = append(tagNames, tagName)
+	}
+	sort.Strings(tagNames)
+
+	response := &rpc.AggregateQueryResult_{
+		Exhaustive: exhaustive,
+		Results:    make([]*rpc.AggregateQueryResultTagNameElement, 0, len(tagNames)),
+	}
+	for _, tagName := range tagNames {
+		values := merged[tagName]
+		tagValues := make([]string, 0, len(values))
+		for tagValue := range values {
+			tagValues = append(tagValues, tagValue)
+		}
+		sort.Strings(tagValues)
+
+		responseElem := &rpc.AggregateQueryResultTagNameElement{
+			TagName:   tagName,
+			TagValues: make([]*rpc.AggregateQueryResultTagValueElement, 0, len(tagValues)),
+		}
+		for _, tagValue := range tagValues {
+			responseElem.TagValues = append(responseElem.TagValues, &rpc.AggregateQueryResultTagValueElement{
+				TagValue: tagValue,
+			})
+		}
+		response.Results = append(response.Results, responseElem)
+	}
+	s.metrics.aggregateMulti.ReportSuccess(s.nowFn().Sub(callStart))
+	return response, nil
+}
+
 func (s *service) AggregateRaw(tctx thrift.Context, req *rpc.AggregateQueryRawRequest) (*rpc.AggregateQueryRawResult_, error) {
 	db, err := s.startReadRPCWithDB()
 	if err != nil {
@@ -796,6 +1091,10 @@ func (s *service) AggregateRaw(tctx thrift.Context, req *rpc.AggregateQueryRawRe
 		s.metrics.aggregate.ReportError(s.nowFn().Sub(callStart))
 		return nil, tterrors.NewBadRequestError(err)
 	}
+	if err := s.queryAdmission.admit(opts.QueryOptions); err != nil {
+		s.metrics.aggregate.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
 
 	queryResult, err := db.AggregateQuery(ctx, ns, query, opts)
 	if err != nil {
@@ -887,6 +1186,15 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 	}, len(req.Ids))
 	for i := range req.Ids {
 		tsID := s.newID(ctx, req.Ids[i])
+		_, include, err := s.readInterceptors.intercept(nsID, tsID, nil)
+		if err != nil {
+			encodedResults[i].err = err
+			continue
+		}
+		if !include {
+			// Filtered series are returned without any data.
+			continue
+		}
 		encoded, err := db.ReadEncoded(ctx, nsID, tsID, start, end)
 		if err != nil {
 			encodedResults[i].err = err
@@ -905,7 +1213,8 @@ func (s *service) FetchBatchRaw(tctx thrift.Context, req *rpc.FetchBatchRawReque
 			continue
 		}
 
-		segments, rpcErr := s.readEncodedResult(ctx, nsID, encodedResults[i].result)
+		segments, rpcErr := s.readEncodedResult(ctx, nsID, encodedResults[i].result,
+			convert.ToSegmentsOptions{BlockMetadata: req.GetFetchBlockMetadata()})
 		if rpcErr != nil {
 			rawResult.Err = rpcErr
 			if tterrors.IsBadRequestError(rawResult.Err) {
@@ -970,7 +1279,16 @@ func (s *service) FetchBatchRawV2(tctx thrift.Context, req *rpc.FetchBatchRawV2R
 		tsID := s.newID(ctx, elem.ID)
 
 		nsIdx := nsIDs[int(elem.NameSpace)]
-		encodedResult, err := db.ReadEncoded(ctx, nsIdx, tsID, start, end)
+		_, include, err := s.readInterceptors.intercept(nsIdx, tsID, nil)
+		if err == nil && !include {
+			// Filtered series are returned without any data.
+			success++
+			continue
+		}
+		var encodedResult [][]xio.BlockReader
+		if err == nil {
+			encodedResult, err = db.ReadEncoded(ctx, nsIdx, tsID, start, end)
+		}
 		if err != nil {
 			rawResult.Err = convert.ToRPCError(err)
 			if tterrors.IsBadRequestError(rawResult.Err) {
@@ -981,7 +1299,8 @@ func (s *service) FetchBatchRawV2(tctx thrift.Context, req *rpc.FetchBatchRawV2R
 			continue
 		}
 
-		segments, rpcErr := s.readEncodedResult(ctx, nsIdx, encodedResult)
+		segments, rpcErr := s.readEncodedResult(ctx, nsIdx, encodedResult,
+			convert.ToSegmentsOptions{})
 		if rpcErr != nil {
 			rawResult.Err = rpcErr
 			if tterrors.IsBadRequestError(rawResult.Err) {
@@ -1025,6 +1344,23 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 	res := rpc.NewFetchBlocksRawResult_()
 	res.Elements = make([]*rpc.Blocks, len(req.Elements))
 
+	// NB: Only respond with the negotiated compression type if the peer asked
+	// for compression, older peers do not know to decompress the segments.
+	var compressor compress.Compressor
+	if req.IsSetAcceptCompression() {
+		compressionType := compress.Negotiate(req.AcceptCompression,
+			fetchBlocksRawCompressionTypes)
+		if compressionType != compress.None {
+			compressor, err = compress.NewCompressor(compressionType)
+			if err != nil {
+				s.metrics.fetchBlocks.ReportError(s.nowFn().Sub(callStart))
+				return nil, convert.ToRPCError(err)
+			}
+		}
+		compression := string(compressionType)
+		res.Compression = &compression
+	}
+
 	// Preallocate starts to maximum size since at least one element will likely
 	// be fetching most blocks for peer bootstrapping
 	ropts := nsMetadata.Options().RetentionOptions()
@@ -1065,6 +1401,14 @@ func (s *service) FetchBlocksRaw(tctx thrift.Context, req *rpc.FetchBlocksRawReq
 					// No data for block, skip this block
 					continue
 				}
+				if compressor != nil {
+					// NB: The checksum is of the uncompressed segments so the
+					// peer verifies it after decompressing.
+					if err := convert.CompressSegments(converted.Segments, compressor); err != nil {
+						s.metrics.fetchBlocks.ReportError(s.nowFn().Sub(callStart))
+						return nil, convert.ToRPCError(err)
+					}
+				}
 				block.Segments = converted.Segments
 				block.Checksum = converted.Checksum
 			}
@@ -1110,106 +1454,99 @@ func (s *service) FetchBlocksMetadataRawV2(tctx thrift.Context, req *rpc.FetchBl
 	}
 
 	var (
-		nsID  = s.newID(ctx, req.NameSpace)
-		start = time.Unix(0, req.RangeStart)
-		end   = time.Unix(0, req.RangeEnd)
+		nsID   = s.newID(ctx, req.NameSpace)
+		start  = time.Unix(0, req.RangeStart)
+		end    = time.Unix(0, req.RangeEnd)
+		result = rpc.NewFetchBlocksMetadataRawV2Result_()
 	)
-	fetchedMetadata, nextPageToken, err := db.FetchBlocksMetadataV2(
-		ctx, nsID, uint32(req.Shard), start, end, req.Limit, req.PageToken, opts)
-	if err != nil {
-		return nil, convert.ToRPCError(err)
-	}
-
-	ctx.RegisterCloser(fetchedMetadata)
-
-	result, err := s.getFetchBlocksMetadataRawV2Result(ctx, nextPageToken, opts, fetchedMetadata)
-	if err != nil {
-		return nil, convert.ToRPCError(err)
-	}
-
+	// Convert the metadata of each series as it is streamed rather than
+	// accumulating all the series metadata for the page first, this avoids
+	// holding the intermediate results in memory for large pages.
+	result.Elements = s.pools.blockMetadataV2Slice.Get()
 	ctx.RegisterFinalizer(s.newCloseableMetadataV2Result(result))
-	return result, nil
-}
-
-func (s *service) getFetchBlocksMetadataRawV2Result(
-	ctx context.Context,
-	nextPageToken storage.PageToken,
-	opts block.FetchBlocksMetadataOptions,
-	results block.FetchBlocksMetadataResults,
-) (*rpc.FetchBlocksMetadataRawV2Result_, error) {
-	elements, err := s.getBlocksMetadataV2FromResult(ctx, opts, results)
+	nextPageToken, err := db.StreamBlocksMetadataV2(ctx, nsID, uint32(req.Shard),
+		start, end, req.Limit, req.PageToken, opts,
+		func(fetchedMetadata block.FetchBlocksMetadataResult) error {
+			var appendErr error
+			result.Elements, appendErr = s.appendBlocksMetadataV2(ctx, opts,
+				result.Elements, fetchedMetadata)
+			return appendErr
+		})
 	if err != nil {
-		return nil, err
+		return nil, convert.ToRPCError(err)
 	}
 
-	result := rpc.NewFetchBlocksMetadataRawV2Result_()
 	result.NextPageToken = nextPageToken
-	result.Elements = elements
 	return result, nil
 }
 
-func (s *service) getBlocksMetadataV2FromResult(
+// appendBlocksMetadataV2 appends the blocks metadata of a series to the
+// blocks and takes ownership of the fetched metadata.
+func (s *service) appendBlocksMetadataV2(
 	ctx context.Context,
 	opts block.FetchBlocksMetadataOptions,
-	results block.FetchBlocksMetadataResults,
+	blocks []*rpc.BlockMetadataV2,
+	fetchedMetadata block.FetchBlocksMetadataResult,
 ) ([]*rpc.BlockMetadataV2, error) {
-	blocks := s.pools.blockMetadataV2Slice.Get()
-	for _, fetchedMetadata := range results.Results() {
-		fetchedMetadataBlocks := fetchedMetadata.Blocks.Results()
-
-		var (
-			id          = fetchedMetadata.ID.Bytes()
-			tags        = fetchedMetadata.Tags
-			encodedTags []byte
-		)
-		if tags != nil && tags.Remaining() > 0 {
-			enc := s.pools.tagEncoder.Get()
-			ctx.RegisterFinalizer(enc)
-			encoded, err := s.encodeTags(enc, tags)
-			if err != nil {
-				return nil, err
-			}
-			encodedTags = encoded.Bytes()
-		}
+	var (
+		id          = fetchedMetadata.ID.Bytes()
+		tags        = fetchedMetadata.Tags
+		encodedTags []byte
+	)
 
-		for _, fetchedMetadataBlock := range fetchedMetadataBlocks {
-			blockMetadata := s.pools.blockMetadataV2.Get()
-			blockMetadata.ID = id
-			blockMetadata.EncodedTags = encodedTags
-			blockMetadata.Start = fetchedMetadataBlock.Start.UnixNano()
+	// The ID is referenced by the response so it must remain valid until the
+	// response is sent, the tags and blocks can be closed once converted.
+	ctx.RegisterFinalizer(fetchedMetadata.ID)
+	fetchedMetadata.ID = nil
+	defer fetchedMetadata.Close()
 
-			if opts.IncludeSizes {
-				size := fetchedMetadataBlock.Size
-				blockMetadata.Size = &size
-			} else {
-				blockMetadata.Size = nil
-			}
+	if tags != nil && tags.Remaining() > 0 {
+		enc := s.pools.tagEncoder.Get()
+		ctx.RegisterFinalizer(enc)
+		encoded, err := s.encodeTags(enc, tags)
+		if err != nil {
+			return blocks, err
+		}
+		encodedTags = encoded.Bytes()
+	}
 
-			checksum := fetchedMetadataBlock.Checksum
-			if opts.IncludeChecksums && checksum != nil {
-				value := int64(*checksum)
-				blockMetadata.Checksum = &value
-			} else {
-				blockMetadata.Checksum = nil
-			}
+	for _, fetchedMetadataBlock := range fetchedMetadata.Blocks.Results() {
+		blockMetadata := s.pools.blockMetadataV2.Get()
+		blockMetadata.ID = id
+		blockMetadata.EncodedTags = encodedTags
+		blockMetadata.Start = fetchedMetadataBlock.Start.UnixNano()
 
-			if opts.IncludeLastRead {
-				lastRead := fetchedMetadataBlock.LastRead.UnixNano()
-				blockMetadata.LastRead = &lastRead
-				blockMetadata.LastReadTimeType = rpc.TimeType_UNIX_NANOSECONDS
-			} else {
-				blockMetadata.LastRead = nil
-				blockMetadata.LastReadTimeType = rpc.TimeType(0)
-			}
+		if opts.IncludeSizes {
+			size := fetchedMetadataBlock.Size
+			blockMetadata.Size = &size
+		} else {
+			blockMetadata.Size = nil
+		}
 
-			if err := fetchedMetadataBlock.Err; err != nil {
-				blockMetadata.Err = convert.ToRPCError(err)
-			} else {
-				blockMetadata.Err = nil
-			}
+		checksum := fetchedMetadataBlock.Checksum
+		if opts.IncludeChecksums && checksum != nil {
+			value := int64(*checksum)
+			blockMetadata.Checksum = &value
+		} else {
+			blockMetadata.Checksum = nil
+		}
 
-			blocks = append(blocks, blockMetadata)
+		if opts.IncludeLastRead {
+			lastRead := fetchedMetadataBlock.LastRead.UnixNano()
+			blockMetadata.LastRead = &lastRead
+			blockMetadata.LastReadTimeType = rpc.TimeType_UNIX_NANOSECONDS
+		} else {
+			blockMetadata.LastRead = nil
+			blockMetadata.LastReadTimeType = rpc.TimeType(0)
+		}
+
+		if err := fetchedMetadataBlock.Err; err != nil {
+			blockMetadata.Err = convert.ToRPCError(err)
+		} else {
+			blockMetadata.Err = nil
 		}
+
+		blocks = append(blocks, blockMetadata)
 	}
 
 	return blocks, nil
@@ -1316,14 +1653,26 @@ func (s *service) WriteTagged(tctx thrift.Context, req *rpc.WriteTaggedRequest)
 	return nil
 }
 
-func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawRequest) error {
+func (s *service) WriteBatchRaw(
+	tctx thrift.Context,
+	req *rpc.WriteBatchRawRequest,
+) (*rpc.WriteBatchRawResult_, error) {
 	s.metrics.writeBatchRawRPCs.Inc(1)
 	db, err := s.startWriteRPCWithDB()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer s.writeRPCCompleted()
 
+	if err := s.checkFencingToken(db, req.FencingToken); err != nil {
+		return nil, err
+	}
+
+	durability, err := writeDurability(req.Durability)
+	if err != nil {
+		return nil, tterrors.NewBadRequestError(err)
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -1333,6 +1682,9 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	// rather than one per ID.
 	pooledReq := s.pools.writeBatchPooledReqPool.Get()
 	pooledReq.writeReq = req
+	if req.IncludeDispositions {
+		pooledReq.dispositions = make([]rpc.WriteDisposition, len(req.Elements))
+	}
 	ctx.RegisterFinalizer(pooledReq)
 
 	var (
@@ -1343,13 +1695,14 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 
 	batchWriter, err := db.BatchWriter(nsID, len(req.Elements))
 	if err != nil {
-		return convert.ToRPCError(err)
+		return nil, convert.ToRPCError(err)
 	}
 
 	// The lifecycle of the annotations is more involved than the rest of the data
 	// so we set the annotation pool put method as the finalization function and
 	// let the database take care of returning them to the pool.
 	batchWriter.SetFinalizeAnnotationFn(finalizeAnnotationFn)
+	batchWriter.SetDurability(durability)
 
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
@@ -1380,7 +1733,7 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	err = db.WriteBatch(ctx, nsID, batchWriter.(ts.WriteBatch),
 		pooledReq)
 	if err != nil {
-		return convert.ToRPCError(err)
+		return nil, convert.ToRPCError(err)
 	}
 
 	nonRetryableErrors += pooledReq.numNonRetryableErrors()
@@ -1396,10 +1749,11 @@ func (s *service) WriteBatchRaw(tctx thrift.Context, req *rpc.WriteBatchRawReque
 	if len(errs) > 0 {
 		batchErrs := rpc.NewWriteBatchRawErrors()
 		batchErrs.Errors = errs
-		return batchErrs
+		batchErrs.Dispositions = pooledReq.dispositions
+		return nil, batchErrs
 	}
 
-	return nil
+	return pooledReq.writeBatchRawResult(), nil
 }
 
 func (s *service) WriteBatchRawV2(tctx thrift.Context, req *rpc.WriteBatchRawV2Request) error {
@@ -1410,6 +1764,15 @@ func (s *service) WriteBatchRawV2(tctx thrift.Context, req *rpc.WriteBatchRawV2R
 	}
 	defer s.writeRPCCompleted()
 
+	if err := s.checkFencingToken(db, req.FencingToken); err != nil {
+		return err
+	}
+
+	durability, err := writeDurability(req.Durability)
+	if err != nil {
+		return tterrors.NewBadRequestError(err)
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -1463,6 +1826,7 @@ func (s *service) WriteBatchRawV2(tctx thrift.Context, req *rpc.WriteBatchRawV2R
 			// so we set the annotation pool put method as the finalization function and
 			// let the database take care of returning them to the pool.
 			batchWriter.SetFinalizeAnnotationFn(finalizeAnnotationFn)
+			batchWriter.SetDurability(durability)
 		}
 
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
@@ -1517,14 +1881,109 @@ func (s *service) WriteBatchRawV2(tctx thrift.Context, req *rpc.WriteBatchRawV2R
 	return nil
 }
 
-func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedBatchRawRequest) error {
+// withWriteIdempotency performs the write unless a write batch with the same
+// idempotency key has already been received within the idempotency window,
+// in which case the result of the original write is returned instead.
+func (s *service) withWriteIdempotency(
+	tctx thrift.Context,
+	key []byte,
+	write func() error,
+) error {
+	if s.writeIdempotency == nil || key == nil {
+		return write()
+	}
+
+	entry, first, err := s.writeIdempotency.begin(key)
+	if err != nil {
+		return tterrors.NewResourceExhaustedError(err)
+	}
+	if first {
+		err := write()
+		s.writeIdempotency.complete(entry, err)
+		return err
+	}
+
+	s.metrics.writeIdempotentRetries.Inc(1)
+	select {
+	case <-entry.done:
+		return entry.err
+	case <-tctx.Done():
+		return tterrors.NewInternalError(xerrors.NewRetryableError(tctx.Err()))
+	}
+}
+
+// checkFencingToken rejects a write batch routed with a topology newer than
+// the one known to this node. A node that was partitioned from the config
+// service may still consider itself the owner of shards that have since
+// moved, so it must not accept writes until it has caught up with the
+// topology the client is routing with.
+func (s *service) checkFencingToken(db storage.Database, token *int64) error {
+	if token == nil {
+		return nil
+	}
+	provider, ok := db.(topology.MapProvider)
+	if !ok {
+		// Not a clustered database, the topology is not versioned.
+		return nil
+	}
+	topoMap, err := provider.TopologyMap()
+	if err != nil {
+		return convert.ToRPCError(err)
+	}
+	if version := int64(topoMap.Version()); *token > version {
+		s.metrics.writeStaleTopology.Inc(1)
+		return tterrors.NewStaleTopologyError(fmt.Errorf(
+			"write routed with topology version %d, node has topology version %d",
+			*token, version))
+	}
+	return nil
+}
+
+// writeDurability returns the durability a write batch requested to be
+// acknowledged with, batches not requesting one use the namespace durability.
+func writeDurability(value *rpc.WriteDurability) (ts.Durability, error) {
+	if value == nil {
+		return ts.DurabilityDefault, nil
+	}
+	return convert.ToDurability(*value)
+}
+
+func (s *service) WriteTaggedBatchRaw(
+	tctx thrift.Context,
+	req *rpc.WriteTaggedBatchRawRequest,
+) (*rpc.WriteBatchRawResult_, error) {
+	// NB: Retries answered with the outcome of an earlier attempt of the
+	// batch do not report dispositions since the writes were not applied
+	// again, unless the earlier attempt failed with errors that carry them.
+	var result *rpc.WriteBatchRawResult_
+	err := s.withWriteIdempotency(tctx, req.IdempotencyKey, func() error {
+		var err error
+		result, err = s.writeTaggedBatchRaw(tctx, req)
+		return err
+	})
+	return result, err
+}
+
+func (s *service) writeTaggedBatchRaw(
+	tctx thrift.Context,
+	req *rpc.WriteTaggedBatchRawRequest,
+) (*rpc.WriteBatchRawResult_, error) {
 	s.metrics.writeTaggedBatchRawRPCs.Inc(1)
 	db, err := s.startWriteRPCWithDB()
 	if err != nil {
-		return err
+		return nil, err
 	}
 	defer s.writeRPCCompleted()
 
+	if err := s.checkFencingToken(db, req.FencingToken); err != nil {
+		return nil, err
+	}
+
+	durability, err := writeDurability(req.Durability)
+	if err != nil {
+		return nil, tterrors.NewBadRequestError(err)
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -1534,6 +1993,9 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	// rather than one per ID.
 	pooledReq := s.pools.writeBatchPooledReqPool.Get()
 	pooledReq.writeTaggedReq = req
+	if req.IncludeDispositions {
+		pooledReq.dispositions = make([]rpc.WriteDisposition, len(req.Elements))
+	}
 	ctx.RegisterFinalizer(pooledReq)
 
 	var (
@@ -1544,7 +2006,7 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 
 	batchWriter, err := db.BatchWriter(nsID, len(req.Elements))
 	if err != nil {
-		return convert.ToRPCError(err)
+		return nil, convert.ToRPCError(err)
 	}
 
 	// The lifecycle of the encoded tags and annotations is more involved than
@@ -1553,6 +2015,7 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	// returning them to the pool.
 	batchWriter.SetFinalizeEncodedTagsFn(finalizeEncodedTagsFn)
 	batchWriter.SetFinalizeAnnotationFn(finalizeAnnotationFn)
+	batchWriter.SetDurability(durability)
 
 	for i, elem := range req.Elements {
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
@@ -1590,7 +2053,7 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 
 	err = db.WriteTaggedBatch(ctx, nsID, batchWriter, pooledReq)
 	if err != nil {
-		return convert.ToRPCError(err)
+		return nil, convert.ToRPCError(err)
 	}
 
 	nonRetryableErrors += pooledReq.numNonRetryableErrors()
@@ -1606,13 +2069,20 @@ func (s *service) WriteTaggedBatchRaw(tctx thrift.Context, req *rpc.WriteTaggedB
 	if len(errs) > 0 {
 		batchErrs := rpc.NewWriteBatchRawErrors()
 		batchErrs.Errors = errs
-		return batchErrs
+		batchErrs.Dispositions = pooledReq.dispositions
+		return nil, batchErrs
 	}
 
-	return nil
+	return pooledReq.writeBatchRawResult(), nil
 }
 
 func (s *service) WriteTaggedBatchRawV2(tctx thrift.Context, req *rpc.WriteTaggedBatchRawV2Request) error {
+	return s.withWriteIdempotency(tctx, req.IdempotencyKey, func() error {
+		return s.writeTaggedBatchRawV2(tctx, req)
+	})
+}
+
+func (s *service) writeTaggedBatchRawV2(tctx thrift.Context, req *rpc.WriteTaggedBatchRawV2Request) error {
 	s.metrics.writeBatchRawRPCs.Inc(1)
 	db, err := s.startWriteRPCWithDB()
 	if err != nil {
@@ -1620,6 +2090,15 @@ func (s *service) WriteTaggedBatchRawV2(tctx thrift.Context, req *rpc.WriteTagge
 	}
 	defer s.writeRPCCompleted()
 
+	if err := s.checkFencingToken(db, req.FencingToken); err != nil {
+		return err
+	}
+
+	durability, err := writeDurability(req.Durability)
+	if err != nil {
+		return tterrors.NewBadRequestError(err)
+	}
+
 	callStart := s.nowFn()
 	ctx := tchannelthrift.Context(tctx)
 
@@ -1674,6 +2153,7 @@ func (s *service) WriteTaggedBatchRawV2(tctx thrift.Context, req *rpc.WriteTagge
 			// function and let the database take care of returning them to the pool.
 			batchWriter.SetFinalizeEncodedTagsFn(finalizeEncodedTagsFn)
 			batchWriter.SetFinalizeAnnotationFn(finalizeAnnotationFn)
+			batchWriter.SetDurability(durability)
 		}
 
 		unit, unitErr := convert.ToUnit(elem.Datapoint.TimestampTimeType)
@@ -1777,6 +2257,45 @@ func (s *service) Truncate(tctx thrift.Context, req *rpc.TruncateRequest) (r *rp
 	return res, nil
 }
 
+func (s *service) ShardWatermarks(tctx thrift.Context, req *rpc.ShardWatermarksRequest) (*rpc.ShardWatermarksResult_, error) {
+	db, err := s.startRPCWithDB()
+	if err != nil {
+		return nil, err
+	}
+
+	callStart := s.nowFn()
+	ctx := tchannelthrift.Context(tctx)
+	watermarks, err := db.ShardWatermarks(s.newID(ctx, req.NameSpace))
+	if err != nil {
+		s.metrics.shardWatermarks.ReportError(s.nowFn().Sub(callStart))
+		return nil, convert.ToRPCError(err)
+	}
+
+	res := rpc.NewShardWatermarksResult_()
+	res.Watermarks = make([]*rpc.ShardWatermark, 0, len(watermarks))
+	for _, w := range watermarks {
+		res.Watermarks = append(res.Watermarks, &rpc.ShardWatermark{
+			Shard:      int32(w.Shard),
+			FlushedTo:  watermarkUnixNanos(w.FlushedTo),
+			RepairedTo: watermarkUnixNanos(w.RepairedTo),
+			DurableTo:  watermarkUnixNanos(w.DurableTo),
+		})
+	}
+
+	s.metrics.shardWatermarks.ReportSuccess(s.nowFn().Sub(callStart))
+
+	return res, nil
+}
+
+// watermarkUnixNanos returns zero for watermarks that have not advanced so
+// that clients do not have to special case the unix nanos of the zero time.
+func watermarkUnixNanos(t time.Time) int64 {
+	if t.IsZero() {
+		return 0
+	}
+	return t.UnixNano()
+}
+
 func (s *service) GetPersistRateLimit(
 	ctx thrift.Context,
 ) (*rpc.NodePersistRateLimitResult_, error) {
@@ -2014,6 +2533,27 @@ func (s *service) readRPCCompleted() {
 	s.state.DecNumOutstandingReadRPCs()
 }
 
+func (s *service) Backpressure() tchannelthrift.Backpressure {
+	db, ok := s.state.DB()
+	if !ok {
+		return tchannelthrift.Backpressure{}
+	}
+
+	level := s.state.PressureLevel()
+	if db.IsOverloaded() {
+		level = tchannelthrift.PressureLevelShedding
+	}
+	bp := tchannelthrift.Backpressure{Level: level}
+	if level == tchannelthrift.PressureLevelShedding {
+		bp.RetryAfter = s.opts.BackpressureRetryAfter()
+	}
+	return bp
+}
+
+func (s *service) PeerStats() peerstats.Tracker {
+	return s.opts.PeerStats()
+}
+
 func (s *service) startRPCWithDB() (storage.Database, error) {
 	db, ok := s.state.DB()
 	if !ok {
@@ -2048,6 +2588,7 @@ func (s *service) readEncodedResult(
 	ctx context.Context,
 	nsID ident.ID,
 	encoded [][]xio.BlockReader,
+	opts convert.ToSegmentsOptions,
 ) ([]*rpc.Segments, *rpc.Error) {
 	ctx, sp, sampled := ctx.StartSampledTraceSpan(tracepoint.FetchReadSingleResult)
 	if sampled {
@@ -2066,7 +2607,7 @@ func (s *service) readEncodedResult(
 	}))
 
 	for _, readers := range encoded {
-		segment, err := s.readEncodedResultSegment(ctx, nsID, readers)
+		segment, err := s.readEncodedResultSegment(ctx, nsID, readers, opts)
 		if err != nil {
 			return nil, err
 		}
@@ -2083,10 +2624,11 @@ func (s *service) readEncodedResultSegment(
 	ctx context.Context,
 	nsID ident.ID,
 	readers []xio.BlockReader,
+	opts convert.ToSegmentsOptions,
 ) (*rpc.Segments, *rpc.Error) {
 	ctx, sp, sampled := ctx.StartSampledTraceSpan(tracepoint.FetchReadSegment)
 	defer sp.Finish()
-	converted, err := convert.ToSegments(readers)
+	converted, err := convert.ToSegmentsWithOptions(readers, opts)
 	if err != nil {
 		return nil, convert.ToRPCError(err)
 	}
@@ -2173,6 +2715,10 @@ type writeBatchPooledReq struct {
 	retryableErrors    int
 	errs               []*rpc.WriteBatchRawError
 
+	// The dispositions are only tracked for requests that asked for them,
+	// they are not pooled since they are returned to the caller.
+	dispositions []rpc.WriteDisposition
+
 	pool *writeBatchPooledReqPool
 }
 
@@ -2270,6 +2816,7 @@ func (r *writeBatchPooledReq) Finalize() {
 		// allocated on the next append call.
 		r.errs = nil
 	}
+	r.dispositions = nil
 
 	// Return to pool
 	r.pool.Put(r)
@@ -2294,6 +2841,19 @@ func (r *writeBatchPooledReq) HandleError(index int, err error) {
 		tterrors.NewWriteBatchRawError(index, err))
 }
 
+// HandleWriteDisposition records the disposition of a write for requests
+// that asked for them, it implements storage.IndexedWriteDispositionHandler.
+func (r *writeBatchPooledReq) HandleWriteDisposition(
+	index int,
+	disposition series.WriteDisposition,
+) {
+	if r.dispositions == nil {
+		return
+	}
+	// NB: Unrecognized dispositions are reported as unknown.
+	r.dispositions[index], _ = convert.ToRPCWriteDisposition(disposition)
+}
+
 func (r *writeBatchPooledReq) addError(err *rpc.WriteBatchRawError) {
 	r.errs = append(r.errs, err)
 }
@@ -2302,6 +2862,17 @@ func (r *writeBatchPooledReq) writeBatchRawErrors() []*rpc.WriteBatchRawError {
 	return r.errs
 }
 
+// writeBatchRawResult returns the result of a batch, which is nil unless the
+// request asked for the disposition of each write.
+func (r *writeBatchPooledReq) writeBatchRawResult() *rpc.WriteBatchRawResult_ {
+	if r.dispositions == nil {
+		return nil
+	}
+	result := rpc.NewWriteBatchRawResult_()
+	result.Dispositions = r.dispositions
+	return result
+}
+
 func (r *writeBatchPooledReq) numRetryableErrors() int {
 	return r.retryableErrors
 }
@@ -39,12 +39,16 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/m3ninx/idx"
 	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/compress"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/serialize"
 	xtime "github.com/m3db/m3/src/x/time"
@@ -518,6 +522,10 @@ func TestServiceFetch(t *testing.T) {
 		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
 	}
 
+	mockNs := storage.NewMockNamespace(ctrl)
+	mockNs.EXPECT().IsFrozen(start, end).Return(true)
+	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true)
+
 	stream, _ := enc.Stream(ctx)
 	mockDB.EXPECT().
 		ReadEncoded(ctx, ident.NewIDMatcher(nsID), ident.NewIDMatcher("foo"), start, end).
@@ -538,6 +546,7 @@ func TestServiceFetch(t *testing.T) {
 		ResultTimeType: rpc.TimeType_UNIX_SECONDS,
 	})
 	require.NoError(t, err)
+	assert.True(t, r.Frozen)
 
 	require.Equal(t, len(values), len(r.Datapoints))
 	for i, v := range values {
@@ -1149,6 +1158,7 @@ func TestServiceFetchBlocksRaw(t *testing.T) {
 		},
 	})
 	require.NoError(t, err)
+	assert.False(t, r.IsSetCompression())
 
 	require.Equal(t, len(ids), len(r.Elements))
 	for i, id := range ids {
@@ -1180,6 +1190,89 @@ func TestServiceFetchBlocksRaw(t *testing.T) {
 	}
 }
 
+func TestServiceFetchBlocksRawCompressed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	nsID := "metrics"
+	mockNs := storage.NewMockNamespace(ctrl)
+	mockNs.EXPECT().Options().Return(testNamespaceOptions).AnyTimes()
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Namespace(ident.NewIDMatcher(nsID)).Return(mockNs, true).AnyTimes()
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Second)
+	starts := []time.Time{start}
+
+	enc := testStorageOpts.EncoderPool().Get()
+	enc.Reset(start, 0, nil)
+	for i := 1; i <= 10; i++ {
+		dp := ts.Datapoint{
+			Timestamp: start.Add(time.Duration(i) * time.Second),
+			Value:     float64(i),
+		}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+	}
+
+	stream, _ := enc.Stream(ctx)
+	expectSegment, err := stream.Segment()
+	require.NoError(t, err)
+
+	var expectHead, expectTail []byte
+	if expectSegment.Head != nil {
+		expectHead = append(expectHead, expectSegment.Head.Bytes()...)
+	}
+	if expectSegment.Tail != nil {
+		expectTail = append(expectTail, expectSegment.Tail.Bytes()...)
+	}
+
+	mockDB.EXPECT().
+		FetchBlocks(ctx, ident.NewIDMatcher(nsID), uint32(0), ident.NewIDMatcher("foo"), starts).
+		Return([]block.FetchBlockResult{
+			block.NewFetchBlockResult(start, []xio.BlockReader{
+				xio.BlockReader{
+					SegmentReader: stream,
+					Start:         start,
+				},
+			}, nil),
+		}, nil)
+
+	r, err := service.FetchBlocksRaw(tctx, &rpc.FetchBlocksRawRequest{
+		NameSpace: []byte(nsID),
+		Shard:     0,
+		Elements: []*rpc.FetchBlocksRawRequestElement{
+			&rpc.FetchBlocksRawRequestElement{
+				ID:     []byte("foo"),
+				Starts: []int64{start.UnixNano()},
+			},
+		},
+		AcceptCompression: []string{"unknown", string(compress.Zstd)},
+	})
+	require.NoError(t, err)
+	require.True(t, r.IsSetCompression())
+	require.Equal(t, string(compress.Zstd), r.GetCompression())
+
+	require.Equal(t, 1, len(r.Elements))
+	require.Equal(t, 1, len(r.Elements[0].Blocks))
+	seg := r.Elements[0].Blocks[0].Segments
+	require.NotNil(t, seg)
+	require.NotNil(t, seg.Merged)
+
+	compressor, err := compress.NewCompressor(compress.Zstd)
+	require.NoError(t, err)
+	require.NoError(t, convert.DecompressSegments(seg, compressor))
+
+	assert.Equal(t, expectHead, seg.Merged.Head)
+	assert.Equal(t, expectTail, seg.Merged.Tail)
+}
+
 func TestServiceFetchBlocksRawIsOverloaded(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1345,9 +1438,25 @@ func TestServiceFetchBlocksMetadataEndpointV2Raw(t *testing.T) {
 		IncludeLastRead:  includeLastRead,
 	}
 	mockDB.EXPECT().
-		FetchBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
-			limit, nil, opts).
-		Return(mockResult, nextPageTokenBytes, nil)
+		StreamBlocksMetadataV2(ctx, ident.NewIDMatcher(nsID), uint32(0), start, end,
+			limit, nil, opts, gomock.Any()).
+		DoAndReturn(func(
+			_ context.Context,
+			_ ident.ID,
+			_ uint32,
+			_, _ time.Time,
+			_ int64,
+			_ storage.PageToken,
+			_ block.FetchBlocksMetadataOptions,
+			fn block.FetchBlocksMetadataResultFn,
+		) (storage.PageToken, error) {
+			for _, result := range mockResult.Results() {
+				if err := fn(result); err != nil {
+					return nil, err
+				}
+			}
+			return nextPageTokenBytes, nil
+		})
 
 	// Run RPC method
 	r, err := service.FetchBlocksMetadataRawV2(tctx, &rpc.FetchBlocksMetadataRawV2Request{
@@ -1483,6 +1592,7 @@ func TestServiceFetchTagged(t *testing.T) {
 
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
@@ -1727,6 +1837,7 @@ func TestServiceFetchTaggedNoData(t *testing.T) {
 
 	mockDB := storage.NewMockDatabase(ctrl)
 	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
 	mockDB.EXPECT().IsOverloaded().Return(false)
 
 	service := NewService(mockDB, testTChannelThriftOptions).(*service)
@@ -1775,6 +1886,7 @@ func TestServiceFetchTaggedNoData(t *testing.T) {
 		Limit:      &limit,
 	})
 	require.NoError(t, err)
+	assert.False(t, r.Frozen)
 
 	// sort to order results to make test deterministic.
 	sort.Slice(r.Elements, func(i, j int) bool {
@@ -1790,6 +1902,108 @@ func TestServiceFetchTaggedNoData(t *testing.T) {
 	}
 }
 
+func TestServiceFetchTaggedSortedPages(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().Namespace(gomock.Any()).Return(nil, false).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+	nsID := "metrics"
+
+	req, err := idx.NewRegexpQuery([]byte("foo"), []byte("b.*"))
+	require.NoError(t, err)
+	qry := index.Query{Query: req}
+
+	newResults := func() index.QueryResults {
+		resMap := index.NewQueryResults(ident.StringID(nsID),
+			index.QueryResultsOptions{}, testIndexOptions)
+		for _, id := range []string{"e", "b", "d", "a", "c"} {
+			resMap.Map().Set(ident.StringID(id), ident.NewTagsIterator(ident.Tags{}))
+		}
+		return resMap
+	}
+	// The limit is applied to the sorted results rather than the query.
+	mockDB.EXPECT().QueryIDs(
+		ctx,
+		ident.NewIDMatcher(nsID),
+		index.NewQueryMatcher(qry),
+		index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+		}).DoAndReturn(func(
+		_ context.Context,
+		_ ident.ID,
+		_ index.Query,
+		_ index.QueryOptions,
+	) (index.QueryResult, error) {
+		return index.QueryResult{Results: newResults(), Exhaustive: true}, nil
+	}).Times(3)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	var limit int64 = 2
+	data, err := idx.Marshal(req)
+	require.NoError(t, err)
+
+	var (
+		pageToken []byte
+		pages     [][]string
+	)
+	for {
+		r, err := service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+			NameSpace:   []byte(nsID),
+			Query:       data,
+			RangeStart:  startNanos,
+			RangeEnd:    endNanos,
+			FetchData:   false,
+			Limit:       &limit,
+			SortedOrder: true,
+			PageToken:   pageToken,
+		})
+		require.NoError(t, err)
+
+		var page []string
+		for _, elem := range r.Elements {
+			page = append(page, string(elem.ID))
+		}
+		pages = append(pages, page)
+
+		if !r.IsSetNextPageToken() {
+			require.True(t, r.Exhaustive)
+			break
+		}
+		require.False(t, r.Exhaustive)
+		pageToken = r.NextPageToken
+	}
+
+	require.Equal(t, [][]string{{"a", "b"}, {"c", "d"}, {"e"}}, pages)
+
+	_, err = service.FetchTagged(tctx, &rpc.FetchTaggedRequest{
+		NameSpace:  []byte(nsID),
+		Query:      data,
+		RangeStart: startNanos,
+		RangeEnd:   endNanos,
+		PageToken:  []byte("invalid"),
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
 func TestServiceFetchTaggedErrs(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1926,6 +2140,78 @@ func TestServiceAggregate(t *testing.T) {
 	require.Equal(t, 0, len(r.Results[1].TagValues))
 }
 
+func TestServiceAggregateMulti(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	start := time.Now().Add(-2 * time.Hour)
+	end := start.Add(2 * time.Hour)
+
+	start, end = start.Truncate(time.Second), end.Truncate(time.Second)
+	qry := index.Query{Query: idx.NewAllQuery()}
+	opts := index.AggregationOptions{
+		QueryOptions: index.QueryOptions{
+			StartInclusive: start,
+			EndExclusive:   end,
+		},
+		FieldFilter: index.AggregateFieldFilter{},
+		Type:        index.AggregateTagNamesAndValues,
+	}
+
+	shortResMap := index.NewAggregateResults(ident.StringID("short"),
+		index.AggregateResultsOptions{}, testIndexOptions)
+	shortResMap.Map().Set(ident.StringID("foo"), index.MustNewAggregateValues(testIndexOptions,
+		ident.StringID("baz"), ident.StringID("qux")))
+	mockDB.EXPECT().AggregateQuery(ctx, ident.NewIDMatcher("short"),
+		index.NewQueryMatcher(qry), opts).Return(
+		index.AggregateQueryResult{Results: shortResMap, Exhaustive: true}, nil)
+
+	longResMap := index.NewAggregateResults(ident.StringID("long"),
+		index.AggregateResultsOptions{}, testIndexOptions)
+	longResMap.Map().Set(ident.StringID("foo"), index.MustNewAggregateValues(testIndexOptions,
+		ident.StringID("bar"), ident.StringID("baz")))
+	longResMap.Map().Set(ident.StringID("city"), index.MustNewAggregateValues(testIndexOptions,
+		ident.StringID("nyc")))
+	mockDB.EXPECT().AggregateQuery(ctx, ident.NewIDMatcher("long"),
+		index.NewQueryMatcher(qry), opts).Return(
+		index.AggregateQueryResult{Results: longResMap, Exhaustive: false}, nil)
+
+	startNanos, err := convert.ToValue(start, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	endNanos, err := convert.ToValue(end, rpc.TimeType_UNIX_NANOSECONDS)
+	require.NoError(t, err)
+	r, err := service.AggregateMulti(tctx, &rpc.AggregateMultiQueryRequest{
+		NameSpaces:         []string{"short", "long"},
+		Query:              &rpc.Query{All: &rpc.AllQuery{}},
+		RangeStart:         startNanos,
+		RangeEnd:           endNanos,
+		AggregateQueryType: rpc.AggregateQueryType_AGGREGATE_BY_TAG_NAME_VALUE,
+	})
+	require.NoError(t, err)
+	require.False(t, r.Exhaustive)
+
+	require.Equal(t, 2, len(r.Results))
+	require.Equal(t, "city", r.Results[0].TagName)
+	require.Equal(t, 1, len(r.Results[0].TagValues))
+	require.Equal(t, "nyc", r.Results[0].TagValues[0].TagValue)
+
+	require.Equal(t, "foo", r.Results[1].TagName)
+	require.Equal(t, 3, len(r.Results[1].TagValues))
+	require.Equal(t, "bar", r.Results[1].TagValues[0].TagValue)
+	require.Equal(t, "baz", r.Results[1].TagValues[1].TagValue)
+	require.Equal(t, "qux", r.Results[1].TagValues[2].TagValue)
+}
+
 func TestServiceWrite(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2161,11 +2447,225 @@ func TestServiceWriteBatchRaw(t *testing.T) {
 	}
 
 	mockDB.EXPECT().IsOverloaded().Return(false)
-	err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+	_, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace: []byte(nsID),
+		Elements:  elements,
+	})
+	require.NoError(t, err)
+}
+
+func TestServiceWriteBatchRawDispositions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+	var elements []*rpc.WriteBatchRawRequestElement
+	for _, id := range []string{"foo", "bar"} {
+		elements = append(elements, &rpc.WriteBatchRawRequestElement{
+			ID: []byte(id),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         time.Now().Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             12.34,
+			},
+		})
+	}
+
+	writeErr := xerrors.NewInvalidParamsError(errors.New("too far in future"))
+	expectWrite := func(err error) {
+		writeBatch := ts.NewWriteBatch(len(elements), ident.StringID(nsID), nil)
+		mockDB.EXPECT().
+			BatchWriter(ident.NewIDMatcher(nsID), len(elements)).
+			Return(writeBatch, nil)
+		mockDB.EXPECT().
+			WriteBatch(ctx, ident.NewIDMatcher(nsID), writeBatch, gomock.Any()).
+			DoAndReturn(func(
+				_ context.Context,
+				_ ident.ID,
+				_ ts.BatchWriter,
+				errHandler storage.IndexedErrorHandler,
+			) error {
+				handler := errHandler.(storage.IndexedWriteDispositionHandler)
+				handler.HandleWriteDisposition(0, series.WriteClamped)
+				if err == nil {
+					handler.HandleWriteDisposition(1, series.WriteDuplicateOverwritten)
+					return nil
+				}
+				errHandler.HandleError(1, err)
+				handler.HandleWriteDisposition(1, series.WriteRejectedTooFuture)
+				return nil
+			})
+	}
+
+	// Dispositions are not returned unless requested.
+	expectWrite(nil)
+	result, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
 		NameSpace: []byte(nsID),
 		Elements:  elements,
 	})
 	require.NoError(t, err)
+	require.Nil(t, result)
+
+	expectWrite(nil)
+	result, err = service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace:           []byte(nsID),
+		Elements:            elements,
+		IncludeDispositions: true,
+	})
+	require.NoError(t, err)
+	require.Equal(t, []rpc.WriteDisposition{
+		rpc.WriteDisposition_CLAMPED,
+		rpc.WriteDisposition_DUPLICATE_OVERWRITTEN,
+	}, result.Dispositions)
+
+	// Dispositions are returned with the errors of a partially failed batch.
+	expectWrite(writeErr)
+	result, err = service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace:           []byte(nsID),
+		Elements:            elements,
+		IncludeDispositions: true,
+	})
+	require.Nil(t, result)
+	batchErrs, ok := err.(*rpc.WriteBatchRawErrors)
+	require.True(t, ok)
+	require.Equal(t, 1, len(batchErrs.Errors))
+	require.Equal(t, int64(1), batchErrs.Errors[0].Index)
+	require.Equal(t, []rpc.WriteDisposition{
+		rpc.WriteDisposition_CLAMPED,
+		rpc.WriteDisposition_REJECTED_TOO_FUTURE,
+	}, batchErrs.Dispositions)
+}
+
+func TestServiceWriteBatchRawDurability(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+	elements := []*rpc.WriteBatchRawRequestElement{
+		{
+			ID: []byte("foo"),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         time.Now().Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             12.34,
+			},
+		},
+	}
+
+	writeBatch := ts.NewWriteBatch(len(elements), ident.StringID(nsID), nil)
+	mockDB.EXPECT().
+		BatchWriter(ident.NewIDMatcher(nsID), len(elements)).
+		Return(writeBatch, nil)
+	mockDB.EXPECT().
+		WriteBatch(ctx, ident.NewIDMatcher(nsID), writeBatch, gomock.Any()).
+		Return(nil)
+
+	_, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace:  []byte(nsID),
+		Elements:   elements,
+		Durability: rpc.WriteDurabilityPtr(rpc.WriteDurability_FSYNC),
+	})
+	require.NoError(t, err)
+	require.Equal(t, ts.DurabilityFsync, writeBatch.Durability())
+
+	// Unknown durabilities are rejected as bad requests.
+	_, err = service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace:  []byte(nsID),
+		Elements:   elements,
+		Durability: rpc.WriteDurabilityPtr(rpc.WriteDurability(100)),
+	})
+	require.Error(t, err)
+	require.True(t, tterrors.IsBadRequestError(err.(*rpc.Error)))
+}
+
+type testClusterDatabase struct {
+	*storage.MockDatabase
+	topoMap topology.Map
+}
+
+func (d testClusterDatabase) TopologyMap() (topology.Map, error) {
+	return d.topoMap, nil
+}
+
+func TestServiceWriteBatchRawStaleTopology(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false).AnyTimes()
+
+	topoMap := topology.NewMockMap(ctrl)
+	topoMap.EXPECT().Version().Return(3).AnyTimes()
+
+	db := testClusterDatabase{MockDatabase: mockDB, topoMap: topoMap}
+	service := NewService(db, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+	elements := []*rpc.WriteBatchRawRequestElement{
+		{
+			ID: []byte("foo"),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         time.Now().Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             12.34,
+			},
+		},
+	}
+
+	// Written with a topology newer than the node's topology.
+	token := int64(4)
+	_, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace:    []byte(nsID),
+		Elements:     elements,
+		FencingToken: &token,
+	})
+	require.Error(t, err)
+	rpcErr, ok := err.(*rpc.Error)
+	require.True(t, ok)
+	assert.True(t, tterrors.IsStaleTopologyErrorFlag(rpcErr))
+	assert.True(t, tterrors.IsRetryableErrorFlag(rpcErr))
+
+	// Written with the same topology as the node's topology.
+	writeBatch := ts.NewWriteBatch(len(elements), ident.StringID(nsID), nil)
+	mockDB.EXPECT().
+		BatchWriter(ident.NewIDMatcher(nsID), len(elements)).
+		Return(writeBatch, nil)
+	mockDB.EXPECT().
+		WriteBatch(ctx, ident.NewIDMatcher(nsID), writeBatch, gomock.Any()).
+		Return(nil)
+
+	token = 3
+	_, err = service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+		NameSpace:    []byte(nsID),
+		Elements:     elements,
+		FencingToken: &token,
+	})
+	require.NoError(t, err)
 }
 
 func TestServiceWriteBatchRawV2SingleNS(t *testing.T) {
@@ -2305,7 +2805,7 @@ func TestServiceWriteBatchRawOverloaded(t *testing.T) {
 	defer ctx.Close()
 
 	mockDB.EXPECT().IsOverloaded().Return(true)
-	err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+	_, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
 		NameSpace: []byte("metrics"),
 	})
 	require.Equal(t, tterrors.NewInternalError(errServerIsOverloaded), err)
@@ -2387,7 +2887,7 @@ func TestServiceWriteBatchRawOverMaxOutstandingRequests(t *testing.T) {
 	<-requestIsOutstanding
 
 	// Second request should get an overloaded error since there is an outstanding request.
-	err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+	_, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
 		NameSpace: []byte(nsID),
 		Elements:  elements,
 	})
@@ -2410,7 +2910,7 @@ func TestServiceWriteBatchRawDatabaseNotSet(t *testing.T) {
 	)
 	defer ctx.Close()
 
-	err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
+	_, err := service.WriteBatchRaw(tctx, &rpc.WriteBatchRawRequest{
 		NameSpace: []byte("metrics"),
 	})
 	require.Equal(t, tterrors.NewInternalError(errDatabaseIsNotInitializedYet), err)
@@ -2475,13 +2975,70 @@ func TestServiceWriteTaggedBatchRaw(t *testing.T) {
 	}
 
 	mockDB.EXPECT().IsOverloaded().Return(false)
-	err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
+	_, err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
 		NameSpace: []byte(nsID),
 		Elements:  elements,
 	})
 	require.NoError(t, err)
 }
 
+func TestServiceWriteTaggedBatchRawIdempotent(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+
+	mockDecoder := serialize.NewMockTagDecoder(ctrl)
+	mockDecoder.EXPECT().Reset(gomock.Any()).AnyTimes()
+	mockDecoder.EXPECT().Err().Return(nil).AnyTimes()
+	mockDecoder.EXPECT().Close().AnyTimes()
+	mockDecoderPool := serialize.NewMockTagDecoderPool(ctrl)
+	mockDecoderPool.EXPECT().Get().Return(mockDecoder).AnyTimes()
+
+	opts := tchannelthrift.NewOptions().
+		SetTagDecoderPool(mockDecoderPool).
+		SetWriteIdempotencyWindow(time.Minute)
+
+	service := NewService(mockDB, opts).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+	elements := []*rpc.WriteTaggedBatchRawRequestElement{
+		{
+			ID:          []byte("foo"),
+			EncodedTags: []byte("a|b"),
+			Datapoint: &rpc.Datapoint{
+				Timestamp:         time.Now().Unix(),
+				TimestampTimeType: rpc.TimeType_UNIX_SECONDS,
+				Value:             12.34,
+			},
+		},
+	}
+
+	// The batch must only be written once regardless of retries.
+	writeBatch := ts.NewWriteBatch(len(elements), ident.StringID(nsID), nil)
+	mockDB.EXPECT().
+		BatchWriter(ident.NewIDMatcher(nsID), len(elements)).
+		Return(writeBatch, nil)
+	mockDB.EXPECT().
+		WriteTaggedBatch(ctx, ident.NewIDMatcher(nsID), writeBatch, gomock.Any()).
+		Return(nil)
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	for i := 0; i < 2; i++ {
+		_, err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
+			NameSpace:      []byte(nsID),
+			Elements:       elements,
+			IdempotencyKey: []byte("key"),
+		})
+		require.NoError(t, err)
+	}
+}
+
 func TestServiceWriteTaggedBatchRawV2(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -2641,7 +3198,7 @@ func TestServiceWriteTaggedBatchRawOverloaded(t *testing.T) {
 	defer ctx.Close()
 
 	mockDB.EXPECT().IsOverloaded().Return(true)
-	err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
+	_, err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
 		NameSpace: []byte("metrics"),
 	})
 	require.Equal(t, tterrors.NewInternalError(errServerIsOverloaded), err)
@@ -2658,7 +3215,7 @@ func TestServiceWriteTaggedBatchRawDatabaseNotSet(t *testing.T) {
 	)
 	defer ctx.Close()
 
-	err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
+	_, err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
 		NameSpace: []byte("metrics"),
 	})
 	require.Equal(t, tterrors.NewInternalError(errDatabaseIsNotInitializedYet), err)
@@ -2718,7 +3275,7 @@ func TestServiceWriteTaggedBatchRawUnknownError(t *testing.T) {
 	}
 
 	mockDB.EXPECT().IsOverloaded().Return(false)
-	err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
+	_, err := service.WriteTaggedBatchRaw(tctx, &rpc.WriteTaggedBatchRawRequest{
 		NameSpace: []byte(nsID),
 		Elements:  elements,
 	})
@@ -2771,6 +3328,42 @@ func TestServiceTruncate(t *testing.T) {
 	assert.Equal(t, truncated, r.NumSeries)
 }
 
+func TestServiceShardWatermarks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockDB := storage.NewMockDatabase(ctrl)
+	mockDB.EXPECT().Options().Return(testStorageOpts).AnyTimes()
+	mockDB.EXPECT().IsOverloaded().Return(false)
+
+	service := NewService(mockDB, testTChannelThriftOptions).(*service)
+
+	tctx, _ := tchannelthrift.NewContext(time.Minute)
+	ctx := tchannelthrift.Context(tctx)
+	defer ctx.Close()
+
+	nsID := "metrics"
+
+	flushedTo := time.Now().Truncate(time.Hour)
+	repairedTo := flushedTo.Add(-time.Hour)
+	mockDB.EXPECT().ShardWatermarks(ident.NewIDMatcher(nsID)).Return([]storage.ShardWatermark{
+		{Shard: 0, FlushedTo: flushedTo, RepairedTo: repairedTo, DurableTo: repairedTo},
+		{Shard: 1},
+	}, nil)
+
+	r, err := service.ShardWatermarks(tctx, &rpc.ShardWatermarksRequest{NameSpace: []byte(nsID)})
+	require.NoError(t, err)
+	require.Equal(t, []*rpc.ShardWatermark{
+		{
+			Shard:      0,
+			FlushedTo:  flushedTo.UnixNano(),
+			RepairedTo: repairedTo.UnixNano(),
+			DurableTo:  repairedTo.UnixNano(),
+		},
+		{Shard: 1},
+	}, r.Watermarks)
+}
+
 func TestServiceSetPersistRateLimit(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
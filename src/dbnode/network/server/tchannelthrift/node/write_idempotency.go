@@ -0,0 +1,170 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package node
+
+import (
+	"container/list"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+)
+
+// errWriteIdempotencyWindowFull is returned when a write batch with an
+// idempotency key is received while the maximum number of keys are tracked
+// and all of their writes are still in progress.
+var errWriteIdempotencyWindowFull = errors.New(
+	"write idempotency window is full of writes in progress")
+
+// writeIdempotencyWindow tracks the results of recently received write
+// batches by their idempotency key so that a batch retried after an
+// ambiguous failure, i.e. a timeout, is not applied more than once.
+type writeIdempotencyWindow struct {
+	sync.Mutex
+
+	nowFn   clock.NowFn
+	window  time.Duration
+	maxKeys int
+	entries map[string]*list.Element
+	// order holds entries in order of insertion, which given a constant
+	// window is also the order of expiry.
+	order *list.List
+}
+
+type writeIdempotencyEntry struct {
+	key       string
+	expires   time.Time
+	completed bool
+	done      chan struct{}
+	err       error
+}
+
+func newWriteIdempotencyWindow(
+	nowFn clock.NowFn,
+	window time.Duration,
+	maxKeys int,
+) *writeIdempotencyWindow {
+	return &writeIdempotencyWindow{
+		nowFn:   nowFn,
+		window:  window,
+		maxKeys: maxKeys,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+// begin returns the entry for the idempotency key and whether the caller is
+// the first to see the key within the window, in which case the caller must
+// perform the write and then call complete with its result.
+//
+// When the maximum number of keys are tracked the oldest key whose write has
+// completed is forgotten to make room. Keys whose writes are still in
+// progress are never forgotten since a retry would then be applied
+// concurrently with the original write, if all of them are in progress the
+// write is rejected with errWriteIdempotencyWindowFull instead.
+func (w *writeIdempotencyWindow) begin(key []byte) (*writeIdempotencyEntry, bool, error) {
+	now := w.nowFn()
+
+	w.Lock()
+	defer w.Unlock()
+
+	w.expireWithLock(now)
+	if elem, ok := w.entries[string(key)]; ok {
+		return elem.Value.(*writeIdempotencyEntry), false, nil
+	}
+
+	for w.order.Len() > 0 && w.order.Len() >= w.maxKeys {
+		if !w.removeOldestCompletedWithLock() {
+			return nil, false, errWriteIdempotencyWindowFull
+		}
+	}
+
+	entry := &writeIdempotencyEntry{
+		key:     string(key),
+		expires: now.Add(w.window),
+		done:    make(chan struct{}),
+	}
+	w.entries[entry.key] = w.order.PushBack(entry)
+	return entry, true, nil
+}
+
+// complete records the result of the write for the entry, waking any
+// retries of the same batch that are waiting on the result.
+func (w *writeIdempotencyWindow) complete(entry *writeIdempotencyEntry, err error) {
+	forget := false
+	switch e := err.(type) {
+	case nil:
+	case *rpc.WriteBatchRawErrors:
+		// Partial failure, the errors slice belongs to a pooled request so
+		// take a copy to replay to retries.
+		batchErrs := rpc.NewWriteBatchRawErrors()
+		batchErrs.Errors = append([]*rpc.WriteBatchRawError(nil), e.Errors...)
+		err = batchErrs
+	default:
+		// The entire batch failed and nothing was written, forget the key
+		// so that a retry of the batch is applied.
+		forget = true
+	}
+
+	w.Lock()
+	entry.completed = true
+	if elem, ok := w.entries[entry.key]; ok && elem.Value == entry && forget {
+		w.removeWithLock(elem)
+	}
+	w.Unlock()
+
+	entry.err = err
+	close(entry.done)
+}
+
+// expireWithLock forgets the keys whose window has elapsed. Like when making
+// room for new keys, keys whose writes are still in progress are kept until
+// their writes complete so that a retry is not applied concurrently with the
+// original write.
+func (w *writeIdempotencyWindow) expireWithLock(now time.Time) {
+	for elem := w.order.Front(); elem != nil; {
+		entry := elem.Value.(*writeIdempotencyEntry)
+		if now.Before(entry.expires) {
+			return
+		}
+		next := elem.Next()
+		if entry.completed {
+			w.removeWithLock(elem)
+		}
+		elem = next
+	}
+}
+
+func (w *writeIdempotencyWindow) removeOldestCompletedWithLock() bool {
+	for elem := w.order.Front(); elem != nil; elem = elem.Next() {
+		if elem.Value.(*writeIdempotencyEntry).completed {
+			w.removeWithLock(elem)
+			return true
+		}
+	}
+	return false
+}
+
+func (w *writeIdempotencyWindow) removeWithLock(elem *list.Element) {
+	entry := w.order.Remove(elem).(*writeIdempotencyEntry)
+	delete(w.entries, entry.key)
+}
@@ -0,0 +1,173 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package node
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/generated/thrift/rpc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteIdempotencyWindowReplaysResult(t *testing.T) {
+	now := time.Now()
+	w := newWriteIdempotencyWindow(func() time.Time { return now },
+		time.Minute, 16)
+
+	entry, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+
+	batchErrs := rpc.NewWriteBatchRawErrors()
+	batchErrs.Errors = []*rpc.WriteBatchRawError{{Index: 1}}
+	w.complete(entry, batchErrs)
+
+	// Mutating the original errors must not affect the recorded result.
+	batchErrs.Errors = batchErrs.Errors[:0]
+
+	retry, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, first)
+	<-retry.done
+	require.Equal(t, []*rpc.WriteBatchRawError{{Index: 1}},
+		retry.err.(*rpc.WriteBatchRawErrors).Errors)
+
+	// Keys are forgotten once the window has elapsed.
+	now = now.Add(time.Minute)
+	_, first, err = w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+}
+
+func TestWriteIdempotencyWindowForgetsFailedWrites(t *testing.T) {
+	now := time.Now()
+	w := newWriteIdempotencyWindow(func() time.Time { return now },
+		time.Minute, 16)
+
+	entry, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+
+	retry, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, first)
+
+	writeErr := errors.New("an error")
+	w.complete(entry, writeErr)
+	<-retry.done
+	require.Equal(t, writeErr, retry.err)
+
+	_, first, err = w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+}
+
+func TestWriteIdempotencyWindowMaxKeys(t *testing.T) {
+	now := time.Now()
+	w := newWriteIdempotencyWindow(func() time.Time { return now },
+		time.Minute, 2)
+
+	for _, key := range []string{"foo", "bar", "baz"} {
+		entry, first, err := w.begin([]byte(key))
+		require.NoError(t, err)
+		require.True(t, first)
+		w.complete(entry, nil)
+	}
+
+	require.Equal(t, 2, len(w.entries))
+	_, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+}
+
+func TestWriteIdempotencyWindowMaxKeysKeepsWritesInProgress(t *testing.T) {
+	now := time.Now()
+	w := newWriteIdempotencyWindow(func() time.Time { return now },
+		time.Minute, 2)
+
+	inProgress, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+
+	completed, first, err := w.begin([]byte("bar"))
+	require.NoError(t, err)
+	require.True(t, first)
+	w.complete(completed, nil)
+
+	// The completed write is forgotten to make room even though the write
+	// in progress is older.
+	_, first, err = w.begin([]byte("baz"))
+	require.NoError(t, err)
+	require.True(t, first)
+
+	retry, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, first)
+	require.Equal(t, inProgress, retry)
+
+	// All tracked writes are in progress, new keys are rejected.
+	_, _, err = w.begin([]byte("qux"))
+	require.Equal(t, errWriteIdempotencyWindowFull, err)
+
+	w.complete(inProgress, nil)
+	_, first, err = w.begin([]byte("qux"))
+	require.NoError(t, err)
+	require.True(t, first)
+}
+
+func TestWriteIdempotencyWindowExpiryKeepsWritesInProgress(t *testing.T) {
+	now := time.Now()
+	w := newWriteIdempotencyWindow(func() time.Time { return now },
+		time.Minute, 16)
+
+	inProgress, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+
+	completed, first, err := w.begin([]byte("bar"))
+	require.NoError(t, err)
+	require.True(t, first)
+	w.complete(completed, nil)
+
+	// The window elapses while the write is still in progress, a retry must
+	// wait on the original write rather than be applied concurrently.
+	now = now.Add(2 * time.Minute)
+	retry, first, err := w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.False(t, first)
+	require.Equal(t, inProgress, retry)
+
+	// The completed write is forgotten as usual.
+	_, first, err = w.begin([]byte("bar"))
+	require.NoError(t, err)
+	require.True(t, first)
+
+	w.complete(inProgress, nil)
+	<-retry.done
+	require.NoError(t, retry.err)
+
+	// Once complete the expired key is forgotten.
+	_, first, err = w.begin([]byte("foo"))
+	require.NoError(t, err)
+	require.True(t, first)
+}
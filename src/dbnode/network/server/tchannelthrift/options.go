@@ -21,7 +21,10 @@
 package tchannelthrift
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/x/ident"
@@ -42,8 +45,19 @@ type options struct {
 	checkedBytesWrapperPool     xpool.CheckedBytesWrapperPool
 	maxOutstandingWriteRequests int
 	maxOutstandingReadRequests  int
+	writeIdempotencyWindow      time.Duration
+	writeIdempotencyMaxKeys     int
+	readInterceptors            []ReadInterceptor
+	backpressureRetryAfter      time.Duration
+	peerStats                   peerstats.Tracker
+	queryAdmissionOpts          QueryAdmissionOptions
 }
 
+const (
+	defaultWriteIdempotencyMaxKeys = 65536
+	defaultBackpressureRetryAfter  = time.Second
+)
+
 // NewOptions creates new options
 func NewOptions() Options {
 	// Use a zero size pool by default, override from config.
@@ -81,6 +95,9 @@ func NewOptions() Options {
 		tagEncoderPool:           tagEncoderPool,
 		tagDecoderPool:           tagDecoderPool,
 		checkedBytesWrapperPool:  bytesWrapperPool,
+		writeIdempotencyMaxKeys:  defaultWriteIdempotencyMaxKeys,
+		backpressureRetryAfter:   defaultBackpressureRetryAfter,
+		peerStats:                peerstats.NewTracker(instrument.NewOptions()),
 	}
 }
 
@@ -193,3 +210,63 @@ func (o *options) SetMaxOutstandingReadRequests(value int) Options {
 func (o *options) MaxOutstandingReadRequests() int {
 	return o.maxOutstandingReadRequests
 }
+
+func (o *options) SetWriteIdempotencyWindow(value time.Duration) Options {
+	opts := *o
+	opts.writeIdempotencyWindow = value
+	return &opts
+}
+
+func (o *options) WriteIdempotencyWindow() time.Duration {
+	return o.writeIdempotencyWindow
+}
+
+func (o *options) SetWriteIdempotencyMaxKeys(value int) Options {
+	opts := *o
+	opts.writeIdempotencyMaxKeys = value
+	return &opts
+}
+
+func (o *options) WriteIdempotencyMaxKeys() int {
+	return o.writeIdempotencyMaxKeys
+}
+
+func (o *options) SetReadInterceptors(value []ReadInterceptor) Options {
+	opts := *o
+	opts.readInterceptors = value
+	return &opts
+}
+
+func (o *options) ReadInterceptors() []ReadInterceptor {
+	return o.readInterceptors
+}
+
+func (o *options) SetBackpressureRetryAfter(value time.Duration) Options {
+	opts := *o
+	opts.backpressureRetryAfter = value
+	return &opts
+}
+
+func (o *options) BackpressureRetryAfter() time.Duration {
+	return o.backpressureRetryAfter
+}
+
+func (o *options) SetPeerStats(value peerstats.Tracker) Options {
+	opts := *o
+	opts.peerStats = value
+	return &opts
+}
+
+func (o *options) PeerStats() peerstats.Tracker {
+	return o.peerStats
+}
+
+func (o *options) SetQueryAdmissionOptions(value QueryAdmissionOptions) Options {
+	opts := *o
+	opts.queryAdmissionOpts = value
+	return &opts
+}
+
+func (o *options) QueryAdmissionOptions() QueryAdmissionOptions {
+	return o.queryAdmissionOpts
+}
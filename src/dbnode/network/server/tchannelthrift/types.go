@@ -21,7 +21,10 @@
 package tchannelthrift
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
 	"github.com/m3db/m3/src/x/ident"
@@ -100,4 +103,105 @@ type Options interface {
 	// MaxOutstandingReadRequests returns the maxinum number of allowed
 	// outstanding read requests.
 	MaxOutstandingReadRequests() int
+
+	// SetWriteIdempotencyWindow sets the duration for which the results of
+	// write batches with an idempotency key are retained so that retries of
+	// the batch are not applied twice, zero disables idempotent writes.
+	SetWriteIdempotencyWindow(value time.Duration) Options
+
+	// WriteIdempotencyWindow returns the duration for which the results of
+	// write batches with an idempotency key are retained so that retries of
+	// the batch are not applied twice, zero disables idempotent writes.
+	WriteIdempotencyWindow() time.Duration
+
+	// SetWriteIdempotencyMaxKeys sets the maximum number of write batch
+	// idempotency keys retained at any one time, write batches received
+	// while this many writes are in progress are rejected as retryable.
+	SetWriteIdempotencyMaxKeys(value int) Options
+
+	// WriteIdempotencyMaxKeys returns the maximum number of write batch
+	// idempotency keys retained at any one time.
+	WriteIdempotencyMaxKeys() int
+
+	// SetReadInterceptors sets the interceptors called with every series
+	// read before it is serialized, in the order they are specified.
+	SetReadInterceptors(value []ReadInterceptor) Options
+
+	// ReadInterceptors returns the interceptors called with every series
+	// read before it is serialized.
+	ReadInterceptors() []ReadInterceptor
+
+	// SetBackpressureRetryAfter sets how long clients are asked to wait
+	// before retrying requests while the node is shedding load.
+	SetBackpressureRetryAfter(value time.Duration) Options
+
+	// BackpressureRetryAfter returns how long clients are asked to wait
+	// before retrying requests while the node is shedding load.
+	BackpressureRetryAfter() time.Duration
+
+	// SetPeerStats sets the tracker of network statistics per calling peer.
+	SetPeerStats(value peerstats.Tracker) Options
+
+	// PeerStats returns the tracker of network statistics per calling peer.
+	PeerStats() peerstats.Tracker
+
+	// SetQueryAdmissionOptions sets the options that control the rejection
+	// of large queries while the node is under garbage collection pressure.
+	SetQueryAdmissionOptions(value QueryAdmissionOptions) Options
+
+	// QueryAdmissionOptions returns the options that control the rejection
+	// of large queries while the node is under garbage collection pressure.
+	QueryAdmissionOptions() QueryAdmissionOptions
+}
+
+// QueryAdmissionOptions controls the rejection of large queries while the
+// node is under garbage collection pressure, such as when it is spending
+// most of its time collecting a heap that keeps growing. Writes, reads by ID
+// and small queries are always admitted. The zero value disables admission.
+type QueryAdmissionOptions struct {
+	// GCPauseFraction is the fraction of wall clock time spent in garbage
+	// collection pauses between samples above which the node is considered
+	// under pressure, zero disables the signal.
+	GCPauseFraction float64
+
+	// HeapGrowthFactor is the factor by which the in use heap grows between
+	// samples above which the node is considered under pressure, zero
+	// disables the signal.
+	HeapGrowthFactor float64
+
+	// SampleInterval is the interval between samples of the runtime memory
+	// statistics, zero uses the default.
+	SampleInterval time.Duration
+
+	// SmallQueryMaxLimit is the largest series limit for which a query is
+	// considered small, queries without a limit are always considered large.
+	SmallQueryMaxLimit int
+
+	// SmallQueryMaxRange is the longest time range for which a query is
+	// considered small, zero does not restrict the range of small queries.
+	SmallQueryMaxRange time.Duration
+}
+
+// Enabled returns whether any signal for query admission is enabled.
+func (o QueryAdmissionOptions) Enabled() bool {
+	return o.GCPauseFraction > 0 || o.HeapGrowthFactor > 0
+}
+
+// ReadInterceptor is called with every series returned by a read before it
+// is serialized in the response, allowing embedders to transform or filter
+// results such as redacting tags or applying tenancy filters.
+type ReadInterceptor interface {
+	// Name returns the name of the interceptor, used to tag its metrics.
+	Name() string
+
+	// InterceptRead is called with the namespace, ID and tags of a series,
+	// the tags are nil for reads by ID. It returns the tags to serialize for
+	// the series and false if the series should be omitted from the results,
+	// interceptors that inspect the tags should do so with a duplicate of the
+	// iterator so that the returned tags are read from the start.
+	InterceptRead(
+		namespace ident.ID,
+		id ident.ID,
+		tags ident.TagIterator,
+	) (ident.TagIterator, bool, error)
 }
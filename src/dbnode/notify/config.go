@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package notify
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/retry"
+)
+
+// Configuration is the configuration for lifecycle event notifications.
+type Configuration struct {
+	// Webhooks are the webhooks events are posted to.
+	Webhooks []WebhookConfiguration `yaml:"webhooks" validate:"nonzero"`
+
+	// QueueSize is the number of events that can be pending delivery
+	// before further events are dropped.
+	QueueSize int `yaml:"queueSize" validate:"min=0"`
+
+	// Retry is the retry configuration for failed deliveries.
+	Retry *retry.Configuration `yaml:"retry"`
+}
+
+// WebhookConfiguration is the configuration for a single webhook.
+type WebhookConfiguration struct {
+	// URL is the URL events are posted to.
+	URL string `yaml:"url" validate:"nonzero"`
+
+	// Events restricts the event types posted to the webhook, all event
+	// types are posted if empty.
+	Events []EventType `yaml:"events"`
+
+	// Headers are additional headers set on each request.
+	Headers map[string]string `yaml:"headers"`
+
+	// Timeout is the timeout of each request.
+	Timeout time.Duration `yaml:"timeout" validate:"min=0"`
+}
+
+// NewNotifier creates a new webhook notifier from the configuration.
+func (c Configuration) NewNotifier(
+	hostID string,
+	iopts instrument.Options,
+) (Notifier, error) {
+	opts := NewOptions().
+		SetInstrumentOptions(iopts).
+		SetHostID(hostID)
+	if c.QueueSize > 0 {
+		opts = opts.SetQueueSize(c.QueueSize)
+	}
+	if c.Retry != nil {
+		opts = opts.SetRetryOptions(c.Retry.NewOptions(iopts.MetricsScope()))
+	}
+
+	webhooks := make([]Webhook, 0, len(c.Webhooks))
+	for _, w := range c.Webhooks {
+		webhooks = append(webhooks, Webhook{
+			URL:     w.URL,
+			Events:  w.Events,
+			Headers: w.Headers,
+			Timeout: w.Timeout,
+		})
+	}
+
+	return NewWebhookNotifier(webhooks, opts)
+}
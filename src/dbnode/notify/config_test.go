@@ -0,0 +1,73 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package notify
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestConfigurationUnmarshal(t *testing.T) {
+	str := `
+webhooks:
+  - url: http://localhost:9000/events
+    events:
+      - bootstrap_complete
+      - repair_divergence
+    headers:
+      Authorization: Bearer token
+    timeout: 5s
+queueSize: 64
+retry:
+  maxRetries: 2
+`
+	var cfg Configuration
+	require.NoError(t, yaml.Unmarshal([]byte(str), &cfg))
+	require.Equal(t, 1, len(cfg.Webhooks))
+	require.Equal(t, []EventType{
+		EventTypeBootstrapComplete,
+		EventTypeRepairDivergence,
+	}, cfg.Webhooks[0].Events)
+	require.Equal(t, 5*time.Second, cfg.Webhooks[0].Timeout)
+	require.Equal(t, "Bearer token", cfg.Webhooks[0].Headers["Authorization"])
+	require.Equal(t, 64, cfg.QueueSize)
+	require.Equal(t, 2, cfg.Retry.MaxRetries)
+
+	n, err := cfg.NewNotifier("host", instrument.NewOptions())
+	require.NoError(t, err)
+	require.NoError(t, n.Close())
+}
+
+func TestConfigurationUnmarshalUnknownEventType(t *testing.T) {
+	str := `
+webhooks:
+  - url: http://localhost:9000/events
+    events:
+      - not_an_event
+`
+	var cfg Configuration
+	require.Error(t, yaml.Unmarshal([]byte(str), &cfg))
+}
@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package notify
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/retry"
+)
+
+const (
+	defaultQueueSize      = 1024
+	defaultInitialBackoff = time.Second
+	defaultMaxBackoff     = 30 * time.Second
+	defaultMaxRetries     = 5
+)
+
+var (
+	errInvalidQueueSize = errors.New("notifier queue size must be positive")
+	errNoRetryOptions   = errors.New("no retry options in notifier options")
+	errNoHTTPClient     = errors.New("no http client in notifier options")
+)
+
+type options struct {
+	instrumentOpts instrument.Options
+	clockOpts      clock.Options
+	retryOpts      retry.Options
+	hostID         string
+	queueSize      int
+	httpClient     *http.Client
+}
+
+// NewOptions creates new notifier options.
+func NewOptions() Options {
+	return &options{
+		instrumentOpts: instrument.NewOptions(),
+		clockOpts:      clock.NewOptions(),
+		retryOpts: retry.NewOptions().
+			SetInitialBackoff(defaultInitialBackoff).
+			SetMaxBackoff(defaultMaxBackoff).
+			SetMaxRetries(defaultMaxRetries),
+		queueSize:  defaultQueueSize,
+		httpClient: &http.Client{},
+	}
+}
+
+func (o *options) Validate() error {
+	if o.queueSize <= 0 {
+		return errInvalidQueueSize
+	}
+	if o.retryOpts == nil {
+		return errNoRetryOptions
+	}
+	if o.httpClient == nil {
+		return errNoHTTPClient
+	}
+	return nil
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetClockOptions(value clock.Options) Options {
+	opts := *o
+	opts.clockOpts = value
+	return &opts
+}
+
+func (o *options) ClockOptions() clock.Options {
+	return o.clockOpts
+}
+
+func (o *options) SetRetryOptions(value retry.Options) Options {
+	opts := *o
+	opts.retryOpts = value
+	return &opts
+}
+
+func (o *options) RetryOptions() retry.Options {
+	return o.retryOpts
+}
+
+func (o *options) SetHostID(value string) Options {
+	opts := *o
+	opts.hostID = value
+	return &opts
+}
+
+func (o *options) HostID() string {
+	return o.hostID
+}
+
+func (o *options) SetQueueSize(value int) Options {
+	opts := *o
+	opts.queueSize = value
+	return &opts
+}
+
+func (o *options) QueueSize() int {
+	return o.queueSize
+}
+
+func (o *options) SetHTTPClient(value *http.Client) Options {
+	opts := *o
+	opts.httpClient = value
+	return &opts
+}
+
+func (o *options) HTTPClient() *http.Client {
+	return o.httpClient
+}
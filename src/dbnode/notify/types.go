@@ -0,0 +1,140 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package notify provides delivery of node lifecycle events to external
+// receivers such as webhooks.
+package notify
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/retry"
+)
+
+// EventType is the type of a lifecycle event.
+type EventType string
+
+const (
+	// EventTypeBootstrapComplete is emitted once the node finishes bootstrapping.
+	EventTypeBootstrapComplete EventType = "bootstrap_complete"
+
+	// EventTypeRepairDivergence is emitted when a shard repair finds more
+	// divergent blocks between replicas than the configured threshold.
+	EventTypeRepairDivergence EventType = "repair_divergence"
+
+	// EventTypeDiskWatchdogTriggered is emitted when the disk watchdog trips.
+	EventTypeDiskWatchdogTriggered EventType = "disk_watchdog_triggered"
+
+	// EventTypeNamespaceQuotaBreached is emitted when a namespace exceeds its quota.
+	EventTypeNamespaceQuotaBreached EventType = "namespace_quota_breached"
+
+	// EventTypeShardDegraded is emitted when a shard exceeds its read error
+	// budget and is isolated from serving reads.
+	EventTypeShardDegraded EventType = "shard_degraded"
+)
+
+var validEventTypes = []EventType{
+	EventTypeBootstrapComplete,
+	EventTypeRepairDivergence,
+	EventTypeDiskWatchdogTriggered,
+	EventTypeNamespaceQuotaBreached,
+	EventTypeShardDegraded,
+}
+
+// Event is a structured lifecycle event.
+type Event struct {
+	Type      EventType              `json:"type"`
+	Time      time.Time              `json:"time"`
+	HostID    string                 `json:"hostID,omitempty"`
+	Namespace string                 `json:"namespace,omitempty"`
+	Message   string                 `json:"message,omitempty"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// Notifier delivers lifecycle events.
+type Notifier interface {
+	// Notify enqueues an event for delivery, it never blocks and drops the
+	// event if the delivery queue is full.
+	Notify(event Event)
+
+	// Close stops the notifier, waiting for enqueued events to be delivered.
+	Close() error
+}
+
+// Webhook is a receiver of events via HTTP POST.
+type Webhook struct {
+	// URL is the URL events are posted to.
+	URL string
+
+	// Events is the set of event types delivered to the webhook, all event
+	// types are delivered if empty.
+	Events []EventType
+
+	// Headers are additional headers set on each request.
+	Headers map[string]string
+
+	// Timeout is the timeout of each request.
+	Timeout time.Duration
+}
+
+// Options are the notifier options.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetClockOptions sets the clock options.
+	SetClockOptions(value clock.Options) Options
+
+	// ClockOptions returns the clock options.
+	ClockOptions() clock.Options
+
+	// SetRetryOptions sets the retry options used when delivering events.
+	SetRetryOptions(value retry.Options) Options
+
+	// RetryOptions returns the retry options used when delivering events.
+	RetryOptions() retry.Options
+
+	// SetHostID sets the host ID stamped on events that do not have one.
+	SetHostID(value string) Options
+
+	// HostID returns the host ID stamped on events that do not have one.
+	HostID() string
+
+	// SetQueueSize sets the number of events that can be pending delivery.
+	SetQueueSize(value int) Options
+
+	// QueueSize returns the number of events that can be pending delivery.
+	QueueSize() int
+
+	// SetHTTPClient sets the HTTP client used to deliver events.
+	SetHTTPClient(value *http.Client) Options
+
+	// HTTPClient returns the HTTP client used to deliver events.
+	HTTPClient() *http.Client
+}
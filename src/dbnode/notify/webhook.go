@@ -0,0 +1,267 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/x/retry"
+
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+var (
+	errNoWebhooks       = errors.New("no webhooks specified")
+	errWebhookNoURL     = errors.New("webhook must specify a url")
+	errNotifierClosed   = errors.New("notifier is closed")
+	errUnknownEventType = errors.New("unknown event type")
+)
+
+type noopNotifier struct{}
+
+// NewNoopNotifier returns a notifier that discards all events.
+func NewNoopNotifier() Notifier {
+	return noopNotifier{}
+}
+
+func (noopNotifier) Notify(event Event) {}
+func (noopNotifier) Close() error       { return nil }
+
+type webhookNotifierMetrics struct {
+	enqueued       tally.Counter
+	dropped        tally.Counter
+	delivered      tally.Counter
+	deliveryErrors tally.Counter
+}
+
+func newWebhookNotifierMetrics(scope tally.Scope) webhookNotifierMetrics {
+	return webhookNotifierMetrics{
+		enqueued:       scope.Counter("enqueued"),
+		dropped:        scope.Counter("dropped"),
+		delivered:      scope.Counter("delivered"),
+		deliveryErrors: scope.Counter("delivery-errors"),
+	}
+}
+
+type webhookNotifier struct {
+	sync.RWMutex
+
+	webhooks []Webhook
+	hostID   string
+	client   *http.Client
+	retrier  retry.Retrier
+	nowFn    clock.NowFn
+	logger   *zap.Logger
+	metrics  webhookNotifierMetrics
+
+	queue  chan Event
+	closed bool
+	doneCh chan struct{}
+}
+
+// NewWebhookNotifier returns a notifier that posts events as JSON to each
+// webhook subscribed to the event type, retrying failed deliveries.
+func NewWebhookNotifier(webhooks []Webhook, opts Options) (Notifier, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if len(webhooks) == 0 {
+		return nil, errNoWebhooks
+	}
+	for _, w := range webhooks {
+		if w.URL == "" {
+			return nil, errWebhookNoURL
+		}
+		for _, t := range w.Events {
+			if err := t.Validate(); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	scope := opts.InstrumentOptions().MetricsScope().SubScope("notify")
+	n := &webhookNotifier{
+		webhooks: webhooks,
+		hostID:   opts.HostID(),
+		client:   opts.HTTPClient(),
+		retrier: retry.NewRetrier(opts.RetryOptions().
+			SetMetricsScope(scope.SubScope("retry"))),
+		nowFn:   opts.ClockOptions().NowFn(),
+		logger:  opts.InstrumentOptions().Logger(),
+		metrics: newWebhookNotifierMetrics(scope),
+		queue:   make(chan Event, opts.QueueSize()),
+		doneCh:  make(chan struct{}),
+	}
+	go n.deliverLoop()
+	return n, nil
+}
+
+func (n *webhookNotifier) Notify(event Event) {
+	if event.Time.IsZero() {
+		event.Time = n.nowFn()
+	}
+	if event.HostID == "" {
+		event.HostID = n.hostID
+	}
+
+	n.RLock()
+	defer n.RUnlock()
+	if n.closed {
+		n.metrics.dropped.Inc(1)
+		return
+	}
+
+	select {
+	case n.queue <- event:
+		n.metrics.enqueued.Inc(1)
+	default:
+		n.metrics.dropped.Inc(1)
+		n.logger.Warn("notifier queue full, dropping event",
+			zap.String("type", string(event.Type)))
+	}
+}
+
+func (n *webhookNotifier) Close() error {
+	n.Lock()
+	if n.closed {
+		n.Unlock()
+		return errNotifierClosed
+	}
+	n.closed = true
+	close(n.queue)
+	n.Unlock()
+
+	<-n.doneCh
+	return nil
+}
+
+func (n *webhookNotifier) deliverLoop() {
+	defer close(n.doneCh)
+
+	for event := range n.queue {
+		body, err := json.Marshal(event)
+		if err != nil {
+			n.metrics.deliveryErrors.Inc(1)
+			n.logger.Error("could not encode event",
+				zap.String("type", string(event.Type)), zap.Error(err))
+			continue
+		}
+
+		for _, w := range n.webhooks {
+			if !w.subscribed(event.Type) {
+				continue
+			}
+			if err := n.retrier.Attempt(func() error {
+				return n.post(w, body)
+			}); err != nil {
+				n.metrics.deliveryErrors.Inc(1)
+				n.logger.Error("could not deliver event to webhook",
+					zap.String("type", string(event.Type)),
+					zap.String("url", w.URL), zap.Error(err))
+				continue
+			}
+			n.metrics.delivered.Inc(1)
+		}
+	}
+}
+
+func (n *webhookNotifier) post(w Webhook, body []byte) error {
+	ctx := context.Background()
+	if w.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, w.Timeout)
+		defer cancel()
+	}
+
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return retry.NonRetryableError(err)
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range w.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return err
+	}
+	// Drain the body so the connection can be reused.
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return nil
+	}
+
+	err = fmt.Errorf("webhook returned status %d", resp.StatusCode)
+	if resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests {
+		return err
+	}
+	return retry.NonRetryableError(err)
+}
+
+func (w Webhook) subscribed(t EventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+	for _, e := range w.Events {
+		if e == t {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate validates that the event type is known.
+func (t EventType) Validate() error {
+	for _, valid := range validEventTypes {
+		if t == valid {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v: %s", errUnknownEventType, string(t))
+}
+
+// UnmarshalYAML unmarshals an EventType into a valid type from string.
+func (t *EventType) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	value := EventType(str)
+	if err := value.Validate(); err != nil {
+		return err
+	}
+	*t = value
+	return nil
+}
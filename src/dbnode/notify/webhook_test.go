@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package notify
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/retry"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testReceiver struct {
+	sync.Mutex
+	events   []Event
+	headers  []http.Header
+	statuses []int
+}
+
+func (r *testReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.Lock()
+	defer r.Unlock()
+
+	status := http.StatusOK
+	if len(r.statuses) > 0 {
+		status = r.statuses[0]
+		r.statuses = r.statuses[1:]
+	}
+	if status == http.StatusOK {
+		var event Event
+		if err := json.NewDecoder(req.Body).Decode(&event); err != nil {
+			status = http.StatusBadRequest
+		} else {
+			r.events = append(r.events, event)
+			r.headers = append(r.headers, req.Header)
+		}
+	}
+	w.WriteHeader(status)
+}
+
+func (r *testReceiver) received() []Event {
+	r.Lock()
+	defer r.Unlock()
+	return append([]Event(nil), r.events...)
+}
+
+func newTestOptions() Options {
+	now := time.Unix(1500000000, 0).UTC()
+	opts := NewOptions()
+	return opts.
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+			return now
+		})).
+		SetRetryOptions(retry.NewOptions().
+			SetInitialBackoff(time.Millisecond).
+			SetMaxBackoff(time.Millisecond).
+			SetMaxRetries(3)).
+		SetHostID("testhost")
+}
+
+func TestWebhookNotifierDeliversSubscribedEvents(t *testing.T) {
+	all := &testReceiver{}
+	allServer := httptest.NewServer(all)
+	defer allServer.Close()
+
+	repairOnly := &testReceiver{}
+	repairOnlyServer := httptest.NewServer(repairOnly)
+	defer repairOnlyServer.Close()
+
+	n, err := NewWebhookNotifier([]Webhook{
+		{URL: allServer.URL, Headers: map[string]string{"X-Token": "secret"}},
+		{URL: repairOnlyServer.URL, Events: []EventType{EventTypeRepairDivergence}},
+	}, newTestOptions())
+	require.NoError(t, err)
+
+	n.Notify(Event{Type: EventTypeBootstrapComplete})
+	n.Notify(Event{
+		Type:      EventTypeRepairDivergence,
+		Namespace: "metrics",
+		Fields:    map[string]interface{}{"shard": 3},
+	})
+	require.NoError(t, n.Close())
+
+	events := all.received()
+	require.Equal(t, 2, len(events))
+	assert.Equal(t, EventTypeBootstrapComplete, events[0].Type)
+	assert.Equal(t, "testhost", events[0].HostID)
+	assert.True(t, time.Unix(1500000000, 0).Equal(events[0].Time))
+	assert.Equal(t, EventTypeRepairDivergence, events[1].Type)
+	assert.Equal(t, "secret", all.headers[0].Get("X-Token"))
+	assert.Equal(t, "application/json", all.headers[0].Get("Content-Type"))
+
+	events = repairOnly.received()
+	require.Equal(t, 1, len(events))
+	assert.Equal(t, EventTypeRepairDivergence, events[0].Type)
+	assert.Equal(t, "metrics", events[0].Namespace)
+	assert.Equal(t, float64(3), events[0].Fields["shard"])
+}
+
+func TestWebhookNotifierRetriesServerErrors(t *testing.T) {
+	r := &testReceiver{
+		statuses: []int{http.StatusInternalServerError, http.StatusTooManyRequests},
+	}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, err := NewWebhookNotifier([]Webhook{{URL: server.URL}}, newTestOptions())
+	require.NoError(t, err)
+
+	n.Notify(Event{Type: EventTypeBootstrapComplete})
+	require.NoError(t, n.Close())
+
+	require.Equal(t, 1, len(r.received()))
+}
+
+func TestWebhookNotifierDoesNotRetryClientErrors(t *testing.T) {
+	r := &testReceiver{
+		statuses: []int{http.StatusNotFound},
+	}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, err := NewWebhookNotifier([]Webhook{{URL: server.URL}}, newTestOptions())
+	require.NoError(t, err)
+
+	n.Notify(Event{Type: EventTypeBootstrapComplete})
+	n.Notify(Event{Type: EventTypeBootstrapComplete})
+	require.NoError(t, n.Close())
+
+	// First event is rejected and not retried, second is delivered.
+	require.Equal(t, 1, len(r.received()))
+}
+
+func TestWebhookNotifierNotifyAfterClose(t *testing.T) {
+	r := &testReceiver{}
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	n, err := NewWebhookNotifier([]Webhook{{URL: server.URL}}, newTestOptions())
+	require.NoError(t, err)
+	require.NoError(t, n.Close())
+	require.Equal(t, errNotifierClosed, n.Close())
+
+	n.Notify(Event{Type: EventTypeBootstrapComplete})
+	require.Equal(t, 0, len(r.received()))
+}
+
+func TestNewWebhookNotifierInvalid(t *testing.T) {
+	_, err := NewWebhookNotifier(nil, newTestOptions())
+	require.Equal(t, errNoWebhooks, err)
+
+	_, err = NewWebhookNotifier([]Webhook{{}}, newTestOptions())
+	require.Equal(t, errWebhookNoURL, err)
+
+	_, err = NewWebhookNotifier([]Webhook{
+		{URL: "http://localhost", Events: []EventType{"unknown"}},
+	}, newTestOptions())
+	require.Error(t, err)
+
+	_, err = NewWebhookNotifier([]Webhook{{URL: "http://localhost"}},
+		newTestOptions().SetQueueSize(0))
+	require.Equal(t, errInvalidQueueSize, err)
+}
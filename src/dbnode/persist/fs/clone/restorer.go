@@ -0,0 +1,300 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clone
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/ident/testutil"
+)
+
+var (
+	errRestoreInvalidNumShards = errors.New("destination number of shards must be positive")
+)
+
+type restorer struct {
+	opts Options
+}
+
+// NewRestorer creates a new fileset restorer
+func NewRestorer(opts Options) FileSetRestorer {
+	return &restorer{
+		opts: opts,
+	}
+}
+
+func (r *restorer) Restore(src RestoreSource, dest RestoreDestination) (RestoreResult, error) {
+	if dest.NumShards <= 0 {
+		return RestoreResult{}, errRestoreInvalidNumShards
+	}
+	destShards := make(map[uint32]struct{}, len(dest.Shards))
+	for _, shard := range dest.Shards {
+		if shard >= uint32(dest.NumShards) {
+			return RestoreResult{}, fmt.Errorf(
+				"destination shard %d out of range for %d shards", shard, dest.NumShards)
+		}
+		destShards[shard] = struct{}{}
+	}
+
+	filesets, err := r.sourceFileSets(src)
+	if err != nil {
+		return RestoreResult{}, err
+	}
+
+	// Restore a block at a time across all of the source shards so that each
+	// destination shard gets a single fileset per block.
+	blockStarts := make([]time.Time, 0, len(filesets))
+	for blockStart := range filesets {
+		blockStarts = append(blockStarts, time.Unix(0, blockStart))
+	}
+	sort.Slice(blockStarts, func(i, j int) bool {
+		return blockStarts[i].Before(blockStarts[j])
+	})
+
+	var (
+		result = RestoreResult{NumBlocks: len(blockStarts)}
+		hashFn = sharding.DefaultHashFn(dest.NumShards)
+	)
+	for _, blockStart := range blockStarts {
+		err := r.restoreBlock(src, dest, destShards, hashFn,
+			filesets[blockStart.UnixNano()], &result)
+		if err != nil {
+			return result, fmt.Errorf("unable to restore block %s: %v",
+				blockStart.String(), err)
+		}
+	}
+
+	return result, nil
+}
+
+// sourceFileSets returns the latest complete volume of each block of every
+// shard of the source, by block start.
+func (r *restorer) sourceFileSets(src RestoreSource) (map[int64][]fs.FileSetFile, error) {
+	var (
+		namespace = ident.StringID(src.Namespace)
+		dir       string
+		filesFn   func(string, ident.ID, uint32) (fs.FileSetFilesSlice, error)
+	)
+	switch src.FileSetType {
+	case persist.FileSetFlushType:
+		dir = fs.NamespaceDataDirPath(src.PathPrefix, namespace)
+		filesFn = fs.DataFiles
+	case persist.FileSetSnapshotType:
+		dir = fs.NamespaceSnapshotsDirPath(src.PathPrefix, namespace)
+		filesFn = fs.SnapshotFiles
+	default:
+		return nil, fmt.Errorf("unknown fileset type: %d", src.FileSetType)
+	}
+
+	entries, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("unable to list source shards: %v", err)
+	}
+
+	filesets := make(map[int64][]fs.FileSetFile)
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		shard, err := strconv.ParseUint(entry.Name(), 10, 32)
+		if err != nil {
+			continue
+		}
+
+		files, err := filesFn(src.PathPrefix, namespace, uint32(shard))
+		if err != nil {
+			return nil, fmt.Errorf("unable to list source shard %d filesets: %v", shard, err)
+		}
+
+		seen := make(map[int64]struct{}, len(files))
+		for _, file := range files {
+			blockStart := file.ID.BlockStart.UnixNano()
+			if _, ok := seen[blockStart]; ok {
+				continue
+			}
+			seen[blockStart] = struct{}{}
+
+			latest, ok := files.LatestVolumeForBlock(file.ID.BlockStart)
+			if !ok {
+				// No complete volume for the block.
+				continue
+			}
+			filesets[blockStart] = append(filesets[blockStart], latest)
+		}
+	}
+
+	return filesets, nil
+}
+
+func (r *restorer) restoreBlock(
+	src RestoreSource,
+	dest RestoreDestination,
+	destShards map[uint32]struct{},
+	hashFn sharding.HashFn,
+	filesets []fs.FileSetFile,
+	result *RestoreResult,
+) error {
+	fsopts := fs.NewOptions().
+		SetDataReaderBufferSize(r.opts.BufferSize()).
+		SetInfoReaderBufferSize(r.opts.BufferSize()).
+		SetWriterBufferSize(r.opts.BufferSize()).
+		SetNewFileMode(r.opts.FileMode()).
+		SetNewDirectoryMode(r.opts.DirMode()).
+		SetDecodingOptions(r.opts.DecodingOptions())
+
+	// NB: Writers are only closed once the whole block has been restored
+	// since closing a writer writes the checkpoint file, a partially restored
+	// block is left without checkpoint files and so is never bootstrapped.
+	writers := make(map[uint32]fs.DataFileSetWriter)
+	for _, fileset := range filesets {
+		err := r.restoreFileSet(fsopts, src, dest, destShards, hashFn,
+			fileset, writers, result)
+		if err != nil {
+			return err
+		}
+	}
+
+	for shard, writer := range writers {
+		if err := writer.Close(); err != nil {
+			return fmt.Errorf("unable to finalize writer for shard %d: %v", shard, err)
+		}
+	}
+
+	return nil
+}
+
+func (r *restorer) restoreFileSet(
+	fsopts fs.Options,
+	src RestoreSource,
+	dest RestoreDestination,
+	destShards map[uint32]struct{},
+	hashFn sharding.HashFn,
+	fileset fs.FileSetFile,
+	writers map[uint32]fs.DataFileSetWriter,
+	result *RestoreResult,
+) error {
+	reader, err := fs.NewReader(r.opts.BytesPool(), fsopts.SetFilePathPrefix(src.PathPrefix))
+	if err != nil {
+		return fmt.Errorf("unable to create fileset reader: %v", err)
+	}
+	openOpts := fs.DataReaderOpenOptions{
+		Identifier:  fileset.ID,
+		FileSetType: src.FileSetType,
+	}
+	if err := reader.Open(openOpts); err != nil {
+		return fmt.Errorf("unable to read source fileset: %v", err)
+	}
+	defer reader.Close()
+
+	blockRange := reader.Range()
+	for {
+		id, tagsIter, data, checksum, err := reader.Read()
+		if err != nil {
+			if err == io.EOF {
+				break
+			}
+			return fmt.Errorf("unexpected error while reading data: %v", err)
+		}
+
+		shard := hashFn(id)
+		if _, ok := destShards[shard]; len(destShards) > 0 && !ok {
+			id.Finalize()
+			tagsIter.Close()
+			data.Finalize()
+			result.NumSkipped++
+			continue
+		}
+
+		writer, ok := writers[shard]
+		if !ok {
+			writer, err = r.newWriter(fsopts, dest, shard, blockRange.Start,
+				blockRange.End.Sub(blockRange.Start))
+			if err != nil {
+				return err
+			}
+			writers[shard] = writer
+		}
+
+		tags, err := testutil.NewTagsFromTagIterator(tagsIter)
+		if err != nil {
+			return err
+		}
+
+		data.IncRef()
+		if err := writer.Write(id, tags, data, checksum); err != nil {
+			return fmt.Errorf("unexpected error while writing data: %v", err)
+		}
+		data.DecRef()
+		data.Finalize()
+		result.NumSeries++
+	}
+
+	return nil
+}
+
+func (r *restorer) newWriter(
+	fsopts fs.Options,
+	dest RestoreDestination,
+	shard uint32,
+	blockStart time.Time,
+	blockSize time.Duration,
+) (fs.DataFileSetWriter, error) {
+	namespace := ident.StringID(dest.Namespace)
+
+	// Restored filesets would shadow any existing data for the block since
+	// only the latest volume of a block is read, so refuse to restore over it.
+	nextVolume, err := fs.NextDataFileSetVolumeIndex(dest.PathPrefix,
+		namespace, shard, blockStart)
+	if err != nil {
+		return nil, fmt.Errorf("unable to check destination filesets: %v", err)
+	}
+	if nextVolume != 0 {
+		return nil, fmt.Errorf("destination shard %d already has data for block %s",
+			shard, blockStart.String())
+	}
+
+	writer, err := fs.NewWriter(fsopts.SetFilePathPrefix(dest.PathPrefix))
+	if err != nil {
+		return nil, fmt.Errorf("unable to create fileset writer: %v", err)
+	}
+	writerOpts := fs.DataWriterOpenOptions{
+		BlockSize: blockSize,
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  namespace,
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+	}
+	if err := writer.Open(writerOpts); err != nil {
+		return nil, fmt.Errorf("unable to open fileset writer: %v", err)
+	}
+	return writer, nil
+}
@@ -0,0 +1,208 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clone
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestorerReshards(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	opts := NewOptions()
+
+	blockSize := time.Hour
+	blockStart := time.Now().Truncate(blockSize)
+	src := RestoreSource{
+		PathPrefix:  path.Join(dir, "src"),
+		Namespace:   "testns-src",
+		FileSetType: persist.FileSetFlushType,
+	}
+	testBytes.IncRef()
+	defer testBytes.DecRef()
+	writeShardedTestData(t, src, 4, blockStart, blockSize, opts)
+
+	dest := RestoreDestination{
+		PathPrefix: path.Join(dir, "dest"),
+		Namespace:  "testns-dest",
+		NumShards:  8,
+	}
+	restorer := NewRestorer(opts)
+	result, err := restorer.Restore(src, dest)
+	require.NoError(t, err)
+	require.Equal(t, RestoreResult{NumBlocks: 1, NumSeries: numTestSeries}, result)
+
+	hashFn := sharding.DefaultHashFn(dest.NumShards)
+	numSeries := 0
+	for shard := uint32(0); shard < uint32(dest.NumShards); shard++ {
+		ids := readTestIDs(t, dest.PathPrefix, dest.Namespace, shard, blockStart, opts)
+		for _, id := range ids {
+			require.Equal(t, shard, hashFn(ident.StringID(id)))
+		}
+		numSeries += len(ids)
+	}
+	require.Equal(t, numTestSeries, numSeries)
+
+	// Restoring over existing data is not allowed.
+	_, err = restorer.Restore(src, dest)
+	require.Error(t, err)
+}
+
+func TestRestorerSubsetOfShards(t *testing.T) {
+	dir, err := ioutil.TempDir("", "restore")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	opts := NewOptions()
+
+	blockSize := time.Hour
+	blockStart := time.Now().Truncate(blockSize)
+	src := RestoreSource{
+		PathPrefix:  path.Join(dir, "src"),
+		Namespace:   "testns-src",
+		FileSetType: persist.FileSetFlushType,
+	}
+	testBytes.IncRef()
+	defer testBytes.DecRef()
+	writeShardedTestData(t, src, 4, blockStart, blockSize, opts)
+
+	dest := RestoreDestination{
+		PathPrefix: path.Join(dir, "dest"),
+		Namespace:  "testns-dest",
+		NumShards:  2,
+		Shards:     []uint32{1},
+	}
+	result, err := NewRestorer(opts).Restore(src, dest)
+	require.NoError(t, err)
+	require.Equal(t, int64(numTestSeries), result.NumSeries+result.NumSkipped)
+
+	ids := readTestIDs(t, dest.PathPrefix, dest.Namespace, 0, blockStart, opts)
+	require.Empty(t, ids)
+	ids = readTestIDs(t, dest.PathPrefix, dest.Namespace, 1, blockStart, opts)
+	require.Equal(t, int(result.NumSeries), len(ids))
+}
+
+func TestRestorerInvalidDestination(t *testing.T) {
+	restorer := NewRestorer(NewOptions())
+	_, err := restorer.Restore(RestoreSource{}, RestoreDestination{NumShards: 0})
+	require.Error(t, err)
+	_, err = restorer.Restore(RestoreSource{}, RestoreDestination{
+		NumShards: 2,
+		Shards:    []uint32{2},
+	})
+	require.Error(t, err)
+}
+
+func writeShardedTestData(
+	t *testing.T,
+	src RestoreSource,
+	numShards int,
+	blockStart time.Time,
+	blockSize time.Duration,
+	opts Options,
+) {
+	hashFn := sharding.DefaultHashFn(numShards)
+	writers := make(map[uint32]fs.DataFileSetWriter)
+	for i := 0; i < numTestSeries; i++ {
+		id := ident.StringID(fmt.Sprintf("test-series.%d", i))
+		shard := hashFn(id)
+		w, ok := writers[shard]
+		if !ok {
+			var err error
+			w, err = fs.NewWriter(fs.NewOptions().
+				SetFilePathPrefix(src.PathPrefix).
+				SetWriterBufferSize(opts.BufferSize()).
+				SetNewFileMode(opts.FileMode()).
+				SetNewDirectoryMode(opts.DirMode()))
+			require.NoError(t, err)
+			require.NoError(t, w.Open(fs.DataWriterOpenOptions{
+				BlockSize: blockSize,
+				Identifier: fs.FileSetFileIdentifier{
+					Namespace:  ident.StringID(src.Namespace),
+					Shard:      shard,
+					BlockStart: blockStart,
+				},
+			}))
+			writers[shard] = w
+		}
+		tags := ident.NewTags(ident.StringTag("foo", "bar"))
+		require.NoError(t, w.Write(id, tags, testBytes, 1234))
+	}
+	for _, w := range writers {
+		require.NoError(t, w.Close())
+	}
+}
+
+func readTestIDs(
+	t *testing.T,
+	pathPrefix string,
+	namespace string,
+	shard uint32,
+	blockStart time.Time,
+	opts Options,
+) []string {
+	files, err := fs.DataFiles(pathPrefix, ident.StringID(namespace), shard)
+	require.NoError(t, err)
+	if len(files) == 0 {
+		return nil
+	}
+
+	r, err := fs.NewReader(opts.BytesPool(), fs.NewOptions().
+		SetFilePathPrefix(pathPrefix).
+		SetDataReaderBufferSize(opts.BufferSize()).
+		SetInfoReaderBufferSize(opts.BufferSize()).
+		SetDecodingOptions(opts.DecodingOptions()))
+	require.NoError(t, err)
+	require.NoError(t, r.Open(fs.DataReaderOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  ident.StringID(namespace),
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+	}))
+	defer r.Close()
+
+	var ids []string
+	for {
+		id, tags, _, _, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		require.True(t, ident.NewTagIterMatcher(
+			ident.NewTagsIterator(ident.NewTags(ident.StringTag("foo", "bar")))).Matches(tags))
+		ids = append(ids, id.String())
+	}
+	return ids
+}
@@ -24,6 +24,7 @@ import (
 	"os"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/x/pool"
 )
@@ -43,6 +44,40 @@ type FileSetCloner interface {
 	Clone(src FileSetID, dest FileSetID, destBlocksize time.Duration) error
 }
 
+// RestoreSource is the collection of identifiers required to
+// identify the filesets of a namespace to restore from
+type RestoreSource struct {
+	PathPrefix  string
+	Namespace   string
+	FileSetType persist.FileSetType
+}
+
+// RestoreDestination is the collection of identifiers required to
+// identify the namespace to restore into and how it is sharded
+type RestoreDestination struct {
+	PathPrefix string
+	Namespace  string
+	NumShards  int
+	// Shards restricts the restore to a subset of the destination
+	// shards, all shards are restored if empty
+	Shards []uint32
+}
+
+// RestoreResult is the result of a restore
+type RestoreResult struct {
+	NumBlocks  int
+	NumSeries  int64
+	NumSkipped int64
+}
+
+// FileSetRestorer restores the filesets of a namespace into a namespace
+// that can be named and sharded differently
+type FileSetRestorer interface {
+	// Restore restores the latest volume of every block of every shard of the
+	// source, re-sharding each series by ID, into new data filesets
+	Restore(src RestoreSource, dest RestoreDestination) (RestoreResult, error)
+}
+
 // Options represents the knobs available while cloning
 type Options interface {
 	// SetBytesPool sets the bytesPool
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/fault"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/uber-go/tally"
@@ -49,6 +50,13 @@ var (
 	zeroFile = persist.CommitLogFile{}
 )
 
+const (
+	// maxPendingFsyncFns is the number of writes awaiting an fsync after which
+	// the commit log is fsynced even if the queue has not yet been drained, so
+	// that fsync acknowledgements are not starved under sustained load.
+	maxPendingFsyncFns = 1024
+)
+
 type newCommitLogWriterFn func(
 	flushFn flushFn,
 	opts Options,
@@ -67,6 +75,7 @@ type commitLogFailFn func(err error)
 type writeOrWriteBatch struct {
 	write      ts.Write
 	writeBatch ts.WriteBatch
+	durability ts.Durability
 }
 
 type commitLog struct {
@@ -160,6 +169,10 @@ type asyncResettableWriter struct {
 	// with commitlog 1 should be called as the writer associated with commitlog 2 may not have been
 	// flushed at all yet.
 	pendingFlushFns []callbackFn
+	// Writes acknowledged on fsync are only acknowledged once the writer has been
+	// fsynced, which for strategies other than StrategyWriteWait does not happen on
+	// every flush, so they are tracked separately from the pending flushFns.
+	pendingFsyncFns []callbackFn
 }
 
 func (w *asyncResettableWriter) onFlush(err error) {
@@ -181,6 +194,8 @@ type commitLogMetrics struct {
 	closeErrors      tally.Counter
 	flushErrors      tally.Counter
 	flushDone        tally.Counter
+	fsyncErrors      tally.Counter
+	fsyncDone        tally.Counter
 }
 
 type eventType int
@@ -279,6 +294,8 @@ func NewCommitLog(opts Options) (CommitLog, error) {
 			closeErrors:      scope.Counter("writes.close-errors"),
 			flushErrors:      scope.Counter("writes.flush-errors"),
 			flushDone:        scope.Counter("writes.flush-done"),
+			fsyncErrors:      scope.Counter("writes.fsync-errors"),
+			fsyncDone:        scope.Counter("writes.fsync-done"),
 		},
 	}
 	// Setup backreferences for onFlush().
@@ -454,6 +471,10 @@ func (l *commitLog) write() {
 
 	for write := range l.writes {
 		if write.eventType == flushEventType {
+			if len(l.writerState.primary.pendingFsyncFns) > 0 {
+				l.fsyncPrimary()
+				continue
+			}
 			l.writerState.primary.writer.Flush(false)
 			continue
 		}
@@ -471,12 +492,21 @@ func (l *commitLog) write() {
 
 		// For writes requiring acks add to pending acks
 		if write.eventType == writeEventType && write.callbackFn != nil {
-			l.writerState.primary.pendingFlushFns = append(
-				l.writerState.primary.pendingFlushFns, write.callbackFn)
+			if l.requiresFsync(write.write) {
+				l.writerState.primary.pendingFsyncFns = append(
+					l.writerState.primary.pendingFsyncFns, write.callbackFn)
+			} else {
+				l.writerState.primary.pendingFlushFns = append(
+					l.writerState.primary.pendingFlushFns, write.callbackFn)
+			}
 		}
 
 		isRotateLogsEvent := write.eventType == rotateLogsEventType
 		if isRotateLogsEvent {
+			// Writes awaiting an fsync must be acknowledged against the writer
+			// they were written to before it is swapped out.
+			l.fsyncPrimary()
+
 			primaryFile, _, err := l.openWriters()
 			if err != nil {
 				l.metrics.errors.Inc(1)
@@ -546,8 +576,18 @@ func (l *commitLog) write() {
 
 		atomic.AddInt64(&l.numWritesInQueue, int64(-numDequeued))
 		l.metrics.success.Inc(numWritesSuccess)
+
+		// Group writes awaiting an fsync into a single fsync once the queue has
+		// been drained, all writes dequeued since the last fsync are covered by it.
+		pendingFsyncs := len(l.writerState.primary.pendingFsyncFns)
+		if pendingFsyncs > 0 && (len(l.writes) == 0 || pendingFsyncs >= maxPendingFsyncFns) {
+			l.fsyncPrimary()
+		}
 	}
 
+	// Acknowledge any writes still awaiting an fsync before closing the writers.
+	l.fsyncPrimary()
+
 	// Ensure that there is no active background goroutine in the middle of reseting
 	// the secondary writer / modifying its state.
 	l.waitForSecondaryWriterAsyncResetComplete()
@@ -608,6 +648,49 @@ func (l *commitLog) onFlush(writer *asyncResettableWriter, err error) {
 	l.metrics.flushDone.Inc(1)
 }
 
+// requiresFsync returns whether a write must be acknowledged on an explicit
+// fsync of the writer, the StrategyWriteWait strategy fsyncs on every flush so
+// writes are only required to wait for an explicit fsync with other strategies.
+func (l *commitLog) requiresFsync(write writeOrWriteBatch) bool {
+	return write.durability == ts.DurabilityFsync &&
+		l.opts.Strategy() != StrategyWriteWait
+}
+
+// fsyncPrimary flushes and fsyncs the primary writer and acknowledges the writes
+// awaiting an fsync. It must only be called by the single-threaded writer goroutine.
+func (l *commitLog) fsyncPrimary() {
+	writer := &l.writerState.primary
+	if len(writer.pendingFsyncFns) == 0 {
+		return
+	}
+
+	var err error
+	if writer.writer == nil {
+		err = errCommitLogClosed
+	} else {
+		err = writer.writer.Flush(true)
+	}
+	if err != nil {
+		l.metrics.errors.Inc(1)
+		l.metrics.fsyncErrors.Inc(1)
+		l.log.Error("failed to fsync commit log", zap.Error(err))
+
+		if l.commitLogFailFn != nil {
+			l.commitLogFailFn(err)
+		}
+	}
+
+	for i := range writer.pendingFsyncFns {
+		writer.pendingFsyncFns[i](callbackResult{
+			eventType: flushEventType,
+			err:       err,
+		})
+		writer.pendingFsyncFns[i] = nil
+	}
+	writer.pendingFsyncFns = writer.pendingFsyncFns[:0]
+	l.metrics.fsyncDone.Inc(1)
+}
+
 // writerState lock must be held for the duration of this function call.
 func (l *commitLog) openWriters() (persist.CommitLogFile, persist.CommitLogFile, error) {
 	// Ensure that the previous asynchronous reset of the secondary writer (if any)
@@ -719,13 +802,26 @@ func (l *commitLog) Write(
 	unit xtime.Unit,
 	annotation ts.Annotation,
 ) error {
-	return l.writeFn(ctx, writeOrWriteBatch{
+	return l.WriteWithDurability(ctx, series, datapoint, unit, annotation,
+		ts.DurabilityDefault)
+}
+
+func (l *commitLog) WriteWithDurability(
+	ctx context.Context,
+	series ts.Series,
+	datapoint ts.Datapoint,
+	unit xtime.Unit,
+	annotation ts.Annotation,
+	durability ts.Durability,
+) error {
+	return l.writeDurable(ctx, writeOrWriteBatch{
 		write: ts.Write{
 			Series:     series,
 			Datapoint:  datapoint,
 			Unit:       unit,
 			Annotation: annotation,
 		},
+		durability: durability,
 	})
 }
 
@@ -733,11 +829,32 @@ func (l *commitLog) WriteBatch(
 	ctx context.Context,
 	writes ts.WriteBatch,
 ) error {
-	return l.writeFn(ctx, writeOrWriteBatch{
+	return l.writeDurable(ctx, writeOrWriteBatch{
 		writeBatch: writes,
+		durability: writes.Durability(),
 	})
 }
 
+// writeDurable writes with the requested durability, writes with the default
+// durability are acknowledged as determined by the commit log strategy.
+func (l *commitLog) writeDurable(
+	ctx context.Context,
+	write writeOrWriteBatch,
+) error {
+	if err := l.opts.FaultInjector().Inject(fault.CommitLogWrite); err != nil {
+		return err
+	}
+
+	switch write.durability {
+	case ts.DurabilityDefault:
+		return l.writeFn(ctx, write)
+	case ts.DurabilityMemory:
+		return l.writeBehind(ctx, write)
+	default:
+		return l.writeWait(ctx, write)
+	}
+}
+
 func (l *commitLog) writeWait(
 	ctx context.Context,
 	write writeOrWriteBatch,
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -92,6 +93,20 @@ func (mr *MockCommitLogMockRecorder) Write(ctx, series, datapoint, unit, annotat
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Write", reflect.TypeOf((*MockCommitLog)(nil).Write), ctx, series, datapoint, unit, annotation)
 }
 
+// WriteWithDurability mocks base method
+func (m *MockCommitLog) WriteWithDurability(ctx context.Context, series ts.Series, datapoint ts.Datapoint, unit time0.Unit, annotation ts.Annotation, durability ts.Durability) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteWithDurability", ctx, series, datapoint, unit, annotation, durability)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteWithDurability indicates an expected call of WriteWithDurability
+func (mr *MockCommitLogMockRecorder) WriteWithDurability(ctx, series, datapoint, unit, annotation, durability interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteWithDurability", reflect.TypeOf((*MockCommitLog)(nil).WriteWithDurability), ctx, series, datapoint, unit, annotation, durability)
+}
+
 // WriteBatch mocks base method
 func (m *MockCommitLog) WriteBatch(ctx context.Context, writes ts.WriteBatch) error {
 	m.ctrl.T.Helper()
@@ -613,3 +628,31 @@ func (mr *MockOptionsMockRecorder) IdentifierPool() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IdentifierPool", reflect.TypeOf((*MockOptions)(nil).IdentifierPool))
 }
+
+// SetFaultInjector mocks base method
+func (m *MockOptions) SetFaultInjector(value fault.Injector) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFaultInjector", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFaultInjector indicates an expected call of SetFaultInjector
+func (mr *MockOptionsMockRecorder) SetFaultInjector(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFaultInjector", reflect.TypeOf((*MockOptions)(nil).SetFaultInjector), value)
+}
+
+// FaultInjector mocks base method
+func (m *MockOptions) FaultInjector() fault.Injector {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FaultInjector")
+	ret0, _ := ret[0].(fault.Injector)
+	return ret0
+}
+
+// FaultInjector indicates an expected call of FaultInjector
+func (mr *MockOptionsMockRecorder) FaultInjector() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FaultInjector", reflect.TypeOf((*MockOptions)(nil).FaultInjector))
+}
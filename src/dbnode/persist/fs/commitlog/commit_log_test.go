@@ -38,7 +38,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/fortytw2/leaktest"
@@ -646,6 +648,29 @@ func TestCommitLogWriteErrorOnClosed(t *testing.T) {
 	require.Equal(t, errCommitLogClosed, err)
 }
 
+func TestCommitLogWriteInjectedFault(t *testing.T) {
+	opts, _ := newTestOptions(t, overrides{})
+	defer cleanup(t, opts)
+
+	opts = opts.SetFaultInjector(fault.NewInjector(map[fault.Point]fault.Faults{
+		fault.CommitLogWrite: {ErrorProbability: 1},
+	}, 0, instrument.NewOptions()))
+	commitLog := newTestCommitLog(t, opts)
+
+	series := testSeries(0, "foo.bar", testTags1, 127)
+	datapoint := ts.Datapoint{Timestamp: time.Now(), Value: 123.456}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	err := commitLog.Write(ctx, series, datapoint, xtime.Millisecond, nil)
+	require.Error(t, err)
+	require.True(t, fault.IsInjectedError(err))
+	require.Equal(t, int64(0), commitLog.QueueLength())
+
+	require.NoError(t, commitLog.Close())
+}
+
 func TestCommitLogWriteErrorOnFull(t *testing.T) {
 	// Set backlog of size one and don't automatically flush.
 	backlogQueueSize := 1
@@ -883,6 +908,104 @@ func TestCommitLogFailOnFlushError(t *testing.T) {
 	require.Equal(t, int64(2), flushErrors.Value())
 }
 
+func TestCommitLogWriteWithDurability(t *testing.T) {
+	opts, scope := newTestOptions(t, overrides{
+		strategy: StrategyWriteBehind,
+	})
+	defer cleanup(t, opts)
+
+	commitLogI, err := NewCommitLog(opts)
+	require.NoError(t, err)
+	commitLog := commitLogI.(*commitLog)
+	writer := newMockCommitLogWriter()
+
+	var syncs int64
+	writer.flushFn = func(sync bool) error {
+		if sync {
+			atomic.AddInt64(&syncs, 1)
+		}
+		commitLog.writerState.primary.onFlush(nil)
+		return nil
+	}
+
+	commitLog.newCommitLogWriterFn = func(
+		_ flushFn,
+		_ Options,
+	) commitLogWriter {
+		return writer
+	}
+
+	require.NoError(t, commitLog.Open())
+	atomic.StoreInt64(&syncs, 0)
+
+	var (
+		ctx    = context.NewContext()
+		series = testSeries(0, "foo.bar", testTags1, 127)
+		dp     = ts.Datapoint{Timestamp: time.Now(), Value: 123.456}
+	)
+
+	// Acknowledging on fsync waits for the writer to be fsynced.
+	require.NoError(t, commitLog.WriteWithDurability(ctx, series, dp,
+		xtime.Millisecond, nil, ts.DurabilityFsync))
+	require.Equal(t, int64(1), atomic.LoadInt64(&syncs))
+
+	fsyncs, ok := snapshotCounterValue(scope, "commitlog.writes.fsync-done")
+	require.True(t, ok)
+	require.Equal(t, int64(1), fsyncs.Value())
+
+	// Acknowledging on memory does not require an fsync.
+	require.NoError(t, commitLog.WriteWithDurability(ctx, series, dp,
+		xtime.Millisecond, nil, ts.DurabilityMemory))
+
+	require.NoError(t, commitLog.Close())
+	require.Equal(t, int64(1), atomic.LoadInt64(&syncs))
+}
+
+func TestCommitLogWriteBatchHonorsBatchDurability(t *testing.T) {
+	opts, _ := newTestOptions(t, overrides{
+		strategy: StrategyWriteBehind,
+	})
+	defer cleanup(t, opts)
+
+	commitLogI, err := NewCommitLog(opts)
+	require.NoError(t, err)
+	commitLog := commitLogI.(*commitLog)
+	writer := newMockCommitLogWriter()
+
+	var syncs int64
+	writer.flushFn = func(sync bool) error {
+		if sync {
+			atomic.AddInt64(&syncs, 1)
+		}
+		commitLog.writerState.primary.onFlush(nil)
+		return nil
+	}
+
+	commitLog.newCommitLogWriterFn = func(
+		_ flushFn,
+		_ Options,
+	) commitLogWriter {
+		return writer
+	}
+
+	require.NoError(t, commitLog.Open())
+	atomic.StoreInt64(&syncs, 0)
+
+	var (
+		ctx        = context.NewContext()
+		series     = testSeries(0, "foo.bar", testTags1, 127)
+		writeBatch = ts.NewWriteBatch(1, ident.StringID("ns"), func(ts.WriteBatch) {})
+	)
+	require.NoError(t, writeBatch.Add(0, series.ID, time.Now(), 123.456, xtime.Millisecond, nil))
+	writeBatch.SetOutcome(0, series, nil)
+	writeBatch.SetDurability(ts.DurabilityFsync)
+
+	require.NoError(t, commitLog.WriteBatch(ctx, writeBatch))
+	require.Equal(t, int64(1), atomic.LoadInt64(&syncs))
+
+	require.NoError(t, commitLog.Close())
+}
+
 func TestCommitLogActiveLogs(t *testing.T) {
 	opts, _ := newTestOptions(t, overrides{
 		strategy: StrategyWriteBehind,
@@ -0,0 +1,413 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"io"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+)
+
+// Summary summarizes the contents of a set of commit log files.
+type Summary struct {
+	// Files contains a summary for each of the commit log files read.
+	Files []FileSummary
+	// CorruptFiles contains the commit log files whose header could not
+	// be read and were not read at all.
+	CorruptFiles []ErrorWithPath
+	// Namespaces contains a summary of the entries read for each namespace,
+	// sorted by namespace.
+	Namespaces []NamespaceSummary
+	// Entries is the total number of entries read.
+	Entries int64
+	// Start is the earliest datapoint timestamp read.
+	Start time.Time
+	// End is the latest datapoint timestamp read.
+	End time.Time
+}
+
+// FileSummary summarizes the contents of a single commit log file.
+type FileSummary struct {
+	FilePath string
+	Index    int64
+	Entries  int64
+	Start    time.Time
+	End      time.Time
+	// Err is set if reading the file stopped early, typically due to a
+	// corrupt chunk, in which case the summary only covers the entries
+	// read before the error.
+	Err error
+}
+
+// NamespaceSummary summarizes the commit log entries for a namespace.
+type NamespaceSummary struct {
+	Namespace string
+	Entries   int64
+	Start     time.Time
+	End       time.Time
+	// Shards contains a summary of the entries for each shard, sorted by shard.
+	Shards []ShardSummary
+}
+
+// ShardSummary summarizes the commit log entries for a shard of a namespace.
+type ShardSummary struct {
+	Shard   uint32
+	Entries int64
+	Start   time.Time
+	End     time.Time
+}
+
+// ReplayPreviewOptions is the options struct for previewing a replay.
+type ReplayPreviewOptions struct {
+	// FileFilterPredicate selects the commit log files to read, all files
+	// are read if not set.
+	FileFilterPredicate FileFilterPredicate
+	// Namespaces are the namespaces that would be replayed into, entries
+	// for any other namespace are skipped.
+	Namespaces []namespace.Metadata
+	// Shards restricts the preview to the given shards if set.
+	Shards []uint32
+	// SkipFlushedBlocks skips blocks that already have a flushed data
+	// fileset on disk since bootstrapping fulfills these from the filesets
+	// rather than from the commit log.
+	SkipFlushedBlocks bool
+}
+
+// ReplayPreview describes what replaying a set of commit log files would
+// write, without writing anything.
+type ReplayPreview struct {
+	// Files contains a summary for each of the commit log files read.
+	Files []FileSummary
+	// CorruptFiles contains the commit log files that could not be read.
+	CorruptFiles []ErrorWithPath
+	// Blocks contains the blocks that would be written, sorted by namespace,
+	// shard and block start.
+	Blocks []ReplayBlockPreview
+	// SkippedUnknownNamespace is the number of entries skipped as their
+	// namespace is not one of the namespaces being replayed.
+	SkippedUnknownNamespace int64
+	// SkippedShard is the number of entries skipped as their shard is not
+	// one of the shards being replayed.
+	SkippedShard int64
+	// SkippedExpired is the number of entries skipped as they fall outside
+	// the retention period of their namespace.
+	SkippedExpired int64
+	// SkippedFlushed is the number of entries skipped as their block
+	// already has a flushed fileset on disk.
+	SkippedFlushed int64
+}
+
+// ReplayBlockPreview describes the writes a replay would make to a block.
+type ReplayBlockPreview struct {
+	Namespace  string
+	Shard      uint32
+	BlockStart time.Time
+	Series     int64
+	Datapoints int64
+	Start      time.Time
+	End        time.Time
+}
+
+type logEntrySeries struct {
+	namespace string
+	id        string
+	shard     uint32
+}
+
+type logEntryFn func(series logEntrySeries, entry LogEntry)
+
+// Summarize reads the commit log files selected by the predicate and returns
+// a summary of their contents, all files are read if the predicate is nil.
+// Reading continues with the next file when a corrupt chunk is encountered.
+func Summarize(opts Options, predicate FileFilterPredicate) (Summary, error) {
+	var (
+		result      Summary
+		byNamespace = make(map[string]*NamespaceSummary)
+		byShard     = make(map[string]map[uint32]*ShardSummary)
+	)
+	files, corruptFiles, err := readLogEntries(opts, predicate,
+		func(series logEntrySeries, entry LogEntry) {
+			ts := entry.Datapoint.Timestamp
+			result.Entries++
+			result.Start, result.End = extendRange(result.Start, result.End, ts)
+
+			nsSummary, ok := byNamespace[series.namespace]
+			if !ok {
+				nsSummary = &NamespaceSummary{Namespace: series.namespace}
+				byNamespace[series.namespace] = nsSummary
+				byShard[series.namespace] = make(map[uint32]*ShardSummary)
+			}
+			nsSummary.Entries++
+			nsSummary.Start, nsSummary.End = extendRange(nsSummary.Start, nsSummary.End, ts)
+
+			shardSummary, ok := byShard[series.namespace][series.shard]
+			if !ok {
+				shardSummary = &ShardSummary{Shard: series.shard}
+				byShard[series.namespace][series.shard] = shardSummary
+			}
+			shardSummary.Entries++
+			shardSummary.Start, shardSummary.End = extendRange(shardSummary.Start, shardSummary.End, ts)
+		})
+	if err != nil {
+		return Summary{}, err
+	}
+
+	result.Files = files
+	result.CorruptFiles = corruptFiles
+	result.Namespaces = make([]NamespaceSummary, 0, len(byNamespace))
+	for ns, nsSummary := range byNamespace {
+		nsSummary.Shards = make([]ShardSummary, 0, len(byShard[ns]))
+		for _, shardSummary := range byShard[ns] {
+			nsSummary.Shards = append(nsSummary.Shards, *shardSummary)
+		}
+		sort.Slice(nsSummary.Shards, func(i, j int) bool {
+			return nsSummary.Shards[i].Shard < nsSummary.Shards[j].Shard
+		})
+		result.Namespaces = append(result.Namespaces, *nsSummary)
+	}
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		return result.Namespaces[i].Namespace < result.Namespaces[j].Namespace
+	})
+
+	return result, nil
+}
+
+type replayBlockKey struct {
+	namespace  string
+	shard      uint32
+	blockStart int64
+}
+
+type replayBlock struct {
+	preview ReplayBlockPreview
+	series  map[string]struct{}
+}
+
+// PreviewReplay reads the commit log files selected by the options and
+// returns the blocks that replaying them would write to. Nothing is written
+// and the database is not required to be running.
+func PreviewReplay(opts Options, previewOpts ReplayPreviewOptions) (ReplayPreview, error) {
+	var (
+		result         ReplayPreview
+		filePathPrefix = opts.FilesystemOptions().FilePathPrefix()
+		now            = opts.ClockOptions().NowFn()()
+		namespaces     = make(map[string]namespace.Metadata, len(previewOpts.Namespaces))
+		shards         map[uint32]struct{}
+		blocks         = make(map[replayBlockKey]*replayBlock)
+		flushed        = make(map[replayBlockKey]bool)
+	)
+	for _, md := range previewOpts.Namespaces {
+		namespaces[md.ID().String()] = md
+	}
+	if len(previewOpts.Shards) > 0 {
+		shards = make(map[uint32]struct{}, len(previewOpts.Shards))
+		for _, shard := range previewOpts.Shards {
+			shards[shard] = struct{}{}
+		}
+	}
+
+	files, corruptFiles, err := readLogEntries(opts, previewOpts.FileFilterPredicate,
+		func(series logEntrySeries, entry LogEntry) {
+			md, ok := namespaces[series.namespace]
+			if !ok {
+				result.SkippedUnknownNamespace++
+				return
+			}
+			if shards != nil {
+				if _, ok := shards[series.shard]; !ok {
+					result.SkippedShard++
+					return
+				}
+			}
+
+			var (
+				ts         = entry.Datapoint.Timestamp
+				retention  = md.Options().RetentionOptions()
+				blockStart = ts.Truncate(retention.BlockSize())
+			)
+			if ts.Before(now.Add(-retention.RetentionPeriod())) {
+				result.SkippedExpired++
+				return
+			}
+
+			key := replayBlockKey{
+				namespace:  series.namespace,
+				shard:      series.shard,
+				blockStart: blockStart.UnixNano(),
+			}
+			if previewOpts.SkipFlushedBlocks {
+				isFlushed, ok := flushed[key]
+				if !ok {
+					// Treat an error checking for the fileset the same as the
+					// fileset not existing as bootstrapping would.
+					isFlushed, _ = fs.DataFileSetExists(filePathPrefix, md.ID(),
+						series.shard, blockStart, 0)
+					flushed[key] = isFlushed
+				}
+				if isFlushed {
+					result.SkippedFlushed++
+					return
+				}
+			}
+
+			block, ok := blocks[key]
+			if !ok {
+				block = &replayBlock{
+					preview: ReplayBlockPreview{
+						Namespace:  series.namespace,
+						Shard:      series.shard,
+						BlockStart: blockStart,
+					},
+					series: make(map[string]struct{}),
+				}
+				blocks[key] = block
+			}
+			if _, ok := block.series[series.id]; !ok {
+				block.series[series.id] = struct{}{}
+				block.preview.Series++
+			}
+			block.preview.Datapoints++
+			block.preview.Start, block.preview.End = extendRange(
+				block.preview.Start, block.preview.End, ts)
+		})
+	if err != nil {
+		return ReplayPreview{}, err
+	}
+
+	result.Files = files
+	result.CorruptFiles = corruptFiles
+	result.Blocks = make([]ReplayBlockPreview, 0, len(blocks))
+	for _, block := range blocks {
+		result.Blocks = append(result.Blocks, block.preview)
+	}
+	sort.Slice(result.Blocks, func(i, j int) bool {
+		a, b := result.Blocks[i], result.Blocks[j]
+		if a.Namespace != b.Namespace {
+			return a.Namespace < b.Namespace
+		}
+		if a.Shard != b.Shard {
+			return a.Shard < b.Shard
+		}
+		return a.BlockStart.Before(b.BlockStart)
+	})
+
+	return result, nil
+}
+
+func readLogEntries(
+	opts Options,
+	predicate FileFilterPredicate,
+	fn logEntryFn,
+) ([]FileSummary, []ErrorWithPath, error) {
+	if predicate == nil {
+		predicate = ReadAllPredicate()
+	}
+
+	files, corruptFiles, err := Files(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	files = filterFiles(files, predicate)
+	corruptFiles = filterCorruptFiles(corruptFiles, predicate)
+
+	summaries := make([]FileSummary, 0, len(files))
+	for _, file := range files {
+		summaries = append(summaries, readLogEntriesFromFile(opts, file, fn))
+	}
+
+	return summaries, corruptFiles, nil
+}
+
+func readLogEntriesFromFile(
+	opts Options,
+	file persist.CommitLogFile,
+	fn logEntryFn,
+) FileSummary {
+	summary := FileSummary{
+		FilePath: file.FilePath,
+		Index:    file.Index,
+	}
+
+	reader := newCommitLogReader(commitLogReaderOptions{
+		commitLogOptions:    opts,
+		returnMetadataAsRef: true,
+	})
+	index, err := reader.Open(file.FilePath)
+	if err != nil {
+		// NB: The reader closes itself if it fails to open.
+		summary.Err = err
+		return summary
+	}
+	defer reader.Close()
+
+	if index != file.Index {
+		summary.Err = errIndexDoesNotMatch
+		return summary
+	}
+
+	// Series metadata is only returned the first time a series is read
+	// from a file, so keep track of it by the series unique index.
+	seriesByIndex := make(map[uint64]logEntrySeries)
+	for {
+		entry, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			summary.Err = err
+			break
+		}
+
+		series, ok := seriesByIndex[entry.Metadata.SeriesUniqueIndex]
+		if !ok {
+			if entry.Series.Namespace == nil || entry.Series.ID == nil {
+				summary.Err = errCommitLogReaderMissingMetadata
+				break
+			}
+			series = logEntrySeries{
+				namespace: entry.Series.Namespace.String(),
+				id:        entry.Series.ID.String(),
+				shard:     entry.Series.Shard,
+			}
+			seriesByIndex[entry.Metadata.SeriesUniqueIndex] = series
+		}
+
+		summary.Entries++
+		summary.Start, summary.End = extendRange(summary.Start, summary.End,
+			entry.Datapoint.Timestamp)
+		fn(series, entry)
+	}
+
+	return summary
+}
+
+func extendRange(start, end, t time.Time) (time.Time, time.Time) {
+	if start.IsZero() || t.Before(start) {
+		start = t
+	}
+	if end.IsZero() || t.After(end) {
+		end = t
+	}
+	return start, end
+}
@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package commitlog
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeInspectTestCommitLogs(t *testing.T, now time.Time) Options {
+	opts, scope := newTestOptions(t, overrides{
+		nowFn:    func() time.Time { return now },
+		strategy: StrategyWriteWait,
+	})
+
+	commitLog := newTestCommitLog(t, opts)
+
+	otherSeries := ts.Series{
+		UniqueIndex: 2,
+		Namespace:   ident.StringID("otherNS"),
+		ID:          ident.StringID("foo.qux"),
+		Shard:       3,
+	}
+	writes := []testWrite{
+		{testSeries(0, "foo.bar", ident.Tags{}, 1), now, 1, xtime.Second, nil, nil},
+		{testSeries(0, "foo.bar", ident.Tags{}, 1), now.Add(time.Second), 2, xtime.Second, nil, nil},
+		{testSeries(1, "foo.baz", ident.Tags{}, 2), now.Add(-72 * time.Hour), 3, xtime.Second, nil, nil},
+		{otherSeries, now, 4, xtime.Second, nil, nil},
+	}
+	writeCommitLogs(t, scope, commitLog, writes).Wait()
+	require.NoError(t, commitLog.Close())
+
+	return opts
+}
+
+func TestSummarize(t *testing.T) {
+	now := time.Now().Truncate(2 * time.Hour).Add(time.Minute)
+	opts := writeInspectTestCommitLogs(t, now)
+	defer cleanup(t, opts)
+
+	summary, err := Summarize(opts, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, 0, len(summary.CorruptFiles))
+	for _, file := range summary.Files {
+		assert.NoError(t, file.Err)
+	}
+	assert.Equal(t, int64(4), summary.Entries)
+	assert.True(t, now.Add(-72*time.Hour).Equal(summary.Start))
+	assert.True(t, now.Add(time.Second).Equal(summary.End))
+
+	require.Equal(t, 2, len(summary.Namespaces))
+	assert.Equal(t, "otherNS", summary.Namespaces[0].Namespace)
+	assert.Equal(t, int64(1), summary.Namespaces[0].Entries)
+
+	testNS := summary.Namespaces[1]
+	assert.Equal(t, "testNS", testNS.Namespace)
+	assert.Equal(t, int64(3), testNS.Entries)
+	require.Equal(t, 2, len(testNS.Shards))
+	assert.Equal(t, ShardSummary{
+		Shard:   1,
+		Entries: 2,
+		Start:   testNS.Shards[0].Start,
+		End:     testNS.Shards[0].End,
+	}, testNS.Shards[0])
+	assert.True(t, now.Equal(testNS.Shards[0].Start))
+	assert.Equal(t, uint32(2), testNS.Shards[1].Shard)
+}
+
+func TestPreviewReplay(t *testing.T) {
+	now := time.Now().Truncate(2 * time.Hour).Add(time.Minute)
+	opts := writeInspectTestCommitLogs(t, now)
+	defer cleanup(t, opts)
+
+	md, err := namespace.NewMetadata(ident.StringID("testNS"), namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().
+			SetRetentionPeriod(48*time.Hour).
+			SetBlockSize(2*time.Hour)))
+	require.NoError(t, err)
+
+	preview, err := PreviewReplay(opts, ReplayPreviewOptions{
+		Namespaces: []namespace.Metadata{md},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, int64(1), preview.SkippedUnknownNamespace)
+	assert.Equal(t, int64(1), preview.SkippedExpired)
+	require.Equal(t, 1, len(preview.Blocks))
+
+	block := preview.Blocks[0]
+	assert.Equal(t, "testNS", block.Namespace)
+	assert.Equal(t, uint32(1), block.Shard)
+	assert.True(t, now.Truncate(2*time.Hour).Equal(block.BlockStart))
+	assert.Equal(t, int64(1), block.Series)
+	assert.Equal(t, int64(2), block.Datapoints)
+
+	preview, err = PreviewReplay(opts, ReplayPreviewOptions{
+		Namespaces: []namespace.Metadata{md},
+		Shards:     []uint32{2},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), preview.SkippedShard)
+	assert.Equal(t, int64(1), preview.SkippedExpired)
+	assert.Equal(t, 0, len(preview.Blocks))
+}
@@ -28,6 +28,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -81,6 +82,7 @@ type options struct {
 	bytesPool               pool.CheckedBytesPool
 	identPool               ident.Pool
 	readConcurrency         int
+	faultInjector           fault.Injector
 }
 
 // NewOptions creates new commit log options
@@ -99,6 +101,7 @@ func NewOptions() Options {
 			return pool.NewBytesPool(s, nil)
 		}),
 		readConcurrency: defaultReadConcurrency,
+		faultInjector:   fault.NewNopInjector(),
 	}
 	o.bytesPool.Init()
 	o.identPool = ident.NewPool(o.bytesPool, ident.PoolOptions{})
@@ -246,3 +249,13 @@ func (o *options) SetIdentifierPool(value ident.Pool) Options {
 func (o *options) IdentifierPool() ident.Pool {
 	return o.identPool
 }
+
+func (o *options) SetFaultInjector(value fault.Injector) Options {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
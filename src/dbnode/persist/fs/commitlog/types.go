@@ -28,6 +28,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/pool"
@@ -49,6 +50,15 @@ const (
 	StrategyWriteBehind
 )
 
+// Durability returns the durability class writes that do not request a
+// durability are acknowledged with when using the strategy.
+func (s Strategy) Durability() ts.Durability {
+	if s == StrategyWriteBehind {
+		return ts.DurabilityMemory
+	}
+	return ts.DurabilityCommitLogBuffer
+}
+
 // CommitLog provides a synchronized commit log
 type CommitLog interface {
 	// Open the commit log
@@ -63,7 +73,19 @@ type CommitLog interface {
 		annotation ts.Annotation,
 	) error
 
-	// WriteBatch is the same as Write, but in batch.
+	// WriteWithDurability is the same as Write, but acknowledges the write
+	// with the given durability rather than the one implied by the strategy.
+	WriteWithDurability(
+		ctx context.Context,
+		series ts.Series,
+		datapoint ts.Datapoint,
+		unit xtime.Unit,
+		annotation ts.Annotation,
+		durability ts.Durability,
+	) error
+
+	// WriteBatch is the same as Write, but in batch, acknowledging the
+	// writes with the durability of the batch.
 	WriteBatch(
 		ctx context.Context,
 		writes ts.WriteBatch,
@@ -214,6 +236,12 @@ type Options interface {
 
 	// IdentifierPool returns the IdentifierPool to use for pooling identifiers.
 	IdentifierPool() ident.Pool
+
+	// SetFaultInjector sets the injector of faults into commit log writes.
+	SetFaultInjector(value fault.Injector) Options
+
+	// FaultInjector returns the injector of faults into commit log writes.
+	FaultInjector() fault.Injector
 }
 
 // FileFilterInfo contains information about a commitog file that can be used to
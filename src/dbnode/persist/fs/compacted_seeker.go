@@ -0,0 +1,246 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var errCompactedSeekerRootNotFound = errors.New("compacted seeker root not found")
+
+type compactedSeekerKey struct {
+	shard               uint32
+	compactedBlockStart xtime.UnixNano
+	volume              int
+}
+
+// compactedSeekerRoot is a seeker of a compacted fileset that is shared by
+// the seekers of all the blocks the compacted fileset holds, so that the
+// index summaries and bloom filter of a compacted fileset are only held in
+// memory once. It is closed once the last seeker using it is closed.
+type compactedSeekerRoot struct {
+	seeker DataFileSetSeeker
+	refs   int
+}
+
+type compactedSeekerRoots struct {
+	sync.Mutex
+	roots map[compactedSeekerKey]*compactedSeekerRoot
+}
+
+func newCompactedSeekerRoots() *compactedSeekerRoots {
+	return &compactedSeekerRoots{
+		roots: make(map[compactedSeekerKey]*compactedSeekerRoot),
+	}
+}
+
+// getOrOpen returns the root seeker of a compacted fileset, opening it with
+// the given function if it is not yet open, and takes a reference to it. The
+// seeker opened is returned as is along with false if the fileset turns out
+// to not be a compacted fileset.
+func (r *compactedSeekerRoots) getOrOpen(
+	key compactedSeekerKey,
+	blockSize time.Duration,
+	openFn func() (DataFileSetSeeker, error),
+) (DataFileSetSeeker, bool, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	root, ok := r.roots[key]
+	if !ok {
+		seeker, err := openFn()
+		if err != nil {
+			return nil, false, err
+		}
+		if seeker.Range().Duration() <= blockSize {
+			return seeker, false, nil
+		}
+		root = &compactedSeekerRoot{seeker: seeker}
+		r.roots[key] = root
+	}
+	root.refs++
+	return root.seeker, true, nil
+}
+
+func (r *compactedSeekerRoots) acquire(key compactedSeekerKey) (DataFileSetSeeker, error) {
+	r.Lock()
+	defer r.Unlock()
+
+	root, ok := r.roots[key]
+	if !ok {
+		return nil, errCompactedSeekerRootNotFound
+	}
+	root.refs++
+	return root.seeker, nil
+}
+
+func (r *compactedSeekerRoots) release(key compactedSeekerKey) error {
+	r.Lock()
+	root, ok := r.roots[key]
+	if !ok {
+		r.Unlock()
+		return errCompactedSeekerRootNotFound
+	}
+	root.refs--
+	if root.refs > 0 {
+		r.Unlock()
+		return nil
+	}
+	delete(r.roots, key)
+	r.Unlock()
+
+	// Close after releasing the lock so any IO is done out of lock.
+	return root.seeker.Close()
+}
+
+// compactedSeeker is a seeker of a single block of a compacted fileset, it
+// seeks with a clone of the root seeker of the compacted fileset and returns
+// the data of the block from the data of each series.
+type compactedSeeker struct {
+	roots *compactedSeekerRoots
+	key   compactedSeekerKey
+	root  DataFileSetSeeker
+	clone ConcurrentDataFileSetSeeker
+	block xtime.Range
+}
+
+var _ DataFileSetSeeker = (*compactedSeeker)(nil)
+
+// newCompactedSeeker returns a seeker of a block of a compacted fileset, the
+// caller must have taken a reference to the root seeker which is released
+// when the seeker is closed.
+func newCompactedSeeker(
+	roots *compactedSeekerRoots,
+	key compactedSeekerKey,
+	root DataFileSetSeeker,
+	block xtime.Range,
+) (*compactedSeeker, error) {
+	clone, err := root.ConcurrentClone()
+	if err != nil {
+		roots.release(key)
+		return nil, err
+	}
+	return &compactedSeeker{
+		roots: roots,
+		key:   key,
+		root:  root,
+		clone: clone,
+		block: block,
+	}, nil
+}
+
+func (s *compactedSeeker) Open(
+	namespace ident.ID,
+	shard uint32,
+	start time.Time,
+	volume int,
+	resources ReusableSeekerResources,
+) error {
+	return errClonesShouldNotBeOpened
+}
+
+func (s *compactedSeeker) SeekByID(
+	id ident.ID,
+	resources ReusableSeekerResources,
+) (checked.Bytes, error) {
+	entry, err := s.SeekIndexEntry(id, resources)
+	if err != nil {
+		return nil, err
+	}
+	if entry.EncodedTags != nil {
+		entry.EncodedTags.DecRef()
+		entry.EncodedTags.Finalize()
+	}
+	return s.SeekByIndexEntry(entry, resources)
+}
+
+// SeekByIndexEntry returns the data of the block of the series of the index
+// entry, returning errSeekIDNotFound if the series has no data for the block.
+func (s *compactedSeeker) SeekByIndexEntry(
+	entry IndexEntry,
+	resources ReusableSeekerResources,
+) (checked.Bytes, error) {
+	data, err := s.clone.SeekByIndexEntry(entry, resources)
+	if err != nil {
+		return nil, err
+	}
+
+	data.IncRef()
+	buf := data.Bytes()
+	block, ok, err := compactedBlockAt(buf,
+		xtime.ToUnixNano(s.root.Range().Start), xtime.ToUnixNano(s.block.Start))
+	if err == nil && ok {
+		// Move the data of the block to the start of the buffer to avoid
+		// copying it into another buffer.
+		copy(buf, buf[block.offset:block.offset+block.size])
+		data.Resize(block.size)
+	}
+	data.DecRef()
+	if err != nil || !ok {
+		data.Finalize()
+		if err != nil {
+			return nil, err
+		}
+		return nil, errSeekIDNotFound
+	}
+	return data, nil
+}
+
+func (s *compactedSeeker) SeekIndexEntry(
+	id ident.ID,
+	resources ReusableSeekerResources,
+) (IndexEntry, error) {
+	return s.clone.SeekIndexEntry(id, resources)
+}
+
+func (s *compactedSeeker) Range() xtime.Range {
+	return s.block
+}
+
+func (s *compactedSeeker) ConcurrentIDBloomFilter() *ManagedConcurrentBloomFilter {
+	return s.root.ConcurrentIDBloomFilter()
+}
+
+func (s *compactedSeeker) ConcurrentClone() (ConcurrentDataFileSetSeeker, error) {
+	root, err := s.roots.acquire(s.key)
+	if err != nil {
+		return nil, err
+	}
+	return newCompactedSeeker(s.roots, s.key, root, s.block)
+}
+
+func (s *compactedSeeker) Close() error {
+	if s.clone == nil {
+		return nil
+	}
+	err := s.clone.Close()
+	s.clone = nil
+	if releaseErr := s.roots.release(s.key); err == nil {
+		err = releaseErr
+	}
+	return err
+}
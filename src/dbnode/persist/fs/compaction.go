@@ -0,0 +1,527 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/pool"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	// compactedBlocksVersion is the version of the encoding of the data of a
+	// series of a compacted fileset.
+	compactedBlocksVersion = 1
+
+	compactedBlockChecksumSize = 4
+)
+
+var (
+	errCompactedBlocksInvalidVersion = errors.New("compacted blocks have an invalid version")
+	errCompactedBlocksCorrupt        = errors.New("compacted blocks header is corrupt")
+
+	errCompactFileSetsNoSources      = errors.New("compact filesets requires at least one source")
+	errCompactFileSetsSourceMismatch = errors.New("compact filesets source and target namespaces or shards differ")
+	errCompactFileSetsSourceOrder    = errors.New("compact filesets sources must be in ascending order of block start")
+	errCompactFileSetsSourceRange    = errors.New("compact filesets source block start is outside of the compacted block")
+)
+
+// compactedBlock is the data of a series for a single block of a compacted
+// fileset. The data of a series in a compacted fileset holds the data of the
+// series for each block of the compacted block it has data for, prefixed by
+// a header that describes the blocks:
+//
+//     version            (1 byte)
+//     number of blocks   (uvarint)
+//     for each block:
+//       block start      (uvarint nanoseconds since the compacted block start)
+//       size             (uvarint)
+//       checksum         (4 bytes, big endian)
+//
+// The data of the blocks follows the header in the order of the header, as
+// it was flushed and without being re-encoded.
+type compactedBlock struct {
+	blockStart xtime.UnixNano
+	// offset is the offset of the data of the block from the start of the
+	// data of the series.
+	offset   int
+	size     int
+	checksum uint32
+}
+
+func encodeCompactedBlocksHeader(
+	buf []byte,
+	compactedBlockStart xtime.UnixNano,
+	blocks []compactedBlock,
+) []byte {
+	var scratch [binary.MaxVarintLen64]byte
+	buf = append(buf[:0], compactedBlocksVersion)
+	n := binary.PutUvarint(scratch[:], uint64(len(blocks)))
+	buf = append(buf, scratch[:n]...)
+	for _, block := range blocks {
+		n = binary.PutUvarint(scratch[:], uint64(block.blockStart-compactedBlockStart))
+		buf = append(buf, scratch[:n]...)
+		n = binary.PutUvarint(scratch[:], uint64(block.size))
+		buf = append(buf, scratch[:n]...)
+		binary.BigEndian.PutUint32(scratch[:compactedBlockChecksumSize], block.checksum)
+		buf = append(buf, scratch[:compactedBlockChecksumSize]...)
+	}
+	return buf
+}
+
+// decodeCompactedBlocks decodes the header of the data of a series of a
+// compacted fileset, appending the blocks it describes to the given slice.
+func decodeCompactedBlocks(
+	data []byte,
+	compactedBlockStart xtime.UnixNano,
+	blocks []compactedBlock,
+) ([]compactedBlock, error) {
+	if len(data) == 0 {
+		return nil, errCompactedBlocksCorrupt
+	}
+	if data[0] != compactedBlocksVersion {
+		return nil, errCompactedBlocksInvalidVersion
+	}
+	pos := 1
+	readUvarint := func() (uint64, error) {
+		v, n := binary.Uvarint(data[pos:])
+		if n <= 0 {
+			return 0, errCompactedBlocksCorrupt
+		}
+		pos += n
+		return v, nil
+	}
+
+	count, err := readUvarint()
+	if err != nil {
+		return nil, err
+	}
+	if count > uint64(len(data)) {
+		return nil, errCompactedBlocksCorrupt
+	}
+	start := len(blocks)
+	for i := uint64(0); i < count; i++ {
+		blockOffset, err := readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		size, err := readUvarint()
+		if err != nil {
+			return nil, err
+		}
+		if pos+compactedBlockChecksumSize > len(data) {
+			return nil, errCompactedBlocksCorrupt
+		}
+		checksum := binary.BigEndian.Uint32(data[pos:])
+		pos += compactedBlockChecksumSize
+		blocks = append(blocks, compactedBlock{
+			blockStart: compactedBlockStart + xtime.UnixNano(blockOffset),
+			size:       int(size),
+			checksum:   checksum,
+		})
+	}
+
+	offset := pos
+	for i := start; i < len(blocks); i++ {
+		blocks[i].offset = offset
+		offset += blocks[i].size
+		if blocks[i].size < 0 || offset > len(data) {
+			return nil, errCompactedBlocksCorrupt
+		}
+	}
+	return blocks, nil
+}
+
+// compactedBlockAt returns the block starting at the given time of the data
+// of a series of a compacted fileset, returning false if the series has no
+// data for the block.
+func compactedBlockAt(
+	data []byte,
+	compactedBlockStart xtime.UnixNano,
+	blockStart xtime.UnixNano,
+) (compactedBlock, bool, error) {
+	var scratch [8]compactedBlock
+	blocks, err := decodeCompactedBlocks(data, compactedBlockStart, scratch[:0])
+	if err != nil {
+		return compactedBlock{}, false, err
+	}
+	for _, block := range blocks {
+		if block.blockStart == blockStart {
+			return block, true, nil
+		}
+	}
+	return compactedBlock{}, false, nil
+}
+
+// DataFileSetForBlock returns the identifier of the data fileset volume that
+// holds the data of a block of a shard, which is either the fileset of the
+// block itself or the compacted fileset of the compacted block the block
+// belongs to, returning false if neither exists.
+func DataFileSetForBlock(
+	filePathPrefix string,
+	md namespace.Metadata,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (FileSetFileIdentifier, bool, error) {
+	id := FileSetFileIdentifier{
+		Namespace:   md.ID(),
+		Shard:       shard,
+		BlockStart:  blockStart,
+		VolumeIndex: volume,
+	}
+	exists, err := DataFileSetExists(filePathPrefix, md.ID(), shard, blockStart, volume)
+	if err != nil || exists {
+		return id, exists, err
+	}
+
+	compactionOpts := md.Options().CompactionOptions()
+	if !compactionOpts.Enabled {
+		return FileSetFileIdentifier{}, false, nil
+	}
+	compactedBlockStart := compactionOpts.CompactedBlockStart(blockStart)
+	if compactedBlockStart.Equal(blockStart) {
+		return FileSetFileIdentifier{}, false, nil
+	}
+	exists, err = DataFileSetExists(filePathPrefix, md.ID(), shard,
+		compactedBlockStart, volume)
+	if err != nil || !exists {
+		return FileSetFileIdentifier{}, false, err
+	}
+	id.BlockStart = compactedBlockStart
+	return id, true, nil
+}
+
+// NextCompactedFileSetVolume returns the volume of the compacted fileset of
+// a compacted block, which is the volume after the latest volume of any of
+// the blocks it holds so that it supersedes the filesets of all of them.
+func NextCompactedFileSetVolume(
+	files FileSetFilesSlice,
+	compactedBlock xtime.Range,
+) int {
+	volume := -1
+	for _, file := range files {
+		blockStart := file.ID.BlockStart
+		if blockStart.Before(compactedBlock.Start) || !blockStart.Before(compactedBlock.End) {
+			continue
+		}
+		if file.ID.VolumeIndex > volume {
+			volume = file.ID.VolumeIndex
+		}
+	}
+	return volume + 1
+}
+
+// CompactFileSetsOptions is a set of options used when compacting the data
+// filesets of the blocks of a compacted block into a single fileset.
+type CompactFileSetsOptions struct {
+	// Sources are the identifiers of the fileset volumes of the blocks
+	// compacted, in ascending order of block start.
+	Sources []FileSetFileIdentifier
+	// Target is the identifier of the compacted fileset volume written, its
+	// block start is the start of the compacted block.
+	Target FileSetFileIdentifier
+	// BlockSize is the block size of the compacted block.
+	BlockSize time.Duration
+}
+
+// CompactFileSets reads the data filesets of the blocks of a compacted block
+// and writes the data of each series for all of the blocks to a single entry
+// of the compacted fileset, returning the number of series written. The data
+// of each block is copied as is without being decoded, so the compacted
+// fileset has a single index entry and bloom filter entry for each series of
+// the compacted block.
+//
+// The sources are read one after the other and the data of a series for the
+// blocks after the one it is first read from is looked up with a seeker of
+// each of their filesets, so only the data of a single series is held in
+// memory at a time.
+//
+// Like the merger, compacting does not signal to the database of the
+// existence of the newly persisted data, nor does it clean up the source
+// filesets.
+func CompactFileSets(
+	reader DataFileSetReader,
+	writer DataFileSetWriter,
+	bytesPool pool.CheckedBytesPool,
+	identPool ident.Pool,
+	fsOpts Options,
+	opts CompactFileSetsOptions,
+) (int, error) {
+	if err := validateCompactFileSetsOptions(opts); err != nil {
+		return 0, err
+	}
+
+	resources := NewReusableSeekerResources(fsOpts)
+	seekers := make([]DataFileSetSeeker, 0, len(opts.Sources))
+	defer func() {
+		for _, seeker := range seekers {
+			seeker.Close()
+		}
+	}()
+	for _, source := range opts.Sources {
+		seeker := NewSeeker(fsOpts.FilePathPrefix(), fsOpts.DataReaderBufferSize(),
+			fsOpts.InfoReaderBufferSize(), bytesPool, false, fsOpts)
+		if err := seeker.Open(source.Namespace, source.Shard, source.BlockStart,
+			source.VolumeIndex, resources); err != nil {
+			return 0, err
+		}
+		seekers = append(seekers, seeker)
+	}
+
+	err := writer.Open(DataWriterOpenOptions{
+		FileSetType: persist.FileSetFlushType,
+		Identifier:  opts.Target,
+		BlockSize:   opts.BlockSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	c := &fileSetCompactor{
+		reader:              reader,
+		writer:              writer,
+		identPool:           identPool,
+		sources:             opts.Sources,
+		seekers:             seekers,
+		resources:           resources,
+		compactedBlockStart: xtime.ToUnixNano(opts.Target.BlockStart),
+	}
+	defer c.finalize()
+
+	written := 0
+	for i, source := range opts.Sources {
+		n, err := c.compactSource(i, source)
+		written += n
+		if err != nil {
+			writer.Close()
+			return 0, err
+		}
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+func validateCompactFileSetsOptions(opts CompactFileSetsOptions) error {
+	if len(opts.Sources) == 0 {
+		return errCompactFileSetsNoSources
+	}
+	compactedBlock := xtime.Range{
+		Start: opts.Target.BlockStart,
+		End:   opts.Target.BlockStart.Add(opts.BlockSize),
+	}
+	for i, source := range opts.Sources {
+		if !source.Namespace.Equal(opts.Target.Namespace) ||
+			source.Shard != opts.Target.Shard {
+			return errCompactFileSetsSourceMismatch
+		}
+		if i > 0 && !source.BlockStart.After(opts.Sources[i-1].BlockStart) {
+			return errCompactFileSetsSourceOrder
+		}
+		if source.BlockStart.Before(compactedBlock.Start) ||
+			!source.BlockStart.Before(compactedBlock.End) {
+			return errCompactFileSetsSourceRange
+		}
+	}
+	return nil
+}
+
+type fileSetCompactor struct {
+	reader              DataFileSetReader
+	writer              DataFileSetWriter
+	identPool           ident.Pool
+	sources             []FileSetFileIdentifier
+	seekers             []DataFileSetSeeker
+	resources           ReusableSeekerResources
+	compactedBlockStart xtime.UnixNano
+
+	blocks []compactedBlock
+	data   []checked.Bytes
+	header []byte
+
+	// IDs and tags read from disk are held on to by the writer until it is
+	// closed, so only finalize them once the target is written.
+	idsToFinalize  []ident.ID
+	tagsToFinalize []ident.Tags
+}
+
+func (c *fileSetCompactor) compactSource(
+	sourceIdx int,
+	source FileSetFileIdentifier,
+) (int, error) {
+	err := c.reader.Open(DataReaderOpenOptions{
+		Identifier:  source,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer c.reader.Close()
+
+	written := 0
+	for {
+		id, tagsIter, data, checksum, err := c.reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return written, err
+		}
+
+		compacted, err := c.compactedBefore(sourceIdx, id)
+		if err != nil || compacted {
+			id.Finalize()
+			tagsIter.Close()
+			data.Finalize()
+			if err != nil {
+				return written, err
+			}
+			continue
+		}
+		c.idsToFinalize = append(c.idsToFinalize, id)
+
+		tags, err := convert.TagsFromTagsIter(id, tagsIter, c.identPool)
+		tagsIter.Close()
+		if err != nil {
+			data.Finalize()
+			return written, err
+		}
+		c.tagsToFinalize = append(c.tagsToFinalize, tags)
+
+		if err := c.writeSeries(sourceIdx, id, tags, data, checksum); err != nil {
+			return written, err
+		}
+		written++
+	}
+
+	return written, c.reader.Validate()
+}
+
+// compactedBefore returns whether the series has data in the fileset of any
+// of the sources before the given source, in which case its data for all of
+// the sources has already been written.
+func (c *fileSetCompactor) compactedBefore(sourceIdx int, id ident.ID) (bool, error) {
+	for _, seeker := range c.seekers[:sourceIdx] {
+		if !seeker.ConcurrentIDBloomFilter().Test(id.Bytes()) {
+			continue
+		}
+		entry, err := seeker.SeekIndexEntry(id, c.resources)
+		if err == errSeekIDNotFound {
+			continue
+		}
+		if err != nil {
+			return false, err
+		}
+		if entry.EncodedTags != nil {
+			entry.EncodedTags.DecRef()
+			entry.EncodedTags.Finalize()
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+func (c *fileSetCompactor) writeSeries(
+	sourceIdx int,
+	id ident.ID,
+	tags ident.Tags,
+	data checked.Bytes,
+	checksum uint32,
+) error {
+	c.blocks = c.blocks[:0]
+	c.data = append(c.data[:0], nil)
+	defer func() {
+		for i, d := range c.data {
+			if d != nil {
+				d.DecRef()
+				d.Finalize()
+			}
+			c.data[i] = nil
+		}
+	}()
+
+	appendBlock := func(source FileSetFileIdentifier, d checked.Bytes, checksum uint32) {
+		d.IncRef()
+		c.data = append(c.data, d)
+		c.blocks = append(c.blocks, compactedBlock{
+			blockStart: xtime.ToUnixNano(source.BlockStart),
+			size:       d.Len(),
+			checksum:   checksum,
+		})
+	}
+
+	appendBlock(c.sources[sourceIdx], data, checksum)
+	for i := sourceIdx + 1; i < len(c.seekers); i++ {
+		seeker := c.seekers[i]
+		if !seeker.ConcurrentIDBloomFilter().Test(id.Bytes()) {
+			continue
+		}
+		d, err := seeker.SeekByID(id, c.resources)
+		if err == errSeekIDNotFound {
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		d.IncRef()
+		blockChecksum := digest.Checksum(d.Bytes())
+		d.DecRef()
+		appendBlock(c.sources[i], d, blockChecksum)
+	}
+
+	c.header = encodeCompactedBlocksHeader(c.header, c.compactedBlockStart, c.blocks)
+	header := checked.NewBytes(c.header, nil)
+	header.IncRef()
+	c.data[0] = header
+
+	d := digest.NewDigest()
+	for _, b := range c.data {
+		d = d.Update(b.Bytes())
+	}
+	if err := c.writer.WriteAll(id, tags, c.data, d.Sum32()); err != nil {
+		return fmt.Errorf("unable to write compacted series %s: %v", id.String(), err)
+	}
+	return nil
+}
+
+func (c *fileSetCompactor) finalize() {
+	for _, id := range c.idsToFinalize {
+		id.Finalize()
+	}
+	for _, tags := range c.tagsToFinalize {
+		tags.Finalize()
+	}
+	c.idsToFinalize = nil
+	c.tagsToFinalize = nil
+}
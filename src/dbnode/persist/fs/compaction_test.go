@@ -0,0 +1,285 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"io/ioutil"
+	"math"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testCompactedBlockSize = 3 * testBlockSize
+
+func newTestCompactionMetadata(t *testing.T) namespace.Metadata {
+	md, err := namespace.NewMetadata(testNs1ID, namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().
+			SetBlockSize(testBlockSize).
+			SetRetentionPeriod(30*24*time.Hour)).
+		SetArchivalOptions(namespace.ArchivalOptions{
+			Enabled:        true,
+			ImmutableAfter: 7 * 24 * time.Hour,
+		}).
+		SetCompactionOptions(namespace.CompactionOptions{
+			Enabled:   true,
+			BlockSize: testCompactedBlockSize,
+			After:     14 * 24 * time.Hour,
+		}))
+	require.NoError(t, err)
+	return md
+}
+
+// writeTestCompactedFileSet writes the filesets of the blocks of a compacted
+// block of shard 1 and compacts them, returning the starts of the blocks,
+// the data of the series for each block and the compacted fileset.
+func writeTestCompactedFileSet(
+	t *testing.T,
+	filePathPrefix string,
+) ([]time.Time, []map[string][]byte, FileSetFileIdentifier) {
+	var (
+		compactedStart = time.Now().Truncate(testCompactedBlockSize)
+		blockStarts    = []time.Time{
+			compactedStart,
+			compactedStart.Add(testBlockSize),
+			compactedStart.Add(2 * testBlockSize),
+		}
+		entries = [][]testEntry{
+			{
+				{"foo", map[string]string{"city": "nyc"}, []byte{1, 2, 3}},
+				{"bar", nil, []byte{4, 5}},
+			},
+			{
+				{"bar", nil, []byte{6}},
+			},
+			{
+				{"baz", map[string]string{"city": "sf"}, []byte{7, 8}},
+				{"foo", map[string]string{"city": "nyc"}, []byte{9}},
+			},
+		}
+		volumes = []int{0, 2, 0}
+		sources []FileSetFileIdentifier
+	)
+
+	w := newTestWriter(t, filePathPrefix)
+	expected := make([]map[string][]byte, 0, len(entries))
+	for i, blockStart := range blockStarts {
+		writeTestDataWithVolume(t, w, 1, blockStart, volumes[i], entries[i],
+			persist.FileSetFlushType)
+		sources = append(sources, FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       1,
+			BlockStart:  blockStart,
+			VolumeIndex: volumes[i],
+		})
+		blockData := make(map[string][]byte, len(entries[i]))
+		for _, entry := range entries[i] {
+			blockData[entry.id] = entry.data
+		}
+		expected = append(expected, blockData)
+	}
+
+	files, err := DataFiles(filePathPrefix, testNs1ID, 1)
+	require.NoError(t, err)
+	target := FileSetFileIdentifier{
+		Namespace:  testNs1ID,
+		Shard:      1,
+		BlockStart: compactedStart,
+		VolumeIndex: NextCompactedFileSetVolume(files, xtime.Range{
+			Start: compactedStart,
+			End:   compactedStart.Add(testCompactedBlockSize),
+		}),
+	}
+	require.Equal(t, 3, target.VolumeIndex)
+
+	written, err := CompactFileSets(newTestReader(t, filePathPrefix),
+		newTestWriter(t, filePathPrefix), testBytesPool, ident.NewPool(nil, ident.PoolOptions{}),
+		testDefaultOpts.SetFilePathPrefix(filePathPrefix), CompactFileSetsOptions{
+			Sources:   sources,
+			Target:    target,
+			BlockSize: testCompactedBlockSize,
+		})
+	require.NoError(t, err)
+	require.Equal(t, 3, written)
+
+	return blockStarts, expected, target
+}
+
+func TestCompactedBlocksRoundTrip(t *testing.T) {
+	var (
+		start  = xtime.ToUnixNano(time.Now().Truncate(testCompactedBlockSize))
+		blocks = []compactedBlock{
+			{blockStart: start, size: 3, checksum: 1},
+			{blockStart: start + xtime.UnixNano(2*testBlockSize), size: 2, checksum: math.MaxUint32},
+		}
+	)
+	header := encodeCompactedBlocksHeader(nil, start, blocks)
+	data := append(header, 1, 2, 3, 4, 5)
+
+	decoded, err := decodeCompactedBlocks(data, start, nil)
+	require.NoError(t, err)
+	blocks[0].offset = len(header)
+	blocks[1].offset = len(header) + 3
+	require.Equal(t, blocks, decoded)
+
+	block, ok, err := compactedBlockAt(data, start, blocks[1].blockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []byte{4, 5}, data[block.offset:block.offset+block.size])
+
+	_, ok, err = compactedBlockAt(data, start, start+xtime.UnixNano(testBlockSize))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// The data of the blocks must not extend past the data of the series.
+	_, err = decodeCompactedBlocks(data[:len(data)-1], start, nil)
+	require.Equal(t, errCompactedBlocksCorrupt, err)
+
+	data[0] = compactedBlocksVersion + 1
+	_, err = decodeCompactedBlocks(data, start, nil)
+	require.Equal(t, errCompactedBlocksInvalidVersion, err)
+}
+
+func TestDataFileSetForBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		md                        = newTestCompactionMetadata(t)
+		blockStarts, _, compacted = writeTestCompactedFileSet(t, dir)
+	)
+
+	// The filesets of the blocks are returned while they exist.
+	id, ok, err := DataFileSetForBlock(dir, md, 1, blockStarts[1], 2)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, id.BlockStart.Equal(blockStarts[1]))
+
+	// Blocks without a fileset of the volume are read from the compacted
+	// fileset of their compacted block.
+	id, ok, err = DataFileSetForBlock(dir, md, 1, blockStarts[2], compacted.VolumeIndex)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.True(t, id.BlockStart.Equal(compacted.BlockStart))
+	require.Equal(t, compacted.VolumeIndex, id.VolumeIndex)
+
+	_, ok, err = DataFileSetForBlock(dir, md, 1, blockStarts[2], compacted.VolumeIndex+1)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Namespaces without compaction only read the filesets of the blocks.
+	_, ok, err = DataFileSetForBlock(dir, testNs1Metadata(t), 1, blockStarts[2],
+		compacted.VolumeIndex)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestCompactFileSets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	blockStarts, expected, compacted := writeTestCompactedFileSet(t, dir)
+
+	reader := newTestReader(t, dir)
+	entries, err := VerifyDataFileSetContents(reader, compacted)
+	require.NoError(t, err)
+	require.Equal(t, 3, entries)
+
+	for i, blockStart := range blockStarts {
+		block := xtime.Range{Start: blockStart, End: blockStart.Add(testBlockSize)}
+		require.NoError(t, reader.Open(DataReaderOpenOptions{
+			Identifier:  compacted,
+			FileSetType: persist.FileSetFlushType,
+			Block:       block,
+		}))
+		require.True(t, block.Equal(reader.Range()))
+		require.Equal(t, len(expected[i]), reader.Entries())
+
+		read := make(map[string][]byte, len(expected[i]))
+		for {
+			id, tags, data, checksum, err := reader.Read()
+			if err == io.EOF {
+				break
+			}
+			require.NoError(t, err)
+
+			data.IncRef()
+			require.Equal(t, digest.Checksum(data.Bytes()), checksum)
+			read[id.String()] = append([]byte(nil), data.Bytes()...)
+			data.DecRef()
+			data.Finalize()
+
+			if id.String() != "bar" {
+				require.True(t, tags.Next())
+				require.Equal(t, "city", tags.Current().Name.String())
+			}
+			require.NoError(t, tags.Err())
+			tags.Close()
+			id.Finalize()
+		}
+		require.Equal(t, expected[i], read)
+		require.NoError(t, reader.Validate())
+		require.NoError(t, reader.Close())
+	}
+
+	// The metadata of a block is read without its data.
+	last := blockStarts[len(blockStarts)-1]
+	require.NoError(t, reader.Open(DataReaderOpenOptions{
+		Identifier:  compacted,
+		FileSetType: persist.FileSetFlushType,
+		Block:       xtime.Range{Start: last, End: last.Add(testBlockSize)},
+	}))
+	for {
+		id, tags, length, checksum, err := reader.ReadMetadata()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data := expected[len(expected)-1][id.String()]
+		require.Equal(t, len(data), length)
+		require.Equal(t, digest.Checksum(data), checksum)
+		tags.Close()
+		id.Finalize()
+	}
+	require.NoError(t, reader.ValidateMetadata())
+	require.NoError(t, reader.Close())
+
+	// Blocks outside of the compacted block are not held by the fileset.
+	outside := compacted.BlockStart.Add(testCompactedBlockSize)
+	err = reader.Open(DataReaderOpenOptions{
+		Identifier:  compacted,
+		FileSetType: persist.FileSetFlushType,
+		Block:       xtime.Range{Start: outside, End: outside.Add(testBlockSize)},
+	})
+	require.Equal(t, errReadBlockNotInFileSet, err)
+}
@@ -828,6 +828,16 @@ func ReadInfoFiles(
 	return infoFileResults
 }
 
+// DataFileSetRequiresMigration returns whether the data fileset described by
+// the info file was written with an older format version than the current one
+// and should be rewritten so that it can be read with the current read path.
+func DataFileSetRequiresMigration(info schema.IndexInfo) bool {
+	if info.MajorVersion != schema.MajorVersion {
+		return info.MajorVersion < schema.MajorVersion
+	}
+	return info.MinorVersion < schema.MinorVersion
+}
+
 // ReadIndexInfoFileResult is the result of reading an info file
 type ReadIndexInfoFileResult struct {
 	ID   FileSetFileIdentifier
@@ -1456,6 +1466,30 @@ func NextSnapshotFileSetVolumeIndex(filePathPrefix string, namespace ident.ID, s
 	return latestFile.ID.VolumeIndex + 1, nil
 }
 
+// NextDataFileSetVolumeIndex returns the next data file set index for a given
+// namespace/shard/blockStart combination, each volume of a block is a newer
+// version of the block that supersedes all lower volumes.
+//
+// The volume index is the write-state version of a block: cold flushes,
+// which also persist data loaded by repairs, write the volume after the
+// shard's cold version of the block and blocks streamed from peers write the
+// volume after the latest one on disk. Shards bootstrap their cold version
+// from the latest volume and delete the superseded volumes when cleaning up
+// compacted filesets.
+func NextDataFileSetVolumeIndex(filePathPrefix string, namespace ident.ID, shard uint32, blockStart time.Time) (int, error) {
+	dataFiles, err := DataFiles(filePathPrefix, namespace, shard)
+	if err != nil {
+		return -1, err
+	}
+
+	latestFile, ok := dataFiles.LatestVolumeForBlock(blockStart)
+	if !ok {
+		return 0, nil
+	}
+
+	return latestFile.ID.VolumeIndex + 1, nil
+}
+
 // NextIndexFileSetVolumeIndex returns the next index file set index for a given
 // namespace/blockStart combination.
 func NextIndexFileSetVolumeIndex(filePathPrefix string, namespace ident.ID, blockStart time.Time) (int, error) {
@@ -36,6 +36,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
@@ -761,6 +762,40 @@ func TestNextSnapshotMetadataFileIndex(t *testing.T) {
 	require.Equal(t, int64(numMetadataFiles), nextIdx)
 }
 
+func TestNextDataFileSetVolumeIndex(t *testing.T) {
+	var (
+		shard      = uint32(0)
+		dir        = createTempDir(t)
+		shardDir   = ShardDataDirPath(dir, testNs1ID, shard)
+		blockStart = time.Now().Truncate(time.Hour)
+	)
+	require.NoError(t, os.MkdirAll(shardDir, 0755))
+	defer os.RemoveAll(dir)
+
+	index, err := NextDataFileSetVolumeIndex(dir, testNs1ID, shard, blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 0, index)
+
+	// Check increments properly
+	curr := -1
+	for i := 0; i <= 10; i++ {
+		index, err := NextDataFileSetVolumeIndex(dir, testNs1ID, shard, blockStart)
+		require.NoError(t, err)
+		require.Equal(t, curr+1, index)
+		curr = index
+
+		w := newTestWriter(t, dir)
+		writeTestDataWithVolume(t, w, shard, blockStart, index,
+			[]testEntry{{"foo", nil, []byte{1, 2, 3}}}, persist.FileSetFlushType)
+	}
+
+	// Volumes of other blocks do not affect the next volume.
+	index, err = NextDataFileSetVolumeIndex(dir, testNs1ID, shard,
+		blockStart.Add(time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 0, index)
+}
+
 func TestNextIndexFileSetVolumeIndex(t *testing.T) {
 	// Make empty directory
 	dir := createTempDir(t)
@@ -1305,3 +1340,25 @@ func mustFileExists(t *testing.T, path string) bool {
 	require.NoError(t, err)
 	return exists
 }
+
+func TestDataFileSetRequiresMigration(t *testing.T) {
+	tests := []struct {
+		major    int64
+		minor    int64
+		expected bool
+	}{
+		{major: schema.MajorVersion, minor: schema.MinorVersion, expected: false},
+		{major: schema.MajorVersion, minor: schema.MinorVersion - 1, expected: true},
+		{major: schema.MajorVersion, minor: schema.MinorVersion + 1, expected: false},
+		{major: schema.MajorVersion - 1, minor: schema.MinorVersion + 1, expected: true},
+		{major: schema.MajorVersion + 1, minor: 0, expected: false},
+	}
+	for _, test := range tests {
+		info := schema.IndexInfo{
+			MajorVersion: test.major,
+			MinorVersion: test.minor,
+		}
+		assert.Equal(t, test.expected, DataFileSetRequiresMigration(info),
+			"major=%d, minor=%d", test.major, test.minor)
+	}
+}
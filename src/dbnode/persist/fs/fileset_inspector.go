@@ -0,0 +1,602 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/pborman/uuid"
+)
+
+const (
+	// numFileSetDigests is the number of digests stored in a data fileset
+	// digests file: info, index, summaries, bloom filter and data.
+	numFileSetDigests = 5
+)
+
+var (
+	errInspectorIndexContentType = errors.New("fileset inspector only supports data filesets")
+	errInspectorDigestsSize      = errors.New("fileset digests file has unexpected size")
+)
+
+// DumpFormat is the format used when writing out fileset inspection results.
+type DumpFormat uint
+
+const (
+	// DumpFormatText writes one human readable line per record.
+	DumpFormatText DumpFormat = iota
+	// DumpFormatJSON writes one JSON object per line.
+	DumpFormatJSON
+)
+
+// String returns the string representation of the dump format.
+func (f DumpFormat) String() string {
+	switch f {
+	case DumpFormatText:
+		return "text"
+	case DumpFormatJSON:
+		return "json"
+	default:
+		return "unknown"
+	}
+}
+
+// ParseDumpFormat parses a dump format from its string representation.
+func ParseDumpFormat(str string) (DumpFormat, error) {
+	switch strings.ToLower(str) {
+	case "text":
+		return DumpFormatText, nil
+	case "json":
+		return DumpFormatJSON, nil
+	default:
+		return 0, fmt.Errorf("unknown dump format: %s", str)
+	}
+}
+
+// FileSetInspectorOptions is the options struct for opening a fileset inspector.
+type FileSetInspectorOptions struct {
+	Identifier  FileSetFileIdentifier
+	FileSetType persist.FileSetType
+	// IDFilter restricts the series listed and dumped to those whose ID
+	// contains the filter, all series are included if empty.
+	IDFilter string
+	// EncodingOptions are used to decode series datapoints, the default
+	// encoding options are used if not set.
+	EncodingOptions encoding.Options
+}
+
+// FileSetInspector provides programmatic access to the contents of a data
+// fileset on disk, such as the series it contains, their datapoints and the
+// info and digests metadata of the fileset.
+type FileSetInspector interface {
+	// Info returns the info and digests metadata of the fileset.
+	Info() FileSetInfo
+
+	// Series returns the metadata of the series in the fileset.
+	Series() ([]FileSetSeries, error)
+
+	// ForEachSeries decodes each series in the fileset and calls fn with
+	// the series and its datapoints, stopping at the first error returned.
+	ForEachSeries(fn func(series FileSetSeriesDatapoints) error) error
+
+	// WriteInfo writes the info and digests metadata of the fileset.
+	WriteInfo(w io.Writer, format DumpFormat) error
+
+	// WriteSeries writes the metadata of each series in the fileset.
+	WriteSeries(w io.Writer, format DumpFormat) error
+
+	// WriteDatapoints writes the datapoints of each series in the fileset.
+	WriteDatapoints(w io.Writer, format DumpFormat) error
+}
+
+// FileSetInfo describes the info and digests metadata of a fileset.
+type FileSetInfo struct {
+	Namespace    string         `json:"namespace"`
+	Shard        uint32         `json:"shard"`
+	FileSetType  string         `json:"filesetType"`
+	BlockStart   time.Time      `json:"blockStart"`
+	BlockSize    time.Duration  `json:"blockSize"`
+	VolumeIndex  int            `json:"volumeIndex"`
+	MajorVersion int64          `json:"majorVersion"`
+	MinorVersion int64          `json:"minorVersion"`
+	Entries      int64          `json:"entries"`
+	Summaries    int64          `json:"summaries"`
+	BloomFilterM int64          `json:"bloomFilterNumElementsM"`
+	BloomFilterK int64          `json:"bloomFilterNumHashesK"`
+	SnapshotTime *time.Time     `json:"snapshotTime,omitempty"`
+	SnapshotID   string         `json:"snapshotID,omitempty"`
+	Digests      FileSetDigests `json:"digests"`
+}
+
+// FileSetDigests contains the digests of each of the files in a fileset.
+type FileSetDigests struct {
+	Info        uint32 `json:"info"`
+	Index       uint32 `json:"index"`
+	Summaries   uint32 `json:"summaries"`
+	BloomFilter uint32 `json:"bloomFilter"`
+	Data        uint32 `json:"data"`
+}
+
+// FileSetSeries describes a series stored in a fileset.
+type FileSetSeries struct {
+	ID       string            `json:"id"`
+	Tags     map[string]string `json:"tags,omitempty"`
+	Length   int               `json:"length"`
+	Checksum uint32            `json:"checksum"`
+}
+
+// FileSetSeriesDatapoints is a series stored in a fileset along with its
+// decoded datapoints.
+type FileSetSeriesDatapoints struct {
+	FileSetSeries
+	Datapoints []FileSetDatapoint `json:"datapoints"`
+}
+
+// FileSetDatapoint is a datapoint decoded from a fileset.
+type FileSetDatapoint struct {
+	Timestamp  time.Time  `json:"timestamp"`
+	Value      float64    `json:"value"`
+	Unit       xtime.Unit `json:"-"`
+	Annotation []byte     `json:"annotation,omitempty"`
+}
+
+// MarshalJSON marshals the datapoint, writing non-finite values as strings
+// since they cannot be represented as JSON numbers.
+func (d FileSetDatapoint) MarshalJSON() ([]byte, error) {
+	var value interface{} = d.Value
+	if math.IsNaN(d.Value) || math.IsInf(d.Value, 0) {
+		value = strconv.FormatFloat(d.Value, 'f', -1, 64)
+	}
+	return json.Marshal(struct {
+		Timestamp  time.Time   `json:"timestamp"`
+		Value      interface{} `json:"value"`
+		Annotation []byte      `json:"annotation,omitempty"`
+	}{
+		Timestamp:  d.Timestamp,
+		Value:      value,
+		Annotation: d.Annotation,
+	})
+}
+
+type fileSetInspector struct {
+	opts         Options
+	inspectOpts  FileSetInspectorOptions
+	encodingOpts encoding.Options
+	info         FileSetInfo
+}
+
+// NewFileSetInspector returns a new inspector for the data fileset described
+// by the inspector options. The checkpoint, digests and info files of the
+// fileset are read and validated up front.
+func NewFileSetInspector(
+	opts Options,
+	inspectOpts FileSetInspectorOptions,
+) (FileSetInspector, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	if inspectOpts.Identifier.FileSetContentType != persist.FileSetDataContentType {
+		return nil, errInspectorIndexContentType
+	}
+
+	encodingOpts := inspectOpts.EncodingOptions
+	if encodingOpts == nil {
+		encodingOpts = encoding.NewOptions()
+	}
+
+	info, err := readFileSetInfo(opts, inspectOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileSetInspector{
+		opts:         opts,
+		inspectOpts:  inspectOpts,
+		encodingOpts: encodingOpts,
+		info:         info,
+	}, nil
+}
+
+func (i *fileSetInspector) Info() FileSetInfo {
+	return i.info
+}
+
+func (i *fileSetInspector) Series() ([]FileSetSeries, error) {
+	var result []FileSetSeries
+	err := i.forEachSeries(false, func(series FileSetSeriesDatapoints) error {
+		result = append(result, series.FileSetSeries)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+func (i *fileSetInspector) ForEachSeries(
+	fn func(series FileSetSeriesDatapoints) error,
+) error {
+	return i.forEachSeries(true, fn)
+}
+
+func (i *fileSetInspector) WriteInfo(w io.Writer, format DumpFormat) error {
+	switch format {
+	case DumpFormatJSON:
+		return json.NewEncoder(w).Encode(i.info)
+	case DumpFormatText:
+		info := i.info
+		_, err := fmt.Fprintf(w,
+			"namespace: %s\nshard: %d\nfilesetType: %s\nblockStart: %s\n"+
+				"blockSize: %s\nvolumeIndex: %d\nmajorVersion: %d\nminorVersion: %d\n"+
+				"entries: %d\nsummaries: %d\nbloomFilterNumElementsM: %d\n"+
+				"bloomFilterNumHashesK: %d\n",
+			info.Namespace, info.Shard, info.FileSetType,
+			info.BlockStart.Format(time.RFC3339Nano), info.BlockSize,
+			info.VolumeIndex, info.MajorVersion, info.MinorVersion, info.Entries,
+			info.Summaries, info.BloomFilterM, info.BloomFilterK)
+		if err != nil {
+			return err
+		}
+		if info.SnapshotTime != nil {
+			_, err := fmt.Fprintf(w, "snapshotTime: %s\nsnapshotID: %s\n",
+				info.SnapshotTime.Format(time.RFC3339Nano), info.SnapshotID)
+			if err != nil {
+				return err
+			}
+		}
+		_, err = fmt.Fprintf(w,
+			"digests: {info: %d, index: %d, summaries: %d, bloomFilter: %d, data: %d}\n",
+			info.Digests.Info, info.Digests.Index, info.Digests.Summaries,
+			info.Digests.BloomFilter, info.Digests.Data)
+		return err
+	default:
+		return fmt.Errorf("unknown dump format: %d", format)
+	}
+}
+
+func (i *fileSetInspector) WriteSeries(w io.Writer, format DumpFormat) error {
+	if err := validateDumpFormat(format); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	return i.forEachSeries(false, func(series FileSetSeriesDatapoints) error {
+		if format == DumpFormatJSON {
+			return enc.Encode(series.FileSetSeries)
+		}
+		_, err := fmt.Fprintf(w, "{id: %s, tags: %s, length: %d, checksum: %d}\n",
+			series.ID, formatTags(series.Tags), series.Length, series.Checksum)
+		return err
+	})
+}
+
+func (i *fileSetInspector) WriteDatapoints(w io.Writer, format DumpFormat) error {
+	if err := validateDumpFormat(format); err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	return i.forEachSeries(true, func(series FileSetSeriesDatapoints) error {
+		if format == DumpFormatJSON {
+			return enc.Encode(series)
+		}
+		for _, dp := range series.Datapoints {
+			_, err := fmt.Fprintf(w, "{id: %s, timestamp: %s, value: %v}\n",
+				series.ID, dp.Timestamp.Format(time.RFC3339Nano), dp.Value)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func (i *fileSetInspector) forEachSeries(
+	withDatapoints bool,
+	fn func(series FileSetSeriesDatapoints) error,
+) error {
+	// NB: The reader is opened for each pass since a reader can only be
+	// progressed through a volume once.
+	reader, err := NewReader(nil, i.opts)
+	if err != nil {
+		return err
+	}
+
+	err = reader.Open(DataReaderOpenOptions{
+		Identifier:  i.inspectOpts.Identifier,
+		FileSetType: i.inspectOpts.FileSetType,
+	})
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	for {
+		var (
+			series FileSetSeriesDatapoints
+			err    error
+		)
+		if withDatapoints {
+			series, err = i.readSeries(reader)
+		} else {
+			series, err = i.readSeriesMetadata(reader)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if i.inspectOpts.IDFilter != "" &&
+			!strings.Contains(series.ID, i.inspectOpts.IDFilter) {
+			continue
+		}
+		if err := fn(series); err != nil {
+			return err
+		}
+	}
+}
+
+func (i *fileSetInspector) readSeriesMetadata(
+	reader DataFileSetReader,
+) (FileSetSeriesDatapoints, error) {
+	id, tagsIter, length, checksum, err := reader.ReadMetadata()
+	if err != nil {
+		return FileSetSeriesDatapoints{}, err
+	}
+
+	series, err := newFileSetSeries(id, tagsIter, length, checksum)
+	if err != nil {
+		return FileSetSeriesDatapoints{}, err
+	}
+
+	return FileSetSeriesDatapoints{FileSetSeries: series}, nil
+}
+
+func (i *fileSetInspector) readSeries(
+	reader DataFileSetReader,
+) (FileSetSeriesDatapoints, error) {
+	id, tagsIter, data, checksum, err := reader.Read()
+	if err != nil {
+		return FileSetSeriesDatapoints{}, err
+	}
+
+	data.IncRef()
+	defer func() {
+		data.DecRef()
+		data.Finalize()
+	}()
+
+	series, err := newFileSetSeries(id, tagsIter, data.Len(), checksum)
+	if err != nil {
+		return FileSetSeriesDatapoints{}, err
+	}
+
+	iter := m3tsz.NewReaderIterator(bytes.NewReader(data.Bytes()),
+		m3tsz.DefaultIntOptimizationEnabled, i.encodingOpts)
+	defer iter.Close()
+
+	var datapoints []FileSetDatapoint
+	for iter.Next() {
+		dp, unit, annotation := iter.Current()
+		var annotationCopy []byte
+		if len(annotation) > 0 {
+			annotationCopy = append([]byte(nil), annotation...)
+		}
+		datapoints = append(datapoints, FileSetDatapoint{
+			Timestamp:  dp.Timestamp,
+			Value:      dp.Value,
+			Unit:       unit,
+			Annotation: annotationCopy,
+		})
+	}
+	if err := iter.Err(); err != nil {
+		return FileSetSeriesDatapoints{}, fmt.Errorf(
+			"unable to decode series %s: %v", series.ID, err)
+	}
+
+	return FileSetSeriesDatapoints{
+		FileSetSeries: series,
+		Datapoints:    datapoints,
+	}, nil
+}
+
+func newFileSetSeries(
+	id ident.ID,
+	tagsIter ident.TagIterator,
+	length int,
+	checksum uint32,
+) (FileSetSeries, error) {
+	defer func() {
+		id.Finalize()
+		tagsIter.Close()
+	}()
+
+	var tags map[string]string
+	if tagsIter.Remaining() > 0 {
+		tags = make(map[string]string, tagsIter.Remaining())
+	}
+	for tagsIter.Next() {
+		tag := tagsIter.Current()
+		tags[tag.Name.String()] = tag.Value.String()
+	}
+	if err := tagsIter.Err(); err != nil {
+		return FileSetSeries{}, err
+	}
+
+	return FileSetSeries{
+		ID:       id.String(),
+		Tags:     tags,
+		Length:   length,
+		Checksum: checksum,
+	}, nil
+}
+
+func readFileSetInfo(
+	opts Options,
+	inspectOpts FileSetInspectorOptions,
+) (FileSetInfo, error) {
+	var (
+		id             = inspectOpts.Identifier
+		filePathPrefix = opts.FilePathPrefix()
+		bufferSize     = opts.InfoReaderBufferSize()
+	)
+
+	checkpointFilePath, err := dataFileSetFilePath(filePathPrefix,
+		inspectOpts.FileSetType, id, checkpointFileSuffix)
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+	digestsFilePath, err := dataFileSetFilePath(filePathPrefix,
+		inspectOpts.FileSetType, id, digestFileSuffix)
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+	infoFilePath, err := dataFileSetFilePath(filePathPrefix,
+		inspectOpts.FileSetType, id, infoFileSuffix)
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+
+	expectedDigestOfDigest, err := readCheckpointFile(checkpointFilePath,
+		digest.NewBuffer())
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+	digestData, err := readAndValidate(digestsFilePath, bufferSize,
+		expectedDigestOfDigest)
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+	if len(digestData) != numFileSetDigests*digest.DigestLenBytes {
+		return FileSetInfo{}, errInspectorDigestsSize
+	}
+
+	// Digests are stored in the same order as read by readFileSetDigests.
+	var digests [numFileSetDigests]uint32
+	for j := range digests {
+		digests[j] = digest.ToBuffer(digestData[j*digest.DigestLenBytes:]).ReadDigest()
+	}
+	fsDigests := FileSetDigests{
+		Info:        digests[0],
+		Index:       digests[1],
+		Summaries:   digests[2],
+		BloomFilter: digests[3],
+		Data:        digests[4],
+	}
+
+	infoData, err := readAndValidate(infoFilePath, bufferSize, fsDigests.Info)
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+	decoder := msgpack.NewDecoder(opts.DecodingOptions())
+	decoder.Reset(msgpack.NewByteDecoderStream(infoData))
+	info, err := decoder.DecodeIndexInfo()
+	if err != nil {
+		return FileSetInfo{}, err
+	}
+
+	result := FileSetInfo{
+		Namespace:    id.Namespace.String(),
+		Shard:        id.Shard,
+		FileSetType:  info.FileType.String(),
+		BlockStart:   xtime.FromNanoseconds(info.BlockStart),
+		BlockSize:    time.Duration(info.BlockSize),
+		VolumeIndex:  info.VolumeIndex,
+		MajorVersion: info.MajorVersion,
+		MinorVersion: info.MinorVersion,
+		Entries:      info.Entries,
+		Summaries:    info.Summaries.Summaries,
+		BloomFilterM: info.BloomFilter.NumElementsM,
+		BloomFilterK: info.BloomFilter.NumHashesK,
+		Digests:      fsDigests,
+	}
+	if info.FileType == persist.FileSetSnapshotType {
+		snapshotTime := xtime.FromNanoseconds(info.SnapshotTime)
+		result.SnapshotTime = &snapshotTime
+		result.SnapshotID = uuid.UUID(info.SnapshotID).String()
+	}
+
+	return result, nil
+}
+
+func dataFileSetFilePath(
+	filePathPrefix string,
+	fileSetType persist.FileSetType,
+	id FileSetFileIdentifier,
+	suffix string,
+) (string, error) {
+	switch fileSetType {
+	case persist.FileSetSnapshotType:
+		dir := ShardSnapshotsDirPath(filePathPrefix, id.Namespace, id.Shard)
+		return filesetPathFromTimeAndIndex(dir, id.BlockStart, id.VolumeIndex, suffix), nil
+	case persist.FileSetFlushType:
+		dir := ShardDataDirPath(filePathPrefix, id.Namespace, id.Shard)
+		isLegacy := false
+		if id.VolumeIndex == 0 {
+			var err error
+			isLegacy, err = isFirstVolumeLegacy(dir, id.BlockStart, checkpointFileSuffix)
+			if err != nil {
+				return "", err
+			}
+		}
+		return dataFilesetPathFromTimeAndIndex(dir, id.BlockStart, id.VolumeIndex, suffix, isLegacy), nil
+	default:
+		return "", fmt.Errorf("unable to inspect fileset with fileset type: %s", fileSetType)
+	}
+}
+
+func validateDumpFormat(format DumpFormat) error {
+	switch format {
+	case DumpFormatText, DumpFormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("unknown dump format: %d", format)
+	}
+}
+
+func formatTags(tags map[string]string) string {
+	keys := make([]string, 0, len(tags))
+	for k := range tags {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, 0, len(keys))
+	for _, k := range keys {
+		pairs = append(pairs, k+"="+tags[k])
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
@@ -0,0 +1,198 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func encodeTestDatapoints(t *testing.T, start time.Time, values []float64) []byte {
+	enc := m3tsz.NewEncoder(start, nil, m3tsz.DefaultIntOptimizationEnabled,
+		encoding.NewOptions())
+	for i, v := range values {
+		dp := ts.Datapoint{Timestamp: start.Add(time.Duration(i) * time.Second), Value: v}
+		require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	stream, ok := enc.Stream(ctx)
+	require.True(t, ok)
+	data, err := ioutil.ReadAll(stream)
+	require.NoError(t, err)
+	return data
+}
+
+func newTestFileSetInspector(
+	t *testing.T,
+	fileSetType persist.FileSetType,
+	idFilter string,
+) (FileSetInspector, time.Time, func()) {
+	dir := createTempDir(t)
+	start := testWriterStart.Truncate(testBlockSize)
+
+	entries := []testEntry{
+		{"foo", map[string]string{"city": "nyc"}, encodeTestDatapoints(t, start, []float64{1, 2.5})},
+		{"bar", nil, encodeTestDatapoints(t, start, []float64{3})},
+	}
+	w := newTestWriter(t, dir)
+	writeTestData(t, w, 0, start, entries, fileSetType)
+
+	inspector, err := NewFileSetInspector(testDefaultOpts.SetFilePathPrefix(dir),
+		FileSetInspectorOptions{
+			Identifier: FileSetFileIdentifier{
+				Namespace:  testNs1ID,
+				Shard:      0,
+				BlockStart: start,
+			},
+			FileSetType: fileSetType,
+			IDFilter:    idFilter,
+		})
+	require.NoError(t, err)
+
+	return inspector, start, func() { os.RemoveAll(dir) }
+}
+
+func TestFileSetInspectorInfo(t *testing.T) {
+	inspector, start, cleanup := newTestFileSetInspector(t, persist.FileSetFlushType, "")
+	defer cleanup()
+
+	info := inspector.Info()
+	assert.Equal(t, testNs1ID.String(), info.Namespace)
+	assert.Equal(t, "flush", info.FileSetType)
+	assert.True(t, start.Equal(info.BlockStart))
+	assert.Equal(t, testBlockSize, info.BlockSize)
+	assert.Equal(t, int64(2), info.Entries)
+	assert.Nil(t, info.SnapshotTime)
+	assert.NotZero(t, info.Digests.Info)
+	assert.NotZero(t, info.Digests.Data)
+
+	var buf bytes.Buffer
+	require.NoError(t, inspector.WriteInfo(&buf, DumpFormatJSON))
+	var decoded FileSetInfo
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, info.Digests, decoded.Digests)
+	assert.Equal(t, info.Entries, decoded.Entries)
+
+	buf.Reset()
+	require.NoError(t, inspector.WriteInfo(&buf, DumpFormatText))
+	assert.Contains(t, buf.String(), "entries: 2\n")
+}
+
+func TestFileSetInspectorSnapshotInfo(t *testing.T) {
+	inspector, start, cleanup := newTestFileSetInspector(t, persist.FileSetSnapshotType, "")
+	defer cleanup()
+
+	info := inspector.Info()
+	assert.Equal(t, "snapshot", info.FileSetType)
+	require.NotNil(t, info.SnapshotTime)
+	assert.True(t, start.Equal(*info.SnapshotTime))
+	assert.Equal(t, testSnapshotID.String(), info.SnapshotID)
+}
+
+func TestFileSetInspectorSeries(t *testing.T) {
+	inspector, _, cleanup := newTestFileSetInspector(t, persist.FileSetFlushType, "")
+	defer cleanup()
+
+	series, err := inspector.Series()
+	require.NoError(t, err)
+	require.Equal(t, 2, len(series))
+
+	byID := make(map[string]FileSetSeries)
+	for _, s := range series {
+		byID[s.ID] = s
+	}
+	assert.Equal(t, map[string]string{"city": "nyc"}, byID["foo"].Tags)
+	assert.Nil(t, byID["bar"].Tags)
+	assert.NotZero(t, byID["foo"].Length)
+
+	var buf bytes.Buffer
+	require.NoError(t, inspector.WriteSeries(&buf, DumpFormatText))
+	assert.Contains(t, buf.String(), "{id: foo, tags: {city=nyc}")
+}
+
+func TestFileSetInspectorDatapoints(t *testing.T) {
+	inspector, start, cleanup := newTestFileSetInspector(t, persist.FileSetFlushType, "fo")
+	defer cleanup()
+
+	var results []FileSetSeriesDatapoints
+	require.NoError(t, inspector.ForEachSeries(func(s FileSetSeriesDatapoints) error {
+		results = append(results, s)
+		return nil
+	}))
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, "foo", results[0].ID)
+	require.Equal(t, 2, len(results[0].Datapoints))
+	assert.True(t, start.Equal(results[0].Datapoints[0].Timestamp))
+	assert.Equal(t, 1.0, results[0].Datapoints[0].Value)
+	assert.Equal(t, 2.5, results[0].Datapoints[1].Value)
+
+	var buf bytes.Buffer
+	require.NoError(t, inspector.WriteDatapoints(&buf, DumpFormatJSON))
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	require.Equal(t, 1, len(lines))
+
+	var decoded struct {
+		ID         string `json:"id"`
+		Datapoints []struct {
+			Value float64 `json:"value"`
+		} `json:"datapoints"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &decoded))
+	assert.Equal(t, "foo", decoded.ID)
+	require.Equal(t, 2, len(decoded.Datapoints))
+	assert.Equal(t, 2.5, decoded.Datapoints[1].Value)
+}
+
+func TestFileSetDatapointMarshalJSONNaN(t *testing.T) {
+	data, err := json.Marshal(FileSetDatapoint{
+		Timestamp: time.Unix(0, 0).UTC(),
+		Value:     math.NaN(),
+	})
+	require.NoError(t, err)
+	assert.Equal(t, `{"timestamp":"1970-01-01T00:00:00Z","value":"NaN"}`, string(data))
+}
+
+func TestParseDumpFormat(t *testing.T) {
+	format, err := ParseDumpFormat("JSON")
+	require.NoError(t, err)
+	assert.Equal(t, DumpFormatJSON, format)
+
+	_, err = ParseDumpFormat("xml")
+	require.Error(t, err)
+}
@@ -0,0 +1,214 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+const (
+	// stagingDirPrefix is the prefix of directories fileset files are
+	// written to before being made visible, the leading dot ensures no
+	// fileset glob patterns match them.
+	stagingDirPrefix = ".staging" + separator
+)
+
+// stagingDirPatterns are the patterns of the staging directories of data and
+// snapshot filesets, which are staged in their shard directory, and of index
+// filesets, which are staged in their namespace directory.
+var stagingDirPatterns = []string{
+	filepath.Join(dataDirName, "*", "*", stagingDirPrefix+"*"),
+	filepath.Join(snapshotDirName, "*", "*", stagingDirPrefix+"*"),
+	filepath.Join(indexDirName, "*", "*", stagingDirPrefix+"*"),
+}
+
+// fileSetStaging writes the files of a fileset to a staging directory
+// alongside their final directory and then makes them visible by renaming
+// them into place. The checkpoint file is always renamed last and any
+// existing checkpoint file is removed first, since readers only consider
+// a fileset complete once its checkpoint file exists this switches the
+// visible fileset atomically and a crash at any point never leaves a
+// partially written fileset visible.
+type fileSetStaging struct {
+	dir        string
+	stagingDir string
+	files      []string
+}
+
+// newFileSetStaging creates the staging directory for a fileset, removing
+// any files left behind by a previous attempt that did not complete.
+func newFileSetStaging(
+	dir string,
+	checkpointFilePath string,
+	newDirectoryMode os.FileMode,
+) (*fileSetStaging, error) {
+	name := strings.TrimSuffix(filepath.Base(checkpointFilePath),
+		separator+checkpointFileSuffix+fileSuffix)
+	stagingDir := filepath.Join(dir, stagingDirPrefix+name)
+	if err := os.RemoveAll(stagingDir); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(stagingDir, newDirectoryMode); err != nil {
+		return nil, err
+	}
+	return &fileSetStaging{
+		dir:        dir,
+		stagingDir: stagingDir,
+	}, nil
+}
+
+// path returns the path to write a file to before it is made visible at
+// the final file path.
+func (s *fileSetStaging) path(filePath string) string {
+	s.files = append(s.files, filePath)
+	return s.stagingPath(filePath)
+}
+
+func (s *fileSetStaging) stagingPath(filePath string) string {
+	return filepath.Join(s.stagingDir, filepath.Base(filePath))
+}
+
+// commit makes the staged files visible, the checkpoint file must already
+// be written to its staging path.
+func (s *fileSetStaging) commit(checkpointFilePath string) error {
+	stagedCheckpointFilePath := s.stagingPath(checkpointFilePath)
+	for _, filePath := range append(s.files, checkpointFilePath) {
+		if err := syncFile(s.stagingPath(filePath)); err != nil {
+			return err
+		}
+	}
+
+	// Hide any fileset previously written to the same paths before the
+	// files it consists of are replaced.
+	if err := os.Remove(checkpointFilePath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := syncFile(s.dir); err != nil {
+		return err
+	}
+
+	for _, filePath := range s.files {
+		if err := os.Rename(s.stagingPath(filePath), filePath); err != nil {
+			return err
+		}
+	}
+	if err := syncFile(s.dir); err != nil {
+		return err
+	}
+
+	if err := os.Rename(stagedCheckpointFilePath, checkpointFilePath); err != nil {
+		return err
+	}
+	if err := syncFile(s.dir); err != nil {
+		return err
+	}
+
+	return s.abort()
+}
+
+// abort removes the staging directory and any files staged in it.
+func (s *fileSetStaging) abort() error {
+	s.files = s.files[:0]
+	return os.RemoveAll(s.stagingDir)
+}
+
+// DeleteStaleStagingDirs removes the staging directories of filesets under
+// the file path prefix that neither they nor any of their files have been
+// modified in since the given time, returning the number of directories
+// removed. Staging directories are left behind when a process crashes
+// while writing a fileset and are otherwise only removed by a later write
+// of the same fileset, which may never happen. Directories modified more
+// recently may belong to a write in progress and are left in place.
+func DeleteStaleStagingDirs(filePathPrefix string, modifiedBefore time.Time) (int, error) {
+	var (
+		multiErr = xerrors.NewMultiError()
+		removed  int
+	)
+	for _, pattern := range stagingDirPatterns {
+		dirs, err := filepath.Glob(filepath.Join(filePathPrefix, pattern))
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		for _, dir := range dirs {
+			modifiedAt, err := latestModTime(dir)
+			if os.IsNotExist(err) {
+				// Removed by the write it belongs to since it was listed.
+				continue
+			}
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			if !modifiedAt.Before(modifiedBefore) {
+				continue
+			}
+			if err := os.RemoveAll(dir); err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			removed++
+		}
+	}
+	return removed, multiErr.FinalError()
+}
+
+// latestModTime returns the latest modification time of a directory and
+// the files in it.
+func latestModTime(dir string) (time.Time, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	latest := info.ModTime()
+
+	fd, err := os.Open(dir)
+	if err != nil {
+		return time.Time{}, err
+	}
+	files, err := fd.Readdir(-1)
+	if closeErr := fd.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, file := range files {
+		if file.ModTime().After(latest) {
+			latest = file.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// syncFile ensures the file or directory at the path is persisted to disk.
+func syncFile(filePath string) error {
+	fd, err := os.Open(filePath)
+	if err != nil {
+		return err
+	}
+	return xerrors.FirstError(fd.Sync(), fd.Close())
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileSetStagingCommitSwitchesFileSet(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		blockStart     = time.Now().Truncate(time.Hour)
+		dataFilePath   = filesetPathFromTimeAndIndex(dir, blockStart, 0, dataFileSuffix)
+		checkpointPath = filesetPathFromTimeAndIndex(dir, blockStart, 0, checkpointFileSuffix)
+	)
+
+	// Write an existing fileset to the same paths.
+	require.NoError(t, ioutil.WriteFile(dataFilePath, []byte("old"), defaultNewFileMode))
+	require.NoError(t, ioutil.WriteFile(checkpointPath, []byte("old"), defaultNewFileMode))
+
+	staging, err := newFileSetStaging(dir, checkpointPath, defaultNewDirectoryMode)
+	require.NoError(t, err)
+	require.NoError(t, ioutil.WriteFile(staging.path(dataFilePath), []byte("new"), defaultNewFileMode))
+	require.NoError(t, ioutil.WriteFile(staging.stagingPath(checkpointPath), []byte("new"), defaultNewFileMode))
+
+	// The existing fileset is visible until the staged fileset is committed.
+	data, err := ioutil.ReadFile(dataFilePath)
+	require.NoError(t, err)
+	require.Equal(t, []byte("old"), data)
+
+	require.NoError(t, staging.commit(checkpointPath))
+
+	for _, filePath := range []string{dataFilePath, checkpointPath} {
+		data, err := ioutil.ReadFile(filePath)
+		require.NoError(t, err)
+		require.Equal(t, []byte("new"), data)
+	}
+
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(files))
+}
+
+func TestFileSetStagingRemovesPreviousAttempt(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		blockStart     = time.Now().Truncate(time.Hour)
+		dataFilePath   = filesetPathFromTimeAndIndex(dir, blockStart, 0, dataFileSuffix)
+		checkpointPath = filesetPathFromTimeAndIndex(dir, blockStart, 0, checkpointFileSuffix)
+	)
+
+	staging, err := newFileSetStaging(dir, checkpointPath, defaultNewDirectoryMode)
+	require.NoError(t, err)
+	stagedFilePath := staging.path(dataFilePath)
+	require.NoError(t, ioutil.WriteFile(stagedFilePath, []byte("partial"), defaultNewFileMode))
+	require.Equal(t, dir, filepath.Dir(filepath.Dir(stagedFilePath)))
+
+	// Simulate a crash before the fileset was committed.
+	staging, err = newFileSetStaging(dir, checkpointPath, defaultNewDirectoryMode)
+	require.NoError(t, err)
+	_, err = os.Stat(stagedFilePath)
+	require.True(t, os.IsNotExist(err))
+
+	require.NoError(t, staging.abort())
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	require.Equal(t, 0, len(files))
+}
+
+func TestDeleteStaleStagingDirs(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	var (
+		now        = time.Now()
+		staleTime  = now.Add(-2 * time.Hour)
+		shardDir   = filepath.Join(dir, dataDirName, "testNs", "0")
+		indexDir   = filepath.Join(dir, indexDirName, dataDirName, "testNs")
+		staleData  = filepath.Join(shardDir, stagingDirPrefix+"stale")
+		staleIndex = filepath.Join(indexDir, stagingDirPrefix+"stale")
+		recent     = filepath.Join(shardDir, stagingDirPrefix+"recent")
+		touched    = filepath.Join(shardDir, stagingDirPrefix+"touched")
+		fileset    = filepath.Join(shardDir, "fileset-0-0-data.db")
+	)
+	for _, stagingDir := range []string{staleData, staleIndex, recent, touched} {
+		require.NoError(t, os.MkdirAll(stagingDir, defaultNewDirectoryMode))
+		require.NoError(t, ioutil.WriteFile(filepath.Join(stagingDir, "partial"),
+			[]byte("partial"), defaultNewFileMode))
+	}
+	require.NoError(t, ioutil.WriteFile(fileset, []byte("data"), defaultNewFileMode))
+
+	// Age everything but the recent staging directory and the file still
+	// being written to in the touched staging directory.
+	for _, path := range []string{
+		staleData, filepath.Join(staleData, "partial"),
+		staleIndex, filepath.Join(staleIndex, "partial"),
+		touched, fileset,
+	} {
+		require.NoError(t, os.Chtimes(path, staleTime, staleTime))
+	}
+
+	removed, err := DeleteStaleStagingDirs(dir, now.Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, 2, removed)
+
+	for _, path := range []string{staleData, staleIndex} {
+		_, err := os.Stat(path)
+		require.True(t, os.IsNotExist(err))
+	}
+	for _, path := range []string{recent, touched, fileset} {
+		_, err := os.Stat(path)
+		require.NoError(t, err)
+	}
+}
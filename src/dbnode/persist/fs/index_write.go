@@ -68,6 +68,7 @@ type indexWriter struct {
 	segments     []writtenIndexSegment
 
 	namespaceDir       string
+	staging            *fileSetStaging
 	checkpointFilePath string
 	infoFilePath       string
 	digestFilePath     string
@@ -142,7 +143,11 @@ func (w *indexWriter) Open(opts IndexWriterOpenOptions) error {
 			w.checkpointFilePath)
 	}
 
-	return nil
+	// Files are written to a staging directory and only made visible once
+	// the fileset is completely written when the writer is closed.
+	w.staging, err = newFileSetStaging(w.namespaceDir, w.checkpointFilePath,
+		w.newDirectoryMode)
+	return err
 }
 
 func (w *indexWriter) WriteSegmentFileSet(
@@ -189,7 +194,7 @@ func (w *indexWriter) WriteSegmentFileSet(
 			return w.markSegmentWriteError(segType, segFileType, err)
 		}
 
-		fd, err := OpenWritable(filePath, w.newFileMode)
+		fd, err := OpenWritable(w.staging.path(filePath), w.newFileMode)
 		if err != nil {
 			return w.markSegmentWriteError(segType, segFileType, err)
 		}
@@ -277,6 +282,18 @@ func (w *indexWriter) digestsFileData(infoFileData []byte) ([]byte, error) {
 }
 
 func (w *indexWriter) Close() error {
+	if err := w.closeAndCommit(); err != nil {
+		// NB: Errors removing the staged files are ignored as they are
+		// removed again by the next attempt to write the same fileset.
+		if w.staging != nil {
+			w.staging.abort()
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *indexWriter) closeAndCommit() error {
 	if w.err != nil {
 		// If a write error occurred don't even bother trying to write out file set
 		return w.err
@@ -287,7 +304,7 @@ func (w *indexWriter) Close() error {
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(w.infoFilePath, infoFileData, w.newFileMode)
+	err = ioutil.WriteFile(w.staging.path(w.infoFilePath), infoFileData, w.newFileMode)
 	if err != nil {
 		return err
 	}
@@ -297,7 +314,7 @@ func (w *indexWriter) Close() error {
 	if err != nil {
 		return err
 	}
-	err = ioutil.WriteFile(w.digestFilePath, digestsFileData, w.newFileMode)
+	err = ioutil.WriteFile(w.staging.path(w.digestFilePath), digestsFileData, w.newFileMode)
 	if err != nil {
 		return err
 	}
@@ -305,5 +322,11 @@ func (w *indexWriter) Close() error {
 	// Write checkpoint file
 	digestBuffer := digest.NewBuffer()
 	digestBuffer.WriteDigest(digest.Checksum(digestsFileData))
-	return ioutil.WriteFile(w.checkpointFilePath, digestBuffer, w.newFileMode)
+	err = ioutil.WriteFile(w.staging.stagingPath(w.checkpointFilePath), digestBuffer, w.newFileMode)
+	if err != nil {
+		return err
+	}
+
+	// Switch to the new fileset only once all of its files are written.
+	return w.staging.commit(w.checkpointFilePath)
 }
@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
@@ -45,6 +46,9 @@ const (
 	// defaultWriterBufferSize is the default buffer size for writing TSDB files
 	defaultWriterBufferSize = 65536
 
+	// defaultWriterConcurrentDataWrites is the default setting for whether to write data files concurrently
+	defaultWriterConcurrentDataWrites = false
+
 	// defaultDataReaderBufferSize is the default buffer size for reading TSDB data and index files
 	defaultDataReaderBufferSize = 65536
 
@@ -54,6 +58,9 @@ const (
 	// defaultSeekReaderBufferSize is the default buffer size for fs seeker's data buffer
 	defaultSeekReaderBufferSize = 4096
 
+	// defaultSeekSequentialReadSize is the default read size for fs seeker's sequential reads
+	defaultSeekSequentialReadSize = 1 << 20 // 1mb
+
 	// defaultMmapEnableHugePages is the default setting whether to enable huge pages or not
 	defaultMmapEnableHugePages = false
 
@@ -89,9 +96,11 @@ type options struct {
 	indexSummariesPercent                float64
 	indexBloomFilterFalsePositivePercent float64
 	writerBufferSize                     int
+	writerConcurrentDataWrites           bool
 	dataReaderBufferSize                 int
 	infoReaderBufferSize                 int
 	seekReaderBufferSize                 int
+	seekSequentialReadSize               int
 	mmapHugePagesThreshold               int64
 	tagEncoderPool                       serialize.TagEncoderPool
 	tagDecoderPool                       serialize.TagDecoderPool
@@ -100,6 +109,7 @@ type options struct {
 	forceBloomFilterMmapMemory           bool
 	mmapEnableHugePages                  bool
 	mmapReporter                         mmap.Reporter
+	faultInjector                        fault.Injector
 }
 
 // NewOptions creates a new set of fs options
@@ -125,14 +135,17 @@ func NewOptions() Options {
 		forceIndexSummariesMmapMemory:        defaultForceIndexSummariesMmapMemory,
 		forceBloomFilterMmapMemory:           defaultForceIndexBloomFilterMmapMemory,
 		writerBufferSize:                     defaultWriterBufferSize,
+		writerConcurrentDataWrites:           defaultWriterConcurrentDataWrites,
 		dataReaderBufferSize:                 defaultDataReaderBufferSize,
 		infoReaderBufferSize:                 defaultInfoReaderBufferSize,
 		seekReaderBufferSize:                 defaultSeekReaderBufferSize,
+		seekSequentialReadSize:               defaultSeekSequentialReadSize,
 		mmapEnableHugePages:                  defaultMmapEnableHugePages,
 		mmapHugePagesThreshold:               defaultMmapHugePagesThreshold,
 		tagEncoderPool:                       tagEncoderPool,
 		tagDecoderPool:                       tagDecoderPool,
 		fstOptions:                           fstOptions,
+		faultInjector:                        fault.NewNopInjector(),
 	}
 }
 
@@ -276,6 +289,16 @@ func (o *options) WriterBufferSize() int {
 	return o.writerBufferSize
 }
 
+func (o *options) SetWriterConcurrentDataWrites(value bool) Options {
+	opts := *o
+	opts.writerConcurrentDataWrites = value
+	return &opts
+}
+
+func (o *options) WriterConcurrentDataWrites() bool {
+	return o.writerConcurrentDataWrites
+}
+
 func (o *options) SetDataReaderBufferSize(value int) Options {
 	opts := *o
 	opts.dataReaderBufferSize = value
@@ -306,6 +329,16 @@ func (o *options) SeekReaderBufferSize() int {
 	return o.seekReaderBufferSize
 }
 
+func (o *options) SetSeekSequentialReadSize(value int) Options {
+	opts := *o
+	opts.seekSequentialReadSize = value
+	return &opts
+}
+
+func (o *options) SeekSequentialReadSize() int {
+	return o.seekSequentialReadSize
+}
+
 func (o *options) SetMmapEnableHugeTLB(value bool) Options {
 	opts := *o
 	opts.mmapEnableHugePages = value
@@ -365,3 +398,13 @@ func (o *options) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 func (o *options) MmapReporter() mmap.Reporter {
 	return o.mmapReporter
 }
+
+func (o *options) SetFaultInjector(value fault.Injector) Options {
+	opts := *o
+	opts.faultInjector = value
+	return &opts
+}
+
+func (o *options) FaultInjector() fault.Injector {
+	return o.faultInjector
+}
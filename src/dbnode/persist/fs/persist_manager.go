@@ -35,6 +35,7 @@ import (
 	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fst"
 	m3ninxpersist "github.com/m3db/m3/src/m3ninx/persist"
 	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 
@@ -250,6 +251,10 @@ func (pm *persistManager) PrepareIndex(opts persist.IndexPrepareOptions) (persis
 		return prepared, errPersistManagerCannotPrepareIndexNotPersisting
 	}
 
+	if err := pm.opts.FaultInjector().Inject(fault.Flush); err != nil {
+		return prepared, err
+	}
+
 	// NB(prateek): unlike data flush files, we allow multiple index flush files for a single block start.
 	// As a result of this, every time we persist index flush data, we have to compute the volume index
 	// to uniquely identify a single FileSetFile on disk.
@@ -416,6 +421,12 @@ func (pm *persistManager) PrepareData(opts persist.DataPrepareOptions) (persist.
 		return prepared, errPersistManagerCannotPrepareDataNotPersisting
 	}
 
+	if opts.FileSetType == persist.FileSetFlushType {
+		if err := pm.opts.FaultInjector().Inject(fault.Flush); err != nil {
+			return prepared, err
+		}
+	}
+
 	exists, err := pm.dataFilesetExists(opts)
 	if err != nil {
 		return prepared, err
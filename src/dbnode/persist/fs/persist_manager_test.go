@@ -35,7 +35,9 @@ import (
 	m3ninxfs "github.com/m3db/m3/src/m3ninx/index/segment/fst"
 	m3ninxpersist "github.com/m3db/m3/src/m3ninx/persist"
 	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/instrument"
 	m3test "github.com/m3db/m3/src/x/test"
 	xtest "github.com/m3db/m3/src/x/test"
 
@@ -169,6 +171,36 @@ func TestPersistenceManagerPrepareOpenError(t *testing.T) {
 	require.Nil(t, prepared.Close)
 }
 
+func TestPersistenceManagerPrepareInjectedFault(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pm, _, _, _ := testDataPersistManager(t, ctrl)
+	defer os.RemoveAll(pm.filePathPrefix)
+
+	pm.opts = pm.opts.SetFaultInjector(fault.NewInjector(map[fault.Point]fault.Faults{
+		fault.Flush: {ErrorProbability: 1},
+	}, 0, instrument.NewOptions()))
+
+	flush, err := pm.StartFlushPersist()
+	require.NoError(t, err)
+
+	defer func() {
+		assert.NoError(t, flush.DoneFlush())
+	}()
+
+	// The writer is never opened since the fault is injected first.
+	prepareOpts := persist.DataPrepareOptions{
+		NamespaceMetadata: testNs1Metadata(t),
+		Shard:             0,
+		BlockStart:        time.Unix(1000, 0),
+	}
+	prepared, err := flush.PrepareData(prepareOpts)
+	require.True(t, fault.IsInjectedError(err))
+	require.Nil(t, prepared.Persist)
+	require.Nil(t, prepared.Close)
+}
+
 func TestPersistenceManagerPrepareSuccess(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
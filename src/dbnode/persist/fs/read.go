@@ -50,6 +50,12 @@ var (
 
 	// errReadNotExpectedSize returned when the size of the next read does not match size specified by the index
 	errReadNotExpectedSize = errors.New("next read not expected size")
+
+	// errReadBlockNotInFileSet returned when the block to read is not held by the fileset
+	errReadBlockNotInFileSet = errors.New("block to read is not held by the fileset")
+
+	// errReadCompactedChecksumMismatch returned when the data of a series of a compacted fileset does not match its checksum
+	errReadCompactedChecksumMismatch = errors.New("compacted data does not match expected checksum")
 )
 
 const (
@@ -82,6 +88,12 @@ type reader struct {
 
 	bloomFilterFd *os.File
 
+	// compactedEntries are the entries of the series with data for the
+	// block read of a compacted fileset, in the order of the index entries
+	// by offset. The entries read are counted against these when set.
+	compactedEntries []compactedReadEntry
+	compacted        bool
+
 	entries         int
 	bloomFilterInfo schema.IndexBloomFilterInfo
 	entriesRead     int
@@ -267,6 +279,12 @@ func (r *reader) Open(opts DataReaderOpenOptions) error {
 		r.Close()
 		return err
 	}
+	if !opts.Block.IsEmpty() && !opts.Block.Equal(r.Range()) {
+		if err := r.readCompactedEntries(opts.Block); err != nil {
+			r.Close()
+			return err
+		}
+	}
 
 	r.open = true
 	r.namespace = namespace
@@ -343,7 +361,59 @@ func (r *reader) readIndexAndSortByOffsetAsc() error {
 	return nil
 }
 
+// compactedReadEntry is an entry of a series with data for the block read
+// of a compacted fileset.
+type compactedReadEntry struct {
+	// index is the index of the index entry of the series by offset.
+	index int
+	block compactedBlock
+}
+
+// readCompactedEntries determines the series of a compacted fileset with
+// data for the given block, the reader then reads the series as if it was
+// the fileset of the block.
+func (r *reader) readCompactedEntries(block xtime.Range) error {
+	if !r.Range().Contains(block) {
+		return errReadBlockNotInFileSet
+	}
+
+	var (
+		data                = r.dataMmap.Bytes
+		compactedBlockStart = xtime.ToUnixNano(r.start)
+		blockStart          = xtime.ToUnixNano(block.Start)
+	)
+	r.compactedEntries = r.compactedEntries[:0]
+	for i, entry := range r.indexEntriesByOffsetAsc {
+		end := entry.Offset + entry.Size
+		if entry.Offset < 0 || entry.Size < 0 || end > int64(len(data)) {
+			return errReadNotExpectedSize
+		}
+		seriesBlock, ok, err := compactedBlockAt(data[entry.Offset:end],
+			compactedBlockStart, blockStart)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+		r.compactedEntries = append(r.compactedEntries, compactedReadEntry{
+			index: i,
+			block: seriesBlock,
+		})
+	}
+
+	r.compacted = true
+	r.entries = len(r.compactedEntries)
+	r.start = block.Start
+	r.blockSize = block.Duration()
+	return nil
+}
+
 func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, error) {
+	if r.compacted {
+		return r.readCompacted()
+	}
+
 	if r.entries > 0 && len(r.indexEntriesByOffsetAsc) < r.entries {
 		// Have not read the index yet, this is required when reading
 		// data as we need each index entry in order by by the offset ascending
@@ -385,11 +455,46 @@ func (r *reader) Read() (ident.ID, ident.TagIterator, checked.Bytes, uint32, err
 	return id, tags, data, uint32(entry.Checksum), nil
 }
 
+func (r *reader) readCompacted() (ident.ID, ident.TagIterator, checked.Bytes, uint32, error) {
+	if r.entriesRead >= r.entries {
+		return nil, nil, nil, 0, io.EOF
+	}
+
+	var (
+		compactedEntry = r.compactedEntries[r.entriesRead]
+		entry          = r.indexEntriesByOffsetAsc[compactedEntry.index]
+		seriesData     = r.dataMmap.Bytes[entry.Offset : entry.Offset+entry.Size]
+	)
+	// NB: The data file is not read through the digest reader for compacted
+	// filesets, so the checksum of each series is validated as it is read.
+	if digest.Checksum(seriesData) != uint32(entry.Checksum) {
+		return nil, nil, nil, 0, errReadCompactedChecksumMismatch
+	}
+
+	block := compactedEntry.block
+	data := r.entryClonedBytes(seriesData[block.offset : block.offset+block.size])
+	id := r.entryClonedID(entry.ID)
+	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
+
+	r.entriesRead++
+	return id, tags, data, block.checksum, nil
+}
+
 func (r *reader) ReadMetadata() (ident.ID, ident.TagIterator, int, uint32, error) {
 	if r.metadataRead >= r.entries {
 		return nil, nil, 0, 0, io.EOF
 	}
 
+	if r.compacted {
+		compactedEntry := r.compactedEntries[r.metadataRead]
+		entry := r.indexEntriesByOffsetAsc[compactedEntry.index]
+		id := r.entryClonedID(entry.ID)
+		tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
+
+		r.metadataRead++
+		return id, tags, compactedEntry.block.size, compactedEntry.block.checksum, nil
+	}
+
 	entry := r.indexEntriesByOffsetAsc[r.metadataRead]
 	id := r.entryClonedID(entry.ID)
 	tags := r.entryClonedEncodedTagsIter(entry.EncodedTags)
@@ -463,6 +568,12 @@ func (r *reader) ValidateMetadata() error {
 // NB(xichen): ValidateData should be called after all data is read because
 // the digest is calculated for the entire data file.
 func (r *reader) ValidateData() error {
+	if r.compacted {
+		if digest.Checksum(r.dataMmap.Bytes) != r.expectedDataDigest {
+			return fmt.Errorf("could not validate data file: %v", errReadCompactedChecksumMismatch)
+		}
+		return nil
+	}
 	err := r.dataReader.Validate(r.expectedDataDigest)
 	if err != nil {
 		return fmt.Errorf("could not validate data file: %v", err)
@@ -500,6 +611,7 @@ func (r *reader) Close() error {
 		r.indexEntriesByOffsetAsc[i].ID = nil
 	}
 	r.indexEntriesByOffsetAsc = r.indexEntriesByOffsetAsc[:0]
+	r.compactedEntries = r.compactedEntries[:0]
 
 	// Save fields we want to reassign after resetting struct
 	opts := r.opts
@@ -515,6 +627,7 @@ func (r *reader) Close() error {
 	bytesPool := r.bytesPool
 	tagDecoderPool := r.tagDecoderPool
 	indexEntriesByOffsetAsc := r.indexEntriesByOffsetAsc
+	compactedEntries := r.compactedEntries
 
 	// Reset struct
 	*r = reader{}
@@ -533,6 +646,7 @@ func (r *reader) Close() error {
 	r.bytesPool = bytesPool
 	r.tagDecoderPool = tagDecoderPool
 	r.indexEntriesByOffsetAsc = indexEntriesByOffsetAsc
+	r.compactedEntries = compactedEntries
 
 	return multiErr.FinalError()
 }
@@ -222,7 +222,9 @@ func TestReadDataUnexpectedSize(t *testing.T) {
 	}
 	err = w.Open(writerOpts)
 	assert.NoError(t, err)
-	dataFile := w.(*writer).dataFdWithDigest.Fd().Name()
+	dataFile := dataFilesetPathFromTimeAndIndex(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 0),
+		testWriterStart, 0, dataFileSuffix, false)
 
 	assert.NoError(t, w.Write(
 		ident.StringID("foo"), ident.Tags{},
@@ -272,6 +272,40 @@ func TestSimpleReadWrite(t *testing.T) {
 	readTestData(t, r, 0, testWriterStart, entries)
 }
 
+func TestSimpleReadWriteConcurrentDataWrites(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"baz", nil, make([]byte, 65536)},
+		{"cat", nil, make([]byte, 100000)},
+		{"foo+bar=baz,qux=qaz", map[string]string{
+			"bar": "baz",
+			"qux": "qaz",
+		}, []byte{7, 8, 9}},
+	}
+
+	w, err := NewWriter(testDefaultOpts.
+		SetFilePathPrefix(filePathPrefix).
+		SetWriterBufferSize(testWriterBufferSize).
+		SetWriterConcurrentDataWrites(true))
+	require.NoError(t, err)
+
+	// Write twice to the same writer to ensure it can be reused.
+	for _, blockStart := range []time.Time{
+		testWriterStart,
+		testWriterStart.Add(testBlockSize),
+	} {
+		writeTestData(t, w, 0, blockStart, entries, persist.FileSetFlushType)
+
+		r := newTestReader(t, filePathPrefix)
+		readTestData(t, r, 0, blockStart, entries)
+	}
+}
+
 func TestCheckpointFileSizeBytesSize(t *testing.T) {
 	// These values need to match so that the logic for determining whether
 	// a checkpoint file is complete or not remains correct.
@@ -0,0 +1,271 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package rebuild
+
+import (
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// documentBatchSize is the number of documents inserted into the
+	// segment builder at a time.
+	documentBatchSize = 256
+)
+
+type indexRebuilder struct {
+	sync.Mutex
+
+	opts           Options
+	fsOpts         fs.Options
+	log            *zap.Logger
+	persistManager persist.Manager
+	builder        *result.IndexBuilder
+}
+
+// NewIndexRebuilder creates a new index rebuilder.
+func NewIndexRebuilder(opts Options) (IndexRebuilder, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	fsOpts := opts.FilesystemOptions()
+	persistManager, err := fs.NewPersistManager(fsOpts)
+	if err != nil {
+		return nil, err
+	}
+
+	segBuilder, err := builder.NewBuilderFromDocuments(opts.IndexBuilderOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &indexRebuilder{
+		opts:           opts,
+		fsOpts:         fsOpts,
+		log:            fsOpts.InstrumentOptions().Logger(),
+		persistManager: persistManager,
+		builder:        result.NewIndexBuilder(segBuilder),
+	}, nil
+}
+
+func (r *indexRebuilder) RebuildBlock(
+	opts IndexBlockRebuildOptions,
+) (IndexBlockRebuildResult, error) {
+	var (
+		md             = opts.NamespaceMetadata
+		nsOpts         = md.Options()
+		indexBlockSize = nsOpts.IndexOptions().BlockSize()
+		dataBlockSize  = nsOpts.RetentionOptions().BlockSize()
+		filePathPrefix = r.fsOpts.FilePathPrefix()
+		res            IndexBlockRebuildResult
+	)
+	if !nsOpts.IndexOptions().Enabled() {
+		return res, fmt.Errorf("namespace %s does not have indexing enabled", md.ID().String())
+	}
+	if !opts.BlockStart.Equal(opts.BlockStart.Truncate(indexBlockSize)) {
+		return res, fmt.Errorf("block start %s is not aligned to index block size %s",
+			opts.BlockStart.String(), indexBlockSize.String())
+	}
+
+	// NB: The builder and the persist manager are not safe for concurrent use.
+	r.Lock()
+	defer r.Unlock()
+
+	existing, err := fs.IndexFileSetsAt(filePathPrefix, md.ID(), opts.BlockStart)
+	if err != nil {
+		return res, err
+	}
+
+	r.builder.Builder().Reset(0)
+	shards := make(map[uint32]struct{}, len(opts.Shards))
+	for _, shard := range opts.Shards {
+		shards[shard] = struct{}{}
+
+		dataFiles, err := fs.DataFiles(filePathPrefix, md.ID(), shard)
+		if err != nil {
+			return res, err
+		}
+
+		blockEnd := opts.BlockStart.Add(indexBlockSize)
+		for t := opts.BlockStart; t.Before(blockEnd); t = t.Add(dataBlockSize) {
+			fileSet, ok := dataFiles.LatestVolumeForBlock(t)
+			if !ok {
+				res.DataFileSetsMissing++
+				continue
+			}
+			if err := r.readDataFileSet(md.ID().String(), shard, fileSet); err != nil {
+				return res, err
+			}
+			res.DataFileSetsRead++
+		}
+	}
+
+	res.Documents = len(r.builder.Builder().Docs())
+	if res.Documents == 0 {
+		// Nothing to persist if the data filesets contain no series.
+		return res, nil
+	}
+
+	if err := r.persist(opts, shards); err != nil {
+		return res, err
+	}
+	res.Persisted = true
+
+	if opts.RemoveExisting {
+		multiErr := xerrors.NewMultiError()
+		for _, fileSet := range existing {
+			if err := fs.DeleteFiles(fileSet.AbsoluteFilepaths); err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			res.RemovedFileSets++
+		}
+		if err := multiErr.FinalError(); err != nil {
+			return res, err
+		}
+	}
+
+	r.log.Info("rebuilt index block from data filesets",
+		zap.String("namespace", md.ID().String()),
+		zap.Time("blockStart", opts.BlockStart),
+		zap.Int("dataFileSetsRead", res.DataFileSetsRead),
+		zap.Int("documents", res.Documents),
+		zap.Int("removedFileSets", res.RemovedFileSets))
+
+	return res, nil
+}
+
+func (r *indexRebuilder) readDataFileSet(
+	namespace string,
+	shard uint32,
+	fileSet fs.FileSetFile,
+) error {
+	reader, err := fs.NewReader(nil, r.fsOpts)
+	if err != nil {
+		return err
+	}
+
+	err = reader.Open(fs.DataReaderOpenOptions{
+		Identifier:  fileSet.ID,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return fmt.Errorf("unable to open data fileset: namespace=%s, shard=%d, "+
+			"blockStart=%s, volume=%d: %v", namespace, shard,
+			fileSet.ID.BlockStart.String(), fileSet.ID.VolumeIndex, err)
+	}
+	defer reader.Close()
+
+	batch := make([]doc.Document, 0, documentBatchSize)
+	for {
+		id, tagsIter, _, _, err := reader.ReadMetadata()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		d, err := convert.FromMetricIter(id, tagsIter)
+		// Finalize the ID and tags.
+		id.Finalize()
+		tagsIter.Close()
+		if err != nil {
+			return err
+		}
+
+		batch = append(batch, d)
+		if len(batch) >= documentBatchSize {
+			if batch, err = r.builder.FlushBatch(batch); err != nil {
+				return err
+			}
+		}
+	}
+
+	_, err = r.builder.FlushBatch(batch)
+	return err
+}
+
+func (r *indexRebuilder) persist(
+	opts IndexBlockRebuildOptions,
+	shards map[uint32]struct{},
+) error {
+	flush, err := r.persistManager.StartIndexPersist()
+	if err != nil {
+		return err
+	}
+
+	var calledDone bool
+	defer func() {
+		if !calledDone {
+			flush.DoneIndex()
+		}
+	}()
+
+	preparedPersist, err := flush.PrepareIndex(persist.IndexPrepareOptions{
+		NamespaceMetadata: opts.NamespaceMetadata,
+		BlockStart:        opts.BlockStart,
+		FileSetType:       persist.FileSetFlushType,
+		Shards:            shards,
+	})
+	if err != nil {
+		return err
+	}
+
+	var calledClose bool
+	defer func() {
+		if !calledClose {
+			preparedPersist.Close()
+		}
+	}()
+
+	if err := preparedPersist.Persist(r.builder.Builder()); err != nil {
+		return err
+	}
+
+	calledClose = true
+	segments, err := preparedPersist.Close()
+	if err != nil {
+		return err
+	}
+
+	// The persisted segments are only needed by a running database, close
+	// them to release the underlying mmaps.
+	multiErr := xerrors.NewMultiError()
+	for _, seg := range segments {
+		multiErr = multiErr.Add(seg.Close())
+	}
+
+	calledDone = true
+	multiErr = multiErr.Add(flush.DoneIndex())
+	return multiErr.FinalError()
+}
@@ -0,0 +1,210 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package rebuild
+
+import (
+	"io/ioutil"
+	"os"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	testDataBlockSize  = time.Hour
+	testIndexBlockSize = 2 * time.Hour
+)
+
+var (
+	testNamespaceID = ident.StringID("testns")
+	testBytes       = []byte("somelongstringofdata")
+)
+
+func newTestNamespaceMetadata(t *testing.T) namespace.Metadata {
+	md, err := namespace.NewMetadata(testNamespaceID, namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().SetBlockSize(testDataBlockSize)).
+		SetIndexOptions(namespace.NewIndexOptions().
+			SetEnabled(true).
+			SetBlockSize(testIndexBlockSize)))
+	require.NoError(t, err)
+	return md
+}
+
+func newTestRebuilder(t *testing.T) (IndexRebuilder, fs.Options, func()) {
+	dir, err := ioutil.TempDir("", "rebuild")
+	require.NoError(t, err)
+
+	fsOpts := fs.NewOptions().SetFilePathPrefix(dir)
+	rebuilder, err := NewIndexRebuilder(NewOptions().SetFilesystemOptions(fsOpts))
+	require.NoError(t, err)
+
+	return rebuilder, fsOpts, func() { os.RemoveAll(dir) }
+}
+
+func writeTestDataFileSet(
+	t *testing.T,
+	fsOpts fs.Options,
+	shard uint32,
+	blockStart time.Time,
+	ids ...string,
+) {
+	w, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+
+	require.NoError(t, w.Open(fs.DataWriterOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  testNamespaceID,
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+		BlockSize:   testDataBlockSize,
+		FileSetType: persist.FileSetFlushType,
+	}))
+
+	for _, id := range ids {
+		data := checked.NewBytes(testBytes, nil)
+		data.IncRef()
+		tags := ident.NewTags(ident.StringTag("city", id))
+		require.NoError(t, w.Write(ident.StringID(id), tags, data, 0))
+		data.DecRef()
+	}
+	require.NoError(t, w.Close())
+}
+
+func readTestIndexInfoFiles(t *testing.T, fsOpts fs.Options) []fs.ReadIndexInfoFileResult {
+	results := fs.ReadIndexInfoFiles(fsOpts.FilePathPrefix(), testNamespaceID,
+		fsOpts.InfoReaderBufferSize())
+	for _, result := range results {
+		require.NoError(t, result.Err.Error())
+	}
+	return results
+}
+
+func TestIndexRebuilderRebuildBlock(t *testing.T) {
+	rebuilder, fsOpts, cleanup := newTestRebuilder(t)
+	defer cleanup()
+
+	blockStart := time.Now().Truncate(testIndexBlockSize)
+	writeTestDataFileSet(t, fsOpts, 0, blockStart, "foo", "bar")
+	writeTestDataFileSet(t, fsOpts, 0, blockStart.Add(testDataBlockSize), "foo", "baz")
+	writeTestDataFileSet(t, fsOpts, 1, blockStart, "qux")
+
+	res, err := rebuilder.RebuildBlock(IndexBlockRebuildOptions{
+		NamespaceMetadata: newTestNamespaceMetadata(t),
+		BlockStart:        blockStart,
+		Shards:            []uint32{0, 1},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, IndexBlockRebuildResult{
+		DataFileSetsRead:    3,
+		DataFileSetsMissing: 1,
+		Documents:           4,
+		Persisted:           true,
+	}, res)
+
+	results := readTestIndexInfoFiles(t, fsOpts)
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, blockStart.UnixNano(), results[0].Info.BlockStart)
+	shards := results[0].Info.Shards
+	sort.Slice(shards, func(i, j int) bool { return shards[i] < shards[j] })
+	assert.Equal(t, []uint32{0, 1}, shards)
+
+	segments, err := fs.ReadIndexSegments(fs.ReadIndexSegmentsOptions{
+		ReaderOptions: fs.IndexReaderOpenOptions{
+			Identifier:  results[0].ID,
+			FileSetType: persist.FileSetFlushType,
+		},
+		FilesystemOptions: fsOpts,
+	})
+	require.NoError(t, err)
+
+	var numDocs int64
+	for _, seg := range segments {
+		numDocs += seg.Size()
+		require.NoError(t, seg.Close())
+	}
+	assert.Equal(t, int64(4), numDocs)
+}
+
+func TestIndexRebuilderRebuildBlockRemoveExisting(t *testing.T) {
+	rebuilder, fsOpts, cleanup := newTestRebuilder(t)
+	defer cleanup()
+
+	blockStart := time.Now().Truncate(testIndexBlockSize)
+	writeTestDataFileSet(t, fsOpts, 0, blockStart, "foo")
+
+	opts := IndexBlockRebuildOptions{
+		NamespaceMetadata: newTestNamespaceMetadata(t),
+		BlockStart:        blockStart,
+		Shards:            []uint32{0},
+	}
+	_, err := rebuilder.RebuildBlock(opts)
+	require.NoError(t, err)
+	_, err = rebuilder.RebuildBlock(opts)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(readTestIndexInfoFiles(t, fsOpts)))
+
+	opts.RemoveExisting = true
+	res, err := rebuilder.RebuildBlock(opts)
+	require.NoError(t, err)
+	assert.True(t, res.Persisted)
+	assert.Equal(t, 2, res.RemovedFileSets)
+
+	results := readTestIndexInfoFiles(t, fsOpts)
+	require.Equal(t, 1, len(results))
+	assert.Equal(t, 2, results[0].ID.VolumeIndex)
+}
+
+func TestIndexRebuilderRebuildBlockNoData(t *testing.T) {
+	rebuilder, fsOpts, cleanup := newTestRebuilder(t)
+	defer cleanup()
+
+	res, err := rebuilder.RebuildBlock(IndexBlockRebuildOptions{
+		NamespaceMetadata: newTestNamespaceMetadata(t),
+		BlockStart:        time.Now().Truncate(testIndexBlockSize),
+		Shards:            []uint32{0},
+	})
+	require.NoError(t, err)
+	assert.False(t, res.Persisted)
+	assert.Equal(t, 2, res.DataFileSetsMissing)
+	assert.Equal(t, 0, len(readTestIndexInfoFiles(t, fsOpts)))
+}
+
+func TestIndexRebuilderRebuildBlockUnalignedBlockStart(t *testing.T) {
+	rebuilder, _, cleanup := newTestRebuilder(t)
+	defer cleanup()
+
+	_, err := rebuilder.RebuildBlock(IndexBlockRebuildOptions{
+		NamespaceMetadata: newTestNamespaceMetadata(t),
+		BlockStart:        time.Now().Truncate(testIndexBlockSize).Add(testDataBlockSize),
+		Shards:            []uint32{0},
+	})
+	require.Error(t, err)
+}
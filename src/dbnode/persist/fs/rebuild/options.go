@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package rebuild
+
+import (
+	"errors"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
+)
+
+var (
+	errNoFilesystemOptions   = errors.New("no filesystem options in index rebuild options")
+	errNoIndexBuilderOptions = errors.New("no index builder options in index rebuild options")
+)
+
+type options struct {
+	fsOpts      fs.Options
+	builderOpts builder.Options
+}
+
+// NewOptions creates new index rebuild options.
+func NewOptions() Options {
+	return &options{
+		fsOpts:      fs.NewOptions(),
+		builderOpts: builder.NewOptions(),
+	}
+}
+
+func (o *options) Validate() error {
+	if o.fsOpts == nil {
+		return errNoFilesystemOptions
+	}
+	if o.builderOpts == nil {
+		return errNoIndexBuilderOptions
+	}
+	return o.fsOpts.Validate()
+}
+
+func (o *options) SetFilesystemOptions(value fs.Options) Options {
+	opts := *o
+	opts.fsOpts = value
+	return &opts
+}
+
+func (o *options) FilesystemOptions() fs.Options {
+	return o.fsOpts
+}
+
+func (o *options) SetIndexBuilderOptions(value builder.Options) Options {
+	opts := *o
+	opts.builderOpts = value
+	return &opts
+}
+
+func (o *options) IndexBuilderOptions() builder.Options {
+	return o.builderOpts
+}
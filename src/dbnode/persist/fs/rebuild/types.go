@@ -0,0 +1,95 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package rebuild provides offline rebuilding of index filesets from the
+// series metadata stored in data filesets.
+package rebuild
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
+)
+
+// IndexRebuilder rebuilds index filesets from data filesets.
+type IndexRebuilder interface {
+	// RebuildBlock rebuilds and persists the index fileset for a single
+	// index block using the series metadata of the data filesets of the
+	// given shards that fall within the block.
+	RebuildBlock(opts IndexBlockRebuildOptions) (IndexBlockRebuildResult, error)
+}
+
+// IndexBlockRebuildOptions is the options struct for rebuilding an index block.
+type IndexBlockRebuildOptions struct {
+	// NamespaceMetadata is the metadata of the namespace to rebuild.
+	NamespaceMetadata namespace.Metadata
+
+	// BlockStart is the start of the index block to rebuild, it must be
+	// aligned to the index block size of the namespace.
+	BlockStart time.Time
+
+	// Shards are the shards whose data filesets are read, the rebuilt index
+	// fileset covers exactly these shards.
+	Shards []uint32
+
+	// RemoveExisting removes the existing index filesets for the block once
+	// the rebuilt index fileset has been written, which is required when the
+	// existing filesets are corrupt or use an older format.
+	RemoveExisting bool
+}
+
+// IndexBlockRebuildResult describes the outcome of rebuilding an index block.
+type IndexBlockRebuildResult struct {
+	// DataFileSetsRead is the number of data filesets read.
+	DataFileSetsRead int
+
+	// DataFileSetsMissing is the number of data blocks within the index
+	// block for which no data fileset exists.
+	DataFileSetsMissing int
+
+	// Documents is the number of documents in the rebuilt index fileset.
+	Documents int
+
+	// Persisted is whether an index fileset was written, no fileset is
+	// written if the data filesets contain no series.
+	Persisted bool
+
+	// RemovedFileSets is the number of existing index filesets removed.
+	RemovedFileSets int
+}
+
+// Options represents the options for rebuilding index filesets.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetFilesystemOptions sets the filesystem options.
+	SetFilesystemOptions(value fs.Options) Options
+
+	// FilesystemOptions returns the filesystem options.
+	FilesystemOptions() fs.Options
+
+	// SetIndexBuilderOptions sets the index segment builder options.
+	SetIndexBuilderOptions(value builder.Options) Options
+
+	// IndexBuilderOptions returns the index segment builder options.
+	IndexBuilderOptions() builder.Options
+}
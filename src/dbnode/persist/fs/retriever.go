@@ -89,14 +89,19 @@ type blockRetriever struct {
 	idPool     ident.Pool
 	nsMetadata namespace.Metadata
 
-	blockSize time.Duration
+	blockSize  time.Duration
+	prefetcher *blockPrefetcher
 
 	status                     blockRetrieverStatus
 	reqsByShardIdx             []*shardRetrieveRequests
 	seekerMgr                  DataFileSetSeekerManager
 	notifyFetch                chan struct{}
+	fetchBatchCh               chan retrieveBatch
 	fetchLoopsShouldShutdownCh chan struct{}
 	fetchLoopsHaveShutdownCh   chan struct{}
+
+	handOffsLock  sync.Mutex
+	handOffsByCtx map[context.Context]int
 }
 
 // NewBlockRetriever returns a new block retriever for TSDB file sets.
@@ -118,10 +123,13 @@ func NewBlockRetriever(
 		idPool:         opts.IdentifierPool(),
 		status:         blockRetrieverNotOpen,
 		notifyFetch:    make(chan struct{}, 1),
+		// Unbuffered so that batches are only handed off to idle fetch loops
+		fetchBatchCh: make(chan retrieveBatch),
 		// We just close this channel when the fetchLoops should shutdown, so no
 		// buffering is required
 		fetchLoopsShouldShutdownCh: make(chan struct{}),
 		fetchLoopsHaveShutdownCh:   make(chan struct{}, opts.FetchConcurrency()),
+		handOffsByCtx:              make(map[context.Context]int),
 	}, nil
 }
 
@@ -145,6 +153,11 @@ func (r *blockRetriever) Open(ns namespace.Metadata) error {
 	// Cache blockSize result
 	r.blockSize = ns.Options().RetentionOptions().BlockSize()
 
+	if r.opts.PrefetchBlocks() > 0 {
+		r.prefetcher = newBlockPrefetcher(r.opts, r.fsOpts,
+			ns.Options().RetentionOptions())
+	}
+
 	for i := 0; i < r.opts.FetchConcurrency(); i++ {
 		go r.fetchLoop(seekerMgr)
 	}
@@ -168,6 +181,17 @@ func (r *blockRetriever) CacheShardIndices(shards []uint32) error {
 	return seekerMgr.CacheShardIndices(shards)
 }
 
+// retrieveBatch is a set of requests for a single shard and block start
+// that a fetch loop has handed off to be fetched by another fetch loop.
+type retrieveBatch struct {
+	shard      uint32
+	blockStart time.Time
+	reqs       []*retrieveRequest
+	// ctxs are the contexts of the requests, which each hold a hand off
+	// until the batch has been fetched.
+	ctxs []context.Context
+}
+
 func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 	var (
 		seekerResources = NewReusableSeekerResources(r.fsOpts)
@@ -194,9 +218,16 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 		}
 
 		status := r.status
-		n := len(inFlight)
+		prefetcher := r.prefetcher
 		r.RUnlock()
 
+		// Add any blocks queued for prefetching that have not been requested
+		// by the requests already in flight.
+		if prefetcher != nil && status == blockRetrieverOpen {
+			inFlight = r.appendPrefetchRequests(seekerMgr, prefetcher, inFlight)
+		}
+		n := len(inFlight)
+
 		// Exit if not open and fulfilled all open requests
 		if n == 0 && status != blockRetrieverOpen {
 			break
@@ -207,6 +238,11 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 			select {
 			case <-r.notifyFetch:
 				continue
+			case batch := <-r.fetchBatchCh:
+				r.fetchBatch(seekerMgr, batch.shard, batch.blockStart,
+					batch.reqs, seekerResources)
+				r.releaseHandOffs(batch.ctxs)
+				continue
 			case <-r.fetchLoopsShouldShutdownCh:
 				break
 			}
@@ -235,7 +271,7 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 				req.shard != currBatchShard {
 				// Fetch any outstanding in the current batch
 				if len(currBatchReqs) > 0 {
-					r.fetchBatch(
+					r.fetchOrHandOffBatch(
 						seekerMgr, currBatchShard, currBatchStart, currBatchReqs, seekerResources)
 					for i := range currBatchReqs {
 						currBatchReqs[i] = nil
@@ -266,6 +302,113 @@ func (r *blockRetriever) fetchLoop(seekerMgr DataFileSetSeekerManager) {
 	r.fetchLoopsHaveShutdownCh <- struct{}{}
 }
 
+// fetchOrHandOffBatch hands off a batch of requests to any idle fetch loop so
+// that a fetch spanning many blocks, such as a long range query for a single
+// series, retrieves the blocks concurrently rather than sequentially. If all
+// fetch loops are busy the batch is fetched by the calling fetch loop.
+// Since each fetch loop only ever borrows one seeker at a time the number of
+// concurrent retrievals remains bounded by the fetch concurrency.
+func (r *blockRetriever) fetchOrHandOffBatch(
+	seekerMgr DataFileSetSeekerManager,
+	shard uint32,
+	blockStart time.Time,
+	reqs []*retrieveRequest,
+	seekerResources ReusableSeekerResources,
+) {
+	if r.tryHandOffBatch(shard, blockStart, reqs) {
+		return
+	}
+	r.fetchBatch(seekerMgr, shard, blockStart, reqs, seekerResources)
+}
+
+// tryHandOffBatch returns whether the batch of requests was handed off to an
+// idle fetch loop. Batches are not handed off if any of the queries that
+// requested them, identified by their context, already has the maximum
+// number of batches handed off so that a single long range query cannot
+// occupy every fetch loop.
+func (r *blockRetriever) tryHandOffBatch(
+	shard uint32,
+	blockStart time.Time,
+	reqs []*retrieveRequest,
+) bool {
+	if r.opts.FetchConcurrency() <= 1 {
+		return false
+	}
+	ctxs, ok := r.acquireHandOffs(reqs)
+	if !ok {
+		return false
+	}
+	// Take a copy of the requests since the caller reuses the slice.
+	batch := retrieveBatch{
+		shard:      shard,
+		blockStart: blockStart,
+		reqs:       append([]*retrieveRequest(nil), reqs...),
+		ctxs:       ctxs,
+	}
+	select {
+	case r.fetchBatchCh <- batch:
+		return true
+	default:
+		// No fetch loops idle, the batch is fetched inline.
+		r.releaseHandOffs(ctxs)
+		return false
+	}
+}
+
+// acquireHandOffs takes a hand off for each of the distinct contexts of the
+// requests, failing if any of them has no hand offs left. Prefetch requests
+// have no context and are not limited.
+func (r *blockRetriever) acquireHandOffs(reqs []*retrieveRequest) ([]context.Context, bool) {
+	limit := r.opts.FetchHandOffsPerQuery()
+	if limit <= 0 {
+		return nil, false
+	}
+
+	var ctxs []context.Context
+	for _, req := range reqs {
+		if req.ctx == nil || containsContext(ctxs, req.ctx) {
+			continue
+		}
+		ctxs = append(ctxs, req.ctx)
+	}
+
+	r.handOffsLock.Lock()
+	defer r.handOffsLock.Unlock()
+	for _, ctx := range ctxs {
+		if r.handOffsByCtx[ctx] >= limit {
+			return nil, false
+		}
+	}
+	for _, ctx := range ctxs {
+		r.handOffsByCtx[ctx]++
+	}
+	return ctxs, true
+}
+
+func (r *blockRetriever) releaseHandOffs(ctxs []context.Context) {
+	if len(ctxs) == 0 {
+		return
+	}
+	r.handOffsLock.Lock()
+	for _, ctx := range ctxs {
+		if n := r.handOffsByCtx[ctx] - 1; n > 0 {
+			r.handOffsByCtx[ctx] = n
+		} else {
+			delete(r.handOffsByCtx, ctx)
+		}
+	}
+	r.handOffsLock.Unlock()
+}
+
+func containsContext(ctxs []context.Context, ctx context.Context) bool {
+	for _, c := range ctxs {
+		if c == ctx {
+			return true
+		}
+	}
+	return false
+}
+
 func (r *blockRetriever) fetchBatch(
 	seekerMgr DataFileSetSeekerManager,
 	shard uint32,
@@ -273,6 +416,10 @@ func (r *blockRetriever) fetchBatch(
 	reqs []*retrieveRequest,
 	seekerResources ReusableSeekerResources,
 ) {
+	// Prefetch requests have no caller to finalize them, so finalize them
+	// on the caller's behalf once the batch has been fetched.
+	defer r.finishPrefetchRequests(reqs)
+
 	// Resolve the seeker from the seeker mgr
 	seeker, err := seekerMgr.Borrow(shard, blockStart)
 	if err != nil {
@@ -282,6 +429,27 @@ func (r *blockRetriever) fetchBatch(
 		return
 	}
 
+	// Read the data of the whole batch ahead through a separate file handle
+	// if any of the requests are part of a sequential scan, since the batch
+	// is read in ascending offset order.
+	for _, req := range reqs {
+		if req.sequential {
+			seekerResources.sequential = true
+			break
+		}
+	}
+	if seekerResources.sequential {
+		defer func() {
+			if err := seekerResources.sequentialFileReader.close(); err != nil {
+				r.logger.Error("err closing sequential reader for shard",
+					zap.Uint32("shard", shard),
+					zap.Int64("blockStart", blockStart.Unix()),
+					zap.Error(err),
+				)
+			}
+		}()
+	}
+
 	// Sort the requests by offset into the file before seeking
 	// to ensure all seeks are in ascending order
 	for _, req := range reqs {
@@ -394,6 +562,8 @@ func (r *blockRetriever) Stream(
 	req.id = r.idPool.Clone(id)
 	req.start = startTime
 	req.blockSize = r.blockSize
+	req.sequential = xio.SequentialReads(ctx)
+	req.ctx = ctx
 
 	req.onRetrieve = onRetrieve
 	req.resultWg.Add(1)
@@ -409,6 +579,7 @@ func (r *blockRetriever) Stream(
 		r.RUnlock()
 		return xio.EmptyBlockReader, errNoSeekerMgr
 	}
+	prefetcher := r.prefetcher
 	r.RUnlock()
 
 	idExists, err := r.seekerMgr.Test(id, shard, startTime)
@@ -432,6 +603,12 @@ func (r *blockRetriever) Stream(
 	reqs.queued = append(reqs.queued, req)
 	reqs.Unlock()
 
+	// The series exists in this block so is likely to exist in the adjacent
+	// blocks too, queue them to be prefetched.
+	if prefetcher != nil && onRetrieve != nil {
+		prefetcher.observe(shard, id, startTime, onRetrieve, nsCtx)
+	}
+
 	// Notify fetch loop
 	select {
 	case r.notifyFetch <- struct{}{}:
@@ -447,6 +624,51 @@ func (r *blockRetriever) Stream(
 	return req.toBlock(), nil
 }
 
+func (r *blockRetriever) appendPrefetchRequests(
+	seekerMgr DataFileSetSeekerManager,
+	prefetcher *blockPrefetcher,
+	inFlight []*retrieveRequest,
+) []*retrieveRequest {
+	for _, candidate := range prefetcher.take(inFlight) {
+		var (
+			shard = candidate.key.shard
+			start = candidate.key.start.ToTime()
+		)
+		// Only prefetch blocks the series is likely to exist in, this also
+		// skips blocks that have no fileset on disk.
+		idExists, err := seekerMgr.Test(candidate.id, shard, start)
+		if err != nil || !idExists {
+			prefetcher.done(candidate.key)
+			candidate.id.Finalize()
+			continue
+		}
+
+		req := r.reqPool.Get()
+		req.shard = shard
+		req.id = candidate.id
+		req.start = start
+		req.blockSize = prefetcher.blockSize
+		req.onRetrieve = candidate.onRetrieve
+		req.nsCtx = candidate.nsCtx
+		req.prefetch = true
+		req.prefetchKey = candidate.key
+		req.resultWg.Add(1)
+
+		inFlight = append(inFlight, req)
+	}
+	return inFlight
+}
+
+func (r *blockRetriever) finishPrefetchRequests(reqs []*retrieveRequest) {
+	for _, req := range reqs {
+		if !req.prefetch {
+			continue
+		}
+		r.prefetcher.done(req.prefetchKey)
+		req.onCallerOrRetrieverDone()
+	}
+}
+
 func (req *retrieveRequest) toBlock() xio.BlockReader {
 	return xio.BlockReader{
 		SegmentReader: req,
@@ -519,6 +741,10 @@ func (r *blockRetriever) Close() error {
 		<-r.fetchLoopsHaveShutdownCh
 	}
 
+	if r.prefetcher != nil {
+		r.prefetcher.close()
+	}
+
 	return r.seekerMgr.Close()
 }
 
@@ -549,6 +775,11 @@ type retrieveRequest struct {
 	onRetrieve block.OnRetrieveBlock
 	nsCtx      namespace.Context
 
+	// ctx is the context of the caller that requested the block, it
+	// identifies the query when limiting the batches handed off between
+	// fetch loops and is nil for prefetch requests.
+	ctx context.Context
+
 	indexEntry IndexEntry
 	reader     xio.SegmentReader
 
@@ -561,6 +792,15 @@ type retrieveRequest struct {
 	shard     uint32
 
 	notFound bool
+
+	// sequential is set for requests that are part of a sequential scan of
+	// full series, the data for these is read ahead of the data requested.
+	sequential bool
+
+	// prefetch is set for requests issued by the prefetcher rather than by
+	// a caller, prefetchKey identifies the budget held by the request.
+	prefetch    bool
+	prefetchKey prefetchKey
 }
 
 func (req *retrieveRequest) onError(err error) {
@@ -659,10 +899,14 @@ func (req *retrieveRequest) resetForReuse() {
 	req.start = time.Time{}
 	req.blockSize = 0
 	req.onRetrieve = nil
+	req.ctx = nil
 	req.indexEntry = IndexEntry{}
 	req.reader = nil
 	req.err = nil
 	req.notFound = false
+	req.sequential = false
+	req.prefetch = false
+	req.prefetchKey = prefetchKey{}
 }
 
 func (req *retrieveRequest) foundAndHasNoError() bool {
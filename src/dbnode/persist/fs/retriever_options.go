@@ -35,6 +35,21 @@ var (
 	defaultFetchConcurrency = runtime.NumCPU()
 
 	errBlockLeaseManagerNotSet = errors.New("block lease manager is not set")
+	errPrefetchBlocksNegative  = errors.New("prefetch blocks must not be negative")
+	errPrefetchBudgetNegative  = errors.New("prefetch budget must not be negative")
+
+	errFetchHandOffsPerQueryNegative = errors.New(
+		"fetch hand offs per query must not be negative")
+)
+
+const (
+	// Prefetching is disabled by default.
+	defaultPrefetchBlocks = 0
+	defaultPrefetchBudget = 4096
+
+	// Each query can have up to four batches retrieved by other fetch loops
+	// in addition to the fetch loop that received its requests.
+	defaultFetchHandOffsPerQuery = 4
 )
 
 type blockRetrieverOptions struct {
@@ -43,6 +58,9 @@ type blockRetrieverOptions struct {
 	fetchConcurrency  int
 	identifierPool    ident.Pool
 	blockLeaseManager block.LeaseManager
+	prefetchBlocks    int
+	prefetchBudget    int
+	handOffsPerQuery  int
 }
 
 // NewBlockRetrieverOptions creates a new set of block retriever options
@@ -65,6 +83,9 @@ func NewBlockRetrieverOptions() BlockRetrieverOptions {
 		bytesPool:        bytesPool,
 		fetchConcurrency: defaultFetchConcurrency,
 		identifierPool:   ident.NewPool(bytesPool, ident.PoolOptions{}),
+		prefetchBlocks:   defaultPrefetchBlocks,
+		prefetchBudget:   defaultPrefetchBudget,
+		handOffsPerQuery: defaultFetchHandOffsPerQuery,
 	}
 
 	return o
@@ -74,6 +95,15 @@ func (o *blockRetrieverOptions) Validate() error {
 	if o.blockLeaseManager == nil {
 		return errBlockLeaseManagerNotSet
 	}
+	if o.prefetchBlocks < 0 {
+		return errPrefetchBlocksNegative
+	}
+	if o.prefetchBudget < 0 {
+		return errPrefetchBudgetNegative
+	}
+	if o.handOffsPerQuery < 0 {
+		return errFetchHandOffsPerQueryNegative
+	}
 	return nil
 }
 
@@ -126,3 +156,33 @@ func (o *blockRetrieverOptions) SetBlockLeaseManager(leaseMgr block.LeaseManager
 func (o *blockRetrieverOptions) BlockLeaseManager() block.LeaseManager {
 	return o.blockLeaseManager
 }
+
+func (o *blockRetrieverOptions) SetPrefetchBlocks(value int) BlockRetrieverOptions {
+	opts := *o
+	opts.prefetchBlocks = value
+	return &opts
+}
+
+func (o *blockRetrieverOptions) PrefetchBlocks() int {
+	return o.prefetchBlocks
+}
+
+func (o *blockRetrieverOptions) SetPrefetchBudget(value int) BlockRetrieverOptions {
+	opts := *o
+	opts.prefetchBudget = value
+	return &opts
+}
+
+func (o *blockRetrieverOptions) PrefetchBudget() int {
+	return o.prefetchBudget
+}
+
+func (o *blockRetrieverOptions) SetFetchHandOffsPerQuery(value int) BlockRetrieverOptions {
+	opts := *o
+	opts.handOffsPerQuery = value
+	return &opts
+}
+
+func (o *blockRetrieverOptions) FetchHandOffsPerQuery() int {
+	return o.handOffsPerQuery
+}
@@ -0,0 +1,187 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/x/clock"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+// blockPrefetcher tracks the blocks read by queries and speculatively queues
+// retrieval of the blocks adjacent to them for the same series. Prefetched
+// blocks are handed to the same OnRetrieveBlock callback as the block that
+// triggered them, which caches them with the series so that queries that
+// extend or shift their time range do not need to go to disk.
+type blockPrefetcher struct {
+	sync.Mutex
+
+	blocks        int
+	budget        int
+	blockSize     time.Duration
+	retentionOpts retention.Options
+	nowFn         clock.NowFn
+	idPool        ident.Pool
+
+	pending     []prefetchCandidate
+	outstanding map[prefetchKey]struct{}
+}
+
+type prefetchKey struct {
+	shard uint32
+	start xtime.UnixNano
+	id    string
+}
+
+type prefetchCandidate struct {
+	key        prefetchKey
+	id         ident.ID
+	onRetrieve block.OnRetrieveBlock
+	nsCtx      namespace.Context
+}
+
+func newBlockPrefetcher(
+	opts BlockRetrieverOptions,
+	fsOpts Options,
+	retentionOpts retention.Options,
+) *blockPrefetcher {
+	return &blockPrefetcher{
+		blocks:        opts.PrefetchBlocks(),
+		budget:        opts.PrefetchBudget(),
+		blockSize:     retentionOpts.BlockSize(),
+		retentionOpts: retentionOpts,
+		nowFn:         fsOpts.ClockOptions().NowFn(),
+		idPool:        opts.IdentifierPool(),
+		outstanding:   make(map[prefetchKey]struct{}),
+	}
+}
+
+// observe queues the blocks adjacent to a block that was requested by a
+// query, blocks are only queued while within the prefetch budget and if
+// they have already been flushed and are still within retention.
+func (p *blockPrefetcher) observe(
+	shard uint32,
+	id ident.ID,
+	start time.Time,
+	onRetrieve block.OnRetrieveBlock,
+	nsCtx namespace.Context,
+) {
+	var (
+		now        = p.nowFn()
+		earliest   = retention.FlushTimeStart(p.retentionOpts, now)
+		latest     = retention.FlushTimeEnd(p.retentionOpts, now)
+		candidates [2]time.Time
+	)
+
+	p.Lock()
+	defer p.Unlock()
+
+	for i := 1; i <= p.blocks; i++ {
+		offset := time.Duration(i) * p.blockSize
+		candidates[0] = start.Add(offset)
+		candidates[1] = start.Add(-offset)
+		for _, candidate := range candidates {
+			if candidate.Before(earliest) || candidate.After(latest) {
+				continue
+			}
+			if len(p.outstanding) >= p.budget {
+				return
+			}
+
+			key := prefetchKey{
+				shard: shard,
+				start: xtime.ToUnixNano(candidate),
+				id:    id.String(),
+			}
+			if _, ok := p.outstanding[key]; ok {
+				continue
+			}
+
+			p.outstanding[key] = struct{}{}
+			p.pending = append(p.pending, prefetchCandidate{
+				key:        key,
+				id:         p.idPool.Clone(id),
+				onRetrieve: onRetrieve,
+				nsCtx:      nsCtx,
+			})
+		}
+	}
+}
+
+// take returns the queued candidates for a fetch loop to retrieve, skipping
+// any candidates that have already been requested by queries in flight.
+func (p *blockPrefetcher) take(
+	inFlight []*retrieveRequest,
+) []prefetchCandidate {
+	p.Lock()
+	defer p.Unlock()
+
+	if len(p.pending) == 0 {
+		return nil
+	}
+
+	requested := make(map[prefetchKey]struct{}, len(inFlight))
+	for _, req := range inFlight {
+		requested[prefetchKey{
+			shard: req.shard,
+			start: xtime.ToUnixNano(req.start),
+			id:    req.id.String(),
+		}] = struct{}{}
+	}
+
+	candidates := make([]prefetchCandidate, 0, len(p.pending))
+	for i, candidate := range p.pending {
+		p.pending[i] = prefetchCandidate{}
+		if _, ok := requested[candidate.key]; ok {
+			delete(p.outstanding, candidate.key)
+			candidate.id.Finalize()
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+	p.pending = p.pending[:0]
+	return candidates
+}
+
+// done releases the budget held by a prefetch request.
+func (p *blockPrefetcher) done(key prefetchKey) {
+	p.Lock()
+	delete(p.outstanding, key)
+	p.Unlock()
+}
+
+// close releases all pending candidates.
+func (p *blockPrefetcher) close() {
+	p.Lock()
+	defer p.Unlock()
+
+	for i, candidate := range p.pending {
+		p.pending[i] = prefetchCandidate{}
+		delete(p.outstanding, candidate.key)
+		candidate.id.Finalize()
+	}
+	p.pending = p.pending[:0]
+}
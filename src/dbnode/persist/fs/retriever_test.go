@@ -31,6 +31,7 @@ import (
 	"path/filepath"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -40,6 +41,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/checked"
+	xclock "github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
@@ -655,6 +657,266 @@ func TestBlockRetrieverOnlyCreatesTagItersIfTagsExists(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestBlockRetrieverSingleSeriesManyBlocks verifies that a single series read
+// across many blocks, which hands off blocks between fetch loops to retrieve
+// them concurrently, returns the data for each block.
+func TestBlockRetrieverSingleSeriesManyBlocks(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Minute)()
+
+	// Make sure reader/writer are looking at the same test directory.
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePathPrefix := filepath.Join(dir, "")
+
+	// Setup constants and config.
+	var (
+		fsOpts    = testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+		rOpts     = testNs1Metadata(t).Options().RetentionOptions()
+		nsCtx     = namespace.NewContextFrom(testNs1Metadata(t))
+		shard     = uint32(0)
+		id        = ident.StringID("foo")
+		numBlocks = 8
+		end       = time.Now().Truncate(rOpts.BlockSize())
+	)
+
+	// Setup the reader.
+	opts := testBlockRetrieverOptions{
+		retrieverOpts: defaultTestBlockRetrieverOptions.SetFetchConcurrency(4),
+		fsOpts:        fsOpts,
+	}
+	retriever, cleanup := newOpenTestBlockRetriever(t, opts)
+	defer cleanup()
+
+	// Write out a test file per block.
+	blockStarts := make([]time.Time, 0, numBlocks)
+	for i := 0; i < numBlocks; i++ {
+		blockStart := end.Add(-time.Duration(i) * rOpts.BlockSize())
+		blockStarts = append(blockStarts, blockStart)
+
+		w, closer := newOpenTestWriter(t, fsOpts, shard, blockStart, 0)
+		data := checked.NewBytes([]byte(fmt.Sprintf("block-%d", i)), nil)
+		data.IncRef()
+		err = w.Write(id, ident.Tags{}, data, digest.Checksum(data.Bytes()))
+		require.NoError(t, err)
+		data.DecRef()
+		closer()
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	readers := make([]xio.BlockReader, 0, numBlocks)
+	for _, blockStart := range blockStarts {
+		reader, err := retriever.Stream(ctx, shard, id, blockStart, nil, nsCtx)
+		require.NoError(t, err)
+		readers = append(readers, reader)
+	}
+
+	for i, reader := range readers {
+		segment, err := reader.Segment()
+		require.NoError(t, err)
+		require.NotNil(t, segment.Head)
+		require.Equal(t, fmt.Sprintf("block-%d", i), string(segment.Head.Bytes()))
+	}
+}
+
+// TestBlockRetrieverHandOffsPerQuery verifies that batches handed off between
+// fetch loops are retrieved concurrently and that the number of batches
+// handed off for a single query is limited.
+func TestBlockRetrieverHandOffsPerQuery(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Minute)()
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		fsOpts  = testDefaultOpts.SetFilePathPrefix(dir)
+		rOpts   = testNs1Metadata(t).Options().RetentionOptions()
+		shard   = uint32(0)
+		end     = time.Now().Truncate(rOpts.BlockSize())
+		active  int32
+		release = make(chan struct{})
+	)
+
+	// Seeks block until released so that batches being retrieved
+	// concurrently can be observed.
+	mockSeeker := NewMockConcurrentDataFileSetSeeker(ctrl)
+	mockSeeker.EXPECT().SeekIndexEntry(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(id ident.ID, resources ReusableSeekerResources) (IndexEntry, error) {
+			atomic.AddInt32(&active, 1)
+			<-release
+			return IndexEntry{}, errSeekErr
+		}).AnyTimes()
+
+	mockSeekerManager := NewMockDataFileSetSeekerManager(ctrl)
+	mockSeekerManager.EXPECT().Open(gomock.Any()).Return(nil)
+	mockSeekerManager.EXPECT().Borrow(gomock.Any(), gomock.Any()).Return(mockSeeker, nil).AnyTimes()
+	mockSeekerManager.EXPECT().Return(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	mockSeekerManager.EXPECT().Close().Return(nil)
+
+	opts := testBlockRetrieverOptions{
+		retrieverOpts: defaultTestBlockRetrieverOptions.
+			SetFetchConcurrency(4).
+			SetFetchHandOffsPerQuery(2),
+		fsOpts: fsOpts,
+		newSeekerMgrFn: func(
+			bytesPool pool.CheckedBytesPool,
+			opts Options,
+			blockRetrieverOpts BlockRetrieverOptions,
+		) DataFileSetSeekerManager {
+			return mockSeekerManager
+		},
+	}
+	retriever, cleanup := newOpenTestBlockRetriever(t, opts)
+	defer cleanup()
+
+	newRequest := func(ctx context.Context, block int) *retrieveRequest {
+		req := retriever.reqPool.Get()
+		req.shard = shard
+		req.id = ident.StringID("foo")
+		req.start = end.Add(-time.Duration(block) * rOpts.BlockSize())
+		req.ctx = ctx
+		req.resultWg.Add(1)
+		return req
+	}
+	// Retry hand offs since fetch loops may not be idle yet.
+	handOff := func(req *retrieveRequest) bool {
+		return xclock.WaitUntil(func() bool {
+			return retriever.tryHandOffBatch(shard, req.start,
+				[]*retrieveRequest{req})
+		}, time.Minute)
+	}
+
+	ctx, otherCtx := context.NewContext(), context.NewContext()
+	defer ctx.Close()
+	defer otherCtx.Close()
+
+	reqs := []*retrieveRequest{newRequest(ctx, 0), newRequest(ctx, 1)}
+	for _, req := range reqs {
+		require.True(t, handOff(req))
+	}
+
+	// The query has the maximum number of batches handed off.
+	limited := newRequest(ctx, 2)
+	require.False(t, retriever.tryHandOffBatch(shard, limited.start,
+		[]*retrieveRequest{limited}))
+
+	// Other queries are not affected.
+	other := newRequest(otherCtx, 0)
+	require.True(t, handOff(other))
+	reqs = append(reqs, other)
+
+	// All of the handed off batches are retrieved at once.
+	require.True(t, xclock.WaitUntil(func() bool {
+		return atomic.LoadInt32(&active) == int32(len(reqs))
+	}, time.Minute))
+
+	close(release)
+	for _, req := range reqs {
+		req.resultWg.Wait()
+		require.Equal(t, errSeekErr, req.err)
+	}
+
+	// Hand offs are released once the batches have been retrieved.
+	require.True(t, xclock.WaitUntil(func() bool {
+		retriever.handOffsLock.Lock()
+		defer retriever.handOffsLock.Unlock()
+		return len(retriever.handOffsByCtx) == 0
+	}, time.Minute))
+	require.True(t, handOff(limited))
+	limited.resultWg.Wait()
+	require.Equal(t, errSeekErr, limited.err)
+}
+
+// TestBlockRetrieverPrefetchesAdjacentBlocks verifies that retrieving a block
+// for a series prefetches the adjacent blocks for the same series and hands
+// them to the retrieve callback.
+func TestBlockRetrieverPrefetchesAdjacentBlocks(t *testing.T) {
+	defer leaktest.CheckTimeout(t, time.Minute)()
+
+	// Make sure reader/writer are looking at the same test directory.
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	filePathPrefix := filepath.Join(dir, "")
+
+	// Setup constants and config.
+	var (
+		fsOpts    = testDefaultOpts.SetFilePathPrefix(filePathPrefix)
+		rOpts     = testNs1Metadata(t).Options().RetentionOptions()
+		nsCtx     = namespace.NewContextFrom(testNs1Metadata(t))
+		shard     = uint32(0)
+		id        = ident.StringID("foo")
+		numBlocks = 5
+		end       = time.Now().Truncate(rOpts.BlockSize()).Add(-2 * rOpts.BlockSize())
+	)
+
+	// Setup the reader.
+	opts := testBlockRetrieverOptions{
+		retrieverOpts: defaultTestBlockRetrieverOptions.SetPrefetchBlocks(1),
+		fsOpts:        fsOpts,
+	}
+	retriever, cleanup := newOpenTestBlockRetriever(t, opts)
+	defer cleanup()
+
+	// Write out a test file per block.
+	blockStarts := make([]time.Time, 0, numBlocks)
+	for i := 0; i < numBlocks; i++ {
+		blockStart := end.Add(-time.Duration(i) * rOpts.BlockSize())
+		blockStarts = append(blockStarts, blockStart)
+
+		w, closer := newOpenTestWriter(t, fsOpts, shard, blockStart, 0)
+		data := checked.NewBytes([]byte(fmt.Sprintf("block-%d", i)), nil)
+		data.IncRef()
+		err = w.Write(id, ident.Tags{}, data, digest.Checksum(data.Bytes()))
+		require.NoError(t, err)
+		data.DecRef()
+		closer()
+	}
+
+	var (
+		retrievedLock sync.Mutex
+		retrieved     = make(map[xtime.UnixNano]string)
+		retrievedWg   sync.WaitGroup
+	)
+	// Expect the requested block and the block either side of it.
+	retrievedWg.Add(3)
+	onRetrieve := block.OnRetrieveBlockFn(func(
+		id ident.ID,
+		tagsIter ident.TagIterator,
+		startTime time.Time,
+		segment ts.Segment,
+		nsCtx namespace.Context,
+	) {
+		retrievedLock.Lock()
+		retrieved[xtime.ToUnixNano(startTime)] = string(segment.Head.Bytes())
+		retrievedLock.Unlock()
+		retrievedWg.Done()
+	})
+
+	ctx := context.NewContext()
+	reader, err := retriever.Stream(ctx, shard, id, blockStarts[2], onRetrieve, nsCtx)
+	require.NoError(t, err)
+	segment, err := reader.Segment()
+	require.NoError(t, err)
+	require.Equal(t, "block-2", string(segment.Head.Bytes()))
+	ctx.Close()
+
+	retrievedWg.Wait()
+	retrievedLock.Lock()
+	defer retrievedLock.Unlock()
+	assert.Equal(t, map[xtime.UnixNano]string{
+		xtime.ToUnixNano(blockStarts[1]): "block-1",
+		xtime.ToUnixNano(blockStarts[2]): "block-2",
+		xtime.ToUnixNano(blockStarts[3]): "block-3",
+	}, retrieved)
+}
+
 // TestBlockRetrieverHandlesErrors verifies the behavior of the Stream() method
 // on the retriever in the case where the SeekIndexEntry function returns an
 // error.
@@ -0,0 +1,224 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"io"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+type rollup struct {
+	reader         DataFileSetReader
+	blockAllocSize int
+	srPool         xio.SegmentReaderPool
+	multiIterPool  encoding.MultiReaderIteratorPool
+	identPool      ident.Pool
+	encoderPool    encoding.EncoderPool
+	nsOpts         namespace.Options
+}
+
+// NewRollup returns a new Rollup. This implementation rolls up the data of an
+// existing fileset to a coarser resolution by keeping the last datapoint of
+// each resolution window of every series, then persists the result.
+//
+// Like the merger, the rollup does not signal to the database of the
+// existence of the newly persisted data, nor does it clean up the original
+// fileset.
+func NewRollup(
+	reader DataFileSetReader,
+	blockAllocSize int,
+	srPool xio.SegmentReaderPool,
+	multiIterPool encoding.MultiReaderIteratorPool,
+	identPool ident.Pool,
+	encoderPool encoding.EncoderPool,
+	nsOpts namespace.Options,
+) Rollup {
+	return &rollup{
+		reader:         reader,
+		blockAllocSize: blockAllocSize,
+		srPool:         srPool,
+		multiIterPool:  multiIterPool,
+		identPool:      identPool,
+		encoderPool:    encoderPool,
+		nsOpts:         nsOpts,
+	}
+}
+
+// Rollup rolls up the data of a fileset to the given resolution and persists
+// it as the next volume of the fileset.
+func (r *rollup) Rollup(
+	fileID FileSetFileIdentifier,
+	resolution time.Duration,
+	nextVolumeIndex int,
+	flushPreparer persist.FlushPreparer,
+	nsCtx namespace.Context,
+) (err error) {
+	var (
+		reader    = r.reader
+		nsID      = fileID.Namespace
+		shard     = fileID.Shard
+		startTime = fileID.BlockStart
+		blockSize = r.nsOpts.RetentionOptions().BlockSize()
+		openOpts  = DataReaderOpenOptions{
+			Identifier: FileSetFileIdentifier{
+				Namespace:   nsID,
+				Shard:       shard,
+				BlockStart:  startTime,
+				VolumeIndex: fileID.VolumeIndex,
+			},
+			FileSetType: persist.FileSetFlushType,
+		}
+	)
+
+	if err := reader.Open(openOpts); err != nil {
+		return err
+	}
+	defer func() {
+		// Only set the error here if not set by the end of the function, since
+		// all other errors take precedence.
+		if err == nil {
+			err = reader.Close()
+		}
+	}()
+
+	nsMd, err := namespace.NewMetadata(nsID, r.nsOpts)
+	if err != nil {
+		return err
+	}
+	prepared, err := flushPreparer.PrepareData(persist.DataPrepareOptions{
+		NamespaceMetadata: nsMd,
+		Shard:             shard,
+		BlockStart:        startTime,
+		VolumeIndex:       nextVolumeIndex,
+		FileSetType:       persist.FileSetFlushType,
+		DeleteIfExists:    false,
+	})
+	if err != nil {
+		return err
+	}
+
+	var (
+		// It's safe to share these between iterations and just reset them each
+		// time because the series gets persisted each loop.
+		segReader  = r.srPool.Get()
+		segReaders = make([]xio.SegmentReader, 1)
+		multiIter  = r.multiIterPool.Get()
+
+		// IDs and tags read from disk are held on to by the underlying writer
+		// until the prepared persist is closed, so only finalize them at the
+		// end of the rollup.
+		idsToFinalize  = make([]ident.ID, 0, reader.Entries())
+		tagsToFinalize = make([]ident.Tags, 0, reader.Entries())
+	)
+	defer func() {
+		segReader.Finalize()
+		multiIter.Close()
+		for _, res := range idsToFinalize {
+			res.Finalize()
+		}
+		for _, res := range tagsToFinalize {
+			res.Finalize()
+		}
+	}()
+
+	for id, tagsIter, data, _, err := reader.Read(); err != io.EOF; id, tagsIter, data, _, err = reader.Read() {
+		if err != nil {
+			return err
+		}
+		idsToFinalize = append(idsToFinalize, id)
+
+		tags, err := convert.TagsFromTagsIter(id, tagsIter, r.identPool)
+		tagsIter.Close()
+		if err != nil {
+			return err
+		}
+		tagsToFinalize = append(tagsToFinalize, tags)
+
+		segReaders[0] = segmentReaderFromData(data, segReader)
+		multiIter.Reset(segReaders, startTime, blockSize, nsCtx.Schema)
+		segment, err := r.rollupIter(multiIter, startTime, resolution, nsCtx.Schema)
+		if err != nil {
+			return err
+		}
+		if err := persistSegment(id, tags, segment, prepared.Persist); err != nil {
+			return err
+		}
+	}
+
+	// Close the flush preparer, which writes the rest of the files in the
+	// fileset.
+	return prepared.Close()
+}
+
+func (r *rollup) rollupIter(
+	iter encoding.MultiReaderIterator,
+	blockStart time.Time,
+	resolution time.Duration,
+	schema namespace.SchemaDescr,
+) (ts.Segment, error) {
+	encoder := r.encoderPool.Get()
+	encoder.Reset(blockStart, r.blockAllocSize, schema)
+
+	var (
+		pending           bool
+		pendingWindow     time.Time
+		pendingDatapoint  ts.Datapoint
+		pendingUnit       xtime.Unit
+		pendingAnnotation ts.Annotation
+	)
+	for iter.Next() {
+		dp, unit, annotation := iter.Current()
+		window := dp.Timestamp.Truncate(resolution)
+		if pending && !window.Equal(pendingWindow) {
+			if err := encoder.Encode(pendingDatapoint, pendingUnit, pendingAnnotation); err != nil {
+				encoder.Close()
+				return ts.Segment{}, err
+			}
+		}
+		// Annotations may be reused by the iterator, so keep a copy of the
+		// one that belongs to the last datapoint of the window.
+		pending = true
+		pendingWindow = window
+		pendingDatapoint = dp
+		pendingUnit = unit
+		pendingAnnotation = append(pendingAnnotation[:0], annotation...)
+	}
+	if err := iter.Err(); err != nil {
+		encoder.Close()
+		return ts.Segment{}, err
+	}
+	if pending {
+		if err := encoder.Encode(pendingDatapoint, pendingUnit, pendingAnnotation); err != nil {
+			encoder.Close()
+			return ts.Segment{}, err
+		}
+	}
+
+	return encoder.Discard(), nil
+}
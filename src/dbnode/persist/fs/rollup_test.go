@@ -0,0 +1,109 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package fs
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRollupKeepsLastDatapointPerWindow(t *testing.T) {
+	diskData := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	diskData.Set(id0, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(0 * time.Second), Value: 0},
+		{Timestamp: startTime.Add(4 * time.Second), Value: 1},
+		{Timestamp: startTime.Add(9 * time.Second), Value: 2},
+		{Timestamp: startTime.Add(10 * time.Second), Value: 3},
+		{Timestamp: startTime.Add(25 * time.Second), Value: 4},
+		{Timestamp: startTime.Add(29 * time.Second), Value: 5},
+	}))
+	diskData.Set(id1, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(3 * time.Second), Value: 6},
+	}))
+
+	expected := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	expected.Set(id0, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(9 * time.Second), Value: 2},
+		{Timestamp: startTime.Add(10 * time.Second), Value: 3},
+		{Timestamp: startTime.Add(29 * time.Second), Value: 5},
+	}))
+	expected.Set(id1, datapointsToCheckedBytes(t, []ts.Datapoint{
+		{Timestamp: startTime.Add(3 * time.Second), Value: 6},
+	}))
+
+	testRollup(t, diskData, 10*time.Second, expected)
+}
+
+func TestRollupWithNoData(t *testing.T) {
+	diskData := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+	expected := newCheckedBytesByIDMap(newCheckedBytesByIDMapOptions{})
+
+	testRollup(t, diskData, 10*time.Second, expected)
+}
+
+func testRollup(
+	t *testing.T,
+	diskData *checkedBytesMap,
+	resolution time.Duration,
+	expectedData *checkedBytesMap,
+) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	reader := mockReaderFromData(ctrl, diskData)
+
+	var persisted []persistedData
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	preparer.EXPECT().PrepareData(gomock.Any()).DoAndReturn(
+		func(opts persist.DataPrepareOptions) (persist.PreparedDataPersist, error) {
+			require.Equal(t, 2, opts.VolumeIndex)
+			return persist.PreparedDataPersist{
+				Persist: func(id ident.ID, tags ident.Tags, segment ts.Segment, checksum uint32) error {
+					persisted = append(persisted, persistedData{
+						id:      id,
+						segment: segment.Clone(nil),
+					})
+					return nil
+				},
+				Close: func() error { return nil },
+			}, nil
+		})
+
+	nsOpts := namespace.NewOptions()
+	r := NewRollup(reader, 0, srPool, multiIterPool,
+		identPool, encoderPool, nsOpts)
+	fsID := FileSetFileIdentifier{
+		Namespace:   ident.StringID("test-ns"),
+		Shard:       uint32(8),
+		BlockStart:  startTime,
+		VolumeIndex: 1,
+	}
+	err := r.Rollup(fsID, resolution, 2, preparer, namespace.Context{})
+	require.NoError(t, err)
+
+	assertPersistedAsExpected(t, persisted, expectedData)
+}
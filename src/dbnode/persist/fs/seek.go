@@ -69,6 +69,7 @@ type seeker struct {
 	blockSize time.Duration
 
 	dataFd        *os.File
+	dataFilePath  string
 	indexFd       *os.File
 	indexFileSize int64
 
@@ -178,6 +179,7 @@ func (s *seeker) Open(
 	}); err != nil {
 		return err
 	}
+	s.dataFilePath = s.dataFd.Name()
 
 	var (
 		infoFdWithDigest           = resources.seekerOpenResources.infoFDDigestReader
@@ -333,7 +335,16 @@ func (s *seeker) SeekByIndexEntry(
 	entry IndexEntry,
 	resources ReusableSeekerResources,
 ) (checked.Bytes, error) {
+	var dataReader io.Reader = resources.offsetFileReader
 	resources.offsetFileReader.reset(s.dataFd, entry.Offset)
+	if resources.sequential {
+		// Fall back to reading through the shared data file handle if the
+		// data file cannot be opened again, it may have since been removed.
+		err := resources.sequentialFileReader.reset(s.dataFilePath, entry.Offset)
+		if err == nil {
+			dataReader = resources.sequentialFileReader
+		}
+	}
 
 	// Obtain an appropriately sized buffer.
 	var buffer checked.Bytes
@@ -350,7 +361,7 @@ func (s *seeker) SeekByIndexEntry(
 
 	// Copy the actual data into the underlying buffer.
 	underlyingBuf := buffer.Bytes()
-	n, err := io.ReadFull(resources.offsetFileReader, underlyingBuf)
+	n, err := io.ReadFull(dataReader, underlyingBuf)
 	if err != nil {
 		return nil, err
 	}
@@ -500,6 +511,8 @@ func (s *seeker) ConcurrentClone() (ConcurrentDataFileSetSeeker, error) {
 
 	seeker := &seeker{
 		opts:          s.opts,
+		start:         s.start,
+		blockSize:     s.blockSize,
 		indexFileSize: s.indexFileSize,
 		// BloomFilter is concurrency safe.
 		bloomFilter: s.bloomFilter,
@@ -508,8 +521,9 @@ func (s *seeker) ConcurrentClone() (ConcurrentDataFileSetSeeker, error) {
 
 		// Index and data fd's are always accessed via the ReadAt() / pread APIs so
 		// they are concurrency safe and can be shared among clones.
-		indexFd: s.indexFd,
-		dataFd:  s.dataFd,
+		indexFd:      s.indexFd,
+		dataFd:       s.dataFd,
+		dataFilePath: s.dataFilePath,
 	}
 
 	return seeker, nil
@@ -543,6 +557,10 @@ type ReusableSeekerResources struct {
 	fileDecoderStream *bufio.Reader
 	byteDecoderStream xmsgpack.ByteDecoderStream
 	offsetFileReader  *offsetFileReader
+	// sequentialFileReader is used in place of the offsetFileReader to read
+	// data when sequential is set.
+	sequentialFileReader *sequentialFileReader
+	sequential           bool
 	// This pool should only be used for calling DecodeIndexEntry. We use a
 	// special pool here to avoid the overhead of channel synchronization, as
 	// well as ref counting that comes with the checked bytes pool. In addition,
@@ -581,6 +599,7 @@ func NewReusableSeekerResources(opts Options) ReusableSeekerResources {
 		fileDecoderStream:         bufio.NewReaderSize(nil, seekReaderSize),
 		byteDecoderStream:         xmsgpack.NewByteDecoderStream(nil),
 		offsetFileReader:          newOffsetFileReader(),
+		sequentialFileReader:      newSequentialFileReader(opts.SeekSequentialReadSize()),
 		decodeIndexEntryBytesPool: newSimpleBytesPool(),
 		seekerOpenResources:       newReusableSeekerOpenResources(opts),
 	}
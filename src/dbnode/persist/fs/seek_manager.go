@@ -106,6 +106,10 @@ type seekerManager struct {
 
 	// Pool of seeker resources that can be used to open new seekers.
 	reusableSeekerResourcesPool pool.ObjectPool
+
+	// compactedSeekers are the seekers of compacted filesets shared by the
+	// seekers of the blocks they hold.
+	compactedSeekers *compactedSeekerRoots
 }
 
 type seekerUnreadBuf struct {
@@ -183,6 +187,7 @@ func NewSeekerManager(
 		logger:                      opts.InstrumentOptions().Logger(),
 		openCloseLoopDoneCh:         make(chan struct{}),
 		reusableSeekerResourcesPool: reusableSeekerResourcesPool,
+		compactedSeekers:            newCompactedSeekerRoots(),
 	}
 	m.openAnyUnopenSeekersFn = m.openAnyUnopenSeekers
 	m.newOpenSeekerFn = m.newOpenSeeker
@@ -736,15 +741,79 @@ func (m *seekerManager) newOpenSeeker(
 	blockStart time.Time,
 	volume int,
 ) (DataFileSetSeeker, error) {
-	exists, err := DataFileSetExists(
-		m.filePathPrefix, m.namespace, shard, blockStart, volume)
+	id, exists, err := DataFileSetForBlock(
+		m.filePathPrefix, m.namespaceMetadata, shard, blockStart, volume)
 	if err != nil {
 		return nil, err
 	}
+	if exists && m.isCompactedFileSetCandidate(id, blockStart) {
+		return m.newOpenCompactedSeeker(id, blockStart)
+	}
 	if !exists {
-		return nil, errSeekerManagerFileSetNotFound
+		// A shard that has not yet been split from its parent shard reads
+		// the blocks it has no fileset of its own for from its parent.
+		source, ok, err := SplitSourceDataFileSet(m.filePathPrefix,
+			m.namespaceMetadata, shard, blockStart)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, errSeekerManagerFileSetNotFound
+		}
+		shard, volume = source.ID.Shard, source.ID.VolumeIndex
 	}
 
+	return m.openSeeker(shard, blockStart, volume)
+}
+
+// isCompactedFileSetCandidate returns whether the fileset that holds a block
+// may be a compacted fileset, which is only the case if it is the fileset of
+// another block or if the block is the first block of a compacted block.
+func (m *seekerManager) isCompactedFileSetCandidate(
+	id FileSetFileIdentifier,
+	blockStart time.Time,
+) bool {
+	compactionOpts := m.namespaceMetadata.Options().CompactionOptions()
+	if !compactionOpts.Enabled {
+		return false
+	}
+	return !id.BlockStart.Equal(blockStart) ||
+		compactionOpts.CompactedBlockStart(blockStart).Equal(blockStart)
+}
+
+// newOpenCompactedSeeker returns a seeker of a block of a namespace that
+// compacts its blocks, which seeks the block in the compacted fileset that
+// holds it if the fileset is a compacted fileset.
+func (m *seekerManager) newOpenCompactedSeeker(
+	id FileSetFileIdentifier,
+	blockStart time.Time,
+) (DataFileSetSeeker, error) {
+	var (
+		blockSize = m.namespaceMetadata.Options().RetentionOptions().BlockSize()
+		key       = compactedSeekerKey{
+			shard:               id.Shard,
+			compactedBlockStart: xtime.ToUnixNano(id.BlockStart),
+			volume:              id.VolumeIndex,
+		}
+	)
+	root, compacted, err := m.compactedSeekers.getOrOpen(key, blockSize,
+		func() (DataFileSetSeeker, error) {
+			return m.openSeeker(id.Shard, id.BlockStart, id.VolumeIndex)
+		})
+	if err != nil || !compacted {
+		return root, err
+	}
+	return newCompactedSeeker(m.compactedSeekers, key, root, xtime.Range{
+		Start: blockStart,
+		End:   blockStart.Add(blockSize),
+	})
+}
+
+func (m *seekerManager) openSeeker(
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (DataFileSetSeeker, error) {
 	// NB(r): Use a lock on the unread buffer to avoid multiple
 	// goroutines reusing the unread buffer that we share between the seekers
 	// when we open each seeker.
@@ -765,7 +834,7 @@ func (m *seekerManager) newOpenSeeker(
 	seeker.setUnreadBuffer(m.unreadBuf.value)
 
 	resources := m.getSeekerResources()
-	err = seeker.Open(m.namespace, shard, blockStart, volume, resources)
+	err := seeker.Open(m.namespace, shard, blockStart, volume, resources)
 	m.putSeekerResources(resources)
 	if err != nil {
 		return nil, err
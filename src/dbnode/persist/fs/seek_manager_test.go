@@ -21,6 +21,8 @@
 package fs
 
 import (
+	"io/ioutil"
+	"os"
 	"sync"
 	"testing"
 	"time"
@@ -406,3 +408,60 @@ func TestSeekerManagerOpenCloseLoop(t *testing.T) {
 	// to prevent the test itself from interfering with the goroutine leak test
 	close(cleanupCh)
 }
+
+func TestSeekerManagerNewOpenSeekerCompactedFileSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	blockStarts, _, compacted := writeTestCompactedFileSet(t, dir)
+
+	m := NewSeekerManager(testBytesPool, testDefaultOpts.SetFilePathPrefix(dir),
+		defaultTestBlockRetrieverOptions).(*seekerManager)
+	m.namespace = testNs1ID
+	m.namespaceMetadata = newTestCompactionMetadata(t)
+	resources := NewReusableSeekerResources(testDefaultOpts)
+
+	requireSeekData := func(seeker ConcurrentDataFileSetSeeker, id string, expected []byte) {
+		data, err := seeker.SeekByID(ident.StringID(id), resources)
+		require.NoError(t, err)
+		data.IncRef()
+		require.Equal(t, expected, data.Bytes())
+		data.DecRef()
+		data.Finalize()
+	}
+
+	// The fileset of a block that is not compacted is seeked as is.
+	seeker, err := m.newOpenSeeker(1, blockStarts[0], 0)
+	require.NoError(t, err)
+	requireSeekData(seeker, "foo", []byte{1, 2, 3})
+	require.Empty(t, m.compactedSeekers.roots)
+	require.NoError(t, seeker.Close())
+
+	// The seekers of the blocks of a compacted fileset only seek their block.
+	seeker, err = m.newOpenSeeker(1, blockStarts[1], compacted.VolumeIndex)
+	require.NoError(t, err)
+	block := xtime.Range{Start: blockStarts[1], End: blockStarts[1].Add(testBlockSize)}
+	require.True(t, block.Equal(seeker.Range()))
+
+	clone, err := seeker.ConcurrentClone()
+	require.NoError(t, err)
+	requireSeekData(clone, "bar", []byte{6})
+	_, err = clone.SeekByID(ident.StringID("foo"), resources)
+	require.Equal(t, errSeekIDNotFound, err)
+	_, err = seeker.SeekByID(ident.StringID("qux"), resources)
+	require.Equal(t, errSeekIDNotFound, err)
+
+	// The seekers of all blocks of the compacted fileset share its seeker.
+	other, err := m.newOpenSeeker(1, blockStarts[2], compacted.VolumeIndex)
+	require.NoError(t, err)
+	require.Len(t, m.compactedSeekers.roots, 1)
+	requireSeekData(other, "foo", []byte{9})
+
+	// The shared seeker is closed once all of its seekers are closed.
+	require.NoError(t, clone.Close())
+	require.NoError(t, seeker.Close())
+	require.Len(t, m.compactedSeekers.roots, 1)
+	require.NoError(t, other.Close())
+	require.Empty(t, m.compactedSeekers.roots)
+}
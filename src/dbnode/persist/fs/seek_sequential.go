@@ -0,0 +1,118 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"os"
+)
+
+var _ io.Reader = &sequentialFileReader{}
+
+// sequentialFileReader implements io.Reader() for reading a data file in
+// ascending offset order, such as when scanning every series of a fileset for
+// an export. Unlike the offsetFileReader it reads through its own file handle
+// which is advised for sequential access and reads ahead of the data requested
+// into a buffer, the shared data file handle of the seeker is left advised for
+// random access so that concurrent random reads are not affected.
+type sequentialFileReader struct {
+	readSize int
+
+	path      string
+	fd        *os.File
+	buf       []byte
+	bufOffset int64
+	offset    int64
+}
+
+func newSequentialFileReader(readSize int) *sequentialFileReader {
+	return &sequentialFileReader{readSize: readSize}
+}
+
+func (r *sequentialFileReader) Read(b []byte) (int, error) {
+	bufEnd := r.bufOffset + int64(len(r.buf))
+	if r.offset < r.bufOffset || r.offset >= bufEnd {
+		if err := r.fill(len(b)); err != nil {
+			return 0, err
+		}
+	}
+	n := copy(b, r.buf[r.offset-r.bufOffset:])
+	r.offset += int64(n)
+	return n, nil
+}
+
+func (r *sequentialFileReader) fill(minSize int) error {
+	size := r.readSize
+	if minSize > size {
+		size = minSize
+	}
+	if cap(r.buf) < size {
+		r.buf = make([]byte, size)
+	}
+	r.buf = r.buf[:size]
+
+	n, err := r.fd.ReadAt(r.buf, r.offset)
+	r.buf = r.buf[:n]
+	r.bufOffset = r.offset
+	if n > 0 {
+		// Reading ahead past the end of the file is expected, only fail if
+		// nothing could be read at the offset.
+		return nil
+	}
+	if err == nil {
+		err = io.EOF
+	}
+	return err
+}
+
+// reset positions the reader at the offset of the data file at the path,
+// opening the data file only if it is not already open.
+func (r *sequentialFileReader) reset(path string, offset int64) error {
+	if r.fd == nil || r.path != path {
+		if err := r.close(); err != nil {
+			return err
+		}
+		fd, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		// Advising is best effort, the reads are correct regardless.
+		_ = fadviseSequential(fd)
+		r.path = path
+		r.fd = fd
+	}
+	r.offset = offset
+	return nil
+}
+
+// close closes the data file if open, the read buffer is retained for reuse.
+func (r *sequentialFileReader) close() error {
+	if r.fd == nil {
+		return nil
+	}
+	err := r.fd.Close()
+	r.path = ""
+	r.fd = nil
+	r.buf = r.buf[:0]
+	r.bufOffset = 0
+	r.offset = 0
+	return err
+}
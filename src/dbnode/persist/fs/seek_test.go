@@ -22,6 +22,7 @@ package fs
 
 import (
 	"errors"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
@@ -91,7 +92,9 @@ func TestSeekDataUnexpectedSize(t *testing.T) {
 	}
 	err = w.Open(writerOpts)
 	assert.NoError(t, err)
-	dataFile := w.(*writer).dataFdWithDigest.Fd().Name()
+	dataFile := dataFilesetPathFromTimeAndIndex(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 0),
+		testWriterStart, 0, dataFileSuffix, false)
 
 	assert.NoError(t, w.Write(
 		ident.StringID("foo"), ident.Tags{},
@@ -279,6 +282,55 @@ func TestSeekIDNotExists(t *testing.T) {
 	assert.NoError(t, s.Close())
 }
 
+func TestSeekSequential(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	if err != nil {
+		t.Fatal(err)
+	}
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		BlockSize: testBlockSize,
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      0,
+			BlockStart: testWriterStart,
+		},
+	}
+	err = w.Open(writerOpts)
+	assert.NoError(t, err)
+	for i := byte(1); i <= 3; i++ {
+		assert.NoError(t, w.Write(
+			ident.StringID(fmt.Sprintf("foo%d", i)), ident.Tags{},
+			bytesRefd([]byte{1, 2, i}),
+			digest.Checksum([]byte{1, 2, i})))
+	}
+	assert.NoError(t, w.Close())
+
+	// Read ahead less than the size of all the data so that the reads
+	// are served both from and past the end of the read ahead buffer.
+	resources := newTestReusableSeekerResources()
+	resources.sequential = true
+	resources.sequentialFileReader = newSequentialFileReader(4)
+	s := newTestSeeker(filePathPrefix)
+	err = s.Open(testNs1ID, 0, testWriterStart, 0, resources)
+	assert.NoError(t, err)
+
+	for _, i := range []byte{1, 2, 3, 1} {
+		data, err := s.SeekByID(ident.StringID(fmt.Sprintf("foo%d", i)), resources)
+		require.NoError(t, err)
+
+		data.IncRef()
+		assert.Equal(t, []byte{1, 2, i}, data.Bytes())
+		data.DecRef()
+	}
+
+	assert.NoError(t, resources.sequentialFileReader.close())
+	assert.NoError(t, s.Close())
+}
+
 func TestReuseSeeker(t *testing.T) {
 	dir, err := ioutil.TempDir("", "testdb")
 	if err != nil {
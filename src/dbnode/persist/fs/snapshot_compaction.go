@@ -0,0 +1,233 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"io"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+var errSnapshotCompactionNoVolumes = errors.New("no snapshot volumes to compact")
+
+type snapshotCompactor struct {
+	reader         DataFileSetReader
+	writer         DataFileSetWriter
+	blockAllocSize int
+	srPool         xio.SegmentReaderPool
+	multiIterPool  encoding.MultiReaderIteratorPool
+	identPool      ident.Pool
+	encoderPool    encoding.EncoderPool
+	nsOpts         namespace.Options
+}
+
+type compactedSnapshotSeries struct {
+	id   ident.ID
+	tags ident.Tags
+	data []checked.Bytes
+}
+
+// NewSnapshotCompactor returns a new SnapshotCompactor. This implementation
+// merges the data of every series across the snapshot volumes of a block and
+// persists the result as a single snapshot volume.
+//
+// Like the merger, the compactor does not clean up the original snapshot
+// volumes, callers must delete them once the compacted volume is persisted.
+func NewSnapshotCompactor(
+	reader DataFileSetReader,
+	writer DataFileSetWriter,
+	blockAllocSize int,
+	srPool xio.SegmentReaderPool,
+	multiIterPool encoding.MultiReaderIteratorPool,
+	identPool ident.Pool,
+	encoderPool encoding.EncoderPool,
+	nsOpts namespace.Options,
+) SnapshotCompactor {
+	return &snapshotCompactor{
+		reader:         reader,
+		writer:         writer,
+		blockAllocSize: blockAllocSize,
+		srPool:         srPool,
+		multiIterPool:  multiIterPool,
+		identPool:      identPool,
+		encoderPool:    encoderPool,
+		nsOpts:         nsOpts,
+	}
+}
+
+// Compact merges the complete snapshot volumes of a single block into a new
+// snapshot volume that carries the snapshot time and ID of the most recent
+// of the volumes, so that it supersedes all of them.
+func (c *snapshotCompactor) Compact(
+	volumes FileSetFilesSlice,
+	nextVolumeIndex int,
+	nsCtx namespace.Context,
+) (err error) {
+	if len(volumes) == 0 {
+		return errSnapshotCompactionNoVolumes
+	}
+
+	latest := volumes[0]
+	for _, volume := range volumes[1:] {
+		if volume.ID.VolumeIndex > latest.ID.VolumeIndex {
+			latest = volume
+		}
+	}
+	snapshotTime, snapshotID, err := latest.SnapshotTimeAndID()
+	if err != nil {
+		return err
+	}
+
+	var (
+		fileID    = latest.ID
+		blockSize = c.nsOpts.RetentionOptions().BlockSize()
+		byID      = make(map[string]*compactedSnapshotSeries)
+		series    []*compactedSnapshotSeries
+	)
+	defer func() {
+		// IDs and tags are held on to by the underlying writer until it is
+		// closed, so only finalize them at the end of the compaction.
+		for _, s := range series {
+			s.id.Finalize()
+			s.tags.Finalize()
+		}
+	}()
+
+	for _, volume := range volumes {
+		if err := c.readVolume(volume.ID, byID, &series); err != nil {
+			return err
+		}
+	}
+
+	if err := c.writer.Open(DataWriterOpenOptions{
+		FileSetType: persist.FileSetSnapshotType,
+		Identifier: FileSetFileIdentifier{
+			Namespace:   fileID.Namespace,
+			Shard:       fileID.Shard,
+			BlockStart:  fileID.BlockStart,
+			VolumeIndex: nextVolumeIndex,
+		},
+		BlockSize: blockSize,
+		Snapshot: DataWriterSnapshotOptions{
+			SnapshotTime: snapshotTime,
+			SnapshotID:   snapshotID,
+		},
+	}); err != nil {
+		return err
+	}
+
+	var (
+		segmentHolder = make([]checked.Bytes, 2)
+		persistFn     = func(id ident.ID, tags ident.Tags, segment ts.Segment, checksum uint32) error {
+			segmentHolder[0] = segment.Head
+			segmentHolder[1] = segment.Tail
+			return c.writer.WriteAll(id, tags, segmentHolder, checksum)
+		}
+		multiIter = c.multiIterPool.Get()
+		ir        = newIterResources(multiIter, fileID.BlockStart, blockSize,
+			c.blockAllocSize, nsCtx.Schema, c.encoderPool)
+		segReaders []xio.SegmentReader
+	)
+	defer multiIter.Close()
+
+	for _, s := range series {
+		for _, data := range s.data {
+			segReader := c.srPool.Get()
+			segReaders = append(segReaders, segmentReaderFromData(data, segReader))
+		}
+		err := persistSegmentReaders(s.id, s.tags, segReaders, ir, persistFn)
+		for i, segReader := range segReaders {
+			segReader.Finalize()
+			segReaders[i] = nil
+		}
+		segReaders = segReaders[:0]
+		if err != nil {
+			// Close the writer without surfacing its error, the partially
+			// written volume has no checkpoint file and is ignored.
+			c.writer.Close()
+			return err
+		}
+	}
+
+	// Close the writer, which writes the rest of the files in the fileset.
+	return c.writer.Close()
+}
+
+func (c *snapshotCompactor) readVolume(
+	fileID FileSetFileIdentifier,
+	byID map[string]*compactedSnapshotSeries,
+	series *[]*compactedSnapshotSeries,
+) (err error) {
+	reader := c.reader
+	if err := reader.Open(DataReaderOpenOptions{
+		Identifier:  fileID,
+		FileSetType: persist.FileSetSnapshotType,
+	}); err != nil {
+		return err
+	}
+	defer func() {
+		// Only set the error here if not set by the end of the function, since
+		// all other errors take precedence.
+		if err == nil {
+			err = reader.Close()
+		}
+	}()
+
+	for id, tagsIter, data, _, err := reader.Read(); err != io.EOF; id, tagsIter, data, _, err = reader.Read() {
+		if err != nil {
+			return err
+		}
+
+		if existing, ok := byID[id.String()]; ok {
+			// Series already read from an earlier volume, its data is merged
+			// with that of every other volume when written.
+			existing.data = append(existing.data, data)
+			id.Finalize()
+			tagsIter.Close()
+			continue
+		}
+
+		tags, err := convert.TagsFromTagsIter(id, tagsIter, c.identPool)
+		tagsIter.Close()
+		if err != nil {
+			id.Finalize()
+			return err
+		}
+
+		s := &compactedSnapshotSeries{
+			id:   id,
+			tags: tags,
+			data: []checked.Bytes{data},
+		}
+		byID[id.String()] = s
+		*series = append(*series, s)
+	}
+
+	return nil
+}
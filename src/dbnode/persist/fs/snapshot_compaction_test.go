@@ -0,0 +1,156 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/pborman/uuid"
+	"github.com/stretchr/testify/require"
+)
+
+func testSnapshotCompactorNsOpts() namespace.Options {
+	nsOpts := namespace.NewOptions()
+	return nsOpts.SetRetentionOptions(nsOpts.RetentionOptions().SetBlockSize(blockSize))
+}
+
+func writeTestSnapshotVolume(
+	t *testing.T,
+	filePathPrefix string,
+	volumeIndex int,
+	snapshotTime time.Time,
+	snapshotID uuid.UUID,
+	data map[string][]ts.Datapoint,
+) {
+	w := newTestWriter(t, filePathPrefix)
+	require.NoError(t, w.Open(DataWriterOpenOptions{
+		FileSetType: persist.FileSetSnapshotType,
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       0,
+			BlockStart:  startTime,
+			VolumeIndex: volumeIndex,
+		},
+		BlockSize: blockSize,
+		Snapshot: DataWriterSnapshotOptions{
+			SnapshotTime: snapshotTime,
+			SnapshotID:   snapshotID,
+		},
+	}))
+	for id, dps := range data {
+		bytes := datapointsToCheckedBytes(t, dps)
+		bytes.IncRef()
+		checksum := digest.Checksum(bytes.Bytes())
+		require.NoError(t, w.Write(ident.StringID(id), ident.Tags{}, bytes, checksum))
+		bytes.DecRef()
+	}
+	require.NoError(t, w.Close())
+}
+
+func TestSnapshotCompactorMergesVolumes(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	var (
+		firstID     = uuid.NewUUID()
+		latestID    = uuid.NewUUID()
+		latestTime  = startTime.Add(time.Minute)
+		firstVolume = map[string][]ts.Datapoint{
+			"id0": {{Timestamp: startTime.Add(1 * time.Second), Value: 1}},
+			"id1": {{Timestamp: startTime.Add(2 * time.Second), Value: 2}},
+		}
+		latestVolume = map[string][]ts.Datapoint{
+			"id0": {
+				{Timestamp: startTime.Add(1 * time.Second), Value: 1},
+				{Timestamp: startTime.Add(3 * time.Second), Value: 3},
+			},
+			"id2": {{Timestamp: startTime.Add(4 * time.Second), Value: 4}},
+		}
+	)
+	writeTestSnapshotVolume(t, filePathPrefix, 0, startTime, firstID, firstVolume)
+	writeTestSnapshotVolume(t, filePathPrefix, 1, latestTime, latestID, latestVolume)
+
+	volumes, err := SnapshotFiles(filePathPrefix, testNs1ID, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, len(volumes))
+
+	compactor := NewSnapshotCompactor(newTestReader(t, filePathPrefix),
+		newTestWriter(t, filePathPrefix), 0, srPool, multiIterPool,
+		identPool, encoderPool, testSnapshotCompactorNsOpts())
+	require.NoError(t, compactor.Compact(volumes, 2, namespace.Context{}))
+
+	volumes, err = SnapshotFiles(filePathPrefix, testNs1ID, 0)
+	require.NoError(t, err)
+	compacted, ok := volumes.LatestVolumeForBlock(startTime)
+	require.True(t, ok)
+	require.Equal(t, 2, compacted.ID.VolumeIndex)
+
+	// The compacted volume supersedes the volumes it was compacted from.
+	snapshotTime, snapshotID, err := compacted.SnapshotTimeAndID()
+	require.NoError(t, err)
+	require.True(t, latestTime.Equal(snapshotTime))
+	require.Equal(t, latestID, snapshotID)
+
+	reader := newTestReader(t, filePathPrefix)
+	require.NoError(t, reader.Open(DataReaderOpenOptions{
+		Identifier:  compacted.ID,
+		FileSetType: persist.FileSetSnapshotType,
+	}))
+	defer reader.Close()
+
+	expected := map[string][]ts.Datapoint{
+		"id0": latestVolume["id0"],
+		"id1": firstVolume["id1"],
+		"id2": latestVolume["id2"],
+	}
+	actual := make(map[string][]ts.Datapoint)
+	for id, tags, data, _, err := reader.Read(); err != io.EOF; id, tags, data, _, err = reader.Read() {
+		require.NoError(t, err)
+		tags.Close()
+		actual[id.String()] = datapointsFromSegment(t, ts.NewSegment(data, nil, 0))
+	}
+	require.Equal(t, len(expected), len(actual))
+	for id, dps := range expected {
+		require.Equal(t, len(dps), len(actual[id]), id)
+		for i := range dps {
+			require.True(t, dps[i].Timestamp.Equal(actual[id][i].Timestamp))
+			require.Equal(t, dps[i].Value, actual[id][i].Value)
+		}
+	}
+}
+
+func TestSnapshotCompactorNoVolumes(t *testing.T) {
+	compactor := NewSnapshotCompactor(nil, nil, 0, srPool, multiIterPool,
+		identPool, encoderPool, testSnapshotCompactorNsOpts())
+	require.Equal(t, errSnapshotCompactionNoVolumes,
+		compactor.Compact(nil, 0, namespace.Context{}))
+}
@@ -0,0 +1,295 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs/msgpack"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const shardSplitMarkerFilePrefix = "split-from"
+
+var (
+	errSplitFileSetNamespaceMismatch = errors.New("split fileset source and target namespaces differ")
+	errSplitFileSetBlockMismatch     = errors.New("split fileset source and target block starts differ")
+)
+
+// SplitFileSetOptions is a set of options used when splitting a data fileset
+// of a parent shard into a data fileset of one of its child shards.
+type SplitFileSetOptions struct {
+	// Source is the identifier of the parent shard fileset volume read.
+	Source FileSetFileIdentifier
+	// Target is the identifier of the child shard fileset volume written.
+	Target FileSetFileIdentifier
+	// ShardFn is the hash function of the shard space split into, only the
+	// series it assigns to the target shard are written.
+	ShardFn sharding.HashFn
+}
+
+// SplitFileSet reads a flushed data fileset volume in full and writes the
+// series that belong to the target shard to the target fileset volume,
+// returning the number of series written. The series data is copied as is
+// without being decoded. A target volume is written even if no series of
+// the source belong to the target shard, marking the block as split.
+//
+// Like the merger, splitting does not signal to the database of the
+// existence of the newly persisted data, nor does it clean up the source
+// fileset.
+func SplitFileSet(
+	reader DataFileSetReader,
+	writer DataFileSetWriter,
+	identPool ident.Pool,
+	opts SplitFileSetOptions,
+) (int, error) {
+	if !opts.Source.Namespace.Equal(opts.Target.Namespace) {
+		return 0, errSplitFileSetNamespaceMismatch
+	}
+	if !opts.Source.BlockStart.Equal(opts.Target.BlockStart) {
+		return 0, errSplitFileSetBlockMismatch
+	}
+
+	err := reader.Open(DataReaderOpenOptions{
+		Identifier:  opts.Source,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	err = writer.Open(DataWriterOpenOptions{
+		FileSetType: persist.FileSetFlushType,
+		Identifier:  opts.Target,
+		BlockSize:   reader.Status().BlockSize,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		// IDs and tags read from disk are held on to by the writer until it
+		// is closed, so only finalize them once the target is written.
+		idsToFinalize  []ident.ID
+		tagsToFinalize []ident.Tags
+	)
+	defer func() {
+		for _, id := range idsToFinalize {
+			id.Finalize()
+		}
+		for _, tags := range tagsToFinalize {
+			tags.Finalize()
+		}
+	}()
+
+	written := 0
+	for {
+		id, tagsIter, data, checksum, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			writer.Close()
+			return 0, err
+		}
+
+		if opts.ShardFn(id) != opts.Target.Shard {
+			id.Finalize()
+			tagsIter.Close()
+			data.Finalize()
+			continue
+		}
+		idsToFinalize = append(idsToFinalize, id)
+
+		tags, err := convert.TagsFromTagsIter(id, tagsIter, identPool)
+		tagsIter.Close()
+		if err != nil {
+			data.Finalize()
+			writer.Close()
+			return 0, err
+		}
+		tagsToFinalize = append(tagsToFinalize, tags)
+
+		data.IncRef()
+		err = writer.Write(id, tags, data, checksum)
+		data.DecRef()
+		data.Finalize()
+		if err != nil {
+			writer.Close()
+			return 0, err
+		}
+		written++
+	}
+
+	if err := reader.Validate(); err != nil {
+		writer.Close()
+		return 0, err
+	}
+	if err := writer.Close(); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// ShardSplitMarkerExists returns whether the data filesets of a shard have
+// been split from the filesets of its parent shard.
+func ShardSplitMarkerExists(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	split sharding.ShardSplit,
+) (bool, error) {
+	return FileExists(shardSplitMarkerFilePath(filePathPrefix, namespace, shard, split))
+}
+
+// WriteShardSplitMarker marks the data filesets of a shard as split from the
+// filesets of its parent shard.
+func WriteShardSplitMarker(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	split sharding.ShardSplit,
+	newDirectoryMode os.FileMode,
+	newFileMode os.FileMode,
+) error {
+	shardDir := ShardDataDirPath(filePathPrefix, namespace, shard)
+	if err := os.MkdirAll(shardDir, newDirectoryMode); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(shardSplitMarkerFilePath(filePathPrefix, namespace, shard, split),
+		nil, newFileMode)
+}
+
+// ShardSplitParent returns the parent shard whose data filesets hold the
+// data of a shard of the namespace that has not yet been split from them,
+// returning false if the shard space of the namespace has not been split,
+// the shard shares its ID with its parent or the shard is already split.
+func ShardSplitParent(
+	filePathPrefix string,
+	md namespace.Metadata,
+	shard uint32,
+) (uint32, bool, error) {
+	reshardOpts := md.Options().ReshardOptions()
+	if !reshardOpts.Enabled {
+		return 0, false, nil
+	}
+	split, err := reshardOpts.ShardSplit()
+	if err != nil {
+		return 0, false, err
+	}
+	parent := split.Parent(shard)
+	if parent == shard {
+		return 0, false, nil
+	}
+	isSplit, err := ShardSplitMarkerExists(filePathPrefix, md.ID(), shard, split)
+	if err != nil || isSplit {
+		return 0, false, err
+	}
+	return parent, true, nil
+}
+
+// SplitSourceDataFileSet returns the latest volume of the data fileset of
+// the parent shard that holds the data of a block of a shard that has not
+// yet been split from its parent, returning false if the shard has a data
+// fileset of its own for the block or does not read from a parent shard.
+func SplitSourceDataFileSet(
+	filePathPrefix string,
+	md namespace.Metadata,
+	shard uint32,
+	blockStart time.Time,
+) (FileSetFile, bool, error) {
+	parent, ok, err := ShardSplitParent(filePathPrefix, md, shard)
+	if err != nil || !ok {
+		return FileSetFile{}, false, err
+	}
+	files, err := DataFiles(filePathPrefix, md.ID(), shard)
+	if err != nil {
+		return FileSetFile{}, false, err
+	}
+	if _, ok := files.LatestVolumeForBlock(blockStart); ok {
+		return FileSetFile{}, false, nil
+	}
+	parentFiles, err := DataFiles(filePathPrefix, md.ID(), parent)
+	if err != nil {
+		return FileSetFile{}, false, err
+	}
+	source, ok := parentFiles.LatestVolumeForBlock(blockStart)
+	return source, ok, nil
+}
+
+// ReadSplitSourceInfoFiles reads the info files of the data filesets of the
+// parent shard of a shard that has not yet been split from its parent for
+// the blocks the shard has no data fileset of its own for, returning them
+// along with the parent shard. No info files are returned if the shard does
+// not read from a parent shard.
+func ReadSplitSourceInfoFiles(
+	filePathPrefix string,
+	md namespace.Metadata,
+	shard uint32,
+	readerBufferSize int,
+	decodingOpts msgpack.DecodingOptions,
+) (uint32, []ReadInfoFileResult, error) {
+	parent, ok, err := ShardSplitParent(filePathPrefix, md, shard)
+	if err != nil || !ok {
+		return 0, nil, err
+	}
+	files, err := DataFiles(filePathPrefix, md.ID(), shard)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	results := ReadInfoFiles(filePathPrefix, md.ID(), parent,
+		readerBufferSize, decodingOpts)
+	sourceResults := results[:0]
+	for _, result := range results {
+		if result.Err.Error() == nil {
+			blockStart := xtime.FromNanoseconds(result.Info.BlockStart)
+			if _, ok := files.LatestVolumeForBlock(blockStart); ok {
+				continue
+			}
+		}
+		sourceResults = append(sourceResults, result)
+	}
+	return parent, sourceResults, nil
+}
+
+func shardSplitMarkerFilePath(
+	filePathPrefix string,
+	namespace ident.ID,
+	shard uint32,
+	split sharding.ShardSplit,
+) string {
+	name := fmt.Sprintf("%s-%d-to-%d", shardSplitMarkerFilePrefix,
+		split.FromNumShards(), split.ToNumShards())
+	return path.Join(ShardDataDirPath(filePathPrefix, namespace, shard), name)
+}
@@ -0,0 +1,273 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitFileSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", map[string]string{"city": "nyc"}, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+		{"baz", map[string]string{"city": "sf"}, []byte{7, 8, 9}},
+	}
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 1, testWriterStart, entries, persist.FileSetFlushType)
+
+	childShards := map[string]uint32{"foo": 1, "bar": 5, "baz": 5}
+	shardFn := func(id ident.ID) uint32 {
+		return childShards[id.String()]
+	}
+
+	var (
+		reader    = newTestReader(t, filePathPrefix)
+		writer    = newTestWriter(t, filePathPrefix)
+		identPool = ident.NewPool(nil, ident.PoolOptions{})
+		source    = FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      1,
+			BlockStart: testWriterStart,
+		}
+	)
+	written, err := SplitFileSet(reader, writer, identPool, SplitFileSetOptions{
+		Source: source,
+		Target: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      5,
+			BlockStart: testWriterStart,
+		},
+		ShardFn: shardFn,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, written)
+
+	exists, err := DataFileSetExists(filePathPrefix, testNs1ID, 5, testWriterStart, 0)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	err = reader.Open(DataReaderOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:  testNs1ID,
+			Shard:      5,
+			BlockStart: testWriterStart,
+		},
+		FileSetType: persist.FileSetFlushType,
+	})
+	require.NoError(t, err)
+	require.Equal(t, testBlockSize, reader.Status().BlockSize)
+
+	read := make(map[string][]byte)
+	for {
+		id, tags, data, _, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		data.IncRef()
+		read[id.String()] = append([]byte(nil), data.Bytes()...)
+		data.DecRef()
+		if id.String() == "baz" {
+			require.True(t, tags.Next())
+			assert.Equal(t, "sf", tags.Current().Value.String())
+		}
+		tags.Close()
+	}
+	require.NoError(t, reader.Close())
+	assert.Equal(t, map[string][]byte{
+		"bar": {4, 5, 6},
+		"baz": {7, 8, 9},
+	}, read)
+
+	// Splitting into the shard that shares the ID of the parent writes the
+	// next volume of the parent.
+	written, err = SplitFileSet(reader, writer, identPool, SplitFileSetOptions{
+		Source: source,
+		Target: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       1,
+			BlockStart:  testWriterStart,
+			VolumeIndex: 1,
+		},
+		ShardFn: shardFn,
+	})
+	require.NoError(t, err)
+	require.Equal(t, 1, written)
+
+	_, err = SplitFileSet(reader, writer, identPool, SplitFileSetOptions{
+		Source: source,
+		Target: FileSetFileIdentifier{
+			Namespace:  testNs2ID,
+			Shard:      5,
+			BlockStart: testWriterStart,
+		},
+		ShardFn: shardFn,
+	})
+	require.Equal(t, errSplitFileSetNamespaceMismatch, err)
+}
+
+func TestShardSplitMarker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	split, err := sharding.NewShardSplit(4, 16)
+	require.NoError(t, err)
+
+	exists, err := ShardSplitMarkerExists(dir, testNs1ID, 5, split)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	require.NoError(t, WriteShardSplitMarker(dir, testNs1ID, 5, split,
+		defaultNewDirectoryMode, defaultNewFileMode))
+
+	exists, err = ShardSplitMarkerExists(dir, testNs1ID, 5, split)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	// Markers of a different split do not apply.
+	other, err := sharding.NewShardSplit(4, 8)
+	require.NoError(t, err)
+	exists, err = ShardSplitMarkerExists(dir, testNs1ID, 5, other)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	// The marker is not mistaken for a fileset.
+	files, err := DataFiles(dir, testNs1ID, 5)
+	require.NoError(t, err)
+	require.Empty(t, files)
+}
+
+func newTestSplitMetadata(t *testing.T) namespace.Metadata {
+	md, err := namespace.NewMetadata(testNs1ID, namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().SetBlockSize(testBlockSize)).
+		SetReshardOptions(namespace.ReshardOptions{
+			Enabled:       true,
+			FromNumShards: 4,
+			ToNumShards:   8,
+		}))
+	require.NoError(t, err)
+	return md
+}
+
+func TestShardSplitParent(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	md := newTestSplitMetadata(t)
+
+	parent, ok, err := ShardSplitParent(dir, md, 5)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), parent)
+
+	// A shard that shares its ID with its parent reads its own filesets.
+	_, ok, err = ShardSplitParent(dir, md, 1)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	split, err := md.Options().ReshardOptions().ShardSplit()
+	require.NoError(t, err)
+	require.NoError(t, WriteShardSplitMarker(dir, testNs1ID, 5, split,
+		defaultNewDirectoryMode, defaultNewFileMode))
+	_, ok, err = ShardSplitParent(dir, md, 5)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Namespaces whose shard space has not been split have no parents.
+	_, ok, err = ShardSplitParent(dir, testNs1Metadata(t), 6)
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestSplitSourceDataFileSet(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	var (
+		md        = newTestSplitMetadata(t)
+		fsOpts    = testDefaultOpts
+		block     = testWriterStart.Truncate(testBlockSize)
+		nextBlock = block.Add(testBlockSize)
+		entries   = []testEntry{
+			{"foo", nil, []byte{1, 2, 3}},
+			{"bar", nil, []byte{4, 5, 6}},
+		}
+	)
+	w := newTestWriter(t, filePathPrefix)
+	writeTestDataWithVolume(t, w, 1, block, 2, entries, persist.FileSetFlushType)
+	writeTestData(t, w, 1, nextBlock, entries, persist.FileSetFlushType)
+
+	// Blocks the child shard has no fileset of its own for are read from
+	// the latest volume of the parent shard.
+	source, ok, err := SplitSourceDataFileSet(filePathPrefix, md, 5, block)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), source.ID.Shard)
+	require.Equal(t, 2, source.ID.VolumeIndex)
+
+	parent, results, err := ReadSplitSourceInfoFiles(filePathPrefix, md, 5,
+		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	require.NoError(t, err)
+	require.Equal(t, uint32(1), parent)
+	require.Len(t, results, 2)
+
+	// Once the child shard has a fileset of its own for a block the block is
+	// no longer read from the parent shard.
+	writeTestDataWithVolume(t, w, 5, block, 1, entries[1:], persist.FileSetFlushType)
+	_, ok, err = SplitSourceDataFileSet(filePathPrefix, md, 5, block)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, results, err = ReadSplitSourceInfoFiles(filePathPrefix, md, 5,
+		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	require.NoError(t, results[0].Err.Error())
+	require.Equal(t, nextBlock.UnixNano(), results[0].Info.BlockStart)
+
+	// Blocks the parent shard has no fileset for are not read from it.
+	_, ok, err = SplitSourceDataFileSet(filePathPrefix, md, 5,
+		nextBlock.Add(testBlockSize))
+	require.NoError(t, err)
+	require.False(t, ok)
+}
@@ -37,6 +37,7 @@ import (
 	idxpersist "github.com/m3db/m3/src/m3ninx/persist"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/mmap"
@@ -116,6 +117,10 @@ type DataFileSetReaderStatus struct {
 type DataReaderOpenOptions struct {
 	Identifier  FileSetFileIdentifier
 	FileSetType persist.FileSetType
+	// Block is the block of the namespace to read, if set and the fileset is
+	// a compacted fileset only the series with data for the block are read
+	// along with their data for the block.
+	Block xtime.Range
 }
 
 // DataFileSetReader provides an unsynchronized reader for a TSDB file set
@@ -419,6 +424,14 @@ type Options interface {
 	// WriterBufferSize returns the buffer size for writing TSDB files.
 	WriterBufferSize() int
 
+	// SetWriterConcurrentDataWrites sets whether data files are checksummed and
+	// written out concurrently with series being written to the writer.
+	SetWriterConcurrentDataWrites(value bool) Options
+
+	// WriterConcurrentDataWrites returns whether data files are checksummed and
+	// written out concurrently with series being written to the writer.
+	WriterConcurrentDataWrites() bool
+
 	// SetInfoReaderBufferSize sets the buffer size for reading TSDB info, digest and checkpoint files.
 	SetInfoReaderBufferSize(value int) Options
 
@@ -437,6 +450,14 @@ type Options interface {
 	// SeekReaderBufferSize size returns the buffer size for seeking TSDB files.
 	SeekReaderBufferSize() int
 
+	// SetSeekSequentialReadSize sets the size of the reads made ahead of the
+	// data requested when seeking TSDB files for sequential scans.
+	SetSeekSequentialReadSize(value int) Options
+
+	// SeekSequentialReadSize returns the size of the reads made ahead of the
+	// data requested when seeking TSDB files for sequential scans.
+	SeekSequentialReadSize() int
+
 	// SetMmapEnableHugeTLB sets whether mmap huge pages are enabled when running on linux.
 	SetMmapEnableHugeTLB(value bool) Options
 
@@ -472,6 +493,14 @@ type Options interface {
 
 	// MmapReporter returns the mmap reporter.
 	MmapReporter() mmap.Reporter
+
+	// SetFaultInjector sets the injector of faults into preparing data and
+	// index volumes to be flushed.
+	SetFaultInjector(value fault.Injector) Options
+
+	// FaultInjector returns the injector of faults into preparing data and
+	// index volumes to be flushed.
+	FaultInjector() fault.Injector
 }
 
 // BlockRetrieverOptions represents the options for block retrieval
@@ -508,6 +537,34 @@ type BlockRetrieverOptions interface {
 
 	// BlockLeaseManager returns the block leaser.
 	BlockLeaseManager() block.LeaseManager
+
+	// SetPrefetchBlocks sets the number of adjacent blocks on either side of a
+	// retrieved block that are speculatively retrieved for the same series,
+	// zero disables prefetching.
+	SetPrefetchBlocks(value int) BlockRetrieverOptions
+
+	// PrefetchBlocks returns the number of adjacent blocks on either side of a
+	// retrieved block that are speculatively retrieved for the same series.
+	PrefetchBlocks() int
+
+	// SetPrefetchBudget sets the maximum number of prefetch requests that can
+	// be pending or in flight at any one time.
+	SetPrefetchBudget(value int) BlockRetrieverOptions
+
+	// PrefetchBudget returns the maximum number of prefetch requests that can
+	// be pending or in flight at any one time.
+	PrefetchBudget() int
+
+	// SetFetchHandOffsPerQuery sets the maximum number of batches of blocks
+	// requested by a single query that are handed off to other fetch loops
+	// to be retrieved concurrently at any one time, zero disables handing
+	// off batches.
+	SetFetchHandOffsPerQuery(value int) BlockRetrieverOptions
+
+	// FetchHandOffsPerQuery returns the maximum number of batches of blocks
+	// requested by a single query that are handed off to other fetch loops
+	// to be retrieved concurrently at any one time.
+	FetchHandOffsPerQuery() int
 }
 
 // ForEachRemainingFn is the function that is run on each of the remaining
@@ -558,3 +615,51 @@ type NewMergerFn func(
 	contextPool context.Pool,
 	nsOpts namespace.Options,
 ) Merger
+
+// Rollup is in charge of rolling up filesets to a coarser resolution.
+type Rollup interface {
+	// Rollup rolls up the specified fileset file to the given resolution and
+	// persists it as the next volume of the fileset.
+	Rollup(
+		fileID FileSetFileIdentifier,
+		resolution time.Duration,
+		nextVolumeIndex int,
+		flushPreparer persist.FlushPreparer,
+		nsCtx namespace.Context,
+	) error
+}
+
+// NewRollupFn is the function to call to get a new Rollup.
+type NewRollupFn func(
+	reader DataFileSetReader,
+	blockAllocSize int,
+	srPool xio.SegmentReaderPool,
+	multiIterPool encoding.MultiReaderIteratorPool,
+	identPool ident.Pool,
+	encoderPool encoding.EncoderPool,
+	nsOpts namespace.Options,
+) Rollup
+
+// SnapshotCompactor is in charge of compacting the snapshot volumes of a block.
+type SnapshotCompactor interface {
+	// Compact merges the specified complete snapshot volumes of a single
+	// block and persists the result as a new snapshot volume of the block.
+	Compact(
+		volumes FileSetFilesSlice,
+		nextVolumeIndex int,
+		nsCtx namespace.Context,
+	) error
+}
+
+// NewSnapshotCompactorFn is the function to call to get a new
+// SnapshotCompactor.
+type NewSnapshotCompactorFn func(
+	reader DataFileSetReader,
+	writer DataFileSetWriter,
+	blockAllocSize int,
+	srPool xio.SegmentReaderPool,
+	multiIterPool encoding.MultiReaderIteratorPool,
+	identPool ident.Pool,
+	encoderPool encoding.EncoderPool,
+	nsOpts namespace.Options,
+) SnapshotCompactor
@@ -0,0 +1,361 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	m3ninxpersist "github.com/m3db/m3/src/m3ninx/persist"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/pool"
+)
+
+// VerifyOptions is a set of options used when verifying the filesets on disk.
+type VerifyOptions struct {
+	// FilesystemOptions is the filesystem options, the filesets verified are
+	// those under the file path prefix.
+	FilesystemOptions Options
+
+	// BytesPool is the optional bytes pool used to read series data.
+	BytesPool pool.CheckedBytesPool
+}
+
+// VerifyReport is the outcome of verifying the filesets on disk.
+type VerifyReport struct {
+	FileSets   []VerifyFileSetResult `json:"filesets"`
+	Verified   int                   `json:"verified"`
+	Corrupt    int                   `json:"corrupt"`
+	Incomplete int                   `json:"incomplete"`
+}
+
+// Corrupted returns whether any of the filesets verified are corrupt.
+func (r VerifyReport) Corrupted() bool {
+	return r.Corrupt > 0
+}
+
+// Write writes the report as indented JSON.
+func (r VerifyReport) Write(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+func (r *VerifyReport) add(result VerifyFileSetResult) {
+	switch {
+	case result.Incomplete:
+		r.Incomplete++
+	case result.Error != "":
+		r.Corrupt++
+	default:
+		r.Verified++
+	}
+	r.FileSets = append(r.FileSets, result)
+}
+
+// VerifyFileSetResult is the outcome of verifying a single fileset volume.
+type VerifyFileSetResult struct {
+	Namespace   string    `json:"namespace"`
+	ContentType string    `json:"contentType"`
+	Shard       uint32    `json:"shard"`
+	BlockStart  time.Time `json:"blockStart"`
+	VolumeIndex int       `json:"volumeIndex"`
+	// Entries is the number of series for data filesets and the number of
+	// segments for index filesets.
+	Entries int `json:"entries"`
+	// Incomplete is set for volumes without a checkpoint file, these are
+	// volumes that were being written when the node stopped and are ignored
+	// by the node so are not considered corrupt.
+	Incomplete bool   `json:"incomplete,omitempty"`
+	Error      string `json:"error,omitempty"`
+}
+
+// VerifyFileSets verifies every flushed data and index fileset volume on disk
+// by reading them in full, validating the digests of all their files, the
+// checksums of all series and that index segments can be read. It returns an
+// error only if the filesets cannot be listed, corrupt filesets are reported
+// in the report.
+func VerifyFileSets(opts VerifyOptions) (VerifyReport, error) {
+	fsOpts := opts.FilesystemOptions
+	if fsOpts == nil {
+		return VerifyReport{}, errFilesystemOptionsNotSpecified
+	}
+	if err := fsOpts.Validate(); err != nil {
+		return VerifyReport{}, err
+	}
+
+	filePathPrefix := fsOpts.FilePathPrefix()
+	reader, err := NewReader(opts.BytesPool, fsOpts)
+	if err != nil {
+		return VerifyReport{}, err
+	}
+
+	var report VerifyReport
+	dataNamespaces, err := subDirectoryNames(DataDirPath(filePathPrefix))
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	for _, nsName := range dataNamespaces {
+		namespace := ident.StringID(nsName)
+		shards, err := subDirectoryNames(NamespaceDataDirPath(filePathPrefix, namespace))
+		if err != nil {
+			return VerifyReport{}, err
+		}
+		for _, shardName := range shards {
+			shard, err := strconv.ParseUint(shardName, 10, 32)
+			if err != nil {
+				// Not a shard directory.
+				continue
+			}
+			filesets, err := DataFiles(filePathPrefix, namespace, uint32(shard))
+			if err != nil {
+				return VerifyReport{}, err
+			}
+			filesets.sortByTimeAndVolumeIndexAscending()
+			for _, fileset := range filesets {
+				report.add(verifyDataFileSet(reader, fileset))
+			}
+		}
+	}
+
+	indexNamespaces, err := subDirectoryNames(path.Join(filePathPrefix, indexDirName, dataDirName))
+	if err != nil {
+		return VerifyReport{}, err
+	}
+	for _, nsName := range indexNamespaces {
+		namespace := ident.StringID(nsName)
+		filesets, err := filesetFiles(filesetFilesSelector{
+			fileSetType:    persist.FileSetFlushType,
+			contentType:    persist.FileSetIndexContentType,
+			filePathPrefix: filePathPrefix,
+			namespace:      namespace,
+			pattern:        filesetFilePattern,
+		})
+		if err != nil {
+			return VerifyReport{}, err
+		}
+		filesets.sortByTimeAndVolumeIndexAscending()
+		for _, fileset := range filesets {
+			report.add(verifyIndexFileSet(fsOpts, fileset))
+		}
+	}
+
+	return report, nil
+}
+
+func verifyDataFileSet(
+	reader DataFileSetReader,
+	fileset FileSetFile,
+) VerifyFileSetResult {
+	result := VerifyFileSetResult{
+		Namespace:   fileset.ID.Namespace.String(),
+		ContentType: persist.FileSetDataContentType.String(),
+		Shard:       fileset.ID.Shard,
+		BlockStart:  fileset.ID.BlockStart,
+		VolumeIndex: fileset.ID.VolumeIndex,
+	}
+	if !fileset.HasCompleteCheckpointFile() {
+		result.Incomplete = true
+		return result
+	}
+
+	entries, err := VerifyDataFileSetContents(reader, fileset.ID)
+	result.Entries = entries
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// VerifyDataFileSetContents reads every series of the data fileset with the
+// given identifier and verifies its checksums, returning the number of
+// entries read.
+func VerifyDataFileSetContents(
+	reader DataFileSetReader,
+	id FileSetFileIdentifier,
+) (int, error) {
+	err := reader.Open(DataReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	bloomFilter, err := reader.ReadBloomFilter()
+	if err != nil {
+		return 0, err
+	}
+	if err := bloomFilter.Close(); err != nil {
+		return 0, err
+	}
+
+	entries := 0
+	for {
+		seriesID, tags, data, checksum, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return entries, err
+		}
+
+		data.IncRef()
+		actual := digest.Checksum(data.Bytes())
+		data.DecRef()
+		data.Finalize()
+		tags.Close()
+		if actual != checksum {
+			err = fmt.Errorf("series %s checksum mismatch: expected=%d, actual=%d",
+				seriesID.String(), checksum, actual)
+		}
+		seriesID.Finalize()
+		if err != nil {
+			return entries, err
+		}
+		entries++
+	}
+
+	return entries, reader.Validate()
+}
+
+func verifyIndexFileSet(
+	fsOpts Options,
+	fileset FileSetFile,
+) VerifyFileSetResult {
+	result := VerifyFileSetResult{
+		Namespace:   fileset.ID.Namespace.String(),
+		ContentType: persist.FileSetIndexContentType.String(),
+		BlockStart:  fileset.ID.BlockStart,
+		VolumeIndex: fileset.ID.VolumeIndex,
+	}
+	if !fileset.HasCompleteCheckpointFile() {
+		result.Incomplete = true
+		return result
+	}
+
+	segments, err := verifyIndexFileSetContents(fsOpts, fileset.ID)
+	result.Entries = segments
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func verifyIndexFileSetContents(
+	fsOpts Options,
+	id FileSetFileIdentifier,
+) (int, error) {
+	reader, err := NewIndexReader(fsOpts)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+
+	id.FileSetContentType = persist.FileSetIndexContentType
+	_, err = reader.Open(IndexReaderOpenOptions{
+		Identifier:  id,
+		FileSetType: persist.FileSetFlushType,
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	segments := 0
+	for {
+		fileset, err := reader.ReadSegmentFileSet()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return segments, err
+		}
+
+		seg, err := m3ninxpersist.NewSegment(fileset, fsOpts.FSTOptions())
+		if err != nil {
+			return segments, err
+		}
+		err = verifyIndexSegment(seg)
+		if closeErr := seg.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return segments, fmt.Errorf("segment %d invalid: %v", segments, err)
+		}
+		segments++
+	}
+
+	return segments, reader.Validate()
+}
+
+// verifyIndexSegment walks every field and term of the segment.
+func verifyIndexSegment(seg segment.Segment) error {
+	fields, err := seg.FieldsIterable().Fields()
+	if err != nil {
+		return err
+	}
+	defer fields.Close()
+
+	for fields.Next() {
+		terms, err := seg.TermsIterable().Terms(fields.Current())
+		if err != nil {
+			return err
+		}
+		for terms.Next() {
+		}
+		err = terms.Err()
+		if closeErr := terms.Close(); err == nil {
+			err = closeErr
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return fields.Err()
+}
+
+func subDirectoryNames(dirPath string) ([]string, error) {
+	entries, err := ioutil.ReadDir(dirPath)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/persist"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyFileSets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	entries := []testEntry{
+		{"foo", nil, []byte{1, 2, 3}},
+		{"bar", nil, []byte{4, 5, 6}},
+	}
+	w := newTestWriter(t, filePathPrefix)
+	writeTestData(t, w, 0, testWriterStart, entries, persist.FileSetFlushType)
+	writeTestData(t, w, 1, testWriterStart, entries, persist.FileSetFlushType)
+
+	// Remove the checkpoint file of a volume to simulate a volume that was
+	// being written when the node stopped.
+	writeTestData(t, w, 2, testWriterStart, entries, persist.FileSetFlushType)
+	require.NoError(t, os.Remove(dataFilesetPathFromTimeAndIndex(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 2), testWriterStart, 0,
+		checkpointFileSuffix, false)))
+
+	opts := VerifyOptions{
+		FilesystemOptions: testDefaultOpts.SetFilePathPrefix(filePathPrefix),
+	}
+	report, err := VerifyFileSets(opts)
+	require.NoError(t, err)
+	assert.False(t, report.Corrupted())
+	assert.Equal(t, 2, report.Verified)
+	assert.Equal(t, 1, report.Incomplete)
+	require.Equal(t, 3, len(report.FileSets))
+	for _, result := range report.FileSets[:2] {
+		assert.Equal(t, testNs1ID.String(), result.Namespace)
+		assert.Equal(t, persist.FileSetDataContentType.String(), result.ContentType)
+		assert.Equal(t, len(entries), result.Entries)
+		assert.Empty(t, result.Error)
+	}
+	assert.True(t, report.FileSets[2].Incomplete)
+
+	// Corrupt the data of a volume.
+	dataFilePath := dataFilesetPathFromTimeAndIndex(
+		ShardDataDirPath(filePathPrefix, testNs1ID, 1), testWriterStart, 0,
+		dataFileSuffix, false)
+	data, err := ioutil.ReadFile(dataFilePath)
+	require.NoError(t, err)
+	data[0]++
+	require.NoError(t, ioutil.WriteFile(dataFilePath, data, 0666))
+
+	report, err = VerifyFileSets(opts)
+	require.NoError(t, err)
+	assert.True(t, report.Corrupted())
+	assert.Equal(t, 1, report.Verified)
+	assert.Equal(t, 1, report.Corrupt)
+	assert.Equal(t, uint32(1), report.FileSets[1].Shard)
+	assert.NotEmpty(t, report.FileSets[1].Error)
+
+	var buf bytes.Buffer
+	require.NoError(t, report.Write(&buf))
+	var decoded VerifyReport
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &decoded))
+	assert.Equal(t, report.Corrupt, decoded.Corrupt)
+}
+
+func TestVerifyFileSetsEmpty(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	report, err := VerifyFileSets(VerifyOptions{
+		FilesystemOptions: testDefaultOpts.SetFilePathPrefix(dir),
+	})
+	require.NoError(t, err)
+	assert.False(t, report.Corrupted())
+	assert.Empty(t, report.FileSets)
+}
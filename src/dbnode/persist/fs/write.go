@@ -66,8 +66,10 @@ type writer struct {
 	summariesFdWithDigest      digest.FdWithDigestWriter
 	bloomFilterFdWithDigest    digest.FdWithDigestWriter
 	dataFdWithDigest           digest.FdWithDigestWriter
+	dataPipeline               *dataWritePipeline
 	digestFdWithDigestContents digest.FdWithDigestContentsWriter
 	checkpointFilePath         string
+	staging                    *fileSetStaging
 	indexEntries               indexEntries
 
 	start        time.Time
@@ -122,7 +124,7 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 		return nil, err
 	}
 	bufferSize := opts.WriterBufferSize()
-	return &writer{
+	w := &writer{
 		filePathPrefix:                  opts.FilePathPrefix(),
 		newFileMode:                     opts.NewFileMode(),
 		newDirectoryMode:                opts.NewDirectoryMode(),
@@ -138,7 +140,12 @@ func NewWriter(opts Options) (DataFileSetWriter, error) {
 		digestBuf:                       digest.NewBuffer(),
 		singleCheckedBytes:              make([]checked.Bytes, 1),
 		tagEncoderPool:                  opts.TagEncoderPool(),
-	}, nil
+	}
+	if opts.WriterConcurrentDataWrites() {
+		w.dataPipeline = newDataWritePipeline(w.dataFdWithDigest,
+			bufferSize, defaultDataWritePipelineNumBuffers)
+	}
+	return w, nil
 }
 
 // Open initializes the internal state for writing to the given shard,
@@ -196,18 +203,27 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 		return fmt.Errorf("unable to open reader with fileset type: %s", opts.FileSetType)
 	}
 
+	// Files are written to a staging directory and only made visible once
+	// the fileset is completely written when the writer is closed.
+	w.staging, err = newFileSetStaging(shardDir, w.checkpointFilePath,
+		w.newDirectoryMode)
+	if err != nil {
+		return err
+	}
+
 	var infoFd, indexFd, summariesFd, bloomFilterFd, dataFd, digestFd *os.File
 	err = openFiles(w.openWritable,
 		map[string]**os.File{
-			infoFilepath:        &infoFd,
-			indexFilepath:       &indexFd,
-			summariesFilepath:   &summariesFd,
-			bloomFilterFilepath: &bloomFilterFd,
-			dataFilepath:        &dataFd,
-			digestFilepath:      &digestFd,
+			w.staging.path(infoFilepath):        &infoFd,
+			w.staging.path(indexFilepath):       &indexFd,
+			w.staging.path(summariesFilepath):   &summariesFd,
+			w.staging.path(bloomFilterFilepath): &bloomFilterFd,
+			w.staging.path(dataFilepath):        &dataFd,
+			w.staging.path(digestFilepath):      &digestFd,
 		},
 	)
 	if err != nil {
+		w.staging.abort()
 		return err
 	}
 
@@ -218,6 +234,10 @@ func (w *writer) Open(opts DataWriterOpenOptions) error {
 	w.dataFdWithDigest.Reset(dataFd)
 	w.digestFdWithDigestContents.Reset(digestFd)
 
+	if w.dataPipeline != nil {
+		w.dataPipeline.Start()
+	}
+
 	return nil
 }
 
@@ -230,6 +250,11 @@ func (w *writer) reset(opts DataWriterOpenOptions) {
 	w.currIdx = 0
 	w.currOffset = 0
 	w.err = nil
+	if w.dataPipeline != nil {
+		// Ensure writes from a previous set of files that was never closed
+		// have finished before the files are reset.
+		w.dataPipeline.Close()
+	}
 	// This happens after writing the previous set of files index files, however, do it
 	// again to ensure they get cleared even if there was a premature error writing out the
 	// previous set of files which would have prevented them from being cleared.
@@ -241,6 +266,13 @@ func (w *writer) writeData(data []byte) error {
 	if len(data) == 0 {
 		return nil
 	}
+	if w.dataPipeline != nil {
+		if err := w.dataPipeline.Write(data); err != nil {
+			return err
+		}
+		w.currOffset += int64(len(data))
+		return nil
+	}
 	written, err := w.dataFdWithDigest.Write(data)
 	if err != nil {
 		return err
@@ -317,6 +349,18 @@ func (w *writer) writeAll(
 }
 
 func (w *writer) Close() error {
+	if err := w.closeAndCommit(); err != nil {
+		// NB: Errors removing the staged files are ignored as they are
+		// removed again by the next attempt to write the same fileset.
+		if w.staging != nil {
+			w.staging.abort()
+		}
+		return err
+	}
+	return nil
+}
+
+func (w *writer) closeAndCommit() error {
 	err := w.close()
 	if w.err != nil {
 		return w.err
@@ -331,10 +375,22 @@ func (w *writer) Close() error {
 		w.err = err
 		return err
 	}
+	// Switch to the new fileset only once all of its files are written.
+	if err := w.staging.commit(w.checkpointFilePath); err != nil {
+		w.err = err
+		return err
+	}
 	return nil
 }
 
 func (w *writer) close() error {
+	if w.dataPipeline != nil {
+		// Wait for all data to be written before using the data file digest.
+		if err := w.dataPipeline.Close(); err != nil {
+			return err
+		}
+	}
+
 	if err := w.writeIndexRelatedFiles(); err != nil {
 		return err
 	}
@@ -360,7 +416,7 @@ func (w *writer) close() error {
 }
 
 func (w *writer) writeCheckpointFile() error {
-	fd, err := w.openWritable(w.checkpointFilePath)
+	fd, err := w.openWritable(w.staging.stagingPath(w.checkpointFilePath))
 	if err != nil {
 		return err
 	}
@@ -549,6 +605,7 @@ func (w *writer) writeInfoFileContents(
 		BlockSize:    int64(w.blockSize),
 		Entries:      w.currIdx,
 		MajorVersion: schema.MajorVersion,
+		MinorVersion: schema.MinorVersion,
 		Summaries: schema.IndexSummariesInfo{
 			Summaries: int64(summaries),
 		},
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"errors"
+	"io"
+	"sync"
+)
+
+const defaultDataWritePipelineNumBuffers = 4
+
+var errDataWritePipelineNotStarted = errors.New("data write pipeline not started")
+
+// dataWritePipeline writes data to the data file from a background goroutine
+// so that checksumming and writing out the data file happens concurrently
+// with the caller merging, encoding and checksumming the next series.
+//
+// Data is copied into a fixed set of buffers before being handed off since
+// callers are free to finalize the data they write once the write returns,
+// when all buffers are in flight writes block until one is written out.
+type dataWritePipeline struct {
+	writer  io.Writer
+	free    chan []byte
+	pending chan []byte
+	done    chan struct{}
+	curr    []byte
+	running bool
+
+	errLock sync.Mutex
+	err     error
+}
+
+func newDataWritePipeline(
+	writer io.Writer,
+	bufferSize int,
+	numBuffers int,
+) *dataWritePipeline {
+	free := make(chan []byte, numBuffers)
+	for i := 0; i < numBuffers; i++ {
+		free <- make([]byte, 0, bufferSize)
+	}
+	return &dataWritePipeline{
+		writer: writer,
+		free:   free,
+	}
+}
+
+// Start starts the background writes, each call to Start must be paired with
+// a call to Close.
+func (p *dataWritePipeline) Start() {
+	p.setErr(nil)
+	p.pending = make(chan []byte, cap(p.free))
+	p.done = make(chan struct{})
+	p.running = true
+	go p.writeLoop(p.pending, p.done)
+}
+
+func (p *dataWritePipeline) writeLoop(pending <-chan []byte, done chan<- struct{}) {
+	for buf := range pending {
+		if p.Err() == nil {
+			if _, err := p.writer.Write(buf); err != nil {
+				p.setErr(err)
+			}
+		}
+		p.free <- buf[:0]
+	}
+	close(done)
+}
+
+// Write enqueues data to be written, returning any error encountered by
+// previously enqueued writes.
+func (p *dataWritePipeline) Write(data []byte) error {
+	if !p.running {
+		return errDataWritePipelineNotStarted
+	}
+	for len(data) > 0 {
+		if p.curr == nil {
+			p.curr = <-p.free
+		}
+		n := copy(p.curr[len(p.curr):cap(p.curr)], data)
+		p.curr = p.curr[:len(p.curr)+n]
+		data = data[n:]
+		if len(p.curr) == cap(p.curr) {
+			p.pending <- p.curr
+			p.curr = nil
+		}
+	}
+	return p.Err()
+}
+
+// Close flushes any buffered data and waits for all enqueued writes to be
+// written out, returning the first error encountered if any.
+func (p *dataWritePipeline) Close() error {
+	if !p.running {
+		return nil
+	}
+	if p.curr != nil {
+		if len(p.curr) > 0 {
+			p.pending <- p.curr
+		} else {
+			p.free <- p.curr
+		}
+		p.curr = nil
+	}
+	close(p.pending)
+	<-p.done
+	p.running = false
+	return p.Err()
+}
+
+func (p *dataWritePipeline) Err() error {
+	p.errLock.Lock()
+	err := p.err
+	p.errLock.Unlock()
+	return err
+}
+
+func (p *dataWritePipeline) setErr(err error) {
+	p.errLock.Lock()
+	if p.err == nil || err == nil {
+		p.err = err
+	}
+	p.errLock.Unlock()
+}
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fs
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type errWriter struct {
+	err error
+}
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestDataWritePipelineWritesInOrder(t *testing.T) {
+	var (
+		buf      bytes.Buffer
+		expected []byte
+		p        = newDataWritePipeline(&buf, 7, 2)
+	)
+
+	// Run twice to ensure the pipeline can be reused once closed.
+	for run := 0; run < 2; run++ {
+		buf.Reset()
+		expected = expected[:0]
+
+		p.Start()
+		for i := 0; i < 100; i++ {
+			data := bytes.Repeat([]byte{byte(i)}, i%13)
+			expected = append(expected, data...)
+			require.NoError(t, p.Write(data))
+		}
+		require.NoError(t, p.Close())
+		require.Equal(t, expected, buf.Bytes())
+	}
+}
+
+func TestDataWritePipelineReturnsWriteError(t *testing.T) {
+	writeErr := errors.New("write failed")
+	p := newDataWritePipeline(errWriter{err: writeErr}, 4, 1)
+	p.Start()
+
+	// The error is surfaced either by a subsequent write or by close.
+	var err error
+	for i := 0; i < 10 && err == nil; i++ {
+		err = p.Write([]byte{1, 2, 3, 4})
+	}
+	closeErr := p.Close()
+	require.Equal(t, writeErr, closeErr)
+	if err != nil {
+		require.Equal(t, writeErr, err)
+	}
+
+	// Restarting clears the error.
+	p.writer = &bytes.Buffer{}
+	p.Start()
+	require.NoError(t, p.Write([]byte{1}))
+	require.NoError(t, p.Close())
+}
+
+func TestDataWritePipelineNotStarted(t *testing.T) {
+	p := newDataWritePipeline(&bytes.Buffer{}, 4, 1)
+	require.Equal(t, errDataWritePipelineNotStarted, p.Write([]byte{1}))
+	require.NoError(t, p.Close())
+}
@@ -21,8 +21,10 @@
 package fs
 
 import (
+	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -65,3 +67,78 @@ func TestWriteReuseAfterError(t *testing.T) {
 	require.NoError(t, w.Open(writerOpts))
 	require.NoError(t, w.Close())
 }
+
+func TestWriteFileSetNotVisibleUntilClose(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	blockStart := time.Now().Truncate(time.Hour)
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       0,
+			BlockStart:  blockStart,
+			VolumeIndex: 0,
+		},
+		BlockSize:   time.Hour,
+		FileSetType: persist.FileSetFlushType,
+	}
+
+	shardDir := ShardDataDirPath(filePathPrefix, testNs1ID, 0)
+	require.NoError(t, w.Open(writerOpts))
+	require.NoError(t, w.Write(ident.StringID("series1"), ident.Tags{},
+		checkedBytes([]byte{1, 2, 3}), 0))
+
+	// Only the staging directory is visible while writing.
+	files, err := ioutil.ReadDir(shardDir)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(files))
+	require.True(t, files[0].IsDir())
+	require.True(t, strings.HasPrefix(files[0].Name(), stagingDirPrefix))
+
+	require.NoError(t, w.Close())
+
+	files, err = ioutil.ReadDir(shardDir)
+	require.NoError(t, err)
+	var names []string
+	for _, file := range files {
+		require.False(t, file.IsDir())
+		names = append(names, file.Name())
+	}
+	require.Equal(t, 7, len(names))
+
+	exists, err := DataFileSetExists(filePathPrefix, testNs1ID, 0, blockStart, 0)
+	require.NoError(t, err)
+	require.True(t, exists)
+}
+
+func TestWriteFileSetErrorRemovesStagedFiles(t *testing.T) {
+	dir := createTempDir(t)
+	filePathPrefix := filepath.Join(dir, "")
+	defer os.RemoveAll(dir)
+
+	seriesID := ident.StringID("series1")
+	w := newTestWriter(t, filePathPrefix)
+	writerOpts := DataWriterOpenOptions{
+		Identifier: FileSetFileIdentifier{
+			Namespace:   testNs1ID,
+			Shard:       0,
+			BlockStart:  time.Now().Truncate(time.Hour),
+			VolumeIndex: 0,
+		},
+		BlockSize:   time.Hour,
+		FileSetType: persist.FileSetFlushType,
+	}
+	data := checkedBytes([]byte{1, 2, 3})
+
+	require.NoError(t, w.Open(writerOpts))
+	require.NoError(t, w.Write(seriesID, ident.Tags{}, data, 0))
+	require.NoError(t, w.Write(seriesID, ident.Tags{}, data, 0))
+	require.Error(t, w.Close())
+
+	files, err := ioutil.ReadDir(ShardDataDirPath(filePathPrefix, testNs1ID, 0))
+	require.NoError(t, err)
+	require.Equal(t, 0, len(files))
+}
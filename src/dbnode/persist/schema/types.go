@@ -29,9 +29,16 @@ import (
 // tooling needs to upgrade older files to newer files before a server restart
 const MajorVersion = 1
 
+// MinorVersion is the minor schema version for a set of fileset files, this
+// is incremented when backwards compatible format improvements are introduced,
+// filesets written with an older minor version are still readable and are
+// rewritten in the background to the current minor version.
+const MinorVersion = 1
+
 // IndexInfo stores metadata information about block filesets
 type IndexInfo struct {
 	MajorVersion int64
+	MinorVersion int64
 	BlockStart   int64
 	BlockSize    int64
 	Entries      int64
@@ -184,6 +184,62 @@ func (mr *MockOptionsMockRecorder) WriteNewSeriesLimitPerShardPerSecond() *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteNewSeriesLimitPerShardPerSecond", reflect.TypeOf((*MockOptions)(nil).WriteNewSeriesLimitPerShardPerSecond))
 }
 
+// SetWriteNewSeriesAdmissionLimitPerShardPerSecond mocks base method
+func (m *MockOptions) SetWriteNewSeriesAdmissionLimitPerShardPerSecond(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteNewSeriesAdmissionLimitPerShardPerSecond", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteNewSeriesAdmissionLimitPerShardPerSecond indicates an expected call of SetWriteNewSeriesAdmissionLimitPerShardPerSecond
+func (mr *MockOptionsMockRecorder) SetWriteNewSeriesAdmissionLimitPerShardPerSecond(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteNewSeriesAdmissionLimitPerShardPerSecond", reflect.TypeOf((*MockOptions)(nil).SetWriteNewSeriesAdmissionLimitPerShardPerSecond), value)
+}
+
+// WriteNewSeriesAdmissionLimitPerShardPerSecond mocks base method
+func (m *MockOptions) WriteNewSeriesAdmissionLimitPerShardPerSecond() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteNewSeriesAdmissionLimitPerShardPerSecond")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// WriteNewSeriesAdmissionLimitPerShardPerSecond indicates an expected call of WriteNewSeriesAdmissionLimitPerShardPerSecond
+func (mr *MockOptionsMockRecorder) WriteNewSeriesAdmissionLimitPerShardPerSecond() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteNewSeriesAdmissionLimitPerShardPerSecond", reflect.TypeOf((*MockOptions)(nil).WriteNewSeriesAdmissionLimitPerShardPerSecond))
+}
+
+// SetWriteNewSeriesAdmissionBurstPerShard mocks base method
+func (m *MockOptions) SetWriteNewSeriesAdmissionBurstPerShard(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteNewSeriesAdmissionBurstPerShard", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteNewSeriesAdmissionBurstPerShard indicates an expected call of SetWriteNewSeriesAdmissionBurstPerShard
+func (mr *MockOptionsMockRecorder) SetWriteNewSeriesAdmissionBurstPerShard(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteNewSeriesAdmissionBurstPerShard", reflect.TypeOf((*MockOptions)(nil).SetWriteNewSeriesAdmissionBurstPerShard), value)
+}
+
+// WriteNewSeriesAdmissionBurstPerShard mocks base method
+func (m *MockOptions) WriteNewSeriesAdmissionBurstPerShard() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteNewSeriesAdmissionBurstPerShard")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// WriteNewSeriesAdmissionBurstPerShard indicates an expected call of WriteNewSeriesAdmissionBurstPerShard
+func (mr *MockOptionsMockRecorder) WriteNewSeriesAdmissionBurstPerShard() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteNewSeriesAdmissionBurstPerShard", reflect.TypeOf((*MockOptions)(nil).WriteNewSeriesAdmissionBurstPerShard))
+}
+
 // SetTickSeriesBatchSize mocks base method
 func (m *MockOptions) SetTickSeriesBatchSize(value int) Options {
 	m.ctrl.T.Helper()
@@ -268,6 +324,34 @@ func (mr *MockOptionsMockRecorder) TickMinimumInterval() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TickMinimumInterval", reflect.TypeOf((*MockOptions)(nil).TickMinimumInterval))
 }
 
+// SetTickLoadPacingOptions mocks base method
+func (m *MockOptions) SetTickLoadPacingOptions(value TickLoadPacingOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTickLoadPacingOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetTickLoadPacingOptions indicates an expected call of SetTickLoadPacingOptions
+func (mr *MockOptionsMockRecorder) SetTickLoadPacingOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTickLoadPacingOptions", reflect.TypeOf((*MockOptions)(nil).SetTickLoadPacingOptions), value)
+}
+
+// TickLoadPacingOptions mocks base method
+func (m *MockOptions) TickLoadPacingOptions() TickLoadPacingOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TickLoadPacingOptions")
+	ret0, _ := ret[0].(TickLoadPacingOptions)
+	return ret0
+}
+
+// TickLoadPacingOptions indicates an expected call of TickLoadPacingOptions
+func (mr *MockOptionsMockRecorder) TickLoadPacingOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TickLoadPacingOptions", reflect.TypeOf((*MockOptions)(nil).TickLoadPacingOptions))
+}
+
 // SetMaxWiredBlocks mocks base method
 func (m *MockOptions) SetMaxWiredBlocks(value uint) Options {
 	m.ctrl.T.Helper()
@@ -296,6 +380,62 @@ func (mr *MockOptionsMockRecorder) MaxWiredBlocks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MaxWiredBlocks", reflect.TypeOf((*MockOptions)(nil).MaxWiredBlocks))
 }
 
+// SetWiredListPressureOptions mocks base method
+func (m *MockOptions) SetWiredListPressureOptions(value WiredListPressureOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWiredListPressureOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWiredListPressureOptions indicates an expected call of SetWiredListPressureOptions
+func (mr *MockOptionsMockRecorder) SetWiredListPressureOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWiredListPressureOptions", reflect.TypeOf((*MockOptions)(nil).SetWiredListPressureOptions), value)
+}
+
+// WiredListPressureOptions mocks base method
+func (m *MockOptions) WiredListPressureOptions() WiredListPressureOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WiredListPressureOptions")
+	ret0, _ := ret[0].(WiredListPressureOptions)
+	return ret0
+}
+
+// WiredListPressureOptions indicates an expected call of WiredListPressureOptions
+func (mr *MockOptionsMockRecorder) WiredListPressureOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WiredListPressureOptions", reflect.TypeOf((*MockOptions)(nil).WiredListPressureOptions))
+}
+
+// SetShardErrorBudgetOptions mocks base method
+func (m *MockOptions) SetShardErrorBudgetOptions(value ShardErrorBudgetOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetShardErrorBudgetOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetShardErrorBudgetOptions indicates an expected call of SetShardErrorBudgetOptions
+func (mr *MockOptionsMockRecorder) SetShardErrorBudgetOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetShardErrorBudgetOptions", reflect.TypeOf((*MockOptions)(nil).SetShardErrorBudgetOptions), value)
+}
+
+// ShardErrorBudgetOptions mocks base method
+func (m *MockOptions) ShardErrorBudgetOptions() ShardErrorBudgetOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardErrorBudgetOptions")
+	ret0, _ := ret[0].(ShardErrorBudgetOptions)
+	return ret0
+}
+
+// ShardErrorBudgetOptions indicates an expected call of ShardErrorBudgetOptions
+func (mr *MockOptionsMockRecorder) ShardErrorBudgetOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardErrorBudgetOptions", reflect.TypeOf((*MockOptions)(nil).ShardErrorBudgetOptions))
+}
+
 // SetClientBootstrapConsistencyLevel mocks base method
 func (m *MockOptions) SetClientBootstrapConsistencyLevel(value topology.ReadConsistencyLevel) Options {
 	m.ctrl.T.Helper()
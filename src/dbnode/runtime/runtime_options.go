@@ -49,32 +49,74 @@ const (
 	defaultTickPerSeriesSleepDuration           = 100 * time.Microsecond
 	defaultTickMinimumInterval                  = 10 * time.Second
 	defaultMaxWiredBlocks                       = uint(1 << 18) // 262,144
+	defaultTickLoadPacingMaxSlowdownFactor      = 10.0
+	defaultWiredListPressureEvictFraction       = 0.1
+	defaultShardErrorBudgetMaxErrors            = 100
+	defaultShardErrorBudgetWindow               = time.Minute
+	defaultShardErrorBudgetIsolationPeriod      = 10 * time.Minute
 )
 
 var (
+	defaultTickLoadPacingOptions = TickLoadPacingOptions{
+		MaxSlowdownFactor: defaultTickLoadPacingMaxSlowdownFactor,
+	}
+	defaultWiredListPressureOptions = WiredListPressureOptions{
+		EvictFraction: defaultWiredListPressureEvictFraction,
+	}
+	defaultShardErrorBudgetOptions = ShardErrorBudgetOptions{
+		MaxErrors:       defaultShardErrorBudgetMaxErrors,
+		Window:          defaultShardErrorBudgetWindow,
+		IsolationPeriod: defaultShardErrorBudgetIsolationPeriod,
+	}
+
 	errWriteNewSeriesBackoffDurationIsNegative = errors.New(
 		"write new series backoff duration cannot be negative")
 	errWriteNewSeriesLimitPerShardPerSecondIsNegative = errors.New(
 		"write new series limit per shard per cannot be negative")
+	errWriteNewSeriesAdmissionLimitPerShardPerSecondIsNegative = errors.New(
+		"write new series admission limit per shard per second cannot be negative")
+	errWriteNewSeriesAdmissionBurstPerShardIsNegative = errors.New(
+		"write new series admission burst per shard cannot be negative")
 	errTickSeriesBatchSizeMustBePositive = errors.New(
 		"tick series batch size must be positive")
 	errTickPerSeriesSleepDurationMustBePositive = errors.New(
 		"tick per series sleep duration must be positive")
+	errTickLoadPacingTargetIsNegative = errors.New(
+		"tick load pacing target cannot be negative")
+	errTickLoadPacingMaxSlowdownFactorTooLow = errors.New(
+		"tick load pacing max slowdown factor must be at least one")
+	errWiredListPressureThresholdMustBePositive = errors.New(
+		"wired list pressure heap in use threshold must be positive")
+	errWiredListPressureProtectedRangeIsNegative = errors.New(
+		"wired list pressure protected range cannot be negative")
+	errWiredListPressureEvictFractionInvalid = errors.New(
+		"wired list pressure evict fraction must be greater than zero and at most one")
+	errShardErrorBudgetMaxErrorsMustBePositive = errors.New(
+		"shard error budget max errors must be positive")
+	errShardErrorBudgetWindowMustBePositive = errors.New(
+		"shard error budget window must be positive")
+	errShardErrorBudgetIsolationPeriodMustBePositive = errors.New(
+		"shard error budget isolation period must be positive")
 )
 
 type options struct {
-	persistRateLimitOpts                 ratelimit.Options
-	writeNewSeriesAsync                  bool
-	writeNewSeriesBackoffDuration        time.Duration
-	writeNewSeriesLimitPerShardPerSecond int
-	tickSeriesBatchSize                  int
-	tickPerSeriesSleepDuration           time.Duration
-	tickMinimumInterval                  time.Duration
-	maxWiredBlocks                       uint
-	clientBootstrapConsistencyLevel      topology.ReadConsistencyLevel
-	clientReadConsistencyLevel           topology.ReadConsistencyLevel
-	clientWriteConsistencyLevel          topology.ConsistencyLevel
-	indexDefaultQueryTimeout             time.Duration
+	persistRateLimitOpts                          ratelimit.Options
+	writeNewSeriesAsync                           bool
+	writeNewSeriesBackoffDuration                 time.Duration
+	writeNewSeriesLimitPerShardPerSecond          int
+	writeNewSeriesAdmissionLimitPerShardPerSecond int
+	writeNewSeriesAdmissionBurstPerShard          int
+	tickSeriesBatchSize                           int
+	tickPerSeriesSleepDuration                    time.Duration
+	tickMinimumInterval                           time.Duration
+	tickLoadPacingOpts                            TickLoadPacingOptions
+	maxWiredBlocks                                uint
+	wiredListPressureOpts                         WiredListPressureOptions
+	shardErrorBudgetOpts                          ShardErrorBudgetOptions
+	clientBootstrapConsistencyLevel               topology.ReadConsistencyLevel
+	clientReadConsistencyLevel                    topology.ReadConsistencyLevel
+	clientWriteConsistencyLevel                   topology.ConsistencyLevel
+	indexDefaultQueryTimeout                      time.Duration
 }
 
 // NewOptions creates a new set of runtime options with defaults
@@ -87,7 +129,10 @@ func NewOptions() Options {
 		tickSeriesBatchSize:                  defaultTickSeriesBatchSize,
 		tickPerSeriesSleepDuration:           defaultTickPerSeriesSleepDuration,
 		tickMinimumInterval:                  defaultTickMinimumInterval,
+		tickLoadPacingOpts:                   defaultTickLoadPacingOptions,
 		maxWiredBlocks:                       defaultMaxWiredBlocks,
+		wiredListPressureOpts:                defaultWiredListPressureOptions,
+		shardErrorBudgetOpts:                 defaultShardErrorBudgetOptions,
 		clientBootstrapConsistencyLevel:      DefaultBootstrapConsistencyLevel,
 		clientReadConsistencyLevel:           DefaultReadConsistencyLevel,
 		clientWriteConsistencyLevel:          DefaultWriteConsistencyLevel,
@@ -107,6 +152,18 @@ func (o *options) Validate() error {
 		return errWriteNewSeriesLimitPerShardPerSecondIsNegative
 	}
 
+	// writeNewSeriesAdmissionLimitPerShardPerSecond can be zero to specify
+	// that no admission control should be enforced
+	if o.writeNewSeriesAdmissionLimitPerShardPerSecond < 0 {
+		return errWriteNewSeriesAdmissionLimitPerShardPerSecondIsNegative
+	}
+
+	// writeNewSeriesAdmissionBurstPerShard can be zero to specify that the
+	// burst is the same as the admission limit
+	if o.writeNewSeriesAdmissionBurstPerShard < 0 {
+		return errWriteNewSeriesAdmissionBurstPerShardIsNegative
+	}
+
 	if !(o.tickSeriesBatchSize > 0) {
 		return errTickSeriesBatchSizeMustBePositive
 	}
@@ -117,6 +174,41 @@ func (o *options) Validate() error {
 
 	// tickMinimumInterval can be zero if user desires
 
+	if pacing := o.tickLoadPacingOpts; pacing.Enabled {
+		if pacing.TargetWriteLatency < 0 ||
+			pacing.TargetReadLatency < 0 ||
+			pacing.TargetCPUUtilization < 0 {
+			return errTickLoadPacingTargetIsNegative
+		}
+		if pacing.MaxSlowdownFactor < 1 {
+			return errTickLoadPacingMaxSlowdownFactorTooLow
+		}
+	}
+
+	if pressure := o.wiredListPressureOpts; pressure.Enabled {
+		if pressure.HeapInUseThresholdBytes == 0 {
+			return errWiredListPressureThresholdMustBePositive
+		}
+		if pressure.ProtectedRange < 0 {
+			return errWiredListPressureProtectedRangeIsNegative
+		}
+		if pressure.EvictFraction <= 0 || pressure.EvictFraction > 1 {
+			return errWiredListPressureEvictFractionInvalid
+		}
+	}
+
+	if budget := o.shardErrorBudgetOpts; budget.Enabled {
+		if budget.MaxErrors <= 0 {
+			return errShardErrorBudgetMaxErrorsMustBePositive
+		}
+		if budget.Window <= 0 {
+			return errShardErrorBudgetWindowMustBePositive
+		}
+		if budget.IsolationPeriod <= 0 {
+			return errShardErrorBudgetIsolationPeriodMustBePositive
+		}
+	}
+
 	return nil
 }
 
@@ -160,6 +252,26 @@ func (o *options) WriteNewSeriesLimitPerShardPerSecond() int {
 	return o.writeNewSeriesLimitPerShardPerSecond
 }
 
+func (o *options) SetWriteNewSeriesAdmissionLimitPerShardPerSecond(value int) Options {
+	opts := *o
+	opts.writeNewSeriesAdmissionLimitPerShardPerSecond = value
+	return &opts
+}
+
+func (o *options) WriteNewSeriesAdmissionLimitPerShardPerSecond() int {
+	return o.writeNewSeriesAdmissionLimitPerShardPerSecond
+}
+
+func (o *options) SetWriteNewSeriesAdmissionBurstPerShard(value int) Options {
+	opts := *o
+	opts.writeNewSeriesAdmissionBurstPerShard = value
+	return &opts
+}
+
+func (o *options) WriteNewSeriesAdmissionBurstPerShard() int {
+	return o.writeNewSeriesAdmissionBurstPerShard
+}
+
 func (o *options) SetTickSeriesBatchSize(value int) Options {
 	opts := *o
 	opts.tickSeriesBatchSize = value
@@ -190,6 +302,16 @@ func (o *options) TickMinimumInterval() time.Duration {
 	return o.tickMinimumInterval
 }
 
+func (o *options) SetTickLoadPacingOptions(value TickLoadPacingOptions) Options {
+	opts := *o
+	opts.tickLoadPacingOpts = value
+	return &opts
+}
+
+func (o *options) TickLoadPacingOptions() TickLoadPacingOptions {
+	return o.tickLoadPacingOpts
+}
+
 func (o *options) SetMaxWiredBlocks(value uint) Options {
 	opts := *o
 	opts.maxWiredBlocks = value
@@ -200,6 +322,26 @@ func (o *options) MaxWiredBlocks() uint {
 	return o.maxWiredBlocks
 }
 
+func (o *options) SetWiredListPressureOptions(value WiredListPressureOptions) Options {
+	opts := *o
+	opts.wiredListPressureOpts = value
+	return &opts
+}
+
+func (o *options) WiredListPressureOptions() WiredListPressureOptions {
+	return o.wiredListPressureOpts
+}
+
+func (o *options) SetShardErrorBudgetOptions(value ShardErrorBudgetOptions) Options {
+	opts := *o
+	opts.shardErrorBudgetOpts = value
+	return &opts
+}
+
+func (o *options) ShardErrorBudgetOptions() ShardErrorBudgetOptions {
+	return o.shardErrorBudgetOpts
+}
+
 func (o *options) SetClientBootstrapConsistencyLevel(value topology.ReadConsistencyLevel) Options {
 	opts := *o
 	opts.clientBootstrapConsistencyLevel = value
@@ -22,6 +22,7 @@ package runtime
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -30,3 +31,96 @@ func TestRuntimeOptionsDefaultsIsValid(t *testing.T) {
 	v := NewOptions()
 	assert.NoError(t, v.Validate())
 }
+
+func TestRuntimeOptionsTickLoadPacingValidate(t *testing.T) {
+	v := NewOptions().SetTickLoadPacingOptions(TickLoadPacingOptions{
+		Enabled:            true,
+		TargetWriteLatency: time.Millisecond,
+		MaxSlowdownFactor:  4,
+	})
+	assert.NoError(t, v.Validate())
+
+	v = NewOptions().SetTickLoadPacingOptions(TickLoadPacingOptions{
+		Enabled:           true,
+		TargetReadLatency: -time.Millisecond,
+		MaxSlowdownFactor: 4,
+	})
+	assert.Equal(t, errTickLoadPacingTargetIsNegative, v.Validate())
+
+	v = NewOptions().SetTickLoadPacingOptions(TickLoadPacingOptions{
+		Enabled:              true,
+		TargetCPUUtilization: 0.8,
+		MaxSlowdownFactor:    0.5,
+	})
+	assert.Equal(t, errTickLoadPacingMaxSlowdownFactorTooLow, v.Validate())
+}
+
+func TestRuntimeOptionsWiredListPressureValidate(t *testing.T) {
+	v := NewOptions().SetWiredListPressureOptions(WiredListPressureOptions{
+		Enabled:                 true,
+		HeapInUseThresholdBytes: 1 << 30,
+		ProtectedRange:          time.Hour,
+		EvictFraction:           0.2,
+	})
+	assert.NoError(t, v.Validate())
+
+	v = NewOptions().SetWiredListPressureOptions(WiredListPressureOptions{
+		Enabled:       true,
+		EvictFraction: 0.2,
+	})
+	assert.Equal(t, errWiredListPressureThresholdMustBePositive, v.Validate())
+
+	v = NewOptions().SetWiredListPressureOptions(WiredListPressureOptions{
+		Enabled:                 true,
+		HeapInUseThresholdBytes: 1 << 30,
+		ProtectedRange:          -time.Hour,
+		EvictFraction:           0.2,
+	})
+	assert.Equal(t, errWiredListPressureProtectedRangeIsNegative, v.Validate())
+
+	v = NewOptions().SetWiredListPressureOptions(WiredListPressureOptions{
+		Enabled:                 true,
+		HeapInUseThresholdBytes: 1 << 30,
+		EvictFraction:           1.5,
+	})
+	assert.Equal(t, errWiredListPressureEvictFractionInvalid, v.Validate())
+}
+
+func TestRuntimeOptionsShardErrorBudgetValidate(t *testing.T) {
+	defaults := NewOptions().ShardErrorBudgetOptions()
+	assert.False(t, defaults.Enabled)
+
+	budget := defaults
+	budget.Enabled = true
+	v := NewOptions().SetShardErrorBudgetOptions(budget)
+	assert.NoError(t, v.Validate())
+
+	budget.MaxErrors = 0
+	v = NewOptions().SetShardErrorBudgetOptions(budget)
+	assert.Equal(t, errShardErrorBudgetMaxErrorsMustBePositive, v.Validate())
+
+	budget = defaults
+	budget.Enabled = true
+	budget.Window = 0
+	v = NewOptions().SetShardErrorBudgetOptions(budget)
+	assert.Equal(t, errShardErrorBudgetWindowMustBePositive, v.Validate())
+
+	budget = defaults
+	budget.Enabled = true
+	budget.IsolationPeriod = -time.Minute
+	v = NewOptions().SetShardErrorBudgetOptions(budget)
+	assert.Equal(t, errShardErrorBudgetIsolationPeriodMustBePositive, v.Validate())
+}
+
+func TestRuntimeOptionsWriteNewSeriesAdmissionValidate(t *testing.T) {
+	v := NewOptions().
+		SetWriteNewSeriesAdmissionLimitPerShardPerSecond(100).
+		SetWriteNewSeriesAdmissionBurstPerShard(1000)
+	assert.NoError(t, v.Validate())
+
+	v = NewOptions().SetWriteNewSeriesAdmissionLimitPerShardPerSecond(-1)
+	assert.Equal(t, errWriteNewSeriesAdmissionLimitPerShardPerSecondIsNegative, v.Validate())
+
+	v = NewOptions().SetWriteNewSeriesAdmissionBurstPerShard(-1)
+	assert.Equal(t, errWriteNewSeriesAdmissionBurstPerShardIsNegative, v.Validate())
+}
@@ -77,6 +77,28 @@ type Options interface {
 	// time series being inserted.
 	WriteNewSeriesLimitPerShardPerSecond() int
 
+	// SetWriteNewSeriesAdmissionLimitPerShardPerSecond sets the sustained rate
+	// at which writes may create new series in a shard, setting to zero
+	// disables admission control. Writes for new series in excess of the rate
+	// and burst are rejected with a cardinality throttled error before any
+	// memory is allocated for the series.
+	SetWriteNewSeriesAdmissionLimitPerShardPerSecond(value int) Options
+
+	// WriteNewSeriesAdmissionLimitPerShardPerSecond returns the sustained rate
+	// at which writes may create new series in a shard, setting to zero
+	// disables admission control.
+	WriteNewSeriesAdmissionLimitPerShardPerSecond() int
+
+	// SetWriteNewSeriesAdmissionBurstPerShard sets the number of new series
+	// that may be created in a shard in a burst above the sustained admission
+	// rate, setting to zero uses the admission rate as the burst.
+	SetWriteNewSeriesAdmissionBurstPerShard(value int) Options
+
+	// WriteNewSeriesAdmissionBurstPerShard returns the number of new series
+	// that may be created in a shard in a burst above the sustained admission
+	// rate, setting to zero uses the admission rate as the burst.
+	WriteNewSeriesAdmissionBurstPerShard() int
+
 	// SetTickSeriesBatchSize sets the batch size to process series together
 	// during a tick before yielding and sleeping the per series duration
 	// multiplied by the batch size.
@@ -117,6 +139,14 @@ type Options interface {
 	// on a per series basis is short.
 	TickMinimumInterval() time.Duration
 
+	// SetTickLoadPacingOptions sets the tick load pacing options which
+	// control how the tick throttles itself when the node is under load.
+	SetTickLoadPacingOptions(value TickLoadPacingOptions) Options
+
+	// TickLoadPacingOptions returns the tick load pacing options which
+	// control how the tick throttles itself when the node is under load.
+	TickLoadPacingOptions() TickLoadPacingOptions
+
 	// SetMaxWiredBlocks sets the max blocks to keep wired; zero is used
 	// to specify no limit. Wired blocks that are in the buffer, I.E are
 	// being written to, cannot be unwired. Similarly, blocks which have
@@ -131,6 +161,22 @@ type Options interface {
 	// can also not be unwired. This means that the limit is best effort.
 	MaxWiredBlocks() uint
 
+	// SetWiredListPressureOptions sets the wired list pressure options which
+	// control evicting wired blocks when the node is under memory pressure.
+	SetWiredListPressureOptions(value WiredListPressureOptions) Options
+
+	// WiredListPressureOptions returns the wired list pressure options which
+	// control evicting wired blocks when the node is under memory pressure.
+	WiredListPressureOptions() WiredListPressureOptions
+
+	// SetShardErrorBudgetOptions sets the shard error budget options which
+	// control isolating shards that persistently fail reads.
+	SetShardErrorBudgetOptions(value ShardErrorBudgetOptions) Options
+
+	// ShardErrorBudgetOptions returns the shard error budget options which
+	// control isolating shards that persistently fail reads.
+	ShardErrorBudgetOptions() ShardErrorBudgetOptions
+
 	// SetClientBootstrapConsistencyLevel sets the client bootstrap
 	// consistency level used when bootstrapping from peers. Setting this
 	// will take effect immediately, and as such can be used to finish a
@@ -170,6 +216,73 @@ type Options interface {
 	IndexDefaultQueryTimeout() time.Duration
 }
 
+// TickLoadPacingOptions is a set of options that slow down the background
+// tick when the node is under load, the tick sleeps are scaled by how far the
+// observed load exceeds the configured targets, up to a maximum slowdown.
+type TickLoadPacingOptions struct {
+	// Enabled enables pacing the tick based on node load.
+	Enabled bool
+
+	// TargetWriteLatency is the mean write latency above which the tick
+	// is slowed down, zero ignores write latency.
+	TargetWriteLatency time.Duration
+
+	// TargetReadLatency is the mean read latency above which the tick
+	// is slowed down, zero ignores read latency.
+	TargetReadLatency time.Duration
+
+	// TargetCPUUtilization is the process CPU utilization, as a fraction of
+	// the available cores, above which the tick is slowed down, zero ignores
+	// CPU utilization.
+	TargetCPUUtilization float64
+
+	// MaxSlowdownFactor is the maximum factor the tick sleeps and minimum
+	// interval are scaled by when the node is under load.
+	MaxSlowdownFactor float64
+}
+
+// WiredListPressureOptions is a set of options that evict wired blocks in
+// order of least recent query access when the heap in use exceeds a
+// threshold, blocks within the protected range are never evicted so that
+// the time range currently being queried stays cached.
+type WiredListPressureOptions struct {
+	// Enabled enables evicting wired blocks under memory pressure.
+	Enabled bool
+
+	// HeapInUseThresholdBytes is the heap in use above which wired
+	// blocks are evicted.
+	HeapInUseThresholdBytes uint64
+
+	// ProtectedRange is how far back from now blocks are protected from
+	// eviction under memory pressure, zero protects no blocks.
+	ProtectedRange time.Duration
+
+	// EvictFraction is the fraction of wired blocks evicted each time the
+	// heap in use is observed above the threshold.
+	EvictFraction float64
+}
+
+// ShardErrorBudgetOptions is a set of options that mark a shard as degraded
+// once it fails more reads within a window than its budget allows, such as
+// when its filesets fail digest validation, a degraded shard is excluded
+// from serving reads so that they are served by the other replicas until
+// the isolation period elapses.
+type ShardErrorBudgetOptions struct {
+	// Enabled enables isolating shards that exceed their error budget.
+	Enabled bool
+
+	// MaxErrors is the number of read errors a shard may return within
+	// the window before it is marked as degraded.
+	MaxErrors int
+
+	// Window is the period over which read errors are counted.
+	Window time.Duration
+
+	// IsolationPeriod is how long a degraded shard is excluded from serving
+	// reads before it is given another chance.
+	IsolationPeriod time.Duration
+}
+
 // OptionsManager updates and supplies runtime options.
 type OptionsManager interface {
 	// Update updates the current runtime options.
@@ -49,7 +49,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/environment"
 	"github.com/m3db/m3/src/dbnode/kvconfig"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/network/peerstats"
 	hjcluster "github.com/m3db/m3/src/dbnode/network/server/httpjson/cluster"
+	hjhandler "github.com/m3db/m3/src/dbnode/network/server/httpjson/handler"
 	hjnode "github.com/m3db/m3/src/dbnode/network/server/httpjson/node"
 	"github.com/m3db/m3/src/dbnode/network/server/tchannelthrift"
 	ttcluster "github.com/m3db/m3/src/dbnode/network/server/tchannelthrift/cluster"
@@ -60,6 +62,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/retention"
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/cluster"
@@ -78,6 +81,7 @@ import (
 	xcontext "github.com/m3db/m3/src/x/context"
 	xdebug "github.com/m3db/m3/src/x/debug"
 	xdocs "github.com/m3db/m3/src/x/docs"
+	"github.com/m3db/m3/src/x/fault"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
 	"github.com/m3db/m3/src/x/lockfile"
@@ -214,6 +218,10 @@ func Run(runOpts RunOptions) {
 
 	go bgValidateProcessLimits(logger)
 	debug.SetGCPercent(cfg.GCPercentage)
+	if cfg.PoolingPolicy.LeakDetectionEnabled {
+		logger.Warn("pool leak detection enabled, this is expensive and for debugging only")
+		pool.EnableLeakDetection()
+	}
 
 	scope, _, err := cfg.Metrics.NewRootScope()
 	if err != nil {
@@ -320,6 +328,18 @@ func Run(runOpts RunOptions) {
 	)
 	opts = opts.SetInstrumentOptions(iopts)
 
+	purgeReporter := storage.NewPurgeReporter(iopts.MetricsScope().SubScope("database"))
+	opts = opts.SetPurgeReporter(purgeReporter)
+
+	if cfg.Notifications != nil {
+		notifier, err := cfg.Notifications.NewNotifier(hostID, iopts)
+		if err != nil {
+			logger.Fatal("could not create notifier", zap.Error(err))
+		}
+		defer notifier.Close()
+		opts = opts.SetNotifier(notifier)
+	}
+
 	// Only override the default MemoryTracker (which has default limits) if a custom limit has
 	// been set.
 	if cfg.Limits.MaxOutstandingRepairedBytes > 0 {
@@ -328,6 +348,20 @@ func Run(runOpts RunOptions) {
 		opts = opts.SetMemoryTracker(memTracker)
 	}
 
+	if cfg.Snapshot != nil {
+		snapshotTrackerOptions := storage.NewSnapshotTrackerOptions(
+			cfg.Snapshot.MinimumInterval,
+			cfg.Snapshot.UnsnapshottedBytesThreshold,
+			cfg.Snapshot.UnsnapshottedSeriesThreshold)
+		snapshotTracker := storage.NewSnapshotTracker(snapshotTrackerOptions)
+		opts = opts.SetSnapshotTracker(snapshotTracker).
+			SetSnapshotCompactionMinVolumes(cfg.Snapshot.CompactionMinVolumes)
+	}
+
+	if v := cfg.Filesystem.MigrationBlocksPerFlush; v != nil {
+		opts = opts.SetFileSetMigrationBlocksPerFlush(*v)
+	}
+
 	opentracing.SetGlobalTracer(tracer)
 
 	if cfg.Index.MaxQueryIDsConcurrency != 0 {
@@ -370,9 +404,19 @@ func Run(runOpts RunOptions) {
 			SetLimitMbps(cfg.Filesystem.ThroughputLimitMbpsOrDefault()).
 			SetLimitCheckEvery(cfg.Filesystem.ThroughputCheckEveryOrDefault())).
 		SetWriteNewSeriesAsync(cfg.WriteNewSeriesAsync).
-		SetWriteNewSeriesBackoffDuration(cfg.WriteNewSeriesBackoffDuration)
+		SetWriteNewSeriesBackoffDuration(cfg.WriteNewSeriesBackoffDuration).
+		SetWriteNewSeriesAdmissionLimitPerShardPerSecond(cfg.Limits.WriteNewSeriesAdmissionLimitPerShardPerSecond).
+		SetWriteNewSeriesAdmissionBurstPerShard(cfg.Limits.WriteNewSeriesAdmissionBurstPerShard)
+	if budgetCfg := cfg.Limits.ShardErrorBudget; budgetCfg != nil {
+		runtimeOpts = runtimeOpts.SetShardErrorBudgetOptions(
+			budgetCfg.ShardErrorBudgetOptions(runtimeOpts.ShardErrorBudgetOptions()))
+	}
 	if lruCfg := cfg.Cache.SeriesConfiguration().LRU; lruCfg != nil {
 		runtimeOpts = runtimeOpts.SetMaxWiredBlocks(lruCfg.MaxBlocks)
+		if pressure := lruCfg.MemoryPressure; pressure != nil {
+			runtimeOpts = runtimeOpts.SetWiredListPressureOptions(
+				pressure.WiredListPressureOptions(runtimeOpts.WiredListPressureOptions()))
+		}
 	}
 
 	// Setup postings list cache.
@@ -410,6 +454,10 @@ func Run(runOpts RunOptions) {
 			SetTickSeriesBatchSize(tick.SeriesBatchSize).
 			SetTickPerSeriesSleepDuration(tick.PerSeriesSleepDuration).
 			SetTickMinimumInterval(tick.MinimumInterval)
+		if pacing := tick.LoadPacing; pacing != nil {
+			runtimeOpts = runtimeOpts.SetTickLoadPacingOptions(
+				pacing.TickLoadPacingOptions(runtimeOpts.TickLoadPacingOptions()))
+		}
 	}
 
 	runtimeOptsMgr := m3dbruntime.NewOptionsManager()
@@ -440,6 +488,15 @@ func Run(runOpts RunOptions) {
 	// to both the DB and the blockRetriever.
 	blockLeaseManager := block.NewLeaseManager(nil)
 	opts = opts.SetBlockLeaseManager(blockLeaseManager)
+
+	faultInjector := fault.NewNopInjector()
+	if faultCfg := cfg.FaultInjection; faultCfg != nil {
+		faultInjector, err = faultCfg.NewInjector(iopts)
+		if err != nil {
+			logger.Fatal("could not create fault injector", zap.Error(err))
+		}
+	}
+
 	fsopts := fs.NewOptions().
 		SetClockOptions(opts.ClockOptions()).
 		SetInstrumentOptions(opts.InstrumentOptions().
@@ -448,9 +505,11 @@ func Run(runOpts RunOptions) {
 		SetNewFileMode(newFileMode).
 		SetNewDirectoryMode(newDirectoryMode).
 		SetWriterBufferSize(cfg.Filesystem.WriteBufferSizeOrDefault()).
+		SetWriterConcurrentDataWrites(cfg.Filesystem.ConcurrentDataWritesOrDefault()).
 		SetDataReaderBufferSize(cfg.Filesystem.DataReadBufferSizeOrDefault()).
 		SetInfoReaderBufferSize(cfg.Filesystem.InfoReadBufferSizeOrDefault()).
 		SetSeekReaderBufferSize(cfg.Filesystem.SeekReadBufferSizeOrDefault()).
+		SetSeekSequentialReadSize(cfg.Filesystem.SeekSequentialReadSizeOrDefault()).
 		SetMmapEnableHugeTLB(shouldUseHugeTLB).
 		SetMmapHugeTLBThreshold(mmapCfg.HugeTLB.Threshold).
 		SetRuntimeOptionsManager(runtimeOptsMgr).
@@ -459,7 +518,8 @@ func Run(runOpts RunOptions) {
 		SetForceIndexSummariesMmapMemory(cfg.Filesystem.ForceIndexSummariesMmapMemoryOrDefault()).
 		SetForceBloomFilterMmapMemory(cfg.Filesystem.ForceBloomFilterMmapMemoryOrDefault()).
 		SetIndexBloomFilterFalsePositivePercent(cfg.Filesystem.BloomFilterFalsePositivePercentOrDefault()).
-		SetMmapReporter(mmapReporter)
+		SetMmapReporter(mmapReporter).
+		SetFaultInjector(faultInjector)
 
 	var commitLogQueueSize int
 	specified := cfg.CommitLog.Queue.Size
@@ -496,6 +556,24 @@ func Run(runOpts RunOptions) {
 	// Apply pooling options.
 	opts = withEncodingAndPoolingOptions(cfg, logger, opts, cfg.PoolingPolicy)
 
+	if schedulerCfg := cfg.BackgroundScheduler; schedulerCfg != nil {
+		schedulerOpts := background.NewOptions().
+			SetInstrumentOptions(iopts).
+			SetLoadFn(opts.TickLoadMonitor().SlowdownFactor)
+		if schedulerCfg.CPUBudget > 0 {
+			schedulerOpts = schedulerOpts.SetCPUBudget(schedulerCfg.CPUBudget)
+		}
+		if schedulerCfg.DiskBudget > 0 {
+			schedulerOpts = schedulerOpts.SetDiskBudget(schedulerCfg.DiskBudget)
+		}
+		scheduler, err := background.NewScheduler(schedulerOpts)
+		if err != nil {
+			logger.Fatal("could not create background scheduler", zap.Error(err))
+		}
+		defer scheduler.Close()
+		opts = opts.SetBackgroundScheduler(scheduler)
+	}
+
 	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
 		SetInstrumentOptions(opts.InstrumentOptions()).
 		SetFilesystemOptions(fsopts).
@@ -503,7 +581,8 @@ func Run(runOpts RunOptions) {
 		SetFlushSize(cfg.CommitLog.FlushMaxBytes).
 		SetFlushInterval(cfg.CommitLog.FlushEvery).
 		SetBacklogQueueSize(commitLogQueueSize).
-		SetBacklogQueueChannelSize(commitLogQueueChannelSize))
+		SetBacklogQueueChannelSize(commitLogQueueChannelSize).
+		SetFaultInjector(faultInjector))
 
 	// Setup the block retriever
 	switch seriesCachePolicy {
@@ -519,7 +598,16 @@ func Run(runOpts RunOptions) {
 			SetBlockLeaseManager(blockLeaseManager)
 		if blockRetrieveCfg := cfg.BlockRetrieve; blockRetrieveCfg != nil {
 			retrieverOpts = retrieverOpts.
-				SetFetchConcurrency(blockRetrieveCfg.FetchConcurrency)
+				SetFetchConcurrency(blockRetrieveCfg.FetchConcurrency).
+				SetPrefetchBlocks(blockRetrieveCfg.PrefetchBlocks)
+			if blockRetrieveCfg.PrefetchBudget > 0 {
+				retrieverOpts = retrieverOpts.
+					SetPrefetchBudget(blockRetrieveCfg.PrefetchBudget)
+			}
+			if blockRetrieveCfg.FetchHandOffsPerQuery > 0 {
+				retrieverOpts = retrieverOpts.
+					SetFetchHandOffsPerQuery(blockRetrieveCfg.FetchHandOffsPerQuery)
+			}
 		}
 		blockRetrieverMgr := block.NewDatabaseBlockRetrieverManager(
 			func(md namespace.Metadata) (block.DatabaseBlockRetriever, error) {
@@ -551,6 +639,7 @@ func Run(runOpts RunOptions) {
 		envCfg, err = cfg.EnvironmentConfig.Configure(environment.ConfigurationParameters{
 			InstrumentOpts:   iopts,
 			HashingSeed:      cfg.Hashing.Seed,
+			HostID:           hostID,
 			NewDirectoryMode: newDirectoryMode,
 		})
 		if err != nil {
@@ -578,6 +667,14 @@ func Run(runOpts RunOptions) {
 
 	opts = opts.SetNamespaceInitializer(syncCfg.NamespaceInitializer)
 
+	// Track network statistics per peer for connections from clients and
+	// other nodes and for connections to other nodes.
+	peersScope := iopts.MetricsScope().SubScope("peers")
+	inboundPeerStats := peerstats.NewTracker(iopts.SetMetricsScope(
+		peersScope.Tagged(map[string]string{"direction": "inbound"})))
+	outboundPeerStats := peerstats.NewTracker(iopts.SetMetricsScope(
+		peersScope.Tagged(map[string]string{"direction": "outbound"})))
+
 	// Set tchannelthrift options.
 	ttopts := tchannelthrift.NewOptions().
 		SetClockOptions(opts.ClockOptions()).
@@ -588,7 +685,20 @@ func Run(runOpts RunOptions) {
 		SetTagDecoderPool(tagDecoderPool).
 		SetCheckedBytesWrapperPool(opts.CheckedBytesWrapperPool()).
 		SetMaxOutstandingWriteRequests(cfg.Limits.MaxOutstandingWriteRequests).
-		SetMaxOutstandingReadRequests(cfg.Limits.MaxOutstandingReadRequests)
+		SetMaxOutstandingReadRequests(cfg.Limits.MaxOutstandingReadRequests).
+		SetPeerStats(inboundPeerStats)
+	if retryAfter := cfg.Limits.BackpressureRetryAfter; retryAfter > 0 {
+		ttopts = ttopts.SetBackpressureRetryAfter(retryAfter)
+	}
+	if admissionCfg := cfg.Limits.QueryAdmission; admissionCfg != nil {
+		ttopts = ttopts.SetQueryAdmissionOptions(admissionCfg.Options())
+	}
+	if idempotencyCfg := cfg.WriteIdempotency; idempotencyCfg != nil {
+		ttopts = ttopts.SetWriteIdempotencyWindow(idempotencyCfg.Window)
+		if idempotencyCfg.MaxKeys > 0 {
+			ttopts = ttopts.SetWriteIdempotencyMaxKeys(idempotencyCfg.MaxKeys)
+		}
+	}
 
 	// Start servers before constructing the DB so orchestration tools can check health endpoints
 	// before topology is set.
@@ -657,6 +767,13 @@ func Run(runOpts RunOptions) {
 					logger.Error("unable to register debug writer endpoint", zap.Error(err))
 				}
 			}
+			mux.HandleFunc(hjhandler.PurgeReportURL,
+				hjhandler.NewPurgeReportHandler(purgeReporter, logger))
+			mux.HandleFunc(hjhandler.PeerStatsURL,
+				hjhandler.NewPeerStatsHandler(inboundPeerStats, outboundPeerStats, logger))
+			if cfg.PoolingPolicy.LeakDetectionEnabled {
+				mux.HandleFunc(hjhandler.PoolLeaksURL, hjhandler.NewPoolLeaksHandler(logger))
+			}
 
 			if err := http.ListenAndServe(cfg.DebugListenAddress, mux); err != nil {
 				logger.Error("debug server could not listen",
@@ -695,7 +812,8 @@ func Run(runOpts RunOptions) {
 	origin := topology.NewHost(hostID, "")
 	m3dbClient, err := newAdminClient(
 		cfg.Client, iopts, syncCfg.TopologyInitializer, runtimeOptsMgr,
-		origin, protoEnabled, schemaRegistry, syncCfg.KVStore, logger)
+		origin, protoEnabled, schemaRegistry, faultInjector, outboundPeerStats,
+		syncCfg.KVStore, logger)
 	if err != nil {
 		logger.Fatal("could not create m3db client", zap.Error(err))
 	}
@@ -730,7 +848,8 @@ func Run(runOpts RunOptions) {
 			clientCfg := *cluster.Client
 			clusterClient, err := newAdminClient(
 				clientCfg, iopts, topologyInitializer, runtimeOptsMgr,
-				origin, protoEnabled, schemaRegistry, syncCfg.KVStore, logger)
+				origin, protoEnabled, schemaRegistry, faultInjector,
+				outboundPeerStats, syncCfg.KVStore, logger)
 			if err != nil {
 				logger.Fatal(
 					"unable to create client for replicated cluster",
@@ -746,6 +865,7 @@ func Run(runOpts RunOptions) {
 
 		if cfg.Repair != nil {
 			repairOpts = repairOpts.
+				SetType(cfg.Repair.Type).
 				SetResultOptions(rsOpts).
 				SetDebugShadowComparisonsEnabled(cfg.Repair.DebugShadowComparisonsEnabled)
 			if cfg.Repair.Throttle > 0 {
@@ -759,6 +879,12 @@ func Run(runOpts RunOptions) {
 				// Set conditionally to avoid stomping on the default value of 1.0.
 				repairOpts = repairOpts.SetDebugShadowComparisonsPercentage(cfg.Repair.DebugShadowComparisonsPercentage)
 			}
+			if cfg.Repair.DivergenceNotifyThreshold > 0 {
+				repairOpts = repairOpts.SetDivergenceNotifyThreshold(cfg.Repair.DivergenceNotifyThreshold)
+			}
+			if cfg.Repair.HistorySize > 0 {
+				repairOpts = repairOpts.SetHistorySize(cfg.Repair.HistorySize)
+			}
 		}
 
 		opts = opts.
@@ -867,6 +993,51 @@ func Run(runOpts RunOptions) {
 	// Now that we've initialized the database we can set it on the service.
 	service.SetDatabase(db)
 
+	if cfg.DebugListenAddress != "" {
+		// The debug server uses the default mux, so the import, write
+		// lateness, block size analysis, freeze, series metadata, query,
+		// stale series and repair history endpoints can be registered once
+		// the database has been created.
+		mux := http.DefaultServeMux
+		mux.HandleFunc(hjhandler.ImportURL,
+			hjhandler.NewImportHandler(db, contextPool, logger))
+		mux.HandleFunc(hjhandler.WriteLatenessURL,
+			hjhandler.NewWriteLatenessHandler(db, logger))
+		mux.HandleFunc(hjhandler.BlockSizeAnalysisURL,
+			hjhandler.NewBlockSizeAnalysisHandler(db, logger))
+		mux.HandleFunc(hjhandler.NamespaceFreezeURL,
+			hjhandler.NewNamespaceFreezeHandler(db, logger))
+		mux.HandleFunc(hjhandler.NamespaceUnfreezeURL,
+			hjhandler.NewNamespaceUnfreezeHandler(db, logger))
+		mux.HandleFunc(hjhandler.SeriesMetadataURL,
+			hjhandler.NewSeriesMetadataHandler(db, contextPool, logger))
+		mux.HandleFunc(hjhandler.QueryURL,
+			hjhandler.NewQueryHandler(db, contextPool, logger))
+		mux.HandleFunc(hjhandler.StaleSeriesURL,
+			hjhandler.NewStaleSeriesHandler(db, contextPool, logger))
+		mux.HandleFunc(hjhandler.RepairHistoryURL,
+			hjhandler.NewRepairHistoryHandler(db, logger))
+	}
+
+	if promCfg := cfg.PromRemoteWrite; promCfg != nil {
+		mux := http.NewServeMux()
+		mux.HandleFunc(hjhandler.PromRemoteWriteURL,
+			hjhandler.NewPromRemoteWriteHandler(db, ident.StringID(promCfg.Namespace),
+				contextPool, tagEncoderPool, logger))
+		mux.HandleFunc(hjhandler.PromRemoteReadURL,
+			hjhandler.NewPromRemoteReadHandler(db, ident.StringID(promCfg.Namespace),
+				contextPool, logger))
+		go func() {
+			logger.Info("prom remote write: listening",
+				zap.String("address", promCfg.ListenAddress),
+				zap.String("namespace", promCfg.Namespace))
+			if err := http.ListenAndServe(promCfg.ListenAddress, mux); err != nil {
+				logger.Error("prom remote write server could not listen",
+					zap.String("address", promCfg.ListenAddress), zap.Error(err))
+			}
+		}()
+	}
+
 	go func() {
 		if runOpts.BootstrapCh != nil {
 			// Notify on bootstrap chan if specified.
@@ -1525,7 +1696,25 @@ func withEncodingAndPoolingOptions(
 		SetQueryResultsPool(queryResultsPool).
 		SetAggregateResultsPool(aggregateQueryResultsPool).
 		SetForwardIndexProbability(cfg.Index.ForwardIndexProbability).
-		SetForwardIndexThreshold(cfg.Index.ForwardIndexThreshold)
+		SetForwardIndexThreshold(cfg.Index.ForwardIndexThreshold).
+		SetQueryBytesBudget(cfg.Index.MaxQueryBytes)
+	if spillCfg := cfg.Index.QuerySpill; spillCfg != nil && spillCfg.Enabled {
+		// Remove the spill files left behind if the node previously crashed
+		// while queries were spilling results, no queries have run yet.
+		removed, err := index.DeleteStaleQuerySpillFiles(spillCfg.Directory, time.Now())
+		if err != nil {
+			logger.Warn("could not remove stale query spill files", zap.Error(err))
+		}
+		if removed > 0 {
+			logger.Info("removed stale query spill files", zap.Int("removed", removed))
+		}
+		indexOpts = indexOpts.SetQuerySpillOptions(index.QuerySpillOptions{
+			Enabled:       true,
+			Directory:     spillCfg.Directory,
+			MaxQueryBytes: spillCfg.MaxQueryBytes,
+			Limiter:       index.NewQuerySpillLimiter(spillCfg.MaxBytes),
+		})
+	}
 
 	queryResultsPool.Init(func() index.QueryResults {
 		// NB(r): Need to initialize after setting the index opts so
@@ -1549,6 +1738,8 @@ func newAdminClient(
 	origin topology.Host,
 	protoEnabled bool,
 	schemaRegistry namespace.SchemaRegistry,
+	faultInjector fault.Injector,
+	peerStats peerstats.Tracker,
 	kvStore kv.Store,
 	logger *zap.Logger,
 ) (client.AdminClient, error) {
@@ -1582,6 +1773,12 @@ func newAdminClient(
 		func(opts client.AdminOptions) client.AdminOptions {
 			return opts.SetSchemaRegistry(schemaRegistry).(client.AdminOptions)
 		},
+		func(opts client.AdminOptions) client.AdminOptions {
+			return opts.SetFaultInjector(faultInjector)
+		},
+		func(opts client.AdminOptions) client.AdminOptions {
+			return opts.SetPeerStats(peerStats).(client.AdminOptions)
+		},
 	)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,50 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package server
+
+import (
+	"io"
+
+	"github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+)
+
+// VerifyFileSets verifies every fileset on disk of the node described by the
+// configuration and writes a report of the results, it is intended to be run
+// in place of starting the node as a pre-flight check after a disk incident.
+// It returns whether any of the filesets are corrupt.
+func VerifyFileSets(cfg config.DBConfiguration, w io.Writer) (bool, error) {
+	fsOpts := fs.NewOptions().
+		SetFilePathPrefix(cfg.Filesystem.FilePathPrefixOrDefault()).
+		SetDataReaderBufferSize(cfg.Filesystem.DataReadBufferSizeOrDefault()).
+		SetInfoReaderBufferSize(cfg.Filesystem.InfoReadBufferSizeOrDefault())
+
+	report, err := fs.VerifyFileSets(fs.VerifyOptions{
+		FilesystemOptions: fsOpts,
+	})
+	if err != nil {
+		return false, err
+	}
+	if err := report.Write(w); err != nil {
+		return false, err
+	}
+	return report.Corrupted(), nil
+}
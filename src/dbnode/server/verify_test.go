@@ -0,0 +1,102 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cmd/services/m3dbnode/config"
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestVerifyFileSets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdb")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		nsID       = ident.StringID("testns")
+		blockSize  = 2 * time.Hour
+		blockStart = time.Now().Truncate(blockSize)
+	)
+	w, err := fs.NewWriter(fs.NewOptions().SetFilePathPrefix(dir))
+	require.NoError(t, err)
+	require.NoError(t, w.Open(fs.DataWriterOpenOptions{
+		BlockSize: blockSize,
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  nsID,
+			Shard:      0,
+			BlockStart: blockStart,
+		},
+	}))
+	data := checked.NewBytes([]byte{1, 2, 3}, nil)
+	data.IncRef()
+	require.NoError(t, w.Write(ident.StringID("foo"), ident.Tags{}, data,
+		digest.Checksum(data.Bytes())))
+	data.DecRef()
+	require.NoError(t, w.Close())
+
+	cfg := config.DBConfiguration{
+		Filesystem: config.FilesystemConfiguration{FilePathPrefix: &dir},
+	}
+	verify := func() (bool, fs.VerifyReport) {
+		var buf bytes.Buffer
+		corrupt, err := VerifyFileSets(cfg, &buf)
+		require.NoError(t, err)
+
+		var report fs.VerifyReport
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &report))
+		return corrupt, report
+	}
+
+	corrupt, report := verify()
+	require.False(t, corrupt)
+	require.Equal(t, 1, report.Verified)
+	require.Equal(t, 0, report.Corrupt)
+
+	// Corrupt the data of the fileset.
+	dataFiles, err := filepath.Glob(filepath.Join(
+		fs.ShardDataDirPath(dir, nsID, 0), "*-data.db"))
+	require.NoError(t, err)
+	require.Equal(t, 1, len(dataFiles))
+	contents, err := ioutil.ReadFile(dataFiles[0])
+	require.NoError(t, err)
+	contents[0]++
+	require.NoError(t, ioutil.WriteFile(dataFiles[0], contents, 0666))
+
+	corrupt, report = verify()
+	require.True(t, corrupt)
+	require.Equal(t, 0, report.Verified)
+	require.Equal(t, 1, report.Corrupt)
+	require.Equal(t, nsID.String(), report.FileSets[0].Namespace)
+	require.NotEmpty(t, report.FileSets[0].Error)
+}
@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/m3db/m3/src/x/ident"
+)
+
+const (
+	// IDShardKeyStrategy assigns series to shards by hashing their whole ID,
+	// this is the default strategy.
+	IDShardKeyStrategy = "id"
+
+	// TenantPrefixShardKeyStrategy assigns series to shards by hashing the
+	// tenant prefix of their ID, i.e. the bytes before the first
+	// TenantPrefixDelimiter, so that all series of a tenant are owned by the
+	// same shard. IDs without the delimiter are hashed as a whole.
+	TenantPrefixShardKeyStrategy = "tenant-prefix"
+
+	// TenantPrefixDelimiter delimits the tenant prefix of series IDs for the
+	// tenant prefix shard key strategy.
+	TenantPrefixDelimiter = ':'
+)
+
+var (
+	errShardKeyStrategyNameEmpty = errors.New("shard key strategy name must not be empty")
+	errShardKeyStrategyFnNil     = errors.New("shard key strategy function must not be nil")
+)
+
+// ShardKeyFn returns the key of a series ID that is hashed to assign the
+// series to a shard, allowing related series to be owned by the same shard.
+type ShardKeyFn func(id ident.ID) ident.ID
+
+var shardKeyStrategies = struct {
+	sync.RWMutex
+	fns map[string]ShardKeyFn
+}{
+	fns: map[string]ShardKeyFn{
+		IDShardKeyStrategy:           IDShardKey,
+		TenantPrefixShardKeyStrategy: TenantPrefixShardKey,
+	},
+}
+
+// RegisterShardKeyStrategy registers a named shard key strategy so that it
+// can be selected by namespaces and clients, strategies must be registered
+// with the same name on both the database nodes and clients.
+func RegisterShardKeyStrategy(name string, fn ShardKeyFn) error {
+	if name == "" {
+		return errShardKeyStrategyNameEmpty
+	}
+	if fn == nil {
+		return errShardKeyStrategyFnNil
+	}
+
+	shardKeyStrategies.Lock()
+	defer shardKeyStrategies.Unlock()
+
+	if _, ok := shardKeyStrategies.fns[name]; ok {
+		return fmt.Errorf("shard key strategy already registered: %s", name)
+	}
+	shardKeyStrategies.fns[name] = fn
+	return nil
+}
+
+// LookupShardKeyStrategy returns the shard key function of a registered
+// strategy, an empty name returns the default strategy.
+func LookupShardKeyStrategy(name string) (ShardKeyFn, error) {
+	if name == "" {
+		name = IDShardKeyStrategy
+	}
+
+	shardKeyStrategies.RLock()
+	fn, ok := shardKeyStrategies.fns[name]
+	shardKeyStrategies.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown shard key strategy: %s", name)
+	}
+	return fn, nil
+}
+
+// IDShardKey returns the whole series ID as its shard key.
+func IDShardKey(id ident.ID) ident.ID {
+	return id
+}
+
+// TenantPrefixShardKey returns the tenant prefix of a series ID as its shard
+// key, or the whole ID if it has no tenant prefix.
+func TenantPrefixShardKey(id ident.ID) ident.ID {
+	data := id.Bytes()
+	idx := bytes.IndexByte(data, TenantPrefixDelimiter)
+	if idx < 0 {
+		return id
+	}
+	return ident.BytesID(data[:idx])
+}
+
+type shardKeyShardSet struct {
+	ShardSet
+
+	shardKeyFn ShardKeyFn
+}
+
+// NewShardKeyShardSet returns a shard set that looks up the shard of series
+// IDs by hashing the shard key returned for them.
+func NewShardKeyShardSet(set ShardSet, fn ShardKeyFn) ShardSet {
+	return &shardKeyShardSet{
+		ShardSet:   set,
+		shardKeyFn: fn,
+	}
+}
+
+func (s *shardKeyShardSet) Lookup(id ident.ID) uint32 {
+	return s.ShardSet.Lookup(s.shardKeyFn(id))
+}
+
+func (s *shardKeyShardSet) HashFn() HashFn {
+	fn := s.ShardSet.HashFn()
+	return func(id ident.ID) uint32 {
+		return fn(s.shardKeyFn(id))
+	}
+}
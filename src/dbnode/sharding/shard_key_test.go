@@ -0,0 +1,76 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantPrefixShardKeyStrategy(t *testing.T) {
+	fn, err := LookupShardKeyStrategy(TenantPrefixShardKeyStrategy)
+	require.NoError(t, err)
+
+	require.Equal(t, "acme", fn(ident.StringID("acme:cpu.user")).String())
+	require.Equal(t, "cpu.user", fn(ident.StringID("cpu.user")).String())
+
+	shards := NewShards([]uint32{0, 1, 2, 3, 4, 5, 6, 7}, shard.Available)
+	set, err := NewShardSet(shards, DefaultHashFn(len(shards)))
+	require.NoError(t, err)
+	keyed := NewShardKeyShardSet(set, fn)
+
+	// All series of a tenant are owned by the shard of the tenant.
+	expected := set.Lookup(ident.StringID("acme"))
+	for _, id := range []string{"acme:cpu.user", "acme:cpu.system", "acme:mem.free"} {
+		require.Equal(t, expected, keyed.Lookup(ident.StringID(id)))
+		require.Equal(t, expected, keyed.HashFn()(ident.StringID(id)))
+	}
+	require.Equal(t, set.AllIDs(), keyed.AllIDs())
+}
+
+func TestLookupShardKeyStrategy(t *testing.T) {
+	fn, err := LookupShardKeyStrategy("")
+	require.NoError(t, err)
+	require.Equal(t, "acme:cpu", fn(ident.StringID("acme:cpu")).String())
+
+	_, err = LookupShardKeyStrategy("unknown")
+	require.Error(t, err)
+}
+
+func TestRegisterShardKeyStrategy(t *testing.T) {
+	require.Error(t, RegisterShardKeyStrategy("", IDShardKey))
+	require.Error(t, RegisterShardKeyStrategy("test-first-byte", nil))
+	require.Error(t, RegisterShardKeyStrategy(IDShardKeyStrategy, IDShardKey))
+
+	firstByte := func(id ident.ID) ident.ID {
+		return ident.BytesID(id.Bytes()[:1])
+	}
+	require.NoError(t, RegisterShardKeyStrategy("test-first-byte", firstByte))
+	require.Error(t, RegisterShardKeyStrategy("test-first-byte", firstByte))
+
+	fn, err := LookupShardKeyStrategy("test-first-byte")
+	require.NoError(t, err)
+	require.Equal(t, "a", fn(ident.StringID("acme")).String())
+}
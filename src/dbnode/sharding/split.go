@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"errors"
+)
+
+var (
+	errShardSplitFromNumShardsZero = errors.New("shard split must split from at least one shard")
+	errShardSplitNotGrowing        = errors.New("shard split must split into more shards than it splits from")
+	errShardSplitNotMultiple       = errors.New("shard split must split into a multiple of the shards it splits from")
+)
+
+// ShardSplit describes splitting a shard space into a larger shard space.
+// The number of shards split into must be a multiple of the number of shards
+// split from, which for a hash function of the form hash(id) % numShards
+// guarantees that every series of a child shard was owned by a single parent
+// shard, namely the child shard modulo the number of shards split from.
+type ShardSplit struct {
+	fromNumShards uint32
+	toNumShards   uint32
+}
+
+// NewShardSplit returns a new shard split from one number of shards to
+// another.
+func NewShardSplit(fromNumShards, toNumShards uint32) (ShardSplit, error) {
+	if fromNumShards == 0 {
+		return ShardSplit{}, errShardSplitFromNumShardsZero
+	}
+	if toNumShards <= fromNumShards {
+		return ShardSplit{}, errShardSplitNotGrowing
+	}
+	if toNumShards%fromNumShards != 0 {
+		return ShardSplit{}, errShardSplitNotMultiple
+	}
+	return ShardSplit{
+		fromNumShards: fromNumShards,
+		toNumShards:   toNumShards,
+	}, nil
+}
+
+// FromNumShards returns the number of shards split from.
+func (s ShardSplit) FromNumShards() uint32 {
+	return s.fromNumShards
+}
+
+// ToNumShards returns the number of shards split into.
+func (s ShardSplit) ToNumShards() uint32 {
+	return s.toNumShards
+}
+
+// Parent returns the shard that owned the series of a child shard before the
+// split.
+func (s ShardSplit) Parent(child uint32) uint32 {
+	return child % s.fromNumShards
+}
+
+// Children returns the shards the series of a parent shard are split into,
+// the first child always shares the ID of the parent.
+func (s ShardSplit) Children(parent uint32) []uint32 {
+	if s.fromNumShards == 0 || parent >= s.fromNumShards {
+		return nil
+	}
+	children := make([]uint32, 0, s.toNumShards/s.fromNumShards)
+	for child := parent; child < s.toNumShards; child += s.fromNumShards {
+		children = append(children, child)
+	}
+	return children
+}
@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package sharding
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewShardSplit(t *testing.T) {
+	_, err := NewShardSplit(0, 4)
+	require.Equal(t, errShardSplitFromNumShardsZero, err)
+	_, err = NewShardSplit(4, 4)
+	require.Equal(t, errShardSplitNotGrowing, err)
+	_, err = NewShardSplit(4, 6)
+	require.Equal(t, errShardSplitNotMultiple, err)
+
+	split, err := NewShardSplit(256, 1024)
+	require.NoError(t, err)
+	require.Equal(t, uint32(256), split.FromNumShards())
+	require.Equal(t, uint32(1024), split.ToNumShards())
+}
+
+func TestShardSplitParentChildren(t *testing.T) {
+	split, err := NewShardSplit(4, 16)
+	require.NoError(t, err)
+
+	require.Equal(t, []uint32{1, 5, 9, 13}, split.Children(1))
+	require.Nil(t, split.Children(4))
+	for _, child := range split.Children(3) {
+		require.Equal(t, uint32(3), split.Parent(child))
+	}
+}
+
+func TestShardSplitHashFn(t *testing.T) {
+	split, err := NewShardSplit(8, 32)
+	require.NoError(t, err)
+
+	var (
+		fromFn = DefaultHashFn(int(split.FromNumShards()))
+		toFn   = DefaultHashFn(int(split.ToNumShards()))
+	)
+	for i := 0; i < 1000; i++ {
+		id := ident.StringID(fmt.Sprintf("foo.%d", i))
+		require.Equal(t, fromFn(id), split.Parent(toFn(id)))
+	}
+}
@@ -0,0 +1,133 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package background
+
+import (
+	"errors"
+	"math"
+	"runtime"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+const (
+	defaultDiskBudget      = 2
+	defaultRecheckInterval = time.Second
+)
+
+var (
+	defaultCPUBudget = int(math.Max(float64(runtime.NumCPU()/4), 1))
+
+	errCPUBudgetNotPositive       = errors.New("cpu budget must be positive")
+	errDiskBudgetNotPositive      = errors.New("disk budget must be positive")
+	errLoadFnNotSet               = errors.New("load fn not set")
+	errRecheckIntervalNotPositive = errors.New("recheck interval must be positive")
+)
+
+type options struct {
+	instrumentOpts  instrument.Options
+	cpuBudget       int
+	diskBudget      int
+	loadFn          LoadFn
+	recheckInterval time.Duration
+}
+
+// NewOptions creates a new set of background work scheduler options.
+func NewOptions() Options {
+	return &options{
+		instrumentOpts:  instrument.NewOptions(),
+		cpuBudget:       defaultCPUBudget,
+		diskBudget:      defaultDiskBudget,
+		loadFn:          noLoad,
+		recheckInterval: defaultRecheckInterval,
+	}
+}
+
+func (o *options) Validate() error {
+	if o.cpuBudget <= 0 {
+		return errCPUBudgetNotPositive
+	}
+	if o.diskBudget <= 0 {
+		return errDiskBudgetNotPositive
+	}
+	if o.loadFn == nil {
+		return errLoadFnNotSet
+	}
+	if o.recheckInterval <= 0 {
+		return errRecheckIntervalNotPositive
+	}
+	return nil
+}
+
+func (o *options) SetInstrumentOptions(value instrument.Options) Options {
+	opts := *o
+	opts.instrumentOpts = value
+	return &opts
+}
+
+func (o *options) InstrumentOptions() instrument.Options {
+	return o.instrumentOpts
+}
+
+func (o *options) SetCPUBudget(value int) Options {
+	opts := *o
+	opts.cpuBudget = value
+	return &opts
+}
+
+func (o *options) CPUBudget() int {
+	return o.cpuBudget
+}
+
+func (o *options) SetDiskBudget(value int) Options {
+	opts := *o
+	opts.diskBudget = value
+	return &opts
+}
+
+func (o *options) DiskBudget() int {
+	return o.diskBudget
+}
+
+func (o *options) SetLoadFn(value LoadFn) Options {
+	opts := *o
+	opts.loadFn = value
+	return &opts
+}
+
+func (o *options) LoadFn() LoadFn {
+	return o.loadFn
+}
+
+func (o *options) SetRecheckInterval(value time.Duration) Options {
+	opts := *o
+	opts.recheckInterval = value
+	return &opts
+}
+
+func (o *options) RecheckInterval() time.Duration {
+	return o.recheckInterval
+}
+
+func noLoad() float64 {
+	return 1
+}
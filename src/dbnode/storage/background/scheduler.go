@@ -0,0 +1,265 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package background
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+const numPriorities = int(HighPriority) + 1
+
+var (
+	errSchedulerClosed = errors.New("background scheduler is closed")
+
+	// prioritySharesOfBudget are the share of the budgets each priority class
+	// may fill, lower priority work leaves headroom for higher priority work.
+	prioritySharesOfBudget = [numPriorities]float64{
+		LowPriority:    0.5,
+		NormalPriority: 0.75,
+		HighPriority:   1,
+	}
+)
+
+type schedulerMetrics struct {
+	running     tally.Gauge
+	admitted    tally.Counter
+	rejected    tally.Counter
+	waitLatency tally.Timer
+}
+
+func newSchedulerMetrics(scope tally.Scope) map[WorkType]schedulerMetrics {
+	m := make(map[WorkType]schedulerMetrics, len(ValidWorkTypes))
+	for _, t := range ValidWorkTypes {
+		typeScope := scope.Tagged(map[string]string{
+			"type":     t.String(),
+			"priority": t.Priority().String(),
+		})
+		m[t] = schedulerMetrics{
+			running:     typeScope.Gauge("running"),
+			admitted:    typeScope.Counter("admitted"),
+			rejected:    typeScope.Counter("rejected"),
+			waitLatency: typeScope.Timer("wait-latency"),
+		}
+	}
+	return m
+}
+
+type scheduler struct {
+	sync.Mutex
+
+	cond       *sync.Cond
+	cpuBudget  int
+	diskBudget int
+	loadFn     LoadFn
+	nowFn      func() time.Time
+
+	running       Cost
+	runningByType map[WorkType]int
+	numRunning    int
+	numWaiting    [numPriorities]int
+	closed        bool
+	doneCh        chan struct{}
+	recheckEvery  time.Duration
+	metricsByType map[WorkType]schedulerMetrics
+}
+
+// NewScheduler returns a new background work scheduler.
+func NewScheduler(opts Options) (Scheduler, error) {
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	scope := opts.InstrumentOptions().MetricsScope().SubScope("background-scheduler")
+	s := &scheduler{
+		cpuBudget:     opts.CPUBudget(),
+		diskBudget:    opts.DiskBudget(),
+		loadFn:        opts.LoadFn(),
+		nowFn:         time.Now,
+		runningByType: make(map[WorkType]int, len(ValidWorkTypes)),
+		doneCh:        make(chan struct{}),
+		recheckEvery:  opts.RecheckInterval(),
+		metricsByType: newSchedulerMetrics(scope),
+	}
+	s.cond = sync.NewCond(s)
+
+	go s.recheckLoop()
+	return s, nil
+}
+
+func (s *scheduler) Acquire(t WorkType) (Release, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return nil, errSchedulerClosed
+	}
+
+	start := s.nowFn()
+	priority := t.Priority()
+	s.numWaiting[priority]++
+	for !s.closed && !s.canAdmitWithLock(t) {
+		s.cond.Wait()
+	}
+	s.numWaiting[priority]--
+
+	// Work of a lower priority may have been held back only by this waiter.
+	s.cond.Broadcast()
+
+	if s.closed {
+		return nil, errSchedulerClosed
+	}
+
+	s.admitWithLock(t)
+	s.metricsByType[t].waitLatency.Record(s.nowFn().Sub(start))
+	return s.releaseFn(t), nil
+}
+
+func (s *scheduler) TryAcquire(t WorkType) (Release, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed || !s.canAdmitWithLock(t) {
+		s.metricsByType[t].rejected.Inc(1)
+		return nil, false
+	}
+
+	s.admitWithLock(t)
+	return s.releaseFn(t), true
+}
+
+func (s *scheduler) Close() error {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.closed {
+		return errSchedulerClosed
+	}
+	s.closed = true
+	close(s.doneCh)
+	s.cond.Broadcast()
+	return nil
+}
+
+func (s *scheduler) canAdmitWithLock(t WorkType) bool {
+	priority := t.Priority()
+	for p := int(priority) + 1; p < numPriorities; p++ {
+		if s.numWaiting[p] > 0 {
+			// Pending work of a higher priority goes first.
+			return false
+		}
+	}
+
+	if s.numRunning == 0 {
+		// Always make progress one unit of work at a time, even under load.
+		return true
+	}
+
+	share := prioritySharesOfBudget[priority]
+	if priority != HighPriority {
+		if load := s.loadFn(); load > 1 {
+			share /= load
+		}
+	}
+
+	cost := t.Cost()
+	if cost.CPU > 0 && float64(s.running.CPU+cost.CPU) > share*float64(s.cpuBudget) {
+		return false
+	}
+	if cost.Disk > 0 && float64(s.running.Disk+cost.Disk) > share*float64(s.diskBudget) {
+		return false
+	}
+	return true
+}
+
+func (s *scheduler) admitWithLock(t WorkType) {
+	cost := t.Cost()
+	s.running.CPU += cost.CPU
+	s.running.Disk += cost.Disk
+	s.numRunning++
+	s.runningByType[t]++
+
+	m := s.metricsByType[t]
+	m.admitted.Inc(1)
+	m.running.Update(float64(s.runningByType[t]))
+}
+
+func (s *scheduler) releaseFn(t WorkType) Release {
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.release(t)
+		})
+	}
+}
+
+func (s *scheduler) release(t WorkType) {
+	s.Lock()
+	defer s.Unlock()
+
+	cost := t.Cost()
+	s.running.CPU -= cost.CPU
+	s.running.Disk -= cost.Disk
+	s.numRunning--
+	s.runningByType[t]--
+	s.metricsByType[t].running.Update(float64(s.runningByType[t]))
+	s.cond.Broadcast()
+}
+
+// recheckLoop periodically wakes up pending work so that it is re-evaluated
+// for admission as the load of the node changes.
+func (s *scheduler) recheckLoop() {
+	ticker := time.NewTicker(s.recheckEvery)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.cond.Broadcast()
+		case <-s.doneCh:
+			return
+		}
+	}
+}
+
+type noopScheduler struct{}
+
+// NewNoopScheduler returns a scheduler that admits all work immediately.
+func NewNoopScheduler() Scheduler {
+	return noopScheduler{}
+}
+
+func (noopScheduler) Acquire(t WorkType) (Release, error) {
+	return noopRelease, nil
+}
+
+func (noopScheduler) TryAcquire(t WorkType) (Release, bool) {
+	return noopRelease, true
+}
+
+func (noopScheduler) Close() error {
+	return nil
+}
+
+func noopRelease() {}
@@ -0,0 +1,232 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package background
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestScheduler(t *testing.T, cpu, disk int, load float64) *scheduler {
+	opts := NewOptions().
+		SetCPUBudget(cpu).
+		SetDiskBudget(disk).
+		SetLoadFn(func() float64 { return load }).
+		SetRecheckInterval(10 * time.Millisecond)
+	s, err := NewScheduler(opts)
+	require.NoError(t, err)
+	return s.(*scheduler)
+}
+
+func waitForWaiting(t *testing.T, s *scheduler, p Priority, n int) {
+	for i := 0; i < 1000; i++ {
+		s.Lock()
+		waiting := s.numWaiting[p]
+		s.Unlock()
+		if waiting == n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	require.FailNow(t, "timed out waiting for pending work")
+}
+
+func TestOptionsValidate(t *testing.T) {
+	require.NoError(t, NewOptions().Validate())
+	require.Equal(t, errCPUBudgetNotPositive,
+		NewOptions().SetCPUBudget(0).Validate())
+	require.Equal(t, errDiskBudgetNotPositive,
+		NewOptions().SetDiskBudget(0).Validate())
+	require.Equal(t, errLoadFnNotSet,
+		NewOptions().SetLoadFn(nil).Validate())
+	require.Equal(t, errRecheckIntervalNotPositive,
+		NewOptions().SetRecheckInterval(0).Validate())
+
+	_, err := NewScheduler(NewOptions().SetCPUBudget(0))
+	require.Equal(t, errCPUBudgetNotPositive, err)
+}
+
+func TestSchedulerAdmitsWithinBudget(t *testing.T) {
+	s := newTestScheduler(t, 2, 2, 1)
+	defer s.Close()
+
+	release1, ok := s.TryAcquire(FlushWork)
+	require.True(t, ok)
+	release2, ok := s.TryAcquire(FlushWork)
+	require.True(t, ok)
+
+	_, ok = s.TryAcquire(FlushWork)
+	require.False(t, ok)
+
+	// Compaction only needs CPU which is exhausted too.
+	_, ok = s.TryAcquire(CompactionWork)
+	require.False(t, ok)
+
+	release1()
+	// Releasing twice must not return the budget twice.
+	release1()
+
+	release3, ok := s.TryAcquire(FlushWork)
+	require.True(t, ok)
+	_, ok = s.TryAcquire(FlushWork)
+	require.False(t, ok)
+
+	release2()
+	release3()
+	assert.Equal(t, Cost{}, s.running)
+	assert.Equal(t, 0, s.numRunning)
+}
+
+func TestSchedulerPriorityShareOfBudget(t *testing.T) {
+	s := newTestScheduler(t, 2, 2, 1)
+	defer s.Close()
+
+	// Low priority work may only fill half the budget.
+	releaseRepair, ok := s.TryAcquire(RepairWork)
+	require.True(t, ok)
+	_, ok = s.TryAcquire(RepairWork)
+	require.False(t, ok)
+
+	// Leaving headroom for high priority work.
+	releaseFlush, ok := s.TryAcquire(FlushWork)
+	require.True(t, ok)
+
+	releaseRepair()
+	releaseFlush()
+}
+
+func TestSchedulerLoadShrinksBudget(t *testing.T) {
+	s := newTestScheduler(t, 4, 4, 2)
+	defer s.Close()
+
+	// Under a load of two normal priority work may only fill 4*0.75/2=1.5.
+	releaseColdFlush, ok := s.TryAcquire(ColdFlushWork)
+	require.True(t, ok)
+	_, ok = s.TryAcquire(ColdFlushWork)
+	require.False(t, ok)
+
+	// High priority work is not subject to the load.
+	var releases []Release
+	for i := 0; i < 3; i++ {
+		release, ok := s.TryAcquire(FlushWork)
+		require.True(t, ok)
+		releases = append(releases, release)
+	}
+	_, ok = s.TryAcquire(FlushWork)
+	require.False(t, ok)
+
+	releaseColdFlush()
+	for _, release := range releases {
+		release()
+	}
+}
+
+func TestSchedulerAlwaysAdmitsWhenIdle(t *testing.T) {
+	s := newTestScheduler(t, 1, 1, 10)
+	defer s.Close()
+
+	release, err := s.Acquire(RepairWork)
+	require.NoError(t, err)
+	release()
+}
+
+func TestSchedulerHigherPriorityWaitersFirst(t *testing.T) {
+	s := newTestScheduler(t, 1, 1, 1)
+	defer s.Close()
+
+	releaseColdFlush, err := s.Acquire(ColdFlushWork)
+	require.NoError(t, err)
+
+	flushCh := make(chan Release, 1)
+	go func() {
+		release, err := s.Acquire(FlushWork)
+		require.NoError(t, err)
+		flushCh <- release
+	}()
+	waitForWaiting(t, s, HighPriority, 1)
+
+	repairCh := make(chan Release, 1)
+	go func() {
+		release, err := s.Acquire(RepairWork)
+		require.NoError(t, err)
+		repairCh <- release
+	}()
+	waitForWaiting(t, s, LowPriority, 1)
+
+	// Low priority work is not admitted ahead of pending high priority work.
+	_, ok := s.TryAcquire(CleanupWork)
+	require.False(t, ok)
+
+	releaseColdFlush()
+	releaseFlush := <-flushCh
+
+	select {
+	case <-repairCh:
+		require.FailNow(t, "repair admitted while flush running")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	releaseFlush()
+	releaseRepair := <-repairCh
+	releaseRepair()
+}
+
+func TestSchedulerClose(t *testing.T) {
+	s := newTestScheduler(t, 1, 1, 1)
+
+	release, err := s.Acquire(FlushWork)
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.Acquire(FlushWork)
+		errCh <- err
+	}()
+	waitForWaiting(t, s, HighPriority, 1)
+
+	require.NoError(t, s.Close())
+	require.Equal(t, errSchedulerClosed, <-errCh)
+	require.Equal(t, errSchedulerClosed, s.Close())
+
+	_, err = s.Acquire(FlushWork)
+	require.Equal(t, errSchedulerClosed, err)
+	_, ok := s.TryAcquire(FlushWork)
+	require.False(t, ok)
+
+	release()
+}
+
+func TestNoopScheduler(t *testing.T) {
+	s := NewNoopScheduler()
+	for _, workType := range ValidWorkTypes {
+		release, err := s.Acquire(workType)
+		require.NoError(t, err)
+		release()
+
+		release, ok := s.TryAcquire(workType)
+		require.True(t, ok)
+		release()
+	}
+	require.NoError(t, s.Close())
+}
@@ -0,0 +1,195 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package background
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Priority is the priority class of a unit of background work, work of a
+// higher priority is always admitted ahead of pending work of a lower
+// priority.
+type Priority int
+
+const (
+	// LowPriority is the priority of work that can be delayed indefinitely.
+	LowPriority Priority = iota
+	// NormalPriority is the priority of work that should run regularly.
+	NormalPriority
+	// HighPriority is the priority of work that foreground traffic depends
+	// on, such as warm flushes which free up memory held by buffers.
+	HighPriority
+)
+
+// String returns the priority as a string.
+func (p Priority) String() string {
+	switch p {
+	case LowPriority:
+		return "low"
+	case NormalPriority:
+		return "normal"
+	case HighPriority:
+		return "high"
+	}
+	return "unknown"
+}
+
+// WorkType is a type of background work coordinated by the scheduler.
+type WorkType int
+
+const (
+	// FlushWork is warm flushing, snapshotting and index flushing.
+	FlushWork WorkType = iota
+	// ColdFlushWork is cold flushing.
+	ColdFlushWork
+	// CleanupWork is cleanup of expired and superseded files.
+	CleanupWork
+	// RepairWork is repairing of data with peers.
+	RepairWork
+	// CompactionWork is background compaction of index segments.
+	CompactionWork
+)
+
+// ValidWorkTypes are the set of valid work types.
+var ValidWorkTypes = []WorkType{
+	FlushWork,
+	ColdFlushWork,
+	CleanupWork,
+	RepairWork,
+	CompactionWork,
+}
+
+// String returns the work type as a string.
+func (t WorkType) String() string {
+	switch t {
+	case FlushWork:
+		return "flush"
+	case ColdFlushWork:
+		return "cold-flush"
+	case CleanupWork:
+		return "cleanup"
+	case RepairWork:
+		return "repair"
+	case CompactionWork:
+		return "compaction"
+	}
+	return "unknown"
+}
+
+// Priority returns the priority class of the work type.
+func (t WorkType) Priority() Priority {
+	switch t {
+	case FlushWork:
+		return HighPriority
+	case ColdFlushWork, CleanupWork:
+		return NormalPriority
+	}
+	return LowPriority
+}
+
+// Cost returns the share of the CPU and disk budgets the work type consumes
+// while it runs.
+func (t WorkType) Cost() Cost {
+	switch t {
+	case FlushWork, ColdFlushWork, RepairWork:
+		return Cost{CPU: 1, Disk: 1}
+	case CleanupWork:
+		return Cost{Disk: 1}
+	case CompactionWork:
+		return Cost{CPU: 1}
+	}
+	return Cost{CPU: 1, Disk: 1}
+}
+
+// Cost is the amount of the CPU and disk budgets consumed by a unit of work.
+type Cost struct {
+	CPU  int
+	Disk int
+}
+
+// Release releases the budget held by a unit of admitted work.
+type Release func()
+
+// Scheduler coordinates background work so that only as much of it runs
+// concurrently as the configured CPU and disk budgets allow, admitting
+// higher priority work first and backing off while the node is under load.
+type Scheduler interface {
+	// Acquire blocks until the work type is admitted, the returned release
+	// must be called once the work completes.
+	Acquire(t WorkType) (Release, error)
+
+	// TryAcquire admits the work type only if it can run immediately, the
+	// returned release must be called once the work completes.
+	TryAcquire(t WorkType) (Release, bool)
+
+	// Close closes the scheduler, pending and future acquires return an error.
+	Close() error
+}
+
+// LoadFn returns the current load of the node as a slowdown factor, a value
+// of one means the node is not under load.
+type LoadFn func() float64
+
+// Options are the options for the background work scheduler.
+type Options interface {
+	// Validate validates the options.
+	Validate() error
+
+	// SetInstrumentOptions sets the instrument options.
+	SetInstrumentOptions(value instrument.Options) Options
+
+	// InstrumentOptions returns the instrument options.
+	InstrumentOptions() instrument.Options
+
+	// SetCPUBudget sets the number of CPU bound units of work that may run
+	// concurrently.
+	SetCPUBudget(value int) Options
+
+	// CPUBudget returns the number of CPU bound units of work that may run
+	// concurrently.
+	CPUBudget() int
+
+	// SetDiskBudget sets the number of disk bound units of work that may run
+	// concurrently.
+	SetDiskBudget(value int) Options
+
+	// DiskBudget returns the number of disk bound units of work that may run
+	// concurrently.
+	DiskBudget() int
+
+	// SetLoadFn sets the function used to scale down the budgets available
+	// to non high priority work while the node is under load.
+	SetLoadFn(value LoadFn) Options
+
+	// LoadFn returns the function used to scale down the budgets available
+	// to non high priority work while the node is under load.
+	LoadFn() LoadFn
+
+	// SetRecheckInterval sets how often pending work is re-evaluated for
+	// admission as the load changes.
+	SetRecheckInterval(value time.Duration) Options
+
+	// RecheckInterval returns how often pending work is re-evaluated for
+	// admission as the load changes.
+	RecheckInterval() time.Duration
+}
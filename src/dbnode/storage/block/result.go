@@ -129,6 +129,19 @@ func NewFetchBlocksMetadataResult(
 	return FetchBlocksMetadataResult{ID: id, Tags: tags, Blocks: blocks}
 }
 
+// Close finalizes the ID, closes the tags and the blocks of the result.
+func (r FetchBlocksMetadataResult) Close() {
+	if r.ID != nil {
+		r.ID.Finalize()
+	}
+	if r.Tags != nil {
+		r.Tags.Close()
+	}
+	if r.Blocks != nil {
+		r.Blocks.Close()
+	}
+}
+
 type fetchBlocksMetadataResults struct {
 	results []FetchBlocksMetadataResult
 	pool    FetchBlocksMetadataResultsPool
@@ -163,19 +176,10 @@ func (s *fetchBlocksMetadataResults) Reset() {
 }
 
 func (s *fetchBlocksMetadataResults) Close() {
+	var zeroed FetchBlocksMetadataResult
 	for i := range s.results {
-		if s.results[i].ID != nil {
-			s.results[i].ID.Finalize()
-			s.results[i].ID = nil
-		}
-		if s.results[i].Tags != nil {
-			s.results[i].Tags.Close()
-			s.results[i].Tags = nil
-		}
-		if s.results[i].Blocks != nil {
-			s.results[i].Blocks.Close()
-			s.results[i].Blocks = nil
-		}
+		s.results[i].Close()
+		s.results[i] = zeroed
 	}
 	if s.pool != nil {
 		s.pool.Put(s)
@@ -115,6 +115,10 @@ type FetchBlocksMetadataResult struct {
 	Blocks FetchBlockMetadataResults
 }
 
+// FetchBlocksMetadataResultFn is called with the blocks metadata of each series
+// as it is read, the callee takes ownership of the result and must close it.
+type FetchBlocksMetadataResultFn func(result FetchBlocksMetadataResult) error
+
 // FetchBlocksMetadataResults captures a collection of FetchBlocksMetadataResult
 type FetchBlocksMetadataResults interface {
 	// Add adds a result to the slice
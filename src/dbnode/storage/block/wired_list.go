@@ -51,12 +51,14 @@ package block
 
 import (
 	"errors"
+	"math"
+	"runtime"
 	"sync"
 	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
-	"github.com/m3db/m3/src/dbnode/runtime"
+	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/x/instrument"
 
 	"github.com/uber-go/tally"
@@ -64,8 +66,9 @@ import (
 )
 
 const (
-	defaultWiredListEventsChannelSize = 65536
-	wiredListSampleGaugesEvery        = 100
+	defaultWiredListEventsChannelSize     = 65536
+	defaultWiredListPressureCheckInterval = 10 * time.Second
+	wiredListSampleGaugesEvery            = 100
 )
 
 var (
@@ -73,14 +76,21 @@ var (
 	errAlreadyStopped = errors.New("wired list already stopped")
 )
 
+// HeapInUseFn returns the bytes of heap in use by the process.
+type HeapInUseFn func() uint64
+
 // WiredList is a database block wired list.
 type WiredList struct {
 	sync.Mutex
 
-	nowFn clock.NowFn
+	nowFn       clock.NowFn
+	heapInUseFn HeapInUseFn
 
 	// Max wired blocks, must use atomic store and load to access.
 	maxWired int64
+	// Pressure options, must use atomic store and load to access.
+	pressureOpts          atomic.Value
+	pressureCheckInterval time.Duration
 
 	root          dbBlock
 	length        int
@@ -99,6 +109,9 @@ type wiredListMetrics struct {
 	pushedBack           tally.Counter
 	inserted             tally.Counter
 	evictedAfterDuration tally.Timer
+	pressureHeapInUse    tally.Gauge
+	pressureEvicted      tally.Counter
+	pressureProtected    tally.Counter
 }
 
 func newWiredListMetrics(scope tally.Scope) wiredListMetrics {
@@ -116,15 +129,28 @@ func newWiredListMetrics(scope tally.Scope) wiredListMetrics {
 		inserted: scope.Counter("inserted"),
 		// Measure how much time blocks spend in the list before being evicted
 		evictedAfterDuration: scope.Timer("evicted-after-duration"),
+		// Heap in use observed by the last memory pressure check
+		pressureHeapInUse: scope.Gauge("pressure-heap-in-use"),
+		// Incremented when a block is evicted due to memory pressure
+		pressureEvicted: scope.Counter("pressure-evicted"),
+		// Incremented when a block is not evicted due to memory pressure
+		// since it is within the protected range
+		pressureProtected: scope.Counter("pressure-evictions-prevented"),
 	}
 }
 
 // WiredListOptions is the options struct for the WiredList constructor.
 type WiredListOptions struct {
-	RuntimeOptionsManager runtime.OptionsManager
+	RuntimeOptionsManager m3dbruntime.OptionsManager
 	InstrumentOptions     instrument.Options
 	ClockOptions          clock.Options
 	EventsChannelSize     int
+	// PressureCheckInterval is how often the heap in use is checked when
+	// evicting under memory pressure is enabled, if zero the default is used.
+	PressureCheckInterval time.Duration
+	// HeapInUseFn returns the heap in use, if nil the heap in use reported
+	// by the Go runtime is used.
+	HeapInUseFn HeapInUseFn
 }
 
 // NewWiredList returns a new database block wired list.
@@ -132,15 +158,25 @@ func NewWiredList(opts WiredListOptions) *WiredList {
 	scope := opts.InstrumentOptions.MetricsScope().
 		SubScope("wired-list")
 	l := &WiredList{
-		nowFn:   opts.ClockOptions.NowFn(),
-		metrics: newWiredListMetrics(scope),
-		iOpts:   opts.InstrumentOptions,
+		nowFn:       opts.ClockOptions.NowFn(),
+		heapInUseFn: opts.HeapInUseFn,
+		metrics:     newWiredListMetrics(scope),
+		iOpts:       opts.InstrumentOptions,
 	}
 	if opts.EventsChannelSize > 0 {
 		l.updatesChSize = opts.EventsChannelSize
 	} else {
 		l.updatesChSize = defaultWiredListEventsChannelSize
 	}
+	if opts.PressureCheckInterval > 0 {
+		l.pressureCheckInterval = opts.PressureCheckInterval
+	} else {
+		l.pressureCheckInterval = defaultWiredListPressureCheckInterval
+	}
+	if l.heapInUseFn == nil {
+		l.heapInUseFn = runtimeHeapInUse
+	}
+	l.pressureOpts.Store(m3dbruntime.WiredListPressureOptions{})
 	l.root.setNext(&l.root)
 	l.root.setPrev(&l.root)
 	opts.RuntimeOptionsManager.RegisterListener(l)
@@ -149,8 +185,9 @@ func NewWiredList(opts WiredListOptions) *WiredList {
 
 // SetRuntimeOptions sets the current runtime options to
 // be consumed by the wired list
-func (l *WiredList) SetRuntimeOptions(value runtime.Options) {
+func (l *WiredList) SetRuntimeOptions(value m3dbruntime.Options) {
 	atomic.StoreInt64(&l.maxWired, int64(value.MaxWiredBlocks()))
+	l.pressureOpts.Store(value.WiredListPressureOptions())
 }
 
 // Start starts processing the wired list
@@ -163,18 +200,31 @@ func (l *WiredList) Start() error {
 
 	l.updatesCh = make(chan DatabaseBlock, l.updatesChSize)
 	l.doneCh = make(chan struct{}, 1)
-	go func() {
+	go func(updatesCh <-chan DatabaseBlock) {
+		// NB: Memory pressure is relieved from the same goroutine that
+		// processes updates since the list is not safe for concurrent use.
+		pressureTicker := time.NewTicker(l.pressureCheckInterval)
+		defer pressureTicker.Stop()
+
 		i := 0
-		for v := range l.updatesCh {
-			l.processUpdateBlock(v)
-			if i%wiredListSampleGaugesEvery == 0 {
-				l.metrics.unwireable.Update(float64(l.length))
-				l.metrics.limit.Update(float64(atomic.LoadInt64(&l.maxWired)))
+		for {
+			select {
+			case v, ok := <-updatesCh:
+				if !ok {
+					l.doneCh <- struct{}{}
+					return
+				}
+				l.processUpdateBlock(v)
+				if i%wiredListSampleGaugesEvery == 0 {
+					l.metrics.unwireable.Update(float64(l.length))
+					l.metrics.limit.Update(float64(atomic.LoadInt64(&l.maxWired)))
+				}
+				i++
+			case <-pressureTicker.C:
+				l.relieveMemoryPressure()
 			}
-			i++
 		}
-		l.doneCh <- struct{}{}
-	}()
+	}(l.updatesCh)
 
 	return nil
 }
@@ -266,63 +316,102 @@ func (l *WiredList) insertAfter(v, at DatabaseBlock) {
 	// Try to unwire all blocks possible
 	bl := l.root.next()
 	for l.length > maxWired && bl != &l.root {
-		entry := bl.wiredListEntry()
-		if !entry.wasRetrievedFromDisk {
-			// This should never happen because processUpdateBlock performs the same
-			// check, and a block should never be pooled in-between those steps because
-			// the wired list is supposed to have sole ownership over that lifecycle and
-			// is single-threaded.
-			instrument.EmitAndLogInvariantViolation(l.iOpts, func(l *zap.Logger) {
-				l.With(
-					zap.Time("blockStart", entry.startTime),
-					zap.Bool("closed", entry.closed),
-					zap.Bool("wasRetrievedFromDisk", entry.wasRetrievedFromDisk),
-				).Error("wired list tried to process a block that was not retrieved from disk")
-			})
+		nextBl := bl.next()
+		l.evict(bl, now)
+		bl = nextBl
+	}
+}
 
-		}
+// relieveMemoryPressure evicts a fraction of the wired blocks in order of
+// least recent access when the heap in use exceeds the pressure threshold,
+// skipping blocks that overlap the protected range.
+func (l *WiredList) relieveMemoryPressure() {
+	opts := l.pressureOpts.Load().(m3dbruntime.WiredListPressureOptions)
+	if !opts.Enabled || l.length == 0 {
+		return
+	}
 
-		// Evict the block before closing it so that callers of series.ReadEncoded()
-		// don't get errors about trying to read from a closed block.
-		if onEvict := bl.OnEvictedFromWiredList(); onEvict != nil {
-			if entry.seriesID == nil {
-				// Entry should always have a series ID attached
-				instrument.EmitAndLogInvariantViolation(l.iOpts, func(l *zap.Logger) {
-					l.With(
-						zap.Time("blockStart", entry.startTime),
-						zap.Bool("closed", entry.closed),
-						zap.Bool("wasRetrievedFromDisk", entry.wasRetrievedFromDisk),
-					).Error("wired list entry does not have seriesID set")
-				})
-
-			} else {
-				onEvict.OnEvictedFromWiredList(entry.seriesID, entry.startTime)
-			}
-		}
+	heapInUse := l.heapInUseFn()
+	l.metrics.pressureHeapInUse.Update(float64(heapInUse))
+	if heapInUse < opts.HeapInUseThresholdBytes {
+		return
+	}
 
-		// bl.CloseIfFromDisk() will return the block to the pool. In order to avoid
-		// races with the pool itself, we capture the value of the next block and
-		// remove the block from the wired list before we close it.
+	var (
+		now            = l.nowFn()
+		protectedStart = now.Add(-opts.ProtectedRange)
+		toEvict        = int(math.Ceil(float64(l.length) * opts.EvictFraction))
+		bl             = l.root.next()
+	)
+	for toEvict > 0 && bl != &l.root {
 		nextBl := bl.next()
-		l.remove(bl)
-		if wasFromDisk := bl.CloseIfFromDisk(); !wasFromDisk {
-			// Should never happen
+		if opts.ProtectedRange > 0 &&
+			bl.StartTime().Add(bl.BlockSize()).After(protectedStart) {
+			l.metrics.pressureProtected.Inc(1)
+			bl = nextBl
+			continue
+		}
+		l.evict(bl, now)
+		l.metrics.pressureEvicted.Inc(1)
+		toEvict--
+		bl = nextBl
+	}
+}
+
+func (l *WiredList) evict(bl DatabaseBlock, now time.Time) {
+	entry := bl.wiredListEntry()
+	if !entry.wasRetrievedFromDisk {
+		// This should never happen because processUpdateBlock performs the same
+		// check, and a block should never be pooled in-between those steps because
+		// the wired list is supposed to have sole ownership over that lifecycle and
+		// is single-threaded.
+		instrument.EmitAndLogInvariantViolation(l.iOpts, func(l *zap.Logger) {
+			l.With(
+				zap.Time("blockStart", entry.startTime),
+				zap.Bool("closed", entry.closed),
+				zap.Bool("wasRetrievedFromDisk", entry.wasRetrievedFromDisk),
+			).Error("wired list tried to process a block that was not retrieved from disk")
+		})
+
+	}
+
+	// Evict the block before closing it so that callers of series.ReadEncoded()
+	// don't get errors about trying to read from a closed block.
+	if onEvict := bl.OnEvictedFromWiredList(); onEvict != nil {
+		if entry.seriesID == nil {
+			// Entry should always have a series ID attached
 			instrument.EmitAndLogInvariantViolation(l.iOpts, func(l *zap.Logger) {
 				l.With(
 					zap.Time("blockStart", entry.startTime),
 					zap.Bool("closed", entry.closed),
 					zap.Bool("wasRetrievedFromDisk", entry.wasRetrievedFromDisk),
-				).Error("wired list tried to close a block that was not from disk")
+				).Error("wired list entry does not have seriesID set")
 			})
+
+		} else {
+			onEvict.OnEvictedFromWiredList(entry.seriesID, entry.startTime)
 		}
+	}
 
-		l.metrics.evicted.Inc(1)
+	// bl.CloseIfFromDisk() will return the block to the pool. In order to avoid
+	// races with the pool itself, callers capture the value of the next block
+	// and we remove the block from the wired list before we close it.
+	l.remove(bl)
+	if wasFromDisk := bl.CloseIfFromDisk(); !wasFromDisk {
+		// Should never happen
+		instrument.EmitAndLogInvariantViolation(l.iOpts, func(l *zap.Logger) {
+			l.With(
+				zap.Time("blockStart", entry.startTime),
+				zap.Bool("closed", entry.closed),
+				zap.Bool("wasRetrievedFromDisk", entry.wasRetrievedFromDisk),
+			).Error("wired list tried to close a block that was not from disk")
+		})
+	}
 
-		enteredListAt := time.Unix(0, bl.enteredListAtUnixNano())
-		l.metrics.evictedAfterDuration.Record(now.Sub(enteredListAt))
+	l.metrics.evicted.Inc(1)
 
-		bl = nextBl
-	}
+	enteredListAt := time.Unix(0, bl.enteredListAtUnixNano())
+	l.metrics.evictedAfterDuration.Record(now.Sub(enteredListAt))
 }
 
 func (l *WiredList) remove(v DatabaseBlock) {
@@ -360,3 +449,9 @@ func (l *WiredList) moveToBack(v DatabaseBlock) {
 func (l *WiredList) exists(v DatabaseBlock) bool {
 	return v.next() != nil || v.prev() != nil
 }
+
+func runtimeHeapInUse() uint64 {
+	var stats runtime.MemStats
+	runtime.ReadMemStats(&stats)
+	return stats.HeapInuse
+}
@@ -167,6 +167,65 @@ func TestWiredListRemovesUnwiredBlocks(t *testing.T) {
 	require.Equal(t, &l.root, l.root.prev())
 }
 
+func TestWiredListRelievesMemoryPressure(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockSize = 2 * time.Hour
+		now       = time.Now().Truncate(blockSize)
+		heapInUse = uint64(0)
+	)
+	l, mgr := newTestWiredList(nil, nil)
+	l.nowFn = func() time.Time { return now }
+	l.heapInUseFn = func() uint64 { return heapInUse }
+	require.NoError(t, mgr.Update(runtime.NewOptions().
+		SetMaxWiredBlocks(0).
+		SetWiredListPressureOptions(runtime.WiredListPressureOptions{
+			Enabled:                 true,
+			HeapInUseThresholdBytes: 100,
+			ProtectedRange:          blockSize,
+			EvictFraction:           0.5,
+		})))
+
+	opts := testOptions.SetWiredList(l)
+
+	// Blocks in access order, the hot block is least recently accessed but
+	// within the protected range.
+	starts := []time.Time{
+		now.Add(-blockSize),
+		now.Add(-4 * blockSize),
+		now.Add(-3 * blockSize),
+		now.Add(-2 * blockSize),
+	}
+	var blocks []*dbBlock
+	l.Start()
+	for i, start := range starts {
+		bl := newTestUnwireableBlock(ctrl, fmt.Sprintf("foo.%d", i), opts)
+		bl.startUnixNanos = start.UnixNano()
+		bl.blockSize = blockSize
+		blocks = append(blocks, bl)
+		l.BlockingUpdate(bl)
+	}
+	l.Stop()
+
+	// No pressure, nothing evicted.
+	heapInUse = 99
+	l.relieveMemoryPressure()
+	require.Equal(t, 4, l.length)
+
+	// Under pressure half the blocks are evicted in order of least recent
+	// access skipping the protected block.
+	heapInUse = 100
+	l.relieveMemoryPressure()
+	require.Equal(t, 2, l.length)
+	require.Equal(t, blocks[0], l.root.next())
+	require.Equal(t, blocks[3], l.root.next().next())
+	require.True(t, blocks[1].closed)
+	require.True(t, blocks[2].closed)
+	require.False(t, blocks[0].closed)
+}
+
 // wiredListTestWiredBlocksString is used to debug the order of the wired list
 func wiredListTestWiredBlocksString(l *WiredList) string { // nolint: unused
 	b := bytes.NewBuffer(nil)
@@ -0,0 +1,285 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+)
+
+const (
+	// targetDatapointsPerBlock is the number of datapoints per series a
+	// recommended block size aims to hold, blocks holding fewer datapoints
+	// compress poorly while blocks holding many more datapoints are slow to
+	// read and retain more data in memory before being flushed.
+	targetDatapointsPerBlock = 720
+
+	// queryRangeQuantile is the quantile of the time ranges of index
+	// queries the recommended index block size is based on.
+	queryRangeQuantile = 0.9
+
+	// maxIndexBlocksPerQuery is the number of index blocks the time range of
+	// most index queries should span with the recommended index block size.
+	maxIndexBlocksPerQuery = 2
+)
+
+// blockSizeCandidates are the block sizes the block size analyzer chooses
+// its recommendations from, in increasing order.
+var blockSizeCandidates = []time.Duration{
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	4 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	48 * time.Hour,
+	96 * time.Hour,
+	168 * time.Hour,
+}
+
+// queryRangeBuckets are the upper bounds of the buckets the time ranges of
+// index queries are tracked in, ranges greater than the last bound are
+// tracked in an overflow bucket.
+var queryRangeBuckets = []time.Duration{
+	5 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+	48 * time.Hour,
+	168 * time.Hour,
+	720 * time.Hour,
+}
+
+// BlockSizeAnalysis is the block size and index block size recommended for
+// a namespace based on the density of datapoints written to it and the time
+// ranges of index queries against it since the namespace was opened.
+type BlockSizeAnalysis struct {
+	// AnalyzedAt is the time of the analysis, zero until the namespace is
+	// first ticked.
+	AnalyzedAt time.Time `json:"analyzedAt"`
+	// BlockSize is the configured block size of the namespace.
+	BlockSize time.Duration `json:"blockSize"`
+	// IndexBlockSize is the configured index block size of the namespace.
+	IndexBlockSize time.Duration `json:"indexBlockSize"`
+	// RecommendedBlockSize is the recommended block size, zero if no
+	// datapoints have been written yet.
+	RecommendedBlockSize time.Duration `json:"recommendedBlockSize"`
+	// RecommendedIndexBlockSize is the recommended index block size, zero
+	// if no block size is recommended.
+	RecommendedIndexBlockSize time.Duration `json:"recommendedIndexBlockSize"`
+	// Series is the number of active series at the time of the analysis.
+	Series int64 `json:"series"`
+	// Datapoints is the number of datapoints written.
+	Datapoints int64 `json:"datapoints"`
+	// DatapointInterval is the estimated mean interval between datapoints
+	// of a series, zero if no datapoints have been written yet.
+	DatapointInterval time.Duration `json:"datapointInterval"`
+	// QueryRanges is the distribution of the time ranges of index queries.
+	QueryRanges []QueryRangeBucket `json:"queryRanges"`
+	// Queries is the number of index queries tracked.
+	Queries int64 `json:"queries"`
+}
+
+// QueryRangeBucket is the number of index queries with a time range less
+// than or equal to the upper bound and greater than the upper bound of the
+// previous bucket.
+type QueryRangeBucket struct {
+	// UpperBound is the upper bound of the bucket, zero for the overflow
+	// bucket of queries with ranges greater than the last bound.
+	UpperBound time.Duration `json:"upperBound"`
+	Count      int64         `json:"count"`
+}
+
+// blockSizeAnalyzer tracks the datapoints written to a namespace and the
+// time ranges of index queries against it, and recommends a block size and
+// index block size for the namespace each time the namespace is ticked so
+// that operators can validate the configuration of the namespace against
+// real traffic.
+type blockSizeAnalyzer struct {
+	sync.RWMutex
+
+	start       time.Time
+	datapoints  int64
+	queryRanges []int64
+	analysis    BlockSizeAnalysis
+}
+
+func newBlockSizeAnalyzer(start time.Time) *blockSizeAnalyzer {
+	return &blockSizeAnalyzer{
+		start:       start,
+		queryRanges: make([]int64, len(queryRangeBuckets)+1),
+	}
+}
+
+// RecordWrite tracks a datapoint written to the namespace.
+func (a *blockSizeAnalyzer) RecordWrite() {
+	atomic.AddInt64(&a.datapoints, 1)
+}
+
+// RecordQuery tracks an index query against the namespace with the given
+// time range.
+func (a *blockSizeAnalyzer) RecordQuery(queryRange time.Duration) {
+	idx := len(queryRangeBuckets)
+	for i, bound := range queryRangeBuckets {
+		if queryRange <= bound {
+			idx = i
+			break
+		}
+	}
+	atomic.AddInt64(&a.queryRanges[idx], 1)
+}
+
+// Analyze updates the recommendations of the analyzer given the number of
+// active series of the namespace and its options.
+func (a *blockSizeAnalyzer) Analyze(
+	now time.Time,
+	activeSeries int64,
+	opts namespace.Options,
+) {
+	var (
+		retention = opts.RetentionOptions().RetentionPeriod()
+		analysis  = BlockSizeAnalysis{
+			AnalyzedAt:     now,
+			BlockSize:      opts.RetentionOptions().BlockSize(),
+			IndexBlockSize: opts.IndexOptions().BlockSize(),
+			Series:         activeSeries,
+			Datapoints:     atomic.LoadInt64(&a.datapoints),
+			QueryRanges:    make([]QueryRangeBucket, 0, len(a.queryRanges)),
+		}
+	)
+	for i := range a.queryRanges {
+		var bound time.Duration
+		if i < len(queryRangeBuckets) {
+			bound = queryRangeBuckets[i]
+		}
+		count := atomic.LoadInt64(&a.queryRanges[i])
+		analysis.QueryRanges = append(analysis.QueryRanges, QueryRangeBucket{
+			UpperBound: bound,
+			Count:      count,
+		})
+		analysis.Queries += count
+	}
+
+	elapsed := now.Sub(a.start)
+	if analysis.Datapoints > 0 && activeSeries > 0 && elapsed > 0 {
+		analysis.DatapointInterval = time.Duration(
+			float64(elapsed) * float64(activeSeries) / float64(analysis.Datapoints))
+		analysis.RecommendedBlockSize = recommendBlockSize(
+			analysis.DatapointInterval, retention)
+		analysis.RecommendedIndexBlockSize = recommendIndexBlockSize(
+			analysis.RecommendedBlockSize, analysis.QueryRanges,
+			analysis.Queries, retention)
+	}
+
+	a.Lock()
+	a.analysis = analysis
+	a.Unlock()
+}
+
+// Analysis returns the latest recommendations of the analyzer.
+func (a *blockSizeAnalyzer) Analysis() BlockSizeAnalysis {
+	a.RLock()
+	v := a.analysis
+	a.RUnlock()
+	return v
+}
+
+// recommendBlockSize returns the largest candidate block size holding at
+// most the target number of datapoints per series and no greater than the
+// retention, or the smallest candidate if none do.
+func recommendBlockSize(
+	datapointInterval time.Duration,
+	retention time.Duration,
+) time.Duration {
+	target := datapointInterval * targetDatapointsPerBlock
+	result := blockSizeCandidates[0]
+	for _, candidate := range blockSizeCandidates {
+		if candidate > target || candidate > retention {
+			break
+		}
+		result = candidate
+	}
+	return result
+}
+
+// recommendIndexBlockSize returns the smallest candidate index block size
+// that is a multiple of the block size and that the time range of most
+// index queries spans at most a couple of blocks of, no greater than the
+// retention unless that is less than the block size.
+func recommendIndexBlockSize(
+	blockSize time.Duration,
+	queryRanges []QueryRangeBucket,
+	queries int64,
+	retention time.Duration,
+) time.Duration {
+	target := blockSize
+	if queryRange := queryRangesQuantile(queryRanges, queries,
+		queryRangeQuantile); queryRange/maxIndexBlocksPerQuery > target {
+		target = queryRange / maxIndexBlocksPerQuery
+	}
+	result := blockSize
+	for _, candidate := range blockSizeCandidates {
+		if candidate > retention {
+			break
+		}
+		if candidate < blockSize || candidate%blockSize != 0 {
+			continue
+		}
+		result = candidate
+		if candidate >= target {
+			break
+		}
+	}
+	return result
+}
+
+// queryRangesQuantile returns the upper bound of the bucket the quantile q
+// of the query ranges falls in, the last bound if it falls in the overflow
+// bucket and zero if there are no queries.
+func queryRangesQuantile(
+	buckets []QueryRangeBucket,
+	queries int64,
+	q float64,
+) time.Duration {
+	if queries == 0 {
+		return 0
+	}
+	var (
+		target     = q * float64(queries)
+		cumulative float64
+	)
+	for _, b := range buckets {
+		cumulative += float64(b.Count)
+		if cumulative >= target && b.UpperBound != 0 {
+			return b.UpperBound
+		}
+	}
+	return queryRangeBuckets[len(queryRangeBuckets)-1]
+}
@@ -0,0 +1,134 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newBlockSizeAnalyzerTestOptions(
+	blockSize, indexBlockSize, retentionPeriod time.Duration,
+) namespace.Options {
+	return namespace.NewOptions().
+		SetRetentionOptions(retention.NewOptions().
+			SetBlockSize(blockSize).
+			SetRetentionPeriod(retentionPeriod)).
+		SetIndexOptions(namespace.NewIndexOptions().
+			SetEnabled(true).
+			SetBlockSize(indexBlockSize))
+}
+
+func TestBlockSizeAnalyzerRecommendations(t *testing.T) {
+	var (
+		start    = time.Now().Truncate(time.Hour)
+		now      = start.Add(time.Hour)
+		series   = int64(100)
+		analyzer = newBlockSizeAnalyzer(start)
+		opts     = newBlockSizeAnalyzerTestOptions(time.Hour, time.Hour, 48*time.Hour)
+	)
+
+	// No recommendations before any datapoints are written.
+	analyzer.Analyze(now, series, opts)
+	analysis := analyzer.Analysis()
+	assert.Equal(t, now, analysis.AnalyzedAt)
+	assert.Equal(t, time.Duration(0), analysis.RecommendedBlockSize)
+	assert.Equal(t, time.Duration(0), analysis.RecommendedIndexBlockSize)
+
+	// Every series is written every ten seconds for an hour.
+	for i := 0; i < 360*int(series); i++ {
+		analyzer.RecordWrite()
+	}
+	for i := 0; i < 10; i++ {
+		analyzer.RecordQuery(time.Hour)
+		analyzer.RecordQuery(10 * time.Hour)
+	}
+
+	analyzer.Analyze(now, series, opts)
+	analysis = analyzer.Analysis()
+	assert.Equal(t, time.Hour, analysis.BlockSize)
+	assert.Equal(t, time.Hour, analysis.IndexBlockSize)
+	assert.Equal(t, series, analysis.Series)
+	assert.Equal(t, int64(36000), analysis.Datapoints)
+	assert.Equal(t, 10*time.Second, analysis.DatapointInterval)
+	assert.Equal(t, int64(20), analysis.Queries)
+	require.Equal(t, len(queryRangeBuckets)+1, len(analysis.QueryRanges))
+
+	// 720 datapoints per series fit in two hours and most queries span up
+	// to twelve hours so span at most two blocks of six hours.
+	assert.Equal(t, 2*time.Hour, analysis.RecommendedBlockSize)
+	assert.Equal(t, 6*time.Hour, analysis.RecommendedIndexBlockSize)
+}
+
+func TestRecommendBlockSize(t *testing.T) {
+	tests := []struct {
+		interval  time.Duration
+		retention time.Duration
+		expected  time.Duration
+	}{
+		{interval: time.Second, retention: 48 * time.Hour, expected: 15 * time.Minute},
+		{interval: 10 * time.Second, retention: 48 * time.Hour, expected: 2 * time.Hour},
+		{interval: time.Minute, retention: 48 * time.Hour, expected: 12 * time.Hour},
+		{interval: time.Minute, retention: 6 * time.Hour, expected: 6 * time.Hour},
+		{interval: 5 * time.Minute, retention: 720 * time.Hour, expected: 48 * time.Hour},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.expected,
+			recommendBlockSize(test.interval, test.retention))
+	}
+}
+
+func TestRecommendIndexBlockSize(t *testing.T) {
+	queryRanges := func(bound time.Duration, count int64) []QueryRangeBucket {
+		buckets := make([]QueryRangeBucket, len(queryRangeBuckets)+1)
+		for i, b := range queryRangeBuckets {
+			buckets[i].UpperBound = b
+		}
+		for i := range buckets {
+			if buckets[i].UpperBound == bound {
+				buckets[i].Count = count
+			}
+		}
+		return buckets
+	}
+
+	// Without queries the index block size matches the block size.
+	assert.Equal(t, 2*time.Hour, recommendIndexBlockSize(2*time.Hour,
+		queryRanges(0, 0), 0, 48*time.Hour))
+
+	// Index block sizes are multiples of the block size.
+	assert.Equal(t, 12*time.Hour, recommendIndexBlockSize(4*time.Hour,
+		queryRanges(12*time.Hour, 10), 10, 48*time.Hour))
+
+	// Index block sizes are capped by the retention.
+	assert.Equal(t, 12*time.Hour, recommendIndexBlockSize(2*time.Hour,
+		queryRanges(168*time.Hour, 10), 10, 12*time.Hour))
+
+	// Queries with ranges past the last bound count as the last bound.
+	assert.Equal(t, 168*time.Hour, recommendIndexBlockSize(2*time.Hour,
+		queryRanges(0, 10), 10, 720*time.Hour))
+}
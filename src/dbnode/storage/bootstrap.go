@@ -27,6 +27,8 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/notify"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/instrument"
@@ -190,6 +192,14 @@ func (m *bootstrapManager) Bootstrap() (BootstrapResult, error) {
 	// across the cluster.
 
 	m.lastBootstrapCompletionTime = m.nowFn()
+	m.opts.Notifier().Notify(notify.Event{
+		Type:    notify.EventTypeBootstrapComplete,
+		Time:    m.lastBootstrapCompletionTime,
+		Message: "bootstrap complete",
+		Fields: map[string]interface{}{
+			"numErrors": len(result.ErrorsBootstrap),
+		},
+	})
 	return result, nil
 }
 
@@ -316,11 +326,18 @@ func (m *bootstrapManager) bootstrap() error {
 		accumulator := NewDatabaseNamespaceDataAccumulator(ns.namespace)
 		accmulators = append(accmulators, accumulator)
 
+		md := ns.namespace.Metadata()
+		var shardFn sharding.HashFn
+		if md.Options().ReshardOptions().Enabled {
+			shardFn = ns.namespace.ShardSet().HashFn()
+		}
+
 		targets = append(targets, bootstrap.ProcessNamespace{
-			Metadata:        ns.namespace.Metadata(),
+			Metadata:        md,
 			Shards:          bootstrapShards,
 			Hooks:           hooks,
 			DataAccumulator: accumulator,
+			ShardFn:         shardFn,
 		})
 	}
 
@@ -33,6 +33,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/series"
@@ -81,6 +82,7 @@ type bootstrapNamespace struct {
 	namespaceContext        namespace.Context
 	dataBlockSize           time.Duration
 	accumulator             bootstrap.NamespaceDataAccumulator
+	shardFn                 sharding.HashFn
 }
 
 type seriesMap map[seriesMapKey]*seriesMapEntry
@@ -242,12 +244,20 @@ func (s *commitLogSource) Read(
 		blockSize := ns.Metadata.Options().RetentionOptions().BlockSize()
 		for shard, tr := range shardTimeRanges {
 			err := s.bootstrapShardSnapshots(
-				ns.Metadata, accumulator, shard, tr, blockSize,
+				ns.Metadata, accumulator, shard, shard, tr, blockSize,
 				mostRecentCompleteSnapshotByBlockShard)
 			if err != nil {
 				return bootstrap.NamespaceResults{}, err
 			}
 		}
+
+		if ns.ShardFn != nil {
+			err := s.bootstrapParentShardSnapshots(
+				ns.Metadata, accumulator, shardTimeRanges, blockSize)
+			if err != nil {
+				return bootstrap.NamespaceResults{}, err
+			}
+		}
 	}
 
 	s.log.Info("read snapshots done",
@@ -410,6 +420,7 @@ func (s *commitLogSource) Read(
 						namespaceContext:        namespace.NewContextFrom(nsMetadata),
 						dataBlockSize:           nsMetadata.Options().RetentionOptions().BlockSize(),
 						accumulator:             nsResult.namespace.DataAccumulator,
+						shardFn:                 nsResult.namespace.ShardFn,
 					}
 				}
 				// Append for quick re-lookup with other series.
@@ -450,11 +461,18 @@ func (s *commitLogSource) Read(
 					tagIter = ident.EmptyTagIterator
 				}
 
+				// Entries written before the shard space of the namespace was
+				// split are recorded against the parent shard of the series.
+				shard := entry.Series.Shard
+				if ns.shardFn != nil {
+					shard = ns.shardFn(entry.Series.ID)
+				}
+
 				// Check out the series for writing, no need for concurrency
 				// as commit log bootstrapper does not perform parallel
 				// checking out of series.
 				series, owned, err := accumulator.CheckoutSeriesWithoutLock(
-					entry.Series.Shard,
+					shard,
 					entry.Series.ID,
 					tagIter)
 				if err != nil {
@@ -674,10 +692,53 @@ func (s *commitLogSource) mostRecentCompleteSnapshotByBlockShard(
 	return mostRecentSnapshotsByBlockShard
 }
 
+// bootstrapParentShardSnapshots reads the snapshots of the parent shards of
+// the shards that have not yet been split from their parent shard, the
+// series of the snapshots that belong to other children of the parent shard
+// are not owned by the shards and skipped.
+func (s *commitLogSource) bootstrapParentShardSnapshots(
+	ns namespace.Metadata,
+	accumulator bootstrap.NamespaceDataAccumulator,
+	shardsTimeRanges result.ShardTimeRanges,
+	blockSize time.Duration,
+) error {
+	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	for shard, tr := range shardsTimeRanges {
+		parent, ok, err := fs.ShardSplitParent(filePathPrefix, ns, shard)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		parentTimeRanges := result.ShardTimeRanges{parent: tr}
+		snapshotFilesByShard, err := s.snapshotFilesByShard(
+			ns.ID(), filePathPrefix, parentTimeRanges)
+		if err != nil {
+			return err
+		}
+		mostRecentCompleteSnapshotByBlockShard, err := s.mostRecentSnapshotByBlockShard(
+			ns, parentTimeRanges, snapshotFilesByShard)
+		if err != nil {
+			return err
+		}
+		if err := s.bootstrapShardSnapshots(
+			ns, accumulator, shard, parent, tr, blockSize,
+			mostRecentCompleteSnapshotByBlockShard); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bootstrapShardSnapshots reads the most recent snapshots of the snapshot
+// shard into the shard.
 func (s *commitLogSource) bootstrapShardSnapshots(
 	ns namespace.Metadata,
 	accumulator bootstrap.NamespaceDataAccumulator,
 	shard uint32,
+	snapshotShard uint32,
 	shardTimeRanges xtime.Ranges,
 	blockSize time.Duration,
 	mostRecentCompleteSnapshotByBlockShard map[xtime.UnixNano]map[uint32]fs.FileSetFile,
@@ -699,7 +760,7 @@ func (s *commitLogSource) bootstrapShardSnapshots(
 
 		for blockStart := currRange.Start.Truncate(blockSize); blockStart.Before(currRange.End); blockStart = blockStart.Add(blockSize) {
 			snapshotsForBlock := mostRecentCompleteSnapshotByBlockShard[xtime.ToUnixNano(blockStart)]
-			mostRecentCompleteSnapshotForShardBlock := snapshotsForBlock[shard]
+			mostRecentCompleteSnapshotForShardBlock := snapshotsForBlock[snapshotShard]
 
 			if mostRecentCompleteSnapshotForShardBlock.CachedSnapshotTime.Equal(blockStart) ||
 				// Should never happen
@@ -710,12 +771,12 @@ func (s *commitLogSource) bootstrapShardSnapshots(
 				// for the fact that this snapshot did not exist when we were deciding which
 				// commit logs to read.
 				s.log.Debug("no snapshots for shard and blockStart",
-					zap.Uint32("shard", shard), zap.Time("blockStart", blockStart))
+					zap.Uint32("shard", snapshotShard), zap.Time("blockStart", blockStart))
 				continue
 			}
 
 			if err := s.bootstrapShardBlockSnapshot(
-				ns, accumulator, shard, blockStart, blockSize,
+				ns, accumulator, shard, snapshotShard, blockStart, blockSize,
 				mostRecentCompleteSnapshotForShardBlock); err != nil {
 				return err
 			}
@@ -729,6 +790,7 @@ func (s *commitLogSource) bootstrapShardBlockSnapshot(
 	ns namespace.Metadata,
 	accumulator bootstrap.NamespaceDataAccumulator,
 	shard uint32,
+	snapshotShard uint32,
 	blockStart time.Time,
 	blockSize time.Duration,
 	mostRecentCompleteSnapshot fs.FileSetFile,
@@ -752,7 +814,7 @@ func (s *commitLogSource) bootstrapShardBlockSnapshot(
 		Identifier: fs.FileSetFileIdentifier{
 			Namespace:   ns.ID(),
 			BlockStart:  blockStart,
-			Shard:       shard,
+			Shard:       snapshotShard,
 			VolumeIndex: mostRecentCompleteSnapshot.ID.VolumeIndex,
 		},
 		FileSetType: persist.FileSetSnapshotType,
@@ -764,7 +826,7 @@ func (s *commitLogSource) bootstrapShardBlockSnapshot(
 		err := reader.Close()
 		if err != nil {
 			s.log.Error("error closing reader for shard",
-				zap.Uint32("shard", shard),
+				zap.Uint32("shard", snapshotShard),
 				zap.Time("blockStart", blockStart),
 				zap.Int("volume", mostRecentCompleteSnapshot.ID.VolumeIndex),
 				zap.Error(err))
@@ -772,7 +834,7 @@ func (s *commitLogSource) bootstrapShardBlockSnapshot(
 	}()
 
 	s.log.Debug("reading snapshot for shard",
-		zap.Uint32("shard", shard),
+		zap.Uint32("shard", snapshotShard),
 		zap.Time("blockStart", blockStart),
 		zap.Int("volume", mostRecentCompleteSnapshot.ID.VolumeIndex))
 
@@ -804,6 +866,8 @@ func (s *commitLogSource) bootstrapShardBlockSnapshot(
 		if err != nil {
 			if !owned {
 				// Skip bootstrapping this series if we don't own it.
+				id.Finalize()
+				tags.Close()
 				continue
 			}
 			return err
@@ -893,7 +957,7 @@ func (s *commitLogSource) startAccumulateWorker(worker *accumulateWorker) {
 		)
 		worker.datapointsRead++
 
-		_, err := entry.Series.Write(ctx, dp.Timestamp, dp.Value,
+		_, _, err := entry.Series.Write(ctx, dp.Timestamp, dp.Value,
 			unit, annotation, series.WriteOptions{
 				SchemaDesc: namespace.namespaceContext.Schema,
 				// NB(r): Make sure this is the series we originally
@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/bootstrapper"
@@ -156,6 +157,7 @@ func (s *fileSystemSource) Read(
 		md := namespace.Metadata
 
 		r, err := s.read(bootstrapDataRunType, md, namespace.DataAccumulator,
+			namespace.ShardFn,
 			namespace.DataRunOptions.ShardTimeRanges,
 			namespace.DataRunOptions.RunOptions)
 		if err != nil {
@@ -184,6 +186,7 @@ func (s *fileSystemSource) Read(
 		}
 
 		r, err := s.read(bootstrapIndexRunType, md, namespace.DataAccumulator,
+			namespace.ShardFn,
 			namespace.IndexRunOptions.ShardTimeRanges,
 			namespace.IndexRunOptions.RunOptions)
 		if err != nil {
@@ -212,13 +215,13 @@ func (s *fileSystemSource) availability(
 ) (result.ShardTimeRanges, error) {
 	result := make(map[uint32]xtime.Ranges, len(shardsTimeRanges))
 	for shard, ranges := range shardsTimeRanges {
-		result[shard] = s.shardAvailability(md.ID(), shard, ranges)
+		result[shard] = s.shardAvailability(md, shard, ranges)
 	}
 	return result, nil
 }
 
 func (s *fileSystemSource) shardAvailability(
-	namespace ident.ID,
+	md namespace.Metadata,
 	shard uint32,
 	targetRangesForShard xtime.Ranges,
 ) xtime.Ranges {
@@ -227,7 +230,20 @@ func (s *fileSystemSource) shardAvailability(
 	}
 
 	readInfoFilesResults := fs.ReadInfoFiles(s.fsopts.FilePathPrefix(),
-		namespace, shard, s.fsopts.InfoReaderBufferSize(), s.fsopts.DecodingOptions())
+		md.ID(), shard, s.fsopts.InfoReaderBufferSize(), s.fsopts.DecodingOptions())
+
+	// A shard that has not yet been split from its parent shard is available
+	// for the blocks it reads from the filesets of its parent shard.
+	_, parentResults, err := fs.ReadSplitSourceInfoFiles(s.fsopts.FilePathPrefix(),
+		md, shard, s.fsopts.InfoReaderBufferSize(), s.fsopts.DecodingOptions())
+	if err != nil {
+		s.log.Error("unable to read info files of parent shard in shardAvailability",
+			zap.Uint32("shard", shard),
+			zap.Stringer("namespace", md.ID()),
+			zap.Error(err),
+		)
+	}
+	readInfoFilesResults = append(readInfoFilesResults, parentResults...)
 
 	var tr xtime.Ranges
 	for i := 0; i < len(readInfoFilesResults); i++ {
@@ -235,7 +251,7 @@ func (s *fileSystemSource) shardAvailability(
 		if err := result.Err.Error(); err != nil {
 			s.log.Error("unable to read info files in shardAvailability",
 				zap.Uint32("shard", shard),
-				zap.Stringer("namespace", namespace),
+				zap.Stringer("namespace", md.ID()),
 				zap.Error(err),
 				zap.Any("targetRangesForShard", targetRangesForShard),
 				zap.String("filepath", result.Err.Filepath()),
@@ -257,6 +273,7 @@ func (s *fileSystemSource) bootstrapFromReaders(
 	run runType,
 	ns namespace.Metadata,
 	accumulator bootstrap.NamespaceDataAccumulator,
+	shardFn sharding.HashFn,
 	runOpts bootstrap.RunOptions,
 	readerPool *bootstrapper.ReaderPool,
 	readersCh <-chan bootstrapper.TimeWindowReaders,
@@ -271,7 +288,7 @@ func (s *fileSystemSource) bootstrapFromReaders(
 		// it is not thread safe and requires reset after every processed index block.
 		s.builder.Builder().Reset(0)
 
-		s.loadShardReadersDataIntoShardResult(run, ns, accumulator,
+		s.loadShardReadersDataIntoShardResult(run, ns, accumulator, shardFn,
 			runOpts, runResult, resultOpts, timeWindowReaders, readerPool)
 	}
 
@@ -320,6 +337,7 @@ func (s *fileSystemSource) loadShardReadersDataIntoShardResult(
 	run runType,
 	ns namespace.Metadata,
 	accumulator bootstrap.NamespaceDataAccumulator,
+	shardFn sharding.HashFn,
 	runOpts bootstrap.RunOptions,
 	runResult *runResult,
 	ropts result.Options,
@@ -370,7 +388,7 @@ func (s *fileSystemSource) loadShardReadersDataIntoShardResult(
 						runResult, start, blockSize, blockPool, seriesCachePolicy)
 				case bootstrapIndexRunType:
 					// We can just read the entry and index if performing an index run.
-					batch, err = s.readNextEntryAndMaybeIndex(r, batch)
+					batch, err = s.readNextEntryAndMaybeIndex(r, shard, shardFn, batch)
 					if err != nil {
 						s.log.Error("readNextEntryAndMaybeIndex failed",
 							zap.String("error", err.Error()),
@@ -604,6 +622,8 @@ func (s *fileSystemSource) readNextEntryAndRecordBlock(
 
 func (s *fileSystemSource) readNextEntryAndMaybeIndex(
 	r fs.DataFileSetReader,
+	shard uint32,
+	shardFn sharding.HashFn,
 	batch []doc.Document,
 ) ([]doc.Document, error) {
 	// If performing index run, then simply read the metadata and add to segment.
@@ -612,6 +632,14 @@ func (s *fileSystemSource) readNextEntryAndMaybeIndex(
 		return batch, err
 	}
 
+	if shardFn != nil && shardFn(id) != shard {
+		// Series read from the filesets of a parent shard that belong to
+		// another child of the parent shard are indexed by that child.
+		id.Finalize()
+		tagsIter.Close()
+		return batch, nil
+	}
+
 	d, err := convert.FromMetricIter(id, tagsIter)
 	// Finalize the ID and tags.
 	id.Finalize()
@@ -633,6 +661,7 @@ func (s *fileSystemSource) read(
 	run runType,
 	md namespace.Metadata,
 	accumulator bootstrap.NamespaceDataAccumulator,
+	shardFn sharding.HashFn,
 	shardsTimeRanges result.ShardTimeRanges,
 	runOpts bootstrap.RunOptions,
 ) (*runResult, error) {
@@ -698,7 +727,7 @@ func (s *fileSystemSource) read(
 	go bootstrapper.EnqueueReaders(md, runOpts, runtimeOpts, s.fsopts, shardsTimeRanges,
 		readerPool, readersCh, blockSize, s.log)
 	bootstrapFromDataReadersResult := s.bootstrapFromReaders(run, md,
-		accumulator, runOpts, readerPool, readersCh)
+		accumulator, shardFn, runOpts, readerPool, readersCh)
 
 	// Merge any existing results if necessary.
 	setOrMergeResult(bootstrapFromDataReadersResult)
@@ -721,7 +750,7 @@ func (s *fileSystemSource) bootstrapDataRunResultFromAvailability(
 		if ranges.IsEmpty() {
 			continue
 		}
-		availability := s.shardAvailability(md.ID(), shard, ranges)
+		availability := s.shardAvailability(md, shard, ranges)
 		remaining := ranges.RemoveRanges(availability)
 		if !remaining.IsEmpty() {
 			unfulfilled.AddRanges(result.ShardTimeRanges{
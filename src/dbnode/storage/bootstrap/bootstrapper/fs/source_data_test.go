@@ -243,14 +243,27 @@ func writeTSDBFiles(
 	shard uint32,
 	start time.Time,
 	series []testSeries,
+) {
+	writeTSDBFilesWithVolume(t, dir, namespace, shard, start, 0, series)
+}
+
+func writeTSDBFilesWithVolume(
+	t require.TestingT,
+	dir string,
+	namespace ident.ID,
+	shard uint32,
+	start time.Time,
+	volume int,
+	series []testSeries,
 ) {
 	w, err := fs.NewWriter(newTestFsOptions(dir))
 	require.NoError(t, err)
 	writerOpts := fs.DataWriterOpenOptions{
 		Identifier: fs.FileSetFileIdentifier{
-			Namespace:  namespace,
-			Shard:      shard,
-			BlockStart: start,
+			Namespace:   namespace,
+			Shard:       shard,
+			BlockStart:  start,
+			VolumeIndex: volume,
 		},
 		BlockSize: testBlockSize,
 	}
@@ -582,6 +595,41 @@ func TestReadTimeFilter(t *testing.T) {
 	validateReadResults(t, src, dir, testShardTimeRanges())
 }
 
+func TestReadLatestVolume(t *testing.T) {
+	dir := createTempDir(t)
+	defer os.RemoveAll(dir)
+
+	writeTSDBFilesWithVolume(t, dir, testNs1ID, testShard, testStart, 0,
+		[]testSeries{{"foo", nil, []byte{1, 2, 3}}})
+	writeTSDBFilesWithVolume(t, dir, testNs1ID, testShard, testStart, 1,
+		[]testSeries{{"foo", nil, []byte{4, 5, 6}}})
+
+	src, err := newFileSystemSource(newTestOptions(t, dir))
+	require.NoError(t, err)
+
+	nsMD := testNsMetadata(t)
+	tester := bootstrap.BuildNamespacesTester(t, testDefaultRunOpts,
+		testShardTimeRanges(), nsMD)
+	defer tester.Finish()
+
+	tester.TestReadWith(src)
+	readers := tester.EnsureDumpReadersForNamespace(nsMD)
+	require.Equal(t, 1, len(readers))
+
+	// Only the latest volume of the block is read.
+	seriesReaders, ok := readers["foo"]
+	require.True(t, ok)
+	require.Equal(t, 1, len(seriesReaders))
+	assert.Equal(t, testStart, seriesReaders[0].Start)
+
+	var b [100]byte
+	n, err := seriesReaders[0].Reader.Read(b[:])
+	require.NoError(t, err)
+	require.Equal(t, []byte{4, 5, 6}, b[:n])
+
+	tester.EnsureNoWrites()
+}
+
 func TestReadPartialError(t *testing.T) {
 	dir := createTempDir(t)
 	defer os.RemoveAll(dir)
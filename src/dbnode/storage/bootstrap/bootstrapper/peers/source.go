@@ -509,19 +509,24 @@ func (s *peersSource) flush(
 	)
 
 	for start := tr.Start; start.Before(tr.End); start = start.Add(blockSize) {
+		// Persist the peer bootstrapped block as the next volume of the block
+		// so it supersedes any volume already on disk, e.g. one that the
+		// filesystem bootstrapper was unable to read. The shard bootstraps its
+		// cold version from the latest volume and older volumes are cleaned up
+		// as compacted filesets, so the versions stay monotonically increasing
+		// with later cold flushes and repairs.
+		volumeIndex, err := fs.NextDataFileSetVolumeIndex(
+			s.opts.FilesystemOptions().FilePathPrefix(), nsMetadata.ID(), shard, start)
+		if err != nil {
+			return err
+		}
+
 		prepareOpts := persist.DataPrepareOptions{
 			NamespaceMetadata: nsMetadata,
 			FileSetType:       persistConfig.FileSetType,
 			Shard:             shard,
 			BlockStart:        start,
-			// When bootstrapping, the volume index will always be 0. However,
-			// if we want to be able to snapshot and flush while bootstrapping,
-			// this may not be the case, e.g. if a flush occurs before a
-			// bootstrap, then the bootstrap volume index will be >0. In order
-			// to support this, bootstrapping code will need to incorporate
-			// merging logic and flush version/volume index will need to be
-			// synchronized between processes.
-			VolumeIndex: 0,
+			VolumeIndex:       volumeIndex,
 			// If we've peer bootstrapped this shard/block combination AND the fileset
 			// already exists on disk, then that means either:
 			// 1) The Filesystem bootstrapper was unable to bootstrap the fileset
@@ -26,6 +26,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/persist/schema"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -113,17 +114,73 @@ func newShardReaders(
 ) ShardReaders {
 	readInfoFilesResults := fs.ReadInfoFiles(fsOpts.FilePathPrefix(),
 		ns.ID(), shard, fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
-	if len(readInfoFilesResults) == 0 {
+	readers := newVolumeReaders(ns, readerPool, shard, shard, tr,
+		readInfoFilesResults, logger)
+
+	// A shard that has not yet been split from its parent shard reads the
+	// blocks it has no fileset of its own for from the filesets of its parent
+	// shard, the series of the other children of the parent shard are not
+	// owned by the shard and skipped by the accumulator.
+	parent, parentResults, err := fs.ReadSplitSourceInfoFiles(fsOpts.FilePathPrefix(),
+		ns, shard, fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	if err != nil {
+		logger.Error("fs bootstrapper unable to read info files of parent shard",
+			zap.Uint32("shard", shard),
+			zap.Stringer("namespace", ns.ID()),
+			zap.Error(err),
+		)
+		// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
+		// and will be re-attempted by the next bootstrapper.
+	}
+	readers = append(readers, newVolumeReaders(ns, readerPool, shard, parent, tr,
+		parentResults, logger)...)
+
+	if len(readers) == 0 {
 		// No readers.
 		return ShardReaders{}
 	}
+	return ShardReaders{Readers: readers}
+}
 
-	readers := make([]fs.DataFileSetReader, 0, len(readInfoFilesResults))
+// newVolumeReaders opens readers for the latest volume of each block of the
+// filesets of the fileset shard that overlaps the time ranges of the shard.
+func newVolumeReaders(
+	ns namespace.Metadata,
+	readerPool *ReaderPool,
+	shard uint32,
+	fileSetShard uint32,
+	tr xtime.Ranges,
+	readInfoFilesResults []fs.ReadInfoFileResult,
+	logger *zap.Logger,
+) []fs.DataFileSetReader {
+	if len(readInfoFilesResults) == 0 {
+		return nil
+	}
+
+	// Each volume of a block supersedes all lower volumes of the block so
+	// only the latest volume of each block is read. A compacted fileset holds
+	// every block of its compacted block and supersedes their filesets.
+	blockSize := ns.Options().RetentionOptions().BlockSize()
+	latestVolumes := make(map[int64]int, len(readInfoFilesResults))
+	for _, result := range readInfoFilesResults {
+		if result.Err.Error() != nil {
+			continue
+		}
+		for _, blockStart := range fileSetBlockStarts(result.Info, blockSize) {
+			volume, ok := latestVolumes[blockStart]
+			if !ok || result.Info.VolumeIndex > volume {
+				latestVolumes[blockStart] = result.Info.VolumeIndex
+			}
+		}
+	}
+
+	readers := make([]fs.DataFileSetReader, 0, len(latestVolumes))
 	for i := 0; i < len(readInfoFilesResults); i++ {
 		result := readInfoFilesResults[i]
 		if err := result.Err.Error(); err != nil {
 			logger.Error("fs bootstrapper unable to read info file",
 				zap.Uint32("shard", shard),
+				zap.Uint32("fileSetShard", fileSetShard),
 				zap.Stringer("namespace", ns.ID()),
 				zap.Error(err),
 				zap.String("timeRange", tr.String()),
@@ -135,47 +192,79 @@ func newShardReaders(
 		}
 
 		info := result.Info
-		blockStart := xtime.FromNanoseconds(info.BlockStart)
-		if !tr.Overlaps(xtime.Range{
-			Start: blockStart,
-			End:   blockStart.Add(ns.Options().RetentionOptions().BlockSize()),
-		}) {
-			// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
-			// and will be re-attempted by the next bootstrapper.
-			continue
-		}
+		fileSetStart := xtime.FromNanoseconds(info.BlockStart)
+		compacted := time.Duration(info.BlockSize) > blockSize
+		for _, start := range fileSetBlockStarts(info, blockSize) {
+			if info.VolumeIndex != latestVolumes[start] {
+				// Superseded by a later volume of the same block.
+				continue
+			}
 
-		r, err := readerPool.Get()
-		if err != nil {
-			logger.Error("unable to get reader from pool")
-			// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
-			// and will be re-attempted by the next bootstrapper.
-			continue
-		}
+			blockStart := xtime.FromNanoseconds(start)
+			block := xtime.Range{
+				Start: blockStart,
+				End:   blockStart.Add(blockSize),
+			}
+			if !tr.Overlaps(block) {
+				// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
+				// and will be re-attempted by the next bootstrapper.
+				continue
+			}
 
-		openOpts := fs.DataReaderOpenOptions{
-			Identifier: fs.FileSetFileIdentifier{
-				Namespace:  ns.ID(),
-				Shard:      shard,
-				BlockStart: blockStart,
-			},
-		}
-		if err := r.Open(openOpts); err != nil {
-			logger.Error("unable to open fileset files",
-				zap.Uint32("shard", shard),
-				zap.Time("blockStart", blockStart),
-				zap.Error(err),
-			)
-			readerPool.Put(r)
-			// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
-			// and will be re-attempted by the next bootstrapper.
-			continue
-		}
+			r, err := readerPool.Get()
+			if err != nil {
+				logger.Error("unable to get reader from pool")
+				// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
+				// and will be re-attempted by the next bootstrapper.
+				continue
+			}
 
-		readers = append(readers, r)
+			openOpts := fs.DataReaderOpenOptions{
+				Identifier: fs.FileSetFileIdentifier{
+					Namespace:   ns.ID(),
+					Shard:       fileSetShard,
+					BlockStart:  fileSetStart,
+					VolumeIndex: info.VolumeIndex,
+				},
+			}
+			if compacted {
+				// Each block of a compacted fileset is read by its own reader
+				// as if it was the fileset of the block.
+				openOpts.Block = block
+			}
+			if err := r.Open(openOpts); err != nil {
+				logger.Error("unable to open fileset files",
+					zap.Uint32("shard", shard),
+					zap.Uint32("fileSetShard", fileSetShard),
+					zap.Time("blockStart", blockStart),
+					zap.Error(err),
+				)
+				readerPool.Put(r)
+				// Errors are marked unfulfilled by markRunResultErrorsAndUnfulfilled
+				// and will be re-attempted by the next bootstrapper.
+				continue
+			}
+
+			readers = append(readers, r)
+		}
 	}
 
-	return ShardReaders{Readers: readers}
+	return readers
+}
+
+// fileSetBlockStarts returns the starts of the blocks the data of a fileset
+// is for, which are all the blocks of its compacted block for a compacted
+// fileset.
+func fileSetBlockStarts(info schema.IndexInfo, blockSize time.Duration) []int64 {
+	fileSetSize := time.Duration(info.BlockSize)
+	if fileSetSize <= blockSize {
+		return []int64{info.BlockStart}
+	}
+	starts := make([]int64, 0, int(fileSetSize/blockSize))
+	for offset := time.Duration(0); offset < fileSetSize; offset += blockSize {
+		starts = append(starts, info.BlockStart+int64(offset))
+	}
+	return starts
 }
 
 // ReaderPool is a lean pool that does not allocate
@@ -171,6 +171,7 @@ func (b bootstrapProcess) Run(
 			Shards:           namespace.Shards,
 			DataAccumulator:  namespace.DataAccumulator,
 			Hooks:            namespace.Hooks,
+			ShardFn:          namespace.ShardFn,
 			DataTargetRange:  dataRanges.firstRangeWithPersistTrue,
 			IndexTargetRange: indexRanges.firstRangeWithPersistTrue,
 			DataRunOptions: NamespaceRunOptions{
@@ -189,6 +190,7 @@ func (b bootstrapProcess) Run(
 			Shards:           namespace.Shards,
 			DataAccumulator:  namespace.DataAccumulator,
 			Hooks:            namespace.Hooks,
+			ShardFn:          namespace.ShardFn,
 			DataTargetRange:  dataRanges.secondRangeWithPersistFalse,
 			IndexTargetRange: indexRanges.secondRangeWithPersistFalse,
 			DataRunOptions: NamespaceRunOptions{
@@ -408,6 +410,7 @@ func NewNamespaces(
 			Metadata:        ns.Metadata,
 			Shards:          ns.Shards,
 			DataAccumulator: ns.DataAccumulator,
+			ShardFn:         ns.ShardFn,
 		})
 	}
 	return Namespaces{
@@ -26,6 +26,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/topology"
@@ -67,6 +68,10 @@ type ProcessNamespace struct {
 	DataAccumulator NamespaceDataAccumulator
 	// Hooks is a set of namespace bootstrap hooks.
 	Hooks NamespaceHooks
+	// ShardFn is the function assigning series to the shards of the
+	// namespace, if the shard space of the namespace has been split it is
+	// used to assign series read from its parent shards to the shards.
+	ShardFn sharding.HashFn
 }
 
 // NamespaceHooks is a set of namespace bootstrap hooks.
@@ -199,6 +204,10 @@ type Namespace struct {
 	DataAccumulator NamespaceDataAccumulator
 	// Hooks is a set of namespace bootstrap hooks.
 	Hooks NamespaceHooks
+	// ShardFn is the function assigning series to the shards of the
+	// namespace, if the shard space of the namespace has been split it is
+	// used to assign series read from its parent shards to the shards.
+	ShardFn sharding.HashFn
 	// DataTargetRange is the data target bootstrap range.
 	DataTargetRange TargetRange
 	// IndexTargetRange is the index target bootstrap range.
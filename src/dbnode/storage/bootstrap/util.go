@@ -266,7 +266,7 @@ func (a *TestDataAccumulator) checkoutSeriesWithLock(
 				unit xtime.Unit,
 				annotation []byte,
 				_ series.WriteOptions,
-			) (bool, error) {
+			) (bool, series.WriteDisposition, error) {
 				a.Lock()
 				a.writeMap[stringID] = append(
 					a.writeMap[stringID], series.DecodedTestValue{
@@ -276,7 +276,7 @@ func (a *TestDataAccumulator) checkoutSeriesWithLock(
 						Annotation: annotation,
 					})
 				a.Unlock()
-				return true, nil
+				return true, series.WriteAccepted, nil
 			}).AnyTimes()
 
 	result := CheckoutSeriesResult{
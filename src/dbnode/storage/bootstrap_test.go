@@ -27,6 +27,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/x/ident"
 
@@ -40,7 +41,8 @@ func TestDatabaseBootstrapWithBootstrapError(t *testing.T) {
 	ctrl := gomock.NewController(xtest.Reporter{T: t})
 	defer ctrl.Finish()
 
-	opts := DefaultTestOptions()
+	notifier := &testNotifier{}
+	opts := DefaultTestOptions().SetNotifier(notifier)
 	now := time.Now()
 	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
 		return now
@@ -84,6 +86,31 @@ func TestDatabaseBootstrapWithBootstrapError(t *testing.T) {
 
 	require.Equal(t, 1, len(result.ErrorsBootstrap))
 	require.Equal(t, "an error", result.ErrorsBootstrap[0].Error())
+
+	events := notifier.received()
+	require.Equal(t, 1, len(events))
+	require.Equal(t, notify.EventTypeBootstrapComplete, events[0].Type)
+	require.Equal(t, now, events[0].Time)
+	require.Equal(t, 1, events[0].Fields["numErrors"])
+}
+
+type testNotifier struct {
+	sync.Mutex
+	events []notify.Event
+}
+
+func (n *testNotifier) Notify(event notify.Event) {
+	n.Lock()
+	n.events = append(n.events, event)
+	n.Unlock()
+}
+
+func (n *testNotifier) Close() error { return nil }
+
+func (n *testNotifier) received() []notify.Event {
+	n.Lock()
+	defer n.Unlock()
+	return append([]notify.Event(nil), n.events...)
 }
 
 func TestDatabaseBootstrapSubsequentCallsQueued(t *testing.T) {
@@ -27,18 +27,25 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/pborman/uuid"
 	"github.com/uber-go/tally"
 	"go.uber.org/zap"
 )
 
+// staleStagingDirAge is how long a fileset staging directory must have gone
+// unmodified before it is considered left behind by a crashed write and is
+// deleted, writes may be in progress concurrently with the cleanup.
+const staleStagingDirAge = time.Hour
+
 type commitLogFilesFn func(commitlog.Options) (persist.CommitLogFiles, []commitlog.ErrorWithPath, error)
 type snapshotMetadataFilesFn func(fs.Options) ([]fs.SnapshotMetadata, []fs.SnapshotMetadataErrorWithPaths, error)
 
@@ -67,6 +74,7 @@ type cleanupManager struct {
 	commitLogFilesFn        commitLogFilesFn
 	snapshotMetadataFilesFn snapshotMetadataFilesFn
 	snapshotFilesFn         snapshotFilesFn
+	newSnapshotCompactorFn  fs.NewSnapshotCompactorFn
 
 	deleteFilesFn               deleteFilesFn
 	deleteInactiveDirectoriesFn deleteInactiveDirectoriesFn
@@ -82,12 +90,16 @@ type cleanupManagerMetrics struct {
 	deletedCommitlogFile        tally.Counter
 	deletedSnapshotFile         tally.Counter
 	deletedSnapshotMetadataFile tally.Counter
+	compactedSnapshotFile       tally.Counter
+	compactSnapshotErrors       tally.Counter
+	deletedStagingDir           tally.Counter
 }
 
 func newCleanupManagerMetrics(scope tally.Scope) cleanupManagerMetrics {
 	clScope := scope.SubScope("commitlog")
 	sScope := scope.SubScope("snapshot")
 	smScope := scope.SubScope("snapshot-metadata")
+	stScope := scope.SubScope("staging")
 	return cleanupManagerMetrics{
 		status:                      scope.Gauge("cleanup"),
 		corruptCommitlogFile:        clScope.Counter("corrupt"),
@@ -96,6 +108,9 @@ func newCleanupManagerMetrics(scope tally.Scope) cleanupManagerMetrics {
 		deletedCommitlogFile:        clScope.Counter("deleted"),
 		deletedSnapshotFile:         sScope.Counter("deleted"),
 		deletedSnapshotMetadataFile: smScope.Counter("deleted"),
+		compactedSnapshotFile:       sScope.Counter("compacted"),
+		compactSnapshotErrors:       sScope.Counter("compact-errors"),
+		deletedStagingDir:           stScope.Counter("deleted"),
 	}
 }
 
@@ -105,6 +120,8 @@ func newCleanupManager(
 	filePathPrefix := opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 	commitLogsDir := fs.CommitLogsDirPath(filePathPrefix)
 
+	deleteFilesFn := newPurgeRecordingDeleteFilesFn(opts.PurgeReporter(),
+		purgedCommitLogOrSnapshot(commitLogsDir), fs.DeleteFiles)
 	return &cleanupManager{
 		database:         database,
 		activeCommitlogs: activeLogs,
@@ -116,7 +133,8 @@ func newCleanupManager(
 		commitLogFilesFn:            commitlog.Files,
 		snapshotMetadataFilesFn:     fs.SortedSnapshotMetadataFiles,
 		snapshotFilesFn:             fs.SnapshotFiles,
-		deleteFilesFn:               fs.DeleteFiles,
+		newSnapshotCompactorFn:      fs.NewSnapshotCompactor,
+		deleteFilesFn:               deleteFilesFn,
 		deleteInactiveDirectoriesFn: fs.DeleteInactiveDirectories,
 		metrics:                     newCleanupManagerMetrics(scope),
 	}
@@ -127,7 +145,10 @@ func (m *cleanupManager) Cleanup(t time.Time) error {
 	m.cleanupInProgress = true
 	m.Unlock()
 
+	purgeReporter := m.opts.PurgeReporter()
+	purgeReporter.Start(t)
 	defer func() {
+		purgeReporter.Finish(m.nowFn())
 		m.Lock()
 		m.cleanupInProgress = false
 		m.Unlock()
@@ -159,11 +180,21 @@ func (m *cleanupManager) Cleanup(t time.Time) error {
 			"encountered errors when deleting inactive namespace files for %v: %v", t, err))
 	}
 
+	if err := m.compactSnapshots(); err != nil {
+		multiErr = multiErr.Add(fmt.Errorf(
+			"encountered errors when compacting snapshot files: %v", err))
+	}
+
 	if err := m.cleanupSnapshotsAndCommitlogs(); err != nil {
 		multiErr = multiErr.Add(fmt.Errorf(
 			"encountered errors when cleaning up snapshot and commitlog files: %v", err))
 	}
 
+	if err := m.deleteStaleStagingDirs(t); err != nil {
+		multiErr = multiErr.Add(fmt.Errorf(
+			"encountered errors when deleting stale staging directories for %v: %v", t, err))
+	}
+
 	return multiErr.FinalError()
 }
 
@@ -179,6 +210,14 @@ func (m *cleanupManager) Report() {
 	}
 }
 
+// deleteStaleStagingDirs deletes the staging directories of fileset writes
+// that were interrupted by a crash and never retried.
+func (m *cleanupManager) deleteStaleStagingDirs(t time.Time) error {
+	removed, err := fs.DeleteStaleStagingDirs(m.filePathPrefix, t.Add(-staleStagingDirAge))
+	m.metrics.deletedStagingDir.Inc(int64(removed))
+	return err
+}
+
 func (m *cleanupManager) deleteInactiveNamespaceFiles() error {
 	var namespaceDirNames []string
 	filePathPrefix := m.database.Options().CommitLogOptions().FilesystemOptions().FilePathPrefix()
@@ -215,11 +254,28 @@ func (m *cleanupManager) deleteInactiveDataFileSetFiles(filesetFilesDirPathFn fu
 		return err
 	}
 	for _, n := range namespaces {
-		var activeShards []string
+		var (
+			activeShards   []string
+			reshardEnabled = n.Options().ReshardOptions().Enabled
+		)
 		namespaceDirPath := filesetFilesDirPathFn(filePathPrefix, n.ID())
 		for _, s := range n.GetOwnedShards() {
 			shard := fmt.Sprintf("%d", s.ID())
 			activeShards = append(activeShards, shard)
+			if !reshardEnabled {
+				continue
+			}
+
+			// The files of the parent shard of a shard that has not yet been
+			// split from it are still read by the shard.
+			parent, ok, err := fs.ShardSplitParent(filePathPrefix, n.Metadata(), s.ID())
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			if ok {
+				activeShards = append(activeShards, fmt.Sprintf("%d", parent))
+			}
 		}
 		multiErr = multiErr.Add(m.deleteInactiveDirectoriesFn(namespaceDirPath, activeShards))
 	}
@@ -239,7 +295,8 @@ func (m *cleanupManager) cleanupDataFiles(t time.Time) error {
 		}
 		earliestToRetain := retention.FlushTimeStart(n.Options().RetentionOptions(), t)
 		shards := n.GetOwnedShards()
-		multiErr = multiErr.Add(m.cleanupExpiredNamespaceDataFiles(earliestToRetain, shards))
+		hooks := m.blockExpiryHooks(n)
+		multiErr = multiErr.Add(m.cleanupExpiredNamespaceDataFiles(earliestToRetain, shards, hooks))
 		multiErr = multiErr.Add(m.cleanupCompactedNamespaceDataFiles(shards))
 	}
 	return multiErr.FinalError()
@@ -255,6 +312,11 @@ func (m *cleanupManager) cleanupExpiredIndexFiles(t time.Time) error {
 		if !n.Options().CleanupEnabled() || !n.Options().IndexOptions().Enabled() {
 			continue
 		}
+		if len(n.FrozenRanges()) > 0 {
+			// Index filesets span multiple data blocks so keep all of them
+			// while any data of the namespace is frozen.
+			continue
+		}
 		idx, err := n.GetIndex()
 		if err != nil {
 			multiErr = multiErr.Add(err)
@@ -265,9 +327,32 @@ func (m *cleanupManager) cleanupExpiredIndexFiles(t time.Time) error {
 	return multiErr.FinalError()
 }
 
-func (m *cleanupManager) cleanupExpiredNamespaceDataFiles(earliestToRetain time.Time, shards []databaseShard) error {
+// blockExpiryHooks returns the hooks to run against the blocks of the
+// namespace before they expire.
+func (m *cleanupManager) blockExpiryHooks(n databaseNamespace) []BlockExpiryHook {
+	hooks := m.opts.BlockExpiryHooks()
+	if dsOpts := n.Options().ExpiryDownsampleOptions(); dsOpts.Enabled {
+		hook := newExpiryDownsampleHook(m.database, dsOpts, m.opts.ContextPool())
+		hooks = append(hooks[:len(hooks):len(hooks)], hook)
+	}
+	return hooks
+}
+
+func (m *cleanupManager) cleanupExpiredNamespaceDataFiles(
+	earliestToRetain time.Time,
+	shards []databaseShard,
+	hooks []BlockExpiryHook,
+) error {
 	multiErr := xerrors.NewMultiError()
 	for _, shard := range shards {
+		// Keep the expired files of a shard if the hooks fail so that they
+		// are run again on the next cleanup rather than losing the data.
+		if len(hooks) > 0 {
+			if err := shard.RunBlockExpiryHooks(earliestToRetain, hooks); err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+		}
 		if err := shard.CleanupExpiredFileSets(earliestToRetain); err != nil {
 			multiErr = multiErr.Add(err)
 		}
@@ -287,34 +372,137 @@ func (m *cleanupManager) cleanupCompactedNamespaceDataFiles(shards []databaseSha
 	return multiErr.FinalError()
 }
 
+// compactSnapshots compacts the snapshot volumes of every block with at least
+// the configured number of complete snapshot volumes into a single volume so
+// that the number of snapshot files kept for a block stays bounded.
+//
+// Only blocks whose most recent volume belongs to the most recent complete
+// snapshot are compacted, the compacted volume takes on the snapshot ID of
+// that volume and would otherwise be deleted by the snapshot cleanup.
+func (m *cleanupManager) compactSnapshots() error {
+	minVolumes := m.opts.SnapshotCompactionMinVolumes()
+	if minVolumes <= 0 {
+		return nil
+	}
+
+	namespaces, err := m.database.GetOwnedNamespaces()
+	if err != nil {
+		return err
+	}
+
+	fsOpts := m.opts.CommitLogOptions().FilesystemOptions()
+	snapshotMetadatas, _, err := m.snapshotMetadataFilesFn(fsOpts)
+	if err != nil {
+		return err
+	}
+	if len(snapshotMetadatas) == 0 {
+		// No compaction can be performed until we have at least one complete snapshot.
+		return nil
+	}
+	mostRecentSnapshot := snapshotMetadatas[0]
+	for _, snapshotMetadata := range snapshotMetadatas[1:] {
+		if snapshotMetadata.ID.Index > mostRecentSnapshot.ID.Index {
+			mostRecentSnapshot = snapshotMetadata
+		}
+	}
+
+	reader, err := fs.NewReader(m.opts.BytesPool(), fsOpts)
+	if err != nil {
+		return err
+	}
+	writer, err := fs.NewWriter(fsOpts)
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, ns := range namespaces {
+		var (
+			nsCtx     = namespace.NewContextFrom(ns.Metadata())
+			compactor = m.newSnapshotCompactorFn(reader, writer,
+				m.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+				m.opts.SegmentReaderPool(), m.opts.MultiReaderIteratorPool(),
+				m.opts.IdentifierPool(), m.opts.EncoderPool(), ns.Options())
+		)
+		for _, s := range ns.GetOwnedShards() {
+			shardSnapshots, err := m.snapshotFilesFn(fsOpts.FilePathPrefix(), ns.ID(), s.ID())
+			if err != nil {
+				multiErr = multiErr.Add(fmt.Errorf("err reading snapshot files for ns: %s and shard: %d, err: %v", ns.ID(), s.ID(), err))
+				continue
+			}
+
+			volumesByBlock := make(map[xtime.UnixNano]fs.FileSetFilesSlice)
+			for _, snapshot := range shardSnapshots {
+				if !snapshot.HasCompleteCheckpointFile() {
+					continue
+				}
+				blockStart := xtime.ToUnixNano(snapshot.ID.BlockStart)
+				volumesByBlock[blockStart] = append(volumesByBlock[blockStart], snapshot)
+			}
+
+			for _, volumes := range volumesByBlock {
+				if len(volumes) < minVolumes {
+					continue
+				}
+
+				latest := volumes[0]
+				for _, volume := range volumes[1:] {
+					if volume.ID.VolumeIndex > latest.ID.VolumeIndex {
+						latest = volume
+					}
+				}
+				_, snapshotID, err := latest.SnapshotTimeAndID()
+				if err != nil || !uuid.Equal(snapshotID, mostRecentSnapshot.ID.UUID) {
+					// Corrupt and superseded snapshot files are deleted by the
+					// snapshot cleanup instead.
+					continue
+				}
+
+				if err := compactor.Compact(volumes, latest.ID.VolumeIndex+1, nsCtx); err != nil {
+					m.metrics.compactSnapshotErrors.Inc(1)
+					multiErr = multiErr.Add(fmt.Errorf(
+						"err compacting snapshot files for ns: %s, shard: %d and block: %s, err: %v",
+						ns.ID(), s.ID(), latest.ID.BlockStart.String(), err))
+					continue
+				}
+
+				m.metrics.compactedSnapshotFile.Inc(int64(len(volumes)))
+				multiErr = multiErr.Add(m.deleteFilesFn(volumes.Filepaths()))
+			}
+		}
+	}
+
+	return multiErr.FinalError()
+}
+
 // The goal of the cleanupSnapshotsAndCommitlogs function is to delete all snapshots files, snapshot metadata
 // files, and commitlog files except for those that are currently required for recovery from a node failure.
 // According to the snapshotting / commitlog rotation logic, the files that are required for a complete
 // recovery are:
 //
-//     1. The most recent (highest index) snapshot metadata files.
-//     2. All snapshot files whose associated snapshot ID matches the snapshot ID of the most recent snapshot
-//        metadata file.
-//     3. All commitlog files whose index is larger than or equal to the index of the commitlog identifier stored
-//        in the most recent snapshot metadata file. This is because the snapshotting and commitlog rotation process
-//        guarantees that the most recent snapshot contains all data stored in commitlogs that were created before
-//        the rotation / snapshot process began.
+//  1. The most recent (highest index) snapshot metadata files.
+//  2. All snapshot files whose associated snapshot ID matches the snapshot ID of the most recent snapshot
+//     metadata file.
+//  3. All commitlog files whose index is larger than or equal to the index of the commitlog identifier stored
+//     in the most recent snapshot metadata file. This is because the snapshotting and commitlog rotation process
+//     guarantees that the most recent snapshot contains all data stored in commitlogs that were created before
+//     the rotation / snapshot process began.
 //
 // cleanupSnapshotsAndCommitlogs accomplishes this goal by performing the following steps:
 //
-//     1. List all the snapshot metadata files on disk.
-//     2. Identify the most recent one (highest index).
-//     3. For every namespace/shard/block combination, delete all snapshot files that match one of the following criteria:
-//         1. Snapshot files whose associated snapshot ID does not match the snapshot ID of the most recent
-//            snapshot metadata file.
-//         2. Snapshot files that are corrupt.
-//     4. Delete all snapshot metadata files prior to the most recent once.
-//     5. Delete corrupt snapshot metadata files.
-//     6. List all the commitlog files on disk.
-//     7. List all the commitlog files that are being actively written to.
-//     8. Delete all commitlog files whose index is lower than the index of the commitlog file referenced in the
-//        most recent snapshot metadata file (ignoring any commitlog files being actively written to.)
-//     9. Delete all corrupt commitlog files (ignoring any commitlog files being actively written to.)
+//  1. List all the snapshot metadata files on disk.
+//  2. Identify the most recent one (highest index).
+//  3. For every namespace/shard/block combination, delete all snapshot files that match one of the following criteria:
+//  1. Snapshot files whose associated snapshot ID does not match the snapshot ID of the most recent
+//     snapshot metadata file.
+//  2. Snapshot files that are corrupt.
+//  4. Delete all snapshot metadata files prior to the most recent once.
+//  5. Delete corrupt snapshot metadata files.
+//  6. List all the commitlog files on disk.
+//  7. List all the commitlog files that are being actively written to.
+//  8. Delete all commitlog files whose index is lower than the index of the commitlog file referenced in the
+//     most recent snapshot metadata file (ignoring any commitlog files being actively written to.)
+//  9. Delete all corrupt commitlog files (ignoring any commitlog files being actively written to.)
 //
 // This process is also modeled formally in TLA+ in the file `SnapshotsSpec.tla`.
 func (m *cleanupManager) cleanupSnapshotsAndCommitlogs() (finalErr error) {
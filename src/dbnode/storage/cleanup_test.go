@@ -23,15 +23,20 @@ package storage
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/ident"
 	xtest "github.com/m3db/m3/src/x/test"
 
@@ -350,6 +355,8 @@ func TestCleanupManagerNamespaceCleanup(t *testing.T) {
 	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
 	ns.EXPECT().GetOwnedShards().Return(nil).AnyTimes()
 
+	ns.EXPECT().FrozenRanges().Return(nil).AnyTimes()
+
 	idx := NewMocknamespaceIndex(ctrl)
 	ns.EXPECT().GetIndex().Return(idx, nil)
 
@@ -421,6 +428,57 @@ func TestCleanupDataAndSnapshotFileSetFiles(t *testing.T) {
 	require.NoError(t, mgr.Cleanup(ts))
 }
 
+func TestCleanupDataFileSetFilesRunsBlockExpiryHooks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	ts := timeFor(36000)
+
+	nsOpts := namespaceOptions.
+		SetExpiryDownsampleOptions(namespace.ExpiryDownsampleOptions{
+			Enabled:         true,
+			TargetNamespace: "metrics_1y",
+			Resolution:      time.Minute,
+		})
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+
+	var (
+		expectedEarliestToRetain = retention.FlushTimeStart(nsOpts.RetentionOptions(), ts)
+		hook                     = NewMockBlockExpiryHook(ctrl)
+		okShard                  = NewMockdatabaseShard(ctrl)
+		failingShard             = NewMockdatabaseShard(ctrl)
+		hooksMatcher             = gomock.AssignableToTypeOf([]BlockExpiryHook{})
+	)
+	okShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	okShard.EXPECT().RunBlockExpiryHooks(expectedEarliestToRetain, hooksMatcher).
+		DoAndReturn(func(_ time.Time, hooks []BlockExpiryHook) error {
+			// The registered hook is followed by the built-in downsample hook.
+			require.Equal(t, 2, len(hooks))
+			require.Equal(t, hook, hooks[0])
+			return nil
+		})
+	okShard.EXPECT().CleanupExpiredFileSets(expectedEarliestToRetain).Return(nil)
+	okShard.EXPECT().CleanupCompactedFileSets().Return(nil)
+
+	// Expired files of a shard are kept if its hooks fail.
+	failingShard.EXPECT().ID().Return(uint32(1)).AnyTimes()
+	failingShard.EXPECT().RunBlockExpiryHooks(expectedEarliestToRetain, hooksMatcher).
+		Return(errors.New("hook failed"))
+	failingShard.EXPECT().CleanupCompactedFileSets().Return(nil)
+
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{okShard, failingShard}).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("nsID")).AnyTimes()
+	ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(false, nil).AnyTimes()
+	namespaces := []databaseNamespace{ns}
+
+	db := newMockdatabase(ctrl, namespaces...)
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil).AnyTimes()
+	mgr := newCleanupManager(db, newNoopFakeActiveLogs(), tally.NoopScope).(*cleanupManager)
+	mgr.opts = mgr.opts.SetBlockExpiryHooks([]BlockExpiryHook{hook})
+
+	require.Error(t, mgr.cleanupDataFiles(ts))
+}
+
 type deleteInactiveDirectoriesCall struct {
 	parentDirPath  string
 	activeDirNames []string
@@ -486,6 +544,38 @@ func TestDeleteInactiveDataAndSnapshotFileSetFiles(t *testing.T) {
 	}
 }
 
+func TestCleanupManagerDeletesStaleStagingDirs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "cleanup-staging")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		now      = time.Now()
+		shardDir = filepath.Join(dir, "data", "nsID", "0")
+		stale    = filepath.Join(shardDir, ".staging-stale")
+		recent   = filepath.Join(shardDir, ".staging-recent")
+	)
+	for _, stagingDir := range []string{stale, recent} {
+		require.NoError(t, os.MkdirAll(stagingDir, 0755))
+	}
+	staleTime := now.Add(-2 * staleStagingDirAge)
+	require.NoError(t, os.Chtimes(stale, staleTime, staleTime))
+
+	db := newMockdatabase(ctrl)
+	mgr := newCleanupManager(db, newNoopFakeActiveLogs(), tally.NoopScope).(*cleanupManager)
+	mgr.filePathPrefix = dir
+
+	require.NoError(t, mgr.deleteStaleStagingDirs(now))
+
+	_, err = os.Stat(stale)
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(recent)
+	require.NoError(t, err)
+}
+
 func TestCleanupManagerPropagatesGetOwnedNamespacesError(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -505,6 +595,121 @@ func TestCleanupManagerPropagatesGetOwnedNamespacesError(t *testing.T) {
 	require.Error(t, mgr.Cleanup(ts))
 }
 
+type fakeSnapshotCompactor struct {
+	compacted   []fs.FileSetFilesSlice
+	nextVolumes []int
+}
+
+func (c *fakeSnapshotCompactor) Compact(
+	volumes fs.FileSetFilesSlice,
+	nextVolumeIndex int,
+	nsCtx namespace.Context,
+) error {
+	c.compacted = append(c.compacted, volumes)
+	c.nextVolumes = append(c.nextVolumes, nextVolumeIndex)
+	return nil
+}
+
+func TestCleanupManagerCompactSnapshots(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		blockSize       = 2 * time.Hour
+		compactedBlock  = time.Now().Truncate(blockSize)
+		supersededBlock = compactedBlock.Add(-blockSize)
+		smallBlock      = compactedBlock.Add(-2 * blockSize)
+		oldUUID         = uuid.Parse("a6367b49-9c83-4706-bd5c-400a4a9ec77c")
+		recentUUID      = uuid.Parse("bed2156f-182a-47ea-83ff-0a55d34c8a82")
+	)
+	snapshotFile := func(blockStart time.Time, volume int, id uuid.UUID) fs.FileSetFile {
+		return fs.FileSetFile{
+			ID: fs.FileSetFileIdentifier{
+				Namespace:   ident.StringID("ns"),
+				BlockStart:  blockStart,
+				VolumeIndex: volume,
+			},
+			AbsoluteFilepaths: []string{
+				fmt.Sprintf("snapshot-%d-%d", blockStart.Unix(), volume),
+			},
+			CachedSnapshotTime:              blockStart,
+			CachedSnapshotID:                id,
+			CachedHasCompleteCheckpointFile: fs.EvalTrue,
+		}
+	}
+	incomplete := snapshotFile(smallBlock, 1, recentUUID)
+	incomplete.CachedHasCompleteCheckpointFile = fs.EvalFalse
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	md, err := namespace.NewMetadata(ident.StringID("ns"), namespaceOptions)
+	require.NoError(t, err)
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().ID().Return(md.ID()).AnyTimes()
+	ns.EXPECT().Options().Return(md.Options()).AnyTimes()
+	ns.EXPECT().Metadata().Return(md).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard}).AnyTimes()
+	namespaces := []databaseNamespace{ns}
+
+	db := newMockdatabase(ctrl, namespaces...)
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil).AnyTimes()
+	mgr := newCleanupManager(db, newNoopFakeActiveLogs(), tally.NoopScope).(*cleanupManager)
+	mgr.opts = mgr.opts.SetSnapshotCompactionMinVolumes(2)
+
+	mgr.snapshotMetadataFilesFn = func(fs.Options) ([]fs.SnapshotMetadata, []fs.SnapshotMetadataErrorWithPaths, error) {
+		return []fs.SnapshotMetadata{
+			{ID: fs.SnapshotMetadataIdentifier{Index: 1, UUID: recentUUID}},
+			{ID: fs.SnapshotMetadataIdentifier{Index: 0, UUID: oldUUID}},
+		}, nil, nil
+	}
+	mgr.snapshotFilesFn = func(filePathPrefix string, namespace ident.ID, shard uint32) (fs.FileSetFilesSlice, error) {
+		return fs.FileSetFilesSlice{
+			snapshotFile(compactedBlock, 0, oldUUID),
+			snapshotFile(compactedBlock, 1, recentUUID),
+			// Superseded by the most recent snapshot, left to the cleanup.
+			snapshotFile(supersededBlock, 0, oldUUID),
+			snapshotFile(supersededBlock, 1, oldUUID),
+			// Not enough complete volumes to compact.
+			snapshotFile(smallBlock, 0, recentUUID),
+			incomplete,
+		}, nil
+	}
+	compactor := &fakeSnapshotCompactor{}
+	mgr.newSnapshotCompactorFn = func(
+		fs.DataFileSetReader,
+		fs.DataFileSetWriter,
+		int,
+		xio.SegmentReaderPool,
+		encoding.MultiReaderIteratorPool,
+		ident.Pool,
+		encoding.EncoderPool,
+		namespace.Options,
+	) fs.SnapshotCompactor {
+		return compactor
+	}
+	var deletedFiles []string
+	mgr.deleteFilesFn = func(files []string) error {
+		deletedFiles = append(deletedFiles, files...)
+		return nil
+	}
+
+	require.NoError(t, mgr.compactSnapshots())
+
+	require.Equal(t, 1, len(compactor.compacted))
+	require.Equal(t, 2, len(compactor.compacted[0]))
+	require.Equal(t, []int{2}, compactor.nextVolumes)
+	require.Equal(t, []string{
+		fmt.Sprintf("snapshot-%d-0", compactedBlock.Unix()),
+		fmt.Sprintf("snapshot-%d-1", compactedBlock.Unix()),
+	}, deletedFiles)
+
+	// Compaction is disabled by default.
+	compactor.compacted = nil
+	mgr.opts = mgr.opts.SetSnapshotCompactionMinVolumes(0)
+	require.NoError(t, mgr.compactSnapshots())
+	require.Equal(t, 0, len(compactor.compacted))
+}
+
 func timeFor(s int64) time.Time {
 	return time.Unix(s, 0)
 }
@@ -31,10 +31,12 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
@@ -55,6 +57,11 @@ const (
 	// lengthy is racey so we're gonna burst past this value anyways and the buffer
 	// gives us breathing room to recover.
 	commitLogQueueCapacityOverloadedFactor = 0.9
+
+	// commitLogEntryEstimatedBytes is the estimated size of a commit log entry
+	// excluding its annotation, i.e. the series index, timestamp, value and
+	// unit, used to track how much commit log would need to be replayed.
+	commitLogEntryEstimatedBytes = 25
 )
 
 var (
@@ -98,8 +105,9 @@ type db struct {
 
 	commitLog commitlog.CommitLog
 
-	state    databaseState
-	mediator databaseMediator
+	state         databaseState
+	mediator      databaseMediator
+	importFileOps importFileOps
 
 	created    uint64
 	bootstraps int
@@ -112,6 +120,7 @@ type db struct {
 	log     *zap.Logger
 
 	writeBatchPool *ts.WriteBatchPool
+	tickLoad       TickLoadMonitor
 }
 
 type databaseMetrics struct {
@@ -121,16 +130,22 @@ type databaseMetrics struct {
 	unknownNamespaceBatchWriter         tally.Counter
 	unknownNamespaceWriteBatch          tally.Counter
 	unknownNamespaceWriteTaggedBatch    tally.Counter
+	unknownNamespaceImport              tally.Counter
 	unknownNamespaceFetchBlocks         tally.Counter
 	unknownNamespaceFetchBlocksMetadata tally.Counter
 	unknownNamespaceQueryIDs            tally.Counter
 	errQueryIDsIndexDisabled            tally.Counter
 	errWriteTaggedIndexDisabled         tally.Counter
+	mirrorWrites                        tally.Counter
+	mirrorErrors                        tally.Counter
+	unknownNamespaceMirror              tally.Counter
+	futureWritesClamped                 tally.Counter
 }
 
 func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 	unknownNamespaceScope := scope.SubScope("unknown-namespace")
 	indexDisabledScope := scope.SubScope("index-disabled")
+	mirrorScope := scope.SubScope("mirror")
 	return databaseMetrics{
 		unknownNamespaceRead:                unknownNamespaceScope.Counter("read"),
 		unknownNamespaceWrite:               unknownNamespaceScope.Counter("write"),
@@ -138,11 +153,16 @@ func newDatabaseMetrics(scope tally.Scope) databaseMetrics {
 		unknownNamespaceBatchWriter:         unknownNamespaceScope.Counter("batch-writer"),
 		unknownNamespaceWriteBatch:          unknownNamespaceScope.Counter("write-batch"),
 		unknownNamespaceWriteTaggedBatch:    unknownNamespaceScope.Counter("write-tagged-batch"),
+		unknownNamespaceImport:              unknownNamespaceScope.Counter("import"),
 		unknownNamespaceFetchBlocks:         unknownNamespaceScope.Counter("fetch-blocks"),
 		unknownNamespaceFetchBlocksMetadata: unknownNamespaceScope.Counter("fetch-blocks-metadata"),
 		unknownNamespaceQueryIDs:            unknownNamespaceScope.Counter("query-ids"),
 		errQueryIDsIndexDisabled:            indexDisabledScope.Counter("err-query-ids"),
 		errWriteTaggedIndexDisabled:         indexDisabledScope.Counter("err-write-tagged"),
+		mirrorWrites:                        mirrorScope.Counter("writes"),
+		mirrorErrors:                        mirrorScope.Counter("errors"),
+		unknownNamespaceMirror:              unknownNamespaceScope.Counter("mirror"),
+		futureWritesClamped:                 scope.Counter("future-writes-clamped"),
 	}
 }
 
@@ -181,6 +201,7 @@ func NewDatabase(
 		metrics:               newDatabaseMetrics(scope),
 		log:                   logger,
 		writeBatchPool:        opts.WriteBatchPool(),
+		tickLoad:              opts.TickLoadMonitor(),
 	}
 
 	databaseIOpts := iopts.SetMetricsScope(scope)
@@ -226,6 +247,7 @@ func NewDatabase(
 		return nil, err
 	}
 	d.mediator = mediator
+	d.importFileOps = newImportFileOps(mediator)
 
 	return d, nil
 }
@@ -259,6 +281,14 @@ func (d *db) UpdateOwnedNamespaces(newNamespaces namespace.Map) error {
 		return err
 	}
 
+	// apply any updates that can take effect without a restart
+	updates, err := d.updateNamespacesWithLock(updates)
+	if err != nil {
+		enrichedErr := fmt.Errorf("unable to update namespaces: %v", err)
+		d.log.Error(enrichedErr.Error())
+		return err
+	}
+
 	// log that updates and removals are skipped
 	if len(removes) > 0 || len(updates) > 0 {
 		d.log.Warn("skipping namespace removals and updates (except schema updates), restart process if you want changes to take effect.")
@@ -361,6 +391,54 @@ func (d *db) addNamespacesWithLock(namespaces []namespace.Metadata) error {
 	return nil
 }
 
+// updateNamespacesWithLock applies the updates that only change the buffer
+// past and buffer future of namespaces or enable indexing on them, returning
+// the remaining updates that require a restart to take effect.
+func (d *db) updateNamespacesWithLock(updates []namespace.Metadata) ([]namespace.Metadata, error) {
+	var remaining []namespace.Metadata
+	for _, n := range updates {
+		ns, ok := d.namespaces.Get(n.ID())
+		if !ok { // should never happen
+			return nil, fmt.Errorf("non-existing namespace marked for update: %v", n.ID().String())
+		}
+
+		var (
+			existingOpts  = ns.Metadata().Options()
+			existingRopts = existingOpts.RetentionOptions()
+			newRopts      = n.Options().RetentionOptions()
+			// Compare the options without the buffer past and buffer future
+			// to check if they are the only changes.
+			withoutBuffers = n.Options().SetRetentionOptions(newRopts.
+					SetBufferPast(existingRopts.BufferPast()).
+					SetBufferFuture(existingRopts.BufferFuture()))
+			enableIndex = !existingOpts.IndexOptions().Enabled() &&
+				n.Options().IndexOptions().Enabled()
+		)
+		if enableIndex {
+			withoutBuffers = withoutBuffers.SetIndexOptions(existingOpts.IndexOptions())
+		}
+		if !withoutBuffers.Equal(existingOpts) {
+			remaining = append(remaining, n)
+			continue
+		}
+
+		if enableIndex {
+			if err := ns.EnableIndex(n.Options().IndexOptions()); err != nil {
+				d.log.Error("unable to enable namespace indexing",
+					zap.Stringer("namespace", n.ID()), zap.Error(err))
+				remaining = append(remaining, n)
+				continue
+			}
+		}
+
+		err := ns.UpdateBufferPastAndFuture(newRopts.BufferPast(), newRopts.BufferFuture())
+		if err != nil {
+			return nil, err
+		}
+	}
+	return remaining, nil
+}
+
 func (d *db) newDatabaseNamespaceWithLock(
 	md namespace.Metadata,
 ) (databaseNamespace, error) {
@@ -572,23 +650,33 @@ func (d *db) Write(
 	unit xtime.Unit,
 	annotation []byte,
 ) error {
+	if d.tickLoad.Enabled() {
+		defer d.recordWriteLatency(d.nowFn())
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceWrite.Inc(1)
 		return err
 	}
 
-	series, wasWritten, err := n.Write(ctx, id, timestamp, value, unit, annotation)
+	timestamp, _ = d.clampFutureWrite(n, timestamp)
+	series, wasWritten, _, err := n.Write(ctx, id, timestamp, value, unit, annotation)
 	if err != nil {
 		return err
 	}
 
+	if target := d.mirrorTarget(n, id, nil); target != nil {
+		d.mirrorWrite(ctx, target, id, nil, timestamp, value, unit, annotation)
+	}
+
 	if !n.Options().WritesToCommitLog() || !wasWritten {
 		return nil
 	}
 
+	d.trackUnsnapshottedBytes(annotation)
 	dp := ts.Datapoint{Timestamp: timestamp, Value: value}
-	return d.commitLog.Write(ctx, series, dp, unit, annotation)
+	return d.writeCommitLog(ctx, n, series, dp, unit, annotation)
 }
 
 func (d *db) WriteTagged(
@@ -600,6 +688,53 @@ func (d *db) WriteTagged(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
+) error {
+	if d.tickLoad.Enabled() {
+		defer d.recordWriteLatency(d.nowFn())
+	}
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceWriteTagged.Inc(1)
+		return err
+	}
+
+	// The tags are duplicated before the write since it consumes them.
+	var mirrorTags ident.TagIterator
+	target := d.mirrorTarget(n, id, tags)
+	if target != nil {
+		mirrorTags = tags.Duplicate()
+		defer mirrorTags.Close()
+	}
+
+	timestamp, _ = d.clampFutureWrite(n, timestamp)
+	series, wasWritten, _, err := n.WriteTagged(ctx, id, tags, timestamp, value, unit, annotation)
+	if err != nil {
+		return err
+	}
+
+	if target != nil {
+		d.mirrorWrite(ctx, target, id, mirrorTags, timestamp, value, unit, annotation)
+	}
+
+	if !n.Options().WritesToCommitLog() || !wasWritten {
+		return nil
+	}
+
+	d.trackUnsnapshottedBytes(annotation)
+	dp := ts.Datapoint{Timestamp: timestamp, Value: value}
+	return d.writeCommitLog(ctx, n, series, dp, unit, annotation)
+}
+
+func (d *db) WriteTaggedBackfill(
+	ctx context.Context,
+	namespace ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
 ) error {
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
@@ -607,7 +742,8 @@ func (d *db) WriteTagged(
 		return err
 	}
 
-	series, wasWritten, err := n.WriteTagged(ctx, id, tags, timestamp, value, unit, annotation)
+	timestamp, _ = d.clampFutureWrite(n, timestamp)
+	series, wasWritten, _, err := n.WriteTaggedBackfill(ctx, id, tags, timestamp, value, unit, annotation)
 	if err != nil {
 		return err
 	}
@@ -616,8 +752,121 @@ func (d *db) WriteTagged(
 		return nil
 	}
 
+	d.trackUnsnapshottedBytes(annotation)
 	dp := ts.Datapoint{Timestamp: timestamp, Value: value}
-	return d.commitLog.Write(ctx, series, dp, unit, annotation)
+	return d.writeCommitLog(ctx, n, series, dp, unit, annotation)
+}
+
+// writeCommitLog writes a single write to the commit log, acknowledging it
+// with the write durability of the namespace.
+func (d *db) writeCommitLog(
+	ctx context.Context,
+	n databaseNamespace,
+	series ts.Series,
+	dp ts.Datapoint,
+	unit xtime.Unit,
+	annotation []byte,
+) error {
+	durability := n.Options().WriteDurability()
+	if durability == ts.DurabilityDefault {
+		return d.commitLog.Write(ctx, series, dp, unit, annotation)
+	}
+	return d.commitLog.WriteWithDurability(ctx, series, dp, unit, annotation, durability)
+}
+
+// clampFutureWrite clamps the timestamp of a write beyond the future write
+// tolerance of the namespace before the write is applied, so that the commit
+// log records the timestamp the write was acknowledged at and replay does not
+// restore it at the unclamped timestamp.
+func (d *db) clampFutureWrite(
+	n databaseNamespace,
+	timestamp time.Time,
+) (time.Time, bool) {
+	timestamp, clamped := n.Options().FutureWriteOptions().Clamp(timestamp, d.nowFn())
+	if clamped {
+		d.metrics.futureWritesClamped.Inc(1)
+	}
+	return timestamp, clamped
+}
+
+// mirrorTarget returns the shadow namespace a write to the given namespace is
+// mirrored into, or nil if the write is not mirrored. Tags are nil for writes
+// of untagged series.
+func (d *db) mirrorTarget(
+	n databaseNamespace,
+	id ident.ID,
+	tags ident.TagIterator,
+) databaseNamespace {
+	mirrorOpts := n.Options().MirrorOptions()
+	if !mirrorOpts.Enabled || !mirrorOpts.Sampled(id) {
+		return nil
+	}
+	matches, err := mirrorOpts.Matches(tags)
+	if err != nil {
+		d.metrics.mirrorErrors.Inc(1)
+		return nil
+	}
+	if !matches {
+		return nil
+	}
+	target, err := d.namespaceFor(ident.StringID(mirrorOpts.TargetNamespace))
+	if err != nil {
+		d.metrics.unknownNamespaceMirror.Inc(1)
+		return nil
+	}
+	return target
+}
+
+// mirrorWrite writes a datapoint written to a namespace into its shadow
+// namespace. Errors are only counted since mirroring must never fail the
+// original write, and mirrored writes are not mirrored any further.
+func (d *db) mirrorWrite(
+	ctx context.Context,
+	target databaseNamespace,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) {
+	var (
+		series     ts.Series
+		wasWritten bool
+		err        error
+	)
+	timestamp, _ = d.clampFutureWrite(target, timestamp)
+	if tags != nil {
+		series, wasWritten, _, err = target.WriteTagged(ctx, id, tags,
+			timestamp, value, unit, annotation)
+	} else {
+		series, wasWritten, _, err = target.Write(ctx, id,
+			timestamp, value, unit, annotation)
+	}
+	if err == nil && wasWritten && target.Options().WritesToCommitLog() {
+		d.trackUnsnapshottedBytes(annotation)
+		dp := ts.Datapoint{Timestamp: timestamp, Value: value}
+		err = d.writeCommitLog(ctx, target, series, dp, unit, annotation)
+	}
+	if err != nil {
+		d.metrics.mirrorErrors.Inc(1)
+		return
+	}
+	d.metrics.mirrorWrites.Inc(1)
+}
+
+func (d *db) Import(
+	ctx context.Context,
+	namespace ident.ID,
+	iter ImportIterator,
+) (ImportResult, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceImport.Inc(1)
+		return ImportResult{}, err
+	}
+
+	return n.Import(ctx, iter, d.importFileOps)
 }
 
 func (d *db) BatchWriter(namespace ident.ID, batchSize int) (ts.BatchWriter, error) {
@@ -669,6 +918,10 @@ func (d *db) writeBatch(
 	}
 	defer sp.Finish()
 
+	if d.tickLoad.Enabled() {
+		defer d.recordWriteLatency(d.nowFn())
+	}
+
 	writes, ok := writer.(ts.WriteBatch)
 	if !ok {
 		return errWriterDoesNotImplementWriteBatch
@@ -684,29 +937,53 @@ func (d *db) writeBatch(
 		return err
 	}
 
-	iter := writes.Iter()
+	// Callers may optionally be notified of how each write was applied.
+	dispositionHandler, _ := errHandler.(IndexedWriteDispositionHandler)
+
+	var (
+		iter                  = writes.Iter()
+		numUnsnapshottedBytes int64
+	)
 	for i, write := range iter {
 		var (
-			series     ts.Series
-			wasWritten bool
-			err        error
+			disposition series.WriteDisposition
+			written     ts.Series
+			wasWritten  bool
+			err         error
+			mirrorTags  ident.TagIterator
 		)
 
+		var tags ident.TagIterator
 		if tagged {
-			series, wasWritten, err = n.WriteTagged(
+			tags = write.TagIter
+		}
+		target := d.mirrorTarget(n, write.Write.Series.ID, tags)
+		if target != nil && tags != nil {
+			mirrorTags = tags.Duplicate()
+		}
+
+		// The clamped timestamp is set on the batch so that the commit log
+		// records the write at the timestamp it was applied at.
+		timestamp, clamped := d.clampFutureWrite(n, write.Write.Datapoint.Timestamp)
+		if clamped {
+			writes.SetTimestamp(i, timestamp)
+		}
+
+		if tagged {
+			written, wasWritten, disposition, err = n.WriteTagged(
 				ctx,
 				write.Write.Series.ID,
 				write.TagIter,
-				write.Write.Datapoint.Timestamp,
+				timestamp,
 				write.Write.Datapoint.Value,
 				write.Write.Unit,
 				write.Write.Annotation,
 			)
 		} else {
-			series, wasWritten, err = n.Write(
+			written, wasWritten, disposition, err = n.Write(
 				ctx,
 				write.Write.Series.ID,
-				write.Write.Datapoint.Timestamp,
+				timestamp,
 				write.Write.Datapoint.Value,
 				write.Write.Unit,
 				write.Write.Annotation,
@@ -716,6 +993,19 @@ func (d *db) writeBatch(
 			// Return errors with the original index provided by the caller so they
 			// can associate the error with the write that caused it.
 			errHandler.HandleError(write.OriginalIndex, err)
+		} else if target != nil {
+			d.mirrorWrite(ctx, target, write.Write.Series.ID, mirrorTags,
+				timestamp, write.Write.Datapoint.Value,
+				write.Write.Unit, write.Write.Annotation)
+		}
+		if clamped && wasWritten && err == nil {
+			disposition = series.WriteClamped
+		}
+		if mirrorTags != nil {
+			mirrorTags.Close()
+		}
+		if dispositionHandler != nil {
+			dispositionHandler.HandleWriteDisposition(write.OriginalIndex, disposition)
 		}
 
 		// Need to set the outcome in the success case so the commitlog gets the
@@ -723,12 +1013,14 @@ func (d *db) writeBatch(
 		// whose lifecycle lives longer than the span of this request, making them
 		// safe for use by the async commitlog. Need to set the outcome in the
 		// error case so that the commitlog knows to skip this entry.
-		writes.SetOutcome(i, series, err)
+		writes.SetOutcome(i, written, err)
 		if !wasWritten || err != nil {
 			// This series has no additional information that needs to be written to
 			// the commit log; set this series to skip writing to the commit log.
 			writes.SetSkipWrite(i)
+			continue
 		}
+		numUnsnapshottedBytes += commitLogEntryEstimatedBytes + int64(len(write.Write.Annotation))
 	}
 	if !n.Options().WritesToCommitLog() {
 		// Finalize here because we can't rely on the commitlog to do it since
@@ -737,6 +1029,16 @@ func (d *db) writeBatch(
 		return nil
 	}
 
+	d.opts.SnapshotTracker().IncNumUnsnapshottedBytes(numUnsnapshottedBytes)
+	// Acknowledge with the stronger of the durability requested for the batch
+	// and the write durability of the namespace, a namespace without a write
+	// durability is acknowledged with the durability of the commit log
+	// strategy so that a batch can not request a weaker durability than it.
+	durability := n.Options().WriteDurability()
+	if durability == ts.DurabilityDefault {
+		durability = d.opts.CommitLogOptions().Strategy().Durability()
+	}
+	writes.SetDurability(writes.Durability().Max(durability))
 	return d.commitLog.WriteBatch(ctx, writes)
 }
 
@@ -812,6 +1114,10 @@ func (d *db) ReadEncoded(
 	}
 	defer sp.Finish()
 
+	if d.tickLoad.Enabled() {
+		defer d.recordReadLatency(d.nowFn())
+	}
+
 	n, err := d.namespaceFor(namespace)
 	if err != nil {
 		d.metrics.unknownNamespaceRead.Inc(1)
@@ -821,6 +1127,14 @@ func (d *db) ReadEncoded(
 	return n.ReadEncoded(ctx, id, start, end)
 }
 
+func (d *db) recordWriteLatency(start time.Time) {
+	d.tickLoad.RecordWriteLatency(d.nowFn().Sub(start))
+}
+
+func (d *db) recordReadLatency(start time.Time) {
+	d.tickLoad.RecordReadLatency(d.nowFn().Sub(start))
+}
+
 func (d *db) FetchBlocks(
 	ctx context.Context,
 	namespace ident.ID,
@@ -878,6 +1192,38 @@ func (d *db) FetchBlocksMetadataV2(
 		pageToken, opts)
 }
 
+func (d *db) StreamBlocksMetadataV2(
+	ctx context.Context,
+	namespace ident.ID,
+	shardID uint32,
+	start, end time.Time,
+	limit int64,
+	pageToken PageToken,
+	opts block.FetchBlocksMetadataOptions,
+	fn block.FetchBlocksMetadataResultFn,
+) (PageToken, error) {
+	ctx, sp, sampled := ctx.StartSampledTraceSpan(tracepoint.DBFetchBlocksMetadataV2)
+	if sampled {
+		sp.LogFields(
+			opentracinglog.String("namespace", namespace.String()),
+			opentracinglog.Uint32("shardID", shardID),
+			xopentracing.Time("start", start),
+			xopentracing.Time("end", end),
+			opentracinglog.Int64("limit", limit),
+		)
+	}
+	defer sp.Finish()
+
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		d.metrics.unknownNamespaceFetchBlocksMetadata.Inc(1)
+		return nil, xerrors.NewInvalidParamsError(err)
+	}
+
+	return n.StreamBlocksMetadataV2(ctx, shardID, start, end, limit,
+		pageToken, opts, fn)
+}
+
 func (d *db) Bootstrap() error {
 	d.Lock()
 	d.bootstraps++
@@ -988,6 +1334,56 @@ func (d *db) FlushState(
 	return n.FlushState(shardID, blockStart)
 }
 
+func (d *db) ShardWatermarks(namespace ident.ID) ([]ShardWatermark, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		now                       = d.nowFn()
+		rtopts                    = n.Options().RetentionOptions()
+		blockSize                 = rtopts.BlockSize()
+		start                     = retention.FlushTimeStart(rtopts, now)
+		end                       = retention.FlushTimeEnd(rtopts, now)
+		repairedTo, repairEnabled = d.mediator.RepairedTo(n)
+		shards                    = n.GetOwnedShards()
+		watermarks                = make([]ShardWatermark, 0, len(shards))
+	)
+	for _, shard := range shards {
+		var flushedTo time.Time
+		for blockStart := start; !blockStart.After(end); blockStart = blockStart.Add(blockSize) {
+			// NB: flush state is not initialized until the shard bootstraps in
+			// which case nothing is considered flushed.
+			state, err := shard.FlushState(blockStart)
+			if err != nil || !statusIsRetrievable(state.WarmStatus) {
+				break
+			}
+			flushedTo = blockStart.Add(blockSize)
+		}
+
+		durableTo := flushedTo
+		if repairEnabled && repairedTo.Before(durableTo) {
+			durableTo = repairedTo
+		}
+		watermarks = append(watermarks, ShardWatermark{
+			Shard:      shard.ID(),
+			FlushedTo:  flushedTo,
+			RepairedTo: repairedTo,
+			DurableTo:  durableTo,
+		})
+	}
+	return watermarks, nil
+}
+
+func (d *db) RepairHistory(namespace ident.ID) ([]BlockRepairHistory, error) {
+	n, err := d.namespaceFor(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return d.mediator.RepairHistory(n), nil
+}
+
 func (d *db) namespaceFor(namespace ident.ID) (databaseNamespace, error) {
 	d.RLock()
 	n, exists := d.namespaces.Get(namespace)
@@ -1017,11 +1413,22 @@ func (d *db) GetOwnedNamespaces() ([]databaseNamespace, error) {
 }
 
 func (d *db) nextIndex() uint64 {
+	// Every new series needs its metadata replayed from the commit log until
+	// the next snapshot so track it towards the snapshot series threshold.
+	d.opts.SnapshotTracker().IncNumUnsnapshottedSeries(1)
 	// Start with index at "1" so that a default "uniqueIndex"
 	// with "0" is invalid (AddUint64 will return the new value).
 	return atomic.AddUint64(&d.created, 1)
 }
 
+// trackUnsnapshottedBytes records the estimated size of a commit log entry
+// that would need to be replayed if the node restarted before the next
+// snapshot.
+func (d *db) trackUnsnapshottedBytes(annotation []byte) {
+	d.opts.SnapshotTracker().IncNumUnsnapshottedBytes(
+		commitLogEntryEstimatedBytes + int64(len(annotation)))
+}
+
 type tsIDs []ident.ID
 
 func (t tsIDs) String() (string, error) {
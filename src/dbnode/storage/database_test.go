@@ -24,6 +24,8 @@ import (
 	stdlibctx "context"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"os"
 	"sort"
 	"sync"
 	"testing"
@@ -32,6 +34,7 @@ import (
 	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/sharding"
@@ -39,6 +42,7 @@ import (
 	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
+	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/topology"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -632,6 +636,55 @@ func TestDatabaseUpdateNamespace(t *testing.T) {
 	require.Nil(t, schema)
 }
 
+func TestDatabaseUpdateNamespaceBufferPastAndFuture(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	require.NoError(t, d.Open())
+	defer func() {
+		close(mapCh)
+		require.NoError(t, d.Close())
+		leaktest.CheckTimeout(t, time.Second)()
+	}()
+
+	// retrieve the update channel to track propatation
+	updateCh := d.opts.NamespaceInitializer().(*mockNsInitializer).updateCh
+
+	// construct new namespace Map only changing the buffer past and future
+	ropts := defaultTestNs1Opts.RetentionOptions().
+		SetBufferPast(30 * time.Minute).
+		SetBufferFuture(5 * time.Minute)
+	md1, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts.SetRetentionOptions(ropts))
+	require.NoError(t, err)
+	md2, err := namespace.NewMetadata(defaultTestNs2ID, defaultTestNs2Opts)
+	require.NoError(t, err)
+	nsMap, err := namespace.NewMap([]namespace.Metadata{md1, md2})
+	require.NoError(t, err)
+
+	// update the database watch with new Map
+	mapCh <- nsMap
+
+	// wait till the update has propagated
+	<-updateCh
+	<-updateCh
+	time.Sleep(10 * time.Millisecond)
+
+	// ensure the buffer past and future have been updated at runtime
+	ns1, ok := d.Namespace(defaultTestNs1ID)
+	require.True(t, ok)
+	require.True(t, ropts.Equal(ns1.Options().RetentionOptions()))
+	require.True(t, ropts.Equal(ns1.Metadata().Options().RetentionOptions()))
+	ns2, ok := d.Namespace(defaultTestNs2ID)
+	require.True(t, ok)
+	require.Equal(t, defaultTestNs2Opts, ns2.Options())
+
+	dbNs1, ok := ns1.(*dbNamespace)
+	require.True(t, ok)
+	require.Equal(t, 30*time.Minute, dbNs1.seriesOpts.BufferWindow().BufferPast())
+	require.Equal(t, 5*time.Minute, dbNs1.seriesOpts.BufferWindow().BufferFuture())
+}
+
 func TestDatabaseCreateSchemaNotSet(t *testing.T) {
 	protoTestDatabaseOptions := DefaultTestOptions().
 		SetSchemaRegistry(namespace.NewSchemaRegistry(true, nil))
@@ -806,13 +859,13 @@ func testDatabaseNamespaceIndexFunctions(t *testing.T, commitlogEnabled bool) {
 	ctx.SetGoContext(opentracing.ContextWithSpan(stdlibctx.Background(), sp))
 
 	ns.EXPECT().WriteTagged(gomock.Any(), ident.NewIDMatcher("foo"), gomock.Any(),
-		time.Time{}, 1.0, xtime.Second, nil).Return(s, true, nil)
+		time.Time{}, 1.0, xtime.Second, nil).Return(s, true, series.WriteAccepted, nil)
 	require.NoError(t, d.WriteTagged(ctx, namespace,
 		id, tagsIter, time.Time{},
 		1.0, xtime.Second, nil))
 
 	ns.EXPECT().WriteTagged(gomock.Any(), ident.NewIDMatcher("foo"), gomock.Any(),
-		time.Time{}, 1.0, xtime.Second, nil).Return(s, false, fmt.Errorf("random err"))
+		time.Time{}, 1.0, xtime.Second, nil).Return(s, false, series.WriteDispositionUnknown, fmt.Errorf("random err"))
 	require.Error(t, d.WriteTagged(ctx, namespace,
 		ident.StringID("foo"), ident.EmptyTagIterator, time.Time{},
 		1.0, xtime.Second, nil))
@@ -942,6 +995,18 @@ type indexedErr struct {
 	err   error
 }
 
+type fakeIndexedWriteDispositionHandler struct {
+	fakeIndexedErrorHandler
+	dispositions []series.WriteDisposition
+}
+
+func (f *fakeIndexedWriteDispositionHandler) HandleWriteDisposition(
+	index int,
+	disposition series.WriteDisposition,
+) {
+	f.dispositions = append(f.dispositions, disposition)
+}
+
 func testDatabaseWriteBatch(t *testing.T,
 	tagged bool, commitlogEnabled bool, skipAll bool) {
 	ctrl := gomock.NewController(t)
@@ -1061,7 +1126,7 @@ func testDatabaseWriteBatch(t *testing.T,
 					ID:        ident.StringID(write.series + "-updated"),
 					Namespace: namespace,
 					Tags:      ident.Tags{},
-				}, wasWritten, write.err)
+				}, wasWritten, series.WriteAccepted, write.err)
 		} else {
 			batchWriter.Add(i*2, ident.StringID(write.series),
 				write.t, write.v, xtime.Second, nil)
@@ -1072,7 +1137,7 @@ func testDatabaseWriteBatch(t *testing.T,
 					ID:        ident.StringID(write.series + "-updated"),
 					Namespace: namespace,
 					Tags:      ident.Tags{},
-				}, wasWritten, write.err)
+				}, wasWritten, series.WriteAccepted, write.err)
 		}
 		i++
 	}
@@ -1156,6 +1221,78 @@ func TestDatabaseFlushState(t *testing.T) {
 	require.Error(t, err)
 }
 
+func TestDatabaseShardWatermarks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, Bootstrapped)
+	defer func() {
+		close(mapCh)
+	}()
+
+	var (
+		rOpts = retention.NewOptions().
+			SetRetentionPeriod(retention.NewOptions().BlockSize() * 2)
+		nsOpts    = namespace.NewOptions().SetRetentionOptions(rOpts)
+		blockSize = rOpts.BlockSize()
+		now       = time.Now().Truncate(blockSize).Add(rOpts.BufferPast()).Add(time.Second)
+
+		flushTimeStart = retention.FlushTimeStart(rOpts, now)
+		flushTimeEnd   = retention.FlushTimeEnd(rOpts, now)
+
+		nsID       = "testns1"
+		ns         = dbAddNewMockNamespace(ctrl, d, nsID)
+		shard0     = NewMockdatabaseShard(ctrl)
+		shard1     = NewMockdatabaseShard(ctrl)
+		flushed    = fileOpState{WarmStatus: fileOpSuccess}
+		notFlushed = fileOpState{WarmStatus: fileOpNotStarted}
+	)
+	require.Equal(t, blockSize, flushTimeEnd.Sub(flushTimeStart))
+	d.nowFn = func() time.Time {
+		return now
+	}
+
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard0, shard1}).AnyTimes()
+	shard0.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	shard0.EXPECT().FlushState(flushTimeStart).Return(flushed, nil).AnyTimes()
+	shard0.EXPECT().FlushState(flushTimeEnd).Return(flushed, nil).AnyTimes()
+	shard1.EXPECT().ID().Return(uint32(1)).AnyTimes()
+	shard1.EXPECT().FlushState(flushTimeStart).Return(flushed, nil).AnyTimes()
+	shard1.EXPECT().FlushState(flushTimeEnd).Return(notFlushed, nil).AnyTimes()
+
+	mediator := NewMockdatabaseMediator(ctrl)
+	d.mediator = mediator
+
+	// Durable watermarks are held back by repairs when repairs are enabled.
+	mediator.EXPECT().RepairedTo(ns).Return(flushTimeStart.Add(blockSize), true)
+	watermarks, err := d.ShardWatermarks(ident.StringID(nsID))
+	require.NoError(t, err)
+	require.Equal(t, []ShardWatermark{
+		{
+			Shard:      0,
+			FlushedTo:  flushTimeEnd.Add(blockSize),
+			RepairedTo: flushTimeStart.Add(blockSize),
+			DurableTo:  flushTimeStart.Add(blockSize),
+		},
+		{
+			Shard:      1,
+			FlushedTo:  flushTimeStart.Add(blockSize),
+			RepairedTo: flushTimeStart.Add(blockSize),
+			DurableTo:  flushTimeStart.Add(blockSize),
+		},
+	}, watermarks)
+
+	mediator.EXPECT().RepairedTo(ns).Return(time.Time{}, false)
+	watermarks, err = d.ShardWatermarks(ident.StringID(nsID))
+	require.NoError(t, err)
+	require.Equal(t, flushTimeEnd.Add(blockSize), watermarks[0].DurableTo)
+	require.Equal(t, flushTimeStart.Add(blockSize), watermarks[1].DurableTo)
+
+	_, err = d.ShardWatermarks(ident.StringID("not-exist"))
+	require.Error(t, err)
+}
+
 func TestDatabaseIsBootstrapped(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1207,13 +1344,13 @@ func TestUpdateBatchWriterBasedOnShardResults(t *testing.T) {
 	)
 
 	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
-		gomock.Any(), gomock.Any()).Return(series1, true, nil)
+		gomock.Any(), gomock.Any()).Return(series1, true, series.WriteAccepted, nil)
 	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
-		gomock.Any(), gomock.Any()).Return(series2, true, err)
+		gomock.Any(), gomock.Any()).Return(series2, true, series.WriteDispositionUnknown, err)
 	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
-		gomock.Any(), gomock.Any()).Return(series3, false, err)
+		gomock.Any(), gomock.Any()).Return(series3, false, series.WriteDispositionUnknown, err)
 	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
-		gomock.Any(), gomock.Any()).Return(series4, false, nil)
+		gomock.Any(), gomock.Any()).Return(series4, false, series.WriteAccepted, nil)
 
 	write := ts.Write{
 		Series: ts.Series{ID: ident.StringID("foo")},
@@ -1246,6 +1383,351 @@ func TestUpdateBatchWriterBasedOnShardResults(t *testing.T) {
 	require.NoError(t, d.Close())
 }
 
+func TestDatabaseWriteBatchReportsWriteDispositions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	commitlog := d.commitLog
+	d.commitLog = nil
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	nsOptions := namespace.NewOptions().
+		SetWritesToCommitLog(false)
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
+	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
+	ns.EXPECT().Close().Return(nil).Times(1)
+	require.NoError(t, d.Open())
+
+	var (
+		namespace = ident.StringID("testns")
+		ctx       = context.NewContext()
+		series1   = ts.Series{UniqueIndex: 0}
+		series2   = ts.Series{UniqueIndex: 1}
+		series3   = ts.Series{UniqueIndex: 2}
+		err       = fmt.Errorf("err")
+	)
+
+	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Return(series1, true, series.WriteClamped, nil)
+	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Return(series2, true, series.WriteDuplicateOverwritten, nil)
+	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Return(series3, false, series.WriteRejectedTooOld, err)
+
+	write := ts.Write{
+		Series: ts.Series{ID: ident.StringID("foo")},
+	}
+
+	iters := []ts.BatchWrite{
+		{Write: write},
+		{Write: write},
+		{Write: write},
+	}
+
+	batchWriter := ts.NewMockWriteBatch(ctrl)
+	batchWriter.EXPECT().Iter().Return(iters)
+	batchWriter.EXPECT().Finalize().Times(1)
+	batchWriter.EXPECT().SetOutcome(0, series1, nil)
+	batchWriter.EXPECT().SetOutcome(1, series2, nil)
+	batchWriter.EXPECT().SetOutcome(2, series3, err)
+	batchWriter.EXPECT().SetSkipWrite(2)
+
+	handler := &fakeIndexedWriteDispositionHandler{}
+	d.WriteBatch(ctx, namespace, batchWriter, handler)
+	require.Equal(t, 1, len(handler.errs))
+	require.Equal(t, []series.WriteDisposition{
+		series.WriteClamped,
+		series.WriteDuplicateOverwritten,
+		series.WriteRejectedTooOld,
+	}, handler.dispositions)
+	d.commitLog = commitlog
+	require.NoError(t, d.Close())
+}
+
+func TestDatabaseWriteBatchWriteDurability(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mockCL := commitlog.NewMockCommitLog(ctrl)
+	cl := d.commitLog
+	d.commitLog = mockCL
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	nsOptions := namespace.NewOptions().
+		SetWriteDurability(ts.DurabilityCommitLogBuffer)
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
+	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
+	ns.EXPECT().Close().Return(nil).Times(1)
+	require.NoError(t, d.Open())
+
+	var (
+		namespace = ident.StringID("testns")
+		ctx       = context.NewContext()
+		series1   = ts.Series{UniqueIndex: 0}
+	)
+
+	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Return(series1, true, series.WriteAccepted, nil).Times(2)
+
+	write := ts.Write{
+		Series: ts.Series{ID: ident.StringID("foo")},
+	}
+
+	// The namespace durability applies to batches requesting a weaker durability.
+	batchWriter := ts.NewMockWriteBatch(ctrl)
+	batchWriter.EXPECT().Iter().Return([]ts.BatchWrite{{Write: write}})
+	batchWriter.EXPECT().SetOutcome(0, series1, nil)
+	batchWriter.EXPECT().Durability().Return(ts.DurabilityMemory)
+	batchWriter.EXPECT().SetDurability(ts.DurabilityCommitLogBuffer)
+	mockCL.EXPECT().WriteBatch(ctx, batchWriter).Return(nil)
+	require.NoError(t, d.WriteBatch(ctx, namespace, batchWriter, &fakeIndexedErrorHandler{}))
+
+	// Batches requesting a stronger durability keep it.
+	batchWriter = ts.NewMockWriteBatch(ctrl)
+	batchWriter.EXPECT().Iter().Return([]ts.BatchWrite{{Write: write}})
+	batchWriter.EXPECT().SetOutcome(0, series1, nil)
+	batchWriter.EXPECT().Durability().Return(ts.DurabilityFsync)
+	batchWriter.EXPECT().SetDurability(ts.DurabilityFsync)
+	mockCL.EXPECT().WriteBatch(ctx, batchWriter).Return(nil)
+	require.NoError(t, d.WriteBatch(ctx, namespace, batchWriter, &fakeIndexedErrorHandler{}))
+
+	d.commitLog = cl
+	require.NoError(t, d.Close())
+}
+
+func TestDatabaseWriteBatchDefaultWriteDurability(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	d.opts = d.opts.SetCommitLogOptions(
+		d.opts.CommitLogOptions().SetStrategy(commitlog.StrategyWriteWait),
+	)
+
+	mockCL := commitlog.NewMockCommitLog(ctrl)
+	cl := d.commitLog
+	d.commitLog = mockCL
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
+	ns.EXPECT().Options().Return(namespace.NewOptions()).AnyTimes()
+	ns.EXPECT().Close().Return(nil).Times(1)
+	require.NoError(t, d.Open())
+
+	var (
+		namespace = ident.StringID("testns")
+		ctx       = context.NewContext()
+		series1   = ts.Series{UniqueIndex: 0}
+	)
+
+	ns.EXPECT().Write(ctx, gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any(), gomock.Any()).Return(series1, true, series.WriteAccepted, nil).Times(3)
+
+	write := ts.Write{
+		Series: ts.Series{ID: ident.StringID("foo")},
+	}
+
+	// Without a namespace durability a batch requesting a weaker durability
+	// than the commit log strategy is acknowledged with the durability of
+	// the strategy rather than the weaker durability.
+	for _, requested := range []ts.Durability{ts.DurabilityDefault, ts.DurabilityMemory} {
+		batchWriter := ts.NewMockWriteBatch(ctrl)
+		batchWriter.EXPECT().Iter().Return([]ts.BatchWrite{{Write: write}})
+		batchWriter.EXPECT().SetOutcome(0, series1, nil)
+		batchWriter.EXPECT().Durability().Return(requested)
+		batchWriter.EXPECT().SetDurability(ts.DurabilityCommitLogBuffer)
+		mockCL.EXPECT().WriteBatch(ctx, batchWriter).Return(nil)
+		require.NoError(t, d.WriteBatch(ctx, namespace, batchWriter, &fakeIndexedErrorHandler{}))
+	}
+
+	// Batches requesting a stronger durability than the strategy keep it.
+	batchWriter := ts.NewMockWriteBatch(ctrl)
+	batchWriter.EXPECT().Iter().Return([]ts.BatchWrite{{Write: write}})
+	batchWriter.EXPECT().SetOutcome(0, series1, nil)
+	batchWriter.EXPECT().Durability().Return(ts.DurabilityFsync)
+	batchWriter.EXPECT().SetDurability(ts.DurabilityFsync)
+	mockCL.EXPECT().WriteBatch(ctx, batchWriter).Return(nil)
+	require.NoError(t, d.WriteBatch(ctx, namespace, batchWriter, &fakeIndexedErrorHandler{}))
+
+	d.commitLog = cl
+	require.NoError(t, d.Close())
+}
+
+func TestDatabaseWriteBatchFutureWriteClampCommitLogRoundTrip(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	now := time.Now().Truncate(time.Second)
+	dbOpts := DefaultTestOptions()
+	dbOpts = dbOpts.
+		SetClockOptions(dbOpts.ClockOptions().SetNowFn(func() time.Time {
+			return now
+		})).
+		SetCommitLogOptions(dbOpts.CommitLogOptions().
+			SetFilesystemOptions(fs.NewOptions().SetFilePathPrefix(dir)))
+
+	d, mapCh, _ := newTestDatabase(t, ctrl, newTestDatabaseOpt{
+		bs:    BootstrapNotStarted,
+		nsMap: testNamespaceMap(t),
+		dbOpt: dbOpts,
+	})
+	defer func() {
+		close(mapCh)
+	}()
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	nsOptions := namespace.NewOptions().
+		SetFutureWriteOptions(namespace.FutureWriteOptions{
+			Enabled:   true,
+			Tolerance: time.Minute,
+			Action:    namespace.FutureWriteClamp,
+		})
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
+	ns.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+	ns.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
+	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
+	ns.EXPECT().Close().Return(nil).Times(1)
+	require.NoError(t, d.Open())
+
+	var (
+		nsID    = ident.StringID("testns")
+		id      = ident.StringID("foo")
+		ctx     = context.NewContext()
+		clamped = now.Add(time.Minute)
+		written = ts.Series{
+			UniqueIndex: 0,
+			Namespace:   nsID,
+			ID:          id,
+		}
+	)
+
+	// The namespace and the commit log must both see the clamped timestamp.
+	ns.EXPECT().Write(ctx, id, clamped, 1.0, xtime.Second, nil).
+		Return(written, true, series.WriteAccepted, nil)
+
+	batchWriter, err := d.BatchWriter(nsID, 1)
+	require.NoError(t, err)
+	require.NoError(t, batchWriter.Add(0, id, now.Add(time.Hour), 1.0, xtime.Second, nil))
+
+	handler := &fakeIndexedWriteDispositionHandler{}
+	require.NoError(t, d.WriteBatch(ctx, nsID, batchWriter, handler))
+	require.Equal(t, 0, len(handler.errs))
+	require.Equal(t, []series.WriteDisposition{series.WriteClamped}, handler.dispositions)
+	require.NoError(t, d.Close())
+
+	// Replaying the commit log restores the write at the clamped timestamp.
+	iter, corruptFiles, err := commitlog.NewIterator(commitlog.IteratorOpts{
+		CommitLogOptions:    dbOpts.CommitLogOptions(),
+		FileFilterPredicate: commitlog.ReadAllPredicate(),
+	})
+	require.NoError(t, err)
+	require.Equal(t, 0, len(corruptFiles))
+	defer iter.Close()
+
+	require.True(t, iter.Next())
+	entry := iter.Current()
+	require.True(t, id.Equal(entry.Series.ID))
+	require.True(t, clamped.Equal(entry.Datapoint.Timestamp))
+	require.Equal(t, 1.0, entry.Datapoint.Value)
+	require.False(t, iter.Next())
+	require.NoError(t, iter.Err())
+}
+
+func TestDatabaseWriteTaggedMirror(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	d, mapCh, _ := defaultTestDatabase(t, ctrl, BootstrapNotStarted)
+	defer func() {
+		close(mapCh)
+	}()
+
+	mockCL := commitlog.NewMockCommitLog(ctrl)
+	cl := d.commitLog
+	d.commitLog = mockCL
+
+	ns := dbAddNewMockNamespace(ctrl, d, "testns")
+	shadow := dbAddNewMockNamespace(ctrl, d, "shadow")
+	nsOptions := namespace.NewOptions().
+		SetWritesToCommitLog(false).
+		SetMirrorOptions(namespace.MirrorOptions{
+			Enabled:         true,
+			TargetNamespace: "shadow",
+			Percentage:      100,
+			Matchers:        []namespace.MirrorMatcher{{Name: "service", Value: "api"}},
+		})
+	for _, n := range []*MockdatabaseNamespace{ns, shadow} {
+		n.EXPECT().GetOwnedShards().Return([]databaseShard{}).AnyTimes()
+		n.EXPECT().Tick(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		n.EXPECT().BootstrapState().Return(ShardBootstrapStates{}).AnyTimes()
+		n.EXPECT().Close().Return(nil).Times(1)
+	}
+	ns.EXPECT().Options().Return(nsOptions).AnyTimes()
+	shadow.EXPECT().Options().Return(namespace.NewOptions()).AnyTimes()
+	require.NoError(t, d.Open())
+
+	var (
+		ctx     = context.NewContext()
+		id      = ident.StringID("foo")
+		now     = time.Now()
+		series1 = ts.Series{UniqueIndex: 1}
+		series2 = ts.Series{UniqueIndex: 2}
+	)
+
+	// Matching writes are mirrored into the shadow namespace.
+	matching := ident.NewTagsIterator(ident.NewTags(ident.StringTag("service", "api")))
+	ns.EXPECT().WriteTagged(ctx, id, matching, now, 1.0, xtime.Second, nil).
+		Return(series1, true, series.WriteAccepted, nil)
+	shadow.EXPECT().WriteTagged(ctx, id, gomock.Any(), now, 1.0, xtime.Second, nil).
+		Return(series2, true, series.WriteAccepted, nil)
+	mockCL.EXPECT().Write(ctx, series2, ts.Datapoint{Timestamp: now, Value: 1.0},
+		xtime.Second, nil).Return(nil)
+	require.NoError(t, d.WriteTagged(ctx, ident.StringID("testns"), id, matching,
+		now, 1.0, xtime.Second, nil))
+
+	// Writes that do not match are not mirrored.
+	other := ident.NewTagsIterator(ident.NewTags(ident.StringTag("service", "web")))
+	ns.EXPECT().WriteTagged(ctx, id, other, now, 2.0, xtime.Second, nil).
+		Return(series1, true, series.WriteAccepted, nil)
+	require.NoError(t, d.WriteTagged(ctx, ident.StringID("testns"), id, other,
+		now, 2.0, xtime.Second, nil))
+
+	// Errors mirroring writes do not fail the original write.
+	ns.EXPECT().WriteTagged(ctx, id, matching, now, 3.0, xtime.Second, nil).
+		Return(series1, true, series.WriteAccepted, nil)
+	shadow.EXPECT().WriteTagged(ctx, id, gomock.Any(), now, 3.0, xtime.Second, nil).
+		Return(series2, false, series.WriteAccepted, errors.New("shadow error"))
+	require.NoError(t, d.WriteTagged(ctx, ident.StringID("testns"), id, matching,
+		now, 3.0, xtime.Second, nil))
+
+	d.commitLog = cl
+	require.NoError(t, d.Close())
+}
+
 func TestDatabaseIsOverloaded(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -34,6 +34,14 @@ var (
 	// ErrTooPast is returned for a write which is too far in the past.
 	ErrTooPast = xerrors.NewInvalidParamsError(errors.New("datapoint is too far in the past"))
 
+	// ErrTooFutureTolerance is returned for a write which is further in the
+	// future than the future write tolerance of the namespace.
+	ErrTooFutureTolerance = xerrors.NewInvalidParamsError(errors.New("datapoint is beyond the future write tolerance"))
+
+	// ErrBlockImmutable is returned for a write into a block that has been
+	// archived and is immutable.
+	ErrBlockImmutable = xerrors.NewInvalidParamsError(errors.New("datapoint is in an immutable archived block"))
+
 	// ErrColdWritesNotEnabled is returned when cold writes are disabled
 	// and a write is too far in the past or future. Note, the error intentionally
 	// excludes anything regarding the cold writes feature until its release.
@@ -63,3 +71,64 @@ func IsUnknownNamespaceError(err error) bool {
 	_, ok := nsErr.(unknownNamespace)
 	return ok
 }
+
+// CardinalityThrottledError is returned when a write that would create a new
+// series is rejected because the shard has exhausted its budget for creating
+// new series.
+type CardinalityThrottledError struct {
+	Shard uint32
+}
+
+func (e CardinalityThrottledError) Error() string {
+	return fmt.Sprintf("new series creation throttled for shard %d: cardinality limit exceeded", e.Shard)
+}
+
+// NewCardinalityThrottledError returns a new cardinality throttled error
+// marked as resource exhausted so clients back off rather than retry
+// immediately.
+func NewCardinalityThrottledError(shard uint32) error {
+	return xerrors.NewResourceExhaustedError(CardinalityThrottledError{Shard: shard})
+}
+
+// IsCardinalityThrottledError returns whether the error is, or wraps, a
+// cardinality throttled error.
+func IsCardinalityThrottledError(err error) bool {
+	for err != nil {
+		if _, ok := err.(CardinalityThrottledError); ok {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+type indexError struct {
+	err error
+}
+
+// NewIndexError returns a new error indicating that a write was applied to
+// the series but could not be inserted into the reverse index. The inner
+// error is preserved so that any retryable or resource exhausted
+// classification of it still applies.
+func NewIndexError(err error) error {
+	return indexError{err: err}
+}
+
+func (e indexError) Error() string {
+	return fmt.Sprintf("index insert failed: %v", e.err)
+}
+
+func (e indexError) InnerError() error {
+	return e.err
+}
+
+// IsIndexError returns whether the error is, or wraps, an index error.
+func IsIndexError(err error) bool {
+	for err != nil {
+		if _, ok := err.(indexError); ok {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
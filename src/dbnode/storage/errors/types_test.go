@@ -21,8 +21,11 @@
 package errors
 
 import (
+	"errors"
 	"testing"
 
+	xerrors "github.com/m3db/m3/src/x/errors"
+
 	"github.com/stretchr/testify/require"
 )
 
@@ -31,3 +34,23 @@ func TestUnknownNamespaceError(t *testing.T) {
 	require.Equal(t, "unknown namespace: ns", err.Error())
 	require.True(t, IsUnknownNamespaceError(err))
 }
+
+func TestCardinalityThrottledError(t *testing.T) {
+	err := NewCardinalityThrottledError(3)
+	require.Equal(t,
+		"new series creation throttled for shard 3: cardinality limit exceeded",
+		err.Error())
+	require.True(t, IsCardinalityThrottledError(err))
+	require.True(t, xerrors.IsResourceExhaustedError(err))
+	require.False(t, IsCardinalityThrottledError(errors.New("other")))
+}
+
+func TestIndexError(t *testing.T) {
+	inner := xerrors.NewResourceExhaustedError(errors.New("queue full"))
+	err := NewIndexError(inner)
+	require.Equal(t, "index insert failed: queue full", err.Error())
+	require.True(t, IsIndexError(err))
+	require.True(t, IsIndexError(xerrors.NewRetryableError(err)))
+	require.True(t, xerrors.IsResourceExhaustedError(err))
+	require.False(t, IsIndexError(inner))
+}
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+type expiryDownsampleWriter interface {
+	WriteTagged(
+		ctx context.Context,
+		namespace ident.ID,
+		id ident.ID,
+		tags ident.TagIterator,
+		timestamp time.Time,
+		value float64,
+		unit xtime.Unit,
+		annotation []byte,
+	) error
+}
+
+type expiryDownsampleHook struct {
+	writer      expiryDownsampleWriter
+	target      ident.ID
+	resolution  time.Duration
+	contextPool context.Pool
+}
+
+// newExpiryDownsampleHook returns a hook that rolls up expiring series to the
+// configured resolution, keeping the last datapoint of every resolution
+// window, and writes them to the target namespace.
+func newExpiryDownsampleHook(
+	writer expiryDownsampleWriter,
+	opts namespace.ExpiryDownsampleOptions,
+	contextPool context.Pool,
+) BlockExpiryHook {
+	return &expiryDownsampleHook{
+		writer:      writer,
+		target:      ident.StringID(opts.TargetNamespace),
+		resolution:  opts.Resolution,
+		contextPool: contextPool,
+	}
+}
+
+func (h *expiryDownsampleHook) OnExpiringSeries(
+	_ ExpiringBlock,
+	id ident.ID,
+	tags ident.Tags,
+	iter encoding.Iterator,
+) error {
+	ctx := h.contextPool.Get()
+	defer ctx.Close()
+
+	var (
+		pending           bool
+		pendingWindow     time.Time
+		pendingDatapoint  ts.Datapoint
+		pendingUnit       xtime.Unit
+		pendingAnnotation ts.Annotation
+	)
+	write := func() error {
+		tagsIter := ident.NewTagsIterator(tags)
+		return h.writer.WriteTagged(ctx, h.target, id, tagsIter,
+			pendingDatapoint.Timestamp, pendingDatapoint.Value,
+			pendingUnit, pendingAnnotation)
+	}
+	for iter.Next() {
+		dp, unit, annotation := iter.Current()
+		window := dp.Timestamp.Truncate(h.resolution)
+		if pending && !window.Equal(pendingWindow) {
+			if err := write(); err != nil {
+				return err
+			}
+		}
+		// Annotations may be reused by the iterator, so keep a copy of the
+		// one that belongs to the last datapoint of the window.
+		pending = true
+		pendingWindow = window
+		pendingDatapoint = dp
+		pendingUnit = unit
+		pendingAnnotation = append(pendingAnnotation[:0], annotation...)
+	}
+	if err := iter.Err(); err != nil {
+		return err
+	}
+	if pending {
+		return write()
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testExpiryDownsampleWrite struct {
+	namespace string
+	id        string
+	tags      int
+	timestamp time.Time
+	value     float64
+}
+
+type testExpiryDownsampleWriter struct {
+	writes []testExpiryDownsampleWrite
+}
+
+func (w *testExpiryDownsampleWriter) WriteTagged(
+	_ context.Context,
+	namespace ident.ID,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	_ xtime.Unit,
+	_ []byte,
+) error {
+	w.writes = append(w.writes, testExpiryDownsampleWrite{
+		namespace: namespace.String(),
+		id:        id.String(),
+		tags:      tags.Remaining(),
+		timestamp: timestamp,
+		value:     value,
+	})
+	return nil
+}
+
+func newTestExpiryDownsampleIter(
+	t *testing.T,
+	start time.Time,
+	datapoints []ts.Datapoint,
+) encoding.Iterator {
+	encoder := m3tsz.NewEncoder(start, nil, true, encoding.NewOptions())
+	for _, dp := range datapoints {
+		require.NoError(t, encoder.Encode(dp, xtime.Second, nil))
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	stream, ok := encoder.Stream(ctx)
+	require.True(t, ok)
+	return m3tsz.NewReaderIterator(stream, true, encoding.NewOptions())
+}
+
+func TestExpiryDownsampleHookKeepsLastDatapointPerWindow(t *testing.T) {
+	var (
+		opts   = DefaultTestOptions()
+		writer = &testExpiryDownsampleWriter{}
+		hook   = newExpiryDownsampleHook(writer, namespace.ExpiryDownsampleOptions{
+			Enabled:         true,
+			TargetNamespace: "metrics_1y",
+			Resolution:      time.Minute,
+		}, opts.ContextPool())
+		start = time.Now().Truncate(2 * time.Hour)
+		tags  = ident.NewTags(ident.StringTag("foo", "bar"))
+	)
+
+	iter := newTestExpiryDownsampleIter(t, start, []ts.Datapoint{
+		{Timestamp: start, Value: 1},
+		{Timestamp: start.Add(30 * time.Second), Value: 2},
+		{Timestamp: start.Add(time.Minute), Value: 3},
+		{Timestamp: start.Add(3*time.Minute + 10*time.Second), Value: 4},
+		{Timestamp: start.Add(3*time.Minute + 50*time.Second), Value: 5},
+	})
+	defer iter.Close()
+
+	block := ExpiringBlock{
+		Namespace:  ident.StringID("metrics_raw"),
+		BlockStart: start,
+		BlockSize:  2 * time.Hour,
+	}
+	require.NoError(t, hook.OnExpiringSeries(block, ident.StringID("foo"), tags, iter))
+
+	require.Equal(t, []testExpiryDownsampleWrite{
+		{namespace: "metrics_1y", id: "foo", tags: 1, timestamp: start.Add(30 * time.Second), value: 2},
+		{namespace: "metrics_1y", id: "foo", tags: 1, timestamp: start.Add(time.Minute), value: 3},
+		{namespace: "metrics_1y", id: "foo", tags: 1, timestamp: start.Add(3*time.Minute + 50*time.Second), value: 5},
+	}, writer.writes)
+}
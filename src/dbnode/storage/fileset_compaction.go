@@ -0,0 +1,314 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+// CompactFileSets compacts the filesets of the blocks of at most limit
+// compacted blocks of the namespace that have aged past the compaction age
+// into a single fileset for each compacted block, returning the number of
+// compacted blocks written.
+func (n *dbNamespace) CompactFileSets(limit int) (int, error) {
+	n.RLock()
+	if n.bootstrapState != Bootstrapped {
+		n.RUnlock()
+		return 0, errNamespaceNotBootstrapped
+	}
+	nopts := n.nopts
+	n.RUnlock()
+
+	if limit <= 0 || nopts.InMemory() || !nopts.FlushEnabled() ||
+		!nopts.CompactionOptions().Enabled {
+		return 0, nil
+	}
+
+	var (
+		multiErr  = xerrors.NewMultiError()
+		compacted int
+	)
+	for _, shard := range n.GetOwnedShards() {
+		if compacted >= limit {
+			break
+		}
+		shardCompacted, err := shard.CompactFileSets(limit - compacted)
+		compacted += shardCompacted
+		if err != nil {
+			detailedErr := fmt.Errorf("shard %d failed to compact filesets: %v",
+				shard.ID(), err)
+			multiErr = multiErr.Add(detailedErr)
+			// Continue with remaining shards.
+		}
+	}
+
+	return compacted, multiErr.FinalError()
+}
+
+// CompactFileSets compacts the filesets of the blocks of at most limit
+// compacted blocks of the shard into a single fileset for each compacted
+// block, returning the number of compacted blocks written.
+//
+// A compacted block is only compacted once all of its blocks are immutable
+// and none of them are frozen. The compacted fileset is written as the next
+// volume after the latest volume of any of its blocks and is verified before
+// it is made visible to readers by marking every block of the compacted
+// block as flushed at its volume, the superseded volumes of the blocks are
+// then removed by the cleanup of compacted filesets. Since the candidates are
+// determined from the info files on disk every time, the compaction resumes
+// where it left off after a restart.
+func (s *dbShard) CompactFileSets(limit int) (int, error) {
+	s.RLock()
+	if s.bootstrapState != Bootstrapped {
+		s.RUnlock()
+		return 0, errShardNotBootstrappedToFlush
+	}
+	s.RUnlock()
+
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	fsOpts := s.opts.CommitLogOptions().FilesystemOptions()
+	// The filesets of a shard that has not yet been split from its parent
+	// shard are compacted once they are split.
+	_, pendingSplit, err := fs.ShardSplitParent(fsOpts.FilePathPrefix(), s.namespace, s.ID())
+	if err != nil || pendingSplit {
+		return 0, err
+	}
+
+	var (
+		nsOpts         = s.namespace.Options()
+		blockSize      = nsOpts.RetentionOptions().BlockSize()
+		compactionOpts = nsOpts.CompactionOptions()
+		now            = s.nowFn()
+		candidates     = make(map[xtime.UnixNano]struct{})
+		compacted      = make(map[xtime.UnixNano]struct{})
+	)
+	results := fs.ReadInfoFiles(fsOpts.FilePathPrefix(), s.namespace.ID(), s.ID(),
+		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	for _, result := range results {
+		if result.Err.Error() != nil {
+			// Unreadable info files are reported when flush states are
+			// updated, there is nothing to compact them from.
+			continue
+		}
+
+		blockStart := xtime.FromNanoseconds(result.Info.BlockStart)
+		compactedBlockStart := xtime.ToUnixNano(compactionOpts.CompactedBlockStart(blockStart))
+		if time.Duration(result.Info.BlockSize) > blockSize {
+			compacted[compactedBlockStart] = struct{}{}
+			continue
+		}
+		candidates[compactedBlockStart] = struct{}{}
+	}
+
+	toCompact := make([]time.Time, 0, len(candidates))
+	for compactedBlockStart := range candidates {
+		if _, ok := compacted[compactedBlockStart]; ok {
+			continue
+		}
+		if !s.isBlockCompactable(compactedBlockStart.ToTime(), now) {
+			continue
+		}
+		toCompact = append(toCompact, compactedBlockStart.ToTime())
+	}
+	// Compact the oldest blocks first.
+	sort.Slice(toCompact, func(i, j int) bool {
+		return toCompact[i].Before(toCompact[j])
+	})
+	if len(toCompact) > limit {
+		toCompact = toCompact[:limit]
+	}
+
+	var (
+		multiErr xerrors.MultiError
+		written  int
+	)
+	for _, compactedBlockStart := range toCompact {
+		ok, err := s.compactBlock(compactedBlockStart)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if ok {
+			written++
+		}
+	}
+
+	return written, multiErr.FinalError()
+}
+
+// isBlockCompactable returns whether the compacted block starting at the
+// given time can be compacted, which requires every block it holds to be
+// immutable and none of them to be frozen.
+func (s *dbShard) isBlockCompactable(compactedBlockStart time.Time, now time.Time) bool {
+	var (
+		nsOpts         = s.namespace.Options()
+		blockSize      = nsOpts.RetentionOptions().BlockSize()
+		archivalOpts   = nsOpts.ArchivalOptions()
+		compactionOpts = nsOpts.CompactionOptions()
+	)
+	if !compactionOpts.IsBlockCompactable(compactedBlockStart, now) {
+		return false
+	}
+	end := compactedBlockStart.Add(compactionOpts.BlockSize)
+	for blockStart := compactedBlockStart; blockStart.Before(end); blockStart = blockStart.Add(blockSize) {
+		if !archivalOpts.IsBlockImmutable(blockStart, blockSize, now) {
+			return false
+		}
+		if s.freezes.IsBlockFrozen(blockStart, blockSize) {
+			return false
+		}
+	}
+	return true
+}
+
+// compactBlock writes the compacted fileset of the compacted block starting
+// at the given time, returning false if none of its blocks have a fileset.
+func (s *dbShard) compactBlock(compactedBlockStart time.Time) (bool, error) {
+	var (
+		fsOpts         = s.opts.CommitLogOptions().FilesystemOptions()
+		filePathPrefix = fsOpts.FilePathPrefix()
+		nsOpts         = s.namespace.Options()
+		blockSize      = nsOpts.RetentionOptions().BlockSize()
+		compactionOpts = nsOpts.CompactionOptions()
+		compactedBlock = xtime.Range{
+			Start: compactedBlockStart,
+			End:   compactedBlockStart.Add(compactionOpts.BlockSize),
+		}
+		sources []fs.FileSetFileIdentifier
+	)
+	for blockStart := compactedBlock.Start; blockStart.Before(compactedBlock.End); blockStart = blockStart.Add(blockSize) {
+		coldVersion, err := s.RetrievableBlockColdVersion(blockStart)
+		if err != nil {
+			return false, err
+		}
+		exists, err := fs.DataFileSetExists(filePathPrefix, s.namespace.ID(), s.ID(),
+			blockStart, coldVersion)
+		if err != nil {
+			return false, err
+		}
+		if !exists {
+			// The shard may not have been assigned to the node yet at the
+			// time the block was flushed.
+			continue
+		}
+		sources = append(sources, fs.FileSetFileIdentifier{
+			Namespace:   s.namespace.ID(),
+			Shard:       s.ID(),
+			BlockStart:  blockStart,
+			VolumeIndex: coldVersion,
+		})
+	}
+	if len(sources) == 0 {
+		return false, nil
+	}
+
+	files, err := fs.DataFiles(filePathPrefix, s.namespace.ID(), s.ID())
+	if err != nil {
+		return false, err
+	}
+	target := fs.FileSetFileIdentifier{
+		Namespace:   s.namespace.ID(),
+		Shard:       s.ID(),
+		BlockStart:  compactedBlockStart,
+		VolumeIndex: fs.NextCompactedFileSetVolume(files, compactedBlock),
+	}
+
+	reader, err := fs.NewReader(s.opts.BytesPool(), fsOpts)
+	if err != nil {
+		return false, err
+	}
+	writer, err := fs.NewWriter(fsOpts)
+	if err != nil {
+		return false, err
+	}
+	if _, err := fs.CompactFileSets(reader, writer, s.opts.BytesPool(),
+		s.identifierPool, fsOpts, fs.CompactFileSetsOptions{
+			Sources:   sources,
+			Target:    target,
+			BlockSize: compactionOpts.BlockSize,
+		}); err != nil {
+		return false, err
+	}
+
+	// Verify the compacted fileset before marking its blocks as flushed,
+	// until then reads continue to be served from the filesets of each block.
+	if _, err := fs.VerifyDataFileSetContents(reader, target); err != nil {
+		if delErr := fs.DeleteFileSetAt(filePathPrefix, s.namespace.ID(),
+			s.ID(), compactedBlockStart, target.VolumeIndex); delErr != nil {
+			s.logger.Error("unable to delete compacted fileset that failed verification",
+				zap.Stringer("namespace", s.namespace.ID()),
+				zap.Uint32("shard", s.ID()),
+				zap.Time("blockStart", compactedBlockStart),
+				zap.Int("volume", target.VolumeIndex),
+				zap.Error(delErr))
+		}
+		return false, fmt.Errorf("compacted fileset for block %s failed verification: %v",
+			compactedBlockStart.String(), err)
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for blockStart := compactedBlock.Start; blockStart.Before(compactedBlock.End); blockStart = blockStart.Add(blockSize) {
+		if err := s.markColdVersionFlushed(blockStart, target.VolumeIndex); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return true, multiErr.FinalError()
+}
+
+// compactedFileSets returns the block sizes of the compacted filesets of the
+// shard by their block start and volume.
+func (s *dbShard) compactedFileSets() map[compactedFileSetKey]time.Duration {
+	var (
+		fsOpts    = s.opts.CommitLogOptions().FilesystemOptions()
+		blockSize = s.namespace.Options().RetentionOptions().BlockSize()
+		compacted = make(map[compactedFileSetKey]time.Duration)
+	)
+	results := fs.ReadInfoFiles(fsOpts.FilePathPrefix(), s.namespace.ID(), s.ID(),
+		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	for _, result := range results {
+		if result.Err.Error() != nil {
+			continue
+		}
+		if size := time.Duration(result.Info.BlockSize); size > blockSize {
+			compacted[compactedFileSetKey{
+				blockStart: xtime.UnixNano(result.Info.BlockStart),
+				volume:     result.Info.VolumeIndex,
+			}] = size
+		}
+	}
+	return compacted
+}
+
+type compactedFileSetKey struct {
+	blockStart xtime.UnixNano
+	volume     int
+}
@@ -0,0 +1,255 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+// MigrateFileSets rewrites filesets of the namespace that were written with
+// an older format version to the current format, migrating at most limit
+// blocks and returning the number of blocks migrated. The filesets of the
+// parent shards of a namespace whose shard space has been split are split
+// into the filesets of its shards first and count towards the limit.
+func (n *dbNamespace) MigrateFileSets(
+	flushPersist persist.FlushPreparer,
+	limit int,
+) (int, error) {
+	n.RLock()
+	if n.bootstrapState != Bootstrapped {
+		n.RUnlock()
+		return 0, errNamespaceNotBootstrapped
+	}
+	nsCtx := n.nsContextWithRLock()
+	nopts := n.nopts
+	n.RUnlock()
+
+	if limit <= 0 || nopts.InMemory() || !nopts.FlushEnabled() {
+		return 0, nil
+	}
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		migrated int
+	)
+	split, err := n.splitFileSets(limit)
+	migrated += split
+	if err != nil {
+		multiErr = multiErr.Add(err)
+	}
+
+	resources, err := newColdFlushReuseableResources(n.opts)
+	if err != nil {
+		return migrated, multiErr.Add(err).FinalError()
+	}
+
+	for _, shard := range n.GetOwnedShards() {
+		if migrated >= limit {
+			break
+		}
+		shardMigrated, err := shard.MigrateFileSets(flushPersist, resources,
+			limit-migrated, nsCtx)
+		migrated += shardMigrated
+		if err != nil {
+			detailedErr := fmt.Errorf("shard %d failed to migrate filesets: %v",
+				shard.ID(), err)
+			multiErr = multiErr.Add(detailedErr)
+			// Continue with remaining shards.
+		}
+	}
+
+	return migrated, multiErr.FinalError()
+}
+
+// MigrateFileSets rewrites the filesets of at most limit blocks of the shard
+// whose most recent volume was written with an older format version to the
+// current format, returning the number of blocks migrated.
+//
+// Each block is rewritten into the next volume of its fileset and the new
+// volume is verified before it is made visible to readers, the superseded
+// volume is then removed by the cleanup of compacted filesets. Since the
+// candidates are determined from the info files on disk every time, the
+// migration resumes where it left off after a restart and a volume that was
+// only partially written before a crash is never read as it has no
+// checkpoint file.
+func (s *dbShard) MigrateFileSets(
+	flushPreparer persist.FlushPreparer,
+	resources coldFlushReuseableResources,
+	limit int,
+	nsCtx namespace.Context,
+) (int, error) {
+	s.RLock()
+	if s.bootstrapState != Bootstrapped {
+		s.RUnlock()
+		return 0, errShardNotBootstrappedToFlush
+	}
+	s.RUnlock()
+
+	if limit <= 0 {
+		return 0, nil
+	}
+
+	var (
+		fsOpts       = s.opts.CommitLogOptions().FilesystemOptions()
+		nsOpts       = s.namespace.Options()
+		blockSize    = nsOpts.RetentionOptions().BlockSize()
+		archivalOpts = nsOpts.ArchivalOptions()
+		now          = s.nowFn()
+		toMigrate    []time.Time
+	)
+	results := fs.ReadInfoFiles(fsOpts.FilePathPrefix(), s.namespace.ID(), s.ID(),
+		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	for _, result := range results {
+		if len(toMigrate) >= limit {
+			break
+		}
+		if result.Err.Error() != nil {
+			// Unreadable info files are reported when flush states are
+			// updated, there is nothing to migrate them from.
+			continue
+		}
+
+		info := result.Info
+		if !fs.DataFileSetRequiresMigration(info) {
+			continue
+		}
+		blockStart := xtime.FromNanoseconds(info.BlockStart)
+		if archivalOpts.IsBlockImmutable(blockStart, blockSize, now) {
+			// Archived blocks are immutable and must not be rewritten.
+			continue
+		}
+		if s.freezes.IsBlockFrozen(blockStart, blockSize) {
+			// Frozen blocks must not be rewritten.
+			continue
+		}
+		coldVersion, err := s.RetrievableBlockColdVersion(blockStart)
+		if err != nil {
+			return 0, err
+		}
+		if info.VolumeIndex != coldVersion {
+			// Superseded volumes are removed by the cleanup of compacted
+			// filesets and do not need to be migrated.
+			continue
+		}
+		toMigrate = append(toMigrate, blockStart)
+	}
+
+	if len(toMigrate) == 0 {
+		return 0, nil
+	}
+
+	var (
+		multiErr xerrors.MultiError
+		migrated int
+		merger   = s.newMergerFn(resources.fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+			s.opts.SegmentReaderPool(), s.opts.MultiReaderIteratorPool(),
+			s.opts.IdentifierPool(), s.opts.EncoderPool(), s.opts.ContextPool(), nsOpts)
+	)
+	for _, blockStart := range toMigrate {
+		if err := s.migrateBlock(merger, resources.fsReader, flushPreparer,
+			blockStart, nsCtx); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		migrated++
+	}
+
+	return migrated, multiErr.FinalError()
+}
+
+func (s *dbShard) migrateBlock(
+	merger fs.Merger,
+	fsReader fs.DataFileSetReader,
+	flushPreparer persist.FlushPreparer,
+	blockStart time.Time,
+	nsCtx namespace.Context,
+) error {
+	coldVersion, err := s.RetrievableBlockColdVersion(blockStart)
+	if err != nil {
+		return err
+	}
+
+	fsID := fs.FileSetFileIdentifier{
+		Namespace:   s.namespace.ID(),
+		Shard:       s.ID(),
+		BlockStart:  blockStart,
+		VolumeIndex: coldVersion,
+	}
+	nextVersion := coldVersion + 1
+	if err := merger.Merge(fsID, emptyMergeWith{}, nextVersion, flushPreparer, nsCtx); err != nil {
+		return err
+	}
+
+	// Verify the rewritten volume before marking it as flushed, until then
+	// reads continue to be served from the previous volume.
+	nextID := fsID
+	nextID.VolumeIndex = nextVersion
+	if _, err := fs.VerifyDataFileSetContents(fsReader, nextID); err != nil {
+		fsOpts := s.opts.CommitLogOptions().FilesystemOptions()
+		if delErr := fs.DeleteFileSetAt(fsOpts.FilePathPrefix(), s.namespace.ID(),
+			s.ID(), blockStart, nextVersion); delErr != nil {
+			s.logger.Error("unable to delete migrated fileset that failed verification",
+				zap.Stringer("namespace", s.namespace.ID()),
+				zap.Uint32("shard", s.ID()),
+				zap.Time("blockStart", blockStart),
+				zap.Int("volume", nextVersion),
+				zap.Error(delErr))
+		}
+		return fmt.Errorf("migrated fileset for block %s failed verification: %v",
+			blockStart.String(), err)
+	}
+
+	return s.markColdVersionFlushed(blockStart, nextVersion)
+}
+
+// emptyMergeWith is a merge target without any data, merging a fileset with
+// it rewrites the fileset as is.
+type emptyMergeWith struct{}
+
+func (emptyMergeWith) Read(
+	ctx context.Context,
+	seriesID ident.ID,
+	blockStart xtime.UnixNano,
+	nsCtx namespace.Context,
+) ([]xio.BlockReader, bool, error) {
+	return nil, false, nil
+}
+
+func (emptyMergeWith) ForEachRemaining(
+	ctx context.Context,
+	blockStart xtime.UnixNano,
+	fn fs.ForEachRemainingFn,
+	nsCtx namespace.Context,
+) error {
+	return nil
+}
@@ -0,0 +1,162 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type recordingMigrationMerger struct {
+	fileIDs []fs.FileSetFileIdentifier
+}
+
+func (m *recordingMigrationMerger) Merge(
+	fileID fs.FileSetFileIdentifier,
+	mergeWith fs.MergeWith,
+	nextVersion int,
+	flushPreparer persist.FlushPreparer,
+	nsCtx namespace.Context,
+) error {
+	m.fileIDs = append(m.fileIDs, fileID)
+	return nil
+}
+
+func TestShardMigrateFileSetsNotBootstrapped(t *testing.T) {
+	shard := testDatabaseShard(t, DefaultTestOptions())
+	defer shard.Close()
+
+	_, err := shard.MigrateFileSets(nil, coldFlushReuseableResources{}, 1,
+		namespace.Context{})
+	require.Equal(t, errShardNotBootstrappedToFlush, err)
+}
+
+func TestShardMigrateFileSetsSkipsCurrentFormat(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		opts   = DefaultTestOptions()
+		fsOpts = opts.CommitLogOptions().FilesystemOptions().
+			SetFilePathPrefix(dir)
+	)
+	opts = opts.SetCommitLogOptions(opts.CommitLogOptions().
+		SetFilesystemOptions(fsOpts))
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	writer, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+
+	var (
+		blockSize   = defaultTestRetentionOpts.BlockSize()
+		start       = time.Now().Truncate(blockSize).Add(-4 * blockSize)
+		blockStarts = []time.Time{start, start.Add(blockSize)}
+	)
+	for _, blockStart := range blockStarts {
+		require.NoError(t, writer.Open(fs.DataWriterOpenOptions{
+			FileSetType: persist.FileSetFlushType,
+			Identifier: fs.FileSetFileIdentifier{
+				Namespace:  defaultTestNs1ID,
+				Shard:      shard.ID(),
+				BlockStart: blockStart,
+			},
+			BlockSize: blockSize,
+		}))
+		require.NoError(t, writer.Close())
+	}
+	require.NoError(t, shard.Bootstrap())
+
+	merger := &recordingMigrationMerger{}
+	shard.newMergerFn = func(
+		reader fs.DataFileSetReader,
+		blockAllocSize int,
+		srPool xio.SegmentReaderPool,
+		multiIterPool encoding.MultiReaderIteratorPool,
+		identPool ident.Pool,
+		encoderPool encoding.EncoderPool,
+		contextPool context.Pool,
+		nsOpts namespace.Options,
+	) fs.Merger {
+		return merger
+	}
+
+	resources := coldFlushReuseableResources{
+		fsReader: fs.NewMockDataFileSetReader(ctrl),
+	}
+	migrated, err := shard.MigrateFileSets(persist.NewMockFlushPreparer(ctrl),
+		resources, 10, namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, 0, migrated)
+	require.Equal(t, 0, len(merger.fileIDs))
+
+	for _, blockStart := range blockStarts {
+		coldVersion, err := shard.RetrievableBlockColdVersion(blockStart)
+		require.NoError(t, err)
+		require.Equal(t, 0, coldVersion)
+	}
+}
+
+func TestEmptyMergeWithHasNoData(t *testing.T) {
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		mergeWith  emptyMergeWith
+		blockStart = xtime.ToUnixNano(time.Now())
+	)
+	readers, ok, err := mergeWith.Read(ctx, ident.StringID("foo"), blockStart,
+		namespace.Context{})
+	require.NoError(t, err)
+	require.False(t, ok)
+	require.Equal(t, 0, len(readers))
+
+	var remaining int
+	err = mergeWith.ForEachRemaining(ctx, blockStart, func(
+		seriesID ident.ID,
+		tags ident.Tags,
+		data []xio.BlockReader,
+	) error {
+		remaining++
+		return nil
+	}, namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, 0, remaining)
+}
@@ -29,6 +29,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	xerrors "github.com/m3db/m3/src/x/errors"
 
 	"github.com/pborman/uuid"
@@ -50,6 +51,7 @@ const (
 	flushManagerColdFlushInProgress
 	flushManagerSnapshotInProgress
 	flushManagerIndexFlushInProgress
+	flushManagerMigrationInProgress
 )
 
 type flushManager struct {
@@ -67,9 +69,14 @@ type flushManager struct {
 	isColdFlushing  tally.Gauge
 	isSnapshotting  tally.Gauge
 	isIndexFlushing tally.Gauge
+	isMigrating     tally.Gauge
+	blocksMigrated  tally.Counter
+	blocksCompacted tally.Counter
 	// This is a "debug" metric for making sure that the snapshotting process
 	// is not overly aggressive.
 	maxBlocksSnapshottedByNamespace tally.Gauge
+	unsnapshottedBytes              tally.Gauge
+	unsnapshottedSeries             tally.Gauge
 
 	lastSuccessfulSnapshotStartTime time.Time
 }
@@ -89,7 +96,12 @@ func newFlushManager(
 		isColdFlushing:                  scope.Gauge("cold-flush"),
 		isSnapshotting:                  scope.Gauge("snapshot"),
 		isIndexFlushing:                 scope.Gauge("index-flush"),
+		isMigrating:                     scope.Gauge("fileset-migration"),
+		blocksMigrated:                  scope.Counter("fileset-migration-blocks"),
+		blocksCompacted:                 scope.Counter("fileset-compaction-blocks"),
 		maxBlocksSnapshottedByNamespace: scope.Gauge("max-blocks-snapshotted-by-namespace"),
+		unsnapshottedBytes:              scope.Gauge("unsnapshotted-bytes"),
+		unsnapshottedSeries:             scope.Gauge("unsnapshotted-series"),
 	}
 }
 
@@ -120,8 +132,11 @@ func (m *flushManager) Flush(startTime time.Time) error {
 	// combination, we attempt a flush and then a cold flush before a snapshot
 	// as the snapshotting process will attempt to snapshot any unflushed blocks
 	// which would be wasteful if the block is already flushable.
+	scheduler := m.opts.BackgroundScheduler()
 	multiErr := xerrors.NewMultiError()
-	if err = m.dataWarmFlush(namespaces, startTime); err != nil {
+	if err = runBackgroundWork(scheduler, background.FlushWork, func() error {
+		return m.dataWarmFlush(namespaces, startTime)
+	}); err != nil {
 		multiErr = multiErr.Add(err)
 	}
 
@@ -149,7 +164,9 @@ func (m *flushManager) Flush(startTime time.Time) error {
 		// t13: memTracker.DecPendingLoadedBytes() --> (numLoadedBytes == 0, numPendingLoadedBytes == 0)
 		memTracker := m.opts.MemoryTracker()
 		memTracker.MarkLoadedAsPending()
-		if err = m.dataColdFlush(namespaces); err != nil {
+		if err = runBackgroundWork(scheduler, background.ColdFlushWork, func() error {
+			return m.dataColdFlush(namespaces)
+		}); err != nil {
 			multiErr = multiErr.Add(err)
 			// If cold flush fails, we can't proceed to snapshotting because
 			// commit log cleanup logic uses the presence of a successful
@@ -165,14 +182,36 @@ func (m *flushManager) Flush(startTime time.Time) error {
 		// value by however many bytes had been tracked when the cold flush began.
 		memTracker.DecPendingLoadedBytes()
 
-		if err = m.dataSnapshot(namespaces, startTime, rotatedCommitlogID); err != nil {
-			multiErr = multiErr.Add(err)
+		// Snapshot once the minimum interval has elapsed or sooner if enough
+		// data has been written since the last snapshot, so that the amount of
+		// commit log to replay after a crash is bounded regardless of the
+		// ingest rate.
+		if m.shouldSnapshot(startTime) {
+			if err = runBackgroundWork(scheduler, background.FlushWork, func() error {
+				return m.dataSnapshot(namespaces, startTime, rotatedCommitlogID)
+			}); err != nil {
+				multiErr = multiErr.Add(err)
+			}
 		}
 	} else {
 		multiErr = multiErr.Add(fmt.Errorf("error rotating commitlog in mediator tick: %v", err))
 	}
 
-	if err = m.indexFlush(namespaces); err != nil {
+	// Filesets written in an older format version are migrated and aged
+	// blocks are compacted in small batches once regular flushing is done so
+	// that rewriting historical data never holds up flushing newly written
+	// data.
+	if limit := m.opts.FileSetMigrationBlocksPerFlush(); limit > 0 {
+		if err = runBackgroundWork(scheduler, background.ColdFlushWork, func() error {
+			return m.dataMigrate(namespaces, limit)
+		}); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	if err = runBackgroundWork(scheduler, background.FlushWork, func() error {
+		return m.indexFlush(namespaces)
+	}); err != nil {
 		multiErr = multiErr.Add(err)
 	}
 
@@ -235,6 +274,48 @@ func (m *flushManager) dataColdFlush(
 	return multiErr.FinalError()
 }
 
+func (m *flushManager) dataMigrate(
+	namespaces []databaseNamespace,
+	limit int,
+) error {
+	flushPersist, err := m.pm.StartFlushPersist()
+	if err != nil {
+		return err
+	}
+
+	m.setState(flushManagerMigrationInProgress)
+	multiErr := xerrors.NewMultiError()
+	for _, ns := range namespaces {
+		if limit <= 0 {
+			break
+		}
+		migrated, err := ns.MigrateFileSets(flushPersist, limit)
+		limit -= migrated
+		m.blocksMigrated.Inc(int64(migrated))
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+
+		// Compacted blocks count towards the same limit as migrated blocks.
+		if limit <= 0 || !ns.Options().CompactionOptions().Enabled {
+			continue
+		}
+		compacted, err := ns.CompactFileSets(limit)
+		limit -= compacted
+		m.blocksCompacted.Inc(int64(compacted))
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	err = flushPersist.DoneFlush()
+	if err != nil {
+		multiErr = multiErr.Add(err)
+	}
+
+	return multiErr.FinalError()
+}
+
 func (m *flushManager) dataSnapshot(
 	namespaces []databaseNamespace,
 	startTime time.Time,
@@ -247,6 +328,11 @@ func (m *flushManager) dataSnapshot(
 		return err
 	}
 
+	// Only writes received before the snapshot began are guaranteed to be
+	// captured by it, so only those are cleared if it succeeds.
+	snapshotTracker := m.opts.SnapshotTracker()
+	snapshotTracker.MarkUnsnapshottedAsPending()
+
 	m.setState(flushManagerSnapshotInProgress)
 	var (
 		maxBlocksSnapshottedByNamespace = 0
@@ -286,10 +372,19 @@ func (m *flushManager) dataSnapshot(
 	finalErr := multiErr.FinalError()
 	if finalErr == nil {
 		m.lastSuccessfulSnapshotStartTime = startTime
+		snapshotTracker.DecPendingUnsnapshotted()
 	}
 	return finalErr
 }
 
+func (m *flushManager) shouldSnapshot(startTime time.Time) bool {
+	lastSnapshotStartTime, ok := m.LastSuccessfulSnapshotStartTime()
+	if !ok {
+		return true
+	}
+	return m.opts.SnapshotTracker().ShouldSnapshot(startTime.Sub(lastSnapshotStartTime))
+}
+
 func (m *flushManager) indexFlush(
 	namespaces []databaseNamespace,
 ) error {
@@ -343,6 +438,16 @@ func (m *flushManager) Report() {
 	} else {
 		m.isIndexFlushing.Update(0)
 	}
+
+	if state == flushManagerMigrationInProgress {
+		m.isMigrating.Update(1)
+	} else {
+		m.isMigrating.Update(0)
+	}
+
+	snapshotTracker := m.opts.SnapshotTracker()
+	m.unsnapshottedBytes.Update(float64(snapshotTracker.NumUnsnapshottedBytes()))
+	m.unsnapshottedSeries.Update(float64(snapshotTracker.NumUnsnapshottedSeries()))
 }
 
 func (m *flushManager) setState(state flushManagerState) {
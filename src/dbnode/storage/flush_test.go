@@ -364,6 +364,119 @@ func TestFlushManagerSkipNamespaceIndexingDisabled(t *testing.T) {
 	require.NoError(t, fm.Flush(now))
 }
 
+func TestFlushManagerMigratesFileSetsWithinLimit(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	var (
+		nsOpts              = defaultTestNs1Opts.SetIndexOptions(namespace.NewIndexOptions().SetEnabled(false))
+		mockFlushPersist    = persist.NewMockFlushPreparer(ctrl)
+		mockSnapshotPersist = persist.NewMockSnapshotPreparer(ctrl)
+		mockPersistManager  = persist.NewMockManager(ctrl)
+		namespaces          []databaseNamespace
+	)
+	for i := 0; i < 3; i++ {
+		ns := NewMockdatabaseNamespace(ctrl)
+		ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+		ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+		ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+		ns.EXPECT().WarmFlush(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		ns.EXPECT().ColdFlush(gomock.Any()).Return(nil).AnyTimes()
+		ns.EXPECT().Snapshot(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		namespaces = append(namespaces, ns)
+	}
+
+	// The third namespace must not be migrated once the limit is reached.
+	namespaces[0].(*MockdatabaseNamespace).EXPECT().
+		MigrateFileSets(mockFlushPersist, 3).Return(2, nil)
+	namespaces[1].(*MockdatabaseNamespace).EXPECT().
+		MigrateFileSets(mockFlushPersist, 1).Return(1, nil)
+
+	mockFlushPersist.EXPECT().DoneFlush().Return(nil).Times(3)
+	mockPersistManager.EXPECT().StartFlushPersist().Return(mockFlushPersist, nil).Times(3)
+
+	mockSnapshotPersist.EXPECT().DoneSnapshot(gomock.Any(), testCommitlogFile).Return(nil)
+	mockPersistManager.EXPECT().StartSnapshotPersist(gomock.Any()).Return(mockSnapshotPersist, nil)
+
+	mockIndexFlusher := persist.NewMockIndexFlush(ctrl)
+	mockIndexFlusher.EXPECT().DoneIndex().Return(nil)
+	mockPersistManager.EXPECT().StartIndexPersist().Return(mockIndexFlusher, nil)
+
+	testOpts := DefaultTestOptions().
+		SetPersistManager(mockPersistManager).
+		SetFileSetMigrationBlocksPerFlush(3)
+	db := newMockdatabase(ctrl)
+	db.EXPECT().Options().Return(testOpts).AnyTimes()
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil)
+
+	cl := commitlog.NewMockCommitLog(ctrl)
+	cl.EXPECT().RotateLogs().Return(testCommitlogFile, nil).AnyTimes()
+
+	fm := newFlushManager(db, cl, tally.NoopScope).(*flushManager)
+	fm.pm = mockPersistManager
+
+	now := time.Unix(0, 0)
+	require.NoError(t, fm.Flush(now))
+}
+
+func TestFlushManagerCompactsFileSetsWithinLimit(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	var (
+		nsOpts = defaultTestNs1Opts.
+			SetIndexOptions(namespace.NewIndexOptions().SetEnabled(false)).
+			SetCompactionOptions(namespace.CompactionOptions{Enabled: true})
+		mockFlushPersist    = persist.NewMockFlushPreparer(ctrl)
+		mockSnapshotPersist = persist.NewMockSnapshotPreparer(ctrl)
+		mockPersistManager  = persist.NewMockManager(ctrl)
+		namespaces          []databaseNamespace
+	)
+	for i := 0; i < 3; i++ {
+		ns := NewMockdatabaseNamespace(ctrl)
+		ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+		ns.EXPECT().ID().Return(defaultTestNs1ID).AnyTimes()
+		ns.EXPECT().NeedsFlush(gomock.Any(), gomock.Any()).Return(true, nil).AnyTimes()
+		ns.EXPECT().WarmFlush(gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		ns.EXPECT().ColdFlush(gomock.Any()).Return(nil).AnyTimes()
+		ns.EXPECT().Snapshot(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).AnyTimes()
+		namespaces = append(namespaces, ns)
+	}
+
+	// Compacted blocks count towards the limit of migrated blocks, so the
+	// remaining namespaces must not be migrated once the limit is reached.
+	namespaces[0].(*MockdatabaseNamespace).EXPECT().
+		MigrateFileSets(mockFlushPersist, 3).Return(1, nil)
+	namespaces[0].(*MockdatabaseNamespace).EXPECT().
+		CompactFileSets(2).Return(2, nil)
+
+	mockFlushPersist.EXPECT().DoneFlush().Return(nil).Times(3)
+	mockPersistManager.EXPECT().StartFlushPersist().Return(mockFlushPersist, nil).Times(3)
+
+	mockSnapshotPersist.EXPECT().DoneSnapshot(gomock.Any(), testCommitlogFile).Return(nil)
+	mockPersistManager.EXPECT().StartSnapshotPersist(gomock.Any()).Return(mockSnapshotPersist, nil)
+
+	mockIndexFlusher := persist.NewMockIndexFlush(ctrl)
+	mockIndexFlusher.EXPECT().DoneIndex().Return(nil)
+	mockPersistManager.EXPECT().StartIndexPersist().Return(mockIndexFlusher, nil)
+
+	testOpts := DefaultTestOptions().
+		SetPersistManager(mockPersistManager).
+		SetFileSetMigrationBlocksPerFlush(3)
+	db := newMockdatabase(ctrl)
+	db.EXPECT().Options().Return(testOpts).AnyTimes()
+	db.EXPECT().GetOwnedNamespaces().Return(namespaces, nil)
+
+	cl := commitlog.NewMockCommitLog(ctrl)
+	cl.EXPECT().RotateLogs().Return(testCommitlogFile, nil).AnyTimes()
+
+	fm := newFlushManager(db, cl, tally.NoopScope).(*flushManager)
+	fm.pm = mockPersistManager
+
+	now := time.Unix(0, 0)
+	require.NoError(t, fm.Flush(now))
+}
+
 func TestFlushManagerNamespaceIndexingEnabled(t *testing.T) {
 	ctrl := gomock.NewController(xtest.Reporter{T: t})
 	defer ctrl.Finish()
@@ -571,6 +684,62 @@ func TestFlushManagerFlushSnapshot(t *testing.T) {
 	require.Equal(t, now, lastSuccessfulSnapshot)
 }
 
+func TestFlushManagerSnapshotByIntervalOrUnsnapshottedBytes(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		mockPersistManager  = persist.NewMockManager(ctrl)
+		mockFlushPersist    = persist.NewMockFlushPreparer(ctrl)
+		mockSnapshotPersist = persist.NewMockSnapshotPreparer(ctrl)
+		mockIndexFlusher    = persist.NewMockIndexFlush(ctrl)
+		snapshotTracker     = NewSnapshotTracker(NewSnapshotTrackerOptions(time.Hour, 100, 0))
+	)
+
+	// Warm and cold flushes happen on every run.
+	mockFlushPersist.EXPECT().DoneFlush().Return(nil).Times(6)
+	mockPersistManager.EXPECT().StartFlushPersist().Return(mockFlushPersist, nil).Times(6)
+	mockIndexFlusher.EXPECT().DoneIndex().Return(nil).Times(3)
+	mockPersistManager.EXPECT().StartIndexPersist().Return(mockIndexFlusher, nil).Times(3)
+
+	// Snapshots only happen on the first and last runs.
+	mockSnapshotPersist.EXPECT().DoneSnapshot(gomock.Any(), testCommitlogFile).Return(nil).Times(2)
+	mockPersistManager.EXPECT().StartSnapshotPersist(gomock.Any()).Return(mockSnapshotPersist, nil).Times(2)
+
+	testOpts := DefaultTestOptions().
+		SetPersistManager(mockPersistManager).
+		SetSnapshotTracker(snapshotTracker)
+	db := NewMockdatabase(ctrl)
+	db.EXPECT().Options().Return(testOpts).AnyTimes()
+	db.EXPECT().GetOwnedNamespaces().Return(nil, nil).AnyTimes()
+
+	cl := commitlog.NewMockCommitLog(ctrl)
+	cl.EXPECT().RotateLogs().Return(testCommitlogFile, nil).AnyTimes()
+
+	fm := newFlushManager(db, cl, tally.NoopScope).(*flushManager)
+
+	// Always snapshot if there has not been a successful snapshot yet.
+	now := time.Unix(0, 0)
+	snapshotTracker.IncNumUnsnapshottedBytes(50)
+	require.NoError(t, fm.Flush(now))
+	require.Equal(t, int64(0), snapshotTracker.NumUnsnapshottedBytes())
+
+	// Neither the minimum interval has elapsed nor the threshold been exceeded.
+	snapshotTracker.IncNumUnsnapshottedBytes(50)
+	require.NoError(t, fm.Flush(now.Add(time.Minute)))
+	lastSuccessfulSnapshot, ok := fm.LastSuccessfulSnapshotStartTime()
+	require.True(t, ok)
+	require.Equal(t, now, lastSuccessfulSnapshot)
+
+	// Exceeding the threshold triggers a snapshot before the interval elapses.
+	snapshotTracker.IncNumUnsnapshottedBytes(50)
+	require.NoError(t, fm.Flush(now.Add(2*time.Minute)))
+	lastSuccessfulSnapshot, ok = fm.LastSuccessfulSnapshotStartTime()
+	require.True(t, ok)
+	require.Equal(t, now.Add(2*time.Minute), lastSuccessfulSnapshot)
+	require.Equal(t, int64(0), snapshotTracker.NumUnsnapshottedBytes())
+}
+
 type timesInOrder []time.Time
 
 func (a timesInOrder) Len() int           { return len(a) }
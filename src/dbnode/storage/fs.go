@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 
 	"go.uber.org/zap"
 )
@@ -65,7 +66,12 @@ type fileOpState struct {
 	// cold version that has been flushed and to validate lease requests from the SeekerManager when it
 	// receives a signal to open a new lease.
 	ColdVersionFlushed int
-	NumFailures        int
+	// RollupResolution is the resolution the latest volume of the block was
+	// rolled up to, zero if the block has not been rolled up. This is not
+	// persisted, so a block may be rolled up again after a restart which is
+	// harmless since rolling up to the same resolution is idempotent.
+	RollupResolution time.Duration
+	NumFailures      int
 }
 
 type runType int
@@ -153,7 +159,9 @@ func (m *fileSystemManager) Run(
 
 	// NB(xichen): perform data cleanup and flushing sequentially to minimize the impact of disk seeks.
 	flushFn := func() {
-		if err := m.Cleanup(t); err != nil {
+		if err := runBackgroundWork(m.opts.BackgroundScheduler(), background.CleanupWork, func() error {
+			return m.Cleanup(t)
+		}); err != nil {
 			m.log.Error("error when cleaning up data", zap.Time("time", t), zap.Error(err))
 		}
 		if err := m.Flush(t); err != nil {
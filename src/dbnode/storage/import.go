@@ -0,0 +1,477 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+const (
+	// importMaxStagedBytes is the maximum size of the encoded data staged in
+	// memory by an import before it is merged into the filesets on disk.
+	importMaxStagedBytes = 64 * 1024 * 1024
+)
+
+var (
+	errImportDatapointsNotSorted = xerrors.NewInvalidParamsError(
+		errors.New("import datapoints must be sorted by time for each series"))
+	errImportSeriesNotContiguous = xerrors.NewInvalidParamsError(
+		errors.New("import datapoints must be contiguous for each series"))
+	errImportBlockOutOfRetention = xerrors.NewInvalidParamsError(
+		errors.New("import datapoint is out of retention"))
+	errImportBlockImmutable = xerrors.NewInvalidParamsError(
+		errors.New("import datapoint is in an archived block"))
+	errImportBlockNotFlushed = xerrors.NewInvalidParamsError(
+		errors.New("import datapoint is in a block that has not been flushed yet, write it instead"))
+)
+
+// dbImportFileOps serializes imports writing to disk and pauses the
+// background file operations of the mediator while they do.
+type dbImportFileOps struct {
+	sync.Mutex
+
+	mediator databaseMediator
+}
+
+func newImportFileOps(mediator databaseMediator) importFileOps {
+	return &dbImportFileOps{mediator: mediator}
+}
+
+func (o *dbImportFileOps) DisableFileOps() {
+	o.Lock()
+	o.mediator.DisableFileOps()
+}
+
+func (o *dbImportFileOps) EnableFileOps() {
+	o.mediator.EnableFileOps()
+	o.Unlock()
+}
+
+type importBlockKey struct {
+	shard      uint32
+	blockStart xtime.UnixNano
+}
+
+type importedSeries struct {
+	id   ident.ID
+	tags ident.Tags
+	data []byte
+}
+
+// importBlock is the data imported for a single block of a shard.
+type importBlock struct {
+	shard      databaseShard
+	blockStart time.Time
+	series     []importedSeries
+	byID       map[string]int
+}
+
+func newImportBlock(shard databaseShard, blockStart time.Time) *importBlock {
+	return &importBlock{
+		shard:      shard,
+		blockStart: blockStart,
+		byID:       make(map[string]int),
+	}
+}
+
+// add adds the data of a series, returning false if the series has already
+// been added to the block.
+func (b *importBlock) add(id ident.ID, tags ident.Tags, data []byte) bool {
+	if _, ok := b.byID[string(id.Bytes())]; ok {
+		return false
+	}
+	b.byID[string(id.Bytes())] = len(b.series)
+	b.series = append(b.series, importedSeries{id: id, tags: tags, data: data})
+	return true
+}
+
+// importMergeWith implements fs.MergeWith, where the merge target is the
+// data imported for a single block of a shard.
+type importMergeWith struct {
+	block     *importBlock
+	blockSize time.Duration
+	merged    []bool
+}
+
+func newImportMergeWith(block *importBlock, blockSize time.Duration) fs.MergeWith {
+	return &importMergeWith{
+		block:     block,
+		blockSize: blockSize,
+		merged:    make([]bool, len(block.series)),
+	}
+}
+
+func (m *importMergeWith) Read(
+	ctx context.Context,
+	seriesID ident.ID,
+	blockStart xtime.UnixNano,
+	nsCtx namespace.Context,
+) ([]xio.BlockReader, bool, error) {
+	if blockStart != xtime.ToUnixNano(m.block.blockStart) {
+		return nil, false, nil
+	}
+	idx, ok := m.block.byID[string(seriesID.Bytes())]
+	if !ok || m.merged[idx] {
+		return nil, false, nil
+	}
+
+	m.merged[idx] = true
+	return m.blockReaders(idx), true, nil
+}
+
+func (m *importMergeWith) ForEachRemaining(
+	ctx context.Context,
+	blockStart xtime.UnixNano,
+	fn fs.ForEachRemainingFn,
+	nsCtx namespace.Context,
+) error {
+	if blockStart != xtime.ToUnixNano(m.block.blockStart) {
+		return nil
+	}
+	for idx, series := range m.block.series {
+		if m.merged[idx] {
+			continue
+		}
+
+		m.merged[idx] = true
+		if err := fn(series.id, series.tags, m.blockReaders(idx)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *importMergeWith) blockReaders(idx int) []xio.BlockReader {
+	data := checked.NewBytes(m.block.series[idx].data, nil)
+	segment := ts.NewSegment(data, nil, ts.FinalizeNone)
+	return []xio.BlockReader{{
+		SegmentReader: xio.NewSegmentReader(segment),
+		Start:         m.block.blockStart,
+		BlockSize:     m.blockSize,
+	}}
+}
+
+// namespaceImporter encodes the datapoints of an import into blocks and
+// stages them in memory, periodically merging the staged blocks into the
+// filesets on disk and registering the imported series with the index.
+type namespaceImporter struct {
+	ns        *dbNamespace
+	nsCtx     namespace.Context
+	fileOps   importFileOps
+	encoder   encoding.Encoder
+	fsReader  fs.DataFileSetReader
+	blockSize time.Duration
+	now       time.Time
+
+	staged      map[importBlockKey]*importBlock
+	stagedBytes int
+	result      ImportResult
+
+	hasSeries     bool
+	seriesID      ident.ID
+	seriesTags    ident.Tags
+	seriesShard   databaseShard
+	lastTimestamp time.Time
+
+	encoding   bool
+	blockStart time.Time
+}
+
+func newNamespaceImporter(
+	ns *dbNamespace,
+	nsCtx namespace.Context,
+	fileOps importFileOps,
+) (*namespaceImporter, error) {
+	fsReader, err := fs.NewReader(ns.opts.BytesPool(),
+		ns.opts.CommitLogOptions().FilesystemOptions())
+	if err != nil {
+		return nil, err
+	}
+
+	return &namespaceImporter{
+		ns:        ns,
+		nsCtx:     nsCtx,
+		fileOps:   fileOps,
+		encoder:   ns.opts.EncoderPool().Get(),
+		fsReader:  fsReader,
+		blockSize: ns.nopts.RetentionOptions().BlockSize(),
+		now:       ns.nowFn(),
+		staged:    make(map[importBlockKey]*importBlock),
+	}, nil
+}
+
+func (i *namespaceImporter) Import(iter ImportIterator) (ImportResult, error) {
+	defer i.encoder.Close()
+
+	for iter.Next() {
+		id, tags, dp, unit, annotation := iter.Current()
+		if !i.hasSeries || !id.Equal(i.seriesID) {
+			if err := i.finishBlock(); err != nil {
+				return i.result, err
+			}
+			if err := i.startSeries(id, tags); err != nil {
+				return i.result, err
+			}
+		}
+		if err := i.write(dp, unit, annotation); err != nil {
+			return i.result, err
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return i.result, err
+	}
+
+	if err := i.finishBlock(); err != nil {
+		return i.result, err
+	}
+	if err := i.apply(); err != nil {
+		return i.result, err
+	}
+	return i.result, nil
+}
+
+func (i *namespaceImporter) startSeries(id ident.ID, tags ident.TagIterator) error {
+	shard, _, err := i.ns.shardFor(id)
+	if err != nil {
+		return err
+	}
+
+	// Copy the ID and tags since they are only valid until the iterator
+	// is advanced and need to live until the staged blocks are applied.
+	seriesTags := ident.NewTags()
+	if tags != nil {
+		tagsIter := tags.Duplicate()
+		for tagsIter.Next() {
+			tag := tagsIter.Current()
+			seriesTags.Append(ident.Tag{
+				Name:  ident.BytesID(append([]byte(nil), tag.Name.Bytes()...)),
+				Value: ident.BytesID(append([]byte(nil), tag.Value.Bytes()...)),
+			})
+		}
+		err = tagsIter.Err()
+		tagsIter.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	i.hasSeries = true
+	i.seriesID = ident.BytesID(append([]byte(nil), id.Bytes()...))
+	i.seriesTags = seriesTags
+	i.seriesShard = shard
+	i.lastTimestamp = time.Time{}
+	i.result.NumSeries++
+	return nil
+}
+
+func (i *namespaceImporter) write(
+	dp ts.Datapoint,
+	unit xtime.Unit,
+	annotation ts.Annotation,
+) error {
+	if !i.lastTimestamp.IsZero() && !dp.Timestamp.After(i.lastTimestamp) {
+		return errImportDatapointsNotSorted
+	}
+	i.lastTimestamp = dp.Timestamp
+
+	blockStart := dp.Timestamp.Truncate(i.blockSize)
+	if !i.encoding || !blockStart.Equal(i.blockStart) {
+		if err := i.finishBlock(); err != nil {
+			return err
+		}
+		if err := i.validateBlock(blockStart); err != nil {
+			return err
+		}
+		i.encoder.Reset(blockStart, i.ns.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+			i.nsCtx.Schema)
+		i.encoding = true
+		i.blockStart = blockStart
+	}
+
+	if err := i.encoder.Encode(dp, unit, annotation); err != nil {
+		return err
+	}
+	i.result.NumDatapoints++
+	return nil
+}
+
+func (i *namespaceImporter) validateBlock(blockStart time.Time) error {
+	nsOpts := i.ns.nopts
+	if blockStart.Before(retention.FlushTimeStart(nsOpts.RetentionOptions(), i.now)) {
+		return errImportBlockOutOfRetention
+	}
+	if nsOpts.ArchivalOptions().IsBlockImmutable(blockStart, i.blockSize, i.now) {
+		return errImportBlockImmutable
+	}
+
+	// Blocks that have not been flushed yet are still accepting writes which
+	// should be used instead since there is no fileset to merge with yet.
+	flushState, err := i.seriesShard.FlushState(blockStart)
+	if err != nil {
+		return err
+	}
+	if flushState.WarmStatus != fileOpSuccess {
+		return errImportBlockNotFlushed
+	}
+	return nil
+}
+
+// finishBlock stages the block currently being encoded, applying the staged
+// blocks if they have grown too large.
+func (i *namespaceImporter) finishBlock() error {
+	if !i.encoding {
+		return nil
+	}
+	i.encoding = false
+
+	segment := i.encoder.Discard()
+	data := make([]byte, 0, segment.Len())
+	if segment.Head != nil {
+		data = append(data, segment.Head.Bytes()...)
+	}
+	if segment.Tail != nil {
+		data = append(data, segment.Tail.Bytes()...)
+	}
+	segment.Finalize()
+
+	key := importBlockKey{
+		shard:      i.seriesShard.ID(),
+		blockStart: xtime.ToUnixNano(i.blockStart),
+	}
+	block, ok := i.staged[key]
+	if !ok {
+		block = newImportBlock(i.seriesShard, i.blockStart)
+		i.staged[key] = block
+	}
+	if !block.add(i.seriesID, i.seriesTags, data) {
+		return errImportSeriesNotContiguous
+	}
+
+	i.stagedBytes += len(data)
+	if i.stagedBytes < importMaxStagedBytes {
+		return nil
+	}
+	return i.apply()
+}
+
+// apply merges the staged blocks into the filesets on disk and registers the
+// imported series with the index.
+func (i *namespaceImporter) apply() error {
+	if len(i.staged) == 0 {
+		return nil
+	}
+
+	i.fileOps.DisableFileOps()
+	defer i.fileOps.EnableFileOps()
+
+	if err := i.applyData(); err != nil {
+		return err
+	}
+	if err := i.applyIndex(); err != nil {
+		return err
+	}
+
+	i.result.NumBlocks += int64(len(i.staged))
+	i.staged = make(map[importBlockKey]*importBlock)
+	i.stagedBytes = 0
+	return nil
+}
+
+func (i *namespaceImporter) applyData() error {
+	flushPersist, err := i.ns.opts.PersistManager().StartFlushPersist()
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, block := range i.staged {
+		mergeWith := newImportMergeWith(block, i.blockSize)
+		err := block.shard.ImportBlock(flushPersist, i.fsReader,
+			block.blockStart, mergeWith, i.nsCtx)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	if err := flushPersist.DoneFlush(); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	return multiErr.FinalError()
+}
+
+func (i *namespaceImporter) applyIndex() error {
+	reverseIndex := i.ns.reverseIndex.get()
+	if reverseIndex == nil {
+		return nil
+	}
+
+	docsByBlockStart := make(map[xtime.UnixNano][]doc.Document)
+	for _, block := range i.staged {
+		indexBlockStart := reverseIndex.BlockStartForWriteTime(block.blockStart)
+		docs := docsByBlockStart[indexBlockStart]
+		for _, series := range block.series {
+			d, err := convert.FromMetric(series.id, series.tags)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, d)
+		}
+		docsByBlockStart[indexBlockStart] = docs
+	}
+
+	for blockStart, docs := range docsByBlockStart {
+		if err := reverseIndex.AddImported(blockStart.ToTime(), docs); err != nil {
+			return err
+		}
+	}
+
+	// Flush the index right away so that the imported series are durably
+	// indexed for index blocks that have already been flushed, otherwise a
+	// restart would bootstrap the index blocks without them.
+	indexFlush, err := i.ns.opts.PersistManager().StartIndexPersist()
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	if err := i.ns.FlushIndex(indexFlush); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	if err := indexFlush.DoneIndex(); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	return multiErr.FinalError()
+}
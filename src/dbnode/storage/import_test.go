@@ -0,0 +1,333 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+type testImportDatapoint struct {
+	id        string
+	tags      ident.Tags
+	timestamp time.Time
+	value     float64
+}
+
+type testImportIterator struct {
+	datapoints []testImportDatapoint
+	idx        int
+}
+
+func newTestImportIterator(datapoints ...testImportDatapoint) *testImportIterator {
+	return &testImportIterator{datapoints: datapoints, idx: -1}
+}
+
+func (it *testImportIterator) Next() bool {
+	it.idx++
+	return it.idx < len(it.datapoints)
+}
+
+func (it *testImportIterator) Current() (ident.ID, ident.TagIterator, ts.Datapoint,
+	xtime.Unit, ts.Annotation) {
+	curr := it.datapoints[it.idx]
+	return ident.StringID(curr.id), ident.NewTagsIterator(curr.tags),
+		ts.Datapoint{Timestamp: curr.timestamp, Value: curr.value}, xtime.Second, nil
+}
+
+func (it *testImportIterator) Err() error {
+	return nil
+}
+
+type testImportFileOps struct {
+	disabled int
+	enabled  int
+}
+
+func (o *testImportFileOps) DisableFileOps() { o.disabled++ }
+func (o *testImportFileOps) EnableFileOps()  { o.enabled++ }
+
+func newTestImportNamespace(
+	t *testing.T,
+	ctrl *gomock.Controller,
+) (*dbNamespace, *persist.MockManager, *MockdatabaseShard, closerFn) {
+	pm := persist.NewMockManager(ctrl)
+	dopts := DefaultTestOptions().
+		SetRuntimeOptionsManager(runtime.NewOptionsManager()).
+		SetPersistManager(pm)
+	ns, closer := newTestNamespaceWithOpts(t, dopts)
+	ns.bootstrapState = Bootstrapped
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(testShardIDs[0].ID()).AnyTimes()
+	ns.shards[testShardIDs[0].ID()] = shard
+	return ns, pm, shard, closer
+}
+
+func readImportedValues(
+	t *testing.T,
+	ns *dbNamespace,
+	readers []xio.BlockReader,
+) []float64 {
+	require.Equal(t, 1, len(readers))
+	iter := ns.opts.ReaderIteratorPool().Get()
+	defer iter.Close()
+
+	iter.Reset(readers[0], nil)
+	var values []float64
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		values = append(values, dp.Value)
+	}
+	require.NoError(t, iter.Err())
+	return values
+}
+
+func TestImportMergeWith(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	blockSize := ns.nopts.RetentionOptions().BlockSize()
+	blockStart := time.Now().Truncate(blockSize).Add(-4 * blockSize)
+
+	encode := func(values ...float64) []byte {
+		enc := ns.opts.EncoderPool().Get()
+		defer enc.Close()
+		enc.Reset(blockStart, 0, nil)
+		for i, v := range values {
+			dp := ts.Datapoint{Timestamp: blockStart.Add(time.Duration(i) * time.Minute), Value: v}
+			require.NoError(t, enc.Encode(dp, xtime.Second, nil))
+		}
+		segment := enc.Discard()
+		defer segment.Finalize()
+		var data []byte
+		if segment.Head != nil {
+			data = append(data, segment.Head.Bytes()...)
+		}
+		if segment.Tail != nil {
+			data = append(data, segment.Tail.Bytes()...)
+		}
+		return data
+	}
+
+	block := newImportBlock(nil, blockStart)
+	require.True(t, block.add(ident.StringID("a"), ident.NewTags(), encode(1, 2)))
+	require.True(t, block.add(ident.StringID("b"), ident.NewTags(), encode(3)))
+	require.False(t, block.add(ident.StringID("a"), ident.NewTags(), encode(4)))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	var (
+		mergeWith = newImportMergeWith(block, blockSize)
+		nsCtx     = namespace.Context{}
+		start     = xtime.ToUnixNano(blockStart)
+	)
+	readers, ok, err := mergeWith.Read(ctx, ident.StringID("a"), start, nsCtx)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, []float64{1, 2}, readImportedValues(t, ns, readers))
+
+	// Series are only merged once and only for the imported block.
+	_, ok, err = mergeWith.Read(ctx, ident.StringID("a"), start, nsCtx)
+	require.NoError(t, err)
+	require.False(t, ok)
+	_, ok, err = mergeWith.Read(ctx, ident.StringID("b"),
+		xtime.ToUnixNano(blockStart.Add(blockSize)), nsCtx)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	remaining := make(map[string][]float64)
+	err = mergeWith.ForEachRemaining(ctx, start, func(
+		seriesID ident.ID,
+		tags ident.Tags,
+		data []xio.BlockReader,
+	) error {
+		remaining[seriesID.String()] = readImportedValues(t, ns, data)
+		return nil
+	}, nsCtx)
+	require.NoError(t, err)
+	require.Equal(t, map[string][]float64{"b": {3}}, remaining)
+}
+
+func TestNamespaceImport(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, pm, shard, closer := newTestImportNamespace(t, ctrl)
+	defer closer()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns.reverseIndex = newNamespaceIndexRef(idx)
+
+	blockSize := ns.nopts.RetentionOptions().BlockSize()
+	blockStart := time.Now().Truncate(blockSize).Add(-4 * blockSize)
+	shard.EXPECT().FlushState(gomock.Any()).
+		Return(fileOpState{WarmStatus: fileOpSuccess}, nil).AnyTimes()
+
+	flushPreparer := persist.NewMockFlushPreparer(ctrl)
+	pm.EXPECT().StartFlushPersist().Return(flushPreparer, nil)
+	flushPreparer.EXPECT().DoneFlush().Return(nil)
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	imported := make(map[xtime.UnixNano]map[string][]float64)
+	shard.EXPECT().
+		ImportBlock(flushPreparer, gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ persist.FlushPreparer,
+			_ fs.DataFileSetReader,
+			blockStart time.Time,
+			mergeWith fs.MergeWith,
+			nsCtx namespace.Context,
+		) error {
+			values := make(map[string][]float64)
+			imported[xtime.ToUnixNano(blockStart)] = values
+			return mergeWith.ForEachRemaining(ctx, xtime.ToUnixNano(blockStart), func(
+				seriesID ident.ID,
+				tags ident.Tags,
+				data []xio.BlockReader,
+			) error {
+				values[seriesID.String()] = readImportedValues(t, ns, data)
+				return nil
+			}, nsCtx)
+		}).Times(2)
+
+	indexed := make(map[string]struct{})
+	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).DoAndReturn(
+		func(t time.Time) xtime.UnixNano {
+			return xtime.ToUnixNano(t.Truncate(blockSize))
+		}).Times(2)
+	idx.EXPECT().AddImported(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ time.Time, docs []doc.Document) error {
+			for _, d := range docs {
+				indexed[string(d.ID)] = struct{}{}
+			}
+			return nil
+		}).Times(2)
+
+	indexFlush := persist.NewMockIndexFlush(ctrl)
+	pm.EXPECT().StartIndexPersist().Return(indexFlush, nil)
+	indexFlush.EXPECT().DoneIndex().Return(nil)
+
+	tags := ident.NewTags(ident.StringTag("foo", "bar"))
+	iter := newTestImportIterator(
+		testImportDatapoint{id: "a", tags: tags, timestamp: blockStart, value: 1},
+		testImportDatapoint{id: "a", tags: tags, timestamp: blockStart.Add(time.Minute), value: 2},
+		testImportDatapoint{id: "a", tags: tags, timestamp: blockStart.Add(blockSize), value: 3},
+		testImportDatapoint{id: "b", tags: tags, timestamp: blockStart.Add(time.Minute), value: 4},
+	)
+	fileOps := &testImportFileOps{}
+	result, err := ns.Import(ctx, iter, fileOps)
+	require.NoError(t, err)
+	require.Equal(t, ImportResult{NumSeries: 2, NumDatapoints: 4, NumBlocks: 2}, result)
+
+	require.Equal(t, map[xtime.UnixNano]map[string][]float64{
+		xtime.ToUnixNano(blockStart):                {"a": {1, 2}, "b": {4}},
+		xtime.ToUnixNano(blockStart.Add(blockSize)): {"a": {3}},
+	}, imported)
+	require.Equal(t, map[string]struct{}{"a": {}, "b": {}}, indexed)
+	require.Equal(t, 1, fileOps.disabled)
+	require.Equal(t, 1, fileOps.enabled)
+}
+
+func TestNamespaceImportInvalidDatapoints(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ns, _, shard, closer := newTestImportNamespace(t, ctrl)
+	defer closer()
+
+	var (
+		blockSize  = ns.nopts.RetentionOptions().BlockSize()
+		now        = time.Now()
+		blockStart = now.Truncate(blockSize).Add(-4 * blockSize)
+		unflushed  = now.Truncate(blockSize)
+	)
+	shard.EXPECT().FlushState(unflushed).
+		Return(fileOpState{WarmStatus: fileOpNotStarted}, nil).AnyTimes()
+	shard.EXPECT().FlushState(gomock.Any()).
+		Return(fileOpState{WarmStatus: fileOpSuccess}, nil).AnyTimes()
+
+	tests := []struct {
+		name       string
+		datapoints []testImportDatapoint
+		expected   error
+	}{
+		{
+			name: "not sorted",
+			datapoints: []testImportDatapoint{
+				{id: "a", timestamp: blockStart.Add(time.Minute)},
+				{id: "a", timestamp: blockStart},
+			},
+			expected: errImportDatapointsNotSorted,
+		},
+		{
+			name: "not contiguous",
+			datapoints: []testImportDatapoint{
+				{id: "a", timestamp: blockStart},
+				{id: "b", timestamp: blockStart},
+				{id: "a", timestamp: blockStart.Add(time.Minute)},
+			},
+			expected: errImportSeriesNotContiguous,
+		},
+		{
+			name: "out of retention",
+			datapoints: []testImportDatapoint{
+				{id: "a", timestamp: now.Add(-2 * ns.nopts.RetentionOptions().RetentionPeriod())},
+			},
+			expected: errImportBlockOutOfRetention,
+		},
+		{
+			name: "not flushed",
+			datapoints: []testImportDatapoint{
+				{id: "a", timestamp: unflushed},
+			},
+			expected: errImportBlockNotFlushed,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.NewContext()
+			defer ctx.Close()
+
+			fileOps := &testImportFileOps{}
+			_, err := ns.Import(ctx, newTestImportIterator(test.datapoints...), fileOps)
+			require.Equal(t, test.expected, err)
+			require.Equal(t, 0, fileOps.disabled)
+		})
+	}
+}
@@ -113,6 +113,10 @@ type nsIndex struct {
 	// support timeouts for query workers pool.
 	queryWorkersPool xsync.WorkerPool
 
+	// queryLimiter enforces the query limits of the namespace, it is nil
+	// if the namespace has no query limits.
+	queryLimiter index.QueryLimiter
+
 	// queriesWg tracks outstanding queries to ensure
 	// we wait for all queries to complete before actually closing
 	// blocks and other cleanup tasks on index close
@@ -152,6 +156,13 @@ type nsIndexState struct {
 	// shardsFilterID is set every time the shards change to correctly
 	// only return IDs that this node owns.
 	shardsFilterID func(ident.ID) bool
+
+	// shardSet is the last assigned shard set, used to resolve the shard
+	// owning the series of a document when purging documents.
+	shardSet sharding.ShardSet
+
+	// lastDocumentsPurge is the tick start time of the last documents purge.
+	lastDocumentsPurge time.Time
 }
 
 // NB: nsIndexRuntimeOptions does not contain its own mutex as some of the variables
@@ -278,6 +289,8 @@ func newNamespaceIndexWithOptions(
 	indexOpts = indexOpts.SetInstrumentOptions(instrumentOpts)
 
 	nowFn := indexOpts.ClockOptions().NowFn()
+	deleteFilesFn := newPurgeRecordingDeleteFilesFn(newIndexOpts.opts.PurgeReporter(),
+		purgedFileTypeOf(PurgedIndexFileSet), fs.DeleteFiles)
 	idx := &nsIndex{
 		state: nsIndexState{
 			closeCh: make(chan struct{}),
@@ -296,7 +309,7 @@ func newNamespaceIndexWithOptions(
 		coldWritesEnabled:     nsMD.Options().ColdWritesEnabled(),
 
 		indexFilesetsBeforeFn: fs.IndexFileSetsBefore,
-		deleteFilesFn:         fs.DeleteFiles,
+		deleteFilesFn:         deleteFilesFn,
 
 		newBlockFn: newBlockFn,
 		opts:       newIndexOpts.opts,
@@ -309,6 +322,9 @@ func newNamespaceIndexWithOptions(
 		queryWorkersPool: newIndexOpts.opts.QueryIDsWorkerPool(),
 		metrics:          newNamespaceIndexMetrics(indexOpts, instrumentOpts),
 	}
+	if limitsOpts := nsMD.Options().QueryLimitsOptions(); limitsOpts.Enabled() {
+		idx.queryLimiter = index.NewQueryLimiter(limitsOpts)
+	}
 
 	// Assign shard set upfront.
 	idx.AssignShardSet(shardSet)
@@ -690,6 +706,20 @@ func (i *nsIndex) BootstrapsDone() uint {
 	return result
 }
 
+func (i *nsIndex) AddImported(
+	blockStart time.Time,
+	docs []doc.Document,
+) error {
+	// NB: Unlike writes, imported series are allowed to be added to blocks
+	// that are past the buffer past window as their data has already been
+	// written directly to the filesets on disk.
+	block, err := i.ensureBlockPresent(blockStart)
+	if err != nil {
+		return err
+	}
+	return block.AddImported(docs)
+}
+
 func (i *nsIndex) Tick(c context.Cancellable, startTime time.Time) (namespaceIndexTickResult, error) {
 	var (
 		result                     = namespaceIndexTickResult{}
@@ -945,6 +975,7 @@ func (i *nsIndex) AssignShardSet(shardSet sharding.ShardSet) {
 	}
 
 	i.state.Lock()
+	i.state.shardSet = shardSet
 	i.state.shardsFilterID = func(id ident.ID) bool {
 		// NB(r): Use a bitset for fast lookups.
 		return set.Test(uint(shardSet.Lookup(id)))
@@ -979,8 +1010,12 @@ func (i *nsIndex) Query(
 	// Get results and set the namespace ID and size limit.
 	results := i.resultsPool.Get()
 	results.Reset(i.nsMetadata.ID(), index.QueryResultsOptions{
-		SizeLimit: opts.Limit,
-		FilterID:  i.shardsFilterID(),
+		SizeLimit:      opts.Limit,
+		FilterID:       i.shardsFilterID(),
+		BytesBudget:    i.opts.IndexOptions().QueryBytesBudget(),
+		SeriesMetadata: opts.SeriesMetadata,
+		QueryLimiter:   i.queryLimiter,
+		Spill:          i.opts.IndexOptions().QuerySpillOptions(),
 	})
 	ctx.RegisterFinalizer(results)
 	exhaustive, err := i.query(ctx, query, results, opts, i.execBlockQueryFn, logFields)
@@ -988,6 +1023,9 @@ func (i *nsIndex) Query(
 		sp.LogFields(opentracinglog.Error(err))
 		return index.QueryResult{}, err
 	}
+	if results.Size() > results.Map().Len() {
+		i.metrics.QuerySpilled.Inc(1)
+	}
 	return index.QueryResult{
 		Results:    results,
 		Exhaustive: exhaustive,
@@ -1014,9 +1052,11 @@ func (i *nsIndex) AggregateQuery(
 	// Get results and set the filters, namespace ID and size limit.
 	results := i.aggregateResultsPool.Get()
 	aopts := index.AggregateResultsOptions{
-		SizeLimit:   opts.Limit,
-		FieldFilter: opts.FieldFilter,
-		Type:        opts.Type,
+		SizeLimit:    opts.Limit,
+		FieldFilter:  opts.FieldFilter,
+		Type:         opts.Type,
+		BytesBudget:  i.opts.IndexOptions().QueryBytesBudget(),
+		QueryLimiter: i.queryLimiter,
 	}
 	ctx.RegisterFinalizer(results)
 	// use appropriate fn to query underlying blocks.
@@ -1055,9 +1095,27 @@ func (i *nsIndex) query(
 	sp.LogFields(logFields...)
 	defer sp.Finish()
 
+	if i.queryLimiter != nil {
+		if err := i.queryLimiter.StartQuery(); err != nil {
+			sp.LogFields(opentracinglog.Error(err))
+			i.metrics.QueryLimitExceeded.Inc(1)
+			return false, err
+		}
+		defer i.queryLimiter.DoneQuery()
+	}
+
 	exhaustive, err := i.queryWithSpan(ctx, query, results, opts, execBlockFn, sp, logFields)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
+		if index.IsQueryBudgetExceededError(err) {
+			i.metrics.QueryBudgetExceeded.Inc(1)
+		}
+		if index.IsQueryLimitExceededError(err) {
+			i.metrics.QueryLimitExceeded.Inc(1)
+		}
+		if index.IsQuerySpillLimitExceededError(err) {
+			i.metrics.QuerySpillLimitExceeded.Inc(1)
+		}
 	}
 
 	return exhaustive, err
@@ -1211,6 +1269,17 @@ func (i *nsIndex) queryWithSpan(
 	// Take reference to vars to return while locked.
 	exhaustive := state.exhaustive
 	err = state.multiErr.FinalError()
+	for _, blockErr := range state.multiErr.Errors() {
+		// Return a budget or limit exceeded error as is rather than as part
+		// of a multi error so that callers can classify it, the remaining
+		// blocks are aborted by the same budget or limit.
+		if index.IsQueryBudgetExceededError(blockErr) ||
+			index.IsQueryLimitExceededError(blockErr) ||
+			index.IsQuerySpillLimitExceededError(blockErr) {
+			err = blockErr
+			break
+		}
+	}
 	state.Unlock()
 
 	if err != nil {
@@ -1548,6 +1617,10 @@ type nsIndexMetrics struct {
 	AsyncInsertErrors            tally.Counter
 	InsertAfterClose             tally.Counter
 	QueryAfterClose              tally.Counter
+	QueryBudgetExceeded          tally.Counter
+	QueryLimitExceeded           tally.Counter
+	QuerySpillLimitExceeded      tally.Counter
+	QuerySpilled                 tally.Counter
 	InsertEndToEndLatency        tally.Timer
 	BlocksEvictedMutableSegments tally.Counter
 	BlockMetrics                 nsIndexBlocksMetrics
@@ -1570,6 +1643,16 @@ func newNamespaceIndexMetrics(
 		QueryAfterClose: scope.Tagged(map[string]string{
 			"error_type": "query-closed",
 		}).Counter("query-after-error"),
+		QueryBudgetExceeded: scope.Tagged(map[string]string{
+			"error_type": "query-budget-exceeded",
+		}).Counter("query-error"),
+		QueryLimitExceeded: scope.Tagged(map[string]string{
+			"error_type": "query-limit-exceeded",
+		}).Counter("query-error"),
+		QuerySpillLimitExceeded: scope.Tagged(map[string]string{
+			"error_type": "query-spill-limit-exceeded",
+		}).Counter("query-error"),
+		QuerySpilled: scope.Counter("query-spilled"),
 		InsertEndToEndLatency: instrument.MustCreateSampledTimer(
 			scope.Timer("insert-end-to-end-latency"),
 			iopts.MetricsSamplingRate()),
@@ -24,6 +24,7 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
@@ -38,6 +39,12 @@ type aggregatedResults struct {
 	aggregateOpts AggregateResultsOptions
 
 	resultsMap *AggregateResultsMap
+	// retainedBytes is the number of bytes retained by the results, used
+	// to enforce the bytes budget of the query.
+	retainedBytes int64
+	// reservedBytes is the number of retained bytes reserved with the query
+	// limiter of the namespace, released when the results are reset.
+	reservedBytes int64
 
 	idPool    ident.Pool
 	bytesPool pool.CheckedBytesPool
@@ -69,7 +76,10 @@ func (r *aggregatedResults) Reset(
 ) {
 	r.Lock()
 
+	releaseQueryLimitBytes(r.aggregateOpts.QueryLimiter, r.reservedBytes)
 	r.aggregateOpts = aggregateOpts
+	r.retainedBytes = 0
+	r.reservedBytes = 0
 
 	// finalize existing held nsID
 	if r.nsID != nil {
@@ -99,6 +109,9 @@ func (r *aggregatedResults) Reset(
 func (r *aggregatedResults) AddDocuments(batch []doc.Document) (int, error) {
 	r.Lock()
 	err := r.addDocumentsBatchWithLock(batch)
+	if err == nil {
+		err = r.checkBytesLimitsWithLock()
+	}
 	size := r.resultsMap.Len()
 	r.Unlock()
 	return size, err
@@ -108,12 +121,13 @@ func (r *aggregatedResults) AggregateResultsOptions() AggregateResultsOptions {
 	return r.aggregateOpts
 }
 
-func (r *aggregatedResults) AddFields(batch []AggregateResultsEntry) int {
+func (r *aggregatedResults) AddFields(batch []AggregateResultsEntry) (int, error) {
 	r.Lock()
 	for _, entry := range batch {
 		f := entry.Field
 		aggValues, ok := r.resultsMap.Get(f)
 		if !ok {
+			r.retainedBytes += int64(len(f.Bytes()))
 			aggValues = r.valuesPool.Get()
 			// we can avoid the copy because we assume ownership of the passed ident.ID,
 			// but still need to finalize it.
@@ -129,6 +143,7 @@ func (r *aggregatedResults) AddFields(batch []AggregateResultsEntry) int {
 		valuesMap := aggValues.Map()
 		for _, t := range entry.Terms {
 			if !valuesMap.Contains(t) {
+				r.retainedBytes += int64(len(t.Bytes()))
 				// we can avoid the copy because we assume ownership of the passed ident.ID,
 				// but still need to finalize it.
 				valuesMap.SetUnsafe(t, struct{}{}, AggregateValuesMapSetUnsafeOptions{
@@ -143,8 +158,20 @@ func (r *aggregatedResults) AddFields(batch []AggregateResultsEntry) int {
 		}
 	}
 	size := r.resultsMap.Len()
+	err := r.checkBytesLimitsWithLock()
 	r.Unlock()
-	return size
+	return size, err
+}
+
+func (r *aggregatedResults) checkBytesLimitsWithLock() error {
+	err := queryBudgetExceeded(r.aggregateOpts.BytesBudget, r.retainedBytes)
+	if err != nil {
+		return err
+	}
+	reserved, err := reserveQueryLimitBytes(r.aggregateOpts.QueryLimiter,
+		r.reservedBytes, r.retainedBytes)
+	r.reservedBytes = reserved
+	return err
 }
 
 func (r *aggregatedResults) addDocumentsBatchWithLock(
@@ -182,6 +209,9 @@ func (r *aggregatedResults) addDocumentTermsWithLock(
 	document doc.Document,
 ) error {
 	for _, field := range document.Fields {
+		if convert.IsSeriesMetadataField(field.Name) {
+			continue
+		}
 		if err := r.addTermWithLock(field.Name); err != nil {
 			return fmt.Errorf("unable to add document terms [%+v]: %v", document, err)
 		}
@@ -215,6 +245,7 @@ func (r *aggregatedResults) addTermWithLock(
 	// Set results map to an empty AggregateValues since we only care about
 	// existence of the term in the map, rather than its set of values.
 	r.resultsMap.Set(termID, r.valuesPool.Get())
+	r.retainedBytes += int64(len(term))
 	return nil
 }
 
@@ -222,6 +253,9 @@ func (r *aggregatedResults) addDocumentWithLock(
 	document doc.Document,
 ) error {
 	for _, field := range document.Fields {
+		if convert.IsSeriesMetadataField(field.Name) {
+			continue
+		}
 		if err := r.addFieldWithLock(field.Name, field.Value); err != nil {
 			return fmt.Errorf("unable to add document [%+v]: %v", document, err)
 		}
@@ -252,6 +286,9 @@ func (r *aggregatedResults) addFieldWithLock(
 
 	valueMap, found := r.resultsMap.Get(termID)
 	if found {
+		if !valueMap.Map().Contains(valueID) {
+			r.retainedBytes += int64(len(value))
+		}
 		return valueMap.addValue(valueID)
 	}
 
@@ -270,6 +307,7 @@ func (r *aggregatedResults) addFieldWithLock(
 	}
 
 	r.resultsMap.Set(termID, aggValues)
+	r.retainedBytes += int64(len(term) + len(value))
 	return nil
 }
 
@@ -112,6 +112,19 @@ func TestAggResultsTermOnlyInsertIdempotency(t *testing.T) {
 	testAggResultsInsertIdempotency(t, res)
 }
 
+func TestAggResultsSkipsSeriesMetadata(t *testing.T) {
+	for _, aggType := range []AggregationType{AggregateTagNamesAndValues, AggregateTagNames} {
+		res := NewAggregateResults(nil, AggregateResultsOptions{
+			Type: aggType,
+		}, testOpts)
+		d := genDoc("foo", "bar", "__m3_meta_unit", "seconds")
+		size, err := res.AddDocuments([]doc.Document{d})
+		require.NoError(t, err)
+		require.Equal(t, 1, size)
+		assert.False(t, res.Map().Contains(ident.StringID("__m3_meta_unit")))
+	}
+}
+
 func TestInvalidAggregateType(t *testing.T) {
 	res := NewAggregateResults(nil, AggregateResultsOptions{
 		Type: 100,
@@ -451,3 +464,44 @@ func TestAggResultFinalize(t *testing.T) {
 		require.False(t, id.IsNoFinalize())
 	}
 }
+
+func TestAggResultsBytesBudgetExceeded(t *testing.T) {
+	res := NewAggregateResults(nil, AggregateResultsOptions{
+		BytesBudget: 10,
+	}, testOpts)
+	size, err := res.AddDocuments([]doc.Document{genDoc("foo", "bar")})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+
+	// Re-adding an existing value does not retain more bytes.
+	size, err = res.AddDocuments([]doc.Document{genDoc("foo", "bar")})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+
+	_, err = res.AddDocuments([]doc.Document{genDoc("foo", "baz")})
+	require.NoError(t, err)
+
+	_, err = res.AddDocuments([]doc.Document{genDoc("qux", "quux")})
+	require.Error(t, err)
+	assert.True(t, IsQueryBudgetExceededError(err))
+}
+
+func TestAggResultsAddFieldsBytesBudgetExceeded(t *testing.T) {
+	res := NewAggregateResults(nil, AggregateResultsOptions{
+		BytesBudget: 8,
+	}, testOpts)
+	size, err := res.AddFields([]AggregateResultsEntry{{
+		Field: ident.StringID("foo"),
+		Terms: []ident.ID{ident.StringID("bar")},
+	}})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+
+	size, err = res.AddFields([]AggregateResultsEntry{{
+		Field: ident.StringID("foo"),
+		Terms: []ident.ID{ident.StringID("baz")},
+	}})
+	require.Error(t, err)
+	assert.True(t, IsQueryBudgetExceededError(err))
+	assert.Equal(t, 1, size)
+}
@@ -28,8 +28,10 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/index/segments"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
 	"github.com/m3db/m3/src/m3ninx/doc"
@@ -37,6 +39,7 @@ import (
 	"github.com/m3db/m3/src/m3ninx/index/segment"
 	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
 	"github.com/m3db/m3/src/m3ninx/index/segment/fst"
+	"github.com/m3db/m3/src/m3ninx/index/segment/mem"
 	"github.com/m3db/m3/src/m3ninx/search"
 	"github.com/m3db/m3/src/m3ninx/search/executor"
 	"github.com/m3db/m3/src/x/context"
@@ -63,6 +66,7 @@ var (
 	errUnableToWriteBlockSealed                = errors.New("unable to write, index block is sealed")
 	errUnableToWriteBlockConcurrent            = errors.New("unable to write, index block is being written to already")
 	errUnableToBootstrapBlockClosed            = errors.New("unable to bootstrap, block is closed")
+	errUnableToAddImportedBlockClosed          = errors.New("unable to add imported documents, block is closed")
 	errUnableToTickBlockClosed                 = errors.New("unable to tick, block is closed")
 	errBlockAlreadyClosed                      = errors.New("unable to close, block already closed")
 	errForegroundCompactorNoPlan               = errors.New("index foreground compactor failed to generate a plan")
@@ -138,6 +142,9 @@ type blockMetrics struct {
 	foregroundCompactionTaskRunLatency tally.Timer
 	backgroundCompactionPlanRunLatency tally.Timer
 	backgroundCompactionTaskRunLatency tally.Timer
+	backgroundPurgeRunLatency          tally.Timer
+	backgroundPurgeDocuments           tally.Counter
+	backgroundPurgeError               tally.Counter
 	segmentFreeMmapSuccess             tally.Counter
 	segmentFreeMmapError               tally.Counter
 	segmentFreeMmapSkipNotImmutable    tally.Counter
@@ -157,6 +164,9 @@ func newBlockMetrics(s tally.Scope) blockMetrics {
 		foregroundCompactionTaskRunLatency: foregroundScope.Timer("compaction-task-run-latency"),
 		backgroundCompactionPlanRunLatency: backgroundScope.Timer("compaction-plan-run-latency"),
 		backgroundCompactionTaskRunLatency: backgroundScope.Timer("compaction-task-run-latency"),
+		backgroundPurgeRunLatency:          backgroundScope.Timer("purge-run-latency"),
+		backgroundPurgeDocuments:           backgroundScope.Counter("purge-documents"),
+		backgroundPurgeError:               backgroundScope.Counter("purge-error"),
 		segmentFreeMmapSuccess: s.Tagged(map[string]string{
 			"result": "success",
 		}).Counter(segmentFreeMmap),
@@ -247,10 +257,18 @@ func (b *block) maybeBackgroundCompactWithLock() {
 		return
 	}
 
+	// Only compact when the background scheduler has budget to spare, if not
+	// the compaction is retried the next time segments are added.
+	release, ok := b.opts.BackgroundScheduler().TryAcquire(background.CompactionWork)
+	if !ok {
+		return
+	}
+
 	// Kick off compaction.
 	b.compact.compactingBackground = true
 	go func() {
 		b.backgroundCompactWithPlan(plan)
+		release()
 
 		b.Lock()
 		b.compact.compactingBackground = false
@@ -419,10 +437,146 @@ func (b *block) addCompactedSegmentFromSegments(
 		}
 	}
 
+	if compacted == nil {
+		// All documents of the compacted segments were dropped.
+		return result
+	}
+
 	// Return all the ones we kept plus the new compacted segment
 	return append(result, newReadableSeg(compacted, b.opts))
 }
 
+func (b *block) PurgeDocuments(
+	filter segment.DocumentsFilter,
+) (BlockPurgeResult, error) {
+	b.Lock()
+	if b.state != blockStateOpen || b.compact.compactingBackground ||
+		len(b.backgroundSegments) == 0 {
+		// Only purge open blocks when not already compacting, sealed
+		// blocks have their in-memory segments evicted once flushed.
+		b.Unlock()
+		return BlockPurgeResult{}, nil
+	}
+
+	if err := b.compact.allocLazyBuilderAndCompactors(b.blockOpts, b.opts); err != nil {
+		b.Unlock()
+		return BlockPurgeResult{}, err
+	}
+
+	segs := make([]segment.Segment, 0, len(b.backgroundSegments))
+	for _, seg := range b.backgroundSegments {
+		segs = append(segs, seg.Segment())
+	}
+
+	// NB: Mark as compacting so that the segments are not closed or
+	// compacted until the purge completes.
+	b.compact.compactingBackground = true
+	b.Unlock()
+
+	result, err := purgeableDocuments(segs, filter)
+	if err != nil || result.NumDocsPurged == 0 {
+		b.Lock()
+		b.compact.compactingBackground = false
+		b.cleanupBackgroundCompactWithLock()
+		b.Unlock()
+		return result, err
+	}
+
+	go func() {
+		b.backgroundPurgeWithSegments(segs, filter, result)
+
+		b.Lock()
+		b.compact.compactingBackground = false
+		b.cleanupBackgroundCompactWithLock()
+		b.Unlock()
+	}()
+
+	return result, nil
+}
+
+func (b *block) backgroundPurgeWithSegments(
+	segs []segment.Segment,
+	filter segment.DocumentsFilter,
+	purgeable BlockPurgeResult,
+) {
+	sw := b.metrics.backgroundPurgeRunLatency.Start()
+	defer sw.Stop()
+
+	var compacted segment.Segment
+	if purgeable.NumDocsPurged < purgeable.NumDocs {
+		var err error
+		compacted, err = b.compact.backgroundCompactor.CompactWithFilter(segs,
+			filter, mmap.ReporterOptions{
+				Context: mmap.Context{
+					Name: mmapIndexBlockName,
+				},
+				Reporter: b.opts.MmapReporter(),
+			})
+		if err != nil {
+			b.metrics.backgroundPurgeError.Inc(1)
+			b.logger.Error("error purging documents from segments",
+				zap.Time("block", b.blockStart), zap.Error(err))
+			return
+		}
+	}
+
+	b.metrics.backgroundPurgeDocuments.Inc(purgeable.NumDocsPurged)
+	b.logger.Debug("purged documents from segments",
+		zap.Time("block", b.blockStart),
+		zap.Int("numSegments", len(segs)),
+		zap.Int64("numDocs", purgeable.NumDocs),
+		zap.Int64("numDocsPurged", purgeable.NumDocsPurged))
+
+	// Rotate out the purged segments and add the compacted one.
+	b.Lock()
+	defer b.Unlock()
+
+	b.backgroundSegments = b.addCompactedSegmentFromSegments(
+		b.backgroundSegments, segs, compacted)
+}
+
+// purgeableDocuments returns the number of unique documents in the segments
+// and the number of those that are not contained by the filter.
+func purgeableDocuments(
+	segs []segment.Segment,
+	filter segment.DocumentsFilter,
+) (BlockPurgeResult, error) {
+	var (
+		result BlockPurgeResult
+		seen   = make(map[string]struct{})
+	)
+	for _, seg := range segs {
+		reader, err := seg.Reader()
+		if err != nil {
+			return BlockPurgeResult{}, err
+		}
+
+		iter, err := reader.AllDocs()
+		if err != nil {
+			reader.Close()
+			return BlockPurgeResult{}, err
+		}
+
+		for iter.Next() {
+			d := iter.Current()
+			if _, ok := seen[string(d.ID)]; ok {
+				continue
+			}
+			seen[string(d.ID)] = struct{}{}
+			result.NumDocs++
+			if !filter.Contains(d) {
+				result.NumDocsPurged++
+			}
+		}
+
+		err = xerrors.FirstError(iter.Err(), iter.Close(), reader.Close())
+		if err != nil {
+			return BlockPurgeResult{}, err
+		}
+	}
+	return result, nil
+}
+
 func (b *block) WriteBatch(inserts *WriteBatch) (WriteBatchResult, error) {
 	b.Lock()
 	if b.state != blockStateOpen {
@@ -1001,6 +1155,9 @@ func (b *block) aggregateWithSpan(
 			if bytes.Equal(field, doc.IDReservedFieldName) {
 				return false
 			}
+			if convert.IsSeriesMetadataField(field) {
+				return false
+			}
 			return aggOpts.FieldFilter.Allow(field)
 		},
 		fieldIterFn: func(s segment.Segment) (segment.FieldsIterator, error) {
@@ -1171,7 +1328,7 @@ func (b *block) addAggregateResults(
 	}
 
 	// try to add the docs to the resource.
-	size := results.AddFields(batch)
+	size, err := results.AddFields(batch)
 
 	// immediately release the checkout on the lifetime of query.
 	cancellable.ReleaseCheckout()
@@ -1184,7 +1341,7 @@ func (b *block) addAggregateResults(
 	batch = batch[:0]
 
 	// return results.
-	return batch, size, nil
+	return batch, size, err
 }
 
 func (b *block) AddResults(
@@ -1261,6 +1418,41 @@ func (b *block) AddResults(
 	return multiErr.FinalError()
 }
 
+func (b *block) AddImported(docs []doc.Document) error {
+	b.Lock()
+	defer b.Unlock()
+
+	if b.state == blockStateClosed {
+		return errUnableToAddImportedBlockClosed
+	}
+
+	seg, err := mem.NewSegment(0, b.opts.MemSegmentOptions())
+	if err != nil {
+		return err
+	}
+
+	inserted := make(map[string]struct{}, len(docs))
+	for _, d := range docs {
+		if _, ok := inserted[string(d.ID)]; ok {
+			continue
+		}
+		inserted[string(d.ID)] = struct{}{}
+		if _, err := seg.Insert(d); err != nil {
+			seg.Close()
+			return err
+		}
+	}
+
+	// NB: The segment deliberately does not cover any shard time ranges so
+	// that it never replaces, nor is mistaken to cover, any bootstrapped
+	// segments. Since it is a mutable segment the block will be flushed again
+	// and the flushed segments will include the imported series.
+	b.shardRangesSegments = append(b.shardRangesSegments, blockShardRangesSegments{
+		segments: []segment.Segment{seg},
+	})
+	return nil
+}
+
 func (b *block) Tick(c context.Cancellable) (BlockTickResult, error) {
 	b.Lock()
 	defer b.Unlock()
@@ -21,6 +21,7 @@
 package index
 
 import (
+	"bytes"
 	stdlibctx "context"
 	"fmt"
 	"testing"
@@ -28,6 +29,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/dbnode/tracepoint"
@@ -887,6 +889,48 @@ func TestBlockAddResultsAfterSealWorks(t *testing.T) {
 	require.Equal(t, seg1, b.shardRangesSegments[0].segments[0])
 }
 
+func TestBlockAddImportedAfterSealKeepsExistingSegments(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	require.NoError(t, blk.Seal())
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	seg1 := segment.NewMockSegment(ctrl)
+	require.NoError(t, blk.AddResults(
+		result.NewIndexBlock(start, []segment.Segment{seg1},
+			result.NewShardTimeRanges(start, start.Add(time.Hour), 1))))
+	require.False(t, b.NeedsMutableSegmentsEvicted())
+
+	require.NoError(t, blk.AddImported([]doc.Document{testDoc1(), testDoc2(), testDoc1()}))
+	require.Equal(t, 2, len(b.shardRangesSegments))
+	require.Equal(t, seg1, b.shardRangesSegments[0].segments[0])
+
+	imported := b.shardRangesSegments[1]
+	require.True(t, imported.shardTimeRanges.IsEmpty())
+	require.Equal(t, 1, len(imported.segments))
+	require.Equal(t, int64(2), imported.segments[0].Size())
+
+	// The imported documents need to be flushed.
+	require.True(t, b.NeedsMutableSegmentsEvicted())
+}
+
+func TestBlockAddImportedAfterCloseFails(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	start := time.Now().Truncate(time.Hour)
+	blk, err := NewBlock(start, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	require.NoError(t, blk.Close())
+
+	require.Error(t, blk.AddImported([]doc.Document{testDoc1()}))
+}
+
 func TestBlockTickSingleSegment(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1594,6 +1638,177 @@ func TestBlockWriteBackgroundCompact(t *testing.T) {
 	b.RUnlock()
 }
 
+func TestBlockBackgroundCompactRequiresSchedulerBudget(t *testing.T) {
+	scheduler, err := background.NewScheduler(background.NewOptions().
+		SetCPUBudget(1))
+	require.NoError(t, err)
+	defer scheduler.Close()
+
+	// Hold the only unit of CPU budget so compactions are not admitted.
+	release, ok := scheduler.TryAcquire(background.CompactionWork)
+	require.True(t, ok)
+
+	opts := testOpts.SetBackgroundScheduler(scheduler)
+	testMD := newTestNSMetadata(t)
+	blockStart := time.Now().Truncate(time.Hour)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, opts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, blk.Close())
+	}()
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	seg1 := testSegment(t, testDoc1(), testDoc2())
+	seg2 := testSegment(t, testDoc3())
+	require.NoError(t, seg1.(segment.MutableSegment).Seal())
+	require.NoError(t, seg2.(segment.MutableSegment).Seal())
+
+	b.Lock()
+	b.backgroundSegments = []*readableSeg{
+		newReadableSeg(seg1, opts),
+		newReadableSeg(seg2, opts),
+	}
+	b.maybeBackgroundCompactWithLock()
+	require.False(t, b.compact.compactingBackground)
+	b.Unlock()
+
+	release()
+
+	b.Lock()
+	b.maybeBackgroundCompactWithLock()
+	require.True(t, b.compact.compactingBackground)
+	b.Unlock()
+
+	// Wait for compaction to finish
+	for {
+		b.RLock()
+		compacting := b.compact.compactingBackground
+		b.RUnlock()
+		if !compacting {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	b.RLock()
+	require.Equal(t, 1, len(b.backgroundSegments))
+	require.Equal(t, 3, int(b.backgroundSegments[0].Segment().Size()))
+	b.RUnlock()
+}
+
+type testDocumentsFilter func(d doc.Document) bool
+
+func (f testDocumentsFilter) Contains(d doc.Document) bool {
+	return f(d)
+}
+
+func TestBlockPurgeDocuments(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	blockStart := time.Now().Truncate(time.Hour)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, blk.Close())
+	}()
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+
+	seg1 := testSegment(t, testDoc1(), testDoc2())
+	seg2 := testSegment(t, testDoc3())
+	require.NoError(t, seg1.(segment.MutableSegment).Seal())
+	require.NoError(t, seg2.(segment.MutableSegment).Seal())
+	b.backgroundSegments = []*readableSeg{
+		newReadableSeg(seg1, testOpts),
+		newReadableSeg(seg2, testOpts),
+	}
+
+	waitForBackgroundCompaction := func() {
+		for {
+			b.RLock()
+			compacting := b.compact.compactingBackground
+			b.RUnlock()
+			if !compacting {
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	// Nothing to purge does not compact.
+	result, err := b.PurgeDocuments(testDocumentsFilter(func(d doc.Document) bool {
+		return true
+	}))
+	require.NoError(t, err)
+	require.Equal(t, BlockPurgeResult{NumDocs: 3}, result)
+	waitForBackgroundCompaction()
+
+	b.RLock()
+	require.Equal(t, 2, len(b.backgroundSegments))
+	b.RUnlock()
+
+	// Purge a single document.
+	result, err = b.PurgeDocuments(testDocumentsFilter(func(d doc.Document) bool {
+		return !bytes.Equal(d.ID, testDoc1().ID)
+	}))
+	require.NoError(t, err)
+	require.Equal(t, BlockPurgeResult{NumDocs: 3, NumDocsPurged: 1}, result)
+	waitForBackgroundCompaction()
+
+	b.RLock()
+	require.Equal(t, 1, len(b.backgroundSegments))
+	purged := b.backgroundSegments[0].Segment()
+	require.Equal(t, int64(2), purged.Size())
+	contains, err := purged.ContainsID(testDoc1().ID)
+	require.NoError(t, err)
+	require.False(t, contains)
+	b.RUnlock()
+
+	// Purge all remaining documents.
+	result, err = b.PurgeDocuments(testDocumentsFilter(func(d doc.Document) bool {
+		return false
+	}))
+	require.NoError(t, err)
+	require.Equal(t, BlockPurgeResult{NumDocs: 2, NumDocsPurged: 2}, result)
+	waitForBackgroundCompaction()
+
+	b.RLock()
+	require.Equal(t, 0, len(b.backgroundSegments))
+	b.RUnlock()
+}
+
+func TestBlockPurgeDocumentsSealedBlock(t *testing.T) {
+	testMD := newTestNSMetadata(t)
+	blockStart := time.Now().Truncate(time.Hour)
+
+	blk, err := NewBlock(blockStart, testMD, BlockOptions{}, testOpts)
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, blk.Close())
+	}()
+
+	b, ok := blk.(*block)
+	require.True(t, ok)
+	b.backgroundSegments = []*readableSeg{
+		newReadableSeg(testSegment(t, testDoc1()), testOpts),
+	}
+	require.NoError(t, b.Seal())
+
+	result, err := b.PurgeDocuments(testDocumentsFilter(func(d doc.Document) bool {
+		return false
+	}))
+	require.NoError(t, err)
+	require.Equal(t, BlockPurgeResult{}, result)
+
+	b.RLock()
+	require.Equal(t, 1, len(b.backgroundSegments))
+	b.RUnlock()
+}
+
 func TestBlockAggregateAfterClose(t *testing.T) {
 	testMD := newTestNSMetadata(t)
 	start := time.Now().Truncate(time.Hour)
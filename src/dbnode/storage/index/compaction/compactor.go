@@ -106,6 +106,19 @@ func NewCompactor(
 func (c *Compactor) Compact(
 	segs []segment.Segment,
 	reporterOptions mmap.ReporterOptions,
+) (segment.Segment, error) {
+	return c.CompactWithFilter(segs, nil, reporterOptions)
+}
+
+// CompactWithFilter will take a set of segments and compact them into an
+// immutable FST segment, dropping any documents not contained by the filter
+// if the filter is not nil.
+// Note: The filter must retain at least one document otherwise there is
+// nothing to compact and an error is returned.
+func (c *Compactor) CompactWithFilter(
+	segs []segment.Segment,
+	filter segment.DocumentsFilter,
+	reporterOptions mmap.ReporterOptions,
 ) (segment.Segment, error) {
 	c.Lock()
 	defer c.Unlock()
@@ -115,6 +128,7 @@ func (c *Compactor) Compact(
 	}
 
 	c.builder.Reset(0)
+	c.builder.SetFilter(filter)
 	if err := c.builder.AddSegments(segs); err != nil {
 		return nil, err
 	}
@@ -21,6 +21,7 @@
 package compaction
 
 import (
+	"bytes"
 	"fmt"
 	"testing"
 
@@ -189,6 +190,52 @@ func TestCompactorCompactDuplicateIDsNoError(t *testing.T) {
 	require.NoError(t, compactor.Close())
 }
 
+type testDocumentsFilter func(d doc.Document) bool
+
+func (f testDocumentsFilter) Contains(d doc.Document) bool {
+	return f(d)
+}
+
+func TestCompactorCompactWithFilter(t *testing.T) {
+	seg1, err := mem.NewSegment(0, testMemSegmentOptions)
+	require.NoError(t, err)
+
+	_, err = seg1.Insert(testDocuments[0])
+	require.NoError(t, err)
+
+	seg2, err := mem.NewSegment(0, testMemSegmentOptions)
+	require.NoError(t, err)
+
+	_, err = seg2.Insert(testDocuments[1])
+	require.NoError(t, err)
+
+	compactor, err := NewCompactor(testDocsPool, testDocsMaxBatch,
+		testBuilderSegmentOptions, testFSTSegmentOptions, CompactorOptions{})
+	require.NoError(t, err)
+
+	filter := testDocumentsFilter(func(d doc.Document) bool {
+		return bytes.Equal(d.ID, testDocuments[1].ID)
+	})
+	compacted, err := compactor.CompactWithFilter([]segment.Segment{
+		mustSeal(t, seg1),
+		mustSeal(t, seg2),
+	}, filter, mmap.ReporterOptions{})
+	require.NoError(t, err)
+
+	assertContents(t, compacted, testDocuments[1:])
+
+	// The filter does not apply to subsequent compactions.
+	compacted, err = compactor.Compact([]segment.Segment{
+		seg1,
+		seg2,
+	}, mmap.ReporterOptions{})
+	require.NoError(t, err)
+
+	assertContents(t, compacted, testDocuments)
+
+	require.NoError(t, compactor.Close())
+}
+
 func assertContents(t *testing.T, seg segment.Segment, docs []doc.Document) {
 	// Ensure has contents
 	require.Equal(t, int64(len(docs)), seg.Size())
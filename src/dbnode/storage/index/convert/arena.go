@@ -0,0 +1,91 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package convert
+
+import (
+	"sync"
+)
+
+const (
+	defaultBytesArenaSlabSize = 16384
+	// maxBytesArenaAllocFraction bounds the size of a single allocation
+	// relative to the slab size, larger allocations are made directly to
+	// avoid wasting the remainder of a slab.
+	maxBytesArenaAllocFraction = 4
+)
+
+// BytesArena allocates long lived byte slices, such as series IDs and tags,
+// from larger slabs so that inserting a series requires a handful of heap
+// objects rather than one per tag name and value. This reduces both the
+// number of allocations and the number of objects the GC has to scan.
+//
+// NB: Slabs are never reused, a slab is released to the GC once every slice
+// allocated from it is no longer referenced, i.e. once the series allocated
+// from it have been evicted and nothing else holds their bytes. The bytes of
+// a series outlive the series itself, index documents and queued commit log
+// writes reference them directly, so there is no point at which a slab could
+// be handed out again without risking overwriting bytes still in use. This
+// means a single long lived series can keep an entire slab alive, hence the
+// slab size is kept relatively small.
+type BytesArena struct {
+	sync.Mutex
+	slabSize int
+	maxAlloc int
+	slab     []byte
+}
+
+// NewBytesArena returns a new bytes arena.
+func NewBytesArena() *BytesArena {
+	return newBytesArena(defaultBytesArenaSlabSize)
+}
+
+func newBytesArena(slabSize int) *BytesArena {
+	return &BytesArena{
+		slabSize: slabSize,
+		maxAlloc: slabSize / maxBytesArenaAllocFraction,
+	}
+}
+
+// Copy returns a copy of the bytes allocated from the arena.
+func (a *BytesArena) Copy(b []byte) []byte {
+	n := len(b)
+	if n == 0 {
+		return nil
+	}
+	if n > a.maxAlloc {
+		// Too large to allocate from a slab.
+		return append([]byte(nil), b...)
+	}
+
+	a.Lock()
+	if a.slabSize-len(a.slab) < n {
+		// Slab is lazily allocated so arenas that are never used
+		// do not hold onto any memory.
+		a.slab = make([]byte, 0, a.slabSize)
+	}
+	start := len(a.slab)
+	a.slab = append(a.slab, b...)
+	// Use a full slice expression so appending to the returned slice beyond
+	// its capacity cannot overwrite bytes allocated to another caller.
+	result := a.slab[start : start+n : start+n]
+	a.Unlock()
+
+	return result
+}
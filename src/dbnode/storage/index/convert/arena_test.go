@@ -0,0 +1,81 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package convert
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestBytesArenaCopy(t *testing.T) {
+	a := newBytesArena(16)
+
+	foo := a.Copy([]byte("foo"))
+	bar := a.Copy([]byte("bar"))
+	require.Equal(t, "foo", string(foo))
+	require.Equal(t, "bar", string(bar))
+	require.Equal(t, 3, cap(foo))
+
+	// Appending must not overwrite bytes allocated to another caller.
+	foo = append(foo, 'x')
+	require.Equal(t, "foox", string(foo))
+	require.Equal(t, "bar", string(bar))
+
+	// Source bytes must be copied.
+	src := []byte("baz")
+	baz := a.Copy(src)
+	src[0] = 'q'
+	require.Equal(t, "baz", string(baz))
+}
+
+func TestBytesArenaCopyNewSlab(t *testing.T) {
+	a := newBytesArena(8)
+
+	first := a.Copy([]byte("ab"))
+	second := a.Copy([]byte("cd"))
+	third := a.Copy([]byte("ef"))
+	fourth := a.Copy([]byte("gh"))
+	// Slab is full, next copy must come from a new slab.
+	fifth := a.Copy([]byte("ij"))
+
+	require.Equal(t, "ab", string(first))
+	require.Equal(t, "cd", string(second))
+	require.Equal(t, "ef", string(third))
+	require.Equal(t, "gh", string(fourth))
+	require.Equal(t, "ij", string(fifth))
+	require.Equal(t, 2, len(a.slab))
+}
+
+func TestBytesArenaCopyLarge(t *testing.T) {
+	a := newBytesArena(16)
+
+	large := a.Copy([]byte("larger-than-max"))
+	require.Equal(t, "larger-than-max", string(large))
+	// Large allocations should not be taken from a slab.
+	require.Nil(t, a.slab)
+}
+
+func TestBytesArenaCopyEmpty(t *testing.T) {
+	a := newBytesArena(16)
+	require.Nil(t, a.Copy(nil))
+	require.Nil(t, a.Copy([]byte{}))
+	require.Nil(t, a.slab)
+}
@@ -76,6 +76,9 @@ func ValidateSeriesTag(tag ident.Tag) error {
 			"field=%s, field_value=%s, field_value_hex=%x",
 			tagName, tagValue, tagValue)
 	}
+	if IsSeriesMetadataField(tagName) {
+		return validateSeriesMetadataTag(tagName, tagValue)
+	}
 	return nil
 }
 
@@ -205,6 +208,26 @@ func TagsFromTagsIter(
 	seriesID ident.ID,
 	iter ident.TagIterator,
 	idPool ident.Pool,
+) (ident.Tags, error) {
+	return tagsFromTagsIter(seriesID, iter, idPool, nil)
+}
+
+// TagsFromTagsIterWithArena returns an ident.Tags from a TagIterator, copying
+// any tag names and values not contained in the series ID from the arena.
+// It is intended for long lived tags, the returned tags must not be finalized.
+func TagsFromTagsIterWithArena(
+	seriesID ident.ID,
+	iter ident.TagIterator,
+	arena *BytesArena,
+) (ident.Tags, error) {
+	return tagsFromTagsIter(seriesID, iter, nil, arena)
+}
+
+func tagsFromTagsIter(
+	seriesID ident.ID,
+	iter ident.TagIterator,
+	idPool ident.Pool,
+	arena *BytesArena,
 ) (ident.Tags, error) {
 	var tags ident.Tags
 	if idPool != nil {
@@ -227,26 +250,16 @@ func TagsFromTagsIter(
 			tag.Name = seriesIDBytes[idx : idx+len(nameBytes)]
 			idRef = true
 		} else {
-			if idPool != nil {
-				tag.Name = idPool.Clone(curr.Name)
-			} else {
-				copiedBytes := append([]byte(nil), curr.Name.Bytes()...)
-				tag.Name = ident.BytesID(copiedBytes)
-			}
+			tag.Name = cloneTagBytes(curr.Name, idPool, arena)
 		}
 		if idx := bytes.Index(seriesIDBytes, valueBytes); idx != -1 {
 			tag.Value = seriesIDBytes[idx : idx+len(valueBytes)]
 			idRef = true
 		} else {
-			if idPool != nil {
-				tag.Value = idPool.Clone(curr.Value)
-			} else {
-				copiedBytes := append([]byte(nil), curr.Value.Bytes()...)
-				tag.Value = ident.BytesID(copiedBytes)
-			}
+			tag.Value = cloneTagBytes(curr.Value, idPool, arena)
 		}
 
-		if idRef {
+		if idRef || arena != nil {
 			tag.NoFinalize() // Taken ref, cannot finalize this.
 		}
 
@@ -259,6 +272,17 @@ func TagsFromTagsIter(
 	return tags, nil
 }
 
+func cloneTagBytes(id ident.ID, idPool ident.Pool, arena *BytesArena) ident.ID {
+	if idPool != nil {
+		return idPool.Clone(id)
+	}
+	if arena != nil {
+		return ident.BytesID(arena.Copy(id.Bytes()))
+	}
+	copiedBytes := append([]byte(nil), id.Bytes()...)
+	return ident.BytesID(copiedBytes)
+}
+
 // NB(prateek): we take an independent copy of the bytes underlying
 // any ids provided, as we need to maintain the lifecycle of the indexed
 // bytes separately from the rest of the storage subsystem.
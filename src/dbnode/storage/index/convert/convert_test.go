@@ -161,6 +161,22 @@ func TestTagsFromTagsIterNoPool(t *testing.T) {
 	require.True(t, true, expectedTags.Equal(tags))
 }
 
+func TestTagsFromTagsIterWithArena(t *testing.T) {
+	var (
+		id           = ident.StringID("foo")
+		expectedTags = ident.NewTags(
+			ident.StringTag("bar", "baz"),
+			ident.StringTag("foo", "m3"),
+		)
+		tagsIter = ident.NewTagsIterator(expectedTags)
+	)
+
+	tags, err := convert.TagsFromTagsIterWithArena(id, tagsIter,
+		convert.NewBytesArena())
+	require.NoError(t, err)
+	require.True(t, expectedTags.Equal(tags))
+}
+
 func TestToMetricInvalidID(t *testing.T) {
 	d := doc.Document{
 		Fields: []doc.Field{
@@ -0,0 +1,161 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package convert
+
+import (
+	"bytes"
+	"fmt"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+const (
+	// MaxSeriesMetadataValueLength is the maximum length in bytes of a
+	// series metadata value.
+	MaxSeriesMetadataValueLength = 256
+)
+
+var (
+	// SeriesMetadataFieldPrefix is the prefix of the reserved tag names the
+	// metadata of a series is stored as.
+	SeriesMetadataFieldPrefix = []byte("__m3_meta_")
+
+	// SeriesMetadataFieldUnit is the reserved tag name of the unit of a series.
+	SeriesMetadataFieldUnit = []byte("__m3_meta_unit")
+	// SeriesMetadataFieldDescription is the reserved tag name of the
+	// description of a series.
+	SeriesMetadataFieldDescription = []byte("__m3_meta_description")
+	// SeriesMetadataFieldType is the reserved tag name of the type of a series.
+	SeriesMetadataFieldType = []byte("__m3_meta_type")
+)
+
+// SeriesMetadata is small immutable metadata attached to a series. It is
+// written as reserved tags alongside the tags of the series and like the tags
+// of a series is fixed by the first write of the series, it is stored in the
+// index and omitted from the tags returned by queries unless requested.
+//
+// NB: Callers that derive series IDs from tags must exclude the metadata tags
+// so that the ID of a series does not change with its metadata.
+type SeriesMetadata struct {
+	Unit        string `json:"unit,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+}
+
+// IsEmpty returns whether no metadata is set.
+func (m SeriesMetadata) IsEmpty() bool {
+	return m == SeriesMetadata{}
+}
+
+// Tags returns the reserved tags to write alongside the tags of a series to
+// attach the metadata to the series.
+func (m SeriesMetadata) Tags() []ident.Tag {
+	tags := make([]ident.Tag, 0, 3)
+	if m.Unit != "" {
+		tags = append(tags, ident.StringTag(string(SeriesMetadataFieldUnit), m.Unit))
+	}
+	if m.Description != "" {
+		tags = append(tags, ident.StringTag(string(SeriesMetadataFieldDescription), m.Description))
+	}
+	if m.Type != "" {
+		tags = append(tags, ident.StringTag(string(SeriesMetadataFieldType), m.Type))
+	}
+	return tags
+}
+
+// set sets the metadata value for a reserved tag name, returning false if
+// the name is not a series metadata tag name.
+func (m *SeriesMetadata) set(name, value []byte) bool {
+	switch {
+	case bytes.Equal(name, SeriesMetadataFieldUnit):
+		m.Unit = string(value)
+	case bytes.Equal(name, SeriesMetadataFieldDescription):
+		m.Description = string(value)
+	case bytes.Equal(name, SeriesMetadataFieldType):
+		m.Type = string(value)
+	default:
+		return false
+	}
+	return true
+}
+
+// IsSeriesMetadataField returns whether a tag or field name is reserved for
+// series metadata.
+func IsSeriesMetadataField(name []byte) bool {
+	return bytes.HasPrefix(name, SeriesMetadataFieldPrefix)
+}
+
+// SeriesMetadataFromTags returns the metadata stored in the tags of a series.
+func SeriesMetadataFromTags(tags ident.TagIterator) (SeriesMetadata, error) {
+	var m SeriesMetadata
+	for tags.Next() {
+		tag := tags.Current()
+		m.set(tag.Name.Bytes(), tag.Value.Bytes())
+	}
+	return m, tags.Err()
+}
+
+// SeriesMetadataFromDocument returns the metadata stored in the fields of
+// the document of a series.
+func SeriesMetadataFromDocument(d doc.Document) SeriesMetadata {
+	var m SeriesMetadata
+	for _, field := range d.Fields {
+		m.set(field.Name, field.Value)
+	}
+	return m
+}
+
+// WithoutSeriesMetadata returns the document without its series metadata
+// fields, the document is returned as is if it has none.
+func WithoutSeriesMetadata(d doc.Document) doc.Document {
+	n := 0
+	for _, field := range d.Fields {
+		if IsSeriesMetadataField(field.Name) {
+			n++
+		}
+	}
+	if n == 0 {
+		return d
+	}
+
+	fields := make([]doc.Field, 0, len(d.Fields)-n)
+	for _, field := range d.Fields {
+		if !IsSeriesMetadataField(field.Name) {
+			fields = append(fields, field)
+		}
+	}
+	d.Fields = fields
+	return d
+}
+
+func validateSeriesMetadataTag(name, value []byte) error {
+	var m SeriesMetadata
+	if !m.set(name, value) {
+		return fmt.Errorf("series contains unknown series metadata field: field=%s",
+			name)
+	}
+	if len(value) > MaxSeriesMetadataValueLength {
+		return fmt.Errorf("series metadata value exceeds max length: "+
+			"field=%s, length=%d, max=%d", name, len(value), MaxSeriesMetadataValueLength)
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package convert_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeriesMetadataTagsRoundTrip(t *testing.T) {
+	metadata := convert.SeriesMetadata{
+		Unit:        "seconds",
+		Description: "request latency",
+		Type:        "histogram",
+	}
+	require.False(t, metadata.IsEmpty())
+	require.True(t, convert.SeriesMetadata{}.IsEmpty())
+	require.Empty(t, convert.SeriesMetadata{}.Tags())
+
+	tags := ident.NewTags(ident.StringTag("city", "nyc"))
+	for _, tag := range metadata.Tags() {
+		require.NoError(t, convert.ValidateSeriesTag(tag))
+		tags.Append(tag)
+	}
+	require.NoError(t, convert.ValidateSeries(ident.StringID("foo"), tags))
+
+	decoded, err := convert.SeriesMetadataFromTags(ident.NewTagsIterator(tags))
+	require.NoError(t, err)
+	require.Equal(t, metadata, decoded)
+
+	d, err := convert.FromMetric(ident.StringID("foo"), tags)
+	require.NoError(t, err)
+	require.Equal(t, metadata, convert.SeriesMetadataFromDocument(d))
+}
+
+func TestSeriesMetadataValidate(t *testing.T) {
+	err := convert.ValidateSeriesTag(ident.StringTag("__m3_meta_color", "red"))
+	require.Error(t, err)
+
+	err = convert.ValidateSeriesTag(ident.StringTag(
+		string(convert.SeriesMetadataFieldDescription),
+		strings.Repeat("a", convert.MaxSeriesMetadataValueLength+1)))
+	require.Error(t, err)
+}
+
+func TestWithoutSeriesMetadata(t *testing.T) {
+	d := doc.Document{
+		ID: []byte("foo"),
+		Fields: []doc.Field{
+			{Name: []byte("city"), Value: []byte("nyc")},
+			{Name: convert.SeriesMetadataFieldUnit, Value: []byte("seconds")},
+		},
+	}
+	stripped := convert.WithoutSeriesMetadata(d)
+	assert.Equal(t, []doc.Field{
+		{Name: []byte("city"), Value: []byte("nyc")},
+	}, stripped.Fields)
+	// The original document is untouched.
+	assert.Equal(t, 2, len(d.Fields))
+
+	// Documents without metadata are returned as is.
+	assert.Equal(t, stripped, convert.WithoutSeriesMetadata(stripped))
+	assert.True(t, convert.IsSeriesMetadataField(convert.SeriesMetadataFieldType))
+	assert.False(t, convert.IsSeriesMetadataField([]byte("city")))
+}
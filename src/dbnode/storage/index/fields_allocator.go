@@ -0,0 +1,60 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"github.com/m3db/m3/src/m3ninx/doc"
+)
+
+const defaultFieldsAllocatorChunkSize = 4096
+
+// FieldsAllocator allocates document fields slices from larger chunks so
+// that converting a batch of series to documents requires a handful of
+// allocations rather than one allocation per document.
+//
+// NB: Chunks are never reused since segments may retain references to the
+// fields of documents inserted into them, a chunk is released once all
+// documents allocated from it are no longer referenced.
+type FieldsAllocator struct {
+	chunkSize int
+	chunk     doc.Fields
+}
+
+// NewFieldsAllocator returns a new fields allocator.
+func NewFieldsAllocator() *FieldsAllocator {
+	return &FieldsAllocator{chunkSize: defaultFieldsAllocatorChunkSize}
+}
+
+// Alloc returns a zero length fields slice with capacity for n fields.
+func (a *FieldsAllocator) Alloc(n int) doc.Fields {
+	if n > a.chunkSize {
+		// Too large to allocate from a chunk.
+		return make(doc.Fields, 0, n)
+	}
+	if cap(a.chunk)-len(a.chunk) < n {
+		a.chunk = make(doc.Fields, 0, a.chunkSize)
+	}
+	start := len(a.chunk)
+	a.chunk = a.chunk[:start+n]
+	// Use a full slice expression so appending to the returned slice beyond
+	// its capacity cannot overwrite fields allocated to another document.
+	return a.chunk[start : start : start+n]
+}
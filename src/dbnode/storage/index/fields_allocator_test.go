@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldsAllocatorAllocDoesNotOverlap(t *testing.T) {
+	alloc := &FieldsAllocator{chunkSize: 4}
+
+	a := alloc.Alloc(2)
+	b := alloc.Alloc(2)
+	require.Equal(t, 0, len(a))
+	require.Equal(t, 2, cap(a))
+
+	a = append(a, doc.Field{Name: []byte("a")}, doc.Field{Name: []byte("b")})
+	b = append(b, doc.Field{Name: []byte("c")}, doc.Field{Name: []byte("d")})
+
+	// Appending beyond capacity must reallocate rather than overwrite b.
+	a = append(a, doc.Field{Name: []byte("e")})
+	require.Equal(t, []byte("c"), b[0].Name)
+	require.Equal(t, []byte("e"), a[2].Name)
+
+	// Chunk exhausted, next allocation comes from a new chunk.
+	c := alloc.Alloc(3)
+	require.Equal(t, 3, cap(c))
+
+	// Allocations larger than a chunk are allocated directly.
+	d := alloc.Alloc(5)
+	require.Equal(t, 5, cap(d))
+}
+
+func BenchmarkFieldsAllocatorAlloc(b *testing.B) {
+	alloc := NewFieldsAllocator()
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = alloc.Alloc(8)
+	}
+}
+
+func BenchmarkFieldsMakeAlloc(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		_ = make(doc.Fields, 0, 8)
+	}
+}
@@ -29,6 +29,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/doc"
@@ -230,6 +231,252 @@ func (mr *MockQueryResultsMockRecorder) Map() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Map", reflect.TypeOf((*MockQueryResults)(nil).Map))
 }
 
+// SpilledIter mocks base method
+func (m *MockQueryResults) SpilledIter() (SpilledResultsIter, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpilledIter")
+	ret0, _ := ret[0].(SpilledResultsIter)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SpilledIter indicates an expected call of SpilledIter
+func (mr *MockQueryResultsMockRecorder) SpilledIter() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpilledIter", reflect.TypeOf((*MockQueryResults)(nil).SpilledIter))
+}
+
+// MockSpilledResultsIter is a mock of SpilledResultsIter interface
+type MockSpilledResultsIter struct {
+	ctrl     *gomock.Controller
+	recorder *MockSpilledResultsIterMockRecorder
+}
+
+// MockSpilledResultsIterMockRecorder is the mock recorder for MockSpilledResultsIter
+type MockSpilledResultsIterMockRecorder struct {
+	mock *MockSpilledResultsIter
+}
+
+// NewMockSpilledResultsIter creates a new mock instance
+func NewMockSpilledResultsIter(ctrl *gomock.Controller) *MockSpilledResultsIter {
+	mock := &MockSpilledResultsIter{ctrl: ctrl}
+	mock.recorder = &MockSpilledResultsIterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSpilledResultsIter) EXPECT() *MockSpilledResultsIterMockRecorder {
+	return m.recorder
+}
+
+// Next mocks base method
+func (m *MockSpilledResultsIter) Next() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Next")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Next indicates an expected call of Next
+func (mr *MockSpilledResultsIterMockRecorder) Next() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Next", reflect.TypeOf((*MockSpilledResultsIter)(nil).Next))
+}
+
+// Current mocks base method
+func (m *MockSpilledResultsIter) Current() ResultsMapEntry {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Current")
+	ret0, _ := ret[0].(ResultsMapEntry)
+	return ret0
+}
+
+// Current indicates an expected call of Current
+func (mr *MockSpilledResultsIterMockRecorder) Current() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Current", reflect.TypeOf((*MockSpilledResultsIter)(nil).Current))
+}
+
+// Err mocks base method
+func (m *MockSpilledResultsIter) Err() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Err")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Err indicates an expected call of Err
+func (mr *MockSpilledResultsIterMockRecorder) Err() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Err", reflect.TypeOf((*MockSpilledResultsIter)(nil).Err))
+}
+
+// MockQuerySpillLimiter is a mock of QuerySpillLimiter interface
+type MockQuerySpillLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockQuerySpillLimiterMockRecorder
+}
+
+// MockQuerySpillLimiterMockRecorder is the mock recorder for MockQuerySpillLimiter
+type MockQuerySpillLimiterMockRecorder struct {
+	mock *MockQuerySpillLimiter
+}
+
+// NewMockQuerySpillLimiter creates a new mock instance
+func NewMockQuerySpillLimiter(ctrl *gomock.Controller) *MockQuerySpillLimiter {
+	mock := &MockQuerySpillLimiter{ctrl: ctrl}
+	mock.recorder = &MockQuerySpillLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockQuerySpillLimiter) EXPECT() *MockQuerySpillLimiterMockRecorder {
+	return m.recorder
+}
+
+// Reserve mocks base method
+func (m *MockQuerySpillLimiter) Reserve(bytes int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", bytes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reserve indicates an expected call of Reserve
+func (mr *MockQuerySpillLimiterMockRecorder) Reserve(bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockQuerySpillLimiter)(nil).Reserve), bytes)
+}
+
+// Release mocks base method
+func (m *MockQuerySpillLimiter) Release(bytes int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release", bytes)
+}
+
+// Release indicates an expected call of Release
+func (mr *MockQuerySpillLimiterMockRecorder) Release(bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockQuerySpillLimiter)(nil).Release), bytes)
+}
+
+// SpilledBytes mocks base method
+func (m *MockQuerySpillLimiter) SpilledBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SpilledBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// SpilledBytes indicates an expected call of SpilledBytes
+func (mr *MockQuerySpillLimiterMockRecorder) SpilledBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SpilledBytes", reflect.TypeOf((*MockQuerySpillLimiter)(nil).SpilledBytes))
+}
+
+// MockQueryLimiter is a mock of QueryLimiter interface
+type MockQueryLimiter struct {
+	ctrl     *gomock.Controller
+	recorder *MockQueryLimiterMockRecorder
+}
+
+// MockQueryLimiterMockRecorder is the mock recorder for MockQueryLimiter
+type MockQueryLimiterMockRecorder struct {
+	mock *MockQueryLimiter
+}
+
+// NewMockQueryLimiter creates a new mock instance
+func NewMockQueryLimiter(ctrl *gomock.Controller) *MockQueryLimiter {
+	mock := &MockQueryLimiter{ctrl: ctrl}
+	mock.recorder = &MockQueryLimiterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockQueryLimiter) EXPECT() *MockQueryLimiterMockRecorder {
+	return m.recorder
+}
+
+// StartQuery mocks base method
+func (m *MockQueryLimiter) StartQuery() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StartQuery")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StartQuery indicates an expected call of StartQuery
+func (mr *MockQueryLimiterMockRecorder) StartQuery() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StartQuery", reflect.TypeOf((*MockQueryLimiter)(nil).StartQuery))
+}
+
+// DoneQuery mocks base method
+func (m *MockQueryLimiter) DoneQuery() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DoneQuery")
+}
+
+// DoneQuery indicates an expected call of DoneQuery
+func (mr *MockQueryLimiterMockRecorder) DoneQuery() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DoneQuery", reflect.TypeOf((*MockQueryLimiter)(nil).DoneQuery))
+}
+
+// Reserve mocks base method
+func (m *MockQueryLimiter) Reserve(bytes int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reserve", bytes)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Reserve indicates an expected call of Reserve
+func (mr *MockQueryLimiterMockRecorder) Reserve(bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reserve", reflect.TypeOf((*MockQueryLimiter)(nil).Reserve), bytes)
+}
+
+// Release mocks base method
+func (m *MockQueryLimiter) Release(bytes int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Release", bytes)
+}
+
+// Release indicates an expected call of Release
+func (mr *MockQueryLimiterMockRecorder) Release(bytes interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockQueryLimiter)(nil).Release), bytes)
+}
+
+// ConcurrentQueries mocks base method
+func (m *MockQueryLimiter) ConcurrentQueries() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConcurrentQueries")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// ConcurrentQueries indicates an expected call of ConcurrentQueries
+func (mr *MockQueryLimiterMockRecorder) ConcurrentQueries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConcurrentQueries", reflect.TypeOf((*MockQueryLimiter)(nil).ConcurrentQueries))
+}
+
+// InFlightResultBytes mocks base method
+func (m *MockQueryLimiter) InFlightResultBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InFlightResultBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// InFlightResultBytes indicates an expected call of InFlightResultBytes
+func (mr *MockQueryLimiterMockRecorder) InFlightResultBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InFlightResultBytes", reflect.TypeOf((*MockQueryLimiter)(nil).InFlightResultBytes))
+}
+
 // MockQueryResultsPool is a mock of QueryResultsPool interface
 type MockQueryResultsPool struct {
 	ctrl     *gomock.Controller
@@ -396,11 +643,12 @@ func (mr *MockAggregateResultsMockRecorder) AggregateResultsOptions() *gomock.Ca
 }
 
 // AddFields mocks base method
-func (m *MockAggregateResults) AddFields(batch []AggregateResultsEntry) int {
+func (m *MockAggregateResults) AddFields(batch []AggregateResultsEntry) (int, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "AddFields", batch)
 	ret0, _ := ret[0].(int)
-	return ret0
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
 }
 
 // AddFields indicates an expected call of AddFields
@@ -728,6 +976,20 @@ func (mr *MockBlockMockRecorder) AddResults(results interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddResults", reflect.TypeOf((*MockBlock)(nil).AddResults), results)
 }
 
+// AddImported mocks base method
+func (m *MockBlock) AddImported(docs []doc.Document) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImported", docs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddImported indicates an expected call of AddImported
+func (mr *MockBlockMockRecorder) AddImported(docs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImported", reflect.TypeOf((*MockBlock)(nil).AddImported), docs)
+}
+
 // Tick mocks base method
 func (m *MockBlock) Tick(c context.Cancellable) (BlockTickResult, error) {
 	m.ctrl.T.Helper()
@@ -743,6 +1005,21 @@ func (mr *MockBlockMockRecorder) Tick(c interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Tick", reflect.TypeOf((*MockBlock)(nil).Tick), c)
 }
 
+// PurgeDocuments mocks base method
+func (m *MockBlock) PurgeDocuments(filter segment.DocumentsFilter) (BlockPurgeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDocuments", filter)
+	ret0, _ := ret[0].(BlockPurgeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDocuments indicates an expected call of PurgeDocuments
+func (mr *MockBlockMockRecorder) PurgeDocuments(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDocuments", reflect.TypeOf((*MockBlock)(nil).PurgeDocuments), filter)
+}
+
 // Stats mocks base method
 func (m *MockBlock) Stats(reporter BlockStatsReporter) error {
 	m.ctrl.T.Helper()
@@ -1552,3 +1829,115 @@ func (mr *MockOptionsMockRecorder) MmapReporter() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MmapReporter", reflect.TypeOf((*MockOptions)(nil).MmapReporter))
 }
+
+// SetDocumentsPurgeInterval mocks base method
+func (m *MockOptions) SetDocumentsPurgeInterval(value time.Duration) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetDocumentsPurgeInterval", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetDocumentsPurgeInterval indicates an expected call of SetDocumentsPurgeInterval
+func (mr *MockOptionsMockRecorder) SetDocumentsPurgeInterval(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDocumentsPurgeInterval", reflect.TypeOf((*MockOptions)(nil).SetDocumentsPurgeInterval), value)
+}
+
+// DocumentsPurgeInterval mocks base method
+func (m *MockOptions) DocumentsPurgeInterval() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DocumentsPurgeInterval")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// DocumentsPurgeInterval indicates an expected call of DocumentsPurgeInterval
+func (mr *MockOptionsMockRecorder) DocumentsPurgeInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DocumentsPurgeInterval", reflect.TypeOf((*MockOptions)(nil).DocumentsPurgeInterval))
+}
+
+// SetQueryBytesBudget mocks base method
+func (m *MockOptions) SetQueryBytesBudget(value int64) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQueryBytesBudget", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetQueryBytesBudget indicates an expected call of SetQueryBytesBudget
+func (mr *MockOptionsMockRecorder) SetQueryBytesBudget(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQueryBytesBudget", reflect.TypeOf((*MockOptions)(nil).SetQueryBytesBudget), value)
+}
+
+// QueryBytesBudget mocks base method
+func (m *MockOptions) QueryBytesBudget() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryBytesBudget")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// QueryBytesBudget indicates an expected call of QueryBytesBudget
+func (mr *MockOptionsMockRecorder) QueryBytesBudget() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryBytesBudget", reflect.TypeOf((*MockOptions)(nil).QueryBytesBudget))
+}
+
+// SetQuerySpillOptions mocks base method
+func (m *MockOptions) SetQuerySpillOptions(value QuerySpillOptions) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetQuerySpillOptions", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetQuerySpillOptions indicates an expected call of SetQuerySpillOptions
+func (mr *MockOptionsMockRecorder) SetQuerySpillOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetQuerySpillOptions", reflect.TypeOf((*MockOptions)(nil).SetQuerySpillOptions), value)
+}
+
+// QuerySpillOptions mocks base method
+func (m *MockOptions) QuerySpillOptions() QuerySpillOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QuerySpillOptions")
+	ret0, _ := ret[0].(QuerySpillOptions)
+	return ret0
+}
+
+// QuerySpillOptions indicates an expected call of QuerySpillOptions
+func (mr *MockOptionsMockRecorder) QuerySpillOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QuerySpillOptions", reflect.TypeOf((*MockOptions)(nil).QuerySpillOptions))
+}
+
+// SetBackgroundScheduler mocks base method
+func (m *MockOptions) SetBackgroundScheduler(value background.Scheduler) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBackgroundScheduler", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetBackgroundScheduler indicates an expected call of SetBackgroundScheduler
+func (mr *MockOptionsMockRecorder) SetBackgroundScheduler(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBackgroundScheduler", reflect.TypeOf((*MockOptions)(nil).SetBackgroundScheduler), value)
+}
+
+// BackgroundScheduler mocks base method
+func (m *MockOptions) BackgroundScheduler() background.Scheduler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackgroundScheduler")
+	ret0, _ := ret[0].(background.Scheduler)
+	return ret0
+}
+
+// BackgroundScheduler indicates an expected call of BackgroundScheduler
+func (mr *MockOptionsMockRecorder) BackgroundScheduler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackgroundScheduler", reflect.TypeOf((*MockOptions)(nil).BackgroundScheduler))
+}
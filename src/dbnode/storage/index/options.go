@@ -22,8 +22,10 @@ package index
 
 import (
 	"errors"
+	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/m3ninx/index/segment/builder"
@@ -122,6 +124,10 @@ type opts struct {
 	postingsListCache               *PostingsListCache
 	readThroughSegmentOptions       ReadThroughSegmentOptions
 	mmapReporter                    mmap.Reporter
+	documentsPurgeInterval          time.Duration
+	queryBytesBudget                int64
+	querySpillOptions               QuerySpillOptions
+	backgroundScheduler             background.Scheduler
 }
 
 var undefinedUUIDFn = func() ([]byte, error) { return nil, errIDGenerationDisabled }
@@ -172,6 +178,7 @@ func NewOptions() Options {
 		aggResultsEntryArrayPool:        aggResultsEntryArrayPool,
 		foregroundCompactionPlannerOpts: defaultForegroundCompactionOpts,
 		backgroundCompactionPlannerOpts: defaultBackgroundCompactionOpts,
+		backgroundScheduler:             background.NewNoopScheduler(),
 	}
 	resultsPool.Init(func() QueryResults {
 		return NewQueryResults(nil, QueryResultsOptions{}, opts)
@@ -414,3 +421,43 @@ func (o *opts) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 func (o *opts) MmapReporter() mmap.Reporter {
 	return o.mmapReporter
 }
+
+func (o *opts) SetDocumentsPurgeInterval(value time.Duration) Options {
+	opts := *o
+	opts.documentsPurgeInterval = value
+	return &opts
+}
+
+func (o *opts) DocumentsPurgeInterval() time.Duration {
+	return o.documentsPurgeInterval
+}
+
+func (o *opts) SetQueryBytesBudget(value int64) Options {
+	opts := *o
+	opts.queryBytesBudget = value
+	return &opts
+}
+
+func (o *opts) QueryBytesBudget() int64 {
+	return o.queryBytesBudget
+}
+
+func (o *opts) SetQuerySpillOptions(value QuerySpillOptions) Options {
+	opts := *o
+	opts.querySpillOptions = value
+	return &opts
+}
+
+func (o *opts) QuerySpillOptions() QuerySpillOptions {
+	return o.querySpillOptions
+}
+
+func (o *opts) SetBackgroundScheduler(value background.Scheduler) Options {
+	opts := *o
+	opts.backgroundScheduler = value
+	return &opts
+}
+
+func (o *opts) BackgroundScheduler() background.Scheduler {
+	return o.backgroundScheduler
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"fmt"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// QueryBudgetExceededError is returned when the bytes retained by the
+// results of an index query exceed the per-query budget, the query is
+// aborted rather than allowed to continue to consume memory.
+type QueryBudgetExceededError struct {
+	// Budget is the per-query budget in bytes.
+	Budget int64
+	// Retained is the number of bytes retained when the query was aborted.
+	Retained int64
+}
+
+func (e QueryBudgetExceededError) Error() string {
+	return fmt.Sprintf("index query exceeded budget: retained=%d, budget=%d",
+		e.Retained, e.Budget)
+}
+
+// newQueryBudgetExceededError returns a query budget exceeded error marked
+// as invalid params since retrying the same query will exceed the budget
+// again.
+func newQueryBudgetExceededError(budget, retained int64) error {
+	return xerrors.NewInvalidParamsError(QueryBudgetExceededError{
+		Budget:   budget,
+		Retained: retained,
+	})
+}
+
+// IsQueryBudgetExceededError returns whether the error is, or wraps, a
+// query budget exceeded error.
+func IsQueryBudgetExceededError(err error) bool {
+	for err != nil {
+		if _, ok := err.(QueryBudgetExceededError); ok {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+// queryBudgetExceeded returns an error if the retained bytes exceed the
+// budget, a budget of zero or less disables the check.
+func queryBudgetExceeded(budget, retained int64) error {
+	if budget > 0 && retained > budget {
+		return newQueryBudgetExceededError(budget, retained)
+	}
+	return nil
+}
+
+// documentBytes returns the number of bytes a document retains when added
+// to query results.
+func documentBytes(d doc.Document) int64 {
+	size := int64(len(d.ID))
+	for _, f := range d.Fields {
+		size += int64(len(f.Name) + len(f.Value))
+	}
+	return size
+}
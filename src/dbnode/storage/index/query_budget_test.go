@@ -0,0 +1,62 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryBudgetExceeded(t *testing.T) {
+	require.NoError(t, queryBudgetExceeded(0, 100))
+	require.NoError(t, queryBudgetExceeded(100, 100))
+
+	err := queryBudgetExceeded(100, 101)
+	require.Error(t, err)
+	assert.True(t, IsQueryBudgetExceededError(err))
+	assert.True(t, xerrors.IsInvalidParams(err))
+
+	inner := xerrors.GetInnerInvalidParamsError(err)
+	budgetErr, ok := inner.(QueryBudgetExceededError)
+	require.True(t, ok)
+	assert.Equal(t, int64(100), budgetErr.Budget)
+	assert.Equal(t, int64(101), budgetErr.Retained)
+
+	assert.False(t, IsQueryBudgetExceededError(nil))
+	assert.False(t, IsQueryBudgetExceededError(errors.New("foo")))
+}
+
+func TestDocumentBytes(t *testing.T) {
+	d := doc.Document{
+		ID: []byte("abc"),
+		Fields: doc.Fields{
+			{Name: []byte("foo"), Value: []byte("bar")},
+			{Name: []byte("a"), Value: []byte("")},
+		},
+	}
+	assert.Equal(t, int64(10), documentBytes(d))
+}
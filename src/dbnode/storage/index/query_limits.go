@@ -0,0 +1,144 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// QueryLimitExceededError is returned when an index query against a
+// namespace would exceed one of the query limits of the namespace.
+type QueryLimitExceededError struct {
+	// Limit is the name of the limit exceeded.
+	Limit string
+	// Max is the value of the limit exceeded.
+	Max int64
+}
+
+func (e QueryLimitExceededError) Error() string {
+	return fmt.Sprintf("index query exceeded namespace limit: limit=%s, max=%d",
+		e.Limit, e.Max)
+}
+
+// newQueryLimitExceededError returns a query limit exceeded error marked as
+// resource exhausted since the query may succeed once other queries against
+// the namespace complete.
+func newQueryLimitExceededError(limit string, max int64) error {
+	return xerrors.NewResourceExhaustedError(QueryLimitExceededError{
+		Limit: limit,
+		Max:   max,
+	})
+}
+
+// IsQueryLimitExceededError returns whether the error is, or wraps, a
+// query limit exceeded error.
+func IsQueryLimitExceededError(err error) bool {
+	for err != nil {
+		if _, ok := err.(QueryLimitExceededError); ok {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+type queryLimiter struct {
+	maxConcurrentQueries   int64
+	maxInFlightResultBytes int64
+
+	concurrentQueries   int64
+	inFlightResultBytes int64
+}
+
+// NewQueryLimiter returns a new query limiter enforcing the query limits of
+// a namespace.
+func NewQueryLimiter(opts namespace.QueryLimitsOptions) QueryLimiter {
+	return &queryLimiter{
+		maxConcurrentQueries:   int64(opts.MaxConcurrentQueries),
+		maxInFlightResultBytes: opts.MaxInFlightResultBytes,
+	}
+}
+
+func (l *queryLimiter) StartQuery() error {
+	n := atomic.AddInt64(&l.concurrentQueries, 1)
+	if l.maxConcurrentQueries > 0 && n > l.maxConcurrentQueries {
+		atomic.AddInt64(&l.concurrentQueries, -1)
+		return newQueryLimitExceededError("maxConcurrentQueries",
+			l.maxConcurrentQueries)
+	}
+	return nil
+}
+
+func (l *queryLimiter) DoneQuery() {
+	atomic.AddInt64(&l.concurrentQueries, -1)
+}
+
+func (l *queryLimiter) Reserve(bytes int64) error {
+	n := atomic.AddInt64(&l.inFlightResultBytes, bytes)
+	if l.maxInFlightResultBytes > 0 && n > l.maxInFlightResultBytes {
+		atomic.AddInt64(&l.inFlightResultBytes, -bytes)
+		return newQueryLimitExceededError("maxInFlightResultBytes",
+			l.maxInFlightResultBytes)
+	}
+	return nil
+}
+
+func (l *queryLimiter) Release(bytes int64) {
+	atomic.AddInt64(&l.inFlightResultBytes, -bytes)
+}
+
+func (l *queryLimiter) ConcurrentQueries() int64 {
+	return atomic.LoadInt64(&l.concurrentQueries)
+}
+
+func (l *queryLimiter) InFlightResultBytes() int64 {
+	return atomic.LoadInt64(&l.inFlightResultBytes)
+}
+
+// reserveQueryLimitBytes reserves the bytes retained by query results since
+// the last reservation with the limiter, if any, and returns the number of
+// bytes reserved in total.
+func reserveQueryLimitBytes(
+	limiter QueryLimiter,
+	reserved int64,
+	retained int64,
+) (int64, error) {
+	if limiter == nil || retained <= reserved {
+		return reserved, nil
+	}
+	if err := limiter.Reserve(retained - reserved); err != nil {
+		return reserved, err
+	}
+	return retained, nil
+}
+
+// releaseQueryLimitBytes releases the bytes reserved by query results with
+// the limiter, if any.
+func releaseQueryLimitBytes(limiter QueryLimiter, reserved int64) {
+	if limiter == nil || reserved <= 0 {
+		return
+	}
+	limiter.Release(reserved)
+}
@@ -0,0 +1,130 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryLimiterConcurrentQueries(t *testing.T) {
+	limiter := NewQueryLimiter(namespace.QueryLimitsOptions{
+		MaxConcurrentQueries: 2,
+	})
+
+	require.NoError(t, limiter.StartQuery())
+	require.NoError(t, limiter.StartQuery())
+
+	err := limiter.StartQuery()
+	require.Error(t, err)
+	require.True(t, IsQueryLimitExceededError(err))
+	require.True(t, xerrors.IsResourceExhaustedError(err))
+	require.Equal(t, int64(2), limiter.ConcurrentQueries())
+
+	limiter.DoneQuery()
+	require.NoError(t, limiter.StartQuery())
+}
+
+func TestQueryLimiterInFlightResultBytes(t *testing.T) {
+	limiter := NewQueryLimiter(namespace.QueryLimitsOptions{
+		MaxInFlightResultBytes: 10,
+	})
+
+	require.NoError(t, limiter.Reserve(6))
+	err := limiter.Reserve(6)
+	require.Error(t, err)
+	require.True(t, IsQueryLimitExceededError(err))
+	require.Equal(t, int64(6), limiter.InFlightResultBytes())
+
+	limiter.Release(6)
+	require.NoError(t, limiter.Reserve(10))
+}
+
+func TestQueryLimiterNoLimits(t *testing.T) {
+	limiter := NewQueryLimiter(namespace.QueryLimitsOptions{})
+	for i := 0; i < 100; i++ {
+		require.NoError(t, limiter.StartQuery())
+		require.NoError(t, limiter.Reserve(1<<20))
+	}
+}
+
+func TestResultsQueryLimiterInFlightResultBytes(t *testing.T) {
+	limiter := NewQueryLimiter(namespace.QueryLimitsOptions{
+		MaxInFlightResultBytes: 10,
+	})
+
+	d1 := doc.Document{ID: []byte("abc"),
+		Fields: doc.Fields{
+			doc.Field{Name: []byte("foo"), Value: []byte("bar")},
+		}}
+	res1 := NewQueryResults(nil, QueryResultsOptions{
+		QueryLimiter: limiter,
+	}, testOpts)
+	_, err := res1.AddDocuments([]doc.Document{d1})
+	require.NoError(t, err)
+	require.Equal(t, int64(9), limiter.InFlightResultBytes())
+
+	// The limit is shared by the results of all queries in-flight.
+	d2 := doc.Document{ID: []byte("def")}
+	res2 := NewQueryResults(nil, QueryResultsOptions{
+		QueryLimiter: limiter,
+	}, testOpts)
+	_, err = res2.AddDocuments([]doc.Document{d2})
+	require.Error(t, err)
+	require.True(t, IsQueryLimitExceededError(err))
+
+	// Resetting the results releases the bytes they reserved.
+	res1.Reset(nil, QueryResultsOptions{})
+	require.Equal(t, int64(0), limiter.InFlightResultBytes())
+
+	res2.Reset(nil, QueryResultsOptions{QueryLimiter: limiter})
+	_, err = res2.AddDocuments([]doc.Document{d2})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), limiter.InFlightResultBytes())
+
+	res2.Finalize()
+	require.Equal(t, int64(0), limiter.InFlightResultBytes())
+}
+
+func TestAggResultsQueryLimiterInFlightResultBytes(t *testing.T) {
+	limiter := NewQueryLimiter(namespace.QueryLimitsOptions{
+		MaxInFlightResultBytes: 8,
+	})
+
+	res := NewAggregateResults(nil, AggregateResultsOptions{
+		QueryLimiter: limiter,
+	}, testOpts)
+	_, err := res.AddDocuments([]doc.Document{genDoc("foo", "bar")})
+	require.NoError(t, err)
+	require.Equal(t, int64(6), limiter.InFlightResultBytes())
+
+	_, err = res.AddDocuments([]doc.Document{genDoc("qux", "quux")})
+	require.Error(t, err)
+	require.True(t, IsQueryLimitExceededError(err))
+
+	res.Finalize()
+	require.Equal(t, int64(0), limiter.InFlightResultBytes())
+}
@@ -0,0 +1,336 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment/fst/encoding/docs"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	"github.com/m3db/m3/src/x/mmap"
+)
+
+const (
+	querySpillFilePrefix = "m3db-query-spill-"
+	querySpillBufferSize = 65536
+)
+
+// QuerySpillLimitExceededError is returned when spilling the results of an
+// index query to disk would exceed one of the spill limits.
+type QuerySpillLimitExceededError struct {
+	// Limit is the name of the limit exceeded.
+	Limit string
+	// Max is the value of the limit exceeded.
+	Max int64
+}
+
+func (e QuerySpillLimitExceededError) Error() string {
+	return fmt.Sprintf("index query exceeded spill limit: limit=%s, max=%d",
+		e.Limit, e.Max)
+}
+
+// newQuerySpillLimitExceededError returns a spill limit exceeded error, the
+// per query limit is marked as invalid params since retrying the same query
+// will exceed it again while the global limit is marked as resource
+// exhausted since the query may succeed once other spills are removed.
+func newQuerySpillLimitExceededError(limit string, max int64, global bool) error {
+	err := QuerySpillLimitExceededError{
+		Limit: limit,
+		Max:   max,
+	}
+	if global {
+		return xerrors.NewResourceExhaustedError(err)
+	}
+	return xerrors.NewInvalidParamsError(err)
+}
+
+// IsQuerySpillLimitExceededError returns whether the error is, or wraps, a
+// query spill limit exceeded error.
+func IsQuerySpillLimitExceededError(err error) bool {
+	for err != nil {
+		if _, ok := err.(QuerySpillLimitExceededError); ok {
+			return true
+		}
+		err = xerrors.InnerError(err)
+	}
+	return false
+}
+
+type querySpillLimiter struct {
+	maxBytes     int64
+	spilledBytes int64
+}
+
+// NewQuerySpillLimiter returns a new query spill limiter enforcing a limit
+// on the bytes spilled by all index queries, zero means no limit.
+func NewQuerySpillLimiter(maxBytes int64) QuerySpillLimiter {
+	return &querySpillLimiter{maxBytes: maxBytes}
+}
+
+func (l *querySpillLimiter) Reserve(bytes int64) error {
+	n := atomic.AddInt64(&l.spilledBytes, bytes)
+	if l.maxBytes > 0 && n > l.maxBytes {
+		atomic.AddInt64(&l.spilledBytes, -bytes)
+		return newQuerySpillLimitExceededError("maxBytes", l.maxBytes, true)
+	}
+	return nil
+}
+
+func (l *querySpillLimiter) Release(bytes int64) {
+	atomic.AddInt64(&l.spilledBytes, -bytes)
+}
+
+func (l *querySpillLimiter) SpilledBytes() int64 {
+	return atomic.LoadInt64(&l.spilledBytes)
+}
+
+// DeleteStaleQuerySpillFiles removes the query spill files in the directory,
+// or in the default directory for temporary files if empty, that were last
+// modified before the given time, returning the number of files removed.
+// Spill files are removed once the results of their query are finalized so
+// they are only left behind when a process crashes while queries are
+// spilling results.
+func DeleteStaleQuerySpillFiles(dir string, modifiedBefore time.Time) (int, error) {
+	if dir == "" {
+		dir = os.TempDir()
+	}
+	files, err := filepath.Glob(filepath.Join(dir, querySpillFilePrefix+"*"))
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		multiErr = xerrors.NewMultiError()
+		removed  int
+	)
+	for _, file := range files {
+		info, err := os.Stat(file)
+		if os.IsNotExist(err) {
+			// Removed by the query it belongs to since it was listed.
+			continue
+		}
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if info.IsDir() || !info.ModTime().Before(modifiedBefore) {
+			continue
+		}
+		if err := os.Remove(file); err != nil {
+			if !os.IsNotExist(err) {
+				multiErr = multiErr.Add(err)
+			}
+			continue
+		}
+		removed++
+	}
+	return removed, multiErr.FinalError()
+}
+
+// querySpill is a temporary file holding the documents of query results
+// that did not fit in the in-memory bytes budget of the query. Documents are
+// appended until the spill is first read, after which the file is mmap'd and
+// the documents read back reference the mmap'd bytes until the spill is
+// closed.
+type querySpill struct {
+	opts QuerySpillOptions
+
+	file   *os.File
+	buf    *bufio.Writer
+	writer *docs.DataWriter
+
+	// ids holds the IDs of the spilled documents so that documents with
+	// duplicate IDs are ignored as they are by the in-memory results.
+	ids     map[string]struct{}
+	offsets []uint64
+	written uint64
+	// spilledBytes is the number of bytes spilled, measured in the same way
+	// as the bytes retained by in-memory results, and reserved with the
+	// limiter if any.
+	spilledBytes int64
+
+	mmap   mmap.Descriptor
+	mapped bool
+}
+
+func newQuerySpill(opts QuerySpillOptions) (*querySpill, error) {
+	file, err := ioutil.TempFile(opts.Directory, querySpillFilePrefix)
+	if err != nil {
+		return nil, err
+	}
+	buf := bufio.NewWriterSize(file, querySpillBufferSize)
+	return &querySpill{
+		opts:   opts,
+		file:   file,
+		buf:    buf,
+		writer: docs.NewDataWriter(buf),
+		ids:    make(map[string]struct{}),
+	}, nil
+}
+
+func (s *querySpill) Contains(id []byte) bool {
+	_, ok := s.ids[string(id)]
+	return ok
+}
+
+func (s *querySpill) Len() int {
+	return len(s.offsets)
+}
+
+func (s *querySpill) Add(d doc.Document) error {
+	if s.mapped {
+		return fmt.Errorf("query spill %s already read", s.file.Name())
+	}
+
+	bytes := documentBytes(d)
+	if max := s.opts.MaxQueryBytes; max > 0 && s.spilledBytes+bytes > max {
+		return newQuerySpillLimitExceededError("maxQueryBytes", max, false)
+	}
+	if s.opts.Limiter != nil {
+		if err := s.opts.Limiter.Reserve(bytes); err != nil {
+			return err
+		}
+	}
+	s.spilledBytes += bytes
+
+	n, err := s.writer.Write(d)
+	if err != nil {
+		return err
+	}
+	s.offsets = append(s.offsets, s.written)
+	s.written += uint64(n)
+	s.ids[string(d.ID)] = struct{}{}
+	return nil
+}
+
+func (s *querySpill) Iter(seriesMetadata bool) (SpilledResultsIter, error) {
+	if !s.mapped {
+		if err := s.buf.Flush(); err != nil {
+			return nil, err
+		}
+		desc, err := mmap.File(s.file, mmap.Options{Read: true})
+		if err != nil {
+			return nil, err
+		}
+		s.mmap = desc
+		s.mapped = true
+	}
+	return &spilledResultsIter{
+		spill:          s,
+		reader:         docs.NewDataReader(s.mmap.Bytes),
+		seriesMetadata: seriesMetadata,
+		idx:            -1,
+	}, nil
+}
+
+// Close unmaps and removes the spill file and releases the bytes spilled
+// with the limiter, documents read back from the spill are invalid once it
+// is closed.
+func (s *querySpill) Close() error {
+	multiErr := xerrors.NewMultiError()
+	if s.mapped {
+		multiErr = multiErr.Add(mmap.Munmap(s.mmap))
+		s.mapped = false
+	}
+	multiErr = multiErr.Add(s.file.Close())
+	multiErr = multiErr.Add(os.Remove(s.file.Name()))
+	if s.opts.Limiter != nil && s.spilledBytes > 0 {
+		s.opts.Limiter.Release(s.spilledBytes)
+	}
+	s.spilledBytes = 0
+	return multiErr.FinalError()
+}
+
+type spilledResultsIter struct {
+	spill          *querySpill
+	reader         *docs.DataReader
+	seriesMetadata bool
+
+	idx  int
+	curr ResultsMapEntry
+	err  error
+}
+
+func (it *spilledResultsIter) Next() bool {
+	if it.err != nil || it.idx+1 >= len(it.spill.offsets) {
+		return false
+	}
+	it.idx++
+
+	d, err := it.reader.Read(it.spill.offsets[it.idx])
+	if err != nil {
+		it.err = err
+		return false
+	}
+	tagsDoc := d
+	if !it.seriesMetadata {
+		tagsDoc = convert.WithoutSeriesMetadata(d)
+	}
+	it.curr = ResultsMapEntry{
+		key:   _ResultsMapKey{key: ident.BytesID(d.ID)},
+		value: convert.ToMetricTags(tagsDoc, convert.Opts{NoClone: true}),
+	}
+	return true
+}
+
+func (it *spilledResultsIter) Current() ResultsMapEntry {
+	return it.curr
+}
+
+func (it *spilledResultsIter) Err() error {
+	return it.err
+}
+
+type emptySpilledResultsIter struct{}
+
+func (emptySpilledResultsIter) Next() bool               { return false }
+func (emptySpilledResultsIter) Current() ResultsMapEntry { return ResultsMapEntry{} }
+func (emptySpilledResultsIter) Err() error               { return nil }
+
+// ResultsEntries returns the results retained in memory followed by the
+// results spilled to disk once the results exceeded their in-memory bytes
+// budget, the spilled results are valid until the results are reset.
+func ResultsEntries(results QueryResults) ([]ResultsMapEntry, error) {
+	entries := make([]ResultsMapEntry, 0, results.Size())
+	for _, entry := range results.Map().Iter() {
+		entries = append(entries, entry)
+	}
+	iter, err := results.SpilledIter()
+	if err != nil {
+		return nil, err
+	}
+	for iter.Next() {
+		entries = append(entries, iter.Current())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
@@ -0,0 +1,193 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQuerySpillDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "query-spill")
+	require.NoError(t, err)
+	return dir
+}
+
+func requireQuerySpillFiles(t *testing.T, dir string, expected int) {
+	files, err := filepath.Glob(filepath.Join(dir, querySpillFilePrefix+"*"))
+	require.NoError(t, err)
+	require.Equal(t, expected, len(files))
+}
+
+func TestResultsSpillExceedingBytesBudget(t *testing.T) {
+	dir := newTestQuerySpillDir(t)
+	defer os.RemoveAll(dir)
+
+	limiter := NewQuerySpillLimiter(0)
+	opts := QueryResultsOptions{
+		BytesBudget: 10,
+		Spill: QuerySpillOptions{
+			Enabled:   true,
+			Directory: dir,
+			Limiter:   limiter,
+		},
+	}
+	res := NewQueryResults(nil, opts, testOpts)
+
+	d1 := doc.Document{ID: []byte("abc"),
+		Fields: doc.Fields{
+			doc.Field{Name: []byte("foo"), Value: []byte("bar")},
+		}}
+	d2 := doc.Document{ID: []byte("def")}
+	d3 := doc.Document{ID: []byte("ghi"),
+		Fields: doc.Fields{
+			doc.Field{Name: []byte("baz"), Value: []byte("qux")},
+		}}
+	size, err := res.AddDocuments([]doc.Document{d1, d2, d3})
+	require.NoError(t, err)
+	require.Equal(t, 3, size)
+
+	// Re-adding a spilled document is ignored as for retained documents.
+	size, err = res.AddDocuments([]doc.Document{d2})
+	require.NoError(t, err)
+	require.Equal(t, 3, size)
+
+	// Only the first document fits in the budget.
+	require.Equal(t, 1, res.Map().Len())
+	require.Equal(t, 3, res.Size())
+	require.Equal(t, int64(12), limiter.SpilledBytes())
+	requireQuerySpillFiles(t, dir, 1)
+
+	entries, err := ResultsEntries(res)
+	require.NoError(t, err)
+	require.Equal(t, 3, len(entries))
+	for i, d := range []doc.Document{d1, d2, d3} {
+		require.Equal(t, string(d.ID), entries[i].Key().String())
+		tags := entries[i].Value()
+		require.Equal(t, len(d.Fields), tags.Remaining())
+		for _, f := range d.Fields {
+			require.True(t, tags.Next())
+			require.True(t, ident.BytesID(f.Name).Equal(tags.Current().Name))
+			require.True(t, ident.BytesID(f.Value).Equal(tags.Current().Value))
+		}
+	}
+
+	// Reset removes the spill file and releases the bytes spilled.
+	res.Reset(nil, opts)
+	require.Equal(t, 0, res.Size())
+	require.Equal(t, int64(0), limiter.SpilledBytes())
+	requireQuerySpillFiles(t, dir, 0)
+}
+
+func TestResultsSpillLimitsExceeded(t *testing.T) {
+	dir := newTestQuerySpillDir(t)
+	defer os.RemoveAll(dir)
+
+	d1 := doc.Document{ID: []byte("abc")}
+	d2 := doc.Document{ID: []byte("def")}
+	d3 := doc.Document{ID: []byte("ghi")}
+
+	// The per query limit is exceeded by the third document.
+	res := NewQueryResults(nil, QueryResultsOptions{
+		BytesBudget: 3,
+		Spill: QuerySpillOptions{
+			Enabled:       true,
+			Directory:     dir,
+			MaxQueryBytes: 5,
+		},
+	}, testOpts)
+	_, err := res.AddDocuments([]doc.Document{d1, d2, d3})
+	require.Error(t, err)
+	require.True(t, IsQuerySpillLimitExceededError(err))
+	res.Reset(nil, QueryResultsOptions{})
+
+	// The global limit is exceeded once another query holds spilled bytes.
+	limiter := NewQuerySpillLimiter(5)
+	require.NoError(t, limiter.Reserve(3))
+	res = NewQueryResults(nil, QueryResultsOptions{
+		BytesBudget: 3,
+		Spill: QuerySpillOptions{
+			Enabled:   true,
+			Directory: dir,
+			Limiter:   limiter,
+		},
+	}, testOpts)
+	_, err = res.AddDocuments([]doc.Document{d1, d2, d3})
+	require.Error(t, err)
+	require.True(t, IsQuerySpillLimitExceededError(err))
+	require.Equal(t, int64(3), limiter.SpilledBytes())
+	res.Reset(nil, QueryResultsOptions{})
+	requireQuerySpillFiles(t, dir, 0)
+}
+
+func TestResultsWithoutSpillBytesBudgetExceeded(t *testing.T) {
+	res := NewQueryResults(nil, QueryResultsOptions{BytesBudget: 3}, testOpts)
+	_, err := res.AddDocuments([]doc.Document{
+		{ID: []byte("abc")},
+		{ID: []byte("def")},
+	})
+	require.Error(t, err)
+	require.True(t, IsQueryBudgetExceededError(err))
+
+	iter, err := res.SpilledIter()
+	require.NoError(t, err)
+	require.False(t, iter.Next())
+}
+
+func TestDeleteStaleQuerySpillFiles(t *testing.T) {
+	dir := newTestQuerySpillDir(t)
+	defer os.RemoveAll(dir)
+
+	// A spill left behind by a crash, one still in use and an unrelated file.
+	stale, err := newQuerySpill(QuerySpillOptions{Directory: dir})
+	require.NoError(t, err)
+	require.NoError(t, stale.file.Close())
+	staleAt := time.Now().Add(-time.Hour)
+	require.NoError(t, os.Chtimes(stale.file.Name(), staleAt, staleAt))
+
+	inUse, err := newQuerySpill(QuerySpillOptions{Directory: dir})
+	require.NoError(t, err)
+	defer inUse.Close()
+
+	other := filepath.Join(dir, "other")
+	require.NoError(t, ioutil.WriteFile(other, []byte("other"), 0666))
+	require.NoError(t, os.Chtimes(other, staleAt, staleAt))
+
+	removed, err := DeleteStaleQuerySpillFiles(dir, time.Now().Add(-time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 1, removed)
+	requireQuerySpillFiles(t, dir, 1)
+
+	_, err = os.Stat(stale.file.Name())
+	require.True(t, os.IsNotExist(err))
+	_, err = os.Stat(inUse.file.Name())
+	require.NoError(t, err)
+	_, err = os.Stat(other)
+	require.NoError(t, err)
+}
@@ -41,6 +41,15 @@ type results struct {
 	opts QueryResultsOptions
 
 	resultsMap *ResultsMap
+	// retainedBytes is the number of bytes retained by the results, used
+	// to enforce the bytes budget of the query.
+	retainedBytes int64
+	// reservedBytes is the number of retained bytes reserved with the query
+	// limiter of the namespace, released when the results are reset.
+	reservedBytes int64
+	// spill holds the documents spilled to disk once the bytes budget was
+	// exceeded, it is nil until the first document is spilled.
+	spill *querySpill
 
 	idPool    ident.Pool
 	bytesPool pool.CheckedBytesPool
@@ -68,7 +77,18 @@ func NewQueryResults(
 func (r *results) Reset(nsID ident.ID, opts QueryResultsOptions) {
 	r.Lock()
 
+	releaseQueryLimitBytes(r.opts.QueryLimiter, r.reservedBytes)
 	r.opts = opts
+	r.retainedBytes = 0
+	r.reservedBytes = 0
+
+	// Remove any spill file, the results read back from it reference its
+	// mmap'd bytes so it's only safe to do so on reset.
+	if r.spill != nil {
+		// Nothing to do with the error besides continuing to reset.
+		_ = r.spill.Close()
+		r.spill = nil
+	}
 
 	// Finalize existing held nsID.
 	if r.nsID != nil {
@@ -100,7 +120,7 @@ func (r *results) Reset(nsID ident.ID, opts QueryResultsOptions) {
 func (r *results) AddDocuments(batch []doc.Document) (int, error) {
 	r.Lock()
 	err := r.addDocumentsBatchWithLock(batch)
-	size := r.resultsMap.Len()
+	size := r.sizeWithLock()
 	r.Unlock()
 	return size, err
 }
@@ -111,6 +131,9 @@ func (r *results) addDocumentsBatchWithLock(batch []doc.Document) error {
 		if err != nil {
 			return err
 		}
+		if err := r.checkBytesLimitsWithLock(); err != nil {
+			return err
+		}
 		if r.opts.SizeLimit > 0 && size >= r.opts.SizeLimit {
 			// Early return if limit enforced and we hit our limit.
 			break
@@ -119,11 +142,21 @@ func (r *results) addDocumentsBatchWithLock(batch []doc.Document) error {
 	return nil
 }
 
+func (r *results) checkBytesLimitsWithLock() error {
+	if err := queryBudgetExceeded(r.opts.BytesBudget, r.retainedBytes); err != nil {
+		return err
+	}
+	reserved, err := reserveQueryLimitBytes(r.opts.QueryLimiter,
+		r.reservedBytes, r.retainedBytes)
+	r.reservedBytes = reserved
+	return err
+}
+
 func (r *results) addDocumentWithLock(
 	d doc.Document,
 ) (bool, int, error) {
 	if len(d.ID) == 0 {
-		return false, r.resultsMap.Len(), errUnableToAddResultMissingID
+		return false, r.sizeWithLock(), errUnableToAddResultMissingID
 	}
 
 	// NB: can cast the []byte -> ident.ID to avoid an alloc
@@ -132,17 +165,31 @@ func (r *results) addDocumentWithLock(
 
 	// Need to apply filter if set first.
 	if r.opts.FilterID != nil && !r.opts.FilterID(tsID) {
-		return false, r.resultsMap.Len(), nil
+		return false, r.sizeWithLock(), nil
+	}
+
+	// check if it already exists in the map or the spill.
+	if r.resultsMap.Contains(tsID) ||
+		(r.spill != nil && r.spill.Contains(d.ID)) {
+		return false, r.sizeWithLock(), nil
 	}
 
-	// check if it already exists in the map.
-	if r.resultsMap.Contains(tsID) {
-		return false, r.resultsMap.Len(), nil
+	// Spill the document rather than retain it if retaining it would
+	// exceed the bytes budget and spilling is enabled.
+	if r.shouldSpillWithLock(d) {
+		if err := r.spillDocumentWithLock(d); err != nil {
+			return false, r.sizeWithLock(), err
+		}
+		return true, r.sizeWithLock(), nil
 	}
 
 	// i.e. it doesn't exist in the map, so we create the tags wrapping
 	// fields prodided by the document.
-	tags := convert.ToMetricTags(d, convert.Opts{NoClone: true})
+	tagsDoc := d
+	if !r.opts.SeriesMetadata {
+		tagsDoc = convert.WithoutSeriesMetadata(d)
+	}
+	tags := convert.ToMetricTags(tagsDoc, convert.Opts{NoClone: true})
 
 	// It is assumed that the document is valid for the lifetime of the index
 	// results.
@@ -150,8 +197,33 @@ func (r *results) addDocumentWithLock(
 		NoCopyKey:     true,
 		NoFinalizeKey: true,
 	})
+	r.retainedBytes += documentBytes(d)
+
+	return true, r.sizeWithLock(), nil
+}
 
-	return true, r.resultsMap.Len(), nil
+func (r *results) shouldSpillWithLock(d doc.Document) bool {
+	return r.opts.Spill.Enabled && r.opts.BytesBudget > 0 &&
+		r.retainedBytes+documentBytes(d) > r.opts.BytesBudget
+}
+
+func (r *results) spillDocumentWithLock(d doc.Document) error {
+	if r.spill == nil {
+		spill, err := newQuerySpill(r.opts.Spill)
+		if err != nil {
+			return err
+		}
+		r.spill = spill
+	}
+	return r.spill.Add(d)
+}
+
+func (r *results) sizeWithLock() int {
+	size := r.resultsMap.Len()
+	if r.spill != nil {
+		size += r.spill.Len()
+	}
+	return size
 }
 
 func (r *results) Namespace() ident.ID {
@@ -168,9 +240,19 @@ func (r *results) Map() *ResultsMap {
 	return v
 }
 
+func (r *results) SpilledIter() (SpilledResultsIter, error) {
+	// Take the write lock since the spill file is mmap'd when first read.
+	r.Lock()
+	defer r.Unlock()
+	if r.spill == nil {
+		return emptySpilledResultsIter{}, nil
+	}
+	return r.spill.Iter(r.opts.SeriesMetadata)
+}
+
 func (r *results) Size() int {
 	r.RLock()
-	v := r.resultsMap.Len()
+	v := r.sizeWithLock()
 	r.RUnlock()
 	return v
 }
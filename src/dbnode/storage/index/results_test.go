@@ -24,6 +24,7 @@ import (
 	"bytes"
 	"testing"
 
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
@@ -214,3 +215,54 @@ func TestFinalize(t *testing.T) {
 		// they had that method.
 	}
 }
+
+func TestResultsBytesBudgetExceeded(t *testing.T) {
+	res := NewQueryResults(nil, QueryResultsOptions{BytesBudget: 10}, testOpts)
+	d1 := doc.Document{ID: []byte("abc"),
+		Fields: doc.Fields{
+			doc.Field{Name: []byte("foo"), Value: []byte("bar")},
+		}}
+	size, err := res.AddDocuments([]doc.Document{d1})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+
+	// Re-adding a document that already exists does not retain more bytes.
+	size, err = res.AddDocuments([]doc.Document{d1})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+
+	d2 := doc.Document{ID: []byte("def")}
+	_, err = res.AddDocuments([]doc.Document{d2})
+	require.Error(t, err)
+	require.True(t, IsQueryBudgetExceededError(err))
+
+	// Reset clears the bytes retained.
+	res.Reset(nil, QueryResultsOptions{BytesBudget: 10})
+	size, err = res.AddDocuments([]doc.Document{d2})
+	require.NoError(t, err)
+	require.Equal(t, 1, size)
+}
+
+func TestResultsSeriesMetadata(t *testing.T) {
+	d := doc.Document{ID: []byte("abc"),
+		Fields: doc.Fields{
+			doc.Field{Name: []byte("foo"), Value: []byte("bar")},
+			doc.Field{Name: convert.SeriesMetadataFieldUnit, Value: []byte("seconds")},
+		}}
+
+	res := NewQueryResults(nil, QueryResultsOptions{}, testOpts)
+	_, err := res.AddDocuments([]doc.Document{d})
+	require.NoError(t, err)
+	tags, ok := res.Map().Get(ident.StringID("abc"))
+	require.True(t, ok)
+	require.Equal(t, 1, tags.Remaining())
+
+	res = NewQueryResults(nil, QueryResultsOptions{SeriesMetadata: true}, testOpts)
+	_, err = res.AddDocuments([]doc.Document{d})
+	require.NoError(t, err)
+	tags, ok = res.Map().Get(ident.StringID("abc"))
+	require.True(t, ok)
+	metadata, err := convert.SeriesMetadataFromTags(tags)
+	require.NoError(t, err)
+	require.Equal(t, convert.SeriesMetadata{Unit: "seconds"}, metadata)
+}
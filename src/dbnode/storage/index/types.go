@@ -23,9 +23,11 @@ package index
 import (
 	"fmt"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/index/compaction"
 	"github.com/m3db/m3/src/m3ninx/doc"
@@ -80,6 +82,9 @@ type QueryOptions struct {
 	StartInclusive time.Time
 	EndExclusive   time.Time
 	Limit          int
+	// SeriesMetadata includes the series metadata tags in the tags of the
+	// results, they are omitted otherwise.
+	SeriesMetadata bool
 }
 
 // LimitExceeded returns whether a given size exceeds the limit
@@ -143,7 +148,26 @@ type QueryResults interface {
 	// method, it is unsafe to read or write to the map if any other caller
 	// mutates the state of the results after obtaining a reference to the map
 	// with this call.
+	// NB: The map only holds the results retained in memory, results
+	// spilled to disk are returned by SpilledIter.
 	Map() *ResultsMap
+
+	// SpilledIter returns an iterator streaming the results spilled to disk
+	// once the results exceeded their in-memory bytes budget, the results
+	// returned are valid until the results are reset.
+	SpilledIter() (SpilledResultsIter, error)
+}
+
+// SpilledResultsIter iterates over the results of a query spilled to disk.
+type SpilledResultsIter interface {
+	// Next returns whether there is a next result.
+	Next() bool
+
+	// Current returns the current result.
+	Current() ResultsMapEntry
+
+	// Err returns any error encountered reading the spilled results.
+	Err() error
 }
 
 // QueryResultsOptions is a set of options to use for query results.
@@ -157,6 +181,84 @@ type QueryResultsOptions struct {
 	// NB(r): This is used to filter out results from shards the DB node
 	// node no longer owns but is still included in index segments.
 	FilterID func(id ident.ID) bool
+
+	// BytesBudget, if positive, is the maximum number of bytes the results
+	// may retain, adding documents that exceed it returns a
+	// QueryBudgetExceededError.
+	BytesBudget int64
+
+	// SeriesMetadata includes the series metadata tags in the tags of the
+	// results, they are omitted otherwise.
+	SeriesMetadata bool
+
+	// QueryLimiter, if provided, has the bytes retained by the results
+	// reserved with it until the results are reset, adding documents that
+	// exceed its in-flight bytes limit returns a QueryLimitExceededError.
+	QueryLimiter QueryLimiter
+
+	// Spill, if enabled, spills documents that would exceed the bytes
+	// budget to a temporary file rather than failing the query.
+	Spill QuerySpillOptions
+}
+
+// QuerySpillOptions configures spilling the results of index queries that
+// exceed their in-memory bytes budget to temporary local files.
+type QuerySpillOptions struct {
+	// Enabled enables spilling results to disk, queries exceeding their
+	// bytes budget fail with a QueryBudgetExceededError otherwise.
+	Enabled bool
+
+	// Directory is the directory spill files are created in, the default
+	// directory for temporary files is used if empty.
+	Directory string
+
+	// MaxQueryBytes, if positive, is the maximum number of bytes a single
+	// query may spill before failing with a QuerySpillLimitExceededError.
+	MaxQueryBytes int64
+
+	// Limiter, if provided, limits the bytes spilled by all queries.
+	Limiter QuerySpillLimiter
+}
+
+// QuerySpillLimiter limits the bytes spilled to disk by all index queries
+// until their results are finalized.
+type QuerySpillLimiter interface {
+	// Reserve reserves bytes spilled by query results, returning a
+	// QuerySpillLimitExceededError and reserving nothing if the limit would
+	// be exceeded.
+	Reserve(bytes int64) error
+
+	// Release releases bytes previously reserved by query results.
+	Release(bytes int64)
+
+	// SpilledBytes returns the number of bytes currently reserved.
+	SpilledBytes() int64
+}
+
+// QueryLimiter limits the concurrent index queries against a namespace and
+// the bytes retained by their results until the results are finalized.
+type QueryLimiter interface {
+	// StartQuery admits a query, returning a QueryLimitExceededError if the
+	// concurrent queries limit is reached. DoneQuery must be called once an
+	// admitted query completes.
+	StartQuery() error
+
+	// DoneQuery marks an admitted query as complete.
+	DoneQuery()
+
+	// Reserve reserves bytes retained by query results, returning a
+	// QueryLimitExceededError and reserving nothing if the in-flight result
+	// bytes limit would be exceeded.
+	Reserve(bytes int64) error
+
+	// Release releases bytes previously reserved by query results.
+	Release(bytes int64)
+
+	// ConcurrentQueries returns the number of queries currently admitted.
+	ConcurrentQueries() int64
+
+	// InFlightResultBytes returns the number of bytes currently reserved.
+	InFlightResultBytes() int64
 }
 
 // QueryResultsAllocator allocates QueryResults types.
@@ -194,7 +296,7 @@ type AggregateResults interface {
 	// i.e. it is not safe to use/modify the idents once this function returns.
 	AddFields(
 		batch []AggregateResultsEntry,
-	) (size int)
+	) (size int, err error)
 
 	// Map returns a map from tag name -> possible tag values,
 	// comprising aggregate results.
@@ -221,6 +323,17 @@ type AggregateResultsOptions struct {
 
 	// FieldFilter is an optional param to filter aggregate values.
 	FieldFilter AggregateFieldFilter
+
+	// BytesBudget, if positive, is the maximum number of bytes the results
+	// may retain, adding documents or fields that exceed it returns a
+	// QueryBudgetExceededError.
+	BytesBudget int64
+
+	// QueryLimiter, if provided, has the bytes retained by the results
+	// reserved with it until the results are reset, adding documents or
+	// fields that exceed its in-flight bytes limit returns a
+	// QueryLimitExceededError.
+	QueryLimiter QueryLimiter
 }
 
 // AggregateResultsAllocator allocates AggregateResults types.
@@ -328,9 +441,21 @@ type Block interface {
 	// AddResults adds bootstrap results to the block.
 	AddResults(results result.IndexBlock) error
 
+	// AddImported indexes documents of series imported directly into the
+	// filesets of the block's time range. Unlike writes this is permitted
+	// once the block is sealed, the documents are held in memory until the
+	// block is next flushed.
+	AddImported(docs []doc.Document) error
+
 	// Tick does internal house keeping operations.
 	Tick(c context.Cancellable) (BlockTickResult, error)
 
+	// PurgeDocuments compacts the in-memory background segments of an open
+	// block to drop the documents not contained by the filter. Documents
+	// are counted synchronously and the compaction, if any documents are
+	// to be purged, runs in the background.
+	PurgeDocuments(filter segment.DocumentsFilter) (BlockPurgeResult, error)
+
 	// Stats returns block stats.
 	Stats(reporter BlockStatsReporter) error
 
@@ -417,6 +542,12 @@ type BlockTickResult struct {
 	NumDocs     int64
 }
 
+// BlockPurgeResult returns statistics about the PurgeDocuments execution.
+type BlockPurgeResult struct {
+	NumDocs       int64
+	NumDocsPurged int64
+}
+
 // WriteBatch is a batch type that allows for building of a slice of documents
 // with metadata in a separate slice, this allows the documents slice to be
 // passed to the segment to batch insert without having to copy into a buffer
@@ -427,6 +558,9 @@ type WriteBatch struct {
 
 	entries []WriteBatchEntry
 	docs    []doc.Document
+
+	refs       int32
+	finalizeFn func(b *WriteBatch)
 }
 
 type writeBatchSortBy uint
@@ -451,6 +585,25 @@ func NewWriteBatch(opts WriteBatchOptions) *WriteBatch {
 	}
 }
 
+// IncRef increments the references held to the batch, each holder of a
+// reference must call DecRef once it no longer uses the batch.
+func (b *WriteBatch) IncRef() {
+	atomic.AddInt32(&b.refs, 1)
+}
+
+// DecRef decrements the references held to the batch, when the last reference
+// is released the batch is returned to the pool it was allocated from, if any.
+func (b *WriteBatch) DecRef() {
+	refs := atomic.AddInt32(&b.refs, -1)
+	if refs > 0 || b.finalizeFn == nil {
+		return
+	}
+	if refs < 0 {
+		panic(fmt.Errorf("write batch negative ref count: %d", refs))
+	}
+	b.finalizeFn(b)
+}
+
 // Append appends an entry with accompanying document.
 func (b *WriteBatch) Append(
 	entry WriteBatchEntry,
@@ -899,4 +1052,38 @@ type Options interface {
 
 	// MmapReporter returns the mmap reporter.
 	MmapReporter() mmap.Reporter
+
+	// SetDocumentsPurgeInterval sets the minimum interval between purges of
+	// the documents of series with no live data from the in-memory segments
+	// of open index blocks, a zero interval disables purging.
+	SetDocumentsPurgeInterval(value time.Duration) Options
+
+	// DocumentsPurgeInterval returns the minimum interval between purges of
+	// the documents of series with no live data.
+	DocumentsPurgeInterval() time.Duration
+
+	// SetQueryBytesBudget sets the maximum number of bytes the results of a
+	// single index query may retain before the query is aborted with a
+	// QueryBudgetExceededError, zero disables the budget.
+	SetQueryBytesBudget(value int64) Options
+
+	// QueryBytesBudget returns the maximum number of bytes the results of a
+	// single index query may retain before the query is aborted.
+	QueryBytesBudget() int64
+
+	// SetQuerySpillOptions sets the options for spilling the results of
+	// index queries that exceed the query bytes budget to disk.
+	SetQuerySpillOptions(value QuerySpillOptions) Options
+
+	// QuerySpillOptions returns the options for spilling the results of
+	// index queries that exceed the query bytes budget to disk.
+	QuerySpillOptions() QuerySpillOptions
+
+	// SetBackgroundScheduler sets the scheduler that admits background
+	// compactions.
+	SetBackgroundScheduler(value background.Scheduler) Options
+
+	// BackgroundScheduler returns the scheduler that admits background
+	// compactions.
+	BackgroundScheduler() background.Scheduler
 }
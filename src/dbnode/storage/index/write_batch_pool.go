@@ -0,0 +1,82 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"github.com/m3db/m3/src/x/pool"
+)
+
+const (
+	// defaultMaxWriteBatchPoolCapacity is the maximum capacity of a write
+	// batch that the pool will allow to remain in the pool, batches larger
+	// than this are discarded to avoid retaining excessive memory after an
+	// exceptionally large batch of inserts.
+	defaultMaxWriteBatchPoolCapacity = 65536
+)
+
+// WriteBatchPool is a pool of WriteBatch, it allows the entries and documents
+// slices of write batches to be reused across batches of inserts.
+type WriteBatchPool struct {
+	pool        pool.ObjectPool
+	opts        WriteBatchOptions
+	maxCapacity int
+}
+
+// NewWriteBatchPool returns a new WriteBatchPool that allocates write
+// batches with the specified options.
+func NewWriteBatchPool(
+	batchOpts WriteBatchOptions,
+	poolOpts pool.ObjectPoolOptions,
+) *WriteBatchPool {
+	return &WriteBatchPool{
+		pool:        pool.NewObjectPool(poolOpts),
+		opts:        batchOpts,
+		maxCapacity: defaultMaxWriteBatchPoolCapacity,
+	}
+}
+
+// Init initializes the pool.
+func (p *WriteBatchPool) Init() {
+	p.pool.Init(func() interface{} {
+		b := NewWriteBatch(p.opts)
+		b.finalizeFn = p.Put
+		return b
+	})
+}
+
+// Get returns a write batch from the pool with a single reference held
+// by the caller.
+func (p *WriteBatchPool) Get() *WriteBatch {
+	b := p.pool.Get().(*WriteBatch)
+	b.IncRef()
+	return b
+}
+
+// Put returns a write batch to the pool.
+func (p *WriteBatchPool) Put(b *WriteBatch) {
+	if cap(b.entries) > p.maxCapacity || cap(b.docs) > p.maxCapacity {
+		// Grown too large to remain in the pool.
+		return
+	}
+	b.Reset()
+	b.sortBy = writeBatchSortByUnmarkedAndBlockStart
+	p.pool.Put(b)
+}
@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package index
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/pool"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newTestWriteBatchPool() *WriteBatchPool {
+	p := NewWriteBatchPool(WriteBatchOptions{
+		IndexBlockSize: time.Hour,
+	}, pool.NewObjectPoolOptions().SetSize(1))
+	p.Init()
+	return p
+}
+
+func TestWriteBatchPoolReusesBatchOnceReleased(t *testing.T) {
+	p := newTestWriteBatchPool()
+
+	batch := p.Get()
+	require.Equal(t, time.Hour, batch.Options().IndexBlockSize)
+	batch.Append(WriteBatchEntry{Timestamp: time.Now()},
+		doc.Document{ID: []byte("foo")})
+
+	// Simulate the index taking a reference while it holds the batch.
+	batch.IncRef()
+	batch.DecRef()
+	require.Equal(t, 1, batch.Len())
+
+	batch.DecRef()
+	require.Equal(t, 0, batch.Len())
+
+	reused := p.Get()
+	require.True(t, batch == reused)
+	require.Equal(t, 0, reused.Len())
+	reused.DecRef()
+}
+
+func TestWriteBatchPoolDiscardsLargeBatches(t *testing.T) {
+	p := newTestWriteBatchPool()
+	p.maxCapacity = 1
+
+	batch := p.Get()
+	for i := 0; i < 2; i++ {
+		batch.Append(WriteBatchEntry{Timestamp: time.Now()}, doc.Document{})
+	}
+	batch.DecRef()
+
+	other := p.Get()
+	require.False(t, batch == other)
+	other.DecRef()
+}
+
+func TestWriteBatchUnpooledDecRefNoop(t *testing.T) {
+	batch := NewWriteBatch(WriteBatchOptions{})
+	batch.Append(WriteBatchEntry{Timestamp: time.Now()}, doc.Document{})
+	batch.IncRef()
+	batch.DecRef()
+	require.Equal(t, 1, batch.Len())
+}
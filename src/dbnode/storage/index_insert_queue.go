@@ -174,6 +174,9 @@ func (q *nsIndexInsertQueue) InsertBatch(
 		return nil, errIndexInsertQueueNotOpen
 	}
 	batchLen := batch.Len()
+	// Hold a reference to the batch until it has been indexed, the caller
+	// retains its own reference and may return it to a pool once released.
+	batch.IncRef()
 	q.currBatch.shardInserts = append(q.currBatch.shardInserts, batch)
 	wg := q.currBatch.wg
 	q.Unlock()
@@ -270,7 +273,8 @@ func (b *nsIndexInsertBatch) Reset() {
 	// We always expect to be waiting for an index
 	b.wg.Add(1)
 	for i := range b.shardInserts {
-		// TODO(prateek): if we start pooling `[]index.WriteBatchEntry`, then we could return to the pool here.
+		// Release the reference taken when the batch was enqueued.
+		b.shardInserts[i].DecRef()
 		b.shardInserts[i] = nil
 	}
 	b.shardInserts = b.shardInserts[:0]
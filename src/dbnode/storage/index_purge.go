@@ -0,0 +1,162 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/m3ninx/index/segment"
+	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+)
+
+func (i *nsIndex) PurgeDocuments(
+	c context.Cancellable,
+	startTime time.Time,
+	shards []databaseShard,
+) (namespaceIndexPurgeResult, error) {
+	var result namespaceIndexPurgeResult
+	interval := i.opts.IndexOptions().DocumentsPurgeInterval()
+	if interval <= 0 {
+		return result, nil
+	}
+
+	i.state.Lock()
+	if i.state.closed || i.state.shardSet == nil ||
+		startTime.Sub(i.state.lastDocumentsPurge) < interval {
+		i.state.Unlock()
+		return result, nil
+	}
+	i.state.lastDocumentsPurge = startTime
+	shardSet := i.state.shardSet
+	blocks := make([]index.Block, 0, len(i.state.blocksByTime))
+	for _, block := range i.state.blocksByTime {
+		blocks = append(blocks, block)
+	}
+	i.state.Unlock()
+
+	shardsByID := make(map[uint32]databaseShard, len(shards))
+	for _, shard := range shards {
+		shardsByID[shard.ID()] = shard
+	}
+
+	dataBlockSize := i.nsMetadata.Options().RetentionOptions().BlockSize()
+	var multiErr xerrors.MultiError
+	for _, block := range blocks {
+		if c.IsCancelled() {
+			multiErr = multiErr.Add(errDbIndexTerminatingTickCancellation)
+			break
+		}
+
+		filter := newLiveSeriesDocumentsFilter(shardSet, shardsByID,
+			block.StartTime(), block.EndTime(), dataBlockSize)
+		blockResult, err := block.PurgeDocuments(filter)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if blockResult.NumDocsPurged > 0 {
+			result.NumBlocks++
+		}
+		result.NumDocs += blockResult.NumDocs
+		result.NumDocsPurged += blockResult.NumDocsPurged
+	}
+
+	return result, multiErr.FinalError()
+}
+
+// liveSeriesDocumentsFilter is a documents filter that retains the documents
+// of series that may have data within the time range of an index block.
+// A series may have data if it is still active in its owning shard or if
+// any of the data blocks within the range have been flushed for the shard,
+// since the series may then have data on disk.
+type liveSeriesDocumentsFilter struct {
+	shardSet   sharding.ShardSet
+	shardsByID map[uint32]databaseShard
+	flushed    map[uint32]bool
+	blockStart time.Time
+	blockEnd   time.Time
+	blockSize  time.Duration
+}
+
+var _ segment.DocumentsFilter = (*liveSeriesDocumentsFilter)(nil)
+
+func newLiveSeriesDocumentsFilter(
+	shardSet sharding.ShardSet,
+	shardsByID map[uint32]databaseShard,
+	blockStart, blockEnd time.Time,
+	dataBlockSize time.Duration,
+) *liveSeriesDocumentsFilter {
+	return &liveSeriesDocumentsFilter{
+		shardSet:   shardSet,
+		shardsByID: shardsByID,
+		flushed:    make(map[uint32]bool, len(shardsByID)),
+		blockStart: blockStart,
+		blockEnd:   blockEnd,
+		blockSize:  dataBlockSize,
+	}
+}
+
+func (f *liveSeriesDocumentsFilter) Contains(d doc.Document) bool {
+	id := ident.BytesID(d.ID)
+	shard, ok := f.shardsByID[f.shardSet.Lookup(id)]
+	if !ok {
+		// Not an owned shard, leave it to the index to filter on query.
+		return true
+	}
+
+	if f.anyDataFlushed(shard) {
+		return true
+	}
+
+	_, exists, err := shard.TagsFromSeriesID(id)
+	if err != nil && err != errShardEntryNotFound {
+		// Conservatively retain the document if the shard cannot be
+		// checked, i.e. if it is no longer open.
+		return true
+	}
+	return exists
+}
+
+func (f *liveSeriesDocumentsFilter) anyDataFlushed(shard databaseShard) bool {
+	shardID := shard.ID()
+	if flushed, ok := f.flushed[shardID]; ok {
+		return flushed
+	}
+
+	flushed := false
+	start := f.blockStart.Truncate(f.blockSize)
+	for t := start; t.Before(f.blockEnd); t = t.Add(f.blockSize) {
+		state, err := shard.FlushState(t)
+		if err != nil || state.WarmStatus == fileOpSuccess ||
+			state.ColdVersionRetrievable > 0 {
+			// Conservatively assume data exists if flush state unknown.
+			flushed = true
+			break
+		}
+	}
+
+	f.flushed[shardID] = flushed
+	return flushed
+}
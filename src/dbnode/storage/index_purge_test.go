@@ -0,0 +1,99 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLiveSeriesDocumentsFilter(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shardSet, err := sharding.NewShardSet(sharding.NewShards([]uint32{0},
+		shard.Available), func(ident.ID) uint32 { return 0 })
+	require.NoError(t, err)
+
+	var (
+		blockSize  = time.Hour
+		blockStart = time.Now().Truncate(2 * blockSize)
+		blockEnd   = blockStart.Add(2 * blockSize)
+	)
+
+	dbShard := NewMockdatabaseShard(ctrl)
+	dbShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	// Flush states are only checked once per shard.
+	dbShard.EXPECT().FlushState(blockStart).Return(fileOpState{}, nil)
+	dbShard.EXPECT().FlushState(blockStart.Add(blockSize)).Return(fileOpState{}, nil)
+	dbShard.EXPECT().TagsFromSeriesID(ident.NewIDMatcher("live")).
+		Return(ident.Tags{}, true, nil)
+	dbShard.EXPECT().TagsFromSeriesID(ident.NewIDMatcher("expired")).
+		Return(ident.Tags{}, false, errShardEntryNotFound)
+	dbShard.EXPECT().TagsFromSeriesID(ident.NewIDMatcher("unknown")).
+		Return(ident.Tags{}, false, errors.New("shard closed"))
+
+	filter := newLiveSeriesDocumentsFilter(shardSet,
+		map[uint32]databaseShard{0: dbShard}, blockStart, blockEnd, blockSize)
+	require.True(t, filter.Contains(doc.Document{ID: []byte("live")}))
+	require.False(t, filter.Contains(doc.Document{ID: []byte("expired")}))
+	require.True(t, filter.Contains(doc.Document{ID: []byte("unknown")}))
+}
+
+func TestLiveSeriesDocumentsFilterDataFlushed(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	shardSet, err := sharding.NewShardSet(sharding.NewShards([]uint32{0, 1},
+		shard.Available), func(id ident.ID) uint32 {
+		if id.String() == "unowned" {
+			return 1
+		}
+		return 0
+	})
+	require.NoError(t, err)
+
+	var (
+		blockSize  = time.Hour
+		blockStart = time.Now().Truncate(2 * blockSize)
+		blockEnd   = blockStart.Add(2 * blockSize)
+	)
+
+	dbShard := NewMockdatabaseShard(ctrl)
+	dbShard.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	dbShard.EXPECT().FlushState(blockStart).Return(fileOpState{
+		WarmStatus: fileOpSuccess,
+	}, nil)
+
+	filter := newLiveSeriesDocumentsFilter(shardSet,
+		map[uint32]databaseShard{0: dbShard}, blockStart, blockEnd, blockSize)
+	require.True(t, filter.Contains(doc.Document{ID: []byte("foo")}))
+	require.True(t, filter.Contains(doc.Document{ID: []byte("bar")}))
+	require.True(t, filter.Contains(doc.Document{ID: []byte("unowned")}))
+}
@@ -319,6 +319,52 @@ func TestNamespaceIndexQueryNoMatchingBlocks(t *testing.T) {
 	assert.Equal(t, 0, aggResult.Results.Size())
 }
 
+func TestNamespaceIndexQueryLimitConcurrentQueries(t *testing.T) {
+	ctrl := gomock.NewController(xtest.Reporter{T: t})
+	defer ctrl.Finish()
+
+	test := newTestIndex(t, ctrl)
+
+	now := time.Now().Truncate(test.indexBlockSize)
+	query := index.Query{Query: idx.NewTermQuery([]byte("foo"), []byte("bar"))}
+	idx := test.index.(*nsIndex)
+
+	defer func() {
+		require.NoError(t, idx.Close())
+	}()
+
+	idx.queryLimiter = index.NewQueryLimiter(namespace.QueryLimitsOptions{
+		MaxConcurrentQueries: 1,
+	})
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	queryOpts := index.QueryOptions{
+		StartInclusive: now.Add(-3 * test.indexBlockSize),
+		EndExclusive:   now.Add(-2 * test.indexBlockSize),
+	}
+
+	// Occupy the only query slot of the namespace.
+	require.NoError(t, idx.queryLimiter.StartQuery())
+
+	_, err := idx.Query(ctx, query, queryOpts)
+	require.Error(t, err)
+	require.True(t, index.IsQueryLimitExceededError(err))
+
+	_, err = idx.AggregateQuery(ctx, query, index.AggregationOptions{
+		QueryOptions: queryOpts,
+	})
+	require.Error(t, err)
+	require.True(t, index.IsQueryLimitExceededError(err))
+
+	idx.queryLimiter.DoneQuery()
+
+	_, err = idx.Query(ctx, query, queryOpts)
+	require.NoError(t, err)
+	require.Equal(t, int64(0), idx.queryLimiter.ConcurrentQueries())
+}
+
 type testIndex struct {
 	index          namespaceIndex
 	metadata       namespace.Metadata
@@ -97,7 +97,7 @@ func newMediator(database database, commitlog commitlog.CommitLog, opts Options)
 		database:            database,
 		opts:                opts,
 		nowFn:               opts.ClockOptions().NowFn(),
-		sleepFn:             time.Sleep,
+		sleepFn:             opts.ClockOptions().Scheduler().Sleep,
 		metrics:             newMediatorMetrics(scope),
 		state:               mediatorNotOpen,
 		mediatorTimeBarrier: newMediatorTimeBarrier(nowFn, iOpts),
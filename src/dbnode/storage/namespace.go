@@ -26,6 +26,7 @@ import (
 	"math"
 	"runtime"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
@@ -56,8 +57,11 @@ import (
 )
 
 var (
-	errNamespaceAlreadyClosed    = errors.New("namespace already closed")
-	errNamespaceIndexingDisabled = errors.New("namespace indexing is disabled")
+	errNamespaceAlreadyClosed       = errors.New("namespace already closed")
+	errNamespaceIndexingDisabled    = errors.New("namespace indexing is disabled")
+	errNamespaceRelabelEncodeFailed = errors.New("namespace relabeled tags encoding failed")
+	errNamespaceSeriesNotInShard    = errors.New("namespace series belongs to another shard")
+	errShardDegraded                = errors.New("shard is degraded after exceeding its read error budget")
 )
 
 type commitLogWriter interface {
@@ -105,12 +109,17 @@ type dbNamespace struct {
 	shutdownCh         chan struct{}
 	id                 ident.ID
 	shardSet           sharding.ShardSet
+	shardKeyFn         sharding.ShardKeyFn
 	blockRetriever     block.DatabaseBlockRetriever
 	namespaceReaderMgr databaseNamespaceReaderManager
 	opts               Options
 	metadata           namespace.Metadata
 	nopts              namespace.Options
+	relabelOpts        namespace.RelabelOptions
+	validationOpts     namespace.ValidationOptions
+	writeInterceptors  []WriteInterceptor
 	seriesOpts         series.Options
+	bufferWindow       *series.BufferWindow
 	nowFn              clock.NowFn
 	snapshotFilesFn    snapshotFilesFn
 	log                *zap.Logger
@@ -127,12 +136,20 @@ type dbNamespace struct {
 
 	increasingIndex increasingIndex
 	commitLogWriter commitLogWriter
-	reverseIndex    namespaceIndex
+	reverseIndex    *namespaceIndexRef
 
 	tickWorkers            xsync.WorkerPool
 	tickWorkersConcurrency int
 	statsLastTick          databaseNamespaceStatsLastTick
 
+	// backfillPendingColdFlush is set when backfill writes have been accepted
+	// since the last cold flush.
+	backfillPendingColdFlush int32
+
+	writeLateness     *writeLatenessTracker
+	blockSizeAnalyzer *blockSizeAnalyzer
+	freezes           *namespaceFreezes
+
 	metrics databaseNamespaceMetrics
 }
 
@@ -157,6 +174,8 @@ type databaseNamespaceMetrics struct {
 	snapshot            instrument.MethodMetrics
 	write               instrument.MethodMetrics
 	writeTagged         instrument.MethodMetrics
+	writeTaggedBackfill instrument.MethodMetrics
+	importData          instrument.MethodMetrics
 	read                instrument.MethodMetrics
 	fetchBlocks         instrument.MethodMetrics
 	fetchBlocksMetadata instrument.MethodMetrics
@@ -165,6 +184,9 @@ type databaseNamespaceMetrics struct {
 	unfulfilled         tally.Counter
 	bootstrapStart      tally.Counter
 	bootstrapEnd        tally.Counter
+	validationRejected  map[namespace.ValidationRule]tally.Counter
+	interceptorDropped  tally.Counter
+	interceptorRejected tally.Counter
 	shards              databaseNamespaceShardMetrics
 	tick                databaseNamespaceTickMetrics
 	status              databaseNamespaceStatusMetrics
@@ -197,6 +219,7 @@ type databaseNamespaceIndexTickMetrics struct {
 	numSegments      tally.Gauge
 	numBlocksSealed  tally.Counter
 	numBlocksEvicted tally.Counter
+	numDocsPurged    tally.Counter
 }
 
 // databaseNamespaceStatusMetrics are metrics emitted at a fixed interval
@@ -228,6 +251,12 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 	indexTickScope := tickScope.SubScope("index")
 	statusScope := scope.SubScope("status")
 	indexStatusScope := statusScope.SubScope("index")
+	validationRejected := make(map[namespace.ValidationRule]tally.Counter)
+	for _, rule := range namespace.ValidValidationRules() {
+		validationRejected[rule] = scope.Tagged(map[string]string{
+			"rule": rule.String(),
+		}).Counter("write.validation-rejected")
+	}
 	return databaseNamespaceMetrics{
 		bootstrap:           instrument.NewMethodMetrics(scope, "bootstrap", samplingRate),
 		flushWarmData:       instrument.NewMethodMetrics(scope, "flushWarmData", samplingRate),
@@ -236,6 +265,8 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		snapshot:            instrument.NewMethodMetrics(scope, "snapshot", samplingRate),
 		write:               instrument.NewMethodMetrics(scope, "write", overrideWriteSamplingRate),
 		writeTagged:         instrument.NewMethodMetrics(scope, "write-tagged", overrideWriteSamplingRate),
+		writeTaggedBackfill: instrument.NewMethodMetrics(scope, "write-tagged-backfill", overrideWriteSamplingRate),
+		importData:          instrument.NewMethodMetrics(scope, "import", samplingRate),
 		read:                instrument.NewMethodMetrics(scope, "read", samplingRate),
 		fetchBlocks:         instrument.NewMethodMetrics(scope, "fetchBlocks", samplingRate),
 		fetchBlocksMetadata: instrument.NewMethodMetrics(scope, "fetchBlocksMetadata", samplingRate),
@@ -244,6 +275,9 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 		unfulfilled:         scope.Counter("bootstrap.unfulfilled"),
 		bootstrapStart:      scope.Counter("bootstrap.start"),
 		bootstrapEnd:        scope.Counter("bootstrap.end"),
+		validationRejected:  validationRejected,
+		interceptorDropped:  scope.Counter("write.interceptor-dropped"),
+		interceptorRejected: scope.Counter("write.interceptor-rejected"),
 		shards: databaseNamespaceShardMetrics{
 			add:         shardsScope.Counter("add"),
 			close:       shardsScope.Counter("close"),
@@ -266,6 +300,7 @@ func newDatabaseNamespaceMetrics(scope tally.Scope, samplingRate float64) databa
 				numSegments:      indexTickScope.Gauge("num-segments"),
 				numBlocksSealed:  indexTickScope.Counter("num-blocks-sealed"),
 				numBlocksEvicted: indexTickScope.Counter("num-blocks-evicted"),
+				numDocsPurged:    indexTickScope.Counter("num-docs-purged"),
 			},
 			evictedBuckets: tickScope.Counter("evicted-buckets"),
 		},
@@ -311,15 +346,36 @@ func newDatabaseNamespace(
 	tickWorkers := xsync.NewWorkerPool(tickWorkersConcurrency)
 	tickWorkers.Init()
 
-	seriesOpts := NewSeriesOptionsFromOptions(opts, nopts.RetentionOptions()).
+	ropts := nopts.RetentionOptions()
+	bufferWindow := series.NewBufferWindow(ropts.BufferPast(), ropts.BufferFuture())
+	seriesOpts := NewSeriesOptionsFromOptions(opts, ropts).
 		SetStats(series.NewStats(scope)).
-		SetColdWritesEnabled(nopts.ColdWritesEnabled())
+		SetColdWritesEnabled(nopts.ColdWritesEnabled()).
+		SetArchivalOptions(nopts.ArchivalOptions()).
+		SetFutureWriteOptions(nopts.FutureWriteOptions()).
+		SetInMemory(nopts.InMemory()).
+		SetBufferWindow(bufferWindow)
 	if err := seriesOpts.Validate(); err != nil {
 		return nil, fmt.Errorf(
 			"unable to create namespace %v, invalid series options: %v",
 			metadata.ID().String(), err)
 	}
 
+	// Series are assigned to shards by hashing the key returned by the shard
+	// key strategy of the namespace, the default hashes the whole series ID.
+	var shardKeyFn sharding.ShardKeyFn
+	if strategy := nopts.ShardKeyStrategy(); strategy != "" &&
+		strategy != sharding.IDShardKeyStrategy {
+		fn, err := sharding.LookupShardKeyStrategy(strategy)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"unable to create namespace %v, invalid shard key strategy: %v",
+				metadata.ID().String(), err)
+		}
+		shardKeyFn = fn
+		shardSet = sharding.NewShardKeyShardSet(shardSet, shardKeyFn)
+	}
+
 	var (
 		index namespaceIndex
 		err   error
@@ -335,20 +391,28 @@ func newDatabaseNamespace(
 		id:                     id,
 		shutdownCh:             make(chan struct{}),
 		shardSet:               shardSet,
+		shardKeyFn:             shardKeyFn,
 		blockRetriever:         blockRetriever,
 		namespaceReaderMgr:     newNamespaceReaderManager(metadata, scope, opts),
 		opts:                   opts,
 		metadata:               metadata,
 		nopts:                  nopts,
+		relabelOpts:            nopts.RelabelOptions(),
+		validationOpts:         nopts.ValidationOptions(),
+		writeInterceptors:      opts.WriteInterceptors(),
 		seriesOpts:             seriesOpts,
+		bufferWindow:           bufferWindow,
 		nowFn:                  opts.ClockOptions().NowFn(),
 		snapshotFilesFn:        fs.SnapshotFiles,
 		log:                    logger,
 		increasingIndex:        increasingIndex,
 		commitLogWriter:        commitLogWriter,
-		reverseIndex:           index,
+		reverseIndex:           newNamespaceIndexRef(index),
 		tickWorkers:            tickWorkers,
 		tickWorkersConcurrency: tickWorkersConcurrency,
+		writeLateness:          newWriteLatenessTracker(scope),
+		blockSizeAnalyzer:      newBlockSizeAnalyzer(opts.ClockOptions().NowFn()()),
+		freezes:                newNamespaceFreezes(),
 		metrics:                newDatabaseNamespaceMetrics(scope, iops.MetricsSamplingRate()),
 	}
 
@@ -408,7 +472,36 @@ func (n *dbNamespace) reportStatusLoop(reportInterval time.Duration) {
 }
 
 func (n *dbNamespace) Options() namespace.Options {
-	return n.nopts
+	// NB: options are updated in UpdateBufferPastAndFuture so requires an RLock.
+	n.RLock()
+	result := n.nopts
+	n.RUnlock()
+	return result
+}
+
+func (n *dbNamespace) UpdateBufferPastAndFuture(bufferPast, bufferFuture time.Duration) error {
+	n.Lock()
+	defer n.Unlock()
+
+	ropts := n.nopts.RetentionOptions().
+		SetBufferPast(bufferPast).
+		SetBufferFuture(bufferFuture)
+
+	// NB: The metadata options may hold a newer schema history than the
+	// namespace options so update both independently.
+	metadata, err := namespace.NewMetadata(n.id,
+		n.metadata.Options().SetRetentionOptions(ropts))
+	if err != nil {
+		return fmt.Errorf("unable to update buffer past and future: %v", err)
+	}
+
+	n.nopts = n.nopts.SetRetentionOptions(ropts)
+	n.metadata = metadata
+	n.bufferWindow.Update(bufferPast, bufferFuture)
+	n.log.Info("updated namespace buffer past and future",
+		zap.Duration("bufferPast", bufferPast),
+		zap.Duration("bufferFuture", bufferFuture))
+	return nil
 }
 
 func (n *dbNamespace) ID() ident.ID {
@@ -438,6 +531,48 @@ func (n *dbNamespace) NumSeries() int64 {
 	return count
 }
 
+func (n *dbNamespace) WriteLateness() WriteLateness {
+	return n.writeLateness.Snapshot()
+}
+
+func (n *dbNamespace) BlockSizeAnalysis() BlockSizeAnalysis {
+	return n.blockSizeAnalyzer.Analysis()
+}
+
+func (n *dbNamespace) Freeze(start, end time.Time) error {
+	if err := n.freezes.Freeze(xtime.Range{Start: start, End: end}); err != nil {
+		return err
+	}
+	n.log.Info("froze namespace data",
+		zap.Time("start", start), zap.Time("end", end))
+	return nil
+}
+
+func (n *dbNamespace) Unfreeze(start, end time.Time) error {
+	if err := n.freezes.Unfreeze(xtime.Range{Start: start, End: end}); err != nil {
+		return err
+	}
+	n.log.Info("unfroze namespace data",
+		zap.Time("start", start), zap.Time("end", end))
+	return nil
+}
+
+func (n *dbNamespace) FrozenRanges() []xtime.Range {
+	return n.freezes.Ranges()
+}
+
+func (n *dbNamespace) IsFrozen(start, end time.Time) bool {
+	return n.freezes.IsFrozen(xtime.Range{Start: start, End: end})
+}
+
+func (n *dbNamespace) SeriesLastWrite(id ident.ID) (time.Time, bool, error) {
+	shard, _, err := n.shardFor(id)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+	return shard.SeriesLastWrite(id)
+}
+
 func (n *dbNamespace) Shards() []Shard {
 	n.RLock()
 	shards := n.shardSet.AllIDs()
@@ -449,7 +584,18 @@ func (n *dbNamespace) Shards() []Shard {
 	return databaseShards
 }
 
+func (n *dbNamespace) ShardSet() sharding.ShardSet {
+	n.RLock()
+	shardSet := n.shardSet
+	n.RUnlock()
+	return shardSet
+}
+
 func (n *dbNamespace) AssignShardSet(shardSet sharding.ShardSet) {
+	if n.shardKeyFn != nil {
+		shardSet = sharding.NewShardKeyShardSet(shardSet, n.shardKeyFn)
+	}
+
 	var (
 		incoming = make(map[uint32]struct{}, len(shardSet.All()))
 		existing []databaseShard
@@ -479,11 +625,11 @@ func (n *dbNamespace) AssignShardSet(shardSet sharding.ShardSet) {
 			bootstrapEnabled := n.nopts.BootstrapEnabled()
 			n.shards[shard] = newDatabaseShard(metadata, shard, n.blockRetriever,
 				n.namespaceReaderMgr, n.increasingIndex, n.reverseIndex,
-				bootstrapEnabled, n.opts, n.seriesOpts)
+				n.freezes, bootstrapEnabled, n.opts, n.seriesOpts)
 			n.metrics.shards.add.Inc(1)
 		}
 	}
-	if idx := n.reverseIndex; idx != nil {
+	if idx := n.reverseIndex.get(); idx != nil {
 		idx.AssignShardSet(shardSet)
 	}
 	n.Unlock()
@@ -569,14 +715,22 @@ func (n *dbNamespace) Tick(c context.Cancellable, startTime time.Time) error {
 
 	// Tick namespaceIndex if it exists.
 	var (
-		indexTickResults namespaceIndexTickResult
-		err              error
+		indexTickResults  namespaceIndexTickResult
+		indexPurgeResults namespaceIndexPurgeResult
+		err               error
 	)
-	if idx := n.reverseIndex; idx != nil {
+	if idx := n.reverseIndex.get(); idx != nil {
 		indexTickResults, err = idx.Tick(c, startTime)
 		if err != nil {
 			multiErr = multiErr.Add(err)
 		}
+
+		// Purge documents of series that no longer have live data now
+		// that the shards have expired any inactive series.
+		indexPurgeResults, err = idx.PurgeDocuments(c, startTime, shards)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+		}
 	}
 
 	// NB: we early terminate here to ensure we are not reporting metrics
@@ -595,6 +749,8 @@ func (n *dbNamespace) Tick(c context.Cancellable, startTime time.Time) error {
 	}
 	n.statsLastTick.Unlock()
 
+	n.blockSizeAnalyzer.Analyze(startTime, int64(r.activeSeries), n.Options())
+
 	n.metrics.tick.activeSeries.Update(float64(r.activeSeries))
 	n.metrics.tick.expiredSeries.Inc(int64(r.expiredSeries))
 	n.metrics.tick.activeBlocks.Update(float64(r.activeBlocks))
@@ -610,6 +766,7 @@ func (n *dbNamespace) Tick(c context.Cancellable, startTime time.Time) error {
 	n.metrics.tick.index.numSegments.Update(float64(indexTickResults.NumSegments))
 	n.metrics.tick.index.numBlocksEvicted.Inc(indexTickResults.NumBlocksEvicted)
 	n.metrics.tick.index.numBlocksSealed.Inc(indexTickResults.NumBlocksSealed)
+	n.metrics.tick.index.numDocsPurged.Inc(indexPurgeResults.NumDocsPurged)
 	n.metrics.tick.errors.Inc(int64(r.errors))
 
 	return nil
@@ -622,21 +779,38 @@ func (n *dbNamespace) Write(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
-) (ts.Series, bool, error) {
+) (ts.Series, bool, series.WriteDisposition, error) {
 	callStart := n.nowFn()
+	if err := n.validateWrite(value, annotation); err != nil {
+		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	accepted, err := n.interceptWrite(id, timestamp, value, unit, annotation)
+	if err != nil {
+		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	if !accepted {
+		n.metrics.write.ReportSuccess(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDropped, nil
+	}
 	shard, nsCtx, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.write.ReportError(n.nowFn().Sub(callStart))
-		return ts.Series{}, false, err
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
 	}
 	opts := series.WriteOptions{
 		TruncateType: n.opts.TruncateType(),
 		SchemaDesc:   nsCtx.Schema,
 	}
-	series, wasWritten, err := shard.Write(ctx, id, timestamp,
+	series, wasWritten, disposition, err := shard.Write(ctx, id, timestamp,
 		value, unit, annotation, opts)
+	if err == nil {
+		n.writeLateness.Record(callStart.Sub(timestamp))
+		n.blockSizeAnalyzer.RecordWrite()
+	}
 	n.metrics.write.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
-	return series, wasWritten, err
+	return series, wasWritten, disposition, err
 }
 
 func (n *dbNamespace) WriteTagged(
@@ -647,25 +821,205 @@ func (n *dbNamespace) WriteTagged(
 	value float64,
 	unit xtime.Unit,
 	annotation []byte,
-) (ts.Series, bool, error) {
+) (ts.Series, bool, series.WriteDisposition, error) {
 	callStart := n.nowFn()
-	if n.reverseIndex == nil { // only happens if indexing is enabled.
+	if n.reverseIndex.get() == nil { // only happens if indexing is enabled.
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
-		return ts.Series{}, false, errNamespaceIndexingDisabled
+		return ts.Series{}, false, series.WriteDispositionUnknown, errNamespaceIndexingDisabled
+	}
+	if err := n.validateWrite(value, annotation); err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	accepted, err := n.interceptWrite(id, timestamp, value, unit, annotation)
+	if err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	if !accepted {
+		n.metrics.writeTagged.ReportSuccess(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDropped, nil
+	}
+	tags, encodedTags, err := n.relabelTags(tags)
+	if err != nil {
+		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
 	}
 	shard, nsCtx, err := n.shardFor(id)
 	if err != nil {
 		n.metrics.writeTagged.ReportError(n.nowFn().Sub(callStart))
-		return ts.Series{}, false, err
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
 	}
 	opts := series.WriteOptions{
 		TruncateType: n.opts.TruncateType(),
 		SchemaDesc:   nsCtx.Schema,
 	}
-	series, wasWritten, err := shard.WriteTagged(ctx, id, tags, timestamp,
+	series, wasWritten, disposition, err := shard.WriteTagged(ctx, id, tags, timestamp,
 		value, unit, annotation, opts)
+	if err == nil {
+		series.EncodedTags = encodedTags
+		n.writeLateness.Record(callStart.Sub(timestamp))
+		n.blockSizeAnalyzer.RecordWrite()
+	}
 	n.metrics.writeTagged.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
-	return series, wasWritten, err
+	return series, wasWritten, disposition, err
+}
+
+func (n *dbNamespace) WriteTaggedBackfill(
+	ctx context.Context,
+	id ident.ID,
+	tags ident.TagIterator,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) (ts.Series, bool, series.WriteDisposition, error) {
+	callStart := n.nowFn()
+	if n.reverseIndex.get() == nil { // only happens if indexing is enabled.
+		n.metrics.writeTaggedBackfill.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, errNamespaceIndexingDisabled
+	}
+	if err := n.validateWrite(value, annotation); err != nil {
+		n.metrics.writeTaggedBackfill.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	accepted, err := n.interceptWrite(id, timestamp, value, unit, annotation)
+	if err != nil {
+		n.metrics.writeTaggedBackfill.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	if !accepted {
+		n.metrics.writeTaggedBackfill.ReportSuccess(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDropped, nil
+	}
+	tags, encodedTags, err := n.relabelTags(tags)
+	if err != nil {
+		n.metrics.writeTaggedBackfill.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	shard, nsCtx, err := n.shardFor(id)
+	if err != nil {
+		n.metrics.writeTaggedBackfill.ReportError(n.nowFn().Sub(callStart))
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
+	}
+	opts := series.WriteOptions{
+		TruncateType:  n.opts.TruncateType(),
+		SchemaDesc:    nsCtx.Schema,
+		BackfillWrite: true,
+	}
+	series, wasWritten, disposition, err := shard.WriteTagged(ctx, id, tags, timestamp,
+		value, unit, annotation, opts)
+	if err == nil {
+		series.EncodedTags = encodedTags
+	}
+	if err == nil && wasWritten {
+		// Backfilled writes into already flushed blocks are cold writes, make
+		// sure the next cold flush picks them up even if cold writes are
+		// otherwise disabled for this namespace.
+		atomic.StoreInt32(&n.backfillPendingColdFlush, 1)
+	}
+	n.metrics.writeTaggedBackfill.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return series, wasWritten, disposition, err
+}
+
+// validateWrite validates the value and annotation of a write against the
+// validation rules of the namespace so that writes violating them never
+// reach the commit log or create a series.
+func (n *dbNamespace) validateWrite(value float64, annotation []byte) error {
+	if !n.validationOpts.Enabled() {
+		return nil
+	}
+	rule := n.validationOpts.Validate(value, annotation)
+	if rule == namespace.ValidationRuleNone {
+		return nil
+	}
+	n.metrics.validationRejected[rule].Inc(1)
+	return xerrors.NewInvalidParamsError(fmt.Errorf(
+		"datapoint rejected by namespace validation rule: %s", rule.String()))
+}
+
+// interceptWrite runs the write interceptors in order, stopping at the first
+// one that drops or rejects the write.
+func (n *dbNamespace) interceptWrite(
+	id ident.ID,
+	timestamp time.Time,
+	value float64,
+	unit xtime.Unit,
+	annotation []byte,
+) (bool, error) {
+	if len(n.writeInterceptors) == 0 {
+		return true, nil
+	}
+	dp := ts.Datapoint{Timestamp: timestamp, Value: value}
+	for _, intercept := range n.writeInterceptors {
+		accepted, err := intercept(n.id, id, dp, unit, annotation)
+		if err != nil {
+			n.metrics.interceptorRejected.Inc(1)
+			return false, err
+		}
+		if !accepted {
+			n.metrics.interceptorDropped.Inc(1)
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// relabelTags applies the relabel rules of the namespace to the tags of a
+// series being written, returning the relabeled tags along with their
+// encoding so that the commit log records the tags the series was indexed
+// with rather than the tags provided by the caller. The series ID is left as
+// is since it determines the shard the series belongs to.
+func (n *dbNamespace) relabelTags(
+	tags ident.TagIterator,
+) (ident.TagIterator, ts.EncodedTags, error) {
+	if !n.relabelOpts.Enabled() {
+		return tags, nil, nil
+	}
+	tags, err := n.relabelOpts.Relabel(tags)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	encoder := n.opts.CommitLogOptions().FilesystemOptions().TagEncoderPool().Get()
+	defer encoder.Finalize()
+	if err := encoder.Encode(tags); err != nil {
+		return nil, nil, err
+	}
+	data, ok := encoder.Data()
+	if !ok {
+		return nil, nil, errNamespaceRelabelEncodeFailed
+	}
+	// NB: The encoded tags are copied since the encoder is returned to the
+	// pool, while the commit log references them after the write returns.
+	encodedTags := append(ts.EncodedTags(nil), data.Bytes()...)
+	return tags, encodedTags, nil
+}
+
+func (n *dbNamespace) Import(
+	ctx context.Context,
+	iter ImportIterator,
+	fileOps importFileOps,
+) (ImportResult, error) {
+	callStart := n.nowFn()
+	n.RLock()
+	if n.bootstrapState != Bootstrapped {
+		n.RUnlock()
+		n.metrics.importData.ReportError(n.nowFn().Sub(callStart))
+		return ImportResult{}, errNamespaceNotBootstrapped
+	}
+	nsCtx := n.nsContextWithRLock()
+	n.RUnlock()
+
+	importer, err := newNamespaceImporter(n, nsCtx, fileOps)
+	if err != nil {
+		n.metrics.importData.ReportError(n.nowFn().Sub(callStart))
+		return ImportResult{}, err
+	}
+
+	result, err := importer.Import(iter)
+	n.metrics.importData.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return result, err
 }
 
 func (n *dbNamespace) SeriesReadWriteRef(
@@ -675,13 +1029,19 @@ func (n *dbNamespace) SeriesReadWriteRef(
 ) (SeriesReadWriteRef, bool, error) {
 	n.RLock()
 	shard, owned, err := n.shardAtWithRLock(shardID)
+	shardSet := n.shardSet
 	n.RUnlock()
 	if err != nil {
 		return SeriesReadWriteRef{}, owned, err
 	}
+	if n.nopts.ReshardOptions().Enabled && shardSet.Lookup(id) != shardID {
+		// Series read from the filesets of a parent shard that belong to
+		// another child of the parent shard are not owned by this shard.
+		return SeriesReadWriteRef{}, false, errNamespaceSeriesNotInShard
+	}
 
 	opts := ShardSeriesReadWriteRefOptions{
-		ReverseIndex: n.reverseIndex != nil,
+		ReverseIndex: n.reverseIndex.get() != nil,
 	}
 
 	res, err := shard.SeriesReadWriteRef(id, tags, opts)
@@ -706,14 +1066,15 @@ func (n *dbNamespace) QueryIDs(
 	defer sp.Finish()
 
 	callStart := n.nowFn()
-	if n.reverseIndex == nil { // only happens if indexing is enabled.
+	reverseIndex := n.reverseIndex.get()
+	if reverseIndex == nil { // only happens if indexing is enabled.
 		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
 		err := errNamespaceIndexingDisabled
 		sp.LogFields(opentracinglog.Error(err))
 		return index.QueryResult{}, err
 	}
 
-	if n.reverseIndex.BootstrapsDone() < 1 {
+	if reverseIndex.BootstrapsDone() < 1 {
 		// Similar to reading shard data, return not bootstrapped
 		n.metrics.queryIDs.ReportError(n.nowFn().Sub(callStart))
 		err := errIndexNotBootstrappedToRead
@@ -722,7 +1083,8 @@ func (n *dbNamespace) QueryIDs(
 			xerrors.NewRetryableError(err)
 	}
 
-	res, err := n.reverseIndex.Query(ctx, query, opts)
+	n.blockSizeAnalyzer.RecordQuery(opts.EndExclusive.Sub(opts.StartInclusive))
+	res, err := reverseIndex.Query(ctx, query, opts)
 	if err != nil {
 		sp.LogFields(opentracinglog.Error(err))
 	}
@@ -736,19 +1098,21 @@ func (n *dbNamespace) AggregateQuery(
 	opts index.AggregationOptions,
 ) (index.AggregateQueryResult, error) {
 	callStart := n.nowFn()
-	if n.reverseIndex == nil { // only happens if indexing is enabled.
+	reverseIndex := n.reverseIndex.get()
+	if reverseIndex == nil { // only happens if indexing is enabled.
 		n.metrics.aggregateQuery.ReportError(n.nowFn().Sub(callStart))
 		return index.AggregateQueryResult{}, errNamespaceIndexingDisabled
 	}
 
-	if n.reverseIndex.BootstrapsDone() < 1 {
+	if reverseIndex.BootstrapsDone() < 1 {
 		// Similar to reading shard data, return not bootstrapped
 		n.metrics.aggregateQuery.ReportError(n.nowFn().Sub(callStart))
 		return index.AggregateQueryResult{},
 			xerrors.NewRetryableError(errIndexNotBootstrappedToRead)
 	}
 
-	res, err := n.reverseIndex.AggregateQuery(ctx, query, opts)
+	n.blockSizeAnalyzer.RecordQuery(opts.EndExclusive.Sub(opts.StartInclusive))
+	res, err := reverseIndex.AggregateQuery(ctx, query, opts)
 	n.metrics.aggregateQuery.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return res, err
 }
@@ -839,6 +1203,28 @@ func (n *dbNamespace) FetchBlocksMetadataV2(
 	return res, nextPageToken, err
 }
 
+func (n *dbNamespace) StreamBlocksMetadataV2(
+	ctx context.Context,
+	shardID uint32,
+	start, end time.Time,
+	limit int64,
+	pageToken PageToken,
+	opts block.FetchBlocksMetadataOptions,
+	fn block.FetchBlocksMetadataResultFn,
+) (PageToken, error) {
+	callStart := n.nowFn()
+	shard, _, err := n.readableShardAt(shardID)
+	if err != nil {
+		n.metrics.fetchBlocksMetadata.ReportError(n.nowFn().Sub(callStart))
+		return nil, err
+	}
+
+	nextPageToken, err := shard.StreamBlocksMetadataV2(ctx, start, end, limit,
+		pageToken, opts, fn)
+	n.metrics.fetchBlocksMetadata.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
+	return nextPageToken, err
+}
+
 func (n *dbNamespace) Bootstrap(
 	bootstrapResult bootstrap.NamespaceResult,
 ) error {
@@ -927,11 +1313,12 @@ func (n *dbNamespace) Bootstrap(
 	}
 	wg.Wait()
 
-	if n.reverseIndex != nil {
+	reverseIndex := n.reverseIndex.get()
+	if reverseIndex != nil {
 		indexResults := bootstrapResult.IndexResult.IndexResults()
 		n.log.Info("bootstrap index with bootstrapped index segments",
 			zap.Int("numIndexBlocks", len(indexResults)))
-		err := n.reverseIndex.Bootstrap(indexResults)
+		err := reverseIndex.Bootstrap(indexResults)
 		multiErr = multiErr.Add(err)
 	}
 
@@ -958,7 +1345,7 @@ func (n *dbNamespace) Bootstrap(
 	if err := markAnyUnfulfilled("data", r.DataResult.Unfulfilled()); err != nil {
 		multiErr = multiErr.Add(err)
 	}
-	if n.reverseIndex != nil {
+	if reverseIndex != nil {
 		if err := markAnyUnfulfilled("index", r.IndexResult.Unfulfilled()); err != nil {
 			multiErr = multiErr.Add(err)
 		}
@@ -1106,8 +1493,14 @@ func (n *dbNamespace) ColdFlush(flushPersist persist.FlushPreparer) error {
 	n.RUnlock()
 
 	// If repair is enabled we still need cold flush regardless of whether cold writes is
-	// enabled since repairs are dependent on the cold flushing logic.
-	if !n.nopts.ColdWritesEnabled() && !n.nopts.RepairEnabled() {
+	// enabled since repairs are dependent on the cold flushing logic. The same applies
+	// to retention tiers since rolled up blocks are written as new cold volumes, and to
+	// backfilled writes into blocks that have already been flushed.
+	// In-memory namespaces never persist data, cold writes are evicted from
+	// the buffer along with warm writes once they fall out of retention.
+	backfillPending := atomic.SwapInt32(&n.backfillPendingColdFlush, 0) == 1
+	if n.nopts.InMemory() || (!n.nopts.ColdWritesEnabled() && !n.nopts.RepairEnabled() &&
+		len(n.nopts.RetentionTiers()) == 0 && !backfillPending) {
 		n.metrics.flushColdData.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
@@ -1117,6 +1510,9 @@ func (n *dbNamespace) ColdFlush(flushPersist persist.FlushPreparer) error {
 
 	resources, err := newColdFlushReuseableResources(n.opts)
 	if err != nil {
+		if backfillPending {
+			atomic.StoreInt32(&n.backfillPendingColdFlush, 1)
+		}
 		return err
 	}
 	for _, shard := range shards {
@@ -1129,6 +1525,10 @@ func (n *dbNamespace) ColdFlush(flushPersist persist.FlushPreparer) error {
 	}
 
 	res := multiErr.FinalError()
+	if res != nil && backfillPending {
+		// Retry flushing backfilled writes on the next cold flush.
+		atomic.StoreInt32(&n.backfillPendingColdFlush, 1)
+	}
 	n.metrics.flushColdData.ReportSuccessOrError(res, n.nowFn().Sub(callStart))
 	return res
 }
@@ -1141,15 +1541,17 @@ func (n *dbNamespace) FlushIndex(flush persist.IndexFlush) error {
 		n.metrics.flushIndex.ReportError(n.nowFn().Sub(callStart))
 		return errNamespaceNotBootstrapped
 	}
+	// NB: Indexing can be enabled at runtime so the options require an RLock.
+	nopts := n.nopts
 	n.RUnlock()
 
-	if !n.nopts.FlushEnabled() || !n.nopts.IndexOptions().Enabled() {
+	if !nopts.FlushEnabled() || !nopts.IndexOptions().Enabled() {
 		n.metrics.flushIndex.ReportSuccess(n.nowFn().Sub(callStart))
 		return nil
 	}
 
 	shards := n.GetOwnedShards()
-	err := n.reverseIndex.Flush(flush, shards)
+	err := n.reverseIndex.get().Flush(flush, shards)
 	n.metrics.flushIndex.ReportSuccessOrError(err, n.nowFn().Sub(callStart))
 	return err
 }
@@ -1366,7 +1768,7 @@ func (n *dbNamespace) GetIndex() (namespaceIndex, error) {
 	if !n.metadata.Options().IndexOptions().Enabled() {
 		return nil, errNamespaceIndexingDisabled
 	}
-	return n.reverseIndex, nil
+	return n.reverseIndex.get(), nil
 }
 
 func (n *dbNamespace) shardFor(id ident.ID) (databaseShard, namespace.Context, error) {
@@ -1418,6 +1820,11 @@ func (n *dbNamespace) readableShardAtWithRLock(shardID uint32) (databaseShard, e
 	if !shard.IsBootstrapped() {
 		return nil, xerrors.NewRetryableError(errShardNotBootstrappedToRead)
 	}
+	if shard.IsDegraded() {
+		// Flag the shard as unavailable so that clients read from the other
+		// replicas while the shard is isolated.
+		return nil, xerrors.NewUnavailableError(errShardDegraded)
+	}
 	return shard, nil
 }
 
@@ -1428,7 +1835,7 @@ func (n *dbNamespace) initShards(needBootstrap bool) {
 	for _, shard := range shards {
 		dbShards[shard] = newDatabaseShard(n.metadata, shard, n.blockRetriever,
 			n.namespaceReaderMgr, n.increasingIndex, n.reverseIndex,
-			needBootstrap, n.opts, n.seriesOpts)
+			n.freezes, needBootstrap, n.opts, n.seriesOpts)
 	}
 	n.shards = dbShards
 	n.Unlock()
@@ -1448,8 +1855,8 @@ func (n *dbNamespace) Close() error {
 	n.namespaceReaderMgr.close()
 	n.closeShards(shards, true)
 	close(n.shutdownCh)
-	if n.reverseIndex != nil {
-		return n.reverseIndex.Close()
+	if idx := n.reverseIndex.get(); idx != nil {
+		return idx.Close()
 	}
 	if n.schemaListener != nil {
 		n.schemaListener.Close()
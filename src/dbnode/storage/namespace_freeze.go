@@ -0,0 +1,117 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+var errFreezeRangeInvalid = errors.New("freeze range start must be before end")
+
+// namespaceFreezes tracks the time ranges of a namespace that are frozen,
+// frozen data is not repaired, compacted by cold flushes or cleaned up so
+// that it is preserved as is while it is being investigated, for instance
+// while investigating a corruption. Freezes are held in memory only and do
+// not survive a restart of the node.
+type namespaceFreezes struct {
+	sync.RWMutex
+	ranges xtime.Ranges
+}
+
+func newNamespaceFreezes() *namespaceFreezes {
+	return &namespaceFreezes{ranges: xtime.NewRanges()}
+}
+
+// Freeze freezes the range.
+func (f *namespaceFreezes) Freeze(r xtime.Range) error {
+	if !r.Start.Before(r.End) {
+		return errFreezeRangeInvalid
+	}
+	f.Lock()
+	f.ranges = f.ranges.AddRange(r)
+	f.Unlock()
+	return nil
+}
+
+// Unfreeze unfreezes the range, any frozen data outside of the range
+// remains frozen.
+func (f *namespaceFreezes) Unfreeze(r xtime.Range) error {
+	if !r.Start.Before(r.End) {
+		return errFreezeRangeInvalid
+	}
+	f.Lock()
+	f.ranges = f.ranges.RemoveRange(r)
+	f.Unlock()
+	return nil
+}
+
+// Ranges returns the frozen ranges sorted by start.
+func (f *namespaceFreezes) Ranges() []xtime.Range {
+	if f == nil {
+		return nil
+	}
+	f.RLock()
+	defer f.RUnlock()
+
+	result := make([]xtime.Range, 0, f.ranges.Len())
+	it := f.ranges.Iter()
+	for it.Next() {
+		result = append(result, it.Value())
+	}
+	return result
+}
+
+// IsFrozen returns whether any of the range is frozen.
+func (f *namespaceFreezes) IsFrozen(r xtime.Range) bool {
+	if f == nil {
+		return false
+	}
+	f.RLock()
+	frozen := f.ranges.Overlaps(r)
+	f.RUnlock()
+	return frozen
+}
+
+// IsBlockFrozen returns whether any of the block is frozen.
+func (f *namespaceFreezes) IsBlockFrozen(
+	blockStart time.Time,
+	blockSize time.Duration,
+) bool {
+	return f.IsFrozen(xtime.Range{Start: blockStart, End: blockStart.Add(blockSize)})
+}
+
+// EarliestToRetain returns the start of the earliest block that must be
+// retained given that blocks before earliestToRetain have expired, the
+// blocks of frozen ranges are retained even once they have expired.
+func (f *namespaceFreezes) EarliestToRetain(
+	earliestToRetain time.Time,
+	blockSize time.Duration,
+) time.Time {
+	ranges := f.Ranges()
+	if len(ranges) == 0 || !ranges[0].Start.Before(earliestToRetain) {
+		return earliestToRetain
+	}
+	return ranges[0].Start.Truncate(blockSize)
+}
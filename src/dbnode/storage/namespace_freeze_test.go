@@ -0,0 +1,95 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceFreezesFreezeUnfreeze(t *testing.T) {
+	var (
+		blockSize = time.Hour
+		start     = time.Now().Truncate(blockSize)
+		freezes   = newNamespaceFreezes()
+	)
+
+	require.NoError(t, freezes.Freeze(xtime.Range{Start: start, End: start.Add(4 * blockSize)}))
+	require.True(t, freezes.IsBlockFrozen(start, blockSize))
+	require.True(t, freezes.IsBlockFrozen(start.Add(3*blockSize), blockSize))
+	require.False(t, freezes.IsBlockFrozen(start.Add(4*blockSize), blockSize))
+	require.False(t, freezes.IsBlockFrozen(start.Add(-blockSize), blockSize))
+
+	// Unfreezing the middle of the range leaves both ends frozen.
+	require.NoError(t, freezes.Unfreeze(xtime.Range{Start: start.Add(blockSize), End: start.Add(3 * blockSize)}))
+	require.True(t, freezes.IsBlockFrozen(start, blockSize))
+	require.False(t, freezes.IsBlockFrozen(start.Add(blockSize), blockSize))
+	require.False(t, freezes.IsBlockFrozen(start.Add(2*blockSize), blockSize))
+	require.True(t, freezes.IsBlockFrozen(start.Add(3*blockSize), blockSize))
+
+	assert.Equal(t, []xtime.Range{
+		{Start: start, End: start.Add(blockSize)},
+		{Start: start.Add(3 * blockSize), End: start.Add(4 * blockSize)},
+	}, freezes.Ranges())
+}
+
+func TestNamespaceFreezesInvalidRange(t *testing.T) {
+	var (
+		start   = time.Now()
+		freezes = newNamespaceFreezes()
+	)
+
+	require.Equal(t, errFreezeRangeInvalid, freezes.Freeze(xtime.Range{Start: start, End: start}))
+	require.Equal(t, errFreezeRangeInvalid, freezes.Unfreeze(xtime.Range{Start: start, End: start.Add(-time.Second)}))
+	require.Empty(t, freezes.Ranges())
+}
+
+func TestNamespaceFreezesEarliestToRetain(t *testing.T) {
+	var (
+		blockSize        = time.Hour
+		earliestToRetain = time.Now().Truncate(blockSize)
+		freezes          = newNamespaceFreezes()
+	)
+
+	require.Equal(t, earliestToRetain, freezes.EarliestToRetain(earliestToRetain, blockSize))
+
+	// Frozen ranges after the retention boundary do not move it.
+	require.NoError(t, freezes.Freeze(xtime.Range{Start: earliestToRetain.Add(blockSize), End: earliestToRetain.Add(2 * blockSize)}))
+	require.Equal(t, earliestToRetain, freezes.EarliestToRetain(earliestToRetain, blockSize))
+
+	// Frozen ranges before it retain the block they start in.
+	frozenStart := earliestToRetain.Add(-3*blockSize + time.Minute)
+	require.NoError(t, freezes.Freeze(xtime.Range{Start: frozenStart, End: frozenStart.Add(time.Minute)}))
+	require.Equal(t, earliestToRetain.Add(-3*blockSize), freezes.EarliestToRetain(earliestToRetain, blockSize))
+}
+
+func TestNamespaceFreezesNil(t *testing.T) {
+	var freezes *namespaceFreezes
+	now := time.Now()
+	require.False(t, freezes.IsFrozen(xtime.Range{Start: now, End: now.Add(time.Hour)}))
+	require.Nil(t, freezes.Ranges())
+	require.Equal(t, now, freezes.EarliestToRetain(now, time.Hour))
+}
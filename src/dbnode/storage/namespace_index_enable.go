@@ -0,0 +1,238 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"math"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/storage/index/convert"
+	"github.com/m3db/m3/src/m3ninx/doc"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+var errNamespaceIndexAlreadyEnabled = errors.New("namespace indexing is already enabled")
+
+// namespaceIndexRef is a reference to the reverse index of a namespace that
+// is shared between the namespace and its shards so that indexing can be
+// enabled at runtime without synchronizing every read of the index.
+type namespaceIndexRef struct {
+	value atomic.Value
+}
+
+type namespaceIndexRefValue struct {
+	index namespaceIndex
+}
+
+func newNamespaceIndexRef(idx namespaceIndex) *namespaceIndexRef {
+	r := &namespaceIndexRef{}
+	r.set(idx)
+	return r
+}
+
+// get returns the reverse index or nil if indexing is not enabled.
+func (r *namespaceIndexRef) get() namespaceIndex {
+	if r == nil {
+		return nil
+	}
+	v, ok := r.value.Load().(namespaceIndexRefValue)
+	if !ok {
+		return nil
+	}
+	return v.index
+}
+
+func (r *namespaceIndexRef) set(idx namespaceIndex) {
+	r.value.Store(namespaceIndexRefValue{index: idx})
+}
+
+// EnableIndex enables indexing on a namespace that was created without an
+// index, the index is backfilled in the background from the series metadata
+// of the blocks within retention and queries are rejected as not bootstrapped
+// until the backfill completes.
+func (n *dbNamespace) EnableIndex(indexOpts namespace.IndexOptions) error {
+	n.Lock()
+	if n.reverseIndex.get() != nil {
+		n.Unlock()
+		return errNamespaceIndexAlreadyEnabled
+	}
+	if n.bootstrapState != Bootstrapped {
+		n.Unlock()
+		return errNamespaceNotBootstrapped
+	}
+
+	nopts := n.nopts.SetIndexOptions(indexOpts)
+	metadata, err := namespace.NewMetadata(n.id,
+		n.metadata.Options().SetIndexOptions(indexOpts))
+	if err != nil {
+		n.Unlock()
+		return err
+	}
+
+	idx, err := newNamespaceIndex(metadata, n.shardSet, n.opts)
+	if err != nil {
+		n.Unlock()
+		return err
+	}
+
+	n.nopts = nopts
+	n.metadata = metadata
+	n.reverseIndex.set(idx)
+	shards := n.getOwnedShardsWithLock()
+	n.Unlock()
+
+	n.log.Info("enabled namespace indexing, backfilling index",
+		zap.Int("numShards", len(shards)))
+
+	go n.backfillIndex(idx, nopts, shards)
+	return nil
+}
+
+func (n *dbNamespace) getOwnedShardsWithLock() []databaseShard {
+	shards := n.shardSet.AllIDs()
+	databaseShards := make([]databaseShard, len(shards))
+	for i, shard := range shards {
+		databaseShards[i] = n.shards[shard]
+	}
+	return databaseShards
+}
+
+// backfillIndex indexes the series of every block within retention, new writes
+// are indexed as they arrive since the index is already visible to the shards.
+func (n *dbNamespace) backfillIndex(
+	idx namespaceIndex,
+	nopts namespace.Options,
+	shards []databaseShard,
+) {
+	var (
+		start          = n.nowFn()
+		ropts          = nopts.RetentionOptions()
+		indexBlockSize = nopts.IndexOptions().BlockSize()
+		rangeStart     = retention.FlushTimeStart(ropts, start)
+		rangeEnd       = start.Truncate(ropts.BlockSize()).
+				Add(ropts.BlockSize()).
+				Add(ropts.BufferFuture())
+		multiErr = xerrors.NewMultiError()
+	)
+	for _, shard := range shards {
+		select {
+		case <-n.shutdownCh:
+			n.log.Info("namespace closed, stopping index backfill")
+			return
+		default:
+		}
+
+		err := n.backfillShardIndex(idx, shard, rangeStart, rangeEnd,
+			ropts.BlockSize(), indexBlockSize)
+		if err != nil {
+			n.log.Error("unable to backfill shard index",
+				zap.Uint32("shard", shard.ID()), zap.Error(err))
+			multiErr = multiErr.Add(err)
+		}
+	}
+
+	// NB: Mark the index as bootstrapped even if some shards failed so that
+	// queries are served, the series that failed to backfill are indexed
+	// again as they are written to.
+	if err := idx.Bootstrap(nil); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+	if err := multiErr.FinalError(); err != nil {
+		n.log.Error("namespace index backfill completed with errors",
+			zap.Duration("took", n.nowFn().Sub(start)), zap.Error(err))
+		return
+	}
+	n.log.Info("namespace index backfill completed",
+		zap.Duration("took", n.nowFn().Sub(start)))
+}
+
+func (n *dbNamespace) backfillShardIndex(
+	idx namespaceIndex,
+	shard databaseShard,
+	start, end time.Time,
+	blockSize, indexBlockSize time.Duration,
+) error {
+	ctx := n.opts.ContextPool().Get()
+	defer ctx.Close()
+
+	var (
+		docsByBlockStart = make(map[xtime.UnixNano][]doc.Document)
+		pageToken        PageToken
+		err              error
+	)
+	for {
+		pageToken, err = shard.StreamBlocksMetadataV2(ctx, start, end,
+			math.MaxInt64, pageToken, block.FetchBlocksMetadataOptions{},
+			func(result block.FetchBlocksMetadataResult) error {
+				defer result.Close()
+
+				tags := result.Tags
+				if tags == nil {
+					tags = ident.EmptyTagIterator
+				}
+				d, err := convert.FromMetricIter(result.ID, tags)
+				if err != nil {
+					return err
+				}
+
+				// Add the series once to every index block that overlaps
+				// any of its data blocks.
+				added := make(map[xtime.UnixNano]struct{})
+				for _, b := range result.Blocks.Results() {
+					var (
+						blockEnd   = b.Start.Add(blockSize)
+						blockStart = idx.BlockStartForWriteTime(b.Start).ToTime()
+					)
+					for t := blockStart; t.Before(blockEnd); t = t.Add(indexBlockSize) {
+						key := xtime.ToUnixNano(t)
+						if _, ok := added[key]; ok {
+							continue
+						}
+						added[key] = struct{}{}
+						docsByBlockStart[key] = append(docsByBlockStart[key], d)
+					}
+				}
+				return nil
+			})
+		if err != nil {
+			return err
+		}
+		if pageToken == nil {
+			break
+		}
+	}
+
+	for blockStart, docs := range docsByBlockStart {
+		if err := idx.AddImported(blockStart.ToTime(), docs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
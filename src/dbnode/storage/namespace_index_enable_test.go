@@ -0,0 +1,84 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	xclock "github.com/m3db/m3/src/x/clock"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNamespaceIndexRef(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var nilRef *namespaceIndexRef
+	require.Nil(t, nilRef.get())
+
+	ref := newNamespaceIndexRef(nil)
+	require.Nil(t, ref.get())
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ref.set(idx)
+	require.Equal(t, idx, ref.get())
+}
+
+func TestNamespaceEnableIndexNotBootstrapped(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	indexOpts := namespace.NewIndexOptions().SetEnabled(true)
+	require.Equal(t, errNamespaceNotBootstrapped, ns.EnableIndex(indexOpts))
+	require.Nil(t, ns.reverseIndex.get())
+	require.False(t, ns.Metadata().Options().IndexOptions().Enabled())
+}
+
+func TestNamespaceEnableIndex(t *testing.T) {
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	defer func() {
+		require.NoError(t, ns.Close())
+	}()
+
+	ns.bootstrapState = Bootstrapped
+
+	indexOpts := namespace.NewIndexOptions().SetEnabled(true)
+	require.NoError(t, ns.EnableIndex(indexOpts))
+	require.True(t, ns.Metadata().Options().IndexOptions().Enabled())
+
+	idx := ns.reverseIndex.get()
+	require.NotNil(t, idx)
+	for _, shard := range ns.GetOwnedShards() {
+		require.Equal(t, idx, shard.(*dbShard).reverseIndex.get())
+	}
+
+	// The index is only queryable once the backfill has completed.
+	require.True(t, xclock.WaitUntil(func() bool {
+		return idx.BootstrapsDone() > 0
+	}, 5*time.Second))
+
+	require.Equal(t, errNamespaceIndexAlreadyEnabled, ns.EnableIndex(indexOpts))
+}
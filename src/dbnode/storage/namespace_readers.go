@@ -195,8 +195,35 @@ func (m *namespaceReaderManager) filesetExistsAt(
 		return false, err
 	}
 
-	return m.filesetExistsFn(m.fsOpts.FilePathPrefix(),
-		m.namespace.ID(), shard, blockStart, latestVolume)
+	_, exists, err := m.fileSetBlockStart(shard, blockStart, latestVolume)
+	return exists, err
+}
+
+// fileSetBlockStart returns the block start of the fileset that holds a
+// volume of a block, which is the start of the compacted block it belongs to
+// if it has been compacted into the fileset of its compacted block.
+func (m *namespaceReaderManager) fileSetBlockStart(
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) (time.Time, bool, error) {
+	exists, err := m.filesetExistsFn(m.fsOpts.FilePathPrefix(),
+		m.namespace.ID(), shard, blockStart, volume)
+	if err != nil || exists {
+		return blockStart, exists, err
+	}
+
+	compactionOpts := m.namespace.Options().CompactionOptions()
+	if !compactionOpts.Enabled {
+		return blockStart, false, nil
+	}
+	compactedBlockStart := compactionOpts.CompactedBlockStart(blockStart)
+	if compactedBlockStart.Equal(blockStart) {
+		return blockStart, false, nil
+	}
+	exists, err = m.filesetExistsFn(m.fsOpts.FilePathPrefix(),
+		m.namespace.ID(), shard, compactedBlockStart, volume)
+	return compactedBlockStart, exists, err
 }
 
 type cachedReaderForKeyResult struct {
@@ -310,6 +337,20 @@ func (m *namespaceReaderManager) get(
 			VolumeIndex: latestVolume,
 		},
 	}
+	if m.namespace.Options().CompactionOptions().Enabled {
+		// Only the block is read from the fileset that holds it if it is a
+		// compacted fileset.
+		fileSetStart, _, err := m.fileSetBlockStart(shard, blockStart, latestVolume)
+		if err != nil {
+			return nil, err
+		}
+		blockSize := m.namespace.Options().RetentionOptions().BlockSize()
+		openOpts.Identifier.BlockStart = fileSetStart
+		openOpts.Block = xtime.Range{
+			Start: blockStart,
+			End:   blockStart.Add(blockSize),
+		}
+	}
 	if err := reader.Open(openOpts); err != nil {
 		return nil, err
 	}
@@ -0,0 +1,324 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	xerrors "github.com/m3db/m3/src/x/errors"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"go.uber.org/zap"
+)
+
+// splitFileSets splits at most limit blocks of the flushed data filesets of
+// the parent shards of the shards owned by the namespace into filesets of
+// the owned shards if the shard space of the namespace has been split,
+// returning the number of blocks split.
+//
+// Until a shard has been split from its parent shard the blocks it has no
+// filesets of its own for are bootstrapped and read from the filesets of its
+// parent shard, so the filesets are split in the background a few blocks at
+// a time rather than before the shards are bootstrapped. Once all blocks of
+// a shard have been split it is marked as split by a marker in the shard
+// directory and no longer reads from its parent shard.
+//
+// The filesets of a parent shard are rewritten for the child shard sharing
+// its ID only once all its other children have been split from it on this
+// node, until then the series of the other children are left in its
+// filesets, which is safe since those series are never read from the
+// parent shard.
+func (n *dbNamespace) splitFileSets(limit int) (int, error) {
+	reshardOpts := n.nopts.ReshardOptions()
+	if !reshardOpts.Enabled || limit <= 0 {
+		return 0, nil
+	}
+	split, err := reshardOpts.ShardSplit()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		shardFn  = n.ShardSet().HashFn()
+		shards   = n.GetOwnedShards()
+		owned    = make(map[uint32]struct{}, len(shards))
+		children = make([]databaseShard, 0, len(shards))
+		parents  = make([]databaseShard, 0, len(shards))
+		multiErr = xerrors.NewMultiError()
+		numSplit int
+	)
+	for _, shard := range shards {
+		owned[shard.ID()] = struct{}{}
+		if split.Parent(shard.ID()) == shard.ID() {
+			parents = append(parents, shard)
+		} else {
+			children = append(children, shard)
+		}
+	}
+
+	// Children not sharing the ID of their parent are split first since the
+	// parent filesets are rewritten once all of them are split.
+	for _, shard := range append(children, parents...) {
+		if numSplit >= limit {
+			break
+		}
+		if split.Parent(shard.ID()) == shard.ID() {
+			childrenSplit, err := n.childrenSplit(shard.ID(), split, owned)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			if !childrenSplit {
+				continue
+			}
+		}
+
+		shardSplit, err := shard.SplitFileSets(shardFn, limit-numSplit)
+		numSplit += shardSplit
+		if err != nil {
+			detailedErr := fmt.Errorf("shard %d failed to split filesets: %v",
+				shard.ID(), err)
+			multiErr = multiErr.Add(detailedErr)
+			// Continue with remaining shards.
+		}
+	}
+
+	return numSplit, multiErr.FinalError()
+}
+
+// childrenSplit returns whether all other children of a parent shard are
+// owned by this node and have been split from it.
+func (n *dbNamespace) childrenSplit(
+	parent uint32,
+	split sharding.ShardSplit,
+	owned map[uint32]struct{},
+) (bool, error) {
+	filePathPrefix := n.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	for _, child := range split.Children(parent) {
+		if child == parent {
+			continue
+		}
+		if _, ok := owned[child]; !ok {
+			// Another node splits this child from its own copy of the parent,
+			// the parent filesets are left as is.
+			return false, nil
+		}
+		isSplit, err := fs.ShardSplitMarkerExists(filePathPrefix, n.ID(), child, split)
+		if err != nil || !isSplit {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// SplitFileSets splits at most limit blocks of the data filesets of the
+// parent shard of the shard that the shard has no filesets of its own for
+// into filesets of the shard, returning the number of blocks split. A shard
+// sharing its ID with its parent rewrites its own filesets without the
+// series of the other children of the parent instead.
+//
+// Each block is split into the next volume of the block of the shard and
+// made visible to readers like a cold flush of the block, until then reads
+// of the block are served from the filesets of the parent shard. The blocks
+// a shard sharing its ID with its parent has rewritten are only tracked in
+// memory, after a restart they are rewritten again until the shard is
+// marked as split.
+func (s *dbShard) SplitFileSets(
+	shardFn sharding.HashFn,
+	limit int,
+) (int, error) {
+	s.RLock()
+	if s.bootstrapState != Bootstrapped {
+		s.RUnlock()
+		return 0, errShardNotBootstrappedToFlush
+	}
+	s.RUnlock()
+
+	reshardOpts := s.namespace.Options().ReshardOptions()
+	if !reshardOpts.Enabled || limit <= 0 {
+		return 0, nil
+	}
+	split, err := reshardOpts.ShardSplit()
+	if err != nil {
+		return 0, err
+	}
+
+	var (
+		fsOpts         = s.opts.CommitLogOptions().FilesystemOptions()
+		filePathPrefix = fsOpts.FilePathPrefix()
+		nsID           = s.namespace.ID()
+		parent         = split.Parent(s.ID())
+	)
+	isSplit, err := fs.ShardSplitMarkerExists(filePathPrefix, nsID, s.ID(), split)
+	if err != nil || isSplit {
+		return 0, err
+	}
+
+	parentFiles, err := fs.DataFiles(filePathPrefix, nsID, parent)
+	if err != nil {
+		return 0, err
+	}
+	files := parentFiles
+	if parent != s.ID() {
+		files, err = fs.DataFiles(filePathPrefix, nsID, s.ID())
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	var (
+		toSplit     []fs.FileSetFile
+		blockStarts = make(map[xtime.UnixNano]struct{}, len(parentFiles))
+		earliest    = retention.FlushTimeStart(s.namespace.Options().RetentionOptions(), s.nowFn())
+	)
+	for _, file := range parentFiles {
+		blockStart := xtime.ToUnixNano(file.ID.BlockStart)
+		if _, ok := blockStarts[blockStart]; ok {
+			continue
+		}
+		blockStarts[blockStart] = struct{}{}
+		if file.ID.BlockStart.Before(earliest) {
+			// Expired blocks are removed by the cleanup rather than split.
+			continue
+		}
+
+		source, ok := parentFiles.LatestVolumeForBlock(file.ID.BlockStart)
+		if !ok {
+			continue
+		}
+		if parent == s.ID() {
+			if _, ok := s.splitBlocks[blockStart]; ok {
+				// Already rewritten without the series of the other children.
+				continue
+			}
+		} else if _, ok := files.LatestVolumeForBlock(file.ID.BlockStart); ok {
+			// Written by the shard itself or already split.
+			continue
+		}
+		toSplit = append(toSplit, source)
+	}
+	sort.Slice(toSplit, func(i, j int) bool {
+		return toSplit[i].ID.BlockStart.Before(toSplit[j].ID.BlockStart)
+	})
+
+	var (
+		remaining = len(toSplit)
+		multiErr  xerrors.MultiError
+		numSplit  int
+		numSeries int
+	)
+	if len(toSplit) > limit {
+		toSplit = toSplit[:limit]
+	}
+	if len(toSplit) > 0 {
+		reader, err := fs.NewReader(s.opts.BytesPool(), fsOpts)
+		if err != nil {
+			return 0, err
+		}
+		writer, err := fs.NewWriter(fsOpts)
+		if err != nil {
+			return 0, err
+		}
+		for _, source := range toSplit {
+			written, err := s.splitBlock(reader, writer, shardFn, source)
+			if err != nil {
+				multiErr = multiErr.Add(err)
+				continue
+			}
+			numSplit++
+			numSeries += written
+		}
+	}
+
+	if err := multiErr.FinalError(); err != nil || numSplit < remaining {
+		return numSplit, err
+	}
+
+	if err := fs.WriteShardSplitMarker(filePathPrefix, nsID, s.ID(), split,
+		fsOpts.NewDirectoryMode(), fsOpts.NewFileMode()); err != nil {
+		return numSplit, err
+	}
+	s.splitBlocks = nil
+
+	s.logger.Info("split shard filesets from parent shard",
+		zap.Stringer("namespace", nsID),
+		zap.Uint32("shard", s.ID()),
+		zap.Uint32("parent", parent),
+		zap.Int("filesets", numSplit),
+		zap.Int("series", numSeries))
+	return numSplit, nil
+}
+
+// splitBlock writes the series of the source fileset of the parent shard
+// that belong to the shard into the next volume of the block of the shard.
+func (s *dbShard) splitBlock(
+	reader fs.DataFileSetReader,
+	writer fs.DataFileSetWriter,
+	shardFn sharding.HashFn,
+	source fs.FileSetFile,
+) (int, error) {
+	blockStart := source.ID.BlockStart
+	coldVersion, err := s.RetrievableBlockColdVersion(blockStart)
+	if err != nil {
+		return 0, err
+	}
+
+	nextVersion := coldVersion + 1
+	written, err := fs.SplitFileSet(reader, writer, s.identifierPool,
+		fs.SplitFileSetOptions{
+			Source: source.ID,
+			Target: fs.FileSetFileIdentifier{
+				Namespace:   s.namespace.ID(),
+				Shard:       s.ID(),
+				BlockStart:  blockStart,
+				VolumeIndex: nextVersion,
+			},
+			ShardFn: shardFn,
+		})
+	if err != nil {
+		return 0, err
+	}
+
+	if err := s.markColdVersionFlushed(blockStart, nextVersion); err != nil {
+		return 0, err
+	}
+	if source.ID.Shard == s.ID() {
+		if s.splitBlocks == nil {
+			s.splitBlocks = make(map[xtime.UnixNano]struct{})
+		}
+		s.splitBlocks[xtime.ToUnixNano(blockStart)] = struct{}{}
+	}
+	return written, nil
+}
+
+// splitSourceBlock returns whether the block of the shard is read from the
+// filesets of its parent shard since the shard has not yet been split.
+func (s *dbShard) splitSourceBlock(blockStart time.Time) (bool, error) {
+	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
+	_, ok, err := fs.SplitSourceDataFileSet(filePathPrefix, s.namespace,
+		s.ID(), blockStart)
+	return ok, err
+}
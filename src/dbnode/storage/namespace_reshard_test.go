@@ -0,0 +1,295 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"io"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/digest"
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/persist"
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/x/checked"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testReshardOpts = namespace.ReshardOptions{
+	Enabled:       true,
+	FromNumShards: 4,
+	ToNumShards:   8,
+}
+
+func newTestReshardNamespace(
+	t *testing.T,
+	dir string,
+	shards []uint32,
+) (*dbNamespace, fs.Options, closerFn) {
+	var (
+		seriesShards = map[string]uint32{"foo": 1, "bar": 5}
+		hashFn       = func(id ident.ID) uint32 {
+			return seriesShards[id.String()]
+		}
+		dopts  = DefaultTestOptions().SetRuntimeOptionsManager(runtime.NewOptionsManager())
+		fsOpts = dopts.CommitLogOptions().FilesystemOptions().
+			SetFilePathPrefix(dir)
+	)
+	dopts = dopts.SetCommitLogOptions(dopts.CommitLogOptions().
+		SetFilesystemOptions(fsOpts))
+
+	metadata := newTestNamespaceMetadataWithIDOpts(t, defaultTestNs1ID,
+		defaultTestNs1Opts.SetReshardOptions(testReshardOpts))
+	shardSet, err := sharding.NewShardSet(
+		sharding.NewShards(shards, shard.Available), hashFn)
+	require.NoError(t, err)
+	ns, err := newDatabaseNamespace(metadata, shardSet, nil, nil, nil, dopts)
+	require.NoError(t, err)
+	return ns.(*dbNamespace), fsOpts, dopts.RuntimeOptionsManager().Close
+}
+
+func writeTestReshardFileSet(
+	t *testing.T,
+	fsOpts fs.Options,
+	shard uint32,
+	blockStart time.Time,
+	ids ...string,
+) {
+	writer, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+	require.NoError(t, writer.Open(fs.DataWriterOpenOptions{
+		FileSetType: persist.FileSetFlushType,
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  defaultTestNs1ID,
+			Shard:      shard,
+			BlockStart: blockStart,
+		},
+		BlockSize: defaultTestNs1Opts.RetentionOptions().BlockSize(),
+	}))
+	for _, id := range ids {
+		data := checked.NewBytes([]byte(id), nil)
+		data.IncRef()
+		require.NoError(t, writer.Write(ident.StringID(id), ident.Tags{}, data,
+			digest.Checksum([]byte(id))))
+	}
+	require.NoError(t, writer.Close())
+}
+
+func readTestReshardFileSet(
+	t *testing.T,
+	fsOpts fs.Options,
+	shard uint32,
+	blockStart time.Time,
+	volume int,
+) []string {
+	reader, err := fs.NewReader(nil, fsOpts)
+	require.NoError(t, err)
+	require.NoError(t, reader.Open(fs.DataReaderOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:   defaultTestNs1ID,
+			Shard:       shard,
+			BlockStart:  blockStart,
+			VolumeIndex: volume,
+		},
+		FileSetType: persist.FileSetFlushType,
+	}))
+	defer reader.Close()
+
+	var ids []string
+	for {
+		id, tags, _, _, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		ids = append(ids, id.String())
+		tags.Close()
+	}
+	return ids
+}
+
+func bootstrapTestReshardShards(t *testing.T, ns *dbNamespace) {
+	for _, shard := range ns.GetOwnedShards() {
+		require.NoError(t, shard.Bootstrap())
+	}
+}
+
+func TestNamespaceSplitFileSets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ns, fsOpts, closer := newTestReshardNamespace(t, dir, []uint32{1, 5})
+	defer closer()
+
+	blockStart := time.Now().Truncate(defaultTestNs1Opts.RetentionOptions().BlockSize())
+	writeTestReshardFileSet(t, fsOpts, 1, blockStart, "foo", "bar")
+
+	// Bootstrapping the shards does not split the filesets, the child shard
+	// reads the block from its parent shard until it is split.
+	bootstrapTestReshardShards(t, ns)
+	exists, err := fs.DataFileSetExists(dir, defaultTestNs1ID, 5, blockStart, 0)
+	require.NoError(t, err)
+	require.False(t, exists)
+	source, ok, err := fs.SplitSourceDataFileSet(dir, ns.Metadata(), 5, blockStart)
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, uint32(1), source.ID.Shard)
+
+	child := ns.shards[5].(*dbShard)
+	state, err := child.FlushState(blockStart)
+	require.NoError(t, err)
+	require.Equal(t, fileOpSuccess, state.WarmStatus)
+	require.Equal(t, 0, state.ColdVersionRetrievable)
+
+	split, err := ns.splitFileSets(10)
+	require.NoError(t, err)
+	require.Equal(t, 2, split)
+
+	require.Equal(t, []string{"bar"}, readTestReshardFileSet(t, fsOpts, 5, blockStart, 1))
+	require.Equal(t, []string{"foo"}, readTestReshardFileSet(t, fsOpts, 1, blockStart, 1))
+	coldVersion, err := child.RetrievableBlockColdVersion(blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 1, coldVersion)
+
+	shardSplit, err := testReshardOpts.ShardSplit()
+	require.NoError(t, err)
+	for _, shard := range []uint32{1, 5} {
+		exists, err := fs.ShardSplitMarkerExists(dir, defaultTestNs1ID, shard, shardSplit)
+		require.NoError(t, err)
+		require.True(t, exists)
+	}
+	_, ok, err = fs.SplitSourceDataFileSet(dir, ns.Metadata(), 5, blockStart)
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	// Shards are only split once.
+	split, err = ns.splitFileSets(10)
+	require.NoError(t, err)
+	require.Equal(t, 0, split)
+	next, err := fs.NextDataFileSetVolumeIndex(dir, defaultTestNs1ID, 1, blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 2, next)
+}
+
+func TestNamespaceSplitFileSetsLimit(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ns, fsOpts, closer := newTestReshardNamespace(t, dir, []uint32{1, 5})
+	defer closer()
+
+	var (
+		blockSize  = defaultTestNs1Opts.RetentionOptions().BlockSize()
+		blockStart = time.Now().Truncate(blockSize)
+		prevStart  = blockStart.Add(-blockSize)
+	)
+	writeTestReshardFileSet(t, fsOpts, 1, prevStart, "foo", "bar")
+	writeTestReshardFileSet(t, fsOpts, 1, blockStart, "foo", "bar")
+	bootstrapTestReshardShards(t, ns)
+
+	shardSplit, err := testReshardOpts.ShardSplit()
+	require.NoError(t, err)
+
+	// Only the earliest block of the child is split, the child is not yet
+	// marked as split and the parent is left as is.
+	split, err := ns.splitFileSets(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, split)
+	require.Equal(t, []string{"bar"}, readTestReshardFileSet(t, fsOpts, 5, prevStart, 1))
+	exists, err := fs.DataFileSetExists(dir, defaultTestNs1ID, 5, blockStart, 1)
+	require.NoError(t, err)
+	require.False(t, exists)
+	exists, err = fs.ShardSplitMarkerExists(dir, defaultTestNs1ID, 5, shardSplit)
+	require.NoError(t, err)
+	require.False(t, exists)
+
+	split, err = ns.splitFileSets(1)
+	require.NoError(t, err)
+	require.Equal(t, 1, split)
+	require.Equal(t, []string{"bar"}, readTestReshardFileSet(t, fsOpts, 5, blockStart, 1))
+	exists, err = fs.ShardSplitMarkerExists(dir, defaultTestNs1ID, 5, shardSplit)
+	require.NoError(t, err)
+	require.True(t, exists)
+
+	split, err = ns.splitFileSets(10)
+	require.NoError(t, err)
+	require.Equal(t, 2, split)
+	require.Equal(t, []string{"foo"}, readTestReshardFileSet(t, fsOpts, 1, prevStart, 1))
+	require.Equal(t, []string{"foo"}, readTestReshardFileSet(t, fsOpts, 1, blockStart, 1))
+}
+
+func TestNamespaceSplitFileSetsChildNotOwned(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ns, fsOpts, closer := newTestReshardNamespace(t, dir, []uint32{1})
+	defer closer()
+
+	blockStart := time.Now().Truncate(defaultTestNs1Opts.RetentionOptions().BlockSize())
+	writeTestReshardFileSet(t, fsOpts, 1, blockStart, "foo", "bar")
+	bootstrapTestReshardShards(t, ns)
+
+	split, err := ns.splitFileSets(10)
+	require.NoError(t, err)
+	require.Equal(t, 0, split)
+
+	// The parent filesets are left as is until all its children are owned.
+	next, err := fs.NextDataFileSetVolumeIndex(dir, defaultTestNs1ID, 1, blockStart)
+	require.NoError(t, err)
+	require.Equal(t, 1, next)
+
+	shardSplit, err := testReshardOpts.ShardSplit()
+	require.NoError(t, err)
+	exists, err := fs.ShardSplitMarkerExists(dir, defaultTestNs1ID, 1, shardSplit)
+	require.NoError(t, err)
+	require.False(t, exists)
+}
+
+func TestNamespaceSeriesReadWriteRefSplitShard(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	ns, _, closer := newTestReshardNamespace(t, dir, []uint32{1, 5})
+	defer closer()
+
+	// Series read from the filesets of the parent shard that belong to
+	// another child of the parent shard are not owned by the parent.
+	_, owned, err := ns.SeriesReadWriteRef(1, ident.StringID("bar"), ident.EmptyTagIterator)
+	require.Equal(t, errNamespaceSeriesNotInShard, err)
+	require.False(t, owned)
+
+	ref, owned, err := ns.SeriesReadWriteRef(5, ident.StringID("bar"), ident.EmptyTagIterator)
+	require.NoError(t, err)
+	require.True(t, owned)
+	require.Equal(t, uint32(5), ref.Shard)
+	ref.ReleaseReadWriteRef.OnReleaseReadWriteRef()
+}
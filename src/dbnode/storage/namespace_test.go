@@ -24,6 +24,7 @@ import (
 	stdlibctx "context"
 	"errors"
 	"fmt"
+	"math"
 	"sync"
 	"testing"
 	"time"
@@ -42,6 +43,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	xmetrics "github.com/m3db/m3/src/dbnode/x/metrics"
 	xidx "github.com/m3db/m3/src/m3ninx/idx"
+	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/context"
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
@@ -118,7 +120,7 @@ func newTestNamespaceWithIndex(
 ) (*dbNamespace, closerFn) {
 	ns, closer := newTestNamespace(t)
 	if index != nil {
-		ns.reverseIndex = index
+		ns.reverseIndex = newNamespaceIndexRef(index)
 	}
 	return ns, closer
 }
@@ -133,7 +135,7 @@ func newTestNamespaceWithTruncateType(
 		SetTruncateType(truncateType)
 
 	ns, closer := newTestNamespaceWithOpts(t, opts)
-	ns.reverseIndex = index
+	ns.reverseIndex = newNamespaceIndexRef(index)
 	return ns, closer
 }
 
@@ -192,7 +194,7 @@ func TestNamespaceWriteShardNotOwned(t *testing.T) {
 		ns.shards[i] = nil
 	}
 	now := time.Now()
-	_, wasWritten, err := ns.Write(ctx, ident.StringID("foo"), now, 0.0, xtime.Second, nil)
+	_, wasWritten, _, err := ns.Write(ctx, ident.StringID("foo"), now, 0.0, xtime.Second, nil)
 	require.Error(t, err)
 	require.True(t, xerrors.IsRetryableError(err))
 	require.Equal(t, "not responsible for shard 0", err.Error())
@@ -221,22 +223,117 @@ func TestNamespaceWriteShardOwned(t *testing.T) {
 			TruncateType: truncateType,
 		}
 		shard.EXPECT().Write(ctx, id, now, val, unit, ant, opts).
-			Return(ts.Series{}, true, nil).Times(1)
+			Return(ts.Series{}, true, series.WriteAccepted, nil).Times(1)
 		shard.EXPECT().Write(ctx, id, now, val, unit, ant, opts).
-			Return(ts.Series{}, false, nil).Times(1)
+			Return(ts.Series{}, false, series.WriteAccepted, nil).Times(1)
 
 		ns.shards[testShardIDs[0].ID()] = shard
 
-		_, wasWritten, err := ns.Write(ctx, id, now, val, unit, ant)
+		_, wasWritten, _, err := ns.Write(ctx, id, now, val, unit, ant)
 		require.NoError(t, err)
 		require.True(t, wasWritten)
 
-		_, wasWritten, err = ns.Write(ctx, id, now, val, unit, ant)
+		_, wasWritten, _, err = ns.Write(ctx, id, now, val, unit, ant)
 		require.NoError(t, err)
 		require.False(t, wasWritten)
 	}
 }
 
+func TestNamespaceWriteValidationRules(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+	ns.validationOpts = namespace.ValidationOptions{
+		RejectNaN:         true,
+		BoundsEnabled:     true,
+		MinValue:          0,
+		MaxValue:          100,
+		MaxAnnotationSize: 2,
+	}
+
+	id := ident.StringID("foo")
+	now := time.Now()
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().Write(ctx, id, now, 1.0, xtime.Second, []byte("a"), gomock.Any()).
+		Return(ts.Series{}, true, series.WriteAccepted, nil)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	_, wasWritten, _, err := ns.Write(ctx, id, now, 1.0, xtime.Second, []byte("a"))
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	// Writes violating a rule never reach the shard.
+	for _, test := range []struct {
+		value      float64
+		annotation []byte
+	}{
+		{value: math.NaN()},
+		{value: -1},
+		{value: 101},
+		{value: 1, annotation: []byte("abc")},
+	} {
+		_, wasWritten, _, err := ns.Write(ctx, id, now, test.value, xtime.Second, test.annotation)
+		require.Error(t, err)
+		require.True(t, xerrors.IsInvalidParams(err))
+		require.False(t, wasWritten)
+	}
+}
+
+func TestNamespaceWriteInterceptors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	var mirrored []float64
+	errRejected := errors.New("rejected")
+	ns.writeInterceptors = []WriteInterceptor{
+		func(_ ident.ID, _ ident.ID, dp ts.Datapoint, _ xtime.Unit, _ []byte) (bool, error) {
+			mirrored = append(mirrored, dp.Value)
+			return true, nil
+		},
+		func(_ ident.ID, _ ident.ID, dp ts.Datapoint, _ xtime.Unit, _ []byte) (bool, error) {
+			if dp.Value < 0 {
+				return false, errRejected
+			}
+			return dp.Value != 0, nil
+		},
+	}
+
+	id := ident.StringID("foo")
+	now := time.Now()
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().Write(ctx, id, now, 1.0, xtime.Second, nil, gomock.Any()).
+		Return(ts.Series{}, true, series.WriteAccepted, nil)
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	_, wasWritten, disposition, err := ns.Write(ctx, id, now, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, series.WriteAccepted, disposition)
+
+	// Dropped writes never reach the shard and do not return an error.
+	_, wasWritten, disposition, err = ns.Write(ctx, id, now, 0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.False(t, wasWritten)
+	require.Equal(t, series.WriteDropped, disposition)
+
+	_, wasWritten, _, err = ns.Write(ctx, id, now, -1, xtime.Second, nil)
+	require.Equal(t, errRejected, err)
+	require.False(t, wasWritten)
+
+	require.Equal(t, []float64{1, 0, -1}, mirrored)
+}
+
 func TestNamespaceReadEncodedShardNotOwned(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
@@ -270,6 +367,7 @@ func TestNamespaceReadEncodedShardOwned(t *testing.T) {
 	ns.shards[testShardIDs[0].ID()] = shard
 
 	shard.EXPECT().IsBootstrapped().Return(true)
+	shard.EXPECT().IsDegraded().Return(false)
 	_, err := ns.ReadEncoded(ctx, id, start, end)
 	require.NoError(t, err)
 
@@ -278,6 +376,14 @@ func TestNamespaceReadEncodedShardOwned(t *testing.T) {
 	require.Error(t, err)
 	require.True(t, xerrors.IsRetryableError(err))
 	require.Equal(t, errShardNotBootstrappedToRead, xerrors.GetInnerRetryableError(err))
+
+	// Reads of a degraded shard are rejected so that replicas serve them.
+	shard.EXPECT().IsBootstrapped().Return(true)
+	shard.EXPECT().IsDegraded().Return(true)
+	_, err = ns.ReadEncoded(ctx, id, start, end)
+	require.Error(t, err)
+	require.True(t, xerrors.IsUnavailableError(err))
+	require.Equal(t, errShardDegraded, xerrors.GetInnerUnavailableError(err))
 }
 
 func TestNamespaceFetchBlocksShardNotOwned(t *testing.T) {
@@ -309,6 +415,7 @@ func TestNamespaceFetchBlocksShardOwned(t *testing.T) {
 	ns.shards[testShardIDs[0].ID()] = shard
 
 	shard.EXPECT().IsBootstrapped().Return(true)
+	shard.EXPECT().IsDegraded().Return(false)
 	res, err := ns.FetchBlocks(ctx, testShardIDs[0].ID(), ident.StringID("foo"), nil)
 	require.NoError(t, err)
 	require.Nil(t, res)
@@ -641,6 +748,7 @@ func TestNamespaceShardAt(t *testing.T) {
 
 	s0 := NewMockdatabaseShard(ctrl)
 	s0.EXPECT().IsBootstrapped().Return(true)
+	s0.EXPECT().IsDegraded().Return(false)
 	ns.shards[0] = s0
 
 	s1 := NewMockdatabaseShard(ctrl)
@@ -659,6 +767,36 @@ func TestNamespaceShardAt(t *testing.T) {
 	require.Equal(t, "not responsible for shard 2", err.Error())
 }
 
+func TestNamespaceShardKeyStrategy(t *testing.T) {
+	shards := sharding.NewShards([]uint32{0, 1, 2, 3, 4, 5, 6, 7}, shard.Available)
+	hashFn := sharding.DefaultHashFn(len(shards))
+	shardSet, err := sharding.NewShardSet(shards, hashFn)
+	require.NoError(t, err)
+
+	opts := defaultTestNs1Opts.
+		SetShardKeyStrategy(sharding.TenantPrefixShardKeyStrategy)
+	metadata := newTestNamespaceMetadataWithIDOpts(t, defaultTestNs1ID, opts)
+	dopts := DefaultTestOptions().SetRuntimeOptionsManager(runtime.NewOptionsManager())
+	defer dopts.RuntimeOptionsManager().Close()
+	oNs, err := newDatabaseNamespace(metadata, shardSet, nil, nil, nil, dopts)
+	require.NoError(t, err)
+	ns := oNs.(*dbNamespace)
+
+	// All series of a tenant are owned by the shard of the tenant, including
+	// after a new shard set is assigned.
+	expected := hashFn(ident.StringID("acme"))
+	for _, id := range []string{"acme:cpu.user", "acme:mem.free"} {
+		shard, _, err := ns.shardFor(ident.StringID(id))
+		require.NoError(t, err)
+		require.Equal(t, expected, shard.ID())
+	}
+
+	ns.AssignShardSet(shardSet)
+	shard, _, err := ns.shardFor(ident.StringID("acme:disk.used"))
+	require.NoError(t, err)
+	require.Equal(t, expected, shard.ID())
+}
+
 func TestNamespaceAssignShardSet(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1082,7 +1220,7 @@ func TestNamespaceIndexInsert(t *testing.T) {
 		idx := NewMocknamespaceIndex(ctrl)
 
 		ns, closer := newTestNamespaceWithTruncateType(t, idx, truncateType)
-		ns.reverseIndex = idx
+		ns.reverseIndex = newNamespaceIndexRef(idx)
 		defer closer()
 
 		ctx := context.NewContext()
@@ -1094,18 +1232,18 @@ func TestNamespaceIndexInsert(t *testing.T) {
 			TruncateType: truncateType,
 		}
 		shard.EXPECT().WriteTagged(ctx, ident.NewIDMatcher("a"), ident.EmptyTagIterator,
-			now, 1.0, xtime.Second, nil, opts).Return(ts.Series{}, true, nil)
+			now, 1.0, xtime.Second, nil, opts).Return(ts.Series{}, true, series.WriteAccepted, nil)
 		shard.EXPECT().WriteTagged(ctx, ident.NewIDMatcher("a"), ident.EmptyTagIterator,
-			now, 1.0, xtime.Second, nil, opts).Return(ts.Series{}, false, nil)
+			now, 1.0, xtime.Second, nil, opts).Return(ts.Series{}, false, series.WriteAccepted, nil)
 
 		ns.shards[testShardIDs[0].ID()] = shard
 
-		_, wasWritten, err := ns.WriteTagged(ctx, ident.StringID("a"),
+		_, wasWritten, _, err := ns.WriteTagged(ctx, ident.StringID("a"),
 			ident.EmptyTagIterator, now, 1.0, xtime.Second, nil)
 		require.NoError(t, err)
 		require.True(t, wasWritten)
 
-		_, wasWritten, err = ns.WriteTagged(ctx, ident.StringID("a"),
+		_, wasWritten, _, err = ns.WriteTagged(ctx, ident.StringID("a"),
 			ident.EmptyTagIterator, now, 1.0, xtime.Second, nil)
 		require.NoError(t, err)
 		require.False(t, wasWritten)
@@ -1116,6 +1254,117 @@ func TestNamespaceIndexInsert(t *testing.T) {
 	}
 }
 
+func TestNamespaceWriteTaggedBackfillTriggersColdFlush(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	now := time.Now()
+
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().ID().Return(testShardIDs[0].ID()).AnyTimes()
+	opts := series.WriteOptions{
+		TruncateType:  series.TypeNone,
+		BackfillWrite: true,
+	}
+	shard.EXPECT().WriteTagged(ctx, ident.NewIDMatcher("a"), ident.EmptyTagIterator,
+		now, 1.0, xtime.Second, nil, opts).Return(ts.Series{}, true, series.WriteAccepted, nil)
+	otherShard := NewMockdatabaseShard(ctrl)
+	otherShard.EXPECT().ID().Return(testShardIDs[1].ID()).AnyTimes()
+	ns.shards[testShardIDs[0].ID()] = shard
+	ns.shards[testShardIDs[1].ID()] = otherShard
+	ns.bootstrapState = Bootstrapped
+
+	// Cold writes are disabled so cold flushes are a no-op until a backfill
+	// write is accepted.
+	require.NoError(t, ns.ColdFlush(nil))
+
+	_, wasWritten, _, err := ns.WriteTaggedBackfill(ctx, ident.StringID("a"),
+		ident.EmptyTagIterator, now, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	shard.EXPECT().ColdFlush(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	otherShard.EXPECT().ColdFlush(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	require.NoError(t, ns.ColdFlush(nil))
+
+	// Subsequent cold flushes are a no-op again.
+	require.NoError(t, ns.ColdFlush(nil))
+}
+
+func TestNamespaceWriteTaggedRelabelsTags(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	idx := NewMocknamespaceIndex(ctrl)
+	ns, closer := newTestNamespaceWithIndex(t, idx)
+	defer closer()
+	ns.relabelOpts = namespace.RelabelOptions{Rules: []namespace.RelabelRule{
+		{Action: namespace.RelabelDrop, Name: "pod"},
+		{Action: namespace.RelabelAdd, Name: "env", Value: "prod"},
+	}}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+	now := time.Now()
+
+	// Series whose tags relabel to the same tags keep their own IDs, and with
+	// them the shards they are routed to, and are written with the same tags.
+	var relabeled []string
+	shard := NewMockdatabaseShard(ctrl)
+	for _, pod := range []string{"foo-1234", "foo-5678"} {
+		shard.EXPECT().WriteTagged(ctx, ident.NewIDMatcher(pod), gomock.Any(),
+			now, 1.0, xtime.Second, nil, gomock.Any()).DoAndReturn(func(
+			_ context.Context,
+			id ident.ID,
+			tags ident.TagIterator,
+			_ time.Time,
+			_ float64,
+			_ xtime.Unit,
+			_ []byte,
+			_ series.WriteOptions,
+		) (ts.Series, bool, series.WriteDisposition, error) {
+			relabeled = relabeled[:0]
+			for tags.Next() {
+				tag := tags.Current()
+				relabeled = append(relabeled, tag.Name.String()+"="+tag.Value.String())
+			}
+			return ts.Series{ID: id}, true, series.WriteAccepted, tags.Err()
+		})
+		ns.shards[ns.shardSet.Lookup(ident.StringID(pod))] = shard
+	}
+
+	for _, pod := range []string{"foo-1234", "foo-5678"} {
+		tags := ident.NewTagsIterator(ident.NewTags(
+			ident.StringTag("app", "foo"),
+			ident.StringTag("pod", pod),
+		))
+		written, wasWritten, _, err := ns.WriteTagged(ctx, ident.StringID(pod), tags,
+			now, 1.0, xtime.Second, nil)
+		require.NoError(t, err)
+		require.True(t, wasWritten)
+		require.Equal(t, pod, written.ID.String())
+		require.Equal(t, []string{"app=foo", "env=prod"}, relabeled)
+
+		// The commit log is written the relabeled tags.
+		iter := ns.opts.CommitLogOptions().FilesystemOptions().TagDecoderPool().Get()
+		iter.Reset(checked.NewBytes(written.EncodedTags, nil))
+		var encoded []string
+		for iter.Next() {
+			tag := iter.Current()
+			encoded = append(encoded, tag.Name.String()+"="+tag.Value.String())
+		}
+		require.NoError(t, iter.Err())
+		iter.Close()
+		require.Equal(t, []string{"app=foo", "env=prod"}, encoded)
+	}
+}
+
 func TestNamespaceIndexQuery(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -1189,6 +1438,8 @@ func TestNamespaceTicksIndex(t *testing.T) {
 
 	ctx := context.NewCancellable()
 	idx.EXPECT().Tick(ctx, gomock.Any()).Return(namespaceIndexTickResult{}, nil)
+	idx.EXPECT().PurgeDocuments(ctx, gomock.Any(), gomock.Any()).
+		Return(namespaceIndexPurgeResult{}, nil)
 	err := ns.Tick(ctx, time.Now())
 	require.NoError(t, err)
 }
@@ -32,11 +32,13 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/retention"
 	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/index"
@@ -51,6 +53,8 @@ import (
 	"github.com/m3db/m3/src/x/mmap"
 	"github.com/m3db/m3/src/x/pool"
 	xsync "github.com/m3db/m3/src/x/sync"
+
+	"github.com/uber-go/tally"
 )
 
 const (
@@ -75,6 +79,10 @@ const (
 	// defaultNumLoadedBytesLimit is the default limit (2GiB) for the number of outstanding loaded bytes that
 	// the memory tracker will allow.
 	defaultNumLoadedBytesLimit = 2 << 30
+
+	// defaultFileSetMigrationBlocksPerFlush is the default number of blocks
+	// with filesets written in an older format version migrated per flush.
+	defaultFileSetMigrationBlocksPerFlush = 4
 )
 
 var (
@@ -93,6 +101,11 @@ var (
 	errIndexOptionsNotSet         = errors.New("index enabled but index options are not set")
 	errPersistManagerNotSet       = errors.New("persist manager is not set")
 	errBlockLeaserNotSet          = errors.New("block leaser is not set")
+
+	errSnapshotCompactionMinVolumesInvalid = errors.New(
+		"snapshot compaction min volumes must be zero or at least two")
+	errFileSetMigrationBlocksPerFlushInvalid = errors.New(
+		"fileset migration blocks per flush must not be negative")
 )
 
 // NewSeriesOptionsFromOptions creates a new set of database series options from provided options.
@@ -157,7 +170,16 @@ type options struct {
 	schemaReg                      namespace.SchemaRegistry
 	blockLeaseManager              block.LeaseManager
 	memoryTracker                  MemoryTracker
+	snapshotTracker                SnapshotTracker
+	snapshotCompactionMinVolumes   int
+	fileSetMigrationBlocksPerFlush int
+	tickLoadMonitor                TickLoadMonitor
+	backgroundScheduler            background.Scheduler
+	purgeReporter                  PurgeReporter
+	blockExpiryHooks               []BlockExpiryHook
+	writeInterceptors              []WriteInterceptor
 	mmapReporter                   mmap.Reporter
+	notifier                       notify.Notifier
 }
 
 // NewOptions creates a new set of storage options with defaults
@@ -228,6 +250,12 @@ func newOptions(poolOpts pool.ObjectPoolOptions) Options {
 		checkedBytesWrapperPool:        bytesWrapperPool,
 		schemaReg:                      namespace.NewSchemaRegistry(false, nil),
 		memoryTracker:                  NewMemoryTracker(NewMemoryTrackerOptions(defaultNumLoadedBytesLimit)),
+		snapshotTracker:                NewSnapshotTracker(NewSnapshotTrackerOptions(0, 0, 0)),
+		fileSetMigrationBlocksPerFlush: defaultFileSetMigrationBlocksPerFlush,
+		tickLoadMonitor:                NewTickLoadMonitor(time.Now),
+		backgroundScheduler:            background.NewNoopScheduler(),
+		purgeReporter:                  NewPurgeReporter(tally.NoopScope),
+		notifier:                       notify.NewNoopNotifier(),
 	}
 	return o.SetEncodingM3TSZPooled()
 }
@@ -281,6 +309,14 @@ func (o *options) Validate() error {
 		return errBlockLeaserNotSet
 	}
 
+	if v := o.snapshotCompactionMinVolumes; v < 0 || v == 1 {
+		return errSnapshotCompactionMinVolumesInvalid
+	}
+
+	if o.fileSetMigrationBlocksPerFlush < 0 {
+		return errFileSetMigrationBlocksPerFlushInvalid
+	}
+
 	return nil
 }
 
@@ -746,6 +782,87 @@ func (o *options) MemoryTracker() MemoryTracker {
 	return o.memoryTracker
 }
 
+func (o *options) SetSnapshotTracker(snapshotTracker SnapshotTracker) Options {
+	opts := *o
+	opts.snapshotTracker = snapshotTracker
+	return &opts
+}
+
+func (o *options) SnapshotTracker() SnapshotTracker {
+	return o.snapshotTracker
+}
+
+func (o *options) SetSnapshotCompactionMinVolumes(value int) Options {
+	opts := *o
+	opts.snapshotCompactionMinVolumes = value
+	return &opts
+}
+
+func (o *options) SnapshotCompactionMinVolumes() int {
+	return o.snapshotCompactionMinVolumes
+}
+
+func (o *options) SetFileSetMigrationBlocksPerFlush(value int) Options {
+	opts := *o
+	opts.fileSetMigrationBlocksPerFlush = value
+	return &opts
+}
+
+func (o *options) FileSetMigrationBlocksPerFlush() int {
+	return o.fileSetMigrationBlocksPerFlush
+}
+
+func (o *options) SetTickLoadMonitor(value TickLoadMonitor) Options {
+	opts := *o
+	opts.tickLoadMonitor = value
+	return &opts
+}
+
+func (o *options) TickLoadMonitor() TickLoadMonitor {
+	return o.tickLoadMonitor
+}
+
+func (o *options) SetBackgroundScheduler(value background.Scheduler) Options {
+	opts := *o
+	opts.backgroundScheduler = value
+	opts.indexOpts = opts.indexOpts.SetBackgroundScheduler(value)
+	return &opts
+}
+
+func (o *options) BackgroundScheduler() background.Scheduler {
+	return o.backgroundScheduler
+}
+
+func (o *options) SetPurgeReporter(value PurgeReporter) Options {
+	opts := *o
+	opts.purgeReporter = value
+	return &opts
+}
+
+func (o *options) PurgeReporter() PurgeReporter {
+	return o.purgeReporter
+}
+
+func (o *options) SetBlockExpiryHooks(value []BlockExpiryHook) Options {
+	opts := *o
+	opts.blockExpiryHooks = value
+	return &opts
+}
+
+func (o *options) BlockExpiryHooks() []BlockExpiryHook {
+	return o.blockExpiryHooks
+}
+
+func (o *options) SetWriteInterceptors(value []WriteInterceptor) Options {
+	opts := *o
+	opts.writeInterceptors = value
+	return &opts
+}
+
+func (o *options) WriteInterceptors() []WriteInterceptor {
+	return o.writeInterceptors
+}
+
 func (o *options) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 	opts := *o
 	opts.mmapReporter = mmapReporter
@@ -755,3 +872,13 @@ func (o *options) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 func (o *options) MmapReporter() mmap.Reporter {
 	return o.mmapReporter
 }
+
+func (o *options) SetNotifier(value notify.Notifier) Options {
+	opts := *o
+	opts.notifier = value
+	return &opts
+}
+
+func (o *options) Notifier() notify.Notifier {
+	return o.notifier
+}
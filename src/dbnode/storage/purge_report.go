@@ -0,0 +1,230 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+// PurgedFileType is the type of a file removed by cleanup.
+type PurgedFileType int
+
+const (
+	// PurgedDataFileSet is a data fileset file.
+	PurgedDataFileSet PurgedFileType = iota
+	// PurgedIndexFileSet is an index fileset (segment) file.
+	PurgedIndexFileSet
+	// PurgedSnapshot is a snapshot or snapshot metadata file.
+	PurgedSnapshot
+	// PurgedCommitLog is a commit log file.
+	PurgedCommitLog
+)
+
+func (t PurgedFileType) String() string {
+	switch t {
+	case PurgedDataFileSet:
+		return "data-fileset"
+	case PurgedIndexFileSet:
+		return "index-fileset"
+	case PurgedSnapshot:
+		return "snapshot"
+	case PurgedCommitLog:
+		return "commitlog"
+	}
+	return "unknown"
+}
+
+// PurgedFile is a file removed by cleanup.
+type PurgedFile struct {
+	Path  string `json:"path"`
+	Bytes int64  `json:"bytes"`
+}
+
+// PurgeReport is the report of the files removed by a single cleanup.
+type PurgeReport struct {
+	StartTime      time.Time    `json:"startTime"`
+	EndTime        time.Time    `json:"endTime"`
+	DataFileSets   []PurgedFile `json:"dataFileSets"`
+	IndexFileSets  []PurgedFile `json:"indexFileSets"`
+	Snapshots      []PurgedFile `json:"snapshots"`
+	CommitLogs     []PurgedFile `json:"commitLogs"`
+	BytesReclaimed int64        `json:"bytesReclaimed"`
+}
+
+func (r *PurgeReport) add(fileType PurgedFileType, files []PurgedFile) {
+	switch fileType {
+	case PurgedDataFileSet:
+		r.DataFileSets = append(r.DataFileSets, files...)
+	case PurgedIndexFileSet:
+		r.IndexFileSets = append(r.IndexFileSets, files...)
+	case PurgedSnapshot:
+		r.Snapshots = append(r.Snapshots, files...)
+	case PurgedCommitLog:
+		r.CommitLogs = append(r.CommitLogs, files...)
+	}
+	for _, f := range files {
+		r.BytesReclaimed += f.Bytes
+	}
+}
+
+type purgeReporterMetrics struct {
+	files map[PurgedFileType]tally.Counter
+	bytes map[PurgedFileType]tally.Counter
+}
+
+func newPurgeReporterMetrics(scope tally.Scope) purgeReporterMetrics {
+	m := purgeReporterMetrics{
+		files: make(map[PurgedFileType]tally.Counter),
+		bytes: make(map[PurgedFileType]tally.Counter),
+	}
+	for _, t := range []PurgedFileType{
+		PurgedDataFileSet, PurgedIndexFileSet, PurgedSnapshot, PurgedCommitLog,
+	} {
+		tagged := scope.Tagged(map[string]string{"type": t.String()})
+		m.files[t] = tagged.Counter("files")
+		m.bytes[t] = tagged.Counter("bytes")
+	}
+	return m
+}
+
+type purgeReporter struct {
+	sync.RWMutex
+
+	current *PurgeReport
+	last    PurgeReport
+	hasLast bool
+	metrics purgeReporterMetrics
+}
+
+// NewPurgeReporter returns a new purge reporter.
+func NewPurgeReporter(scope tally.Scope) PurgeReporter {
+	return &purgeReporter{
+		metrics: newPurgeReporterMetrics(scope.SubScope("purge")),
+	}
+}
+
+func (r *purgeReporter) Start(t time.Time) {
+	r.Lock()
+	r.current = &PurgeReport{StartTime: t}
+	r.Unlock()
+}
+
+func (r *purgeReporter) RecordPurged(fileType PurgedFileType, files []PurgedFile) {
+	if len(files) == 0 {
+		return
+	}
+
+	r.Lock()
+	if r.current != nil {
+		r.current.add(fileType, files)
+	}
+	r.Unlock()
+
+	var bytes int64
+	for _, f := range files {
+		bytes += f.Bytes
+	}
+	r.metrics.files[fileType].Inc(int64(len(files)))
+	r.metrics.bytes[fileType].Inc(bytes)
+}
+
+func (r *purgeReporter) Finish(t time.Time) {
+	r.Lock()
+	defer r.Unlock()
+
+	if r.current == nil {
+		return
+	}
+	r.current.EndTime = t
+	r.last = *r.current
+	r.hasLast = true
+	r.current = nil
+}
+
+func (r *purgeReporter) LastReport() (PurgeReport, bool) {
+	r.RLock()
+	defer r.RUnlock()
+	return r.last, r.hasLast
+}
+
+type purgedFileTypeFn func(path string) PurgedFileType
+
+func purgedFileTypeOf(fileType PurgedFileType) purgedFileTypeFn {
+	return func(string) PurgedFileType {
+		return fileType
+	}
+}
+
+// purgedCommitLogOrSnapshot returns the type of files removed alongside
+// commit logs which are either commit logs or snapshots.
+func purgedCommitLogOrSnapshot(commitLogsDir string) purgedFileTypeFn {
+	return func(path string) PurgedFileType {
+		if strings.HasPrefix(path, commitLogsDir) {
+			return PurgedCommitLog
+		}
+		return PurgedSnapshot
+	}
+}
+
+// newPurgeRecordingDeleteFilesFn returns a deleteFilesFn that records the
+// files that were removed by the wrapped deleteFilesFn with the reporter.
+func newPurgeRecordingDeleteFilesFn(
+	reporter PurgeReporter,
+	fileTypeFn purgedFileTypeFn,
+	deleteFn deleteFilesFn,
+) deleteFilesFn {
+	return func(files []string) error {
+		if len(files) == 0 {
+			return deleteFn(files)
+		}
+
+		sizes := make([]int64, len(files))
+		for i, f := range files {
+			if info, err := os.Stat(f); err == nil {
+				sizes[i] = info.Size()
+			}
+		}
+
+		err := deleteFn(files)
+
+		// NB: Only record the files that no longer exist since deletion
+		// is best effort and may have only partially succeeded.
+		purged := make(map[PurgedFileType][]PurgedFile)
+		for i, f := range files {
+			if _, statErr := os.Stat(f); !os.IsNotExist(statErr) {
+				continue
+			}
+			fileType := fileTypeFn(f)
+			purged[fileType] = append(purged[fileType], PurgedFile{
+				Path:  f,
+				Bytes: sizes[i],
+			})
+		}
+		for fileType, files := range purged {
+			reporter.RecordPurged(fileType, files)
+		}
+		return err
+	}
+}
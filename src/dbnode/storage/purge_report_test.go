@@ -0,0 +1,101 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/persist/fs"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestPurgeReporterRecordsDeletedFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "purge-report")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		commitLogsDir = fs.CommitLogsDirPath(dir)
+		snapshotsDir  = fs.SnapshotsDirPath(dir)
+		commitLog     = path.Join(commitLogsDir, "commitlog-0-0.db")
+		snapshot      = path.Join(snapshotsDir, "snapshot-0.db")
+		missing       = path.Join(snapshotsDir, "missing.db")
+	)
+	require.NoError(t, os.MkdirAll(commitLogsDir, 0755))
+	require.NoError(t, os.MkdirAll(snapshotsDir, 0755))
+	require.NoError(t, ioutil.WriteFile(commitLog, make([]byte, 10), 0644))
+	require.NoError(t, ioutil.WriteFile(snapshot, make([]byte, 5), 0644))
+
+	scope := tally.NewTestScope("", nil)
+	reporter := NewPurgeReporter(scope)
+	_, ok := reporter.LastReport()
+	require.False(t, ok)
+
+	start := time.Now()
+	reporter.Start(start)
+	deleteFn := newPurgeRecordingDeleteFilesFn(reporter,
+		purgedCommitLogOrSnapshot(commitLogsDir), fs.DeleteFiles)
+	require.Error(t, deleteFn([]string{commitLog, snapshot, missing}))
+	end := start.Add(time.Second)
+	reporter.Finish(end)
+
+	report, ok := reporter.LastReport()
+	require.True(t, ok)
+	require.Equal(t, PurgeReport{
+		StartTime:      start,
+		EndTime:        end,
+		Snapshots:      []PurgedFile{{Path: snapshot, Bytes: 5}},
+		CommitLogs:     []PurgedFile{{Path: commitLog, Bytes: 10}},
+		BytesReclaimed: 15,
+	}, report)
+
+	counters := scope.Snapshot().Counters()
+	require.Equal(t, int64(1), counters["purge.files+type=commitlog"].Value())
+	require.Equal(t, int64(10), counters["purge.bytes+type=commitlog"].Value())
+	require.Equal(t, int64(5), counters["purge.bytes+type=snapshot"].Value())
+}
+
+func TestPurgeReporterOnlyReportsFinishedCleanups(t *testing.T) {
+	reporter := NewPurgeReporter(tally.NoopScope)
+	files := []PurgedFile{{Path: "a", Bytes: 1}}
+
+	// Files recorded outside of a cleanup are not reported.
+	reporter.RecordPurged(PurgedDataFileSet, files)
+	reporter.Finish(time.Now())
+	_, ok := reporter.LastReport()
+	require.False(t, ok)
+
+	reporter.Start(time.Now())
+	reporter.RecordPurged(PurgedIndexFileSet, files)
+	_, ok = reporter.LastReport()
+	require.False(t, ok)
+
+	reporter.Finish(time.Now())
+	report, ok := reporter.LastReport()
+	require.True(t, ok)
+	require.Equal(t, files, report.IndexFileSets)
+	require.Equal(t, int64(1), report.BytesReclaimed)
+}
@@ -25,6 +25,7 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"sort"
 	"strconv"
 	"sync"
 	"sync/atomic"
@@ -33,7 +34,9 @@ import (
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/retention"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -64,6 +67,7 @@ type shardRepairer struct {
 	rpopts   repair.Options
 	clients  []client.AdminClient
 	recordFn recordFn
+	history  *repairHistory
 	logger   *zap.Logger
 	scope    tally.Scope
 	nowFn    clock.NowFn
@@ -77,6 +81,7 @@ func newShardRepairer(opts Options, rpopts repair.Options) databaseShardRepairer
 		opts:    opts,
 		rpopts:  rpopts,
 		clients: rpopts.AdminClients(),
+		history: newRepairHistory(rpopts.HistorySize()),
 		logger:  iopts.Logger(),
 		scope:   scope,
 		nowFn:   opts.ClockOptions().NowFn(),
@@ -90,12 +95,48 @@ func (r shardRepairer) Options() repair.Options {
 	return r.rpopts
 }
 
+func (r shardRepairer) History(namespace ident.ID) []BlockRepairHistory {
+	return r.history.blocks(namespace)
+}
+
 func (r shardRepairer) Repair(
 	ctx context.Context,
 	nsCtx namespace.Context,
 	nsMeta namespace.Metadata,
 	tr xtime.Range,
 	shard databaseShard,
+) (repair.MetadataComparisonResult, error) {
+	var (
+		start   = r.nowFn()
+		outcome = RepairOutcome{Time: start, Action: RepairActionNone}
+	)
+	metadataRes, err := r.repair(ctx, nsCtx, nsMeta, tr, shard, &outcome)
+	outcome.Duration = r.nowFn().Sub(start)
+	if err != nil {
+		outcome.Action = RepairActionFailed
+		outcome.Error = err.Error()
+	} else {
+		outcome.NumSeries = metadataRes.NumSeries
+		outcome.NumBlocks = metadataRes.NumBlocks
+		outcome.SizeDiffSeries = metadataRes.SizeDifferences.NumSeries()
+		outcome.SizeDiffBlocks = metadataRes.SizeDifferences.NumBlocks()
+		outcome.ChecksumDiffSeries = metadataRes.ChecksumDifferences.NumSeries()
+		outcome.ChecksumDiffBlocks = metadataRes.ChecksumDifferences.NumBlocks()
+	}
+
+	expireBefore := retention.FlushTimeStart(nsMeta.Options().RetentionOptions(), start)
+	r.history.record(nsCtx.ID, shard.ID(), tr.Start, outcome, expireBefore)
+
+	return metadataRes, err
+}
+
+func (r shardRepairer) repair(
+	ctx context.Context,
+	nsCtx namespace.Context,
+	nsMeta namespace.Metadata,
+	tr xtime.Range,
+	shard databaseShard,
+	outcome *RepairOutcome,
 ) (repair.MetadataComparisonResult, error) {
 	var sessions []sessionAndTopo
 	for _, c := range r.clients {
@@ -262,6 +303,27 @@ func (r shardRepairer) Repair(
 		}
 	}
 
+	peers := make(map[string]struct{})
+	for _, metadatasToFetchBlocksFor := range metadatasToFetchBlocksForPerSession {
+		for _, replicaMetadata := range metadatasToFetchBlocksFor {
+			peers[replicaMetadata.Host.ID()] = struct{}{}
+		}
+	}
+	for peer := range peers {
+		outcome.Peers = append(outcome.Peers, peer)
+	}
+	sort.Strings(outcome.Peers)
+
+	if r.rpopts.Type() == repair.CompareOnlyRepair {
+		// Only record the differences, the diverging blocks are not repaired.
+		if len(peers) > 0 {
+			outcome.Action = RepairActionCompared
+		}
+		r.recordFn(nsCtx.ID, shard, metadataRes)
+		r.notifyDivergence(nsCtx.ID, shard, tr, metadataRes)
+		return metadataRes, nil
+	}
+
 	// TODO(rartoul): Copying the IDs for the purposes of the map key is wasteful. Considering using
 	// SetUnsafe or marking as NoFinalize() and making the map check IsNoFinalize().
 	numMismatchSeries := seriesWithChecksumMismatches.Len()
@@ -293,12 +355,48 @@ func (r shardRepairer) Repair(
 	if err := r.loadDataIntoShard(shard, results); err != nil {
 		return repair.MetadataComparisonResult{}, err
 	}
+	if len(peers) > 0 {
+		outcome.Action = RepairActionLoaded
+	}
 
 	r.recordFn(nsCtx.ID, shard, metadataRes)
+	r.notifyDivergence(nsCtx.ID, shard, tr, metadataRes)
 
 	return metadataRes, nil
 }
 
+func (r shardRepairer) notifyDivergence(
+	namespace ident.ID,
+	shard databaseShard,
+	tr xtime.Range,
+	diffRes repair.MetadataComparisonResult,
+) {
+	var (
+		sizeDiffBlocks     = diffRes.SizeDifferences.NumBlocks()
+		checksumDiffBlocks = diffRes.ChecksumDifferences.NumBlocks()
+		divergentBlocks    = sizeDiffBlocks + checksumDiffBlocks
+	)
+	if divergentBlocks == 0 || divergentBlocks <= r.rpopts.DivergenceNotifyThreshold() {
+		return
+	}
+
+	r.opts.Notifier().Notify(notify.Event{
+		Type:      notify.EventTypeRepairDivergence,
+		Time:      r.nowFn(),
+		Namespace: namespace.String(),
+		Message:   "repair found divergent blocks between replicas",
+		Fields: map[string]interface{}{
+			"shard":              shard.ID(),
+			"rangeStart":         tr.Start,
+			"rangeEnd":           tr.End,
+			"numSeries":          diffRes.NumSeries,
+			"numBlocks":          diffRes.NumBlocks,
+			"sizeDiffBlocks":     sizeDiffBlocks,
+			"checksumDiffBlocks": checksumDiffBlocks,
+		},
+	})
+}
+
 // TODO(rartoul): Currently throttling via the MemoryTracker can only occur at the level of an entire
 // block for a given namespace/shard/blockStart. For almost all practical use-cases this is fine, but
 // this could be improved and made more granular by breaking data that is being loaded into the shard
@@ -443,13 +541,15 @@ func (r repairStatesByNs) setRepairState(
 // NB(prateek): dbRepairer.Repair(...) guarantees atomicity of execution, so all other
 // state does not need to be thread safe. One exception - `dbRepairer.closed` is used
 // for early termination if `dbRepairer.Stop()` is called during a repair, so we guard
-// it with a mutex.
+// it with a mutex. The other is `dbRepairer.repairStatesByNs` which is read when
+// computing repair watermarks, so writes to it are guarded by `statesLock`.
 type dbRepairer struct {
 	database         database
 	opts             Options
 	ropts            repair.Options
 	shardRepairer    databaseShardRepairer
 	repairStatesByNs repairStatesByNs
+	ownedShardsByNs  map[string]map[uint32]struct{}
 
 	repairFn            repairFn
 	sleepFn             sleepFn
@@ -459,6 +559,7 @@ type dbRepairer struct {
 	scope               tally.Scope
 	status              tally.Gauge
 
+	statesLock sync.RWMutex
 	closedLock sync.Mutex
 	running    int32
 	closed     bool
@@ -485,7 +586,8 @@ func newDatabaseRepairer(database database, opts Options) (databaseRepairer, err
 		ropts:               ropts,
 		shardRepairer:       shardRepairer,
 		repairStatesByNs:    newRepairStates(),
-		sleepFn:             time.Sleep,
+		ownedShardsByNs:     make(map[string]map[uint32]struct{}),
+		sleepFn:             opts.ClockOptions().Scheduler().Sleep,
 		nowFn:               nowFn,
 		logger:              opts.InstrumentOptions().Logger(),
 		repairCheckInterval: ropts.RepairCheckInterval(),
@@ -509,7 +611,7 @@ func (r *dbRepairer) run() {
 
 		r.sleepFn(r.repairCheckInterval)
 
-		if err := r.repairFn(); err != nil {
+		if err := runBackgroundWork(r.opts.BackgroundScheduler(), background.RepairWork, r.repairFn); err != nil {
 			r.logger.Error("error repairing database", zap.Error(err))
 		}
 	}
@@ -518,10 +620,17 @@ func (r *dbRepairer) run() {
 func (r *dbRepairer) namespaceRepairTimeRange(ns databaseNamespace) xtime.Range {
 	var (
 		now    = r.nowFn()
-		rtopts = ns.Options().RetentionOptions()
+		nsOpts = ns.Options()
+		rtopts = nsOpts.RetentionOptions()
+		start  = retention.FlushTimeStart(rtopts, now)
 	)
+	// Archived blocks are immutable so repairs must not write into them.
+	immutableBefore, ok := nsOpts.ArchivalOptions().ImmutableBefore(rtopts.BlockSize(), now)
+	if ok && immutableBefore.After(start) {
+		start = immutableBefore
+	}
 	return xtime.Range{
-		Start: retention.FlushTimeStart(rtopts, now),
+		Start: start,
 		End:   retention.FlushTimeEnd(rtopts, now)}
 }
 
@@ -569,6 +678,8 @@ func (r *dbRepairer) Repair() error {
 	}
 
 	for _, n := range namespaces {
+		r.resetRepairStatesOnAcquiredShards(n)
+
 		repairRange := r.namespaceRepairTimeRange(n)
 		blockSize := n.Options().RetentionOptions().BlockSize()
 
@@ -583,6 +694,11 @@ func (r *dbRepairer) Repair() error {
 			leastRecentlyRepairedBlockStartLastRepairTime time.Time
 		)
 		repairRange.IterateBackward(blockSize, func(blockStart time.Time) bool {
+			if n.IsFrozen(blockStart, blockStart.Add(blockSize)) {
+				// Frozen blocks must be preserved as is so are not repaired.
+				return true
+			}
+
 			repairState, ok := r.repairStatesByNs.repairStates(n.ID(), blockStart)
 			if ok && (leastRecentlyRepairedBlockStart.IsZero() ||
 				repairState.LastAttempt.Before(leastRecentlyRepairedBlockStartLastRepairTime)) {
@@ -641,6 +757,40 @@ func (r *dbRepairer) Repair() error {
 	return multiErr.FinalError()
 }
 
+// resetRepairStatesOnAcquiredShards clears the repair states of a namespace
+// when the node acquires shards it did not own before, such as when a replica
+// is added to the cluster, so that every block is repaired again until the
+// new replica has converged with its peers.
+func (r *dbRepairer) resetRepairStatesOnAcquiredShards(n databaseNamespace) {
+	var (
+		nsID             = n.ID().String()
+		prevOwned, found = r.ownedShardsByNs[nsID]
+		shards           = n.GetOwnedShards()
+		owned            = make(map[uint32]struct{}, len(shards))
+		acquired         = false
+	)
+	for _, shard := range shards {
+		owned[shard.ID()] = struct{}{}
+		if _, ok := prevOwned[shard.ID()]; !ok {
+			acquired = true
+		}
+	}
+	r.ownedShardsByNs[nsID] = owned
+
+	if !found || !acquired {
+		return
+	}
+
+	r.logger.Info("acquired shards, resetting repair states",
+		zap.String("namespace", nsID))
+	r.statesLock.Lock()
+	delete(r.repairStatesByNs, nsID)
+	r.statesLock.Unlock()
+	r.scope.Tagged(map[string]string{
+		"namespace": nsID,
+	}).Counter("repair-states-reset").Inc(1)
+}
+
 func (r *dbRepairer) Report() {
 	if atomic.LoadInt32(&r.running) == 1 {
 		r.status.Update(1)
@@ -677,10 +827,41 @@ func (r *dbRepairer) markRepairAttempt(
 	blockStart time.Time,
 	repairTime time.Time,
 	repairStatus repairStatus) {
+	r.statesLock.Lock()
 	repairState, _ := r.repairStatesByNs.repairStates(namespace, blockStart)
 	repairState.Status = repairStatus
 	repairState.LastAttempt = repairTime
 	r.repairStatesByNs.setRepairState(namespace, blockStart, repairState)
+	r.statesLock.Unlock()
+}
+
+// RepairedTo returns the end of the contiguous run of blocks, starting from
+// the beginning of the repairable range, that have been successfully repaired.
+// Frozen blocks are never repaired and so count as repaired.
+func (r *dbRepairer) RepairHistory(n databaseNamespace) []BlockRepairHistory {
+	return r.shardRepairer.History(n.ID())
+}
+
+func (r *dbRepairer) RepairedTo(n databaseNamespace) (time.Time, bool) {
+	var (
+		repairRange = r.namespaceRepairTimeRange(n)
+		blockSize   = n.Options().RetentionOptions().BlockSize()
+		repairedTo  time.Time
+	)
+	r.statesLock.RLock()
+	defer r.statesLock.RUnlock()
+
+	for blockStart := repairRange.Start; !blockStart.After(repairRange.End); blockStart = blockStart.Add(blockSize) {
+		blockEnd := blockStart.Add(blockSize)
+		if !n.IsFrozen(blockStart, blockEnd) {
+			repairState, ok := r.repairStatesByNs.repairStates(n.ID(), blockStart)
+			if !ok || repairState.Status != repairSuccess {
+				break
+			}
+		}
+		repairedTo = blockEnd
+	}
+	return repairedTo, true
 }
 
 var noOpRepairer databaseRepairer = repairerNoOp{}
@@ -694,6 +875,14 @@ func (r repairerNoOp) Stop()         {}
 func (r repairerNoOp) Repair() error { return nil }
 func (r repairerNoOp) Report()       {}
 
+func (r repairerNoOp) RepairedTo(n databaseNamespace) (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (r repairerNoOp) RepairHistory(n databaseNamespace) []BlockRepairHistory {
+	return nil
+}
+
 func (r shardRepairer) shadowCompare(
 	start time.Time,
 	end time.Time,
@@ -0,0 +1,266 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package repair
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/x/ident"
+	xsync "github.com/m3db/m3/src/x/sync"
+)
+
+var (
+	errConsistencyCheckInvalidRange = errors.New("consistency check start must be before end")
+)
+
+type consistencyChecker struct {
+	client client.AdminClient
+	opts   Options
+}
+
+// NewConsistencyChecker creates a new consistency checker that compares the
+// block metadata of every replica using the given admin client. The admin
+// client should not be created with an origin that owns shards since the
+// origin host is excluded when fetching metadata from peers.
+func NewConsistencyChecker(
+	adminClient client.AdminClient,
+	opts Options,
+) (ConsistencyChecker, error) {
+	opts = opts.SetAdminClients([]client.AdminClient{adminClient})
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+	return &consistencyChecker{
+		client: adminClient,
+		opts:   opts,
+	}, nil
+}
+
+func (c *consistencyChecker) Check(
+	namespace ident.ID,
+	start, end time.Time,
+) (ConsistencyReport, error) {
+	if !start.Before(end) {
+		return ConsistencyReport{}, errConsistencyCheckInvalidRange
+	}
+
+	session, err := c.client.DefaultAdminSession()
+	if err != nil {
+		return ConsistencyReport{}, fmt.Errorf("error obtaining default admin session: %v", err)
+	}
+	topoMap, err := session.TopologyMap()
+	if err != nil {
+		return ConsistencyReport{}, fmt.Errorf("error obtaining topology map: %v", err)
+	}
+
+	var (
+		shards  = topoMap.ShardSet().AllIDs()
+		reports = make([]ShardConsistencyReport, len(shards))
+		workers = xsync.NewWorkerPool(c.opts.RepairShardConcurrency())
+		wg      sync.WaitGroup
+	)
+	workers.Init()
+	for i, shard := range shards {
+		i, shard := i, shard
+		wg.Add(1)
+		workers.Go(func() {
+			defer wg.Done()
+			reports[i] = c.checkShard(session, topoMap, namespace, shard, start, end)
+		})
+	}
+	wg.Wait()
+
+	sort.Slice(reports, func(i, j int) bool {
+		return reports[i].Shard < reports[j].Shard
+	})
+
+	report := ConsistencyReport{
+		Namespace: namespace,
+		Start:     start,
+		End:       end,
+		Shards:    reports,
+	}
+	for _, shardReport := range reports {
+		report.NumSeries += shardReport.NumSeries
+		report.NumBlocks += shardReport.NumBlocks
+		report.NumDifferences += int64(len(shardReport.Differences))
+	}
+
+	return report, nil
+}
+
+func (c *consistencyChecker) checkShard(
+	session client.AdminSession,
+	topoMap topology.Map,
+	namespace ident.ID,
+	shard uint32,
+	start, end time.Time,
+) ShardConsistencyReport {
+	report := ShardConsistencyReport{Shard: shard}
+
+	// The session never fetches metadata from its own origin, so the origin
+	// is not expected to be one of the replicas compared.
+	origin := session.Origin()
+	err := topoMap.RouteShardForEach(shard, func(_ int, host topology.Host) {
+		if origin != nil && origin.ID() == host.ID() {
+			return
+		}
+		report.Replicas = append(report.Replicas, host.ID())
+	})
+	if err != nil {
+		report.Err = err
+		return report
+	}
+	sort.Strings(report.Replicas)
+
+	peerIter, err := session.FetchBlocksMetadataFromPeers(namespace, shard,
+		start, end, c.opts.RepairConsistencyLevel(), c.opts.ResultOptions())
+	if err != nil {
+		report.Err = err
+		return report
+	}
+
+	var (
+		pool     = c.opts.ReplicaMetadataSlicePool()
+		metadata = NewReplicaSeriesMetadata()
+	)
+	defer metadata.Close()
+
+	for peerIter.Next() {
+		peer, peerBlock := peerIter.Current()
+		blocks := metadata.GetOrAdd(peerBlock.ID)
+		blocks.GetOrAdd(peerBlock.Start, pool).Add(block.ReplicaMetadata{
+			Host:     peer,
+			Metadata: peerBlock,
+		})
+	}
+	if err := peerIter.Err(); err != nil {
+		report.Err = err
+		return report
+	}
+
+	report.NumSeries = metadata.NumSeries()
+	report.NumBlocks = metadata.NumBlocks()
+	for _, entry := range metadata.Series().Iter() {
+		series := entry.Value()
+		for _, b := range series.Metadata.Blocks() {
+			if diff, ok := compareReplicas(series.ID, b, report.Replicas); ok {
+				report.Differences = append(report.Differences, diff)
+			}
+		}
+	}
+
+	sort.Slice(report.Differences, func(i, j int) bool {
+		a, b := report.Differences[i], report.Differences[j]
+		if a.ID != b.ID {
+			return a.ID < b.ID
+		}
+		return a.Start.Before(b.Start)
+	})
+
+	return report
+}
+
+// compareReplicas compares the metadata of all the replicas of a block and
+// returns the differences found, if any. Unlike the repair comparer there is
+// no origin host, every replica is expected to have the block.
+func compareReplicas(
+	id ident.ID,
+	b ReplicaBlockMetadata,
+	replicas []string,
+) (BlockDifference, bool) {
+	var (
+		diff = BlockDifference{
+			ID:    id.String(),
+			Start: b.Start(),
+		}
+		hasBlock      = make(map[string]struct{}, len(replicas))
+		sizeVal       int64
+		firstSize     = true
+		sameSize      = true
+		checksumVal   uint32
+		firstChecksum = true
+		sameChecksum  = true
+	)
+	for _, hm := range b.Metadata() {
+		hasBlock[hm.Host.ID()] = struct{}{}
+
+		// Copy the metadata since the series ID is not owned by the report.
+		replica := block.ReplicaMetadata{
+			Host: hm.Host,
+			Metadata: block.Metadata{
+				ID:       ident.StringID(diff.ID),
+				Start:    hm.Metadata.Start,
+				Size:     hm.Metadata.Size,
+				LastRead: hm.Metadata.LastRead,
+			},
+		}
+		if hm.Metadata.Checksum != nil {
+			checksum := *hm.Metadata.Checksum
+			replica.Metadata.Checksum = &checksum
+		}
+		diff.Replicas = append(diff.Replicas, replica)
+
+		// Skip metadata without a checksum, as with the repair comparer this
+		// usually means the block has unmerged or pending data.
+		if hm.Metadata.Checksum == nil {
+			continue
+		}
+
+		if firstSize {
+			sizeVal = hm.Metadata.Size
+			firstSize = false
+		} else if hm.Metadata.Size != sizeVal {
+			sameSize = false
+		}
+
+		if firstChecksum {
+			checksumVal = *hm.Metadata.Checksum
+			firstChecksum = false
+		} else if *hm.Metadata.Checksum != checksumVal {
+			sameChecksum = false
+		}
+	}
+
+	for _, replica := range replicas {
+		if _, ok := hasBlock[replica]; !ok {
+			diff.MissingReplicas = append(diff.MissingReplicas, replica)
+		}
+	}
+
+	if len(diff.MissingReplicas) > 0 {
+		diff.Types = append(diff.Types, BlockMissingDifference)
+	}
+	if !sameSize {
+		diff.Types = append(diff.Types, BlockSizeDifference)
+	}
+	if !sameChecksum {
+		diff.Types = append(diff.Types, BlockChecksumDifference)
+	}
+
+	return diff, len(diff.Types) > 0
+}
@@ -0,0 +1,143 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package repair
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/client"
+	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/block"
+	"github.com/m3db/m3/src/dbnode/topology"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConsistencyCheckerCheck(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		namespace = ident.StringID("testns")
+		now       = time.Now().Truncate(time.Hour)
+		start     = now.Add(-2 * time.Hour)
+		end       = now
+		hosts     = []topology.Host{
+			topology.NewHost("h0", "addr0"),
+			topology.NewHost("h1", "addr1"),
+			topology.NewHost("h2", "addr2"),
+		}
+		checksums = []uint32{1, 2}
+		opts      = testRepairOptions()
+	)
+
+	shardSet, err := sharding.NewShardSet(sharding.NewShards([]uint32{0}, shard.Available),
+		sharding.DefaultHashFn(1))
+	require.NoError(t, err)
+
+	topoMap := topology.NewMockMap(ctrl)
+	topoMap.EXPECT().ShardSet().Return(shardSet)
+	topoMap.EXPECT().RouteShardForEach(uint32(0), gomock.Any()).DoAndReturn(
+		func(_ uint32, fn topology.RouteForEachFn) error {
+			for i, host := range hosts {
+				fn(i, host)
+			}
+			return nil
+		})
+
+	metadata := []block.ReplicaMetadata{
+		// Consistent across all replicas.
+		{Host: hosts[0], Metadata: block.NewMetadata(ident.StringID("foo"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+		{Host: hosts[1], Metadata: block.NewMetadata(ident.StringID("foo"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+		{Host: hosts[2], Metadata: block.NewMetadata(ident.StringID("foo"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+		// Checksum mismatch on one replica.
+		{Host: hosts[0], Metadata: block.NewMetadata(ident.StringID("bar"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+		{Host: hosts[1], Metadata: block.NewMetadata(ident.StringID("bar"), ident.Tags{}, start, 10, &checksums[1], time.Time{})},
+		{Host: hosts[2], Metadata: block.NewMetadata(ident.StringID("bar"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+		// Missing from one replica.
+		{Host: hosts[0], Metadata: block.NewMetadata(ident.StringID("baz"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+		{Host: hosts[1], Metadata: block.NewMetadata(ident.StringID("baz"), ident.Tags{}, start, 10, &checksums[0], time.Time{})},
+	}
+
+	peerIter := client.NewMockPeerBlockMetadataIter(ctrl)
+	var calls []*gomock.Call
+	for _, m := range metadata {
+		calls = append(calls,
+			peerIter.EXPECT().Next().Return(true),
+			peerIter.EXPECT().Current().Return(m.Host, m.Metadata))
+	}
+	calls = append(calls,
+		peerIter.EXPECT().Next().Return(false),
+		peerIter.EXPECT().Err().Return(nil))
+	gomock.InOrder(calls...)
+
+	session := client.NewMockAdminSession(ctrl)
+	session.EXPECT().TopologyMap().Return(topoMap, nil)
+	session.EXPECT().Origin().Return(nil)
+	session.EXPECT().
+		FetchBlocksMetadataFromPeers(namespace, uint32(0), start, end,
+			opts.RepairConsistencyLevel(), gomock.Any()).
+		Return(peerIter, nil)
+
+	adminClient := client.NewMockAdminClient(ctrl)
+	adminClient.EXPECT().DefaultAdminSession().Return(session, nil)
+
+	checker, err := NewConsistencyChecker(adminClient, opts)
+	require.NoError(t, err)
+
+	report, err := checker.Check(namespace, start, end)
+	require.NoError(t, err)
+	require.False(t, report.Consistent())
+
+	assert.Equal(t, int64(3), report.NumSeries)
+	assert.Equal(t, int64(3), report.NumBlocks)
+	assert.Equal(t, int64(2), report.NumDifferences)
+
+	require.Equal(t, 1, len(report.Shards))
+	shardReport := report.Shards[0]
+	require.NoError(t, shardReport.Err)
+	assert.Equal(t, []string{"h0", "h1", "h2"}, shardReport.Replicas)
+
+	require.Equal(t, 2, len(shardReport.Differences))
+	assert.Equal(t, "bar", shardReport.Differences[0].ID)
+	assert.Equal(t, []BlockDifferenceType{BlockChecksumDifference},
+		shardReport.Differences[0].Types)
+	assert.Equal(t, "baz", shardReport.Differences[1].ID)
+	assert.Equal(t, []BlockDifferenceType{BlockMissingDifference},
+		shardReport.Differences[1].Types)
+	assert.Equal(t, []string{"h2"}, shardReport.Differences[1].MissingReplicas)
+}
+
+func TestConsistencyCheckerInvalidRange(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	checker, err := NewConsistencyChecker(client.NewMockAdminClient(ctrl), testRepairOptions())
+	require.NoError(t, err)
+
+	now := time.Now()
+	_, err = checker.Check(ident.StringID("testns"), now, now)
+	require.Equal(t, errConsistencyCheckInvalidRange, err)
+}
@@ -39,6 +39,8 @@ const (
 	defaultRepairShardConcurrency           = 1
 	defaultDebugShadowComparisonsEnabled    = false
 	defaultDebugShadowComparisonsPercentage = 1.0
+	defaultDivergenceNotifyThreshold        = 0
+	defaultHistorySize                      = 10
 )
 
 var (
@@ -48,9 +50,12 @@ var (
 	errNoReplicaMetadataSlicePool              = errors.New("no replica metadata pool in repair options")
 	errNoResultOptions                         = errors.New("no result options in repair options")
 	errInvalidDebugShadowComparisonsPercentage = errors.New("debug shadow comparisons percentage must be between 0 and 1")
+	errInvalidDivergenceNotifyThreshold        = errors.New("invalid divergence notify threshold in repair options")
+	errInvalidHistorySize                      = errors.New("invalid history size in repair options")
 )
 
 type options struct {
+	repairType                       Type
 	adminClients                     []client.AdminClient
 	repairConsistencyLevel           topology.ReadConsistencyLevel
 	repairShardConcurrency           int
@@ -60,11 +65,14 @@ type options struct {
 	resultOptions                    result.Options
 	debugShadowComparisonsEnabled    bool
 	debugShadowComparisonsPercentage float64
+	divergenceNotifyThreshold        int64
+	historySize                      int
 }
 
 // NewOptions creates new bootstrap options
 func NewOptions() Options {
 	return &options{
+		repairType:                       DefaultType,
 		repairConsistencyLevel:           defaultRepairConsistencyLevel,
 		repairShardConcurrency:           defaultRepairShardConcurrency,
 		repairCheckInterval:              defaultRepairCheckInterval,
@@ -73,9 +81,21 @@ func NewOptions() Options {
 		resultOptions:                    result.NewOptions(),
 		debugShadowComparisonsEnabled:    defaultDebugShadowComparisonsEnabled,
 		debugShadowComparisonsPercentage: defaultDebugShadowComparisonsPercentage,
+		divergenceNotifyThreshold:        defaultDivergenceNotifyThreshold,
+		historySize:                      defaultHistorySize,
 	}
 }
 
+func (o *options) SetType(value Type) Options {
+	opts := *o
+	opts.repairType = value
+	return &opts
+}
+
+func (o *options) Type() Type {
+	return o.repairType
+}
+
 func (o *options) SetAdminClients(value []client.AdminClient) Options {
 	opts := *o
 	opts.adminClients = value
@@ -166,7 +186,30 @@ func (o *options) DebugShadowComparisonsPercentage() float64 {
 	return o.debugShadowComparisonsPercentage
 }
 
+func (o *options) SetDivergenceNotifyThreshold(value int64) Options {
+	opts := *o
+	opts.divergenceNotifyThreshold = value
+	return &opts
+}
+
+func (o *options) DivergenceNotifyThreshold() int64 {
+	return o.divergenceNotifyThreshold
+}
+
+func (o *options) SetHistorySize(value int) Options {
+	opts := *o
+	opts.historySize = value
+	return &opts
+}
+
+func (o *options) HistorySize() int {
+	return o.historySize
+}
+
 func (o *options) Validate() error {
+	if err := ValidateType(o.repairType); err != nil {
+		return err
+	}
 	if len(o.adminClients) == 0 {
 		return errNoAdminClient
 	}
@@ -202,5 +245,11 @@ func (o *options) Validate() error {
 		o.debugShadowComparisonsPercentage < 0 {
 		return errInvalidDebugShadowComparisonsPercentage
 	}
+	if o.divergenceNotifyThreshold < 0 {
+		return errInvalidDivergenceNotifyThreshold
+	}
+	if o.historySize < 0 {
+		return errInvalidHistorySize
+	}
 	return nil
 }
@@ -0,0 +1,108 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package repair
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	errTypeUnspecified = errors.New("repair type unspecified")
+)
+
+// Type is the type of repair to run.
+type Type uint
+
+const (
+	// FullRepair compares the metadata of each shard with the other replicas
+	// and then streams the blocks that diverge from the peers and merges them
+	// into the local shard so that the replicas converge.
+	FullRepair Type = iota
+	// CompareOnlyRepair compares the metadata of each shard with the other
+	// replicas and records the differences without repairing any blocks, this
+	// is useful for observing the divergence between replicas.
+	CompareOnlyRepair
+
+	// DefaultType is the default repair type.
+	DefaultType = FullRepair
+)
+
+// ValidTypes returns the valid repair types.
+func ValidTypes() []Type {
+	return []Type{FullRepair, CompareOnlyRepair}
+}
+
+func (t Type) String() string {
+	switch t {
+	case FullRepair:
+		return "full"
+	case CompareOnlyRepair:
+		return "compare_only"
+	}
+	return "unknown"
+}
+
+// ValidateType validates a repair type.
+func ValidateType(v Type) error {
+	for _, valid := range ValidTypes() {
+		if valid == v {
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid repair Type '%d' valid types are: %v",
+		uint(v), ValidTypes())
+}
+
+// ParseType parses a Type from a string.
+func ParseType(str string) (Type, error) {
+	var r Type
+	if str == "" {
+		return r, errTypeUnspecified
+	}
+	for _, valid := range ValidTypes() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid repair Type '%s' valid types are: %v",
+		str, ValidTypes())
+}
+
+// MarshalYAML marshals a Type as its string representation.
+func (t Type) MarshalYAML() (interface{}, error) {
+	return t.String(), nil
+}
+
+// UnmarshalYAML unmarshals a Type into a valid type from string.
+func (t *Type) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseType(str)
+	if err != nil {
+		return err
+	}
+	*t = r
+	return nil
+}
@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package repair
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestParseType(t *testing.T) {
+	for _, valid := range ValidTypes() {
+		parsed, err := ParseType(valid.String())
+		require.NoError(t, err)
+		require.Equal(t, valid, parsed)
+		require.NoError(t, ValidateType(valid))
+	}
+
+	_, err := ParseType("")
+	require.Error(t, err)
+	_, err = ParseType("partial")
+	require.Error(t, err)
+	require.Error(t, ValidateType(Type(len(ValidTypes()))))
+}
+
+func TestTypeYAMLRoundTrip(t *testing.T) {
+	var cfg struct {
+		Type Type `yaml:"type"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte("type: compare_only\n"), &cfg))
+	require.Equal(t, CompareOnlyRepair, cfg.Type)
+
+	data, err := yaml.Marshal(cfg)
+	require.NoError(t, err)
+	require.Equal(t, "type: compare_only\n", string(data))
+
+	require.Error(t, yaml.Unmarshal([]byte("type: partial\n"), &cfg))
+}
@@ -142,8 +142,127 @@ type MetadataComparisonResult struct {
 	ChecksumDifferences ReplicaSeriesMetadata
 }
 
+// ConsistencyChecker verifies the consistency of data across all replicas of
+// a namespace independently of the background repairer.
+type ConsistencyChecker interface {
+	// Check compares the block metadata of every replica of every shard for
+	// the namespace and time range and returns a consistency report.
+	Check(namespace ident.ID, start, end time.Time) (ConsistencyReport, error)
+}
+
+// ConsistencyReport captures the result of a consistency check across replicas.
+type ConsistencyReport struct {
+	// Namespace is the namespace checked.
+	Namespace ident.ID
+
+	// Start is the start of the time range checked.
+	Start time.Time
+
+	// End is the end of the time range checked.
+	End time.Time
+
+	// NumSeries is the total number of series seen across all shards.
+	NumSeries int64
+
+	// NumBlocks is the total number of blocks seen across all shards.
+	NumBlocks int64
+
+	// NumDifferences is the total number of inconsistent blocks.
+	NumDifferences int64
+
+	// Shards contains the report for each shard, sorted by shard.
+	Shards []ShardConsistencyReport
+}
+
+// Consistent returns whether all blocks of all shards were checked
+// successfully and found to be consistent.
+func (r ConsistencyReport) Consistent() bool {
+	if r.NumDifferences > 0 {
+		return false
+	}
+	for _, shard := range r.Shards {
+		if shard.Err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// ShardConsistencyReport captures the result of a consistency check for a shard.
+type ShardConsistencyReport struct {
+	// Shard is the shard checked.
+	Shard uint32
+
+	// Replicas are the IDs of the hosts that own the shard and were compared.
+	Replicas []string
+
+	// NumSeries is the number of series seen.
+	NumSeries int64
+
+	// NumBlocks is the number of blocks seen.
+	NumBlocks int64
+
+	// Differences contains the inconsistent blocks.
+	Differences []BlockDifference
+
+	// Err is set if the block metadata for the shard could not be fetched.
+	Err error
+}
+
+// BlockDifferenceType describes how the replicas of a block differ.
+type BlockDifferenceType int
+
+const (
+	// BlockMissingDifference indicates that some replicas do not have the block.
+	BlockMissingDifference BlockDifferenceType = iota
+
+	// BlockSizeDifference indicates that the replicas have different block sizes.
+	BlockSizeDifference
+
+	// BlockChecksumDifference indicates that the replicas have different block checksums.
+	BlockChecksumDifference
+)
+
+// String returns the string representation of the block difference type.
+func (t BlockDifferenceType) String() string {
+	switch t {
+	case BlockMissingDifference:
+		return "missing"
+	case BlockSizeDifference:
+		return "size"
+	case BlockChecksumDifference:
+		return "checksum"
+	default:
+		return "unknown"
+	}
+}
+
+// BlockDifference describes an inconsistent block of a series.
+type BlockDifference struct {
+	// ID is the series ID.
+	ID string
+
+	// Start is the block start.
+	Start time.Time
+
+	// Types are the ways in which the replicas of the block differ.
+	Types []BlockDifferenceType
+
+	// Replicas contains the block metadata of each replica that has the block.
+	Replicas []block.ReplicaMetadata
+
+	// MissingReplicas are the IDs of the hosts that do not have the block.
+	MissingReplicas []string
+}
+
 // Options are the repair options
 type Options interface {
+	// SetType sets the type of repair to run.
+	SetType(value Type) Options
+
+	// Type returns the type of repair to run.
+	Type() Type
+
 	// SetAdminClient sets the admin client.
 	SetAdminClients(value []client.AdminClient) Options
 
@@ -200,6 +319,22 @@ type Options interface {
 	// DebugShadowComparisonsPercentage returns the debug shadow comparisons percentage.
 	DebugShadowComparisonsPercentage() float64
 
+	// SetDivergenceNotifyThreshold sets the number of divergent blocks found
+	// repairing a shard above which a divergence notification is sent.
+	SetDivergenceNotifyThreshold(value int64) Options
+
+	// DivergenceNotifyThreshold returns the number of divergent blocks found
+	// repairing a shard above which a divergence notification is sent.
+	DivergenceNotifyThreshold() int64
+
+	// SetHistorySize sets the number of repair outcomes retained for each
+	// block of a shard, zero disables the repair history.
+	SetHistorySize(value int) Options
+
+	// HistorySize returns the number of repair outcomes retained for each
+	// block of a shard, zero disables the repair history.
+	HistorySize() int
+
 	// Validate checks if the options are valid.
 	Validate() error
 }
@@ -0,0 +1,115 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+)
+
+type repairHistoryKey struct {
+	shard      uint32
+	blockStart xtime.UnixNano
+}
+
+// repairHistory retains a bounded number of the most recent repair outcomes
+// of each block of each shard, shards of a namespace may be repaired
+// concurrently so it is guarded by a lock.
+type repairHistory struct {
+	sync.RWMutex
+	size int
+	byNs map[string]map[repairHistoryKey][]RepairOutcome
+}
+
+func newRepairHistory(size int) *repairHistory {
+	return &repairHistory{
+		size: size,
+		byNs: make(map[string]map[repairHistoryKey][]RepairOutcome),
+	}
+}
+
+// record adds the outcome of a repair of a block of a shard, removing the
+// history of blocks of the namespace that start before expireBefore since
+// they have fallen out of retention.
+func (h *repairHistory) record(
+	namespace ident.ID,
+	shard uint32,
+	blockStart time.Time,
+	outcome RepairOutcome,
+	expireBefore time.Time,
+) {
+	if h.size <= 0 {
+		return
+	}
+
+	h.Lock()
+	defer h.Unlock()
+
+	nsHistory, ok := h.byNs[namespace.String()]
+	if !ok {
+		nsHistory = make(map[repairHistoryKey][]RepairOutcome)
+		h.byNs[namespace.String()] = nsHistory
+	}
+
+	expireBeforeNanos := xtime.ToUnixNano(expireBefore)
+	for key := range nsHistory {
+		if key.blockStart < expireBeforeNanos {
+			delete(nsHistory, key)
+		}
+	}
+
+	key := repairHistoryKey{shard: shard, blockStart: xtime.ToUnixNano(blockStart)}
+	outcomes := append(nsHistory[key], outcome)
+	if len(outcomes) > h.size {
+		// Copy rather than reslice so the evicted outcomes can be released.
+		outcomes = append([]RepairOutcome(nil), outcomes[len(outcomes)-h.size:]...)
+	}
+	nsHistory[key] = outcomes
+}
+
+// blocks returns the history of each block of each shard of the namespace,
+// ordered by shard and then block start.
+func (h *repairHistory) blocks(namespace ident.ID) []BlockRepairHistory {
+	h.RLock()
+	defer h.RUnlock()
+
+	nsHistory := h.byNs[namespace.String()]
+	result := make([]BlockRepairHistory, 0, len(nsHistory))
+	for key, outcomes := range nsHistory {
+		result = append(result, BlockRepairHistory{
+			Shard:      key.shard,
+			BlockStart: key.blockStart.ToTime(),
+			Outcomes:   append([]RepairOutcome(nil), outcomes...),
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Shard != result[j].Shard {
+			return result[i].Shard < result[j].Shard
+		}
+		return result[i].BlockStart.Before(result[j].BlockStart)
+	})
+	return result
+}
@@ -0,0 +1,79 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepairHistoryBounded(t *testing.T) {
+	var (
+		h          = newRepairHistory(2)
+		ns         = ident.StringID("testns")
+		blockStart = time.Unix(0, 0).Add(time.Hour)
+	)
+	for i := 0; i < 3; i++ {
+		h.record(ns, 1, blockStart, RepairOutcome{
+			NumBlocks: int64(i),
+			Action:    RepairActionNone,
+		}, time.Unix(0, 0))
+	}
+	h.record(ns, 0, blockStart, RepairOutcome{Action: RepairActionFailed}, time.Unix(0, 0))
+
+	history := h.blocks(ns)
+	require.Equal(t, 2, len(history))
+	require.Equal(t, uint32(0), history[0].Shard)
+	require.Equal(t, []RepairOutcome{{Action: RepairActionFailed}}, history[0].Outcomes)
+	require.Equal(t, uint32(1), history[1].Shard)
+	require.True(t, blockStart.Equal(history[1].BlockStart))
+	require.Equal(t, []RepairOutcome{
+		{NumBlocks: 1, Action: RepairActionNone},
+		{NumBlocks: 2, Action: RepairActionNone},
+	}, history[1].Outcomes)
+
+	require.Empty(t, h.blocks(ident.StringID("other")))
+}
+
+func TestRepairHistoryExpires(t *testing.T) {
+	var (
+		h     = newRepairHistory(2)
+		ns    = ident.StringID("testns")
+		start = time.Unix(0, 0).Add(time.Hour)
+	)
+	h.record(ns, 0, start, RepairOutcome{}, time.Unix(0, 0))
+	h.record(ns, 0, start.Add(time.Hour), RepairOutcome{}, start.Add(time.Hour))
+
+	history := h.blocks(ns)
+	require.Equal(t, 1, len(history))
+	require.True(t, start.Add(time.Hour).Equal(history[0].BlockStart))
+}
+
+func TestRepairHistoryDisabled(t *testing.T) {
+	h := newRepairHistory(0)
+	ns := ident.StringID("testns")
+	h.record(ns, 0, time.Unix(0, 0), RepairOutcome{}, time.Unix(0, 0))
+	require.Empty(t, h.blocks(ns))
+}
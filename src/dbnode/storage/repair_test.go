@@ -27,6 +27,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -136,6 +137,7 @@ func testDatabaseShardRepairerRepair(t *testing.T, withLimit bool) {
 		rtopts         = defaultTestRetentionOpts
 		memTrackerOpts = NewMemoryTrackerOptions(1)
 		memTracker     = NewMemoryTracker(memTrackerOpts)
+		notifier       = &testNotifier{}
 	)
 	if withLimit {
 		opts = opts.SetMemoryTracker(memTracker)
@@ -143,7 +145,8 @@ func testDatabaseShardRepairerRepair(t *testing.T, withLimit bool) {
 
 	opts = opts.
 		SetClockOptions(copts.SetNowFn(nowFn)).
-		SetInstrumentOptions(iopts.SetMetricsScope(tally.NoopScope))
+		SetInstrumentOptions(iopts.SetMetricsScope(tally.NoopScope)).
+		SetNotifier(notifier)
 
 	var (
 		namespaceID     = ident.StringID("testNamespace")
@@ -318,9 +321,106 @@ func testDatabaseShardRepairerRepair(t *testing.T, withLimit bool) {
 			{Host: topology.NewHost("1", "addr1"), Metadata: inputBlocks[1].Metadata},
 		}
 		require.Equal(t, expected, currBlock.Metadata())
+
+		events := notifier.received()
+		require.Equal(t, i+1, len(events))
+		event := events[i]
+		require.Equal(t, notify.EventTypeRepairDivergence, event.Type)
+		require.Equal(t, namespaceID.String(), event.Namespace)
+		require.Equal(t, shardID, event.Fields["shard"])
+		require.Equal(t, int64(1), event.Fields["sizeDiffBlocks"])
+		require.Equal(t, int64(1), event.Fields["checksumDiffBlocks"])
+
+		history := repairer.History(namespaceID)
+		require.Equal(t, 1, len(history))
+		require.Equal(t, shardID, history[0].Shard)
+		require.True(t, start.Equal(history[0].BlockStart))
+		require.Equal(t, 1, len(history[0].Outcomes))
+		outcome := history[0].Outcomes[0]
+		require.Equal(t, RepairActionLoaded, outcome.Action)
+		require.Equal(t, []string{"1"}, outcome.Peers)
+		require.Equal(t, int64(1), outcome.SizeDiffBlocks)
+		require.Equal(t, int64(1), outcome.ChecksumDiffBlocks)
+		require.Empty(t, outcome.Error)
 	}
 }
 
+func TestDatabaseShardRepairerRepairCompareOnly(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	session := client.NewMockAdminSession(ctrl)
+	session.EXPECT().Origin().Return(topology.NewHost("0", "addr0")).AnyTimes()
+	session.EXPECT().TopologyMap().AnyTimes()
+
+	mockClient := client.NewMockAdminClient(ctrl)
+	mockClient.EXPECT().DefaultAdminSession().Return(session, nil).AnyTimes()
+
+	var (
+		rpOpts = testRepairOptions(ctrl).
+			SetAdminClients([]client.AdminClient{mockClient}).
+			SetType(repair.CompareOnlyRepair)
+		now         = time.Now()
+		opts        = DefaultTestOptions()
+		namespaceID = ident.StringID("testNamespace")
+		start       = now
+		end         = now.Add(defaultTestRetentionOpts.BlockSize())
+		checksums   = []uint32{4, 5}
+		shardID     = uint32(0)
+		shard       = NewMockdatabaseShard(ctrl)
+	)
+	opts = opts.
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time { return now })).
+		SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(tally.NoopScope))
+
+	localResults := block.NewFetchBlocksMetadataResults()
+	results := block.NewFetchBlockMetadataResults()
+	results.Add(block.NewFetchBlockMetadataResult(now.Add(30*time.Minute),
+		1, &checksums[0], now, nil))
+	localResults.Add(block.NewFetchBlocksMetadataResult(ident.StringID("foo"), nil, results))
+	shard.EXPECT().
+		FetchBlocksMetadataV2(gomock.Any(), start, end, gomock.Any(), nil, gomock.Any()).
+		Return(localResults, nil, nil)
+	shard.EXPECT().ID().Return(shardID).AnyTimes()
+
+	peerIter := client.NewMockPeerBlockMetadataIter(ctrl)
+	peerHost := topology.NewHost("1", "addr1")
+	gomock.InOrder(
+		peerIter.EXPECT().Next().Return(true),
+		peerIter.EXPECT().Current().Return(peerHost, block.NewMetadata(ident.StringID("foo"),
+			ident.Tags{}, now.Add(30*time.Minute), 1, &checksums[1], now)),
+		peerIter.EXPECT().Next().Return(false),
+		peerIter.EXPECT().Err().Return(nil),
+	)
+	session.EXPECT().
+		FetchBlocksMetadataFromPeers(namespaceID, shardID, start, end,
+			rpOpts.RepairConsistencyLevel(), gomock.Any()).
+		Return(peerIter, nil)
+
+	// No blocks are fetched from peers or loaded into the shard.
+	var resDiff repair.MetadataComparisonResult
+	repairer := newShardRepairer(opts, rpOpts).(shardRepairer)
+	repairer.recordFn = func(_ ident.ID, _ databaseShard, diffRes repair.MetadataComparisonResult) {
+		resDiff = diffRes
+	}
+
+	nsMeta, err := namespace.NewMetadata(namespaceID, namespace.NewOptions())
+	require.NoError(t, err)
+	ctx := context.NewContext()
+	defer ctx.Close()
+	_, err = repairer.Repair(ctx, namespace.Context{ID: namespaceID}, nsMeta,
+		xtime.Range{Start: start, End: end}, shard)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), resDiff.ChecksumDifferences.NumBlocks())
+
+	history := repairer.History(namespaceID)
+	require.Equal(t, 1, len(history))
+	outcome := history[0].Outcomes[0]
+	require.Equal(t, RepairActionCompared, outcome.Action)
+	require.Equal(t, []string{"1"}, outcome.Peers)
+	require.Equal(t, int64(1), outcome.ChecksumDiffBlocks)
+}
+
 type multiSessionTestMock struct {
 	host    topology.Host
 	client  *client.MockAdminClient
@@ -674,6 +774,10 @@ func TestDatabaseRepairPrioritizationLogic(t *testing.T) {
 
 			ns1.EXPECT().ID().Return(ident.StringID("ns1")).AnyTimes()
 			ns2.EXPECT().ID().Return(ident.StringID("ns2")).AnyTimes()
+			ns1.EXPECT().GetOwnedShards().Return(nil).AnyTimes()
+			ns2.EXPECT().GetOwnedShards().Return(nil).AnyTimes()
+			ns1.EXPECT().IsFrozen(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+			ns2.EXPECT().IsFrozen(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
 
 			ns1.EXPECT().Repair(gomock.Any(), tc.expectedNS1Repair.repairRange)
 			ns2.EXPECT().Repair(gomock.Any(), tc.expectedNS2Repair.repairRange)
@@ -683,3 +787,131 @@ func TestDatabaseRepairPrioritizationLogic(t *testing.T) {
 		})
 	}
 }
+
+func TestDatabaseRepairResetsRepairStatesOnAcquiredShards(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		rOpts = retention.NewOptions().
+			SetRetentionPeriod(retention.NewOptions().BlockSize() * 2)
+		nsOpts = namespace.NewOptions().
+			SetRetentionOptions(rOpts)
+		blockSize = rOpts.BlockSize()
+
+		// Set current time such that the previous block is flushable.
+		now = time.Now().Truncate(blockSize).Add(rOpts.BufferPast()).Add(time.Second)
+
+		flushTimeStart = retention.FlushTimeStart(rOpts, now)
+		flushTimeEnd   = retention.FlushTimeEnd(rOpts, now)
+
+		flushTimeStartNano = xtime.ToUnixNano(flushTimeStart)
+		flushTimeEndNano   = xtime.ToUnixNano(flushTimeEnd)
+	)
+	require.NoError(t, nsOpts.Validate())
+
+	opts := DefaultTestOptions().SetRepairOptions(testRepairOptions(ctrl))
+	mockDatabase := NewMockdatabase(ctrl)
+
+	databaseRepairer, err := newDatabaseRepairer(mockDatabase, opts)
+	require.NoError(t, err)
+	repairer := databaseRepairer.(*dbRepairer)
+	repairer.nowFn = func() time.Time {
+		return now
+	}
+	repairer.repairStatesByNs = repairStatesByNs{
+		"ns1": namespaceRepairStateByTime{
+			flushTimeStartNano: repairState{
+				Status:      repairSuccess,
+				LastAttempt: time.Time{},
+			},
+			flushTimeEndNano: repairState{
+				Status:      repairSuccess,
+				LastAttempt: time.Time{}.Add(time.Second),
+			},
+		},
+	}
+
+	var (
+		ns     = NewMockdatabaseNamespace(ctrl)
+		shard0 = NewMockdatabaseShard(ctrl)
+		shard1 = NewMockdatabaseShard(ctrl)
+	)
+	shard0.EXPECT().ID().Return(uint32(0)).AnyTimes()
+	shard1.EXPECT().ID().Return(uint32(1)).AnyTimes()
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("ns1")).AnyTimes()
+	ns.EXPECT().IsFrozen(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+	mockDatabase.EXPECT().IsBootstrapped().Return(true).AnyTimes()
+	mockDatabase.EXPECT().GetOwnedNamespaces().Return([]databaseNamespace{ns}, nil).AnyTimes()
+
+	// All blocks have been repaired so the least recently repaired block
+	// is repaired.
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard0})
+	ns.EXPECT().Repair(gomock.Any(),
+		xtime.Range{Start: flushTimeStart, End: flushTimeStart.Add(blockSize)})
+	require.NoError(t, repairer.Repair())
+
+	// Acquiring a shard resets the repair states so the most recent block
+	// is repaired again.
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard0, shard1})
+	ns.EXPECT().Repair(gomock.Any(),
+		xtime.Range{Start: flushTimeEnd, End: flushTimeEnd.Add(blockSize)})
+	require.NoError(t, repairer.Repair())
+
+	// Followed by the rest of the blocks that have not been repaired since.
+	ns.EXPECT().GetOwnedShards().Return([]databaseShard{shard0, shard1})
+	ns.EXPECT().Repair(gomock.Any(),
+		xtime.Range{Start: flushTimeStart, End: flushTimeStart.Add(blockSize)})
+	require.NoError(t, repairer.Repair())
+}
+
+func TestDatabaseRepairerRepairedTo(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	var (
+		rOpts = retention.NewOptions().
+			SetRetentionPeriod(retention.NewOptions().BlockSize() * 2)
+		nsOpts = namespace.NewOptions().
+			SetRetentionOptions(rOpts)
+		blockSize = rOpts.BlockSize()
+
+		// Set current time such that the previous block is flushable.
+		now = time.Now().Truncate(blockSize).Add(rOpts.BufferPast()).Add(time.Second)
+
+		flushTimeStart = retention.FlushTimeStart(rOpts, now)
+		flushTimeEnd   = retention.FlushTimeEnd(rOpts, now)
+	)
+	require.NoError(t, nsOpts.Validate())
+
+	opts := DefaultTestOptions().SetRepairOptions(testRepairOptions(ctrl))
+	databaseRepairer, err := newDatabaseRepairer(NewMockdatabase(ctrl), opts)
+	require.NoError(t, err)
+	repairer := databaseRepairer.(*dbRepairer)
+	repairer.nowFn = func() time.Time {
+		return now
+	}
+
+	ns := NewMockdatabaseNamespace(ctrl)
+	ns.EXPECT().Options().Return(nsOpts).AnyTimes()
+	ns.EXPECT().ID().Return(ident.StringID("ns1")).AnyTimes()
+	ns.EXPECT().IsFrozen(gomock.Any(), gomock.Any()).Return(false).AnyTimes()
+
+	repairedTo, enabled := repairer.RepairedTo(ns)
+	require.True(t, enabled)
+	require.True(t, repairedTo.IsZero())
+
+	// A failed repair stops the watermark from advancing past the block.
+	repairer.markRepairAttempt(ns.ID(), flushTimeStart, now, repairSuccess)
+	repairer.markRepairAttempt(ns.ID(), flushTimeEnd, now, repairFailed)
+	repairedTo, _ = repairer.RepairedTo(ns)
+	require.Equal(t, flushTimeStart.Add(blockSize), repairedTo)
+
+	repairer.markRepairAttempt(ns.ID(), flushTimeEnd, now, repairSuccess)
+	repairedTo, _ = repairer.RepairedTo(ns)
+	require.Equal(t, flushTimeEnd.Add(blockSize), repairedTo)
+
+	_, enabled = newNoopDatabaseRepairer().RepairedTo(ns)
+	require.False(t, enabled)
+}
@@ -82,7 +82,7 @@ type databaseBuffer interface {
 		unit xtime.Unit,
 		annotation []byte,
 		wOpts WriteOptions,
-	) (bool, error)
+	) (bool, WriteDisposition, error)
 
 	Snapshot(
 		ctx context.Context,
@@ -251,6 +251,16 @@ func (b *dbBuffer) Reset(opts databaseBufferResetOptions) {
 	b.blockRetriever = opts.BlockRetriever
 }
 
+// bufferPastAndFuture returns the buffer past and buffer future to use for
+// writes, preferring the runtime buffer window of the namespace if set.
+func (b *dbBuffer) bufferPastAndFuture() (time.Duration, time.Duration) {
+	if w := b.opts.BufferWindow(); w != nil {
+		return w.BufferPast(), w.BufferFuture()
+	}
+	ropts := b.opts.RetentionOptions()
+	return ropts.BufferPast(), ropts.BufferFuture()
+}
+
 func (b *dbBuffer) Write(
 	ctx context.Context,
 	timestamp time.Time,
@@ -258,27 +268,44 @@ func (b *dbBuffer) Write(
 	unit xtime.Unit,
 	annotation []byte,
 	wOpts WriteOptions,
-) (bool, error) {
+) (bool, WriteDisposition, error) {
+	bufferPast, bufferFuture := b.bufferPastAndFuture()
 	var (
-		ropts        = b.opts.RetentionOptions()
-		bufferPast   = ropts.BufferPast()
-		bufferFuture = ropts.BufferFuture()
-		now          = b.nowFn()
-		pastLimit    = now.Add(-1 * bufferPast)
-		futureLimit  = now.Add(bufferFuture)
-		blockSize    = ropts.BlockSize()
-		blockStart   = timestamp.Truncate(blockSize)
-		writeType    WriteType
+		ropts       = b.opts.RetentionOptions()
+		now         = b.nowFn()
+		pastLimit   = now.Add(-1 * bufferPast)
+		futureLimit = now.Add(bufferFuture)
+		blockSize   = ropts.BlockSize()
+		blockStart  = timestamp.Truncate(blockSize)
+		writeType   WriteType
+		clamped     bool
 	)
+	// NB: Bootstrap writes have already been accepted so they are not subject
+	// to the future write tolerance.
+	// NB: Writes through the database are clamped before they are applied so
+	// that the commit log sees the clamped timestamp too, clamping here only
+	// covers writes made to the series directly.
+	if limit, ok := b.opts.FutureWriteOptions().FutureLimit(now); ok &&
+		!wOpts.BootstrapWrite && timestamp.After(limit) {
+		if b.opts.FutureWriteOptions().Action != namespace.FutureWriteClamp {
+			b.opts.Stats().IncFutureWritesRejected()
+			return false, WriteRejectedTooFuture, m3dberrors.ErrTooFutureTolerance
+		}
+		b.opts.Stats().IncFutureWritesClamped()
+		timestamp = limit
+		blockStart = timestamp.Truncate(blockSize)
+		clamped = true
+	}
 	switch {
-	case wOpts.BootstrapWrite:
+	case wOpts.BootstrapWrite,
+		wOpts.BackfillWrite && !pastLimit.Before(timestamp):
 		exists, err := b.blockRetriever.IsBlockRetrievable(blockStart)
 		if err != nil {
-			return false, err
+			return false, WriteDispositionUnknown, err
 		}
-		// Bootstrap writes are allowed to be outside of time boundaries
-		// and determined as cold or warm writes depending on whether
-		// the block is retrievable or not.
+		// Bootstrap and backfill writes are allowed to be outside of time
+		// boundaries and determined as cold or warm writes depending on
+		// whether the block is retrievable or not.
 		if !exists {
 			writeType = WarmWrite
 		} else {
@@ -288,7 +315,7 @@ func (b *dbBuffer) Write(
 	case !pastLimit.Before(timestamp):
 		writeType = ColdWrite
 		if !b.opts.ColdWritesEnabled() {
-			return false, xerrors.NewInvalidParamsError(
+			return false, WriteRejectedTooOld, xerrors.NewInvalidParamsError(
 				fmt.Errorf("datapoint too far in past: "+
 					"id=%s, off_by=%s, timestamp=%s, past_limit=%s, "+
 					"timestamp_unix_nanos=%d, past_limit_unix_nanos=%d",
@@ -301,7 +328,7 @@ func (b *dbBuffer) Write(
 	case !futureLimit.After(timestamp):
 		writeType = ColdWrite
 		if !b.opts.ColdWritesEnabled() {
-			return false, xerrors.NewInvalidParamsError(
+			return false, WriteRejectedTooFuture, xerrors.NewInvalidParamsError(
 				fmt.Errorf("datapoint too far in future: "+
 					"id=%s, off_by=%s, timestamp=%s, future_limit=%s, "+
 					"timestamp_unix_nanos=%d, future_limit_unix_nanos=%d",
@@ -314,9 +341,27 @@ func (b *dbBuffer) Write(
 	default:
 		writeType = WarmWrite
 
+		// NB: If the buffer past has been raised at runtime the block may have
+		// already been warm flushed with a smaller buffer past, in which case
+		// the write needs to be a cold write to be persisted.
+		if bufferPast > ropts.BufferPast() && b.blockRetriever != nil &&
+			now.Add(-ropts.BufferPast()).After(timestamp) {
+			exists, err := b.blockRetriever.IsBlockRetrievable(blockStart)
+			if err != nil {
+				return false, WriteDispositionUnknown, err
+			}
+			if exists {
+				if !b.opts.ColdWritesEnabled() {
+					return false, WriteRejectedColdWritesDisabled, m3dberrors.ErrColdWritesNotEnabled
+				}
+				writeType = ColdWrite
+			}
+		}
 	}
 
-	if writeType == ColdWrite {
+	// NB: Backfill writes may be warm writes into blocks that have not yet
+	// been flushed, so check them against retention regardless of write type.
+	if writeType == ColdWrite || wOpts.BackfillWrite {
 		retentionLimit := now.Add(-ropts.RetentionPeriod())
 		if wOpts.BootstrapWrite {
 			// NB(r): Allow bootstrapping to write to blocks that are
@@ -327,21 +372,29 @@ func (b *dbBuffer) Write(
 			if wOpts.SkipOutOfRetention {
 				// Allow for datapoint to be skipped since caller does not
 				// want writes out of retention to fail.
-				return false, nil
+				return false, WriteRejectedTooOld, nil
 			}
-			return false, m3dberrors.ErrTooPast
+			return false, WriteRejectedTooOld, m3dberrors.ErrTooPast
 		}
 
 		if !now.Add(ropts.FutureRetentionPeriod()).Add(blockSize).After(timestamp) {
 			if wOpts.SkipOutOfRetention {
 				// Allow for datapoint to be skipped since caller does not
 				// want writes out of retention to fail.
-				return false, nil
+				return false, WriteRejectedTooFuture, nil
 			}
-			return false, m3dberrors.ErrTooFuture
+			return false, WriteRejectedTooFuture, m3dberrors.ErrTooFuture
 		}
 
-		b.opts.Stats().IncColdWrites()
+		if writeType == ColdWrite {
+			// NB: Bootstrap writes are allowed into immutable blocks since
+			// they only restore data that was already accepted.
+			archivalOpts := b.opts.ArchivalOptions()
+			if !wOpts.BootstrapWrite && archivalOpts.IsBlockImmutable(blockStart, blockSize, now) {
+				return false, WriteRejectedTooOld, m3dberrors.ErrBlockImmutable
+			}
+			b.opts.Stats().IncColdWrites()
+		}
 	}
 
 	buckets := b.bucketVersionsAtCreate(blockStart)
@@ -355,7 +408,17 @@ func (b *dbBuffer) Write(
 		value = wOpts.TransformOptions.ForceValue
 	}
 
-	return buckets.write(timestamp, value, unit, annotation, writeType, wOpts.SchemaDesc)
+	wasWritten, disposition, err := buckets.write(timestamp, value, unit,
+		annotation, writeType, wOpts.SchemaDesc)
+	if err != nil {
+		return false, WriteDispositionUnknown, err
+	}
+	if clamped {
+		// Report the clamp over an overwrite since the timestamp the caller
+		// provided was not the one written.
+		disposition = WriteClamped
+	}
+	return wasWritten, disposition, nil
 }
 
 func (b *dbBuffer) IsEmpty() bool {
@@ -398,8 +461,25 @@ func (b *dbBuffer) Stats() bufferStats {
 
 func (b *dbBuffer) Tick(blockStates ShardBlockStateSnapshot, nsCtx namespace.Context) bufferTickResult {
 	mergedOutOfOrder := 0
-	var evictedBucketTimes OptimizedTimes
+	var (
+		evictedBucketTimes OptimizedTimes
+		inMemory           = b.opts.InMemory()
+		expireCutoff       time.Time
+	)
+	if inMemory {
+		ropts := b.opts.RetentionOptions()
+		expireCutoff = b.nowFn().Add(-ropts.RetentionPeriod()).Truncate(ropts.BlockSize())
+	}
 	for tNano, buckets := range b.bucketsMap {
+		if inMemory && tNano.ToTime().Before(expireCutoff) {
+			// Buckets of in-memory namespaces are never flushed, instead
+			// retention is enforced by evicting them once they expire.
+			buckets.removeAllBuckets()
+			b.removeBucketVersionsAt(tNano.ToTime())
+			evictedBucketTimes.Add(tNano)
+			continue
+		}
+
 		// The blockStates map is never written to after creation, so this
 		// read access is safe. Since this version map is a snapshot of the
 		// versions, the real block flush versions may be higher. This is okay
@@ -965,7 +1045,7 @@ func (b *BufferBucketVersions) write(
 	annotation []byte,
 	writeType WriteType,
 	schema namespace.SchemaDescr,
-) (bool, error) {
+) (bool, WriteDisposition, error) {
 	return b.writableBucketCreate(writeType).write(timestamp, value, unit, annotation, schema)
 }
 
@@ -1010,6 +1090,17 @@ func (b *BufferBucketVersions) removeBucketsUpToVersion(
 	b.buckets = nonEvictedBuckets
 }
 
+func (b *BufferBucketVersions) removeAllBuckets() {
+	for i, bucket := range b.buckets {
+		// Release the encoders now rather than when the bucket is next
+		// reused, the data is no longer readable once expired.
+		bucket.reset()
+		b.bucketPool.Put(bucket)
+		b.buckets[i] = nil
+	}
+	b.buckets = b.buckets[:0]
+}
+
 func (b *BufferBucketVersions) setLastRead(value time.Time) {
 	atomic.StoreInt64(&b.lastReadUnixNanos, value.UnixNano())
 }
@@ -1118,26 +1209,33 @@ func (b *BufferBucket) write(
 	unit xtime.Unit,
 	annotation []byte,
 	schema namespace.SchemaDescr,
-) (bool, error) {
+) (bool, WriteDisposition, error) {
 	datapoint := ts.Datapoint{
 		Timestamp: timestamp,
 		Value:     value,
 	}
 
-	// Find the correct encoder to write to
-	idx := -1
+	// Find the correct encoder to write to. Duplicates are only detected
+	// against the last datapoint of each encoder, which avoids decoding the
+	// encoders on every write, so dispositions reporting duplicates are
+	// best-effort.
+	var (
+		idx         = -1
+		disposition = WriteAccepted
+	)
 	for i := range b.encoders {
 		lastWriteAt := b.encoders[i].lastWriteAt
 		if timestamp.Equal(lastWriteAt) {
 			last, err := b.encoders[i].encoder.LastEncoded()
 			if err != nil {
-				return false, err
+				return false, WriteDispositionUnknown, err
 			}
 			if last.Value == value {
 				// No-op since matches the current value. Propagates up to callers that
 				// no value was written.
-				return false, nil
+				return false, WriteDuplicate, nil
 			}
+			disposition = WriteDuplicateOverwritten
 			continue
 		}
 
@@ -1154,7 +1252,10 @@ func (b *BufferBucket) write(
 	// The encoders pushed later will surface their values first.
 	if idx != -1 {
 		err := b.writeToEncoderIndex(idx, datapoint, unit, annotation, schema)
-		return err == nil, err
+		if err != nil {
+			return false, WriteDispositionUnknown, err
+		}
+		return true, disposition, nil
 	}
 
 	// Need a new encoder, we didn't find an encoder to write to
@@ -1176,9 +1277,9 @@ func (b *BufferBucket) write(
 	if err != nil {
 		encoder.Close()
 		b.encoders = b.encoders[:idx]
-		return false, err
+		return false, WriteDispositionUnknown, err
 	}
-	return true, nil
+	return true, disposition, nil
 }
 
 func (b *BufferBucket) writeToEncoderIndex(
@@ -1206,6 +1307,7 @@ func (b *BufferBucket) streams(ctx context.Context) []xio.BlockReader {
 		}
 		if s, err := bl.Stream(ctx); err == nil && s.IsNotEmpty() {
 			// NB(r): block stream method will register the stream closer already
+			s.Source = xio.BlockSourcePeer
 			streams = append(streams, s)
 		}
 	}
@@ -1216,6 +1318,8 @@ func (b *BufferBucket) streams(ctx context.Context) []xio.BlockReader {
 				SegmentReader: s,
 				Start:         start,
 				BlockSize:     b.opts.RetentionOptions().BlockSize(),
+				Source:        xio.BlockSourceMemory,
+				LastWrite:     b.encoders[i].lastWriteAt,
 			}
 			ctx.RegisterFinalizer(s)
 			streams = append(streams, br)
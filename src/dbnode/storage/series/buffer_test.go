@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/storage/block"
+	m3dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/checked"
@@ -80,7 +81,7 @@ func newBufferTestOptions() Options {
 func verifyWriteToBuffer(t *testing.T, buffer databaseBuffer,
 	v DecodedTestValue, schema namespace.SchemaDescr) {
 	ctx := context.NewContext()
-	wasWritten, err := buffer.Write(ctx, v.Timestamp, v.Value, v.Unit,
+	wasWritten, _, err := buffer.Write(ctx, v.Timestamp, v.Value, v.Unit,
 		v.Annotation, WriteOptions{SchemaDesc: schema})
 	require.NoError(t, err)
 	require.True(t, wasWritten)
@@ -102,9 +103,10 @@ func TestBufferWriteTooFuture(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
 
-	wasWritten, err := buffer.Write(ctx, curr.Add(rops.BufferFuture()), 1,
+	wasWritten, disposition, err := buffer.Write(ctx, curr.Add(rops.BufferFuture()), 1,
 		xtime.Second, nil, WriteOptions{})
 	assert.False(t, wasWritten)
+	assert.Equal(t, WriteRejectedTooFuture, disposition)
 	assert.Error(t, err)
 	assert.True(t, xerrors.IsInvalidParams(err))
 	assert.True(t, strings.Contains(err.Error(), "datapoint too far in future"))
@@ -127,9 +129,10 @@ func TestBufferWriteTooPast(t *testing.T) {
 	})
 	ctx := context.NewContext()
 	defer ctx.Close()
-	wasWritten, err := buffer.Write(ctx, curr.Add(-1*rops.BufferPast()), 1, xtime.Second,
+	wasWritten, disposition, err := buffer.Write(ctx, curr.Add(-1*rops.BufferPast()), 1, xtime.Second,
 		nil, WriteOptions{})
 	assert.False(t, wasWritten)
+	assert.Equal(t, WriteRejectedTooOld, disposition)
 	assert.Error(t, err)
 	assert.True(t, xerrors.IsInvalidParams(err))
 	assert.True(t, strings.Contains(err.Error(), "datapoint too far in past"))
@@ -138,6 +141,247 @@ func TestBufferWriteTooPast(t *testing.T) {
 	assert.True(t, strings.Contains(err.Error(), "past_limit="))
 }
 
+func TestBufferWriteBackfill(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newBufferTestOptions()
+	rops := opts.RetentionOptions()
+	blockSize := rops.BlockSize()
+	curr := time.Now().Truncate(blockSize)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+
+	var (
+		unflushed = curr.Add(-10 * blockSize)
+		flushed   = curr.Add(-20 * blockSize)
+		expired   = curr.Add(-rops.RetentionPeriod() - blockSize)
+	)
+	retriever := NewMockQueryableBlockRetriever(ctrl)
+	retriever.EXPECT().IsBlockRetrievable(unflushed).Return(false, nil)
+	retriever.EXPECT().IsBlockRetrievable(flushed).Return(true, nil)
+	retriever.EXPECT().IsBlockRetrievable(expired).Return(false, nil)
+
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		ID:             ident.StringID("foo"),
+		BlockRetriever: retriever,
+		Options:        opts,
+	})
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	wOpts := WriteOptions{BackfillWrite: true}
+	wasWritten, _, err := buffer.Write(ctx, unflushed, 1, xtime.Second, nil, wOpts)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	// Only writes into blocks that have already been flushed are cold writes.
+	require.Equal(t, 0, buffer.ColdFlushBlockStarts(nil).Len())
+	wasWritten, _, err = buffer.Write(ctx, flushed, 2, xtime.Second, nil, wOpts)
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, 1, buffer.ColdFlushBlockStarts(nil).Len())
+
+	// Backfill writes must still be within retention.
+	wasWritten, _, err = buffer.Write(ctx, expired, 3, xtime.Second, nil, wOpts)
+	require.Equal(t, m3dberrors.ErrTooPast, err)
+	require.False(t, wasWritten)
+}
+
+func TestBufferWriteImmutableBlock(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newBufferTestOptions().
+		SetColdWritesEnabled(true).
+		SetArchivalOptions(namespace.ArchivalOptions{
+			Enabled:        true,
+			ImmutableAfter: time.Hour,
+		})
+	blockSize := opts.RetentionOptions().BlockSize()
+	curr := time.Now().Truncate(blockSize)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+
+	var (
+		mutable   = curr.Add(-10 * blockSize)
+		immutable = curr.Add(-time.Hour).Add(-blockSize)
+	)
+	retriever := NewMockQueryableBlockRetriever(ctrl)
+	retriever.EXPECT().IsBlockRetrievable(immutable).Return(true, nil)
+
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		ID:             ident.StringID("foo"),
+		BlockRetriever: retriever,
+		Options:        opts,
+	})
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	wasWritten, _, err := buffer.Write(ctx, mutable, 1, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	wasWritten, _, err = buffer.Write(ctx, immutable, 2, xtime.Second, nil, WriteOptions{})
+	require.Equal(t, m3dberrors.ErrBlockImmutable, err)
+	require.False(t, wasWritten)
+
+	// Bootstrap writes restore previously accepted data and are allowed.
+	wasWritten, _, err = buffer.Write(ctx, immutable, 2, xtime.Second, nil,
+		WriteOptions{BootstrapWrite: true})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+}
+
+func TestBufferWriteFutureTolerance(t *testing.T) {
+	opts := newBufferTestOptions().
+		SetColdWritesEnabled(true).
+		SetFutureWriteOptions(namespace.FutureWriteOptions{
+			Enabled:   true,
+			Tolerance: time.Minute,
+		})
+	blockSize := opts.RetentionOptions().BlockSize()
+	curr := time.Now().Truncate(blockSize)
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		ID:      ident.StringID("foo"),
+		Options: opts,
+	})
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	wasWritten, _, err := buffer.Write(ctx, curr.Add(time.Minute), 1, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+
+	wasWritten, disposition, err := buffer.Write(ctx, curr.Add(2*blockSize), 2, xtime.Second, nil, WriteOptions{})
+	require.Equal(t, m3dberrors.ErrTooFutureTolerance, err)
+	require.False(t, wasWritten)
+	require.Equal(t, WriteRejectedTooFuture, disposition)
+
+	// Writes beyond the tolerance are clamped to it when configured to.
+	futureWriteOpts := opts.FutureWriteOptions()
+	futureWriteOpts.Action = namespace.FutureWriteClamp
+	buffer.opts = opts.SetFutureWriteOptions(futureWriteOpts)
+	wasWritten, disposition, err = buffer.Write(ctx, curr.Add(2*blockSize), 2, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, WriteClamped, disposition)
+	require.Equal(t, []time.Time{curr}, buffer.inOrderBlockStarts)
+}
+
+func TestBufferWriteDispositions(t *testing.T) {
+	opts := newBufferTestOptions()
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		ID:      ident.StringID("foo"),
+		Options: opts,
+	})
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	wasWritten, disposition, err := buffer.Write(ctx, curr, 1, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, WriteAccepted, disposition)
+
+	// Writing a different value at the same timestamp overwrites it.
+	wasWritten, disposition, err = buffer.Write(ctx, curr, 2, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, WriteDuplicateOverwritten, disposition)
+
+	// Writing the same value at the same timestamp is a no-op.
+	wasWritten, disposition, err = buffer.Write(ctx, curr, 2, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.False(t, wasWritten)
+	require.Equal(t, WriteDuplicate, disposition)
+
+	wasWritten, disposition, err = buffer.Write(ctx, curr.Add(time.Second), 3, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, WriteAccepted, disposition)
+
+	// Duplicates are only detected against the last datapoint of each
+	// encoder, so replacing a datapoint that is no longer the last datapoint
+	// of its encoder is reported as accepted.
+	wasWritten, disposition, err = buffer.Write(ctx, curr.Add(2*time.Second), 4, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, WriteAccepted, disposition)
+
+	wasWritten, disposition, err = buffer.Write(ctx, curr.Add(time.Second), 5, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, WriteAccepted, disposition)
+}
+
+func TestBufferWriteRaisedBufferPast(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newBufferTestOptions().SetColdWritesEnabled(true)
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+	window := NewBufferWindow(rops.BufferPast(), rops.BufferFuture())
+	opts = opts.
+		SetBufferWindow(window).
+		SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+			return curr
+		}))
+
+	var (
+		flushed   = curr.Add(-time.Second).Add(-rops.BufferPast())
+		unflushed = curr.Add(-time.Second)
+	)
+	retriever := NewMockQueryableBlockRetriever(ctrl)
+	retriever.EXPECT().IsBlockRetrievable(flushed.Truncate(rops.BlockSize())).Return(true, nil).Times(2)
+
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		ID:             ident.StringID("foo"),
+		BlockRetriever: retriever,
+		Options:        opts,
+	})
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	// Writes within the configured buffer past do not check whether the
+	// block has been flushed.
+	window.Update(2*rops.BufferPast(), rops.BufferFuture())
+	wasWritten, _, err := buffer.Write(ctx, unflushed, 1, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, 0, buffer.ColdFlushBlockStarts(nil).Len())
+
+	// Writes only within the raised buffer past are cold writes if the block
+	// has already been flushed.
+	wasWritten, _, err = buffer.Write(ctx, flushed, 2, xtime.Second, nil, WriteOptions{})
+	require.NoError(t, err)
+	require.True(t, wasWritten)
+	require.Equal(t, 1, buffer.ColdFlushBlockStarts(nil).Len())
+
+	// Such writes are rejected if cold writes are not enabled.
+	buffer.opts = opts.SetColdWritesEnabled(false)
+	wasWritten, disposition, err := buffer.Write(ctx, flushed, 3, xtime.Second, nil, WriteOptions{})
+	require.Equal(t, m3dberrors.ErrColdWritesNotEnabled, err)
+	require.False(t, wasWritten)
+	require.Equal(t, WriteRejectedColdWritesDisabled, disposition)
+}
+
 func TestBufferWriteError(t *testing.T) {
 	var (
 		opts   = newBufferTestOptions()
@@ -156,7 +400,7 @@ func TestBufferWriteError(t *testing.T) {
 	defer ctx.Close()
 
 	timeUnitNotExist := xtime.Unit(127)
-	wasWritten, err := buffer.Write(ctx, curr, 1, timeUnitNotExist, nil, WriteOptions{})
+	wasWritten, _, err := buffer.Write(ctx, curr, 1, timeUnitNotExist, nil, WriteOptions{})
 	require.False(t, wasWritten)
 	require.Error(t, err)
 }
@@ -523,7 +767,7 @@ func TestBufferBucketWriteDuplicateUpserts(t *testing.T) {
 
 	for _, values := range data {
 		for _, value := range values {
-			wasWritten, err := b.write(value.Timestamp, value.Value,
+			wasWritten, _, err := b.write(value.Timestamp, value.Value,
 				value.Unit, value.Annotation, nil)
 			require.NoError(t, err)
 			require.True(t, wasWritten)
@@ -592,7 +836,7 @@ func TestBufferBucketDuplicatePointsNotWrittenButUpserted(t *testing.T) {
 	for _, valuesWithMeta := range data {
 		for _, valueWithMeta := range valuesWithMeta {
 			value := valueWithMeta.v
-			wasWritten, err := b.write(value.Timestamp, value.Value,
+			wasWritten, _, err := b.write(value.Timestamp, value.Value,
 				value.Unit, value.Annotation, nil)
 			require.NoError(t, err)
 			assert.Equal(t, valueWithMeta.w, wasWritten)
@@ -646,7 +890,7 @@ func TestIndexedBufferWriteOnlyWritesSinglePoint(t *testing.T) {
 				ForceValue:        forceValue,
 			},
 		}
-		wasWritten, err := buffer.Write(ctx, v.Timestamp, v.Value, v.Unit,
+		wasWritten, _, err := buffer.Write(ctx, v.Timestamp, v.Value, v.Unit,
 			v.Annotation, writeOpts)
 		require.NoError(t, err)
 		expectedWrite := i == 0
@@ -1040,7 +1284,7 @@ func testBufferWithEmptyEncoder(t *testing.T, testSnapshot bool) {
 	ctx := context.NewContext()
 	defer ctx.Close()
 
-	wasWritten, err := buffer.Write(ctx, curr, 1, xtime.Second, nil, WriteOptions{})
+	wasWritten, _, err := buffer.Write(ctx, curr, 1, xtime.Second, nil, WriteOptions{})
 	require.NoError(t, err)
 	require.True(t, wasWritten)
 
@@ -1731,3 +1975,48 @@ func TestBufferLoadColdWrite(t *testing.T) {
 	coldFlushBlockStarts := buffer.ColdFlushBlockStarts(nil)
 	require.Equal(t, 1, coldFlushBlockStarts.Len())
 }
+
+func TestBufferTickInMemoryEvictsExpiredBuckets(t *testing.T) {
+	opts := newBufferTestOptions().SetInMemory(true)
+	opts = opts.SetRetentionOptions(opts.RetentionOptions().
+		SetRetentionPeriod(10 * time.Minute))
+	rops := opts.RetentionOptions()
+	curr := time.Now().Truncate(rops.BlockSize())
+	start := curr
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(func() time.Time {
+		return curr
+	}))
+	buffer := newDatabaseBuffer().(*dbBuffer)
+	buffer.Reset(databaseBufferResetOptions{
+		ID:      ident.StringID("foo"),
+		Options: opts,
+	})
+
+	verifyWriteToBuffer(t, buffer, DecodedTestValue{curr, 1, xtime.Second, nil}, nil)
+	curr = curr.Add(rops.BlockSize())
+	verifyWriteToBuffer(t, buffer, DecodedTestValue{curr, 2, xtime.Second, nil}, nil)
+
+	// Nothing is ever flushed, buckets within retention are kept.
+	shardBlockState := NewShardBlockStateSnapshot(true, BootstrappedBlockStateSnapshot{
+		Snapshot: map[xtime.UnixNano]BlockState{},
+	})
+	r := buffer.Tick(shardBlockState, namespace.Context{})
+	require.Equal(t, 0, r.evictedBucketTimes.Len())
+	require.Equal(t, 2, len(buffer.bucketsMap))
+
+	// Move time forward such that only the first block is out of retention.
+	curr = start.Add(rops.RetentionPeriod()).Add(rops.BlockSize())
+	r = buffer.Tick(shardBlockState, namespace.Context{})
+	require.Equal(t, 1, r.evictedBucketTimes.Len())
+	require.True(t, r.evictedBucketTimes.Contains(xtime.ToUnixNano(start)))
+	require.Equal(t, 1, len(buffer.bucketsMap))
+	_, ok := buffer.bucketVersionsAt(start)
+	require.False(t, ok)
+
+	// Buffers of persistent namespaces are only evicted once flushed.
+	buffer.opts = opts.SetInMemory(false)
+	curr = curr.Add(rops.RetentionPeriod())
+	r = buffer.Tick(shardBlockState, namespace.Context{})
+	require.Equal(t, 0, r.evictedBucketTimes.Len())
+	require.Equal(t, 1, len(buffer.bucketsMap))
+}
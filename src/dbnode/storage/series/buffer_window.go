@@ -0,0 +1,57 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package series
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// BufferWindow is the buffer past and buffer future of a namespace, shared by
+// all series of the namespace so that it can be tuned at runtime.
+type BufferWindow struct {
+	bufferPast   int64
+	bufferFuture int64
+}
+
+// NewBufferWindow returns a new BufferWindow.
+func NewBufferWindow(bufferPast, bufferFuture time.Duration) *BufferWindow {
+	return &BufferWindow{
+		bufferPast:   int64(bufferPast),
+		bufferFuture: int64(bufferFuture),
+	}
+}
+
+// Update updates the buffer past and buffer future, subsequent writes and
+// reads will honor the new values.
+func (w *BufferWindow) Update(bufferPast, bufferFuture time.Duration) {
+	atomic.StoreInt64(&w.bufferPast, int64(bufferPast))
+	atomic.StoreInt64(&w.bufferFuture, int64(bufferFuture))
+}
+
+// BufferPast returns the buffer past.
+func (w *BufferWindow) BufferPast() time.Duration {
+	return time.Duration(atomic.LoadInt64(&w.bufferPast))
+}
+
+// BufferFuture returns the buffer future.
+func (w *BufferWindow) BufferFuture() time.Duration {
+	return time.Duration(atomic.LoadInt64(&w.bufferFuture))
+}
@@ -23,6 +23,7 @@ package series
 import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/x/context"
@@ -44,6 +45,10 @@ type options struct {
 	identifierPool                ident.Pool
 	stats                         Stats
 	coldWritesEnabled             bool
+	archivalOpts                  namespace.ArchivalOptions
+	futureWriteOpts               namespace.FutureWriteOptions
+	inMemory                      bool
+	bufferWindow                  *BufferWindow
 	bufferBucketPool              *BufferBucketPool
 	bufferBucketVersionsPool      *BufferBucketVersionsPool
 }
@@ -199,6 +204,46 @@ func (o *options) ColdWritesEnabled() bool {
 	return o.coldWritesEnabled
 }
 
+func (o *options) SetArchivalOptions(value namespace.ArchivalOptions) Options {
+	opts := *o
+	opts.archivalOpts = value
+	return &opts
+}
+
+func (o *options) ArchivalOptions() namespace.ArchivalOptions {
+	return o.archivalOpts
+}
+
+func (o *options) SetFutureWriteOptions(value namespace.FutureWriteOptions) Options {
+	opts := *o
+	opts.futureWriteOpts = value
+	return &opts
+}
+
+func (o *options) FutureWriteOptions() namespace.FutureWriteOptions {
+	return o.futureWriteOpts
+}
+
+func (o *options) SetInMemory(value bool) Options {
+	opts := *o
+	opts.inMemory = value
+	return &opts
+}
+
+func (o *options) InMemory() bool {
+	return o.inMemory
+}
+
+func (o *options) SetBufferWindow(value *BufferWindow) Options {
+	opts := *o
+	opts.bufferWindow = value
+	return &opts
+}
+
+func (o *options) BufferWindow() *BufferWindow {
+	return o.bufferWindow
+}
+
 func (o *options) SetBufferBucketVersionsPool(value *BufferBucketVersionsPool) Options {
 	opts := *o
 	opts.bufferBucketVersionsPool = value
@@ -25,6 +25,7 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/storage/block"
@@ -37,6 +38,8 @@ import (
 var (
 	errSeriesReadInvalidRange = errors.New(
 		"series invalid time range read argument specified")
+	errArchivedBlockChecksumMismatch = errors.New(
+		"archived block checksum mismatch")
 )
 
 // Reader reads results from a series, or a series block
@@ -121,7 +124,11 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 	if alignedStart.Before(earliest) {
 		alignedStart = earliest
 	}
-	latest := now.Add(ropts.BufferFuture()).Truncate(size)
+	bufferFuture := ropts.BufferFuture()
+	if w := r.opts.BufferWindow(); w != nil {
+		bufferFuture = w.BufferFuture()
+	}
+	latest := now.Add(bufferFuture).Truncate(size)
 	if alignedEnd.After(latest) {
 		alignedEnd = latest
 	}
@@ -148,7 +155,11 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 				if err != nil {
 					return nil, err
 				}
+				if err := r.verifyArchivedBlock(blockAt, size, now, block, streamedBlock); err != nil {
+					return nil, err
+				}
 				if streamedBlock.IsNotEmpty() {
+					streamedBlock.Source = xio.BlockSourceDisk
 					resultsBlock = append(resultsBlock, streamedBlock)
 					// NB(r): Mark this block as read now
 					block.SetLastReadTime(now)
@@ -177,6 +188,7 @@ func (r Reader) readersWithBlocksMapAndBuffer(
 						return nil, err
 					}
 					if streamedBlock.IsNotEmpty() {
+						streamedBlock.Source = xio.BlockSourceDisk
 						resultsBlock = append(resultsBlock, streamedBlock)
 					}
 				}
@@ -238,6 +250,8 @@ func (r Reader) fetchBlocksWithBlocksMapAndBuffer(
 		// }
 		res         = make([]block.FetchBlockResult, 0, len(starts))
 		cachePolicy = r.opts.CachePolicy()
+		blockSize   = r.opts.RetentionOptions().BlockSize()
+		now         = r.opts.ClockOptions().NowFn()()
 		// NB(r): Always use nil for OnRetrieveBlock so we don't cache the
 		// series after fetching it from disk, the fetch blocks API is called
 		// during streaming so to cache it in memory would mean we would
@@ -253,6 +267,9 @@ func (r Reader) fetchBlocksWithBlocksMapAndBuffer(
 		if seriesBlocks != nil {
 			if b, exists := seriesBlocks.BlockAt(start); exists {
 				streamedBlock, err := b.Stream(ctx)
+				if err == nil {
+					err = r.verifyArchivedBlock(start, blockSize, now, b, streamedBlock)
+				}
 				if err != nil {
 					// Short-circuit this entire blockstart if an error was encountered.
 					r := block.NewFetchBlockResult(start, nil,
@@ -344,3 +361,33 @@ func (r Reader) fetchBlocksWithBlocksMapAndBuffer(
 	block.SortFetchBlockResultByTimeAscending(res)
 	return res, nil
 }
+
+// verifyArchivedBlock verifies the checksum of an in-memory block that has
+// been archived and is immutable, if checksum verification is enabled.
+func (r Reader) verifyArchivedBlock(
+	blockStart time.Time,
+	blockSize time.Duration,
+	now time.Time,
+	b block.DatabaseBlock,
+	stream xio.BlockReader,
+) error {
+	archivalOpts := r.opts.ArchivalOptions()
+	if !archivalOpts.VerifyChecksumOnRead ||
+		!archivalOpts.IsBlockImmutable(blockStart, blockSize, now) {
+		return nil
+	}
+
+	expected, err := b.Checksum()
+	if err != nil {
+		return err
+	}
+	segment, err := stream.Segment()
+	if err != nil {
+		return err
+	}
+	if actual := digest.SegmentChecksum(segment); actual != expected {
+		return fmt.Errorf("%v: id=%s, start=%v, expected=%d, actual=%d",
+			errArchivedBlockChecksumMismatch, r.id.String(), blockStart, expected, actual)
+	}
+	return nil
+}
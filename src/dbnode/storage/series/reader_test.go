@@ -26,9 +26,11 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
+	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -653,3 +655,46 @@ func TestReaderReadEncodedRobust(t *testing.T) {
 		})
 	}
 }
+
+func TestReaderFetchBlocksArchivedChecksumMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := newSeriesTestOptions()
+	blockSize := opts.RetentionOptions().BlockSize()
+	opts = opts.SetArchivalOptions(namespace.ArchivalOptions{
+		Enabled:              true,
+		ImmutableAfter:       blockSize,
+		VerifyChecksumOnRead: true,
+	})
+
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	var (
+		now       = opts.ClockOptions().NowFn()()
+		start     = now.Truncate(blockSize).Add(-4 * blockSize)
+		data      = checked.NewBytes([]byte{0x1, 0x2, 0x3}, nil)
+		segment   = ts.NewSegment(data, nil, ts.FinalizeNone)
+		diskCache = block.NewMockDatabaseSeriesBlocks(ctrl)
+		b         = block.NewMockDatabaseBlock(ctrl)
+		segReader = xio.NewMockSegmentReader(ctrl)
+	)
+	segReader.EXPECT().Segment().Return(segment, nil)
+	b.EXPECT().Stream(ctx).Return(xio.BlockReader{
+		SegmentReader: segReader,
+		Start:         start,
+		BlockSize:     blockSize,
+	}, nil)
+	b.EXPECT().Checksum().Return(digest.SegmentChecksum(segment)+1, nil)
+	diskCache.EXPECT().BlockAt(start).Return(b, true)
+
+	reader := NewReaderUsingRetriever(ident.StringID("foo"), nil, nil, nil, opts)
+	r, err := reader.fetchBlocksWithBlocksMapAndBuffer(ctx, []time.Time{start},
+		diskCache, nil, namespace.Context{})
+	require.NoError(t, err)
+	require.Equal(t, 1, len(r))
+	require.Error(t, r[0].Err)
+	require.True(t, strings.Contains(r[0].Err.Error(),
+		errArchivedBlockChecksumMismatch.Error()))
+}
@@ -73,6 +73,7 @@ type dbSeries struct {
 	onRetrieveBlock             block.OnRetrieveBlock
 	blockOnEvictedFromWiredList block.OnEvictedFromWiredList
 	pool                        DatabaseSeriesPool
+	lastWrite                   time.Time
 }
 
 // NewDatabaseSeries creates a new database series.
@@ -288,12 +289,12 @@ func (s *dbSeries) Write(
 	unit xtime.Unit,
 	annotation []byte,
 	wOpts WriteOptions,
-) (bool, error) {
+) (bool, WriteDisposition, error) {
 	s.Lock()
 	matchUniqueIndex := wOpts.MatchUniqueIndex
 	if matchUniqueIndex {
 		if s.uniqueIndex == 0 {
-			return false, errSeriesMatchUniqueIndexInvalid
+			return false, WriteDispositionUnknown, errSeriesMatchUniqueIndexInvalid
 		}
 		if s.uniqueIndex != wOpts.MatchUniqueIndexValue {
 			// NB(r): Match unique index allows for a caller to
@@ -301,13 +302,23 @@ func (s *dbSeries) Write(
 			// later while keeping a direct reference to the series
 			// while the shard and namespace continues to own and manage
 			// the lifecycle of the series.
-			return false, errSeriesMatchUniqueIndexFailed
+			return false, WriteDispositionUnknown, errSeriesMatchUniqueIndexFailed
 		}
 	}
 
-	wasWritten, err := s.buffer.Write(ctx, timestamp, value, unit, annotation, wOpts)
+	wasWritten, disposition, err := s.buffer.Write(ctx, timestamp, value, unit, annotation, wOpts)
+	if err == nil {
+		s.lastWrite = s.opts.ClockOptions().NowFn()()
+	}
 	s.Unlock()
-	return wasWritten, err
+	return wasWritten, disposition, err
+}
+
+func (s *dbSeries) LastWrite() time.Time {
+	s.RLock()
+	lastWrite := s.lastWrite
+	s.RUnlock()
+	return lastWrite
 }
 
 func (s *dbSeries) ReadEncoded(
@@ -606,6 +617,7 @@ func (s *dbSeries) Reset(opts DatabaseSeriesOptions) {
 	s.id = opts.ID
 	s.tags = opts.Tags
 	s.uniqueIndex = opts.UniqueIndex
+	s.lastWrite = time.Time{}
 	s.cachedBlocks.Reset()
 	s.buffer.Reset(databaseBufferResetOptions{
 		ID:             opts.ID,
@@ -190,6 +190,20 @@ func (mr *MockDatabaseSeriesMockRecorder) NumActiveBlocks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumActiveBlocks", reflect.TypeOf((*MockDatabaseSeries)(nil).NumActiveBlocks))
 }
 
+// LastWrite mocks base method
+func (m *MockDatabaseSeries) LastWrite() time.Time {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastWrite")
+	ret0, _ := ret[0].(time.Time)
+	return ret0
+}
+
+// LastWrite indicates an expected call of LastWrite
+func (mr *MockDatabaseSeriesMockRecorder) LastWrite() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastWrite", reflect.TypeOf((*MockDatabaseSeries)(nil).LastWrite))
+}
+
 // OnEvictedFromWiredList mocks base method
 func (m *MockDatabaseSeries) OnEvictedFromWiredList(arg0 ident.ID, arg1 time.Time) {
 	m.ctrl.T.Helper()
@@ -314,12 +328,13 @@ func (mr *MockDatabaseSeriesMockRecorder) WarmFlush(arg0, arg1, arg2, arg3 inter
 }
 
 // Write mocks base method
-func (m *MockDatabaseSeries) Write(arg0 context.Context, arg1 time.Time, arg2 float64, arg3 time0.Unit, arg4 []byte, arg5 WriteOptions) (bool, error) {
+func (m *MockDatabaseSeries) Write(arg0 context.Context, arg1 time.Time, arg2 float64, arg3 time0.Unit, arg4 []byte, arg5 WriteOptions) (bool, WriteDisposition, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Write", arg0, arg1, arg2, arg3, arg4, arg5)
 	ret0, _ := ret[0].(bool)
-	ret1, _ := ret[1].(error)
-	return ret0, ret1
+	ret1, _ := ret[1].(WriteDisposition)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
 }
 
 // Write indicates an expected call of Write
@@ -67,7 +67,8 @@ type DatabaseSeries interface {
 	// Tick executes async updates
 	Tick(blockStates ShardBlockStateSnapshot, nsCtx namespace.Context) (TickResult, error)
 
-	// Write writes a new value.
+	// Write writes a new value and returns whether the value was written
+	// along with how the value was applied.
 	Write(
 		ctx context.Context,
 		timestamp time.Time,
@@ -75,7 +76,7 @@ type DatabaseSeries interface {
 		unit xtime.Unit,
 		annotation []byte,
 		wOpts WriteOptions,
-	) (bool, error)
+	) (bool, WriteDisposition, error)
 
 	// ReadEncoded reads encoded blocks.
 	ReadEncoded(
@@ -114,6 +115,10 @@ type DatabaseSeries interface {
 	// NumActiveBlocks returns the number of active blocks the series currently holds.
 	NumActiveBlocks() int
 
+	// LastWrite returns when the series was last written to since it was
+	// loaded into memory, zero if it has not been written to.
+	LastWrite() time.Time
+
 	/// LoadBlock loads a single block into the series.
 	LoadBlock(
 		block block.DatabaseBlock,
@@ -341,6 +346,34 @@ type Options interface {
 	// ColdWritesEnabled returns whether cold writes are enabled.
 	ColdWritesEnabled() bool
 
+	// SetArchivalOptions sets the archival options of the namespace.
+	SetArchivalOptions(value namespace.ArchivalOptions) Options
+
+	// ArchivalOptions returns the archival options of the namespace.
+	ArchivalOptions() namespace.ArchivalOptions
+
+	// SetFutureWriteOptions sets the future write options of the namespace.
+	SetFutureWriteOptions(value namespace.FutureWriteOptions) Options
+
+	// FutureWriteOptions returns the future write options of the namespace.
+	FutureWriteOptions() namespace.FutureWriteOptions
+
+	// SetInMemory sets whether the namespace is purely in-memory, in which
+	// case buffered data is evicted once it falls out of retention rather
+	// than once it has been flushed.
+	SetInMemory(value bool) Options
+
+	// InMemory returns whether the namespace is purely in-memory.
+	InMemory() bool
+
+	// SetBufferWindow sets the runtime buffer past and buffer future of the
+	// namespace, overriding those of the retention options if set.
+	SetBufferWindow(value *BufferWindow) Options
+
+	// BufferWindow returns the runtime buffer past and buffer future of the
+	// namespace.
+	BufferWindow() *BufferWindow
+
 	// SetBufferBucketVersionsPool sets the BufferBucketVersionsPool.
 	SetBufferBucketVersionsPool(value *BufferBucketVersionsPool) Options
 
@@ -356,16 +389,20 @@ type Options interface {
 
 // Stats is passed down from namespace/shard to avoid allocations per series.
 type Stats struct {
-	encoderCreated tally.Counter
-	coldWrites     tally.Counter
+	encoderCreated       tally.Counter
+	coldWrites           tally.Counter
+	futureWritesRejected tally.Counter
+	futureWritesClamped  tally.Counter
 }
 
 // NewStats returns a new Stats for the provided scope.
 func NewStats(scope tally.Scope) Stats {
 	subScope := scope.SubScope("series")
 	return Stats{
-		encoderCreated: subScope.Counter("encoder-created"),
-		coldWrites:     subScope.Counter("cold-writes"),
+		encoderCreated:       subScope.Counter("encoder-created"),
+		coldWrites:           subScope.Counter("cold-writes"),
+		futureWritesRejected: subScope.Counter("future-writes-rejected"),
+		futureWritesClamped:  subScope.Counter("future-writes-clamped"),
 	}
 }
 
@@ -379,6 +416,16 @@ func (s Stats) IncColdWrites() {
 	s.coldWrites.Inc(1)
 }
 
+// IncFutureWritesRejected incs the FutureWritesRejected stat.
+func (s Stats) IncFutureWritesRejected() {
+	s.futureWritesRejected.Inc(1)
+}
+
+// IncFutureWritesClamped incs the FutureWritesClamped stat.
+func (s Stats) IncFutureWritesClamped() {
+	s.futureWritesClamped.Inc(1)
+}
+
 // WriteType is an enum for warm/cold write types.
 type WriteType int
 
@@ -420,6 +467,12 @@ type WriteOptions struct {
 	// bootstrappers filling data that they know has not yet been flushed to
 	// disk.
 	BootstrapWrite bool
+	// BackfillWrite allows a write outside of the buffer past window for
+	// backfilling time windows that became valid after the namespace
+	// retention period was extended. Like bootstrap writes, these are warm
+	// writes if the block hasn't already been flushed to disk and cold writes
+	// otherwise, regardless of whether cold writes are enabled.
+	BackfillWrite bool
 	// SkipOutOfRetention allows for skipping writes that are out of retention
 	// by just returning success, this allows for callers to not have to
 	// deal with clock skew when they are trying to write a value that may not
@@ -0,0 +1,128 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package series
+
+// WriteDisposition describes how a datapoint was applied by a write, which
+// allows callers to track the fidelity of the data they ingest.
+type WriteDisposition uint8
+
+const (
+	// WriteDispositionUnknown indicates the disposition of the datapoint is
+	// not known at the time the write returns, such as for writes that are
+	// applied asynchronously.
+	WriteDispositionUnknown WriteDisposition = iota
+
+	// WriteAccepted indicates the datapoint was written as is.
+	WriteAccepted
+
+	// WriteClamped indicates the timestamp of the datapoint was clamped to
+	// the future write limit of the namespace before being written.
+	WriteClamped
+
+	// WriteDuplicateOverwritten indicates the datapoint replaced a buffered
+	// datapoint with the same timestamp. Detection is best-effort: only the
+	// most recent datapoint of each in-order encoder of the buffer is
+	// compared, so a datapoint that replaces an older buffered datapoint or a
+	// flushed datapoint is reported as accepted.
+	WriteDuplicateOverwritten
+
+	// WriteRejectedTooOld indicates the datapoint was rejected or skipped
+	// for being too far in the past.
+	WriteRejectedTooOld
+
+	// WriteDropped indicates the datapoint was dropped by a write interceptor.
+	WriteDropped
+
+	// WriteDuplicate indicates the datapoint matched the value of a buffered
+	// datapoint with the same timestamp and was not written since it would
+	// not change the series. Detection is best-effort in the same way as for
+	// WriteDuplicateOverwritten.
+	WriteDuplicate
+
+	// WriteRejectedTooFuture indicates the datapoint was rejected or skipped
+	// for being too far in the future.
+	WriteRejectedTooFuture
+
+	// WriteRejectedColdWritesDisabled indicates the datapoint was rejected
+	// since it would be a cold write into an already flushed block and cold
+	// writes are not enabled for the namespace.
+	WriteRejectedColdWritesDisabled
+)
+
+// ValidWriteDispositions returns the valid write dispositions.
+func ValidWriteDispositions() []WriteDisposition {
+	return []WriteDisposition{
+		WriteDispositionUnknown,
+		WriteAccepted,
+		WriteClamped,
+		WriteDuplicateOverwritten,
+		WriteRejectedTooOld,
+		WriteDropped,
+		WriteDuplicate,
+		WriteRejectedTooFuture,
+		WriteRejectedColdWritesDisabled,
+	}
+}
+
+func (d WriteDisposition) String() string {
+	switch d {
+	case WriteDispositionUnknown:
+		return "unknown"
+	case WriteAccepted:
+		return "accepted"
+	case WriteClamped:
+		return "clamped"
+	case WriteDuplicateOverwritten:
+		return "duplicate-overwritten"
+	case WriteRejectedTooOld:
+		return "rejected-too-old"
+	case WriteDropped:
+		return "dropped"
+	case WriteDuplicate:
+		return "duplicate"
+	case WriteRejectedTooFuture:
+		return "rejected-too-future"
+	case WriteRejectedColdWritesDisabled:
+		return "rejected-cold-writes-disabled"
+	default:
+		// Should never get here.
+		return "unknown"
+	}
+}
+
+// MarshalText marshals the write disposition as its string representation.
+func (d WriteDisposition) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText unmarshals a write disposition from its string
+// representation, unrecognized values unmarshal as unknown.
+func (d *WriteDisposition) UnmarshalText(text []byte) error {
+	str := string(text)
+	for _, valid := range ValidWriteDispositions() {
+		if str == valid.String() {
+			*d = valid
+			return nil
+		}
+	}
+
+	*d = WriteDispositionUnknown
+	return nil
+}
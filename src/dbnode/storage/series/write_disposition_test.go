@@ -0,0 +1,47 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package series
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWriteDispositionJSONRoundTrip(t *testing.T) {
+	for _, value := range ValidWriteDispositions() {
+		data, err := json.Marshal(value)
+		require.NoError(t, err)
+
+		var decoded WriteDisposition
+		require.NoError(t, json.Unmarshal(data, &decoded))
+		assert.Equal(t, value, decoded)
+	}
+
+	data, err := json.Marshal(WriteDuplicateOverwritten)
+	require.NoError(t, err)
+	assert.Equal(t, `"duplicate-overwritten"`, string(data))
+
+	var decoded WriteDisposition
+	require.NoError(t, json.Unmarshal([]byte(`"not-a-disposition"`), &decoded))
+	assert.Equal(t, WriteDispositionUnknown, decoded)
+}
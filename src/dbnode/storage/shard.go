@@ -27,17 +27,20 @@ import (
 	"io"
 	"math"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/generated/proto/pagetoken"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/retention"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/index/convert"
 	"github.com/m3db/m3/src/dbnode/storage/repair"
@@ -52,6 +55,7 @@ import (
 	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
+	"github.com/m3db/m3/src/x/pool"
 	xtime "github.com/m3db/m3/src/x/time"
 
 	"github.com/gogo/protobuf/proto"
@@ -62,6 +66,7 @@ import (
 const (
 	shardIterateBatchPercent = 0.01
 	shardIterateBatchMinSize = 16
+	shardIndexBatchPoolSize  = 4
 )
 
 var (
@@ -77,6 +82,7 @@ var (
 	errFlushStateIsNotInitialized          = errors.New("shard flush state is not initialized")
 	errFlushStateAlreadyInitialized        = errors.New("shard flush state is already initialized")
 	errTriedToLoadNilSeries                = errors.New("tried to load nil series into shard")
+	errShardBlockNotFlushedToImport        = errors.New("shard block has not been flushed, unable to import")
 
 	// ErrDatabaseLoadLimitHit is the error returned when the database load limit
 	// is hit or exceeded.
@@ -159,12 +165,18 @@ type dbShard struct {
 	namespaceReaderMgr       databaseNamespaceReaderManager
 	increasingIndex          increasingIndex
 	seriesPool               series.DatabaseSeriesPool
-	reverseIndex             namespaceIndex
+	reverseIndex             *namespaceIndexRef
+	freezes                  *namespaceFreezes
 	insertQueue              *dbShardInsertQueue
+	newSeriesLimiter         *shardNewSeriesLimiter
+	errorBudget              *shardErrorBudget
+	indexBatchPool           *index.WriteBatchPool
 	lookup                   *shardMap
 	list                     *list.List
+	seriesStripes            *shardSeriesStripes
 	bootstrapState           BootstrapState
 	newMergerFn              fs.NewMergerFn
+	newRollupFn              fs.NewRollupFn
 	newFSMergeWithMemFn      newFSMergeWithMemFn
 	filesetsFn               filesetsFn
 	filesetPathsBeforeFn     filesetPathsBeforeFn
@@ -181,6 +193,28 @@ type dbShard struct {
 	metrics                  dbShardMetrics
 	ticking                  bool
 	shard                    uint32
+	// writeNewSeriesAsync mirrors the runtime option of the same name so
+	// it can be read atomically by writes that bypass the shard lock.
+	writeNewSeriesAsync int32
+	// indexFieldsAlloc is only accessed by the insert queue when inserting
+	// batches of series and does not require synchronization.
+	indexFieldsAlloc *index.FieldsAllocator
+	// seriesBytesArena allocates the long lived series ID and tag bytes of
+	// new series and is safe for concurrent use.
+	seriesBytesArena *convert.BytesArena
+	// seriesFilter holds a *shardSeriesFilter of the IDs of inserted series,
+	// it is swapped out when rebuilt during a tick.
+	seriesFilter atomic.Value
+	// splitBlocks holds the blocks of a shard sharing its ID with its parent
+	// shard whose filesets have been rewritten without the series of the
+	// other children of the parent shard, it is only accessed when filesets
+	// are split by the flush manager and does not require synchronization.
+	splitBlocks map[xtime.UnixNano]struct{}
+	// epoch identifies this incarnation of the shard and is encoded in
+	// blocks metadata page tokens so that cursors into the in memory
+	// series list are not trusted once the node restarts or the shard is
+	// reassigned.
+	epoch int64
 }
 
 // NB(r): dbShardRuntimeOptions does not contain its own
@@ -201,7 +235,11 @@ type dbShardMetrics struct {
 	closeLatency            tally.Timer
 	insertAsyncInsertErrors tally.Counter
 	insertAsyncWriteErrors  tally.Counter
+	newSeriesThrottled      tally.Counter
 	seriesTicked            tally.Gauge
+	pageTokenEpochResets    tally.Counter
+	pageTokenBlockSkips     tally.Counter
+	degraded                tally.Counter
 }
 
 func newDatabaseShardMetrics(shardID uint32, scope tally.Scope) dbShardMetrics {
@@ -216,9 +254,19 @@ func newDatabaseShardMetrics(shardID uint32, scope tally.Scope) dbShardMetrics {
 		insertAsyncWriteErrors: scope.Tagged(map[string]string{
 			"error_type": "write-value",
 		}).Counter("insert-async.errors"),
+		newSeriesThrottled: scope.Counter("new-series-throttled"),
 		seriesTicked: scope.Tagged(map[string]string{
 			"shard": fmt.Sprintf("%d", shardID),
 		}).Gauge("series-ticked"),
+		pageTokenEpochResets: scope.Tagged(map[string]string{
+			"reason": "epoch-mismatch",
+		}).Counter("page-token.resumes"),
+		pageTokenBlockSkips: scope.Tagged(map[string]string{
+			"reason": "block-missing",
+		}).Counter("page-token.resumes"),
+		degraded: scope.Tagged(map[string]string{
+			"shard": fmt.Sprintf("%d", shardID),
+		}).Counter("degraded"),
 	}
 }
 
@@ -246,7 +294,8 @@ func newDatabaseShard(
 	blockRetriever block.DatabaseBlockRetriever,
 	namespaceReaderMgr databaseNamespaceReaderManager,
 	increasingIndex increasingIndex,
-	reverseIndex namespaceIndex,
+	reverseIndex *namespaceIndexRef,
+	freezes *namespaceFreezes,
 	needsBootstrap bool,
 	opts Options,
 	seriesOpts series.Options,
@@ -254,6 +303,8 @@ func newDatabaseShard(
 	scope := opts.InstrumentOptions().MetricsScope().
 		SubScope("dbshard")
 
+	deleteFilesFn := newPurgeRecordingDeleteFilesFn(opts.PurgeReporter(),
+		purgedFileTypeOf(PurgedDataFileSet), fs.DeleteFiles)
 	s := &dbShard{
 		opts:                 opts,
 		seriesOpts:           seriesOpts,
@@ -265,15 +316,18 @@ func newDatabaseShard(
 		increasingIndex:      increasingIndex,
 		seriesPool:           opts.DatabaseSeriesPool(),
 		reverseIndex:         reverseIndex,
+		freezes:              freezes,
 		lookup:               newShardMap(shardMapOptions{}),
 		list:                 list.New(),
+		seriesStripes:        newShardSeriesStripes(defaultShardSeriesStripes),
 		newMergerFn:          fs.NewMerger,
+		newRollupFn:          fs.NewRollup,
 		newFSMergeWithMemFn:  newFSMergeWithMem,
 		filesetsFn:           fs.DataFiles,
 		filesetPathsBeforeFn: fs.DataFileSetsBefore,
-		deleteFilesFn:        fs.DeleteFiles,
+		deleteFilesFn:        deleteFilesFn,
 		snapshotFilesFn:      fs.SnapshotFiles,
-		sleepFn:              time.Sleep,
+		sleepFn:              opts.ClockOptions().Scheduler().Sleep,
 		identifierPool:       opts.IdentifierPool(),
 		contextPool:          opts.ContextPool(),
 		flushState:           newShardFlushState(),
@@ -281,8 +335,23 @@ func newDatabaseShard(
 		logger:               opts.InstrumentOptions().Logger(),
 		metrics:              newDatabaseShardMetrics(shard, scope),
 	}
+	s.epoch = s.nowFn().UnixNano()
 	s.insertQueue = newDatabaseShardInsertQueue(s.insertSeriesBatch,
 		s.nowFn, scope)
+	s.newSeriesLimiter = newShardNewSeriesLimiter(s.nowFn)
+	s.errorBudget = newShardErrorBudget(s.nowFn)
+
+	indexBatchPoolOpts := pool.NewObjectPoolOptions().
+		SetSize(shardIndexBatchPoolSize).
+		SetInstrumentOptions(opts.InstrumentOptions().SetMetricsScope(
+			scope.SubScope("index-batch-pool")))
+	s.indexBatchPool = index.NewWriteBatchPool(index.WriteBatchOptions{
+		IndexBlockSize: namespaceMetadata.Options().IndexOptions().BlockSize(),
+	}, indexBatchPoolOpts)
+	s.indexBatchPool.Init()
+	s.indexFieldsAlloc = index.NewFieldsAllocator()
+	s.seriesBytesArena = convert.NewBytesArena()
+	s.seriesFilter.Store(newShardSeriesFilter(defaultShardSeriesFilterCapacity))
 
 	registerRuntimeOptionsListener := func(listener runtime.OptionsListener) {
 		elem := opts.RuntimeOptionsManager().RegisterListener(listener)
@@ -290,6 +359,8 @@ func newDatabaseShard(
 	}
 	registerRuntimeOptionsListener(s)
 	registerRuntimeOptionsListener(s.insertQueue)
+	registerRuntimeOptionsListener(s.newSeriesLimiter)
+	registerRuntimeOptionsListener(s.errorBudget)
 
 	// Start the insert queue after registering runtime options listeners
 	// that may immediately fire with values
@@ -324,6 +395,11 @@ func (s *dbShard) SetRuntimeOptions(value runtime.Options) {
 		tickSleepSeriesBatchSize: value.TickSeriesBatchSize(),
 		tickSleepPerSeries:       value.TickPerSeriesSleepDuration(),
 	}
+	var writeNewSeriesAsync int32
+	if value.WriteNewSeriesAsync() {
+		writeNewSeriesAsync = 1
+	}
+	atomic.StoreInt32(&s.writeNewSeriesAsync, writeNewSeriesAsync)
 	s.Unlock()
 }
 
@@ -595,6 +671,9 @@ func (s *dbShard) Close() error {
 		return errShardNotOpen
 	}
 	s.state = dbShardStateClosing
+	// Ensure writes can no longer resolve series without observing the
+	// shard is closing.
+	s.seriesStripes.Reset()
 	s.Unlock()
 
 	s.insertQueue.Stop()
@@ -690,6 +769,7 @@ func (s *dbShard) tickAndExpire(
 	s.RLock()
 	tickSleepBatch := s.currRuntimeOptions.tickSleepSeriesBatchSize
 	tickSleepPerSeries := s.currRuntimeOptions.tickSleepPerSeries
+	tickLoad := s.opts.TickLoadMonitor()
 	// Use blockStatesSnapshotWithRLock here to prevent nested read locks.
 	// Nested read locks will cause deadlocks if there is write lock attempt in
 	// between the nested read locks, since the write lock attempt will block
@@ -720,8 +800,11 @@ func (s *dbShard) tickAndExpire(
 				}
 				// Expose shard level Tick() progress externally.
 				s.metrics.seriesTicked.Update(float64(i))
-				// Throttle the tick
+				// Throttle the tick, sleeping longer when the node is under load
 				sleepFor := time.Duration(tickSleepBatch) * tickSleepPerSeries
+				if tickLoad.Enabled() {
+					sleepFor = time.Duration(float64(sleepFor) * tickLoad.SlowdownFactor())
+				}
 				s.sleepFn(sleepFor)
 				slept += sleepFor
 			}
@@ -772,9 +855,31 @@ func (s *dbShard) tickAndExpire(
 		return tickResult{}, errShardClosingTickTerminated
 	}
 
+	if policy == tickPolicyRegular && s.loadSeriesFilter().NeedsRebuild() {
+		s.rebuildSeriesFilter()
+	}
+
 	return r, nil
 }
 
+func (s *dbShard) loadSeriesFilter() *shardSeriesFilter {
+	return s.seriesFilter.Load().(*shardSeriesFilter)
+}
+
+// rebuildSeriesFilter resizes the series filter for the current number of
+// series, this also drops the IDs of any series that have been purged.
+func (s *dbShard) rebuildSeriesFilter() {
+	// Hold the read lock for the duration of the rebuild so that no series
+	// can be inserted, and therefore missed, before the filter is swapped.
+	s.RLock()
+	filter := newShardSeriesFilter(2 * s.list.Len())
+	for elem := s.list.Front(); elem != nil; elem = elem.Next() {
+		filter.Add(elem.Value.(*lookup.Entry).Series.ID())
+	}
+	s.seriesFilter.Store(filter)
+	s.RUnlock()
+}
+
 // NB(prateek): purgeExpiredSeries requires that all entries passed to it have at least one reader/writer,
 // i.e. have a readWriteCount of at least 1.
 // Currently, this function is only called by the lambda inside `tickAndExpire`'s `forEachShardEntryBatch`
@@ -791,22 +896,28 @@ func (s *dbShard) purgeExpiredSeries(expiredEntries []*lookup.Entry) {
 			continue
 		}
 
-		count := entry.ReaderWriterCount()
-		// The contract requires all entries to have count >= 1.
-		if count < 1 {
-			s.logger.Error("purgeExpiredSeries encountered invalid series read/write count",
-				zap.String("series", series.ID().String()),
-				zap.Int32("readerWriterCount", count))
-			continue
-		}
-		// If this series is currently being written to or read from, we don't
-		// remove to ensure a consistent view of the series to other users.
-		if count > 1 {
-			continue
-		}
-		// If there have been datapoints written to the series since its
-		// last empty check, we don't remove it.
-		if !series.IsEmpty() {
+		// NB: The check is performed holding the series stripe lock so that
+		// writes which bypass the shard lock cannot take a reference to the
+		// series while it is being removed.
+		removed := s.seriesStripes.RemoveIf(id, entry, func() bool {
+			count := entry.ReaderWriterCount()
+			// The contract requires all entries to have count >= 1.
+			if count < 1 {
+				s.logger.Error("purgeExpiredSeries encountered invalid series read/write count",
+					zap.String("series", series.ID().String()),
+					zap.Int32("readerWriterCount", count))
+				return false
+			}
+			// If this series is currently being written to or read from, we don't
+			// remove to ensure a consistent view of the series to other users.
+			if count > 1 {
+				return false
+			}
+			// If there have been datapoints written to the series since its
+			// last empty check, we don't remove it.
+			return series.IsEmpty()
+		})
+		if !removed {
 			continue
 		}
 		// NB(xichen): if we get here, we are guaranteed that there can be
@@ -828,7 +939,7 @@ func (s *dbShard) WriteTagged(
 	unit xtime.Unit,
 	annotation []byte,
 	wOpts series.WriteOptions,
-) (ts.Series, bool, error) {
+) (ts.Series, bool, series.WriteDisposition, error) {
 	return s.writeAndIndex(ctx, id, tags, timestamp,
 		value, unit, annotation, wOpts, true)
 }
@@ -841,7 +952,7 @@ func (s *dbShard) Write(
 	unit xtime.Unit,
 	annotation []byte,
 	wOpts series.WriteOptions,
-) (ts.Series, bool, error) {
+) (ts.Series, bool, series.WriteDisposition, error) {
 	return s.writeAndIndex(ctx, id, ident.EmptyTagIterator, timestamp,
 		value, unit, annotation, wOpts, false)
 }
@@ -856,15 +967,23 @@ func (s *dbShard) writeAndIndex(
 	annotation []byte,
 	wOpts series.WriteOptions,
 	shouldReverseIndex bool,
-) (ts.Series, bool, error) {
+) (ts.Series, bool, series.WriteDisposition, error) {
 	// Prepare write
 	entry, opts, err := s.tryRetrieveWritableSeries(id)
 	if err != nil {
-		return ts.Series{}, false, err
+		return ts.Series{}, false, series.WriteDispositionUnknown, err
 	}
 
 	writable := entry != nil
 
+	// Reject writes for new series before allocating anything for them if
+	// the shard has exhausted its budget for creating new series.
+	if !writable && !s.newSeriesLimiter.admit() {
+		s.metrics.newSeriesThrottled.Inc(1)
+		return ts.Series{}, false, series.WriteDispositionUnknown,
+			dberrors.NewCardinalityThrottledError(s.shard)
+	}
+
 	// If no entry and we are not writing new series asynchronously.
 	if !writable && !opts.writeNewSeriesAsync {
 		// Avoid double lookup by enqueueing insert immediately.
@@ -876,7 +995,7 @@ func (s *dbShard) writeAndIndex(
 			},
 		})
 		if err != nil {
-			return ts.Series{}, false, err
+			return ts.Series{}, false, series.WriteDispositionUnknown, err
 		}
 
 		// Wait for the insert to be batched together and inserted
@@ -885,7 +1004,7 @@ func (s *dbShard) writeAndIndex(
 		// Retrieve the inserted entry
 		entry, err = s.writableSeries(id, tags)
 		if err != nil {
-			return ts.Series{}, false, err
+			return ts.Series{}, false, series.WriteDispositionUnknown, err
 		}
 		writable = true
 
@@ -901,12 +1020,15 @@ func (s *dbShard) writeAndIndex(
 		// async, since there is no information about whether the write succeeded
 		// or not.
 		wasWritten = true
+		// Similarly the disposition of an async write is not known until the
+		// series has been inserted and the write applied.
+		disposition = series.WriteDispositionUnknown
 	)
 	if writable {
 		// Perform write. No need to copy the annotation here because we're using it
 		// synchronously and all downstream code will copy anthing they need to maintain
 		// a reference to.
-		wasWritten, err = entry.Series.Write(ctx, timestamp, value, unit, annotation, wOpts)
+		wasWritten, disposition, err = entry.Series.Write(ctx, timestamp, value, unit, annotation, wOpts)
 		// Load series metadata before decrementing the writer count
 		// to ensure this metadata is snapshotted at a consistent state
 		// NB(r): We explicitly do not place the series ID back into a
@@ -917,15 +1039,17 @@ func (s *dbShard) writeAndIndex(
 		commitLogSeriesTags = entry.Series.Tags()
 		commitLogSeriesUniqueIndex = entry.Index
 		if err == nil && shouldReverseIndex {
-			if entry.NeedsIndexUpdate(s.reverseIndex.BlockStartForWriteTime(timestamp)) {
-				err = s.insertSeriesForIndexingAsyncBatched(entry, timestamp,
-					opts.writeNewSeriesAsync)
+			if entry.NeedsIndexUpdate(s.reverseIndex.get().BlockStartForWriteTime(timestamp)) {
+				if err = s.insertSeriesForIndexingAsyncBatched(entry, timestamp,
+					opts.writeNewSeriesAsync); err != nil {
+					err = dberrors.NewIndexError(err)
+				}
 			}
 		}
 		// release the reference we got on entry from `writableSeries`
 		entry.DecrementReaderWriterCount()
 		if err != nil {
-			return ts.Series{}, false, err
+			return ts.Series{}, false, disposition, err
 		}
 	} else {
 		// This is an asynchronous insert and write which means we need to clone the annotation
@@ -955,7 +1079,7 @@ func (s *dbShard) writeAndIndex(
 			},
 		})
 		if err != nil {
-			return ts.Series{}, false, err
+			return ts.Series{}, false, series.WriteDispositionUnknown, err
 		}
 		// NB(r): Make sure to use the copied ID which will eventually
 		// be set to the newly series inserted ID.
@@ -976,7 +1100,7 @@ func (s *dbShard) writeAndIndex(
 		Shard:       s.shard,
 	}
 
-	return series, wasWritten, nil
+	return series, wasWritten, disposition, nil
 }
 
 func (s *dbShard) SeriesReadWriteRef(
@@ -1031,6 +1155,17 @@ func (s *dbShard) ReadEncoded(
 	id ident.ID,
 	start, end time.Time,
 	nsCtx namespace.Context,
+) ([][]xio.BlockReader, error) {
+	res, err := s.readEncoded(ctx, id, start, end, nsCtx)
+	s.recordReadError(err)
+	return res, err
+}
+
+func (s *dbShard) readEncoded(
+	ctx context.Context,
+	id ident.ID,
+	start, end time.Time,
+	nsCtx namespace.Context,
 ) ([][]xio.BlockReader, error) {
 	s.RLock()
 	entry, _, err := s.lookupEntryWithLock(id)
@@ -1063,6 +1198,41 @@ func (s *dbShard) ReadEncoded(
 	return reader.ReadEncoded(ctx, start, end, nsCtx)
 }
 
+// IsDegraded returns whether the shard has exhausted its read error budget
+// and is excluded from serving reads.
+func (s *dbShard) IsDegraded() bool {
+	return s.errorBudget.isDegraded()
+}
+
+// recordReadError counts a failed read against the error budget of the
+// shard, alerting operators when the shard becomes degraded as a result.
+func (s *dbShard) recordReadError(err error) {
+	if err == nil || !s.errorBudget.record(err) {
+		return
+	}
+
+	budget := s.opts.RuntimeOptionsManager().Get().ShardErrorBudgetOptions()
+	s.metrics.degraded.Inc(1)
+	s.logger.Error("shard exceeded read error budget, isolating shard from reads",
+		zap.String("namespace", s.namespace.ID().String()),
+		zap.Uint32("shard", s.shard),
+		zap.Int("maxErrors", budget.MaxErrors),
+		zap.Duration("window", budget.Window),
+		zap.Duration("isolationPeriod", budget.IsolationPeriod),
+		zap.Error(err))
+	s.opts.Notifier().Notify(notify.Event{
+		Type:      notify.EventTypeShardDegraded,
+		Time:      s.nowFn(),
+		Namespace: s.namespace.ID().String(),
+		Message:   "shard exceeded read error budget and is isolated from reads",
+		Fields: map[string]interface{}{
+			"shard":           s.shard,
+			"error":           err.Error(),
+			"isolationPeriod": budget.IsolationPeriod.String(),
+		},
+	})
+}
+
 // lookupEntryWithLock returns the entry for a given id while holding a read lock or a write lock.
 func (s *dbShard) lookupEntryWithLock(id ident.ID) (*lookup.Entry, *list.Element, error) {
 	if s.state != dbShardStateOpen {
@@ -1107,6 +1277,23 @@ func (s *dbShard) tryRetrieveWritableSeries(id ident.ID) (
 	writableSeriesOptions,
 	error,
 ) {
+	// Fast path for series that are definitely new that avoids taking either
+	// a stripe lock or the shard lock to resolve them.
+	if !s.loadSeriesFilter().MayContain(id) {
+		opts := writableSeriesOptions{
+			writeNewSeriesAsync: atomic.LoadInt32(&s.writeNewSeriesAsync) == 1,
+		}
+		return nil, opts, nil
+	}
+
+	// Fast path for existing series that avoids contending on the shard lock.
+	if entry, ok := s.seriesStripes.TryIncrementReaderWriterCount(id); ok {
+		opts := writableSeriesOptions{
+			writeNewSeriesAsync: atomic.LoadInt32(&s.writeNewSeriesAsync) == 1,
+		}
+		return entry, opts, nil
+	}
+
 	s.RLock()
 	opts := writableSeriesOptions{
 		writeNewSeriesAsync: s.currRuntimeOptions.writeNewSeriesAsync,
@@ -1145,7 +1332,7 @@ func (s *dbShard) newShardEntry(
 		// required.
 		seriesID = ident.BytesID(id.Bytes())
 	} else {
-		seriesID = ident.BytesID(append([]byte(nil), id.Bytes()...))
+		seriesID = ident.BytesID(s.seriesBytesArena.Copy(id.Bytes()))
 		seriesID.NoFinalize()
 	}
 
@@ -1160,10 +1347,12 @@ func (s *dbShard) newShardEntry(
 			return nil, errNewShardEntryTagsIterNotAtIndexZero
 		}
 
-		// Pass nil for the identifier pool because the pool will force us to use an array
+		// Avoid the identifier pool because the pool will force us to use an array
 		// with a large capacity to store the tags. Since these tags are long-lived, it's
-		// better to allocate an array of the exact size to save memory.
-		seriesTags, err = convert.TagsFromTagsIter(seriesID, tagsIter, nil)
+		// better to allocate an array of the exact size to save memory and to copy
+		// the tag bytes from the arena to reduce the number of objects the GC scans.
+		seriesTags, err = convert.TagsFromTagsIterWithArena(seriesID, tagsIter,
+			s.seriesBytesArena)
 		tagsIter.Close()
 		if err != nil {
 			return nil, err
@@ -1212,7 +1401,7 @@ func (s *dbShard) insertSeriesForIndexingAsyncBatched(
 	timestamp time.Time,
 	async bool,
 ) error {
-	indexBlockStart := s.reverseIndex.BlockStartForWriteTime(timestamp)
+	indexBlockStart := s.reverseIndex.get().BlockStartForWriteTime(timestamp)
 	// inc a ref on the entry to ensure it's valid until the queue acts upon it.
 	entry.OnIndexPrepare()
 	wg, err := s.insertQueue.Insert(dbShardInsert{
@@ -1366,13 +1555,12 @@ func (s *dbShard) insertNewShardEntryWithLock(entry *lookup.Entry) {
 		NoCopyKey:     true,
 		NoFinalizeKey: true,
 	})
+	s.seriesStripes.Add(copiedID, entry)
+	s.loadSeriesFilter().Add(copiedID)
 }
 
 func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
-	var (
-		anyPendingAction   = false
-		numPendingIndexing = 0
-	)
+	anyPendingAction := false
 
 	s.Lock()
 	for i := range inserts {
@@ -1385,10 +1573,6 @@ func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
 		anyPendingAction = anyPendingAction || hasPendingWrite ||
 			hasPendingRetrievedBlock || hasPendingIndexing
 
-		if hasPendingIndexing {
-			numPendingIndexing++
-		}
-
 		// we don't need to inc the entry ref count if we already have a ref on the entry. check if
 		// that's the case.
 		if inserts[i].opts.entryRefCountIncremented {
@@ -1440,12 +1624,8 @@ func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
 
 	// Perform any indexing, pending writes or pending retrieved blocks outside of lock
 	ctx := s.contextPool.Get()
-	// TODO(prateek): pool this type
-	indexBlockSize := s.namespace.Options().IndexOptions().BlockSize()
-	indexBatch := index.NewWriteBatch(index.WriteBatchOptions{
-		InitialCapacity: numPendingIndexing,
-		IndexBlockSize:  indexBlockSize,
-	})
+	indexBatch := s.indexBatchPool.Get()
+	defer indexBatch.DecRef()
 	for i := range inserts {
 		var (
 			entry           = inserts[i].entry
@@ -1462,7 +1642,7 @@ func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
 			// operation and there is nothing further to do with this value.
 			// TODO: Consider propagating the `wasWritten` argument back to the caller
 			// using waitgroup (or otherwise) in the future.
-			_, err := entry.Series.Write(ctx, write.timestamp, write.value,
+			_, _, err := entry.Series.Write(ctx, write.timestamp, write.value,
 				write.unit, annotationBytes, write.opts)
 			if err != nil {
 				s.metrics.insertAsyncWriteErrors.Inc(1)
@@ -1488,7 +1668,7 @@ func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
 
 			var d doc.Document
 			d.ID = id.Bytes() // IDs from shard entries are always set NoFinalize
-			d.Fields = make(doc.Fields, 0, len(tags))
+			d.Fields = s.indexFieldsAlloc.Alloc(len(tags))
 			for _, tag := range tags {
 				d.Fields = append(d.Fields, doc.Field{
 					Name:  tag.Name.Bytes(),  // Tags from shard entries are always set NoFinalize
@@ -1515,7 +1695,7 @@ func (s *dbShard) insertSeriesBatch(inserts []dbShardInsert) error {
 	var err error
 	// index all requested entries in batch.
 	if indexBatch.Len() > 0 {
-		err = s.reverseIndex.WriteBatch(indexBatch)
+		err = s.reverseIndex.get().WriteBatch(indexBatch)
 	}
 
 	// Avoid goroutine spinning up to close this context
@@ -1529,6 +1709,17 @@ func (s *dbShard) FetchBlocks(
 	id ident.ID,
 	starts []time.Time,
 	nsCtx namespace.Context,
+) ([]block.FetchBlockResult, error) {
+	res, err := s.fetchBlocks(ctx, id, starts, nsCtx)
+	s.recordReadError(err)
+	return res, err
+}
+
+func (s *dbShard) fetchBlocks(
+	ctx context.Context,
+	id ident.ID,
+	starts []time.Time,
+	nsCtx namespace.Context,
 ) ([]block.FetchBlockResult, error) {
 	s.RLock()
 	entry, _, err := s.lookupEntryWithLock(id)
@@ -1587,17 +1778,18 @@ func (s *dbShard) fetchActiveBlocksMetadata(
 	limit int64,
 	indexCursor int64,
 	opts series.FetchBlocksMetadataOptions,
-) (block.FetchBlocksMetadataResults, *int64, error) {
+	fn block.FetchBlocksMetadataResultFn,
+) (*int64, error) {
 	var (
-		res             = s.opts.FetchBlocksMetadataResultsPool().Get()
 		fetchCtx        = s.contextPool.Get()
 		nextIndexCursor *int64
+		numResults      int64
 	)
 
 	var loopErr error
 	s.forEachShardEntry(func(entry *lookup.Entry) bool {
 		// Break out of the iteration loop once we've accumulated enough entries.
-		if int64(len(res.Results())) >= limit {
+		if numResults >= limit {
 			next := int64(entry.Index)
 			nextIndexCursor = &next
 			return false
@@ -1628,13 +1820,17 @@ func (s *dbShard) fetchActiveBlocksMetadata(
 			return true
 		}
 
-		// Otherwise add it to the result which takes care of closing the metadata
-		res.Add(metadata)
+		// Otherwise pass it on which takes care of closing the metadata
+		numResults++
+		if err := fn(metadata); err != nil {
+			loopErr = err
+			return false
+		}
 
 		return true
 	})
 
-	return res, nextIndexCursor, loopErr
+	return nextIndexCursor, loopErr
 }
 
 func (s *dbShard) FetchBlocksMetadataV2(
@@ -1644,10 +1840,31 @@ func (s *dbShard) FetchBlocksMetadataV2(
 	encodedPageToken PageToken,
 	opts block.FetchBlocksMetadataOptions,
 ) (block.FetchBlocksMetadataResults, PageToken, error) {
+	result := s.opts.FetchBlocksMetadataResultsPool().Get()
+	nextPageToken, err := s.StreamBlocksMetadataV2(ctx, start, end, limit,
+		encodedPageToken, opts, func(r block.FetchBlocksMetadataResult) error {
+			result.Add(r)
+			return nil
+		})
+	if err != nil {
+		result.Close()
+		return nil, nil, err
+	}
+	return result, nextPageToken, nil
+}
+
+func (s *dbShard) StreamBlocksMetadataV2(
+	ctx context.Context,
+	start, end time.Time,
+	limit int64,
+	encodedPageToken PageToken,
+	opts block.FetchBlocksMetadataOptions,
+	fn block.FetchBlocksMetadataResultFn,
+) (PageToken, error) {
 	token := new(pagetoken.PageToken)
 	if encodedPageToken != nil {
 		if err := proto.Unmarshal(encodedPageToken, token); err != nil {
-			return nil, nil, xerrors.NewInvalidParamsError(errShardInvalidPageToken)
+			return nil, xerrors.NewInvalidParamsError(errShardInvalidPageToken)
 		}
 	}
 
@@ -1679,16 +1896,26 @@ func (s *dbShard) FetchBlocksMetadataV2(
 		indexCursor := int64(0)
 		if activePhase != nil {
 			indexCursor = activePhase.IndexCursor
+			if activePhase.ShardEpoch != s.epoch {
+				// The cursor indexes into the series of a different incarnation
+				// of this shard, i.e. the node restarted or the shard was
+				// reassigned since the token was issued. Index values are not
+				// stable across incarnations so resuming from the cursor could
+				// skip series, restart the phase instead since duplicate
+				// metadata is merged by consumers.
+				indexCursor = 0
+				s.metrics.pageTokenEpochResets.Inc(1)
+			}
 		}
 		// We do not include cached blocks because we'll send metadata for
 		// those blocks when we send metadata directly from the flushed files.
 		seriesFetchBlocksMetadataOpts := series.FetchBlocksMetadataOptions{
 			FetchBlocksMetadataOptions: opts,
 		}
-		result, nextIndexCursor, err := s.fetchActiveBlocksMetadata(ctx, start, end,
-			limit, indexCursor, seriesFetchBlocksMetadataOpts)
+		nextIndexCursor, err := s.fetchActiveBlocksMetadata(ctx, start, end,
+			limit, indexCursor, seriesFetchBlocksMetadataOpts, fn)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		// Encode the next page token.
@@ -1702,22 +1929,22 @@ func (s *dbShard) FetchBlocksMetadataV2(
 			token = &pagetoken.PageToken{
 				ActiveSeriesPhase: &pagetoken.PageToken_ActiveSeriesPhase{
 					IndexCursor: *nextIndexCursor,
+					ShardEpoch:  s.epoch,
 				},
 			}
 		}
 
 		data, err := proto.Marshal(token)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
-		return result, PageToken(data), nil
+		return PageToken(data), nil
 	}
 
 	// Must be in the second phase, start with checking the latest possible
 	// flushed block and work backwards.
 	var (
-		result    = s.opts.FetchBlocksMetadataResultsPool().Get()
 		ropts     = s.namespace.Options().RetentionOptions()
 		blockSize = ropts.BlockSize()
 		// Subtract one blocksize because all fetch requests are exclusive on the end side.
@@ -1735,7 +1962,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 		!blockStart.Before(retention.FlushTimeStart(ropts, s.nowFn())) {
 		exists, err := s.namespaceReaderMgr.filesetExistsAt(s.shard, blockStart)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 		if !exists {
 			// No fileset files here.
@@ -1745,26 +1972,26 @@ func (s *dbShard) FetchBlocksMetadataV2(
 
 		var pos readerPosition
 		if !tokenBlockStart.IsZero() {
-			// Was previously seeking through a previous block, need to validate
-			// this is the correct one we found otherwise the file just went missing.
-			if !blockStart.Equal(tokenBlockStart) {
-				return nil, nil, fmt.Errorf(
-					"was reading block at %v but next available block is: %v",
-					tokenBlockStart, blockStart)
+			// Was previously seeking through a previous block, if the fileset
+			// files for that block went missing (e.g. expired by retention while
+			// the node was down) then every later block was already sent, so
+			// resume from the start of the next available earlier block.
+			if blockStart.Equal(tokenBlockStart) {
+				pos.metadataIdx = int(flushedPhase.CurrBlockEntryIdx)
+				pos.volume = int(flushedPhase.Volume)
+			} else {
+				s.metrics.pageTokenBlockSkips.Inc(1)
 			}
 
 			// Do not need to check if we move onto the next block that it matches
 			// the token's block start on next iteration.
 			tokenBlockStart = time.Time{}
-
-			pos.metadataIdx = int(flushedPhase.CurrBlockEntryIdx)
-			pos.volume = int(flushedPhase.Volume)
 		}
 
 		// Open a reader at this position, potentially from cache.
 		reader, err := s.namespaceReaderMgr.get(s.shard, blockStart, pos)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		for numResults < limit {
@@ -1772,7 +1999,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 			if err == io.EOF {
 				// Clean end of volume, we can break now.
 				if err := reader.Close(); err != nil {
-					return nil, nil, fmt.Errorf(
+					return nil, fmt.Errorf(
 						"could not close metadata reader for block %v: %v",
 						blockStart, err)
 				}
@@ -1783,7 +2010,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 				if err := reader.Close(); err != nil {
 					s.logger.Error("could not close reader on unexpected err", zap.Error(err))
 				}
-				return nil, nil, fmt.Errorf(
+				return nil, fmt.Errorf(
 					"could not read metadata for block %v: %v",
 					blockStart, err)
 			}
@@ -1802,8 +2029,14 @@ func (s *dbShard) FetchBlocksMetadataV2(
 			blockResult.Add(value)
 
 			numResults++
-			result.Add(block.NewFetchBlocksMetadataResult(id, tags,
-				blockResult))
+			err = fn(block.NewFetchBlocksMetadataResult(id, tags, blockResult))
+			if err != nil {
+				// Best effort to close the reader when unable to stream results.
+				if err := reader.Close(); err != nil {
+					s.logger.Error("could not close reader on unexpected err", zap.Error(err))
+				}
+				return nil, err
+			}
 		}
 
 		endPos := int64(reader.MetadataRead())
@@ -1819,7 +2052,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 		// the reader into a shared pool, don't use the reader after this call.
 		err = s.namespaceReaderMgr.put(reader)
 		if err != nil {
-			return nil, nil, err
+			return nil, err
 		}
 
 		if numResults >= limit {
@@ -1833,9 +2066,9 @@ func (s *dbShard) FetchBlocksMetadataV2(
 			}
 			data, err := proto.Marshal(token)
 			if err != nil {
-				return nil, nil, err
+				return nil, err
 			}
-			return result, PageToken(data), nil
+			return PageToken(data), nil
 		}
 
 		// Otherwise we move on to the previous block.
@@ -1843,7 +2076,7 @@ func (s *dbShard) FetchBlocksMetadataV2(
 	}
 
 	// No more results if we fall through.
-	return result, nil, nil
+	return nil, nil
 }
 
 func (s *dbShard) PrepareBootstrap() error {
@@ -1876,6 +2109,7 @@ func (s *dbShard) initializeFlushStates() {
 
 func (s *dbShard) UpdateFlushStates() {
 	fsOpts := s.opts.CommitLogOptions().FilesystemOptions()
+	blockSize := s.namespace.Options().RetentionOptions().BlockSize()
 	readInfoFilesResults := fs.ReadInfoFiles(fsOpts.FilePathPrefix(), s.namespace.ID(), s.shard,
 		fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
 
@@ -1890,22 +2124,65 @@ func (s *dbShard) UpdateFlushStates() {
 		}
 
 		info := result.Info
-		at := xtime.FromNanoseconds(info.BlockStart)
-		currState := s.flushStateNoBootstrapCheck(at)
-		if currState.WarmStatus != fileOpSuccess {
-			s.markWarmFlushStateSuccess(at)
+		// A compacted fileset holds the data of every block of its compacted
+		// block, so all of them are flushed at its volume.
+		fileSetStart := xtime.FromNanoseconds(info.BlockStart)
+		fileSetEnd := fileSetStart.Add(time.Duration(info.BlockSize))
+		for at := fileSetStart; at == fileSetStart || at.Before(fileSetEnd); at = at.Add(blockSize) {
+			currState := s.flushStateNoBootstrapCheck(at)
+			if currState.WarmStatus != fileOpSuccess {
+				s.markWarmFlushStateSuccess(at)
+			}
+
+			// Cold version needs to get bootstrapped so that the 1:1 relationship
+			// between volume number and cold version is maintained and the volume
+			// numbers / flush versions remain monotonically increasing.
+			//
+			// Note that there can be multiple info files for the same block, for
+			// example if the database didn't get to clean up compacted filesets
+			// before terminating.
+			if currState.ColdVersionRetrievable < info.VolumeIndex {
+				s.setFlushStateColdVersionRetrievable(at, info.VolumeIndex)
+				s.setFlushStateColdVersionFlushed(at, info.VolumeIndex)
+			}
 		}
+	}
 
-		// Cold version needs to get bootstrapped so that the 1:1 relationship
-		// between volume number and cold version is maintained and the volume
-		// numbers / flush versions remain monotonically increasing.
-		//
-		// Note that there can be multiple info files for the same block, for
-		// example if the database didn't get to clean up compacted filesets
-		// before terminating.
-		if currState.ColdVersionRetrievable < info.VolumeIndex {
-			s.setFlushStateColdVersionRetrievable(at, info.VolumeIndex)
-			s.setFlushStateColdVersionFlushed(at, info.VolumeIndex)
+	s.updateSplitSourceFlushStates()
+}
+
+// updateSplitSourceFlushStates marks the blocks of a shard that has not yet
+// been split from its parent shard that only the parent shard has filesets
+// for as flushed, the blocks are read from the filesets of the parent shard
+// until they are split into filesets of the shard.
+func (s *dbShard) updateSplitSourceFlushStates() {
+	fsOpts := s.opts.CommitLogOptions().FilesystemOptions()
+	parent, readInfoFilesResults, err := fs.ReadSplitSourceInfoFiles(fsOpts.FilePathPrefix(),
+		s.namespace, s.shard, fsOpts.InfoReaderBufferSize(), fsOpts.DecodingOptions())
+	if err != nil {
+		s.logger.Error("unable to read info files of parent shard in shard bootstrap",
+			zap.Uint32("shard", s.ID()),
+			zap.Stringer("namespace", s.namespace.ID()),
+			zap.Error(err))
+		return
+	}
+
+	for _, result := range readInfoFilesResults {
+		if err := result.Err.Error(); err != nil {
+			s.logger.Error("unable to read info files of parent shard in shard bootstrap",
+				zap.Uint32("shard", s.ID()),
+				zap.Uint32("parent", parent),
+				zap.Stringer("namespace", s.namespace.ID()),
+				zap.String("filepath", result.Err.Filepath()),
+				zap.Error(err))
+			continue
+		}
+
+		// The cold version of blocks read from the parent shard is left at
+		// zero, splitting a block writes the next volume of the shard.
+		at := xtime.FromNanoseconds(result.Info.BlockStart)
+		if s.flushStateNoBootstrapCheck(at).WarmStatus != fileOpSuccess {
+			s.markWarmFlushStateSuccess(at)
 		}
 	}
 }
@@ -2020,7 +2297,7 @@ func (s *dbShard) loadBlock(
 				// will happen while the write lock is held so that it can't immediately
 				// be expired.
 				insertType:      insertSyncIncReaderWriterCount,
-				hasPendingIndex: s.reverseIndex != nil,
+				hasPendingIndex: s.reverseIndex.get() != nil,
 				pendingIndex: dbShardPendingIndex{
 					timestamp:  timestamp,
 					enqueuedAt: s.nowFn(),
@@ -2059,8 +2336,8 @@ func (s *dbShard) loadBlock(
 	// Cannot close blocks once done as series takes ref to them.
 
 	// Check if needs to be reverse indexed.
-	if s.reverseIndex != nil &&
-		entry.NeedsIndexUpdate(s.reverseIndex.BlockStartForWriteTime(timestamp)) {
+	if idx := s.reverseIndex.get(); idx != nil &&
+		entry.NeedsIndexUpdate(idx.BlockStartForWriteTime(timestamp)) {
 		err = s.insertSeriesForIndexingAsyncBatched(entry, timestamp,
 			shardOpts.writeNewSeriesAsync)
 		if err != nil {
@@ -2194,6 +2471,7 @@ func (s *dbShard) ColdFlush(
 		// forEachShardEntry should not execute in parallel, but protect with a lock anyways for paranoia.
 		loopErrLock sync.Mutex
 		loopErr     error
+		blockSize   = s.namespace.Options().RetentionOptions().BlockSize()
 	)
 	// First, loop through all series to capture data on which blocks have dirty
 	// series and add them to the resources for further processing.
@@ -2215,6 +2493,11 @@ func (s *dbShard) ColdFlush(
 			if !hasWarmFlushed {
 				return
 			}
+			if s.freezes.IsBlockFrozen(t.ToTime(), blockSize) {
+				// Frozen blocks must not be rewritten, their cold writes
+				// are merged once the block is unfrozen.
+				return
+			}
 
 			seriesList := dirtySeriesToWrite[t]
 			if seriesList == nil {
@@ -2237,7 +2520,7 @@ func (s *dbShard) ColdFlush(
 		// may be non-empty when dirtySeries is empty because we purposely
 		// leave empty seriesLists in the dirtySeriesToWrite map to avoid having
 		// to reallocate them in subsequent usages of the shared resource.
-		return nil
+		return s.rollupFlush(flushPreparer, resources, nsCtx)
 	}
 
 	merger := s.newMergerFn(resources.fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
@@ -2249,6 +2532,18 @@ func (s *dbShard) ColdFlush(
 	// a block, we continue to try persisting other blocks.
 	for blockStart := range dirtySeriesToWrite {
 		startTime := blockStart.ToTime()
+		splitSource, err := s.splitSourceBlock(startTime)
+		if err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+		if splitSource {
+			// Blocks still read from the filesets of the parent shard have
+			// no fileset of this shard to merge with, their cold writes are
+			// merged once the block is split from the parent shard.
+			continue
+		}
+
 		coldVersion, err := s.RetrievableBlockColdVersion(startTime)
 		if err != nil {
 			multiErr = multiErr.Add(err)
@@ -2269,42 +2564,143 @@ func (s *dbShard) ColdFlush(
 			continue
 		}
 
-		// After writing the full block successfully update the ColdVersionFlushed number. This will
-		// allow the SeekerManager to open a lease on the latest version of the fileset files because
-		// the BlockLeaseVerifier will check the ColdVersionFlushed value, but the buffer only looks at
-		// ColdVersionRetrievable so a concurrent tick will not yet cause the blocks in memory to be
-		// evicted (which is the desired behavior because we haven't updated the open leases yet which
-		// means the newly written data is not available for querying via the SeekerManager yet.)
-		s.setFlushStateColdVersionFlushed(startTime, nextVersion)
+		if err := s.markColdVersionFlushed(startTime, nextVersion); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+	}
 
-		// Notify all block leasers that a new volume for the namespace/shard/blockstart
-		// has been created. This will block until all leasers have relinquished their
-		// leases.
-		_, err = s.opts.BlockLeaseManager().UpdateOpenLeases(block.LeaseDescriptor{
-			Namespace:  s.namespace.ID(),
-			Shard:      s.ID(),
-			BlockStart: startTime,
-		}, block.LeaseState{Volume: nextVersion})
-		// After writing the full block successfully **and** propagating the new lease to the
-		// BlockLeaseManager, update the ColdVersionRetrievable in the flush state. Once this function
-		// completes concurrent ticks will be able to evict the data from memory that was just flushed
-		// (which is now safe to do since the SeekerManager has been notified of the presence of new
-		// files).
-		//
-		// NB(rartoul): Ideally the ColdVersionRetrievable would only be updated if the call to UpdateOpenLeases
-		// succeeded, but that would allow the ColdVersionRetrievable and ColdVersionFlushed numbers to drift
-		// which would increase the complexity of the code to address a situation that is probably not
-		// recoverable (failure to UpdateOpenLeases is an invariant violated error).
-		s.setFlushStateColdVersionRetrievable(startTime, nextVersion)
-		if err != nil {
-			instrument.EmitAndLogInvariantViolation(s.opts.InstrumentOptions(), func(l *zap.Logger) {
-				l.With(
-					zap.String("namespace", s.namespace.ID().String()),
-					zap.Uint32("shard", s.ID()),
-					zap.Time("blockStart", startTime),
-					zap.Int("nextVersion", nextVersion),
-				).Error("failed to update open leases after updating flush state cold version")
-			})
+	// Roll up blocks after merging cold writes so that the rolled up volumes
+	// include any data merged above.
+	if err := s.rollupFlush(flushPreparer, resources, nsCtx); err != nil {
+		multiErr = multiErr.Add(err)
+	}
+
+	return multiErr.FinalError()
+}
+
+// markColdVersionFlushed updates the flush state of a block after a new
+// volume of it has been written and propagates the new volume to the block
+// leasers.
+func (s *dbShard) markColdVersionFlushed(startTime time.Time, nextVersion int) error {
+	// After writing the full block successfully update the ColdVersionFlushed number. This will
+	// allow the SeekerManager to open a lease on the latest version of the fileset files because
+	// the BlockLeaseVerifier will check the ColdVersionFlushed value, but the buffer only looks at
+	// ColdVersionRetrievable so a concurrent tick will not yet cause the blocks in memory to be
+	// evicted (which is the desired behavior because we haven't updated the open leases yet which
+	// means the newly written data is not available for querying via the SeekerManager yet.)
+	s.setFlushStateColdVersionFlushed(startTime, nextVersion)
+
+	// Notify all block leasers that a new volume for the namespace/shard/blockstart
+	// has been created. This will block until all leasers have relinquished their
+	// leases.
+	_, err := s.opts.BlockLeaseManager().UpdateOpenLeases(block.LeaseDescriptor{
+		Namespace:  s.namespace.ID(),
+		Shard:      s.ID(),
+		BlockStart: startTime,
+	}, block.LeaseState{Volume: nextVersion})
+	// After writing the full block successfully **and** propagating the new lease to the
+	// BlockLeaseManager, update the ColdVersionRetrievable in the flush state. Once this function
+	// completes concurrent ticks will be able to evict the data from memory that was just flushed
+	// (which is now safe to do since the SeekerManager has been notified of the presence of new
+	// files).
+	//
+	// NB(rartoul): Ideally the ColdVersionRetrievable would only be updated if the call to UpdateOpenLeases
+	// succeeded, but that would allow the ColdVersionRetrievable and ColdVersionFlushed numbers to drift
+	// which would increase the complexity of the code to address a situation that is probably not
+	// recoverable (failure to UpdateOpenLeases is an invariant violated error).
+	s.setFlushStateColdVersionRetrievable(startTime, nextVersion)
+	if err != nil {
+		instrument.EmitAndLogInvariantViolation(s.opts.InstrumentOptions(), func(l *zap.Logger) {
+			l.With(
+				zap.String("namespace", s.namespace.ID().String()),
+				zap.Uint32("shard", s.ID()),
+				zap.Time("blockStart", startTime),
+				zap.Int("nextVersion", nextVersion),
+			).Error("failed to update open leases after updating flush state cold version")
+		})
+		return err
+	}
+	return nil
+}
+
+// rollupFlush rolls up the filesets of warm flushed blocks that have aged
+// into a coarser retention tier of the namespace, writing each rolled up
+// block as the next volume of its fileset.
+func (s *dbShard) rollupFlush(
+	flushPreparer persist.FlushPreparer,
+	resources coldFlushReuseableResources,
+	nsCtx namespace.Context,
+) error {
+	nsOpts := s.namespace.Options()
+	tiers := nsOpts.RetentionTiers()
+	if len(tiers) == 0 {
+		return nil
+	}
+
+	type rollupBlock struct {
+		blockStart  time.Time
+		coldVersion int
+		resolution  time.Duration
+	}
+	var (
+		blockSize    = nsOpts.RetentionOptions().BlockSize()
+		archivalOpts = nsOpts.ArchivalOptions()
+		now          = s.nowFn()
+		toRollup     []rollupBlock
+	)
+	s.flushState.RLock()
+	for t, state := range s.flushState.statesByTime {
+		if !statusIsRetrievable(state.WarmStatus) {
+			continue
+		}
+		blockStart := t.ToTime()
+		if archivalOpts.IsBlockImmutable(blockStart, blockSize, now) {
+			// Archived blocks are immutable and must not be rewritten.
+			continue
+		}
+		if s.freezes.IsBlockFrozen(blockStart, blockSize) {
+			// Frozen blocks must not be rewritten.
+			continue
+		}
+		tier, ok := namespace.RetentionTierForBlock(tiers, blockStart, blockSize, now)
+		if !ok || tier.Resolution <= state.RollupResolution {
+			continue
+		}
+		toRollup = append(toRollup, rollupBlock{
+			blockStart:  blockStart,
+			coldVersion: state.ColdVersionFlushed,
+			resolution:  tier.Resolution,
+		})
+	}
+	s.flushState.RUnlock()
+
+	if len(toRollup) == 0 {
+		return nil
+	}
+
+	var (
+		multiErr xerrors.MultiError
+		rollup   = s.newRollupFn(resources.fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+			s.opts.SegmentReaderPool(), s.opts.MultiReaderIteratorPool(),
+			s.opts.IdentifierPool(), s.opts.EncoderPool(), nsOpts)
+	)
+	for _, b := range toRollup {
+		fsID := fs.FileSetFileIdentifier{
+			Namespace:   s.namespace.ID(),
+			Shard:       s.ID(),
+			BlockStart:  b.blockStart,
+			VolumeIndex: b.coldVersion,
+		}
+
+		nextVersion := b.coldVersion + 1
+		if err := rollup.Rollup(fsID, b.resolution, nextVersion, flushPreparer, nsCtx); err != nil {
+			multiErr = multiErr.Add(err)
+			continue
+		}
+
+		s.setFlushStateRollupResolution(b.blockStart, b.resolution)
+		if err := s.markColdVersionFlushed(b.blockStart, nextVersion); err != nil {
 			multiErr = multiErr.Add(err)
 			continue
 		}
@@ -2313,6 +2709,56 @@ func (s *dbShard) ColdFlush(
 	return multiErr.FinalError()
 }
 
+func (s *dbShard) ImportBlock(
+	flushPreparer persist.FlushPreparer,
+	fsReader fs.DataFileSetReader,
+	blockStart time.Time,
+	mergeWith fs.MergeWith,
+	nsCtx namespace.Context,
+) error {
+	s.RLock()
+	if s.bootstrapState != Bootstrapped {
+		s.RUnlock()
+		return errShardNotBootstrappedToFlush
+	}
+	s.RUnlock()
+
+	// Imports can only be merged with blocks that have been warm flushed
+	// since there must be a fileset to merge with.
+	hasWarmFlushed, err := s.hasWarmFlushed(blockStart)
+	if err != nil {
+		return err
+	}
+	if !hasWarmFlushed {
+		return errShardBlockNotFlushedToImport
+	}
+
+	coldVersion, err := s.RetrievableBlockColdVersion(blockStart)
+	if err != nil {
+		return err
+	}
+
+	fsID := fs.FileSetFileIdentifier{
+		Namespace:   s.namespace.ID(),
+		Shard:       s.ID(),
+		BlockStart:  blockStart,
+		VolumeIndex: coldVersion,
+	}
+
+	merger := s.newMergerFn(fsReader, s.opts.DatabaseBlockOptions().DatabaseBlockAllocSize(),
+		s.opts.SegmentReaderPool(), s.opts.MultiReaderIteratorPool(),
+		s.opts.IdentifierPool(), s.opts.EncoderPool(), s.opts.ContextPool(), s.namespace.Options())
+	nextVersion := coldVersion + 1
+	if err := merger.Merge(fsID, mergeWith, nextVersion, flushPreparer, nsCtx); err != nil {
+		return err
+	}
+
+	// The imported data is at the raw resolution, so make sure the block is
+	// rolled up again if it had aged into a retention tier.
+	s.setFlushStateRollupResolution(blockStart, 0)
+	return s.markColdVersionFlushed(blockStart, nextVersion)
+}
+
 func (s *dbShard) Snapshot(
 	blockStart time.Time,
 	snapshotTime time.Time,
@@ -2456,6 +2902,14 @@ func (s *dbShard) setFlushStateColdVersionFlushed(blockStart time.Time, version
 	s.flushState.Unlock()
 }
 
+func (s *dbShard) setFlushStateRollupResolution(blockStart time.Time, resolution time.Duration) {
+	s.flushState.Lock()
+	state := s.flushState.statesByTime[xtime.ToUnixNano(blockStart)]
+	state.RollupResolution = resolution
+	s.flushState.statesByTime[xtime.ToUnixNano(blockStart)] = state
+	s.flushState.Unlock()
+}
+
 func (s *dbShard) removeAnyFlushStatesTooEarly(startTime time.Time) {
 	s.flushState.Lock()
 	earliestFlush := retention.FlushTimeStart(s.namespace.Options().RetentionOptions(), startTime)
@@ -2468,6 +2922,11 @@ func (s *dbShard) removeAnyFlushStatesTooEarly(startTime time.Time) {
 }
 
 func (s *dbShard) CleanupExpiredFileSets(earliestToRetain time.Time) error {
+	// Frozen blocks are kept even once they have expired, as are compacted
+	// filesets until all of the blocks they hold have expired.
+	earliestToRetain = s.freezes.EarliestToRetain(earliestToRetain,
+		s.namespace.Options().RetentionOptions().BlockSize())
+	earliestToRetain = s.namespace.Options().CompactionOptions().EarliestToRetain(earliestToRetain)
 	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 	expired, err := s.filesetPathsBeforeFn(filePathPrefix, s.namespace.ID(), s.ID(), earliestToRetain)
 	if err != nil {
@@ -2478,6 +2937,197 @@ func (s *dbShard) CleanupExpiredFileSets(earliestToRetain time.Time) error {
 	return s.deleteFilesFn(expired)
 }
 
+func (s *dbShard) RunBlockExpiryHooks(
+	earliestToRetain time.Time,
+	hooks []BlockExpiryHook,
+) error {
+	if len(hooks) == 0 {
+		return nil
+	}
+	// Frozen blocks are kept even once they have expired so their hooks are
+	// run once they are unfrozen, the hooks of the blocks of a compacted
+	// fileset are run once it is removed.
+	var (
+		blockSize      = s.namespace.Options().RetentionOptions().BlockSize()
+		compactionOpts = s.namespace.Options().CompactionOptions()
+	)
+	earliestToRetain = s.freezes.EarliestToRetain(earliestToRetain, blockSize)
+	earliestToRetain = compactionOpts.EarliestToRetain(earliestToRetain)
+
+	fsOpts := s.opts.CommitLogOptions().FilesystemOptions()
+	filePathPrefix := fsOpts.FilePathPrefix()
+	filesets, err := s.filesetsFn(filePathPrefix, s.namespace.ID(), s.ID())
+	if err != nil {
+		return fmt.Errorf("encountered errors when getting fileset files for prefix %s namespace %s shard %d: %v",
+			filePathPrefix, s.namespace.ID(), s.ID(), err)
+	}
+
+	// Only the latest complete volume of an expiring block holds all of its
+	// data, earlier volumes have been superseded by cold flushes.
+	var (
+		expiring []fs.FileSetFile
+		seen     = make(map[xtime.UnixNano]struct{})
+	)
+	for _, fileset := range filesets {
+		blockStart := fileset.ID.BlockStart
+		if !blockStart.Before(earliestToRetain) {
+			continue
+		}
+		if _, ok := seen[xtime.ToUnixNano(blockStart)]; ok {
+			continue
+		}
+		seen[xtime.ToUnixNano(blockStart)] = struct{}{}
+		latest, ok := filesets.LatestVolumeForBlock(blockStart)
+		if !ok {
+			continue
+		}
+		expiring = append(expiring, latest)
+	}
+	if len(expiring) == 0 {
+		return nil
+	}
+
+	// The blocks of a compacted fileset are read from it, the filesets of the
+	// blocks it holds have been superseded by it.
+	var (
+		expiringBlocks []expiringFileSetBlock
+		compacted      map[compactedFileSetKey]time.Duration
+		covered        = make(map[xtime.UnixNano]struct{})
+	)
+	if compactionOpts.Enabled {
+		compacted = s.compactedFileSets()
+	}
+	for _, fileset := range expiring {
+		key := compactedFileSetKey{
+			blockStart: xtime.ToUnixNano(fileset.ID.BlockStart),
+			volume:     fileset.ID.VolumeIndex,
+		}
+		size, ok := compacted[key]
+		if !ok {
+			continue
+		}
+		end := fileset.ID.BlockStart.Add(size)
+		for blockStart := fileset.ID.BlockStart; blockStart.Before(end); blockStart = blockStart.Add(blockSize) {
+			covered[xtime.ToUnixNano(blockStart)] = struct{}{}
+			expiringBlocks = append(expiringBlocks, expiringFileSetBlock{
+				fileID:     fileset.ID,
+				blockStart: blockStart,
+			})
+		}
+	}
+	for _, fileset := range expiring {
+		key := compactedFileSetKey{
+			blockStart: xtime.ToUnixNano(fileset.ID.BlockStart),
+			volume:     fileset.ID.VolumeIndex,
+		}
+		if _, ok := compacted[key]; ok {
+			continue
+		}
+		if _, ok := covered[key.blockStart]; ok {
+			continue
+		}
+		expiringBlocks = append(expiringBlocks, expiringFileSetBlock{
+			fileID:     fileset.ID,
+			blockStart: fileset.ID.BlockStart,
+		})
+	}
+
+	reader, err := fs.NewReader(s.opts.BytesPool(), fsOpts)
+	if err != nil {
+		return err
+	}
+
+	multiErr := xerrors.NewMultiError()
+	for _, block := range expiringBlocks {
+		if err := s.runBlockExpiryHooks(reader, block.fileID, block.blockStart, hooks); err != nil {
+			multiErr = multiErr.Add(err)
+		}
+	}
+	return multiErr.FinalError()
+}
+
+// expiringFileSetBlock is an expiring block along with the fileset that
+// holds its data.
+type expiringFileSetBlock struct {
+	fileID     fs.FileSetFileIdentifier
+	blockStart time.Time
+}
+
+func (s *dbShard) runBlockExpiryHooks(
+	reader fs.DataFileSetReader,
+	fileID fs.FileSetFileIdentifier,
+	blockStart time.Time,
+	hooks []BlockExpiryHook,
+) (err error) {
+	blockSize := s.namespace.Options().RetentionOptions().BlockSize()
+	openOpts := fs.DataReaderOpenOptions{
+		Identifier:  fileID,
+		FileSetType: persist.FileSetFlushType,
+		Block: xtime.Range{
+			Start: blockStart,
+			End:   blockStart.Add(blockSize),
+		},
+	}
+	if err := reader.Open(openOpts); err != nil {
+		return err
+	}
+	defer func() {
+		// Only set the error here if not set by the end of the function, since
+		// all other errors take precedence.
+		if closeErr := reader.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+
+	var (
+		nsCtx    = namespace.NewContextFrom(s.namespace)
+		expiring = ExpiringBlock{
+			Namespace:  s.namespace.ID(),
+			Shard:      s.ID(),
+			BlockStart: blockStart,
+			BlockSize:  blockSize,
+		}
+		segReader  = s.opts.SegmentReaderPool().Get()
+		segReaders = make([]xio.SegmentReader, 1)
+		multiIter  = s.opts.MultiReaderIteratorPool().Get()
+	)
+	defer func() {
+		segReader.Finalize()
+		multiIter.Close()
+	}()
+
+	for id, tagsIter, data, _, err := reader.Read(); err != io.EOF; id, tagsIter, data, _, err = reader.Read() {
+		if err != nil {
+			return err
+		}
+
+		tags, err := convert.TagsFromTagsIter(id, tagsIter, s.identifierPool)
+		tagsIter.Close()
+		if err != nil {
+			id.Finalize()
+			return err
+		}
+
+		segment := ts.NewSegment(data, nil, ts.FinalizeNone)
+		for _, hook := range hooks {
+			segReader.Reset(segment)
+			segReaders[0] = segReader
+			multiIter.Reset(segReaders, blockStart, blockSize, nsCtx.Schema)
+			if err = hook.OnExpiringSeries(expiring, id, tags, multiIter); err != nil {
+				break
+			}
+		}
+
+		id.Finalize()
+		tags.Finalize()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func (s *dbShard) CleanupCompactedFileSets() error {
 	filePathPrefix := s.opts.CommitLogOptions().FilesystemOptions().FilePathPrefix()
 	filesets, err := s.filesetsFn(filePathPrefix, s.namespace.ID(), s.ID())
@@ -2495,9 +3145,16 @@ func (s *dbShard) CleanupCompactedFileSets() error {
 		return errShardIsNotBootstrapped
 	}
 
-	toDelete := fs.FileSetFilesSlice(make([]fs.FileSetFile, 0, len(filesets)))
+	var (
+		blockSize = s.namespace.Options().RetentionOptions().BlockSize()
+		toDelete  = fs.FileSetFilesSlice(make([]fs.FileSetFile, 0, len(filesets)))
+	)
 	for _, datafile := range filesets {
 		fileID := datafile.ID
+		if s.freezes.IsBlockFrozen(fileID.BlockStart, blockSize) {
+			// Keep every volume of frozen blocks.
+			continue
+		}
 		blockState := blockStatesSnapshot.Snapshot[xtime.ToUnixNano(fileID.BlockStart)]
 		if fileID.VolumeIndex < blockState.ColdVersion {
 			toDelete = append(toDelete, datafile)
@@ -2528,6 +3185,20 @@ func (s *dbShard) TagsFromSeriesID(seriesID ident.ID) (ident.Tags, bool, error)
 	return entry.Series.Tags(), true, nil
 }
 
+func (s *dbShard) SeriesLastWrite(id ident.ID) (time.Time, bool, error) {
+	s.RLock()
+	entry, _, err := s.lookupEntryWithLock(id)
+	s.RUnlock()
+	if err == errShardEntryNotFound {
+		return time.Time{}, false, nil
+	}
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	return entry.Series.LastWrite(), true, nil
+}
+
 func (s *dbShard) BootstrapState() BootstrapState {
 	s.RLock()
 	bs := s.bootstrapState
@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	stdcontext "context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	xerrors "github.com/m3db/m3/src/x/errors"
+)
+
+// shardErrorBudget counts the reads of a shard that fail persistently, such
+// as reads of filesets that fail digest validation, and marks the shard as
+// degraded once it fails more reads within a window than its budget allows.
+// A degraded shard is excluded from serving reads for the isolation period
+// so that the other replicas serve its reads rather than a fraction of all
+// queries failing until an operator intervenes.
+type shardErrorBudget struct {
+	sync.Mutex
+
+	nowFn clock.NowFn
+	opts  runtime.ShardErrorBudgetOptions
+
+	windowStart time.Time
+	numErrors   int
+
+	// degradedUntilNanos is read atomically by every read of the shard.
+	degradedUntilNanos int64
+}
+
+func newShardErrorBudget(nowFn clock.NowFn) *shardErrorBudget {
+	return &shardErrorBudget{
+		nowFn: nowFn,
+	}
+}
+
+func (b *shardErrorBudget) SetRuntimeOptions(value runtime.Options) {
+	b.Lock()
+	b.opts = value.ShardErrorBudgetOptions()
+	if !b.opts.Enabled {
+		b.numErrors = 0
+		atomic.StoreInt64(&b.degradedUntilNanos, 0)
+	}
+	b.Unlock()
+}
+
+// isDegraded returns whether the shard is excluded from serving reads.
+func (b *shardErrorBudget) isDegraded() bool {
+	degradedUntil := atomic.LoadInt64(&b.degradedUntilNanos)
+	return degradedUntil > 0 && b.nowFn().UnixNano() < degradedUntil
+}
+
+// record records the outcome of a read of the shard, returning true if the
+// read exhausted the error budget and the shard is now degraded.
+func (b *shardErrorBudget) record(err error) bool {
+	if !isPersistentReadError(err) {
+		return false
+	}
+
+	b.Lock()
+	defer b.Unlock()
+
+	if !b.opts.Enabled || b.isDegraded() {
+		return false
+	}
+
+	now := b.nowFn()
+	if now.Sub(b.windowStart) >= b.opts.Window {
+		b.windowStart = now
+		b.numErrors = 0
+	}
+	b.numErrors++
+	if b.numErrors < b.opts.MaxErrors {
+		return false
+	}
+
+	// Start counting afresh once the isolation period elapses so the shard
+	// is only isolated again if it keeps failing reads.
+	degradedUntil := now.Add(b.opts.IsolationPeriod)
+	b.windowStart = degradedUntil
+	b.numErrors = 0
+	atomic.StoreInt64(&b.degradedUntilNanos, degradedUntil.UnixNano())
+	return true
+}
+
+// isPersistentReadError returns whether a read error is likely to recur for
+// reads of the shard, errors caused by the request or transient conditions
+// such as the shard bootstrapping or the node being overloaded do not count
+// against the error budget of the shard.
+func isPersistentReadError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch {
+	case err == stdcontext.Canceled, err == stdcontext.DeadlineExceeded:
+		return false
+	case xerrors.IsInvalidParams(err),
+		xerrors.IsRetryableError(err),
+		xerrors.IsResourceExhaustedError(err):
+		return false
+	}
+	return true
+}
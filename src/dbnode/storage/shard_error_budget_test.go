@@ -0,0 +1,131 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	stdcontext "context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/notify"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	xerrors "github.com/m3db/m3/src/x/errors"
+
+	"github.com/stretchr/testify/require"
+)
+
+func testShardErrorBudgetRuntimeOptions() runtime.Options {
+	return runtime.NewOptions().SetShardErrorBudgetOptions(
+		runtime.ShardErrorBudgetOptions{
+			Enabled:         true,
+			MaxErrors:       3,
+			Window:          time.Minute,
+			IsolationPeriod: 10 * time.Minute,
+		})
+}
+
+func TestShardErrorBudgetDisabledByDefault(t *testing.T) {
+	b := newShardErrorBudget(time.Now)
+	b.SetRuntimeOptions(runtime.NewOptions())
+	for i := 0; i < 1000; i++ {
+		require.False(t, b.record(errors.New("digest mismatch")))
+	}
+	require.False(t, b.isDegraded())
+}
+
+func TestShardErrorBudgetDegradesAndRecovers(t *testing.T) {
+	now := time.Now()
+	b := newShardErrorBudget(func() time.Time { return now })
+	b.SetRuntimeOptions(testShardErrorBudgetRuntimeOptions())
+
+	readErr := errors.New("digest mismatch")
+	require.False(t, b.record(readErr))
+	require.False(t, b.record(readErr))
+
+	// Errors outside of the window do not count towards the budget.
+	now = now.Add(time.Minute)
+	require.False(t, b.record(readErr))
+	require.False(t, b.record(readErr))
+	require.False(t, b.isDegraded())
+
+	// Exhausting the budget within the window degrades the shard.
+	require.True(t, b.record(readErr))
+	require.True(t, b.isDegraded())
+	require.False(t, b.record(readErr))
+
+	// The shard serves reads again once the isolation period elapses.
+	now = now.Add(10 * time.Minute)
+	require.False(t, b.isDegraded())
+	require.False(t, b.record(readErr))
+	require.False(t, b.isDegraded())
+
+	// Disabling the budget restores a degraded shard immediately.
+	require.False(t, b.record(readErr))
+	require.True(t, b.record(readErr))
+	require.True(t, b.isDegraded())
+	b.SetRuntimeOptions(runtime.NewOptions())
+	require.False(t, b.isDegraded())
+}
+
+func TestShardErrorBudgetIgnoresTransientErrors(t *testing.T) {
+	b := newShardErrorBudget(time.Now)
+	b.SetRuntimeOptions(testShardErrorBudgetRuntimeOptions())
+
+	transient := []error{
+		nil,
+		stdcontext.Canceled,
+		stdcontext.DeadlineExceeded,
+		xerrors.NewInvalidParamsError(errors.New("bad request")),
+		xerrors.NewRetryableError(errShardNotBootstrappedToRead),
+		xerrors.NewResourceExhaustedError(errors.New("limit exceeded")),
+	}
+	for i := 0; i < 3; i++ {
+		for _, err := range transient {
+			require.False(t, b.record(err))
+		}
+	}
+	require.False(t, b.isDegraded())
+}
+
+func TestShardDegradedNotifiesOperators(t *testing.T) {
+	runtimeOptsMgr := runtime.NewOptionsManager()
+	require.NoError(t, runtimeOptsMgr.Update(testShardErrorBudgetRuntimeOptions()))
+
+	notifier := &testNotifier{}
+	opts := DefaultTestOptions().
+		SetRuntimeOptionsManager(runtimeOptsMgr).
+		SetNotifier(notifier)
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	for i := 0; i < 3; i++ {
+		require.False(t, shard.IsDegraded())
+		shard.recordReadError(errors.New("digest mismatch"))
+	}
+	require.True(t, shard.IsDegraded())
+
+	events := notifier.received()
+	require.Equal(t, 1, len(events))
+	require.Equal(t, notify.EventTypeShardDegraded, events[0].Type)
+	require.Equal(t, shard.namespace.ID().String(), events[0].Namespace)
+	require.Equal(t, shard.ID(), events[0].Fields["shard"])
+}
@@ -96,6 +96,7 @@ func TestShardFetchBlocksMetadataV2WithSeriesCachePolicyCacheAll(t *testing.T) {
 	currPageToken, err := proto.Marshal(&pagetoken.PageToken{
 		ActiveSeriesPhase: &pagetoken.PageToken_ActiveSeriesPhase{
 			IndexCursor: startCursor,
+			ShardEpoch:  shard.epoch,
 		},
 	})
 	require.NoError(t, err)
@@ -111,12 +112,178 @@ func TestShardFetchBlocksMetadataV2WithSeriesCachePolicyCacheAll(t *testing.T) {
 
 	require.NotNil(t, pageToken.GetActiveSeriesPhase())
 	require.Equal(t, int64(8), pageToken.GetActiveSeriesPhase().IndexCursor)
+	require.Equal(t, shard.epoch, pageToken.GetActiveSeriesPhase().ShardEpoch)
 
 	for i := 0; i < len(res.Results()); i++ {
 		require.Equal(t, ids[i], res.Results()[i].ID)
 	}
 }
 
+func TestShardStreamBlocksMetadataV2StopsOnCallbackError(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions().SetSeriesCachePolicy(series.CacheAll)
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+	start := time.Now()
+	end := start.Add(defaultTestRetentionOpts.BlockSize())
+
+	fetchOpts := block.FetchBlocksMetadataOptions{IncludeSizes: true}
+	seriesFetchOpts := series.FetchBlocksMetadataOptions{
+		FetchBlocksMetadataOptions: fetchOpts,
+	}
+	for i := int64(0); i < 3; i++ {
+		id := ident.StringID(fmt.Sprintf("foo.%d", i))
+		series := addMockSeries(ctrl, shard, id, ident.Tags{}, uint64(i))
+		if i > 1 {
+			// Should not be fetched after the callback returns an error.
+			continue
+		}
+		blocks := block.NewFetchBlockMetadataResults()
+		blocks.Add(block.NewFetchBlockMetadataResult(start, 0, nil, time.Time{}, nil))
+		series.EXPECT().
+			FetchBlocksMetadata(gomock.Not(nil), start, end, seriesFetchOpts).
+			Return(block.NewFetchBlocksMetadataResult(id, nil, blocks), nil)
+	}
+
+	var (
+		streamed    []string
+		callbackErr = fmt.Errorf("callback error")
+	)
+	_, err := shard.StreamBlocksMetadataV2(ctx, start, end, 10, nil, fetchOpts,
+		func(result block.FetchBlocksMetadataResult) error {
+			defer result.Close()
+			streamed = append(streamed, result.ID.String())
+			if len(streamed) == 2 {
+				return callbackErr
+			}
+			return nil
+		})
+	require.Equal(t, callbackErr, err)
+	require.Equal(t, []string{"foo.0", "foo.1"}, streamed)
+}
+
+func TestShardStreamBlocksMetadataV2RestartsActivePhaseOnEpochMismatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	opts := DefaultTestOptions().SetSeriesCachePolicy(series.CacheAll)
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+	start := time.Now()
+	end := start.Add(defaultTestRetentionOpts.BlockSize())
+
+	fetchOpts := block.FetchBlocksMetadataOptions{IncludeSizes: true}
+	seriesFetchOpts := series.FetchBlocksMetadataOptions{
+		FetchBlocksMetadataOptions: fetchOpts,
+	}
+	for i := int64(0); i < 3; i++ {
+		id := ident.StringID(fmt.Sprintf("foo.%d", i))
+		series := addMockSeries(ctrl, shard, id, ident.Tags{}, uint64(i))
+		blocks := block.NewFetchBlockMetadataResults()
+		blocks.Add(block.NewFetchBlockMetadataResult(start, 0, nil, time.Time{}, nil))
+		series.EXPECT().
+			FetchBlocksMetadata(gomock.Not(nil), start, end, seriesFetchOpts).
+			Return(block.NewFetchBlocksMetadataResult(id, nil, blocks), nil)
+	}
+
+	// Token issued by a previous incarnation of the shard, the cursor
+	// cannot be trusted so all series should be returned.
+	currPageToken, err := proto.Marshal(&pagetoken.PageToken{
+		ActiveSeriesPhase: &pagetoken.PageToken_ActiveSeriesPhase{
+			IndexCursor: 2,
+			ShardEpoch:  shard.epoch - 1,
+		},
+	})
+	require.NoError(t, err)
+
+	var streamed []string
+	nextPageToken, err := shard.StreamBlocksMetadataV2(ctx, start, end, 10,
+		currPageToken, fetchOpts,
+		func(result block.FetchBlocksMetadataResult) error {
+			defer result.Close()
+			streamed = append(streamed, result.ID.String())
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, []string{"foo.0", "foo.1", "foo.2"}, streamed)
+
+	pageToken := new(pagetoken.PageToken)
+	require.NoError(t, proto.Unmarshal(nextPageToken, pageToken))
+	require.NotNil(t, pageToken.GetFlushedSeriesPhase())
+}
+
+func TestShardStreamBlocksMetadataV2ResumesFromEarlierBlockWhenTokenBlockMissing(t *testing.T) {
+	opts := DefaultTestOptions().SetSeriesCachePolicy(series.CacheRecentlyRead)
+	ctx := opts.ContextPool().Get()
+	defer ctx.Close()
+
+	fsOpts := opts.CommitLogOptions().FilesystemOptions()
+
+	shard := testDatabaseShard(t, opts)
+	defer shard.Close()
+
+	var (
+		blockSize       = defaultTestRetentionOpts.BlockSize()
+		mostRecentStart = time.Now().Truncate(blockSize)
+		flushedStart    = mostRecentStart.Add(-2 * blockSize)
+		missingStart    = mostRecentStart.Add(-blockSize)
+		start           = flushedStart
+		end             = mostRecentStart
+		numSeries       = 3
+	)
+
+	writer, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+	err = writer.Open(fs.DataWriterOpenOptions{
+		Identifier: fs.FileSetFileIdentifier{
+			Namespace:  shard.namespace.ID(),
+			Shard:      shard.shard,
+			BlockStart: flushedStart,
+		},
+		BlockSize: blockSize,
+	})
+	require.NoError(t, err)
+	for i := 0; i < numSeries; i++ {
+		data := checked.NewBytes([]byte{byte(i)}, nil)
+		data.IncRef()
+		id := ident.StringID(fmt.Sprintf("foo.%d", i))
+		err = writer.Write(id, ident.Tags{}, data, digest.Checksum(data.Bytes()))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	// Token points part way into a block whose fileset files no longer exist.
+	currPageToken, err := proto.Marshal(&pagetoken.PageToken{
+		FlushedSeriesPhase: &pagetoken.PageToken_FlushedSeriesPhase{
+			CurrBlockStartUnixNanos: missingStart.UnixNano(),
+			CurrBlockEntryIdx:       2,
+		},
+	})
+	require.NoError(t, err)
+
+	var streamed []string
+	_, err = shard.StreamBlocksMetadataV2(ctx, start, end, 10,
+		currPageToken, block.FetchBlocksMetadataOptions{},
+		func(result block.FetchBlocksMetadataResult) error {
+			defer result.Close()
+			for _, r := range result.Blocks.Results() {
+				require.True(t, flushedStart.Equal(r.Start))
+			}
+			streamed = append(streamed, result.ID.String())
+			return nil
+		})
+	require.NoError(t, err)
+	require.Equal(t, numSeries, len(streamed))
+}
+
 type fetchBlockMetadataResultByStart []block.FetchBlockMetadataResult
 
 func (b fetchBlockMetadataResultByStart) Len() int      { return len(b) }
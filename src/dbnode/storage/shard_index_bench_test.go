@@ -0,0 +1,85 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/index"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+)
+
+func BenchmarkShardWriteTaggedNewSeries(b *testing.B) {
+	ctrl := gomock.NewController(b)
+	defer ctrl.Finish()
+
+	var (
+		now        = time.Now()
+		blockSize  = namespace.NewIndexOptions().BlockSize()
+		blockStart = xtime.ToUnixNano(now.Truncate(blockSize))
+	)
+	idx := NewMocknamespaceIndex(ctrl)
+	idx.EXPECT().BlockStartForWriteTime(gomock.Any()).Return(blockStart).AnyTimes()
+	idx.EXPECT().WriteBatch(gomock.Any()).Do(
+		func(batch *index.WriteBatch) {
+			for _, e := range batch.PendingEntries() {
+				e.OnIndexSeries.OnIndexSuccess(blockStart)
+				e.OnIndexSeries.OnIndexFinalize(blockStart)
+			}
+		}).Return(nil).AnyTimes()
+
+	shard := newBenchmarkDatabaseShard(b, DefaultTestOptions(), idx)
+	shard.SetRuntimeOptions(runtime.NewOptions().SetWriteNewSeriesAsync(false))
+	defer shard.Close()
+
+	ids := make([]ident.ID, 0, b.N)
+	tags := make([]ident.Tags, 0, b.N)
+	for i := 0; i < b.N; i++ {
+		ids = append(ids, ident.StringID(fmt.Sprintf("series.%d", i)))
+		tags = append(tags, ident.NewTags(
+			ident.StringTag("__name__", "series"),
+			ident.StringTag("host", fmt.Sprintf("host.%d", i%128)),
+			ident.StringTag("instance", fmt.Sprintf("instance.%d", i)),
+		))
+	}
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, _, _, err := shard.WriteTagged(ctx, ids[i], ident.NewTagsIterator(tags[i]),
+			now, 1.0, xtime.Second, nil, series.WriteOptions{})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -79,19 +79,19 @@ func TestShardInsertNamespaceIndex(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
 
-	_, wasWritten, err := shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err := shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now, 1.0, xtime.Second, nil, series.WriteOptions{})
 	require.NoError(t, err)
 	require.True(t, wasWritten)
 
-	_, wasWritten, err = shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err = shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now, 2.0, xtime.Second, nil, series.WriteOptions{})
 	require.NoError(t, err)
 	require.True(t, wasWritten)
 
-	_, wasWritten, err = shard.Write(
+	_, wasWritten, _, err = shard.Write(
 		ctx, ident.StringID("baz"), now, 1.0, xtime.Second, nil, series.WriteOptions{})
 	require.NoError(t, err)
 	require.True(t, wasWritten)
@@ -129,18 +129,18 @@ func TestShardAsyncInsertNamespaceIndex(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
 	now := time.Now()
-	_, wasWritten, err := shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err := shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now, 1.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
 	assert.True(t, wasWritten)
 
-	_, wasWritten, err = shard.Write(ctx, ident.StringID("bar"), now,
+	_, wasWritten, _, err = shard.Write(ctx, ident.StringID("bar"), now,
 		1.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
 	assert.True(t, wasWritten)
 
-	_, wasWritten, err = shard.WriteTagged(ctx, ident.StringID("baz"),
+	_, wasWritten, _, err = shard.WriteTagged(ctx, ident.StringID("baz"),
 		ident.NewTagsIterator(ident.NewTags(
 			ident.StringTag("all", "tags"),
 			ident.StringTag("should", "be-present"),
@@ -216,7 +216,7 @@ func TestShardAsyncIndexOnlyWhenNotIndexed(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
 
-	_, wasWritten, err := shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err := shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now, 1.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
@@ -230,14 +230,14 @@ func TestShardAsyncIndexOnlyWhenNotIndexed(t *testing.T) {
 	}
 
 	// ensure we don't index once we have already indexed
-	_, wasWritten, err = shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err = shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now.Add(time.Second), 2.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
 	assert.True(t, wasWritten)
 
 	// ensure attempting to write same point yields false and does not write
-	_, wasWritten, err = shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err = shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now.Add(time.Second), 2.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
@@ -288,7 +288,7 @@ func TestShardAsyncIndexIfExpired(t *testing.T) {
 	ctx := context.NewContext()
 	defer ctx.Close()
 
-	_, wasWritten, err := shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err := shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		now, 1.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
@@ -302,7 +302,7 @@ func TestShardAsyncIndexIfExpired(t *testing.T) {
 
 	// ensure we index because it's expired
 	nextWriteTime := now.Add(blockSize)
-	_, wasWritten, err = shard.WriteTagged(ctx, ident.StringID("foo"),
+	_, wasWritten, _, err = shard.WriteTagged(ctx, ident.StringID("foo"),
 		ident.NewTagsIterator(ident.NewTags(ident.StringTag("name", "value"))),
 		nextWriteTime, 2.0, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
@@ -31,6 +31,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/x/checked"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	xtime "github.com/m3db/m3/src/x/time"
 
@@ -41,7 +42,8 @@ import (
 var (
 	errShardInsertQueueNotOpen             = errors.New("shard insert queue is not open")
 	errShardInsertQueueAlreadyOpenOrClosed = errors.New("shard insert queue already open or is closed")
-	errNewSeriesInsertRateLimitExceeded    = errors.New("shard insert of new series exceeds rate limit")
+	errNewSeriesInsertRateLimitExceeded    = xerrors.NewResourceExhaustedError(
+		errors.New("shard insert of new series exceeds rate limit"))
 )
 
 type dbShardInsertQueueState int
@@ -0,0 +1,89 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	"github.com/m3db/m3/src/dbnode/runtime"
+)
+
+// shardNewSeriesLimiter is a token bucket that admits the creation of new
+// series in a shard at a sustained rate with a configurable burst. It is
+// consulted before anything is allocated for a new series so that a sudden
+// explosion of new series is rejected rather than exhausting memory.
+type shardNewSeriesLimiter struct {
+	sync.Mutex
+
+	nowFn clock.NowFn
+
+	// limitPerSecond of zero disables the limiter.
+	limitPerSecond int
+	burst          int
+	tokens         float64
+	lastRefill     time.Time
+}
+
+func newShardNewSeriesLimiter(nowFn clock.NowFn) *shardNewSeriesLimiter {
+	return &shardNewSeriesLimiter{
+		nowFn: nowFn,
+	}
+}
+
+func (l *shardNewSeriesLimiter) SetRuntimeOptions(value runtime.Options) {
+	l.Lock()
+	l.limitPerSecond = value.WriteNewSeriesAdmissionLimitPerShardPerSecond()
+	l.burst = value.WriteNewSeriesAdmissionBurstPerShard()
+	if l.burst <= 0 {
+		l.burst = l.limitPerSecond
+	}
+	l.tokens = math.Min(l.tokens, float64(l.burst))
+	l.Unlock()
+}
+
+// admit returns whether a new series may be created, consuming a token if so.
+func (l *shardNewSeriesLimiter) admit() bool {
+	l.Lock()
+	defer l.Unlock()
+
+	if l.limitPerSecond <= 0 {
+		return true
+	}
+
+	now := l.nowFn()
+	if l.lastRefill.IsZero() {
+		// Start with a full bucket.
+		l.tokens = float64(l.burst)
+	} else if elapsed := now.Sub(l.lastRefill); elapsed > 0 {
+		refill := elapsed.Seconds() * float64(l.limitPerSecond)
+		l.tokens = math.Min(l.tokens+refill, float64(l.burst))
+	}
+	l.lastRefill = now
+
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
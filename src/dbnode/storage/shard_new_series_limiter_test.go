@@ -0,0 +1,78 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/runtime"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardNewSeriesLimiterDisabledByDefault(t *testing.T) {
+	l := newShardNewSeriesLimiter(time.Now)
+	l.SetRuntimeOptions(runtime.NewOptions())
+	for i := 0; i < 1000; i++ {
+		require.True(t, l.admit())
+	}
+}
+
+func TestShardNewSeriesLimiterBurstAndRefill(t *testing.T) {
+	now := time.Now()
+	l := newShardNewSeriesLimiter(func() time.Time { return now })
+	l.SetRuntimeOptions(runtime.NewOptions().
+		SetWriteNewSeriesAdmissionLimitPerShardPerSecond(10).
+		SetWriteNewSeriesAdmissionBurstPerShard(20))
+
+	// The full burst is admitted at once.
+	for i := 0; i < 20; i++ {
+		require.True(t, l.admit())
+	}
+	require.False(t, l.admit())
+
+	// Tokens refill at the sustained rate.
+	now = now.Add(500 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		require.True(t, l.admit())
+	}
+	require.False(t, l.admit())
+
+	// Tokens never refill above the burst.
+	now = now.Add(time.Minute)
+	for i := 0; i < 20; i++ {
+		require.True(t, l.admit())
+	}
+	require.False(t, l.admit())
+}
+
+func TestShardNewSeriesLimiterBurstDefaultsToLimit(t *testing.T) {
+	now := time.Now()
+	l := newShardNewSeriesLimiter(func() time.Time { return now })
+	l.SetRuntimeOptions(runtime.NewOptions().
+		SetWriteNewSeriesAdmissionLimitPerShardPerSecond(5))
+
+	for i := 0; i < 5; i++ {
+		require.True(t, l.admit())
+	}
+	require.False(t, l.admit())
+}
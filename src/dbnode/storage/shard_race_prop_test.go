@@ -187,7 +187,7 @@ func testShardTickWriteRace(t *testing.T, tickBatchSize, numSeries int) {
 			<-barrier
 			ctx := context.NewContext()
 			now := time.Now()
-			_, wasWritten, err := shard.Write(ctx, id, now, 1.0, xtime.Second, nil, series.WriteOptions{})
+			_, wasWritten, _, err := shard.Write(ctx, id, now, 1.0, xtime.Second, nil, series.WriteOptions{})
 			assert.NoError(t, err)
 			assert.True(t, wasWritten)
 			ctx.BlockingClose()
@@ -281,7 +281,7 @@ func TestShardTickBootstrapWriteRace(t *testing.T) {
 			<-barrier
 			ctx := context.NewContext()
 			now := time.Now()
-			_, wasWritten, err := shard.Write(ctx, id, now, 1.0, xtime.Second, nil, series.WriteOptions{})
+			_, wasWritten, _, err := shard.Write(ctx, id, now, 1.0, xtime.Second, nil, series.WriteOptions{})
 			assert.NoError(t, err)
 			assert.True(t, wasWritten)
 			ctx.BlockingClose()
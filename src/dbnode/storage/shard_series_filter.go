@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"math/bits"
+	"sync/atomic"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/cespare/xxhash"
+)
+
+const (
+	defaultShardSeriesFilterCapacity = 4096
+	shardSeriesFilterBitsPerElement  = 10
+	shardSeriesFilterNumHashes       = 4
+)
+
+// shardSeriesFilter is a bloom filter of the IDs of the series inserted into
+// a shard. Writes consult it before resolving a series through either the
+// series stripes or the shard lookup map so that writes for series that are
+// definitely new go straight to being inserted without taking a stripe lock
+// or the shard lock to look them up first.
+//
+// Elements are never removed, the filter is rebuilt from the series held by
+// the shard once more elements have been added than it was sized for. Series
+// are added to the filter while holding the shard write lock and rebuilds
+// hold the shard read lock, so a series held by the shard is always in the
+// current filter by the time its insert has completed. A write that checks
+// the filter while the insert of the same series is still in flight falls
+// back to the insert path, which finds the existing series under the shard
+// lock, and never results in a duplicate series.
+//
+// It is safe for concurrent use without any locks.
+type shardSeriesFilter struct {
+	words    []uint64
+	numBits  uint64
+	capacity int64
+	added    int64
+}
+
+func newShardSeriesFilter(capacity int) *shardSeriesFilter {
+	if capacity < defaultShardSeriesFilterCapacity {
+		capacity = defaultShardSeriesFilterCapacity
+	}
+	numWords := (capacity*shardSeriesFilterBitsPerElement + 63) / 64
+	return &shardSeriesFilter{
+		words:    make([]uint64, numWords),
+		numBits:  uint64(numWords * 64),
+		capacity: int64(capacity),
+	}
+}
+
+// Add adds a series ID to the filter.
+func (f *shardSeriesFilter) Add(id ident.ID) {
+	h1, h2 := shardSeriesFilterHashes(id)
+	for i := uint64(0); i < shardSeriesFilterNumHashes; i++ {
+		bit := (h1 + i*h2) % f.numBits
+		word, mask := &f.words[bit/64], uint64(1)<<(bit%64)
+		for {
+			curr := atomic.LoadUint64(word)
+			if curr&mask != 0 ||
+				atomic.CompareAndSwapUint64(word, curr, curr|mask) {
+				break
+			}
+		}
+	}
+	atomic.AddInt64(&f.added, 1)
+}
+
+// MayContain returns false if the series ID has definitely not been added
+// to the filter.
+func (f *shardSeriesFilter) MayContain(id ident.ID) bool {
+	h1, h2 := shardSeriesFilterHashes(id)
+	for i := uint64(0); i < shardSeriesFilterNumHashes; i++ {
+		bit := (h1 + i*h2) % f.numBits
+		if atomic.LoadUint64(&f.words[bit/64])&(uint64(1)<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// NeedsRebuild returns true if more elements have been added to the filter
+// than it was sized for.
+func (f *shardSeriesFilter) NeedsRebuild() bool {
+	return atomic.LoadInt64(&f.added) > f.capacity
+}
+
+// shardSeriesFilterHashes derives the hashes for the filter using double
+// hashing of a single hash of the ID.
+func shardSeriesFilterHashes(id ident.ID) (uint64, uint64) {
+	hash := xxhash.Sum64(id.Bytes())
+	// Ensure the second hash is odd so that probes do not repeat.
+	return hash, bits.RotateLeft64(hash, 32) | 1
+}
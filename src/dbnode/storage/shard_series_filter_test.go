@@ -0,0 +1,163 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/cespare/xxhash"
+	"github.com/stretchr/testify/require"
+)
+
+func TestShardSeriesFilterAddAndMayContain(t *testing.T) {
+	filter := newShardSeriesFilter(0)
+	require.Equal(t, int64(defaultShardSeriesFilterCapacity), filter.capacity)
+
+	require.False(t, filter.MayContain(ident.StringID("foo")))
+	filter.Add(ident.StringID("foo"))
+	require.True(t, filter.MayContain(ident.StringID("foo")))
+	require.False(t, filter.NeedsRebuild())
+}
+
+func TestShardSeriesFilterNoFalseNegatives(t *testing.T) {
+	var (
+		filter = newShardSeriesFilter(0)
+		n      = defaultShardSeriesFilterCapacity
+	)
+	for i := 0; i < n; i++ {
+		filter.Add(ident.StringID(fmt.Sprintf("series.%d", i)))
+	}
+	for i := 0; i < n; i++ {
+		require.True(t, filter.MayContain(ident.StringID(fmt.Sprintf("series.%d", i))))
+	}
+
+	// At capacity the false positive rate should remain low.
+	falsePositives := 0
+	for i := 0; i < n; i++ {
+		if filter.MayContain(ident.StringID(fmt.Sprintf("other.%d", i))) {
+			falsePositives++
+		}
+	}
+	require.True(t, falsePositives < n/20,
+		fmt.Sprintf("too many false positives: %d", falsePositives))
+
+	require.False(t, filter.NeedsRebuild())
+	filter.Add(ident.StringID("one-more"))
+	require.True(t, filter.NeedsRebuild())
+}
+
+func TestShardSeriesFilterConcurrentAdd(t *testing.T) {
+	var (
+		filter     = newShardSeriesFilter(0)
+		wg         sync.WaitGroup
+		numWorkers = 8
+		numPerWork = 256
+	)
+	for i := 0; i < numWorkers; i++ {
+		i := i
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < numPerWork; j++ {
+				filter.Add(ident.StringID(fmt.Sprintf("series.%d.%d", i, j)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	for i := 0; i < numWorkers; i++ {
+		for j := 0; j < numPerWork; j++ {
+			require.True(t, filter.MayContain(
+				ident.StringID(fmt.Sprintf("series.%d.%d", i, j))))
+		}
+	}
+	require.Equal(t, int64(numWorkers*numPerWork), filter.added)
+}
+
+func TestShardRebuildSeriesFilter(t *testing.T) {
+	shard := testDatabaseShard(t, DefaultTestOptions())
+	defer shard.Close()
+
+	n := defaultShardSeriesFilterCapacity + 1
+	shard.Lock()
+	for i := 0; i < n; i++ {
+		shard.insertNewShardEntryWithLock(newTestStripesEntry(fmt.Sprintf("series.%d", i)))
+	}
+	shard.Unlock()
+	require.True(t, shard.loadSeriesFilter().NeedsRebuild())
+
+	shard.rebuildSeriesFilter()
+
+	filter := shard.loadSeriesFilter()
+	require.False(t, filter.NeedsRebuild())
+	require.Equal(t, int64(2*n), filter.capacity)
+	for i := 0; i < n; i++ {
+		id := ident.StringID(fmt.Sprintf("series.%d", i))
+		require.True(t, filter.MayContain(id))
+
+		entry, _, err := shard.tryRetrieveWritableSeries(id)
+		require.NoError(t, err)
+		require.NotNil(t, entry)
+		entry.DecrementReaderWriterCount()
+	}
+}
+
+func TestShardTryRetrieveWritableSeriesNewSeriesSkipsLocks(t *testing.T) {
+	shard := testDatabaseShard(t, DefaultTestOptions())
+	defer shard.Close()
+
+	shard.Lock()
+	shard.insertNewShardEntryWithLock(newTestStripesEntry("foo"))
+	shard.Unlock()
+
+	// Hold the stripe lock of the new series and the shard lock, a series
+	// that is definitely new must resolve without waiting on either.
+	id := ident.StringID("bar")
+	stripe := shard.seriesStripes.stripe(xxhash.Sum64(id.Bytes()))
+	stripe.Lock()
+	shard.Lock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		entry, _, err := shard.tryRetrieveWritableSeries(id)
+		require.NoError(t, err)
+		require.Nil(t, entry)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(10 * time.Second):
+		require.FailNow(t, "resolving a new series waited on a lock")
+	}
+	shard.Unlock()
+	stripe.Unlock()
+
+	// Existing series still resolve through the stripes.
+	entry, _, err := shard.tryRetrieveWritableSeries(ident.StringID("foo"))
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	entry.DecrementReaderWriterCount()
+}
@@ -0,0 +1,142 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+
+	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/cespare/xxhash"
+)
+
+const (
+	defaultShardSeriesStripes = 16
+	cacheLineSize             = 64
+)
+
+// shardSeriesStripes is a striped index of the entries held in a shard that
+// lets writes to already inserted series resolve and take a reference to
+// their entry without acquiring the shard lock. Each stripe is guarded by its
+// own lock so that concurrent writers only contend when their series hash to
+// the same stripe.
+//
+// The shard lookup map and list remain the source of truth, the stripes only
+// ever hold a subset of the entries in the lookup map. Entries are added to
+// the stripes with the shard lock held and are removed with both the shard
+// lock and the stripe lock held, lock ordering is always shard then stripe.
+//
+// NB: Entries are keyed by the hash of their ID, on the (very unlikely) event
+// of a hash collision the second series is simply not added to the stripes
+// and will always resolve through the shard lookup map.
+type shardSeriesStripes struct {
+	stripes []shardSeriesStripe
+	mask    uint64
+}
+
+type shardSeriesStripe struct {
+	sync.RWMutex
+	entries map[uint64]*lookup.Entry
+	// Pad out the stripe so neighboring stripe locks do not share a cache line.
+	_ [cacheLineSize]byte
+}
+
+func newShardSeriesStripes(numStripes int) *shardSeriesStripes {
+	n := 1
+	for n < numStripes {
+		n <<= 1
+	}
+	stripes := make([]shardSeriesStripe, n)
+	for i := range stripes {
+		stripes[i].entries = make(map[uint64]*lookup.Entry)
+	}
+	return &shardSeriesStripes{
+		stripes: stripes,
+		mask:    uint64(n - 1),
+	}
+}
+
+func (s *shardSeriesStripes) stripe(hash uint64) *shardSeriesStripe {
+	return &s.stripes[hash&s.mask]
+}
+
+// TryIncrementReaderWriterCount returns the entry for the series with the
+// reader writer count already incremented if the series is held in the stripes.
+func (s *shardSeriesStripes) TryIncrementReaderWriterCount(id ident.ID) (*lookup.Entry, bool) {
+	hash := xxhash.Sum64(id.Bytes())
+	stripe := s.stripe(hash)
+	stripe.RLock()
+	entry, ok := stripe.entries[hash]
+	if !ok || !entry.Series.ID().Equal(id) {
+		stripe.RUnlock()
+		return nil, false
+	}
+	// Increment while holding the stripe lock so that the entry cannot be
+	// purged between being resolved and being referenced.
+	entry.IncrementReaderWriterCount()
+	stripe.RUnlock()
+	return entry, true
+}
+
+// Add adds an entry to the stripes, must be called with the shard lock held.
+func (s *shardSeriesStripes) Add(id ident.ID, entry *lookup.Entry) {
+	hash := xxhash.Sum64(id.Bytes())
+	stripe := s.stripe(hash)
+	stripe.Lock()
+	if _, exists := stripe.entries[hash]; !exists {
+		stripe.entries[hash] = entry
+	}
+	stripe.Unlock()
+}
+
+// RemoveIf removes the entry from the stripes if removeFn returns true, the
+// stripe lock is held for the duration of the callback so that no new
+// references can be taken through the stripes while deciding. Returns the
+// result of the callback. Must be called with the shard lock held.
+func (s *shardSeriesStripes) RemoveIf(
+	id ident.ID,
+	entry *lookup.Entry,
+	removeFn func() bool,
+) bool {
+	hash := xxhash.Sum64(id.Bytes())
+	stripe := s.stripe(hash)
+	stripe.Lock()
+	remove := removeFn()
+	if remove {
+		if existing, ok := stripe.entries[hash]; ok && existing == entry {
+			delete(stripe.entries, hash)
+		}
+	}
+	stripe.Unlock()
+	return remove
+}
+
+// Reset removes all entries from the stripes, must be called with the shard
+// lock held.
+func (s *shardSeriesStripes) Reset() {
+	for i := range s.stripes {
+		stripe := &s.stripes[i]
+		stripe.Lock()
+		stripe.entries = make(map[uint64]*lookup.Entry)
+		stripe.Unlock()
+	}
+}
@@ -0,0 +1,201 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
+	"github.com/m3db/m3/src/x/ident"
+
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func newTestStripesEntry(id string) *lookup.Entry {
+	return lookup.NewEntry(series.NewDatabaseSeries(series.DatabaseSeriesOptions{
+		ID:      ident.StringID(id),
+		Options: series.NewOptions(),
+	}), 0)
+}
+
+func TestShardSeriesStripesAddAndIncrement(t *testing.T) {
+	stripes := newShardSeriesStripes(3)
+	require.Equal(t, 4, len(stripes.stripes))
+
+	entry := newTestStripesEntry("foo")
+	_, ok := stripes.TryIncrementReaderWriterCount(ident.StringID("foo"))
+	require.False(t, ok)
+
+	stripes.Add(entry.Series.ID(), entry)
+	resolved, ok := stripes.TryIncrementReaderWriterCount(ident.StringID("foo"))
+	require.True(t, ok)
+	require.True(t, entry == resolved)
+	require.Equal(t, int32(1), entry.ReaderWriterCount())
+
+	_, ok = stripes.TryIncrementReaderWriterCount(ident.StringID("bar"))
+	require.False(t, ok)
+}
+
+func TestShardSeriesStripesRemoveIf(t *testing.T) {
+	stripes := newShardSeriesStripes(defaultShardSeriesStripes)
+	entry := newTestStripesEntry("foo")
+	stripes.Add(entry.Series.ID(), entry)
+
+	require.False(t, stripes.RemoveIf(entry.Series.ID(), entry, func() bool {
+		return false
+	}))
+	_, ok := stripes.TryIncrementReaderWriterCount(ident.StringID("foo"))
+	require.True(t, ok)
+
+	require.True(t, stripes.RemoveIf(entry.Series.ID(), entry, func() bool {
+		return true
+	}))
+	_, ok = stripes.TryIncrementReaderWriterCount(ident.StringID("foo"))
+	require.False(t, ok)
+}
+
+func TestShardSeriesStripesRemoveIfDifferentEntry(t *testing.T) {
+	stripes := newShardSeriesStripes(defaultShardSeriesStripes)
+	entry := newTestStripesEntry("foo")
+	other := newTestStripesEntry("foo")
+	stripes.Add(entry.Series.ID(), entry)
+
+	// Adding a second entry for the same hash does not replace the first.
+	stripes.Add(other.Series.ID(), other)
+	require.True(t, stripes.RemoveIf(other.Series.ID(), other, func() bool {
+		return true
+	}))
+
+	resolved, ok := stripes.TryIncrementReaderWriterCount(ident.StringID("foo"))
+	require.True(t, ok)
+	require.True(t, entry == resolved)
+}
+
+func TestShardSeriesStripesReset(t *testing.T) {
+	stripes := newShardSeriesStripes(defaultShardSeriesStripes)
+	for i := 0; i < 100; i++ {
+		entry := newTestStripesEntry(fmt.Sprintf("foo.%d", i))
+		stripes.Add(entry.Series.ID(), entry)
+	}
+
+	stripes.Reset()
+	for i := 0; i < 100; i++ {
+		_, ok := stripes.TryIncrementReaderWriterCount(ident.StringID(fmt.Sprintf("foo.%d", i)))
+		require.False(t, ok)
+	}
+}
+
+func TestShardTryRetrieveWritableSeriesClosed(t *testing.T) {
+	opts := DefaultTestOptions()
+	shard := testDatabaseShard(t, opts)
+
+	entry := newTestStripesEntry("foo")
+	shard.Lock()
+	shard.insertNewShardEntryWithLock(entry)
+	shard.Unlock()
+
+	resolved, _, err := shard.tryRetrieveWritableSeries(ident.StringID("foo"))
+	require.NoError(t, err)
+	require.True(t, entry == resolved)
+	resolved.DecrementReaderWriterCount()
+
+	require.NoError(t, shard.Close())
+
+	_, _, err = shard.tryRetrieveWritableSeries(ident.StringID("foo"))
+	require.Error(t, err)
+}
+
+// BenchmarkShardSeriesLookupShardLock and BenchmarkShardSeriesLookupStripes
+// compare resolving existing series for writes via the shard lock against
+// via the series stripes under heavy write concurrency.
+func BenchmarkShardSeriesLookupShardLock(b *testing.B) {
+	benchmarkShardSeriesLookup(b, func(shard *dbShard, id ident.ID) *lookup.Entry {
+		shard.RLock()
+		entry, _, err := shard.lookupEntryWithLock(id)
+		if err != nil {
+			shard.RUnlock()
+			b.Fatal(err)
+		}
+		entry.IncrementReaderWriterCount()
+		shard.RUnlock()
+		return entry
+	})
+}
+
+func BenchmarkShardSeriesLookupStripes(b *testing.B) {
+	benchmarkShardSeriesLookup(b, func(shard *dbShard, id ident.ID) *lookup.Entry {
+		entry, _, err := shard.tryRetrieveWritableSeries(id)
+		if err != nil || entry == nil {
+			b.Fatal(err)
+		}
+		return entry
+	})
+}
+
+func newBenchmarkDatabaseShard(
+	b *testing.B,
+	opts Options,
+	idx namespaceIndex,
+) *dbShard {
+	metadata, err := namespace.NewMetadata(defaultTestNs1ID, defaultTestNs1Opts)
+	require.NoError(b, err)
+
+	nsReaderMgr := newNamespaceReaderManager(metadata, tally.NoopScope, opts)
+	seriesOpts := NewSeriesOptionsFromOptions(opts, defaultTestNs1Opts.RetentionOptions())
+	return newDatabaseShard(metadata, 0, nil, nsReaderMgr,
+		&testIncreasingIndex{}, newNamespaceIndexRef(idx), nil, false, opts, seriesOpts).(*dbShard)
+}
+
+func benchmarkShardSeriesLookup(
+	b *testing.B,
+	resolveFn func(shard *dbShard, id ident.ID) *lookup.Entry,
+) {
+	const numSeries = 4096
+
+	shard := newBenchmarkDatabaseShard(b, DefaultTestOptions(), nil)
+	defer shard.Close()
+
+	ids := make([]ident.ID, 0, numSeries)
+	shard.Lock()
+	for i := 0; i < numSeries; i++ {
+		entry := newTestStripesEntry(fmt.Sprintf("series.%d", i))
+		shard.insertNewShardEntryWithLock(entry)
+		ids = append(ids, entry.Series.ID())
+	}
+	shard.Unlock()
+
+	var next uint64
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := atomic.AddUint64(&next, 7919)
+		for pb.Next() {
+			i++
+			entry := resolveFn(shard, ids[i%numSeries])
+			entry.DecrementReaderWriterCount()
+		}
+	})
+}
@@ -32,7 +32,9 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/m3db/m3/src/dbnode/digest"
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/encoding/m3tsz"
 	"github.com/m3db/m3/src/dbnode/namespace"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
@@ -40,6 +42,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
+	dberrors "github.com/m3db/m3/src/dbnode/storage/errors"
 	"github.com/m3db/m3/src/dbnode/storage/series"
 	"github.com/m3db/m3/src/dbnode/storage/series/lookup"
 	"github.com/m3db/m3/src/dbnode/ts"
@@ -47,6 +50,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/x/checked"
 	"github.com/m3db/m3/src/x/context"
+	xerrors "github.com/m3db/m3/src/x/errors"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/pool"
 	xtest "github.com/m3db/m3/src/x/test"
@@ -83,7 +87,7 @@ func testDatabaseShardWithIndexFn(
 		SetBufferBucketVersionsPool(series.NewBufferBucketVersionsPool(nil)).
 		SetBufferBucketPool(series.NewBufferBucketPool(nil))
 	return newDatabaseShard(metadata, 0, nil, nsReaderMgr,
-		&testIncreasingIndex{}, idx, true, opts, seriesOpts).(*dbShard)
+		&testIncreasingIndex{}, newNamespaceIndexRef(idx), nil, true, opts, seriesOpts).(*dbShard)
 }
 
 func addMockSeries(ctrl *gomock.Controller, shard *dbShard, id ident.ID, tags ident.Tags, index uint64) *series.MockDatabaseSeries {
@@ -103,7 +107,7 @@ func TestShardDontNeedBootstrap(t *testing.T) {
 	defer closer()
 	seriesOpts := NewSeriesOptionsFromOptions(opts, testNs.Options().RetentionOptions())
 	shard := newDatabaseShard(testNs.metadata, 0, nil, nil,
-		&testIncreasingIndex{}, nil, false, opts, seriesOpts).(*dbShard)
+		&testIncreasingIndex{}, nil, nil, false, opts, seriesOpts).(*dbShard)
 	defer shard.Close()
 
 	require.Equal(t, Bootstrapped, shard.bootstrapState)
@@ -116,7 +120,7 @@ func TestShardErrorIfDoubleBootstrap(t *testing.T) {
 	defer closer()
 	seriesOpts := NewSeriesOptionsFromOptions(opts, testNs.Options().RetentionOptions())
 	shard := newDatabaseShard(testNs.metadata, 0, nil, nil,
-		&testIncreasingIndex{}, nil, false, opts, seriesOpts).(*dbShard)
+		&testIncreasingIndex{}, nil, nil, false, opts, seriesOpts).(*dbShard)
 	defer shard.Close()
 
 	require.Equal(t, Bootstrapped, shard.bootstrapState)
@@ -291,6 +295,77 @@ func TestShardBootstrapWithFlushVersionNoCleanUp(t *testing.T) {
 	require.Equal(t, numVolumes-1, flushState.ColdVersionFlushed)
 }
 
+// TestShardVolumesComposeAcrossPeerStreamsAndColdFlush ensures that a block
+// streamed from peers on top of a volume already on disk is written as the
+// next write-state version of the block, that the shard bootstraps its cold
+// version from it so that the next cold flush, which is also how repaired
+// data is persisted, writes the version after it, and that the superseded
+// volume is garbage collected.
+func TestShardVolumesComposeAcrossPeerStreamsAndColdFlush(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		opts   = DefaultTestOptions()
+		fsOpts = opts.CommitLogOptions().FilesystemOptions().
+			SetFilePathPrefix(dir)
+		newClOpts = opts.
+				CommitLogOptions().
+				SetFilesystemOptions(fsOpts)
+	)
+	opts = opts.
+		SetCommitLogOptions(newClOpts)
+
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	writer, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+
+	var (
+		blockSize = 2 * time.Hour
+		start     = time.Now().Truncate(blockSize)
+	)
+	// Write the volume already on disk followed by the volume streamed from
+	// peers, each at the next volume index of the block.
+	for i := 0; i < 2; i++ {
+		volumeIndex, err := fs.NextDataFileSetVolumeIndex(dir, defaultTestNs1ID,
+			s.ID(), start)
+		require.NoError(t, err)
+		require.Equal(t, i, volumeIndex)
+
+		writer.Open(fs.DataWriterOpenOptions{
+			FileSetType: persist.FileSetFlushType,
+			Identifier: fs.FileSetFileIdentifier{
+				Namespace:   defaultTestNs1ID,
+				Shard:       s.ID(),
+				BlockStart:  start,
+				VolumeIndex: volumeIndex,
+			},
+		})
+		require.NoError(t, writer.Close())
+	}
+
+	require.NoError(t, s.Bootstrap())
+
+	coldVersion, err := s.RetrievableBlockColdVersion(start)
+	require.NoError(t, err)
+	require.Equal(t, 1, coldVersion)
+
+	nextVolumeIndex, err := fs.NextDataFileSetVolumeIndex(dir, defaultTestNs1ID,
+		s.ID(), start)
+	require.NoError(t, err)
+	require.Equal(t, coldVersion+1, nextVolumeIndex)
+
+	require.NoError(t, s.CleanupCompactedFileSets())
+
+	filesets, err := fs.DataFiles(dir, defaultTestNs1ID, s.ID())
+	require.NoError(t, err)
+	require.Equal(t, 1, len(filesets))
+	require.Equal(t, 1, filesets[0].ID.VolumeIndex)
+}
+
 // TestShardBootstrapWithCacheShardIndices ensures that the shard is able to bootstrap
 // and call CacheShardIndices if a BlockRetrieverManager is present.
 func TestShardBootstrapWithCacheShardIndices(t *testing.T) {
@@ -673,6 +748,157 @@ func TestShardColdFlushNoMergeIfNothingDirty(t *testing.T) {
 	}
 }
 
+func TestShardColdFlushRollsUpAgedBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Now()
+	nowFn := func() time.Time {
+		return now
+	}
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+	blockSize := defaultTestRetentionOpts.BlockSize()
+	shard := testDatabaseShard(t, opts)
+	require.NoError(t, shard.Bootstrap())
+
+	nsOpts := defaultTestNs1Opts.SetRetentionTiers([]namespace.RetentionTier{
+		{Retention: 12 * time.Hour},
+		{Resolution: 10 * time.Minute, Retention: defaultTestRetentionOpts.RetentionPeriod()},
+	})
+	md, err := namespace.NewMetadata(defaultTestNs1ID, nsOpts)
+	require.NoError(t, err)
+	shard.namespace = md
+
+	rollup := &recordingRollup{}
+	shard.newRollupFn = func(
+		reader fs.DataFileSetReader,
+		blockAllocSize int,
+		srPool xio.SegmentReaderPool,
+		multiIterPool encoding.MultiReaderIteratorPool,
+		identPool ident.Pool,
+		encoderPool encoding.EncoderPool,
+		nsOpts namespace.Options,
+	) fs.Rollup {
+		return rollup
+	}
+
+	// t0 has aged out of the raw tier while t1 is still covered by it.
+	t0 := now.Truncate(blockSize).Add(-10 * blockSize)
+	t1 := now.Truncate(blockSize).Add(-2 * blockSize)
+	shard.markWarmFlushStateSuccess(t0)
+	shard.markWarmFlushStateSuccess(t1)
+
+	resources := coldFlushReuseableResources{
+		dirtySeries:        newDirtySeriesMap(dirtySeriesMapOptions{}),
+		dirtySeriesToWrite: make(map[xtime.UnixNano]*idList),
+		idElementPool:      newIDElementPool(nil),
+		fsReader:           fs.NewMockDataFileSetReader(ctrl),
+	}
+	preparer := persist.NewMockFlushPreparer(ctrl)
+	nsCtx := namespace.Context{}
+
+	require.NoError(t, shard.ColdFlush(preparer, resources, nsCtx))
+	require.Equal(t, 1, len(rollup.calls))
+	require.True(t, t0.Equal(rollup.calls[0].fileID.BlockStart))
+	require.Equal(t, 0, rollup.calls[0].fileID.VolumeIndex)
+	require.Equal(t, 10*time.Minute, rollup.calls[0].resolution)
+	require.Equal(t, 1, rollup.calls[0].nextVersion)
+
+	coldVersion, err := shard.RetrievableBlockColdVersion(t0)
+	require.NoError(t, err)
+	require.Equal(t, 1, coldVersion)
+	coldVersion, err = shard.RetrievableBlockColdVersion(t1)
+	require.NoError(t, err)
+	require.Equal(t, 0, coldVersion)
+
+	// Blocks already rolled up to their tier's resolution are not rolled up again.
+	require.NoError(t, shard.ColdFlush(preparer, resources, nsCtx))
+	require.Equal(t, 1, len(rollup.calls))
+}
+
+func TestShardColdFlushSkipsRollupOfImmutableBlocks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	now := time.Now()
+	nowFn := func() time.Time {
+		return now
+	}
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().SetNowFn(nowFn))
+	blockSize := defaultTestRetentionOpts.BlockSize()
+	shard := testDatabaseShard(t, opts)
+	require.NoError(t, shard.Bootstrap())
+
+	nsOpts := defaultTestNs1Opts.
+		SetRetentionTiers([]namespace.RetentionTier{
+			{Retention: 12 * time.Hour},
+			{Resolution: 10 * time.Minute, Retention: defaultTestRetentionOpts.RetentionPeriod()},
+		}).
+		SetArchivalOptions(namespace.ArchivalOptions{
+			Enabled:        true,
+			ImmutableAfter: 16 * time.Hour,
+		})
+	md, err := namespace.NewMetadata(defaultTestNs1ID, nsOpts)
+	require.NoError(t, err)
+	shard.namespace = md
+
+	rollup := &recordingRollup{}
+	shard.newRollupFn = func(
+		reader fs.DataFileSetReader,
+		blockAllocSize int,
+		srPool xio.SegmentReaderPool,
+		multiIterPool encoding.MultiReaderIteratorPool,
+		identPool ident.Pool,
+		encoderPool encoding.EncoderPool,
+		nsOpts namespace.Options,
+	) fs.Rollup {
+		return rollup
+	}
+
+	// Both blocks have aged out of the raw tier but only t1 is still mutable.
+	t0 := now.Truncate(blockSize).Add(-10 * blockSize)
+	t1 := now.Truncate(blockSize).Add(-8 * blockSize)
+	shard.markWarmFlushStateSuccess(t0)
+	shard.markWarmFlushStateSuccess(t1)
+
+	resources := coldFlushReuseableResources{
+		dirtySeries:        newDirtySeriesMap(dirtySeriesMapOptions{}),
+		dirtySeriesToWrite: make(map[xtime.UnixNano]*idList),
+		idElementPool:      newIDElementPool(nil),
+		fsReader:           fs.NewMockDataFileSetReader(ctrl),
+	}
+	preparer := persist.NewMockFlushPreparer(ctrl)
+
+	require.NoError(t, shard.ColdFlush(preparer, resources, namespace.Context{}))
+	require.Equal(t, 1, len(rollup.calls))
+	require.True(t, t1.Equal(rollup.calls[0].fileID.BlockStart))
+}
+
+type recordingRollupCall struct {
+	fileID      fs.FileSetFileIdentifier
+	resolution  time.Duration
+	nextVersion int
+}
+
+type recordingRollup struct {
+	calls []recordingRollupCall
+}
+
+func (r *recordingRollup) Rollup(
+	fileID fs.FileSetFileIdentifier,
+	resolution time.Duration,
+	nextVersion int,
+	flushPreparer persist.FlushPreparer,
+	nsCtx namespace.Context,
+) error {
+	r.calls = append(r.calls, recordingRollupCall{
+		fileID:      fileID,
+		resolution:  resolution,
+		nextVersion: nextVersion,
+	})
+	return nil
+}
+
 func newMergerTestFn(
 	reader fs.DataFileSetReader,
 	blockAllocSize int,
@@ -836,7 +1062,7 @@ func writeShardAndVerify(
 	expectedShouldWrite bool,
 	expectedIdx uint64,
 ) {
-	series, wasWritten, err := shard.Write(ctx, ident.StringID(id),
+	series, wasWritten, _, err := shard.Write(ctx, ident.StringID(id),
 		now, value, xtime.Second, nil, series.WriteOptions{})
 	assert.NoError(t, err)
 	assert.Equal(t, expectedShouldWrite, wasWritten)
@@ -986,6 +1212,31 @@ func TestShardWriteAsyncWithAnnotations(t *testing.T) {
 	})
 }
 
+func TestShardWriteNewSeriesCardinalityThrottled(t *testing.T) {
+	shard := testDatabaseShard(t, DefaultTestOptions())
+	require.NoError(t, shard.Bootstrap())
+	defer shard.Close()
+	shard.newSeriesLimiter.SetRuntimeOptions(runtime.NewOptions().
+		SetWriteNewSeriesAdmissionLimitPerShardPerSecond(1))
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	now := time.Now()
+	writeShardAndVerify(ctx, t, shard, "foo", now, 1.0, true, 0)
+
+	// Creating another new series exceeds the admission budget.
+	_, wasWritten, _, err := shard.Write(ctx, ident.StringID("bar"),
+		now, 2.0, xtime.Second, nil, series.WriteOptions{})
+	require.Error(t, err)
+	require.False(t, wasWritten)
+	require.True(t, dberrors.IsCardinalityThrottledError(err))
+	require.True(t, xerrors.IsResourceExhaustedError(err))
+
+	// Writes to existing series are not throttled.
+	writeShardAndVerify(ctx, t, shard, "foo", now.Add(time.Second), 3.0, true, 0)
+}
+
 func testShardWriteAsync(t *testing.T, writes []testWrite) {
 	dir, err := ioutil.TempDir("", "testdir")
 	require.NoError(t, err)
@@ -1318,7 +1569,7 @@ func TestPurgeExpiredSeriesWriteAfterTicking(t *testing.T) {
 	s.EXPECT().Tick(gomock.Any(), gomock.Any()).Do(func(interface{}, interface{}) {
 		// Emulate a write taking place just after tick for this series
 		s.EXPECT().Write(gomock.Any(), gomock.Any(), gomock.Any(),
-			gomock.Any(), gomock.Any(), gomock.Any()).Return(true, nil)
+			gomock.Any(), gomock.Any(), gomock.Any()).Return(true, series.WriteAccepted, nil)
 
 		ctx := opts.ContextPool().Get()
 		nowFn := opts.ClockOptions().NowFn()
@@ -1447,6 +1698,103 @@ func TestShardCleanupExpiredFileSets(t *testing.T) {
 	require.Equal(t, []string{defaultTestNs1ID.String(), "0"}, deletedFiles)
 }
 
+type testBlockExpiryHookSeries struct {
+	blockStart time.Time
+	id         string
+	values     []float64
+}
+
+type testBlockExpiryHook struct {
+	series []testBlockExpiryHookSeries
+}
+
+func (h *testBlockExpiryHook) OnExpiringSeries(
+	block ExpiringBlock,
+	id ident.ID,
+	_ ident.Tags,
+	iter encoding.Iterator,
+) error {
+	var values []float64
+	for iter.Next() {
+		dp, _, _ := iter.Current()
+		values = append(values, dp.Value)
+	}
+	h.series = append(h.series, testBlockExpiryHookSeries{
+		blockStart: block.BlockStart,
+		id:         id.String(),
+		values:     values,
+	})
+	return iter.Err()
+}
+
+func TestShardRunBlockExpiryHooks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "testdir")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		opts   = DefaultTestOptions()
+		fsOpts = opts.CommitLogOptions().FilesystemOptions().
+			SetFilePathPrefix(dir)
+		newClOpts = opts.
+				CommitLogOptions().
+				SetFilesystemOptions(fsOpts)
+	)
+	opts = opts.
+		SetCommitLogOptions(newClOpts)
+
+	s := testDatabaseShard(t, opts)
+	defer s.Close()
+
+	writer, err := fs.NewWriter(fsOpts)
+	require.NoError(t, err)
+
+	var (
+		blockSize        = s.namespace.Options().RetentionOptions().BlockSize()
+		earliestToRetain = time.Now().Truncate(blockSize).Add(-4 * blockSize)
+		expiredStart     = earliestToRetain.Add(-blockSize)
+	)
+	for i, blockStart := range []time.Time{expiredStart, earliestToRetain} {
+		require.NoError(t, writer.Open(fs.DataWriterOpenOptions{
+			FileSetType: persist.FileSetFlushType,
+			Identifier: fs.FileSetFileIdentifier{
+				Namespace:  s.namespace.ID(),
+				Shard:      s.ID(),
+				BlockStart: blockStart,
+			},
+			BlockSize: blockSize,
+		}))
+
+		encoder := m3tsz.NewEncoder(blockStart, nil, true, encoding.NewOptions())
+		for j := 0; j < 2; j++ {
+			dp := ts.Datapoint{
+				Timestamp: blockStart.Add(time.Duration(j) * time.Minute),
+				Value:     float64(i*10 + j),
+			}
+			require.NoError(t, encoder.Encode(dp, xtime.Second, nil))
+		}
+		ctx := context.NewContext()
+		stream, ok := encoder.Stream(ctx)
+		require.True(t, ok)
+		data, err := ioutil.ReadAll(stream)
+		require.NoError(t, err)
+		ctx.Close()
+
+		bytes := checked.NewBytes(data, nil)
+		bytes.IncRef()
+		require.NoError(t, writer.Write(ident.StringID("foo"), ident.Tags{},
+			bytes, digest.Checksum(data)))
+		require.NoError(t, writer.Close())
+	}
+
+	hook := &testBlockExpiryHook{}
+	require.NoError(t, s.RunBlockExpiryHooks(earliestToRetain, []BlockExpiryHook{hook}))
+	require.Equal(t, 1, len(hook.series))
+	require.True(t, expiredStart.Equal(hook.series[0].blockStart))
+	require.Equal(t, "foo", hook.series[0].id)
+	require.Equal(t, []float64{0, 1}, hook.series[0].values)
+}
+
 type testCloser struct {
 	called int
 }
@@ -1461,13 +1809,15 @@ func TestShardRegisterRuntimeOptionsListeners(t *testing.T) {
 
 	callRegisterListenerOnShard := 0
 	callRegisterListenerOnShardInsertQueue := 0
+	callRegisterListenerOnShardNewSeriesLimiter := 0
+	callRegisterListenerOnShardErrorBudget := 0
 
 	closer := &testCloser{}
 
 	runtimeOptsMgr := runtime.NewMockOptionsManager(ctrl)
 	runtimeOptsMgr.EXPECT().
 		RegisterListener(gomock.Any()).
-		Times(2).
+		Times(4).
 		Do(func(l runtime.OptionsListener) {
 			if _, ok := l.(*dbShard); ok {
 				callRegisterListenerOnShard++
@@ -1475,6 +1825,12 @@ func TestShardRegisterRuntimeOptionsListeners(t *testing.T) {
 			if _, ok := l.(*dbShardInsertQueue); ok {
 				callRegisterListenerOnShardInsertQueue++
 			}
+			if _, ok := l.(*shardNewSeriesLimiter); ok {
+				callRegisterListenerOnShardNewSeriesLimiter++
+			}
+			if _, ok := l.(*shardErrorBudget); ok {
+				callRegisterListenerOnShardErrorBudget++
+			}
 		}).
 		Return(closer)
 
@@ -1485,12 +1841,14 @@ func TestShardRegisterRuntimeOptionsListeners(t *testing.T) {
 
 	assert.Equal(t, 1, callRegisterListenerOnShard)
 	assert.Equal(t, 1, callRegisterListenerOnShardInsertQueue)
+	assert.Equal(t, 1, callRegisterListenerOnShardNewSeriesLimiter)
+	assert.Equal(t, 1, callRegisterListenerOnShardErrorBudget)
 
 	assert.Equal(t, 0, closer.called)
 
 	shard.Close()
 
-	assert.Equal(t, 2, closer.called)
+	assert.Equal(t, 4, closer.called)
 }
 
 func TestShardReadEncodedCachesSeriesWithRecentlyReadPolicy(t *testing.T) {
@@ -0,0 +1,125 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync"
+	"time"
+)
+
+type snapshotTracker struct {
+	sync.Mutex
+
+	opts SnapshotTrackerOptions
+
+	numUnsnapshottedBytes         int64
+	numUnsnapshottedSeries        int64
+	numPendingUnsnapshottedBytes  int64
+	numPendingUnsnapshottedSeries int64
+}
+
+func (t *snapshotTracker) IncNumUnsnapshottedBytes(x int64) {
+	t.Lock()
+	t.numUnsnapshottedBytes += x
+	t.Unlock()
+}
+
+func (t *snapshotTracker) IncNumUnsnapshottedSeries(x int64) {
+	t.Lock()
+	t.numUnsnapshottedSeries += x
+	t.Unlock()
+}
+
+func (t *snapshotTracker) NumUnsnapshottedBytes() int64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.numUnsnapshottedBytes
+}
+
+func (t *snapshotTracker) NumUnsnapshottedSeries() int64 {
+	t.Lock()
+	defer t.Unlock()
+	return t.numUnsnapshottedSeries
+}
+
+func (t *snapshotTracker) ShouldSnapshot(sinceLastSnapshot time.Duration) bool {
+	t.Lock()
+	defer t.Unlock()
+	// Minimum interval of 0 means snapshot every time the flush manager runs.
+	if sinceLastSnapshot >= t.opts.minimumInterval {
+		return true
+	}
+	// Thresholds of 0 mean the threshold is disabled.
+	if limit := t.opts.unsnapshottedBytesThreshold; limit > 0 &&
+		t.numUnsnapshottedBytes >= limit {
+		return true
+	}
+	if limit := t.opts.unsnapshottedSeriesThreshold; limit > 0 &&
+		t.numUnsnapshottedSeries >= limit {
+		return true
+	}
+	return false
+}
+
+func (t *snapshotTracker) MarkUnsnapshottedAsPending() {
+	t.Lock()
+	t.numPendingUnsnapshottedBytes = t.numUnsnapshottedBytes
+	t.numPendingUnsnapshottedSeries = t.numUnsnapshottedSeries
+	t.Unlock()
+}
+
+func (t *snapshotTracker) DecPendingUnsnapshotted() {
+	t.Lock()
+	t.numUnsnapshottedBytes -= t.numPendingUnsnapshottedBytes
+	t.numUnsnapshottedSeries -= t.numPendingUnsnapshottedSeries
+	t.numPendingUnsnapshottedBytes = 0
+	t.numPendingUnsnapshottedSeries = 0
+	t.Unlock()
+}
+
+// SnapshotTrackerOptions are the options for the SnapshotTracker.
+type SnapshotTrackerOptions struct {
+	minimumInterval              time.Duration
+	unsnapshottedBytesThreshold  int64
+	unsnapshottedSeriesThreshold int64
+}
+
+// NewSnapshotTrackerOptions creates a new SnapshotTrackerOptions, a minimum
+// interval of zero snapshots every time the flush manager runs and thresholds
+// of zero are disabled.
+func NewSnapshotTrackerOptions(
+	minimumInterval time.Duration,
+	unsnapshottedBytesThreshold int64,
+	unsnapshottedSeriesThreshold int64,
+) SnapshotTrackerOptions {
+	return SnapshotTrackerOptions{
+		minimumInterval:              minimumInterval,
+		unsnapshottedBytesThreshold:  unsnapshottedBytesThreshold,
+		unsnapshottedSeriesThreshold: unsnapshottedSeriesThreshold,
+	}
+}
+
+// NewSnapshotTracker creates a new SnapshotTracker.
+func NewSnapshotTracker(opts SnapshotTrackerOptions) SnapshotTracker {
+	return &snapshotTracker{
+		opts: opts,
+	}
+}
@@ -0,0 +1,75 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotTrackerShouldSnapshotAfterMinimumInterval(t *testing.T) {
+	snapshotTracker := NewSnapshotTracker(NewSnapshotTrackerOptions(time.Minute, 0, 0))
+	require.False(t, snapshotTracker.ShouldSnapshot(time.Second))
+	require.True(t, snapshotTracker.ShouldSnapshot(time.Minute))
+}
+
+func TestSnapshotTrackerShouldSnapshotEveryTimeIfNoMinimumInterval(t *testing.T) {
+	snapshotTracker := NewSnapshotTracker(NewSnapshotTrackerOptions(0, 0, 0))
+	require.True(t, snapshotTracker.ShouldSnapshot(0))
+}
+
+func TestSnapshotTrackerShouldSnapshotAfterThresholdsExceeded(t *testing.T) {
+	snapshotTracker := NewSnapshotTracker(NewSnapshotTrackerOptions(time.Hour, 100, 10))
+	snapshotTracker.IncNumUnsnapshottedBytes(99)
+	snapshotTracker.IncNumUnsnapshottedSeries(9)
+	require.False(t, snapshotTracker.ShouldSnapshot(time.Second))
+
+	snapshotTracker.IncNumUnsnapshottedBytes(1)
+	require.True(t, snapshotTracker.ShouldSnapshot(time.Second))
+
+	snapshotTracker.MarkUnsnapshottedAsPending()
+	snapshotTracker.DecPendingUnsnapshotted()
+	require.False(t, snapshotTracker.ShouldSnapshot(time.Second))
+
+	snapshotTracker.IncNumUnsnapshottedSeries(10)
+	require.True(t, snapshotTracker.ShouldSnapshot(time.Second))
+}
+
+func TestSnapshotTrackerIncMarkAndDec(t *testing.T) {
+	snapshotTracker := NewSnapshotTracker(NewSnapshotTrackerOptions(0, 0, 0))
+	snapshotTracker.IncNumUnsnapshottedBytes(10)
+	snapshotTracker.IncNumUnsnapshottedSeries(1)
+	snapshotTracker.MarkUnsnapshottedAsPending()
+
+	// Writes after the mark are not cleared by the following dec.
+	snapshotTracker.IncNumUnsnapshottedBytes(20)
+	snapshotTracker.IncNumUnsnapshottedSeries(2)
+	snapshotTracker.DecPendingUnsnapshotted()
+	require.Equal(t, int64(20), snapshotTracker.NumUnsnapshottedBytes())
+	require.Equal(t, int64(2), snapshotTracker.NumUnsnapshottedSeries())
+
+	// Calling dec twice does not decrement twice.
+	snapshotTracker.DecPendingUnsnapshotted()
+	require.Equal(t, int64(20), snapshotTracker.NumUnsnapshottedBytes())
+	require.Equal(t, int64(2), snapshotTracker.NumUnsnapshottedSeries())
+}
@@ -32,11 +32,13 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -46,6 +48,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
@@ -254,6 +257,35 @@ func (mr *MockDatabaseMockRecorder) WriteTagged(ctx, namespace, id, tags, timest
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockDatabase)(nil).WriteTagged), ctx, namespace, id, tags, timestamp, value, unit, annotation)
 }
 
+// WriteTaggedBackfill mocks base method
+func (m *MockDatabase) WriteTaggedBackfill(ctx context.Context, namespace, id ident.ID, tags ident.TagIterator, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTaggedBackfill", ctx, namespace, id, tags, timestamp, value, unit, annotation)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// WriteTaggedBackfill indicates an expected call of WriteTaggedBackfill
+func (mr *MockDatabaseMockRecorder) WriteTaggedBackfill(ctx, namespace, id, tags, timestamp, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTaggedBackfill", reflect.TypeOf((*MockDatabase)(nil).WriteTaggedBackfill), ctx, namespace, id, tags, timestamp, value, unit, annotation)
+}
+
+// Import mocks base method
+func (m *MockDatabase) Import(ctx context.Context, namespace ident.ID, iter ImportIterator) (ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, namespace, iter)
+	ret0, _ := ret[0].(ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import
+func (mr *MockDatabaseMockRecorder) Import(ctx, namespace, iter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockDatabase)(nil).Import), ctx, namespace, iter)
+}
+
 // BatchWriter mocks base method
 func (m *MockDatabase) BatchWriter(namespace ident.ID, batchSize int) (ts.BatchWriter, error) {
 	m.ctrl.T.Helper()
@@ -373,6 +405,21 @@ func (mr *MockDatabaseMockRecorder) FetchBlocksMetadataV2(ctx, namespace, shard,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlocksMetadataV2", reflect.TypeOf((*MockDatabase)(nil).FetchBlocksMetadataV2), ctx, namespace, shard, start, end, limit, pageToken, opts)
 }
 
+// StreamBlocksMetadataV2 mocks base method
+func (m *MockDatabase) StreamBlocksMetadataV2(ctx context.Context, namespace ident.ID, shard uint32, start, end time.Time, limit int64, pageToken PageToken, opts block.FetchBlocksMetadataOptions, fn block.FetchBlocksMetadataResultFn) (PageToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamBlocksMetadataV2", ctx, namespace, shard, start, end, limit, pageToken, opts, fn)
+	ret0, _ := ret[0].(PageToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamBlocksMetadataV2 indicates an expected call of StreamBlocksMetadataV2
+func (mr *MockDatabaseMockRecorder) StreamBlocksMetadataV2(ctx, namespace, shard, start, end, limit, pageToken, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamBlocksMetadataV2", reflect.TypeOf((*MockDatabase)(nil).StreamBlocksMetadataV2), ctx, namespace, shard, start, end, limit, pageToken, opts, fn)
+}
+
 // Bootstrap mocks base method
 func (m *MockDatabase) Bootstrap() error {
 	m.ctrl.T.Helper()
@@ -487,6 +534,36 @@ func (mr *MockDatabaseMockRecorder) FlushState(namespace, shardID, blockStart in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushState", reflect.TypeOf((*MockDatabase)(nil).FlushState), namespace, shardID, blockStart)
 }
 
+// ShardWatermarks mocks base method
+func (m *MockDatabase) ShardWatermarks(namespace ident.ID) ([]ShardWatermark, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardWatermarks", namespace)
+	ret0, _ := ret[0].([]ShardWatermark)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardWatermarks indicates an expected call of ShardWatermarks
+func (mr *MockDatabaseMockRecorder) ShardWatermarks(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardWatermarks", reflect.TypeOf((*MockDatabase)(nil).ShardWatermarks), namespace)
+}
+
+// RepairHistory mocks base method
+func (m *MockDatabase) RepairHistory(namespace ident.ID) ([]BlockRepairHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairHistory", namespace)
+	ret0, _ := ret[0].([]BlockRepairHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepairHistory indicates an expected call of RepairHistory
+func (mr *MockDatabaseMockRecorder) RepairHistory(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairHistory", reflect.TypeOf((*MockDatabase)(nil).RepairHistory), namespace)
+}
+
 // Mockdatabase is a mock of database interface
 type Mockdatabase struct {
 	ctrl     *gomock.Controller
@@ -649,6 +726,21 @@ func (mr *MockdatabaseMockRecorder) WriteTagged(ctx, namespace, id, tags, timest
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*Mockdatabase)(nil).WriteTagged), ctx, namespace, id, tags, timestamp, value, unit, annotation)
 }
 
+// Import mocks base method
+func (m *Mockdatabase) Import(ctx context.Context, namespace ident.ID, iter ImportIterator) (ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, namespace, iter)
+	ret0, _ := ret[0].(ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import
+func (mr *MockdatabaseMockRecorder) Import(ctx, namespace, iter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*Mockdatabase)(nil).Import), ctx, namespace, iter)
+}
+
 // BatchWriter mocks base method
 func (m *Mockdatabase) BatchWriter(namespace ident.ID, batchSize int) (ts.BatchWriter, error) {
 	m.ctrl.T.Helper()
@@ -882,6 +974,36 @@ func (mr *MockdatabaseMockRecorder) FlushState(namespace, shardID, blockStart in
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FlushState", reflect.TypeOf((*Mockdatabase)(nil).FlushState), namespace, shardID, blockStart)
 }
 
+// ShardWatermarks mocks base method
+func (m *Mockdatabase) ShardWatermarks(namespace ident.ID) ([]ShardWatermark, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardWatermarks", namespace)
+	ret0, _ := ret[0].([]ShardWatermark)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ShardWatermarks indicates an expected call of ShardWatermarks
+func (mr *MockdatabaseMockRecorder) ShardWatermarks(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardWatermarks", reflect.TypeOf((*Mockdatabase)(nil).ShardWatermarks), namespace)
+}
+
+// RepairHistory mocks base method
+func (m *Mockdatabase) RepairHistory(namespace ident.ID) ([]BlockRepairHistory, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairHistory", namespace)
+	ret0, _ := ret[0].([]BlockRepairHistory)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RepairHistory indicates an expected call of RepairHistory
+func (mr *MockdatabaseMockRecorder) RepairHistory(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairHistory", reflect.TypeOf((*Mockdatabase)(nil).RepairHistory), namespace)
+}
+
 // GetOwnedNamespaces mocks base method
 func (m *Mockdatabase) GetOwnedNamespaces() ([]databaseNamespace, error) {
 	m.ctrl.T.Helper()
@@ -1018,6 +1140,106 @@ func (mr *MockNamespaceMockRecorder) Shards() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shards", reflect.TypeOf((*MockNamespace)(nil).Shards))
 }
 
+// WriteLateness mocks base method
+func (m *MockNamespace) WriteLateness() WriteLateness {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteLateness")
+	ret0, _ := ret[0].(WriteLateness)
+	return ret0
+}
+
+// WriteLateness indicates an expected call of WriteLateness
+func (mr *MockNamespaceMockRecorder) WriteLateness() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLateness", reflect.TypeOf((*MockNamespace)(nil).WriteLateness))
+}
+
+// BlockSizeAnalysis mocks base method
+func (m *MockNamespace) BlockSizeAnalysis() BlockSizeAnalysis {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSizeAnalysis")
+	ret0, _ := ret[0].(BlockSizeAnalysis)
+	return ret0
+}
+
+// BlockSizeAnalysis indicates an expected call of BlockSizeAnalysis
+func (mr *MockNamespaceMockRecorder) BlockSizeAnalysis() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSizeAnalysis", reflect.TypeOf((*MockNamespace)(nil).BlockSizeAnalysis))
+}
+
+// Freeze mocks base method
+func (m *MockNamespace) Freeze(start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Freeze", start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Freeze indicates an expected call of Freeze
+func (mr *MockNamespaceMockRecorder) Freeze(start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Freeze", reflect.TypeOf((*MockNamespace)(nil).Freeze), start, end)
+}
+
+// Unfreeze mocks base method
+func (m *MockNamespace) Unfreeze(start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfreeze", start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfreeze indicates an expected call of Unfreeze
+func (mr *MockNamespaceMockRecorder) Unfreeze(start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfreeze", reflect.TypeOf((*MockNamespace)(nil).Unfreeze), start, end)
+}
+
+// FrozenRanges mocks base method
+func (m *MockNamespace) FrozenRanges() []time0.Range {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FrozenRanges")
+	ret0, _ := ret[0].([]time0.Range)
+	return ret0
+}
+
+// FrozenRanges indicates an expected call of FrozenRanges
+func (mr *MockNamespaceMockRecorder) FrozenRanges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FrozenRanges", reflect.TypeOf((*MockNamespace)(nil).FrozenRanges))
+}
+
+// IsFrozen mocks base method
+func (m *MockNamespace) IsFrozen(start, end time.Time) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFrozen", start, end)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsFrozen indicates an expected call of IsFrozen
+func (mr *MockNamespaceMockRecorder) IsFrozen(start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFrozen", reflect.TypeOf((*MockNamespace)(nil).IsFrozen), start, end)
+}
+
+// SeriesLastWrite mocks base method
+func (m *MockNamespace) SeriesLastWrite(id ident.ID) (time.Time, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SeriesLastWrite", id)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SeriesLastWrite indicates an expected call of SeriesLastWrite
+func (mr *MockNamespaceMockRecorder) SeriesLastWrite(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SeriesLastWrite", reflect.TypeOf((*MockNamespace)(nil).SeriesLastWrite), id)
+}
+
 // MockdatabaseNamespace is a mock of databaseNamespace interface
 type MockdatabaseNamespace struct {
 	ctrl     *gomock.Controller
@@ -1125,6 +1347,106 @@ func (mr *MockdatabaseNamespaceMockRecorder) Shards() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Shards", reflect.TypeOf((*MockdatabaseNamespace)(nil).Shards))
 }
 
+// WriteLateness mocks base method
+func (m *MockdatabaseNamespace) WriteLateness() WriteLateness {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteLateness")
+	ret0, _ := ret[0].(WriteLateness)
+	return ret0
+}
+
+// WriteLateness indicates an expected call of WriteLateness
+func (mr *MockdatabaseNamespaceMockRecorder) WriteLateness() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteLateness", reflect.TypeOf((*MockdatabaseNamespace)(nil).WriteLateness))
+}
+
+// BlockSizeAnalysis mocks base method
+func (m *MockdatabaseNamespace) BlockSizeAnalysis() BlockSizeAnalysis {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockSizeAnalysis")
+	ret0, _ := ret[0].(BlockSizeAnalysis)
+	return ret0
+}
+
+// BlockSizeAnalysis indicates an expected call of BlockSizeAnalysis
+func (mr *MockdatabaseNamespaceMockRecorder) BlockSizeAnalysis() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockSizeAnalysis", reflect.TypeOf((*MockdatabaseNamespace)(nil).BlockSizeAnalysis))
+}
+
+// Freeze mocks base method
+func (m *MockdatabaseNamespace) Freeze(start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Freeze", start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Freeze indicates an expected call of Freeze
+func (mr *MockdatabaseNamespaceMockRecorder) Freeze(start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Freeze", reflect.TypeOf((*MockdatabaseNamespace)(nil).Freeze), start, end)
+}
+
+// Unfreeze mocks base method
+func (m *MockdatabaseNamespace) Unfreeze(start, end time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unfreeze", start, end)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unfreeze indicates an expected call of Unfreeze
+func (mr *MockdatabaseNamespaceMockRecorder) Unfreeze(start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unfreeze", reflect.TypeOf((*MockdatabaseNamespace)(nil).Unfreeze), start, end)
+}
+
+// FrozenRanges mocks base method
+func (m *MockdatabaseNamespace) FrozenRanges() []time0.Range {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FrozenRanges")
+	ret0, _ := ret[0].([]time0.Range)
+	return ret0
+}
+
+// FrozenRanges indicates an expected call of FrozenRanges
+func (mr *MockdatabaseNamespaceMockRecorder) FrozenRanges() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FrozenRanges", reflect.TypeOf((*MockdatabaseNamespace)(nil).FrozenRanges))
+}
+
+// IsFrozen mocks base method
+func (m *MockdatabaseNamespace) IsFrozen(start, end time.Time) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsFrozen", start, end)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsFrozen indicates an expected call of IsFrozen
+func (mr *MockdatabaseNamespaceMockRecorder) IsFrozen(start, end interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsFrozen", reflect.TypeOf((*MockdatabaseNamespace)(nil).IsFrozen), start, end)
+}
+
+// SeriesLastWrite mocks base method
+func (m *MockdatabaseNamespace) SeriesLastWrite(id ident.ID) (time.Time, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SeriesLastWrite", id)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SeriesLastWrite indicates an expected call of SeriesLastWrite
+func (mr *MockdatabaseNamespaceMockRecorder) SeriesLastWrite(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SeriesLastWrite", reflect.TypeOf((*MockdatabaseNamespace)(nil).SeriesLastWrite), id)
+}
+
 // Close mocks base method
 func (m *MockdatabaseNamespace) Close() error {
 	m.ctrl.T.Helper()
@@ -1151,6 +1473,48 @@ func (mr *MockdatabaseNamespaceMockRecorder) AssignShardSet(shardSet interface{}
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AssignShardSet", reflect.TypeOf((*MockdatabaseNamespace)(nil).AssignShardSet), shardSet)
 }
 
+// ShardSet mocks base method
+func (m *MockdatabaseNamespace) ShardSet() sharding.ShardSet {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShardSet")
+	ret0, _ := ret[0].(sharding.ShardSet)
+	return ret0
+}
+
+// ShardSet indicates an expected call of ShardSet
+func (mr *MockdatabaseNamespaceMockRecorder) ShardSet() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShardSet", reflect.TypeOf((*MockdatabaseNamespace)(nil).ShardSet))
+}
+
+// UpdateBufferPastAndFuture mocks base method
+func (m *MockdatabaseNamespace) UpdateBufferPastAndFuture(bufferPast, bufferFuture time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateBufferPastAndFuture", bufferPast, bufferFuture)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateBufferPastAndFuture indicates an expected call of UpdateBufferPastAndFuture
+func (mr *MockdatabaseNamespaceMockRecorder) UpdateBufferPastAndFuture(bufferPast, bufferFuture interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateBufferPastAndFuture", reflect.TypeOf((*MockdatabaseNamespace)(nil).UpdateBufferPastAndFuture), bufferPast, bufferFuture)
+}
+
+// EnableIndex mocks base method
+func (m *MockdatabaseNamespace) EnableIndex(indexOpts namespace.IndexOptions) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "EnableIndex", indexOpts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// EnableIndex indicates an expected call of EnableIndex
+func (mr *MockdatabaseNamespaceMockRecorder) EnableIndex(indexOpts interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "EnableIndex", reflect.TypeOf((*MockdatabaseNamespace)(nil).EnableIndex), indexOpts)
+}
+
 // GetOwnedShards mocks base method
 func (m *MockdatabaseNamespace) GetOwnedShards() []databaseShard {
 	m.ctrl.T.Helper()
@@ -1195,13 +1559,14 @@ func (mr *MockdatabaseNamespaceMockRecorder) Tick(c, startTime interface{}) *gom
 }
 
 // Write mocks base method
-func (m *MockdatabaseNamespace) Write(ctx context.Context, id ident.ID, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) (ts.Series, bool, error) {
+func (m *MockdatabaseNamespace) Write(ctx context.Context, id ident.ID, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) (ts.Series, bool, series.WriteDisposition, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Write", ctx, id, timestamp, value, unit, annotation)
 	ret0, _ := ret[0].(ts.Series)
 	ret1, _ := ret[1].(bool)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret2, _ := ret[2].(series.WriteDisposition)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
 }
 
 // Write indicates an expected call of Write
@@ -1211,13 +1576,14 @@ func (mr *MockdatabaseNamespaceMockRecorder) Write(ctx, id, timestamp, value, un
 }
 
 // WriteTagged mocks base method
-func (m *MockdatabaseNamespace) WriteTagged(ctx context.Context, id ident.ID, tags ident.TagIterator, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) (ts.Series, bool, error) {
+func (m *MockdatabaseNamespace) WriteTagged(ctx context.Context, id ident.ID, tags ident.TagIterator, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) (ts.Series, bool, series.WriteDisposition, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "WriteTagged", ctx, id, tags, timestamp, value, unit, annotation)
 	ret0, _ := ret[0].(ts.Series)
 	ret1, _ := ret[1].(bool)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret2, _ := ret[2].(series.WriteDisposition)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
 }
 
 // WriteTagged indicates an expected call of WriteTagged
@@ -1226,6 +1592,38 @@ func (mr *MockdatabaseNamespaceMockRecorder) WriteTagged(ctx, id, tags, timestam
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTagged", reflect.TypeOf((*MockdatabaseNamespace)(nil).WriteTagged), ctx, id, tags, timestamp, value, unit, annotation)
 }
 
+// WriteTaggedBackfill mocks base method
+func (m *MockdatabaseNamespace) WriteTaggedBackfill(ctx context.Context, id ident.ID, tags ident.TagIterator, timestamp time.Time, value float64, unit time0.Unit, annotation []byte) (ts.Series, bool, series.WriteDisposition, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteTaggedBackfill", ctx, id, tags, timestamp, value, unit, annotation)
+	ret0, _ := ret[0].(ts.Series)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(series.WriteDisposition)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
+}
+
+// WriteTaggedBackfill indicates an expected call of WriteTaggedBackfill
+func (mr *MockdatabaseNamespaceMockRecorder) WriteTaggedBackfill(ctx, id, tags, timestamp, value, unit, annotation interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteTaggedBackfill", reflect.TypeOf((*MockdatabaseNamespace)(nil).WriteTaggedBackfill), ctx, id, tags, timestamp, value, unit, annotation)
+}
+
+// Import mocks base method
+func (m *MockdatabaseNamespace) Import(ctx context.Context, iter ImportIterator, fileOps importFileOps) (ImportResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Import", ctx, iter, fileOps)
+	ret0, _ := ret[0].(ImportResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Import indicates an expected call of Import
+func (mr *MockdatabaseNamespaceMockRecorder) Import(ctx, iter, fileOps interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Import", reflect.TypeOf((*MockdatabaseNamespace)(nil).Import), ctx, iter, fileOps)
+}
+
 // QueryIDs mocks base method
 func (m *MockdatabaseNamespace) QueryIDs(ctx context.Context, query index.Query, opts index.QueryOptions) (index.QueryResult, error) {
 	m.ctrl.T.Helper()
@@ -1302,6 +1700,21 @@ func (mr *MockdatabaseNamespaceMockRecorder) FetchBlocksMetadataV2(ctx, shardID,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlocksMetadataV2", reflect.TypeOf((*MockdatabaseNamespace)(nil).FetchBlocksMetadataV2), ctx, shardID, start, end, limit, pageToken, opts)
 }
 
+// StreamBlocksMetadataV2 mocks base method
+func (m *MockdatabaseNamespace) StreamBlocksMetadataV2(ctx context.Context, shardID uint32, start, end time.Time, limit int64, pageToken PageToken, opts block.FetchBlocksMetadataOptions, fn block.FetchBlocksMetadataResultFn) (PageToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamBlocksMetadataV2", ctx, shardID, start, end, limit, pageToken, opts, fn)
+	ret0, _ := ret[0].(PageToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamBlocksMetadataV2 indicates an expected call of StreamBlocksMetadataV2
+func (mr *MockdatabaseNamespaceMockRecorder) StreamBlocksMetadataV2(ctx, shardID, start, end, limit, pageToken, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamBlocksMetadataV2", reflect.TypeOf((*MockdatabaseNamespace)(nil).StreamBlocksMetadataV2), ctx, shardID, start, end, limit, pageToken, opts, fn)
+}
+
 // PrepareBootstrap mocks base method
 func (m *MockdatabaseNamespace) PrepareBootstrap() ([]databaseShard, error) {
 	m.ctrl.T.Helper()
@@ -1373,6 +1786,36 @@ func (mr *MockdatabaseNamespaceMockRecorder) ColdFlush(flush interface{}) *gomoc
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ColdFlush", reflect.TypeOf((*MockdatabaseNamespace)(nil).ColdFlush), flush)
 }
 
+// CompactFileSets mocks base method
+func (m *MockdatabaseNamespace) CompactFileSets(limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompactFileSets", limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompactFileSets indicates an expected call of CompactFileSets
+func (mr *MockdatabaseNamespaceMockRecorder) CompactFileSets(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompactFileSets", reflect.TypeOf((*MockdatabaseNamespace)(nil).CompactFileSets), limit)
+}
+
+// MigrateFileSets mocks base method
+func (m *MockdatabaseNamespace) MigrateFileSets(flush persist.FlushPreparer, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateFileSets", flush, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateFileSets indicates an expected call of MigrateFileSets
+func (mr *MockdatabaseNamespaceMockRecorder) MigrateFileSets(flush, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateFileSets", reflect.TypeOf((*MockdatabaseNamespace)(nil).MigrateFileSets), flush, limit)
+}
+
 // Snapshot mocks base method
 func (m *MockdatabaseNamespace) Snapshot(blockStart, snapshotTime time.Time, flush persist.SnapshotPreparer) error {
 	m.ctrl.T.Helper()
@@ -1620,6 +2063,20 @@ func (mr *MockdatabaseShardMockRecorder) IsBootstrapped() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsBootstrapped", reflect.TypeOf((*MockdatabaseShard)(nil).IsBootstrapped))
 }
 
+// IsDegraded mocks base method
+func (m *MockdatabaseShard) IsDegraded() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsDegraded")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// IsDegraded indicates an expected call of IsDegraded
+func (mr *MockdatabaseShardMockRecorder) IsDegraded() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsDegraded", reflect.TypeOf((*MockdatabaseShard)(nil).IsDegraded))
+}
+
 // BootstrapState mocks base method
 func (m *MockdatabaseShard) BootstrapState() BootstrapState {
 	m.ctrl.T.Helper()
@@ -1676,13 +2133,14 @@ func (mr *MockdatabaseShardMockRecorder) Tick(c, startTime, nsCtx interface{}) *
 }
 
 // Write mocks base method
-func (m *MockdatabaseShard) Write(ctx context.Context, id ident.ID, timestamp time.Time, value float64, unit time0.Unit, annotation []byte, wOpts series.WriteOptions) (ts.Series, bool, error) {
+func (m *MockdatabaseShard) Write(ctx context.Context, id ident.ID, timestamp time.Time, value float64, unit time0.Unit, annotation []byte, wOpts series.WriteOptions) (ts.Series, bool, series.WriteDisposition, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "Write", ctx, id, timestamp, value, unit, annotation, wOpts)
 	ret0, _ := ret[0].(ts.Series)
 	ret1, _ := ret[1].(bool)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret2, _ := ret[2].(series.WriteDisposition)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
 }
 
 // Write indicates an expected call of Write
@@ -1692,13 +2150,14 @@ func (mr *MockdatabaseShardMockRecorder) Write(ctx, id, timestamp, value, unit,
 }
 
 // WriteTagged mocks base method
-func (m *MockdatabaseShard) WriteTagged(ctx context.Context, id ident.ID, tags ident.TagIterator, timestamp time.Time, value float64, unit time0.Unit, annotation []byte, wOpts series.WriteOptions) (ts.Series, bool, error) {
+func (m *MockdatabaseShard) WriteTagged(ctx context.Context, id ident.ID, tags ident.TagIterator, timestamp time.Time, value float64, unit time0.Unit, annotation []byte, wOpts series.WriteOptions) (ts.Series, bool, series.WriteDisposition, error) {
 	m.ctrl.T.Helper()
 	ret := m.ctrl.Call(m, "WriteTagged", ctx, id, tags, timestamp, value, unit, annotation, wOpts)
 	ret0, _ := ret[0].(ts.Series)
 	ret1, _ := ret[1].(bool)
-	ret2, _ := ret[2].(error)
-	return ret0, ret1, ret2
+	ret2, _ := ret[2].(series.WriteDisposition)
+	ret3, _ := ret[3].(error)
+	return ret0, ret1, ret2, ret3
 }
 
 // WriteTagged indicates an expected call of WriteTagged
@@ -1768,6 +2227,21 @@ func (mr *MockdatabaseShardMockRecorder) FetchBlocksMetadataV2(ctx, start, end,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FetchBlocksMetadataV2", reflect.TypeOf((*MockdatabaseShard)(nil).FetchBlocksMetadataV2), ctx, start, end, limit, pageToken, opts)
 }
 
+// StreamBlocksMetadataV2 mocks base method
+func (m *MockdatabaseShard) StreamBlocksMetadataV2(ctx context.Context, start, end time.Time, limit int64, pageToken PageToken, opts block.FetchBlocksMetadataOptions, fn block.FetchBlocksMetadataResultFn) (PageToken, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamBlocksMetadataV2", ctx, start, end, limit, pageToken, opts, fn)
+	ret0, _ := ret[0].(PageToken)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamBlocksMetadataV2 indicates an expected call of StreamBlocksMetadataV2
+func (mr *MockdatabaseShardMockRecorder) StreamBlocksMetadataV2(ctx, start, end, limit, pageToken, opts, fn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamBlocksMetadataV2", reflect.TypeOf((*MockdatabaseShard)(nil).StreamBlocksMetadataV2), ctx, start, end, limit, pageToken, opts, fn)
+}
+
 // PrepareBootstrap mocks base method
 func (m *MockdatabaseShard) PrepareBootstrap() error {
 	m.ctrl.T.Helper()
@@ -1864,6 +2338,65 @@ func (mr *MockdatabaseShardMockRecorder) Snapshot(blockStart, snapshotStart, flu
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Snapshot", reflect.TypeOf((*MockdatabaseShard)(nil).Snapshot), blockStart, snapshotStart, flush, nsCtx)
 }
 
+// ImportBlock mocks base method
+func (m *MockdatabaseShard) ImportBlock(flush persist.FlushPreparer, fsReader fs.DataFileSetReader, blockStart time.Time, mergeWith fs.MergeWith, nsCtx namespace.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportBlock", flush, fsReader, blockStart, mergeWith, nsCtx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ImportBlock indicates an expected call of ImportBlock
+func (mr *MockdatabaseShardMockRecorder) ImportBlock(flush, fsReader, blockStart, mergeWith, nsCtx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportBlock", reflect.TypeOf((*MockdatabaseShard)(nil).ImportBlock), flush, fsReader, blockStart, mergeWith, nsCtx)
+}
+
+// CompactFileSets mocks base method
+func (m *MockdatabaseShard) CompactFileSets(limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CompactFileSets", limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CompactFileSets indicates an expected call of CompactFileSets
+func (mr *MockdatabaseShardMockRecorder) CompactFileSets(limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CompactFileSets", reflect.TypeOf((*MockdatabaseShard)(nil).CompactFileSets), limit)
+}
+
+// MigrateFileSets mocks base method
+func (m *MockdatabaseShard) MigrateFileSets(flush persist.FlushPreparer, resources coldFlushReuseableResources, limit int, nsCtx namespace.Context) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MigrateFileSets", flush, resources, limit, nsCtx)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MigrateFileSets indicates an expected call of MigrateFileSets
+func (mr *MockdatabaseShardMockRecorder) MigrateFileSets(flush, resources, limit, nsCtx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MigrateFileSets", reflect.TypeOf((*MockdatabaseShard)(nil).MigrateFileSets), flush, resources, limit, nsCtx)
+}
+
+// SplitFileSets mocks base method
+func (m *MockdatabaseShard) SplitFileSets(shardFn sharding.HashFn, limit int) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SplitFileSets", shardFn, limit)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SplitFileSets indicates an expected call of SplitFileSets
+func (mr *MockdatabaseShardMockRecorder) SplitFileSets(shardFn, limit interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SplitFileSets", reflect.TypeOf((*MockdatabaseShard)(nil).SplitFileSets), shardFn, limit)
+}
+
 // FlushState mocks base method
 func (m *MockdatabaseShard) FlushState(blockStart time.Time) (fileOpState, error) {
 	m.ctrl.T.Helper()
@@ -1893,6 +2426,20 @@ func (mr *MockdatabaseShardMockRecorder) CleanupExpiredFileSets(earliestToRetain
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CleanupExpiredFileSets", reflect.TypeOf((*MockdatabaseShard)(nil).CleanupExpiredFileSets), earliestToRetain)
 }
 
+// RunBlockExpiryHooks mocks base method
+func (m *MockdatabaseShard) RunBlockExpiryHooks(earliestToRetain time.Time, hooks []BlockExpiryHook) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunBlockExpiryHooks", earliestToRetain, hooks)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// RunBlockExpiryHooks indicates an expected call of RunBlockExpiryHooks
+func (mr *MockdatabaseShardMockRecorder) RunBlockExpiryHooks(earliestToRetain, hooks interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunBlockExpiryHooks", reflect.TypeOf((*MockdatabaseShard)(nil).RunBlockExpiryHooks), earliestToRetain, hooks)
+}
+
 // CleanupCompactedFileSets mocks base method
 func (m *MockdatabaseShard) CleanupCompactedFileSets() error {
 	m.ctrl.T.Helper()
@@ -1938,6 +2485,22 @@ func (mr *MockdatabaseShardMockRecorder) TagsFromSeriesID(seriesID interface{})
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TagsFromSeriesID", reflect.TypeOf((*MockdatabaseShard)(nil).TagsFromSeriesID), seriesID)
 }
 
+// SeriesLastWrite mocks base method
+func (m *MockdatabaseShard) SeriesLastWrite(id ident.ID) (time.Time, bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SeriesLastWrite", id)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SeriesLastWrite indicates an expected call of SeriesLastWrite
+func (mr *MockdatabaseShardMockRecorder) SeriesLastWrite(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SeriesLastWrite", reflect.TypeOf((*MockdatabaseShard)(nil).SeriesLastWrite), id)
+}
+
 // SeriesReadWriteRef mocks base method
 func (m *MockdatabaseShard) SeriesReadWriteRef(id ident.ID, tags ident.TagIterator, opts ShardSeriesReadWriteRefOptions) (SeriesReadWriteRef, error) {
 	m.ctrl.T.Helper()
@@ -2074,6 +2637,20 @@ func (mr *MocknamespaceIndexMockRecorder) BootstrapsDone() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BootstrapsDone", reflect.TypeOf((*MocknamespaceIndex)(nil).BootstrapsDone))
 }
 
+// AddImported mocks base method
+func (m *MocknamespaceIndex) AddImported(blockStart time.Time, docs []doc.Document) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AddImported", blockStart, docs)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// AddImported indicates an expected call of AddImported
+func (mr *MocknamespaceIndexMockRecorder) AddImported(blockStart, docs interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddImported", reflect.TypeOf((*MocknamespaceIndex)(nil).AddImported), blockStart, docs)
+}
+
 // CleanupExpiredFileSets mocks base method
 func (m *MocknamespaceIndex) CleanupExpiredFileSets(t time.Time) error {
 	m.ctrl.T.Helper()
@@ -2117,6 +2694,21 @@ func (mr *MocknamespaceIndexMockRecorder) Flush(flush, shards interface{}) *gomo
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Flush", reflect.TypeOf((*MocknamespaceIndex)(nil).Flush), flush, shards)
 }
 
+// PurgeDocuments mocks base method
+func (m *MocknamespaceIndex) PurgeDocuments(c context.Cancellable, startTime time.Time, shards []databaseShard) (namespaceIndexPurgeResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDocuments", c, startTime, shards)
+	ret0, _ := ret[0].(namespaceIndexPurgeResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDocuments indicates an expected call of PurgeDocuments
+func (mr *MocknamespaceIndexMockRecorder) PurgeDocuments(c, startTime, shards interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDocuments", reflect.TypeOf((*MocknamespaceIndex)(nil).PurgeDocuments), c, startTime, shards)
+}
+
 // Close mocks base method
 func (m *MocknamespaceIndex) Close() error {
 	m.ctrl.T.Helper()
@@ -2575,6 +3167,20 @@ func (mr *MockdatabaseShardRepairerMockRecorder) Repair(ctx, nsCtx, nsMeta, tr,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*MockdatabaseShardRepairer)(nil).Repair), ctx, nsCtx, nsMeta, tr, shard)
 }
 
+// History mocks base method
+func (m *MockdatabaseShardRepairer) History(namespace ident.ID) []BlockRepairHistory {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "History", namespace)
+	ret0, _ := ret[0].([]BlockRepairHistory)
+	return ret0
+}
+
+// History indicates an expected call of History
+func (mr *MockdatabaseShardRepairerMockRecorder) History(namespace interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "History", reflect.TypeOf((*MockdatabaseShardRepairer)(nil).History), namespace)
+}
+
 // MockdatabaseRepairer is a mock of databaseRepairer interface
 type MockdatabaseRepairer struct {
 	ctrl     *gomock.Controller
@@ -2648,6 +3254,35 @@ func (mr *MockdatabaseRepairerMockRecorder) Report() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Report", reflect.TypeOf((*MockdatabaseRepairer)(nil).Report))
 }
 
+// RepairedTo mocks base method
+func (m *MockdatabaseRepairer) RepairedTo(n databaseNamespace) (time.Time, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairedTo", n)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// RepairedTo indicates an expected call of RepairedTo
+func (mr *MockdatabaseRepairerMockRecorder) RepairedTo(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairedTo", reflect.TypeOf((*MockdatabaseRepairer)(nil).RepairedTo), n)
+}
+
+// RepairHistory mocks base method
+func (m *MockdatabaseRepairer) RepairHistory(n databaseNamespace) []BlockRepairHistory {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairHistory", n)
+	ret0, _ := ret[0].([]BlockRepairHistory)
+	return ret0
+}
+
+// RepairHistory indicates an expected call of RepairHistory
+func (mr *MockdatabaseRepairerMockRecorder) RepairHistory(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairHistory", reflect.TypeOf((*MockdatabaseRepairer)(nil).RepairHistory), n)
+}
+
 // MockdatabaseTickManager is a mock of databaseTickManager interface
 type MockdatabaseTickManager struct {
 	ctrl     *gomock.Controller
@@ -2818,6 +3453,35 @@ func (mr *MockdatabaseMediatorMockRecorder) Repair() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Repair", reflect.TypeOf((*MockdatabaseMediator)(nil).Repair))
 }
 
+// RepairedTo mocks base method
+func (m *MockdatabaseMediator) RepairedTo(n databaseNamespace) (time.Time, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairedTo", n)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// RepairedTo indicates an expected call of RepairedTo
+func (mr *MockdatabaseMediatorMockRecorder) RepairedTo(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairedTo", reflect.TypeOf((*MockdatabaseMediator)(nil).RepairedTo), n)
+}
+
+// RepairHistory mocks base method
+func (m *MockdatabaseMediator) RepairHistory(n databaseNamespace) []BlockRepairHistory {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RepairHistory", n)
+	ret0, _ := ret[0].([]BlockRepairHistory)
+	return ret0
+}
+
+// RepairHistory indicates an expected call of RepairHistory
+func (mr *MockdatabaseMediatorMockRecorder) RepairHistory(n interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RepairHistory", reflect.TypeOf((*MockdatabaseMediator)(nil).RepairHistory), n)
+}
+
 // Close mocks base method
 func (m *MockdatabaseMediator) Close() error {
 	m.ctrl.T.Helper()
@@ -3946,6 +4610,230 @@ func (mr *MockOptionsMockRecorder) MemoryTracker() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MemoryTracker", reflect.TypeOf((*MockOptions)(nil).MemoryTracker))
 }
 
+// SetSnapshotTracker mocks base method
+func (m *MockOptions) SetSnapshotTracker(snapshotTracker SnapshotTracker) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotTracker", snapshotTracker)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetSnapshotTracker indicates an expected call of SetSnapshotTracker
+func (mr *MockOptionsMockRecorder) SetSnapshotTracker(snapshotTracker interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotTracker", reflect.TypeOf((*MockOptions)(nil).SetSnapshotTracker), snapshotTracker)
+}
+
+// SnapshotTracker mocks base method
+func (m *MockOptions) SnapshotTracker() SnapshotTracker {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotTracker")
+	ret0, _ := ret[0].(SnapshotTracker)
+	return ret0
+}
+
+// SnapshotTracker indicates an expected call of SnapshotTracker
+func (mr *MockOptionsMockRecorder) SnapshotTracker() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotTracker", reflect.TypeOf((*MockOptions)(nil).SnapshotTracker))
+}
+
+// SetSnapshotCompactionMinVolumes mocks base method
+func (m *MockOptions) SetSnapshotCompactionMinVolumes(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetSnapshotCompactionMinVolumes", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetSnapshotCompactionMinVolumes indicates an expected call of SetSnapshotCompactionMinVolumes
+func (mr *MockOptionsMockRecorder) SetSnapshotCompactionMinVolumes(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSnapshotCompactionMinVolumes", reflect.TypeOf((*MockOptions)(nil).SetSnapshotCompactionMinVolumes), value)
+}
+
+// SnapshotCompactionMinVolumes mocks base method
+func (m *MockOptions) SnapshotCompactionMinVolumes() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SnapshotCompactionMinVolumes")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// SnapshotCompactionMinVolumes indicates an expected call of SnapshotCompactionMinVolumes
+func (mr *MockOptionsMockRecorder) SnapshotCompactionMinVolumes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SnapshotCompactionMinVolumes", reflect.TypeOf((*MockOptions)(nil).SnapshotCompactionMinVolumes))
+}
+
+// SetFileSetMigrationBlocksPerFlush mocks base method
+func (m *MockOptions) SetFileSetMigrationBlocksPerFlush(value int) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetFileSetMigrationBlocksPerFlush", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetFileSetMigrationBlocksPerFlush indicates an expected call of SetFileSetMigrationBlocksPerFlush
+func (mr *MockOptionsMockRecorder) SetFileSetMigrationBlocksPerFlush(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFileSetMigrationBlocksPerFlush", reflect.TypeOf((*MockOptions)(nil).SetFileSetMigrationBlocksPerFlush), value)
+}
+
+// FileSetMigrationBlocksPerFlush mocks base method
+func (m *MockOptions) FileSetMigrationBlocksPerFlush() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FileSetMigrationBlocksPerFlush")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// FileSetMigrationBlocksPerFlush indicates an expected call of FileSetMigrationBlocksPerFlush
+func (mr *MockOptionsMockRecorder) FileSetMigrationBlocksPerFlush() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FileSetMigrationBlocksPerFlush", reflect.TypeOf((*MockOptions)(nil).FileSetMigrationBlocksPerFlush))
+}
+
+// SetTickLoadMonitor mocks base method
+func (m *MockOptions) SetTickLoadMonitor(value TickLoadMonitor) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetTickLoadMonitor", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetTickLoadMonitor indicates an expected call of SetTickLoadMonitor
+func (mr *MockOptionsMockRecorder) SetTickLoadMonitor(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTickLoadMonitor", reflect.TypeOf((*MockOptions)(nil).SetTickLoadMonitor), value)
+}
+
+// TickLoadMonitor mocks base method
+func (m *MockOptions) TickLoadMonitor() TickLoadMonitor {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TickLoadMonitor")
+	ret0, _ := ret[0].(TickLoadMonitor)
+	return ret0
+}
+
+// TickLoadMonitor indicates an expected call of TickLoadMonitor
+func (mr *MockOptionsMockRecorder) TickLoadMonitor() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TickLoadMonitor", reflect.TypeOf((*MockOptions)(nil).TickLoadMonitor))
+}
+
+// SetBackgroundScheduler mocks base method
+func (m *MockOptions) SetBackgroundScheduler(value background.Scheduler) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBackgroundScheduler", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetBackgroundScheduler indicates an expected call of SetBackgroundScheduler
+func (mr *MockOptionsMockRecorder) SetBackgroundScheduler(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBackgroundScheduler", reflect.TypeOf((*MockOptions)(nil).SetBackgroundScheduler), value)
+}
+
+// BackgroundScheduler mocks base method
+func (m *MockOptions) BackgroundScheduler() background.Scheduler {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BackgroundScheduler")
+	ret0, _ := ret[0].(background.Scheduler)
+	return ret0
+}
+
+// BackgroundScheduler indicates an expected call of BackgroundScheduler
+func (mr *MockOptionsMockRecorder) BackgroundScheduler() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BackgroundScheduler", reflect.TypeOf((*MockOptions)(nil).BackgroundScheduler))
+}
+
+// SetPurgeReporter mocks base method
+func (m *MockOptions) SetPurgeReporter(value PurgeReporter) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPurgeReporter", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetPurgeReporter indicates an expected call of SetPurgeReporter
+func (mr *MockOptionsMockRecorder) SetPurgeReporter(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPurgeReporter", reflect.TypeOf((*MockOptions)(nil).SetPurgeReporter), value)
+}
+
+// PurgeReporter mocks base method
+func (m *MockOptions) PurgeReporter() PurgeReporter {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeReporter")
+	ret0, _ := ret[0].(PurgeReporter)
+	return ret0
+}
+
+// PurgeReporter indicates an expected call of PurgeReporter
+func (mr *MockOptionsMockRecorder) PurgeReporter() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeReporter", reflect.TypeOf((*MockOptions)(nil).PurgeReporter))
+}
+
+// SetBlockExpiryHooks mocks base method
+func (m *MockOptions) SetBlockExpiryHooks(value []BlockExpiryHook) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetBlockExpiryHooks", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetBlockExpiryHooks indicates an expected call of SetBlockExpiryHooks
+func (mr *MockOptionsMockRecorder) SetBlockExpiryHooks(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetBlockExpiryHooks", reflect.TypeOf((*MockOptions)(nil).SetBlockExpiryHooks), value)
+}
+
+// BlockExpiryHooks mocks base method
+func (m *MockOptions) BlockExpiryHooks() []BlockExpiryHook {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "BlockExpiryHooks")
+	ret0, _ := ret[0].([]BlockExpiryHook)
+	return ret0
+}
+
+// BlockExpiryHooks indicates an expected call of BlockExpiryHooks
+func (mr *MockOptionsMockRecorder) BlockExpiryHooks() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "BlockExpiryHooks", reflect.TypeOf((*MockOptions)(nil).BlockExpiryHooks))
+}
+
+// SetWriteInterceptors mocks base method
+func (m *MockOptions) SetWriteInterceptors(value []WriteInterceptor) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetWriteInterceptors", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetWriteInterceptors indicates an expected call of SetWriteInterceptors
+func (mr *MockOptionsMockRecorder) SetWriteInterceptors(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetWriteInterceptors", reflect.TypeOf((*MockOptions)(nil).SetWriteInterceptors), value)
+}
+
+// WriteInterceptors mocks base method
+func (m *MockOptions) WriteInterceptors() []WriteInterceptor {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WriteInterceptors")
+	ret0, _ := ret[0].([]WriteInterceptor)
+	return ret0
+}
+
+// WriteInterceptors indicates an expected call of WriteInterceptors
+func (mr *MockOptionsMockRecorder) WriteInterceptors() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WriteInterceptors", reflect.TypeOf((*MockOptions)(nil).WriteInterceptors))
+}
+
 // SetMmapReporter mocks base method
 func (m *MockOptions) SetMmapReporter(mmapReporter mmap.Reporter) Options {
 	m.ctrl.T.Helper()
@@ -3974,6 +4862,34 @@ func (mr *MockOptionsMockRecorder) MmapReporter() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MmapReporter", reflect.TypeOf((*MockOptions)(nil).MmapReporter))
 }
 
+// SetNotifier mocks base method
+func (m *MockOptions) SetNotifier(value notify.Notifier) Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetNotifier", value)
+	ret0, _ := ret[0].(Options)
+	return ret0
+}
+
+// SetNotifier indicates an expected call of SetNotifier
+func (mr *MockOptionsMockRecorder) SetNotifier(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetNotifier", reflect.TypeOf((*MockOptions)(nil).SetNotifier), value)
+}
+
+// Notifier mocks base method
+func (m *MockOptions) Notifier() notify.Notifier {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Notifier")
+	ret0, _ := ret[0].(notify.Notifier)
+	return ret0
+}
+
+// Notifier indicates an expected call of Notifier
+func (mr *MockOptionsMockRecorder) Notifier() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Notifier", reflect.TypeOf((*MockOptions)(nil).Notifier))
+}
+
 // MockMemoryTracker is a mock of MemoryTracker interface
 type MockMemoryTracker struct {
 	ctrl     *gomock.Controller
@@ -4060,3 +4976,314 @@ func (mr *MockMemoryTrackerMockRecorder) WaitForDec() *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WaitForDec", reflect.TypeOf((*MockMemoryTracker)(nil).WaitForDec))
 }
+
+// MockSnapshotTracker is a mock of SnapshotTracker interface
+type MockSnapshotTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockSnapshotTrackerMockRecorder
+}
+
+// MockSnapshotTrackerMockRecorder is the mock recorder for MockSnapshotTracker
+type MockSnapshotTrackerMockRecorder struct {
+	mock *MockSnapshotTracker
+}
+
+// NewMockSnapshotTracker creates a new mock instance
+func NewMockSnapshotTracker(ctrl *gomock.Controller) *MockSnapshotTracker {
+	mock := &MockSnapshotTracker{ctrl: ctrl}
+	mock.recorder = &MockSnapshotTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockSnapshotTracker) EXPECT() *MockSnapshotTrackerMockRecorder {
+	return m.recorder
+}
+
+// IncNumUnsnapshottedBytes mocks base method
+func (m *MockSnapshotTracker) IncNumUnsnapshottedBytes(x int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncNumUnsnapshottedBytes", x)
+}
+
+// IncNumUnsnapshottedBytes indicates an expected call of IncNumUnsnapshottedBytes
+func (mr *MockSnapshotTrackerMockRecorder) IncNumUnsnapshottedBytes(x interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncNumUnsnapshottedBytes", reflect.TypeOf((*MockSnapshotTracker)(nil).IncNumUnsnapshottedBytes), x)
+}
+
+// IncNumUnsnapshottedSeries mocks base method
+func (m *MockSnapshotTracker) IncNumUnsnapshottedSeries(x int64) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "IncNumUnsnapshottedSeries", x)
+}
+
+// IncNumUnsnapshottedSeries indicates an expected call of IncNumUnsnapshottedSeries
+func (mr *MockSnapshotTrackerMockRecorder) IncNumUnsnapshottedSeries(x interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncNumUnsnapshottedSeries", reflect.TypeOf((*MockSnapshotTracker)(nil).IncNumUnsnapshottedSeries), x)
+}
+
+// NumUnsnapshottedBytes mocks base method
+func (m *MockSnapshotTracker) NumUnsnapshottedBytes() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumUnsnapshottedBytes")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// NumUnsnapshottedBytes indicates an expected call of NumUnsnapshottedBytes
+func (mr *MockSnapshotTrackerMockRecorder) NumUnsnapshottedBytes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumUnsnapshottedBytes", reflect.TypeOf((*MockSnapshotTracker)(nil).NumUnsnapshottedBytes))
+}
+
+// NumUnsnapshottedSeries mocks base method
+func (m *MockSnapshotTracker) NumUnsnapshottedSeries() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "NumUnsnapshottedSeries")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// NumUnsnapshottedSeries indicates an expected call of NumUnsnapshottedSeries
+func (mr *MockSnapshotTrackerMockRecorder) NumUnsnapshottedSeries() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "NumUnsnapshottedSeries", reflect.TypeOf((*MockSnapshotTracker)(nil).NumUnsnapshottedSeries))
+}
+
+// ShouldSnapshot mocks base method
+func (m *MockSnapshotTracker) ShouldSnapshot(sinceLastSnapshot time.Duration) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ShouldSnapshot", sinceLastSnapshot)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// ShouldSnapshot indicates an expected call of ShouldSnapshot
+func (mr *MockSnapshotTrackerMockRecorder) ShouldSnapshot(sinceLastSnapshot interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ShouldSnapshot", reflect.TypeOf((*MockSnapshotTracker)(nil).ShouldSnapshot), sinceLastSnapshot)
+}
+
+// MarkUnsnapshottedAsPending mocks base method
+func (m *MockSnapshotTracker) MarkUnsnapshottedAsPending() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "MarkUnsnapshottedAsPending")
+}
+
+// MarkUnsnapshottedAsPending indicates an expected call of MarkUnsnapshottedAsPending
+func (mr *MockSnapshotTrackerMockRecorder) MarkUnsnapshottedAsPending() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkUnsnapshottedAsPending", reflect.TypeOf((*MockSnapshotTracker)(nil).MarkUnsnapshottedAsPending))
+}
+
+// DecPendingUnsnapshotted mocks base method
+func (m *MockSnapshotTracker) DecPendingUnsnapshotted() {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "DecPendingUnsnapshotted")
+}
+
+// DecPendingUnsnapshotted indicates an expected call of DecPendingUnsnapshotted
+func (mr *MockSnapshotTrackerMockRecorder) DecPendingUnsnapshotted() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DecPendingUnsnapshotted", reflect.TypeOf((*MockSnapshotTracker)(nil).DecPendingUnsnapshotted))
+}
+
+// MockTickLoadMonitor is a mock of TickLoadMonitor interface
+type MockTickLoadMonitor struct {
+	ctrl     *gomock.Controller
+	recorder *MockTickLoadMonitorMockRecorder
+}
+
+// MockTickLoadMonitorMockRecorder is the mock recorder for MockTickLoadMonitor
+type MockTickLoadMonitorMockRecorder struct {
+	mock *MockTickLoadMonitor
+}
+
+// NewMockTickLoadMonitor creates a new mock instance
+func NewMockTickLoadMonitor(ctrl *gomock.Controller) *MockTickLoadMonitor {
+	mock := &MockTickLoadMonitor{ctrl: ctrl}
+	mock.recorder = &MockTickLoadMonitorMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockTickLoadMonitor) EXPECT() *MockTickLoadMonitorMockRecorder {
+	return m.recorder
+}
+
+// SetRuntimeOptions mocks base method
+func (m *MockTickLoadMonitor) SetRuntimeOptions(value runtime.Options) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetRuntimeOptions", value)
+}
+
+// SetRuntimeOptions indicates an expected call of SetRuntimeOptions
+func (mr *MockTickLoadMonitorMockRecorder) SetRuntimeOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRuntimeOptions", reflect.TypeOf((*MockTickLoadMonitor)(nil).SetRuntimeOptions), value)
+}
+
+// Enabled mocks base method
+func (m *MockTickLoadMonitor) Enabled() bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Enabled")
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Enabled indicates an expected call of Enabled
+func (mr *MockTickLoadMonitorMockRecorder) Enabled() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Enabled", reflect.TypeOf((*MockTickLoadMonitor)(nil).Enabled))
+}
+
+// RecordWriteLatency mocks base method
+func (m *MockTickLoadMonitor) RecordWriteLatency(value time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordWriteLatency", value)
+}
+
+// RecordWriteLatency indicates an expected call of RecordWriteLatency
+func (mr *MockTickLoadMonitorMockRecorder) RecordWriteLatency(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordWriteLatency", reflect.TypeOf((*MockTickLoadMonitor)(nil).RecordWriteLatency), value)
+}
+
+// RecordReadLatency mocks base method
+func (m *MockTickLoadMonitor) RecordReadLatency(value time.Duration) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordReadLatency", value)
+}
+
+// RecordReadLatency indicates an expected call of RecordReadLatency
+func (mr *MockTickLoadMonitorMockRecorder) RecordReadLatency(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordReadLatency", reflect.TypeOf((*MockTickLoadMonitor)(nil).RecordReadLatency), value)
+}
+
+// SlowdownFactor mocks base method
+func (m *MockTickLoadMonitor) SlowdownFactor() float64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SlowdownFactor")
+	ret0, _ := ret[0].(float64)
+	return ret0
+}
+
+// SlowdownFactor indicates an expected call of SlowdownFactor
+func (mr *MockTickLoadMonitorMockRecorder) SlowdownFactor() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SlowdownFactor", reflect.TypeOf((*MockTickLoadMonitor)(nil).SlowdownFactor))
+}
+
+// MockPurgeReporter is a mock of PurgeReporter interface
+type MockPurgeReporter struct {
+	ctrl     *gomock.Controller
+	recorder *MockPurgeReporterMockRecorder
+}
+
+// MockPurgeReporterMockRecorder is the mock recorder for MockPurgeReporter
+type MockPurgeReporterMockRecorder struct {
+	mock *MockPurgeReporter
+}
+
+// NewMockPurgeReporter creates a new mock instance
+func NewMockPurgeReporter(ctrl *gomock.Controller) *MockPurgeReporter {
+	mock := &MockPurgeReporter{ctrl: ctrl}
+	mock.recorder = &MockPurgeReporterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockPurgeReporter) EXPECT() *MockPurgeReporterMockRecorder {
+	return m.recorder
+}
+
+// Start mocks base method
+func (m *MockPurgeReporter) Start(t time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Start", t)
+}
+
+// Start indicates an expected call of Start
+func (mr *MockPurgeReporterMockRecorder) Start(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Start", reflect.TypeOf((*MockPurgeReporter)(nil).Start), t)
+}
+
+// RecordPurged mocks base method
+func (m *MockPurgeReporter) RecordPurged(fileType PurgedFileType, files []PurgedFile) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "RecordPurged", fileType, files)
+}
+
+// RecordPurged indicates an expected call of RecordPurged
+func (mr *MockPurgeReporterMockRecorder) RecordPurged(fileType, files interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPurged", reflect.TypeOf((*MockPurgeReporter)(nil).RecordPurged), fileType, files)
+}
+
+// Finish mocks base method
+func (m *MockPurgeReporter) Finish(t time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "Finish", t)
+}
+
+// Finish indicates an expected call of Finish
+func (mr *MockPurgeReporterMockRecorder) Finish(t interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Finish", reflect.TypeOf((*MockPurgeReporter)(nil).Finish), t)
+}
+
+// LastReport mocks base method
+func (m *MockPurgeReporter) LastReport() (PurgeReport, bool) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LastReport")
+	ret0, _ := ret[0].(PurgeReport)
+	ret1, _ := ret[1].(bool)
+	return ret0, ret1
+}
+
+// LastReport indicates an expected call of LastReport
+func (mr *MockPurgeReporterMockRecorder) LastReport() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LastReport", reflect.TypeOf((*MockPurgeReporter)(nil).LastReport))
+}
+
+// MockBlockExpiryHook is a mock of BlockExpiryHook interface
+type MockBlockExpiryHook struct {
+	ctrl     *gomock.Controller
+	recorder *MockBlockExpiryHookMockRecorder
+}
+
+// MockBlockExpiryHookMockRecorder is the mock recorder for MockBlockExpiryHook
+type MockBlockExpiryHookMockRecorder struct {
+	mock *MockBlockExpiryHook
+}
+
+// NewMockBlockExpiryHook creates a new mock instance
+func NewMockBlockExpiryHook(ctrl *gomock.Controller) *MockBlockExpiryHook {
+	mock := &MockBlockExpiryHook{ctrl: ctrl}
+	mock.recorder = &MockBlockExpiryHookMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockBlockExpiryHook) EXPECT() *MockBlockExpiryHookMockRecorder {
+	return m.recorder
+}
+
+// OnExpiringSeries mocks base method
+func (m *MockBlockExpiryHook) OnExpiringSeries(block ExpiringBlock, id ident.ID, tags ident.Tags, iter encoding.Iterator) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "OnExpiringSeries", block, id, tags, iter)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// OnExpiringSeries indicates an expected call of OnExpiringSeries
+func (mr *MockBlockExpiryHookMockRecorder) OnExpiringSeries(block, id, tags, iter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "OnExpiringSeries", reflect.TypeOf((*MockBlockExpiryHook)(nil).OnExpiringSeries), block, id, tags, iter)
+}
@@ -50,6 +50,7 @@ type tickManagerMetrics struct {
 	tickCancelled      tally.Counter
 	tickDeadlineMissed tally.Counter
 	tickDeadlineMet    tally.Counter
+	tickLoadSlowdown   tally.Gauge
 }
 
 func newTickManagerMetrics(scope tally.Scope) tickManagerMetrics {
@@ -59,6 +60,7 @@ func newTickManagerMetrics(scope tally.Scope) tickManagerMetrics {
 		tickCancelled:      scope.Counter("cancelled"),
 		tickDeadlineMissed: scope.Counter("deadline.missed"),
 		tickDeadlineMet:    scope.Counter("deadline.met"),
+		tickLoadSlowdown:   scope.Gauge("load-slowdown-factor"),
 	}
 }
 
@@ -68,6 +70,7 @@ type tickManager struct {
 	opts     Options
 	nowFn    clock.NowFn
 	sleepFn  sleepFn
+	tickLoad TickLoadMonitor
 
 	metrics tickManagerMetrics
 	c       context.Cancellable
@@ -107,7 +110,8 @@ func newTickManager(database database, opts Options) databaseTickManager {
 		database: database,
 		opts:     opts,
 		nowFn:    opts.ClockOptions().NowFn(),
-		sleepFn:  time.Sleep,
+		sleepFn:  opts.ClockOptions().Scheduler().Sleep,
+		tickLoad: opts.TickLoadMonitor(),
 		metrics:  newTickManagerMetrics(scope),
 		c:        context.NewCancellable(),
 		tokenCh:  tokenCh,
@@ -115,6 +119,7 @@ func newTickManager(database database, opts Options) databaseTickManager {
 
 	runtimeOptsMgr := opts.RuntimeOptionsManager()
 	runtimeOptsMgr.RegisterListener(mgr)
+	runtimeOptsMgr.RegisterListener(mgr.tickLoad)
 	return mgr
 }
 
@@ -182,7 +187,7 @@ func (mgr *tickManager) Tick(forceType forceType, startTime time.Time) error {
 	took := mgr.nowFn().Sub(start)
 	mgr.metrics.tickWorkDuration.Record(took)
 
-	min := mgr.runtimeOpts.values().tickMinInterval
+	min := mgr.tickMinInterval()
 
 	// Sleep in a loop so that cancellations propagate if need to
 	// wait to fulfill the tick min interval
@@ -194,7 +199,7 @@ func (mgr *tickManager) Tick(forceType forceType, startTime time.Time) error {
 		mgr.sleepFn(interval)
 		// Check again at the end of each sleep to see if it
 		// has changed. Particularly useful for integration tests.
-		min = mgr.runtimeOpts.values().tickMinInterval
+		min = mgr.tickMinInterval()
 	}
 
 	end := mgr.nowFn()
@@ -208,3 +213,12 @@ func (mgr *tickManager) Tick(forceType forceType, startTime time.Time) error {
 
 	return multiErr.FinalError()
 }
+
+// tickMinInterval returns the minimum tick interval scaled by the current
+// load of the node so that ticks are spaced further apart under load.
+func (mgr *tickManager) tickMinInterval() time.Duration {
+	min := mgr.runtimeOpts.values().tickMinInterval
+	slowdown := mgr.tickLoad.SlowdownFactor()
+	mgr.metrics.tickLoadSlowdown.Update(slowdown)
+	return time.Duration(float64(min) * slowdown)
+}
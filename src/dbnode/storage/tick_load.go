@@ -0,0 +1,177 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"math"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/clock"
+	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
+)
+
+const (
+	// tickLoadSampleInterval is the minimum interval between samples of the
+	// node load, the slowdown factor is reused between samples.
+	tickLoadSampleInterval = time.Second
+)
+
+type processCPUTimeFn func() time.Duration
+
+type tickLoadMonitor struct {
+	sync.Mutex
+
+	nowFn     clock.NowFn
+	cpuTimeFn processCPUTimeFn
+	numCPU    int
+
+	enabled int32
+	opts    m3dbruntime.TickLoadPacingOptions
+
+	writeLatencySum   int64
+	writeLatencyCount int64
+	readLatencySum    int64
+	readLatencyCount  int64
+
+	lastSampleAt  time.Time
+	lastCPUTime   time.Duration
+	slowdownValue float64
+}
+
+// NewTickLoadMonitor returns a new tick load monitor, it must be registered
+// as a runtime options listener to receive the tick load pacing options.
+func NewTickLoadMonitor(nowFn clock.NowFn) TickLoadMonitor {
+	return newTickLoadMonitor(nowFn, processCPUTime)
+}
+
+func newTickLoadMonitor(
+	nowFn clock.NowFn,
+	cpuTimeFn processCPUTimeFn,
+) *tickLoadMonitor {
+	return &tickLoadMonitor{
+		nowFn:         nowFn,
+		cpuTimeFn:     cpuTimeFn,
+		numCPU:        runtime.NumCPU(),
+		slowdownValue: 1,
+	}
+}
+
+func (m *tickLoadMonitor) SetRuntimeOptions(value m3dbruntime.Options) {
+	opts := value.TickLoadPacingOptions()
+
+	m.Lock()
+	m.opts = opts
+	m.slowdownValue = 1
+	m.resetSampleWithLock()
+	m.Unlock()
+
+	enabled := int32(0)
+	if opts.Enabled {
+		enabled = 1
+	}
+	atomic.StoreInt32(&m.enabled, enabled)
+}
+
+func (m *tickLoadMonitor) Enabled() bool {
+	return atomic.LoadInt32(&m.enabled) == 1
+}
+
+func (m *tickLoadMonitor) RecordWriteLatency(value time.Duration) {
+	if !m.Enabled() {
+		return
+	}
+	atomic.AddInt64(&m.writeLatencySum, int64(value))
+	atomic.AddInt64(&m.writeLatencyCount, 1)
+}
+
+func (m *tickLoadMonitor) RecordReadLatency(value time.Duration) {
+	if !m.Enabled() {
+		return
+	}
+	atomic.AddInt64(&m.readLatencySum, int64(value))
+	atomic.AddInt64(&m.readLatencyCount, 1)
+}
+
+func (m *tickLoadMonitor) SlowdownFactor() float64 {
+	if !m.Enabled() {
+		return 1
+	}
+
+	m.Lock()
+	defer m.Unlock()
+
+	now := m.nowFn()
+	elapsed := now.Sub(m.lastSampleAt)
+	if elapsed < tickLoadSampleInterval {
+		return m.slowdownValue
+	}
+
+	var (
+		opts         = m.opts
+		cpuTime      = m.cpuTimeFn()
+		cpuElapsed   = cpuTime - m.lastCPUTime
+		writeSum     = atomic.SwapInt64(&m.writeLatencySum, 0)
+		writeCount   = atomic.SwapInt64(&m.writeLatencyCount, 0)
+		readSum      = atomic.SwapInt64(&m.readLatencySum, 0)
+		readCount    = atomic.SwapInt64(&m.readLatencyCount, 0)
+		slowdown     = 1.0
+		firstSample  = m.lastSampleAt.IsZero()
+		availableCPU = float64(elapsed) * float64(m.numCPU)
+	)
+	m.lastSampleAt = now
+	m.lastCPUTime = cpuTime
+
+	if target := opts.TargetWriteLatency; target > 0 && writeCount > 0 {
+		mean := float64(writeSum) / float64(writeCount)
+		slowdown = math.Max(slowdown, mean/float64(target))
+	}
+	if target := opts.TargetReadLatency; target > 0 && readCount > 0 {
+		mean := float64(readSum) / float64(readCount)
+		slowdown = math.Max(slowdown, mean/float64(target))
+	}
+	if target := opts.TargetCPUUtilization; target > 0 && !firstSample && availableCPU > 0 {
+		utilization := float64(cpuElapsed) / availableCPU
+		slowdown = math.Max(slowdown, utilization/target)
+	}
+
+	m.slowdownValue = math.Min(slowdown, math.Max(opts.MaxSlowdownFactor, 1))
+	return m.slowdownValue
+}
+
+func (m *tickLoadMonitor) resetSampleWithLock() {
+	m.lastSampleAt = time.Time{}
+	m.lastCPUTime = 0
+	atomic.StoreInt64(&m.writeLatencySum, 0)
+	atomic.StoreInt64(&m.writeLatencyCount, 0)
+	atomic.StoreInt64(&m.readLatencySum, 0)
+	atomic.StoreInt64(&m.readLatencyCount, 0)
+}
+
+func processCPUTime() time.Duration {
+	var usage syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &usage); err != nil {
+		return 0
+	}
+	return time.Duration(usage.Utime.Nano() + usage.Stime.Nano())
+}
@@ -0,0 +1,116 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package storage
+
+import (
+	"runtime"
+	"testing"
+	"time"
+
+	m3dbruntime "github.com/m3db/m3/src/dbnode/runtime"
+
+	"github.com/stretchr/testify/require"
+)
+
+type testTickLoadClock struct {
+	now     time.Time
+	cpuTime time.Duration
+}
+
+func (c *testTickLoadClock) nowFn() time.Time {
+	return c.now
+}
+
+func (c *testTickLoadClock) cpuTimeFn() time.Duration {
+	return c.cpuTime
+}
+
+func newTestTickLoadMonitor(
+	opts m3dbruntime.TickLoadPacingOptions,
+) (*tickLoadMonitor, *testTickLoadClock) {
+	clock := &testTickLoadClock{now: time.Now()}
+	m := newTickLoadMonitor(clock.nowFn, clock.cpuTimeFn)
+	m.SetRuntimeOptions(m3dbruntime.NewOptions().SetTickLoadPacingOptions(opts))
+	return m, clock
+}
+
+func TestTickLoadMonitorDisabled(t *testing.T) {
+	m, _ := newTestTickLoadMonitor(m3dbruntime.TickLoadPacingOptions{
+		TargetWriteLatency: time.Millisecond,
+		MaxSlowdownFactor:  10,
+	})
+	require.False(t, m.Enabled())
+
+	m.RecordWriteLatency(time.Second)
+	require.Equal(t, 1.0, m.SlowdownFactor())
+	require.Equal(t, int64(0), m.writeLatencyCount)
+}
+
+func TestTickLoadMonitorLatency(t *testing.T) {
+	m, clock := newTestTickLoadMonitor(m3dbruntime.TickLoadPacingOptions{
+		Enabled:            true,
+		TargetWriteLatency: 10 * time.Millisecond,
+		TargetReadLatency:  10 * time.Millisecond,
+		MaxSlowdownFactor:  10,
+	})
+	require.True(t, m.Enabled())
+
+	// Below target should not slow down the tick.
+	m.RecordWriteLatency(5 * time.Millisecond)
+	m.RecordReadLatency(5 * time.Millisecond)
+	require.Equal(t, 1.0, m.SlowdownFactor())
+
+	// Mean write latency of 30ms is 3x the target.
+	m.RecordWriteLatency(20 * time.Millisecond)
+	m.RecordWriteLatency(40 * time.Millisecond)
+	m.RecordReadLatency(20 * time.Millisecond)
+
+	// Not sampled again until the sample interval has elapsed.
+	require.Equal(t, 1.0, m.SlowdownFactor())
+	clock.now = clock.now.Add(tickLoadSampleInterval)
+	require.Equal(t, 3.0, m.SlowdownFactor())
+
+	// Slowdown is capped at the max slowdown factor.
+	m.RecordReadLatency(time.Second)
+	clock.now = clock.now.Add(tickLoadSampleInterval)
+	require.Equal(t, 10.0, m.SlowdownFactor())
+
+	// No load recovers to no slowdown.
+	clock.now = clock.now.Add(tickLoadSampleInterval)
+	require.Equal(t, 1.0, m.SlowdownFactor())
+}
+
+func TestTickLoadMonitorCPUUtilization(t *testing.T) {
+	m, clock := newTestTickLoadMonitor(m3dbruntime.TickLoadPacingOptions{
+		Enabled:              true,
+		TargetCPUUtilization: 0.25,
+		MaxSlowdownFactor:    10,
+	})
+
+	// First sample has no CPU time to compare against.
+	clock.cpuTime = time.Hour
+	require.Equal(t, 1.0, m.SlowdownFactor())
+
+	// Use half of all available cores, which is twice the target.
+	elapsed := 2 * tickLoadSampleInterval
+	clock.now = clock.now.Add(elapsed)
+	clock.cpuTime += time.Duration(runtime.NumCPU()) * elapsed / 2
+	require.Equal(t, 2.0, m.SlowdownFactor())
+}
@@ -26,6 +26,8 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/x/clock"
 	"github.com/m3db/m3/src/x/context"
 
 	"github.com/golang/mock/gomock"
@@ -51,6 +53,44 @@ func TestTickManagerTickNormalFlow(t *testing.T) {
 	require.Equal(t, 1, len(tm.tokenCh))
 }
 
+func TestTickManagerTickSimulatedScheduler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	start := time.Now().Truncate(time.Hour)
+	scheduler := clock.NewSimulatedScheduler(start)
+	opts := DefaultTestOptions()
+	opts = opts.SetClockOptions(opts.ClockOptions().
+		SetNowFn(scheduler.Now).
+		SetScheduler(scheduler))
+	c := context.NewCancellable()
+
+	namespace := NewMockdatabaseNamespace(ctrl)
+	namespace.EXPECT().Tick(c, gomock.Any())
+	db := newMockdatabase(ctrl, namespace)
+
+	tm := newTickManager(db, opts).(*tickManager)
+	tm.c = c
+	tm.SetRuntimeOptions(runtime.NewOptions().
+		SetTickMinimumInterval(5 * cancellationCheckInterval))
+
+	doneCh := make(chan error)
+	go func() {
+		doneCh <- tm.Tick(noForce, start)
+	}()
+
+	for i := 0; i < 5; i++ {
+		require.True(t, clock.WaitUntil(func() bool {
+			return scheduler.Sleepers() == 1
+		}, time.Second))
+		scheduler.Advance(cancellationCheckInterval)
+	}
+
+	require.NoError(t, <-doneCh)
+	require.Equal(t, start.Add(5*cancellationCheckInterval), scheduler.Now())
+	require.Equal(t, 0, scheduler.Sleepers())
+}
+
 func TestTickManagerTickCancelled(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -28,11 +28,13 @@ import (
 	"github.com/m3db/m3/src/dbnode/clock"
 	"github.com/m3db/m3/src/dbnode/encoding"
 	"github.com/m3db/m3/src/dbnode/namespace"
+	"github.com/m3db/m3/src/dbnode/notify"
 	"github.com/m3db/m3/src/dbnode/persist"
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/persist/fs/commitlog"
 	"github.com/m3db/m3/src/dbnode/runtime"
 	"github.com/m3db/m3/src/dbnode/sharding"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap"
 	"github.com/m3db/m3/src/dbnode/storage/bootstrap/result"
@@ -43,6 +45,7 @@ import (
 	"github.com/m3db/m3/src/dbnode/ts"
 	"github.com/m3db/m3/src/dbnode/x/xio"
 	"github.com/m3db/m3/src/dbnode/x/xpool"
+	"github.com/m3db/m3/src/m3ninx/doc"
 	"github.com/m3db/m3/src/x/context"
 	"github.com/m3db/m3/src/x/ident"
 	"github.com/m3db/m3/src/x/instrument"
@@ -62,6 +65,13 @@ type IndexedErrorHandler interface {
 	HandleError(index int, err error)
 }
 
+// IndexedWriteDispositionHandler can be implemented by an IndexedErrorHandler
+// passed to WriteBatch or WriteTaggedBatch to be notified of how each write
+// in the batch was applied, based on the write's index.
+type IndexedWriteDispositionHandler interface {
+	HandleWriteDisposition(index int, disposition series.WriteDisposition)
+}
+
 // Database is a time series database.
 type Database interface {
 	// Options returns the database options.
@@ -115,6 +125,29 @@ type Database interface {
 		annotation []byte,
 	) error
 
+	// WriteTaggedBackfill writes values to the database for an ID that fall
+	// outside of the buffer past window, typically to backfill time windows
+	// that became valid after the namespace retention period was extended.
+	WriteTaggedBackfill(
+		ctx context.Context,
+		namespace ident.ID,
+		id ident.ID,
+		tags ident.TagIterator,
+		timestamp time.Time,
+		value float64,
+		unit xtime.Unit,
+		annotation []byte,
+	) error
+
+	// Import imports historical datapoints for many series directly into the
+	// filesets of blocks that have already been flushed, bypassing the
+	// in-memory buffer, and registers the series with the index.
+	Import(
+		ctx context.Context,
+		namespace ident.ID,
+		iter ImportIterator,
+	) (ImportResult, error)
+
 	// BatchWriter returns a batch writer for the provided namespace that can
 	// be used to issue a batch of writes to either WriteBatch
 	// or WriteTaggedBatch.
@@ -191,6 +224,21 @@ type Database interface {
 		opts block.FetchBlocksMetadataOptions,
 	) (block.FetchBlocksMetadataResults, PageToken, error)
 
+	// StreamBlocksMetadataV2 streams blocks metadata for a given shard to the
+	// callback one series at a time rather than accumulating a page of results,
+	// returns the next page token and any error encountered. If we have
+	// streamed all the block metadata, we return nil as the next page token.
+	StreamBlocksMetadataV2(
+		ctx context.Context,
+		namespace ident.ID,
+		shard uint32,
+		start, end time.Time,
+		limit int64,
+		pageToken PageToken,
+		opts block.FetchBlocksMetadataOptions,
+		fn block.FetchBlocksMetadataResultFn,
+	) (PageToken, error)
+
 	// Bootstrap bootstraps the database.
 	Bootstrap() error
 
@@ -217,6 +265,14 @@ type Database interface {
 
 	// FlushState returns the flush state for the specified shard and block start.
 	FlushState(namespace ident.ID, shardID uint32, blockStart time.Time) (fileOpState, error)
+
+	// ShardWatermarks returns the flush and repair watermarks of each shard
+	// the database owns for the given namespace.
+	ShardWatermarks(namespace ident.ID) ([]ShardWatermark, error)
+
+	// RepairHistory returns the recent repair outcomes of each block of each
+	// shard of the given namespace that has been repaired.
+	RepairHistory(namespace ident.ID) ([]BlockRepairHistory, error)
 }
 
 // database is the internal database interface.
@@ -249,6 +305,33 @@ type Namespace interface {
 
 	// Shards returns the shard description.
 	Shards() []Shard
+
+	// WriteLateness returns the distribution of how late datapoints written
+	// to the namespace arrived, excluding backfill writes.
+	WriteLateness() WriteLateness
+
+	// BlockSizeAnalysis returns the block size and index block size
+	// recommended for the namespace based on the writes and index queries
+	// it has served.
+	BlockSizeAnalysis() BlockSizeAnalysis
+
+	// Freeze freezes the data of the namespace in the time range, frozen
+	// data is not repaired, compacted or cleaned up until it is unfrozen.
+	Freeze(start, end time.Time) error
+
+	// Unfreeze unfreezes the data of the namespace in the time range.
+	Unfreeze(start, end time.Time) error
+
+	// FrozenRanges returns the frozen time ranges of the namespace.
+	FrozenRanges() []xtime.Range
+
+	// IsFrozen returns whether any of the data of the namespace in the time
+	// range is frozen.
+	IsFrozen(start, end time.Time) bool
+
+	// SeriesLastWrite returns when a series was last written to and whether
+	// the series is currently held in memory.
+	SeriesLastWrite(id ident.ID) (time.Time, bool, error)
 }
 
 // NamespacesByID is a sortable slice of namespaces by ID.
@@ -269,6 +352,17 @@ type databaseNamespace interface {
 	// AssignShardSet sets the shard set assignment and returns immediately.
 	AssignShardSet(shardSet sharding.ShardSet)
 
+	// ShardSet returns the shard set assigned to the namespace.
+	ShardSet() sharding.ShardSet
+
+	// UpdateBufferPastAndFuture updates the buffer past and buffer future of
+	// the namespace at runtime.
+	UpdateBufferPastAndFuture(bufferPast, bufferFuture time.Duration) error
+
+	// EnableIndex enables indexing on a namespace that was created with
+	// indexing disabled and backfills the index in the background.
+	EnableIndex(indexOpts namespace.IndexOptions) error
+
 	// GetOwnedShards returns the database shards.
 	GetOwnedShards() []databaseShard
 
@@ -286,7 +380,7 @@ type databaseNamespace interface {
 		value float64,
 		unit xtime.Unit,
 		annotation []byte,
-	) (ts.Series, bool, error)
+	) (ts.Series, bool, series.WriteDisposition, error)
 
 	// WriteTagged values to the namespace for an ID.
 	WriteTagged(
@@ -297,7 +391,28 @@ type databaseNamespace interface {
 		value float64,
 		unit xtime.Unit,
 		annotation []byte,
-	) (ts.Series, bool, error)
+	) (ts.Series, bool, series.WriteDisposition, error)
+
+	// WriteTaggedBackfill writes values to the namespace for an ID that fall
+	// outside of the buffer past window.
+	WriteTaggedBackfill(
+		ctx context.Context,
+		id ident.ID,
+		tags ident.TagIterator,
+		timestamp time.Time,
+		value float64,
+		unit xtime.Unit,
+		annotation []byte,
+	) (ts.Series, bool, series.WriteDisposition, error)
+
+	// Import imports historical datapoints directly into the filesets of
+	// blocks that have already been flushed, pausing the background file
+	// operations with fileOps while imported data is written to disk.
+	Import(
+		ctx context.Context,
+		iter ImportIterator,
+		fileOps importFileOps,
+	) (ImportResult, error)
 
 	// QueryIDs resolves the given query into known IDs.
 	QueryIDs(
@@ -339,6 +454,17 @@ type databaseNamespace interface {
 		opts block.FetchBlocksMetadataOptions,
 	) (block.FetchBlocksMetadataResults, PageToken, error)
 
+	// StreamBlocksMetadataV2 streams blocks metadata to the callback.
+	StreamBlocksMetadataV2(
+		ctx context.Context,
+		shardID uint32,
+		start, end time.Time,
+		limit int64,
+		pageToken PageToken,
+		opts block.FetchBlocksMetadataOptions,
+		fn block.FetchBlocksMetadataResultFn,
+	) (PageToken, error)
+
 	// PrepareBootstrap prepares the namespace for bootstrapping by ensuring
 	// it's shards know which flushed files reside on disk, so that calls
 	// to series.LoadBlock(...) will succeed.
@@ -360,6 +486,20 @@ type databaseNamespace interface {
 		flush persist.FlushPreparer,
 	) error
 
+	// MigrateFileSets rewrites at most limit blocks with filesets written in
+	// an older format version to the current format or of the parent shards
+	// of a namespace whose shard space has been split, returning the number
+	// of blocks migrated.
+	MigrateFileSets(
+		flush persist.FlushPreparer,
+		limit int,
+	) (int, error)
+
+	// CompactFileSets compacts the filesets of the blocks of at most limit
+	// aged compacted blocks into a single fileset for each compacted block,
+	// returning the number of compacted blocks written.
+	CompactFileSets(limit int) (int, error)
+
 	// Snapshot snapshots unflushed in-memory WarmWrites.
 	Snapshot(blockStart, snapshotTime time.Time, flush persist.SnapshotPreparer) error
 
@@ -417,6 +557,10 @@ type Shard interface {
 	// IsBootstrapped returns whether the shard is already bootstrapped.
 	IsBootstrapped() bool
 
+	// IsDegraded returns whether the shard has exhausted its read error
+	// budget and is excluded from serving reads.
+	IsDegraded() bool
+
 	// BootstrapState returns the shards' bootstrap state.
 	BootstrapState() BootstrapState
 }
@@ -444,7 +588,7 @@ type databaseShard interface {
 		unit xtime.Unit,
 		annotation []byte,
 		wOpts series.WriteOptions,
-	) (ts.Series, bool, error)
+	) (ts.Series, bool, series.WriteDisposition, error)
 
 	// WriteTagged writes a value to the shard for an ID with tags.
 	WriteTagged(
@@ -456,7 +600,7 @@ type databaseShard interface {
 		unit xtime.Unit,
 		annotation []byte,
 		wOpts series.WriteOptions,
-	) (ts.Series, bool, error)
+	) (ts.Series, bool, series.WriteDisposition, error)
 
 	ReadEncoded(
 		ctx context.Context,
@@ -494,6 +638,16 @@ type databaseShard interface {
 		opts block.FetchBlocksMetadataOptions,
 	) (block.FetchBlocksMetadataResults, PageToken, error)
 
+	// StreamBlocksMetadataV2 streams blocks metadata to the callback.
+	StreamBlocksMetadataV2(
+		ctx context.Context,
+		start, end time.Time,
+		limit int64,
+		pageToken PageToken,
+		opts block.FetchBlocksMetadataOptions,
+		fn block.FetchBlocksMetadataResultFn,
+	) (PageToken, error)
+
 	// PrepareBootstrap prepares the shard for bootstrapping by ensuring
 	// it knows which flushed files reside on disk.
 	PrepareBootstrap() error
@@ -533,12 +687,49 @@ type databaseShard interface {
 		nsCtx namespace.Context,
 	) error
 
+	// ImportBlock merges data imported for a block that has already been
+	// flushed into the next volume of the block's fileset.
+	ImportBlock(
+		flush persist.FlushPreparer,
+		fsReader fs.DataFileSetReader,
+		blockStart time.Time,
+		mergeWith fs.MergeWith,
+		nsCtx namespace.Context,
+	) error
+
+	// MigrateFileSets rewrites at most limit blocks with filesets written in
+	// an older format version to the current format, returning the number of
+	// blocks migrated.
+	MigrateFileSets(
+		flush persist.FlushPreparer,
+		resources coldFlushReuseableResources,
+		limit int,
+		nsCtx namespace.Context,
+	) (int, error)
+
+	// CompactFileSets compacts the filesets of the blocks of at most limit
+	// aged compacted blocks into a single fileset for each compacted block,
+	// returning the number of compacted blocks written.
+	CompactFileSets(limit int) (int, error)
+
+	// SplitFileSets splits at most limit blocks of the filesets of the parent
+	// shard of the shard into filesets of the shard, returning the number of
+	// blocks split.
+	SplitFileSets(
+		shardFn sharding.HashFn,
+		limit int,
+	) (int, error)
+
 	// FlushState returns the flush state for this shard at block start.
 	FlushState(blockStart time.Time) (fileOpState, error)
 
 	// CleanupExpiredFileSets removes expired fileset files.
 	CleanupExpiredFileSets(earliestToRetain time.Time) error
 
+	// RunBlockExpiryHooks runs the hooks against the series of every flushed
+	// block that expires before the given time.
+	RunBlockExpiryHooks(earliestToRetain time.Time, hooks []BlockExpiryHook) error
+
 	// CleanupCompactedFileSets removes fileset files that have been compacted,
 	// meaning that there exists a more recent, superset, fully persisted
 	// fileset for that block.
@@ -558,6 +749,10 @@ type databaseShard interface {
 	// necessary given the callsites that current exist?
 	TagsFromSeriesID(seriesID ident.ID) (ident.Tags, bool, error)
 
+	// SeriesLastWrite returns when a series was last written to and whether
+	// the series is currently held in memory.
+	SeriesLastWrite(id ident.ID) (time.Time, bool, error)
+
 	// SeriesReadWriteRef returns a read/write ref to a series, callers
 	// must make sure to call the release callback once finished
 	// with the reference.
@@ -612,6 +807,14 @@ type namespaceIndex interface {
 	// BootstrapsDone returns the number of completed bootstraps.
 	BootstrapsDone() uint
 
+	// AddImported indexes documents of series imported directly into the
+	// filesets of past blocks, the documents are held in memory until the
+	// index block is next flushed.
+	AddImported(
+		blockStart time.Time,
+		docs []doc.Document,
+	) error
+
 	// CleanupExpiredFileSets removes expired fileset files. Expiration is calcuated
 	// using the provided `t` as the frame of reference.
 	CleanupExpiredFileSets(t time.Time) error
@@ -627,6 +830,15 @@ type namespaceIndex interface {
 		shards []databaseShard,
 	) error
 
+	// PurgeDocuments purges the documents of series with no live data in
+	// the owned shards from the in-memory segments of open blocks, at most
+	// once per configured documents purge interval.
+	PurgeDocuments(
+		c context.Cancellable,
+		startTime time.Time,
+		shards []databaseShard,
+	) (namespaceIndexPurgeResult, error)
+
 	// Close will release the index resources and close the index.
 	Close() error
 }
@@ -641,6 +853,14 @@ type namespaceIndexTickResult struct {
 	NumTotalDocs     int64
 }
 
+// namespaceIndexPurgeResult are details about the work performed by the
+// namespaceIndex during a PurgeDocuments().
+type namespaceIndexPurgeResult struct {
+	NumBlocks     int64
+	NumDocs       int64
+	NumDocsPurged int64
+}
+
 // namespaceIndexInsertQueue is a queue used in-front of the indexing component
 // for Writes. NB: this is an interface to allow easier unit tests in namespaceIndex.
 type namespaceIndexInsertQueue interface {
@@ -748,6 +968,10 @@ type databaseShardRepairer interface {
 		tr xtime.Range,
 		shard databaseShard,
 	) (repair.MetadataComparisonResult, error)
+
+	// History returns the recent repair outcomes of each block of each
+	// shard of a namespace that has been repaired.
+	History(namespace ident.ID) []BlockRepairHistory
 }
 
 // databaseRepairer repairs in-memory database data.
@@ -763,6 +987,14 @@ type databaseRepairer interface {
 
 	// Report reports runtime information.
 	Report()
+
+	// RepairedTo returns the end of the contiguous run of successfully
+	// repaired blocks of a namespace and whether repairs are enabled.
+	RepairedTo(n databaseNamespace) (time.Time, bool)
+
+	// RepairHistory returns the recent repair outcomes of each block of
+	// each shard of a namespace that has been repaired.
+	RepairHistory(n databaseNamespace) []BlockRepairHistory
 }
 
 // databaseTickManager performs periodic ticking.
@@ -800,6 +1032,14 @@ type databaseMediator interface {
 	// Repair repairs the database.
 	Repair() error
 
+	// RepairedTo returns the end of the contiguous run of successfully
+	// repaired blocks of a namespace and whether repairs are enabled.
+	RepairedTo(n databaseNamespace) (time.Time, bool)
+
+	// RepairHistory returns the recent repair outcomes of each block of
+	// each shard of a namespace that has been repaired.
+	RepairHistory(n databaseNamespace) []BlockRepairHistory
+
 	// Close closes the mediator.
 	Close() error
 
@@ -1054,11 +1294,78 @@ type Options interface {
 	// MemoryTracker returns the MemoryTracker.
 	MemoryTracker() MemoryTracker
 
+	// SetSnapshotTracker sets the SnapshotTracker.
+	SetSnapshotTracker(snapshotTracker SnapshotTracker) Options
+
+	// SnapshotTracker returns the SnapshotTracker.
+	SnapshotTracker() SnapshotTracker
+
+	// SetSnapshotCompactionMinVolumes sets the number of snapshot volumes of
+	// a block at or above which cleanup compacts them into a single volume,
+	// zero disables snapshot compaction.
+	SetSnapshotCompactionMinVolumes(value int) Options
+
+	// SnapshotCompactionMinVolumes returns the number of snapshot volumes of
+	// a block at or above which cleanup compacts them into a single volume.
+	SnapshotCompactionMinVolumes() int
+
+	// SetFileSetMigrationBlocksPerFlush sets the maximum number of blocks with
+	// filesets written in an older format version that are rewritten to the
+	// current format during each flush, zero disables fileset migration. Aged
+	// blocks of namespaces that compact their blocks are compacted within the
+	// same limit.
+	SetFileSetMigrationBlocksPerFlush(value int) Options
+
+	// FileSetMigrationBlocksPerFlush returns the maximum number of blocks with
+	// filesets written in an older format version that are rewritten to the
+	// current format during each flush.
+	FileSetMigrationBlocksPerFlush() int
+
+	// SetTickLoadMonitor sets the tick load monitor.
+	SetTickLoadMonitor(value TickLoadMonitor) Options
+
+	// TickLoadMonitor returns the tick load monitor.
+	TickLoadMonitor() TickLoadMonitor
+
+	// SetBackgroundScheduler sets the scheduler that coordinates flushes,
+	// cleanup, repairs and index compactions.
+	SetBackgroundScheduler(value background.Scheduler) Options
+
+	// BackgroundScheduler returns the scheduler that coordinates flushes,
+	// cleanup, repairs and index compactions.
+	BackgroundScheduler() background.Scheduler
+
+	// SetPurgeReporter sets the purge reporter.
+	SetPurgeReporter(value PurgeReporter) Options
+
+	// PurgeReporter returns the purge reporter.
+	PurgeReporter() PurgeReporter
+
+	// SetBlockExpiryHooks sets the hooks run before blocks expire.
+	SetBlockExpiryHooks(value []BlockExpiryHook) Options
+
+	// BlockExpiryHooks returns the hooks run before blocks expire.
+	BlockExpiryHooks() []BlockExpiryHook
+
+	// SetWriteInterceptors sets the interceptors called with every write
+	// before it is buffered, in the order they are specified.
+	SetWriteInterceptors(value []WriteInterceptor) Options
+
+	// WriteInterceptors returns the interceptors called with every write
+	// before it is buffered.
+	WriteInterceptors() []WriteInterceptor
+
 	// SetMmapReporter sets the mmap reporter.
 	SetMmapReporter(mmapReporter mmap.Reporter) Options
 
 	// MmapReporter returns the mmap reporter.
 	MmapReporter() mmap.Reporter
+
+	// SetNotifier sets the notifier lifecycle events are sent to.
+	SetNotifier(value notify.Notifier) Options
+
+	// Notifier returns the notifier lifecycle events are sent to.
+	Notifier() notify.Notifier
 }
 
 // MemoryTracker tracks memory.
@@ -1085,6 +1392,206 @@ type MemoryTracker interface {
 	WaitForDec()
 }
 
+// SnapshotTracker tracks the volume of data written since the last
+// successful snapshot so that snapshots can be triggered by the amount of
+// commit log that would need to be replayed, not just by time.
+type SnapshotTracker interface {
+	// IncNumUnsnapshottedBytes increments the number of bytes that have been
+	// written to the commit log since the last successful snapshot.
+	IncNumUnsnapshottedBytes(x int64)
+
+	// IncNumUnsnapshottedSeries increments the number of series that have
+	// been created since the last successful snapshot.
+	IncNumUnsnapshottedSeries(x int64)
+
+	// NumUnsnapshottedBytes returns the number of bytes that have been
+	// written to the commit log since the last successful snapshot.
+	NumUnsnapshottedBytes() int64
+
+	// NumUnsnapshottedSeries returns the number of series that have been
+	// created since the last successful snapshot.
+	NumUnsnapshottedSeries() int64
+
+	// ShouldSnapshot returns whether a snapshot should be taken given the
+	// time elapsed since the last successful snapshot, either because the
+	// minimum interval has elapsed or a volume threshold has been exceeded.
+	ShouldSnapshot(sinceLastSnapshot time.Duration) bool
+
+	// MarkUnsnapshottedAsPending marks the current number of unsnapshotted
+	// bytes and series as pending so that a subsequent call to
+	// DecPendingUnsnapshotted() will decrement them by the numbers that were
+	// set when this function was last executed.
+	MarkUnsnapshottedAsPending()
+
+	// DecPendingUnsnapshotted decrements the number of unsnapshotted bytes
+	// and series by the pending numbers that were captured by the last call
+	// to MarkUnsnapshottedAsPending().
+	DecPendingUnsnapshotted()
+}
+
+// TickLoadMonitor monitors the load of the node so that the background tick
+// can throttle itself rather than worsen the load of a node in distress.
+type TickLoadMonitor interface {
+	runtime.OptionsListener
+
+	// Enabled returns whether tick load pacing is enabled.
+	Enabled() bool
+
+	// RecordWriteLatency records the latency of a write.
+	RecordWriteLatency(value time.Duration)
+
+	// RecordReadLatency records the latency of a read.
+	RecordReadLatency(value time.Duration)
+
+	// SlowdownFactor returns the factor, at least one, to scale the tick
+	// sleeps and minimum interval by given the current load of the node.
+	SlowdownFactor() float64
+}
+
+// PurgeReporter records the files removed by cleanup of expired data and
+// reports exactly what was removed by the most recent cleanup.
+type PurgeReporter interface {
+	// Start starts the report for a cleanup.
+	Start(t time.Time)
+
+	// RecordPurged records files of a type removed by the current cleanup.
+	RecordPurged(fileType PurgedFileType, files []PurgedFile)
+
+	// Finish completes the report for the current cleanup.
+	Finish(t time.Time)
+
+	// LastReport returns the report of the most recently completed cleanup,
+	// returning false if no cleanup has completed yet.
+	LastReport() (PurgeReport, bool)
+}
+
+// ExpiringBlock describes a flushed block of a shard that is about to age out
+// of retention.
+type ExpiringBlock struct {
+	Namespace  ident.ID
+	Shard      uint32
+	BlockStart time.Time
+	BlockSize  time.Duration
+}
+
+// BlockExpiryHook is run against the flushed blocks of a shard just before
+// they age out of retention and their fileset files are deleted, allowing
+// summaries of the data to be computed and stored elsewhere.
+type BlockExpiryHook interface {
+	// OnExpiringSeries is called with every series of an expiring block, the
+	// ID, tags and iterator are only valid for the duration of the call.
+	OnExpiringSeries(
+		block ExpiringBlock,
+		id ident.ID,
+		tags ident.Tags,
+		iter encoding.Iterator,
+	) error
+}
+
+// WriteInterceptor is called with every datapoint written to a namespace
+// before it is buffered, allowing embedders to validate, sample or mirror
+// writes without changing the write path. Returning false drops the write
+// without an error while returning an error rejects the write with it. The
+// IDs and annotation are only valid for the duration of the call.
+type WriteInterceptor func(
+	namespace ident.ID,
+	id ident.ID,
+	datapoint ts.Datapoint,
+	unit xtime.Unit,
+	annotation []byte,
+) (bool, error)
+
+// ImportIterator iterates over the datapoints of series to import. The
+// datapoints of a series must be contiguous and sorted by time.
+type ImportIterator interface {
+	// Next returns whether there is another datapoint to import.
+	Next() bool
+
+	// Current returns the current datapoint and the series it belongs to, the
+	// ID and tags are only valid until the next call to Next.
+	Current() (id ident.ID, tags ident.TagIterator, dp ts.Datapoint,
+		unit xtime.Unit, annotation ts.Annotation)
+
+	// Err returns any error encountered while iterating.
+	Err() error
+}
+
+// ImportResult describes the data imported by an import.
+type ImportResult struct {
+	NumSeries     int64
+	NumDatapoints int64
+	NumBlocks     int64
+}
+
+// importFileOps pauses and resumes the background file operations so that
+// imported data can be written to disk.
+type importFileOps interface {
+	// DisableFileOps disables file operations.
+	DisableFileOps()
+
+	// EnableFileOps enables file operations.
+	EnableFileOps()
+}
+
+// ShardWatermark describes up to which time the data of a shard has been
+// persisted and repaired, zero times indicate the watermark has not advanced
+// past the start of retention.
+type ShardWatermark struct {
+	Shard uint32
+	// FlushedTo is the end of the contiguous run of warm flushed blocks.
+	FlushedTo time.Time
+	// RepairedTo is the end of the contiguous run of repaired blocks, it is
+	// always zero when repairs are disabled.
+	RepairedTo time.Time
+	// DurableTo is the time before which data is both flushed and, if
+	// repairs are enabled, repaired.
+	DurableTo time.Time
+}
+
+// RepairAction is the action taken by the repair of a block of a shard.
+type RepairAction string
+
+const (
+	// RepairActionNone indicates the block had not diverged from its peers.
+	RepairActionNone RepairAction = "none"
+	// RepairActionLoaded indicates blocks that diverged from peers were
+	// fetched from the peers and loaded into the shard.
+	RepairActionLoaded RepairAction = "loaded"
+	// RepairActionCompared indicates blocks diverged from peers but were
+	// not repaired since the repair only compares replicas.
+	RepairActionCompared RepairAction = "compared"
+	// RepairActionFailed indicates the repair failed.
+	RepairActionFailed RepairAction = "failed"
+)
+
+// RepairOutcome describes the outcome of a repair of a block of a shard.
+type RepairOutcome struct {
+	// Time is when the repair started.
+	Time     time.Time
+	Duration time.Duration
+	Action   RepairAction
+	// NumSeries and NumBlocks are the number of series and blocks compared.
+	NumSeries int64
+	NumBlocks int64
+	// The number of series and blocks that diverged from peers.
+	SizeDiffSeries     int64
+	SizeDiffBlocks     int64
+	ChecksumDiffSeries int64
+	ChecksumDiffBlocks int64
+	// Peers are the IDs of the peers with blocks that diverged.
+	Peers []string
+	// Error is set if the repair failed.
+	Error string
+}
+
+// BlockRepairHistory is the history of repairs of a block of a shard.
+type BlockRepairHistory struct {
+	Shard      uint32
+	BlockStart time.Time
+	// Outcomes are the most recent repair outcomes, oldest first.
+	Outcomes []RepairOutcome
+}
+
 // DatabaseBootstrapState stores a snapshot of the bootstrap state for all shards across all
 // namespaces at a given moment in time.
 type DatabaseBootstrapState struct {
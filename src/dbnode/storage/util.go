@@ -26,6 +26,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/persist/fs"
 	"github.com/m3db/m3/src/dbnode/runtime"
+	"github.com/m3db/m3/src/dbnode/storage/background"
 	"github.com/m3db/m3/src/dbnode/storage/block"
 	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/dbnode/storage/series"
@@ -75,6 +76,7 @@ func DefaultTestOptions() Options {
 			SetSeriesCachePolicy(series.CacheAll).
 			SetPersistManager(pm).
 			SetRepairEnabled(false).
+			SetFileSetMigrationBlocksPerFlush(0).
 			SetCommitLogOptions(
 				opts.CommitLogOptions().SetFilesystemOptions(fsOpts)).
 			SetBlockLeaseManager(blockLeaseManager)
@@ -124,3 +126,18 @@ func filterTimes(times []time.Time, predicate func(t time.Time) bool) []time.Tim
 
 	return filtered
 }
+
+// runBackgroundWork runs fn once the scheduler admits the work type and
+// releases the budget it held once fn returns.
+func runBackgroundWork(
+	scheduler background.Scheduler,
+	workType background.WorkType,
+	fn func() error,
+) error {
+	release, err := scheduler.Acquire(workType)
+	if err != nil {
+		return err
+	}
+	defer release()
+	return fn()
+}
@@ -0,0 +1,153 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/uber-go/tally"
+)
+
+// writeLatenessBuckets are the upper bounds of the buckets datapoint write
+// lateness is tracked in, writes later than the last bound are tracked in
+// an overflow bucket.
+var writeLatenessBuckets = []time.Duration{
+	time.Second,
+	5 * time.Second,
+	10 * time.Second,
+	30 * time.Second,
+	time.Minute,
+	2 * time.Minute,
+	5 * time.Minute,
+	10 * time.Minute,
+	15 * time.Minute,
+	30 * time.Minute,
+	time.Hour,
+	2 * time.Hour,
+	6 * time.Hour,
+	12 * time.Hour,
+	24 * time.Hour,
+}
+
+// WriteLateness is the distribution of how late datapoints written to a
+// namespace arrived relative to the time they were written at, since the
+// namespace was opened.
+type WriteLateness struct {
+	// Buckets are the number of datapoints written per lateness bucket.
+	Buckets []WriteLatenessBucket `json:"buckets"`
+	// Early is the number of datapoints with timestamps in the future.
+	Early int64 `json:"early"`
+	// Count is the total number of datapoints tracked, including early ones.
+	Count int64 `json:"count"`
+}
+
+// WriteLatenessBucket is the number of datapoints written with a lateness
+// less than or equal to the upper bound and greater than the upper bound of
+// the previous bucket.
+type WriteLatenessBucket struct {
+	// UpperBound is the upper bound of the bucket, zero for the overflow
+	// bucket of datapoints later than the last bound.
+	UpperBound time.Duration `json:"upperBound"`
+	Count      int64         `json:"count"`
+}
+
+// Quantile returns the upper bound of the bucket the quantile q of the
+// lateness of datapoints falls in, early datapoints count as on time. It
+// returns false if the quantile falls in the overflow bucket, in which case
+// the lateness is greater than the returned last bound.
+func (l WriteLateness) Quantile(q float64) (time.Duration, bool) {
+	var (
+		target     = q * float64(l.Count)
+		cumulative = float64(l.Early)
+	)
+	if cumulative >= target {
+		return 0, true
+	}
+	for _, b := range l.Buckets {
+		cumulative += float64(b.Count)
+		if cumulative >= target && b.UpperBound != 0 {
+			return b.UpperBound, true
+		}
+	}
+	return writeLatenessBuckets[len(writeLatenessBuckets)-1], false
+}
+
+// writeLatenessTracker tracks the lateness of datapoints written to a
+// namespace, both as a histogram metric and as counts that can be served
+// to operators to help choose the buffer past of the namespace and to
+// detect delays in upstream pipelines.
+type writeLatenessTracker struct {
+	counts    []int64
+	early     int64
+	histogram tally.Histogram
+	earlyCtr  tally.Counter
+}
+
+func newWriteLatenessTracker(scope tally.Scope) *writeLatenessTracker {
+	return &writeLatenessTracker{
+		counts: make([]int64, len(writeLatenessBuckets)+1),
+		histogram: scope.Histogram("write-lateness",
+			tally.DurationBuckets(writeLatenessBuckets)),
+		earlyCtr: scope.Counter("write-early"),
+	}
+}
+
+// Record tracks a datapoint written with the given lateness, negative for
+// datapoints with timestamps in the future.
+func (t *writeLatenessTracker) Record(lateness time.Duration) {
+	if lateness < 0 {
+		atomic.AddInt64(&t.early, 1)
+		t.earlyCtr.Inc(1)
+		return
+	}
+	idx := len(writeLatenessBuckets)
+	for i, bound := range writeLatenessBuckets {
+		if lateness <= bound {
+			idx = i
+			break
+		}
+	}
+	atomic.AddInt64(&t.counts[idx], 1)
+	t.histogram.RecordDuration(lateness)
+}
+
+// Snapshot returns the distribution of write lateness tracked so far.
+func (t *writeLatenessTracker) Snapshot() WriteLateness {
+	result := WriteLateness{
+		Buckets: make([]WriteLatenessBucket, 0, len(t.counts)),
+		Early:   atomic.LoadInt64(&t.early),
+	}
+	result.Count = result.Early
+	for i := range t.counts {
+		var bound time.Duration
+		if i < len(writeLatenessBuckets) {
+			bound = writeLatenessBuckets[i]
+		}
+		count := atomic.LoadInt64(&t.counts[i])
+		result.Buckets = append(result.Buckets, WriteLatenessBucket{
+			UpperBound: bound,
+			Count:      count,
+		})
+		result.Count += count
+	}
+	return result
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/dbnode/storage/series"
+	"github.com/m3db/m3/src/dbnode/ts"
+	"github.com/m3db/m3/src/x/context"
+	"github.com/m3db/m3/src/x/ident"
+	xtime "github.com/m3db/m3/src/x/time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestWriteLatenessTracker(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	tracker := newWriteLatenessTracker(scope)
+
+	tracker.Record(-time.Second)
+	tracker.Record(0)
+	tracker.Record(500 * time.Millisecond)
+	tracker.Record(3 * time.Minute)
+	tracker.Record(48 * time.Hour)
+
+	lateness := tracker.Snapshot()
+	assert.Equal(t, int64(5), lateness.Count)
+	assert.Equal(t, int64(1), lateness.Early)
+	require.Equal(t, len(writeLatenessBuckets)+1, len(lateness.Buckets))
+
+	counts := make(map[time.Duration]int64)
+	for _, b := range lateness.Buckets {
+		if b.Count > 0 {
+			counts[b.UpperBound] = b.Count
+		}
+	}
+	assert.Equal(t, map[time.Duration]int64{
+		time.Second:     2,
+		5 * time.Minute: 1,
+		0:               1,
+	}, counts)
+
+	snapshot := scope.Snapshot()
+	assert.Equal(t, int64(1), snapshot.Counters()["write-early+"].Value())
+	assert.Equal(t, 1, len(snapshot.Histograms()))
+}
+
+func TestWriteLatenessQuantile(t *testing.T) {
+	tracker := newWriteLatenessTracker(tally.NoopScope)
+
+	value, ok := tracker.Snapshot().Quantile(0.99)
+	assert.True(t, ok)
+	assert.Equal(t, time.Duration(0), value)
+
+	for i := 0; i < 90; i++ {
+		tracker.Record(2 * time.Second)
+	}
+	for i := 0; i < 9; i++ {
+		tracker.Record(20 * time.Minute)
+	}
+	tracker.Record(72 * time.Hour)
+
+	lateness := tracker.Snapshot()
+	value, ok = lateness.Quantile(0.5)
+	assert.True(t, ok)
+	assert.Equal(t, 5*time.Second, value)
+
+	value, ok = lateness.Quantile(0.99)
+	assert.True(t, ok)
+	assert.Equal(t, 30*time.Minute, value)
+
+	value, ok = lateness.Quantile(1)
+	assert.False(t, ok)
+	assert.Equal(t, 24*time.Hour, value)
+}
+
+func TestNamespaceWriteLateness(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	ctx := context.NewContext()
+	defer ctx.Close()
+
+	ns, closer := newTestNamespace(t)
+	defer closer()
+
+	now := time.Now()
+	ns.nowFn = func() time.Time { return now }
+
+	var (
+		id    = ident.StringID("foo")
+		start = now.Add(-90 * time.Second)
+		opts  = series.WriteOptions{TruncateType: ns.opts.TruncateType()}
+	)
+	shard := NewMockdatabaseShard(ctrl)
+	shard.EXPECT().Write(ctx, id, start, 1.0, xtime.Second, nil, opts).
+		Return(ts.Series{}, true, series.WriteAccepted, nil)
+	shard.EXPECT().Write(ctx, id, now, 2.0, xtime.Second, nil, opts).
+		Return(ts.Series{}, false, series.WriteDispositionUnknown, errors.New("write error"))
+	ns.shards[testShardIDs[0].ID()] = shard
+
+	_, _, _, err := ns.Write(ctx, id, start, 1.0, xtime.Second, nil)
+	require.NoError(t, err)
+	_, _, _, err = ns.Write(ctx, id, now, 2.0, xtime.Second, nil)
+	require.Error(t, err)
+
+	// Only the successful write is tracked.
+	lateness := ns.WriteLateness()
+	assert.Equal(t, int64(1), lateness.Count)
+	value, ok := lateness.Quantile(1)
+	assert.True(t, ok)
+	assert.Equal(t, 2*time.Minute, value)
+}
@@ -0,0 +1,226 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"errors"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	xwatch "github.com/m3db/m3/src/x/watch"
+
+	"go.uber.org/zap"
+)
+
+var errNoHostAddresses = errors.New("host name resolved to no addresses")
+
+type dnsInitializer struct {
+	sync.Mutex
+	opts DNSOptions
+	topo Topology
+}
+
+// NewDNSInitializer returns a topology initializer for a static set of hosts
+// and shard assignments that periodically re-resolves the host names of the
+// host addresses, this allows running a cluster without a placement stored
+// in a config service.
+func NewDNSInitializer(opts DNSOptions) Initializer {
+	return &dnsInitializer{opts: opts}
+}
+
+func (i *dnsInitializer) Init() (Topology, error) {
+	i.Lock()
+	defer i.Unlock()
+
+	if i.topo != nil {
+		return i.topo, nil
+	}
+
+	if err := i.opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	topo, err := newDNSTopology(i.opts)
+	if err != nil {
+		return nil, err
+	}
+
+	i.topo = topo
+	return i.topo, nil
+}
+
+func (i *dnsInitializer) TopologyIsSet() (bool, error) {
+	// Always has the specified hosts and shard assignments ready.
+	return true, nil
+}
+
+type dnsTopology struct {
+	sync.RWMutex
+	opts      DNSOptions
+	watchable xwatch.Watchable
+	addresses []string
+	closed    bool
+	closedCh  chan struct{}
+	logger    *zap.Logger
+}
+
+func newDNSTopology(opts DNSOptions) (Topology, error) {
+	t := &dnsTopology{
+		opts:      opts,
+		watchable: xwatch.NewWatchable(),
+		closedCh:  make(chan struct{}),
+		logger:    opts.InstrumentOptions().Logger(),
+	}
+
+	// Fail on the initial resolution so that a misconfigured host
+	// is surfaced on start up rather than routed to silently.
+	addresses, err := t.resolve()
+	if err != nil {
+		return nil, err
+	}
+	t.update(addresses)
+
+	go t.run()
+	return t, nil
+}
+
+func (t *dnsTopology) run() {
+	ticker := time.NewTicker(t.opts.RefreshInterval())
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+		case <-t.closedCh:
+			return
+		}
+
+		addresses, err := t.resolve()
+		if err != nil {
+			t.logger.Warn("dns topology could not resolve hosts, keeping previous addresses",
+				zap.Error(err))
+			continue
+		}
+		if t.changed(addresses) {
+			t.logger.Info("dns topology host addresses changed, updating topology")
+			t.update(addresses)
+		}
+	}
+}
+
+// resolve returns the resolved address of each of the host shard sets.
+func (t *dnsTopology) resolve() ([]string, error) {
+	var (
+		hostShardSets = t.opts.StaticOptions().HostShardSets()
+		lookupHostFn  = t.opts.LookupHostFn()
+		addresses     = make([]string, 0, len(hostShardSets))
+	)
+	for _, hostShardSet := range hostShardSets {
+		address, err := resolveAddress(hostShardSet.Host().Address(), lookupHostFn)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+	return addresses, nil
+}
+
+func resolveAddress(address string, lookupHostFn LookupHostFn) (string, error) {
+	hostname, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", err
+	}
+	if net.ParseIP(hostname) != nil {
+		return address, nil
+	}
+
+	resolved, err := lookupHostFn(hostname)
+	if err != nil {
+		return "", err
+	}
+	if len(resolved) == 0 {
+		return "", errNoHostAddresses
+	}
+
+	// Pick the lowest address so the choice is stable across lookups
+	// that return the same addresses in a different order.
+	sort.Strings(resolved)
+	return net.JoinHostPort(resolved[0], port), nil
+}
+
+func (t *dnsTopology) changed(addresses []string) bool {
+	t.RLock()
+	defer t.RUnlock()
+
+	for i, address := range addresses {
+		if t.addresses[i] != address {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *dnsTopology) update(addresses []string) {
+	var (
+		staticOpts    = t.opts.StaticOptions()
+		hostShardSets = staticOpts.HostShardSets()
+		resolved      = make([]HostShardSet, 0, len(hostShardSets))
+	)
+	for i, hostShardSet := range hostShardSets {
+		host := NewHostWithIsolationGroup(hostShardSet.Host().ID(), addresses[i],
+			hostShardSet.Host().IsolationGroup())
+		resolved = append(resolved, NewHostShardSet(host, hostShardSet.ShardSet()))
+	}
+
+	t.Lock()
+	t.addresses = addresses
+	t.Unlock()
+
+	t.watchable.Update(NewStaticMap(staticOpts.SetHostShardSets(resolved)))
+}
+
+func (t *dnsTopology) Get() Map {
+	return t.watchable.Get().(Map)
+}
+
+func (t *dnsTopology) Watch() (MapWatch, error) {
+	_, w, err := t.watchable.Watch()
+	if err != nil {
+		return nil, err
+	}
+	return NewMapWatch(w), nil
+}
+
+func (t *dnsTopology) Close() {
+	t.Lock()
+	defer t.Unlock()
+
+	if t.closed {
+		return
+	}
+
+	t.closed = true
+
+	close(t.closedCh)
+	t.watchable.Close()
+}
@@ -0,0 +1,146 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package topology
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/dbnode/sharding"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testResolver struct {
+	sync.Mutex
+	addresses map[string][]string
+	err       error
+}
+
+func (r *testResolver) set(hostname string, addresses ...string) {
+	r.Lock()
+	r.addresses[hostname] = addresses
+	r.Unlock()
+}
+
+func (r *testResolver) setErr(err error) {
+	r.Lock()
+	r.err = err
+	r.Unlock()
+}
+
+func (r *testResolver) lookupHost(hostname string) ([]string, error) {
+	r.Lock()
+	defer r.Unlock()
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.addresses[hostname], nil
+}
+
+func testDNSOptions(t *testing.T, resolver *testResolver) DNSOptions {
+	shards := sharding.NewShards([]uint32{0, 1}, shard.Available)
+	shardSet, err := sharding.NewShardSet(shards, sharding.DefaultHashFn(len(shards)))
+	require.NoError(t, err)
+
+	hostShardSets := []HostShardSet{
+		NewHostShardSet(NewHost("a", "node-a:9000"), shardSet),
+		NewHostShardSet(NewHost("b", "127.0.0.2:9000"), shardSet),
+	}
+	staticOpts := NewStaticOptions().
+		SetReplicas(2).
+		SetShardSet(shardSet).
+		SetHostShardSets(hostShardSets)
+
+	return NewDNSOptions().
+		SetStaticOptions(staticOpts).
+		SetRefreshInterval(time.Millisecond).
+		SetLookupHostFn(resolver.lookupHost)
+}
+
+func TestDNSTopologyResolvesHostAddresses(t *testing.T) {
+	resolver := &testResolver{addresses: make(map[string][]string)}
+	resolver.set("node-a", "10.0.0.2", "10.0.0.1")
+
+	topo, err := NewDNSInitializer(testDNSOptions(t, resolver)).Init()
+	require.NoError(t, err)
+	defer topo.Close()
+
+	m := topo.Get()
+	require.Equal(t, 2, m.HostsLen())
+	a, ok := m.LookupHostShardSet("a")
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.1:9000", a.Host().Address())
+	assert.Equal(t, []uint32{0, 1}, a.ShardSet().AllIDs())
+
+	b, ok := m.LookupHostShardSet("b")
+	require.True(t, ok)
+	assert.Equal(t, "127.0.0.2:9000", b.Host().Address())
+}
+
+func TestDNSTopologyInitFailsOnUnresolvedHost(t *testing.T) {
+	resolver := &testResolver{addresses: make(map[string][]string)}
+
+	_, err := NewDNSInitializer(testDNSOptions(t, resolver)).Init()
+	require.Equal(t, errNoHostAddresses, err)
+}
+
+func TestDNSTopologyRefreshUpdatesWatch(t *testing.T) {
+	resolver := &testResolver{addresses: make(map[string][]string)}
+	resolver.set("node-a", "10.0.0.1")
+
+	topo, err := NewDNSInitializer(testDNSOptions(t, resolver)).Init()
+	require.NoError(t, err)
+	defer topo.Close()
+
+	w, err := topo.Watch()
+	require.NoError(t, err)
+	defer w.Close()
+	<-w.C()
+
+	// Failed lookups keep the previous addresses.
+	resolver.setErr(errors.New("lookup failed"))
+	time.Sleep(10 * time.Millisecond)
+	a, ok := topo.Get().LookupHostShardSet("a")
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.1:9000", a.Host().Address())
+
+	resolver.setErr(nil)
+	resolver.set("node-a", "10.0.0.3")
+	<-w.C()
+	a, ok = w.Get().LookupHostShardSet("a")
+	require.True(t, ok)
+	assert.Equal(t, "10.0.0.3:9000", a.Host().Address())
+}
+
+func TestDNSOptionsValidate(t *testing.T) {
+	assert.Equal(t, errNoStaticOptions, NewDNSOptions().Validate())
+
+	resolver := &testResolver{addresses: make(map[string][]string)}
+	opts := testDNSOptions(t, resolver)
+	assert.NoError(t, opts.Validate())
+	assert.Equal(t, errInvalidRefresh, opts.SetRefreshInterval(0).Validate())
+	assert.Equal(t, errNoLookupHostFn, opts.SetLookupHostFn(nil).Validate())
+}
@@ -23,8 +23,11 @@ package topology
 import (
 	"errors"
 	"sync"
+	"time"
 
+	"github.com/m3db/m3/src/cluster/generated/proto/placementpb"
 	"github.com/m3db/m3/src/cluster/kv"
+	kvutil "github.com/m3db/m3/src/cluster/kv/util"
 	"github.com/m3db/m3/src/cluster/placement"
 	"github.com/m3db/m3/src/cluster/services"
 	"github.com/m3db/m3/src/cluster/shard"
@@ -40,6 +43,7 @@ var (
 	errMissingShard              = errors.New("shard is missing")
 	errNotEnoughReplicasForShard = errors.New("replicas of shard is less than expected")
 	errInvalidTopology           = errors.New("could not parse latest value from config service")
+	errTopologyClosed            = errors.New("topology closed")
 )
 
 type dynamicInitializer struct {
@@ -104,6 +108,11 @@ type dynamicTopology struct {
 	logger    *zap.Logger
 }
 
+type dynamicWatchResult struct {
+	watch services.Watch
+	err   error
+}
+
 func newDynamicTopology(opts DynamicOptions) (DynamicTopology, error) {
 	services, err := opts.ConfigServiceClient().Services(opts.ServicesOverrideOptions())
 	if err != nil {
@@ -113,34 +122,109 @@ func newDynamicTopology(opts DynamicOptions) (DynamicTopology, error) {
 	logger := opts.InstrumentOptions().Logger()
 	logger.Info("waiting for dynamic topology initialization, " +
 		"if this takes a long time, make sure that a topology/placement is configured")
-	watch, err := services.Watch(opts.ServiceID(), opts.QueryOptions())
-	if err != nil {
-		return nil, err
-	}
-	<-watch.C()
-	logger.Info("initial topology / placement value received")
-
-	m, err := getMapFromUpdate(watch.Get(), opts.HashGen())
-	if err != nil {
-		logger.Error("dynamic topology received invalid initial value", zap.Error(err))
-		return nil, err
-	}
 
-	watchable := xwatch.NewWatchable()
-	watchable.Update(m)
+	// NB: Watch blocks until the initial value is received, so wait for it
+	// asynchronously so that the local cache can be used after a timeout.
+	watchCh := make(chan dynamicWatchResult, 1)
+	go func() {
+		watch, err := services.Watch(opts.ServiceID(), opts.QueryOptions())
+		if err == nil {
+			<-watch.C()
+		}
+		watchCh <- dynamicWatchResult{watch: watch, err: err}
+	}()
 
 	dt := &dynamicTopology{
 		opts:      opts,
 		services:  services,
-		watch:     watch,
-		watchable: watchable,
+		watchable: xwatch.NewWatchable(),
 		hashGen:   opts.HashGen(),
 		logger:    logger,
 	}
-	go dt.run()
+
+	cachePath := opts.LocalCachePath()
+	if cachePath == "" || opts.InitTimeout() <= 0 {
+		if err := dt.init(<-watchCh); err != nil {
+			return nil, err
+		}
+		go dt.run()
+		return dt, nil
+	}
+
+	select {
+	case result := <-watchCh:
+		if err := dt.init(result); err != nil {
+			return nil, err
+		}
+		go dt.run()
+		return dt, nil
+	case <-time.After(opts.InitTimeout()):
+	}
+
+	m, err := readCachedMap(cachePath, opts)
+	if err != nil {
+		logger.Warn("could not read topology local cache, waiting for config service",
+			zap.String("path", cachePath), zap.Error(err))
+		if err := dt.init(<-watchCh); err != nil {
+			return nil, err
+		}
+		go dt.run()
+		return dt, nil
+	}
+
+	logger.Warn("topology not received from config service within timeout, using local cache",
+		zap.String("path", cachePath))
+	dt.watchable.Update(m)
+	go func() {
+		// Reconcile with the config service once it becomes available.
+		if err := dt.init(<-watchCh); err != nil {
+			logger.Error("dynamic topology could not watch config service, using local cache",
+				zap.Error(err))
+			return
+		}
+		dt.run()
+	}()
 	return dt, nil
 }
 
+func (t *dynamicTopology) init(result dynamicWatchResult) error {
+	if result.err != nil {
+		return result.err
+	}
+	t.logger.Info("initial topology / placement value received")
+
+	if err := t.update(result.watch.Get()); err != nil {
+		t.logger.Error("dynamic topology received invalid initial value", zap.Error(err))
+		result.watch.Close()
+		return err
+	}
+
+	t.Lock()
+	defer t.Unlock()
+	if t.closed {
+		result.watch.Close()
+		return errTopologyClosed
+	}
+	t.watch = result.watch
+	return nil
+}
+
+func (t *dynamicTopology) update(data interface{}) error {
+	m, err := getMapFromUpdate(data, t.hashGen)
+	if err != nil {
+		return err
+	}
+	t.watchable.Update(m)
+
+	if cachePath := t.opts.LocalCachePath(); cachePath != "" {
+		if err := writeCachedService(cachePath, data.(services.Service)); err != nil {
+			t.logger.Warn("could not persist topology to local cache",
+				zap.String("path", cachePath), zap.Error(err))
+		}
+	}
+	return nil
+}
+
 func (t *dynamicTopology) isClosed() bool {
 	t.RLock()
 	closed := t.closed
@@ -155,12 +239,9 @@ func (t *dynamicTopology) run() {
 			break
 		}
 
-		m, err := getMapFromUpdate(t.watch.Get(), t.hashGen)
-		if err != nil {
+		if err := t.update(t.watch.Get()); err != nil {
 			t.logger.Warn("dynamic topology received invalid update", zap.Error(err))
-			continue
 		}
-		t.watchable.Update(m)
 	}
 }
 
@@ -186,7 +267,9 @@ func (t *dynamicTopology) Close() {
 
 	t.closed = true
 
-	t.watch.Close()
+	if t.watch != nil {
+		t.watch.Close()
+	}
 	t.watchable.Close()
 }
 
@@ -254,7 +337,8 @@ func getStaticOptions(service services.Service, hashGen sharding.HashGen) (Stati
 	return NewStaticOptions().
 		SetReplicas(replicas).
 		SetShardSet(allShardSet).
-		SetHostShardSets(hostShardSets), nil
+		SetHostShardSets(hostShardSets).
+		SetVersion(service.Version()), nil
 }
 
 func validateInstances(
@@ -289,3 +373,46 @@ func validateInstances(
 	}
 	return s, nil
 }
+
+// writeCachedService persists the placement of a service to the local cache.
+func writeCachedService(path string, service services.Service) error {
+	instances := make(map[string]*placementpb.Instance, len(service.Instances()))
+	for _, instance := range service.Instances() {
+		shards, err := instance.Shards().Proto()
+		if err != nil {
+			return err
+		}
+		instances[instance.InstanceID()] = &placementpb.Instance{
+			Id:       instance.InstanceID(),
+			Endpoint: instance.Endpoint(),
+			Shards:   shards,
+		}
+	}
+
+	return kvutil.PersistValue(path, service.Version(), &placementpb.Placement{
+		Instances:     instances,
+		ReplicaFactor: uint32(service.Replication().Replicas()),
+		NumShards:     uint32(service.Sharding().NumShards()),
+		IsSharded:     service.Sharding().IsSharded(),
+	})
+}
+
+// readCachedMap reads the topology map from the placement persisted to the
+// local cache.
+func readCachedMap(path string, opts DynamicOptions) (Map, error) {
+	value, err := kvutil.ReadPersistedValue(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var p placementpb.Placement
+	if err := value.Unmarshal(&p); err != nil {
+		return nil, err
+	}
+
+	service, err := services.NewServiceFromProto(&p, opts.ServiceID())
+	if err != nil {
+		return nil, err
+	}
+	return getMapFromUpdate(service.SetVersion(value.Version()), opts.HashGen())
+}
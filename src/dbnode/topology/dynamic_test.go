@@ -21,6 +21,9 @@
 package topology
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"sync"
 	"testing"
 	"time"
@@ -251,3 +254,108 @@ func goodInstances() []services.ServiceInstance {
 
 	return []services.ServiceInstance{i1, i2, i3}
 }
+
+type cacheTestWatch struct {
+	sync.RWMutex
+	ch   chan struct{}
+	data services.Service
+}
+
+func (w *cacheTestWatch) update(data services.Service) {
+	w.Lock()
+	w.data = data
+	w.Unlock()
+	w.ch <- struct{}{}
+}
+
+func (w *cacheTestWatch) Close() {}
+
+func (w *cacheTestWatch) Get() services.Service {
+	w.RLock()
+	defer w.RUnlock()
+	return w.data
+}
+
+func (w *cacheTestWatch) C() <-chan struct{} {
+	return w.ch
+}
+
+func cacheTestService(endpoint string, version int) services.Service {
+	instance := services.NewServiceInstance().
+		SetInstanceID("h1").
+		SetEndpoint(endpoint).
+		SetShards(shard.NewShards([]shard.Shard{
+			shard.NewShard(0).SetState(shard.Available),
+			shard.NewShard(1).SetState(shard.Available),
+		}))
+	return services.NewService().
+		SetReplication(services.NewServiceReplication().SetReplicas(1)).
+		SetSharding(services.NewServiceSharding().SetNumShards(2).SetIsSharded(true)).
+		SetInstances([]services.ServiceInstance{instance}).
+		SetVersion(version)
+}
+
+func cacheTestOptions(
+	ctrl *gomock.Controller,
+	watch services.Watch,
+	cachePath string,
+) DynamicOptions {
+	opts := NewDynamicOptions()
+	mockCSServices := services.NewMockServices(ctrl)
+	mockCSServices.EXPECT().Watch(opts.ServiceID(), opts.QueryOptions()).Return(watch, nil)
+
+	mockCSClient := client.NewMockClient(ctrl)
+	mockCSClient.EXPECT().Services(gomock.Any()).Return(mockCSServices, nil)
+	return opts.
+		SetConfigServiceClient(mockCSClient).
+		SetLocalCachePath(cachePath).
+		SetInitTimeout(10 * time.Millisecond)
+}
+
+func TestDynamicTopologyLocalCache(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "topology-cache")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+	cachePath := filepath.Join(dir, "placement.json")
+
+	// The placement received from the config service is persisted.
+	watch := &cacheTestWatch{ch: make(chan struct{}, 1)}
+	watch.update(cacheTestService("h1:9000", 3))
+	topo, err := newDynamicTopology(cacheTestOptions(ctrl, watch, cachePath))
+	require.NoError(t, err)
+	require.Equal(t, 1, topo.Get().HostsLen())
+	require.Equal(t, 3, topo.Get().Version())
+	topo.Close()
+
+	_, err = os.Stat(cachePath)
+	require.NoError(t, err)
+
+	// Without a placement from the config service the persisted placement
+	// is used until the config service becomes available.
+	watch = &cacheTestWatch{ch: make(chan struct{}, 1)}
+	topo, err = newDynamicTopology(cacheTestOptions(ctrl, watch, cachePath))
+	require.NoError(t, err)
+	defer topo.Close()
+
+	m := topo.Get()
+	require.Equal(t, 1, m.HostsLen())
+	require.Equal(t, 2, len(m.ShardSet().AllIDs()))
+	require.Equal(t, 3, m.Version())
+	host, ok := m.LookupHostShardSet("h1")
+	require.True(t, ok)
+	require.Equal(t, "h1:9000", host.Host().Address())
+
+	w, err := topo.Watch()
+	require.NoError(t, err)
+	<-w.C()
+
+	watch.update(cacheTestService("h1:9001", 4))
+	<-w.C()
+	host, ok = w.Get().LookupHostShardSet("h1")
+	require.True(t, ok)
+	require.Equal(t, "h1:9001", host.Host().Address())
+	require.Equal(t, 4, w.Get().Version())
+}
@@ -21,6 +21,7 @@
 package topology
 
 import (
+	"github.com/m3db/m3/src/cluster/shard"
 	"github.com/m3db/m3/src/dbnode/sharding"
 	"github.com/m3db/m3/src/x/ident"
 	xwatch "github.com/m3db/m3/src/x/watch"
@@ -35,6 +36,7 @@ type staticMap struct {
 	orderedHostsByShard [][]orderedHost
 	replicas            int
 	majority            int
+	version             int
 }
 
 // NewStaticMap creates a new static topology map
@@ -49,7 +51,8 @@ func NewStaticMap(opts StaticOptions) Map {
 		hostsByShard:        make([][]Host, totalShards),
 		orderedHostsByShard: make([][]orderedHost, totalShards),
 		replicas:            opts.Replicas(),
-		majority:            Majority(opts.Replicas()),
+		majority:            Majority(establishedReplicas(opts.Replicas(), hostShardSets)),
+		version:             opts.Version(),
 	}
 
 	for idx, hostShardSet := range hostShardSets {
@@ -68,6 +71,43 @@ func NewStaticMap(opts StaticOptions) Map {
 	return &topoMap
 }
 
+// establishedReplicas returns the number of replicas that can be relied upon
+// for consistency while the replica factor of the topology is being
+// increased. Replicas added by a replica factor change are initializing
+// without a source to stream from, and until every one of them is available
+// the majority keeps being computed from the replicas that existed before the
+// change. Shards whose replicas are all new, as with an initial placement, do
+// not lower the replicas since there is no prior replica factor to fall back to.
+func establishedReplicas(replicas int, hostShardSets []HostShardSet) int {
+	var (
+		totalByShard = make(map[uint32]int)
+		newByShard   = make(map[uint32]int)
+	)
+	for _, hostShardSet := range hostShardSets {
+		for _, s := range hostShardSet.ShardSet().All() {
+			switch s.State() {
+			case shard.Leaving:
+				// Leaving replicas are accounted for by the initializing
+				// replica taking over from them.
+				continue
+			case shard.Initializing:
+				if s.SourceID() == "" {
+					newByShard[s.ID()]++
+				}
+			}
+			totalByShard[s.ID()]++
+		}
+	}
+
+	established := replicas
+	for shardID, total := range totalByShard {
+		if n := total - newByShard[shardID]; n > 0 && n < established {
+			established = n
+		}
+	}
+	return established
+}
+
 type orderedHost struct {
 	idx  int
 	host Host
@@ -132,6 +172,10 @@ func (t *staticMap) MajorityReplicas() int {
 	return t.majority
 }
 
+func (t *staticMap) Version() int {
+	return t.version
+}
+
 type mapWatch struct {
 	xwatch.Watch
 }
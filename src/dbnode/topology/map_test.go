@@ -135,4 +135,60 @@ func TestStaticMap(t *testing.T) {
 
 	assert.Equal(t, 2, m.Replicas())
 	assert.Equal(t, 2, m.MajorityReplicas())
+	assert.Equal(t, 0, m.Version())
+}
+
+func TestStaticMapMajorityReplicasDuringReplicaFactorIncrease(t *testing.T) {
+	newShardSet := func(shards ...shard.Shard) sharding.ShardSet {
+		shardSet, err := sharding.NewShardSet(shards, sharding.DefaultHashFn(2))
+		require.NoError(t, err)
+		return shardSet
+	}
+	newMap := func(replicas int, newReplicaState shard.State) Map {
+		hostShardSets := []HostShardSet{
+			NewHostShardSet(NewHost("h1", "h1:9000"), newShardSet(
+				shard.NewShard(0).SetState(shard.Available),
+				shard.NewShard(1).SetState(shard.Available))),
+			NewHostShardSet(NewHost("h2", "h2:9000"), newShardSet(
+				shard.NewShard(0).SetState(newReplicaState),
+				shard.NewShard(1).SetState(newReplicaState))),
+		}
+		opts := NewStaticOptions().
+			SetShardSet(newTestShardSet(t, []uint32{0, 1}, sharding.DefaultHashFn(2))).
+			SetReplicas(replicas).
+			SetHostShardSets(hostShardSets)
+		require.NoError(t, opts.Validate())
+		return NewStaticMap(opts)
+	}
+
+	// The replica factor was increased from 1 to 2, the majority is
+	// computed from the single established replica until the new
+	// replica is available.
+	m := newMap(2, shard.Initializing)
+	assert.Equal(t, 2, m.Replicas())
+	assert.Equal(t, 1, m.MajorityReplicas())
+
+	m = newMap(2, shard.Available)
+	assert.Equal(t, 2, m.Replicas())
+	assert.Equal(t, 2, m.MajorityReplicas())
+}
+
+func TestStaticMapMajorityReplicasInitialPlacement(t *testing.T) {
+	var hostShardSets []HostShardSet
+	for _, id := range []string{"h1", "h2", "h3"} {
+		shardSet, err := sharding.NewShardSet(
+			sharding.NewShards([]uint32{0, 1}, shard.Initializing),
+			sharding.DefaultHashFn(2))
+		require.NoError(t, err)
+		hostShardSets = append(hostShardSets,
+			NewHostShardSet(NewHost(id, id+":9000"), shardSet))
+	}
+
+	opts := NewStaticOptions().
+		SetShardSet(newTestShardSet(t, []uint32{0, 1}, sharding.DefaultHashFn(2))).
+		SetReplicas(3).
+		SetHostShardSets(hostShardSets)
+
+	m := NewStaticMap(opts)
+	assert.Equal(t, 2, m.MajorityReplicas())
 }
@@ -23,6 +23,7 @@ package topology
 import (
 	"errors"
 	"fmt"
+	"net"
 	"time"
 
 	"github.com/m3db/m3/src/cluster/client"
@@ -35,18 +36,24 @@ const (
 	defaultServiceName = "m3db"
 	defaultInitTimeout = 0 // Wait indefinitely by default for topology
 	defaultReplicas    = 3
+
+	defaultDNSRefreshInterval = 30 * time.Second
 )
 
 var (
 	errNoConfigServiceClient = errors.New("no config service client")
 	errNoHashGen             = errors.New("no hash gen function defined")
 	errInvalidReplicas       = errors.New("replicas must be equal to or greater than 1")
+	errNoStaticOptions       = errors.New("no static options")
+	errNoLookupHostFn        = errors.New("no lookup host function defined")
+	errInvalidRefresh        = errors.New("dns refresh interval must be positive")
 )
 
 type staticOptions struct {
 	shardSet      sharding.ShardSet
 	replicas      int
 	hostShardSets []HostShardSet
+	version       int
 }
 
 // NewStaticOptions creates a new set of static topology options
@@ -116,6 +123,16 @@ func (o *staticOptions) HostShardSets() []HostShardSet {
 	return o.hostShardSets
 }
 
+func (o *staticOptions) SetVersion(value int) StaticOptions {
+	opts := *o
+	opts.version = value
+	return &opts
+}
+
+func (o *staticOptions) Version() int {
+	return o.version
+}
+
 type dynamicOptions struct {
 	configServiceClient     client.Client
 	serviceID               services.ServiceID
@@ -124,6 +141,7 @@ type dynamicOptions struct {
 	instrumentOptions       instrument.Options
 	initTimeout             time.Duration
 	hashGen                 sharding.HashGen
+	localCachePath          string
 }
 
 // NewDynamicOptions creates a new set of dynamic topology options
@@ -202,3 +220,90 @@ func (o *dynamicOptions) SetHashGen(h sharding.HashGen) DynamicOptions {
 func (o *dynamicOptions) HashGen() sharding.HashGen {
 	return o.hashGen
 }
+
+func (o *dynamicOptions) SetInitTimeout(value time.Duration) DynamicOptions {
+	o.initTimeout = value
+	return o
+}
+
+func (o *dynamicOptions) InitTimeout() time.Duration {
+	return o.initTimeout
+}
+
+func (o *dynamicOptions) SetLocalCachePath(value string) DynamicOptions {
+	o.localCachePath = value
+	return o
+}
+
+func (o *dynamicOptions) LocalCachePath() string {
+	return o.localCachePath
+}
+
+type dnsOptions struct {
+	staticOptions     StaticOptions
+	refreshInterval   time.Duration
+	lookupHostFn      LookupHostFn
+	instrumentOptions instrument.Options
+}
+
+// NewDNSOptions creates a new set of dns topology options
+func NewDNSOptions() DNSOptions {
+	return &dnsOptions{
+		refreshInterval:   defaultDNSRefreshInterval,
+		lookupHostFn:      net.LookupHost,
+		instrumentOptions: instrument.NewOptions(),
+	}
+}
+
+func (o *dnsOptions) Validate() error {
+	if o.staticOptions == nil {
+		return errNoStaticOptions
+	}
+	if o.lookupHostFn == nil {
+		return errNoLookupHostFn
+	}
+	if o.refreshInterval <= 0 {
+		return errInvalidRefresh
+	}
+	return o.staticOptions.Validate()
+}
+
+func (o *dnsOptions) SetStaticOptions(value StaticOptions) DNSOptions {
+	opts := *o
+	opts.staticOptions = value
+	return &opts
+}
+
+func (o *dnsOptions) StaticOptions() StaticOptions {
+	return o.staticOptions
+}
+
+func (o *dnsOptions) SetRefreshInterval(value time.Duration) DNSOptions {
+	opts := *o
+	opts.refreshInterval = value
+	return &opts
+}
+
+func (o *dnsOptions) RefreshInterval() time.Duration {
+	return o.refreshInterval
+}
+
+func (o *dnsOptions) SetLookupHostFn(value LookupHostFn) DNSOptions {
+	opts := *o
+	opts.lookupHostFn = value
+	return &opts
+}
+
+func (o *dnsOptions) LookupHostFn() LookupHostFn {
+	return o.lookupHostFn
+}
+
+func (o *dnsOptions) SetInstrumentOptions(value instrument.Options) DNSOptions {
+	opts := *o
+	opts.instrumentOptions = value
+	return &opts
+}
+
+func (o *dnsOptions) InstrumentOptions() instrument.Options {
+	return o.instrumentOptions
+}
@@ -26,6 +26,7 @@ package topology
 
 import (
 	"reflect"
+	"time"
 
 	"github.com/m3db/m3/src/cluster/client"
 	"github.com/m3db/m3/src/cluster/services"
@@ -87,6 +88,20 @@ func (mr *MockHostMockRecorder) Address() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Address", reflect.TypeOf((*MockHost)(nil).Address))
 }
 
+// IsolationGroup mocks base method
+func (m *MockHost) IsolationGroup() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IsolationGroup")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// IsolationGroup indicates an expected call of IsolationGroup
+func (mr *MockHostMockRecorder) IsolationGroup() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IsolationGroup", reflect.TypeOf((*MockHost)(nil).IsolationGroup))
+}
+
 // String mocks base method
 func (m *MockHost) String() string {
 	m.ctrl.T.Helper()
@@ -596,6 +611,20 @@ func (mr *MockMapMockRecorder) MajorityReplicas() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MajorityReplicas", reflect.TypeOf((*MockMap)(nil).MajorityReplicas))
 }
 
+// Version mocks base method
+func (m *MockMap) Version() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Version indicates an expected call of Version
+func (mr *MockMapMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockMap)(nil).Version))
+}
+
 // MockStaticOptions is a mock of StaticOptions interface
 type MockStaticOptions struct {
 	ctrl     *gomock.Controller
@@ -717,6 +746,34 @@ func (mr *MockStaticOptionsMockRecorder) HostShardSets() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HostShardSets", reflect.TypeOf((*MockStaticOptions)(nil).HostShardSets))
 }
 
+// SetVersion mocks base method
+func (m *MockStaticOptions) SetVersion(value int) StaticOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetVersion", value)
+	ret0, _ := ret[0].(StaticOptions)
+	return ret0
+}
+
+// SetVersion indicates an expected call of SetVersion
+func (mr *MockStaticOptionsMockRecorder) SetVersion(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetVersion", reflect.TypeOf((*MockStaticOptions)(nil).SetVersion), value)
+}
+
+// Version mocks base method
+func (m *MockStaticOptions) Version() int {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Version")
+	ret0, _ := ret[0].(int)
+	return ret0
+}
+
+// Version indicates an expected call of Version
+func (mr *MockStaticOptionsMockRecorder) Version() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockStaticOptions)(nil).Version))
+}
+
 // MockDynamicOptions is a mock of DynamicOptions interface
 type MockDynamicOptions struct {
 	ctrl     *gomock.Controller
@@ -922,6 +979,211 @@ func (mr *MockDynamicOptionsMockRecorder) HashGen() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashGen", reflect.TypeOf((*MockDynamicOptions)(nil).HashGen))
 }
 
+// SetInitTimeout mocks base method
+func (m *MockDynamicOptions) SetInitTimeout(value time.Duration) DynamicOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInitTimeout", value)
+	ret0, _ := ret[0].(DynamicOptions)
+	return ret0
+}
+
+// SetInitTimeout indicates an expected call of SetInitTimeout
+func (mr *MockDynamicOptionsMockRecorder) SetInitTimeout(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInitTimeout", reflect.TypeOf((*MockDynamicOptions)(nil).SetInitTimeout), value)
+}
+
+// InitTimeout mocks base method
+func (m *MockDynamicOptions) InitTimeout() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InitTimeout")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// InitTimeout indicates an expected call of InitTimeout
+func (mr *MockDynamicOptionsMockRecorder) InitTimeout() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InitTimeout", reflect.TypeOf((*MockDynamicOptions)(nil).InitTimeout))
+}
+
+// SetLocalCachePath mocks base method
+func (m *MockDynamicOptions) SetLocalCachePath(value string) DynamicOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLocalCachePath", value)
+	ret0, _ := ret[0].(DynamicOptions)
+	return ret0
+}
+
+// SetLocalCachePath indicates an expected call of SetLocalCachePath
+func (mr *MockDynamicOptionsMockRecorder) SetLocalCachePath(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLocalCachePath", reflect.TypeOf((*MockDynamicOptions)(nil).SetLocalCachePath), value)
+}
+
+// LocalCachePath mocks base method
+func (m *MockDynamicOptions) LocalCachePath() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LocalCachePath")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// LocalCachePath indicates an expected call of LocalCachePath
+func (mr *MockDynamicOptionsMockRecorder) LocalCachePath() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LocalCachePath", reflect.TypeOf((*MockDynamicOptions)(nil).LocalCachePath))
+}
+
+// MockDNSOptions is a mock of DNSOptions interface
+type MockDNSOptions struct {
+	ctrl     *gomock.Controller
+	recorder *MockDNSOptionsMockRecorder
+}
+
+// MockDNSOptionsMockRecorder is the mock recorder for MockDNSOptions
+type MockDNSOptionsMockRecorder struct {
+	mock *MockDNSOptions
+}
+
+// NewMockDNSOptions creates a new mock instance
+func NewMockDNSOptions(ctrl *gomock.Controller) *MockDNSOptions {
+	mock := &MockDNSOptions{ctrl: ctrl}
+	mock.recorder = &MockDNSOptionsMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDNSOptions) EXPECT() *MockDNSOptionsMockRecorder {
+	return m.recorder
+}
+
+// Validate mocks base method
+func (m *MockDNSOptions) Validate() error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Validate")
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Validate indicates an expected call of Validate
+func (mr *MockDNSOptionsMockRecorder) Validate() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockDNSOptions)(nil).Validate))
+}
+
+// SetStaticOptions mocks base method
+func (m *MockDNSOptions) SetStaticOptions(value StaticOptions) DNSOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetStaticOptions", value)
+	ret0, _ := ret[0].(DNSOptions)
+	return ret0
+}
+
+// SetStaticOptions indicates an expected call of SetStaticOptions
+func (mr *MockDNSOptionsMockRecorder) SetStaticOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetStaticOptions", reflect.TypeOf((*MockDNSOptions)(nil).SetStaticOptions), value)
+}
+
+// StaticOptions mocks base method
+func (m *MockDNSOptions) StaticOptions() StaticOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StaticOptions")
+	ret0, _ := ret[0].(StaticOptions)
+	return ret0
+}
+
+// StaticOptions indicates an expected call of StaticOptions
+func (mr *MockDNSOptionsMockRecorder) StaticOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StaticOptions", reflect.TypeOf((*MockDNSOptions)(nil).StaticOptions))
+}
+
+// SetRefreshInterval mocks base method
+func (m *MockDNSOptions) SetRefreshInterval(value time.Duration) DNSOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRefreshInterval", value)
+	ret0, _ := ret[0].(DNSOptions)
+	return ret0
+}
+
+// SetRefreshInterval indicates an expected call of SetRefreshInterval
+func (mr *MockDNSOptionsMockRecorder) SetRefreshInterval(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRefreshInterval", reflect.TypeOf((*MockDNSOptions)(nil).SetRefreshInterval), value)
+}
+
+// RefreshInterval mocks base method
+func (m *MockDNSOptions) RefreshInterval() time.Duration {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RefreshInterval")
+	ret0, _ := ret[0].(time.Duration)
+	return ret0
+}
+
+// RefreshInterval indicates an expected call of RefreshInterval
+func (mr *MockDNSOptionsMockRecorder) RefreshInterval() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RefreshInterval", reflect.TypeOf((*MockDNSOptions)(nil).RefreshInterval))
+}
+
+// SetLookupHostFn mocks base method
+func (m *MockDNSOptions) SetLookupHostFn(value LookupHostFn) DNSOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetLookupHostFn", value)
+	ret0, _ := ret[0].(DNSOptions)
+	return ret0
+}
+
+// SetLookupHostFn indicates an expected call of SetLookupHostFn
+func (mr *MockDNSOptionsMockRecorder) SetLookupHostFn(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetLookupHostFn", reflect.TypeOf((*MockDNSOptions)(nil).SetLookupHostFn), value)
+}
+
+// LookupHostFn mocks base method
+func (m *MockDNSOptions) LookupHostFn() LookupHostFn {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "LookupHostFn")
+	ret0, _ := ret[0].(LookupHostFn)
+	return ret0
+}
+
+// LookupHostFn indicates an expected call of LookupHostFn
+func (mr *MockDNSOptionsMockRecorder) LookupHostFn() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "LookupHostFn", reflect.TypeOf((*MockDNSOptions)(nil).LookupHostFn))
+}
+
+// SetInstrumentOptions mocks base method
+func (m *MockDNSOptions) SetInstrumentOptions(value instrument.Options) DNSOptions {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetInstrumentOptions", value)
+	ret0, _ := ret[0].(DNSOptions)
+	return ret0
+}
+
+// SetInstrumentOptions indicates an expected call of SetInstrumentOptions
+func (mr *MockDNSOptionsMockRecorder) SetInstrumentOptions(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetInstrumentOptions", reflect.TypeOf((*MockDNSOptions)(nil).SetInstrumentOptions), value)
+}
+
+// InstrumentOptions mocks base method
+func (m *MockDNSOptions) InstrumentOptions() instrument.Options {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InstrumentOptions")
+	ret0, _ := ret[0].(instrument.Options)
+	return ret0
+}
+
+// InstrumentOptions indicates an expected call of InstrumentOptions
+func (mr *MockDNSOptionsMockRecorder) InstrumentOptions() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InstrumentOptions", reflect.TypeOf((*MockDNSOptions)(nil).InstrumentOptions))
+}
+
 // MockMapProvider is a mock of MapProvider interface
 type MockMapProvider struct {
 	ctrl     *gomock.Controller
@@ -21,6 +21,8 @@
 package topology
 
 import (
+	"time"
+
 	"github.com/m3db/m3/src/cluster/client"
 	"github.com/m3db/m3/src/cluster/services"
 	"github.com/m3db/m3/src/cluster/shard"
@@ -37,6 +39,10 @@ type Host interface {
 	// Address returns the address of the host
 	Address() string
 
+	// IsolationGroup returns the isolation group of the host, typically the
+	// availability zone it runs in, or empty if unknown
+	IsolationGroup() string
+
 	// String returns a string representation of the host
 	String() string
 }
@@ -129,8 +135,14 @@ type Map interface {
 	// Replicas returns the number of replicas in the topology
 	Replicas() int
 
-	// MajorityReplicas returns the number of replicas to establish majority in the topology
+	// MajorityReplicas returns the number of replicas to establish majority in the topology,
+	// while the replica factor is being increased it is computed from the replicas
+	// that existed before the change until all of the new replicas are available.
 	MajorityReplicas() int
+
+	// Version returns the version of the placement the topology was built
+	// from, zero if the topology is not versioned (i.e. a static topology)
+	Version() int
 }
 
 // RouteForEachFn is a function to execute for each routed to host
@@ -141,12 +153,31 @@ type StaticConfiguration struct {
 	Shards   int               `yaml:"shards"`
 	Replicas int               `yaml:"replicas"`
 	Hosts    []HostShardConfig `yaml:"hosts"`
+
+	// DNS when set periodically re-resolves the host names of the listen
+	// addresses so that hosts can move without a placement stored in etcd.
+	DNS *DNSConfiguration `yaml:"dns"`
 }
 
 // HostShardConfig stores host information for fanout
 type HostShardConfig struct {
 	HostID        string `yaml:"hostID"`
 	ListenAddress string `yaml:"listenAddress"`
+
+	// Shards are the shards assigned to the host, if empty the host
+	// is assigned all shards.
+	Shards []uint32 `yaml:"shards"`
+
+	// IsolationGroup is the isolation group of the host, typically the
+	// availability zone it runs in.
+	IsolationGroup string `yaml:"isolationGroup"`
+}
+
+// DNSConfiguration is the configuration for re-resolving the host
+// names of a static topology.
+type DNSConfiguration struct {
+	// RefreshInterval is how often to re-resolve the host names.
+	RefreshInterval time.Duration `yaml:"refreshInterval"`
 }
 
 // StaticOptions is a set of options for static topology
@@ -171,6 +202,12 @@ type StaticOptions interface {
 
 	// HostShardSets returns the hostShardSets
 	HostShardSets() []HostShardSet
+
+	// SetVersion sets the version of the placement the topology is built from
+	SetVersion(value int) StaticOptions
+
+	// Version returns the version of the placement the topology is built from
+	Version() int
 }
 
 // DynamicOptions is a set of options for dynamic topology
@@ -213,6 +250,60 @@ type DynamicOptions interface {
 
 	// HashGen returns HashGen function
 	HashGen() sharding.HashGen
+
+	// SetInitTimeout sets how long to wait for the initial placement from
+	// the config service before falling back to the local cache, zero
+	// waits indefinitely
+	SetInitTimeout(value time.Duration) DynamicOptions
+
+	// InitTimeout returns how long to wait for the initial placement from
+	// the config service before falling back to the local cache
+	InitTimeout() time.Duration
+
+	// SetLocalCachePath sets the path of the file the last received placement
+	// is persisted to, which is used if the config service is unavailable
+	// on start up
+	SetLocalCachePath(value string) DynamicOptions
+
+	// LocalCachePath returns the path of the file the last received placement
+	// is persisted to
+	LocalCachePath() string
+}
+
+// LookupHostFn resolves a host name to its addresses.
+type LookupHostFn func(host string) ([]string, error)
+
+// DNSOptions is a set of options for a static topology that periodically
+// re-resolves the host names of its host addresses
+type DNSOptions interface {
+	// Validate validates the options
+	Validate() error
+
+	// SetStaticOptions sets the static options that hold the hosts and
+	// their shard assignments
+	SetStaticOptions(value StaticOptions) DNSOptions
+
+	// StaticOptions returns the static options that hold the hosts and
+	// their shard assignments
+	StaticOptions() StaticOptions
+
+	// SetRefreshInterval sets the interval to re-resolve host names at
+	SetRefreshInterval(value time.Duration) DNSOptions
+
+	// RefreshInterval returns the interval to re-resolve host names at
+	RefreshInterval() time.Duration
+
+	// SetLookupHostFn sets the function used to resolve host names
+	SetLookupHostFn(value LookupHostFn) DNSOptions
+
+	// LookupHostFn returns the function used to resolve host names
+	LookupHostFn() LookupHostFn
+
+	// SetInstrumentOptions sets the instrumentation options
+	SetInstrumentOptions(value instrument.Options) DNSOptions
+
+	// InstrumentOptions returns the instrumentation options
+	InstrumentOptions() instrument.Options
 }
 
 // MapProvider is an interface that can provide
@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ts
+
+import (
+	"errors"
+	"fmt"
+)
+
+var errDurabilityUnspecified = errors.New("durability unspecified")
+
+// Durability is the durability class a write is acknowledged with, ordered
+// from weakest to strongest so that the strongest of several requested
+// classes can be selected by comparison.
+type Durability uint
+
+const (
+	// DurabilityDefault acknowledges writes with the durability implied by the
+	// commit log strategy.
+	DurabilityDefault Durability = iota
+	// DurabilityMemory acknowledges writes once they have been applied in
+	// memory and enqueued to the commit log.
+	DurabilityMemory
+	// DurabilityCommitLogBuffer acknowledges writes once the commit log buffer
+	// they were written to has been flushed to the commit log file.
+	DurabilityCommitLogBuffer
+	// DurabilityFsync acknowledges writes once the commit log file they were
+	// written to has been fsynced.
+	DurabilityFsync
+)
+
+// ValidDurabilities returns the valid durabilities.
+func ValidDurabilities() []Durability {
+	return []Durability{
+		DurabilityDefault,
+		DurabilityMemory,
+		DurabilityCommitLogBuffer,
+		DurabilityFsync,
+	}
+}
+
+func (d Durability) String() string {
+	switch d {
+	case DurabilityDefault:
+		return "default"
+	case DurabilityMemory:
+		return "ack-on-memory"
+	case DurabilityCommitLogBuffer:
+		return "ack-on-commitlog-buffer"
+	case DurabilityFsync:
+		return "ack-on-fsync"
+	}
+	return "unknown"
+}
+
+// Max returns the stronger of the durability and the other durability.
+func (d Durability) Max(other Durability) Durability {
+	if other > d {
+		return other
+	}
+	return d
+}
+
+// ParseDurability parses a Durability from a string.
+func ParseDurability(str string) (Durability, error) {
+	var r Durability
+	if str == "" {
+		return r, errDurabilityUnspecified
+	}
+	for _, valid := range ValidDurabilities() {
+		if str == valid.String() {
+			r = valid
+			return r, nil
+		}
+	}
+	return r, fmt.Errorf("invalid Durability '%s' valid types are: %v",
+		str, ValidDurabilities())
+}
+
+// UnmarshalYAML unmarshals a Durability into a valid type from string.
+func (d *Durability) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	r, err := ParseDurability(str)
+	if err != nil {
+		return err
+	}
+	*d = r
+	return nil
+}
@@ -0,0 +1,58 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package ts
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestParseDurability(t *testing.T) {
+	for _, valid := range ValidDurabilities() {
+		durability, err := ParseDurability(valid.String())
+		require.NoError(t, err)
+		require.Equal(t, valid, durability)
+	}
+
+	_, err := ParseDurability("")
+	require.Equal(t, errDurabilityUnspecified, err)
+	_, err = ParseDurability("ack-on-disk")
+	require.Error(t, err)
+}
+
+func TestDurabilityMax(t *testing.T) {
+	require.Equal(t, DurabilityFsync, DurabilityMemory.Max(DurabilityFsync))
+	require.Equal(t, DurabilityFsync, DurabilityFsync.Max(DurabilityCommitLogBuffer))
+	require.Equal(t, DurabilityMemory, DurabilityDefault.Max(DurabilityMemory))
+	require.Equal(t, DurabilityDefault, DurabilityDefault.Max(DurabilityDefault))
+}
+
+func TestDurabilityUnmarshalYAML(t *testing.T) {
+	var cfg struct {
+		Durability Durability `yaml:"durability"`
+	}
+	require.NoError(t, yaml.Unmarshal([]byte("durability: ack-on-fsync\n"), &cfg))
+	require.Equal(t, DurabilityFsync, cfg.Durability)
+
+	require.Error(t, yaml.Unmarshal([]byte("durability: fsync\n"), &cfg))
+}
@@ -118,6 +118,11 @@ type WriteBatch interface {
 	Iter() []BatchWrite
 	SetOutcome(idx int, series Series, err error)
 	SetSkipWrite(idx int)
+	// SetTimestamp sets the timestamp the write at the given index was
+	// applied at, if different from the timestamp it was added with.
+	SetTimestamp(idx int, timestamp time.Time)
+	// Durability returns the durability class the batch is acknowledged with.
+	Durability() Durability
 	Reset(batchSize int, ns ident.ID)
 	Finalize()
 
@@ -152,4 +157,7 @@ type BatchWriter interface {
 	SetFinalizeEncodedTagsFn(f FinalizeEncodedTagsFn)
 
 	SetFinalizeAnnotationFn(f FinalizeAnnotationFn)
+
+	// SetDurability sets the durability class the batch is acknowledged with.
+	SetDurability(value Durability)
 }
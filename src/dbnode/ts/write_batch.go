@@ -44,6 +44,7 @@ type writeBatch struct {
 	// writeBatch itself gets finalized.
 	finalizeAnnotationFn FinalizeAnnotationFn
 	finalizeFn           func(WriteBatch)
+	durability           Durability
 }
 
 // NewWriteBatch creates a new WriteBatch.
@@ -110,6 +111,7 @@ func (b *writeBatch) Reset(
 	b.ns = ns
 	b.finalizeEncodedTagsFn = nil
 	b.finalizeAnnotationFn = nil
+	b.durability = DurabilityDefault
 }
 
 func (b *writeBatch) Iter() []BatchWrite {
@@ -119,8 +121,11 @@ func (b *writeBatch) Iter() []BatchWrite {
 func (b *writeBatch) SetOutcome(idx int, series Series, err error) {
 	b.writes[idx].SkipWrite = false
 	b.writes[idx].Write.Series = series
-	// Make sure that the EncodedTags does not get clobbered
-	b.writes[idx].Write.Series.EncodedTags = b.writes[idx].EncodedTags
+	// Make sure that the EncodedTags does not get clobbered, unless the
+	// series was written with different tags than it was added with.
+	if series.EncodedTags == nil {
+		b.writes[idx].Write.Series.EncodedTags = b.writes[idx].EncodedTags
+	}
 	b.writes[idx].Err = err
 }
 
@@ -128,6 +133,18 @@ func (b *writeBatch) SetSkipWrite(idx int) {
 	b.writes[idx].SkipWrite = true
 }
 
+func (b *writeBatch) SetTimestamp(idx int, timestamp time.Time) {
+	b.writes[idx].Write.Datapoint.Timestamp = timestamp
+}
+
+func (b *writeBatch) SetDurability(value Durability) {
+	b.durability = value
+}
+
+func (b *writeBatch) Durability() Durability {
+	return b.durability
+}
+
 // Set the function that will be called to finalize annotations when a WriteBatch
 // is finalized, allowing the caller to pool them.
 func (b *writeBatch) SetFinalizeEncodedTagsFn(f FinalizeEncodedTagsFn) {
@@ -166,6 +183,7 @@ func (b *writeBatch) Finalize() {
 	b.finalizeAnnotationFn = nil
 
 	b.ns = nil
+	b.durability = DurabilityDefault
 
 	var zeroedWrite BatchWrite
 	for i := range b.writes {
@@ -109,6 +109,18 @@ func (mr *MockWriteBatchMockRecorder) SetFinalizeAnnotationFn(f interface{}) *go
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFinalizeAnnotationFn", reflect.TypeOf((*MockWriteBatch)(nil).SetFinalizeAnnotationFn), f)
 }
 
+// SetDurability mocks base method
+func (m *MockWriteBatch) SetDurability(value Durability) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDurability", value)
+}
+
+// SetDurability indicates an expected call of SetDurability
+func (mr *MockWriteBatchMockRecorder) SetDurability(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDurability", reflect.TypeOf((*MockWriteBatch)(nil).SetDurability), value)
+}
+
 // Iter mocks base method
 func (m *MockWriteBatch) Iter() []BatchWrite {
 	m.ctrl.T.Helper()
@@ -147,6 +159,32 @@ func (mr *MockWriteBatchMockRecorder) SetSkipWrite(idx interface{}) *gomock.Call
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetSkipWrite", reflect.TypeOf((*MockWriteBatch)(nil).SetSkipWrite), idx)
 }
 
+// SetTimestamp mocks base method
+func (m *MockWriteBatch) SetTimestamp(idx int, timestamp time.Time) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetTimestamp", idx, timestamp)
+}
+
+// SetTimestamp indicates an expected call of SetTimestamp
+func (mr *MockWriteBatchMockRecorder) SetTimestamp(idx, timestamp interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetTimestamp", reflect.TypeOf((*MockWriteBatch)(nil).SetTimestamp), idx, timestamp)
+}
+
+// Durability mocks base method
+func (m *MockWriteBatch) Durability() Durability {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Durability")
+	ret0, _ := ret[0].(Durability)
+	return ret0
+}
+
+// Durability indicates an expected call of Durability
+func (mr *MockWriteBatchMockRecorder) Durability() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Durability", reflect.TypeOf((*MockWriteBatch)(nil).Durability))
+}
+
 // Reset mocks base method
 func (m *MockWriteBatch) Reset(batchSize int, ns ident.ID) {
 	m.ctrl.T.Helper()
@@ -259,3 +297,15 @@ func (mr *MockBatchWriterMockRecorder) SetFinalizeAnnotationFn(f interface{}) *g
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFinalizeAnnotationFn", reflect.TypeOf((*MockBatchWriter)(nil).SetFinalizeAnnotationFn), f)
 }
+
+// SetDurability mocks base method
+func (m *MockBatchWriter) SetDurability(value Durability) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetDurability", value)
+}
+
+// SetDurability indicates an expected call of SetDurability
+func (mr *MockBatchWriterMockRecorder) SetDurability(value interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetDurability", reflect.TypeOf((*MockBatchWriter)(nil).SetDurability), value)
+}
@@ -216,6 +216,32 @@ func TestBatchWriterSetSeries(t *testing.T) {
 	}
 }
 
+func TestBatchWriterSetOutcomeWrittenEncodedTags(t *testing.T) {
+	writeBatch := NewWriteBatch(batchSize, namespace, nil)
+
+	for i, write := range writes {
+		writeBatch.AddTagged(
+			i,
+			write.id,
+			write.tagIter,
+			write.encodedTags(t).Bytes(),
+			write.timestamp,
+			write.value,
+			write.unit,
+			write.annotation)
+	}
+
+	// Series written with different tags than they were added with keep the
+	// encoded tags they were written with.
+	written := EncodedTags("written")
+	writeBatch.SetOutcome(0, Series{ID: ident.StringID("0"), EncodedTags: written}, nil)
+	writeBatch.SetOutcome(1, Series{ID: ident.StringID("1")}, nil)
+
+	iter := writeBatch.Iter()
+	require.Equal(t, written, iter[0].Write.Series.EncodedTags)
+	require.Equal(t, iter[1].EncodedTags, iter[1].Write.Series.EncodedTags)
+}
+
 func TestWriteBatchReset(t *testing.T) {
 	var (
 		numResets  = 10
@@ -239,6 +265,17 @@ func TestWriteBatchReset(t *testing.T) {
 	}
 }
 
+func TestWriteBatchDurability(t *testing.T) {
+	writeBatch := NewWriteBatch(batchSize, namespace, nil)
+	require.Equal(t, DurabilityDefault, writeBatch.Durability())
+
+	writeBatch.SetDurability(DurabilityFsync)
+	require.Equal(t, DurabilityFsync, writeBatch.Durability())
+
+	writeBatch.Reset(batchSize, namespace)
+	require.Equal(t, DurabilityDefault, writeBatch.Durability())
+}
+
 func assertDataPresent(t *testing.T, writes []testWrite, batchWriter WriteBatch) {
 	for _, write := range writes {
 		var (
@@ -37,6 +37,8 @@ func (b BlockReader) CloneBlock(pool pool.CheckedBytesPool) (BlockReader, error)
 		SegmentReader: sr,
 		Start:         b.Start,
 		BlockSize:     b.BlockSize,
+		Source:        b.Source,
+		LastWrite:     b.LastWrite,
 	}, nil
 }
 
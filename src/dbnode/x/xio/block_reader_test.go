@@ -67,6 +67,8 @@ func TestCloneBlock(t *testing.T) {
 		SegmentReader: reader,
 		Start:         start,
 		BlockSize:     blockSize,
+		Source:        BlockSourceMemory,
+		LastWrite:     start.Add(time.Minute),
 	}
 
 	read, err := b.Read(p)
@@ -89,6 +91,8 @@ func TestCloneBlock(t *testing.T) {
 
 	require.Equal(t, b2.Start, start)
 	require.Equal(t, b2.BlockSize, blockSize)
+	require.Equal(t, BlockSourceMemory, b2.Source)
+	require.Equal(t, start.Add(time.Minute), b2.LastWrite)
 
 	read, err = b2.Read(p)
 
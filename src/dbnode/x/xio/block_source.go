@@ -0,0 +1,52 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xio
+
+// BlockSource is where the data of a block reader was read from.
+type BlockSource int
+
+const (
+	// BlockSourceUnknown is the source of block readers not read from a
+	// series, such as those decoded from a remote response.
+	BlockSourceUnknown BlockSource = iota
+	// BlockSourceMemory is the source of unflushed writes held in the
+	// series buffer.
+	BlockSourceMemory
+	// BlockSourceDisk is the source of flushed blocks, whether retrieved
+	// from a fileset or cached in memory after being retrieved.
+	BlockSourceDisk
+	// BlockSourcePeer is the source of blocks streamed from peers, such as
+	// by repairs, and loaded into the series buffer until flushed.
+	BlockSourcePeer
+)
+
+// String returns the block source as a string.
+func (s BlockSource) String() string {
+	switch s {
+	case BlockSourceMemory:
+		return "memory"
+	case BlockSourceDisk:
+		return "disk"
+	case BlockSourcePeer:
+		return "peer"
+	}
+	return "unknown"
+}
@@ -0,0 +1,51 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package xio
+
+import (
+	stdctx "context"
+
+	"github.com/m3db/m3/src/x/context"
+)
+
+type sequentialReadsKey struct{}
+
+// WithSequentialReads marks the reads made with the context as sequential
+// scans of full series, such as for an export, so that data files can be
+// read ahead of the data requested rather than read only what was requested.
+func WithSequentialReads(ctx context.Context) {
+	goCtx, ok := ctx.GoContext()
+	if !ok {
+		goCtx = stdctx.Background()
+	}
+	ctx.SetGoContext(stdctx.WithValue(goCtx, sequentialReadsKey{}, true))
+}
+
+// SequentialReads returns whether the reads made with the context are
+// marked as sequential scans of full series.
+func SequentialReads(ctx context.Context) bool {
+	goCtx, ok := ctx.GoContext()
+	if !ok {
+		return false
+	}
+	sequential, _ := goCtx.Value(sequentialReadsKey{}).(bool)
+	return sequential
+}
@@ -34,6 +34,11 @@ type BlockReader struct {
 	SegmentReader
 	Start     time.Time
 	BlockSize time.Duration
+	// Source is where the block was read from.
+	Source BlockSource
+	// LastWrite is the time of the last write to the block, it is only
+	// known for unflushed writes held in memory and is zero otherwise.
+	LastWrite time.Time
 }
 
 // EmptyBlockReader represents the default block reader.
@@ -35,6 +35,7 @@ type builderFromSegments struct {
 	docs           []doc.Document
 	idSet          *IDsMap
 	segments       []segmentMetadata
+	filter         segment.DocumentsFilter
 	termsIter      *termsIterFromSegments
 	offset         postings.ID
 	segmentsOffset postings.ID
@@ -45,8 +46,9 @@ type segmentMetadata struct {
 	offset  postings.ID
 	// duplicatesAsc is a lookup of document IDs are duplicates
 	// in this segment, that is documents that are already
-	// contained by other segments and hence should not be
-	// returned when looking up documents.
+	// contained by other segments or that were skipped by the
+	// filter and hence should not be returned when looking up
+	// documents.
 	duplicatesAsc []postings.ID
 }
 
@@ -73,6 +75,9 @@ func (b *builderFromSegments) Reset(offset postings.ID) {
 	// Reset all entries in ID set
 	b.idSet.Reset()
 
+	// Reset the filter
+	b.filter = nil
+
 	// Reset the segments metadata
 	b.segmentsOffset = 0
 	var emptySegment segmentMetadata
@@ -84,6 +89,10 @@ func (b *builderFromSegments) Reset(offset postings.ID) {
 	b.termsIter.clear()
 }
 
+func (b *builderFromSegments) SetFilter(filter segment.DocumentsFilter) {
+	b.filter = filter
+}
+
 func (b *builderFromSegments) AddSegments(segments []segment.Segment) error {
 	// numMaxDocs can sometimes be larger than the actual number of documents
 	// since some are duplicates
@@ -115,6 +124,12 @@ func (b *builderFromSegments) AddSegments(segments []segment.Segment) error {
 				duplicates = append(duplicates, iter.PostingsID())
 				continue
 			}
+			if b.filter != nil && !b.filter.Contains(d) {
+				// Skipped documents are treated the same as duplicates
+				// so that they are removed from the postings lists.
+				duplicates = append(duplicates, iter.PostingsID())
+				continue
+			}
 			b.idSet.SetUnsafe(d.ID, struct{}{}, IDsMapSetUnsafeOptions{
 				NoCopyKey:     true,
 				NoFinalizeKey: true,
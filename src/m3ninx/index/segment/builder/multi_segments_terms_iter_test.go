@@ -91,6 +91,73 @@ func TestTermsIterFromSegmentsDeduplicates(t *testing.T) {
 	})
 }
 
+type testDocumentsFilter func(d doc.Document) bool
+
+func (f testDocumentsFilter) Contains(d doc.Document) bool {
+	return f(d)
+}
+
+func TestTermsIterFromSegmentsSkipsFilteredDocuments(t *testing.T) {
+	segments := []segment.Segment{
+		newTestSegmentWithDocs(t, []doc.Document{
+			{
+				ID: []byte("foo"),
+				Fields: []doc.Field{
+					{Name: []byte("fruit"), Value: []byte("banana")},
+				},
+			},
+			{
+				ID: []byte("bar"),
+				Fields: []doc.Field{
+					{Name: []byte("fruit"), Value: []byte("apple")},
+				},
+			},
+		}),
+		newTestSegmentWithDocs(t, []doc.Document{
+			{
+				ID: []byte("baz"),
+				Fields: []doc.Field{
+					{Name: []byte("fruit"), Value: []byte("apple")},
+				},
+			},
+			{
+				ID: []byte("qux"),
+				Fields: []doc.Field{
+					{Name: []byte("fruit"), Value: []byte("watermelon")},
+				},
+			},
+			{
+				ID: []byte("quux"),
+				Fields: []doc.Field{
+					{Name: []byte("fruit"), Value: []byte("watermelon")},
+				},
+			},
+		}),
+	}
+
+	builder := NewBuilderFromSegments(testOptions)
+	builder.Reset(0)
+	builder.SetFilter(testDocumentsFilter(func(d doc.Document) bool {
+		id := string(d.ID)
+		return id != "foo" && id != "qux"
+	}))
+	require.NoError(t, builder.AddSegments(segments))
+	require.Equal(t, 3, len(builder.Docs()))
+
+	iter, err := builder.Terms([]byte("fruit"))
+	require.NoError(t, err)
+
+	assertTermsPostings(t, builder.Docs(), iter, termPostings{
+		"apple":      []int{0, 1},
+		"watermelon": []int{2},
+	})
+
+	// Reset clears the filter.
+	builder.Reset(0)
+	require.NoError(t, builder.AddSegments(segments))
+	require.Equal(t, 5, len(builder.Docs()))
+}
+
 func assertTermsPostings(
 	t *testing.T,
 	docs []doc.Document,
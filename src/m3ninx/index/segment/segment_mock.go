@@ -1093,6 +1093,43 @@ func (mr *MockDocumentsBuilderMockRecorder) InsertBatch(b interface{}) *gomock.C
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertBatch", reflect.TypeOf((*MockDocumentsBuilder)(nil).InsertBatch), b)
 }
 
+// MockDocumentsFilter is a mock of DocumentsFilter interface
+type MockDocumentsFilter struct {
+	ctrl     *gomock.Controller
+	recorder *MockDocumentsFilterMockRecorder
+}
+
+// MockDocumentsFilterMockRecorder is the mock recorder for MockDocumentsFilter
+type MockDocumentsFilterMockRecorder struct {
+	mock *MockDocumentsFilter
+}
+
+// NewMockDocumentsFilter creates a new mock instance
+func NewMockDocumentsFilter(ctrl *gomock.Controller) *MockDocumentsFilter {
+	mock := &MockDocumentsFilter{ctrl: ctrl}
+	mock.recorder = &MockDocumentsFilterMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use
+func (m *MockDocumentsFilter) EXPECT() *MockDocumentsFilterMockRecorder {
+	return m.recorder
+}
+
+// Contains mocks base method
+func (m *MockDocumentsFilter) Contains(d doc.Document) bool {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Contains", d)
+	ret0, _ := ret[0].(bool)
+	return ret0
+}
+
+// Contains indicates an expected call of Contains
+func (mr *MockDocumentsFilterMockRecorder) Contains(d interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Contains", reflect.TypeOf((*MockDocumentsFilter)(nil).Contains), d)
+}
+
 // MockSegmentsBuilder is a mock of SegmentsBuilder interface
 type MockSegmentsBuilder struct {
 	ctrl     *gomock.Controller
@@ -1187,6 +1224,18 @@ func (mr *MockSegmentsBuilderMockRecorder) AllDocs() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AllDocs", reflect.TypeOf((*MockSegmentsBuilder)(nil).AllDocs))
 }
 
+// SetFilter mocks base method
+func (m *MockSegmentsBuilder) SetFilter(filter DocumentsFilter) {
+	m.ctrl.T.Helper()
+	m.ctrl.Call(m, "SetFilter", filter)
+}
+
+// SetFilter indicates an expected call of SetFilter
+func (mr *MockSegmentsBuilderMockRecorder) SetFilter(filter interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetFilter", reflect.TypeOf((*MockSegmentsBuilder)(nil).SetFilter), filter)
+}
+
 // AddSegments mocks base method
 func (m *MockSegmentsBuilder) AddSegments(segments []Segment) error {
 	m.ctrl.T.Helper()
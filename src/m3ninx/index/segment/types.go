@@ -173,10 +173,21 @@ type DocumentsBuilder interface {
 	index.Writer
 }
 
+// DocumentsFilter is a filter for documents.
+type DocumentsFilter interface {
+	// Contains returns whether the document should be retained.
+	Contains(d doc.Document) bool
+}
+
 // SegmentsBuilder is a builder that is built from segments.
 type SegmentsBuilder interface {
 	Builder
 
+	// SetFilter sets a filter on documents added from segments, documents
+	// not contained by the filter are skipped. The filter is applied to
+	// segments added after it is set and is cleared by Reset.
+	SetFilter(filter DocumentsFilter)
+
 	// AddSegments adds segments to build from.
 	AddSegments(segments []Segment) error
 }
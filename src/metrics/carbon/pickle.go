@@ -0,0 +1,630 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package carbon
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"go.uber.org/zap"
+)
+
+const (
+	pickleHeaderSize = 4
+	// maxPickleFrameSize matches the max pickle receiver message size of carbon.
+	maxPickleFrameSize = 1 << 20
+
+	pickleOpMark            = '('
+	pickleOpStop            = '.'
+	pickleOpInt             = 'I'
+	pickleOpBinInt          = 'J'
+	pickleOpBinInt1         = 'K'
+	pickleOpBinInt2         = 'M'
+	pickleOpLong            = 'L'
+	pickleOpNone            = 'N'
+	pickleOpString          = 'S'
+	pickleOpBinString       = 'T'
+	pickleOpShortBinString  = 'U'
+	pickleOpUnicode         = 'V'
+	pickleOpBinUnicode      = 'X'
+	pickleOpAppend          = 'a'
+	pickleOpAppends         = 'e'
+	pickleOpFloat           = 'F'
+	pickleOpBinFloat        = 'G'
+	pickleOpGet             = 'g'
+	pickleOpBinGet          = 'h'
+	pickleOpLongBinGet      = 'j'
+	pickleOpList            = 'l'
+	pickleOpEmptyList       = ']'
+	pickleOpPut             = 'p'
+	pickleOpBinPut          = 'q'
+	pickleOpLongBinPut      = 'r'
+	pickleOpTuple           = 't'
+	pickleOpEmptyTuple      = ')'
+	pickleOpBinBytes        = 'B'
+	pickleOpShortBinBytes   = 'C'
+	pickleOpProto           = 0x80
+	pickleOpTuple1          = 0x85
+	pickleOpTuple2          = 0x86
+	pickleOpTuple3          = 0x87
+	pickleOpNewTrue         = 0x88
+	pickleOpNewFalse        = 0x89
+	pickleOpLong1           = 0x8a
+	pickleOpShortBinUnicode = 0x8c
+	pickleOpMemoize         = 0x94
+	pickleOpFrame           = 0x95
+)
+
+var (
+	errPickleTruncated      = errors.New("pickle: truncated data")
+	errPickleStackUnderflow = errors.New("pickle: stack underflow")
+	errPickleNoMark         = errors.New("pickle: mark not found")
+	errPickleNotList        = errors.New("pickle: expected a list of metrics")
+	errPickleFrameTooLarge  = errors.New("pickle: frame exceeds max size")
+)
+
+// pickleList is a python list, which unlike a tuple can be appended to after
+// it has been memoized.
+type pickleList struct {
+	items []interface{}
+}
+
+// ParsePickle parses a pickled list of (path, (timestamp, value)) tuples, as
+// sent by carbon relays using the pickle protocol, and returns the metrics
+// and number of malformed metrics. The returned metric names reference data.
+func ParsePickle(mets []Metric, data []byte) ([]Metric, int, error) {
+	u := unpickler{data: data, memo: make(map[int]interface{})}
+	value, err := u.load()
+	if err != nil {
+		return mets, 0, err
+	}
+
+	list, ok := value.(*pickleList)
+	if !ok {
+		return mets, 0, errPickleNotList
+	}
+
+	malformed := 0
+	for _, item := range list.items {
+		m, err := pickleMetric(item)
+		if err != nil {
+			malformed++
+			continue
+		}
+		mets = append(mets, m)
+	}
+
+	return mets, malformed, nil
+}
+
+func pickleMetric(item interface{}) (Metric, error) {
+	tuple, ok := pickleSequence(item)
+	if !ok || len(tuple) != 2 {
+		return Metric{}, errInvalidLine
+	}
+
+	name, ok := tuple[0].([]byte)
+	if !ok || len(name) == 0 {
+		return Metric{}, errInvalidLine
+	}
+	if !utf8.Valid(name) {
+		return Metric{}, errNotUTF8
+	}
+
+	datapoint, ok := pickleSequence(tuple[1])
+	if !ok || len(datapoint) != 2 {
+		return Metric{}, errInvalidLine
+	}
+
+	timestamp, err := pickleFloat(datapoint[0])
+	if err != nil {
+		return Metric{}, err
+	}
+	value, err := pickleFloat(datapoint[1])
+	if err != nil {
+		return Metric{}, err
+	}
+
+	return Metric{
+		Name: name,
+		Time: time.Unix(int64(timestamp), 0),
+		Val:  value,
+	}, nil
+}
+
+func pickleSequence(v interface{}) ([]interface{}, bool) {
+	switch v := v.(type) {
+	case []interface{}:
+		return v, true
+	case *pickleList:
+		return v.items, true
+	}
+	return nil, false
+}
+
+func pickleFloat(v interface{}) (float64, error) {
+	switch v := v.(type) {
+	case int64:
+		return float64(v), nil
+	case float64:
+		return v, nil
+	case *big.Int:
+		f, _ := new(big.Float).SetInt(v).Float64()
+		return f, nil
+	case bool:
+		if v {
+			return 1, nil
+		}
+		return 0, nil
+	case []byte:
+		if s := strings.ToLower(string(v)); s == negativeNanStr || s == nanStr {
+			return mathNan, nil
+		}
+		return strconv.ParseFloat(string(v), floatBitSize)
+	}
+	return 0, errInvalidLine
+}
+
+// unpickler decodes the subset of the pickle format, up to protocol 4, that
+// is used to encode lists of tuples of strings and numbers.
+type unpickler struct {
+	data  []byte
+	idx   int
+	stack []interface{}
+	marks []int
+	memo  map[int]interface{}
+}
+
+func (u *unpickler) load() (interface{}, error) {
+	for {
+		op, err := u.readByte()
+		if err != nil {
+			return nil, err
+		}
+
+		switch op {
+		case pickleOpProto:
+			_, err = u.read(1)
+		case pickleOpFrame:
+			_, err = u.read(8)
+		case pickleOpStop:
+			return u.pop()
+		case pickleOpMark:
+			u.marks = append(u.marks, len(u.stack))
+		case pickleOpNone:
+			u.push(nil)
+		case pickleOpNewTrue:
+			u.push(true)
+		case pickleOpNewFalse:
+			u.push(false)
+		case pickleOpInt:
+			err = u.loadInt()
+		case pickleOpLong:
+			err = u.loadLong()
+		case pickleOpBinInt:
+			var b []byte
+			if b, err = u.read(4); err == nil {
+				u.push(int64(int32(binary.LittleEndian.Uint32(b))))
+			}
+		case pickleOpBinInt1:
+			var b []byte
+			if b, err = u.read(1); err == nil {
+				u.push(int64(b[0]))
+			}
+		case pickleOpBinInt2:
+			var b []byte
+			if b, err = u.read(2); err == nil {
+				u.push(int64(binary.LittleEndian.Uint16(b)))
+			}
+		case pickleOpLong1:
+			err = u.loadLong1()
+		case pickleOpFloat:
+			var line []byte
+			if line, err = u.readLine(); err == nil {
+				var f float64
+				if f, err = strconv.ParseFloat(string(line), floatBitSize); err == nil {
+					u.push(f)
+				}
+			}
+		case pickleOpBinFloat:
+			var b []byte
+			if b, err = u.read(8); err == nil {
+				u.push(math.Float64frombits(binary.BigEndian.Uint64(b)))
+			}
+		case pickleOpString:
+			var line []byte
+			if line, err = u.readLine(); err == nil {
+				if len(line) < 2 || line[0] != line[len(line)-1] ||
+					(line[0] != '\'' && line[0] != '"') {
+					err = fmt.Errorf("pickle: invalid string %q", line)
+				} else {
+					u.push(line[1 : len(line)-1])
+				}
+			}
+		case pickleOpUnicode:
+			var line []byte
+			if line, err = u.readLine(); err == nil {
+				u.push(line)
+			}
+		case pickleOpShortBinString, pickleOpShortBinBytes, pickleOpShortBinUnicode:
+			var b []byte
+			if b, err = u.read(1); err == nil {
+				err = u.loadBytes(uint64(b[0]))
+			}
+		case pickleOpBinString, pickleOpBinBytes, pickleOpBinUnicode:
+			var b []byte
+			if b, err = u.read(4); err == nil {
+				err = u.loadBytes(uint64(binary.LittleEndian.Uint32(b)))
+			}
+		case pickleOpEmptyList:
+			u.push(&pickleList{})
+		case pickleOpList:
+			var items []interface{}
+			if items, err = u.popMark(); err == nil {
+				u.push(&pickleList{items: items})
+			}
+		case pickleOpAppend:
+			var item interface{}
+			if item, err = u.pop(); err == nil {
+				err = u.appendToList(item)
+			}
+		case pickleOpAppends:
+			var items []interface{}
+			if items, err = u.popMark(); err == nil {
+				err = u.appendToList(items...)
+			}
+		case pickleOpEmptyTuple:
+			u.push([]interface{}{})
+		case pickleOpTuple:
+			var items []interface{}
+			if items, err = u.popMark(); err == nil {
+				u.push(items)
+			}
+		case pickleOpTuple1, pickleOpTuple2, pickleOpTuple3:
+			err = u.loadTuple(int(op-pickleOpTuple1) + 1)
+		case pickleOpPut:
+			var line []byte
+			if line, err = u.readLine(); err == nil {
+				var idx int
+				if idx, err = strconv.Atoi(string(line)); err == nil {
+					err = u.put(idx)
+				}
+			}
+		case pickleOpBinPut:
+			var b []byte
+			if b, err = u.read(1); err == nil {
+				err = u.put(int(b[0]))
+			}
+		case pickleOpLongBinPut:
+			var b []byte
+			if b, err = u.read(4); err == nil {
+				err = u.put(int(binary.LittleEndian.Uint32(b)))
+			}
+		case pickleOpMemoize:
+			err = u.put(len(u.memo))
+		case pickleOpGet:
+			var line []byte
+			if line, err = u.readLine(); err == nil {
+				var idx int
+				if idx, err = strconv.Atoi(string(line)); err == nil {
+					err = u.get(idx)
+				}
+			}
+		case pickleOpBinGet:
+			var b []byte
+			if b, err = u.read(1); err == nil {
+				err = u.get(int(b[0]))
+			}
+		case pickleOpLongBinGet:
+			var b []byte
+			if b, err = u.read(4); err == nil {
+				err = u.get(int(binary.LittleEndian.Uint32(b)))
+			}
+		default:
+			err = fmt.Errorf("pickle: unsupported opcode 0x%x", op)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (u *unpickler) readByte() (byte, error) {
+	if u.idx >= len(u.data) {
+		return 0, errPickleTruncated
+	}
+	b := u.data[u.idx]
+	u.idx++
+	return b, nil
+}
+
+func (u *unpickler) read(n uint64) ([]byte, error) {
+	if uint64(len(u.data)-u.idx) < n {
+		return nil, errPickleTruncated
+	}
+	b := u.data[u.idx : u.idx+int(n)]
+	u.idx += int(n)
+	return b, nil
+}
+
+func (u *unpickler) readLine() ([]byte, error) {
+	for i := u.idx; i < len(u.data); i++ {
+		if u.data[i] == '\n' {
+			line := u.data[u.idx:i]
+			u.idx = i + 1
+			return line, nil
+		}
+	}
+	return nil, errPickleTruncated
+}
+
+func (u *unpickler) loadBytes(n uint64) error {
+	b, err := u.read(n)
+	if err != nil {
+		return err
+	}
+	u.push(b)
+	return nil
+}
+
+func (u *unpickler) loadInt() error {
+	line, err := u.readLine()
+	if err != nil {
+		return err
+	}
+
+	// Protocol 0 encodes booleans as the ints 01 and 00.
+	switch string(line) {
+	case "01":
+		u.push(true)
+		return nil
+	case "00":
+		u.push(false)
+		return nil
+	}
+
+	v, err := strconv.ParseInt(string(line), 10, 64)
+	if err != nil {
+		return err
+	}
+	u.push(v)
+	return nil
+}
+
+func (u *unpickler) loadLong() error {
+	line, err := u.readLine()
+	if err != nil {
+		return err
+	}
+
+	line = []byte(strings.TrimSuffix(string(line), "L"))
+	v, ok := new(big.Int).SetString(string(line), 10)
+	if !ok {
+		return fmt.Errorf("pickle: invalid long %q", line)
+	}
+	u.pushInt(v)
+	return nil
+}
+
+func (u *unpickler) loadLong1() error {
+	b, err := u.read(1)
+	if err != nil {
+		return err
+	}
+	b, err = u.read(uint64(b[0]))
+	if err != nil {
+		return err
+	}
+
+	// The value is encoded in little endian two's complement.
+	be := make([]byte, len(b))
+	for i := range b {
+		be[len(b)-1-i] = b[i]
+	}
+	v := new(big.Int).SetBytes(be)
+	if len(b) > 0 && b[len(b)-1]&0x80 != 0 {
+		v.Sub(v, new(big.Int).Lsh(big.NewInt(1), uint(len(b)*8)))
+	}
+	u.pushInt(v)
+	return nil
+}
+
+func (u *unpickler) pushInt(v *big.Int) {
+	if v.IsInt64() {
+		u.push(v.Int64())
+		return
+	}
+	u.push(v)
+}
+
+func (u *unpickler) loadTuple(n int) error {
+	if len(u.stack) < n {
+		return errPickleStackUnderflow
+	}
+	items := make([]interface{}, n)
+	copy(items, u.stack[len(u.stack)-n:])
+	u.stack = u.stack[:len(u.stack)-n]
+	u.push(items)
+	return nil
+}
+
+func (u *unpickler) appendToList(items ...interface{}) error {
+	if len(u.stack) == 0 {
+		return errPickleStackUnderflow
+	}
+	list, ok := u.stack[len(u.stack)-1].(*pickleList)
+	if !ok {
+		return errPickleNotList
+	}
+	list.items = append(list.items, items...)
+	return nil
+}
+
+func (u *unpickler) put(idx int) error {
+	if len(u.stack) == 0 {
+		return errPickleStackUnderflow
+	}
+	u.memo[idx] = u.stack[len(u.stack)-1]
+	return nil
+}
+
+func (u *unpickler) get(idx int) error {
+	v, ok := u.memo[idx]
+	if !ok {
+		return fmt.Errorf("pickle: memo key %d not found", idx)
+	}
+	u.push(v)
+	return nil
+}
+
+func (u *unpickler) push(v interface{}) {
+	u.stack = append(u.stack, v)
+}
+
+func (u *unpickler) pop() (interface{}, error) {
+	if len(u.stack) == 0 {
+		return nil, errPickleStackUnderflow
+	}
+	v := u.stack[len(u.stack)-1]
+	u.stack = u.stack[:len(u.stack)-1]
+	return v, nil
+}
+
+func (u *unpickler) popMark() ([]interface{}, error) {
+	if len(u.marks) == 0 {
+		return nil, errPickleNoMark
+	}
+	mark := u.marks[len(u.marks)-1]
+	u.marks = u.marks[:len(u.marks)-1]
+	if mark > len(u.stack) {
+		return nil, errPickleStackUnderflow
+	}
+	items := make([]interface{}, len(u.stack)-mark)
+	copy(items, u.stack[mark:])
+	u.stack = u.stack[:mark]
+	return items, nil
+}
+
+// A PickleScanner is used to scan carbon metrics sent using the pickle
+// protocol, where each message is a 4 byte big endian length followed by a
+// pickled list of metrics, from an underlying io.Reader.
+type PickleScanner struct {
+	r       *bufio.Reader
+	header  [pickleHeaderSize]byte
+	buf     []byte
+	metrics []Metric
+	idx     int
+	err     error
+
+	// The number of malformed metrics encountered.
+	MalformedCount int
+
+	iOpts instrument.Options
+}
+
+// NewPickleScanner creates a new carbon pickle scanner.
+func NewPickleScanner(r io.Reader, iOpts instrument.Options) *PickleScanner {
+	return &PickleScanner{
+		r:     bufio.NewReaderSize(r, initScannerBufferSize),
+		iOpts: iOpts,
+	}
+}
+
+// Scan scans for the next carbon metric. Malformed metrics and messages are
+// skipped but counted.
+func (s *PickleScanner) Scan() bool {
+	for {
+		s.idx++
+		if s.idx < len(s.metrics) {
+			return true
+		}
+
+		if s.err != nil {
+			return false
+		}
+
+		if !s.readMessage() {
+			return false
+		}
+	}
+}
+
+func (s *PickleScanner) readMessage() bool {
+	s.metrics = s.metrics[:0]
+	s.idx = -1
+
+	if _, err := io.ReadFull(s.r, s.header[:]); err != nil {
+		if err != io.EOF {
+			s.err = err
+		}
+		return false
+	}
+
+	size := binary.BigEndian.Uint32(s.header[:])
+	if size > maxPickleFrameSize {
+		s.err = errPickleFrameTooLarge
+		return false
+	}
+
+	if cap(s.buf) < int(size) {
+		s.buf = make([]byte, size)
+	}
+	s.buf = s.buf[:size]
+	if _, err := io.ReadFull(s.r, s.buf); err != nil {
+		s.err = err
+		return false
+	}
+
+	var (
+		malformed int
+		err       error
+	)
+	s.metrics, malformed, err = ParsePickle(s.metrics, s.buf)
+	s.MalformedCount += malformed
+	if err != nil {
+		s.iOpts.Logger().Error("error trying to scan malformed carbon pickle message",
+			zap.Int("size", len(s.buf)), zap.Error(err))
+		s.MalformedCount++
+	}
+
+	return true
+}
+
+// Metric returns the path, timestamp, and value of the last parsed metric.
+func (s *PickleScanner) Metric() ([]byte, time.Time, float64) {
+	m := s.metrics[s.idx]
+	return m.Name, m.Time, m.Val
+}
+
+// Err returns any errors in the scan.
+func (s *PickleScanner) Err() error { return s.err }
@@ -0,0 +1,138 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package carbon
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Pickled [("foo.bar", (1500000000, 1.5)), ("baz", (1500000001.0, 2))] using
+// protocols 0, 2 and 4.
+var testPickles = map[string]string{
+	"protocol0": "(lp0\n(Vfoo.bar\np1\n(I1500000000\nF1.5\ntp2\ntp3\na(Vbaz\np4\n" +
+		"(F1500000001.0\nI2\ntp5\ntp6\na.",
+	"protocol2": "\x80\x02]q\x00(X\x07\x00\x00\x00foo.barq\x01J\x00/hYG?\xf8\x00\x00" +
+		"\x00\x00\x00\x00\x86q\x02\x86q\x03X\x03\x00\x00\x00bazq\x04GA\xd6Z\x0b\xc0@" +
+		"\x00\x00K\x02\x86q\x05\x86q\x06e.",
+	"protocol4": "\x80\x04\x956\x00\x00\x00\x00\x00\x00\x00]\x94(\x8c\x07foo.bar\x94J" +
+		"\x00/hYG?\xf8\x00\x00\x00\x00\x00\x00\x86\x94\x86\x94\x8c\x03baz\x94GA\xd6Z" +
+		"\x0b\xc0@\x00\x00K\x02\x86\x94\x86\x94e.",
+}
+
+var testPickleMetrics = []Metric{
+	{Name: []byte("foo.bar"), Time: time.Unix(1500000000, 0), Val: 1.5},
+	{Name: []byte("baz"), Time: time.Unix(1500000001, 0), Val: 2},
+}
+
+func TestParsePickle(t *testing.T) {
+	for name, data := range testPickles {
+		t.Run(name, func(t *testing.T) {
+			mets, malformed, err := ParsePickle(nil, []byte(data))
+			require.NoError(t, err)
+			assert.Equal(t, 0, malformed)
+			assert.Equal(t, testPickleMetrics, mets)
+		})
+	}
+}
+
+func TestParsePickleMalformedMetrics(t *testing.T) {
+	// Pickled [("foo", (1500000000, "nan")), ("bad",), (1, (1, 2))].
+	data := "\x80\x02]q\x00(X\x03\x00\x00\x00fooq\x01J\x00/hYX\x03\x00\x00\x00nanq" +
+		"\x02\x86q\x03\x86q\x04X\x03\x00\x00\x00badq\x05\x85q\x06K\x01K\x01K\x02\x86q" +
+		"\x07\x86q\x08e."
+
+	mets, malformed, err := ParsePickle(nil, []byte(data))
+	require.NoError(t, err)
+	assert.Equal(t, 2, malformed)
+	require.Equal(t, 1, len(mets))
+	assert.Equal(t, "foo", string(mets[0].Name))
+	assert.Equal(t, time.Unix(1500000000, 0), mets[0].Time)
+	assert.True(t, math.IsNaN(mets[0].Val))
+}
+
+func TestParsePickleErrors(t *testing.T) {
+	data := testPickles["protocol2"]
+	_, _, err := ParsePickle(nil, []byte(data[:len(data)-5]))
+	assert.Error(t, err)
+
+	// Pickled 1 rather than a list.
+	_, _, err = ParsePickle(nil, []byte("\x80\x02K\x01."))
+	assert.Equal(t, errPickleNotList, err)
+
+	// Pickled {} which uses an unsupported opcode.
+	_, _, err = ParsePickle(nil, []byte("\x80\x02}q\x00."))
+	assert.Error(t, err)
+}
+
+func writeTestPickleMessage(buf *bytes.Buffer, data string) {
+	var header [pickleHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:], uint32(len(data)))
+	buf.Write(header[:])
+	buf.WriteString(data)
+}
+
+func TestPickleScannerMetric(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestPickleMessage(&buf, testPickles["protocol2"])
+	writeTestPickleMessage(&buf, "\x80\x02}q\x00.")
+	writeTestPickleMessage(&buf, testPickles["protocol4"])
+
+	s := NewPickleScanner(&buf, testIOpts)
+	for i := 0; i < 2; i++ {
+		for _, expected := range testPickleMetrics {
+			require.True(t, s.Scan(), "could not scan metric, err: %v", s.Err())
+			name, ts, value := s.Metric()
+			assert.Equal(t, string(expected.Name), string(name))
+			assert.Equal(t, expected.Time, ts)
+			assert.Equal(t, expected.Val, value)
+		}
+	}
+
+	assert.False(t, s.Scan(), "parsed past end of buffer")
+	assert.Nil(t, s.Err())
+	assert.Equal(t, 1, s.MalformedCount)
+}
+
+func TestPickleScannerTruncatedMessage(t *testing.T) {
+	var buf bytes.Buffer
+	writeTestPickleMessage(&buf, testPickles["protocol2"])
+	buf.Truncate(buf.Len() - 1)
+
+	s := NewPickleScanner(&buf, testIOpts)
+	assert.False(t, s.Scan())
+	assert.Error(t, s.Err())
+}
+
+func TestPickleScannerMessageTooLarge(t *testing.T) {
+	var header [pickleHeaderSize]byte
+	binary.BigEndian.PutUint32(header[:], maxPickleFrameSize+1)
+
+	s := NewPickleScanner(bytes.NewReader(header[:]), testIOpts)
+	assert.False(t, s.Scan())
+	assert.Equal(t, errPickleFrameTooLarge, s.Err())
+}
@@ -171,7 +171,57 @@ func testLocalType(t *testing.T, providedType string, placementExists bool) {
 							"blockSizeNanos": "3600000000000"
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"retentionTiers": [],
+						"archivalOptions": {
+							"enabled": false,
+							"immutableAfterNanos": "0",
+							"verifyChecksumOnRead": false
+						},
+						"futureWriteOptions": {
+							"enabled": false,
+							"toleranceNanos": "0",
+							"action": "REJECT"
+						},
+						"expiryDownsampleOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"resolutionNanos": "0"
+						},
+						"inMemory": false,
+						"shardKeyStrategy": "",
+						"relabelOptions": {
+							"rules": []
+						},
+						"mirrorOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"percentage": 0,
+							"matchers": []
+						},
+						"reshardOptions": {
+							"enabled": false,
+							"fromNumShards": 0,
+							"toNumShards": 0
+						},
+						"queryLimitsOptions": {
+							"maxConcurrentQueries": "0",
+							"maxInFlightResultBytes": "0"
+						},
+						"writeDurability": "DEFAULT",
+						"validationOptions": {
+							"rejectNaN": false,
+							"rejectInf": false,
+							"boundsEnabled": false,
+							"minValue": 0,
+							"maxValue": 0,
+							"maxAnnotationSize": "0"
+						},
+						"compactionOptions": {
+							"enabled": false,
+							"blockSizeNanos": "0",
+							"afterNanos": "0"
+						}
 					}
 				}
 			}
@@ -329,7 +379,57 @@ func TestLocalTypeWithNumShards(t *testing.T) {
 							"blockSizeNanos": "3600000000000"
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"retentionTiers": [],
+						"archivalOptions": {
+							"enabled": false,
+							"immutableAfterNanos": "0",
+							"verifyChecksumOnRead": false
+						},
+						"futureWriteOptions": {
+							"enabled": false,
+							"toleranceNanos": "0",
+							"action": "REJECT"
+						},
+						"expiryDownsampleOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"resolutionNanos": "0"
+						},
+						"inMemory": false,
+						"shardKeyStrategy": "",
+						"relabelOptions": {
+							"rules": []
+						},
+						"mirrorOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"percentage": 0,
+							"matchers": []
+						},
+						"reshardOptions": {
+							"enabled": false,
+							"fromNumShards": 0,
+							"toNumShards": 0
+						},
+						"queryLimitsOptions": {
+							"maxConcurrentQueries": "0",
+							"maxInFlightResultBytes": "0"
+						},
+						"writeDurability": "DEFAULT",
+						"validationOptions": {
+							"rejectNaN": false,
+							"rejectInf": false,
+							"boundsEnabled": false,
+							"minValue": 0,
+							"maxValue": 0,
+							"maxAnnotationSize": "0"
+						},
+						"compactionOptions": {
+							"enabled": false,
+							"blockSizeNanos": "0",
+							"afterNanos": "0"
+						}
 					}
 				}
 			}
@@ -439,7 +539,57 @@ func TestLocalWithBlockSizeNanos(t *testing.T) {
 							"blockSizeNanos": "10800000000000"
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"retentionTiers": [],
+						"archivalOptions": {
+							"enabled": false,
+							"immutableAfterNanos": "0",
+							"verifyChecksumOnRead": false
+						},
+						"futureWriteOptions": {
+							"enabled": false,
+							"toleranceNanos": "0",
+							"action": "REJECT"
+						},
+						"expiryDownsampleOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"resolutionNanos": "0"
+						},
+						"inMemory": false,
+						"shardKeyStrategy": "",
+						"relabelOptions": {
+							"rules": []
+						},
+						"mirrorOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"percentage": 0,
+							"matchers": []
+						},
+						"reshardOptions": {
+							"enabled": false,
+							"fromNumShards": 0,
+							"toNumShards": 0
+						},
+						"queryLimitsOptions": {
+							"maxConcurrentQueries": "0",
+							"maxInFlightResultBytes": "0"
+						},
+						"writeDurability": "DEFAULT",
+						"validationOptions": {
+							"rejectNaN": false,
+							"rejectInf": false,
+							"boundsEnabled": false,
+							"minValue": 0,
+							"maxValue": 0,
+							"maxAnnotationSize": "0"
+						},
+						"compactionOptions": {
+							"enabled": false,
+							"blockSizeNanos": "0",
+							"afterNanos": "0"
+						}
 					}
 				}
 			}
@@ -553,7 +703,57 @@ func TestLocalWithBlockSizeExpectedSeriesDatapointsPerHour(t *testing.T) {
 							"blockSizeNanos": "%d"
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"retentionTiers": [],
+						"archivalOptions": {
+							"enabled": false,
+							"immutableAfterNanos": "0",
+							"verifyChecksumOnRead": false
+						},
+						"futureWriteOptions": {
+							"enabled": false,
+							"toleranceNanos": "0",
+							"action": "REJECT"
+						},
+						"expiryDownsampleOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"resolutionNanos": "0"
+						},
+						"inMemory": false,
+						"shardKeyStrategy": "",
+						"relabelOptions": {
+							"rules": []
+						},
+						"mirrorOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"percentage": 0,
+							"matchers": []
+						},
+						"reshardOptions": {
+							"enabled": false,
+							"fromNumShards": 0,
+							"toNumShards": 0
+						},
+						"queryLimitsOptions": {
+							"maxConcurrentQueries": "0",
+							"maxInFlightResultBytes": "0"
+						},
+						"writeDurability": "DEFAULT",
+						"validationOptions": {
+							"rejectNaN": false,
+							"rejectInf": false,
+							"boundsEnabled": false,
+							"minValue": 0,
+							"maxValue": 0,
+							"maxAnnotationSize": "0"
+						},
+						"compactionOptions": {
+							"enabled": false,
+							"blockSizeNanos": "0",
+							"afterNanos": "0"
+						}
 					}
 				}
 			}
@@ -801,7 +1001,57 @@ func testClusterTypeHosts(t *testing.T, placementExists bool) {
 							"blockSizeNanos": "3600000000000"
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"retentionTiers": [],
+						"archivalOptions": {
+							"enabled": false,
+							"immutableAfterNanos": "0",
+							"verifyChecksumOnRead": false
+						},
+						"futureWriteOptions": {
+							"enabled": false,
+							"toleranceNanos": "0",
+							"action": "REJECT"
+						},
+						"expiryDownsampleOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"resolutionNanos": "0"
+						},
+						"inMemory": false,
+						"shardKeyStrategy": "",
+						"relabelOptions": {
+							"rules": []
+						},
+						"mirrorOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"percentage": 0,
+							"matchers": []
+						},
+						"reshardOptions": {
+							"enabled": false,
+							"fromNumShards": 0,
+							"toNumShards": 0
+						},
+						"queryLimitsOptions": {
+							"maxConcurrentQueries": "0",
+							"maxInFlightResultBytes": "0"
+						},
+						"writeDurability": "DEFAULT",
+						"validationOptions": {
+							"rejectNaN": false,
+							"rejectInf": false,
+							"boundsEnabled": false,
+							"minValue": 0,
+							"maxValue": 0,
+							"maxAnnotationSize": "0"
+						},
+						"compactionOptions": {
+							"enabled": false,
+							"blockSizeNanos": "0",
+							"afterNanos": "0"
+						}
 					}
 				}
 			}
@@ -933,7 +1183,57 @@ func TestClusterTypeHostsWithIsolationGroup(t *testing.T) {
 							"blockSizeNanos": "3600000000000"
 						},
 						"schemaOptions": null,
-						"coldWritesEnabled": false
+						"coldWritesEnabled": false,
+						"retentionTiers": [],
+						"archivalOptions": {
+							"enabled": false,
+							"immutableAfterNanos": "0",
+							"verifyChecksumOnRead": false
+						},
+						"futureWriteOptions": {
+							"enabled": false,
+							"toleranceNanos": "0",
+							"action": "REJECT"
+						},
+						"expiryDownsampleOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"resolutionNanos": "0"
+						},
+						"inMemory": false,
+						"shardKeyStrategy": "",
+						"relabelOptions": {
+							"rules": []
+						},
+						"mirrorOptions": {
+							"enabled": false,
+							"targetNamespace": "",
+							"percentage": 0,
+							"matchers": []
+						},
+						"reshardOptions": {
+							"enabled": false,
+							"fromNumShards": 0,
+							"toNumShards": 0
+						},
+						"queryLimitsOptions": {
+							"maxConcurrentQueries": "0",
+							"maxInFlightResultBytes": "0"
+						},
+						"writeDurability": "DEFAULT",
+						"validationOptions": {
+							"rejectNaN": false,
+							"rejectInf": false,
+							"boundsEnabled": false,
+							"minValue": 0,
+							"maxValue": 0,
+							"maxAnnotationSize": "0"
+						},
+						"compactionOptions": {
+							"enabled": false,
+							"blockSizeNanos": "0",
+							"afterNanos": "0"
+						}
 					}
 				}
 			}
@@ -0,0 +1,260 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+// Package export provides an admin API that exports the datapoints of
+// matched series over a time range to CSV or Parquet files, either on local
+// disk or uploaded to object storage, as a throttled server side job.
+package export
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/util/logging"
+	"github.com/m3db/m3/src/x/instrument"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"github.com/pborman/uuid"
+	"go.uber.org/zap"
+)
+
+const (
+	// URL is the url for the export handler, jobs are started with a POST,
+	// inspected with a GET and cancelled with a DELETE.
+	URL = handler.RoutePrefixV1 + "/export"
+
+	formatParam = "format"
+	pathParam   = "path"
+	urlParam    = "url"
+	idParam     = "id"
+	startParam  = "start"
+)
+
+var (
+	// HTTPMethods are the HTTP methods used with this resource.
+	HTTPMethods = []string{http.MethodGet, http.MethodPost, http.MethodDelete}
+
+	errDirectoryNotSet     = errors.New("export directory must be set")
+	errStartNotSet         = errors.New("start must be set")
+	errDestinationNotSet   = errors.New("exactly one of path or url must be set")
+	errUploadsNotAllowed   = errors.New("uploading exports is not enabled")
+	errInvalidPath         = errors.New("path must be relative to the export directory")
+	errInvalidUploadScheme = errors.New("url must be an http or https url")
+	errIDNotSet            = errors.New("id must be set")
+)
+
+// Handler is the export handler.
+type Handler struct {
+	jobs                *jobManager
+	opts                Options
+	tagOptions          models.TagOptions
+	fetchOptionsBuilder handleroptions.FetchOptionsBuilder
+	instrumentOpts      instrument.Options
+}
+
+// NewHandler returns a new export handler.
+func NewHandler(
+	handlerOpts options.HandlerOptions,
+	opts Options,
+) (http.Handler, error) {
+	if opts.Directory == "" {
+		return nil, errDirectoryNotSet
+	}
+
+	info, err := os.Stat(opts.Directory)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("export directory is not a directory: %s",
+			opts.Directory)
+	}
+
+	instrumentOpts := handlerOpts.InstrumentOpts()
+	return &Handler{
+		jobs:                newJobManager(handlerOpts.Storage(), opts, instrumentOpts),
+		opts:                opts,
+		tagOptions:          handlerOpts.TagOptions(),
+		fetchOptionsBuilder: handlerOpts.FetchOptionsBuilder(),
+		instrumentOpts:      instrumentOpts,
+	}, nil
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	logger := logging.WithContext(r.Context(), h.instrumentOpts)
+
+	switch r.Method {
+	case http.MethodGet:
+		id := r.URL.Query().Get(idParam)
+		if id == "" {
+			xhttp.WriteJSONResponse(w, h.jobs.list(), logger)
+			return
+		}
+
+		job, err := h.jobs.get(id)
+		if err != nil {
+			xhttp.Error(w, err, http.StatusNotFound)
+			return
+		}
+		xhttp.WriteJSONResponse(w, job, logger)
+	case http.MethodPost:
+		req, parseErr := h.parseRequest(r)
+		if parseErr != nil {
+			logger.Error("unable to parse export request", zap.Error(parseErr))
+			xhttp.Error(w, parseErr.Inner(), parseErr.Code())
+			return
+		}
+
+		job, err := h.jobs.start(req)
+		if err != nil {
+			logger.Error("unable to start export job", zap.Error(err))
+			xhttp.Error(w, err, http.StatusTooManyRequests)
+			return
+		}
+		xhttp.WriteJSONResponse(w, job, logger)
+	case http.MethodDelete:
+		id := r.URL.Query().Get(idParam)
+		if id == "" {
+			xhttp.Error(w, errIDNotSet, http.StatusBadRequest)
+			return
+		}
+
+		job, err := h.jobs.cancel(id)
+		if err == errJobNotFound {
+			xhttp.Error(w, err, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			xhttp.Error(w, err, http.StatusConflict)
+			return
+		}
+		xhttp.WriteJSONResponse(w, job, logger)
+	default:
+		xhttp.Error(w, fmt.Errorf("unsupported method: %s", r.Method),
+			http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *Handler) parseRequest(r *http.Request) (jobRequest, *xhttp.ParseError) {
+	queries, parseErr := prometheus.ParseSeriesMatchQuery(r, h.tagOptions)
+	if parseErr != nil {
+		return jobRequest{}, parseErr
+	}
+
+	// Unlike series matching an export has no implicit start, exporting
+	// everything since the epoch is rarely what was intended.
+	if r.Form.Get(startParam) == "" {
+		return jobRequest{}, xhttp.NewParseError(errStartNotSet,
+			http.StatusBadRequest)
+	}
+
+	fetchOpts, parseErr := h.fetchOptionsBuilder.NewFetchOptions(r)
+	if parseErr != nil {
+		return jobRequest{}, parseErr
+	}
+
+	format := FormatCSV
+	if str := r.Form.Get(formatParam); str != "" {
+		var err error
+		format, err = ParseFormat(str)
+		if err != nil {
+			return jobRequest{}, xhttp.NewParseError(err, http.StatusBadRequest)
+		}
+	}
+
+	req := jobRequest{
+		format:    format,
+		queries:   queries,
+		fetchOpts: fetchOpts,
+	}
+
+	var (
+		path      = r.Form.Get(pathParam)
+		uploadURL = r.Form.Get(urlParam)
+	)
+	switch {
+	case path != "" && uploadURL == "":
+		resolved, err := h.resolvePath(path)
+		if err != nil {
+			return jobRequest{}, xhttp.NewParseError(err, http.StatusBadRequest)
+		}
+		req.path = resolved
+		req.destination = resolved
+	case path == "" && uploadURL != "":
+		if !h.opts.AllowUploads {
+			return jobRequest{}, xhttp.NewParseError(errUploadsNotAllowed,
+				http.StatusBadRequest)
+		}
+
+		parsed, err := url.Parse(uploadURL)
+		if err != nil {
+			return jobRequest{}, xhttp.NewParseError(err, http.StatusBadRequest)
+		}
+		if parsed.Scheme != "http" && parsed.Scheme != "https" {
+			return jobRequest{}, xhttp.NewParseError(errInvalidUploadScheme,
+				http.StatusBadRequest)
+		}
+
+		req.path = filepath.Join(h.opts.Directory,
+			fmt.Sprintf(".upload-%s.%s", uuid.New(), format))
+		req.uploadURL = uploadURL
+		// Strip the query which carries the signature of pre-signed URLs.
+		req.destination = parsed.Scheme + "://" + parsed.Host + parsed.Path
+	default:
+		return jobRequest{}, xhttp.NewParseError(errDestinationNotSet,
+			http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+// resolvePath resolves a destination path within the export directory,
+// creating any missing parent directories. Existing files are not replaced.
+func (h *Handler) resolvePath(path string) (string, error) {
+	if filepath.IsAbs(path) {
+		return "", errInvalidPath
+	}
+
+	clean := filepath.Clean(path)
+	if clean == "." || clean == ".." ||
+		strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+		return "", errInvalidPath
+	}
+
+	resolved := filepath.Join(h.opts.Directory, clean)
+	if _, err := os.Stat(resolved); err == nil {
+		return "", fmt.Errorf("export destination already exists: %s", clean)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(resolved), 0755); err != nil {
+		return "", err
+	}
+
+	return resolved, nil
+}
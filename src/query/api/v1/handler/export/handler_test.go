@@ -0,0 +1,263 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/api/v1/options"
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+	"github.com/m3db/m3/src/query/models"
+	"github.com/m3db/m3/src/query/storage"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestHandler(
+	t *testing.T,
+	ctrl *gomock.Controller,
+	opts Options,
+) (*Handler, *storage.MockStorage) {
+	store := storage.NewMockStorage(ctrl)
+	handlerOpts := options.EmptyHandlerOptions().
+		SetStorage(store).
+		SetTagOptions(models.NewTagOptions()).
+		SetFetchOptionsBuilder(handleroptions.NewFetchOptionsBuilder(
+			handleroptions.FetchOptionsBuilderOptions{}))
+
+	h, err := NewHandler(handlerOpts, opts)
+	require.NoError(t, err)
+	return h.(*Handler), store
+}
+
+func testPromResult(
+	_ context.Context,
+	query *storage.FetchQuery,
+	_ *storage.FetchOptions,
+) (storage.PromResult, error) {
+	return storage.PromResult{
+		PromResult: &prompb.QueryResult{
+			Timeseries: []*prompb.TimeSeries{{
+				Labels: []prompb.Label{
+					{Name: []byte("__name__"), Value: []byte("up")},
+					{Name: []byte("job"), Value: []byte("node")},
+				},
+				Samples: []prompb.Sample{{
+					Timestamp: storage.TimeToPromTimestamp(query.Start),
+					Value:     1,
+				}},
+			}},
+		},
+	}, nil
+}
+
+func serveExport(t *testing.T, h *Handler, method string, form url.Values) (int, Job) {
+	req := httptest.NewRequest(method, URL, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if method != http.MethodPost {
+		req = httptest.NewRequest(method, URL+"?"+form.Encode(), nil)
+	}
+
+	recorder := httptest.NewRecorder()
+	h.ServeHTTP(recorder, req)
+
+	var job Job
+	if recorder.Code == http.StatusOK {
+		require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &job))
+	}
+	return recorder.Code, job
+}
+
+func waitForJob(t *testing.T, h *Handler, id string) Job {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		job, err := h.jobs.get(id)
+		require.NoError(t, err)
+		if job.finished() {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.FailNow(t, "export job did not finish")
+	return Job{}
+}
+
+func TestExportToLocalCSV(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	h, store := newTestHandler(t, ctrl, Options{
+		Directory:   dir,
+		FetchWindow: time.Hour,
+	})
+	store.EXPECT().FetchProm(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(testPromResult).Times(2)
+
+	code, job := serveExport(t, h, http.MethodPost, url.Values{
+		"match[]": []string{"up"},
+		"start":   []string{"0"},
+		"end":     []string{"7200"},
+		"path":    []string{"nested/up.csv"},
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, FormatCSV, job.Format)
+
+	job = waitForJob(t, h, job.ID)
+	require.Equal(t, JobStateSucceeded, job.State, job.Error)
+	assert.Equal(t, int64(2), job.Datapoints)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "nested", "up.csv"))
+	require.NoError(t, err)
+	expected := "series,timestamp,value\n" +
+		"\"up{job=\"\"node\"\"}\",0,1\n" +
+		"\"up{job=\"\"node\"\"}\",3600000,1\n"
+	assert.Equal(t, expected, string(data))
+	assert.Equal(t, int64(len(data)), job.Bytes)
+
+	code, job = serveExport(t, h, http.MethodGet, url.Values{"id": []string{job.ID}})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, JobStateSucceeded, job.State)
+}
+
+func TestExportUpload(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	var (
+		uploaded    = make(chan []byte, 1)
+		contentType string
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodPut, r.Method)
+		contentType = r.Header.Get("Content-Type")
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		uploaded <- body
+	}))
+	defer server.Close()
+
+	h, store := newTestHandler(t, ctrl, Options{
+		Directory:    dir,
+		AllowUploads: true,
+	})
+	store.EXPECT().FetchProm(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(testPromResult)
+
+	code, job := serveExport(t, h, http.MethodPost, url.Values{
+		"match[]": []string{"up"},
+		"start":   []string{"0"},
+		"end":     []string{"60"},
+		"format":  []string{"parquet"},
+		"url":     []string{server.URL + "/bucket/up.parquet?signature=secret"},
+	})
+	require.Equal(t, http.StatusOK, code)
+	assert.Equal(t, server.URL+"/bucket/up.parquet", job.Destination)
+
+	job = waitForJob(t, h, job.ID)
+	require.Equal(t, JobStateSucceeded, job.State, job.Error)
+
+	body := <-uploaded
+	assert.Equal(t, parquetMagic, string(body[:4]))
+	assert.Equal(t, "application/octet-stream", contentType)
+
+	// The staged file is removed once uploaded.
+	files, err := ioutil.ReadDir(dir)
+	require.NoError(t, err)
+	assert.Len(t, files, 0)
+}
+
+func TestExportInvalidRequests(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	h, _ := newTestHandler(t, ctrl, Options{Directory: dir})
+
+	tests := []struct {
+		name string
+		form url.Values
+	}{
+		{
+			name: "no start",
+			form: url.Values{"match[]": {"up"}, "path": {"up.csv"}},
+		},
+		{
+			name: "no destination",
+			form: url.Values{"match[]": {"up"}, "start": {"0"}},
+		},
+		{
+			name: "both destinations",
+			form: url.Values{"match[]": {"up"}, "start": {"0"},
+				"path": {"up.csv"}, "url": {"http://localhost/up.csv"}},
+		},
+		{
+			name: "escaping path",
+			form: url.Values{"match[]": {"up"}, "start": {"0"},
+				"path": {"../up.csv"}},
+		},
+		{
+			name: "absolute path",
+			form: url.Values{"match[]": {"up"}, "start": {"0"},
+				"path": {"/tmp/up.csv"}},
+		},
+		{
+			name: "uploads not allowed",
+			form: url.Values{"match[]": {"up"}, "start": {"0"},
+				"url": {"http://localhost/up.csv"}},
+		},
+		{
+			name: "unknown format",
+			form: url.Values{"match[]": {"up"}, "start": {"0"},
+				"path": {"up.xlsx"}, "format": {"xlsx"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, _ := serveExport(t, h, http.MethodPost, tt.form)
+			assert.Equal(t, http.StatusBadRequest, code)
+		})
+	}
+}
@@ -0,0 +1,540 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/pborman/uuid"
+	"github.com/uber-go/tally"
+	"go.uber.org/zap"
+)
+
+const (
+	defaultMaxConcurrentJobs = 1
+	defaultFetchWindow       = time.Hour
+	defaultRetainedJobs      = 100
+
+	tmpFileSuffix = ".tmp"
+)
+
+var (
+	errTooManyJobs   = errors.New("too many export jobs pending")
+	errJobNotFound   = errors.New("export job not found")
+	errJobCancelled  = errors.New("export job cancelled")
+	errJobNotRunning = errors.New("export job already finished")
+)
+
+// JobState is the state of an export job.
+type JobState string
+
+const (
+	// JobStatePending is the state of a job waiting for a free job slot.
+	JobStatePending JobState = "pending"
+	// JobStateRunning is the state of a job that is exporting.
+	JobStateRunning JobState = "running"
+	// JobStateSucceeded is the state of a job that completed its export.
+	JobStateSucceeded JobState = "succeeded"
+	// JobStateFailed is the state of a job that encountered an error.
+	JobStateFailed JobState = "failed"
+	// JobStateCancelled is the state of a job that was cancelled.
+	JobStateCancelled JobState = "cancelled"
+)
+
+// Job is the status of an export job.
+type Job struct {
+	ID          string     `json:"id"`
+	State       JobState   `json:"state"`
+	Format      Format     `json:"format"`
+	Destination string     `json:"destination"`
+	Queries     []string   `json:"queries"`
+	Start       time.Time  `json:"start"`
+	End         time.Time  `json:"end"`
+	Datapoints  int64      `json:"datapoints"`
+	Bytes       int64      `json:"bytes"`
+	Error       string     `json:"error,omitempty"`
+	CreatedAt   time.Time  `json:"createdAt"`
+	FinishedAt  *time.Time `json:"finishedAt,omitempty"`
+}
+
+func (j Job) finished() bool {
+	return j.FinishedAt != nil
+}
+
+// Options are the options for export jobs.
+type Options struct {
+	// Directory is the local directory exports are written to, local
+	// destinations are relative to it and uploads are staged in it.
+	Directory string
+	// MaxConcurrentJobs is the max number of jobs that run concurrently,
+	// further jobs wait for a running job to complete.
+	MaxConcurrentJobs int
+	// MaxDatapointsPerSecond throttles each job to export at most this many
+	// datapoints per second, jobs are not throttled if zero.
+	MaxDatapointsPerSecond int
+	// FetchWindow is the time range fetched at a time, bounding the memory
+	// used by a job regardless of the time range exported.
+	FetchWindow time.Duration
+	// AllowUploads allows exports to be uploaded to object storage using a
+	// pre-signed URL instead of being kept on local disk.
+	AllowUploads bool
+	// UploadClient is the HTTP client used to upload exports.
+	UploadClient *http.Client
+	// RetainedJobs is the number of finished jobs whose status is retained.
+	RetainedJobs int
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxConcurrentJobs <= 0 {
+		o.MaxConcurrentJobs = defaultMaxConcurrentJobs
+	}
+	if o.FetchWindow <= 0 {
+		o.FetchWindow = defaultFetchWindow
+	}
+	if o.UploadClient == nil {
+		o.UploadClient = http.DefaultClient
+	}
+	if o.RetainedJobs <= 0 {
+		o.RetainedJobs = defaultRetainedJobs
+	}
+	return o
+}
+
+type jobRequest struct {
+	format    Format
+	queries   []*storage.FetchQuery
+	fetchOpts *storage.FetchOptions
+	// path is the local file the export is written to.
+	path string
+	// uploadURL is the pre-signed URL the export is uploaded to, if set the
+	// local file is removed once uploaded.
+	uploadURL string
+	// destination is the destination reported in the job status, it does
+	// not include the upload URL since it usually embeds credentials.
+	destination string
+}
+
+type exportJob struct {
+	sync.RWMutex
+	status Job
+	req    jobRequest
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+func (j *exportJob) snapshot() Job {
+	j.RLock()
+	status := j.status
+	j.RUnlock()
+	return status
+}
+
+func (j *exportJob) update(fn func(status *Job)) {
+	j.Lock()
+	fn(&j.status)
+	j.Unlock()
+}
+
+type jobMetrics struct {
+	started    tally.Counter
+	succeeded  tally.Counter
+	failed     tally.Counter
+	cancelled  tally.Counter
+	datapoints tally.Counter
+	bytes      tally.Counter
+	throttled  tally.Timer
+}
+
+func newJobMetrics(scope tally.Scope) jobMetrics {
+	return jobMetrics{
+		started:    scope.Counter("jobs-started"),
+		succeeded:  scope.Counter("jobs-succeeded"),
+		failed:     scope.Counter("jobs-failed"),
+		cancelled:  scope.Counter("jobs-cancelled"),
+		datapoints: scope.Counter("datapoints"),
+		bytes:      scope.Counter("bytes"),
+		throttled:  scope.Timer("throttled"),
+	}
+}
+
+// jobManager runs export jobs server side, at most MaxConcurrentJobs at a
+// time, and keeps the status of recently finished jobs.
+type jobManager struct {
+	sync.RWMutex
+
+	store   storage.Storage
+	opts    Options
+	jobs    map[string]*exportJob
+	order   []string
+	slots   chan struct{}
+	logger  *zap.Logger
+	metrics jobMetrics
+	nowFn   func() time.Time
+	sleepFn func(ctx context.Context, d time.Duration)
+}
+
+func newJobManager(
+	store storage.Storage,
+	opts Options,
+	instrumentOpts instrument.Options,
+) *jobManager {
+	opts = opts.withDefaults()
+	return &jobManager{
+		store:   store,
+		opts:    opts,
+		jobs:    make(map[string]*exportJob),
+		slots:   make(chan struct{}, opts.MaxConcurrentJobs),
+		logger:  instrumentOpts.Logger(),
+		metrics: newJobMetrics(instrumentOpts.MetricsScope().SubScope("export")),
+		nowFn:   time.Now,
+		sleepFn: sleepWithContext,
+	}
+}
+
+func (m *jobManager) start(req jobRequest) (Job, error) {
+	m.Lock()
+	defer m.Unlock()
+
+	pending := 0
+	for _, job := range m.jobs {
+		if !job.snapshot().finished() {
+			pending++
+		}
+	}
+	// Bound pending jobs so that a burst of requests can not queue
+	// unbounded work, jobs beyond the running jobs wait for a slot.
+	if pending >= 2*m.opts.MaxConcurrentJobs {
+		return Job{}, errTooManyJobs
+	}
+
+	queries := make([]string, 0, len(req.queries))
+	for _, q := range req.queries {
+		queries = append(queries, q.Raw)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	job := &exportJob{
+		status: Job{
+			ID:          uuid.New(),
+			State:       JobStatePending,
+			Format:      req.format,
+			Destination: req.destination,
+			Queries:     queries,
+			Start:       req.queries[0].Start,
+			End:         req.queries[0].End,
+			CreatedAt:   m.nowFn(),
+		},
+		req:    req,
+		ctx:    ctx,
+		cancel: cancel,
+	}
+
+	m.jobs[job.status.ID] = job
+	m.order = append(m.order, job.status.ID)
+	m.evictWithLock()
+
+	m.metrics.started.Inc(1)
+	go m.run(job)
+
+	return job.snapshot(), nil
+}
+
+// evictWithLock drops the oldest finished jobs beyond the retained count.
+func (m *jobManager) evictWithLock() {
+	finished := 0
+	for _, id := range m.order {
+		if m.jobs[id].snapshot().finished() {
+			finished++
+		}
+	}
+
+	order := m.order[:0]
+	for _, id := range m.order {
+		if finished > m.opts.RetainedJobs && m.jobs[id].snapshot().finished() {
+			delete(m.jobs, id)
+			finished--
+			continue
+		}
+		order = append(order, id)
+	}
+	m.order = order
+}
+
+func (m *jobManager) get(id string) (Job, error) {
+	m.RLock()
+	job, ok := m.jobs[id]
+	m.RUnlock()
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+
+	return job.snapshot(), nil
+}
+
+func (m *jobManager) list() []Job {
+	m.RLock()
+	defer m.RUnlock()
+
+	jobs := make([]Job, 0, len(m.order))
+	for _, id := range m.order {
+		jobs = append(jobs, m.jobs[id].snapshot())
+	}
+
+	return jobs
+}
+
+func (m *jobManager) cancel(id string) (Job, error) {
+	m.RLock()
+	job, ok := m.jobs[id]
+	m.RUnlock()
+	if !ok {
+		return Job{}, errJobNotFound
+	}
+
+	if job.snapshot().finished() {
+		return Job{}, errJobNotRunning
+	}
+
+	job.cancel()
+	return job.snapshot(), nil
+}
+
+func (m *jobManager) run(job *exportJob) {
+	defer job.cancel()
+
+	select {
+	case m.slots <- struct{}{}:
+		defer func() { <-m.slots }()
+	case <-job.ctx.Done():
+		m.finish(job, errJobCancelled)
+		return
+	}
+
+	job.update(func(status *Job) {
+		status.State = JobStateRunning
+	})
+
+	m.finish(job, m.export(job))
+}
+
+func (m *jobManager) finish(job *exportJob, err error) {
+	now := m.nowFn()
+	job.update(func(status *Job) {
+		status.FinishedAt = &now
+		switch {
+		case err == nil:
+			status.State = JobStateSucceeded
+		case err == errJobCancelled || job.ctx.Err() != nil:
+			status.State = JobStateCancelled
+			status.Error = errJobCancelled.Error()
+		default:
+			status.State = JobStateFailed
+			status.Error = err.Error()
+		}
+	})
+
+	status := job.snapshot()
+	switch status.State {
+	case JobStateSucceeded:
+		m.metrics.succeeded.Inc(1)
+		m.logger.Info("export job succeeded",
+			zap.String("id", status.ID),
+			zap.Int64("datapoints", status.Datapoints),
+			zap.Int64("bytes", status.Bytes))
+	case JobStateCancelled:
+		m.metrics.cancelled.Inc(1)
+		m.logger.Info("export job cancelled", zap.String("id", status.ID))
+	default:
+		m.metrics.failed.Inc(1)
+		m.logger.Error("export job failed",
+			zap.String("id", status.ID), zap.Error(err))
+	}
+}
+
+// export writes the job to a temporary file which is only renamed into
+// place, or uploaded, once the export has completed successfully.
+func (m *jobManager) export(job *exportJob) error {
+	tmpPath := job.req.path + tmpFileSuffix
+	file, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmpPath)
+
+	counting := &countingWriter{file: file}
+	err = m.write(job, counting)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	if job.req.uploadURL != "" {
+		return m.upload(job, tmpPath)
+	}
+
+	return os.Rename(tmpPath, job.req.path)
+}
+
+func (m *jobManager) write(job *exportJob, counting *countingWriter) error {
+	w, err := newRowWriter(job.req.format, counting)
+	if err != nil {
+		return err
+	}
+
+	var (
+		throttleStart = m.nowFn()
+		datapoints    int64
+	)
+	for _, query := range job.req.queries {
+		for start := query.Start; start.Before(query.End); start = start.Add(m.opts.FetchWindow) {
+			if job.ctx.Err() != nil {
+				return errJobCancelled
+			}
+
+			end := start.Add(m.opts.FetchWindow)
+			if end.After(query.End) {
+				end = query.End
+			}
+
+			windowQuery := *query
+			windowQuery.Start = start
+			windowQuery.End = end
+			result, err := m.store.FetchProm(job.ctx, &windowQuery, job.req.fetchOpts)
+			if err != nil {
+				return err
+			}
+
+			var written int64
+			for _, series := range result.PromResult.GetTimeseries() {
+				name := seriesName(series.Labels)
+				for _, sample := range series.Samples {
+					if err := w.WriteRow(name, sample.Timestamp, sample.Value); err != nil {
+						return err
+					}
+				}
+				written += int64(len(series.Samples))
+			}
+
+			datapoints += written
+			m.metrics.datapoints.Inc(written)
+			job.update(func(status *Job) {
+				status.Datapoints = datapoints
+				status.Bytes = counting.n
+			})
+
+			m.throttle(job.ctx, throttleStart, datapoints)
+		}
+	}
+
+	if err := w.Close(); err != nil {
+		return err
+	}
+
+	m.metrics.bytes.Inc(counting.n)
+	job.update(func(status *Job) {
+		status.Bytes = counting.n
+	})
+	return nil
+}
+
+// throttle sleeps until the datapoints exported since start are within the
+// max datapoints per second.
+func (m *jobManager) throttle(ctx context.Context, start time.Time, datapoints int64) {
+	if m.opts.MaxDatapointsPerSecond <= 0 {
+		return
+	}
+
+	expected := time.Duration(float64(datapoints) /
+		float64(m.opts.MaxDatapointsPerSecond) * float64(time.Second))
+	if wait := expected - m.nowFn().Sub(start); wait > 0 {
+		m.metrics.throttled.Record(wait)
+		m.sleepFn(ctx, wait)
+	}
+}
+
+func (m *jobManager) upload(job *exportJob, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPut, job.req.uploadURL, file)
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(job.ctx)
+	req.ContentLength = info.Size()
+	req.Header.Set("Content-Type", job.req.format.contentType())
+
+	resp, err := m.opts.UploadClient.Do(req)
+	if err != nil {
+		// Do not surface the URL in the job status, pre-signed URLs embed
+		// credentials.
+		if urlErr, ok := err.(*url.Error); ok {
+			err = fmt.Errorf("export upload failed: %v", urlErr.Err)
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("export upload failed with status: %s", resp.Status)
+	}
+
+	return nil
+}
+
+type countingWriter struct {
+	file *os.File
+	n    int64
+}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	n, err := w.file.Write(p)
+	w.n += int64(n)
+	return n, err
+}
+
+func sleepWithContext(ctx context.Context, d time.Duration) {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+	case <-ctx.Done():
+	}
+}
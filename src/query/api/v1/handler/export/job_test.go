@@ -0,0 +1,167 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/query/storage"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testJobRequest(dir string) jobRequest {
+	path := filepath.Join(dir, "out.csv")
+	return jobRequest{
+		format: FormatCSV,
+		queries: []*storage.FetchQuery{{
+			Raw:   "match[]=up",
+			Start: time.Unix(0, 0),
+			End:   time.Unix(0, 0).Add(4 * time.Hour),
+		}},
+		fetchOpts:   storage.NewFetchOptions(),
+		path:        path,
+		destination: path,
+	}
+}
+
+func TestJobThrottle(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	store := storage.NewMockStorage(ctrl)
+	store.EXPECT().FetchProm(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(testPromResult).Times(4)
+
+	m := newJobManager(store, Options{
+		Directory:              dir,
+		MaxDatapointsPerSecond: 1,
+	}, instrument.NewOptions())
+
+	now := time.Unix(0, 0)
+	m.nowFn = func() time.Time { return now }
+
+	var slept []time.Duration
+	m.sleepFn = func(_ context.Context, d time.Duration) {
+		slept = append(slept, d)
+	}
+
+	job, err := m.start(testJobRequest(dir))
+	require.NoError(t, err)
+
+	var status Job
+	for i := 0; i < 1000; i++ {
+		status, err = m.get(job.ID)
+		require.NoError(t, err)
+		if status.finished() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	require.Equal(t, JobStateSucceeded, status.State, status.Error)
+	assert.Equal(t, int64(4), status.Datapoints)
+	// The clock does not advance so each datapoint adds a second of wait.
+	assert.Equal(t, []time.Duration{
+		time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second,
+	}, slept)
+}
+
+func TestJobCancelWhilePending(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := newJobManager(storage.NewMockStorage(ctrl), Options{
+		Directory: dir,
+	}, instrument.NewOptions())
+
+	// Occupy the only job slot so the job remains pending.
+	m.slots <- struct{}{}
+
+	job, err := m.start(testJobRequest(dir))
+	require.NoError(t, err)
+	assert.Equal(t, JobStatePending, job.State)
+
+	_, err = m.cancel(job.ID)
+	require.NoError(t, err)
+
+	var status Job
+	for i := 0; i < 1000; i++ {
+		status, err = m.get(job.ID)
+		require.NoError(t, err)
+		if status.finished() {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	assert.Equal(t, JobStateCancelled, status.State)
+
+	_, err = m.cancel(job.ID)
+	assert.Equal(t, errJobNotRunning, err)
+
+	_, err = m.get("unknown")
+	assert.Equal(t, errJobNotFound, err)
+}
+
+func TestJobManagerLimitsPendingJobs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	dir, err := ioutil.TempDir("", "export_test")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	m := newJobManager(storage.NewMockStorage(ctrl), Options{
+		Directory: dir,
+	}, instrument.NewOptions())
+	m.slots <- struct{}{}
+
+	var ids []string
+	for i := 0; i < 2; i++ {
+		job, err := m.start(testJobRequest(dir))
+		require.NoError(t, err)
+		ids = append(ids, job.ID)
+	}
+
+	_, err = m.start(testJobRequest(dir))
+	assert.Equal(t, errTooManyJobs, err)
+	assert.Len(t, m.list(), 2)
+
+	for _, id := range ids {
+		_, err := m.cancel(id)
+		require.NoError(t, err)
+	}
+}
@@ -0,0 +1,378 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"bufio"
+	"encoding/binary"
+	"io"
+	"math"
+)
+
+const (
+	parquetMagic = "PAR1"
+
+	// parquetRowGroupRows is the number of rows buffered before a row group
+	// is written, each column of a row group is written as a single page.
+	parquetRowGroupRows = 1 << 16
+
+	parquetCreatedBy = "m3query export"
+)
+
+// Parquet format enum values, see parquet.thrift in the parquet-format repo.
+const (
+	parquetTypeInt64     = 2
+	parquetTypeDouble    = 5
+	parquetTypeByteArray = 6
+
+	parquetConvertedTypeUTF8            = 0
+	parquetConvertedTypeTimestampMillis = 9
+
+	parquetRepetitionRequired = 0
+
+	parquetEncodingPlain = 0
+	parquetEncodingRLE   = 3
+
+	parquetCodecUncompressed = 0
+
+	parquetPageTypeData = 0
+)
+
+type parquetColumn struct {
+	name          string
+	physicalType  int32
+	convertedType int32
+}
+
+// parquetColumns are the columns of an export, matching the CSV columns.
+var parquetColumns = []parquetColumn{
+	{name: seriesColumn, physicalType: parquetTypeByteArray, convertedType: parquetConvertedTypeUTF8},
+	{name: timestampColumn, physicalType: parquetTypeInt64, convertedType: parquetConvertedTypeTimestampMillis},
+	{name: valueColumn, physicalType: parquetTypeDouble, convertedType: -1},
+}
+
+type parquetColumnChunk struct {
+	offset int64
+	size   int64
+}
+
+type parquetRowGroup struct {
+	numRows int64
+	columns []parquetColumnChunk
+}
+
+// parquetWriter writes rows to a Parquet file with a flat schema of required
+// columns. Pages are PLAIN encoded and uncompressed which keeps the writer
+// small while remaining readable by any Parquet implementation.
+type parquetWriter struct {
+	w         *bufio.Writer
+	offset    int64
+	series    [][]byte
+	seriesLen int
+	times     []int64
+	values    []float64
+	rowGroups []parquetRowGroup
+	numRows   int64
+	page      []byte
+}
+
+func newParquetWriter(w io.Writer) (*parquetWriter, error) {
+	pw := &parquetWriter{w: bufio.NewWriter(w)}
+	if err := pw.write([]byte(parquetMagic)); err != nil {
+		return nil, err
+	}
+
+	return pw, nil
+}
+
+func (w *parquetWriter) WriteRow(series []byte, timestampMs int64, value float64) error {
+	w.series = append(w.series, series)
+	w.seriesLen += len(series)
+	w.times = append(w.times, timestampMs)
+	w.values = append(w.values, value)
+	if len(w.times) < parquetRowGroupRows {
+		return nil
+	}
+
+	return w.flushRowGroup()
+}
+
+func (w *parquetWriter) Close() error {
+	if err := w.flushRowGroup(); err != nil {
+		return err
+	}
+
+	footer := w.encodeFileMetadata()
+	var footerLen [4]byte
+	binary.LittleEndian.PutUint32(footerLen[:], uint32(len(footer)))
+	for _, b := range [][]byte{footer, footerLen[:], []byte(parquetMagic)} {
+		if err := w.write(b); err != nil {
+			return err
+		}
+	}
+
+	return w.w.Flush()
+}
+
+func (w *parquetWriter) write(b []byte) error {
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	return err
+}
+
+func (w *parquetWriter) flushRowGroup() error {
+	numRows := len(w.times)
+	if numRows == 0 {
+		return nil
+	}
+
+	group := parquetRowGroup{
+		numRows: int64(numRows),
+		columns: make([]parquetColumnChunk, 0, len(parquetColumns)),
+	}
+
+	for i := range parquetColumns {
+		w.page = w.page[:0]
+		switch i {
+		case 0:
+			w.page = growBytes(w.page, 4*numRows+w.seriesLen)
+			for _, s := range w.series {
+				w.page = appendUint32(w.page, uint32(len(s)))
+				w.page = append(w.page, s...)
+			}
+		case 1:
+			w.page = growBytes(w.page, 8*numRows)
+			for _, t := range w.times {
+				w.page = appendUint64(w.page, uint64(t))
+			}
+		case 2:
+			w.page = growBytes(w.page, 8*numRows)
+			for _, v := range w.values {
+				w.page = appendUint64(w.page, math.Float64bits(v))
+			}
+		}
+
+		offset := w.offset
+		header := encodeParquetPageHeader(int32(len(w.page)), int32(numRows))
+		if err := w.write(header); err != nil {
+			return err
+		}
+		if err := w.write(w.page); err != nil {
+			return err
+		}
+
+		group.columns = append(group.columns, parquetColumnChunk{
+			offset: offset,
+			size:   w.offset - offset,
+		})
+	}
+
+	w.rowGroups = append(w.rowGroups, group)
+	w.numRows += int64(numRows)
+	w.series = w.series[:0]
+	w.seriesLen = 0
+	w.times = w.times[:0]
+	w.values = w.values[:0]
+	return nil
+}
+
+func encodeParquetPageHeader(size, numValues int32) []byte {
+	var e thriftCompactEncoder
+	e.structBegin()
+	e.i32Field(1, parquetPageTypeData)
+	e.i32Field(2, size)
+	e.i32Field(3, size)
+	e.structFieldBegin(5)
+	e.i32Field(1, numValues)
+	e.i32Field(2, parquetEncodingPlain)
+	e.i32Field(3, parquetEncodingRLE)
+	e.i32Field(4, parquetEncodingRLE)
+	e.structEnd()
+	e.structEnd()
+	return e.buf
+}
+
+func (w *parquetWriter) encodeFileMetadata() []byte {
+	var e thriftCompactEncoder
+	e.structBegin()
+	e.i32Field(1, 1)
+
+	e.listFieldBegin(2, thriftCompactStruct, 1+len(parquetColumns))
+	e.structBegin()
+	e.binaryField(4, []byte("schema"))
+	e.i32Field(5, int32(len(parquetColumns)))
+	e.structEnd()
+	for _, c := range parquetColumns {
+		e.structBegin()
+		e.i32Field(1, c.physicalType)
+		e.i32Field(3, parquetRepetitionRequired)
+		e.binaryField(4, []byte(c.name))
+		if c.convertedType >= 0 {
+			e.i32Field(6, c.convertedType)
+		}
+		e.structEnd()
+	}
+
+	e.i64Field(3, w.numRows)
+
+	e.listFieldBegin(4, thriftCompactStruct, len(w.rowGroups))
+	for _, group := range w.rowGroups {
+		var totalSize int64
+		for _, c := range group.columns {
+			totalSize += c.size
+		}
+
+		e.structBegin()
+		e.listFieldBegin(1, thriftCompactStruct, len(group.columns))
+		for i, c := range group.columns {
+			column := parquetColumns[i]
+			e.structBegin()
+			e.i64Field(2, c.offset)
+			e.structFieldBegin(3)
+			e.i32Field(1, column.physicalType)
+			e.listFieldBegin(2, thriftCompactI32, 2)
+			e.i32(parquetEncodingPlain)
+			e.i32(parquetEncodingRLE)
+			e.listFieldBegin(3, thriftCompactBinary, 1)
+			e.binary([]byte(column.name))
+			e.i32Field(4, parquetCodecUncompressed)
+			e.i64Field(5, group.numRows)
+			e.i64Field(6, c.size)
+			e.i64Field(7, c.size)
+			e.i64Field(9, c.offset)
+			e.structEnd()
+			e.structEnd()
+		}
+		e.i64Field(2, totalSize)
+		e.i64Field(3, group.numRows)
+		e.structEnd()
+	}
+
+	e.binaryField(6, []byte(parquetCreatedBy))
+	e.structEnd()
+	return e.buf
+}
+
+func growBytes(b []byte, n int) []byte {
+	if cap(b)-len(b) >= n {
+		return b
+	}
+
+	grown := make([]byte, len(b), len(b)+n)
+	copy(grown, b)
+	return grown
+}
+
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// Thrift compact protocol type identifiers.
+const (
+	thriftCompactI32    = 5
+	thriftCompactI64    = 6
+	thriftCompactBinary = 8
+	thriftCompactList   = 9
+	thriftCompactStruct = 12
+)
+
+// thriftCompactEncoder encodes the subset of the thrift compact protocol
+// required for Parquet page headers and file metadata.
+type thriftCompactEncoder struct {
+	buf        []byte
+	lastFields []int16
+}
+
+func (e *thriftCompactEncoder) structBegin() {
+	e.lastFields = append(e.lastFields, 0)
+}
+
+func (e *thriftCompactEncoder) structEnd() {
+	e.buf = append(e.buf, 0)
+	e.lastFields = e.lastFields[:len(e.lastFields)-1]
+}
+
+func (e *thriftCompactEncoder) fieldBegin(id int16, typ byte) {
+	last := &e.lastFields[len(e.lastFields)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		e.buf = append(e.buf, byte(delta)<<4|typ)
+	} else {
+		e.buf = append(e.buf, typ)
+		e.i32(int32(id))
+	}
+	*last = id
+}
+
+func (e *thriftCompactEncoder) structFieldBegin(id int16) {
+	e.fieldBegin(id, thriftCompactStruct)
+	e.structBegin()
+}
+
+func (e *thriftCompactEncoder) listFieldBegin(id int16, elemType byte, size int) {
+	e.fieldBegin(id, thriftCompactList)
+	if size < 15 {
+		e.buf = append(e.buf, byte(size)<<4|elemType)
+		return
+	}
+	e.buf = append(e.buf, 0xf0|elemType)
+	e.uvarint(uint64(size))
+}
+
+func (e *thriftCompactEncoder) i32Field(id int16, v int32) {
+	e.fieldBegin(id, thriftCompactI32)
+	e.i32(v)
+}
+
+func (e *thriftCompactEncoder) i64Field(id int16, v int64) {
+	e.fieldBegin(id, thriftCompactI64)
+	e.i64(v)
+}
+
+func (e *thriftCompactEncoder) binaryField(id int16, v []byte) {
+	e.fieldBegin(id, thriftCompactBinary)
+	e.binary(v)
+}
+
+func (e *thriftCompactEncoder) i32(v int32) {
+	e.uvarint(uint64(uint32((v << 1) ^ (v >> 31))))
+}
+
+func (e *thriftCompactEncoder) i64(v int64) {
+	e.uvarint(uint64((v << 1) ^ (v >> 63)))
+}
+
+func (e *thriftCompactEncoder) binary(v []byte) {
+	e.uvarint(uint64(len(v)))
+	e.buf = append(e.buf, v...)
+}
+
+func (e *thriftCompactEncoder) uvarint(v uint64) {
+	var buf [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(buf[:], v)
+	e.buf = append(e.buf, buf[:n]...)
+}
@@ -0,0 +1,151 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// thriftCompactDecoder decodes thrift compact structs into maps of field ID
+// to value, it is only used to verify the written Parquet metadata.
+type thriftCompactDecoder struct {
+	t   *testing.T
+	buf []byte
+	idx int
+}
+
+func (d *thriftCompactDecoder) uvarint() uint64 {
+	v, n := binary.Uvarint(d.buf[d.idx:])
+	require.True(d.t, n > 0)
+	d.idx += n
+	return v
+}
+
+func (d *thriftCompactDecoder) zigzag() int64 {
+	v := d.uvarint()
+	return int64(v>>1) ^ -int64(v&1)
+}
+
+func (d *thriftCompactDecoder) value(typ byte) interface{} {
+	switch typ {
+	case thriftCompactI32, thriftCompactI64:
+		return d.zigzag()
+	case thriftCompactBinary:
+		n := int(d.uvarint())
+		v := d.buf[d.idx : d.idx+n]
+		d.idx += n
+		return string(v)
+	case thriftCompactStruct:
+		return d.structValue()
+	case thriftCompactList:
+		header := d.buf[d.idx]
+		d.idx++
+		size := int(header >> 4)
+		if size == 15 {
+			size = int(d.uvarint())
+		}
+		list := make([]interface{}, 0, size)
+		for i := 0; i < size; i++ {
+			list = append(list, d.value(header&0x0f))
+		}
+		return list
+	default:
+		require.FailNow(d.t, "unexpected thrift type", "type: %d", typ)
+		return nil
+	}
+}
+
+func (d *thriftCompactDecoder) structValue() map[int64]interface{} {
+	var (
+		result = make(map[int64]interface{})
+		last   int64
+	)
+	for {
+		header := d.buf[d.idx]
+		d.idx++
+		if header == 0 {
+			return result
+		}
+
+		id := last + int64(header>>4)
+		if header>>4 == 0 {
+			id = d.zigzag()
+		}
+		last = id
+		result[id] = d.value(header & 0x0f)
+	}
+}
+
+func TestParquetWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newRowWriter(FormatParquet, &buf)
+	require.NoError(t, err)
+
+	numRows := parquetRowGroupRows + 10
+	for i := 0; i < numRows; i++ {
+		require.NoError(t, w.WriteRow([]byte(`up{job="a"}`), int64(i), float64(i)/2))
+	}
+	require.NoError(t, w.Close())
+
+	data := buf.Bytes()
+	require.Equal(t, parquetMagic, string(data[:4]))
+	require.Equal(t, parquetMagic, string(data[len(data)-4:]))
+
+	footerLen := int(binary.LittleEndian.Uint32(data[len(data)-8:]))
+	footer := &thriftCompactDecoder{t: t, buf: data, idx: len(data) - 8 - footerLen}
+	metadata := footer.structValue()
+	require.Equal(t, len(data)-8, footer.idx)
+
+	assert.Equal(t, int64(numRows), metadata[3])
+	schema := metadata[2].([]interface{})
+	require.Len(t, schema, 4)
+	assert.Equal(t, "schema", schema[0].(map[int64]interface{})[4])
+	assert.Equal(t, "timestamp", schema[2].(map[int64]interface{})[4])
+
+	rowGroups := metadata[4].([]interface{})
+	require.Len(t, rowGroups, 2)
+
+	var rows int64
+	for _, rg := range rowGroups {
+		rowGroup := rg.(map[int64]interface{})
+		columns := rowGroup[1].([]interface{})
+		require.Len(t, columns, len(parquetColumns))
+
+		// Verify the values column page decodes to the written values.
+		column := columns[2].(map[int64]interface{})[3].(map[int64]interface{})
+		offset := column[9].(int64)
+		page := &thriftCompactDecoder{t: t, buf: data, idx: int(offset)}
+		header := page.structValue()
+		numValues := header[5].(map[int64]interface{})[1].(int64)
+		assert.Equal(t, rowGroup[3], numValues)
+		assert.Equal(t, column[6], int64(page.idx)-offset+header[2].(int64))
+
+		first := math.Float64frombits(binary.LittleEndian.Uint64(data[page.idx:]))
+		assert.Equal(t, float64(rows)/2, first)
+		rows += numValues
+	}
+	assert.Equal(t, int64(numRows), rows)
+}
@@ -0,0 +1,158 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+)
+
+// Format is the file format of an export.
+type Format string
+
+const (
+	// FormatCSV exports rows as comma separated values with a header row.
+	FormatCSV Format = "csv"
+	// FormatParquet exports rows as an uncompressed Parquet file.
+	FormatParquet Format = "parquet"
+)
+
+const (
+	seriesColumn    = "series"
+	timestampColumn = "timestamp"
+	valueColumn     = "value"
+)
+
+var promNameLabel = []byte("__name__")
+
+// ParseFormat parses an export format.
+func ParseFormat(str string) (Format, error) {
+	switch f := Format(str); f {
+	case FormatCSV, FormatParquet:
+		return f, nil
+	default:
+		return "", fmt.Errorf("unknown export format: %s", str)
+	}
+}
+
+// contentType returns the content type used when uploading the format.
+func (f Format) contentType() string {
+	if f == FormatParquet {
+		return "application/octet-stream"
+	}
+
+	return "text/csv"
+}
+
+// rowWriter writes the rows of an export, a row per datapoint.
+type rowWriter interface {
+	// WriteRow writes a datapoint of a series.
+	WriteRow(series []byte, timestampMs int64, value float64) error
+
+	// Close flushes any buffered rows, it does not close the underlying writer.
+	Close() error
+}
+
+func newRowWriter(format Format, w io.Writer) (rowWriter, error) {
+	switch format {
+	case FormatCSV:
+		return newCSVWriter(w)
+	case FormatParquet:
+		return newParquetWriter(w)
+	default:
+		return nil, fmt.Errorf("unknown export format: %s", format)
+	}
+}
+
+type csvWriter struct {
+	w      *bufio.Writer
+	csv    *csv.Writer
+	record []string
+}
+
+func newCSVWriter(w io.Writer) (*csvWriter, error) {
+	buffered := bufio.NewWriter(w)
+	cw := &csvWriter{
+		w:      buffered,
+		csv:    csv.NewWriter(buffered),
+		record: make([]string, 3),
+	}
+	if err := cw.csv.Write([]string{
+		seriesColumn, timestampColumn, valueColumn,
+	}); err != nil {
+		return nil, err
+	}
+
+	return cw, nil
+}
+
+func (w *csvWriter) WriteRow(series []byte, timestampMs int64, value float64) error {
+	w.record[0] = string(series)
+	w.record[1] = strconv.FormatInt(timestampMs, 10)
+	w.record[2] = strconv.FormatFloat(value, 'g', -1, 64)
+	return w.csv.Write(w.record)
+}
+
+func (w *csvWriter) Close() error {
+	w.csv.Flush()
+	if err := w.csv.Error(); err != nil {
+		return err
+	}
+
+	return w.w.Flush()
+}
+
+// seriesName formats labels the way Prometheus formats series, for example
+// http_requests_total{code="200",method="get"}.
+func seriesName(labels []prompb.Label) []byte {
+	var (
+		buf   bytes.Buffer
+		first = true
+	)
+	for _, l := range labels {
+		if bytes.Equal(l.Name, promNameLabel) {
+			buf.Write(l.Value)
+			break
+		}
+	}
+
+	buf.WriteByte('{')
+	for _, l := range labels {
+		if bytes.Equal(l.Name, promNameLabel) {
+			continue
+		}
+		if !first {
+			buf.WriteByte(',')
+		}
+		first = false
+		buf.Write(l.Name)
+		buf.WriteByte('=')
+		buf.WriteString(strconv.Quote(string(l.Value)))
+	}
+	buf.WriteByte('}')
+
+	return buf.Bytes()
+}
@@ -0,0 +1,72 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package export
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/m3db/m3/src/query/generated/proto/prompb"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFormat(t *testing.T) {
+	format, err := ParseFormat("csv")
+	require.NoError(t, err)
+	assert.Equal(t, FormatCSV, format)
+
+	format, err = ParseFormat("parquet")
+	require.NoError(t, err)
+	assert.Equal(t, FormatParquet, format)
+
+	_, err = ParseFormat("xlsx")
+	require.Error(t, err)
+}
+
+func TestSeriesName(t *testing.T) {
+	name := seriesName([]prompb.Label{
+		{Name: []byte("__name__"), Value: []byte("http_requests_total")},
+		{Name: []byte("code"), Value: []byte("200")},
+		{Name: []byte("path"), Value: []byte(`/a"b`)},
+	})
+	assert.Equal(t, `http_requests_total{code="200",path="/a\"b"}`, string(name))
+
+	name = seriesName([]prompb.Label{
+		{Name: []byte("job"), Value: []byte("node")},
+	})
+	assert.Equal(t, `{job="node"}`, string(name))
+}
+
+func TestCSVWriter(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := newRowWriter(FormatCSV, &buf)
+	require.NoError(t, err)
+
+	require.NoError(t, w.WriteRow([]byte(`up{job="a"}`), 1000, 1))
+	require.NoError(t, w.WriteRow([]byte(`up{job="a"}`), 2000, 0.5))
+	require.NoError(t, w.Close())
+
+	expected := "series,timestamp,value\n" +
+		"\"up{job=\"\"a\"\"}\",1000,1\n" +
+		"\"up{job=\"\"a\"\"}\",2000,0.5\n"
+	assert.Equal(t, expected, buf.String())
+}
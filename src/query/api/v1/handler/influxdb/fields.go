@@ -0,0 +1,110 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxdb
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+)
+
+const defaultFieldTag = "field"
+
+type fieldExpansion int
+
+const (
+	suffixFieldExpansion fieldExpansion = iota
+	tagFieldExpansion
+	measurementFieldExpansion
+	dropFieldExpansion
+)
+
+// fieldRule is a compiled rule for expanding the fields of a point into series.
+type fieldRule struct {
+	measurement *regexp.Regexp
+	field       *regexp.Regexp
+	expansion   fieldExpansion
+	fieldTag    []byte
+}
+
+type fieldRules []fieldRule
+
+func newFieldRules(
+	cfgs []config.InfluxDBFieldRuleConfiguration,
+	promRewriter *promRewriter,
+) (fieldRules, error) {
+	rules := make(fieldRules, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		rule := fieldRule{}
+		switch cfg.Expansion {
+		case config.InfluxDBFieldExpansionSuffix:
+			rule.expansion = suffixFieldExpansion
+		case config.InfluxDBFieldExpansionTag:
+			rule.expansion = tagFieldExpansion
+		case config.InfluxDBFieldExpansionMeasurement:
+			rule.expansion = measurementFieldExpansion
+		case config.InfluxDBFieldExpansionDrop:
+			rule.expansion = dropFieldExpansion
+		default:
+			return nil, fmt.Errorf("invalid influxdb field expansion: %s", cfg.Expansion)
+		}
+
+		var err error
+		if cfg.Measurement != "" {
+			if rule.measurement, err = regexp.Compile(cfg.Measurement); err != nil {
+				return nil, fmt.Errorf("invalid influxdb field rule measurement: %v", err)
+			}
+		}
+		if cfg.Field != "" {
+			if rule.field, err = regexp.Compile(cfg.Field); err != nil {
+				return nil, fmt.Errorf("invalid influxdb field rule field: %v", err)
+			}
+		}
+
+		if rule.expansion == tagFieldExpansion {
+			fieldTag := cfg.FieldTag
+			if fieldTag == "" {
+				fieldTag = defaultFieldTag
+			}
+			rule.fieldTag = []byte(fieldTag)
+			promRewriter.rewriteLabel(rule.fieldTag)
+		}
+
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// match returns the first rule matching the measurement and field key,
+// defaulting to the suffix expansion.
+func (r fieldRules) match(measurement, field []byte) fieldRule {
+	for _, rule := range r {
+		if rule.measurement != nil && !rule.measurement.Match(measurement) {
+			continue
+		}
+		if rule.field != nil && !rule.field.Match(field) {
+			continue
+		}
+		return rule
+	}
+	return fieldRule{expansion: suffixFieldExpansion}
+}
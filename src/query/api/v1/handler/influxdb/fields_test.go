@@ -0,0 +1,69 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package influxdb
+
+import (
+	"testing"
+
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFieldRulesMatch(t *testing.T) {
+	rules, err := newFieldRules([]config.InfluxDBFieldRuleConfiguration{
+		{Measurement: "^cpu$", Field: "^usage_", Expansion: config.InfluxDBFieldExpansionTag},
+		{Measurement: "^mem$", Expansion: config.InfluxDBFieldExpansionDrop},
+		{Field: "^value$", Expansion: config.InfluxDBFieldExpansionMeasurement},
+		{Measurement: "^disk$", Expansion: config.InfluxDBFieldExpansionTag, FieldTag: "disk-field"},
+	}, newPromRewriter())
+	require.NoError(t, err)
+
+	rule := rules.match([]byte("cpu"), []byte("usage_idle"))
+	assert.Equal(t, tagFieldExpansion, rule.expansion)
+	assert.Equal(t, []byte("field"), rule.fieldTag)
+
+	rule = rules.match([]byte("cpu"), []byte("time_idle"))
+	assert.Equal(t, suffixFieldExpansion, rule.expansion)
+
+	rule = rules.match([]byte("mem"), []byte("used"))
+	assert.Equal(t, dropFieldExpansion, rule.expansion)
+
+	rule = rules.match([]byte("temperature"), []byte("value"))
+	assert.Equal(t, measurementFieldExpansion, rule.expansion)
+
+	rule = rules.match([]byte("disk"), []byte("free"))
+	assert.Equal(t, tagFieldExpansion, rule.expansion)
+	assert.Equal(t, []byte("disk_field"), rule.fieldTag)
+}
+
+func TestNewFieldRulesInvalid(t *testing.T) {
+	_, err := newFieldRules([]config.InfluxDBFieldRuleConfiguration{
+		{Expansion: "unknown"},
+	}, newPromRewriter())
+	require.Error(t, err)
+
+	_, err = newFieldRules([]config.InfluxDBFieldRuleConfiguration{
+		{Measurement: "(", Expansion: config.InfluxDBFieldExpansionDrop},
+	}, newPromRewriter())
+	require.Error(t, err)
+}
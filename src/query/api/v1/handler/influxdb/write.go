@@ -22,6 +22,7 @@ package influxdb
 
 import (
 	"bytes"
+	"compress/gzip"
 	"errors"
 	"fmt"
 	"io/ioutil"
@@ -29,6 +30,7 @@ import (
 	"time"
 
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/api/v1/options"
@@ -55,10 +57,12 @@ type ingestWriteHandler struct {
 	handlerOpts  options.HandlerOptions
 	tagOpts      models.TagOptions
 	promRewriter *promRewriter
+	fieldRules   fieldRules
 }
 
 type ingestField struct {
-	name  []byte // to be stored in __name__; rest of tags stay constant for the Point
+	name  []byte      // to be stored in __name__; rest of tags stay constant for the Point
+	tag   *models.Tag // holds the field key when expanded to a tag
 	value float64
 }
 
@@ -67,6 +71,7 @@ type ingestIterator struct {
 	points       []imodels.Point
 	tagOpts      models.TagOptions
 	promRewriter *promRewriter
+	fieldRules   fieldRules
 
 	// internal
 	pointIndex int
@@ -130,15 +135,48 @@ func (ii *ingestIterator) populateFields() bool {
 			continue
 		}
 		tail := it.FieldKey()
-		name := make([]byte, 0, bnamelen+len(tail))
-		name = append(name, bname...)
-		name = append(name, tail...)
-		ii.promRewriter.rewriteMetricTail(name[bnamelen:])
-		ii.fields = append(ii.fields, &ingestField{name: name, value: value})
+		rule := ii.fieldRules.match(point.Name(), tail)
+		switch rule.expansion {
+		case dropFieldExpansion:
+			continue
+		case tagFieldExpansion, measurementFieldExpansion:
+			// Drop the trailing separator of the measurement name.
+			name := make([]byte, bnamelen-1)
+			copy(name, bname)
+			field := &ingestField{name: name, value: value}
+			if rule.expansion == tagFieldExpansion {
+				fieldKey := make([]byte, len(tail))
+				copy(fieldKey, tail)
+				field.tag = &models.Tag{Name: rule.fieldTag, Value: fieldKey}
+			}
+			ii.fields = append(ii.fields, field)
+		default:
+			name := make([]byte, 0, bnamelen+len(tail))
+			name = append(name, bname...)
+			name = append(name, tail...)
+			ii.promRewriter.rewriteMetricTail(name[bnamelen:])
+			ii.fields = append(ii.fields, &ingestField{name: name, value: value})
+		}
 	}
 	return n > 0
 }
 
+// removeFieldsWithDuplicateTags removes the fields expanded to a tag that is
+// already set on the current point.
+func (ii *ingestIterator) removeFieldsWithDuplicateTags() {
+	fields := ii.fields[:0]
+	for _, field := range ii.fields {
+		if field.tag != nil {
+			if _, exists := ii.tags.Get(field.tag.Name); exists {
+				ii.err = ii.err.Add(fmt.Errorf("non-unique Prometheus label %v", string(field.tag.Name)))
+				continue
+			}
+		}
+		fields = append(fields, field)
+	}
+	ii.fields = fields
+}
+
 func (ii *ingestIterator) Next() bool {
 	for len(ii.points) > ii.pointIndex {
 		if ii.nextFieldIndex == 0 {
@@ -176,6 +214,7 @@ func (ii *ingestIterator) Next() bool {
 					continue
 				}
 				ii.tags = tags
+				ii.removeFieldsWithDuplicateTags()
 			}
 		}
 		ii.nextFieldIndex += 1
@@ -223,6 +262,9 @@ func (ii *ingestIterator) Current() (models.Tags, ts.Datapoints, xtime.Unit, []b
 		point := ii.points[ii.pointIndex]
 		field := ii.fields[ii.nextFieldIndex-1]
 		tags := copyTagsWithNewName(ii.tags, field.name)
+		if field.tag != nil {
+			tags = tags.AddTag(*field.tag)
+		}
 
 		t := point.Time()
 
@@ -243,25 +285,53 @@ func (ii *ingestIterator) Error() error {
 	return ii.err.FinalError()
 }
 
-func NewInfluxWriterHandler(options options.HandlerOptions) http.Handler {
+// NewInfluxWriterHandler returns a new handler for writing InfluxDB line
+// protocol points, expanding the fields of points into series using the
+// configured field rules.
+func NewInfluxWriterHandler(options options.HandlerOptions) (http.Handler, error) {
+	var ruleCfgs []config.InfluxDBFieldRuleConfiguration
+	if influxCfg := options.Config().InfluxDB; influxCfg != nil {
+		ruleCfgs = influxCfg.FieldRules
+	}
+	promRewriter := newPromRewriter()
+	rules, err := newFieldRules(ruleCfgs, promRewriter)
+	if err != nil {
+		return nil, err
+	}
 	return &ingestWriteHandler{handlerOpts: options,
 		tagOpts:      options.TagOptions(),
-		promRewriter: newPromRewriter()}
+		promRewriter: promRewriter,
+		fieldRules:   rules}, nil
 }
 
 func (iwh *ingestWriteHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	bytes, err := ioutil.ReadAll(r.Body)
+	body := r.Body
+	// Telegraf gzips request bodies by default.
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		gzipReader, err := gzip.NewReader(r.Body)
+		if err != nil {
+			xhttp.Error(w, err, http.StatusBadRequest)
+			return
+		}
+		defer gzipReader.Close()
+		body = gzipReader
+	}
+	bytes, err := ioutil.ReadAll(body)
 	if err != nil {
 		xhttp.Error(w, err, http.StatusInternalServerError)
 		return
 	}
-	points, err := imodels.ParsePoints(bytes)
+	// Timestamps are in nanoseconds unless a precision is specified, matching
+	// the InfluxDB write API.
+	precision := r.URL.Query().Get("precision")
+	points, err := imodels.ParsePointsWithPrecision(bytes, time.Now().UTC(), precision)
 	if err != nil {
 		xhttp.Error(w, err, http.StatusInternalServerError)
 		return
 	}
 	opts := ingest.WriteOptions{}
-	iter := &ingestIterator{points: points, tagOpts: iwh.tagOpts, promRewriter: iwh.promRewriter}
+	iter := &ingestIterator{points: points, tagOpts: iwh.tagOpts,
+		promRewriter: iwh.promRewriter, fieldRules: iwh.fieldRules}
 	batchErr := iwh.handlerOpts.DownsamplerAndWriter().WriteBatch(r.Context(), iter, opts)
 	if batchErr == nil {
 		w.WriteHeader(http.StatusNoContent)
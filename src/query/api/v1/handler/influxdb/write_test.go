@@ -25,6 +25,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	xtime "github.com/m3db/m3/src/x/time"
 	imodels "github.com/influxdata/influxdb/models"
 	"github.com/stretchr/testify/assert"
@@ -118,6 +119,57 @@ func TestIngestIteratorIssue2125(t *testing.T) {
 	assert.Equal(t, t2.String(), "__name__: measure_k2, lab: foo")
 }
 
+func TestIngestIteratorFieldRules(t *testing.T) {
+	s := `cpu,host=a usage_idle=1,usage_user=2,time_idle=3i 1574838670386469800
+mem,host=a used=4i 1574838670386469800
+temperature,host=a value=5 1574838670386469800
+`
+	points, err := imodels.ParsePoints([]byte(s))
+	require.NoError(t, err)
+
+	promRewriter := newPromRewriter()
+	rules, err := newFieldRules([]config.InfluxDBFieldRuleConfiguration{
+		{Measurement: "^cpu$", Field: "^usage_", Expansion: config.InfluxDBFieldExpansionTag},
+		{Measurement: "^mem$", Expansion: config.InfluxDBFieldExpansionDrop},
+		{Field: "^value$", Expansion: config.InfluxDBFieldExpansionMeasurement},
+	}, promRewriter)
+	require.NoError(t, err)
+
+	iter := &ingestIterator{points: points, promRewriter: promRewriter, fieldRules: rules}
+	for _, line := range []string{
+		"__name__: cpu, field: usage_idle, host: a 1 2019-11-27 07:11:10.3864698 +0000 UTC",
+		"__name__: cpu, field: usage_user, host: a 2 2019-11-27 07:11:10.3864698 +0000 UTC",
+		"__name__: cpu_time_idle, host: a 3 2019-11-27 07:11:10.3864698 +0000 UTC",
+		"__name__: temperature, host: a 5 2019-11-27 07:11:10.3864698 +0000 UTC",
+		"",
+	} {
+		assert.Equal(t, line, iter.pop(t))
+	}
+	require.NoError(t, iter.Error())
+}
+
+func TestIngestIteratorFieldRuleDuplicateTag(t *testing.T) {
+	s := `cpu,field=x usage_idle=1,time_idle=3i 1574838670386469800
+`
+	points, err := imodels.ParsePoints([]byte(s))
+	require.NoError(t, err)
+
+	promRewriter := newPromRewriter()
+	rules, err := newFieldRules([]config.InfluxDBFieldRuleConfiguration{
+		{Field: "^usage_", Expansion: config.InfluxDBFieldExpansionTag},
+	}, promRewriter)
+	require.NoError(t, err)
+
+	iter := &ingestIterator{points: points, promRewriter: promRewriter, fieldRules: rules}
+	for _, line := range []string{
+		"__name__: cpu_time_idle, field: x 3 2019-11-27 07:11:10.3864698 +0000 UTC",
+		"",
+	} {
+		assert.Equal(t, line, iter.pop(t))
+	}
+	require.EqualError(t, iter.Error(), "non-unique Prometheus label field")
+}
+
 func TestDetermineTimeUnit(t *testing.T) {
 	now := time.Now()
 	zerot := now.Add(time.Duration(-now.UnixNano() % int64(time.Second)))
@@ -105,7 +105,7 @@ func TestNamespaceAddHandler(t *testing.T) {
 	resp = w.Result()
 	body, _ = ioutil.ReadAll(resp.Body)
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
-	assert.Equal(t, "{\"registry\":{\"namespaces\":{\"testNamespace\":{\"bootstrapEnabled\":true,\"flushEnabled\":true,\"writesToCommitLog\":true,\"cleanupEnabled\":true,\"repairEnabled\":true,\"retentionOptions\":{\"retentionPeriodNanos\":\"172800000000000\",\"blockSizeNanos\":\"7200000000000\",\"bufferFutureNanos\":\"600000000000\",\"bufferPastNanos\":\"600000000000\",\"blockDataExpiry\":true,\"blockDataExpiryAfterNotAccessPeriodNanos\":\"300000000000\",\"futureRetentionPeriodNanos\":\"0\"},\"snapshotEnabled\":true,\"indexOptions\":{\"enabled\":true,\"blockSizeNanos\":\"7200000000000\"},\"schemaOptions\":null,\"coldWritesEnabled\":false}}}}", string(body))
+	assert.Equal(t, "{\"registry\":{\"namespaces\":{\"testNamespace\":{\"bootstrapEnabled\":true,\"flushEnabled\":true,\"writesToCommitLog\":true,\"cleanupEnabled\":true,\"repairEnabled\":true,\"retentionOptions\":{\"retentionPeriodNanos\":\"172800000000000\",\"blockSizeNanos\":\"7200000000000\",\"bufferFutureNanos\":\"600000000000\",\"bufferPastNanos\":\"600000000000\",\"blockDataExpiry\":true,\"blockDataExpiryAfterNotAccessPeriodNanos\":\"300000000000\",\"futureRetentionPeriodNanos\":\"0\"},\"snapshotEnabled\":true,\"indexOptions\":{\"enabled\":true,\"blockSizeNanos\":\"7200000000000\"},\"schemaOptions\":null,\"coldWritesEnabled\":false,\"retentionTiers\":[],\"archivalOptions\":{\"enabled\":false,\"immutableAfterNanos\":\"0\",\"verifyChecksumOnRead\":false},\"futureWriteOptions\":{\"enabled\":false,\"toleranceNanos\":\"0\",\"action\":\"REJECT\"},\"expiryDownsampleOptions\":{\"enabled\":false,\"targetNamespace\":\"\",\"resolutionNanos\":\"0\"},\"inMemory\":false,\"shardKeyStrategy\":\"\",\"relabelOptions\":{\"rules\":[]},\"mirrorOptions\":{\"enabled\":false,\"targetNamespace\":\"\",\"percentage\":0,\"matchers\":[]},\"reshardOptions\":{\"enabled\":false,\"fromNumShards\":0,\"toNumShards\":0},\"queryLimitsOptions\":{\"maxConcurrentQueries\":\"0\",\"maxInFlightResultBytes\":\"0\"},\"writeDurability\":\"DEFAULT\",\"validationOptions\":{\"rejectNaN\":false,\"rejectInf\":false,\"boundsEnabled\":false,\"minValue\":0,\"maxValue\":0,\"maxAnnotationSize\":\"0\"},\"compactionOptions\":{\"enabled\":false,\"blockSizeNanos\":\"0\",\"afterNanos\":\"0\"}}}}}", string(body))
 }
 
 func TestNamespaceAddHandler_Conflict(t *testing.T) {
@@ -310,6 +310,13 @@ func RegisterRoutes(
 	r.HandleFunc(M3DBSetURL, setFn).Methods(SetHTTPMethod)
 	r.HandleFunc(M3AggSetURL, setFn).Methods(SetHTTPMethod)
 	r.HandleFunc(M3CoordinatorSetURL, setFn).Methods(SetHTTPMethod)
+
+	// Replica factor
+	var (
+		replicaFactorHandler = NewReplicaFactorHandler(opts)
+		replicaFactorFn      = applyMiddleware(replicaFactorHandler.ServeHTTP, defaults, opts.instrumentOptions)
+	)
+	r.HandleFunc(M3DBReplicaFactorURL, replicaFactorFn).Methods(ReplicaFactorHTTPMethod)
 }
 
 func newPlacementCutoverNanosFn(
@@ -0,0 +1,174 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package placement
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/query/api/v1/handler"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/query/generated/proto/admin"
+	"github.com/m3db/m3/src/query/util/logging"
+	xhttp "github.com/m3db/m3/src/x/net/http"
+
+	"go.uber.org/zap"
+)
+
+const (
+	// ReplicaFactorHTTPMethod is the HTTP method for the replica factor endpoint.
+	ReplicaFactorHTTPMethod = http.MethodPost
+
+	replicaFactorPathName = "replica_factor"
+)
+
+var (
+	// M3DBReplicaFactorURL is the url for the m3db replica factor handler
+	// (method POST).
+	M3DBReplicaFactorURL = path.Join(handler.RoutePrefixV1,
+		M3DBServicePlacementPathName, replicaFactorPathName)
+
+	errInvalidReplicaFactor = errors.New("replica factor must be greater than zero")
+)
+
+// ReplicaFactorRequest is the request to change the replica factor of a
+// placement.
+type ReplicaFactorRequest struct {
+	ReplicaFactor int  `json:"replicaFactor"`
+	Force         bool `json:"force"`
+}
+
+// ReplicaFactorHandler is the type for placement replica factor changes.
+type ReplicaFactorHandler Handler
+
+// NewReplicaFactorHandler returns a new ReplicaFactorHandler.
+func NewReplicaFactorHandler(opts HandlerOptions) *ReplicaFactorHandler {
+	return &ReplicaFactorHandler{HandlerOptions: opts, nowFn: time.Now}
+}
+
+func (h *ReplicaFactorHandler) ServeHTTP(
+	svc handleroptions.ServiceNameAndDefaults,
+	w http.ResponseWriter,
+	r *http.Request,
+) {
+	ctx := r.Context()
+	logger := logging.WithContext(ctx, h.instrumentOptions)
+
+	req, pErr := h.parseRequest(r)
+	if pErr != nil {
+		xhttp.Error(w, pErr.Inner(), pErr.Code())
+		return
+	}
+
+	placement, err := h.SetReplicaFactor(svc, r, req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if _, ok := err.(unsafeAddError); ok {
+			status = http.StatusBadRequest
+		}
+		logger.Error("unable to change replica factor", zap.Error(err))
+		xhttp.Error(w, err, status)
+		return
+	}
+
+	placementProto, err := placement.Proto()
+	if err != nil {
+		logger.Error("unable to get placement protobuf", zap.Error(err))
+		xhttp.Error(w, err, http.StatusInternalServerError)
+		return
+	}
+
+	resp := &admin.PlacementGetResponse{
+		Placement: placementProto,
+		Version:   int32(placement.Version()),
+	}
+
+	xhttp.WriteProtoMsgJSONResponse(w, resp, logger)
+}
+
+func (h *ReplicaFactorHandler) parseRequest(r *http.Request) (*ReplicaFactorRequest, *xhttp.ParseError) {
+	defer r.Body.Close()
+
+	req := &ReplicaFactorRequest{}
+	if err := json.NewDecoder(r.Body).Decode(req); err != nil {
+		return nil, xhttp.NewParseError(err, http.StatusBadRequest)
+	}
+	if req.ReplicaFactor <= 0 {
+		return nil, xhttp.NewParseError(errInvalidReplicaFactor, http.StatusBadRequest)
+	}
+
+	return req, nil
+}
+
+// SetReplicaFactor changes the replica factor of the placement. New replicas
+// are added as initializing shards that bootstrap from the existing replicas,
+// and unless forced the change is only made once all shards are available so
+// that a replica factor change is never started while another is in progress.
+func (h *ReplicaFactorHandler) SetReplicaFactor(
+	svc handleroptions.ServiceNameAndDefaults,
+	httpReq *http.Request,
+	req *ReplicaFactorRequest,
+) (placement.Placement, error) {
+	serviceOpts := handleroptions.NewServiceOptions(svc,
+		httpReq.Header, h.m3AggServiceOptions)
+	service, algo, err := ServiceWithAlgo(h.clusterClient,
+		serviceOpts, h.nowFn(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	curPlacement, err := service.Placement()
+	if err != nil {
+		return nil, err
+	}
+
+	if curPlacement.ReplicaFactor() == req.ReplicaFactor {
+		return curPlacement, nil
+	}
+
+	if !req.Force {
+		if err := validateAllAvailable(curPlacement); err != nil {
+			return nil, err
+		}
+	}
+
+	// We use the algorithm directly so that we can CheckAndSet on the placement
+	// to make "atomic" forward progress.
+	newPlacement := curPlacement
+	for newPlacement.ReplicaFactor() < req.ReplicaFactor {
+		if newPlacement, err = algo.AddReplica(newPlacement); err != nil {
+			return nil, fmt.Errorf("unable to add replica: %v", err)
+		}
+	}
+	for newPlacement.ReplicaFactor() > req.ReplicaFactor {
+		if newPlacement, err = algo.RemoveReplica(newPlacement); err != nil {
+			return nil, fmt.Errorf("unable to remove replica: %v", err)
+		}
+	}
+
+	// Ensure the placement we're updating is still the one on which we validated
+	// all shards are available.
+	return service.CheckAndSet(newPlacement, curPlacement.Version())
+}
@@ -0,0 +1,188 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package placement
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/cluster/placement"
+	"github.com/m3db/m3/src/cluster/shard"
+	"github.com/m3db/m3/src/cmd/services/m3query/config"
+	"github.com/m3db/m3/src/query/api/v1/handler/prometheus/handleroptions"
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newReplicaFactorRequest(body string) *http.Request {
+	rb := strings.NewReader(body)
+	return httptest.NewRequest(ReplicaFactorHTTPMethod, M3DBReplicaFactorURL, rb)
+}
+
+func newReplicaFactorTestHandler(
+	t *testing.T,
+	ctrl *gomock.Controller,
+) (*ReplicaFactorHandler, *placement.MockService) {
+	mockClient, mockPlacementService := SetupPlacementTest(t, ctrl)
+	handlerOpts, err := NewHandlerOptions(mockClient, config.Configuration{}, nil, instrument.NewOptions())
+	require.NoError(t, err)
+	handler := NewReplicaFactorHandler(handlerOpts)
+	handler.nowFn = func() time.Time { return time.Unix(0, 0) }
+	return handler, mockPlacementService
+}
+
+func newReplicaFactorTestPlacement() placement.Placement {
+	var instances []placement.Instance
+	for _, id := range []string{"A", "B", "C"} {
+		instance := placement.NewInstance().
+			SetID(id).
+			SetIsolationGroup("r" + id).
+			SetZone("z1").
+			SetEndpoint(id).
+			SetWeight(1)
+		if id != "C" {
+			instance = instance.SetShards(shard.NewShards([]shard.Shard{
+				shard.NewShard(1).SetState(shard.Available),
+			}))
+		}
+		instances = append(instances, instance)
+	}
+
+	return placement.NewPlacement().
+		SetInstances(instances).
+		SetShards([]uint32{1}).
+		SetReplicaFactor(2).
+		SetIsSharded(true).
+		SetVersion(1)
+}
+
+type placementReplicaFactorMatcher struct {
+	replicaFactor int
+}
+
+func (m placementReplicaFactorMatcher) Matches(x interface{}) bool {
+	pl := x.(placement.Placement)
+	if pl.ReplicaFactor() != m.replicaFactor {
+		return false
+	}
+
+	total := 0
+	for _, instance := range pl.Instances() {
+		total += instance.Shards().NumShards()
+	}
+	return total == m.replicaFactor
+}
+
+func (m placementReplicaFactorMatcher) String() string {
+	return "matches if the placement has the expected replica factor"
+}
+
+func TestPlacementReplicaFactorHandlerAddReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, mockPlacementService := newReplicaFactorTestHandler(t, ctrl)
+	svcDefaults := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+
+	pl := newReplicaFactorTestPlacement()
+	mockPlacementService.EXPECT().Placement().Return(pl, nil)
+	mockPlacementService.EXPECT().
+		CheckAndSet(placementReplicaFactorMatcher{replicaFactor: 3}, 1).
+		DoAndReturn(func(p placement.Placement, _ int) (placement.Placement, error) {
+			instance, ok := p.Instance("C")
+			require.True(t, ok)
+			// The new replica bootstraps from the existing replicas.
+			assert.Equal(t, 1, instance.Shards().NumShardsForState(shard.Initializing))
+			return p.SetVersion(2), nil
+		})
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(svcDefaults, w, newReplicaFactorRequest(`{"replicaFactor": 3}`))
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusOK, resp.StatusCode, string(body))
+	assert.Contains(t, string(body), `"replicaFactor":3`)
+}
+
+func TestPlacementReplicaFactorHandlerRemoveReplica(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, mockPlacementService := newReplicaFactorTestHandler(t, ctrl)
+	svcDefaults := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+
+	pl := newReplicaFactorTestPlacement()
+	instances := pl.Instances()[:2]
+	pl = pl.SetInstances(instances)
+	mockPlacementService.EXPECT().Placement().Return(pl, nil)
+	mockPlacementService.EXPECT().
+		CheckAndSet(placementReplicaFactorMatcher{replicaFactor: 1}, 1).
+		Return(pl, nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(svcDefaults, w, newReplicaFactorRequest(`{"replicaFactor": 1}`))
+	resp := w.Result()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestPlacementReplicaFactorHandlerSafeErr(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, mockPlacementService := newReplicaFactorTestHandler(t, ctrl)
+	svcDefaults := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+
+	mockPlacementService.EXPECT().Placement().Return(newInitPlacement(), nil)
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(svcDefaults, w, newReplicaFactorRequest(`{"replicaFactor": 3}`))
+	resp := w.Result()
+	body, _ := ioutil.ReadAll(resp.Body)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	assert.Equal(t, `{"error":"instances [A,B] do not have all shards available"}`+"\n", string(body))
+}
+
+func TestPlacementReplicaFactorHandlerInvalidRequest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handler, _ := newReplicaFactorTestHandler(t, ctrl)
+	svcDefaults := handleroptions.ServiceNameAndDefaults{
+		ServiceName: handleroptions.M3DBServiceName,
+	}
+
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(svcDefaults, w, newReplicaFactorRequest(`{"replicaFactor": 0}`))
+	resp := w.Result()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -30,6 +30,7 @@ import (
 	"github.com/m3db/m3/src/query/api/experimental/annotated"
 	"github.com/m3db/m3/src/query/api/v1/handler"
 	"github.com/m3db/m3/src/query/api/v1/handler/database"
+	"github.com/m3db/m3/src/query/api/v1/handler/export"
 	"github.com/m3db/m3/src/query/api/v1/handler/graphite"
 	"github.com/m3db/m3/src/query/api/v1/handler/influxdb"
 	m3json "github.com/m3db/m3/src/query/api/v1/handler/json"
@@ -171,8 +172,12 @@ func (h *Handler) RegisterRoutes() error {
 	).Methods(native.PromReadInstantHTTPMethods...)
 
 	// InfluxDB write endpoint.
+	influxWriteHandler, err := influxdb.NewInfluxWriterHandler(h.options)
+	if err != nil {
+		return err
+	}
 	h.router.HandleFunc(influxdb.InfluxWriteURL,
-		wrapped(influxdb.NewInfluxWriterHandler(h.options)).ServeHTTP).Methods(influxdb.InfluxWriteHTTPMethod)
+		wrapped(influxWriteHandler).ServeHTTP).Methods(influxdb.InfluxWriteHTTPMethod)
 
 	// Native M3 search and write endpoints.
 	h.router.HandleFunc(handler.SearchURL,
@@ -217,6 +222,23 @@ func (h *Handler) RegisterRoutes() error {
 		wrapped(graphite.NewFindHandler(h.options)).ServeHTTP,
 	).Methods(graphite.FindHTTPMethods...)
 
+	// Bulk export endpoint.
+	if exportCfg := h.options.Config().Export; exportCfg != nil {
+		exportHandler, err := export.NewHandler(h.options, export.Options{
+			Directory:              exportCfg.Directory,
+			MaxConcurrentJobs:      exportCfg.MaxConcurrentJobs,
+			MaxDatapointsPerSecond: exportCfg.MaxDatapointsPerSecond,
+			FetchWindow:            exportCfg.FetchWindow,
+			AllowUploads:           exportCfg.AllowUploads,
+		})
+		if err != nil {
+			return err
+		}
+		h.router.HandleFunc(export.URL,
+			wrapped(exportHandler).ServeHTTP,
+		).Methods(export.HTTPMethods...)
+	}
+
 	placementOpts, err := h.placementOpts()
 	if err != nil {
 		return err
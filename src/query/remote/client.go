@@ -148,8 +148,11 @@ func (c *grpcClient) fetchRaw(
 	}
 
 	defer fetchClient.CloseSend()
-	meta := block.NewResultMetadata()
-	seriesIterators := make([]encoding.SeriesIterator, 0, initResultSize)
+	var (
+		meta            = block.NewResultMetadata()
+		seriesIterators = make([]encoding.SeriesIterator, 0, initResultSize)
+		received        int
+	)
 	for {
 		select {
 		// If query is killed during gRPC streaming, close the channel
@@ -164,9 +167,11 @@ func (c *grpcClient) fetchRaw(
 		}
 
 		if err != nil {
-			return fetchResult, err
+			return fetchResult, streamRecvError(err, received)
 		}
 
+		received++
+
 		receivedMeta := decodeResultMetadata(result.GetMeta())
 		meta = meta.CombineMetadata(receivedMeta)
 		iters, err := decodeCompressedFetchResponse(result, pools)
@@ -177,6 +182,10 @@ func (c *grpcClient) fetchRaw(
 		seriesIterators = append(seriesIterators, iters.Iters()...)
 	}
 
+	if err := checkStreamComplete(fetchClient, received); err != nil {
+		return fetchResult, err
+	}
+
 	fetchResult.Metadata = meta
 	fetchResult.SeriesIterators = encoding.NewSeriesIterators(
 		seriesIterators,
@@ -230,8 +239,11 @@ func (c *grpcClient) SearchSeries(
 		return nil, err
 	}
 
-	metrics := make(models.Metrics, 0, initResultSize)
-	meta := block.NewResultMetadata()
+	var (
+		metrics  = make(models.Metrics, 0, initResultSize)
+		meta     = block.NewResultMetadata()
+		received int
+	)
 	defer searchClient.CloseSend()
 	for {
 		select {
@@ -241,18 +253,19 @@ func (c *grpcClient) SearchSeries(
 		default:
 		}
 
-		received, err := searchClient.Recv()
+		response, err := searchClient.Recv()
 		if err == io.EOF {
 			break
 		}
 
 		if err != nil {
-			return nil, err
+			return nil, streamRecvError(err, received)
 		}
 
-		receivedMeta := decodeResultMetadata(received.GetMeta())
+		received++
+		receivedMeta := decodeResultMetadata(response.GetMeta())
 		meta = meta.CombineMetadata(receivedMeta)
-		m, err := decodeSearchResponse(received, pools, c.opts.TagOptions())
+		m, err := decodeSearchResponse(response, pools, c.opts.TagOptions())
 		if err != nil {
 			return nil, err
 		}
@@ -260,6 +273,10 @@ func (c *grpcClient) SearchSeries(
 		metrics = append(metrics, m...)
 	}
 
+	if err := checkStreamComplete(searchClient, received); err != nil {
+		return nil, err
+	}
+
 	return &storage.SearchResults{
 		Metrics:  metrics,
 		Metadata: meta,
@@ -286,8 +303,11 @@ func (c *grpcClient) CompleteTags(
 		return nil, err
 	}
 
-	tags := make([]storage.CompletedTag, 0, initResultSize)
-	meta := block.NewResultMetadata()
+	var (
+		tags     = make([]storage.CompletedTag, 0, initResultSize)
+		meta     = block.NewResultMetadata()
+		received int
+	)
 	defer completeTagsClient.CloseSend()
 	for {
 		select {
@@ -297,16 +317,17 @@ func (c *grpcClient) CompleteTags(
 		default:
 		}
 
-		received, err := completeTagsClient.Recv()
+		response, err := completeTagsClient.Recv()
 		if err == io.EOF {
 			break
 		} else if err != nil {
-			return nil, err
+			return nil, streamRecvError(err, received)
 		}
 
-		receivedMeta := decodeResultMetadata(received.GetMeta())
+		received++
+		receivedMeta := decodeResultMetadata(response.GetMeta())
 		meta = meta.CombineMetadata(receivedMeta)
-		result, err := decodeCompleteTagsResponse(received, query.CompleteNameOnly)
+		result, err := decodeCompleteTagsResponse(response, query.CompleteNameOnly)
 		if err != nil {
 			return nil, err
 		}
@@ -314,6 +335,10 @@ func (c *grpcClient) CompleteTags(
 		tags = append(tags, result...)
 	}
 
+	if err := checkStreamComplete(completeTagsClient, received); err != nil {
+		return nil, err
+	}
+
 	return &storage.CompleteTagsResult{
 		CompleteNameOnly: query.CompleteNameOnly,
 		CompletedTags:    tags,
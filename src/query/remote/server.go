@@ -108,7 +108,12 @@ func (s *grpcServer) waitForPools() (encoding.IteratorPools, error) {
 func (s *grpcServer) Fetch(
 	message *rpc.FetchRequest,
 	stream rpc.Query_FetchServer,
-) error {
+) (err error) {
+	var sent int
+	defer func() {
+		setStreamTrailer(stream, sent, err)
+	}()
+
 	ctx := retrieveMetadata(stream.Context(), s.instrumentOpts)
 	logger := logging.WithContext(ctx, s.instrumentOpts)
 	storeQuery, err := decodeFetchRequest(message)
@@ -162,6 +167,7 @@ func (s *grpcServer) Fetch(
 			logger.Error("unable to send fetch result", zap.Error(err))
 			return err
 		}
+		sent++
 	}
 
 	return nil
@@ -170,8 +176,11 @@ func (s *grpcServer) Fetch(
 func (s *grpcServer) Search(
 	message *rpc.SearchRequest,
 	stream rpc.Query_SearchServer,
-) error {
-	var err error
+) (err error) {
+	var sent int
+	defer func() {
+		setStreamTrailer(stream, sent, err)
+	}()
 
 	ctx := retrieveMetadata(stream.Context(), s.instrumentOpts)
 	logger := logging.WithContext(ctx, s.instrumentOpts)
@@ -217,6 +226,7 @@ func (s *grpcServer) Search(
 			logger.Error("unable to send search result", zap.Error(err))
 			return err
 		}
+		sent++
 	}
 
 	return nil
@@ -225,8 +235,11 @@ func (s *grpcServer) Search(
 func (s *grpcServer) CompleteTags(
 	message *rpc.CompleteTagsRequest,
 	stream rpc.Query_CompleteTagsServer,
-) error {
-	var err error
+) (err error) {
+	var sent int
+	defer func() {
+		setStreamTrailer(stream, sent, err)
+	}()
 
 	ctx := retrieveMetadata(stream.Context(), s.instrumentOpts)
 	logger := logging.WithContext(ctx, s.instrumentOpts)
@@ -269,6 +282,7 @@ func (s *grpcServer) CompleteTags(
 			logger.Error("unable to send complete tags result", zap.Error(err))
 			return err
 		}
+		sent++
 	}
 
 	return nil
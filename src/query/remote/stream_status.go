@@ -0,0 +1,124 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// The trailer of every response stream records whether the server completed
+// the stream and how many responses it sent so that clients can distinguish
+// a complete result from a stream cut short by a failure.
+const (
+	streamStatusKey    = "m3-stream-status"
+	streamResponsesKey = "m3-stream-responses"
+	streamErrorKey     = "m3-stream-error"
+
+	streamStatusComplete = "complete"
+	streamStatusError    = "error"
+)
+
+var errStreamResponsesMissing = errors.New("stream completed with missing responses")
+
+// setStreamTrailer sets the trailer of a response stream once the handler has
+// finished sending responses.
+func setStreamTrailer(stream grpc.ServerStream, responses int, err error) {
+	md := metadata.MD{
+		streamStatusKey:    []string{streamStatusComplete},
+		streamResponsesKey: []string{strconv.Itoa(responses)},
+	}
+	if err != nil {
+		md[streamStatusKey] = []string{streamStatusError}
+		md[streamErrorKey] = []string{err.Error()}
+	}
+	stream.SetTrailer(md)
+}
+
+// checkStreamComplete checks that a response stream the client has read to
+// the end was completed by the server with every response received, streams
+// from servers that do not set the stream trailer are assumed to be complete.
+func checkStreamComplete(stream grpc.ClientStream, received int) error {
+	md := stream.Trailer()
+	status := md[streamStatusKey]
+	if len(status) == 0 {
+		return nil
+	}
+
+	if status[0] != streamStatusComplete {
+		var inner error = errStreamResponsesMissing
+		if msg := md[streamErrorKey]; len(msg) > 0 {
+			inner = errors.New(msg[0])
+		}
+		return NewStreamTruncatedError(inner, received)
+	}
+
+	if sent := md[streamResponsesKey]; len(sent) > 0 {
+		responses, err := strconv.Atoi(sent[0])
+		if err != nil {
+			return fmt.Errorf("invalid stream responses trailer: %v", err)
+		}
+		if responses != received {
+			return NewStreamTruncatedError(errStreamResponsesMissing, received)
+		}
+	}
+	return nil
+}
+
+// streamRecvError returns the error for a failure to receive from a response
+// stream, a failure after responses have been received truncates the stream.
+func streamRecvError(err error, received int) error {
+	if received == 0 {
+		return err
+	}
+	return NewStreamTruncatedError(err, received)
+}
+
+type streamTruncatedError struct {
+	inner    error
+	received int
+}
+
+// NewStreamTruncatedError creates a new error for a response stream that was
+// cut short after the given number of responses were received.
+func NewStreamTruncatedError(inner error, received int) error {
+	return streamTruncatedError{inner: inner, received: received}
+}
+
+func (e streamTruncatedError) Error() string {
+	return fmt.Sprintf("stream truncated after %d responses: %v",
+		e.received, e.inner)
+}
+
+func (e streamTruncatedError) InnerError() error {
+	return e.inner
+}
+
+// IsStreamTruncatedError returns true if the error is for a response stream
+// that was cut short, the results received are incomplete.
+func IsStreamTruncatedError(err error) bool {
+	_, ok := err.(streamTruncatedError)
+	return ok
+}
@@ -0,0 +1,90 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package remote
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+type trailerServerStream struct {
+	grpc.ServerStream
+	trailer metadata.MD
+}
+
+func (s *trailerServerStream) SetTrailer(md metadata.MD) {
+	s.trailer = md
+}
+
+type trailerClientStream struct {
+	grpc.ClientStream
+	trailer metadata.MD
+}
+
+func (s *trailerClientStream) Trailer() metadata.MD {
+	return s.trailer
+}
+
+func TestStreamTrailerComplete(t *testing.T) {
+	server := &trailerServerStream{}
+	setStreamTrailer(server, 3, nil)
+	assert.Equal(t, []string{streamStatusComplete}, server.trailer[streamStatusKey])
+	assert.Equal(t, []string{"3"}, server.trailer[streamResponsesKey])
+
+	client := &trailerClientStream{trailer: server.trailer}
+	require.NoError(t, checkStreamComplete(client, 3))
+
+	err := checkStreamComplete(client, 2)
+	require.Error(t, err)
+	assert.True(t, IsStreamTruncatedError(err))
+}
+
+func TestStreamTrailerError(t *testing.T) {
+	server := &trailerServerStream{}
+	setStreamTrailer(server, 1, errors.New("send failed"))
+	assert.Equal(t, []string{streamStatusError}, server.trailer[streamStatusKey])
+
+	client := &trailerClientStream{trailer: server.trailer}
+	err := checkStreamComplete(client, 1)
+	require.Error(t, err)
+	assert.True(t, IsStreamTruncatedError(err))
+	assert.Equal(t, "send failed", err.(streamTruncatedError).InnerError().Error())
+}
+
+func TestStreamTrailerMissing(t *testing.T) {
+	// Servers that do not set the trailer are assumed to complete streams.
+	client := &trailerClientStream{trailer: metadata.MD{}}
+	require.NoError(t, checkStreamComplete(client, 0))
+}
+
+func TestStreamRecvError(t *testing.T) {
+	inner := errors.New("connection reset")
+	assert.Equal(t, inner, streamRecvError(inner, 0))
+
+	err := streamRecvError(inner, 2)
+	assert.True(t, IsStreamTruncatedError(err))
+	assert.False(t, IsStreamTruncatedError(inner))
+}
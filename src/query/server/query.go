@@ -36,6 +36,8 @@ import (
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/downsample"
 	"github.com/m3db/m3/src/cmd/services/m3coordinator/ingest"
 	ingestcarbon "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/carbon"
+	ingestotlp "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/otlp"
+	ingeststatsd "github.com/m3db/m3/src/cmd/services/m3coordinator/ingest/statsd"
 	dbconfig "github.com/m3db/m3/src/cmd/services/m3dbnode/config"
 	"github.com/m3db/m3/src/cmd/services/m3query/config"
 	"github.com/m3db/m3/src/dbnode/client"
@@ -387,13 +389,25 @@ func Run(runOpts RunOptions) {
 	}
 
 	if cfg.Carbon != nil && cfg.Carbon.Ingester != nil {
-		server, ok := startCarbonIngestion(cfg.Carbon, instrumentOptions,
+		servers := startCarbonIngestion(cfg.Carbon, instrumentOptions,
 			logger, m3dbClusters, downsamplerAndWriter)
-		if ok {
+		for _, server := range servers {
 			defer server.Close()
 		}
 	}
 
+	if cfg.StatsD != nil {
+		server := startStatsDIngestion(cfg.StatsD, instrumentOptions, logger,
+			m3dbClusters, downsamplerAndWriter)
+		defer server.Close()
+	}
+
+	if cfg.OTLP != nil {
+		server := startOTLPIngestion(cfg.OTLP, instrumentOptions, logger,
+			downsamplerAndWriter)
+		defer server.GracefulStop()
+	}
+
 	// Wait for process interrupt.
 	xos.WaitForInterrupt(logger, xos.InterruptOptions{
 		InterruptCh: runOpts.InterruptCh,
@@ -845,13 +859,131 @@ func startGRPCServer(
 	return server, nil
 }
 
+func startOTLPIngestion(
+	cfg *config.OTLPConfiguration,
+	iOpts instrument.Options,
+	logger *zap.Logger,
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+) *grpc.Server {
+	logger.Info("otlp ingestion enabled, configuring receiver")
+
+	receiver, err := ingestotlp.NewReceiver(downsamplerAndWriter,
+		ingestotlp.Options{
+			InstrumentOptions: iOpts.SetMetricsScope(
+				iOpts.MetricsScope().SubScope("ingest-otlp")),
+			ResourceAttributes: cfg.ResourceAttributes,
+			AttributeTags:      cfg.AttributeTags,
+			DropAttributes:     cfg.DropAttributes,
+		})
+	if err != nil {
+		logger.Fatal("unable to create otlp receiver", zap.Error(err))
+	}
+
+	listener, err := net.Listen("tcp", cfg.ListenAddress)
+	if err != nil {
+		logger.Fatal("unable to listen on otlp listen address",
+			zap.String("listenAddress", cfg.ListenAddress), zap.Error(err))
+	}
+
+	server := grpc.NewServer()
+	ingestotlp.RegisterMetricsServiceServer(server, receiver)
+	go func() {
+		if err := server.Serve(listener); err != nil {
+			logger.Error("error from serving otlp gRPC server", zap.Error(err))
+		}
+	}()
+
+	logger.Info("started otlp ingestion server",
+		zap.String("listenAddress", cfg.ListenAddress))
+	return server
+}
+
+func startStatsDIngestion(
+	cfg *config.StatsDConfiguration,
+	iOpts instrument.Options,
+	logger *zap.Logger,
+	m3dbClusters m3.Clusters,
+	downsamplerAndWriter ingest.DownsamplerAndWriter,
+) *ingeststatsd.Server {
+	logger.Info("statsd ingestion enabled, configuring ingester")
+
+	var (
+		statsdIOpts = iOpts.SetMetricsScope(
+			iOpts.MetricsScope().SubScope("ingest-statsd"))
+		workerPoolOpts = xsync.NewPooledWorkerPoolOptions().
+				SetGrowOnDemand(true).
+				SetKillWorkerProbability(0.001)
+		workerPoolSize = defaultCarbonIngesterWorkerPoolSize
+	)
+	if cfg.MaxConcurrency > 0 {
+		workerPoolOpts = xsync.NewPooledWorkerPoolOptions().
+			SetGrowOnDemand(false).
+			SetInstrumentOptions(statsdIOpts)
+		workerPoolSize = cfg.MaxConcurrency
+	}
+	workerPool, err := xsync.NewPooledWorkerPool(workerPoolSize, workerPoolOpts)
+	if err != nil {
+		logger.Fatal("unable to create worker pool for statsd ingester", zap.Error(err))
+	}
+	workerPool.Init()
+
+	var writeOpts ingest.WriteOptions
+	if len(cfg.Policies) > 0 {
+		if m3dbClusters == nil {
+			logger.Fatal("statsd ingestion storage policies are only supported when connecting to M3DB clusters directly")
+		}
+
+		// Only write to the namespaces of the storage policies since the
+		// metrics have already been aggregated.
+		writeOpts = ingest.WriteOptions{
+			DownsampleOverride: true,
+			WriteOverride:      true,
+		}
+		for _, p := range cfg.Policies {
+			_, ok := m3dbClusters.AggregatedClusterNamespace(m3.RetentionResolution{
+				Resolution: p.Resolution,
+				Retention:  p.Retention,
+			})
+			if !ok {
+				logger.Fatal(
+					"cannot enable statsd ingestion without a corresponding aggregated M3DB namespace",
+					zap.String("resolution", p.Resolution.String()), zap.String("retention", p.Retention.String()))
+			}
+			writeOpts.WriteStoragePolicies = append(writeOpts.WriteStoragePolicies,
+				policy.NewStoragePolicy(p.Resolution, xtime.Second, p.Retention))
+		}
+	}
+
+	server, err := ingeststatsd.NewServer(cfg.ListenAddress, downsamplerAndWriter,
+		ingeststatsd.Options{
+			InstrumentOptions: statsdIOpts,
+			WorkerPool:        workerPool,
+			FlushInterval:     cfg.FlushIntervalOrDefault(),
+			Prefix:            cfg.PrefixOrDefault(),
+			Percentiles:       cfg.PercentilesOrDefault(),
+			WriteOptions:      writeOpts,
+		})
+	if err != nil {
+		logger.Fatal("unable to create statsd ingester", zap.Error(err))
+	}
+
+	logger.Info("starting statsd ingestion server", zap.String("listenAddress", cfg.ListenAddress))
+	if err := server.ListenAndServe(); err != nil {
+		logger.Fatal("unable to start statsd ingestion server at listen address",
+			zap.String("listenAddress", cfg.ListenAddress), zap.Error(err))
+	}
+
+	logger.Info("started statsd ingestion server", zap.String("listenAddress", cfg.ListenAddress))
+	return server
+}
+
 func startCarbonIngestion(
 	cfg *config.CarbonConfiguration,
 	iOpts instrument.Options,
 	logger *zap.Logger,
 	m3dbClusters m3.Clusters,
 	downsamplerAndWriter ingest.DownsamplerAndWriter,
-) (xserver.Server, bool) {
+) []xserver.Server {
 	ingesterCfg := cfg.Ingester
 	logger.Info("carbon ingestion enabled, configuring ingester")
 
@@ -931,7 +1063,7 @@ func startCarbonIngestion(
 
 	if len(rules.Rules) == 0 {
 		logger.Warn("no carbon ingestion rules were provided and no aggregated M3DB namespaces exist, carbon metrics will not be ingested")
-		return nil, false
+		return nil
 	}
 
 	if len(ingesterCfg.Rules) == 0 {
@@ -967,7 +1099,29 @@ func startCarbonIngestion(
 
 	logger.Info("started carbon ingestion server", zap.String("listenAddress", carbonListenAddress))
 
-	return carbonServer, true
+	servers := []xserver.Server{carbonServer}
+	if pickleListenAddress := ingesterCfg.PickleListenAddress; pickleListenAddress != "" {
+		pickleIngester, err := ingestcarbon.NewPickleIngester(
+			downsamplerAndWriter, rules, ingestcarbon.Options{
+				InstrumentOptions: carbonIOpts,
+				WorkerPool:        workerPool,
+			})
+		if err != nil {
+			logger.Fatal("unable to create carbon pickle ingester", zap.Error(err))
+		}
+
+		pickleServer := xserver.NewServer(pickleListenAddress, pickleIngester, serverOpts)
+		logger.Info("starting carbon pickle ingestion server", zap.String("listenAddress", pickleListenAddress))
+		if err := pickleServer.ListenAndServe(); err != nil {
+			logger.Fatal("unable to start carbon pickle ingestion server at listen address",
+				zap.String("listenAddress", pickleListenAddress), zap.Error(err))
+		}
+
+		logger.Info("started carbon pickle ingestion server", zap.String("listenAddress", pickleListenAddress))
+		servers = append(servers, pickleServer)
+	}
+
+	return servers
 }
 
 func newDownsamplerAndWriter(storage storage.Storage, downsampler downsample.Downsampler) (ingest.DownsamplerAndWriter, error) {
@@ -291,6 +291,19 @@ func (s *m3storage) fetchCompressed(
 		return nil, errNoNamespacesConfigured
 	}
 
+	// NB: clamp the start of the fetch to the retention of the resolved
+	// namespaces and surface a warning, otherwise the missing range is
+	// indistinguishable from data loss to the caller.
+	clampedStart, clamped := clampStartToRetention(s.nowFn(),
+		query.Start, query.End, namespaces)
+	var clampedWarning string
+	if clamped {
+		opts.StartInclusive = clampedStart
+		clampedWarning = fmt.Sprintf("retention_clamped_range_%s_%s",
+			query.Start.UTC().Format(time.RFC3339),
+			clampedStart.UTC().Format(time.RFC3339))
+	}
+
 	pools, err := namespaces[0].Session().IteratorPools()
 	if err != nil {
 		return nil, fmt.Errorf("unable to retrieve iterator pools: %v", err)
@@ -306,6 +319,9 @@ func (s *m3storage) fetchCompressed(
 			iters, exhaustive, err := session.FetchTagged(ns, m3query, opts)
 			meta := block.NewResultMetadata()
 			meta.Exhaustive = exhaustive
+			if clamped {
+				meta.AddWarning(s.Name(), clampedWarning)
+			}
 			fetchResult := SeriesFetchResult{
 				SeriesIterators: iters,
 				Metadata:        meta,
@@ -330,6 +346,35 @@ func (s *m3storage) fetchCompressed(
 	return result, err
 }
 
+// clampStartToRetention returns the start of the range that is still within
+// retention of at least one of the namespaces, and whether the start was
+// clamped at all.
+func clampStartToRetention(
+	now, start, end time.Time,
+	namespaces ClusterNamespaces,
+) (time.Time, bool) {
+	var maxRetention time.Duration
+	for _, n := range namespaces {
+		retention := n.Options().Attributes().Retention
+		if retention <= 0 {
+			// Unbounded retention, nothing to clamp.
+			return start, false
+		}
+		if retention > maxRetention {
+			maxRetention = retention
+		}
+	}
+
+	earliest := now.Add(-maxRetention)
+	if !start.Before(earliest) {
+		return start, false
+	}
+	if earliest.After(end) {
+		earliest = end
+	}
+	return earliest, true
+}
+
 func (s *m3storage) SearchSeries(
 	ctx context.Context,
 	query *storage.FetchQuery,
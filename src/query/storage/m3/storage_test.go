@@ -30,6 +30,7 @@ import (
 
 	"github.com/m3db/m3/src/dbnode/client"
 	"github.com/m3db/m3/src/dbnode/encoding"
+	"github.com/m3db/m3/src/dbnode/storage/index"
 	"github.com/m3db/m3/src/query/models"
 	"github.com/m3db/m3/src/query/storage"
 	"github.com/m3db/m3/src/query/test/seriesiter"
@@ -296,6 +297,74 @@ func TestLocalReadExceedsRetention(t *testing.T) {
 	assertFetchResult(t, results, testTag)
 }
 
+func TestLocalReadExceedsRetentionClampsAndWarns(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+	store, sessions := setup(t, ctrl)
+	testTag := seriesiter.GenerateTag()
+
+	now := time.Now()
+	store.(*m3storage).nowFn = func() time.Time { return now }
+
+	session := sessions.aggregated1YearRetention10MinuteResolution
+	session.EXPECT().FetchTagged(gomock.Any(), gomock.Any(), gomock.Any()).
+		DoAndReturn(func(
+			_ ident.ID,
+			_ index.Query,
+			opts index.QueryOptions,
+		) (encoding.SeriesIterators, bool, error) {
+			assert.True(t, now.Add(-testLongestRetention).Equal(opts.StartInclusive))
+			return seriesiter.NewMockSeriesIters(ctrl, testTag, 1, 2), true, nil
+		})
+	session.EXPECT().IteratorPools().Return(nil, nil).AnyTimes()
+
+	searchReq := newFetchReq()
+	searchReq.Start = now.Add(-2 * testLongestRetention)
+	searchReq.End = now
+	results, err := store.FetchProm(context.TODO(), searchReq, buildFetchOpts())
+	require.NoError(t, err)
+	assertFetchResult(t, results, testTag)
+
+	warnings := results.Metadata.Warnings
+	require.Equal(t, 1, len(warnings))
+	assert.Equal(t, "local_store", warnings[0].Name)
+	assert.Contains(t, warnings[0].Message, "retention_clamped_range_")
+	assert.Contains(t, warnings[0].Message,
+		now.Add(-testLongestRetention).UTC().Format(time.RFC3339))
+}
+
+func TestClampStartToRetention(t *testing.T) {
+	ctrl := xtest.NewController(t)
+	defer ctrl.Finish()
+	clusters, err := NewClusters(UnaggregatedClusterNamespaceDefinition{
+		NamespaceID: ident.StringID("metrics_unaggregated"),
+		Session:     client.NewMockSession(ctrl),
+		Retention:   test1MonthRetention,
+	})
+	require.NoError(t, err)
+
+	var (
+		now        = time.Now()
+		namespaces = clusters.ClusterNamespaces()
+	)
+
+	start, clamped := clampStartToRetention(now,
+		now.Add(-time.Hour), now, namespaces)
+	assert.False(t, clamped)
+	assert.True(t, now.Add(-time.Hour).Equal(start))
+
+	start, clamped = clampStartToRetention(now,
+		now.Add(-2*test1MonthRetention), now, namespaces)
+	assert.True(t, clamped)
+	assert.True(t, now.Add(-test1MonthRetention).Equal(start))
+
+	end := now.Add(-2 * test1MonthRetention).Add(time.Hour)
+	start, clamped = clampStartToRetention(now,
+		now.Add(-2*test1MonthRetention), end, namespaces)
+	assert.True(t, clamped)
+	assert.True(t, end.Equal(start))
+}
+
 func buildFetchOpts() *storage.FetchOptions {
 	opts := storage.NewFetchOptions()
 	opts.Limit = 100
@@ -117,6 +117,34 @@ func (s *AsyncSession) WriteTagged(namespace, id ident.ID, tags ident.TagIterato
 	return s.session.WriteTagged(namespace, id, tags, t, value, unit, annotation)
 }
 
+// WriteWithDispositions writes a value to the database for an ID and returns
+// how the write was applied by each host that reported it.
+func (s *AsyncSession) WriteWithDispositions(namespace, id ident.ID, t time.Time,
+	value float64, unit xtime.Unit, annotation []byte) (client.WriteDispositions, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.session.WriteWithDispositions(namespace, id, t, value, unit, annotation)
+}
+
+// WriteTaggedWithDispositions writes a value to the database for an ID and
+// given tags and returns how the write was applied by each host that
+// reported it.
+func (s *AsyncSession) WriteTaggedWithDispositions(namespace, id ident.ID,
+	tags ident.TagIterator, t time.Time, value float64, unit xtime.Unit,
+	annotation []byte) (client.WriteDispositions, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return nil, s.err
+	}
+
+	return s.session.WriteTaggedWithDispositions(namespace, id, tags, t, value, unit, annotation)
+}
+
 // Fetch fetches values from the database for an ID.
 func (s *AsyncSession) Fetch(namespace, id ident.ID, startInclusive,
 	endExclusive time.Time) (encoding.SeriesIterator, error) {
@@ -191,6 +219,18 @@ func (s *AsyncSession) ShardID(id ident.ID) (uint32, error) {
 	return s.session.ShardID(id)
 }
 
+// NamespaceShardID returns the shard for an ID of a namespace, routing the
+// ID with the shard key strategy of the namespace.
+func (s *AsyncSession) NamespaceShardID(namespace, id ident.ID) (uint32, error) {
+	s.RLock()
+	defer s.RUnlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+
+	return s.session.NamespaceShardID(namespace, id)
+}
+
 // IteratorPools exposes the internal iterator pools used by the session to
 // clients.
 func (s *AsyncSession) IteratorPools() (encoding.IteratorPools, error) {
@@ -28,12 +28,14 @@ type options struct {
 	nowFn           NowFn
 	maxPositiveSkew time.Duration
 	maxNegativeSkew time.Duration
+	scheduler       Scheduler
 }
 
 // NewOptions creates new clock options.
 func NewOptions() Options {
 	return &options{
-		nowFn: time.Now,
+		nowFn:     time.Now,
+		scheduler: NewSystemScheduler(),
 	}
 }
 
@@ -66,3 +68,13 @@ func (o *options) SetMaxNegativeSkew(value time.Duration) Options {
 func (o *options) MaxNegativeSkew() time.Duration {
 	return o.maxNegativeSkew
 }
+
+func (o *options) SetScheduler(value Scheduler) Options {
+	opts := *o
+	opts.scheduler = value
+	return &opts
+}
+
+func (o *options) Scheduler() Scheduler {
+	return o.scheduler
+}
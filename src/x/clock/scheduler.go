@@ -0,0 +1,135 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clock
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// Scheduler drives the passage of time for background loops so that they
+// can be run against either the system clock or a simulated one.
+type Scheduler interface {
+	// Now returns the current time.
+	Now() time.Time
+
+	// Sleep blocks the calling goroutine for the given duration.
+	Sleep(d time.Duration)
+}
+
+// SimulatedScheduler is a scheduler whose time only moves forward when
+// advanced explicitly, allowing tests to deterministically simulate long
+// periods of time in a short amount of wall clock time.
+type SimulatedScheduler interface {
+	Scheduler
+
+	// Advance moves the current time forward by the given duration and wakes
+	// any goroutines whose sleep ends at or before the new current time.
+	Advance(d time.Duration)
+
+	// Sleepers returns the number of goroutines currently sleeping.
+	Sleepers() int
+}
+
+type systemScheduler struct{}
+
+// NewSystemScheduler returns a scheduler backed by the system clock.
+func NewSystemScheduler() Scheduler {
+	return systemScheduler{}
+}
+
+func (systemScheduler) Now() time.Time {
+	return time.Now()
+}
+
+func (systemScheduler) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+type simulatedSleeper struct {
+	wakeAt time.Time
+	doneCh chan struct{}
+}
+
+type simulatedScheduler struct {
+	sync.Mutex
+
+	now      time.Time
+	sleepers []simulatedSleeper
+}
+
+// NewSimulatedScheduler returns a simulated scheduler starting at the given
+// time.
+func NewSimulatedScheduler(start time.Time) SimulatedScheduler {
+	return &simulatedScheduler{now: start}
+}
+
+func (s *simulatedScheduler) Now() time.Time {
+	s.Lock()
+	now := s.now
+	s.Unlock()
+	return now
+}
+
+func (s *simulatedScheduler) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	s.Lock()
+	sleeper := simulatedSleeper{
+		wakeAt: s.now.Add(d),
+		doneCh: make(chan struct{}),
+	}
+	s.sleepers = append(s.sleepers, sleeper)
+	s.Unlock()
+
+	<-sleeper.doneCh
+}
+
+func (s *simulatedScheduler) Advance(d time.Duration) {
+	s.Lock()
+	s.now = s.now.Add(d)
+
+	// Wake sleepers in the order their sleeps end so that goroutines
+	// observe the same ordering they would against the system clock.
+	sort.SliceStable(s.sleepers, func(i, j int) bool {
+		return s.sleepers[i].wakeAt.Before(s.sleepers[j].wakeAt)
+	})
+	n := 0
+	for _, sleeper := range s.sleepers {
+		if sleeper.wakeAt.After(s.now) {
+			break
+		}
+		close(sleeper.doneCh)
+		n++
+	}
+	s.sleepers = append(s.sleepers[:0], s.sleepers[n:]...)
+	s.Unlock()
+}
+
+func (s *simulatedScheduler) Sleepers() int {
+	s.Lock()
+	n := len(s.sleepers)
+	s.Unlock()
+	return n
+}
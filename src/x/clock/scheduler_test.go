@@ -0,0 +1,88 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package clock
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemScheduler(t *testing.T) {
+	s := NewSystemScheduler()
+	start := s.Now()
+	s.Sleep(time.Millisecond)
+	require.True(t, s.Now().Sub(start) >= time.Millisecond)
+}
+
+func TestSimulatedSchedulerAdvance(t *testing.T) {
+	start := time.Unix(1000, 0)
+	s := NewSimulatedScheduler(start)
+	require.Equal(t, start, s.Now())
+
+	// Non-positive sleeps return immediately.
+	s.Sleep(0)
+
+	doneCh := make(chan struct{})
+	go func() {
+		s.Sleep(time.Hour)
+		close(doneCh)
+	}()
+	require.True(t, WaitUntil(func() bool { return s.Sleepers() == 1 }, time.Second))
+
+	s.Advance(30 * time.Minute)
+	require.Equal(t, start.Add(30*time.Minute), s.Now())
+	require.Equal(t, 1, s.Sleepers())
+	select {
+	case <-doneCh:
+		require.FailNow(t, "sleeper woke before its deadline")
+	default:
+	}
+
+	s.Advance(30 * time.Minute)
+	<-doneCh
+	require.Equal(t, start.Add(time.Hour), s.Now())
+	require.Equal(t, 0, s.Sleepers())
+}
+
+func TestSimulatedSchedulerAdvanceWakesInOrder(t *testing.T) {
+	s := NewSimulatedScheduler(time.Unix(0, 0))
+
+	resultCh := make(chan time.Duration, 3)
+	for _, d := range []time.Duration{3 * time.Hour, time.Hour, 2 * time.Hour} {
+		d := d
+		go func() {
+			s.Sleep(d)
+			resultCh <- d
+		}()
+	}
+	require.True(t, WaitUntil(func() bool { return s.Sleepers() == 3 }, time.Second))
+
+	s.Advance(time.Hour)
+	require.Equal(t, time.Hour, <-resultCh)
+	require.Equal(t, 2, s.Sleepers())
+
+	s.Advance(2 * time.Hour)
+	results := []time.Duration{<-resultCh, <-resultCh}
+	require.ElementsMatch(t, []time.Duration{2 * time.Hour, 3 * time.Hour}, results)
+	require.Equal(t, 0, s.Sleepers())
+}
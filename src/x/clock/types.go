@@ -51,6 +51,12 @@ type Options interface {
 	// MaxNegativeSkew returns the maximum negative clock skew
 	// with regard to a reference clock.
 	MaxNegativeSkew() time.Duration
+
+	// SetScheduler sets the scheduler that drives background loops.
+	SetScheduler(value Scheduler) Options
+
+	// Scheduler returns the scheduler that drives background loops.
+	Scheduler() Scheduler
 }
 
 // ConditionFn specifies a predicate to check.
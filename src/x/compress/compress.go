@@ -0,0 +1,189 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package compress provides block compression codecs that can be negotiated
+// between peers by name.
+package compress
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/pierrec/lz4"
+)
+
+// Type is a compression type, its string form is used to negotiate the
+// compression type between peers.
+type Type string
+
+const (
+	// None is no compression.
+	None Type = "none"
+	// LZ4 is LZ4 frame compression, fast with a moderate compression ratio.
+	LZ4 Type = "lz4"
+	// Zstd is Zstandard compression, slower than LZ4 with a higher
+	// compression ratio.
+	Zstd Type = "zstd"
+)
+
+var (
+	validTypes = []Type{None, LZ4, Zstd}
+
+	errNoneNotCompressor = errors.New("no compressor for compression type none")
+)
+
+// ValidTypes returns the valid compression types.
+func ValidTypes() []Type {
+	return append([]Type(nil), validTypes...)
+}
+
+// ParseType parses a compression type from its string form.
+func ParseType(str string) (Type, error) {
+	for _, t := range validTypes {
+		if str == string(t) {
+			return t, nil
+		}
+	}
+	return None, fmt.Errorf("invalid compression type '%s', valid types are: %v",
+		str, validTypes)
+}
+
+// UnmarshalYAML unmarshals a compression type from YAML.
+func (t *Type) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	parsed, err := ParseType(str)
+	if err != nil {
+		return err
+	}
+	*t = parsed
+	return nil
+}
+
+// Negotiate returns the first of the accepted compression types, in order
+// of preference of the requester, that is supported. It returns None if
+// none of the accepted types are supported.
+func Negotiate(accepted []string, supported []Type) Type {
+	for _, str := range accepted {
+		for _, t := range supported {
+			if str == string(t) {
+				return t
+			}
+		}
+	}
+	return None
+}
+
+// Compressor compresses and decompresses blocks of bytes, it is safe for
+// concurrent use.
+type Compressor interface {
+	// Type returns the compression type.
+	Type() Type
+
+	// Compress appends the compressed form of src to dst.
+	Compress(dst, src []byte) ([]byte, error)
+
+	// Decompress appends the decompressed form of src to dst.
+	Decompress(dst, src []byte) ([]byte, error)
+}
+
+// NewCompressor returns a compressor for a compression type.
+func NewCompressor(t Type) (Compressor, error) {
+	switch t {
+	case LZ4:
+		return lz4Compressor{}, nil
+	case Zstd:
+		return newZstdCompressor()
+	case None:
+		return nil, errNoneNotCompressor
+	}
+	return nil, fmt.Errorf("unknown compression type: %s", t)
+}
+
+type lz4Compressor struct{}
+
+func (c lz4Compressor) Type() Type {
+	return LZ4
+}
+
+func (c lz4Compressor) Compress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	w := lz4.NewWriter(buf)
+	if _, err := w.Write(src); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func (c lz4Compressor) Decompress(dst, src []byte) ([]byte, error) {
+	buf := bytes.NewBuffer(dst)
+	if _, err := buf.ReadFrom(lz4.NewReader(bytes.NewReader(src))); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// NB: The zstd encoder and decoder keep sizeable internal state, they are
+// safe for concurrent use of EncodeAll and DecodeAll so share one of each.
+var (
+	zstdOnce    sync.Once
+	zstdEncoder *zstd.Encoder
+	zstdDecoder *zstd.Decoder
+	zstdErr     error
+)
+
+type zstdCompressor struct {
+	encoder *zstd.Encoder
+	decoder *zstd.Decoder
+}
+
+func newZstdCompressor() (Compressor, error) {
+	zstdOnce.Do(func() {
+		zstdEncoder, zstdErr = zstd.NewWriter(nil)
+		if zstdErr != nil {
+			return
+		}
+		zstdDecoder, zstdErr = zstd.NewReader(nil)
+	})
+	if zstdErr != nil {
+		return nil, zstdErr
+	}
+	return zstdCompressor{encoder: zstdEncoder, decoder: zstdDecoder}, nil
+}
+
+func (c zstdCompressor) Type() Type {
+	return Zstd
+}
+
+func (c zstdCompressor) Compress(dst, src []byte) ([]byte, error) {
+	return c.encoder.EncodeAll(src, dst), nil
+}
+
+func (c zstdCompressor) Decompress(dst, src []byte) ([]byte, error) {
+	return c.decoder.DecodeAll(src, dst)
+}
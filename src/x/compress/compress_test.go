@@ -0,0 +1,93 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package compress
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	yaml "gopkg.in/yaml.v2"
+)
+
+func TestCompressorRoundTrip(t *testing.T) {
+	src := bytes.Repeat([]byte("some repetitive block data "), 1024)
+	for _, typ := range []Type{LZ4, Zstd} {
+		t.Run(string(typ), func(t *testing.T) {
+			c, err := NewCompressor(typ)
+			require.NoError(t, err)
+			assert.Equal(t, typ, c.Type())
+
+			prefix := []byte("prefix")
+			compressed, err := c.Compress(append([]byte(nil), prefix...), src)
+			require.NoError(t, err)
+			require.True(t, bytes.HasPrefix(compressed, prefix))
+			assert.True(t, len(compressed) < len(src))
+
+			decompressed, err := c.Decompress(nil, compressed[len(prefix):])
+			require.NoError(t, err)
+			assert.Equal(t, src, decompressed)
+
+			empty, err := c.Compress(nil, nil)
+			require.NoError(t, err)
+			decompressed, err = c.Decompress(nil, empty)
+			require.NoError(t, err)
+			assert.Equal(t, 0, len(decompressed))
+		})
+	}
+}
+
+func TestNewCompressorInvalid(t *testing.T) {
+	_, err := NewCompressor(None)
+	require.Error(t, err)
+
+	_, err = NewCompressor(Type("snappy"))
+	require.Error(t, err)
+}
+
+func TestParseType(t *testing.T) {
+	for _, typ := range ValidTypes() {
+		parsed, err := ParseType(string(typ))
+		require.NoError(t, err)
+		assert.Equal(t, typ, parsed)
+	}
+
+	_, err := ParseType("snappy")
+	require.Error(t, err)
+}
+
+func TestTypeUnmarshalYAML(t *testing.T) {
+	var types []Type
+	require.NoError(t, yaml.Unmarshal([]byte("[zstd, lz4]"), &types))
+	assert.Equal(t, []Type{Zstd, LZ4}, types)
+
+	require.Error(t, yaml.Unmarshal([]byte("[gzip]"), &types))
+}
+
+func TestNegotiate(t *testing.T) {
+	supported := []Type{LZ4, Zstd}
+	assert.Equal(t, Zstd, Negotiate([]string{"zstd", "lz4"}, supported))
+	assert.Equal(t, LZ4, Negotiate([]string{"brotli", "lz4"}, supported))
+	assert.Equal(t, None, Negotiate([]string{"brotli"}, supported))
+	assert.Equal(t, None, Negotiate(nil, supported))
+	assert.Equal(t, None, Negotiate([]string{"lz4"}, nil))
+}
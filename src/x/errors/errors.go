@@ -25,6 +25,7 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"time"
 )
 
 // FirstError returns the first non nil error.
@@ -198,6 +199,182 @@ func GetInnerNonRetryableError(err error) error {
 	return nil
 }
 
+type resourceExhaustedError struct {
+	containedError
+}
+
+// NewResourceExhaustedError creates a new resource exhausted error, used
+// to signal that a request was rejected due to a limit or overload and
+// may succeed if retried later.
+func NewResourceExhaustedError(inner error) error {
+	return resourceExhaustedError{containedError{inner}}
+}
+
+func (e resourceExhaustedError) Error() string {
+	return e.inner.Error()
+}
+
+func (e resourceExhaustedError) InnerError() error {
+	return e.inner
+}
+
+// IsResourceExhaustedError returns true if this is a resource exhausted error.
+func IsResourceExhaustedError(err error) bool {
+	return GetInnerResourceExhaustedError(err) != nil
+}
+
+// GetInnerResourceExhaustedError returns an inner resource exhausted error
+// if contained by this error, nil otherwise.
+func GetInnerResourceExhaustedError(err error) error {
+	for err != nil {
+		if _, ok := err.(resourceExhaustedError); ok {
+			return InnerError(err)
+		}
+		err = InnerError(err)
+	}
+	return nil
+}
+
+type unavailableError struct {
+	containedError
+}
+
+// NewUnavailableError creates a new unavailable error, used to signal
+// that a request could not be served because the service is not ready
+// to serve it, i.e. it is still bootstrapping.
+func NewUnavailableError(inner error) error {
+	return unavailableError{containedError{inner}}
+}
+
+func (e unavailableError) Error() string {
+	return e.inner.Error()
+}
+
+func (e unavailableError) InnerError() error {
+	return e.inner
+}
+
+// IsUnavailableError returns true if this is an unavailable error.
+func IsUnavailableError(err error) bool {
+	return GetInnerUnavailableError(err) != nil
+}
+
+// GetInnerUnavailableError returns an inner unavailable error
+// if contained by this error, nil otherwise.
+func GetInnerUnavailableError(err error) error {
+	for err != nil {
+		if _, ok := err.(unavailableError); ok {
+			return InnerError(err)
+		}
+		err = InnerError(err)
+	}
+	return nil
+}
+
+type staleTopologyError struct {
+	containedError
+}
+
+// NewStaleTopologyError creates a new stale topology error, used to signal
+// that a request was routed using a topology that is older than the one
+// currently known to the receiver, i.e. the shard has since moved.
+func NewStaleTopologyError(inner error) error {
+	return staleTopologyError{containedError{inner}}
+}
+
+func (e staleTopologyError) Error() string {
+	return e.inner.Error()
+}
+
+func (e staleTopologyError) InnerError() error {
+	return e.inner
+}
+
+// IsStaleTopologyError returns true if this is a stale topology error.
+func IsStaleTopologyError(err error) bool {
+	return GetInnerStaleTopologyError(err) != nil
+}
+
+// GetInnerStaleTopologyError returns an inner stale topology error
+// if contained by this error, nil otherwise.
+func GetInnerStaleTopologyError(err error) error {
+	for err != nil {
+		if _, ok := err.(staleTopologyError); ok {
+			return InnerError(err)
+		}
+		err = InnerError(err)
+	}
+	return nil
+}
+
+type retryAfterError struct {
+	containedError
+	retryAfter time.Duration
+}
+
+// NewRetryAfterError creates a new retry after error, used to carry a hint
+// from the source of an error of how long to wait before retrying.
+func NewRetryAfterError(inner error, retryAfter time.Duration) error {
+	return retryAfterError{containedError: containedError{inner}, retryAfter: retryAfter}
+}
+
+func (e retryAfterError) Error() string {
+	return e.inner.Error()
+}
+
+func (e retryAfterError) InnerError() error {
+	return e.inner
+}
+
+// GetRetryAfter returns the retry after hint of the first retry after error
+// contained by this error, false if there is none.
+func GetRetryAfter(err error) (time.Duration, bool) {
+	for err != nil {
+		if e, ok := err.(retryAfterError); ok {
+			return e.retryAfter, true
+		}
+		err = InnerError(err)
+	}
+	return 0, false
+}
+
+type overCapacityError struct {
+	containedError
+}
+
+// NewOverCapacityError creates a new over capacity error, used to signal
+// that a request was rejected to protect the receiver while it is unable
+// to take on more work, it is also a resource exhausted error so that
+// callers that only check for resource exhaustion back off as well.
+func NewOverCapacityError(inner error) error {
+	return overCapacityError{containedError{NewResourceExhaustedError(inner)}}
+}
+
+func (e overCapacityError) Error() string {
+	return e.inner.Error()
+}
+
+func (e overCapacityError) InnerError() error {
+	return e.inner
+}
+
+// IsOverCapacityError returns true if this is an over capacity error.
+func IsOverCapacityError(err error) bool {
+	return GetInnerOverCapacityError(err) != nil
+}
+
+// GetInnerOverCapacityError returns an inner over capacity error
+// if contained by this error, nil otherwise.
+func GetInnerOverCapacityError(err error) error {
+	for err != nil {
+		if _, ok := err.(overCapacityError); ok {
+			return InnerError(err)
+		}
+		err = InnerError(err)
+	}
+	return nil
+}
+
 // MultiError is an immutable error that packages a list of errors.
 //
 // TODO(xichen): we may want to limit the number of errors included.
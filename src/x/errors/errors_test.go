@@ -24,6 +24,7 @@ import (
 	"errors"
 	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -48,6 +49,36 @@ func TestWrap(t *testing.T) {
 	assert.Error(t, wrappedErr)
 	assert.Equal(t, "context about nonretryable error: detailed error message", wrappedErr.Error())
 	assert.True(t, IsNonRetryableError(wrappedErr))
+
+	err = NewResourceExhaustedError(inner)
+	wrappedErr = Wrap(err, "context about resource exhausted error")
+	assert.Error(t, wrappedErr)
+	assert.Equal(t, "context about resource exhausted error: detailed error message", wrappedErr.Error())
+	assert.True(t, IsResourceExhaustedError(wrappedErr))
+	assert.False(t, IsUnavailableError(wrappedErr))
+
+	err = NewUnavailableError(inner)
+	wrappedErr = Wrap(err, "context about unavailable error")
+	assert.Error(t, wrappedErr)
+	assert.Equal(t, "context about unavailable error: detailed error message", wrappedErr.Error())
+	assert.True(t, IsUnavailableError(wrappedErr))
+	assert.False(t, IsResourceExhaustedError(wrappedErr))
+	assert.False(t, IsStaleTopologyError(wrappedErr))
+
+	err = NewStaleTopologyError(inner)
+	wrappedErr = Wrap(err, "context about stale topology error")
+	assert.Error(t, wrappedErr)
+	assert.Equal(t, "context about stale topology error: detailed error message", wrappedErr.Error())
+	assert.True(t, IsStaleTopologyError(wrappedErr))
+	assert.False(t, IsUnavailableError(wrappedErr))
+
+	err = NewOverCapacityError(inner)
+	wrappedErr = Wrap(err, "context about over capacity error")
+	assert.Error(t, wrappedErr)
+	assert.Equal(t, "context about over capacity error: detailed error message", wrappedErr.Error())
+	assert.True(t, IsOverCapacityError(wrappedErr))
+	assert.True(t, IsResourceExhaustedError(wrappedErr))
+	assert.False(t, IsStaleTopologyError(wrappedErr))
 }
 
 func TestWrapf(t *testing.T) {
@@ -118,3 +149,17 @@ func TestErrorsIsAnErrorAndFormatsErrors(t *testing.T) {
 	assert.Equal(t, "[<some error: foo=2, bar=baz>, "+
 		"<some other error: foo=42, bar=qux>]", errs.Error())
 }
+
+func TestRetryAfterError(t *testing.T) {
+	inner := NewResourceExhaustedError(errors.New("overloaded"))
+	err := NewRetryAfterError(inner, time.Second)
+	assert.Equal(t, "overloaded", err.Error())
+	assert.True(t, IsResourceExhaustedError(err))
+
+	retryAfter, ok := GetRetryAfter(NewRetryableError(err))
+	require.True(t, ok)
+	assert.Equal(t, time.Second, retryAfter)
+
+	_, ok = GetRetryAfter(inner)
+	assert.False(t, ok)
+}
@@ -0,0 +1,80 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fault
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+)
+
+// Configuration configures fault injection, it must only be enabled for
+// resilience testing.
+type Configuration struct {
+	// Enabled enables injecting faults.
+	Enabled bool `yaml:"enabled"`
+
+	// Seed is the seed of the random numbers faults are injected with.
+	Seed int64 `yaml:"seed"`
+
+	// Points configures the faults injected into each point.
+	Points map[Point]PointConfiguration `yaml:"points"`
+}
+
+// PointConfiguration configures the faults injected into a point.
+type PointConfiguration struct {
+	// ErrorProbability is the probability an operation fails with an
+	// injected error.
+	ErrorProbability float64 `yaml:"errorProbability" validate:"min=0.0,max=1.0"`
+
+	// LatencyProbability is the probability latency is injected before an
+	// operation is performed.
+	LatencyProbability float64 `yaml:"latencyProbability" validate:"min=0.0,max=1.0"`
+
+	// Latency is the latency injected.
+	Latency time.Duration `yaml:"latency" validate:"min=0"`
+}
+
+// NewInjector creates a new injector based on the configuration, it never
+// injects faults unless enabled.
+func (c Configuration) NewInjector(iopts instrument.Options) (Injector, error) {
+	if !c.Enabled {
+		return NewNopInjector(), nil
+	}
+
+	valid := make(map[Point]struct{})
+	for _, point := range ValidPoints() {
+		valid[point] = struct{}{}
+	}
+	faults := make(map[Point]Faults, len(c.Points))
+	for point, cfg := range c.Points {
+		if _, ok := valid[point]; !ok {
+			return nil, fmt.Errorf("invalid fault injection point: %s", point)
+		}
+		faults[point] = Faults{
+			ErrorProbability:   cfg.ErrorProbability,
+			LatencyProbability: cfg.LatencyProbability,
+			Latency:            cfg.Latency,
+		}
+	}
+	return NewInjector(faults, c.Seed, iopts), nil
+}
@@ -0,0 +1,164 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+// Package fault provides hooks to inject latency and errors into
+// operations so that resilience tests exercise real failure paths.
+package fault
+
+import (
+	"fmt"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/uber-go/tally"
+)
+
+// Point identifies an operation faults can be injected into.
+type Point string
+
+const (
+	// CommitLogWrite is the point of writes to the commit log.
+	CommitLogWrite Point = "commitlog-write"
+	// PeerFetch is the point of fetches of blocks and blocks metadata from
+	// peers.
+	PeerFetch Point = "peer-fetch"
+	// Flush is the point of preparing data and index volumes to be flushed
+	// to disk.
+	Flush Point = "flush"
+)
+
+// ValidPoints returns the valid points faults can be injected into.
+func ValidPoints() []Point {
+	return []Point{CommitLogWrite, PeerFetch, Flush}
+}
+
+// Faults are the faults injected into the operations of a point.
+type Faults struct {
+	// ErrorProbability is the probability an operation fails with an
+	// injected error.
+	ErrorProbability float64
+	// LatencyProbability is the probability latency is injected before an
+	// operation is performed.
+	LatencyProbability float64
+	// Latency is the latency injected.
+	Latency time.Duration
+}
+
+// InjectedError is the error operations fail with when an error is
+// injected into them.
+type InjectedError struct {
+	// Point is the point the error was injected into.
+	Point Point
+}
+
+func (e InjectedError) Error() string {
+	return fmt.Sprintf("injected fault: point=%s", e.Point)
+}
+
+// IsInjectedError returns whether the error is an injected error.
+func IsInjectedError(err error) bool {
+	_, ok := err.(InjectedError)
+	return ok
+}
+
+// Injector injects faults into operations.
+type Injector interface {
+	// Inject injects the faults of the point into an operation, sleeping
+	// for any latency injected and returning any error injected. It must
+	// be called before the operation is performed.
+	Inject(point Point) error
+}
+
+type nopInjector struct{}
+
+// NewNopInjector returns an injector that never injects faults.
+func NewNopInjector() Injector {
+	return nopInjector{}
+}
+
+func (nopInjector) Inject(point Point) error {
+	return nil
+}
+
+type injectorMetrics struct {
+	errors  tally.Counter
+	latency tally.Counter
+}
+
+type injector struct {
+	sync.Mutex
+
+	faults  map[Point]Faults
+	metrics map[Point]injectorMetrics
+	rng     *rand.Rand
+	sleepFn func(time.Duration)
+}
+
+// NewInjector returns an injector injecting the faults of each point with
+// their probabilities, using the seed for reproducible test runs.
+func NewInjector(
+	faults map[Point]Faults,
+	seed int64,
+	iopts instrument.Options,
+) Injector {
+	var (
+		scope   = iopts.MetricsScope().SubScope("fault")
+		metrics = make(map[Point]injectorMetrics, len(faults))
+	)
+	for point := range faults {
+		pointScope := scope.Tagged(map[string]string{"point": string(point)})
+		metrics[point] = injectorMetrics{
+			errors:  pointScope.Counter("injected-errors"),
+			latency: pointScope.Counter("injected-latency"),
+		}
+	}
+	return &injector{
+		faults:  faults,
+		metrics: metrics,
+		rng:     rand.New(rand.NewSource(seed)),
+		sleepFn: time.Sleep,
+	}
+}
+
+func (i *injector) Inject(point Point) error {
+	faults, ok := i.faults[point]
+	if !ok {
+		return nil
+	}
+
+	i.Lock()
+	injectLatency := i.rng.Float64() < faults.LatencyProbability
+	injectError := i.rng.Float64() < faults.ErrorProbability
+	i.Unlock()
+
+	metrics := i.metrics[point]
+	if injectLatency && faults.Latency > 0 {
+		metrics.latency.Inc(1)
+		i.sleepFn(faults.Latency)
+	}
+	if injectError {
+		metrics.errors.Inc(1)
+		return InjectedError{Point: point}
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+
+package fault
+
+import (
+	"testing"
+	"time"
+
+	"github.com/m3db/m3/src/x/instrument"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/uber-go/tally"
+)
+
+func TestInjectorInject(t *testing.T) {
+	scope := tally.NewTestScope("", nil)
+	iopts := instrument.NewOptions().SetMetricsScope(scope)
+	inj := NewInjector(map[Point]Faults{
+		CommitLogWrite: {ErrorProbability: 1},
+		PeerFetch:      {LatencyProbability: 1, Latency: time.Second},
+		Flush:          {},
+	}, 0, iopts).(*injector)
+
+	var slept []time.Duration
+	inj.sleepFn = func(d time.Duration) {
+		slept = append(slept, d)
+	}
+
+	err := inj.Inject(CommitLogWrite)
+	require.Error(t, err)
+	assert.True(t, IsInjectedError(err))
+	assert.Equal(t, CommitLogWrite, err.(InjectedError).Point)
+
+	assert.NoError(t, inj.Inject(PeerFetch))
+	assert.Equal(t, []time.Duration{time.Second}, slept)
+
+	assert.NoError(t, inj.Inject(Flush))
+	assert.NoError(t, inj.Inject(Point("unknown")))
+
+	counters := scope.Snapshot().Counters()
+	require.Contains(t, counters, "fault.injected-errors+point=commitlog-write")
+	assert.Equal(t, int64(1),
+		counters["fault.injected-errors+point=commitlog-write"].Value())
+	require.Contains(t, counters, "fault.injected-latency+point=peer-fetch")
+	assert.Equal(t, int64(1),
+		counters["fault.injected-latency+point=peer-fetch"].Value())
+}
+
+func TestInjectorInjectProbability(t *testing.T) {
+	inj := NewInjector(map[Point]Faults{
+		Flush: {ErrorProbability: 0.5},
+	}, 42, instrument.NewOptions())
+
+	var errs int
+	for i := 0; i < 1000; i++ {
+		if inj.Inject(Flush) != nil {
+			errs++
+		}
+	}
+	assert.True(t, errs > 400 && errs < 600, "errs=%d", errs)
+}
+
+func TestConfigurationNewInjector(t *testing.T) {
+	iopts := instrument.NewOptions()
+
+	inj, err := Configuration{}.NewInjector(iopts)
+	require.NoError(t, err)
+	assert.Equal(t, NewNopInjector(), inj)
+
+	cfg := Configuration{
+		Enabled: true,
+		Points: map[Point]PointConfiguration{
+			Flush: {ErrorProbability: 1},
+		},
+	}
+	inj, err = cfg.NewInjector(iopts)
+	require.NoError(t, err)
+	assert.True(t, IsInjectedError(inj.Inject(Flush)))
+	assert.NoError(t, inj.Inject(CommitLogWrite))
+
+	cfg.Points[Point("unknown")] = PointConfiguration{}
+	_, err = cfg.NewInjector(iopts)
+	assert.Error(t, err)
+}
@@ -0,0 +1,244 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package pool
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"runtime"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	defaultLeakDetection         = false
+	defaultLeakDetectionMaxDepth = 64
+)
+
+var (
+	leakDetectionFlag     = defaultLeakDetection
+	leakDetectionMaxDepth = defaultLeakDetectionMaxDepth
+	leakDetectionNowFn    = time.Now
+)
+
+var leaks struct {
+	// Accessed atomically, kept first for 64-bit alignment.
+	numOutstanding int64
+
+	sync.Mutex
+	outstanding map[uintptr]*leakEntry
+	collected   map[leakKey]uint64
+}
+
+type leakEntry struct {
+	typ      reflect.Type
+	pc       []uintptr
+	acquired time.Time
+}
+
+type leakKey struct {
+	typ   string
+	stack string
+}
+
+// Leak describes objects checked out of object pools from the same call
+// stack that have not been returned.
+type Leak struct {
+	// Type is the type of the objects checked out.
+	Type string `json:"type"`
+	// Stack is the stack trace of the call that checked the objects out.
+	Stack string `json:"stack"`
+	// Outstanding is the number of objects still checked out.
+	Outstanding int `json:"outstanding"`
+	// Collected is the number of objects that were garbage collected
+	// without ever being returned to their pool.
+	Collected uint64 `json:"collected"`
+	// OldestAcquiredAt is the time the oldest outstanding object was
+	// checked out, zero if there are no outstanding objects.
+	OldestAcquiredAt time.Time `json:"oldestAcquiredAt"`
+}
+
+// EnableLeakDetection turns tracking of objects checked out of object pools
+// on. Each checked out object records the stack that acquired it and, for
+// pointer types, a finalizer that records a leak if the object is garbage
+// collected before being returned. This is expensive and meant for chasing
+// pool exhaustion bugs only, it should not be combined with leak detection
+// in the checked package since both rely on finalizers.
+func EnableLeakDetection() {
+	leakDetectionFlag = true
+}
+
+// DisableLeakDetection turns tracking of objects checked out of object pools
+// off, objects already being tracked are still reported until returned.
+func DisableLeakDetection() {
+	leakDetectionFlag = false
+}
+
+// SetLeakDetectionMaxDepth sets the max depth of acquisition stack traces.
+func SetLeakDetectionMaxDepth(frames int) {
+	leakDetectionMaxDepth = frames
+}
+
+func leakDetectionEnabled() bool {
+	return leakDetectionFlag
+}
+
+// DumpLeaks returns the objects that were checked out of object pools at
+// least olderThan ago and not yet returned, along with those that were
+// garbage collected without being returned, grouped by acquisition stack.
+func DumpLeaks(olderThan time.Duration) []Leak {
+	var (
+		cutoff = leakDetectionNowFn().Add(-olderThan)
+		byKey  = make(map[leakKey]*Leak)
+		lookup = func(k leakKey) *Leak {
+			leak, ok := byKey[k]
+			if !ok {
+				leak = &Leak{Type: k.typ, Stack: k.stack}
+				byKey[k] = leak
+			}
+			return leak
+		}
+	)
+
+	leaks.Lock()
+	for _, entry := range leaks.outstanding {
+		if entry.acquired.After(cutoff) {
+			continue
+		}
+		leak := lookup(entry.key())
+		leak.Outstanding++
+		if leak.OldestAcquiredAt.IsZero() ||
+			entry.acquired.Before(leak.OldestAcquiredAt) {
+			leak.OldestAcquiredAt = entry.acquired
+		}
+	}
+	for k, n := range leaks.collected {
+		lookup(k).Collected += n
+	}
+	leaks.Unlock()
+
+	result := make([]Leak, 0, len(byKey))
+	for _, leak := range byKey {
+		result = append(result, *leak)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		if a.Outstanding+int(a.Collected) != b.Outstanding+int(b.Collected) {
+			return a.Outstanding+int(a.Collected) > b.Outstanding+int(b.Collected)
+		}
+		return a.Stack < b.Stack
+	})
+	return result
+}
+
+func trackCheckout(obj interface{}) {
+	if !leakDetectionEnabled() {
+		return
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		// Only pointers can be identified and have finalizers attached.
+		return
+	}
+
+	pc := make([]uintptr, leakDetectionMaxDepth)
+	// Skip runtime.Callers, trackCheckout and the pool Get itself.
+	n := runtime.Callers(3, pc)
+	entry := &leakEntry{
+		typ:      v.Type(),
+		pc:       pc[:n],
+		acquired: leakDetectionNowFn(),
+	}
+
+	ptr := v.Pointer()
+	leaks.Lock()
+	_, tracked := leaks.outstanding[ptr]
+	leaks.outstanding[ptr] = entry
+	if !tracked {
+		atomic.AddInt64(&leaks.numOutstanding, 1)
+		runtime.SetFinalizer(obj, onLeakedObjectFinalize)
+	}
+	leaks.Unlock()
+}
+
+func trackReturn(obj interface{}) {
+	if atomic.LoadInt64(&leaks.numOutstanding) == 0 {
+		return
+	}
+
+	v := reflect.ValueOf(obj)
+	if v.Kind() != reflect.Ptr || v.IsNil() {
+		return
+	}
+
+	ptr := v.Pointer()
+	leaks.Lock()
+	_, tracked := leaks.outstanding[ptr]
+	if tracked {
+		delete(leaks.outstanding, ptr)
+		atomic.AddInt64(&leaks.numOutstanding, -1)
+		runtime.SetFinalizer(obj, nil)
+	}
+	leaks.Unlock()
+}
+
+func onLeakedObjectFinalize(obj interface{}) {
+	ptr := reflect.ValueOf(obj).Pointer()
+	leaks.Lock()
+	if entry, ok := leaks.outstanding[ptr]; ok {
+		delete(leaks.outstanding, ptr)
+		atomic.AddInt64(&leaks.numOutstanding, -1)
+		leaks.collected[entry.key()]++
+	}
+	leaks.Unlock()
+}
+
+func (e *leakEntry) key() leakKey {
+	return leakKey{typ: e.typ.String(), stack: e.stack()}
+}
+
+func (e *leakEntry) stack() string {
+	buf := bytes.NewBuffer(nil)
+	frames := runtime.CallersFrames(e.pc)
+	for {
+		frame, more := frames.Next()
+		buf.WriteString(frame.Function)
+		buf.WriteString("(...)")
+		buf.WriteString("\n")
+		buf.WriteString("\t")
+		buf.WriteString(frame.File)
+		buf.WriteString(":")
+		buf.WriteString(fmt.Sprintf("%d", frame.Line))
+		buf.WriteString("\n")
+		if !more {
+			break
+		}
+	}
+	return buf.String()
+}
+
+func init() {
+	leaks.outstanding = make(map[uintptr]*leakEntry)
+	leaks.collected = make(map[leakKey]uint64)
+}
@@ -0,0 +1,114 @@
+// Copyright (c) 2020 Uber Technologies, Inc.
+//
+// Permission is hereby granted, free of charge, to any person obtaining a copy
+// of this software and associated documentation files (the "Software"), to deal
+// in the Software without restriction, including without limitation the rights
+// to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+// copies of the Software, and to permit persons to whom the Software is
+// furnished to do so, subject to the following conditions:
+//
+// The above copyright notice and this permission notice shall be included in
+// all copies or substantial portions of the Software.
+//
+// THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+// IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+// FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+// AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+// LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+// OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+// THE SOFTWARE.
+package pool
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type leakTestObject struct {
+	value []byte
+}
+
+func resetLeaks() {
+	leaks.Lock()
+	leaks.numOutstanding = 0
+	leaks.outstanding = make(map[uintptr]*leakEntry)
+	leaks.collected = make(map[leakKey]uint64)
+	leaks.Unlock()
+}
+
+func newLeakTestPool() (ObjectPool, func()) {
+	EnableLeakDetection()
+	resetLeaks()
+
+	pool := NewObjectPool(NewObjectPoolOptions().SetSize(1))
+	pool.Init(func() interface{} {
+		return &leakTestObject{value: make([]byte, 64)}
+	})
+	return pool, func() {
+		DisableLeakDetection()
+		resetLeaks()
+	}
+}
+
+func TestLeakDetectionOutstanding(t *testing.T) {
+	pool, cleanup := newLeakTestPool()
+	defer cleanup()
+
+	obj := pool.Get()
+	other := pool.Get()
+
+	leaked := DumpLeaks(0)
+	require.Equal(t, 2, len(leaked))
+	for _, leak := range leaked {
+		assert.Equal(t, "*pool.leakTestObject", leak.Type)
+		assert.Equal(t, 1, leak.Outstanding)
+		assert.Equal(t, uint64(0), leak.Collected)
+		assert.False(t, leak.OldestAcquiredAt.IsZero())
+		assert.True(t, strings.Contains(leak.Stack, "TestLeakDetectionOutstanding"))
+	}
+
+	// Nothing is old enough to be reported.
+	assert.Equal(t, 0, len(DumpLeaks(time.Hour)))
+
+	pool.Put(obj)
+	pool.Put(other)
+	assert.Equal(t, 0, len(DumpLeaks(0)))
+}
+
+func TestLeakDetectionCollected(t *testing.T) {
+	pool, cleanup := newLeakTestPool()
+	defer cleanup()
+
+	func() {
+		obj := pool.Get()
+		runtime.KeepAlive(obj)
+	}()
+
+	start := time.Now()
+	for time.Since(start) < 10*time.Second {
+		runtime.GC()
+		leaked := DumpLeaks(0)
+		if len(leaked) == 1 && leaked[0].Collected == 1 {
+			assert.Equal(t, 0, leaked[0].Outstanding)
+			assert.True(t, strings.Contains(leaked[0].Stack, "TestLeakDetectionCollected"))
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	require.FailNow(t, "leaked object was not reported as collected")
+}
+
+func TestLeakDetectionDisabled(t *testing.T) {
+	pool, cleanup := newLeakTestPool()
+	defer cleanup()
+	DisableLeakDetection()
+
+	pool.Get()
+	assert.Equal(t, 0, len(DumpLeaks(0)))
+}
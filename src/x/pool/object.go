@@ -125,6 +125,8 @@ func (p *objectPool) Get() interface{} {
 		p.tryFill()
 	}
 
+	trackCheckout(v)
+
 	return v
 }
 
@@ -135,6 +137,8 @@ func (p *objectPool) Put(obj interface{}) {
 		return
 	}
 
+	trackReturn(obj)
+
 	select {
 	case p.values <- obj:
 	default:
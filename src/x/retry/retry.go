@@ -126,14 +126,23 @@ func (r *retrier) attempt(continueFn ContinueFn, fn Fn) error {
 	r.metrics.errors.Inc(1)
 
 	for i := 1; r.forever || i <= r.maxRetries; i++ {
-		r.sleepFn(time.Duration(BackoffNanos(
+		backoff := time.Duration(BackoffNanos(
 			i,
 			r.jitter,
 			r.backoffFactor,
 			r.initialBackoff,
 			r.maxBackoff,
 			r.rngFn,
-		)))
+		))
+		// Respect any hint of how long to wait before retrying, such as
+		// a server asking for clients to back off while it sheds load.
+		if retryAfter, ok := xerrors.GetRetryAfter(err); ok && retryAfter > backoff {
+			backoff = retryAfter
+			if backoff > r.maxBackoff {
+				backoff = r.maxBackoff
+			}
+		}
+		r.sleepFn(backoff)
 
 		if continueFn != nil && !continueFn(attempt) {
 			return ErrWhileConditionFalse
@@ -29,6 +29,8 @@ import (
 	"testing"
 	"time"
 
+	xerrors "github.com/m3db/m3/src/x/errors"
+
 	"github.com/leanovate/gopter"
 	"github.com/leanovate/gopter/gen"
 	"github.com/leanovate/gopter/prop"
@@ -120,6 +122,26 @@ func TestRetrierMaxBackoff(t *testing.T) {
 	assert.Equal(t, 6*time.Second, slept)
 }
 
+func TestRetrierRetryAfter(t *testing.T) {
+	succeedAfter := 2
+	opts := testOptions().SetMaxBackoff(8 * time.Second)
+	slept := []time.Duration{}
+	r := NewRetrier(opts).(*retrier)
+	r.sleepFn = func(t time.Duration) {
+		slept = append(slept, t)
+	}
+	err := r.Attempt(newTestFn(testFnOpts{
+		succeedAfter: &succeedAfter,
+		errs: []error{
+			xerrors.NewRetryAfterError(errTestFn, 5*time.Second),
+			// Hints longer than the max backoff are capped.
+			xerrors.NewRetryAfterError(errTestFn, 30*time.Second),
+		},
+	}))
+	assert.Nil(t, err)
+	assert.Equal(t, []time.Duration{5 * time.Second, 8 * time.Second}, slept)
+}
+
 func TestRetrierExponentialBackOffBreakWhileImmediate(t *testing.T) {
 	slept := time.Duration(0)
 	r := NewRetrier(testOptions()).(*retrier)